@@ -0,0 +1,57 @@
+// Command cloud-bridge runs the Ricochet Cloud Bridge: agents connect to it
+// over WebSocket, it relays their messages to WhatsApp when configured, and
+// serves a dashboard at /dashboard to follow them and answer their asks.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/igoryan-dao/ricochet/internal/bridge"
+)
+
+func main() {
+	port := flag.Int("port", 8080, "Port to listen on")
+	agentToken := flag.String("agent-token", os.Getenv("RICOCHET_BRIDGE_SECRET"), "Token agents must present to connect to /ws, required with WhatsApp (default $RICOCHET_BRIDGE_SECRET)")
+	token := flag.String("dashboard-token", os.Getenv("RICOCHET_DASHBOARD_TOKEN"), "Token required to open the dashboard (default $RICOCHET_DASHBOARD_TOKEN)")
+	noDashboard := flag.Bool("no-dashboard", false, "Don't serve the dashboard")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := bridge.NewServer(*port)
+	if *agentToken != "" {
+		server.RequireAgentToken(*agentToken)
+	} else {
+		log.Printf("No agent token: any agent can connect to /ws")
+	}
+
+	if cfg := bridge.WhatsAppConfigFromEnv(); cfg.PhoneNumberID != "" {
+		wa, err := bridge.NewWhatsApp(cfg)
+		if err != nil {
+			log.Fatalf("WhatsApp: %v", err)
+		}
+		server.EnableWhatsApp(wa)
+		log.Printf("WhatsApp enabled, webhook at /whatsapp/webhook")
+	}
+
+	if !*noDashboard {
+		dashboardToken := server.EnableDashboard(*token)
+		if *token == "" {
+			log.Printf("Dashboard at http://localhost:%d/dashboard?token=%s (local only; set -dashboard-token to open it remotely)", *port, dashboardToken)
+		} else {
+			log.Printf("Dashboard at http://localhost:%d/dashboard", *port)
+		}
+	}
+
+	if err := server.Start(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
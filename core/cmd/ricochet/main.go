@@ -20,11 +20,15 @@ import (
 	"github.com/igoryan-dao/ricochet/internal/codegraph"
 	"github.com/igoryan-dao/ricochet/internal/config"
 	"github.com/igoryan-dao/ricochet/internal/host"
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
 	"github.com/igoryan-dao/ricochet/internal/livemode"
 	"github.com/igoryan-dao/ricochet/internal/mcp"
+	"github.com/igoryan-dao/ricochet/internal/mcpserver"
 	"github.com/igoryan-dao/ricochet/internal/modes"
+	"github.com/igoryan-dao/ricochet/internal/notify"
 	"github.com/igoryan-dao/ricochet/internal/prompts"
 	"github.com/igoryan-dao/ricochet/internal/protocol"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
 	"github.com/igoryan-dao/ricochet/internal/server"
 	"github.com/igoryan-dao/ricochet/internal/tui"
 	"github.com/igoryan-dao/ricochet/internal/workflow"
@@ -136,11 +140,20 @@ func main() {
 	lipgloss.SetColorProfile(termenv.TrueColor)
 
 	log.SetPrefix("[ricochet-core] ")
-	log.SetOutput(os.Stderr)
+	log.SetOutput(safeguard.RedactingWriter(os.Stderr))
 
-	fmt.Println("\n\n********************************************************")
-	fmt.Println("* RICOCHET CORE v2.0 - BUILD UPDATED: 2026-01-19 21:38 *")
-	fmt.Println("********************************************************")
+	// Subcommands that run without starting the core
+	if len(os.Args) > 2 && os.Args[1] == "settings" && os.Args[2] == "migrate" {
+		os.Exit(runSettingsMigrate(os.Args[3:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "pack" {
+		os.Exit(runPack(os.Args[2:]))
+	}
+
+	// Banner goes to stderr: stdout is the protocol stream in stdio and MCP modes
+	fmt.Fprintln(os.Stderr, "\n\n********************************************************")
+	fmt.Fprintln(os.Stderr, "* RICOCHET CORE v2.0 - BUILD UPDATED: 2026-01-19 21:38 *")
+	fmt.Fprintln(os.Stderr, "********************************************************")
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -168,20 +181,37 @@ func main() {
 		log.Printf("Warning: Failed to initialize settings store: %v", errStore)
 	}
 
+	// Merge the project-local .ricochet/config.yaml over global settings
+	if err := settingsStore.LoadProject(cwd); err != nil {
+		log.Printf("Warning: Failed to load project settings: %v", err)
+	}
+
 	settings := settingsStore.Get()
 
+	// Route all outbound HTTP through the configured proxy and CA bundle
+	if err := httpclient.Configure(settings.Network); err != nil {
+		log.Printf("Warning: Failed to apply network settings: %v", err)
+	}
+
 	// Initialize default config (will be updated via settings)
 	cfg = &agent.Config{
 		Provider: agent.ProviderConfig{
 			Provider: settings.Provider.Provider,
 			Model:    settings.Provider.Model,
 			APIKey:   settings.Provider.APIKey,
+			Routing:  settings.Provider.Routing,
 		},
 		SystemPrompt:    prompts.BuildSystemPrompt(cwd),
 		MaxTokens:       4096, // Max tokens for response
 		ContextWindow:   128000,
 		EnableCodeIndex: settings.Context.EnableCodeIndex,
+		IndexIgnore:     settings.Index.Ignore,
+		VectorStore:     settings.Context.VectorStore,
 		AutoApproval:    &settings.AutoApproval,
+		Tools:           settings.Tools,
+		Checkpoints:     settings.Checkpoints,
+		Memory:          settings.Memory,
+		Sessions:        settings.Sessions,
 	}
 
 	// Configure Embedding Provider if one is specified
@@ -213,6 +243,7 @@ func main() {
 	port := "5555"
 	isStdio := false
 	forceTui := false
+	isMCP := false
 
 	for i := 0; i < len(args); i++ {
 		if args[i] == "--server" {
@@ -224,10 +255,14 @@ func main() {
 			isStdio = true
 		} else if args[i] == "--tui" {
 			forceTui = true
+		} else if args[i] == "--mcp" {
+			isMCP = true
 		}
 	}
 
-	if isServer {
+	if isMCP {
+		runMCPMode(ctx, cwd)
+	} else if isServer {
 		runServerMode(ctx, cwd, port)
 	} else if isStdio {
 		runStdioMode(ctx, cwd)
@@ -236,7 +271,7 @@ func main() {
 		runInteractiveMode(ctx, cwd)
 	} else {
 		// Default to MCP mode if no args and not TTY, or handle as needed
-		runMCPMode(ctx)
+		runMCPMode(ctx, cwd)
 	}
 }
 
@@ -247,6 +282,7 @@ func runStdioMode(ctx context.Context, cwd string) {
 	stdioHost := host.NewStdioHost(cwd)
 	modesManager := modes.NewManager(cwd)
 	mcpHub := mcp.NewHub(cwd)
+	mcpHub.SetSecretStore(settingsStore.Secrets())
 	cg := codegraph.NewService()
 	// Init Workflow Manager
 	wm := workflow.NewManager(cwd)
@@ -332,6 +368,9 @@ func runStdioMode(ctx context.Context, cwd string) {
 	// Send ready message
 	sendMessage(protocol.RPCMessage{Type: "ready", Payload: protocol.EncodeRPC(map[string]string{"version": "0.1.0"})})
 
+	// Keepalive so the extension can detect and restart a wedged core
+	handler.StartHeartbeat(ctx, writer, server.DefaultHeartbeatInterval)
+
 	// Read messages from stdin
 	scanner := bufio.NewScanner(os.Stdin)
 	buf := make([]byte, 0, 64*1024)
@@ -390,6 +429,7 @@ func runServerMode(ctx context.Context, cwd, port string) {
 
 	modesManager := modes.NewManager(cwd)
 	mcpHub := mcp.NewHub(cwd)
+	mcpHub.SetSecretStore(settingsStore.Secrets())
 	cg := codegraph.NewService()
 	wm := workflow.NewManager(cwd)
 	wm.LoadWorkflows()
@@ -452,6 +492,12 @@ func runServerMode(ctx context.Context, cwd, port string) {
 		liveCtrl,
 	)
 
+	// Liveness endpoint for supervisors and load balancers
+	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(handler.Health())
+	})
+
 	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
@@ -515,10 +561,38 @@ func runServerMode(ctx context.Context, cwd, port string) {
 }
 
 // runMCPMode runs as MCP server (for Claude Code, Cursor, etc.)
-func runMCPMode(ctx context.Context) {
+func runMCPMode(ctx context.Context, cwd string) {
 	log.Println("Starting in MCP mode...")
-	log.Println("MCP server not yet integrated into unified binary")
-	<-ctx.Done()
+
+	// stdout carries the protocol; send stray prints from hosts and tools to stderr
+	out := os.Stdout
+	os.Stdout = os.Stderr
+
+	if cfg.Provider.APIKey == "" {
+		cfg.Provider.APIKey = settingsStore.Get().Provider.APIKeys[cfg.Provider.Provider]
+	}
+
+	newAgent := func() (*agent.Controller, error) {
+		wm := workflow.NewManager(cwd)
+		if err := wm.LoadWorkflows(); err != nil {
+			log.Printf("Warning: Failed to load workflows: %v", err)
+		}
+		mcpHub := mcp.NewHub(cwd)
+		mcpHub.SetSecretStore(settingsStore.Secrets())
+		return agent.NewController(cfg, agent.ControllerOptions{
+			Host:            host.NewNativeHost(cwd),
+			Modes:           modes.NewManager(cwd),
+			McpHub:          mcpHub,
+			Codegraph:       codegraph.NewService(),
+			WorkflowManager: wm,
+			Settings:        settingsStore,
+		})
+	}
+
+	srv := mcpserver.New("0.1.0", newAgent)
+	if err := srv.Serve(ctx, os.Stdin, out); err != nil && ctx.Err() == nil {
+		log.Printf("MCP server error: %v", err)
+	}
 }
 
 func sendMessage(msg interface{}) {
@@ -538,7 +612,7 @@ func runInteractiveMode(_ context.Context, cwd string) {
 	// Redirect logs to file to avoid messing up TUI
 	f, err := os.OpenFile("ricochet.log", os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
 	if err == nil {
-		log.SetOutput(f)
+		log.SetOutput(safeguard.RedactingWriter(f))
 		defer f.Close()
 	} else {
 		log.SetOutput(new(devNull))
@@ -552,17 +626,25 @@ func runInteractiveMode(_ context.Context, cwd string) {
 	// For simplicity, let's rely on standard config loading inside NewController (partial duplication but safe)
 
 	settingsStore, _ := config.NewStore()
+	if err := settingsStore.LoadProject(cwd); err != nil {
+		log.Printf("Warning: Failed to load project settings: %v", err)
+	}
 	settings := settingsStore.Get()
 	cfg := &agent.Config{
 		Provider: agent.ProviderConfig{
 			Provider: settings.Provider.Provider,
 			Model:    settings.Provider.Model,
 			APIKey:   settings.Provider.APIKey,
+			Routing:  settings.Provider.Routing,
 		},
 		SystemPrompt:  prompts.BuildSystemPrompt(cwd), // Updated to use prompts package
 		MaxTokens:     4096,
 		ContextWindow: 128000,
+		IndexIgnore:   settings.Index.Ignore,
+		VectorStore:   settings.Context.VectorStore,
 		AutoApproval:  &settings.AutoApproval,
+		Tools:         settings.Tools,
+		Sessions:      settings.Sessions,
 	}
 
 	// FORCE-ENABLE read ops for better UX (ignoring stale config if needed)
@@ -593,7 +675,8 @@ func runInteractiveMode(_ context.Context, cwd string) {
 	}
 
 	opts := agent.ControllerOptions{
-		Host: tuiHost,
+		Host:     tuiHost,
+		Settings: settingsStore,
 	}
 
 	controller, err := agent.NewController(cfg, opts)
@@ -669,7 +752,15 @@ func runInteractiveMode(_ context.Context, cwd string) {
 	}
 	m.SettingsStore = settingsStore
 
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	// Desktop notifications when the terminal loses focus and no messenger is active
+	notifier := notify.NewDesktop(settings.AutoApproval.EnableNotifications)
+	if liveCtrl != nil {
+		notifier.SetRemote(liveCtrl)
+	}
+	controller.SetNotifier(notifier)
+	m.Notifier = notifier
+
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithReportFocus())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error running Ricochet TUI: %v\n", err)
 		os.Exit(1)
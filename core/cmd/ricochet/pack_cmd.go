@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+	"github.com/igoryan-dao/ricochet/internal/packs"
+	"github.com/mattn/go-isatty"
+)
+
+const packUsage = `Usage:
+  ricochet pack install <name>[@constraint] [--registry url|dir] [--force] [--trust] [--allow-unsigned]
+  ricochet pack remove <name>
+  ricochet pack list
+  ricochet pack keygen <key-file>
+  ricochet pack publish --key <key-file> --registry <registry clone> [--push]`
+
+// runPack implements the "ricochet pack" subcommands. Packs are installed into
+// the current directory; the registry and trusted keys come from settings.json.
+func runPack(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, packUsage)
+		return 2
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	switch args[0] {
+	case "install":
+		return runPackInstall(cwd, args[1:])
+	case "remove", "uninstall":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, packUsage)
+			return 2
+		}
+		removed, err := (&packs.Installer{Root: cwd}).Remove(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		fmt.Printf("✅ Removed %s\n", strings.Join(removed, ", "))
+		return 0
+	case "list":
+		lock, err := packs.LoadLock(cwd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		if len(lock.Packs) == 0 {
+			fmt.Println("No packs installed")
+			return 0
+		}
+		for _, name := range sortedKeys(lock.Packs) {
+			p := lock.Packs[name]
+			note := ""
+			if !p.Explicit {
+				note = " (dependency)"
+			}
+			fmt.Printf("  %s@%s%s — %d files, %d modes\n", name, p.Version, note, len(p.Files), len(p.Modes))
+		}
+		return 0
+	case "keygen":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, packUsage)
+			return 2
+		}
+		pub, err := packs.GenerateKey(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		fmt.Printf("✅ Signing key written to %s\nPublic key (share with users for packs.trusted_keys):\n  %s\n", args[1], pub)
+		return 0
+	case "publish":
+		return runPackPublish(cwd, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown pack command: %s\n%s\n", args[0], packUsage)
+		return 2
+	}
+}
+
+func runPackInstall(cwd string, args []string) int {
+	var target, registry string
+	var force, trust, allowUnsigned bool
+
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--registry" && i+1 < len(args):
+			registry = args[i+1]
+			i++
+		case args[i] == "--force":
+			force = true
+		case args[i] == "--trust":
+			trust = true
+		case args[i] == "--allow-unsigned":
+			allowUnsigned = true
+		case target == "" && !strings.HasPrefix(args[i], "-"):
+			target = args[i]
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown argument: %s\n%s\n", args[i], packUsage)
+			return 2
+		}
+	}
+	if target == "" {
+		fmt.Fprintln(os.Stderr, packUsage)
+		return 2
+	}
+	name, constraint, _ := strings.Cut(target, "@")
+
+	store, err := config.NewStore()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	settings := store.Get()
+	if registry == "" {
+		registry = os.Getenv(packs.RegistryEnv)
+	}
+	if registry == "" {
+		registry = settings.Packs.Registry
+	}
+
+	reg, err := packs.OpenRegistry(registry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	var newKeys []string
+	in := &packs.Installer{
+		Root:        cwd,
+		Registry:    reg,
+		TrustedKeys: settings.Packs.TrustedKeys,
+		SkipTrust:   allowUnsigned,
+		Force:       force,
+		TrustKey: func(pack, key string) bool {
+			if !trust && !confirm(fmt.Sprintf("%s is signed by an untrusted key:\n  %s\nTrust this key?", pack, key)) {
+				return false
+			}
+			newKeys = append(newKeys, key)
+			return true
+		},
+	}
+
+	installed, err := in.Install(name, constraint)
+	if len(newKeys) > 0 {
+		if saveErr := store.Update(func(s *config.Settings) {
+			s.Packs.TrustedKeys = append(s.Packs.TrustedKeys, newKeys...)
+		}); saveErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save trusted keys: %v\n", saveErr)
+		}
+	}
+	for _, p := range installed {
+		fmt.Printf("  + %s\n", p)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	if len(installed) == 0 {
+		fmt.Printf("✅ %s is already installed\n", name)
+	} else {
+		fmt.Println("✅ Installed")
+	}
+	return 0
+}
+
+func runPackPublish(cwd string, args []string) int {
+	var keyPath, registry string
+	push := false
+
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--key" && i+1 < len(args):
+			keyPath = args[i+1]
+			i++
+		case args[i] == "--registry" && i+1 < len(args):
+			registry = args[i+1]
+			i++
+		case args[i] == "--push":
+			push = true
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown argument: %s\n%s\n", args[i], packUsage)
+			return 2
+		}
+	}
+	if keyPath == "" || registry == "" {
+		fmt.Fprintln(os.Stderr, packUsage)
+		return 2
+	}
+
+	key, err := packs.LoadKey(keyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	m, err := packs.Publish(cwd, registry, key, push)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	fmt.Printf("✅ Published %s@%s (%d files)\n", m.Name, m.Version, len(m.Files))
+	return 0
+}
+
+// confirm asks a yes/no question on the terminal; without one it answers no
+func confirm(question string) bool {
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		return false
+	}
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func sortedKeys(m map[string]*packs.LockedPack) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+)
+
+// runSettingsMigrate implements "ricochet settings migrate [--apply] [--path file]".
+// By default it is a dry run that only prints the planned changes.
+func runSettingsMigrate(args []string) int {
+	apply := false
+	path := ""
+
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--apply" {
+			apply = true
+		} else if args[i] == "--dry-run" {
+			apply = false
+		} else if args[i] == "--path" && i+1 < len(args) {
+			path = args[i+1]
+			i++
+		} else {
+			fmt.Fprintf(os.Stderr, "Unknown argument: %s\nUsage: ricochet settings migrate [--dry-run|--apply] [--path settings.json]\n", args[i])
+			return 2
+		}
+	}
+
+	if path == "" {
+		var err error
+		if path, err = config.DefaultSettingsPath(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	report, err := config.PlanMigration(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	if !report.NeedsMigration() {
+		fmt.Printf("✅ %s is already at schema v%d\n", path, report.ToVersion)
+		return 0
+	}
+
+	fmt.Printf("Settings file: %s\n", path)
+	fmt.Printf("Schema: v%d → v%d\n", report.FromVersion, report.ToVersion)
+	for _, change := range report.Changes {
+		fmt.Printf("  • %s\n", change)
+	}
+
+	if !apply {
+		fmt.Println("\nDry run: no changes written. Re-run with --apply to migrate.")
+		return 0
+	}
+
+	// Loading through the store runs the migration and writes the backup
+	if _, err := config.OpenStore(path); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Migration failed: %v\n", err)
+		return 1
+	}
+	fmt.Println("\n✅ Migrated (previous file kept as backup)")
+	return 0
+}
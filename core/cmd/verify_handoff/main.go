@@ -13,7 +13,7 @@ func main() {
 	// Mock Generator
 	mockGen := func(ctx context.Context, prompt string) (string, error) {
 		if strings.Contains(prompt, "CONVERSATION HISTORY:") {
-			return "# SPEC.md\n\n## Goal\nTest goal.\n", nil
+			return `{"spec": "# SPEC.md\n\n## Goal\nTest goal.\n", "tasks": [{"id": "1", "title": "Design the engine"}], "open_questions": ["Which fuel?"]}`, nil
 		}
 		return "", fmt.Errorf("prompt missing history")
 	}
@@ -25,16 +25,16 @@ func main() {
 		{Role: "assistant", Content: "Okay, let's design the engine."},
 	}
 
-	spec, err := service.GenerateSpec(context.Background(), msgs)
+	artifacts, err := service.GenerateArtifacts(context.Background(), msgs)
 	if err != nil {
 		fmt.Printf("FAILED: %v\n", err)
 		return
 	}
 
-	if strings.Contains(spec, "# SPEC.md") {
+	if strings.Contains(artifacts.Spec, "# SPEC.md") && len(artifacts.Tasks) == 1 && len(artifacts.OpenQuestions) == 1 {
 		fmt.Println("SUCCESS: Handoff prompt generation and callback working.")
-		fmt.Printf("Generated Spec:\n%s\n", spec)
+		fmt.Printf("Generated Spec:\n%s\n", artifacts.Spec)
 	} else {
-		fmt.Printf("FAILED: Unexpected output: %s\n", spec)
+		fmt.Printf("FAILED: Unexpected output: %+v\n", artifacts)
 	}
 }
@@ -20,6 +20,7 @@ const anthropicAPIVersion = "2023-06-01"
 type AnthropicProvider struct {
 	apiKey string
 	model  string
+	client *http.Client // nil = shared default
 }
 
 // NewAnthropicProvider creates a new Anthropic provider
@@ -37,6 +38,10 @@ func (p *AnthropicProvider) Name() string {
 	return "anthropic"
 }
 
+func (p *AnthropicProvider) setHTTPClient(client *http.Client) {
+	p.client = client
+}
+
 // anthropicRequest is the Anthropic API request format
 type anthropicRequest struct {
 	Model     string             `json:"model"`
@@ -109,7 +114,7 @@ func (p *AnthropicProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatRe
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	resp, err := doRequest(ctx, "POST", anthropicAPIURL, p.headers(), bytes.NewReader(body))
+	resp, err := doRequest(ctx, p.client, "POST", anthropicAPIURL, p.headers(), bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -145,7 +150,7 @@ func (p *AnthropicProvider) ChatStream(ctx context.Context, req *ChatRequest, ca
 		return fmt.Errorf("marshal request: %w", err)
 	}
 
-	resp, err := doRequest(ctx, "POST", anthropicAPIURL, p.headers(), bytes.NewReader(body))
+	resp, err := doRequest(ctx, p.client, "POST", anthropicAPIURL, p.headers(), bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
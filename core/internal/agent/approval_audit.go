@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/host"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+	"github.com/igoryan-dao/ricochet/internal/tools"
+)
+
+// recordToolApproval writes the decision on one call of an approval batch to
+// the audit log. Meta and read tools always run and are not recorded when
+// nobody was asked.
+func (c *Controller) recordToolApproval(tc ToolCallInfo, asked, approved bool) {
+	if c.safeguard == nil {
+		return
+	}
+	decision := safeguard.DecisionDenied
+	if approved {
+		decision = safeguard.DecisionApproved
+	}
+	entry := safeguard.AuditEntry{
+		Tool:       tc.Name,
+		Target:     strings.Join(c.permissionTargets(tc), ", "),
+		ArgsDigest: safeguard.DigestArgs([]byte(tc.Arguments)),
+		Decision:   decision,
+	}
+	if asked {
+		entry.Approver = safeguard.LocalUser()
+		entry.Channel = host.Channel(c.host)
+	} else {
+		switch tools.GetToolCategory(tc.Name) {
+		case tools.CategoryMeta, tools.CategoryRead:
+			return
+		}
+		entry.Auto = true
+		entry.Approver = "mode_policy"
+		entry.Channel = safeguard.ChannelPolicy
+		if id, ok := c.allowingRule(tc); ok {
+			entry.Approver = "rule " + id
+		}
+	}
+	c.safeguard.RecordApproval(entry)
+}
+
+// QueryApprovals searches the approval audit log
+func (c *Controller) QueryApprovals(q safeguard.AuditQuery) ([]safeguard.AuditEntry, error) {
+	return c.safeguard.QueryApprovals(q)
+}
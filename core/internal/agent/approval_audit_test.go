@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+func TestRecordToolApproval(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store, err := safeguard.NewPermissionStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit, err := safeguard.NewAuditLog(filepath.Join(t.TempDir(), "approvals.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Controller{safeguard: &safeguard.Manager{PermissionStore: store, Audit: audit}}
+
+	read := ToolCallInfo{Name: "read_file", Arguments: `{"path":"a.go"}`}
+	command := ToolCallInfo{Name: "execute_command", Arguments: `{"command":"make test"}`}
+	c.rememberApproval(command)
+
+	c.recordToolApproval(read, false, true)    // Reads that never ask are not logged
+	c.recordToolApproval(command, false, true) // Approved by the stored rule
+	c.recordToolApproval(ToolCallInfo{Name: "write_file", Arguments: `{"path":"b.go"}`}, false, true)
+	c.recordToolApproval(read, true, false) // Denied when asked
+
+	entries, err := c.QueryApprovals(safeguard.AuditQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries: %+v", len(entries), entries)
+	}
+	if e := entries[0]; !e.Auto || e.Approver != "rule r1" || e.Target != "make test" || e.Channel != safeguard.ChannelPolicy || e.ArgsDigest == "" {
+		t.Errorf("rule approval: %+v", e)
+	}
+	if e := entries[1]; !e.Auto || e.Approver != "mode_policy" || e.Target != "b.go" {
+		t.Errorf("mode approval: %+v", e)
+	}
+	if e := entries[2]; e.Auto || e.Decision != safeguard.DecisionDenied || e.Approver == "" || e.Channel != "unknown" {
+		t.Errorf("manual denial: %+v", e)
+	}
+}
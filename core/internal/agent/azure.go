@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Azure OpenAI provider - OpenAI-compatible API routed by deployment name:
+// {endpoint}/openai/deployments/{deployment}/chat/completions?api-version=...
+
+const defaultAzureAPIVersion = "2024-10-21"
+
+// NewAzureOpenAIProvider creates a provider for an Azure OpenAI resource.
+// endpoint falls back to AZURE_OPENAI_ENDPOINT, deployment to the model name.
+func NewAzureOpenAIProvider(apiKey, model, endpoint, deployment, apiVersion string) (Provider, error) {
+	if endpoint == "" {
+		endpoint = os.Getenv("AZURE_OPENAI_ENDPOINT")
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("azure: no endpoint configured (set base_url or AZURE_OPENAI_ENDPOINT)")
+	}
+	if apiVersion == "" {
+		apiVersion = os.Getenv("AZURE_OPENAI_API_VERSION")
+	}
+	if apiVersion == "" {
+		apiVersion = defaultAzureAPIVersion
+	}
+	if deployment == "" {
+		deployment = model
+	}
+	if deployment == "" {
+		return nil, fmt.Errorf("azure: no deployment or model configured")
+	}
+
+	embedDeployment := os.Getenv("AZURE_OPENAI_EMBEDDING_DEPLOYMENT")
+	if embedDeployment == "" {
+		embedDeployment = "text-embedding-3-small"
+	}
+
+	return &OpenAIProvider{
+		apiKey:       apiKey,
+		model:        model,
+		baseURL:      azureDeploymentURL(endpoint, deployment, "chat/completions", apiVersion),
+		embedURL:     azureDeploymentURL(endpoint, embedDeployment, "embeddings", apiVersion),
+		name:         "azure",
+		apiKeyHeader: "api-key",
+	}, nil
+}
+
+func azureDeploymentURL(endpoint, deployment, operation, apiVersion string) string {
+	base := strings.TrimSuffix(endpoint, "/")
+	// Accept endpoints given with the /openai suffix as well
+	base = strings.TrimSuffix(base, "/openai")
+	return fmt.Sprintf("%s/openai/deployments/%s/%s?api-version=%s",
+		base, url.PathEscape(deployment), operation, url.QueryEscape(apiVersion))
+}
@@ -0,0 +1,475 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+// AWS Bedrock provider - uses the model-agnostic Converse API, so Claude,
+// Llama and other Bedrock models share one request format.
+
+const (
+	defaultBedrockRegion     = "us-east-1"
+	defaultBedrockModel      = "anthropic.claude-3-5-sonnet-20241022-v2:0"
+	bedrockEmbeddingModel    = "amazon.titan-embed-text-v2:0"
+	bedrockSigningService    = "bedrock"
+	bedrockEventStreamHeader = "application/vnd.amazon.eventstream"
+)
+
+// BedrockProvider implements Provider for AWS Bedrock
+type BedrockProvider struct {
+	apiKey string // Bedrock API key (Bearer), or "access:secret[:session]"
+	model  string
+	region string
+	client *http.Client // nil = shared default
+}
+
+// NewBedrockProvider creates a Bedrock provider. apiKey is either a Bedrock API
+// key or "access:secret[:session]"; when empty, AWS_* environment credentials are used.
+func NewBedrockProvider(apiKey, model, region string) *BedrockProvider {
+	if model == "" {
+		model = defaultBedrockModel
+	}
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = defaultBedrockRegion
+	}
+	return &BedrockProvider{
+		apiKey: apiKey,
+		model:  model,
+		region: region,
+	}
+}
+
+func (p *BedrockProvider) Name() string {
+	return "bedrock"
+}
+
+func (p *BedrockProvider) setHTTPClient(client *http.Client) {
+	p.client = client
+}
+
+// bedrockRequest is the Converse API request format
+type bedrockRequest struct {
+	Messages        []bedrockMessage      `json:"messages"`
+	System          []bedrockContentBlock `json:"system,omitempty"`
+	InferenceConfig *bedrockInference     `json:"inferenceConfig,omitempty"`
+	ToolConfig      *bedrockToolConfig    `json:"toolConfig,omitempty"`
+}
+
+type bedrockMessage struct {
+	Role    string                `json:"role"`
+	Content []bedrockContentBlock `json:"content"`
+}
+
+type bedrockContentBlock struct {
+	Text       string             `json:"text,omitempty"`
+	ToolUse    *bedrockToolUse    `json:"toolUse,omitempty"`
+	ToolResult *bedrockToolResult `json:"toolResult,omitempty"`
+}
+
+type bedrockToolUse struct {
+	ToolUseID string          `json:"toolUseId"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+}
+
+type bedrockToolResult struct {
+	ToolUseID string                `json:"toolUseId"`
+	Content   []bedrockContentBlock `json:"content"`
+	Status    string                `json:"status,omitempty"` // success, error
+}
+
+type bedrockInference struct {
+	MaxTokens   int     `json:"maxTokens,omitempty"`
+	Temperature float64 `json:"temperature,omitempty"`
+}
+
+type bedrockToolConfig struct {
+	Tools []bedrockTool `json:"tools"`
+}
+
+type bedrockTool struct {
+	ToolSpec struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		InputSchema struct {
+			JSON map[string]interface{} `json:"json"`
+		} `json:"inputSchema"`
+	} `json:"toolSpec"`
+}
+
+// bedrockResponse is the Converse API response format
+type bedrockResponse struct {
+	Output struct {
+		Message bedrockMessage `json:"message"`
+	} `json:"output"`
+	StopReason string `json:"stopReason"`
+	Usage      struct {
+		InputTokens  int `json:"inputTokens"`
+		OutputTokens int `json:"outputTokens"`
+	} `json:"usage"`
+}
+
+// Chat performs a non-streaming chat completion
+func (p *BedrockProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	body, err := json.Marshal(p.buildRequest(req))
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	resp, err := p.do(ctx, p.modelURL(p.model, "converse"), body)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var bedrockResp bedrockResponse
+	if err := json.Unmarshal(respBody, &bedrockResp); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+
+	result := &ChatResponse{
+		Model:      p.model,
+		StopReason: bedrockResp.StopReason,
+		Usage: Usage{
+			InputTokens:  bedrockResp.Usage.InputTokens,
+			OutputTokens: bedrockResp.Usage.OutputTokens,
+		},
+	}
+	for _, block := range bedrockResp.Output.Message.Content {
+		switch {
+		case block.ToolUse != nil:
+			result.ToolCalls = append(result.ToolCalls, protocol.ToolUseBlock{
+				ID:    block.ToolUse.ToolUseID,
+				Name:  block.ToolUse.Name,
+				Input: block.ToolUse.Input,
+			})
+		case block.Text != "":
+			result.Content += block.Text
+		}
+	}
+	return result, nil
+}
+
+// bedrockStreamEvent covers the converse-stream event payloads we consume
+type bedrockStreamEvent struct {
+	ContentBlockIndex int `json:"contentBlockIndex"`
+	Start             *struct {
+		ToolUse *struct {
+			ToolUseID string `json:"toolUseId"`
+			Name      string `json:"name"`
+		} `json:"toolUse"`
+	} `json:"start"`
+	Delta *struct {
+		Text    string `json:"text"`
+		ToolUse *struct {
+			Input string `json:"input"`
+		} `json:"toolUse"`
+	} `json:"delta"`
+	StopReason string `json:"stopReason"`
+	Message    string `json:"message"` // Exception events
+}
+
+// ChatStream performs a streaming chat completion
+func (p *BedrockProvider) ChatStream(ctx context.Context, req *ChatRequest, callback StreamCallback) error {
+	body, err := json.Marshal(p.buildRequest(req))
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	resp, err := p.do(ctx, p.modelURL(p.model, "converse-stream"), body)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return p.processStream(resp.Body, callback)
+}
+
+func (p *BedrockProvider) processStream(reader io.Reader, callback StreamCallback) error {
+	type toolBuffer struct {
+		id    string
+		name  string
+		input strings.Builder
+	}
+	tools := make(map[int]*toolBuffer)
+
+	for {
+		msg, err := readEventStreamMessage(reader)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var event bedrockStreamEvent
+		if len(msg.Payload) > 0 {
+			if err := json.Unmarshal(msg.Payload, &event); err != nil {
+				continue
+			}
+		}
+
+		if msg.Headers[":message-type"] == "exception" || msg.Headers[":message-type"] == "error" {
+			kind := msg.Headers[":exception-type"]
+			if kind == "" {
+				kind = msg.Headers[":error-code"]
+			}
+			return fmt.Errorf("bedrock %s: %s", kind, event.Message)
+		}
+
+		switch msg.Headers[":event-type"] {
+		case "contentBlockStart":
+			if event.Start != nil && event.Start.ToolUse != nil {
+				tools[event.ContentBlockIndex] = &toolBuffer{
+					id:   event.Start.ToolUse.ToolUseID,
+					name: event.Start.ToolUse.Name,
+				}
+			}
+
+		case "contentBlockDelta":
+			if event.Delta == nil {
+				continue
+			}
+			if event.Delta.Text != "" {
+				callback(&StreamChunk{
+					Type:  "content_block_delta",
+					Delta: event.Delta.Text,
+				})
+			}
+			if event.Delta.ToolUse != nil {
+				if buf, ok := tools[event.ContentBlockIndex]; ok {
+					buf.input.WriteString(event.Delta.ToolUse.Input)
+				}
+			}
+
+		case "contentBlockStop":
+			if buf, ok := tools[event.ContentBlockIndex]; ok {
+				input := buf.input.String()
+				if input == "" {
+					input = "{}"
+				}
+				callback(&StreamChunk{
+					Type: "tool_use",
+					ToolUse: &protocol.ToolUseBlock{
+						ID:    buf.id,
+						Name:  buf.name,
+						Input: json.RawMessage(input),
+					},
+				})
+				delete(tools, event.ContentBlockIndex)
+			}
+
+		case "messageStop":
+			callback(&StreamChunk{
+				Type:       "message_delta",
+				StopReason: event.StopReason,
+			})
+		}
+	}
+
+	callback(&StreamChunk{Type: "message_stop"})
+	return nil
+}
+
+type bedrockEmbedRequest struct {
+	InputText string `json:"inputText"`
+}
+
+type bedrockEmbedResponse struct {
+	Embedding []float32 `json:"embedding"`
+}
+
+// EmbeddingModel identifies the embedding model for index compatibility checks
+func (p *BedrockProvider) EmbeddingModel() string {
+	return "bedrock/" + bedrockEmbeddingModel
+}
+
+// Embed uses Titan Text Embeddings, which takes one input per request
+func (p *BedrockProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	result := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		body, _ := json.Marshal(bedrockEmbedRequest{InputText: text})
+		resp, err := p.do(ctx, p.modelURL(bedrockEmbeddingModel, "invoke"), body)
+		if err != nil {
+			return nil, err
+		}
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("Bedrock Embed error %d: %s", resp.StatusCode, string(respBody))
+		}
+
+		var embedResp bedrockEmbedResponse
+		if err := json.Unmarshal(respBody, &embedResp); err != nil {
+			return nil, err
+		}
+		result = append(result, embedResp.Embedding)
+	}
+	return result, nil
+}
+
+// modelURL builds a bedrock-runtime URL. Model IDs contain ':' (e.g. "...-v2:0"),
+// which is sent percent-encoded.
+func (p *BedrockProvider) modelURL(model, operation string) string {
+	return fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com/model/%s/%s",
+		p.region, strings.ReplaceAll(model, ":", "%3A"), operation)
+}
+
+// do sends a signed POST. Retries in doRequest reuse the signature, which stays
+// valid for several minutes.
+func (p *BedrockProvider) do(ctx context.Context, url string, body []byte) (*http.Response, error) {
+	headers := map[string]string{
+		"Content-Type": "application/json",
+		"Accept":       "application/json",
+	}
+	if strings.HasSuffix(url, "/converse-stream") {
+		headers["Accept"] = bedrockEventStreamHeader
+	}
+
+	if p.apiKey != "" && !strings.Contains(p.apiKey, ":") {
+		// Bedrock API key
+		headers["Authorization"] = "Bearer " + p.apiKey
+	} else {
+		creds, ok := parseAWSCredentials(p.apiKey)
+		if !ok {
+			return nil, fmt.Errorf("bedrock: no AWS credentials (set an API key, \"access:secret\", or AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY)")
+		}
+		if err := signV4("POST", url, headers, body, creds, p.region, bedrockSigningService, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	return doRequest(ctx, p.client, "POST", url, headers, bytes.NewReader(body))
+}
+
+func (p *BedrockProvider) buildRequest(req *ChatRequest) *bedrockRequest {
+	var system []bedrockContentBlock
+	if req.SystemPrompt != "" {
+		system = append(system, bedrockContentBlock{Text: req.SystemPrompt})
+	}
+
+	// Converse requires alternating roles, so consecutive same-role messages are merged
+	var messages []bedrockMessage
+	add := func(role string, blocks ...bedrockContentBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			return
+		}
+		messages = append(messages, bedrockMessage{Role: role, Content: blocks})
+	}
+
+	for _, msg := range req.Messages {
+		if msg.Role == "system" {
+			if msg.Content != "" {
+				system = append(system, bedrockContentBlock{Text: msg.Content})
+			}
+			continue
+		}
+
+		// Handle tool results
+		if len(msg.ToolResults) > 0 {
+			blocks := make([]bedrockContentBlock, 0, len(msg.ToolResults))
+			for _, tr := range msg.ToolResults {
+				content := tr.Content
+				if content == "" {
+					content = "(no output)" // Empty text blocks are rejected
+				}
+				status := "success"
+				if tr.IsError {
+					status = "error"
+				}
+				blocks = append(blocks, bedrockContentBlock{ToolResult: &bedrockToolResult{
+					ToolUseID: tr.ToolUseID,
+					Content:   []bedrockContentBlock{{Text: content}},
+					Status:    status,
+				}})
+			}
+			add("user", blocks...)
+			continue
+		}
+
+		var blocks []bedrockContentBlock
+		if msg.Content != "" {
+			blocks = append(blocks, bedrockContentBlock{Text: msg.Content})
+		}
+		for _, tu := range msg.ToolUse {
+			input := tu.Input
+			if len(input) == 0 {
+				input = json.RawMessage("{}")
+			}
+			blocks = append(blocks, bedrockContentBlock{ToolUse: &bedrockToolUse{
+				ToolUseID: tu.ID,
+				Name:      tu.Name,
+				Input:     input,
+			}})
+		}
+		add(msg.Role, blocks...)
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = 4096
+	}
+
+	out := &bedrockRequest{
+		Messages: messages,
+		System:   system,
+		InferenceConfig: &bedrockInference{
+			MaxTokens:   maxTokens,
+			Temperature: req.Temperature,
+		},
+	}
+
+	if len(req.Tools) > 0 {
+		out.ToolConfig = &bedrockToolConfig{}
+		for _, t := range req.Tools {
+			var tool bedrockTool
+			tool.ToolSpec.Name = t.Name
+			tool.ToolSpec.Description = t.Description
+			tool.ToolSpec.InputSchema.JSON = t.InputSchema
+			out.ToolConfig.Tools = append(out.ToolConfig.Tools, tool)
+		}
+	}
+	return out
+}
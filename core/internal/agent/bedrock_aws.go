@@ -0,0 +1,256 @@
+package agent
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Minimal AWS plumbing for Bedrock: SigV4 request signing and the binary
+// event-stream framing used by streaming responses. No SDK dependency.
+
+// awsCredentials are static AWS credentials
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// parseAWSCredentials reads "access:secret[:session]", falling back to the
+// standard AWS_* environment variables when key is empty
+func parseAWSCredentials(key string) (awsCredentials, bool) {
+	if key != "" {
+		parts := strings.SplitN(key, ":", 3)
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return awsCredentials{}, false
+		}
+		creds := awsCredentials{AccessKeyID: parts[0], SecretAccessKey: parts[1]}
+		if len(parts) == 3 {
+			creds.SessionToken = parts[2]
+		}
+		return creds, true
+	}
+
+	creds := awsCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	return creds, creds.AccessKeyID != "" && creds.SecretAccessKey != ""
+}
+
+// signV4 adds X-Amz-Date, X-Amz-Security-Token (if any) and Authorization to
+// headers. Every header in the map is signed, plus host.
+func signV4(method, rawURL string, headers map[string]string, body []byte, creds awsCredentials, region, service string, now time.Time) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	headers["X-Amz-Date"] = amzDate
+	if creds.SessionToken != "" {
+		headers["X-Amz-Security-Token"] = creds.SessionToken
+	}
+
+	canonical := map[string]string{"host": u.Host}
+	for k, v := range headers {
+		canonical[strings.ToLower(k)] = strings.Join(strings.Fields(v), " ")
+	}
+	names := make([]string, 0, len(canonical))
+	for k := range canonical {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + canonical[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		method,
+		awsCanonicalURI(u),
+		awsCanonicalQuery(u),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	headers["Authorization"] = fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature)
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsCanonicalURI encodes each segment of the already-escaped path again, as SigV4 requires outside S3
+func awsCanonicalURI(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = awsURIEncode(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+func awsCanonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, awsURIEncode(k)+"="+awsURIEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsURIEncode percent-encodes everything except RFC 3986 unreserved characters
+func awsURIEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// eventStreamMessage is one frame of an application/vnd.amazon.eventstream body
+type eventStreamMessage struct {
+	Headers map[string]string // String-valued headers only (":event-type", ":message-type", ...)
+	Payload []byte
+}
+
+// maxEventStreamFrame guards against allocating for a corrupt length prefix
+const maxEventStreamFrame = 16 << 20
+
+// readEventStreamMessage reads the next frame. It returns io.EOF at a clean end of stream.
+func readEventStreamMessage(r io.Reader) (*eventStreamMessage, error) {
+	var prelude [12]byte
+	if _, err := io.ReadFull(r, prelude[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("event stream: truncated prelude")
+		}
+		return nil, err
+	}
+
+	totalLen := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return nil, fmt.Errorf("event stream: prelude checksum mismatch")
+	}
+	if totalLen < 16 || totalLen > maxEventStreamFrame || headersLen > totalLen-16 {
+		return nil, fmt.Errorf("event stream: invalid frame length %d", totalLen)
+	}
+
+	frame := make([]byte, totalLen)
+	copy(frame, prelude[:])
+	if _, err := io.ReadFull(r, frame[12:]); err != nil {
+		return nil, fmt.Errorf("event stream: truncated frame: %w", err)
+	}
+	if crc32.ChecksumIEEE(frame[:totalLen-4]) != binary.BigEndian.Uint32(frame[totalLen-4:]) {
+		return nil, fmt.Errorf("event stream: message checksum mismatch")
+	}
+
+	headers, err := parseEventStreamHeaders(frame[12 : 12+headersLen])
+	if err != nil {
+		return nil, err
+	}
+	return &eventStreamMessage{
+		Headers: headers,
+		Payload: frame[12+headersLen : totalLen-4],
+	}, nil
+}
+
+func parseEventStreamHeaders(b []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	errShort := fmt.Errorf("event stream: malformed headers")
+
+	for len(b) > 0 {
+		nameLen := int(b[0])
+		if len(b) < 1+nameLen+1 {
+			return nil, errShort
+		}
+		name := string(b[1 : 1+nameLen])
+		typ := b[1+nameLen]
+		b = b[2+nameLen:]
+
+		var size int
+		switch typ {
+		case 0, 1: // bool true/false: no value
+			size = 0
+		case 2: // byte
+			size = 1
+		case 3: // short
+			size = 2
+		case 4: // int
+			size = 4
+		case 5, 8: // long, timestamp
+			size = 8
+		case 9: // uuid
+			size = 16
+		case 6, 7: // bytes, string: 2-byte length prefix
+			if len(b) < 2 {
+				return nil, errShort
+			}
+			n := int(binary.BigEndian.Uint16(b))
+			if len(b) < 2+n {
+				return nil, errShort
+			}
+			if typ == 7 {
+				headers[name] = string(b[2 : 2+n])
+			}
+			b = b[2+n:]
+			continue
+		default:
+			return nil, fmt.Errorf("event stream: unknown header type %d", typ)
+		}
+		if len(b) < size {
+			return nil, errShort
+		}
+		b = b[size:]
+	}
+	return headers, nil
+}
@@ -0,0 +1,109 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignV4(t *testing.T) {
+	// Example from the AWS SigV4 documentation
+	headers := map[string]string{
+		"Content-Type": "application/x-www-form-urlencoded; charset=utf-8",
+	}
+	creds := awsCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	err := signV4("GET", "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", headers, nil, creds, "us-east-1", "iam", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date, " +
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if headers["Authorization"] != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", headers["Authorization"], want)
+	}
+}
+
+func TestBedrockProcessStream(t *testing.T) {
+	var stream bytes.Buffer
+	for _, e := range []struct{ event, payload string }{
+		{"messageStart", `{"role":"assistant"}`},
+		{"contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"Let me look."}}`},
+		{"contentBlockStop", `{"contentBlockIndex":0}`},
+		{"contentBlockStart", `{"contentBlockIndex":1,"start":{"toolUse":{"toolUseId":"t1","name":"read_file"}}}`},
+		{"contentBlockDelta", `{"contentBlockIndex":1,"delta":{"toolUse":{"input":"{\"path\":"}}}`},
+		{"contentBlockDelta", `{"contentBlockIndex":1,"delta":{"toolUse":{"input":"\"a.go\"}"}}}`},
+		{"contentBlockStop", `{"contentBlockIndex":1}`},
+		{"messageStop", `{"stopReason":"tool_use"}`},
+	} {
+		stream.Write(eventStreamFrame(map[string]string{
+			":event-type":   e.event,
+			":message-type": "event",
+		}, []byte(e.payload)))
+	}
+
+	var text strings.Builder
+	var chunks []*StreamChunk
+	p := NewBedrockProvider("", "", "us-west-2")
+	err := p.processStream(&stream, func(c *StreamChunk) error {
+		text.WriteString(c.Delta)
+		chunks = append(chunks, c)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if text.String() != "Let me look." {
+		t.Errorf("text = %q", text.String())
+	}
+	var tool *StreamChunk
+	for _, c := range chunks {
+		if c.Type == "tool_use" {
+			tool = c
+		}
+	}
+	if tool == nil || tool.ToolUse.ID != "t1" || string(tool.ToolUse.Input) != `{"path":"a.go"}` {
+		t.Fatalf("tool_use chunk = %+v", tool)
+	}
+	if last := chunks[len(chunks)-1]; last.Type != "message_stop" {
+		t.Errorf("last chunk = %q, want message_stop", last.Type)
+	}
+}
+
+func TestAzureDeploymentURL(t *testing.T) {
+	got := azureDeploymentURL("https://res.openai.azure.com/openai/", "gpt-4o-prod", "chat/completions", "2024-10-21")
+	want := "https://res.openai.azure.com/openai/deployments/gpt-4o-prod/chat/completions?api-version=2024-10-21"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+// eventStreamFrame encodes an event-stream message with string headers
+func eventStreamFrame(headers map[string]string, payload []byte) []byte {
+	var hb bytes.Buffer
+	for k, v := range headers {
+		hb.WriteByte(byte(len(k)))
+		hb.WriteString(k)
+		hb.WriteByte(7)
+		binary.Write(&hb, binary.BigEndian, uint16(len(v)))
+		hb.WriteString(v)
+	}
+
+	total := 12 + hb.Len() + len(payload) + 4
+	frame := binary.BigEndian.AppendUint32(nil, uint32(total))
+	frame = binary.BigEndian.AppendUint32(frame, uint32(hb.Len()))
+	frame = binary.BigEndian.AppendUint32(frame, crc32.ChecksumIEEE(frame[:8]))
+	frame = append(frame, hb.Bytes()...)
+	frame = append(frame, payload...)
+	return binary.BigEndian.AppendUint32(frame, crc32.ChecksumIEEE(frame))
+}
@@ -0,0 +1,181 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	context_manager "github.com/igoryan-dao/ricochet/internal/context"
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+const (
+	maxRestoreChars    = 20000 // Cap on the transcript /expand brings back
+	maxRestoredResult  = 1500  // Cap on each tool result in that transcript
+	compactionSumStart = "=== PREVIOUS CONTEXT SUMMARY ===\n"
+)
+
+// recordCondensation archives what a condensation hid from the model. The
+// first condensation of a session is announced, so it never happens silently.
+func (c *Controller) recordCondensation(sessionID, kind string, before, after []protocol.Message, summary string, callback func(update interface{})) {
+	if c.condenseArchive == nil {
+		return
+	}
+	rec, err := c.condenseArchive.Record(sessionID, kind, before, after, summary)
+	if err != nil {
+		log.Printf("[Agent] Warning: failed to archive condensed context: %v", err)
+		return
+	}
+	if rec == nil || rec.ID != 1 || kind == context_manager.KindCompacted {
+		return // Compaction has its own notice
+	}
+	callback(ChatUpdate{
+		SessionID: sessionID,
+		Message: ChatMessage{
+			ID:        uuid.New().String(),
+			Role:      "system",
+			Content:   fmt.Sprintf("**Context %s**: %d older messages are hidden from the model. `/expand` brings them back.", kind, len(rec.Hidden)),
+			Timestamp: time.Now().UnixMilli(),
+		},
+	})
+}
+
+// CondensationLog lists what condensation hid in a session and when
+func (c *Controller) CondensationLog(sessionID string) []context_manager.ArchiveEntry {
+	if c.condenseArchive == nil {
+		return nil
+	}
+	return c.condenseArchive.Log(sessionID)
+}
+
+// CondensedSegment returns the messages one condensation hid
+func (c *Controller) CondensedSegment(sessionID string, id int) ([]protocol.Message, error) {
+	if c.condenseArchive == nil {
+		return nil, fmt.Errorf("no condensation #%d in this session", id)
+	}
+	return c.condenseArchive.Segment(sessionID, id)
+}
+
+// handleExpandCommand runs /expand: without arguments it lists the
+// condensations, with a number it restores that segment and with text it
+// restores the archived messages mentioning it
+func (c *Controller) handleExpandCommand(sessionID, args string, callback func(update interface{})) error {
+	reply := func(content string) {
+		callback(ChatUpdate{
+			SessionID: sessionID,
+			Message: ChatMessage{
+				ID:        uuid.New().String(),
+				Role:      "assistant",
+				Content:   content,
+				Timestamp: time.Now().UnixMilli(),
+			},
+		})
+	}
+	session := c.sessionManager.GetSession(sessionID)
+	if session == nil || c.condenseArchive == nil {
+		reply("❌ No active session.")
+		return nil
+	}
+
+	entries := c.condenseArchive.Log(sessionID)
+	if args == "" {
+		if len(entries) == 0 {
+			reply("Nothing was condensed in this session.")
+			return nil
+		}
+		var sb strings.Builder
+		sb.WriteString("**Condensed history**:\n")
+		for _, e := range entries {
+			fmt.Fprintf(&sb, "%d. %s, %s: %d messages, ~%d tokens", e.ID, e.Time.Format("15:04"), e.Kind, e.Messages, e.Tokens)
+			if e.Preview != "" {
+				fmt.Fprintf(&sb, " (from \"%s\")", e.Preview)
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n`/expand <n>` restores a segment, `/expand <text>` the messages mentioning the text.")
+		reply(sb.String())
+		return nil
+	}
+
+	var msgs []protocol.Message
+	var source string
+	if id, err := strconv.Atoi(args); err == nil {
+		if msgs, err = c.condenseArchive.Segment(sessionID, id); err != nil {
+			reply("❌ " + err.Error())
+			return nil
+		}
+		source = fmt.Sprintf("condensation #%d", id)
+	} else {
+		msgs = c.condenseArchive.Search(sessionID, args)
+		source = fmt.Sprintf("messages mentioning %q", args)
+	}
+	if len(msgs) == 0 {
+		reply(fmt.Sprintf("Nothing in the condensed history matches %q.", args))
+		return nil
+	}
+
+	transcript, truncated := restoreTranscript(msgs)
+	header := fmt.Sprintf("[Restored from condensed history: %s]\n", source)
+	if truncated {
+		header += "[Shortened; the oldest messages were left out]\n"
+	}
+	session.StateHandler.AddMessage(protocol.Message{Role: "user", Content: header + transcript})
+	session.StateHandler.AddMessage(protocol.Message{Role: "assistant", Content: "I have the restored context and will take it into account."})
+	reply(fmt.Sprintf("♻️ Restored %d messages (%s) into the conversation.", len(msgs), source))
+	return nil
+}
+
+// restoreTranscript renders archived messages as text within
+// maxRestoreChars, keeping the newest when it must drop some
+func restoreTranscript(msgs []protocol.Message) (string, bool) {
+	var parts []string
+	size := 0
+	truncated := false
+	for i := len(msgs) - 1; i >= 0; i-- {
+		part := renderArchived(msgs[i])
+		if part == "" {
+			continue
+		}
+		if size+len(part) > maxRestoreChars {
+			truncated = true
+			break
+		}
+		parts = append(parts, part)
+		size += len(part)
+	}
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, "\n"), truncated
+}
+
+func renderArchived(m protocol.Message) string {
+	var sb strings.Builder
+	if content := strings.TrimSpace(m.Content); content != "" {
+		fmt.Fprintf(&sb, "%s: %s\n", m.Role, content)
+	}
+	for _, tu := range m.ToolUse {
+		fmt.Fprintf(&sb, "%s called %s %s\n", m.Role, tu.Name, string(tu.Input))
+	}
+	for _, tr := range m.ToolResults {
+		content := tr.Content
+		if len(content) > maxRestoredResult {
+			content = content[:maxRestoredResult] + "…"
+		}
+		fmt.Fprintf(&sb, "tool result: %s\n", content)
+	}
+	return sb.String()
+}
+
+// compactionSummary returns the summary a compaction put into the history
+func compactionSummary(msgs []protocol.Message) string {
+	for _, m := range msgs {
+		if m.Role == "system" && strings.HasPrefix(m.Content, compactionSumStart) {
+			return strings.TrimSuffix(strings.TrimPrefix(m.Content, compactionSumStart), "\n================================")
+		}
+	}
+	return ""
+}
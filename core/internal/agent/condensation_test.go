@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	context_manager "github.com/igoryan-dao/ricochet/internal/context"
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+func TestExpandCommand_RestoresCondensedHistory(t *testing.T) {
+	c := &Controller{
+		sessionManager:  NewSessionManager(t.TempDir()),
+		condenseArchive: context_manager.NewArchive(t.TempDir()),
+	}
+	session := c.sessionManager.CreateSession()
+	var history []protocol.Message
+	for i := 0; i < 4; i++ {
+		history = append(history,
+			protocol.Message{Role: "user", Content: fmt.Sprintf("question %d", i)},
+			protocol.Message{Role: "assistant", Content: fmt.Sprintf("answer %d", i)})
+	}
+	history[1].Content = "use the staging bucket"
+	session.StateHandler.SetMessages(history)
+
+	var notices []string
+	collect := func(update interface{}) {
+		if u, ok := update.(ChatUpdate); ok {
+			notices = append(notices, u.Message.Content)
+		}
+	}
+	c.recordCondensation(session.ID, context_manager.KindCondensed, history, history[4:], "summary", collect)
+	c.recordCondensation(session.ID, context_manager.KindCondensed, history, history[4:], "summary", collect)
+	if len(notices) != 1 || !strings.Contains(notices[0], "4 older messages") {
+		t.Fatalf("notices = %q, want one announcing the condensation", notices)
+	}
+
+	notices = nil
+	c.handleExpandCommand(session.ID, "", collect)
+	if len(notices) != 1 || !strings.Contains(notices[0], "1. ") || !strings.Contains(notices[0], "4 messages") {
+		t.Fatalf("/expand = %q, want the log", notices)
+	}
+
+	c.handleExpandCommand(session.ID, "staging", collect)
+	msgs := session.StateHandler.GetMessages()
+	if len(msgs) != 10 {
+		t.Fatalf("session has %d messages, want the restored pair added", len(msgs))
+	}
+	if restored := msgs[8]; restored.Role != "user" || !strings.Contains(restored.Content, "assistant: use the staging bucket") || !strings.Contains(restored.Content, "user: question 0") {
+		t.Errorf("restored message = %q", restored.Content)
+	}
+	if msgs[9].Role != "assistant" {
+		t.Errorf("restored context is not acknowledged: %+v", msgs[9])
+	}
+
+	c.handleExpandCommand(session.ID, "7", collect)
+	if last := notices[len(notices)-1]; !strings.Contains(last, "no condensation #7") {
+		t.Errorf("/expand 7 = %q", last)
+	}
+}
@@ -0,0 +1,248 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/igoryan-dao/ricochet/internal/codegraph"
+	context_manager "github.com/igoryan-dao/ricochet/internal/context"
+	"github.com/igoryan-dao/ricochet/internal/modes"
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+	"github.com/igoryan-dao/ricochet/internal/tools"
+)
+
+// ContextSection is one part of a request and the tokens it takes
+type ContextSection struct {
+	Name   string `json:"name"`
+	Tokens int    `json:"tokens"`
+	Detail string `json:"detail,omitempty"` // e.g. "42 tools"
+}
+
+// ContextBreakdown shows where the tokens of a request go
+type ContextBreakdown struct {
+	Sections  []ContextSection `json:"sections"` // Largest first
+	Total     int              `json:"total"`
+	TokensMax int              `json:"tokens_max"`
+	Measured  bool             `json:"measured"` // Taken from the last request; otherwise estimated for the next one
+	At        time.Time        `json:"at"`
+}
+
+// promptParts are the pieces a request is assembled from
+type promptParts struct {
+	System, Mode, RepoMap, Rules, Skills, Memory, Plan, MCP, Env, Files, Pinned string
+	Messages                                                                    []protocol.Message
+	Tools                                                                       []protocol.Tool
+}
+
+func (p promptParts) breakdown(max int, measured bool) *ContextBreakdown {
+	b := &ContextBreakdown{TokensMax: max, Measured: measured, At: time.Now()}
+	add := func(name, text, detail string) {
+		if tokens := context_manager.EstimateTokens(text); tokens > 0 {
+			b.Sections = append(b.Sections, ContextSection{Name: name, Tokens: tokens, Detail: detail})
+		}
+	}
+	add("System prompt", p.System, "")
+	add("Mode", p.Mode, "")
+	add("Repo map", p.RepoMap, "")
+	add("Rules", p.Rules, "")
+	add("Skills", p.Skills, "")
+	add("Memory", p.Memory, "")
+	add("Plan", p.Plan, "")
+	add("MCP resources", p.MCP, "")
+	add("Environment", p.Env, "")
+	add("Accessed files", p.Files, "")
+	add("Pinned files and notes", p.Pinned, "")
+
+	history, pinned := 0, 0
+	for _, m := range p.Messages {
+		tokens := context_manager.EstimateMessageTokens(m)
+		history += tokens
+		if m.Pinned {
+			pinned += tokens
+		}
+	}
+	if history > 0 {
+		detail := fmt.Sprintf("%d messages", len(p.Messages))
+		if pinned > 0 {
+			detail += fmt.Sprintf(", %d tokens pinned", pinned)
+		}
+		b.Sections = append(b.Sections, ContextSection{Name: "History", Tokens: history, Detail: detail})
+	}
+	if len(p.Tools) > 0 {
+		schema, _ := json.Marshal(p.Tools)
+		add("Tool schemas", string(schema), fmt.Sprintf("%d tools", len(p.Tools)))
+	}
+
+	sort.SliceStable(b.Sections, func(i, j int) bool { return b.Sections[i].Tokens > b.Sections[j].Tokens })
+	for _, s := range b.Sections {
+		b.Total += s.Tokens
+	}
+	return b
+}
+
+// recordBreakdown keeps the breakdown of a session's last request
+func (c *Controller) recordBreakdown(sessionID string, b *ContextBreakdown) {
+	c.breakdownMu.Lock()
+	defer c.breakdownMu.Unlock()
+	if c.breakdowns == nil {
+		c.breakdowns = make(map[string]*ContextBreakdown)
+	}
+	c.breakdowns[sessionID] = b
+}
+
+// ContextBreakdown reports where a session's tokens go: measured on its
+// last request, or estimated for the next one before the first
+func (c *Controller) ContextBreakdown(sessionID string) (*ContextBreakdown, error) {
+	c.breakdownMu.Lock()
+	b := c.breakdowns[sessionID]
+	c.breakdownMu.Unlock()
+	if b != nil {
+		return b, nil
+	}
+
+	session := c.sessionManager.GetSession(sessionID)
+	if session == nil {
+		return nil, fmt.Errorf("session not found: %s", sessionID)
+	}
+	activeMode := c.modes.GetActiveMode()
+	parts := promptParts{
+		System:   c.config.SystemPrompt,
+		Mode:     modePromptFor(activeMode),
+		RepoMap:  c.repoMapPrompt(session),
+		Rules:    c.rules.Prompt(activeMode.Slug, session.FileTracker.GetFiles()),
+		Env:      c.envTracker.GetContext(),
+		Files:    session.FileTracker.GetContext(),
+		Pinned:   c.pinnedPrompt(session),
+		MCP:      c.mcpResourceContext(),
+		Messages: session.StateHandler.GetMessages(),
+		Tools:    allowedTools(c.executor.GetDefinitions(), activeMode),
+	}
+	if c.memoryManager != nil {
+		parts.Memory = c.memoryManager.GetSystemPromptPart()
+	}
+	if c.planManager != nil {
+		parts.Plan = c.planManager.GenerateContext()
+	}
+	limit := c.config.ContextWindow
+	if limit <= 0 {
+		limit = 128000
+	}
+	return parts.breakdown(limit, false), nil
+}
+
+// handleContextCommand runs /context: where the session's tokens go
+func (c *Controller) handleContextCommand(sessionID string, callback func(update interface{})) error {
+	content := ""
+	if b, err := c.ContextBreakdown(sessionID); err != nil {
+		content = "❌ " + err.Error()
+	} else {
+		content = formatBreakdown(b)
+	}
+	callback(ChatUpdate{
+		SessionID: sessionID,
+		Message: ChatMessage{
+			ID:        uuid.New().String(),
+			Role:      "assistant",
+			Content:   content,
+			Timestamp: time.Now().UnixMilli(),
+		},
+	})
+	return nil
+}
+
+func formatBreakdown(b *ContextBreakdown) string {
+	var sb strings.Builder
+	source := "estimated for the next request"
+	if b.Measured {
+		source = "measured on the last request"
+	}
+	fmt.Fprintf(&sb, "📊 **Context usage** (%s): %d of %d tokens (%.1f%%)\n\n```\n", source, b.Total, b.TokensMax, float64(b.Total)/float64(b.TokensMax)*100)
+	for _, s := range b.Sections {
+		share := float64(s.Tokens) / float64(max(b.Total, 1))
+		bar := strings.Repeat("█", int(share*20+0.5))
+		fmt.Fprintf(&sb, "%-24s %8d  %-20s %3.0f%%", s.Name, s.Tokens, bar, share*100)
+		if s.Detail != "" {
+			fmt.Fprintf(&sb, "  %s", s.Detail)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("```")
+	return sb.String()
+}
+
+// allowedTools is the schema of the tools the mode may use
+func allowedTools(defs []tools.ToolDefinition, mode modes.Mode) []protocol.Tool {
+	var providerTools []protocol.Tool
+	for _, d := range defs {
+		if modes.IsToolAllowed(mode, d.Name) {
+			providerTools = append(providerTools, protocol.Tool{
+				Name:        d.Name,
+				Description: d.Description,
+				InputSchema: d.InputSchema,
+			})
+		}
+	}
+	return providerTools
+}
+
+// modePromptFor is the system prompt section describing the active mode
+func modePromptFor(mode modes.Mode) string {
+	return fmt.Sprintf("\n\n### Current Mode: %s\n%s\n%s",
+		mode.Name,
+		mode.RoleDefinition,
+		mode.CustomInstructions)
+}
+
+// repoMapPrompt is the repository map section, ranked toward the files
+// and searches of the session
+func (c *Controller) repoMapPrompt(session *Session) string {
+	zones := c.zonesPrompt()
+	if c.codegraph == nil {
+		return zones
+	}
+	// Limit size: 5% of context window or max 100 files. Ranked
+	// toward the files and searches of this conversation.
+	focus := codegraph.Focus{Queries: session.FileTracker.RecentQueries(5)}
+	for _, path := range session.FileTracker.RecentFiles(20) {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.envTracker.GetCwd(), path)
+		}
+		focus.Files = append(focus.Files, filepath.Clean(path))
+	}
+	repoMap := c.codegraph.GenerateFocusedRepoMap(100, focus)
+	if repoMap == "" {
+		return zones
+	}
+	return "\n\n" + repoMap + "\n\n(This repository map is auto-generated based on Code Graph PageRank analysis, weighted toward the files and searches of this conversation)" + zones
+}
+
+// zoneMeanings tells the model what each trust zone lets it do
+var zoneMeanings = map[string]string{
+	"readonly": "read only: writes, edits and deletes are refused",
+	"safe":     "edits ask for approval as usual",
+	"danger":   "edits apply without asking",
+}
+
+// zonesPrompt lists the per-path trust zones of permissions.yaml, which the
+// repository map's zone attributes refer to
+func (c *Controller) zonesPrompt() string {
+	if c.safeguard == nil || c.safeguard.CurrentZone == safeguard.ZoneReadOnly {
+		return "" // The whole workspace is read only
+	}
+	zones := c.safeguard.PathZones()
+	if len(zones) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\n### Trust Zones\nTool calls on these paths are held to their zone, most specific pattern first. Do not attempt writes in read-only zones.\n")
+	for _, z := range zones {
+		fmt.Fprintf(&sb, "- `%s`: %s (%s)\n", z.Pattern, z.Zone, zoneMeanings[z.Zone])
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
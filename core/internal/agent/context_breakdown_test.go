@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+func TestPromptPartsBreakdown(t *testing.T) {
+	parts := promptParts{
+		System: strings.Repeat("You are a careful engineer. ", 200),
+		Rules:  "Use tabs.",
+		Messages: []protocol.Message{
+			{Role: "user", Content: "hello"},
+			{Role: "assistant", Content: "hi there", Pinned: true},
+		},
+		Tools: []protocol.Tool{{Name: "read_file", Description: "Read a file", InputSchema: map[string]interface{}{"type": "object"}}},
+	}
+	b := parts.breakdown(1000, true)
+
+	if b.Sections[0].Name != "System prompt" {
+		t.Fatalf("largest section = %q, want the system prompt first", b.Sections[0].Name)
+	}
+	names := map[string]ContextSection{}
+	sum := 0
+	for _, s := range b.Sections {
+		names[s.Name] = s
+		sum += s.Tokens
+	}
+	if _, ok := names["Memory"]; ok {
+		t.Error("empty sections should be left out")
+	}
+	if names["Tool schemas"].Detail != "1 tools" || !strings.Contains(names["History"].Detail, "2 messages") || !strings.Contains(names["History"].Detail, "pinned") {
+		t.Errorf("unexpected details: %+v", b.Sections)
+	}
+	if b.Total != sum || !b.Measured || b.TokensMax != 1000 {
+		t.Errorf("breakdown = %+v", b)
+	}
+}
+
+func TestContextCommand_ShowsRecordedBreakdown(t *testing.T) {
+	c := &Controller{sessionManager: NewSessionManager(t.TempDir())}
+	session := c.sessionManager.CreateSession()
+	c.recordBreakdown(session.ID, promptParts{System: "system", RepoMap: strings.Repeat("pkg/file.go ", 50)}.breakdown(128000, true))
+
+	b, err := c.ContextBreakdown(session.ID)
+	if err != nil || !b.Measured || b.Sections[0].Name != "Repo map" {
+		t.Fatalf("ContextBreakdown = %+v, %v", b, err)
+	}
+
+	var out string
+	c.handleContextCommand(session.ID, func(update interface{}) {
+		out = update.(ChatUpdate).Message.Content
+	})
+	if !strings.Contains(out, "measured on the last request") || !strings.Contains(out, "Repo map") {
+		t.Errorf("/context output = %q", out)
+	}
+
+	if _, err := c.ContextBreakdown("missing"); err == nil {
+		t.Error("expected an error for an unknown session")
+	}
+}
@@ -126,7 +126,7 @@ func (cm *ContextManager) Compact(ctx context.Context, history []protocol.Messag
 	// We'll use a System message to be authoritative.
 	newHistory = append(newHistory, protocol.Message{
 		Role:    "system",
-		Content: "=== PREVIOUS CONTEXT SUMMARY ===\n" + summary + "\n================================",
+		Content: compactionSumStart + summary + "\n================================",
 	})
 
 	// Add Tail (Last 5 messages) to keep immediate flow
@@ -139,6 +139,13 @@ func (cm *ContextManager) Compact(ctx context.Context, history []protocol.Messag
 		// Ensure we don't split a tool call and its result?
 		// Ideally yes. For now, simple slice.
 		tail := history[startIdx:]
+
+		// Pinned messages survive compaction
+		for _, msg := range history[:startIdx] {
+			if msg.Pinned && msg.Role != "system" {
+				newHistory = append(newHistory, msg)
+			}
+		}
 		newHistory = append(newHistory, tail...)
 	} else {
 		// Fallback (shouldn't happen given threshold)
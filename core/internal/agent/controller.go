@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -23,43 +24,51 @@ import (
 	mcpHubPkg "github.com/igoryan-dao/ricochet/internal/mcp"
 	"github.com/igoryan-dao/ricochet/internal/memory"
 	"github.com/igoryan-dao/ricochet/internal/modes"
+	"github.com/igoryan-dao/ricochet/internal/paths"
 	"github.com/igoryan-dao/ricochet/internal/prompts"
 	"github.com/igoryan-dao/ricochet/internal/protocol"
 	"github.com/igoryan-dao/ricochet/internal/qc"
+	"github.com/igoryan-dao/ricochet/internal/ratelimit"
 	"github.com/igoryan-dao/ricochet/internal/rules"
 	"github.com/igoryan-dao/ricochet/internal/safeguard"
+	"github.com/igoryan-dao/ricochet/internal/safeguard/checkpoint"
 	"github.com/igoryan-dao/ricochet/internal/skills"
 	"github.com/igoryan-dao/ricochet/internal/terminal"
 	"github.com/igoryan-dao/ricochet/internal/tools"
 	"github.com/igoryan-dao/ricochet/internal/workflow"
 )
 
+// planModeNotice is the system message added before a Plan Mode prompt
+const planModeNotice = "PLAN MODE ENABLED: You are in read-only mode for exploration and planning. You can use search and read tools, but avoid making any file changes or executing destructive commands. If the user asks for changes, explain your plan first."
+
 // Controller manages chat sessions and AI interactions
 type Controller struct {
-	mu                sync.RWMutex
-	provider          Provider
-	sessionManager    *SessionManager
-	config            *Config
-	executor          tools.Executor
-	envTracker        *context_manager.EnvironmentTracker
-	safeguard         *safeguard.Manager
-	modes             *modes.Manager
-	rules             *rules.Manager
-	host              host.Host
-	checkpointManager *CheckpointManager
-	providersManager  *config.ProvidersManager
-	indexer           *index.Indexer
+	mu               sync.RWMutex
+	provider         Provider
+	sessionManager   *SessionManager
+	config           *Config
+	executor         tools.Executor
+	envTracker       *context_manager.EnvironmentTracker
+	safeguard        *safeguard.Manager
+	modes            *modes.Manager
+	rules            *rules.Manager
+	host             host.Host
+	providersManager *config.ProvidersManager
+	indexer          *index.Indexer
+	indexRoots       *index.Roots // The workspace plus other repos registered for search
 
 	codegraph          *codegraph.Service
 	handoffService     *handoff.Service
 	workflows          *workflow.Manager
 	workflowEngine     *workflow.Engine
 	skills             *skills.Manager
+	settings           *config.Store // Persists skill toggles; nil when not wired in
 	qcManager          *qc.Manager
 	dynamicHooks       *hooks.DynamicHookManager
 	memoryManager      *memory.Manager
 	injectionProcessor *InjectionProcessor
 	mcpManager         *mcpHubPkg.Manager
+	mcpHub             *mcpHubPkg.Hub     // nil when MCP servers are not wired in
 	gitManager         *git.Manager       // Git integration
 	contextManager     *ContextManager    // Context compaction
 	loopDetector       *LoopDetector      // Detects repetitive content patterns
@@ -67,10 +76,28 @@ type Controller struct {
 	swarm              *SwarmOrchestrator // Swarm Orchestrator
 	helpAgent          *HelpAgent         // Handles help queries
 	defaultModel       string             // Default model for internal tasks
+	trustStore         *safeguard.TrustStore
+	trustAsked         bool               // Trust prompt already shown (or impossible) this process
+	deferredIndexing   func()             // Starts indexing once the workspace is trusted
+	stopIndexWatch     context.CancelFunc // Stops the index watcher; nil until indexing starts
+	liveMode           tools.LiveModeProvider
+	notifier           tools.Notifier // Desktop fallback when no messenger is active
 
 	// Abort support
 	abortMu     sync.Mutex
 	abortCancel context.CancelFunc
+	turns       map[uint64]context.CancelFunc // Every running turn, for the emergency stop
+	nextTurn    uint64
+
+	// Session learnings, see memorizeSession
+	memorizeMu sync.Mutex
+	memorized  map[string]int // Messages of each session already memorized, by session ID
+
+	condenseArchive *context_manager.Archive // What condensation hid from the model, see /expand
+	pinMu           sync.Mutex               // Guards pin changes, see pins.go
+
+	breakdownMu sync.Mutex
+	breakdowns  map[string]*ContextBreakdown // Per-session token breakdown of the last request, see /context
 
 	// UI Callbacks
 	onTaskProgress func(protocol.TaskProgress)
@@ -84,8 +111,13 @@ type Config struct {
 	MaxTokens         int                          `json:"max_tokens"`     // Max tokens for response generation
 	ContextWindow     int                          `json:"context_window"` // Context window limit for pruning
 	EnableCodeIndex   bool                         `json:"enable_code_index"`
+	IndexIgnore       []string                     `json:"index_ignore,omitempty"` // Extra glob patterns skipped by the indexer
+	VectorStore       config.VectorStoreSettings   `json:"vector_store,omitempty"` // Backend of the codebase index
 	AutoApproval      *config.AutoApprovalSettings `json:"auto_approval"`
 	Tools             config.ToolsSettings         `json:"tools"`
+	Checkpoints       config.CheckpointSettings    `json:"checkpoints"`
+	Memory            config.MemorySettings        `json:"memory"`
+	Sessions          config.SessionSettings       `json:"sessions"`
 	Swarm             SwarmConfig                  `json:"swarm"`
 }
 
@@ -95,6 +127,7 @@ type Session struct {
 	StateHandler *MessageStateHandler         `json:"-"` // Internal state handler
 	FileTracker  *context_manager.FileTracker `json:"-"` // Tracks accessed files
 	Todos        []protocol.Todo              `json:"todos"`
+	Pins         []context_manager.Pin        `json:"pins,omitempty"` // Pinned files and notes, see pins.go
 	TotalCost    float64                      `json:"total_cost"`
 	CreatedAt    time.Time                    `json:"created_at"`
 }
@@ -108,15 +141,11 @@ type ControllerOptions struct {
 	ProvidersManager *config.ProvidersManager
 	Codegraph        *codegraph.Service
 	WorkflowManager  *workflow.Manager
+	Settings         *config.Store
 }
 
 // NewController creates a new agent controller
 func NewController(cfg *Config, opts ...ControllerOptions) (*Controller, error) {
-	provider, err := NewProvider(cfg.Provider)
-	if err != nil {
-		return nil, fmt.Errorf("create provider: %w", err)
-	}
-
 	cwd, _ := os.Getwd()
 
 	var h host.Host
@@ -126,6 +155,7 @@ func NewController(cfg *Config, opts ...ControllerOptions) (*Controller, error)
 	var pm *config.ProvidersManager
 	var cg *codegraph.Service
 	var wm *workflow.Manager
+	var settings *config.Store
 
 	if len(opts) > 0 {
 		h = opts[0].Host
@@ -135,6 +165,13 @@ func NewController(cfg *Config, opts ...ControllerOptions) (*Controller, error)
 		pm = opts[0].ProvidersManager
 		cg = opts[0].Codegraph
 		wm = opts[0].WorkflowManager
+		settings = opts[0].Settings
+	}
+
+	cfg.Provider = withProviderDefaults(cfg.Provider, pm)
+	provider, err := NewProvider(cfg.Provider)
+	if err != nil {
+		return nil, fmt.Errorf("create provider: %w", err)
 	}
 
 	if h == nil {
@@ -162,6 +199,7 @@ func NewController(cfg *Config, opts ...ControllerOptions) (*Controller, error)
 		}
 		// Set Tools Settings (DisableLLMCorrection)
 		safeguardMgr.SetToolsSettings(&cfg.Tools)
+		safeguardMgr.SetCheckpointSettings(cfg.Checkpoints)
 	}
 
 	// Initialize Session Manager
@@ -169,6 +207,11 @@ func NewController(cfg *Config, opts ...ControllerOptions) (*Controller, error)
 	configDir := filepath.Join(os.Getenv("HOME"), ".ricochet")
 	sessionDir := filepath.Join(configDir, "sessions")
 	sessionManager := NewSessionManager(sessionDir)
+	if policy, err := ParseFsyncPolicy(cfg.Sessions.Fsync); err != nil {
+		log.Printf("[Agent] %v, syncing every append", err)
+	} else {
+		sessionManager.SetFsyncPolicy(policy)
+	}
 
 	// Initialize MCP Manager
 	mcpManager := mcpHubPkg.NewManager(configDir)
@@ -176,34 +219,40 @@ func NewController(cfg *Config, opts ...ControllerOptions) (*Controller, error)
 	// Initialize Git Manager
 	gitMgr := git.NewManager(cwd)
 
-	// Initialize Embedder
-	// If EmbeddingProvider is configured, use it. Otherwise try to use main provider.
-	var embedder index.Embedder
-	embedder = provider // Default: use main provider (if it implements Embedder)
-
-	if cfg.EmbeddingProvider != nil && cfg.EmbeddingProvider.Provider != "" {
-		embProv, err := NewProvider(*cfg.EmbeddingProvider)
-		if err != nil {
-			log.Printf("Warning: Failed to create embedding provider: %v. Falling back to main provider.", err)
-		} else {
-			embedder = embProv
-			log.Printf("Using separate embedding provider: %s", cfg.EmbeddingProvider.Provider)
-		}
-	} else if provider.Name() == "anthropic" {
-		// Specific warning for Anthropic which doesn't support embeddings
-		log.Printf("Warning: Main provider is Anthropic (no embeddings) and no separate embedding provider configured. Codebase search will not work.")
+	// Initialize Embedder: the embedding provider if configured, else the main
+	// provider if it can embed, else the built-in offline embedder
+	if cfg.EmbeddingProvider != nil {
+		embCfg := withProviderDefaults(*cfg.EmbeddingProvider, pm)
+		cfg.EmbeddingProvider = &embCfg
 	}
+	embedder := selectEmbedder(provider, cfg.Provider, cfg.EmbeddingProvider)
 
 	// Initialize indexer
-	indexPath := filepath.Join(os.Getenv("HOME"), ".ricochet", "index.vdb")
-	store, _ := index.NewLocalStore(indexPath)
+	indexDir := filepath.Join(os.Getenv("HOME"), ".ricochet")
+	store, err := index.OpenStore(cfg.VectorStore, indexDir)
+	if err != nil {
+		log.Printf("Warning: vector store %q unavailable, using the default: %v", cfg.VectorStore.Backend, err)
+		store, _ = index.OpenStore(config.VectorStoreSettings{}, indexDir)
+	}
 	indexer := index.NewIndexer(store, embedder, cwd)
+	indexer.SetIgnorePatterns(cfg.IndexIgnore)
+	indexer.SetResumeFile(filepath.Join(paths.GetCacheDir(cwd), "index-embeddings.partial"))
+	if err := indexer.CheckCompatible(0); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	indexRoots := index.NewRoots(indexer, embedder, cfg.IndexIgnore, func(name, path string) (index.VectorStore, error) {
+		return index.OpenRootStore(cfg.VectorStore, indexDir, name, path)
+	})
 
 	// Initialize Skill Manager
 	skillMgr := skills.NewManager(cwd)
 	if err := skillMgr.LoadSkills(); err != nil {
 		log.Printf("Warning: Failed to load skills: %v", err)
 	}
+	if settings != nil {
+		skillMgr.SetDisabled(settings.Get().Skills.Disabled)
+	}
+	skillMgr.StartWatcher()
 
 	// Initialize QC Manager
 	qcMgr := qc.NewManager(cwd)
@@ -216,6 +265,9 @@ func NewController(cfg *Config, opts ...ControllerOptions) (*Controller, error)
 
 	// Initialize Injection Processor (Phase 17)
 	injectionProc := NewInjectionProcessor(cwd)
+	for name, path := range handoff.ExistingArtifacts(cwd) {
+		injectionProc.Register(name, path) // Left by an earlier handoff
+	}
 
 	// Initialize Plan Manager (Autonomous Agent)
 	pmMgr := NewPlanManager(cwd)
@@ -224,6 +276,11 @@ func NewController(cfg *Config, opts ...ControllerOptions) (*Controller, error)
 	}
 
 	executor := tools.NewNativeExecutor(h, mm, safeguardMgr, mcpHub, indexer, cg, wm)
+	executor.SetIndexRoots(indexRoots)
+	if memoryMgr != nil {
+		executor.SetMemory(memoryMgr) // One store for remember and learned facts
+	}
+	executor.SetStats(tools.NewToolStats(filepath.Join(paths.GetStatsDir(cwd), "tools.json")))
 
 	// Register Subtask Tool (circular dependency handled via interface or setter later)
 	// For now, we'll inject it into the executor if supported, or handle via special tool dispatch.
@@ -239,32 +296,19 @@ func NewController(cfg *Config, opts ...ControllerOptions) (*Controller, error)
 	subtaskTool := &tools.SubtaskTool{} // Executor set later to avoid circular init
 	executor.RegisterTool(subtaskTool)
 
-	// Trigger indexing in background
-	if cfg.EnableCodeIndex {
-		go func() {
-			ctx := context.Background()
-			if err := indexer.IndexAll(ctx); err != nil {
-				log.Printf("Background indexing failed: %v", err)
-			}
-		}()
-
-		// Also trigger CodeGraph rebuild if available
-		if cg != nil {
-			go func() {
-				start := time.Now()
-				log.Printf("Building code graph...")
-				if err := cg.Rebuild(cwd); err != nil {
-					log.Printf("Code graph rebuild failed: %v", err)
-				} else {
-					log.Printf("Code graph built in %v (files: %d)", time.Since(start), len(cg.GetAllFiles()))
-
-					// Compute PageRank (takes a few iterations)
-					log.Printf("Computing PageRank...")
-					prStart := time.Now()
-					cg.CalculatePageRank()
-					log.Printf("PageRank computed in %v", time.Since(prStart))
-				}
-			}()
+	// Seed zone and auto-approval from the workspace trust decision.
+	// Unclassified workspaces are prompted on first chat, and indexing waits for consent.
+	trustStore, err := safeguard.NewTrustStore()
+	if err != nil {
+		log.Printf("Warning: Failed to load workspace trust: %v", err)
+	}
+	indexNow, awaitTrust := cfg.EnableCodeIndex, false
+	if trustStore != nil && safeguardMgr != nil {
+		if level, ok := trustStore.Get(cwd); ok {
+			safeguardMgr.ApplyTrust(level, cfg.AutoApproval)
+			indexNow = indexNow && level.AllowsIndexing()
+		} else {
+			indexNow, awaitTrust = false, true
 		}
 	}
 
@@ -272,9 +316,6 @@ func NewController(cfg *Config, opts ...ControllerOptions) (*Controller, error)
 	// storageDir := paths.GetSessionDir(cwd) // Using sessionDir from above
 	// sm := NewSessionManager(storageDir)
 
-	// Initialize Checkpoint Manager (Phase 18)
-	checkpointMgr := NewCheckpointManager(cwd)
-
 	c := &Controller{
 		provider:           provider,
 		sessionManager:     sessionManager,
@@ -287,15 +328,18 @@ func NewController(cfg *Config, opts ...ControllerOptions) (*Controller, error)
 		host:               h,
 		providersManager:   pm,
 		indexer:            indexer,
+		indexRoots:         indexRoots,
 		skills:             skillMgr,
+		settings:           settings,
 		qcManager:          qcMgr,
 		dynamicHooks:       hooksMgr,
 		memoryManager:      memoryMgr,
 		injectionProcessor: injectionProc,
 		mcpManager:         mcpManager,
+		mcpHub:             mcpHub,
 		gitManager:         gitMgr,
 		contextManager:     NewContextManager(provider, cfg.ContextWindow, 4000),
-		checkpointManager:  checkpointMgr,
+		condenseArchive:    context_manager.NewArchive(filepath.Join(sessionDir, "condensed")),
 		planManager:        pmMgr,
 		helpAgent:          NewHelpAgent(),
 		defaultModel:       cfg.Provider.Model,
@@ -312,7 +356,23 @@ func NewController(cfg *Config, opts ...ControllerOptions) (*Controller, error)
 			}
 			return resp.Content, nil
 		}),
-		workflows: wm,
+		workflows:  wm,
+		trustStore: trustStore,
+		codegraph:  cg,
+	}
+	if cg != nil && safeguardMgr != nil {
+		cg.SetZoneFunc(safeguardMgr.ZoneLabel)
+	}
+
+	if indexNow {
+		c.startBackgroundIndexing(cwd, cg)
+	} else if awaitTrust && cfg.EnableCodeIndex {
+		c.deferredIndexing = func() { c.startBackgroundIndexing(cwd, cg) }
+	}
+
+	c.helpAgent.SetEmbedder(embedder)
+	if jm, ok := h.(host.JobManager); ok {
+		c.envTracker.SetJobs(func() []string { return runningJobs(jm) })
 	}
 
 	// Initialize Swarm Orchestrator
@@ -323,6 +383,14 @@ func NewController(cfg *Config, opts ...ControllerOptions) (*Controller, error)
 	executor.RegisterTool(&StartSwarmToolImpl{Orchestrator: c.swarm})
 	executor.RegisterTool(&UpdatePlanToolImpl{Plan: pmMgr})
 
+	// analyze_image / generate_image, routed to a model that can handle images
+	executor.SetImageModel(imageModel{c: c})
+
+	// Team-defined tools from .ricochet/tools
+	if names := executor.LoadCustomTools(filepath.Join(cwd, ".ricochet", "tools")); len(names) > 0 {
+		log.Printf("Loaded custom tools: %s", strings.Join(names, ", "))
+	}
+
 	// Initialize Workflow Engine with Controller as executor
 	// Initialize Workflow Engine with Controller as executor
 	// We pass a simple adapter for command execution
@@ -336,10 +404,14 @@ func NewController(cfg *Config, opts ...ControllerOptions) (*Controller, error)
 
 // RunSubtask executes a goal in an isolated session
 func (c *Controller) RunSubtask(ctx context.Context, parentSessionID string, goal string, contextInfo string, role string) (string, error) {
-	log.Printf("[Controller] Starting SUBTASK: %s (Role: %s, Parent: %s)", goal, role, parentSessionID)
-
 	// 1. Create Child Session
-	childSession := c.CreateSession() // Start fresh
+	return c.runSubtaskInSession(ctx, c.CreateSession(), parentSessionID, goal, contextInfo, role)
+}
+
+// runSubtaskInSession drives the subtask auto-pilot loop inside a caller-provided session,
+// so callers that need per-run accounting (e.g. swarm best-of) can inspect it afterwards.
+func (c *Controller) runSubtaskInSession(ctx context.Context, childSession *Session, parentSessionID string, goal string, contextInfo string, role string) (string, error) {
+	log.Printf("[Controller] Starting SUBTASK: %s (Role: %s, Parent: %s)", goal, role, parentSessionID)
 
 	// 1.5 Context Inheritance: Copy Active Files from Parent
 	if parentSessionID != "" {
@@ -517,6 +589,50 @@ func (c *Controller) GetGitManager() *git.Manager {
 	return c.gitManager
 }
 
+// ExecuteTool runs a single tool outside of a chat turn
+func (c *Controller) ExecuteTool(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	result, err := c.executor.Execute(ctx, name, args)
+	result = c.redactToolResult(result)
+	if err == nil {
+		result = c.screenToolResult("", ToolCallInfo{Name: name, Arguments: string(args)}, result, nil)
+	}
+	return result, err
+}
+
+// redactToolResult masks the secrets in a tool result, noting how many were
+// hidden so the model doesn't mistake the placeholders for real values
+func (c *Controller) redactToolResult(result string) string {
+	redacted, n := c.safeguard.RedactSecrets(result)
+	if n == 0 {
+		return result
+	}
+	return redacted + fmt.Sprintf("\n\n(%d secret(s) replaced with %s)", n, safeguard.Redacted)
+}
+
+// RedactSecrets masks secrets in text per the workspace's redaction rules,
+// e.g. before it is forwarded to a messenger
+func (c *Controller) RedactSecrets(text string) string {
+	redacted, _ := c.safeguard.RedactSecrets(text)
+	return redacted
+}
+
+// GetIndexer returns the codebase indexer
+func (c *Controller) GetIndexer() *index.Indexer {
+	return c.indexer
+}
+
+// GetIndexRoots returns the indexed repos of the workspace
+func (c *Controller) GetIndexRoots() *index.Roots {
+	return c.indexRoots
+}
+
+// ProviderName returns the name of the active AI provider
+func (c *Controller) ProviderName() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.provider.Name()
+}
+
 func (c *Controller) GetPlanManager() *PlanManager {
 	return c.planManager
 }
@@ -574,13 +690,105 @@ func truncateString(s string, max int) string {
 	return string(runes[:max]) + "... (truncated)"
 }
 
+// startBackgroundIndexing indexes the workspace, keeps the index fresh as
+// files change, and rebuilds the code graph in the background
+func (c *Controller) startBackgroundIndexing(cwd string, cg *codegraph.Service) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.mu.Lock()
+	c.stopIndexWatch = cancel
+	c.mu.Unlock()
+	c.indexer.SetProgress(func(p index.Progress) {
+		c.ReportTaskProgress(ctx, protocol.TaskProgress{
+			TaskName:        "Indexing codebase",
+			Status:          p.Phase,
+			Summary:         p.Summary(),
+			IsActive:        p.Phase != index.PhaseDone && p.Phase != index.PhaseFailed,
+			AgentIdentifier: "Indexer",
+		})
+	})
+	go func() {
+		if err := c.indexer.IndexAll(ctx); err != nil {
+			log.Printf("Background indexing failed: %v", err)
+		}
+		c.indexer.Watch(ctx)
+	}()
+
+	// Also update the CodeGraph if available, from the previous session's
+	// cache so only changed files are parsed again
+	if cg != nil {
+		go func() {
+			start := time.Now()
+			cachePath := filepath.Join(paths.GetCacheDir(cwd), "codegraph.json")
+			if len(cg.GetAllFiles()) == 0 {
+				if err := cg.LoadCache(cachePath); err != nil && !os.IsNotExist(err) {
+					log.Printf("Warning: ignoring code graph cache: %v", err)
+				}
+			}
+			stats, err := cg.Update(cwd)
+			if err != nil {
+				log.Printf("Code graph update failed: %v", err)
+				return
+			}
+			log.Printf("Code graph updated in %v (files: %d, parsed: %d, removed: %d)", time.Since(start), stats.Files, stats.Parsed, stats.Removed)
+
+			// Compute PageRank (incremental, skipped when nothing changed)
+			prStart := time.Now()
+			cg.CalculatePageRank()
+			log.Printf("PageRank computed in %v", time.Since(prStart))
+			if err := cg.SaveCache(cachePath); err != nil {
+				log.Printf("Warning: failed to save code graph cache: %v", err)
+			}
+		}()
+	}
+}
+
+// Close stops the controller's background work, for when it is replaced
+func (c *Controller) Close() {
+	c.mu.Lock()
+	stop := c.stopIndexWatch
+	c.stopIndexWatch = nil
+	c.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+	if c.indexRoots != nil {
+		c.indexRoots.Close()
+	}
+	for _, session := range c.sessionManager.ListSessions() {
+		c.endSession(session)
+	}
+}
+
 // SetLiveMode sets the live mode provider for the executor
 func (c *Controller) SetLiveMode(lm tools.LiveModeProvider) {
+	c.mu.Lock()
+	c.liveMode = lm
+	c.mu.Unlock()
 	if ne, ok := c.executor.(*tools.NativeExecutor); ok {
 		ne.SetLiveMode(lm)
 	}
 }
 
+// SetNotifier sets the desktop notifier for approval requests and task completion
+func (c *Controller) SetNotifier(n tools.Notifier) {
+	c.mu.Lock()
+	c.notifier = n
+	c.mu.Unlock()
+	if ne, ok := c.executor.(*tools.NativeExecutor); ok {
+		ne.SetNotifier(n)
+	}
+}
+
+// notify raises a desktop notification if a notifier is set
+func (c *Controller) notify(title, message string) {
+	c.mu.RLock()
+	n := c.notifier
+	c.mu.RUnlock()
+	if n != nil {
+		n.Notify(title, message)
+	}
+}
+
 // AbortCurrentSession cancels any running chat session
 func (c *Controller) AbortCurrentSession() {
 	c.abortMu.Lock()
@@ -592,8 +800,39 @@ func (c *Controller) AbortCurrentSession() {
 	}
 }
 
-// CreateSession creates a new session
+// GetContextStatus estimates how much of the context window a session's
+// history takes up, or returns nil for an unknown session
+func (c *Controller) GetContextStatus(sessionID string) *protocol.ContextStatus {
+	session := c.GetSession(sessionID)
+	if session == nil || c.contextManager == nil {
+		return nil
+	}
+
+	msgs := session.StateHandler.GetMessages()
+	var pinnedMsgs []protocol.Message
+	for _, msg := range msgs {
+		if msg.Pinned {
+			pinnedMsgs = append(pinnedMsgs, msg)
+		}
+	}
+	pinnedFiles := len(c.pinnedPrompt(session)) / 4 // Same estimate as EstimateTokens
+	used := c.contextManager.EstimateTokens(msgs) + pinnedFiles
+	max := c.contextManager.contextWindow
+	return &protocol.ContextStatus{
+		TokensUsed:     used,
+		TokensMax:      max,
+		Percentage:     float64(used) / float64(max) * 100,
+		CumulativeCost: session.TotalCost,
+		PinnedTokens:   c.contextManager.EstimateTokens(pinnedMsgs) + pinnedFiles,
+	}
+}
+
+// CreateSession creates a new session. Starting a new chat ends the
+// others, so what they taught is memorized.
 func (c *Controller) CreateSession() *Session {
+	for _, other := range c.sessionManager.ListSessions() {
+		c.endSession(other)
+	}
 	s := c.sessionManager.CreateSession()
 	if c.workflows != nil {
 		c.workflows.Hooks.Trigger("on_session_created")
@@ -618,13 +857,17 @@ func (c *Controller) HydrateSession(sessionID string, messages []protocol.Messag
 	session.StateHandler.SetMessages(messages)
 }
 
-// DeleteSession deletes a session
+// DeleteSession deletes a session, memorizing it first
 func (c *Controller) DeleteSession(id string) error {
+	c.endSession(c.sessionManager.GetSession(id))
+	c.condenseArchive.Remove(id)
 	return c.sessionManager.DeleteSession(id)
 }
 
 // ClearSession clears a session's messages
 func (c *Controller) ClearSession(id string) {
+	c.endSession(c.sessionManager.GetSession(id))
+	c.condenseArchive.Remove(id)
 	c.sessionManager.DeleteSession(id)
 	c.sessionManager.CreateSession() // Recreate
 }
@@ -639,12 +882,16 @@ func (c *Controller) SetMainSessionID(sessionID string) {
 	}
 }
 
+// maxListedModels caps each provider's entry in the /model listing
+const maxListedModels = 15
+
 // ChatRequest represents a request to chat
 type ChatRequestInput struct {
 	SessionID string `json:"session_id"`
 	Content   string `json:"content"`
 	Via       string `json:"via,omitempty"` // Message source: telegram, discord, ide
 	PlanMode  bool   `json:"plan_mode,omitempty"`
+	Routing   string `json:"routing,omitempty"` // OpenRouter preference for this message: price, latency, throughput
 }
 
 // ChatUpdate represents a chat update event
@@ -686,10 +933,12 @@ type ActivityItem struct {
 
 // TaskMetadata tracks usage statistics
 type TaskMetadata struct {
-	TokensIn     int     `json:"tokensIn"`
-	TokensOut    int     `json:"tokensOut"`
-	TotalCost    float64 `json:"totalCost"`
-	ContextLimit int     `json:"contextLimit"`
+	TokensIn     int       `json:"tokensIn"`
+	TokensOut    int       `json:"tokensOut"`
+	TotalCost    float64   `json:"totalCost"`
+	ContextLimit int       `json:"contextLimit"`
+	Diff         *TurnDiff `json:"diff,omitempty"`     // Workspace changes caused by this turn
+	Upstream     string    `json:"upstream,omitempty"` // Provider OpenRouter routed the request to
 }
 
 // ProgressStep represents a granular action taken by the agent
@@ -719,10 +968,12 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 	ctx, cancel := context.WithCancel(ctx)
 	c.abortMu.Lock()
 	c.abortCancel = cancel
+	turn := c.trackTurn(cancel)
 	c.abortMu.Unlock()
 	defer func() {
 		c.abortMu.Lock()
 		c.abortCancel = nil
+		delete(c.turns, turn)
 		c.abortMu.Unlock()
 	}()
 
@@ -735,6 +986,13 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 	if session == nil {
 		return fmt.Errorf("session '%s' not found. Type /new to start.", input.SessionID)
 	}
+	// Journal appends are O(1), so persist every turn
+	defer c.sessionManager.Save(input.SessionID)
+
+	// First chat in an unclassified workspace: ask how far to trust it
+	if !strings.HasPrefix(input.Content, "/trust") {
+		c.ensureWorkspaceTrust(ctx, input.SessionID, callback)
+	}
 
 	// Add user message if content provided
 	if input.Content != "" {
@@ -742,7 +1000,7 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 			// Prepend a Plan Mode constraint
 			session.StateHandler.AddMessage(protocol.Message{
 				Role:    "system",
-				Content: "PLAN MODE ENABLED: You are in read-only mode for exploration and planning. You can use search and read tools, but avoid making any file changes or executing destructive commands. If the user asks for changes, explain your plan first.",
+				Content: planModeNotice,
 			})
 		}
 		// SLASH COMMAND INTERCEPTION
@@ -750,8 +1008,10 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 			// 1. Model Switching: /model
 			if strings.HasPrefix(input.Content, "/model") {
 				args := strings.TrimSpace(strings.TrimPrefix(input.Content, "/model"))
-				if args == "" {
-					// List available models
+				if !strings.Contains(args, ":") {
+					// List available models; a bare argument filters by model ID
+					filter := strings.ToLower(args)
+					c.providersManager.RefreshModels(ctx)
 					available := c.providersManager.GetAvailableProviders()
 					var sb strings.Builder
 					sb.WriteString("### 🤖 Available Models\n\n")
@@ -762,19 +1022,40 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 						} else if p.HasKey {
 							icon = "🔑"
 						}
-						sb.WriteString(fmt.Sprintf("%s **%s** (%s)\n", icon, p.Name, p.ID))
+						var models []config.AvailableModel
 						for _, m := range p.Models {
+							if filter == "" || strings.Contains(strings.ToLower(m.ID), filter) {
+								models = append(models, m)
+							}
+						}
+						if filter != "" && len(models) == 0 {
+							continue
+						}
+						sb.WriteString(fmt.Sprintf("%s **%s** (%s)\n", icon, p.Name, p.ID))
+						hidden := 0
+						if filter == "" && len(models) > maxListedModels {
+							// OpenRouter alone lists hundreds of models
+							hidden = len(models) - maxListedModels
+							models = models[:maxListedModels]
+						}
+						for _, m := range models {
 							current := ""
 							c.mu.RLock()
 							if p.ID == c.config.Provider.Provider && m.ID == c.config.Provider.Model {
 								current = " (current)"
 							}
 							c.mu.RUnlock()
+							if m.Deprecated {
+								current += " ⚠️ deprecated"
+							}
 							sb.WriteString(fmt.Sprintf("  - `%s:%s`%s\n", p.ID, m.ID, current))
 						}
+						if hidden > 0 {
+							sb.WriteString(fmt.Sprintf("  - …and %d more (`/model <filter>` to search)\n", hidden))
+						}
 						sb.WriteString("\n")
 					}
-					sb.WriteString("**Usage**: `/model provider:model` (e.g. `/model anthropic:claude-3-5-sonnet`)")
+					sb.WriteString("**Usage**: `/model provider:model` (e.g. `/model anthropic:claude-3-5-sonnet`), `/model <filter>` to search")
 
 					callback(ChatUpdate{
 						SessionID: input.SessionID,
@@ -789,26 +1070,15 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 				}
 
 				// Switch model
-				parts := strings.Split(args, ":")
-				if len(parts) != 2 {
-					callback(ChatUpdate{
-						SessionID: input.SessionID,
-						Message: ChatMessage{
-							ID:        uuid.New().String(),
-							Role:      "assistant",
-							Content:   "❌ Invalid format. Use: `/model provider:model`",
-							Timestamp: time.Now().UnixMilli(),
-						},
-					})
-					return nil
-				}
-
+				// Only the first ':' separates the provider; Bedrock model IDs contain ':' too
+				parts := strings.SplitN(args, ":", 2)
 				providerID := parts[0]
 				modelID := parts[1]
 
 				// Validate and get key
 				apiKey := c.providersManager.GetAPIKey(providerID)
-				if apiKey == "" {
+				_, hasAWSEnv := parseAWSCredentials("")
+				if apiKey == "" && !(providerID == "bedrock" && hasAWSEnv) && providerID != config.LocalProviderID {
 					callback(ChatUpdate{
 						SessionID: input.SessionID,
 						Message: ChatMessage{
@@ -822,12 +1092,12 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 				}
 
 				// Re-initialize provider
-				newConfig := ProviderConfig{
+				newConfig := withProviderDefaults(ProviderConfig{
 					Provider: providerID,
 					Model:    modelID,
 					APIKey:   apiKey,
-					BaseURL:  c.providersManager.GetBaseURL(providerID),
-				}
+					Routing:  c.config.Provider.Routing,
+				}, c.providersManager)
 
 				newProvider, err := NewProvider(newConfig)
 				if err != nil {
@@ -865,6 +1135,61 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 			cmdParts := strings.Split(input.Content, " ")
 			cmdName := cmdParts[0]
 
+			// 2. Guided revert of the last agent run(s): /undo-run
+			if cmdName == "/undo-run" {
+				return c.handleUndoRun(ctx, input.SessionID, strings.TrimSpace(strings.TrimPrefix(input.Content, cmdName)), callback)
+			}
+			if cmdName == "/trust" {
+				return c.handleTrustCommand(input.SessionID, strings.TrimSpace(strings.TrimPrefix(input.Content, cmdName)), callback)
+			}
+			if cmdName == "/stats" {
+				return c.handleStatsCommand(input.SessionID, strings.TrimSpace(strings.TrimPrefix(input.Content, cmdName)), callback)
+			}
+			if cmdName == "/memory" {
+				return c.handleMemoryCommand(input.SessionID, strings.TrimSpace(strings.TrimPrefix(input.Content, cmdName)), callback)
+			}
+			if cmdName == "/expand" {
+				return c.handleExpandCommand(input.SessionID, strings.TrimSpace(strings.TrimPrefix(input.Content, cmdName)), callback)
+			}
+			if cmdName == "/context" {
+				return c.handleContextCommand(input.SessionID, callback)
+			}
+			if cmdName == "/skills" {
+				return c.handleSkillsCommand(input.SessionID, strings.TrimSpace(strings.TrimPrefix(input.Content, cmdName)), callback)
+			}
+			if cmdName == "/mode" {
+				return c.handleModeCommand(input.SessionID, strings.TrimSpace(strings.TrimPrefix(input.Content, cmdName)), callback)
+			}
+			if cmdName == "/panic" {
+				return c.handlePanicCommand(input.SessionID, callback)
+			}
+			if cmdName == "/resume" {
+				return c.handleResumeCommand(input.SessionID, callback)
+			}
+			if cmdName == "/permissions" {
+				return c.handlePermissionsCommand(input.SessionID, strings.TrimSpace(strings.TrimPrefix(input.Content, cmdName)), callback)
+			}
+			if cmdName == "/mcp" {
+				return c.handleMcpCommand(ctx, input.SessionID, strings.TrimSpace(strings.TrimPrefix(input.Content, cmdName)), callback)
+			}
+			if strings.HasPrefix(cmdName, "/mcp__") {
+				// MCP prompts run as a normal turn with the rendered prompt as the message
+				expanded, err := c.expandMcpPrompt(ctx, cmdName, strings.TrimSpace(strings.TrimPrefix(input.Content, cmdName)))
+				if err != nil {
+					callback(ChatUpdate{
+						SessionID: input.SessionID,
+						Message: ChatMessage{
+							ID:        uuid.New().String(),
+							Role:      "assistant",
+							Content:   fmt.Sprintf("❌ %v", err),
+							Timestamp: time.Now().UnixMilli(),
+						},
+					})
+					return nil
+				}
+				input.Content = expanded
+			}
+
 			if c.workflows != nil {
 				if wf, ok := c.workflows.GetWorkflow(cmdName); ok {
 					go func() {
@@ -1062,6 +1387,25 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 		})
 	}
 
+	// Tag provider requests with this session for fair queueing, and show the
+	// queue position while the rate limiter holds one (not recorded as a step)
+	ctx = ratelimit.WithSession(ctx, input.SessionID)
+	ctx = ratelimit.WithObserver(ctx, func(ahead int) {
+		status := "Waiting for provider rate limit"
+		if ahead > 0 {
+			status = fmt.Sprintf("Queued behind %d request(s)", ahead)
+		}
+		callback(protocol.TaskProgress{
+			TaskName:   dynamicTaskName,
+			Status:     status,
+			Summary:    taskSummary,
+			Steps:      accumulatedSteps,
+			IsActive:   true,
+			ToolCount:  totalToolCount,
+			TokenCount: totalTokenCount,
+		})
+	})
+
 	// REMOVED: Unconditional "Starting..." task emission.
 	// This prevents simple chats ("Hi") from creating a task tree node.
 	// Real tasks will trigger progress updates via tools or specific logic steps.
@@ -1074,6 +1418,16 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 	// Usage tracking
 	var totalTokensIn int
 	var totalTokensOut int
+	var costAdjust float64 // Provider-billed cost minus the price-table estimate, for turns that report it
+
+	routing := c.config.Provider.Routing
+	if input.Routing != "" {
+		if r, err := NormalizeRouting(input.Routing); err != nil {
+			log.Printf("⚠️ Ignoring routing preference: %v", err)
+		} else {
+			routing = r
+		}
+	}
 
 	// Create assistant message placeholder ONCE for the whole chat session
 	// Use the current message count as a stable index for the ID
@@ -1094,6 +1448,17 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 	}
 	emitUpdate(assistantMsg)
 
+	// Shadow checkpoint taken before the first write, for turn-level diff attribution
+	turnBaseCheckpoint := ""
+	defer func() {
+		if diff := c.finishTurnDiff(turnBaseCheckpoint); diff != nil {
+			assistantMsg.Metadata.Diff = diff
+			assistantMsg.IsStreaming = false
+			emitUpdate(assistantMsg)
+			log.Printf("📊 Turn %s", diff.Summary())
+		}
+	}()
+
 	for currentTurn < maxTurns {
 		currentTurn++
 
@@ -1104,18 +1469,9 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 
 		// Prepare Tools config for provider
 		defs := c.executor.GetDefinitions()
-		var providerTools []protocol.Tool
 		activeMode := c.modes.GetActiveMode()
-
-		for _, d := range defs {
-			if modes.IsToolAllowed(activeMode, d.Name) {
-				providerTools = append(providerTools, protocol.Tool{
-					Name:        d.Name,
-					Description: d.Description,
-					InputSchema: d.InputSchema,
-				})
-			}
-		}
+		providerTools := allowedTools(defs, activeMode)
+		c.applyModeZone(activeMode)
 
 		// Manage context window (condensation + sliding window)
 		// Use ContextWindow for pruning, not MaxTokens (response limit)
@@ -1136,6 +1492,7 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 				log.Printf("[Agent] Warning: Compaction failed: %v", err)
 			} else {
 				session.StateHandler.SetMessages(compacted)
+				c.recordCondensation(input.SessionID, context_manager.KindCompacted, currentMessages, compacted, compactionSummary(compacted), callback)
 				currentMessages = compacted // Update local reference
 
 				// Notify frontend of compaction
@@ -1169,10 +1526,15 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 		// If query is about help, switch system prompt to Expert Help Agent
 		currentSystemPrompt := c.config.SystemPrompt
 		if c.helpAgent.IsHelpQuery(input.Content) {
-			currentSystemPrompt = c.helpAgent.GetSystemPrompt()
+			currentSystemPrompt = c.helpAgent.BuildSystemPrompt(ctx, input.Content, defs)
 			log.Printf("🤖 Help Agent Activated for query: %s", input.Content)
 		}
 
+		// Pinned files and notes ride in the system prompt, out of reach of condensation
+		basePrompt := currentSystemPrompt
+		pinnedPrompt := c.pinnedPrompt(session)
+		currentSystemPrompt += pinnedPrompt
+
 		wm := context_manager.NewWindowManagerWithSettings(contextLimit, ctxSettings, condenseProvider)
 
 		contextResult, err := wm.ManageContext(ctx, currentMessages, currentSystemPrompt)
@@ -1182,6 +1544,9 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 
 		if contextResult.WasCondensed {
 			log.Printf("🧠 Reflex Engine: Context condensed (Summary length: %d chars)", len(contextResult.Summary))
+			c.recordCondensation(input.SessionID, context_manager.KindCondensed, currentMessages, contextResult.Messages, contextResult.Summary, callback)
+		} else if contextResult.WasTruncated {
+			c.recordCondensation(input.SessionID, context_manager.KindTruncated, currentMessages, contextResult.Messages, "", callback)
 		}
 
 		// Inject CodeGraph Repo Map if available (Repo Intelligence)
@@ -1192,24 +1557,15 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 		// A better strategy is to append it to the System Prompt, or use a "Developer" role message if supported.
 		// Let's modify System Prompt for this turn.
 
-		finalSystemPrompt := contextResult.SystemPrompt
-		if c.codegraph != nil {
-			// Limit size: 5% of context window or max 100 files
-			repoMap := c.codegraph.GenerateRepoMap(100)
-			if repoMap != "" {
-				finalSystemPrompt += "\n\n" + repoMap + "\n\n(This repository map is auto-generated based on Code Graph PageRank analysis)"
-			}
-		}
+		repoMapPrompt := c.repoMapPrompt(session)
+		finalSystemPrompt := contextResult.SystemPrompt + repoMapPrompt
 
 		// Build request
 		// Build request with enhanced context including Active Mode and Project Rules
 		// activeMode retrieved earlier
-		modePrompt := fmt.Sprintf("\n\n### Current Mode: %s\n%s\n%s",
-			activeMode.Name,
-			activeMode.RoleDefinition,
-			activeMode.CustomInstructions)
+		modePrompt := modePromptFor(activeMode)
 
-		rulesContext := c.rules.GetRules()
+		rulesContext := c.rules.Prompt(activeMode.Slug, session.FileTracker.GetFiles())
 
 		// Skill Injection (Hardcore Workflow)
 		var skillContext string
@@ -1242,7 +1598,12 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 		// Inject Plan Context (Autonomous Agent)
 		planContext := c.planManager.GenerateContext()
 
-		enhancedSystemPrompt := finalSystemPrompt + modePrompt + memoryContext + rulesContext + skillContext + planContext + "\n\n" + c.envTracker.GetContext() + "\n" + session.FileTracker.GetContext()
+		// List MCP resources the agent can read
+		mcpContext := c.mcpResourceContext()
+
+		envContext := c.envTracker.GetContext()
+		filesContext := session.FileTracker.GetContext()
+		enhancedSystemPrompt := finalSystemPrompt + modePrompt + memoryContext + rulesContext + skillContext + planContext + mcpContext + "\n\n" + envContext + "\n" + filesContext
 
 		// Use contextResult.Messages as prunedMessages
 		prunedMessages := contextResult.Messages
@@ -1284,12 +1645,29 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 			log.Printf("📨 Ephemeral message injected (mode=%s, inTask=%v)", normalizedMode, isInTaskMode)
 		}
 
+		c.recordBreakdown(session.ID, promptParts{
+			System:   basePrompt,
+			Mode:     modePrompt,
+			RepoMap:  repoMapPrompt,
+			Rules:    rulesContext,
+			Skills:   skillContext,
+			Memory:   memoryContext,
+			Plan:     planContext,
+			MCP:      mcpContext,
+			Env:      envContext,
+			Files:    filesContext,
+			Pinned:   pinnedPrompt,
+			Messages: prunedMessages,
+			Tools:    providerTools,
+		}.breakdown(contextLimit, true))
+
 		req := &ChatRequest{
-			Model:        c.config.Provider.Model,
+			Model:        modeModel(activeMode, c.config.Provider.Model),
 			Messages:     prunedMessages,
 			SystemPrompt: enhancedSystemPrompt,
 			MaxTokens:    c.config.MaxTokens,
 			Tools:        providerTools,
+			Routing:      routing,
 		}
 
 		// Calculate Input Tokens (Prompt) - Heuristic: len / 4
@@ -1321,7 +1699,7 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 				}
 			}
 
-			if isFree {
+			if isFree || c.config.Provider.Provider == config.LocalProviderID {
 				return 0
 			}
 
@@ -1352,11 +1730,13 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 				WasCondensed:   contextResult.WasCondensed,
 				WasTruncated:   contextResult.WasTruncated,
 				CumulativeCost: session.TotalCost,
+				PinnedTokens:   contextResult.PinnedTokens + context_manager.EstimateBudgetedTokens(pinnedPrompt),
 			},
 		})
 
 		var currentTurnContent string
 		var currentTurnReasoning string // Track reasoning separately for DeepSeek R1
+		var turnTokensOut int           // Output tokens estimated for this turn
 		var currentTurnToolCalls []ToolCallInfo
 
 		// Throttling for streaming updates to prevent webview crash
@@ -1412,9 +1792,9 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 					deltaTokens = 1
 				}
 				totalTokensOut += deltaTokens
+				turnTokensOut += deltaTokens
 				assistantMsg.Metadata.TokensOut = totalTokensOut
-				turnCost := calcCost(totalTokensIn, totalTokensOut)
-				assistantMsg.Metadata.TotalCost = turnCost
+				assistantMsg.Metadata.TotalCost = calcCost(totalTokensIn, totalTokensOut) + costAdjust
 
 				// Throttle streaming updates to prevent webview overflow
 				// BUT always emit: first chunk, thinking tags (reasoning), and after throttle interval
@@ -1447,6 +1827,30 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 					emitUpdate(assistantMsg)
 				}
 
+			case "usage":
+				// Replace this turn's length-based estimates with the provider's counts
+				if u := chunk.Usage; u != nil {
+					if u.InputTokens > 0 {
+						totalTokensIn += u.InputTokens - promptTokens
+						promptTokens = u.InputTokens
+					}
+					if u.OutputTokens > 0 {
+						totalTokensOut += u.OutputTokens - turnTokensOut
+						turnTokensOut = u.OutputTokens
+					}
+					if u.Cost > 0 {
+						adjust := u.Cost - calcCost(promptTokens, turnTokensOut)
+						costAdjust += adjust
+						session.TotalCost += adjust
+					}
+					if u.Upstream != "" {
+						assistantMsg.Metadata.Upstream = u.Upstream
+					}
+					assistantMsg.Metadata.TokensIn = totalTokensIn
+					assistantMsg.Metadata.TokensOut = totalTokensOut
+					assistantMsg.Metadata.TotalCost = calcCost(totalTokensIn, totalTokensOut) + costAdjust
+				}
+
 			case "message_stop", "message_delta":
 				assistantMsg.IsStreaming = false
 				emitUpdate(assistantMsg)
@@ -1486,11 +1890,14 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 
 		// If no tools used, we are done
 		if len(currentTurnToolCalls) == 0 {
+			c.notify("Ricochet finished", currentTurnContent)
 			break
 		}
 
 		// Initialize QC flag
 		runQC := false
+		// Set when the user approved this turn's calls, so tools don't ask again
+		userApproved := false
 
 		// ─── BATCH TOOL CONFIRMATION (Phase 19) ───
 		if len(currentTurnToolCalls) > 0 {
@@ -1538,15 +1945,20 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 					"No",
 				}
 
+				c.notify("Ricochet needs approval", fmt.Sprintf("%d tool call(s) waiting for your review", len(currentTurnToolCalls)))
 				choiceIdx, err := c.host.AskUserChoice(summary.String(), choices)
 				if err != nil {
 					return fmt.Errorf("approval failed: %w", err)
 				}
 
 				// 0 = Yes
-				// 1 = Yes + Whitelist (runtime only for now)
+				// 1 = Yes + persistent project rule per tool call
 				// 2 = No
 
+				for _, tc := range currentTurnToolCalls {
+					c.recordToolApproval(tc, true, choiceIdx != 2)
+				}
+
 				if choiceIdx == 2 {
 					// User denied. Send rejection messages for all tools.
 					var toolResults []protocol.ToolResultBlock
@@ -1563,28 +1975,41 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 					})
 					continue // Go to next turn (AI will react to rejection)
 				}
+				userApproved = true
 
 				if choiceIdx == 1 {
-					// Whitelist the tools in this batch
+					// Remember each call in this batch as a scoped rule
 					for _, tc := range currentTurnToolCalls {
-						if c.config.AutoApproval != nil {
-							// Naive runtime whitelist: just toggle the broad category if applicable?
-							// Better: we can't easily persist fine-grained rules yet without config overhaul.
-							// For now, let's just enable the category for this session.
-							switch tc.Name {
-							case "read_file", "list_dir":
-								c.config.AutoApproval.ReadFiles = true
-							case "execute_command":
-								c.config.AutoApproval.ExecuteSafeCommands = true
-							}
+						if err := c.rememberApproval(tc); err != nil {
+							log.Printf("[Agent] Failed to save permission rule for %s: %v", tc.Name, err)
 						}
 					}
 				}
+			} else {
+				for _, tc := range currentTurnToolCalls {
+					c.recordToolApproval(tc, false, true)
+				}
+			}
+		}
+
+		if turnBaseCheckpoint == "" {
+			for _, tc := range currentTurnToolCalls {
+				if isWriteTool(tc.Name) {
+					turnBaseCheckpoint = c.beginTurnDiff()
+					if turnBaseCheckpoint != "" {
+						c.tagTurnCheckpoint(session, turnBaseCheckpoint)
+					}
+					break
+				}
 			}
 		}
 
 		// EXECUTE TOOLS
 		log.Printf("Executing %d tools...", len(currentTurnToolCalls))
+		toolCtx := ctx
+		if userApproved {
+			toolCtx = tools.WithUserApproval(ctx)
+		}
 		var toolResults []protocol.ToolResultBlock
 		for i, tc := range currentTurnToolCalls {
 			// Prettify tool name for progress
@@ -1636,6 +2061,13 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 
 			if err == nil {
 				switch tc.Name {
+				case "pin_context":
+					var payload map[string]interface{}
+					if err = json.Unmarshal([]byte(tc.Arguments), &payload); err == nil {
+						result = c.handlePinTool(input.SessionID, payload)
+					} else {
+						result = fmt.Sprintf("Error parsing pin_context args: %v", err)
+					}
 				case "update_todos":
 					var payload struct {
 						Todos []protocol.Todo `json:"todos"`
@@ -1699,24 +2131,12 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 						if err == nil && payload.Handoff {
 							// 2. Trigger Handoff
 							log.Printf("🧠 Triggering Intelligent Handoff...")
-							cwd, _ := os.Getwd()
-
-							// Summarize history (exclude current tool call)
-							msgs := session.StateHandler.GetMessages()
-							spec, hErr := c.handoffService.GenerateSpec(ctx, msgs)
+							note, hErr := c.runHandoff(ctx, session)
 							if hErr != nil {
-								log.Printf("Handoff generation failed: %v", hErr)
+								log.Printf("Handoff failed: %v", hErr)
 								result += fmt.Sprintf("\n(Warning: Handoff failed: %v)", hErr)
 							} else {
-								sErr := c.handoffService.SaveSpec(cwd, spec)
-								if sErr != nil {
-									log.Printf("Handoff save failed: %v", sErr)
-								} else {
-									// 3. Condense Context: Re-initialize session but keep ID
-									// For now, we just log it. Real pruning happens in ContextManager anyway.
-									// But to "Start Fresh", we could archive messages.
-									result += "\n\n🧠 **Intelligent Handoff Complete**\nContext condensed into `SPEC.md`. Mode switched."
-								}
+								result += "\n\n🧠 **Intelligent Handoff Complete**\n" + note + " Mode switched."
 							}
 						}
 					} else {
@@ -1729,9 +2149,11 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 
 				case "update_plan":
 					var payload struct {
-						TaskID       string   `json:"task_id"`
-						Status       string   `json:"status"`
-						Dependencies []string `json:"dependencies"`
+						TaskID          string   `json:"task_id"`
+						Status          string   `json:"status"`
+						Dependencies    []string `json:"dependencies"`
+						Attempts        int      `json:"attempts"`
+						SelectionPolicy string   `json:"selection_policy"`
 					}
 					if err = json.Unmarshal([]byte(tc.Arguments), &payload); err == nil {
 						// Update Status
@@ -1749,11 +2171,20 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 								result += fmt.Sprintf(" Set dependencies: %v.", payload.Dependencies)
 							}
 						}
+
+						// Configure best-of-N execution if provided
+						if payload.Attempts > 0 {
+							if atErr := c.planManager.SetTaskAttempts(payload.TaskID, payload.Attempts, payload.SelectionPolicy); atErr != nil {
+								result += fmt.Sprintf(" (Failed to set attempts: %v)", atErr)
+							} else {
+								result += fmt.Sprintf(" Best-of-%d enabled.", payload.Attempts)
+							}
+						}
 					} else {
 						result = fmt.Sprintf("Error parsing update_plan args: %v", err)
 					}
 				default:
-					result, err = c.executor.Execute(ctx, tc.Name, json.RawMessage(tc.Arguments))
+					result, err = c.executor.Execute(toolCtx, tc.Name, json.RawMessage(tc.Arguments))
 				}
 			}
 			isError := false
@@ -1786,6 +2217,12 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 				stuckCounter = 0 // Reset stuck counter on successful tool execution
 			}
 
+			// Mask credentials before the provider, the UI or a messenger sees them
+			result = c.redactToolResult(result)
+			if !isError {
+				result = c.screenToolResult(input.SessionID, tc, result, callback)
+			}
+
 			displayResult := truncateString(result, 1000)
 
 			currentTurnToolCalls[i].Result = displayResult
@@ -1817,7 +2254,26 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 				}
 			}
 
-			// Track file access for context
+			// Track test runs, so failing suites stay in the environment context
+			if tc.Name == "execute_command" {
+				var payload struct {
+					Command    string `json:"command"`
+					Background bool   `json:"background"`
+				}
+				if json.Unmarshal([]byte(tc.Arguments), &payload) == nil && !payload.Background {
+					c.envTracker.ObserveCommand(payload.Command, result, isError)
+				}
+			}
+
+			// Track file access and searches for context
+			if !isError && tc.Name == "codebase_search" {
+				var argsMap map[string]interface{}
+				if json.Unmarshal([]byte(tc.Arguments), &argsMap) == nil {
+					if query, ok := argsMap["query"].(string); ok {
+						session.FileTracker.AddQuery(query)
+					}
+				}
+			}
 			if !isError && (tc.Name == "read_file" || tc.Name == "write_file" || tc.Name == "view_file") {
 				var argsMap map[string]interface{}
 				if json.Unmarshal([]byte(tc.Arguments), &argsMap) == nil {
@@ -1832,7 +2288,7 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 			}
 
 			// Track file edits for task progress
-			if !isError && (tc.Name == "write_file" || tc.Name == "replace_file_content" || tc.Name == "write_to_file") {
+			if !isError && (tc.Name == "write_file" || tc.Name == "replace_file_content" || tc.Name == "write_to_file" || tc.Name == "apply_diff") {
 				var argsMap map[string]interface{}
 				if json.Unmarshal([]byte(tc.Arguments), &argsMap) == nil {
 					var target string
@@ -1840,33 +2296,24 @@ func (c *Controller) Chat(ctx context.Context, input ChatRequestInput, callback
 						target = t
 					} else if t, ok := argsMap["AbsolutePath"].(string); ok {
 						target = t
+					} else if t, ok := argsMap["path"].(string); ok && tc.Name == "apply_diff" {
+						target = t
 					}
 
 					if target != "" {
 						emitTaskProgress(fmt.Sprintf("Edited %s", filepath.Base(target)), []string{target}, 0, 0, "")
+						session.FileTracker.AddFile(target) // Its own edit is not a change underneath it
 					}
 				}
 			}
 
-			// Auto-checkpoint after write operations (Phase 18)
-			if !isError && c.checkpointManager != nil && isWriteTool(tc.Name) {
-				// Detect target file for specific snapshot
-				var targetFiles []string
-				var argsMap map[string]interface{}
-				if json.Unmarshal([]byte(tc.Arguments), &argsMap) == nil {
-					if t, ok := argsMap["TargetFile"].(string); ok {
-						targetFiles = append(targetFiles, t)
-					} else if t, ok := argsMap["path"].(string); ok {
-						targetFiles = append(targetFiles, t)
-					} else if t, ok := argsMap["AbsolutePath"].(string); ok {
-						targetFiles = append(targetFiles, t)
-					}
-				}
-
-				cpID, cpErr := c.checkpointManager.Save(fmt.Sprintf("Auto: After %s", tc.Name), targetFiles)
-				if cpErr == nil && cpID != "" {
-					assistantMsg.CheckpointHash = cpID
-					log.Printf("📸 Auto-Checkpoint saved: %s (after %s)", cpID[:8], tc.Name)
+			// Auto-checkpoint after write operations, into the same shadow history
+			// the tools, IDE and TUI restore from
+			if !isError && c.safeguard != nil && isWriteTool(tc.Name) {
+				cpHash, cpErr := c.safeguard.CreateCheckpoint(fmt.Sprintf("Auto: After %s", tc.Name))
+				if cpErr == nil && cpHash != "" {
+					assistantMsg.CheckpointHash = cpHash
+					log.Printf("📸 Auto-Checkpoint saved: %s (after %s)", cpHash[:8], tc.Name)
 				}
 			}
 
@@ -2158,6 +2605,27 @@ func (c *Controller) deriveActivity(name string, arguments string, result string
 			activity.Type = "edit"
 			activity.File = path
 		}
+	case "apply_diff":
+		diff, _ := argsMap["diff"].(string)
+		activity.Type = "edit"
+		activity.File, _ = argsMap["path"].(string)
+		activity.Additions, activity.Deletions = tools.DiffStats(diff)
+	case "edit_files":
+		if edits, ok := argsMap["edits"].([]interface{}); ok {
+			var paths []string
+			for _, ed := range edits {
+				if m, ok := ed.(map[string]interface{}); ok {
+					if p, ok := m["path"].(string); ok && !slices.Contains(paths, p) {
+						paths = append(paths, p)
+					}
+				}
+			}
+			activity.Type = "edit"
+			activity.File = strings.Join(paths, ", ")
+		}
+	case "rename_symbol":
+		activity.Type = "edit"
+		activity.File, _ = argsMap["path"].(string)
 	case "search_files", "grep_search", "find_by_name":
 		if query, ok := argsMap["query"].(string); ok {
 			activity.Type = "search"
@@ -2171,6 +2639,10 @@ func (c *Controller) deriveActivity(name string, arguments string, result string
 			activity.Type = "search"
 			activity.Query = pattern
 			activity.Results = strings.Count(result, "\n")
+		} else if pattern, ok := argsMap["pattern"].(string); ok {
+			activity.Type = "search"
+			activity.Query = pattern
+			activity.Results = strings.Count(result, "\n")
 		}
 	case "execute_command", "run_command":
 		activity.Type = "command"
@@ -2228,26 +2700,24 @@ func (a *condenseAdapter) Summarize(ctx context.Context, prompt string) (string,
 // isWriteTool returns true if the tool modifies workspace files
 func isWriteTool(name string) bool {
 	writingTools := map[string]bool{
-		"write_to_file":     true,
-		"write_file":        true,
-		"execute_command":   true,
-		"apply_diff":        true,
-		"delete_file":       true,
-		"create_directory":  true,
-		"run_command":       true,
-		"replace_in_file":   true,
-		"insert_code_block": true,
+		"write_to_file":       true,
+		"write_file":          true,
+		"execute_command":     true,
+		"execute_bash_script": true,
+		"execute_node":        true,
+		"apply_diff":          true,
+		"edit_files":          true,
+		"delete_file":         true,
+		"move_file":           true,
+		"create_directory":    true,
+		"rename_symbol":       true,
+		"run_command":         true,
+		"replace_in_file":     true,
+		"insert_code_block":   true,
 	}
 	return writingTools[name]
 }
 
-// SetCheckpointManager sets the checkpoint manager for auto-saving
-func (c *Controller) SetCheckpointManager(mgr *CheckpointManager) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.checkpointManager = mgr
-}
-
 // normalizeModeName converts mode names to match ephemeral message expectations
 // Maps various mode names to: "planning", "execution", "verification", or "code"
 func normalizeModeName(modeName string) string {
@@ -2303,14 +2773,82 @@ func (c *Controller) formatToolCall(tc ToolCallInfo) string {
 		if path, ok := getStr("TargetFile", "path", "file"); ok {
 			return fmt.Sprintf("Edit file **%s**", path)
 		}
+	case "apply_diff":
+		if path, ok := getStr("path"); ok {
+			return fmt.Sprintf("Apply diff to **%s**", path)
+		}
+		return "Apply diff"
+	case "edit_files":
+		if edits, ok := args["edits"].([]interface{}); ok {
+			return fmt.Sprintf("Edit %d location(s) in one transaction", len(edits))
+		}
 	case "execute_command", "run_command":
 		if cmd, ok := getStr("command", "CommandLine", "cmd"); ok {
 			return fmt.Sprintf("Run command: `%s`", cmd)
 		}
+	case "execute_node", "execute_bash_script":
+		lang := "bash"
+		if tc.Name == "execute_node" {
+			lang = "Node.js"
+		}
+		profile, ok := getStr("profile")
+		if !ok {
+			profile = "default"
+		}
+		return fmt.Sprintf("Run %s script (sandbox: %s)", lang, profile)
+	case "sql_query", "sql_execute":
+		if q, ok := getStr("query", "statements"); ok {
+			if db, ok := getStr("database"); ok {
+				return fmt.Sprintf("SQL on **%s**: `%s`", db, q)
+			}
+			return fmt.Sprintf("SQL: `%s`", q)
+		}
+	case "http_request":
+		if u, ok := getStr("url"); ok {
+			method, _ := getStr("method")
+			if method == "" {
+				method = "GET"
+			}
+			return fmt.Sprintf("HTTP %s `%s`", strings.ToUpper(method), u)
+		}
 	case "grep_search":
 		if q, ok := getStr("Query", "query", "pattern"); ok {
 			return fmt.Sprintf("Search for \"%s\"", q)
 		}
+	case "delete_file":
+		if path, ok := getStr("path"); ok {
+			return fmt.Sprintf("Delete **%s**", path)
+		}
+	case "move_file":
+		src, _ := getStr("source")
+		dst, _ := getStr("destination")
+		return fmt.Sprintf("Move **%s** to **%s**", src, dst)
+	case "create_directory":
+		if path, ok := getStr("path"); ok {
+			return fmt.Sprintf("Create directory **%s**", path)
+		}
+	case "get_references", "hover_docs", "rename_symbol":
+		target, _ := getStr("symbol")
+		if target == "" {
+			path, _ := getStr("path")
+			target = fmt.Sprintf("%s:%v", path, args["line"])
+		}
+		switch tc.Name {
+		case "get_references":
+			return fmt.Sprintf("Find references to **%s**", target)
+		case "hover_docs":
+			return fmt.Sprintf("Look up docs for **%s**", target)
+		}
+		newName, _ := getStr("new_name")
+		return fmt.Sprintf("Rename **%s** to **%s**", target, newName)
+	case "find_by_name":
+		if p, ok := getStr("pattern", "Pattern"); ok {
+			return fmt.Sprintf("Find files matching `%s`", p)
+		}
+	case "web_fetch":
+		if u, ok := getStr("url"); ok {
+			return fmt.Sprintf("Fetch `%s`", u)
+		}
 	case "codebase_search":
 		if q, ok := getStr("Query", "query"); ok {
 			return fmt.Sprintf("Semantic search: \"%s\"", q)
@@ -2319,7 +2857,7 @@ func (c *Controller) formatToolCall(tc ToolCallInfo) string {
 		// Return empty string to HIDE this from the visual tree
 		// The TUI listens to the actual event, so we don't need a tree node for the tool call itself
 		return ""
-	case "update_todos", "get_context_stats":
+	case "update_todos", "get_context_stats", "pin_context":
 		return ""
 	}
 
@@ -2412,21 +2950,48 @@ func (c *Controller) GetProvidersManager() *config.ProvidersManager {
 	return c.providersManager
 }
 
-// --- Checkpoint Management (Phase 18) ---
+// --- Checkpoint Management ---
+
+// errNoCheckpoints is returned when the safeguard manager failed to start
+var errNoCheckpoints = fmt.Errorf("checkpoints are not available")
+
+// SaveCheckpoint snapshots the whole workspace and returns its hash
+func (c *Controller) SaveCheckpoint(message string) (string, error) {
+	if c.safeguard == nil {
+		return "", errNoCheckpoints
+	}
+	if message == "" {
+		message = "Manual checkpoint"
+	}
+	return c.safeguard.CreateCheckpoint(message)
+}
 
-// SaveCheckpoint creates a manual snapshot of current workspace files
-func (c *Controller) SaveCheckpoint(name string, files []string) (string, error) {
-	return c.checkpointManager.Save(name, files)
+// ListCheckpoints returns up to n workspace checkpoints, newest first
+func (c *Controller) ListCheckpoints(n int) ([]checkpoint.CommitInfo, error) {
+	if c.safeguard == nil {
+		return nil, errNoCheckpoints
+	}
+	return c.safeguard.ListCheckpoints(n)
 }
 
-// ListCheckpoints returns all project snapshots
-func (c *Controller) ListCheckpoints() ([]Checkpoint, error) {
-	return c.checkpointManager.List()
+// RestoreCheckpoint reverts the workspace to a checkpoint. The current state
+// is checkpointed first, so the restore itself can be undone.
+func (c *Controller) RestoreCheckpoint(hash string) error {
+	if c.safeguard == nil {
+		return errNoCheckpoints
+	}
+	if _, err := c.safeguard.CreateCheckpoint(fmt.Sprintf("Before restore to %.8s", hash)); err != nil {
+		return fmt.Errorf("failed to checkpoint before restoring: %w", err)
+	}
+	return c.safeguard.RestoreCheckpoint(hash)
 }
 
-// RestoreCheckpoint reverts project to a specific state
-func (c *Controller) RestoreCheckpoint(idOrName string) error {
-	return c.checkpointManager.Restore(idOrName)
+// PruneCheckpoints applies the checkpoint retention policy now
+func (c *Controller) PruneCheckpoints() (checkpoint.PruneResult, error) {
+	if c.safeguard == nil {
+		return checkpoint.PruneResult{}, errNoCheckpoints
+	}
+	return c.safeguard.PruneCheckpoints()
 }
 
 // isToolAutoApproved checks if a tool call can proceed without manual confirmation.
@@ -2440,6 +3005,11 @@ func (c *Controller) isToolAutoApproved(tc ToolCallInfo, planMode bool) bool {
 		return true
 	}
 
+	// ─── STORED RULES: "Yes, and don't ask again" ───
+	if c.permissionAllows(tc) {
+		return true
+	}
+
 	// ─── READ TOOLS: ALWAYS ALLOW (Silent) ───
 	// Read-only operations should NEVER interrupt the user's flow.
 	// This is unconditional - reading files is always safe.
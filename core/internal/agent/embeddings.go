@@ -0,0 +1,185 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
+	"github.com/igoryan-dao/ricochet/internal/index"
+)
+
+// Embedding providers. Chat providers with an embeddings API embed directly;
+// Voyage, Jina, MiniLM and the built-in hasher are embedding-only.
+
+// embeddingCapable lists the chat providers whose Embed works
+var embeddingCapable = map[string]bool{
+	"openai":               true,
+	"azure":                true,
+	"azure-openai":         true,
+	"bedrock":              true,
+	"gemini":               true,
+	"mistral":              true,
+	config.LocalProviderID: true,
+	"ollama":               true,
+	"lmstudio":             true,
+}
+
+// EmbeddingOnlyProviders are valid for embedding_provider but cannot chat
+var EmbeddingOnlyProviders = []string{"voyage", "jina", "minilm", "builtin"}
+
+const (
+	voyageEmbedURL     = "https://api.voyageai.com/v1/embeddings"
+	voyageDefaultModel = "voyage-code-3"
+	jinaEmbedURL       = "https://api.jina.ai/v1/embeddings"
+	jinaDefaultModel   = "jina-embeddings-v2-base-code"
+	miniLMModel        = "all-minilm" // all-MiniLM-L6-v2 (384 dimensions) as packaged by Ollama
+)
+
+// NewEmbedder creates the embedder for an embedding_provider setting
+func NewEmbedder(cfg ProviderConfig) (index.Embedder, error) {
+	var e index.Embedder
+	switch strings.ToLower(cfg.Provider) {
+	case "voyage":
+		key := orDefault(cfg.APIKey, os.Getenv("VOYAGE_API_KEY"))
+		e = newHTTPEmbedder("voyage", voyageEmbedURL, key, orDefault(cfg.Model, voyageDefaultModel), map[string]interface{}{"input_type": "document"})
+	case "jina":
+		key := orDefault(cfg.APIKey, os.Getenv("JINA_API_KEY"))
+		e = newHTTPEmbedder("jina", jinaEmbedURL, key, orDefault(cfg.Model, jinaDefaultModel), nil)
+	case "onnx":
+		// In-process ONNX would mean shipping the onnxruntime shared library
+		// for every platform next to the binary, plus the model files
+		return nil, fmt.Errorf("onnx embeddings are not built in; use minilm to run all-MiniLM-L6-v2 through Ollama or LM Studio, or builtin offline")
+	case "minilm":
+		p := NewLocalProvider(cfg.APIKey, "", cfg.BaseURL).(*OpenAIProvider)
+		p.embedModel = orDefault(cfg.Model, miniLMModel)
+		e = p
+	case "builtin", "hash":
+		return index.NewHashEmbedder(), nil
+	default:
+		if !embeddingCapable[strings.ToLower(cfg.Provider)] {
+			return nil, fmt.Errorf("%s has no embeddings API; use openai, gemini, mistral, bedrock, azure, local, %s",
+				cfg.Provider, strings.Join(EmbeddingOnlyProviders, ", "))
+		}
+		p, err := NewProvider(cfg)
+		if err != nil {
+			return nil, err
+		}
+		// For an embedding provider, Model names the embedding model
+		if op, ok := unwrapProvider(p).(*OpenAIProvider); ok && cfg.Model != "" {
+			op.embedModel = cfg.Model
+		}
+		return p, nil
+	}
+
+	if s, ok := e.(httpClientSetter); ok {
+		s.setHTTPClient(httpclient.ForProvider(cfg.Provider, defaultProviderTimeout))
+	}
+	return e, nil
+}
+
+// selectEmbedder returns the configured embedding provider, else the main
+// provider if it can embed, else the built-in offline embedder
+func selectEmbedder(main Provider, mainCfg ProviderConfig, embCfg *ProviderConfig) index.Embedder {
+	if embCfg != nil && embCfg.Provider != "" {
+		e, err := NewEmbedder(*embCfg)
+		if err == nil {
+			log.Printf("Using separate embedding provider: %s", embCfg.Provider)
+			return e
+		}
+		log.Printf("Warning: Failed to create embedding provider: %v", err)
+	}
+	if main != nil && embeddingCapable[strings.ToLower(mainCfg.Provider)] {
+		return main
+	}
+	log.Printf("Provider %q has no embeddings API; codebase search uses the built-in offline embedder. Set embedding_provider for better results.", mainCfg.Provider)
+	return index.NewHashEmbedder()
+}
+
+// httpEmbedder calls an OpenAI-style /embeddings endpoint (Voyage, Jina)
+type httpEmbedder struct {
+	name   string
+	url    string
+	apiKey string
+	model  string
+	extra  map[string]interface{} // Additional request fields
+	client *http.Client
+}
+
+func newHTTPEmbedder(name, url, apiKey, model string, extra map[string]interface{}) *httpEmbedder {
+	return &httpEmbedder{name: name, url: url, apiKey: apiKey, model: model, extra: extra}
+}
+
+func (e *httpEmbedder) setHTTPClient(client *http.Client) {
+	e.client = client
+}
+
+func (e *httpEmbedder) EmbeddingModel() string {
+	return e.name + "/" + e.model
+}
+
+func (e *httpEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	if e.apiKey == "" {
+		return nil, fmt.Errorf("%s embeddings need an API key", e.name)
+	}
+
+	req := map[string]interface{}{"model": e.model, "input": texts}
+	for k, v := range e.extra {
+		req[k] = v
+	}
+	body, _ := json.Marshal(req)
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + e.apiKey,
+	}
+
+	resp, err := doRequest(ctx, e.client, "POST", e.url, headers, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s embed error %d: %s", e.name, resp.StatusCode, string(respBody))
+	}
+
+	var embedResp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, err
+	}
+	if len(embedResp.Data) != len(texts) {
+		return nil, fmt.Errorf("%s returned %d embeddings for %d inputs", e.name, len(embedResp.Data), len(texts))
+	}
+
+	result := make([][]float32, len(texts))
+	for _, d := range embedResp.Data {
+		if d.Index < 0 || d.Index >= len(result) {
+			return nil, fmt.Errorf("%s returned embedding index %d out of range", e.name, d.Index)
+		}
+		result[d.Index] = d.Embedding
+	}
+	return result, nil
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/igoryan-dao/ricochet/internal/host"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+	"github.com/igoryan-dao/ricochet/internal/tools"
+)
+
+// EmergencyReport is the kill switch state and what engaging it halted
+type EmergencyReport struct {
+	safeguard.EmergencyState
+	AbortedTurns    int      `json:"aborted_turns"`
+	KilledJobs      []string `json:"killed_jobs,omitempty"`
+	ClosedTerminals int      `json:"closed_terminals"`
+}
+
+// trackTurn registers a running turn's cancel func; the caller holds abortMu
+func (c *Controller) trackTurn(cancel context.CancelFunc) uint64 {
+	if c.turns == nil {
+		c.turns = make(map[uint64]context.CancelFunc)
+	}
+	c.nextTurn++
+	c.turns[c.nextTurn] = cancel
+	return c.nextTurn
+}
+
+// abortAllTurns cancels every running turn, returning how many there were
+func (c *Controller) abortAllTurns() int {
+	c.abortMu.Lock()
+	defer c.abortMu.Unlock()
+	n := len(c.turns)
+	for _, cancel := range c.turns {
+		cancel()
+	}
+	return n
+}
+
+// EmergencyStop pulls the kill switch: writes and execution are disabled
+// everywhere until ResumeFromEmergencyStop, every running turn is aborted,
+// and background jobs and interactive terminals are killed. by names the
+// channel that asked, for the record.
+func (c *Controller) EmergencyStop(by string) EmergencyReport {
+	report := EmergencyReport{EmergencyState: safeguard.EngageEmergencyStop(by)}
+	report.AbortedTurns = c.abortAllTurns()
+
+	if jm, ok := c.host.(host.JobManager); ok {
+		for _, job := range jm.ListJobs() {
+			if job.Status != host.StatusRunning {
+				continue
+			}
+			if _, err := jm.KillJob(job.ID); err != nil {
+				log.Printf("[Agent] Emergency stop: failed to kill job %s: %v", job.ID, err)
+				continue
+			}
+			report.KilledJobs = append(report.KilledJobs, job.Command)
+		}
+	}
+	if ne, ok := c.executor.(*tools.NativeExecutor); ok {
+		report.ClosedTerminals = ne.CloseTerminals()
+	}
+
+	log.Printf("[Agent] 🚨 Emergency stop by %s: %d turn(s) aborted, %d job(s) killed, %d terminal(s) closed", by, report.AbortedTurns, len(report.KilledJobs), report.ClosedTerminals)
+	c.notify("Ricochet emergency stop", "Writes and commands are disabled until you resume")
+	return report
+}
+
+// ResumeFromEmergencyStop releases the kill switch, returning the state it
+// released
+func (c *Controller) ResumeFromEmergencyStop() safeguard.EmergencyState {
+	released := safeguard.ReleaseEmergencyStop()
+	if released.Active {
+		log.Printf("[Agent] Emergency stop released (engaged by %s at %s)", released.By, released.Since.Format(time.RFC3339))
+	}
+	return released
+}
+
+// EmergencyStatus reports whether the kill switch is engaged
+func (c *Controller) EmergencyStatus() safeguard.EmergencyState {
+	return safeguard.Emergency()
+}
+
+// FormatEmergencyReport describes an emergency stop for a chat or messenger
+func FormatEmergencyReport(r EmergencyReport) string {
+	var sb strings.Builder
+	sb.WriteString("🚨 **Emergency stop engaged.** Writes, commands, scripts, HTTP requests and MCP tools are disabled until you `/resume`.\n\n")
+	fmt.Fprintf(&sb, "- Turns aborted: %d\n", r.AbortedTurns)
+	fmt.Fprintf(&sb, "- Background jobs killed: %d\n", len(r.KilledJobs))
+	for _, cmd := range r.KilledJobs {
+		fmt.Fprintf(&sb, "  - `%s`\n", cmd)
+	}
+	fmt.Fprintf(&sb, "- Terminals closed: %d", r.ClosedTerminals)
+	return sb.String()
+}
+
+// FormatEmergencyResume describes the release of the kill switch
+func FormatEmergencyResume(released safeguard.EmergencyState) string {
+	if !released.Active {
+		return "✅ The emergency stop is not engaged."
+	}
+	return fmt.Sprintf("✅ **Emergency stop released.** It was engaged from %s at %s; writes and commands are enabled again.", released.By, released.Since.Format("15:04:05"))
+}
+
+// handlePanicCommand implements /panic
+func (c *Controller) handlePanicCommand(sessionID string, callback func(update interface{})) error {
+	report := c.EmergencyStop(host.Channel(c.host))
+	return c.sendReply(sessionID, FormatEmergencyReport(report), callback)
+}
+
+// handleResumeCommand implements /resume
+func (c *Controller) handleResumeCommand(sessionID string, callback func(update interface{})) error {
+	return c.sendReply(sessionID, FormatEmergencyResume(c.ResumeFromEmergencyStop()), callback)
+}
+
+// sendReply answers a slash command in the chat
+func (c *Controller) sendReply(sessionID, content string, callback func(update interface{})) error {
+	callback(ChatUpdate{
+		SessionID: sessionID,
+		Message: ChatMessage{
+			ID:        uuid.New().String(),
+			Role:      "assistant",
+			Content:   content,
+			Timestamp: time.Now().UnixMilli(),
+		},
+	})
+	return nil
+}
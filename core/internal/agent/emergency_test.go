@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+func TestEmergencyStop(t *testing.T) {
+	t.Cleanup(func() { safeguard.ReleaseEmergencyStop() })
+	c := &Controller{}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel1()
+	defer cancel2()
+	c.abortMu.Lock()
+	c.trackTurn(cancel1)
+	c.trackTurn(cancel2)
+	c.abortMu.Unlock()
+
+	report := c.EmergencyStop("ide")
+	if report.AbortedTurns != 2 || ctx1.Err() == nil || ctx2.Err() == nil {
+		t.Errorf("not every turn aborted: %+v", report)
+	}
+	if !c.EmergencyStatus().Active || report.By != "ide" {
+		t.Errorf("kill switch not engaged: %+v", report)
+	}
+	if msg := FormatEmergencyReport(report); !strings.Contains(msg, "Turns aborted: 2") {
+		t.Errorf("report: %s", msg)
+	}
+
+	if released := c.ResumeFromEmergencyStop(); !released.Active {
+		t.Error("resume released nothing")
+	}
+	if c.EmergencyStatus().Active {
+		t.Error("still engaged after resume")
+	}
+	if msg := FormatEmergencyResume(c.ResumeFromEmergencyStop()); !strings.Contains(msg, "not engaged") {
+		t.Errorf("second resume: %s", msg)
+	}
+}
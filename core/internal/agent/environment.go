@@ -0,0 +1,29 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/host"
+)
+
+// runningJobs describes the background jobs still running, for the
+// environment context
+func runningJobs(jm host.JobManager) []string {
+	var jobs []string
+	for _, job := range jm.ListJobs() {
+		if job.Status != host.StatusRunning {
+			continue
+		}
+		label := job.ID
+		if job.Name != "" {
+			label = fmt.Sprintf("%s (%s)", job.Name, job.ID)
+		}
+		command := []rune(job.Command)
+		if len(command) > 60 {
+			command = append(command[:60], '…')
+		}
+		jobs = append(jobs, fmt.Sprintf("%s `%s` for %s", label, string(command), time.Since(job.StartTime).Round(time.Second)))
+	}
+	return jobs
+}
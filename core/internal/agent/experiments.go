@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+	"github.com/igoryan-dao/ricochet/internal/safeguard/checkpoint"
+)
+
+// ExperimentState is the experiment list with the branch the workspace is on
+type ExperimentState struct {
+	Experiments []checkpoint.Experiment `json:"experiments"`
+	Current     string                  `json:"current"` // checkpoint.MainLine when on the main line
+}
+
+// ListExperiments returns the workspace experiments and which one is checked out
+func (c *Controller) ListExperiments() (ExperimentState, error) {
+	if c.safeguard == nil {
+		return ExperimentState{}, errNoCheckpoints
+	}
+	exps, err := c.safeguard.ListExperiments()
+	if err != nil {
+		return ExperimentState{}, err
+	}
+	current := c.safeguard.CurrentExperiment()
+	if current == "" {
+		current = checkpoint.MainLine
+	}
+	return ExperimentState{Experiments: exps, Current: current}, nil
+}
+
+// ForkExperiment starts a named experiment from the current workspace
+func (c *Controller) ForkExperiment(name string) (string, error) {
+	sg, unlock, err := c.idleSafeguard()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	hash, err := sg.ForkExperiment(name)
+	if err == nil {
+		log.Printf("🧪 Forked experiment %s at %.8s", name, hash)
+	}
+	return hash, err
+}
+
+// SwitchExperiment checks out an experiment, or the main line
+func (c *Controller) SwitchExperiment(name string) error {
+	sg, unlock, err := c.idleSafeguard()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return sg.SwitchExperiment(name)
+}
+
+// CompareExperiment returns the three-way diff of an experiment and the main line
+func (c *Controller) CompareExperiment(name string) (checkpoint.ExperimentDiff, error) {
+	sg, unlock, err := c.idleSafeguard()
+	if err != nil {
+		return checkpoint.ExperimentDiff{}, err
+	}
+	defer unlock()
+	return sg.CompareExperiment(name)
+}
+
+// MergeExperiment merges an experiment into the main line and deletes it
+func (c *Controller) MergeExperiment(name string) (checkpoint.MergeResult, error) {
+	sg, unlock, err := c.idleSafeguard()
+	if err != nil {
+		return checkpoint.MergeResult{}, err
+	}
+	defer unlock()
+	res, err := sg.MergeExperiment(name)
+	if err == nil {
+		log.Printf("🧪 Merged experiment %s (%d conflicts)", name, len(res.Conflicts))
+	}
+	return res, err
+}
+
+// DiscardExperiment deletes an experiment
+func (c *Controller) DiscardExperiment(name string) error {
+	sg, unlock, err := c.idleSafeguard()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return sg.DiscardExperiment(name)
+}
+
+// idleSafeguard returns the safeguard manager for an operation that swaps
+// the workspace, holding off chats until unlock. It fails while a reply is
+// running, since the agent would keep writing to the old branch's files.
+func (c *Controller) idleSafeguard() (*safeguard.Manager, func(), error) {
+	if c.safeguard == nil {
+		return nil, nil, errNoCheckpoints
+	}
+	c.abortMu.Lock()
+	if c.abortCancel != nil {
+		c.abortMu.Unlock()
+		return nil, nil, fmt.Errorf("a reply is still running; stop it before changing experiments")
+	}
+	return c.safeguard, c.abortMu.Unlock, nil
+}
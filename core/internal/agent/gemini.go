@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 
 	"github.com/igoryan-dao/ricochet/internal/protocol"
@@ -16,6 +17,7 @@ import (
 type GeminiProvider struct {
 	apiKey string
 	model  string
+	client *http.Client // nil = shared default
 }
 
 // NewGeminiProvider creates a new Gemini provider
@@ -30,6 +32,10 @@ func (p *GeminiProvider) Name() string {
 	return "gemini"
 }
 
+func (p *GeminiProvider) setHTTPClient(client *http.Client) {
+	p.client = client
+}
+
 // Gemini API structures
 type geminiRequest struct {
 	Contents         []geminiContent         `json:"contents"`
@@ -111,6 +117,11 @@ type geminiBatchEmbedResponse struct {
 	} `json:"embeddings"`
 }
 
+// EmbeddingModel identifies the embedding model for index compatibility checks
+func (p *GeminiProvider) EmbeddingModel() string {
+	return "gemini/text-embedding-004"
+}
+
 func (p *GeminiProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
 	if len(texts) == 0 {
 		return nil, nil
@@ -126,7 +137,7 @@ func (p *GeminiProvider) Embed(ctx context.Context, texts []string) ([][]float32
 		body, _ := json.Marshal(req)
 		url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/text-embedding-004:embedContent?key=%s", p.apiKey)
 
-		resp, err := doRequest(ctx, "POST", url, map[string]string{"Content-Type": "application/json"}, bytes.NewReader(body))
+		resp, err := doRequest(ctx, p.client, "POST", url, map[string]string{"Content-Type": "application/json"}, bytes.NewReader(body))
 		if err != nil {
 			return nil, err
 		}
@@ -158,7 +169,7 @@ func (p *GeminiProvider) Embed(ctx context.Context, texts []string) ([][]float32
 	body, _ := json.Marshal(batchReq)
 	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/text-embedding-004:batchEmbedContents?key=%s", p.apiKey)
 
-	resp, err := doRequest(ctx, "POST", url, map[string]string{"Content-Type": "application/json"}, bytes.NewReader(body))
+	resp, err := doRequest(ctx, p.client, "POST", url, map[string]string{"Content-Type": "application/json"}, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -242,7 +253,7 @@ func (p *GeminiProvider) ChatStream(ctx context.Context, req *ChatRequest, callb
 		return fmt.Errorf("marshal request: %w", err)
 	}
 
-	resp, err := doRequest(ctx, "POST", url, map[string]string{
+	resp, err := doRequest(ctx, p.client, "POST", url, map[string]string{
 		"Content-Type": "application/json",
 	}, bytes.NewReader(body))
 	if err != nil {
@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/context/handoff"
+)
+
+// runHandoff condenses the session into the handoff artifacts, seeds the
+// plan with its tasks and makes @SPEC, @PLAN and @OPEN_QUESTIONS resolve to
+// them. It returns a note for the switch_mode result.
+func (c *Controller) runHandoff(ctx context.Context, session *Session) (string, error) {
+	cwd, _ := os.Getwd()
+	artifacts, err := c.handoffService.GenerateArtifacts(ctx, session.StateHandler.GetMessages())
+	if err != nil {
+		return "", err
+	}
+	paths, err := c.handoffService.SaveArtifacts(cwd, artifacts)
+	if err != nil {
+		return "", err
+	}
+	c.registerArtifacts(paths)
+
+	seeded := 0
+	if c.planManager != nil && len(artifacts.Tasks) > 0 {
+		if seeded, err = seedPlan(c.planManager, artifacts.Tasks); err != nil {
+			log.Printf("Handoff: failed to seed the plan: %v", err)
+		}
+	}
+
+	names := make([]string, 0, len(paths))
+	for name := range paths {
+		names = append(names, "`@"+name+"`")
+	}
+	sort.Strings(names)
+	note := fmt.Sprintf("Context condensed into %s.", strings.Join(names, ", "))
+	if seeded > 0 {
+		note += fmt.Sprintf(" %d tasks added to the plan.", seeded)
+	}
+	if n := len(artifacts.OpenQuestions); n > 0 {
+		note += fmt.Sprintf(" %d open questions need answers, see `@%s`.", n, handoff.ArtifactOpenQuestions)
+	}
+	return note, nil
+}
+
+// registerArtifacts makes @ references to handoff artifacts resolve
+func (c *Controller) registerArtifacts(paths map[string]string) {
+	if c.injectionProcessor == nil {
+		return
+	}
+	for name, path := range paths {
+		c.injectionProcessor.Register(name, path)
+	}
+}
+
+// seedPlan adds the handoff tasks to the plan. A plan with nothing left to
+// do is replaced; otherwise tasks it already has, by title, are skipped.
+// Dependencies are mapped to the plan's task IDs.
+func seedPlan(pm *PlanManager, tasks []handoff.Task) (int, error) {
+	existing := pm.GetTasks()
+	finished := true
+	titles := make(map[string]string) // Lowercased title -> plan ID
+	for _, t := range existing {
+		if t.Status != "done" && t.Status != "completed" {
+			finished = false
+		}
+		titles[strings.ToLower(t.Title)] = t.ID
+	}
+	if finished {
+		existing, titles = nil, make(map[string]string)
+	}
+
+	ids := make(map[string]string) // Handoff ID -> plan ID
+	plan := existing
+	added := 0
+	for _, t := range tasks {
+		if id, ok := titles[strings.ToLower(t.Title)]; ok {
+			ids[t.ID] = id
+			continue
+		}
+		item := TaskItem{ID: fmt.Sprintf("%d", len(plan)+1), Title: t.Title, Status: "pending", Context: t.Description}
+		for _, dep := range t.Dependencies {
+			if id, ok := ids[dep]; ok {
+				item.Dependencies = append(item.Dependencies, id)
+			}
+		}
+		ids[t.ID] = item.ID
+		titles[strings.ToLower(t.Title)] = item.ID
+		plan = append(plan, item)
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	return added, pm.SetPlan(plan)
+}
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/context/handoff"
+)
+
+func TestSeedPlan(t *testing.T) {
+	pm := NewPlanManager(t.TempDir())
+	tasks := []handoff.Task{
+		{ID: "a", Title: "Write the parser"},
+		{ID: "b", Title: "Wire it up", Dependencies: []string{"a"}},
+	}
+	if n, err := seedPlan(pm, tasks); err != nil || n != 2 {
+		t.Fatalf("seedPlan() = %d, %v", n, err)
+	}
+	plan := pm.GetTasks()
+	if len(plan) != 2 || plan[1].ID != "2" || len(plan[1].Dependencies) != 1 || plan[1].Dependencies[0] != "1" {
+		t.Fatalf("plan = %+v", plan)
+	}
+
+	// An unfinished plan keeps its tasks and gains only new ones
+	tasks = append(tasks, handoff.Task{ID: "c", Title: "Document it", Dependencies: []string{"b"}})
+	if n, _ := seedPlan(pm, tasks); n != 1 {
+		t.Errorf("reseeding added %d tasks, want 1", n)
+	}
+	if plan = pm.GetTasks(); len(plan) != 3 || plan[2].Dependencies[0] != "2" {
+		t.Errorf("plan = %+v", plan)
+	}
+
+	// A finished plan is replaced
+	for _, task := range plan {
+		pm.UpdateTask(task.ID, "done")
+	}
+	seedPlan(pm, []handoff.Task{{ID: "1", Title: "Next thing"}})
+	if plan = pm.GetTasks(); len(plan) != 1 || plan[0].Title != "Next thing" {
+		t.Errorf("plan = %+v", plan)
+	}
+}
+
+func TestInjectionProcessor_ResolvesRegisteredArtifacts(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "SPEC.md")
+	os.WriteFile(spec, []byte("the agreed spec"), 0644)
+	p := NewInjectionProcessor(t.TempDir())
+	p.Register(handoff.ArtifactSpec, spec)
+
+	for _, input := range []string{"follow @SPEC", "see @SPEC.md", "as in @SPEC."} {
+		out, info := p.Process(input)
+		if !strings.Contains(out, "the agreed spec") || len(info) != 1 {
+			t.Errorf("Process(%q) = %q, %v", input, out, info)
+		}
+	}
+}
@@ -1,12 +1,27 @@
 package agent
 
 import (
+	"context"
+	"log"
 	"strings"
+	"sync"
+
+	"github.com/igoryan-dao/ricochet/internal/index"
+	"github.com/igoryan-dao/ricochet/internal/tools"
 )
 
 // HelpAgent manages help-related queries
 type HelpAgent struct {
 	keywords []string
+
+	mu          sync.Mutex
+	embedder    index.Embedder    // nil = keyword search only
+	docs        []index.Document  // Embedded docs plus registered tools
+	store       *index.LocalStore // Vector index over docs, built on first use
+	indexedDocs int               // len(docs) when store was built
+	embedFailed bool              // Embedder can't embed; stay on keyword search
+	lastQuery   string            // Memo: the chat loop asks again on every turn
+	lastResults []index.Document
 }
 
 // NewHelpAgent creates a new help agent
@@ -41,13 +56,87 @@ func (h *HelpAgent) IsHelpQuery(input string) bool {
 func (h *HelpAgent) GetSystemPrompt() string {
 	return `You are the Ricochet CLI Help Agent.
 Your goal is to assist users with using the Ricochet CLI tool, configuring it, and understanding its features.
-You have access to the full documentation of Ricochet.
+Answer from the Ricochet documentation excerpts below; they match the running version. If they don't cover the question, say so rather than guessing.
 
 Key Features to explain if asked:
 - **Modes**: Plan (read-only), Act (execution).
-- **Commands**: /help, /status, /init, /ether.
+- **Commands**: /help, /status, /init, /ether, /undo-run, /trust.
 - **Workflow**: Auto-approval, safe guard, tool usage.
 - **TUI**: Tab to toggle focus, up/down history.
 
 If the user asks about general coding or specific implementation similar to their project, politely inform them you are the Help Agent and switch context back to the main assistant if needed, or answer if it's about Ricochet's capabilities in that area.`
 }
+
+// SetEmbedder enables semantic search over the docs
+func (h *HelpAgent) SetEmbedder(e index.Embedder) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.embedder = e
+	h.store = nil
+	h.embedFailed = false
+	h.lastQuery = ""
+}
+
+// BuildSystemPrompt returns the help prompt with the doc sections most relevant to query
+func (h *HelpAgent) BuildSystemPrompt(ctx context.Context, query string, defs []tools.ToolDefinition) string {
+	docs := h.Search(ctx, query, defs, helpDocsLimit)
+	if len(docs) == 0 {
+		return h.GetSystemPrompt()
+	}
+
+	var sb strings.Builder
+	sb.WriteString(h.GetSystemPrompt())
+	sb.WriteString("\n\n## Ricochet documentation\n")
+	for _, d := range docs {
+		sb.WriteString("\n" + d.Content + "\n")
+	}
+	return sb.String()
+}
+
+// Search returns the doc sections (and tool descriptions) most relevant to query
+func (h *HelpAgent) Search(ctx context.Context, query string, defs []tools.ToolDefinition, limit int) []index.Document {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	docs := append(loadHelpDocs(), toolHelpDocs(defs)...)
+	if len(docs) != len(h.docs) {
+		h.docs = docs
+		h.store = nil
+	} else if query == h.lastQuery {
+		return h.lastResults
+	}
+	h.lastQuery = query
+	h.lastResults = h.search(ctx, query, limit)
+	return h.lastResults
+}
+
+func (h *HelpAgent) search(ctx context.Context, query string, limit int) []index.Document {
+
+	if h.embedder != nil && !h.embedFailed {
+		if h.store == nil || h.indexedDocs != len(h.docs) {
+			store, err := buildHelpIndex(ctx, h.embedder, h.docs)
+			if err != nil {
+				log.Printf("Help docs: embedding unavailable, using keyword search: %v", err)
+				h.embedFailed = true
+			} else {
+				h.store = store
+				h.indexedDocs = len(h.docs)
+			}
+		}
+		if h.store != nil {
+			if emb, err := h.embedder.Embed(ctx, []string{query}); err == nil && len(emb) > 0 {
+				if results, err := h.store.Search(emb[0], limit); err == nil && len(results) > 0 {
+					out := make([]index.Document, 0, len(results))
+					for _, r := range results {
+						d := *r.Document
+						d.Embedding = nil
+						out = append(out, d)
+					}
+					return out
+				}
+			}
+		}
+	}
+
+	return keywordSearch(h.docs, query, limit)
+}
@@ -1,7 +1,11 @@
 package agent
 
 import (
+	"context"
+	"strings"
 	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/tools"
 )
 
 func TestIsHelpQuery(t *testing.T) {
@@ -26,3 +30,29 @@ func TestIsHelpQuery(t *testing.T) {
 		}
 	}
 }
+
+func TestHelpAgent_SearchDocs(t *testing.T) {
+	agent := NewHelpAgent()
+	defs := []tools.ToolDefinition{{Name: "codebase_search", Description: "Semantic search over the indexed codebase"}}
+
+	docs := agent.Search(context.Background(), "How do I undo the last agent run?", defs, 3)
+	if len(docs) == 0 || !strings.Contains(docs[0].Content, "/undo-run") {
+		t.Fatalf("expected undo-run docs first, got %+v", docs)
+	}
+
+	docs = agent.Search(context.Background(), "what does codebase_search do", defs, 3)
+	found := false
+	for _, d := range docs {
+		if d.ID == "help:tool:codebase_search" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected registered tool in results, got %+v", docs)
+	}
+
+	prompt := agent.BuildSystemPrompt(context.Background(), "how to set a proxy", nil)
+	if !strings.Contains(prompt, "network.proxy_url") {
+		t.Errorf("expected proxy docs in help prompt")
+	}
+}
@@ -0,0 +1,188 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/index"
+	"github.com/igoryan-dao/ricochet/internal/paths"
+	"github.com/igoryan-dao/ricochet/internal/tools"
+)
+
+// helpDocsFS ships Ricochet's own documentation with the binary, so help
+// answers always match the running version.
+//
+//go:embed helpdocs/*.md
+var helpDocsFS embed.FS
+
+// helpDocsLimit is how many doc sections are added to a help prompt
+const helpDocsLimit = 5
+
+// loadHelpDocs splits the embedded docs into one document per "## " section
+func loadHelpDocs() []index.Document {
+	entries, err := helpDocsFS.ReadDir("helpdocs")
+	if err != nil {
+		return nil
+	}
+
+	var docs []index.Document
+	for _, e := range entries {
+		data, err := helpDocsFS.ReadFile(path.Join("helpdocs", e.Name()))
+		if err != nil {
+			continue
+		}
+
+		page := strings.TrimSuffix(e.Name(), ".md")
+		var title string
+		var body strings.Builder
+		flush := func() {
+			if title != "" && strings.TrimSpace(body.String()) != "" {
+				docs = append(docs, index.Document{
+					ID:       fmt.Sprintf("help:%s#%d", page, len(docs)),
+					FilePath: e.Name(),
+					Content:  "### " + title + "\n" + strings.TrimSpace(body.String()),
+					Metadata: map[string]interface{}{"title": title},
+				})
+			}
+			body.Reset()
+		}
+
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, "## ") {
+				flush()
+				title = strings.TrimPrefix(line, "## ")
+				continue
+			}
+			if strings.HasPrefix(line, "# ") {
+				continue
+			}
+			body.WriteString(line + "\n")
+		}
+		flush()
+	}
+	return docs
+}
+
+// toolHelpDocs documents the tools registered in this build
+func toolHelpDocs(defs []tools.ToolDefinition) []index.Document {
+	docs := make([]index.Document, 0, len(defs))
+	for _, d := range defs {
+		docs = append(docs, index.Document{
+			ID:       "help:tool:" + d.Name,
+			FilePath: "tools",
+			Content:  fmt.Sprintf("### Tool `%s`\n%s", d.Name, d.Description),
+			Metadata: map[string]interface{}{"title": "Tool " + d.Name},
+		})
+	}
+	return docs
+}
+
+// buildHelpIndex embeds docs into a vector store cached under ~/.ricochet,
+// keyed by the docs content so a new version re-embeds automatically.
+func buildHelpIndex(ctx context.Context, embedder index.Embedder, docs []index.Document) (*index.LocalStore, error) {
+	hash := sha256.New()
+	hash.Write([]byte(embedderName(embedder)))
+	for _, d := range docs {
+		hash.Write([]byte(d.ID + d.Content))
+	}
+	cachePath := filepath.Join(paths.GetGlobalDir(), "help", hex.EncodeToString(hash.Sum(nil))[:16]+".vdb")
+
+	store, err := index.NewLocalStore(cachePath)
+	if err != nil {
+		// Corrupt cache: rebuild it
+		os.Remove(cachePath)
+		if store, err = index.NewLocalStore(cachePath); err != nil {
+			return nil, err
+		}
+	}
+	if store.Count() == len(docs) {
+		return store, nil
+	}
+	store.Clear()
+
+	texts := make([]string, len(docs))
+	for i, d := range docs {
+		texts[i] = d.Content
+	}
+	embeddings, err := embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != len(docs) {
+		return nil, fmt.Errorf("embedder returned %d vectors for %d docs", len(embeddings), len(docs))
+	}
+
+	withEmb := make([]index.Document, len(docs))
+	for i, d := range docs {
+		d.Embedding = embeddings[i]
+		withEmb[i] = d
+	}
+	store.Add(withEmb)
+	if err := store.Save(); err != nil {
+		log.Printf("Warning: failed to cache help index: %v", err)
+	}
+	return store, nil
+}
+
+// keywordSearch ranks docs by query term overlap; used when no embedder is available
+func keywordSearch(docs []index.Document, query string, limit int) []index.Document {
+	var terms []string
+	for _, t := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !(r == '/' || r == '_' || r == '-' || r == '.' || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'))
+	}) {
+		if len(t) > 2 && !helpStopWords[t] {
+			terms = append(terms, t)
+		}
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+
+	type scored struct {
+		doc   index.Document
+		score int
+	}
+	var ranked []scored
+	for _, d := range docs {
+		content := strings.ToLower(d.Content)
+		title := strings.ToLower(fmt.Sprint(d.Metadata["title"]))
+		score := 0
+		for _, t := range terms {
+			score += strings.Count(content, t)
+			if strings.Contains(title, t) {
+				score += 3
+			}
+		}
+		if score > 0 {
+			ranked = append(ranked, scored{d, score})
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+
+	var out []index.Document
+	for i := 0; i < len(ranked) && i < limit; i++ {
+		out = append(out, ranked[i].doc)
+	}
+	return out
+}
+
+var helpStopWords = map[string]bool{
+	"how": true, "the": true, "what": true, "does": true, "can": true, "and": true,
+	"for": true, "with": true, "ricochet": true, "use": true, "this": true, "that": true,
+}
+
+func embedderName(e index.Embedder) string {
+	if p, ok := e.(Provider); ok {
+		return p.Name()
+	}
+	return fmt.Sprintf("%T", e)
+}
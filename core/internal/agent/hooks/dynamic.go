@@ -131,7 +131,7 @@ func (m *DynamicHookManager) CheckPreToolUse(toolName string, args map[string]in
 		if hook.Event == "bash" && toolName != "execute_command" {
 			continue
 		}
-		if hook.Event == "file" && toolName != "replace_file_content" && toolName != "write_to_file" && toolName != "edit_file" {
+		if hook.Event == "file" && toolName != "replace_file_content" && toolName != "write_to_file" && toolName != "edit_file" && toolName != "apply_diff" {
 			continue
 		}
 
@@ -195,6 +195,9 @@ func getFieldVal(_ string, args map[string]interface{}, field string) string {
 		if v, ok := args["AbsolutePath"].(string); ok {
 			return v
 		}
+		if v, ok := args["path"].(string); ok {
+			return v
+		}
 	}
 	return ""
 }
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+)
+
+// imageModel routes the image tools to the active provider when its model
+// can handle images, and otherwise to another configured provider that can
+type imageModel struct {
+	c *Controller
+}
+
+func (m imageModel) active() (Provider, ProviderConfig) {
+	m.c.mu.RLock()
+	defer m.c.mu.RUnlock()
+	return m.c.provider, m.c.config.Provider
+}
+
+func (m imageModel) AnalyzeImage(ctx context.Context, prompt string, image []byte, mimeType string) (string, error) {
+	p, cfg := m.active()
+	pm := m.c.providersManager
+	if v, ok := AsVisionProvider(p); ok && (pm == nil || pm.SupportsVision(cfg.Provider, cfg.Model)) {
+		return v.AnalyzeImage(ctx, prompt, image, mimeType)
+	}
+	if pm == nil {
+		return "", fmt.Errorf("%s cannot analyze images", p.Name())
+	}
+	providerID, modelID, ok := pm.VisionModel()
+	if !ok {
+		return "", fmt.Errorf("no vision model available: %s/%s does not accept images and no other provider with a key has a model marked supports_vision", cfg.Provider, cfg.Model)
+	}
+	fallback, err := m.provider(providerID, modelID, cfg, pm)
+	if err != nil {
+		return "", err
+	}
+	v, ok := AsVisionProvider(fallback)
+	if !ok {
+		return "", fmt.Errorf("%s cannot analyze images", providerID)
+	}
+	return v.AnalyzeImage(ctx, prompt, image, mimeType)
+}
+
+func (m imageModel) GenerateImage(ctx context.Context, prompt, size string) ([]byte, error) {
+	p, cfg := m.active()
+	// Other OpenAI-compatible APIs share the provider type but not the images endpoint
+	if g, ok := AsImageGenerator(p); ok && cfg.Provider == "openai" {
+		return g.GenerateImage(ctx, prompt, size)
+	}
+	pm := m.c.providersManager
+	if pm == nil || pm.GetAPIKey("openai") == "" {
+		return nil, fmt.Errorf("image generation needs an OpenAI API key")
+	}
+	openai, err := m.provider("openai", "", cfg, pm)
+	if err != nil {
+		return nil, err
+	}
+	g, _ := AsImageGenerator(openai)
+	return g.GenerateImage(ctx, prompt, size)
+}
+
+// provider creates a provider other than the active one, keeping its routing
+func (m imageModel) provider(providerID, modelID string, active ProviderConfig, pm *config.ProvidersManager) (Provider, error) {
+	p, err := NewProvider(withProviderDefaults(ProviderConfig{
+		Provider: providerID,
+		Model:    modelID,
+		APIKey:   pm.GetAPIKey(providerID),
+		Routing:  active.Routing,
+	}, pm))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize %s: %w", providerID, err)
+	}
+	return p, nil
+}
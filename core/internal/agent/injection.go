@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+// untrustedSource names where a tool's result comes from when its content
+// is written by someone other than the user: web pages, MCP servers and
+// files. Results of other tools are not screened.
+func (c *Controller) untrustedSource(tool string) string {
+	switch tool {
+	case "http_request", "web_fetch", "browser_open", "browser_snapshot", "browser_logs":
+		return safeguard.SourceWeb
+	case "read_file":
+		return safeguard.SourceFile
+	case "read_mcp_resource":
+		return safeguard.SourceMCP
+	}
+	if c.mcpHub != nil {
+		if _, _, ok := c.mcpHub.ResolveTool(tool); ok {
+			return safeguard.SourceMCP
+		}
+	}
+	return ""
+}
+
+// contentLabel says which page, file or tool a result came from
+func contentLabel(tc ToolCallInfo) string {
+	var args struct {
+		URL  string `json:"url"`
+		Path string `json:"path"`
+		URI  string `json:"uri"`
+	}
+	_ = json.Unmarshal([]byte(tc.Arguments), &args)
+	for _, target := range []string{args.URL, args.Path, args.URI} {
+		if target != "" {
+			return tc.Name + " " + target
+		}
+	}
+	return tc.Name
+}
+
+// screenToolResult quarantines a result from an untrusted source that looks
+// like a prompt injection, and reports the quarantine to the user
+func (c *Controller) screenToolResult(sessionID string, tc ToolCallInfo, result string, callback func(update interface{})) string {
+	source := c.untrustedSource(tc.Name)
+	if source == "" {
+		return result
+	}
+	label := contentLabel(tc)
+	screened, signs := c.safeguard.ScreenContent(source, label, result)
+	if len(signs) == 0 {
+		return result
+	}
+	log.Printf("[Agent] Quarantined %s result of %s: possible prompt injection (%s)", source, label, strings.Join(signs, ", "))
+	if callback != nil {
+		callback(ChatUpdate{
+			SessionID: sessionID,
+			Message: ChatMessage{
+				ID:        uuid.New().String(),
+				Role:      "system",
+				Content:   fmt.Sprintf("🛡️ **Possible prompt injection** in the result of `%s` (%s). It was passed to the model quarantined, marked as data not to obey. Check what the agent does next.", label, strings.Join(signs, ", ")),
+				Timestamp: time.Now().UnixMilli(),
+			},
+		})
+	}
+	return screened
+}
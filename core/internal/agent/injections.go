@@ -6,16 +6,42 @@ import (
 	"os/exec"
 	"regexp"
 	"strings"
+	"sync"
 )
 
 // InjectionProcessor handles @file and !cmd expansions
 type InjectionProcessor struct {
 	cwd string
+
+	mu      sync.RWMutex
+	aliases map[string]string // @ names resolving to files elsewhere, e.g. SPEC
 }
 
 // NewInjectionProcessor creates a new processor
 func NewInjectionProcessor(cwd string) *InjectionProcessor {
-	return &InjectionProcessor{cwd: cwd}
+	return &InjectionProcessor{cwd: cwd, aliases: make(map[string]string)}
+}
+
+// Register makes @name and @name.md resolve to path, unless a workspace
+// file of that name exists
+func (p *InjectionProcessor) Register(name, path string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.aliases[name] = path
+	p.aliases[name+".md"] = path
+}
+
+// resolve returns the file an @ reference points to
+func (p *InjectionProcessor) resolve(ref string) string {
+	if _, err := os.Stat(ref); err == nil {
+		return ref
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if path, ok := p.aliases[strings.TrimRight(ref, ".")]; ok { // "see @SPEC."
+		return path
+	}
+	return ref
 }
 
 // Process expands injections in the input text
@@ -33,7 +59,7 @@ func (p *InjectionProcessor) Process(input string) (string, []string) {
 			continue
 		}
 		path := match[1]
-		content, err := os.ReadFile(path)
+		content, err := os.ReadFile(p.resolve(path))
 		if err != nil {
 			infoMessages = append(infoMessages, fmt.Sprintf("⚠️ Could not read file @%s: %v", path, err))
 			continue
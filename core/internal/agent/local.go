@@ -0,0 +1,26 @@
+package agent
+
+import (
+	"os"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+)
+
+// Local provider - Ollama or LM Studio through their OpenAI-compatible /v1 API
+
+// NewLocalProvider creates a provider for a local model server. baseURL is the
+// server root (default http://localhost:11434); no API key is required.
+func NewLocalProvider(apiKey, model, baseURL string) Provider {
+	if apiKey == "" {
+		apiKey = "local" // Ignored by Ollama/LM Studio, but some builds reject an empty bearer
+	}
+	embedModel := os.Getenv("LOCAL_EMBEDDING_MODEL")
+	if embedModel == "" {
+		embedModel = "nomic-embed-text"
+	}
+
+	p := NewOpenAIProvider(apiKey, model, config.LocalBaseURL(baseURL)+"/v1", "", "")
+	p.name = config.LocalProviderID
+	p.embedModel = embedModel
+	return p
+}
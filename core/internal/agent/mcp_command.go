@@ -0,0 +1,383 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	mcpHubPkg "github.com/igoryan-dao/ricochet/internal/mcp"
+	"github.com/igoryan-dao/ricochet/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxPromptResources caps the resources listed in the system prompt
+const maxPromptResources = 50
+
+// mcpLoginTimeout bounds how long /mcp login waits for the browser
+const mcpLoginTimeout = 5 * time.Minute
+
+const mcpUsage = "**Usage**:\n" +
+	"- `/mcp list`: configured servers and their status\n" +
+	"- `/mcp registry`: servers available to install\n" +
+	"- `/mcp add <name> [KEY=value ...] [args ...]`: install a server from the registry\n" +
+	"- `/mcp remove <name>`: remove a server\n" +
+	"- `/mcp login <name>`: authorize a server that uses OAuth\n" +
+	"- `/mcp logout <name>`: forget a server's OAuth tokens\n" +
+	"- `/mcp resources`: resources the agent can read\n" +
+	"- `/mcp prompts`: server prompts, run as `/mcp__<server>__<prompt> [args]`"
+
+// handleMcpCommand implements `/mcp list|registry|add|remove|login|logout`
+func (c *Controller) handleMcpCommand(ctx context.Context, sessionID, args string, callback func(update interface{})) error {
+	reply := func(content string) {
+		callback(ChatUpdate{
+			SessionID: sessionID,
+			Message: ChatMessage{
+				ID:        uuid.New().String(),
+				Role:      "assistant",
+				Content:   content,
+				Timestamp: time.Now().UnixMilli(),
+			},
+		})
+	}
+
+	if c.mcpManager == nil {
+		reply("❌ MCP management is not available.")
+		return nil
+	}
+
+	parts := strings.Fields(args)
+	if len(parts) == 0 {
+		reply(mcpUsage)
+		return nil
+	}
+
+	switch parts[0] {
+	case "list":
+		reply(c.formatMcpServers())
+
+	case "registry":
+		items, err := mcpHubPkg.LoadRegistry(c.mcpManager.ConfigDir())
+		if err != nil {
+			reply(fmt.Sprintf("❌ Failed to load the MCP registry: %v", err))
+			return nil
+		}
+		var sb strings.Builder
+		sb.WriteString("**MCP Registry**\n")
+		for _, item := range items {
+			sb.WriteString(fmt.Sprintf("- **%s**: %s", item.Name, item.Description))
+			if len(item.RequiredEnv) > 0 {
+				sb.WriteString(fmt.Sprintf(" (needs `%s`)", strings.Join(item.RequiredEnv, "`, `")))
+			}
+			if item.ArgsHint != "" {
+				sb.WriteString(fmt.Sprintf(" (args: `%s`)", item.ArgsHint))
+			}
+			sb.WriteString("\n")
+		}
+		reply(sb.String())
+
+	case "add", "install":
+		if len(parts) < 2 {
+			reply(mcpUsage)
+			return nil
+		}
+		items, err := mcpHubPkg.LoadRegistry(c.mcpManager.ConfigDir())
+		if err != nil {
+			reply(fmt.Sprintf("❌ Failed to load the MCP registry: %v", err))
+			return nil
+		}
+		item, ok := mcpHubPkg.FindRegistryItem(items, parts[1])
+		if !ok {
+			reply(fmt.Sprintf("❌ `%s` is not in the registry. See `/mcp registry`.", parts[1]))
+			return nil
+		}
+		env, extra := splitEnvArgs(parts[2:])
+
+		reply(fmt.Sprintf("⏳ Installing **%s** and checking that it starts...", item.Name))
+		result, err := mcpHubPkg.Install(ctx, c.mcpManager, item, env, extra)
+		if err != nil {
+			reply(fmt.Sprintf("❌ %v", err))
+			return nil
+		}
+		if c.mcpHub != nil {
+			c.mcpHub.Reload()
+		}
+		reply(fmt.Sprintf("✅ Installed **%s** with %d tools: %s", result.Name, len(result.Tools), strings.Join(result.Tools, ", ")))
+
+	case "resources":
+		if c.mcpHub == nil || len(c.mcpHub.Resources()) == 0 {
+			reply("No MCP resources available.")
+			return nil
+		}
+		var sb strings.Builder
+		sb.WriteString("**MCP Resources**\n")
+		for _, r := range c.mcpHub.Resources() {
+			sb.WriteString(fmt.Sprintf("- `%s` (%s) %s\n", r.URI, r.Server, r.Name))
+		}
+		reply(sb.String())
+
+	case "prompts":
+		if c.mcpHub == nil || len(c.mcpHub.Prompts()) == 0 {
+			reply("No MCP prompts available.")
+			return nil
+		}
+		var sb strings.Builder
+		sb.WriteString("**MCP Prompts**\n")
+		for _, p := range c.mcpHub.Prompts() {
+			sb.WriteString(fmt.Sprintf("- `%s`%s", mcpPromptCommand(p.Server, p.Name), promptArgsUsage(p.Prompt)))
+			if p.Description != "" {
+				sb.WriteString(": " + p.Description)
+			}
+			sb.WriteString("\n")
+		}
+		reply(sb.String())
+
+	case "remove", "uninstall":
+		if len(parts) < 2 {
+			reply(mcpUsage)
+			return nil
+		}
+		if err := c.mcpManager.RemoveServer(parts[1]); err != nil {
+			reply(fmt.Sprintf("❌ %v", err))
+			return nil
+		}
+		if c.mcpHub != nil {
+			c.mcpHub.Reload()
+		}
+		reply(fmt.Sprintf("🗑️ Removed MCP server **%s**.", parts[1]))
+
+	case "login":
+		if len(parts) < 2 || c.mcpHub == nil {
+			reply(mcpUsage)
+			return nil
+		}
+		loginCtx, cancel := context.WithTimeout(ctx, mcpLoginTimeout)
+		defer cancel()
+		err := c.mcpHub.Login(loginCtx, parts[1], func(authURL string) {
+			mcpHubPkg.OpenBrowser(authURL)
+			reply(fmt.Sprintf("🔐 Authorize **%s** in your browser. If it did not open, visit:\n%s", parts[1], authURL))
+		})
+		if err != nil {
+			reply(fmt.Sprintf("❌ Login to **%s** failed: %v", parts[1], err))
+			return nil
+		}
+		reply(fmt.Sprintf("✅ Logged in to **%s**.", parts[1]))
+
+	case "logout":
+		if len(parts) < 2 || c.mcpHub == nil {
+			reply(mcpUsage)
+			return nil
+		}
+		if err := c.mcpHub.Logout(parts[1]); err != nil {
+			reply(fmt.Sprintf("❌ %v", err))
+			return nil
+		}
+		reply(fmt.Sprintf("🔓 Logged out of **%s**.", parts[1]))
+
+	default:
+		reply(mcpUsage)
+	}
+	return nil
+}
+
+// formatMcpServers lists configured servers with the hub's connection status
+func (c *Controller) formatMcpServers() string {
+	servers, err := c.mcpManager.ListServers()
+	if err != nil {
+		return fmt.Sprintf("❌ Failed to read MCP settings: %v", err)
+	}
+	if len(servers) == 0 {
+		return "No MCP servers configured. See `/mcp registry`."
+	}
+
+	status := make(map[string]mcpHubPkg.ServerStatus)
+	if c.mcpHub != nil {
+		for _, s := range c.mcpHub.Status() {
+			status[s.Name] = s
+		}
+	}
+
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString("**MCP Servers**\n")
+	for _, name := range names {
+		cfg := servers[name]
+		state := "not loaded"
+		if cfg.Disabled {
+			state = "disabled"
+		} else if s, ok := status[name]; ok {
+			switch s.State {
+			case "connected", "idle":
+				state = fmt.Sprintf("%s, %d tools", s.State, s.Tools)
+			case "error":
+				state = "error: " + s.Error
+			default:
+				state = s.State
+			}
+		}
+		target := cfg.URL
+		if !cfg.Remote() {
+			target = strings.TrimSpace(cfg.Command + " " + strings.Join(cfg.Args, " "))
+		}
+		sb.WriteString(fmt.Sprintf("- **%s** (%s): %s — `%s`\n", name, cfg.Transport(), state, target))
+		for _, conflict := range status[name].Conflicts {
+			sb.WriteString(fmt.Sprintf("  - ⚠️ not registered: %s\n", conflict))
+		}
+	}
+	return sb.String()
+}
+
+// splitEnvArgs separates KEY=value tokens from plain arguments
+func splitEnvArgs(tokens []string) (map[string]string, []string) {
+	env := make(map[string]string)
+	var args []string
+	for _, tok := range tokens {
+		key, value, ok := strings.Cut(tok, "=")
+		if ok && key != "" && key == strings.ToUpper(key) && !strings.ContainsAny(key, ":/") {
+			env[key] = value
+			continue
+		}
+		args = append(args, tok)
+	}
+	return env, args
+}
+
+// mcpResourceContext lists MCP resources for the system prompt so the agent
+// knows what it can read with read_mcp_resource
+func (c *Controller) mcpResourceContext() string {
+	if c.mcpHub == nil {
+		return ""
+	}
+	resources := c.mcpHub.Resources()
+	if len(resources) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n\n### MCP Resources\nRead these with `read_mcp_resource`:\n")
+	for i, r := range resources {
+		if i == maxPromptResources {
+			sb.WriteString(fmt.Sprintf("- ...and %d more\n", len(resources)-i))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("- `%s` (server: %s) %s", r.URI, r.Server, r.Name))
+		if r.Description != "" {
+			sb.WriteString(": " + r.Description)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// mcpPromptCommand is the slash command that runs a server's prompt
+func mcpPromptCommand(server, prompt string) string {
+	return "/mcp__" + server + "__" + prompt
+}
+
+func promptArgsUsage(p mcp.Prompt) string {
+	var sb strings.Builder
+	for _, a := range p.Arguments {
+		if a.Required {
+			sb.WriteString(fmt.Sprintf(" <%s>", a.Name))
+		} else {
+			sb.WriteString(fmt.Sprintf(" [%s]", a.Name))
+		}
+	}
+	return sb.String()
+}
+
+// expandMcpPrompt renders `/mcp__<server>__<prompt> [args]` into the message
+// sent to the model. Arguments are given as name=value, or positionally in
+// the order the prompt declares them; the last one takes the rest of the line.
+func (c *Controller) expandMcpPrompt(ctx context.Context, cmdName, args string) (string, error) {
+	if c.mcpHub == nil {
+		return "", fmt.Errorf("no MCP servers are configured")
+	}
+	server, name, ok := strings.Cut(strings.TrimPrefix(cmdName, "/mcp__"), "__")
+	if !ok {
+		return "", fmt.Errorf("expected %s", mcpPromptCommand("<server>", "<prompt>"))
+	}
+
+	var prompt *mcp.Prompt
+	for _, p := range c.mcpHub.Prompts() {
+		if p.Server == server && p.Name == name {
+			prompt = &p.Prompt
+			break
+		}
+	}
+	if prompt == nil {
+		return "", fmt.Errorf("unknown MCP prompt %s (see `/mcp prompts`)", cmdName)
+	}
+
+	values := parsePromptArgs(prompt.Arguments, args)
+	for _, a := range prompt.Arguments {
+		if a.Required && values[a.Name] == "" {
+			return "", fmt.Errorf("missing argument %q. Usage: `%s%s`", a.Name, cmdName, promptArgsUsage(*prompt))
+		}
+	}
+
+	result, err := c.mcpHub.GetPrompt(ctx, server, name, values)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, m := range result.Messages {
+		if len(result.Messages) > 1 {
+			sb.WriteString(fmt.Sprintf("[%s]\n", m.Role))
+		}
+		switch content := m.Content.(type) {
+		case mcp.TextContent:
+			sb.WriteString(content.Text)
+		case mcp.EmbeddedResource:
+			sb.WriteString(tools.FormatResourceContents([]mcp.ResourceContents{content.Resource}))
+		default:
+			sb.WriteString("[non-text prompt content omitted]")
+		}
+		sb.WriteString("\n\n")
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// parsePromptArgs maps a command line onto the prompt's declared arguments
+func parsePromptArgs(declared []mcp.PromptArgument, line string) map[string]string {
+	values := make(map[string]string)
+	isDeclared := make(map[string]bool)
+	for _, a := range declared {
+		isDeclared[a.Name] = true
+	}
+
+	var positional []string
+	for _, tok := range strings.Fields(line) {
+		if key, value, ok := strings.Cut(tok, "="); ok && isDeclared[key] {
+			values[key] = value
+			continue
+		}
+		positional = append(positional, tok)
+	}
+
+	var open []string
+	for _, a := range declared {
+		if _, set := values[a.Name]; !set {
+			open = append(open, a.Name)
+		}
+	}
+	for i, name := range open {
+		if i >= len(positional) {
+			break
+		}
+		if i == len(open)-1 {
+			values[name] = strings.Join(positional[i:], " ")
+			break
+		}
+		values[name] = positional[i]
+	}
+	return values
+}
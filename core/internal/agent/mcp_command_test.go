@@ -0,0 +1,36 @@
+package agent
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestParsePromptArgs(t *testing.T) {
+	declared := []mcp.PromptArgument{{Name: "file"}, {Name: "focus"}}
+
+	cases := []struct {
+		line string
+		want map[string]string
+	}{
+		{"main.go look for races", map[string]string{"file": "main.go", "focus": "look for races"}},
+		{"focus=security main.go", map[string]string{"focus": "security", "file": "main.go"}},
+		{"", map[string]string{}},
+	}
+	for _, c := range cases {
+		if got := parsePromptArgs(declared, c.line); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("parsePromptArgs(%q) = %v, want %v", c.line, got, c.want)
+		}
+	}
+}
+
+func TestSplitEnvArgs(t *testing.T) {
+	env, args := splitEnvArgs([]string{"GITHUB_TOKEN=abc", "postgresql://u:p@localhost/db?sslmode=disable", "./"})
+	if env["GITHUB_TOKEN"] != "abc" || len(env) != 1 {
+		t.Errorf("unexpected env: %v", env)
+	}
+	if len(args) != 2 {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
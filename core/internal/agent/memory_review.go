@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/igoryan-dao/ricochet/internal/memory"
+)
+
+// PendingMemories returns the learned facts waiting for review
+func (c *Controller) PendingMemories() []memory.MemoryItem {
+	if c.memoryManager == nil {
+		return nil
+	}
+	return c.memoryManager.Pending()
+}
+
+// ReviewMemories approves and rejects pending memories by key. edits
+// rewords approved facts before they are kept.
+func (c *Controller) ReviewMemories(approve, reject []string, edits map[string]string) error {
+	if c.memoryManager == nil {
+		return fmt.Errorf("memory is not available")
+	}
+	for _, key := range approve {
+		if err := c.memoryManager.Approve(key, edits[key]); err != nil {
+			return err
+		}
+	}
+	for _, key := range reject {
+		if err := c.memoryManager.Reject(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleMemoryCommand runs /memory: stats, and review of learned facts
+func (c *Controller) handleMemoryCommand(sessionID, args string, callback func(update interface{})) error {
+	reply := func(content string) {
+		callback(ChatUpdate{
+			SessionID: sessionID,
+			Message: ChatMessage{
+				ID:        uuid.New().String(),
+				Role:      "assistant",
+				Content:   content,
+				Timestamp: time.Now().UnixMilli(),
+			},
+		})
+	}
+	if c.memoryManager == nil {
+		reply("❌ Memory is not available.")
+		return nil
+	}
+
+	fields := strings.Fields(args)
+	action := ""
+	if len(fields) > 0 {
+		action = fields[0]
+	}
+	pending := c.memoryManager.Pending()
+
+	switch action {
+	case "":
+		total, learned := len(c.memoryManager.GetAll()), len(c.memoryManager.Learned())
+		msg := fmt.Sprintf("🧠 **%d memories**: %d saved, %d learned from sessions.", total, total-learned, learned)
+		if len(pending) > 0 {
+			msg += fmt.Sprintf("\n%d learned facts wait for review: `/memory review`.", len(pending))
+		}
+		reply(msg)
+
+	case "review":
+		if len(pending) == 0 {
+			reply("No learned facts wait for review.")
+			return nil
+		}
+		var sb strings.Builder
+		sb.WriteString("**Learned facts waiting for review**:\n")
+		for i, item := range pending {
+			seen := ""
+			if item.Hits > 0 {
+				seen = fmt.Sprintf(" _(seen in %d sessions)_", item.Hits+1)
+			}
+			sb.WriteString(fmt.Sprintf("%d. %s%s\n", i+1, item.Value, seen))
+		}
+		sb.WriteString("\nKeep them with `/memory approve <n>…` or drop them with `/memory reject <n>…` (or `all`).")
+		reply(sb.String())
+
+	case "approve", "reject":
+		keys, err := pendingKeys(pending, fields[1:])
+		if err != nil {
+			reply("❌ " + err.Error())
+			return nil
+		}
+		if action == "approve" {
+			err = c.ReviewMemories(keys, nil, nil)
+		} else {
+			err = c.ReviewMemories(nil, keys, nil)
+		}
+		if err != nil {
+			reply(fmt.Sprintf("❌ Failed to %s memories: %v", action, err))
+			return nil
+		}
+		verb := "Kept"
+		if action == "reject" {
+			verb = "Dropped"
+		}
+		reply(fmt.Sprintf("✅ %s %d learned facts; %d left to review.", verb, len(keys), len(pending)-len(keys)))
+
+	default:
+		reply("**Usage**: `/memory`, `/memory review`, `/memory approve <n>…|all` or `/memory reject <n>…|all`")
+	}
+	return nil
+}
+
+// pendingKeys maps review numbers (1-based, or "all") to pending memory keys
+func pendingKeys(pending []memory.MemoryItem, args []string) ([]string, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("say which facts, by their number in `/memory review`, or `all`")
+	}
+	var keys []string
+	for _, arg := range args {
+		if arg == "all" {
+			keys = keys[:0]
+			for _, item := range pending {
+				keys = append(keys, item.Key)
+			}
+			return keys, nil
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(arg, ","))
+		if err != nil || n < 1 || n > len(pending) {
+			return nil, fmt.Errorf("no fact %q to review; see `/memory review`", arg)
+		}
+		keys = append(keys, pending[n-1].Key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/modes"
+)
+
+// ActiveMode returns the mode the agent is working in
+func (c *Controller) ActiveMode() modes.Mode {
+	if c.modes == nil {
+		return modes.BuiltinModes[0]
+	}
+	return c.modes.GetActiveMode()
+}
+
+// applyModeZone sets the workspace zone the active mode asks for
+func (c *Controller) applyModeZone(mode modes.Mode) {
+	if c.safeguard == nil {
+		return
+	}
+	if err := c.safeguard.SetModeZone(mode.Zone); err != nil {
+		log.Printf("[Agent] Mode %s: %v", mode.Slug, err)
+	}
+}
+
+// modeModel returns the model the mode overrides the configured one with
+func modeModel(mode modes.Mode, configured string) string {
+	if mode.Model != "" {
+		return mode.Model
+	}
+	return configured
+}
+
+// handleModeCommand lists the modes (/mode) or switches to one (/mode <slug>)
+func (c *Controller) handleModeCommand(sessionID, args string, callback func(update interface{})) error {
+	if args != "" {
+		if err := c.modes.SetMode(args); err != nil {
+			return c.sendReply(sessionID, fmt.Sprintf("❌ %v. Run /mode to list the modes.", err), callback)
+		}
+		mode := c.modes.GetActiveMode()
+		c.applyModeZone(mode)
+		return c.sendReply(sessionID, fmt.Sprintf("🔄 Switched to **%s** (`%s`)%s", mode.Name, mode.Slug, modeDetails(mode)), callback)
+	}
+	return c.sendReply(sessionID, FormatModes(c.modes.ListModes(), c.modes.GetActiveMode().Slug, c.modes.LoadErrors()), callback)
+}
+
+// FormatModes renders the mode list with the active mode marked and the
+// project modes that failed to load
+func FormatModes(list []modes.Mode, active string, loadErrors []string) string {
+	var sb strings.Builder
+	sb.WriteString("**Modes**\n\n")
+	for _, mode := range list {
+		marker := "  "
+		if mode.Slug == active {
+			marker = "▶ "
+		}
+		source := ""
+		if mode.Source == "project" {
+			source = " _(project)_"
+		}
+		fmt.Fprintf(&sb, "%s`%s` %s%s%s\n", marker, mode.Slug, mode.Name, source, modeDetails(mode))
+	}
+	if len(loadErrors) > 0 {
+		sb.WriteString("\n⚠️ **Skipped project modes**\n")
+		for _, e := range loadErrors {
+			fmt.Fprintf(&sb, "- %s\n", e)
+		}
+	}
+	sb.WriteString("\nSwitch with `/mode <slug>`. Define project modes in `.ricochet/modes/*.yaml`.")
+	return sb.String()
+}
+
+// modeDetails summarises the zone and model a mode overrides
+func modeDetails(mode modes.Mode) string {
+	var parts []string
+	if mode.Zone != "" {
+		parts = append(parts, "zone "+mode.Zone)
+	}
+	if mode.Model != "" {
+		parts = append(parts, "model `"+mode.Model+"`")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " — " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/modes"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+func TestModeCommand(t *testing.T) {
+	dir := t.TempDir()
+	modesDir := filepath.Join(dir, ".ricochet", "modes")
+	if err := os.MkdirAll(modesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(modesDir, "reviewer.yaml"), []byte("name: Reviewer\nrole_definition: You review.\ntool_groups: [read]\nzone: readonly\nmodel: small-model\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(modesDir, "bad.yaml"), []byte("role_definition: x\ntool_groups: [teleport]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mm := modes.NewManager(dir)
+	c := &Controller{modes: mm, safeguard: &safeguard.Manager{Trust: safeguard.TrustTrusted, CurrentZone: safeguard.ZoneSafe}}
+	var reply string
+	callback := func(update interface{}) { reply = update.(ChatUpdate).Message.Content }
+
+	c.handleModeCommand("s1", "", callback)
+	for _, want := range []string{"▶ `code`", "`reviewer` Reviewer _(project)_ — zone readonly, model `small-model`", "bad.yaml", "teleport"} {
+		if !strings.Contains(reply, want) {
+			t.Errorf("mode list missing %q:\n%s", want, reply)
+		}
+	}
+
+	c.handleModeCommand("s1", "reviewer", callback)
+	if !strings.Contains(reply, "Switched to **Reviewer**") || c.ActiveMode().Slug != "reviewer" {
+		t.Errorf("switch reply: %s", reply)
+	}
+	if c.safeguard.CurrentZone != safeguard.ZoneReadOnly {
+		t.Errorf("zone after switch = %s", c.safeguard.CurrentZone)
+	}
+	if got := modeModel(c.ActiveMode(), "big-model"); got != "small-model" {
+		t.Errorf("model = %s", got)
+	}
+
+	c.handleModeCommand("s1", "nope", callback)
+	if !strings.Contains(reply, "mode nope not found") {
+		t.Errorf("unknown mode reply: %s", reply)
+	}
+}
@@ -24,6 +24,12 @@ type OpenAIProvider struct {
 	baseURL      string
 	organization string
 	project      string
+	client       *http.Client // nil = shared default
+	name         string       // Overrides the name derived from baseURL
+	apiKeyHeader string       // Sends the key in this header instead of "Authorization: Bearer"
+	embedURL     string       // Overrides the embeddings URL derived from baseURL
+	embedModel   string       // Overrides the default embedding model
+	openRouter   bool         // Sends routing preferences and requests billed-cost usage
 }
 
 // NewOpenAIProvider creates a new OpenAI-compatible provider
@@ -49,12 +55,23 @@ func NewOpenAIProvider(apiKey, model, baseURL, organization, project string) *Op
 }
 
 func (p *OpenAIProvider) Name() string {
-	if strings.Contains(p.baseURL, "openrouter") {
+	if p.name != "" {
+		return p.name
+	}
+	if p.isOpenRouter() {
 		return "openrouter"
 	}
 	return "openai"
 }
 
+func (p *OpenAIProvider) isOpenRouter() bool {
+	return p.openRouter || strings.Contains(p.baseURL, "openrouter")
+}
+
+func (p *OpenAIProvider) setHTTPClient(client *http.Client) {
+	p.client = client
+}
+
 // openaiRequest is the OpenAI API request format
 type openaiRequest struct {
 	Model       string          `json:"model"`
@@ -63,6 +80,10 @@ type openaiRequest struct {
 	Temperature float64         `json:"temperature,omitempty"`
 	Tools       []openaiTool    `json:"tools,omitempty"`
 	Stream      bool            `json:"stream,omitempty"`
+
+	// OpenRouter only
+	Provider *openRouterProvider `json:"provider,omitempty"`
+	Usage    *openRouterUsage    `json:"usage,omitempty"`
 }
 
 type openaiMessage struct {
@@ -102,12 +123,9 @@ type openaiResponse struct {
 		Message      openaiMessage `json:"message"`
 		FinishReason string        `json:"finish_reason"`
 	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
-	Error *struct {
+	Usage    openaiUsage `json:"usage"`
+	Provider string      `json:"provider,omitempty"` // OpenRouter: upstream that served the request
+	Error    *struct {
 		Message string `json:"message"`
 		Type    string `json:"type"`
 		Code    string `json:"code"`
@@ -127,7 +145,7 @@ func (p *OpenAIProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatRespo
 	headers := p.headers()
 	headers["X-Client-Request-Id"] = requestID
 
-	resp, err := doRequest(ctx, "POST", p.baseURL, headers, bytes.NewReader(body))
+	resp, err := doRequest(ctx, p.client, "POST", p.baseURL, headers, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -173,15 +191,18 @@ func (p *OpenAIProvider) Embed(ctx context.Context, texts []string) ([][]float32
 		return nil, nil
 	}
 
-	embedURL := strings.Replace(p.baseURL, "/chat/completions", "/embeddings", 1)
+	embedURL := p.embedURL
+	if embedURL == "" {
+		embedURL = strings.Replace(p.baseURL, "/chat/completions", "/embeddings", 1)
+	}
 
 	req := openaiEmbedRequest{
-		Model: "text-embedding-3-small", // Default embedding model
+		Model: p.embeddingModelID(),
 		Input: texts,
 	}
 
 	body, _ := json.Marshal(req)
-	resp, err := doRequest(ctx, "POST", embedURL, p.headers(), bytes.NewReader(body))
+	resp, err := doRequest(ctx, p.client, "POST", embedURL, p.headers(), bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -204,6 +225,18 @@ func (p *OpenAIProvider) Embed(ctx context.Context, texts []string) ([][]float32
 	return result, nil
 }
 
+// EmbeddingModel identifies the embedding model for index compatibility checks
+func (p *OpenAIProvider) EmbeddingModel() string {
+	return p.Name() + "/" + p.embeddingModelID()
+}
+
+func (p *OpenAIProvider) embeddingModelID() string {
+	if p.embedModel != "" {
+		return p.embedModel
+	}
+	return "text-embedding-3-small" // Default embedding model
+}
+
 // ChatStream performs a streaming chat completion
 func (p *OpenAIProvider) ChatStream(ctx context.Context, req *ChatRequest, callback StreamCallback) error {
 	openaiReq := p.buildRequest(req, true)
@@ -217,7 +250,7 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, req *ChatRequest, callb
 	headers := p.headers()
 	headers["X-Client-Request-Id"] = requestID
 
-	resp, err := doRequest(ctx, "POST", p.baseURL, headers, bytes.NewReader(body))
+	resp, err := doRequest(ctx, p.client, "POST", p.baseURL, headers, bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -236,8 +269,12 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, req *ChatRequest, callb
 
 func (p *OpenAIProvider) headers() map[string]string {
 	headers := map[string]string{
-		"Content-Type":  "application/json",
-		"Authorization": "Bearer " + p.apiKey,
+		"Content-Type": "application/json",
+	}
+	if p.apiKeyHeader != "" {
+		headers[p.apiKeyHeader] = p.apiKey
+	} else {
+		headers["Authorization"] = "Bearer " + p.apiKey
 	}
 
 	if p.organization != "" {
@@ -248,7 +285,7 @@ func (p *OpenAIProvider) headers() map[string]string {
 	}
 
 	// OpenRouter specific headers
-	if strings.Contains(p.baseURL, "openrouter") {
+	if p.isOpenRouter() {
 		headers["HTTP-Referer"] = "https://ricochet.dev"
 		headers["X-Title"] = "Ricochet"
 	}
@@ -333,7 +370,7 @@ func (p *OpenAIProvider) buildRequest(req *ChatRequest, stream bool) *openaiRequ
 		})
 	}
 
-	return &openaiRequest{
+	out := &openaiRequest{
 		Model:       p.model,
 		Messages:    messages,
 		MaxTokens:   maxTokens,
@@ -341,6 +378,13 @@ func (p *OpenAIProvider) buildRequest(req *ChatRequest, stream bool) *openaiRequ
 		Tools:       tools,
 		Stream:      stream,
 	}
+	if p.isOpenRouter() {
+		out.Usage = &openRouterUsage{Include: true}
+		if sort, err := NormalizeRouting(req.Routing); err == nil && sort != "" {
+			out.Provider = &openRouterProvider{Sort: sort}
+		}
+	}
+	return out
 }
 
 func (p *OpenAIProvider) parseResponse(resp *openaiResponse) *ChatResponse {
@@ -368,10 +412,24 @@ func (p *OpenAIProvider) parseResponse(resp *openaiResponse) *ChatResponse {
 		Content:    choice.Message.Content,
 		ToolCalls:  toolCalls,
 		StopReason: choice.FinishReason,
-		Usage: Usage{
-			InputTokens:  resp.Usage.PromptTokens,
-			OutputTokens: resp.Usage.CompletionTokens,
-		},
+		Usage:      resp.Usage.usage(resp.Provider),
+	}
+}
+
+type openaiUsage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost,omitempty"` // OpenRouter usage accounting, in USD
+}
+
+// usage converts the API usage block
+func (u openaiUsage) usage(upstream string) Usage {
+	return Usage{
+		InputTokens:  u.PromptTokens,
+		OutputTokens: u.CompletionTokens,
+		Cost:         u.Cost,
+		Upstream:     upstream,
 	}
 }
 
@@ -391,6 +449,8 @@ type openaiStreamChunk struct {
 		} `json:"delta"`
 		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
+	Usage    *openaiUsage `json:"usage,omitempty"`    // Final chunk, when usage is requested
+	Provider string       `json:"provider,omitempty"` // OpenRouter upstream
 }
 
 // openaiStreamToolCall is a tool call in a streaming response (includes Index)
@@ -445,6 +505,11 @@ func (p *OpenAIProvider) processStream(reader io.Reader, callback StreamCallback
 			continue
 		}
 
+		if chunk.Usage != nil {
+			usage := chunk.Usage.usage(chunk.Provider)
+			callback(&StreamChunk{Type: "usage", Usage: &usage})
+		}
+
 		if len(chunk.Choices) == 0 {
 			continue
 		}
@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+)
+
+// OpenRouter - OpenAI-compatible API with provider routing and billed-cost reporting
+
+// openRouterProvider is the "provider" request object selecting how OpenRouter routes
+type openRouterProvider struct {
+	Sort string `json:"sort,omitempty"` // price, latency or throughput
+}
+
+// openRouterUsage asks OpenRouter to include the billed cost in the usage block
+type openRouterUsage struct {
+	Include bool `json:"include"`
+}
+
+// routingAliases maps accepted spellings to OpenRouter's sort values
+var routingAliases = map[string]string{
+	"price":      "price",
+	"cheap":      "price",
+	"cost":       "price",
+	"latency":    "latency",
+	"fast":       "latency",
+	"throughput": "throughput",
+	"default":    "",
+	"":           "",
+}
+
+// NormalizeRouting validates a routing preference; "" and "default" leave routing to OpenRouter
+func NormalizeRouting(pref string) (string, error) {
+	sort, ok := routingAliases[strings.ToLower(strings.TrimSpace(pref))]
+	if !ok {
+		return "", fmt.Errorf("unknown routing preference %q (use price, latency or throughput)", pref)
+	}
+	return sort, nil
+}
+
+// NewOpenRouterProvider creates a provider for OpenRouter. baseURL defaults to https://openrouter.ai/api/v1.
+func NewOpenRouterProvider(apiKey, model, baseURL string) *OpenAIProvider {
+	if baseURL == "" {
+		baseURL = config.DefaultOpenRouterURL
+	}
+	// OpenRouter doesn't use the standard Org/Project headers
+	p := NewOpenAIProvider(apiKey, model, baseURL, "", "")
+	p.name = config.OpenRouterProviderID
+	p.openRouter = true
+	return p
+}
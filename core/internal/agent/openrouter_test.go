@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOpenRouterRoutingRequest(t *testing.T) {
+	p := NewOpenRouterProvider("key", "openai/gpt-4o", "")
+
+	body, _ := json.Marshal(p.buildRequest(&ChatRequest{Routing: "cheap"}, true))
+	if !strings.Contains(string(body), `"provider":{"sort":"price"}`) || !strings.Contains(string(body), `"usage":{"include":true}`) {
+		t.Errorf("routing or usage missing from request: %s", body)
+	}
+
+	body, _ = json.Marshal(p.buildRequest(&ChatRequest{}, true))
+	if strings.Contains(string(body), `"provider"`) {
+		t.Errorf("default routing should omit the provider object: %s", body)
+	}
+
+	// Plain OpenAI requests carry neither field
+	body, _ = json.Marshal(NewOpenAIProvider("key", "gpt-4o", "", "", "").buildRequest(&ChatRequest{Routing: "price"}, true))
+	if strings.Contains(string(body), `"provider"`) || strings.Contains(string(body), `"usage"`) {
+		t.Errorf("OpenRouter fields sent to OpenAI: %s", body)
+	}
+
+	if _, err := NormalizeRouting("fastest"); err == nil {
+		t.Error("expected error for unknown routing preference")
+	}
+}
+
+func TestOpenRouterStreamUsage(t *testing.T) {
+	stream := strings.Join([]string{
+		`data: {"provider":"Anthropic","choices":[{"delta":{"content":"Hi"}}]}`,
+		`data: {"provider":"Anthropic","choices":[],"usage":{"prompt_tokens":1200,"completion_tokens":30,"cost":0.00405}}`,
+		`data: [DONE]`,
+	}, "\n")
+
+	var usage *Usage
+	p := NewOpenRouterProvider("key", "anthropic/claude-sonnet-4", "")
+	err := p.processStream(strings.NewReader(stream), func(chunk *StreamChunk) error {
+		if chunk.Type == "usage" {
+			usage = chunk.Usage
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage == nil || usage.InputTokens != 1200 || usage.OutputTokens != 30 || usage.Cost != 0.00405 || usage.Upstream != "Anthropic" {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+}
@@ -0,0 +1,249 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/igoryan-dao/ricochet/internal/config"
+	"github.com/igoryan-dao/ricochet/internal/database"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+	"github.com/igoryan-dao/ricochet/internal/tools"
+)
+
+// permissionStore returns the persistent permission rules, or nil when the
+// safeguard is not initialised
+func (c *Controller) permissionStore() *safeguard.PermissionStore {
+	if c.safeguard == nil {
+		return nil
+	}
+	return c.safeguard.PermissionStore
+}
+
+// permissionTargets are what "don't ask again" rules for a tool call are
+// scoped to: the exact command for shell commands, the script digest for
+// scripts, the host for web tools, the database for SQL tools, the server
+// for MCP tools and every path a file tool touches. It is empty when the
+// call's target cannot be told, and then no rule covers the call.
+func (c *Controller) permissionTargets(tc ToolCallInfo) []string {
+	var args struct {
+		Command     string `json:"command"`
+		Script      string `json:"script"`
+		URL         string `json:"url"`
+		Database    string `json:"database"`
+		Path        string `json:"path"`
+		Source      string `json:"source"`
+		Destination string `json:"destination"`
+		Diff        string `json:"diff"`
+		Edits       []struct {
+			Path string `json:"path"`
+		} `json:"edits"`
+	}
+	if err := json.Unmarshal([]byte(tc.Arguments), &args); err != nil {
+		return nil
+	}
+
+	var targets []string
+	switch tc.Name {
+	case "execute_command":
+		targets = []string{args.Command}
+	case "execute_bash_script", "execute_node":
+		if args.Script != "" {
+			targets = []string{tools.ScriptTarget(args.Script)}
+		}
+	case "http_request", "web_fetch":
+		if u, err := url.Parse(strings.TrimSpace(args.URL)); err == nil {
+			targets = []string{u.Hostname()}
+		}
+	case "sql_query", "sql_schema", "sql_execute":
+		var databases map[string]config.DatabaseSettings
+		if c.safeguard != nil && c.safeguard.ToolsSettings != nil {
+			databases = c.safeguard.ToolsSettings.Databases
+		}
+		if name, _, err := database.Select(databases, args.Database); err == nil {
+			targets = []string{name}
+		}
+	case "move_file":
+		targets = []string{args.Source, args.Destination}
+	case "apply_diff":
+		targets = tools.DiffPaths(args.Diff, args.Path)
+	case "edit_files":
+		for _, edit := range args.Edits {
+			if edit.Path != "" {
+				edit.Path = filepath.Clean(edit.Path) // As EditFiles stages it
+			}
+			targets = append(targets, edit.Path)
+		}
+	case "rename_symbol":
+		// The files a rename touches are only known once the server answers
+	default:
+		if c.mcpHub != nil {
+			if server, _, ok := c.mcpHub.ResolveTool(tc.Name); ok {
+				targets = []string{server}
+				break
+			}
+		}
+		targets = []string{args.Path}
+	}
+	for _, t := range targets {
+		if t == "" {
+			return nil
+		}
+	}
+	return targets
+}
+
+// allowingRule returns the ID of a stored rule approving a tool call: every
+// target of the call must be allowed
+func (c *Controller) allowingRule(tc ToolCallInfo) (string, bool) {
+	store := c.permissionStore()
+	targets := c.permissionTargets(tc)
+	if store == nil || len(targets) == 0 {
+		return "", false
+	}
+	id := ""
+	for _, target := range targets {
+		ruleID, ok := store.AllowingRule(tc.Name, target)
+		if !ok {
+			return "", false
+		}
+		if id == "" {
+			id = ruleID
+		}
+	}
+	return id, true
+}
+
+// permissionAllows reports whether stored rules approve a tool call
+func (c *Controller) permissionAllows(tc ToolCallInfo) bool {
+	_, ok := c.allowingRule(tc)
+	return ok
+}
+
+// rememberApproval saves project rules allowing the same tool call again,
+// one per target. Calls without a known target are not remembered: a rule
+// without one would allow any use of the tool.
+func (c *Controller) rememberApproval(tc ToolCallInfo) error {
+	store := c.permissionStore()
+	if store == nil {
+		return fmt.Errorf("safeguard not initialized")
+	}
+	targets := c.permissionTargets(tc)
+	if len(targets) == 0 {
+		return fmt.Errorf("%s has no target to scope a rule to", tc.Name)
+	}
+	for _, target := range targets {
+		err := store.AddRule(safeguard.PermissionRule{
+			Tool:   tc.Name,
+			Path:   target,
+			Exact:  true,
+			Action: "allow",
+			Scope:  safeguard.ScopeProject,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PermissionRules lists the stored rules in force in this workspace
+func (c *Controller) PermissionRules() ([]safeguard.PermissionRule, error) {
+	store := c.permissionStore()
+	if store == nil {
+		return nil, fmt.Errorf("safeguard not initialized")
+	}
+	return store.Rules(), nil
+}
+
+// RemovePermissionRule deletes a stored rule by ID
+func (c *Controller) RemovePermissionRule(id string) error {
+	store := c.permissionStore()
+	if store == nil {
+		return fmt.Errorf("safeguard not initialized")
+	}
+	return store.RemoveRule(id)
+}
+
+// ExportPermissionRules returns the rules in force in this workspace in the
+// permissions.json format
+func (c *Controller) ExportPermissionRules() (string, error) {
+	store := c.permissionStore()
+	if store == nil {
+		return "", fmt.Errorf("safeguard not initialized")
+	}
+	data, err := store.Export()
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// handlePermissionsCommand implements /permissions [remove <id>...|export]
+func (c *Controller) handlePermissionsCommand(sessionID, args string, callback func(update interface{})) error {
+	reply := func(content string) error {
+		callback(ChatUpdate{
+			SessionID: sessionID,
+			Message: ChatMessage{
+				ID:        uuid.New().String(),
+				Role:      "assistant",
+				Content:   content,
+				Timestamp: time.Now().UnixMilli(),
+			},
+		})
+		return nil
+	}
+
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 0 || fields[0] == "list":
+		rules, err := c.PermissionRules()
+		if err != nil {
+			return reply("❌ " + err.Error())
+		}
+		return reply(formatPermissionRules(rules))
+
+	case fields[0] == "remove":
+		if len(fields) < 2 {
+			return reply("Usage: /permissions remove <id>...")
+		}
+		var sb strings.Builder
+		for _, id := range fields[1:] {
+			if err := c.RemovePermissionRule(id); err != nil {
+				fmt.Fprintf(&sb, "❌ %v\n", err)
+			} else {
+				fmt.Fprintf(&sb, "🗑️ Removed rule %s\n", id)
+			}
+		}
+		return reply(strings.TrimSpace(sb.String()))
+
+	case fields[0] == "export":
+		data, err := c.ExportPermissionRules()
+		if err != nil {
+			return reply("❌ " + err.Error())
+		}
+		return reply("```json\n" + data + "\n```")
+	}
+	return reply("Usage: /permissions [list|remove <id>...|export]")
+}
+
+func formatPermissionRules(rules []safeguard.PermissionRule) string {
+	if len(rules) == 0 {
+		return "🔐 No stored permission rules. Choose \"Yes, and don't ask again\" when approving a tool to add one."
+	}
+	var sb strings.Builder
+	sb.WriteString("🔐 **Permission rules**\n\n```\n")
+	for _, r := range rules {
+		target := r.Path
+		if target == "" {
+			target = "(any)"
+		}
+		fmt.Fprintf(&sb, "%-4s %-5s %-7s %-20s %s\n", r.ID, r.Action, r.Scope, r.Tool, target)
+	}
+	sb.WriteString("```\n\nRemove a rule with `/permissions remove <id>`, or print them all as JSON with `/permissions export`.")
+	return sb.String()
+}
@@ -0,0 +1,207 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	context_manager "github.com/igoryan-dao/ricochet/internal/context"
+)
+
+// messagePinPrefix marks pinned messages in pin IDs: "msg-<index>"
+const messagePinPrefix = "msg-"
+
+// PinnedItem is one pinned message, file or note with its share of the context
+type PinnedItem struct {
+	ID      string `json:"id"`
+	Kind    string `json:"kind"` // message, file or note
+	Role    string `json:"role,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Preview string `json:"preview,omitempty"`
+	Tokens  int    `json:"tokens"`
+}
+
+// PinMessage pins or unpins a message of the session history by index.
+// An assistant tool call and its results are pinned together, so neither
+// is left orphaned when the rest of the history is condensed.
+func (c *Controller) PinMessage(sessionID string, index int, pinned bool) error {
+	session := c.sessionManager.GetSession(sessionID)
+	if session == nil {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	c.pinMu.Lock()
+	defer c.pinMu.Unlock()
+
+	msgs := session.StateHandler.GetMessages()
+	if index < 0 || index >= len(msgs) {
+		return fmt.Errorf("no message %d in this session", index)
+	}
+	indexes := []int{index}
+	if msg := msgs[index]; msg.Role == "assistant" && len(msg.ToolUse) > 0 && index+1 < len(msgs) && len(msgs[index+1].ToolResults) > 0 {
+		indexes = append(indexes, index+1)
+	} else if len(msg.ToolResults) > 0 && index > 0 && len(msgs[index-1].ToolUse) > 0 {
+		indexes = append(indexes, index-1)
+	}
+	for _, i := range indexes {
+		msg := msgs[i]
+		msg.Pinned = pinned
+		session.StateHandler.UpdateMessage(i, msg)
+	}
+	return c.sessionManager.Save(sessionID)
+}
+
+// AddPin pins a workspace file or a note to the session
+func (c *Controller) AddPin(sessionID, kind, value string) (context_manager.Pin, error) {
+	session := c.sessionManager.GetSession(sessionID)
+	if session == nil {
+		return context_manager.Pin{}, fmt.Errorf("session not found: %s", sessionID)
+	}
+	value = strings.TrimSpace(value)
+	pin := context_manager.Pin{Kind: kind, CreatedAt: time.Now()}
+	switch kind {
+	case context_manager.PinFile:
+		path := value
+		if !filepath.IsAbs(path) {
+			cwd, _ := os.Getwd()
+			path = filepath.Join(cwd, path)
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			return pin, fmt.Errorf("not a file: %s", value)
+		}
+		pin.Path = value
+	case context_manager.PinNote:
+		pin.Note = value
+	default:
+		return pin, fmt.Errorf("unknown pin kind %q: use file or note", kind)
+	}
+	if value == "" {
+		return pin, fmt.Errorf("nothing to pin")
+	}
+
+	c.pinMu.Lock()
+	defer c.pinMu.Unlock()
+	next := 1
+	for _, p := range session.Pins {
+		if kind == context_manager.PinFile && p.Path == pin.Path {
+			return p, nil // Already pinned
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(p.ID, "p")); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+	pin.ID = fmt.Sprintf("p%d", next)
+	session.Pins = append(append([]context_manager.Pin(nil), session.Pins...), pin)
+	return pin, c.sessionManager.Save(sessionID)
+}
+
+// Unpin removes a pin by ID: a file or note pin, or "msg-<index>"
+func (c *Controller) Unpin(sessionID, id string) error {
+	if index, err := strconv.Atoi(strings.TrimPrefix(id, messagePinPrefix)); err == nil && strings.HasPrefix(id, messagePinPrefix) {
+		return c.PinMessage(sessionID, index, false)
+	}
+	session := c.sessionManager.GetSession(sessionID)
+	if session == nil {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	c.pinMu.Lock()
+	defer c.pinMu.Unlock()
+	for i, p := range session.Pins {
+		if p.ID == id {
+			pins := append([]context_manager.Pin(nil), session.Pins[:i]...)
+			session.Pins = append(pins, session.Pins[i+1:]...)
+			return c.sessionManager.Save(sessionID)
+		}
+	}
+	return fmt.Errorf("no pin %s in this session", id)
+}
+
+// PinnedContext lists a session's pins with the tokens each takes
+func (c *Controller) PinnedContext(sessionID string) []PinnedItem {
+	session := c.sessionManager.GetSession(sessionID)
+	if session == nil {
+		return nil
+	}
+	items := []PinnedItem{}
+	for i, msg := range session.StateHandler.GetMessages() {
+		if !msg.Pinned {
+			continue
+		}
+		items = append(items, PinnedItem{
+			ID:      fmt.Sprintf("%s%d", messagePinPrefix, i),
+			Kind:    "message",
+			Role:    msg.Role,
+			Preview: pinPreview(msg.Content),
+			Tokens:  context_manager.EstimateMessageBudgetedTokens(msg),
+		})
+	}
+	cwd, _ := os.Getwd()
+	for _, p := range session.Pins {
+		item := PinnedItem{ID: p.ID, Kind: p.Kind, Path: p.Path, Preview: pinPreview(p.Note)}
+		item.Tokens = context_manager.EstimateBudgetedTokens(context_manager.RenderPins([]context_manager.Pin{p}, cwd))
+		items = append(items, item)
+	}
+	return items
+}
+
+// pinnedPrompt renders the session's pinned files and notes for the system prompt
+func (c *Controller) pinnedPrompt(session *Session) string {
+	cwd, _ := os.Getwd()
+	return context_manager.RenderPins(session.Pins, cwd)
+}
+
+// handlePinTool runs the pin_context tool
+func (c *Controller) handlePinTool(sessionID string, args map[string]interface{}) string {
+	str := func(key string) string {
+		s, _ := args[key].(string)
+		return s
+	}
+	switch str("action") {
+	case "pin", "":
+		var pin context_manager.Pin
+		var err error
+		if file := str("file"); file != "" {
+			pin, err = c.AddPin(sessionID, context_manager.PinFile, file)
+		} else {
+			pin, err = c.AddPin(sessionID, context_manager.PinNote, str("note"))
+		}
+		if err != nil {
+			return fmt.Sprintf("Error: %v", err)
+		}
+		return fmt.Sprintf("Pinned %s %s: it stays in context for the rest of the session.", pin.Kind, pin.ID)
+	case "unpin":
+		if err := c.Unpin(sessionID, str("id")); err != nil {
+			return fmt.Sprintf("Error: %v", err)
+		}
+		return fmt.Sprintf("Unpinned %s.", str("id"))
+	case "list":
+		items := c.PinnedContext(sessionID)
+		if len(items) == 0 {
+			return "Nothing is pinned."
+		}
+		var sb strings.Builder
+		for _, item := range items {
+			fmt.Fprintf(&sb, "- %s %s", item.ID, item.Kind)
+			if item.Path != "" {
+				fmt.Fprintf(&sb, " %s", item.Path)
+			}
+			if item.Preview != "" {
+				fmt.Fprintf(&sb, ": %s", item.Preview)
+			}
+			fmt.Fprintf(&sb, " (~%d tokens)\n", item.Tokens)
+		}
+		return sb.String()
+	default:
+		return fmt.Sprintf("Error: unknown action %q: use pin, unpin or list", str("action"))
+	}
+}
+
+func pinPreview(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > 80 {
+		s = string(r[:80]) + "…"
+	}
+	return s
+}
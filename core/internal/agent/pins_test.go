@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	context_manager "github.com/igoryan-dao/ricochet/internal/context"
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+func TestPins_MessagesFilesAndNotes(t *testing.T) {
+	dir := t.TempDir()
+	c := &Controller{sessionManager: NewSessionManager(dir), contextManager: NewContextManager(nil, 100000, 4000)}
+	session := c.sessionManager.CreateSession()
+	session.StateHandler.SetMessages([]protocol.Message{
+		{Role: "user", Content: "keep the API stable"},
+		{Role: "assistant", ToolUse: []protocol.ToolUseBlock{{ID: "t1", Name: "read_file", Input: json.RawMessage(`{}`)}}},
+		{Role: "user", ToolResults: []protocol.ToolResultBlock{{ToolUseID: "t1", Content: "contents"}}},
+	})
+
+	if err := c.PinMessage(session.ID, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	// Pinning a tool result pins its call too
+	if err := c.PinMessage(session.ID, 2, true); err != nil {
+		t.Fatal(err)
+	}
+	for i, msg := range session.StateHandler.GetMessages() {
+		if !msg.Pinned {
+			t.Errorf("message %d is not pinned", i)
+		}
+	}
+	if err := c.PinMessage(session.ID, 5, true); err == nil {
+		t.Error("pinning a missing message should fail")
+	}
+
+	cwd, _ := os.Getwd()
+	file := filepath.Join(t.TempDir(), "spec.md")
+	os.WriteFile(file, []byte("spec"), 0644)
+	pin, err := c.AddPin(session.ID, context_manager.PinFile, file)
+	if err != nil || pin.ID != "p1" {
+		t.Fatalf("AddPin(file) = %+v, %v", pin, err)
+	}
+	if again, _ := c.AddPin(session.ID, context_manager.PinFile, file); again.ID != "p1" {
+		t.Errorf("pinning a file twice made %s", again.ID)
+	}
+	if _, err := c.AddPin(session.ID, context_manager.PinFile, filepath.Join(cwd, "missing.go")); err == nil {
+		t.Error("pinning a missing file should fail")
+	}
+	if pin, err = c.AddPin(session.ID, context_manager.PinNote, "tabs, not spaces"); err != nil || pin.ID != "p2" {
+		t.Fatalf("AddPin(note) = %+v, %v", pin, err)
+	}
+
+	items := c.PinnedContext(session.ID)
+	if len(items) != 5 || items[0].ID != "msg-0" || items[3].Kind != "file" || items[4].Preview != "tabs, not spaces" {
+		t.Fatalf("PinnedContext() = %+v", items)
+	}
+	status := c.GetContextStatus(session.ID)
+	if status.PinnedTokens == 0 || status.PinnedTokens > status.TokensUsed {
+		t.Errorf("PinnedTokens = %d of %d used", status.PinnedTokens, status.TokensUsed)
+	}
+
+	if err := c.Unpin(session.ID, "p1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Unpin(session.ID, "msg-0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Unpin(session.ID, "p9"); err == nil {
+		t.Error("removing a missing pin should fail")
+	}
+
+	// Pins survive a restart
+	reloaded := NewSessionManager(dir).GetSession(session.ID)
+	if reloaded == nil {
+		t.Fatal("session was not persisted")
+	}
+	if len(reloaded.Pins) != 1 || reloaded.Pins[0].Note != "tabs, not spaces" {
+		t.Errorf("reloaded pins = %+v", reloaded.Pins)
+	}
+	if msgs := reloaded.StateHandler.GetMessages(); msgs[0].Pinned || !msgs[2].Pinned {
+		t.Errorf("reloaded pinned flags = %v, %v", msgs[0].Pinned, msgs[2].Pinned)
+	}
+}
@@ -12,16 +12,18 @@ import (
 
 // TaskItem represents a single step in the agent's plan
 type TaskItem struct {
-	ID             string   `json:"id"`
-	Title          string   `json:"title"`
-	Status         string   `json:"status"` // "pending", "active", "done", "failed"
-	Context        string   `json:"context,omitempty"`
-	Dependencies   []string `json:"dependencies,omitempty"` // IDs of tasks that block this one
-	RetryCount     int      `json:"retry_count"`
-	MaxRetries     int      `json:"max_retries"`
-	Priority       int      `json:"priority"`        // 0=normal, 1=high, 2=critical
-	TimeoutSeconds int      `json:"timeout_seconds"` // 0 = no timeout
-	Output         string   `json:"output,omitempty"`
+	ID              string   `json:"id"`
+	Title           string   `json:"title"`
+	Status          string   `json:"status"` // "pending", "active", "done", "failed"
+	Context         string   `json:"context,omitempty"`
+	Dependencies    []string `json:"dependencies,omitempty"` // IDs of tasks that block this one
+	RetryCount      int      `json:"retry_count"`
+	MaxRetries      int      `json:"max_retries"`
+	Priority        int      `json:"priority"`        // 0=normal, 1=high, 2=critical
+	TimeoutSeconds  int      `json:"timeout_seconds"` // 0 = no timeout
+	Output          string   `json:"output,omitempty"`
+	Attempts        int      `json:"attempts,omitempty"`         // >1 = best-of-N: run N competing attempts and keep the winner
+	SelectionPolicy string   `json:"selection_policy,omitempty"` // "auto" or "manual" (overrides SwarmConfig)
 }
 
 // PlanManager handles the agent's long-term plan
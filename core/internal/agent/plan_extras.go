@@ -151,6 +151,30 @@ func (pm *PlanManager) SetTaskOutput(id, output string) error {
 	return pm.saveInternal()
 }
 
+// SetTaskAttempts configures best-of-N execution for a task
+func (pm *PlanManager) SetTaskAttempts(id string, attempts int, policy string) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	found := false
+	for i, task := range pm.Tasks {
+		if task.ID == id {
+			pm.Tasks[i].Attempts = attempts
+			if policy != "" {
+				pm.Tasks[i].SelectionPolicy = policy
+			}
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("task ID '%s' not found", id)
+	}
+
+	return pm.saveInternal()
+}
+
 // ValidatePlan checks for cycles in the dependency graph using DFS
 func (pm *PlanManager) ValidatePlan() error {
 	pm.mu.RLock()
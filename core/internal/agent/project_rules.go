@@ -0,0 +1,35 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/igoryan-dao/ricochet/internal/rules"
+)
+
+// RulesReport is how the project rules were scoped for a turn
+type RulesReport struct {
+	Mode   string             `json:"mode"`
+	Files  []string           `json:"files"`
+	Rules  []rules.Evaluation `json:"rules"`
+	Errors []string           `json:"errors,omitempty"` // Rule files skipped for bad frontmatter
+}
+
+// EffectiveRules reports which rules in .ricochet/rules apply, and why. mode
+// defaults to the active mode, and files to those the session has worked on.
+func (c *Controller) EffectiveRules(sessionID, mode string, files []string) (*RulesReport, error) {
+	if mode == "" {
+		mode = c.ActiveMode().Slug
+	}
+	if len(files) == 0 && sessionID != "" {
+		session := c.sessionManager.GetSession(sessionID)
+		if session == nil {
+			return nil, fmt.Errorf("session not found: %s", sessionID)
+		}
+		files = session.FileTracker.GetFiles()
+		sort.Strings(files)
+	}
+
+	evals, errs := c.rules.Evaluate(mode, files)
+	return &RulesReport{Mode: mode, Files: files, Rules: evals, Errors: errs}, nil
+}
@@ -0,0 +1,31 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/rules"
+)
+
+func TestEffectiveRules(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".ricochet", "rules"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".ricochet", "rules", "tests.md"), []byte("---\nglobs: \"*_test.go\"\n---\nTable-driven tests.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := &Controller{rules: rules.NewManager(dir)}
+
+	report, err := c.EffectiveRules("", "", []string{"a_test.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Mode != "code" || len(report.Rules) != 1 || !report.Rules[0].Applied {
+		t.Errorf("report: %+v", report)
+	}
+	if report, _ = c.EffectiveRules("", "test", []string{"main.go"}); report.Mode != "test" || report.Rules[0].Applied {
+		t.Errorf("report for main.go: %+v", report)
+	}
+}
@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/igoryan-dao/ricochet/internal/config"
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
 	"github.com/igoryan-dao/ricochet/internal/protocol"
+	"github.com/igoryan-dao/ricochet/internal/ratelimit"
 )
 
 // Provider represents an AI provider (Anthropic, OpenAI, OpenRouter)
@@ -33,6 +36,7 @@ type ChatRequest struct {
 	Temperature  float64            `json:"temperature,omitempty"`
 	Tools        []protocol.Tool    `json:"tools,omitempty"`
 	SystemPrompt string             `json:"system,omitempty"`
+	Routing      string             `json:"routing,omitempty"` // OpenRouter: price, latency or throughput
 }
 
 // ChatResponse represents a chat completion response
@@ -47,8 +51,10 @@ type ChatResponse struct {
 
 // Usage represents token usage
 type Usage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost,omitempty"`     // USD billed, when the provider reports it (OpenRouter)
+	Upstream     string  `json:"upstream,omitempty"` // Provider that served a routed request
 }
 
 // StreamChunk represents a streaming response chunk
@@ -58,6 +64,7 @@ type StreamChunk struct {
 	ReasoningDelta string                 `json:"reasoning_delta,omitempty"` // DeepSeek R1 reasoning
 	ToolUse        *protocol.ToolUseBlock `json:"tool_use,omitempty"`
 	StopReason     string                 `json:"stop_reason,omitempty"`
+	Usage          *Usage                 `json:"usage,omitempty"` // Provider-reported totals, on "usage" chunks
 }
 
 // ProviderConfig holds provider configuration
@@ -68,27 +75,47 @@ type ProviderConfig struct {
 	BaseURL      string `json:"base_url,omitempty"` // For custom endpoints
 	Organization string `json:"organization,omitempty"`
 	Project      string `json:"project,omitempty"`
+	APIVersion   string `json:"api_version,omitempty"` // Azure OpenAI api-version
+	Region       string `json:"region,omitempty"`      // AWS Bedrock region
+	Deployment   string `json:"deployment,omitempty"`  // Azure OpenAI deployment (defaults to Model)
+	Routing      string `json:"routing,omitempty"`     // Default OpenRouter routing preference
+	RPM          int    `json:"rpm,omitempty"`         // Requests per minute (0 = unlimited)
+	TPM          int    `json:"tpm,omitempty"`         // Tokens per minute (0 = unlimited)
 }
 
 // NewProvider creates a provider based on config
 func NewProvider(cfg ProviderConfig) (Provider, error) {
+	p, err := newProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// Route through the shared proxy/TLS transport with this provider's timeout
+	if s, ok := p.(httpClientSetter); ok {
+		s.setHTTPClient(httpclient.ForProvider(cfg.Provider, defaultProviderTimeout))
+	}
+	return withRateLimit(p, cfg), nil
+}
+
+func newProvider(cfg ProviderConfig) (Provider, error) {
 	switch strings.ToLower(cfg.Provider) {
 	case "anthropic":
 		return NewAnthropicProvider(cfg.APIKey, cfg.Model), nil
 	case "openai":
 		return NewOpenAIProvider(cfg.APIKey, cfg.Model, cfg.BaseURL, cfg.Organization, cfg.Project), nil
-	case "openrouter":
-		baseURL := "https://openrouter.ai/api/v1"
-		if cfg.BaseURL != "" {
-			baseURL = cfg.BaseURL
-		}
-		return NewOpenAIProvider(cfg.APIKey, cfg.Model, baseURL, "", ""), nil // OpenRouter doesn't use standard Org/Project headers
+	case config.OpenRouterProviderID:
+		return NewOpenRouterProvider(cfg.APIKey, cfg.Model, cfg.BaseURL), nil
 	case "xai":
 		return NewXAIProvider(cfg.APIKey, cfg.Model), nil
 	case "gemini":
 		return NewGeminiProvider(cfg.APIKey, cfg.Model), nil
 	case "minimax":
 		return NewMinimaxProvider(cfg.APIKey, cfg.Model), nil
+	case "azure", "azure-openai":
+		return NewAzureOpenAIProvider(cfg.APIKey, cfg.Model, cfg.BaseURL, cfg.Deployment, cfg.APIVersion)
+	case "bedrock":
+		return NewBedrockProvider(cfg.APIKey, cfg.Model, cfg.Region), nil
+	case config.LocalProviderID, "ollama", "lmstudio":
+		return NewLocalProvider(cfg.APIKey, cfg.Model, cfg.BaseURL), nil
 	case "deepseek":
 		return NewOpenAIProvider(cfg.APIKey, cfg.Model, "https://api.deepseek.com/v1", "", ""), nil
 	case "mistral":
@@ -96,7 +123,9 @@ func NewProvider(cfg ProviderConfig) (Provider, error) {
 		if cfg.BaseURL != "" {
 			baseURL = cfg.BaseURL
 		}
-		return NewOpenAIProvider(cfg.APIKey, cfg.Model, baseURL, "", ""), nil
+		p := NewOpenAIProvider(cfg.APIKey, cfg.Model, baseURL, "", "")
+		p.embedModel = "mistral-embed"
+		return p, nil
 	case "zhipu", "glm":
 		baseURL := "https://api.z.ai/api/paas/v4"
 		if cfg.BaseURL != "" {
@@ -108,21 +137,47 @@ func NewProvider(cfg ProviderConfig) (Provider, error) {
 	}
 }
 
-// httpClient is a shared HTTP client with a long timeout for AI requests
-var httpClient = &http.Client{
-	Timeout: 10 * time.Minute,
-	Transport: &http.Transport{
-		IdleConnTimeout:     90 * time.Second,
-		TLSHandshakeTimeout: 10 * time.Second,
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-	},
+// withProviderDefaults fills endpoint routing (base URL, api-version, region,
+// deployment) and rate limits from providers.yaml where the config leaves them unset
+func withProviderDefaults(cfg ProviderConfig, pm *config.ProvidersManager) ProviderConfig {
+	if pm == nil || cfg.Provider == "" {
+		return cfg
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = pm.GetBaseURL(cfg.Provider)
+	}
+	if cfg.APIVersion == "" {
+		cfg.APIVersion = pm.GetAPIVersion(cfg.Provider)
+	}
+	if cfg.Region == "" {
+		cfg.Region = pm.GetRegion(cfg.Provider)
+	}
+	if cfg.Deployment == "" {
+		cfg.Deployment = pm.GetDeployment(cfg.Provider, cfg.Model)
+	}
+	if cfg.RPM == 0 && cfg.TPM == 0 {
+		cfg.RPM, cfg.TPM = pm.GetRateLimits(cfg.Provider)
+	}
+	return cfg
+}
+
+// defaultProviderTimeout is the request timeout for AI requests unless network.timeouts overrides it
+const defaultProviderTimeout = 10 * time.Minute
+
+// httpClient is the fallback client for providers created without NewProvider
+var httpClient = httpclient.Client(defaultProviderTimeout)
+
+// httpClientSetter is implemented by providers that accept a per-provider client
+type httpClientSetter interface {
+	setHTTPClient(client *http.Client)
 }
 
 // doRequest performs an HTTP request and returns the response with retry logic
-func doRequest(ctx context.Context, method, url string, headers map[string]string, body io.Reader) (*http.Response, error) {
+func doRequest(ctx context.Context, client *http.Client, method, url string, headers map[string]string, body io.Reader) (*http.Response, error) {
+	if client == nil {
+		client = httpClient
+	}
+
 	// Read body into buffer for retries
 	var bodyBytes []byte
 	var err error
@@ -152,7 +207,7 @@ func doRequest(ctx context.Context, method, url string, headers map[string]strin
 			req.Header.Set(k, v)
 		}
 
-		resp, err := httpClient.Do(req)
+		resp, err := client.Do(req)
 		if err != nil {
 			// Check for transient errors or context cancellation that IS NOT the user hitting stop
 			// Actually, if context is canceled, we should probably stop unless we are detached?
@@ -171,6 +226,23 @@ func doRequest(ctx context.Context, method, url string, headers map[string]strin
 			return nil, err
 		}
 
+		// Rate limited: hold this provider's queue and retry after the advertised delay
+		if resp.StatusCode == http.StatusTooManyRequests {
+			wait := retryAfter(resp.Header, retryDelay)
+			ratelimit.Throttled(ctx, wait)
+			if i < maxRetries && wait <= maxRetryAfter {
+				log.Printf("[Network] API returned 429. Retrying in %v...", wait)
+				resp.Body.Close()
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+				retryDelay *= 2
+				continue
+			}
+		}
+
 		// Check for 5xx errors
 		if resp.StatusCode >= 500 {
 			if i < maxRetries {
@@ -187,3 +259,24 @@ func doRequest(ctx context.Context, method, url string, headers map[string]strin
 
 	return nil, fmt.Errorf("max retries exceeded")
 }
+
+// maxRetryAfter caps how long doRequest waits on a 429 before giving up
+const maxRetryAfter = 60 * time.Second
+
+// retryAfter parses a Retry-After header (seconds or HTTP date), falling back to def
+func retryAfter(h http.Header, def time.Duration) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return def
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+		return 0
+	}
+	return def
+}
@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"context"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/ratelimit"
+)
+
+// reservedOutputTokens is the completion budget held per request until the
+// real usage is known
+const reservedOutputTokens = 4096
+
+// rateLimitedProvider admits requests through the provider's shared limiter
+// (rpm/tpm from providers.yaml) and queues them fairly across sessions
+type rateLimitedProvider struct {
+	Provider
+	id     string
+	limits ratelimit.Limits
+}
+
+// withRateLimit wraps p with the limiter for cfg.Provider
+func withRateLimit(p Provider, cfg ProviderConfig) Provider {
+	return &rateLimitedProvider{
+		Provider: p,
+		id:       strings.ToLower(cfg.Provider),
+		limits:   ratelimit.Limits{RPM: cfg.RPM, TPM: cfg.TPM},
+	}
+}
+
+// unwrapProvider returns the underlying provider of a rate-limited one
+func unwrapProvider(p Provider) Provider {
+	if r, ok := p.(*rateLimitedProvider); ok {
+		return r.Provider
+	}
+	return p
+}
+
+func (r *rateLimitedProvider) acquire(ctx context.Context, tokens int) (context.Context, ratelimit.Release, error) {
+	l := ratelimit.For(r.id, r.limits)
+	release, err := l.Acquire(ctx, ratelimit.SessionFrom(ctx), tokens, ratelimit.ObserverFrom(ctx))
+	if err != nil {
+		return ctx, nil, err
+	}
+	return ratelimit.WithLimiter(ctx, l), release, nil
+}
+
+func (r *rateLimitedProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	input := estimateRequestTokens(req)
+	ctx, release, err := r.acquire(ctx, input+outputReservation(req))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.Provider.Chat(ctx, req)
+	if err != nil || resp == nil {
+		release(0)
+		return resp, err
+	}
+	if used := resp.Usage.InputTokens + resp.Usage.OutputTokens; used > 0 {
+		release(used)
+	} else {
+		release(input + len(resp.Content)/4)
+	}
+	return resp, nil
+}
+
+func (r *rateLimitedProvider) ChatStream(ctx context.Context, req *ChatRequest, callback StreamCallback) error {
+	input := estimateRequestTokens(req)
+	ctx, release, err := r.acquire(ctx, input+outputReservation(req))
+	if err != nil {
+		return err
+	}
+
+	var reported, outChars int
+	err = r.Provider.ChatStream(ctx, req, func(chunk *StreamChunk) error {
+		outChars += len(chunk.Delta) + len(chunk.ReasoningDelta)
+		if chunk.Usage != nil {
+			reported = chunk.Usage.InputTokens + chunk.Usage.OutputTokens
+		}
+		return callback(chunk)
+	})
+	switch {
+	case reported > 0:
+		release(reported)
+	case err != nil:
+		release(0) // Unknown how much was consumed; keep the reservation
+	default:
+		release(input + outChars/4)
+	}
+	return err
+}
+
+func (r *rateLimitedProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	tokens := 0
+	for _, t := range texts {
+		tokens += len(t) / 4
+	}
+	ctx, release, err := r.acquire(ctx, tokens)
+	if err != nil {
+		return nil, err
+	}
+	defer release(0)
+	return r.Provider.Embed(ctx, texts)
+}
+
+// EmbeddingModel forwards the wrapped provider's embedding model, if it reports one
+func (r *rateLimitedProvider) EmbeddingModel() string {
+	if m, ok := r.Provider.(interface{ EmbeddingModel() string }); ok {
+		return m.EmbeddingModel()
+	}
+	return ""
+}
+
+// estimateRequestTokens approximates the prompt size (char/4)
+func estimateRequestTokens(req *ChatRequest) int {
+	count := len(req.SystemPrompt) / 4
+	for _, msg := range req.Messages {
+		count += len(msg.Content) / 4
+		for _, tool := range msg.ToolUse {
+			count += len(tool.Input) / 4
+		}
+		for _, res := range msg.ToolResults {
+			count += len(res.Content) / 4
+		}
+	}
+	for _, tool := range req.Tools {
+		count += (len(tool.Name) + len(tool.Description)) / 4
+	}
+	return count
+}
+
+func outputReservation(req *ChatRequest) int {
+	if req.MaxTokens > 0 && req.MaxTokens < reservedOutputTokens {
+		return req.MaxTokens
+	}
+	return reservedOutputTokens
+}
@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+// RewindResult reports a rewind of a session to before one of its messages
+type RewindResult struct {
+	SessionID  string `json:"sessionId"`
+	MessageID  string `json:"messageId"`
+	Checkpoint string `json:"checkpoint,omitempty"` // Workspace state restored; empty when the agent wrote nothing since
+	Safety     string `json:"safety,omitempty"`     // Checkpoint of the state before the rewind, to undo it
+	Removed    int    `json:"removed"`              // History messages dropped
+	Prompt     string `json:"prompt"`               // The user prompt that was dropped, to edit and resend
+}
+
+// RewindToMessage undoes a whole answer: it restores the workspace to before
+// the turn a message belongs to and truncates the session history back to
+// the user prompt that started it. Message IDs are the "msg-<index>" IDs of
+// GetState and chat updates. The workspace is restored first, and history
+// is left untouched if that fails; chats can't start while it runs.
+func (c *Controller) RewindToMessage(sessionID, messageID string) (*RewindResult, error) {
+	c.abortMu.Lock()
+	defer c.abortMu.Unlock()
+	if c.abortCancel != nil {
+		return nil, fmt.Errorf("a reply is still running; stop it before rewinding")
+	}
+
+	session := c.GetSession(sessionID)
+	if session == nil {
+		return nil, fmt.Errorf("session '%s' not found", sessionID)
+	}
+	msgs := session.StateHandler.GetMessages()
+
+	index, err := messageIndex(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if index >= len(msgs) {
+		return nil, fmt.Errorf("message %s is not in session %s", messageID, sessionID)
+	}
+	start := promptIndex(msgs, index)
+	if start < 0 {
+		return nil, fmt.Errorf("message %s has no user prompt before it", messageID)
+	}
+
+	res := &RewindResult{
+		SessionID: sessionID,
+		MessageID: messageID,
+		Removed:   len(msgs) - start,
+		Prompt:    msgs[start].Content,
+	}
+
+	// The earliest checkpoint from this turn on holds the workspace before it
+	for _, msg := range msgs[start:] {
+		if isPrompt(msg) && msg.Checkpoint != "" {
+			res.Checkpoint = msg.Checkpoint
+			break
+		}
+	}
+	if res.Checkpoint != "" {
+		if c.safeguard == nil {
+			return nil, errNoCheckpoints
+		}
+		if res.Safety, err = c.safeguard.CreateCheckpoint(fmt.Sprintf("Before rewind to %s", messageID)); err != nil {
+			return nil, fmt.Errorf("failed to checkpoint before rewinding: %w", err)
+		}
+		if err := c.safeguard.RestoreCheckpoint(res.Checkpoint); err != nil {
+			return nil, fmt.Errorf("failed to restore checkpoint %.8s: %w", res.Checkpoint, err)
+		}
+	}
+
+	// A Plan Mode notice belongs to the prompt after it
+	if start > 0 && msgs[start-1].Role == "system" && msgs[start-1].Content == planModeNotice {
+		start--
+		res.Removed++
+	}
+	session.StateHandler.SetMessages(msgs[:start])
+	if err := c.sessionManager.Save(sessionID); err != nil {
+		return res, fmt.Errorf("workspace restored, but saving the truncated history failed: %w", err)
+	}
+	log.Printf("⏪ Rewound session %s to before %s (%d messages dropped)", sessionID, messageID, res.Removed)
+	return res, nil
+}
+
+// LastPromptID returns the ID of the session's latest user prompt, the
+// message to rewind to for undoing the last answer
+func (c *Controller) LastPromptID(sessionID string) (string, bool) {
+	session := c.GetSession(sessionID)
+	if session == nil {
+		return "", false
+	}
+	msgs := session.StateHandler.GetMessages()
+	if i := promptIndex(msgs, len(msgs)-1); i >= 0 {
+		return fmt.Sprintf("msg-%d", i), true
+	}
+	return "", false
+}
+
+// tagTurnCheckpoint records the checkpoint taken before a turn's first write
+// on the prompt that started the turn, keeping an earlier one
+func (c *Controller) tagTurnCheckpoint(session *Session, hash string) {
+	msgs := session.StateHandler.GetMessages()
+	i := promptIndex(msgs, len(msgs)-1)
+	if i < 0 || msgs[i].Checkpoint != "" {
+		return
+	}
+	msg := msgs[i]
+	msg.Checkpoint = hash
+	session.StateHandler.UpdateMessage(i, msg)
+}
+
+// messageIndex parses a "msg-<index>" message ID
+func messageIndex(messageID string) (int, error) {
+	n, ok := strings.CutPrefix(messageID, "msg-")
+	index, err := strconv.Atoi(n)
+	if !ok || err != nil || index < 0 {
+		return 0, fmt.Errorf("invalid message ID %q", messageID)
+	}
+	return index, nil
+}
+
+// promptIndex returns the index of the user prompt at or before index, or -1
+func promptIndex(msgs []protocol.Message, index int) int {
+	for i := index; i >= 0; i-- {
+		if isPrompt(msgs[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// isPrompt reports whether a history message is something the user typed,
+// rather than tool results sent back as a user message
+func isPrompt(msg protocol.Message) bool {
+	return msg.Role == "user" && len(msg.ToolResults) == 0
+}
@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+func newRewindController(t *testing.T) (*Controller, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	cwd := t.TempDir()
+	sg, err := safeguard.NewManager(cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Controller{sessionManager: NewSessionManager(t.TempDir()), safeguard: sg}, cwd
+}
+
+func writeWorkspaceFile(t *testing.T, cwd, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(cwd, "main.go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// runTurn adds a prompt and an answer that writes content, checkpointing
+// before the write like Chat does
+func runTurn(t *testing.T, c *Controller, s *Session, cwd, prompt, content string) {
+	t.Helper()
+	s.StateHandler.AddMessage(protocol.Message{Role: "user", Content: prompt})
+	s.StateHandler.AddMessage(protocol.Message{Role: "assistant", ToolUse: []protocol.ToolUseBlock{{ID: "t1", Name: "write_file"}}})
+	hash, err := c.safeguard.CreateCheckpoint(turnStartMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.tagTurnCheckpoint(s, hash)
+	writeWorkspaceFile(t, cwd, content)
+	s.StateHandler.AddMessage(protocol.Message{Role: "user", ToolResults: []protocol.ToolResultBlock{{ToolUseID: "t1", Content: "ok"}}})
+	s.StateHandler.AddMessage(protocol.Message{Role: "assistant", Content: "Done."})
+}
+
+func TestRewindToMessage(t *testing.T) {
+	c, cwd := newRewindController(t)
+	writeWorkspaceFile(t, cwd, "v0")
+	s := c.sessionManager.CreateSessionWithID("s1")
+
+	runTurn(t, c, s, cwd, "first", "v1")
+	runTurn(t, c, s, cwd, "second", "v2")
+
+	// Undo the answer to the first prompt, and everything after it
+	res, err := c.RewindToMessage("s1", "msg-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Prompt != "first" || res.Removed != 8 || res.Safety == "" {
+		t.Errorf("result = %+v", res)
+	}
+	if data, _ := os.ReadFile(filepath.Join(cwd, "main.go")); string(data) != "v0" {
+		t.Errorf("workspace = %q, want the state before the first answer", data)
+	}
+	if n := s.StateHandler.Count(); n != 0 {
+		t.Errorf("history has %d messages, want none", n)
+	}
+
+	// The rewind itself can be undone
+	if err := c.safeguard.RestoreCheckpoint(res.Safety); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(cwd, "main.go")); string(data) != "v2" {
+		t.Errorf("workspace after undoing the rewind = %q, want v2", data)
+	}
+}
+
+func TestRewindToMessage_LastAnswer(t *testing.T) {
+	c, cwd := newRewindController(t)
+	writeWorkspaceFile(t, cwd, "v0")
+	s := c.sessionManager.CreateSessionWithID("s1")
+
+	runTurn(t, c, s, cwd, "first", "v1")
+	// A prompt answered without writing anything
+	s.StateHandler.AddMessage(protocol.Message{Role: "user", Content: "explain"})
+	s.StateHandler.AddMessage(protocol.Message{Role: "assistant", Content: "It prints."})
+
+	id, ok := c.LastPromptID("s1")
+	if !ok || id != "msg-4" {
+		t.Fatalf("LastPromptID = %q, %v", id, ok)
+	}
+	res, err := c.RewindToMessage("s1", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Checkpoint != "" || res.Removed != 2 {
+		t.Errorf("result = %+v, want only history dropped", res)
+	}
+	if data, _ := os.ReadFile(filepath.Join(cwd, "main.go")); string(data) != "v1" {
+		t.Errorf("workspace = %q, want it untouched", data)
+	}
+	if n := s.StateHandler.Count(); n != 4 {
+		t.Errorf("history has %d messages, want 4", n)
+	}
+}
+
+func TestRewindToMessage_Invalid(t *testing.T) {
+	c, _ := newRewindController(t)
+	s := c.sessionManager.CreateSessionWithID("s1")
+	s.StateHandler.AddMessage(protocol.Message{Role: "user", Content: "hi"})
+
+	for _, id := range []string{"msg-5", "abc", "msg--1"} {
+		if _, err := c.RewindToMessage("s1", id); err == nil {
+			t.Errorf("RewindToMessage(%q) succeeded", id)
+		}
+	}
+	if _, err := c.RewindToMessage("missing", "msg-0"); err == nil {
+		t.Error("rewind of an unknown session succeeded")
+	}
+	if s.StateHandler.Count() != 1 {
+		t.Error("a failed rewind changed the history")
+	}
+}
@@ -0,0 +1,350 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	context_manager "github.com/igoryan-dao/ricochet/internal/context"
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+// Sessions are stored as append-only JSONL journals (<id>.jsonl): a snapshot
+// record followed by incremental records, so a save writes only what changed.
+// Journals are compacted back into a single snapshot in the background.
+
+const (
+	journalExt       = ".jsonl"
+	legacySessionExt = ".json"
+
+	// compactAfterRecords triggers background compaction once this many
+	// incremental records follow the last snapshot
+	compactAfterRecords = 256
+
+	// fsyncInterval bounds how often FsyncPeriodic syncs appends
+	fsyncInterval = time.Second
+)
+
+// FsyncPolicy controls when session journal appends are flushed to disk.
+// Snapshots are always written to a temp file, fsynced and renamed.
+type FsyncPolicy int
+
+const (
+	FsyncAlways   FsyncPolicy = iota // fsync every append (default)
+	FsyncPeriodic                    // fsync at most once per second
+	FsyncNever                       // leave flushing to the OS
+)
+
+// ParseFsyncPolicy reads the sessions.fsync setting; empty is FsyncAlways
+func ParseFsyncPolicy(s string) (FsyncPolicy, error) {
+	switch s {
+	case "", "always":
+		return FsyncAlways, nil
+	case "periodic":
+		return FsyncPeriodic, nil
+	case "never":
+		return FsyncNever, nil
+	}
+	return FsyncAlways, fmt.Errorf("unknown sessions.fsync policy %q", s)
+}
+
+// Journal record ops
+const (
+	opSnapshot = "snapshot"
+	opMessages = "messages" // Truncate to From, then append Messages
+	opTodos    = "todos"
+	opPins     = "pins"
+)
+
+type journalRecord struct {
+	Op       string                `json:"op"`
+	Session  *SessionData          `json:"session,omitempty"`
+	From     int                   `json:"from,omitempty"`
+	Messages []protocol.Message    `json:"messages,omitempty"`
+	Todos    []protocol.Todo       `json:"todos,omitempty"`
+	Pins     []context_manager.Pin `json:"pins,omitempty"`
+}
+
+// sessionJournal tracks what of a session is already on disk
+type sessionJournal struct {
+	mu          sync.Mutex
+	initialized bool   // A valid .jsonl with a snapshot exists
+	persisted   int    // Messages on disk
+	todos       string // JSON of the todos on disk
+	pins        string // JSON of the pins on disk
+	records     int    // Incremental records since the last snapshot
+	compacting  bool
+	lastSync    time.Time
+}
+
+func (m *SessionManager) journal(id string) *sessionJournal {
+	m.journalMu.Lock()
+	defer m.journalMu.Unlock()
+
+	j, ok := m.journals[id]
+	if !ok {
+		j = &sessionJournal{}
+		m.journals[id] = j
+	}
+	return j
+}
+
+func (m *SessionManager) journalPath(id string) string {
+	return filepath.Join(m.storageDir, id+journalExt)
+}
+
+// persist appends the session's unsaved changes to its journal
+func (m *SessionManager) persist(session *Session) error {
+	j := m.journal(session.ID)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	from, changed := session.StateHandler.TakeChanges(j.persisted)
+	todosJSON, _ := json.Marshal(session.Todos)
+	pinsJSON, _ := json.Marshal(session.Pins)
+
+	// A missing journal or a rewritten history (e.g. context condensing) needs a fresh snapshot
+	if !j.initialized || (from == 0 && j.persisted > 0) {
+		return m.writeSnapshot(session, j)
+	}
+
+	var records []journalRecord
+	if from < j.persisted || len(changed) > 0 {
+		records = append(records, journalRecord{Op: opMessages, From: from, Messages: changed})
+	}
+	if string(todosJSON) != j.todos {
+		records = append(records, journalRecord{Op: opTodos, Todos: session.Todos})
+	}
+	if string(pinsJSON) != j.pins {
+		records = append(records, journalRecord{Op: opPins, Pins: session.Pins})
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	if err := m.appendJournal(session.ID, buf.Bytes(), j); err != nil {
+		// The file may now end in a partial record; rewrite it on the next save
+		j.initialized = false
+		return err
+	}
+
+	j.persisted = from + len(changed)
+	j.todos = string(todosJSON)
+	j.pins = string(pinsJSON)
+	j.records += len(records)
+
+	if j.records >= compactAfterRecords && !j.compacting {
+		j.compacting = true
+		go func() {
+			if err := m.Compact(session.ID); err != nil {
+				log.Printf("Warning: failed to compact session %s: %v", session.ID, err)
+			}
+		}()
+	}
+	return nil
+}
+
+func (m *SessionManager) appendJournal(id string, data []byte, j *sessionJournal) error {
+	f, err := os.OpenFile(m.journalPath(id), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+
+	switch m.fsync {
+	case FsyncAlways:
+		return f.Sync()
+	case FsyncPeriodic:
+		if time.Since(j.lastSync) >= fsyncInterval {
+			j.lastSync = time.Now()
+			return f.Sync()
+		}
+	}
+	return nil
+}
+
+// writeSnapshot atomically replaces the journal with a single snapshot record.
+// Caller must hold j.mu and have called TakeChanges.
+func (m *SessionManager) writeSnapshot(session *Session, j *sessionJournal) error {
+	data := SessionData{
+		ID:        session.ID,
+		Messages:  session.StateHandler.GetMessages(),
+		Todos:     session.Todos,
+		Pins:      session.Pins,
+		CreatedAt: session.CreatedAt,
+	}
+
+	line, err := json.Marshal(journalRecord{Op: opSnapshot, Session: &data})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	path := m.journalPath(session.ID)
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	f.Close()
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	syncDir(m.storageDir)
+
+	todosJSON, _ := json.Marshal(data.Todos)
+	pinsJSON, _ := json.Marshal(data.Pins)
+	j.initialized = true
+	j.persisted = len(data.Messages)
+	j.todos = string(todosJSON)
+	j.pins = string(pinsJSON)
+	j.records = 0
+	j.lastSync = time.Now()
+
+	// The journal supersedes any pre-journal session file
+	os.Remove(filepath.Join(m.storageDir, session.ID+legacySessionExt))
+	return nil
+}
+
+// Compact rewrites a session's journal as a single snapshot
+func (m *SessionManager) Compact(id string) error {
+	if m.storageDir == "" {
+		return nil
+	}
+
+	m.mu.RLock()
+	session, ok := m.sessions[id]
+	m.mu.RUnlock()
+
+	j := m.journal(id)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.compacting = false
+
+	if !ok {
+		return fmt.Errorf("session not found: %s", id)
+	}
+
+	// Anything changed after this point is marked dirty again and appended on the next save
+	session.StateHandler.TakeChanges(j.persisted)
+	return m.writeSnapshot(session, j)
+}
+
+// loadJournal replays a session journal. A torn or corrupt tail (e.g. from a
+// crash mid-append) is dropped and the journal is rewritten on the next save.
+func loadJournal(path string) (*SessionData, *sessionJournal, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var sd *SessionData
+	j := &sessionJournal{initialized: true}
+	reader := bufio.NewReader(f)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			// Last line has no newline: the append never finished
+			j.initialized = false
+			break
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return nil, nil, err
+			}
+			break
+		}
+
+		var r journalRecord
+		if err := json.Unmarshal(line, &r); err != nil {
+			j.initialized = false
+			break
+		}
+
+		switch r.Op {
+		case opSnapshot:
+			if r.Session != nil {
+				sd = r.Session
+				j.records = 0
+			}
+		case opMessages:
+			if sd == nil || r.From > len(sd.Messages) {
+				j.initialized = false
+				break
+			}
+			sd.Messages = append(sd.Messages[:r.From], r.Messages...)
+			j.records++
+		case opTodos:
+			if sd != nil {
+				sd.Todos = r.Todos
+				j.records++
+			}
+		case opPins:
+			if sd != nil {
+				sd.Pins = r.Pins
+				j.records++
+			}
+		}
+		if !j.initialized {
+			break
+		}
+	}
+
+	if sd == nil {
+		return nil, nil, fmt.Errorf("no snapshot in %s", path)
+	}
+	if !j.initialized {
+		log.Printf("Warning: session journal %s has a damaged tail, recovered %d messages", filepath.Base(path), len(sd.Messages))
+	}
+
+	todosJSON, _ := json.Marshal(sd.Todos)
+	pinsJSON, _ := json.Marshal(sd.Pins)
+	j.persisted = len(sd.Messages)
+	j.todos = string(todosJSON)
+	j.pins = string(pinsJSON)
+	return sd, j, nil
+}
+
+// syncDir fsyncs a directory so a rename survives a crash (best effort)
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+func TestSessionJournal_AppendAndReload(t *testing.T) {
+	dir := t.TempDir()
+	sm := NewSessionManager(dir)
+	s := sm.CreateSessionWithID("s1")
+
+	s.StateHandler.AddMessage(protocol.Message{Role: "user", Content: "hello"})
+	s.StateHandler.AddMessage(protocol.Message{Role: "assistant", Content: "par"})
+	if err := sm.Save("s1"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// In-place streaming update of the last message plus a new todo list
+	s.StateHandler.UpdateMessage(1, protocol.Message{Role: "assistant", Content: "partial answer"})
+	s.Todos = []protocol.Todo{{Text: "write tests"}}
+	if err := sm.Save("s1"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if j := sm.journal("s1"); j.records != 3 {
+		t.Errorf("expected 3 incremental records, got %d", j.records)
+	}
+
+	reloaded := NewSessionManager(dir).GetSession("s1")
+	if reloaded == nil {
+		t.Fatal("session not reloaded")
+	}
+	msgs := reloaded.StateHandler.GetMessages()
+	if len(msgs) != 2 || msgs[1].Content != "partial answer" {
+		t.Errorf("unexpected messages after reload: %+v", msgs)
+	}
+	if len(reloaded.Todos) != 1 {
+		t.Errorf("expected todos to be reloaded, got %+v", reloaded.Todos)
+	}
+}
+
+func TestSessionJournal_TornTailAndCompaction(t *testing.T) {
+	dir := t.TempDir()
+	sm := NewSessionManager(dir)
+	s := sm.CreateSessionWithID("s1")
+	s.StateHandler.AddMessage(protocol.Message{Role: "user", Content: "one"})
+	sm.Save("s1")
+
+	// Simulate a crash mid-append
+	f, _ := os.OpenFile(filepath.Join(dir, "s1"+journalExt), os.O_WRONLY|os.O_APPEND, 0644)
+	f.WriteString(`{"op":"messages","from":1,"mess`)
+	f.Close()
+
+	sm2 := NewSessionManager(dir)
+	s2 := sm2.GetSession("s1")
+	if got := s2.StateHandler.Count(); got != 1 {
+		t.Fatalf("expected 1 recovered message, got %d", got)
+	}
+
+	// The damaged journal is rewritten as a snapshot on the next save
+	s2.StateHandler.AddMessage(protocol.Message{Role: "assistant", Content: "two"})
+	if err := sm2.Save("s1"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := sm2.Compact("s1"); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+
+	if got := NewSessionManager(dir).GetSession("s1").StateHandler.Count(); got != 2 {
+		t.Errorf("expected 2 messages after compaction, got %d", got)
+	}
+}
+
+func TestParseFsyncPolicy(t *testing.T) {
+	for s, want := range map[string]FsyncPolicy{"": FsyncAlways, "always": FsyncAlways, "periodic": FsyncPeriodic, "never": FsyncNever} {
+		if got, err := ParseFsyncPolicy(s); err != nil || got != want {
+			t.Errorf("ParseFsyncPolicy(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParseFsyncPolicy("sometimes"); err == nil {
+		t.Error("unknown policy accepted")
+	}
+}
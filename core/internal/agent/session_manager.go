@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,10 +17,11 @@ import (
 
 // SessionData is the persistable part of a session
 type SessionData struct {
-	ID        string             `json:"id"`
-	Messages  []protocol.Message `json:"messages"`
-	Todos     []protocol.Todo    `json:"todos"`
-	CreatedAt time.Time          `json:"created_at"`
+	ID        string                `json:"id"`
+	Messages  []protocol.Message    `json:"messages"`
+	Todos     []protocol.Todo       `json:"todos"`
+	Pins      []context_manager.Pin `json:"pins,omitempty"`
+	CreatedAt time.Time             `json:"created_at"`
 }
 
 // SessionManager handles concurrent agents and their persistence
@@ -27,6 +29,10 @@ type SessionManager struct {
 	mu         sync.RWMutex
 	sessions   map[string]*Session
 	storageDir string
+	fsync      FsyncPolicy
+
+	journalMu sync.Mutex
+	journals  map[string]*sessionJournal
 }
 
 func NewSessionManager(storageDir string) *SessionManager {
@@ -39,6 +45,7 @@ func NewSessionManager(storageDir string) *SessionManager {
 	manager := &SessionManager{
 		sessions:   make(map[string]*Session),
 		storageDir: storageDir,
+		journals:   make(map[string]*sessionJournal),
 	}
 
 	manager.LoadAll()
@@ -120,26 +127,17 @@ func (m *SessionManager) Save(id string) error {
 	return m.saveLocked(session)
 }
 
-// saveLocked saves a session to disk. It assumes the caller holds the lock (read or write).
+// SetFsyncPolicy sets when journal appends are flushed to disk
+func (m *SessionManager) SetFsyncPolicy(policy FsyncPolicy) {
+	m.fsync = policy
+}
+
+// saveLocked persists a session's changes since the last save. It assumes the caller holds the lock (read or write).
 func (m *SessionManager) saveLocked(session *Session) error {
 	if m.storageDir == "" {
 		return nil
 	}
-
-	data := SessionData{
-		ID:        session.ID,
-		Messages:  session.StateHandler.GetMessages(),
-		Todos:     session.Todos,
-		CreatedAt: session.CreatedAt,
-	}
-
-	bytes, err := json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	path := filepath.Join(m.storageDir, session.ID+".json")
-	return os.WriteFile(path, bytes, 0644)
+	return m.persist(session)
 }
 
 func (m *SessionManager) LoadAll() {
@@ -152,31 +150,60 @@ func (m *SessionManager) LoadAll() {
 		return
 	}
 
+	journaled := make(map[string]bool)
 	for _, f := range files {
-		if filepath.Ext(f.Name()) == ".json" {
-			data, err := os.ReadFile(filepath.Join(m.storageDir, f.Name()))
+		if filepath.Ext(f.Name()) == journalExt {
+			journaled[strings.TrimSuffix(f.Name(), journalExt)] = true
+		}
+	}
+
+	for _, f := range files {
+		var sd *SessionData
+		var j *sessionJournal
+
+		switch filepath.Ext(f.Name()) {
+		case journalExt:
+			sd, j, err = loadJournal(filepath.Join(m.storageDir, f.Name()))
 			if err != nil {
+				log.Printf("Warning: failed to load session %s: %v", f.Name(), err)
 				continue
 			}
-
-			var sd SessionData
-			if err := json.Unmarshal(data, &sd); err != nil {
+		case legacySessionExt:
+			// Pre-journal format; converted to a journal on the next save
+			if journaled[strings.TrimSuffix(f.Name(), legacySessionExt)] {
 				continue
 			}
-
-			session := &Session{
-				ID:           sd.ID,
-				StateHandler: NewMessageStateHandler(sd.ID),
-				FileTracker:  context_manager.NewFileTracker(),
-				Todos:        sd.Todos,
-				CreatedAt:    sd.CreatedAt,
+			data, err := os.ReadFile(filepath.Join(m.storageDir, f.Name()))
+			if err != nil {
+				continue
 			}
-			session.StateHandler.SetMessages(sd.Messages)
+			sd = &SessionData{}
+			if err := json.Unmarshal(data, sd); err != nil {
+				continue
+			}
+			j = &sessionJournal{}
+		default:
+			continue
+		}
 
-			m.mu.Lock()
-			m.sessions[sd.ID] = session
-			m.mu.Unlock()
+		session := &Session{
+			ID:           sd.ID,
+			StateHandler: NewMessageStateHandler(sd.ID),
+			FileTracker:  context_manager.NewFileTracker(),
+			Todos:        sd.Todos,
+			Pins:         sd.Pins,
+			CreatedAt:    sd.CreatedAt,
 		}
+		session.StateHandler.SetMessages(sd.Messages)
+		session.StateHandler.TakeChanges(len(sd.Messages)) // Loaded messages are already on disk
+
+		m.mu.Lock()
+		m.sessions[sd.ID] = session
+		m.mu.Unlock()
+
+		m.journalMu.Lock()
+		m.journals[sd.ID] = j
+		m.journalMu.Unlock()
 	}
 }
 
@@ -185,9 +212,13 @@ func (m *SessionManager) DeleteSession(id string) error {
 	delete(m.sessions, id)
 	m.mu.Unlock()
 
+	m.journalMu.Lock()
+	delete(m.journals, id)
+	m.journalMu.Unlock()
+
 	if m.storageDir != "" {
-		path := filepath.Join(m.storageDir, id+".json")
-		os.Remove(path)
+		os.Remove(m.journalPath(id))
+		os.Remove(filepath.Join(m.storageDir, id+legacySessionExt))
 	}
 	return nil
 }
@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+const (
+	minMemorizedTurns  = 3     // User messages a session needs before it's worth memorizing
+	maxTranscriptChars = 24000 // Of a session's end, sent to the summarizer
+	maxMessageChars    = 2000
+	memorizeTimeout    = 2 * time.Minute
+)
+
+const memorizePrompt = `You read a finished chat between a developer and a coding assistant and extract durable facts about the project, for future sessions to know from the start.
+
+Keep only facts likely to stay true and useful later: build, test, lint and run commands, package managers and tools, conventions, architecture, where things live, the developer's stated preferences. Skip the task itself, temporary state, guesses, file contents and anything under "Already known".
+
+Reply with a JSON array of at most 8 short, self-contained facts and nothing else, e.g. ["Project uses pnpm, not npm", "Tests run with make test"]. Reply [] if there is nothing durable.`
+
+// endSession memorizes a finished session in the background
+func (c *Controller) endSession(session *Session) {
+	if session == nil || c.memoryManager == nil || c.config == nil || c.config.Memory.DisableLearning {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), memorizeTimeout)
+		defer cancel()
+		if err := c.memorizeSession(ctx, session); err != nil {
+			log.Printf("[Memory] Failed to learn from session %s: %v", session.ID, err)
+		}
+	}()
+}
+
+// memorizeSession asks the model for the durable facts in a session and
+// adds them to the project memory. Sessions with fewer than
+// minMemorizedTurns new user messages since the last time are skipped.
+func (c *Controller) memorizeSession(ctx context.Context, session *Session) error {
+	c.memorizeMu.Lock()
+	defer c.memorizeMu.Unlock()
+
+	messages := session.StateHandler.GetMessages()
+	done := c.memorized[session.ID]
+	if done > len(messages) {
+		done = 0 // Rewound or cleared
+	}
+	turns := 0
+	for _, m := range messages[done:] {
+		if text := strings.TrimSpace(m.Content); m.Role == "user" && text != "" && !strings.HasPrefix(text, "/") {
+			turns++
+		}
+	}
+	if turns < minMemorizedTurns {
+		return nil
+	}
+
+	var known strings.Builder
+	for _, item := range c.memoryManager.GetAll() {
+		fmt.Fprintf(&known, "- %s\n", item.Value)
+	}
+	user := fmt.Sprintf("Already known:\n%s\nChat:\n%s", known.String(), sessionTranscript(messages))
+
+	c.mu.RLock()
+	provider, model := c.provider, c.defaultModel
+	c.mu.RUnlock()
+	resp, err := provider.Chat(ctx, &ChatRequest{
+		Model: model,
+		Messages: []protocol.Message{
+			{Role: "system", Content: memorizePrompt},
+			{Role: "user", Content: user},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	facts, err := parseFacts(resp.Content)
+	if err != nil {
+		return err
+	}
+
+	review := !c.config.Memory.AutoApprove
+	added, confirmed, err := c.memoryManager.Learn(facts, review)
+	if err != nil {
+		return err
+	}
+	if c.memorized == nil {
+		c.memorized = make(map[string]int)
+	}
+	c.memorized[session.ID] = len(messages)
+	if review && added > 0 {
+		log.Printf("[Memory] Learned from session %s: %d new facts to review with /memory review, %d confirmed", session.ID, added, confirmed)
+	} else if added+confirmed > 0 {
+		log.Printf("[Memory] Learned from session %s: %d new facts, %d confirmed", session.ID, added, confirmed)
+	}
+	return nil
+}
+
+// sessionTranscript renders the end of a session's chat as text, tool
+// results left out
+func sessionTranscript(messages []protocol.Message) string {
+	var parts []string
+	size := 0
+	for i := len(messages) - 1; i >= 0 && size < maxTranscriptChars; i-- {
+		m := messages[i]
+		text := strings.TrimSpace(m.Content)
+		for _, tool := range m.ToolUse {
+			text += fmt.Sprintf("\n[called %s %s]", tool.Name, truncateString(string(tool.Input), 200))
+		}
+		if text == "" || (m.Role != "user" && m.Role != "assistant") {
+			continue
+		}
+		part := fmt.Sprintf("%s: %s", m.Role, truncateString(text, maxMessageChars))
+		parts = append([]string{part}, parts...)
+		size += len(part)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// parseFacts reads the summarizer's JSON array, tolerating code fences and
+// text around it
+func parseFacts(reply string) ([]string, error) {
+	start, end := strings.Index(reply, "["), strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no fact list in the reply: %q", truncateString(reply, 200))
+	}
+	var facts []string
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &facts); err != nil {
+		return nil, fmt.Errorf("invalid fact list: %w", err)
+	}
+	return facts, nil
+}
@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/memory"
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+// factsProvider answers every chat with a fixed reply and keeps the prompts
+type factsProvider struct {
+	reply   string
+	prompts []string
+}
+
+func (p *factsProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	p.prompts = append(p.prompts, req.Messages[len(req.Messages)-1].Content)
+	return &ChatResponse{Content: p.reply}, nil
+}
+
+func (p *factsProvider) ChatStream(ctx context.Context, req *ChatRequest, callback StreamCallback) error {
+	return fmt.Errorf("not supported")
+}
+
+func (p *factsProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (p *factsProvider) Name() string { return "facts" }
+
+func newMemorizingController(t *testing.T, reply string) (*Controller, *factsProvider) {
+	t.Helper()
+	mgr, err := memory.NewManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &factsProvider{reply: reply}
+	return &Controller{
+		sessionManager: NewSessionManager(t.TempDir()),
+		memoryManager:  mgr,
+		provider:       provider,
+		config:         &Config{},
+	}, provider
+}
+
+func chatTurns(session *Session, n int) {
+	for i := 0; i < n; i++ {
+		session.StateHandler.AddMessage(protocol.Message{Role: "user", Content: fmt.Sprintf("question %d", i)})
+		session.StateHandler.AddMessage(protocol.Message{Role: "assistant", Content: fmt.Sprintf("answer %d", i)})
+	}
+}
+
+func TestMemorizeSession(t *testing.T) {
+	c, provider := newMemorizingController(t, "```json\n[\"Project uses pnpm\", \"Tests run with make test\"]\n```")
+	session := c.CreateSession()
+
+	chatTurns(session, 2)
+	if err := c.memorizeSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.prompts) != 0 {
+		t.Fatal("a two-turn session was memorized")
+	}
+
+	chatTurns(session, 1)
+	if err := c.memorizeSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.memoryManager.Pending()) != 2 || len(c.memoryManager.Learned()) != 0 {
+		t.Fatalf("pending %+v, learned %+v; want new facts pending review", c.memoryManager.Pending(), c.memoryManager.Learned())
+	}
+	if !strings.Contains(provider.prompts[0], "user: question 1\n\nassistant: answer 1") {
+		t.Errorf("transcript missing from the prompt: %q", provider.prompts[0])
+	}
+
+	// Nothing new since
+	if err := c.memorizeSession(context.Background(), session); err != nil || len(provider.prompts) != 1 {
+		t.Errorf("memorized again without new turns: %d prompts, %v", len(provider.prompts), err)
+	}
+}
+
+func TestParseFacts(t *testing.T) {
+	facts, err := parseFacts("Here you go:\n[\"a\", \"b\"]")
+	if err != nil || len(facts) != 2 {
+		t.Errorf("parseFacts = %v, %v", facts, err)
+	}
+	if _, err := parseFacts("Nothing to add."); err == nil {
+		t.Error("expected an error without a list")
+	}
+}
+
+func TestMemoryCommand_Review(t *testing.T) {
+	c, _ := newMemorizingController(t, "")
+	c.memoryManager.Learn([]string{"Project uses pnpm", "Tests run with make test", "Deploys go through Heroku"}, true)
+
+	run := func(args string) string {
+		var reply string
+		c.handleMemoryCommand("s", args, func(update interface{}) {
+			reply = update.(ChatUpdate).Message.Content
+		})
+		return reply
+	}
+	review := run("review")
+	if !strings.Contains(review, "1. ") || !strings.Contains(review, "3. ") {
+		t.Fatalf("review = %q", review)
+	}
+	first := c.memoryManager.Pending()[0].Value
+
+	if reply := run("approve 1"); !strings.Contains(reply, "Kept 1") {
+		t.Errorf("approve = %q", reply)
+	}
+	if reply := run("reject 5"); !strings.Contains(reply, "❌") {
+		t.Errorf("reject of a missing fact = %q", reply)
+	}
+	if reply := run("reject all"); !strings.Contains(reply, "Dropped 2") {
+		t.Errorf("reject all = %q", reply)
+	}
+	learned := c.memoryManager.Learned()
+	if len(learned) != 1 || learned[0].Value != first || len(c.memoryManager.Pending()) != 0 {
+		t.Errorf("after review: learned %+v, pending %+v", learned, c.memoryManager.Pending())
+	}
+	if !strings.Contains(c.memoryManager.GetSystemPromptPart(), "- "+first) {
+		t.Error("approved fact not in the system prompt part")
+	}
+}
@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+	"github.com/igoryan-dao/ricochet/internal/skills"
+)
+
+const skillsUsage = "Usage: `/skills`, `/skills enable|disable <name>` or `/skills import <git-url> [name]`"
+
+// handleSkillsCommand lists skills with their matchers, toggles them and
+// imports them from git
+func (c *Controller) handleSkillsCommand(sessionID, args string, callback func(update interface{})) error {
+	if c.skills == nil {
+		return c.sendReply(sessionID, "Skills are not available.", callback)
+	}
+	fields := strings.Fields(args)
+	if len(fields) == 0 || fields[0] == "list" {
+		return c.sendReply(sessionID, FormatSkills(c.skills.Skills(), c.skills.IsEnabled), callback)
+	}
+
+	switch fields[0] {
+	case "enable", "disable":
+		if len(fields) != 2 {
+			return c.sendReply(sessionID, skillsUsage, callback)
+		}
+		enabled := fields[0] == "enable"
+		if err := c.skills.SetEnabled(fields[1], enabled); err != nil {
+			return c.sendReply(sessionID, fmt.Sprintf("❌ %v. Run /skills to list them.", err), callback)
+		}
+		reply := fmt.Sprintf("✅ Skill `%s` %sd", fields[1], fields[0])
+		if err := c.saveSkillSettings(); err != nil {
+			reply += fmt.Sprintf(" for this session only: %v", err)
+		}
+		return c.sendReply(sessionID, reply, callback)
+
+	case "import":
+		if len(fields) < 2 || len(fields) > 3 {
+			return c.sendReply(sessionID, skillsUsage, callback)
+		}
+		name := ""
+		if len(fields) == 3 {
+			name = fields[2]
+		}
+		return c.sendReply(sessionID, c.importSkills(fields[1], name), callback)
+	}
+	return c.sendReply(sessionID, skillsUsage, callback)
+}
+
+// saveSkillSettings persists the disabled skills to settings.json
+func (c *Controller) saveSkillSettings() error {
+	if c.settings == nil {
+		return fmt.Errorf("settings are not available")
+	}
+	disabled := c.skills.Disabled()
+	return c.settings.Update(func(s *config.Settings) {
+		s.Skills.Disabled = disabled
+	})
+}
+
+// importSkills installs skills from a git URL, subject to the egress policy,
+// and warns about imported skills that look like prompt injections
+func (c *Controller) importSkills(source, name string) string {
+	if host := gitHost(source); host != "" {
+		if err := c.safeguard.CheckEgress(host); err != nil {
+			return fmt.Sprintf("❌ %v", err)
+		}
+	}
+	installed, err := c.skills.Import(source, name)
+	if len(installed) == 0 {
+		return fmt.Sprintf("❌ %v", err)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "✅ Installed %d skill(s) into `.ricochet/skills`:\n", len(installed))
+	loaded := make(map[string]skills.SkillRule)
+	for _, rule := range c.skills.Skills() {
+		loaded[rule.Name] = rule
+	}
+	for _, skill := range installed {
+		fmt.Fprintf(&sb, "- `%s`\n", skill)
+		if rule, ok := loaded[skill]; ok {
+			if _, signs := c.safeguard.ScreenContent(safeguard.SourceFile, skill, rule.Content); len(signs) > 0 {
+				fmt.Fprintf(&sb, "  ⚠️ looks like a prompt injection (%s); review it or `/skills disable %s`\n", strings.Join(signs, ", "), skill)
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(&sb, "⚠️ Reloading skills: %v\n", err)
+	}
+	return sb.String()
+}
+
+var scpLikeGit = regexp.MustCompile(`^[\w.-]+@([\w.-]+):`)
+
+// gitHost returns the host a git source is fetched from, or "" for a local path
+func gitHost(source string) string {
+	if strings.Contains(source, "://") {
+		if u, err := url.Parse(source); err == nil && u.Scheme != "file" {
+			return u.Hostname()
+		}
+		return ""
+	}
+	if m := scpLikeGit.FindStringSubmatch(source); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// FormatSkills renders the skills with their enforcement level, what
+// activates them and whether they are enabled
+func FormatSkills(list []skills.SkillRule, enabled func(string) bool) string {
+	if len(list) == 0 {
+		return "No skills loaded. Add one in `.ricochet/skills/<name>/SKILL.md` or `/skills import <git-url>`."
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**Skills** (%d)\n\n", len(list))
+	for _, rule := range list {
+		state := "✅"
+		if !enabled(rule.Name) {
+			state = "⏸️"
+		}
+		enforcement := rule.Enforcement
+		if enforcement == "" {
+			enforcement = "no enforcement"
+		}
+		fmt.Fprintf(&sb, "%s `%s` — %s, %s\n", state, rule.Name, enforcement, rule.Type)
+		fmt.Fprintf(&sb, "   %s\n", skillMatchers(rule))
+	}
+	sb.WriteString("\n" + skillsUsage)
+	return sb.String()
+}
+
+// skillMatchers summarises the triggers that activate a skill
+func skillMatchers(rule skills.SkillRule) string {
+	var parts []string
+	add := func(label string, values []string) {
+		if len(values) > 0 {
+			parts = append(parts, label+": "+strings.Join(values, ", "))
+		}
+	}
+	add("keywords", rule.PromptTriggers.Keywords)
+	add("intents", rule.PromptTriggers.IntentPatterns)
+	add("paths", rule.FileTriggers.PathPatterns)
+	add("content", rule.FileTriggers.ContentPatterns)
+	if len(parts) == 0 {
+		return "no matchers, never activated"
+	}
+	return strings.Join(parts, " · ")
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+	"github.com/igoryan-dao/ricochet/internal/skills"
+)
+
+func TestSkillsCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	skillDir := filepath.Join(dir, ".ricochet", "skills", "sql")
+	if err := os.MkdirAll(skillDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte("---\nenforcement: force\ntriggers:\n  keywords: [migration]\n  pathPatterns: [\"**/*.sql\"]\n---\nWrite reversible migrations.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mgr := skills.NewManager(dir)
+	if err := mgr.LoadSkills(); err != nil {
+		t.Fatal(err)
+	}
+	store, err := config.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Controller{skills: mgr, settings: store}
+	var reply string
+	callback := func(update interface{}) { reply = update.(ChatUpdate).Message.Content }
+
+	c.handleSkillsCommand("s1", "", callback)
+	if !strings.Contains(reply, "✅ `sql` — force, dynamic\n   keywords: migration · paths: **/*.sql") {
+		t.Errorf("list:\n%s", reply)
+	}
+
+	c.handleSkillsCommand("s1", "disable sql", callback)
+	if reply != "✅ Skill `sql` disabled" || mgr.IsEnabled("sql") {
+		t.Errorf("disable: %s", reply)
+	}
+	reloaded, err := config.NewStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.Get().Skills.Disabled; len(got) != 1 || got[0] != "sql" {
+		t.Errorf("saved disabled skills = %v", got)
+	}
+	c.handleSkillsCommand("s1", "", callback)
+	if !strings.Contains(reply, "⏸️ `sql`") {
+		t.Errorf("list after disable:\n%s", reply)
+	}
+
+	c.handleSkillsCommand("s1", "enable nope", callback)
+	if !strings.Contains(reply, "skill nope not found") {
+		t.Errorf("unknown skill: %s", reply)
+	}
+}
+
+func TestGitHost(t *testing.T) {
+	for source, want := range map[string]string{
+		"https://github.com/acme/skills.git": "github.com",
+		"git@gitlab.example.com:acme/skills": "gitlab.example.com",
+		"file:///tmp/skills":                 "",
+		"../skills":                          "",
+	} {
+		if got := gitHost(source); got != want {
+			t.Errorf("gitHost(%s) = %q, want %q", source, got, want)
+		}
+	}
+}
@@ -14,6 +14,7 @@ type MessageStateHandler struct {
 	messages  []protocol.Message
 	sessionID string
 	updatedAt time.Time
+	dirtyFrom int // Lowest index changed in place since the last TakeChanges, -1 if none
 }
 
 // NewMessageStateHandler creates a new handler
@@ -22,6 +23,7 @@ func NewMessageStateHandler(sessionID string) *MessageStateHandler {
 		messages:  make([]protocol.Message, 0),
 		sessionID: sessionID,
 		updatedAt: time.Now(),
+		dirtyFrom: -1,
 	}
 }
 
@@ -39,6 +41,7 @@ func (h *MessageStateHandler) SetMessages(msgs []protocol.Message) {
 	defer h.mu.Unlock()
 	h.messages = msgs
 	h.updatedAt = time.Now()
+	h.dirtyFrom = 0
 }
 
 // UpdateMessage updates a message at a specific index (useful for streaming partial updates)
@@ -48,9 +51,33 @@ func (h *MessageStateHandler) UpdateMessage(index int, msg protocol.Message) {
 	if index >= 0 && index < len(h.messages) {
 		h.messages[index] = msg
 		h.updatedAt = time.Now()
+		if h.dirtyFrom < 0 || index < h.dirtyFrom {
+			h.dirtyFrom = index
+		}
 	}
 }
 
+// TakeChanges returns the messages that differ from a persisted prefix of
+// length persisted: everything from the returned index on must be rewritten.
+// It resets change tracking, so callers must persist what it returns.
+func (h *MessageStateHandler) TakeChanges(persisted int) (from int, changed []protocol.Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	from = persisted
+	if h.dirtyFrom >= 0 && h.dirtyFrom < from {
+		from = h.dirtyFrom
+	}
+	if from > len(h.messages) {
+		from = len(h.messages)
+	}
+	h.dirtyFrom = -1
+
+	changed = make([]protocol.Message, len(h.messages)-from)
+	copy(changed, h.messages[from:])
+	return from, changed
+}
+
 // GetMessages returns a copy of the messages
 func (h *MessageStateHandler) GetMessages() []protocol.Message {
 	h.mu.RLock()
@@ -12,7 +12,9 @@ import (
 
 // SwarmConfig holds configuration for the orchestrator
 type SwarmConfig struct {
-	MaxWorkers int `json:"max_workers"`
+	MaxWorkers      int    `json:"max_workers"`
+	BestOf          int    `json:"best_of,omitempty"`          // Default number of competing attempts per task (0/1 = disabled)
+	SelectionPolicy string `json:"selection_policy,omitempty"` // "auto" (pick top-ranked) or "manual" (ask the user)
 }
 
 // SwarmOrchestrator manages parallel execution of tasks
@@ -24,6 +26,10 @@ type SwarmOrchestrator struct {
 	mu         sync.Mutex
 	active     bool
 	paused     bool
+	// workspaceMu keeps best-of runs, which restore checkpoints over the
+	// whole workspace, from overlapping other workers: plain tasks share it,
+	// a best-of run holds it alone
+	workspaceMu sync.RWMutex
 }
 
 // NewSwarmOrchestrator creates a new orchestrator
@@ -178,7 +184,15 @@ func (so *SwarmOrchestrator) loop(ctx context.Context) {
 						defer cancel()
 					}
 
-					output, err := so.controller.RunSubtask(taskCtx, "SWARM_ROOT", t.Title, t.Context, "swarm-worker")
+					var output string
+					var err error
+					if attempts := so.attemptsFor(t); attempts > 1 {
+						output, err = so.runBestOf(taskCtx, t, attempts)
+					} else {
+						so.workspaceMu.RLock()
+						output, err = so.controller.RunSubtask(taskCtx, "SWARM_ROOT", t.Title, t.Context, "swarm-worker")
+						so.workspaceMu.RUnlock()
+					}
 
 					if err != nil {
 						log.Printf("❌ Task %s failed: %v", t.ID, err)
@@ -0,0 +1,233 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+	"github.com/igoryan-dao/ricochet/internal/tools"
+)
+
+// Selection policies for best-of swarm tasks
+const (
+	SelectionAuto   = "auto"   // Merge the top-ranked attempt without asking
+	SelectionManual = "manual" // Present the ranking and let the user pick
+)
+
+// AttemptResult captures the outcome of one competing attempt at a swarm task
+type AttemptResult struct {
+	Index      int     `json:"index"`
+	Checkpoint string  `json:"checkpoint"` // Shadow-git hash holding this attempt's workspace state
+	Files      int     `json:"files"`
+	Additions  int     `json:"additions"`
+	Deletions  int     `json:"deletions"`
+	QCPassed   bool    `json:"qc_passed"`
+	QCCommand  string  `json:"qc_command,omitempty"`
+	Cost       float64 `json:"cost"`
+	Output     string  `json:"output,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// DiffSize returns the total number of changed lines
+func (a AttemptResult) DiffSize() int {
+	return a.Additions + a.Deletions
+}
+
+// RankAttempts orders attempts best-first:
+// completed runs beat failed ones, passing QC beats failing QC,
+// then smaller diffs win, then cheaper runs win.
+func RankAttempts(attempts []AttemptResult) []AttemptResult {
+	ranked := make([]AttemptResult, len(attempts))
+	copy(ranked, attempts)
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if (a.Error == "") != (b.Error == "") {
+			return a.Error == ""
+		}
+		if a.QCPassed != b.QCPassed {
+			return a.QCPassed
+		}
+		if a.DiffSize() != b.DiffSize() {
+			return a.DiffSize() < b.DiffSize()
+		}
+		return a.Cost < b.Cost
+	})
+
+	return ranked
+}
+
+// FormatAttemptComparison renders a ranked comparison table for the UI
+func FormatAttemptComparison(taskTitle string, ranked []AttemptResult) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("### 🏁 Best-of-%d: %s\n\n", len(ranked), taskTitle))
+	sb.WriteString("| Rank | Attempt | Status | QC | Files | Diff | Cost |\n")
+	sb.WriteString("|---|---|---|---|---|---|---|\n")
+
+	for i, a := range ranked {
+		status := "✅ done"
+		if a.Error != "" {
+			status = "❌ " + truncateString(a.Error, 40)
+		}
+		qcStatus := "❌"
+		if a.QCPassed {
+			qcStatus = "✅"
+		}
+		if a.QCCommand == "" {
+			qcStatus = "n/a"
+		}
+		sb.WriteString(fmt.Sprintf("| %d | #%d | %s | %s | %d | +%d/-%d | $%.4f |\n",
+			i+1, a.Index, status, qcStatus, a.Files, a.Additions, a.Deletions, a.Cost))
+	}
+
+	return sb.String()
+}
+
+// attemptsFor resolves how many competing attempts a task should get
+func (so *SwarmOrchestrator) attemptsFor(t TaskItem) int {
+	if t.Attempts > 0 {
+		return t.Attempts
+	}
+	return so.config.BestOf
+}
+
+// selectionPolicyFor resolves the winner selection policy for a task
+func (so *SwarmOrchestrator) selectionPolicyFor(t TaskItem) string {
+	if t.SelectionPolicy != "" {
+		return t.SelectionPolicy
+	}
+	if so.config.SelectionPolicy != "" {
+		return so.config.SelectionPolicy
+	}
+	return SelectionAuto
+}
+
+// runBestOf runs n competing attempts at the same task, snapshotting each one as a
+// shadow-git checkpoint, verifying it with QC, and merging the winner back into the workspace.
+// Attempts share the workspace, so they run one after another from the same base checkpoint,
+// and no other swarm task runs meanwhile.
+func (so *SwarmOrchestrator) runBestOf(ctx context.Context, t TaskItem, n int) (string, error) {
+	sg := so.controller.safeguard
+	if sg == nil {
+		log.Printf("⚠️ [Swarm] Best-of requested for task %s but checkpoints are unavailable. Running a single attempt.", t.ID)
+		return so.controller.RunSubtask(ctx, "SWARM_ROOT", t.Title, t.Context, "swarm-worker")
+	}
+
+	// Restoring checkpoints would clobber the files of workers running
+	// alongside, so wait for them and keep new ones out until done
+	so.workspaceMu.Lock()
+	defer so.workspaceMu.Unlock()
+
+	base, err := sg.CreateCheckpoint(fmt.Sprintf("Swarm %s: base before best-of-%d", t.ID, n))
+	if err != nil {
+		return "", fmt.Errorf("best-of base checkpoint: %w", err)
+	}
+
+	agentID := fmt.Sprintf("Swarm-%s", t.ID)
+	var results []AttemptResult
+
+	for i := 1; i <= n; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		if i > 1 {
+			if err := sg.RestoreCheckpoint(base); err != nil {
+				return "", fmt.Errorf("reset to base for attempt %d: %w", i, err)
+			}
+		}
+
+		so.controller.ReportTaskProgress(ctx, protocol.TaskProgress{
+			TaskName:        t.Title,
+			Status:          fmt.Sprintf("Attempt %d/%d", i, n),
+			Mode:            "execution",
+			IsActive:        true,
+			AgentIdentifier: agentID,
+			AgentColor:      "#00FF99",
+		})
+
+		session := so.controller.CreateSession()
+		output, runErr := so.controller.runSubtaskInSession(ctx, session, "SWARM_ROOT", t.Title, t.Context, "swarm-worker")
+
+		res := AttemptResult{Index: i, Output: output, Cost: session.TotalCost}
+		if runErr != nil {
+			res.Error = runErr.Error()
+		} else {
+			var sub tools.SubtaskResult
+			if json.Unmarshal([]byte(output), &sub) == nil && sub.Status == "failed" {
+				res.Error = sub.Error
+			}
+		}
+
+		hash, cpErr := sg.CreateCheckpoint(fmt.Sprintf("Swarm %s: attempt %d/%d", t.ID, i, n))
+		if cpErr != nil {
+			log.Printf("⚠️ [Swarm] Failed to checkpoint attempt %d of task %s: %v", i, t.ID, cpErr)
+			res.Error = fmt.Sprintf("checkpoint failed: %v", cpErr)
+			results = append(results, res)
+			continue
+		}
+		res.Checkpoint = hash
+
+		if stat, err := sg.CheckpointDiff(base, hash); err == nil {
+			res.Files = stat.Files
+			res.Additions = stat.Additions
+			res.Deletions = stat.Deletions
+		}
+
+		if so.controller.qcManager != nil {
+			if qcRes, err := so.controller.qcManager.RunCheck(ctx); err == nil {
+				res.QCPassed = qcRes.Success
+				res.QCCommand = qcRes.Command
+			}
+		}
+
+		log.Printf("🐝 [Swarm] Task %s attempt %d/%d: files=%d +%d/-%d qc=%v cost=$%.4f",
+			t.ID, i, n, res.Files, res.Additions, res.Deletions, res.QCPassed, res.Cost)
+		results = append(results, res)
+	}
+
+	if len(results) == 0 {
+		_ = sg.RestoreCheckpoint(base)
+		return "", fmt.Errorf("best-of cancelled before any attempt finished")
+	}
+
+	ranked := RankAttempts(results)
+	comparison := FormatAttemptComparison(t.Title, ranked)
+
+	winner := ranked[0]
+	if so.selectionPolicyFor(t) == SelectionManual && so.controller.host != nil {
+		choices := make([]string, len(ranked))
+		for i, a := range ranked {
+			choices[i] = fmt.Sprintf("Attempt #%d (+%d/-%d, QC %v)", a.Index, a.Additions, a.Deletions, a.QCPassed)
+		}
+		choiceIdx, err := so.controller.host.AskUserChoice(comparison+"\nWhich attempt should be merged?", choices)
+		if err != nil {
+			log.Printf("⚠️ [Swarm] Manual selection failed, falling back to top-ranked attempt: %v", err)
+		} else if choiceIdx >= 0 && choiceIdx < len(ranked) {
+			winner = ranked[choiceIdx]
+		}
+	}
+
+	if winner.Error != "" || winner.Checkpoint == "" {
+		_ = sg.RestoreCheckpoint(base)
+		return "", fmt.Errorf("all %d attempts failed:\n%s", len(results), comparison)
+	}
+
+	if err := sg.RestoreCheckpoint(winner.Checkpoint); err != nil {
+		return "", fmt.Errorf("merge attempt #%d: %w", winner.Index, err)
+	}
+
+	so.controller.ReportTaskProgress(ctx, protocol.TaskProgress{
+		TaskName:        t.Title,
+		Status:          fmt.Sprintf("Merged attempt #%d", winner.Index),
+		Summary:         comparison,
+		IsActive:        false,
+		AgentIdentifier: agentID,
+		AgentColor:      "#00FF99",
+	})
+
+	return fmt.Sprintf("%s\n\n%s\n✅ Merged attempt #%d (checkpoint %s)", winner.Output, comparison, winner.Index, winner.Checkpoint), nil
+}
@@ -77,3 +77,27 @@ func TestSwarmLoopLogic(t *testing.T) {
 
 	// This pattern is standard and correct for bounding concurrency.
 }
+
+func TestRankAttempts(t *testing.T) {
+	attempts := []AttemptResult{
+		{Index: 1, QCPassed: false, QCCommand: "go build ./...", Additions: 5},
+		{Index: 2, QCPassed: true, QCCommand: "go build ./...", Additions: 40, Deletions: 2, Cost: 0.02},
+		{Index: 3, Error: "subtask timed out"},
+		{Index: 4, QCPassed: true, QCCommand: "go build ./...", Additions: 10, Cost: 0.05},
+		{Index: 5, QCPassed: true, QCCommand: "go build ./...", Additions: 10, Cost: 0.01},
+	}
+
+	ranked := RankAttempts(attempts)
+
+	want := []int{5, 4, 2, 1, 3}
+	for i, idx := range want {
+		if ranked[i].Index != idx {
+			t.Fatalf("rank %d: expected attempt #%d, got #%d", i+1, idx, ranked[i].Index)
+		}
+	}
+
+	// Input must not be reordered
+	if attempts[0].Index != 1 {
+		t.Errorf("RankAttempts mutated its input")
+	}
+}
@@ -57,9 +57,11 @@ func (t *UpdatePlanToolImpl) Definition() protocol.Tool {
 
 func (t *UpdatePlanToolImpl) Execute(ctx context.Context, args json.RawMessage) (string, error) {
 	var input struct {
-		TaskID       string   `json:"task_id"`
-		Status       string   `json:"status"`
-		Dependencies []string `json:"dependencies"`
+		TaskID          string   `json:"task_id"`
+		Status          string   `json:"status"`
+		Dependencies    []string `json:"dependencies"`
+		Attempts        int      `json:"attempts"`
+		SelectionPolicy string   `json:"selection_policy"`
 	}
 	if err := json.Unmarshal(args, &input); err != nil {
 		return "", fmt.Errorf("invalid arguments: %w", err)
@@ -81,5 +83,12 @@ func (t *UpdatePlanToolImpl) Execute(ctx context.Context, args json.RawMessage)
 		}
 	}
 
+	// Configure best-of-N if requested
+	if input.Attempts > 0 {
+		if err := t.Plan.SetTaskAttempts(input.TaskID, input.Attempts, input.SelectionPolicy); err != nil {
+			return "", err
+		}
+	}
+
 	return fmt.Sprintf("✅ Task %s updated to %s", input.TaskID, input.Status), nil
 }
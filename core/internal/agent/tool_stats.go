@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/igoryan-dao/ricochet/internal/tools"
+)
+
+// ToolStats returns the executor's per-tool usage, or nil when not recorded
+func (c *Controller) ToolStats() *tools.ToolStats {
+	if ne, ok := c.executor.(*tools.NativeExecutor); ok {
+		return ne.Stats()
+	}
+	return nil
+}
+
+// handleStatsCommand implements `/stats [reset]`
+func (c *Controller) handleStatsCommand(sessionID, args string, callback func(update interface{})) error {
+	reply := func(content string) {
+		callback(ChatUpdate{
+			SessionID: sessionID,
+			Message: ChatMessage{
+				ID:        uuid.New().String(),
+				Role:      "assistant",
+				Content:   content,
+				Timestamp: time.Now().UnixMilli(),
+			},
+		})
+	}
+
+	stats := c.ToolStats()
+	if stats == nil {
+		reply("❌ Tool statistics are not available.")
+		return nil
+	}
+
+	switch args {
+	case "":
+		reply(tools.FormatToolStats(stats.Snapshot()))
+	case "reset":
+		if err := stats.Reset(); err != nil {
+			reply(fmt.Sprintf("❌ Failed to reset tool statistics: %v", err))
+			return nil
+		}
+		reply("🧹 Tool statistics cleared.")
+	default:
+		reply("**Usage**: `/stats` or `/stats reset`")
+	}
+	return nil
+}
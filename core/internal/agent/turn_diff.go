@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+)
+
+// TurnDiff is the aggregate workspace change caused by one assistant turn
+type TurnDiff struct {
+	Files          int      `json:"files"`
+	Additions      int      `json:"additions"`
+	Deletions      int      `json:"deletions"`
+	Paths          []string `json:"paths,omitempty"`
+	FromCheckpoint string   `json:"fromCheckpoint"` // Shadow checkpoint before the first write
+	ToCheckpoint   string   `json:"toCheckpoint"`   // Shadow checkpoint at end of turn
+}
+
+// Summary renders the diff as "changed 3 files (+120/−8)"
+func (d *TurnDiff) Summary() string {
+	noun := "files"
+	if d.Files == 1 {
+		noun = "file"
+	}
+	return fmt.Sprintf("changed %d %s (+%d/−%d)", d.Files, noun, d.Additions, d.Deletions)
+}
+
+// beginTurnDiff snapshots the workspace before the first write of a turn.
+// Returns "" if the shadow checkpoint system is unavailable.
+func (c *Controller) beginTurnDiff() string {
+	if c.safeguard == nil {
+		return ""
+	}
+	hash, err := c.safeguard.CreateCheckpoint(turnStartMessage)
+	if err != nil {
+		log.Printf("⚠️ Turn diff: failed to snapshot workspace: %v", err)
+		return ""
+	}
+	return hash
+}
+
+// finishTurnDiff snapshots the workspace at end of turn and diffs it against fromHash.
+// Returns nil when nothing was written or the turn left the workspace unchanged.
+func (c *Controller) finishTurnDiff(fromHash string) *TurnDiff {
+	if fromHash == "" || c.safeguard == nil {
+		return nil
+	}
+
+	toHash, err := c.safeguard.CreateCheckpoint("Turn end")
+	if err != nil {
+		log.Printf("⚠️ Turn diff: failed to snapshot workspace: %v", err)
+		return nil
+	}
+
+	stat, err := c.safeguard.CheckpointDiff(fromHash, toHash)
+	if err != nil {
+		log.Printf("⚠️ Turn diff: %v", err)
+		return nil
+	}
+	if stat.Files == 0 {
+		return nil
+	}
+
+	return &TurnDiff{
+		Files:          stat.Files,
+		Additions:      stat.Additions,
+		Deletions:      stat.Deletions,
+		Paths:          stat.Paths,
+		FromCheckpoint: fromHash,
+		ToCheckpoint:   toHash,
+	}
+}
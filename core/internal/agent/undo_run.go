@@ -0,0 +1,226 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/igoryan-dao/ricochet/internal/safeguard/checkpoint"
+)
+
+// turnStartMessage marks the shadow checkpoint taken before a run's first write
+const turnStartMessage = "Turn start"
+
+// undoRunSearchDepth bounds how far back /undo-run looks for run boundaries
+const undoRunSearchDepth = 200
+
+// handleUndoRun implements `/undo-run [N] [--yes] [path...]`.
+// It reverts the workspace to the state before the last N agent runs,
+// optionally limited to specific paths, and runs QC afterwards.
+func (c *Controller) handleUndoRun(ctx context.Context, sessionID, args string, callback func(update interface{})) error {
+	reply := func(content string) {
+		callback(ChatUpdate{
+			SessionID: sessionID,
+			Message: ChatMessage{
+				ID:        uuid.New().String(),
+				Role:      "assistant",
+				Content:   content,
+				Timestamp: time.Now().UnixMilli(),
+			},
+		})
+	}
+
+	if c.safeguard == nil {
+		reply("❌ Checkpoints are not available in this workspace, nothing to undo.")
+		return nil
+	}
+
+	runs := 1
+	confirmed := false
+	var only []string
+	for _, arg := range strings.Fields(args) {
+		if arg == "--yes" || arg == "-y" {
+			confirmed = true
+		} else if n, err := strconv.Atoi(arg); err == nil && n > 0 && len(only) == 0 {
+			runs = n
+		} else {
+			only = append(only, arg)
+		}
+	}
+
+	// 1. Find the checkpoint taken before the Nth most recent run
+	commits, err := c.safeguard.ListCheckpoints(undoRunSearchDepth)
+	if err != nil {
+		reply(fmt.Sprintf("❌ Failed to read checkpoints: %v", err))
+		return nil
+	}
+	var target *checkpoint.CommitInfo
+	seen := 0
+	for i := range commits {
+		if commits[i].Message == turnStartMessage {
+			seen++
+			if seen == runs {
+				target = &commits[i]
+				break
+			}
+		}
+	}
+	if target == nil {
+		reply(fmt.Sprintf("📭 Found %d agent run(s) with file changes; cannot undo %d.", seen, runs))
+		return nil
+	}
+
+	// 2. Snapshot the current state so the undo itself can be undone
+	safety, err := c.safeguard.CreateCheckpoint("Before undo-run")
+	if err != nil {
+		reply(fmt.Sprintf("❌ Failed to snapshot current state: %v", err))
+		return nil
+	}
+
+	changes, err := c.safeguard.CheckpointChanges(target.Hash, safety)
+	if err != nil {
+		reply(fmt.Sprintf("❌ Failed to compute changes: %v", err))
+		return nil
+	}
+	changes = filterFileChanges(changes, only)
+	if len(changes) == 0 {
+		reply("✅ Nothing to revert: the workspace already matches the state before that run.")
+		return nil
+	}
+
+	// 3. Summarize exactly what will be reverted
+	summary := c.formatUndoSummary(target, runs, changes)
+
+	if !confirmed {
+		choices := []string{
+			fmt.Sprintf("Revert all %d file(s)", len(changes)),
+			"Revert modified files only (keep new files)",
+			"Cancel",
+		}
+		choice, err := c.host.AskUserChoice(summary, choices)
+		if err != nil {
+			// Host can't prompt: show the plan and let the user re-run with --yes
+			reply(summary + fmt.Sprintf("\n\nRun `/undo-run %s --yes` to proceed.", strings.TrimSpace(args)))
+			return nil
+		}
+		switch choice {
+		case 1:
+			changes = filterFileChanges(changes, nil, "A")
+		case 2:
+			reply("↩️ Undo cancelled.")
+			return nil
+		}
+	}
+
+	// 4. Selective rollback
+	paths := make([]string, 0, len(changes))
+	for _, ch := range changes {
+		paths = append(paths, ch.Path)
+	}
+	if err := c.safeguard.RestoreCheckpointPaths(target.Hash, paths); err != nil {
+		reply(fmt.Sprintf("❌ Revert failed: %v\n\nYour pre-undo state is saved as checkpoint `%s`.", err, safety[:8]))
+		return nil
+	}
+	log.Printf("↩️ Undo-run: reverted %d file(s) to %s", len(paths), target.Hash[:8])
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("↩️ **Reverted %d file(s)** to the state before %s.\n", len(paths), pluralRuns(runs)))
+
+	// 5. Post-revert QC
+	if c.qcManager != nil {
+		qcRes, err := c.qcManager.RunCheck(ctx)
+		switch {
+		case err != nil:
+			sb.WriteString(fmt.Sprintf("\n⚠️ QC could not run: %v\n", err))
+		case qcRes.Success:
+			if qcRes.Command != "" {
+				sb.WriteString(fmt.Sprintf("\n✅ QC passed (`%s`)\n", qcRes.Command))
+			}
+		default:
+			sb.WriteString(fmt.Sprintf("\n❌ QC failed after revert (`%s`)\n```\n%s\n```\n", qcRes.Command, truncateString(qcRes.Output, 1500)))
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("\nPre-undo state saved as checkpoint `%s`.", safety[:8]))
+	reply(sb.String())
+	return nil
+}
+
+// formatUndoSummary renders the revert plan as markdown
+func (c *Controller) formatUndoSummary(target *checkpoint.CommitInfo, runs int, changes []checkpoint.FileChange) string {
+	// Flag files that also have uncommitted changes in the user's own git repo
+	dirty := make(map[string]string)
+	if c.gitManager != nil && c.gitManager.IsRepo() {
+		if status, err := c.gitManager.Status(); err == nil {
+			for _, line := range strings.Split(status, "\n") {
+				fields := strings.Fields(line)
+				if len(fields) >= 2 {
+					dirty[fields[len(fields)-1]] = fields[0]
+				}
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("### ↩️ Undo %s\n\n", pluralRuns(runs)))
+	sb.WriteString(fmt.Sprintf("Reverting to checkpoint `%s` (%s).\n\n", target.Hash[:8], target.Timestamp.Format("15:04:05")))
+	sb.WriteString("| File | Action | Lines | Hunks | Git |\n|---|---|---|---|---|\n")
+
+	var created []string
+	for _, ch := range changes {
+		action := "restore"
+		switch ch.Status {
+		case "A":
+			action = "**delete** (new file)"
+			created = append(created, ch.Path)
+		case "D":
+			action = "recreate"
+		}
+		sb.WriteString(fmt.Sprintf("| `%s` | %s | +%d/−%d | %d | %s |\n",
+			ch.Path, action, ch.Additions, ch.Deletions, ch.Hunks, dirty[ch.Path]))
+	}
+
+	if len(created) > 0 {
+		sb.WriteString(fmt.Sprintf("\n⚠️ %d untracked file(s) created by the run will be deleted.\n", len(created)))
+	}
+	return sb.String()
+}
+
+// filterFileChanges keeps changes under the given paths (all if empty) and drops the given statuses
+func filterFileChanges(changes []checkpoint.FileChange, only []string, dropStatus ...string) []checkpoint.FileChange {
+	var out []checkpoint.FileChange
+	for _, ch := range changes {
+		skip := false
+		for _, s := range dropStatus {
+			if ch.Status == s {
+				skip = true
+			}
+		}
+		if skip {
+			continue
+		}
+		if len(only) == 0 {
+			out = append(out, ch)
+			continue
+		}
+		for _, p := range only {
+			p = strings.TrimSuffix(p, "/")
+			if ch.Path == p || strings.HasPrefix(ch.Path, p+"/") {
+				out = append(out, ch)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func pluralRuns(n int) string {
+	if n == 1 {
+		return "the last agent run"
+	}
+	return fmt.Sprintf("the last %d agent runs", n)
+}
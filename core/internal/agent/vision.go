@@ -0,0 +1,189 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Multimodal requests. Chat messages are text-only, so images go through
+// single-shot requests that carry one image and an instruction.
+
+// visionMaxTokens caps the length of an image analysis
+const visionMaxTokens = 2048
+
+// VisionProvider is implemented by providers whose API accepts images.
+// Whether the configured model actually sees them is up to providers.yaml
+// (supports_vision).
+type VisionProvider interface {
+	AnalyzeImage(ctx context.Context, prompt string, image []byte, mimeType string) (string, error)
+}
+
+// ImageGenerator is implemented by providers that can create images
+type ImageGenerator interface {
+	GenerateImage(ctx context.Context, prompt, size string) ([]byte, error)
+}
+
+// AsVisionProvider returns p's vision capability, if it has one
+func AsVisionProvider(p Provider) (VisionProvider, bool) {
+	v, ok := unwrapProvider(p).(VisionProvider)
+	return v, ok
+}
+
+// AsImageGenerator returns p's image generation capability, if it has one
+func AsImageGenerator(p Provider) (ImageGenerator, bool) {
+	g, ok := unwrapProvider(p).(ImageGenerator)
+	return g, ok
+}
+
+func dataURL(image []byte, mimeType string) string {
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(image)
+}
+
+// postJSON sends payload and decodes a 200 response into out
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+	resp, err := doRequest(ctx, client, "POST", url, headers, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(respBody))
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	return nil
+}
+
+// AnalyzeImage sends the image as an image_url content part
+func (p *OpenAIProvider) AnalyzeImage(ctx context.Context, prompt string, image []byte, mimeType string) (string, error) {
+	payload := map[string]interface{}{
+		"model":      p.model,
+		"max_tokens": visionMaxTokens,
+		"messages": []map[string]interface{}{{
+			"role": "user",
+			"content": []map[string]interface{}{
+				{"type": "text", "text": prompt},
+				{"type": "image_url", "image_url": map[string]string{"url": dataURL(image, mimeType)}},
+			},
+		}},
+	}
+	var resp openaiResponse
+	if err := postJSON(ctx, p.client, p.baseURL, p.headers(), payload, &resp); err != nil {
+		return "", err
+	}
+	if resp.Error != nil {
+		return "", fmt.Errorf("API error: %s", resp.Error.Message)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("empty response")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// GenerateImage calls the images API next to the chat completions endpoint.
+// Only OpenAI itself serves it; compatible APIs reuse this type without it.
+func (p *OpenAIProvider) GenerateImage(ctx context.Context, prompt, size string) ([]byte, error) {
+	if size == "" {
+		size = "1024x1024"
+	}
+	payload := map[string]interface{}{
+		"model":           "dall-e-3",
+		"prompt":          prompt,
+		"size":            size,
+		"n":               1,
+		"response_format": "b64_json",
+	}
+	var resp struct {
+		Data []struct {
+			B64JSON string `json:"b64_json"`
+		} `json:"data"`
+	}
+	url := strings.Replace(p.baseURL, "/chat/completions", "/images/generations", 1)
+	if err := postJSON(ctx, p.client, url, p.headers(), payload, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 || resp.Data[0].B64JSON == "" {
+		return nil, fmt.Errorf("no image returned")
+	}
+	return base64.StdEncoding.DecodeString(resp.Data[0].B64JSON)
+}
+
+// AnalyzeImage sends the image as a base64 image block
+func (p *AnthropicProvider) AnalyzeImage(ctx context.Context, prompt string, image []byte, mimeType string) (string, error) {
+	payload := map[string]interface{}{
+		"model":      p.model,
+		"max_tokens": visionMaxTokens,
+		"messages": []map[string]interface{}{{
+			"role": "user",
+			"content": []map[string]interface{}{
+				{"type": "image", "source": map[string]string{
+					"type":       "base64",
+					"media_type": mimeType,
+					"data":       base64.StdEncoding.EncodeToString(image),
+				}},
+				{"type": "text", "text": prompt},
+			},
+		}},
+	}
+	var resp anthropicResponse
+	if err := postJSON(ctx, p.client, anthropicAPIURL, p.headers(), payload, &resp); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			sb.WriteString(block.Text)
+		}
+	}
+	return sb.String(), nil
+}
+
+// AnalyzeImage sends the image as inline data
+func (p *GeminiProvider) AnalyzeImage(ctx context.Context, prompt string, image []byte, mimeType string) (string, error) {
+	payload := map[string]interface{}{
+		"contents": []map[string]interface{}{{
+			"role": "user",
+			"parts": []map[string]interface{}{
+				{"inlineData": map[string]string{
+					"mimeType": mimeType,
+					"data":     base64.StdEncoding.EncodeToString(image),
+				}},
+				{"text": prompt},
+			},
+		}},
+		"generationConfig": geminiGenerationConfig{MaxOutputTokens: visionMaxTokens},
+	}
+	model := p.model
+	if model == "" {
+		model = "gemini-3-flash"
+	}
+	var resp geminiResponse
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", model, p.apiKey)
+	if err := postJSON(ctx, p.client, url, map[string]string{"Content-Type": "application/json"}, payload, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Candidates) == 0 {
+		return "", fmt.Errorf("empty response")
+	}
+	var sb strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		sb.WriteString(part.Text)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAIAnalyzeImage(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": "A red square"}}},
+		})
+	}))
+	defer srv.Close()
+
+	p := NewOpenAIProvider("key", "gpt-4o", srv.URL, "", "")
+	out, err := p.AnalyzeImage(context.Background(), "What is this?", []byte("png"), "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "A red square" {
+		t.Errorf("got %q", out)
+	}
+	if !strings.Contains(body, `"url":"data:image/png;base64,cG5n"`) || !strings.Contains(body, `"text":"What is this?"`) {
+		t.Errorf("image or prompt missing from request: %s", body)
+	}
+
+	if _, ok := AsVisionProvider(withRateLimit(p, ProviderConfig{})); !ok {
+		t.Error("rate-limited OpenAI provider should expose vision")
+	}
+}
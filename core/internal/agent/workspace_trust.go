@@ -0,0 +1,143 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+// trustChoices are offered in the same order as safeguard.TrustLevels
+var trustChoices = []string{
+	"Trust: auto-approval as configured, codebase indexing allowed",
+	"Restricted: ask before every action, no indexing",
+	"Read-only: analysis only, no edits or commands",
+}
+
+// ensureWorkspaceTrust asks the user to classify a workspace the first time
+// Ricochet runs in it, then applies and remembers the decision.
+func (c *Controller) ensureWorkspaceTrust(ctx context.Context, sessionID string, callback func(update interface{})) {
+	c.mu.Lock()
+	if c.trustAsked || c.trustStore == nil || c.safeguard == nil || c.safeguard.Trust != "" {
+		c.mu.Unlock()
+		return
+	}
+	c.trustAsked = true
+	lm := c.liveMode
+	c.mu.Unlock()
+
+	root := c.host.GetCWD()
+	question := fmt.Sprintf("🛡️ Ricochet hasn't worked in this folder before:\n%s\n\nHow much should it trust this workspace?", root)
+
+	var level safeguard.TrustLevel
+	if lm != nil && lm.IsEnabled() {
+		// Telegram only offers Yes/No buttons; a typed reply can name the level
+		answer, err := lm.AskUserRemote(ctx, question+"\n\n✅ Yes = trusted, ❌ No = read-only, or reply \"restricted\".")
+		if err != nil {
+			log.Printf("Workspace trust prompt failed: %v", err)
+			return
+		}
+		parsed, ok := safeguard.ParseTrustLevel(answer)
+		if !ok {
+			parsed = safeguard.TrustRestricted
+		}
+		level = parsed
+	} else {
+		choice, err := c.host.AskUserChoice(question, trustChoices)
+		if err != nil {
+			// Host can't prompt (e.g. headless): keep configured behaviour, ask again next start
+			log.Printf("Workspace trust prompt unavailable, using configured defaults: %v", err)
+			return
+		}
+		if choice < 0 || choice >= len(safeguard.TrustLevels) {
+			choice = 1 // Dismissed: fail closed to restricted
+		}
+		level = safeguard.TrustLevels[choice]
+	}
+
+	if err := c.setWorkspaceTrust(root, level); err != nil {
+		log.Printf("Warning: Failed to save workspace trust: %v", err)
+	}
+
+	callback(ChatUpdate{
+		SessionID: sessionID,
+		Message: ChatMessage{
+			ID:        uuid.New().String(),
+			Role:      "assistant",
+			Content:   fmt.Sprintf("🛡️ Workspace marked as **%s**. Change it any time with `/trust <trusted|restricted|read-only>`.", level),
+			Timestamp: time.Now().UnixMilli(),
+		},
+	})
+}
+
+// setWorkspaceTrust persists level for root and applies it to the running controller
+func (c *Controller) setWorkspaceTrust(root string, level safeguard.TrustLevel) error {
+	c.mu.Lock()
+	c.safeguard.ApplyTrust(level, c.config.AutoApproval)
+	startIndexing := c.deferredIndexing
+	if level.AllowsIndexing() {
+		c.deferredIndexing = nil
+	} else {
+		startIndexing = nil
+	}
+	c.mu.Unlock()
+
+	log.Printf("🛡️ Workspace trust for %s: %s", root, level)
+	if startIndexing != nil {
+		startIndexing()
+	}
+	return c.trustStore.Set(root, level)
+}
+
+// handleTrustCommand implements `/trust [level]`
+func (c *Controller) handleTrustCommand(sessionID, args string, callback func(update interface{})) error {
+	reply := func(content string) {
+		callback(ChatUpdate{
+			SessionID: sessionID,
+			Message: ChatMessage{
+				ID:        uuid.New().String(),
+				Role:      "assistant",
+				Content:   content,
+				Timestamp: time.Now().UnixMilli(),
+			},
+		})
+	}
+
+	if c.safeguard == nil || c.trustStore == nil {
+		reply("❌ Workspace trust is not available.")
+		return nil
+	}
+
+	if args == "" {
+		current := string(c.safeguard.Trust)
+		if current == "" {
+			current = "not classified"
+		}
+		reply(fmt.Sprintf("🛡️ Workspace trust: **%s**\n\n**Usage**: `/trust trusted|restricted|read-only`", current))
+		return nil
+	}
+
+	level, ok := safeguard.ParseTrustLevel(args)
+	if !ok {
+		var names []string
+		for _, l := range safeguard.TrustLevels {
+			names = append(names, string(l))
+		}
+		reply(fmt.Sprintf("❌ Unknown trust level `%s`. Use one of: %s", args, strings.Join(names, ", ")))
+		return nil
+	}
+
+	c.mu.Lock()
+	c.trustAsked = true
+	c.mu.Unlock()
+	if err := c.setWorkspaceTrust(c.host.GetCWD(), level); err != nil {
+		reply(fmt.Sprintf("⚠️ Applied **%s** for this session but failed to save it: %v", level, err))
+		return nil
+	}
+	reply(fmt.Sprintf("🛡️ Workspace marked as **%s**.", level))
+	return nil
+}
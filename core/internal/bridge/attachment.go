@@ -0,0 +1,118 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/bridge/proto"
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
+)
+
+const (
+	// maxAttachmentSize bounds a file carried in a bridge message
+	maxAttachmentSize = 16 << 20
+	// maxMessageSize is the gRPC message limit on both ends, room for an
+	// attachment plus the rest of the message
+	maxMessageSize = maxAttachmentSize + 1<<20
+)
+
+// FileAttachment reads a file into an attachment for an OutgoingMessage
+func FileAttachment(path, caption string) (*proto.Attachment, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxAttachmentSize {
+		return nil, fmt.Errorf("%s is too large to send over the bridge (%d MB max)", filepath.Base(path), maxAttachmentSize>>20)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.Attachment{
+		Name:     filepath.Base(path),
+		MimeType: attachmentMimeType(path, data),
+		Data:     data,
+		Caption:  caption,
+	}, nil
+}
+
+// SaveAttachment writes a received attachment into dir, fetching it first if
+// it only carries a URL, and returns its path
+func SaveAttachment(ctx context.Context, att *proto.Attachment, dir string) (string, error) {
+	data := att.Data
+	if len(data) == 0 && att.Url != "" {
+		var err error
+		if data, err = fetchAttachment(ctx, att.Url); err != nil {
+			return "", err
+		}
+	}
+
+	name := filepath.Base(strings.ReplaceAll(att.Name, "\\", "/"))
+	if name == "" || name == "." || name == "/" || name == ".." {
+		name = "file"
+		if exts, _ := mime.ExtensionsByType(att.MimeType); len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+	// Prefixed so files of the same name don't overwrite each other
+	path := filepath.Join(dir, newMessageID()[:8]+"_"+name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// fetchAttachment downloads an attachment given by URL
+func fetchAttachment(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpclient.Client(time.Minute).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attachment: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch attachment: HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAttachmentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxAttachmentSize {
+		return nil, fmt.Errorf("attachment is larger than %d MB", maxAttachmentSize>>20)
+	}
+	return data, nil
+}
+
+// attachmentMimeType guesses a file's type from its extension, else its
+// contents
+func attachmentMimeType(name string, data []byte) string {
+	if t := mime.TypeByExtension(filepath.Ext(name)); t != "" {
+		return t
+	}
+	return http.DetectContentType(data)
+}
+
+// attachmentKind groups an attachment as image, audio, video or document,
+// the media types messengers tell apart
+func attachmentKind(att *proto.Attachment) string {
+	kind, _, _ := strings.Cut(att.MimeType, "/")
+	switch kind {
+	case "image", "audio", "video":
+		return kind
+	}
+	return "document"
+}
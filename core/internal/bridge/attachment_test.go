@@ -0,0 +1,62 @@
+package bridge
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/bridge/proto"
+)
+
+func TestFileAttachment_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "shot.png")
+	os.WriteFile(src, []byte("\x89PNG\r\n\x1a\n"), 0644)
+
+	att, err := FileAttachment(src, "Done")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if att.Name != "shot.png" || att.MimeType != "image/png" || att.Caption != "Done" {
+		t.Errorf("attachment = %s %s %q", att.Name, att.MimeType, att.Caption)
+	}
+	if kind := attachmentKind(att); kind != "image" {
+		t.Errorf("kind = %s, want image", kind)
+	}
+
+	path, err := SaveAttachment(t.Context(), att, filepath.Join(dir, "received"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(path, "_shot.png") || filepath.Dir(path) != filepath.Join(dir, "received") {
+		t.Errorf("saved to %s", path)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(att.Data) {
+		t.Errorf("saved %q", data)
+	}
+}
+
+func TestSaveAttachment_SanitizesName(t *testing.T) {
+	dir := t.TempDir()
+	path, err := SaveAttachment(t.Context(), &proto.Attachment{Name: "../../etc/passwd", Data: []byte("x")}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(path) != dir || !strings.HasSuffix(path, "_passwd") {
+		t.Errorf("saved to %s, want inside %s", path, dir)
+	}
+}
+
+func TestAttachmentKind(t *testing.T) {
+	for mimeType, want := range map[string]string{
+		"audio/ogg":       "audio",
+		"video/mp4":       "video",
+		"application/pdf": "document",
+		"":                "document",
+	} {
+		if got := attachmentKind(&proto.Attachment{MimeType: mimeType}); got != want {
+			t.Errorf("attachmentKind(%q) = %s, want %s", mimeType, got, want)
+		}
+	}
+}
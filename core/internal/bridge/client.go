@@ -2,44 +2,248 @@ package bridge
 
 import (
 	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"log"
+	"math/rand/v2"
 	"net"
+	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/gorilla/websocket"
 	"github.com/hashicorp/yamux"
 	"github.com/igoryan-dao/ricochet/internal/bridge/proto"
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
+	"github.com/igoryan-dao/ricochet/internal/paths"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
-// Client handles connection to Ricochet Cloud with multiple multiplexed services
+// MessageIDKey is the gRPC metadata key carrying an outgoing message's
+// dedupe ID: a message replayed after a lost acknowledgement keeps its ID
+const MessageIDKey = "x-message-id"
+
+// Reconnection backoff doubles from minBackoff up to maxBackoff
+const (
+	minBackoff  = time.Second
+	maxBackoff  = time.Minute
+	sendTimeout = 30 * time.Second
+	// heartbeatTimeout drops a connection whose heartbeats stopped
+	heartbeatTimeout = 3 * heartbeatInterval
+)
+
+// ConnectionStatus is the state of the connection to the Cloud Bridge
+type ConnectionStatus string
+
+const (
+	StatusConnected    ConnectionStatus = "connected"
+	StatusDisconnected ConnectionStatus = "disconnected"
+	StatusReconnecting ConnectionStatus = "reconnecting"
+)
+
+// ConnectionState is reported to the UI whenever the connection changes
+type ConnectionState struct {
+	Status  ConnectionStatus `json:"status"`
+	Attempt int              `json:"attempt,omitempty"`   // Reconnection attempt, from 1
+	RetryIn int64            `json:"retryInMs,omitempty"` // Delay before the attempt
+	Queued  int              `json:"queued"`              // Messages waiting in the outbox
+	Error   string           `json:"error,omitempty"`
+}
+
+// Client handles connection to Ricochet Cloud with multiple multiplexed services.
+// It reconnects with backoff when the connection drops; messages sent in the
+// meantime wait in a persistent outbox and are replayed after reconnecting.
 type Client struct {
 	cloudURL  string
 	sessionID string
-	session   *yamux.Session
-	grpcConn  *grpc.ClientConn
+	host      string // Name of this machine, for routing between hosts
 
+	// Current connection, replaced on reconnect
+	connMu       sync.Mutex
+	connected    bool
+	session      *yamux.Session
+	grpcConn     *grpc.ClientConn
 	bridgeClient proto.BridgeServiceClient
 	chatClient   proto.ChatServiceClient
 	sttClient    proto.STTServiceClient
+	eventStream  proto.ChatService_StreamEventsClient
 
-	eventStream proto.ChatService_StreamEventsClient
-	incomingCh  chan *proto.BridgeEvent
+	incomingCh chan *proto.BridgeEvent
+
+	// Platform each chat last wrote from, so replies go back the same way
+	platformsMu sync.Mutex
+	platforms   map[int64]string
+
+	// Undelivered messages; replays are serialized to keep them in order
+	outbox  *Outbox
+	flushMu sync.Mutex
+
+	stateMu       sync.Mutex
+	state         ConnectionState
+	onStateChange func(ConnectionState)
+
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 func NewClient(cloudURL, sessionID string) *Client {
-	return &Client{
+	c := &Client{
 		cloudURL:   cloudURL,
 		sessionID:  sessionID,
+		host:       hostName(),
 		incomingCh: make(chan *proto.BridgeEvent, 100),
+		platforms:  make(map[int64]string),
+		state:      ConnectionState{Status: StatusDisconnected},
+		done:       make(chan struct{}),
+	}
+
+	outbox, err := OpenOutbox(outboxPath(sessionID))
+	if err != nil {
+		log.Printf("Bridge outbox unavailable, messages sent while offline will be lost: %v", err)
+	} else {
+		c.outbox = outbox
+		c.state.Queued = outbox.Len()
+	}
+	return c
+}
+
+// hostName names this machine for the bridge: $RICOCHET_HOST_NAME, else the
+// hostname without its domain
+func hostName() string {
+	if name := strings.TrimSpace(os.Getenv("RICOCHET_HOST_NAME")); name != "" {
+		return name
+	}
+	name, _ := os.Hostname()
+	name, _, _ = strings.Cut(name, ".")
+	return name
+}
+
+// SetHostName sets the name users pick this machine by, e.g. "laptop".
+// It applies from the next connection.
+func (c *Client) SetHostName(name string) {
+	c.connMu.Lock()
+	c.host = name
+	c.connMu.Unlock()
+}
+
+// outboxPath is where a session's undelivered messages are kept
+func outboxPath(sessionID string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(sessionID)
+	if name == "" {
+		name = "default"
+	}
+	return filepath.Join(paths.GetGlobalDir(), "bridge", "outbox-"+name+".json")
+}
+
+// SetOnStateChange sets the callback for connection state changes
+func (c *Client) SetOnStateChange(fn func(ConnectionState)) {
+	c.stateMu.Lock()
+	c.onStateChange = fn
+	c.stateMu.Unlock()
+}
+
+// State returns the current connection state
+func (c *Client) State() ConnectionState {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.state
+}
+
+// setState records and reports a connection state
+func (c *Client) setState(st ConnectionState) {
+	if c.outbox != nil {
+		st.Queued = c.outbox.Len()
+	}
+	c.stateMu.Lock()
+	c.state = st
+	fn := c.onStateChange
+	c.stateMu.Unlock()
+	if fn != nil {
+		fn(st)
 	}
 }
 
+// Start connects to the Cloud Bridge. Once connected, the connection is
+// kept up until ctx is done or Close is called.
 func (c *Client) Start(ctx context.Context) error {
+	if err := c.connect(ctx); err != nil {
+		return err
+	}
+	c.setState(ConnectionState{Status: StatusConnected})
+	go c.flush()
+	go c.run(ctx)
+	return nil
+}
+
+// run listens on the connection and reconnects, with exponential backoff,
+// whenever it drops
+func (c *Client) run(ctx context.Context) {
+	for {
+		err := c.listen()
+		c.disconnect()
+		if c.stopped(ctx) {
+			return
+		}
+		log.Printf("Bridge stream closed: %v", err)
+		c.setState(ConnectionState{Status: StatusDisconnected, Error: err.Error()})
+
+		for attempt := 1; ; attempt++ {
+			delay := backoffDelay(attempt)
+			c.setState(ConnectionState{Status: StatusReconnecting, Attempt: attempt, RetryIn: delay.Milliseconds()})
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			case <-c.done:
+				return
+			}
+
+			if err := c.connect(ctx); err != nil {
+				log.Printf("Bridge reconnect attempt %d failed: %v", attempt, err)
+				continue
+			}
+			break
+		}
+
+		log.Printf("Reconnected to Ricochet Cloud")
+		c.setState(ConnectionState{Status: StatusConnected})
+		go c.flush()
+	}
+}
+
+// stopped reports whether the client was closed or its context is done
+func (c *Client) stopped(ctx context.Context) bool {
+	select {
+	case <-c.done:
+		return true
+	case <-ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// backoffDelay is the wait before a reconnection attempt: doubling from
+// minBackoff, capped at maxBackoff, with up to 20% jitter so clients don't
+// reconnect in lockstep
+func backoffDelay(attempt int) time.Duration {
+	delay := maxBackoff
+	if attempt < 8 {
+		delay = min(minBackoff<<(attempt-1), maxBackoff)
+	}
+	return delay + time.Duration(rand.Int64N(int64(delay)/5+1))
+}
+
+// connect dials the Cloud Bridge, handshakes and opens the event stream
+func (c *Client) connect(ctx context.Context) error {
 	u, err := url.Parse(c.cloudURL)
 	if err != nil {
 		return err
@@ -47,8 +251,15 @@ func (c *Client) Start(ctx context.Context) error {
 
 	log.Printf("Connecting to Ricochet Cloud at %s...", u.String())
 
-	dialer := websocket.DefaultDialer
-	conn, _, err := dialer.DialContext(ctx, u.String(), nil)
+	// The bridge lets in agents that present its token
+	secret := os.Getenv("RICOCHET_BRIDGE_SECRET")
+	var header http.Header
+	if secret != "" {
+		header = http.Header{"Authorization": {"Bearer " + secret}}
+	}
+
+	dialer := httpclient.WebsocketDialer()
+	conn, _, err := dialer.DialContext(ctx, u.String(), header)
 	if err != nil {
 		return fmt.Errorf("websocket dial: %w", err)
 	}
@@ -58,91 +269,262 @@ func (c *Client) Start(ctx context.Context) error {
 	// Start Yamux session
 	session, err := yamux.Client(rwc, nil)
 	if err != nil {
+		conn.Close()
 		return fmt.Errorf("yamux client: %w", err)
 	}
-	c.session = session
 
 	// gRPC over yamux
-	c.grpcConn, err = grpc.DialContext(ctx, "",
+	grpcConn, err := grpc.DialContext(ctx, "",
 		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
 			return session.Open()
 		}),
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		// Room for attachments
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMessageSize), grpc.MaxCallSendMsgSize(maxMessageSize)),
 	)
 	if err != nil {
+		session.Close()
 		return fmt.Errorf("grpc dial: %w", err)
 	}
+	fail := func(err error) error {
+		grpcConn.Close()
+		session.Close()
+		return err
+	}
 
 	// Initialize sub-clients
-	c.bridgeClient = proto.NewBridgeServiceClient(c.grpcConn)
-	c.chatClient = proto.NewChatServiceClient(c.grpcConn)
-	c.sttClient = proto.NewSTTServiceClient(c.grpcConn)
+	bridgeClient := proto.NewBridgeServiceClient(grpcConn)
+	chatClient := proto.NewChatServiceClient(grpcConn)
+	sttClient := proto.NewSTTServiceClient(grpcConn)
 
 	// Add auth secret to metadata
-	secret := os.Getenv("RICOCHET_BRIDGE_SECRET")
 	ctx = metadata.AppendToOutgoingContext(ctx, "x-bridge-secret", secret)
 
 	// 1. Handshake
-	resp, err := c.bridgeClient.Handshake(ctx, &proto.HandshakeRequest{
+	c.connMu.Lock()
+	host := c.host
+	c.connMu.Unlock()
+	resp, err := bridgeClient.Handshake(ctx, &proto.HandshakeRequest{
 		SessionId: c.sessionID,
 		Version:   "1.0.0",
 		Secret:    secret,
+		Host:      host,
 	})
 	if err != nil {
-		return fmt.Errorf("handshake failed: %w", err)
+		return fail(fmt.Errorf("handshake failed: %w", err))
 	}
 	if !resp.Success {
-		return fmt.Errorf("handshake rejected: %s", resp.Message)
+		return fail(fmt.Errorf("handshake rejected: %s", resp.Message))
 	}
 
 	log.Printf("Cloud Bridge Handshake successful: %s", resp.Message)
 
 	// 2. Start streaming events
-	c.eventStream, err = c.chatClient.StreamEvents(ctx, &proto.Empty{})
+	eventStream, err := chatClient.StreamEvents(ctx, &proto.Empty{})
 	if err != nil {
-		return fmt.Errorf("stream events failed: %w", err)
+		return fail(fmt.Errorf("stream events failed: %w", err))
 	}
 
-	// Start listening for incoming events
-	go c.listenLoop()
-
+	c.connMu.Lock()
+	c.session, c.grpcConn = session, grpcConn
+	c.bridgeClient, c.chatClient, c.sttClient = bridgeClient, chatClient, sttClient
+	c.eventStream = eventStream
+	c.connected = true
+	c.connMu.Unlock()
 	return nil
 }
 
-func (c *Client) listenLoop() {
+// disconnect tears down the current connection
+func (c *Client) disconnect() {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	c.connected = false
+	if c.eventStream != nil {
+		c.eventStream.CloseSend()
+	}
+	if c.grpcConn != nil {
+		c.grpcConn.Close()
+	}
+	if c.session != nil {
+		c.session.Close()
+	}
+}
+
+// listen forwards incoming events until the stream drops. Once the bridge
+// has sent a heartbeat, missing the next ones for heartbeatTimeout drops
+// the connection so it gets reestablished.
+func (c *Client) listen() error {
+	c.connMu.Lock()
+	stream := c.eventStream
+	c.connMu.Unlock()
+
+	var watchdog *time.Timer
+	defer func() {
+		if watchdog != nil {
+			watchdog.Stop()
+		}
+	}()
+
 	for {
-		event, err := c.eventStream.Recv()
+		event, err := stream.Recv()
 		if err != nil {
-			log.Printf("Bridge stream closed: %v", err)
-			return
+			return err
+		}
+		if event.GetHeartbeat() != nil {
+			if watchdog == nil {
+				watchdog = time.AfterFunc(heartbeatTimeout, func() {
+					log.Printf("No heartbeat from Ricochet Cloud for %v, reconnecting", heartbeatTimeout)
+					c.disconnect()
+				})
+			} else {
+				watchdog.Reset(heartbeatTimeout)
+			}
+			continue
+		}
+		if msg := event.GetIncomingMessage(); msg != nil {
+			c.rememberPlatform(msg.ChatId, msg.Platform)
+		}
+		if cb := event.GetCallback(); cb != nil {
+			c.rememberPlatform(cb.ChatId, cb.Platform)
 		}
 		c.incomingCh <- event
 	}
 }
 
+// rememberPlatform notes the platform a chat is on, so replies go back there
+func (c *Client) rememberPlatform(chatID int64, platform string) {
+	if platform == "" {
+		return
+	}
+	c.platformsMu.Lock()
+	c.platforms[chatID] = platform
+	c.platformsMu.Unlock()
+}
+
+// platform returns the platform a chat is on, defaulting to Telegram
+func (c *Client) platform(chatID int64) string {
+	c.platformsMu.Lock()
+	defer c.platformsMu.Unlock()
+	if p, ok := c.platforms[chatID]; ok {
+		return p
+	}
+	return "telegram"
+}
+
+// Send delivers an outgoing message. While disconnected, or while older
+// messages are still being replayed, it is queued in the outbox instead.
 func (c *Client) Send(event *proto.BridgeEvent) error {
 	// For backward compatibility, we still have Send, but it should ideally use SendMessage
 	// If the payload is an outgoing message, we route it to chatClient
-	if msg := event.GetOutgoingMessage(); msg != nil {
-		_, err := c.chatClient.SendMessage(context.Background(), msg)
-		return err
+	msg := event.GetOutgoingMessage()
+	if msg == nil {
+		return fmt.Errorf("direct send of event type not implemented via ChatService yet")
+	}
+	if msg.Platform == "" {
+		msg.Platform = c.platform(msg.ChatId)
 	}
 
-	return fmt.Errorf("direct send of event type not implemented via ChatService yet")
+	id := newMessageID()
+	if c.outbox == nil || (c.isConnected() && c.outbox.Len() == 0) {
+		err := c.deliver(id, msg)
+		if c.outbox == nil || !isTransient(err) {
+			return err
+		}
+		log.Printf("Bridge send failed, queueing message: %v", err)
+	}
+
+	if err := c.outbox.Add(id, msg); err != nil {
+		return fmt.Errorf("failed to queue message: %w", err)
+	}
+	c.setState(c.State())
+	if c.isConnected() {
+		go c.flush()
+	}
+	return nil
 }
 
-func (c *Client) Incoming() <-chan *proto.BridgeEvent {
-	return c.incomingCh
+// deliver sends a message once under its dedupe ID
+func (c *Client) deliver(id string, msg *proto.OutgoingMessage) error {
+	c.connMu.Lock()
+	chat, connected := c.chatClient, c.connected
+	c.connMu.Unlock()
+	if !connected {
+		return status.Error(codes.Unavailable, "not connected")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, MessageIDKey, id)
+	_, err := chat.SendMessage(ctx, msg)
+	return err
 }
 
-func (c *Client) Close() {
-	if c.eventStream != nil {
-		c.eventStream.CloseSend()
+// flush replays the outbox in order. It stops at the first transient error
+// to retry after the next reconnect; messages the cloud rejects are dropped.
+func (c *Client) flush() {
+	if c.outbox == nil {
+		return
 	}
-	if c.grpcConn != nil {
-		c.grpcConn.Close()
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	ids, msgs := c.outbox.Pending()
+	if len(ids) == 0 {
+		return
 	}
-	if c.session != nil {
-		c.session.Close()
+	log.Printf("Replaying %d queued bridge message(s)", len(ids))
+	for i, id := range ids {
+		err := c.deliver(id, msgs[i])
+		if isTransient(err) {
+			log.Printf("Bridge replay interrupted: %v", err)
+			break
+		}
+		if err != nil {
+			log.Printf("Bridge dropped queued message %s: %v", id, err)
+		}
+		if err := c.outbox.Remove(id); err != nil {
+			log.Printf("Failed to update bridge outbox: %v", err)
+		}
+	}
+	c.setState(c.State())
+}
+
+// isConnected reports whether the client currently has a connection
+func (c *Client) isConnected() bool {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	return c.connected
+}
+
+// isTransient reports whether a send failed for connection reasons and is
+// worth retrying
+func isTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// newMessageID returns a random dedupe ID
+func newMessageID() string {
+	b := make([]byte, 12)
+	if _, err := cryptorand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
 	}
+	return hex.EncodeToString(b)
+}
+
+func (c *Client) Incoming() <-chan *proto.BridgeEvent {
+	return c.incomingCh
+}
+
+// Close disconnects and stops reconnecting; queued messages stay in the
+// outbox for the next run
+func (c *Client) Close() {
+	c.closeOnce.Do(func() { close(c.done) })
+	c.disconnect()
 }
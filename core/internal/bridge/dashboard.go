@@ -0,0 +1,483 @@
+package bridge
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/bridge/proto"
+)
+
+// Dashboard limits
+const (
+	maxRecentMessages = 100
+	maxPendingAsks    = 50
+	maxListedSessions = 50
+	maxDashboardBody  = 64 << 10
+)
+
+//go:embed dashboard.html
+var dashboardHTML []byte
+
+// AgentInfo is an agent connected to the bridge
+type AgentInfo struct {
+	ID          string    `json:"id"`
+	SessionID   string    `json:"sessionId"`
+	Host        string    `json:"host"`
+	Version     string    `json:"version"`
+	RemoteAddr  string    `json:"remoteAddr"`
+	ConnectedAt time.Time `json:"connectedAt"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+// SessionInfo is a session seen by the bridge
+type SessionInfo struct {
+	ID           string    `json:"id"`
+	Connected    bool      `json:"connected"`
+	Messages     int       `json:"messages"`
+	LastActivity time.Time `json:"lastActivity"`
+}
+
+// MessageRecord is a message that went through the bridge
+type MessageRecord struct {
+	Time        time.Time `json:"time"`
+	SessionID   string    `json:"sessionId,omitempty"`
+	Direction   string    `json:"direction"` // "in" from a user, "out" from an agent
+	Platform    string    `json:"platform"`
+	ChatID      int64     `json:"chatId"`
+	Body        string    `json:"body"`
+	Attachments []string  `json:"attachments,omitempty"` // File names
+}
+
+// AskButton is an answer offered by an ask
+type AskButton struct {
+	Text string `json:"text"`
+	Data string `json:"data"`
+}
+
+// Ask is an agent message with reply buttons still waiting for an answer,
+// such as an approval
+type Ask struct {
+	ID        string        `json:"id"`
+	SessionID string        `json:"sessionId,omitempty"`
+	Platform  string        `json:"platform"`
+	ChatID    int64         `json:"chatId"`
+	Question  string        `json:"question"`
+	Buttons   [][]AskButton `json:"buttons"`
+	AskedAt   time.Time     `json:"askedAt"`
+}
+
+// DashboardState is what the dashboard shows
+type DashboardState struct {
+	Hosts    []HostInfo      `json:"hosts"`
+	Agents   []AgentInfo     `json:"agents"`
+	Sessions []SessionInfo   `json:"sessions"`
+	Messages []MessageRecord `json:"messages"`
+	Asks     []Ask           `json:"asks"`
+}
+
+// activity tracks the agents, sessions, messages and open asks going
+// through the server, for the dashboard
+type activity struct {
+	mu       sync.Mutex
+	nextID   int
+	agents   map[string]*AgentInfo
+	sessions map[string]*SessionInfo
+	messages []MessageRecord
+	asks     []*Ask
+}
+
+func newActivity() *activity {
+	return &activity{
+		agents:   make(map[string]*AgentInfo),
+		sessions: make(map[string]*SessionInfo),
+	}
+}
+
+// agentKey carries the ID of the agent behind a call in its context
+type agentKey struct{}
+
+// agentConn serves the calls of one agent connection, tagging their context
+// with the agent so the server knows who made them
+type agentConn struct {
+	*Server
+	agentID string
+}
+
+func (a *agentConn) Handshake(ctx context.Context, req *proto.HandshakeRequest) (*proto.HandshakeResponse, error) {
+	return a.Server.Handshake(context.WithValue(ctx, agentKey{}, a.agentID), req)
+}
+
+func (a *agentConn) StreamEvents(empty *proto.Empty, stream proto.ChatService_StreamEventsServer) error {
+	return a.Server.streamEvents(a.agentID, stream)
+}
+
+func (a *agentConn) SendMessage(ctx context.Context, msg *proto.OutgoingMessage) (*proto.MessageResponse, error) {
+	return a.Server.SendMessage(context.WithValue(ctx, agentKey{}, a.agentID), msg)
+}
+
+// agentFromContext returns the ID of the agent behind a call, if known
+func agentFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(agentKey{}).(string)
+	return id
+}
+
+// connect registers a new agent connection and returns its ID
+func (a *activity) connect(remoteAddr string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nextID++
+	now := time.Now()
+	id := fmt.Sprintf("agent-%d", a.nextID)
+	a.agents[id] = &AgentInfo{ID: id, RemoteAddr: remoteAddr, ConnectedAt: now, LastSeen: now}
+	return id
+}
+
+// disconnect forgets an agent connection
+func (a *activity) disconnect(agentID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.agents, agentID)
+}
+
+// handshake records the host, session and version an agent connected with
+func (a *activity) handshake(agentID, host string, req *proto.HandshakeRequest) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	agent, ok := a.agents[agentID]
+	if !ok {
+		return
+	}
+	agent.SessionID = req.SessionId
+	agent.Host = host
+	agent.Version = req.Version
+	agent.LastSeen = time.Now()
+	a.touchSession(req.SessionId, false)
+}
+
+// outgoing records a message sent by an agent, opening an ask if it offers
+// buttons
+func (a *activity) outgoing(agentID string, msg *proto.OutgoingMessage) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var sessionID string
+	if agent, ok := a.agents[agentID]; ok {
+		agent.LastSeen = time.Now()
+		sessionID = agent.SessionID
+	}
+	a.record(MessageRecord{
+		Time:        time.Now(),
+		SessionID:   sessionID,
+		Direction:   "out",
+		Platform:    msg.Platform,
+		ChatID:      msg.ChatId,
+		Body:        msg.Body,
+		Attachments: attachmentNames(msg.Attachments),
+	})
+
+	if len(msg.Buttons) == 0 {
+		return
+	}
+	ask := &Ask{
+		SessionID: sessionID,
+		Platform:  msg.Platform,
+		ChatID:    msg.ChatId,
+		Question:  msg.Body,
+		AskedAt:   time.Now(),
+	}
+	for _, row := range msg.Buttons {
+		var buttons []AskButton
+		for _, btn := range row.Buttons {
+			buttons = append(buttons, AskButton{Text: btn.Text, Data: btn.Data})
+		}
+		ask.Buttons = append(ask.Buttons, buttons)
+	}
+	// Random so that a page can't guess the answer URL of an open ask
+	ask.ID = "ask-" + rand.Text()
+	a.asks = append(a.asks, ask)
+	if over := len(a.asks) - maxPendingAsks; over > 0 {
+		a.asks = a.asks[over:]
+	}
+}
+
+// incoming records a user message or button press. It answers the asks
+// open in its chat, as the agent takes it as the answer.
+func (a *activity) incoming(event *proto.BridgeEvent) {
+	rec := MessageRecord{Time: time.Now(), SessionID: event.SessionId, Direction: "in"}
+	if msg := event.GetIncomingMessage(); msg != nil {
+		rec.Platform, rec.ChatID, rec.Body = msg.Platform, msg.ChatId, msg.Body
+		rec.Attachments = attachmentNames(msg.Attachments)
+	} else if cb := event.GetCallback(); cb != nil {
+		rec.Platform, rec.ChatID, rec.Body = cb.Platform, cb.ChatId, "🔘 "+cb.Label
+	} else {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.record(rec)
+
+	open := a.asks[:0]
+	for _, ask := range a.asks {
+		if ask.ChatID != rec.ChatID || ask.Platform != rec.Platform {
+			open = append(open, ask)
+		}
+	}
+	a.asks = open
+}
+
+// attachmentNames lists the names of attachments
+func attachmentNames(atts []*proto.Attachment) []string {
+	var names []string
+	for _, att := range atts {
+		names = append(names, att.Name)
+	}
+	return names
+}
+
+// record keeps a message in the recent list. The caller holds the lock.
+func (a *activity) record(m MessageRecord) {
+	a.messages = append(a.messages, m)
+	if over := len(a.messages) - maxRecentMessages; over > 0 {
+		a.messages = append(a.messages[:0:0], a.messages[over:]...)
+	}
+	if m.SessionID != "" {
+		a.touchSession(m.SessionID, true)
+	}
+}
+
+// touchSession notes activity in a session. The caller holds the lock.
+func (a *activity) touchSession(sessionID string, message bool) {
+	if sessionID == "" {
+		return
+	}
+	sess, ok := a.sessions[sessionID]
+	if !ok {
+		sess = &SessionInfo{ID: sessionID}
+		a.sessions[sessionID] = sess
+	}
+	sess.LastActivity = time.Now()
+	if message {
+		sess.Messages++
+	}
+}
+
+// ask returns an open ask by ID
+func (a *activity) ask(id string) (Ask, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, ask := range a.asks {
+		if ask.ID == id {
+			return *ask, true
+		}
+	}
+	return Ask{}, false
+}
+
+// snapshot returns the dashboard state: newest messages and sessions first
+func (a *activity) snapshot() DashboardState {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	st := DashboardState{
+		Agents:   []AgentInfo{},
+		Sessions: []SessionInfo{},
+		Messages: make([]MessageRecord, 0, len(a.messages)),
+		Asks:     make([]Ask, 0, len(a.asks)),
+	}
+	connected := make(map[string]bool)
+	for _, agent := range a.agents {
+		st.Agents = append(st.Agents, *agent)
+		connected[agent.SessionID] = true
+	}
+	sort.Slice(st.Agents, func(i, j int) bool { return st.Agents[i].ConnectedAt.Before(st.Agents[j].ConnectedAt) })
+
+	for _, sess := range a.sessions {
+		info := *sess
+		info.Connected = connected[sess.ID]
+		st.Sessions = append(st.Sessions, info)
+	}
+	sort.Slice(st.Sessions, func(i, j int) bool { return st.Sessions[i].LastActivity.After(st.Sessions[j].LastActivity) })
+	if len(st.Sessions) > maxListedSessions {
+		st.Sessions = st.Sessions[:maxListedSessions]
+	}
+
+	for i := len(a.messages) - 1; i >= 0; i-- {
+		st.Messages = append(st.Messages, a.messages[i])
+	}
+	for _, ask := range a.asks {
+		st.Asks = append(st.Asks, *ask)
+	}
+	return st
+}
+
+// EnableDashboard serves a web dashboard at /dashboard showing connected
+// agents, sessions, recent messages and open asks, which can be answered
+// from the browser. Requests must carry the token as a bearer token or a
+// token query parameter. Without a token, a random one is generated and only
+// requests from the local machine are served. It returns the token.
+func (s *Server) EnableDashboard(token string) string {
+	s.dashboardOn = true
+	s.dashboardLocal = token == ""
+	if token == "" {
+		token = rand.Text()
+		log.Printf("Dashboard enabled without a token: generated one, only reachable from localhost")
+	}
+	s.dashboardToken = token
+	return token
+}
+
+// registerDashboard adds the dashboard routes to mux
+func (s *Server) registerDashboard(mux *http.ServeMux) {
+	mux.HandleFunc("GET /dashboard", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(dashboardHTML)
+	}))
+	mux.HandleFunc("GET /dashboard/api/state", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+		st := s.activity.snapshot()
+		st.Hosts = s.relay.list()
+		writeJSON(w, http.StatusOK, st)
+	}))
+	mux.HandleFunc("POST /dashboard/api/asks/{id}/answer", s.authorized(s.handleAnswer))
+}
+
+// authorized checks the dashboard token before calling next. Requests from
+// another origin are refused, and posts must be JSON, so that other pages
+// open in the browser can't use the dashboard. With a generated token,
+// requests must also come from a loopback address, not through a proxy, and
+// name a local host, which defeats DNS rebinding.
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.dashboardLocal && (!isLocalRequest(r) || !isLocalHost(r.Host)) {
+			http.Error(w, "the dashboard needs a token to be reached from another machine", http.StatusForbidden)
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+				http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+				return
+			}
+		}
+		token := r.URL.Query().Get("token")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			token = bearer
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.dashboardToken)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodPost {
+			if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+				http.Error(w, "requests must be application/json", http.StatusUnsupportedMediaType)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// isLocalHost reports whether a Host header names the local machine
+func isLocalHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isLocalRequest reports whether r comes straight from the local machine
+func isLocalRequest(r *http.Request) bool {
+	if r.Header.Get("X-Forwarded-For") != "" || r.Header.Get("Forwarded") != "" {
+		return false
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// answerRequest is an answer to an ask: the data of a button, reported as a
+// Callback, or free text
+type answerRequest struct {
+	Data string `json:"data"`
+	Text string `json:"text"`
+}
+
+// handleAnswer delivers a browser answer to an ask as if the user had
+// replied from their messenger
+func (s *Server) handleAnswer(w http.ResponseWriter, r *http.Request) {
+	ask, ok := s.activity.ask(r.PathValue("id"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "ask is no longer open"})
+		return
+	}
+
+	var req answerRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxDashboardBody)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+		return
+	}
+
+	event := &proto.BridgeEvent{SessionId: ask.SessionID}
+	switch {
+	case req.Data != "":
+		for _, row := range ask.Buttons {
+			for _, btn := range row {
+				if btn.Data == req.Data {
+					event.Payload = &proto.BridgeEvent_Callback{Callback: &proto.Callback{
+						ChatId:   ask.ChatID,
+						Platform: ask.Platform,
+						Data:     btn.Data,
+						Label:    btn.Text,
+					}}
+				}
+			}
+		}
+		if event.Payload == nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown button"})
+			return
+		}
+	case strings.TrimSpace(req.Text) != "":
+		event.Payload = &proto.BridgeEvent_IncomingMessage{IncomingMessage: &proto.IncomingMessage{
+			ChatId:   ask.ChatID,
+			Platform: ask.Platform,
+			Body:     strings.TrimSpace(req.Text),
+		}}
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "empty answer"})
+		return
+	}
+
+	log.Printf("Dashboard answered %s in chat %d", ask.ID, ask.ChatID)
+	s.broadcast(event)
+	writeJSON(w, http.StatusOK, map[string]string{"status": "sent"})
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to write dashboard response: %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/bridge/proto"
+)
+
+func newTestDashboard(t *testing.T) (*Server, *httptest.Server) {
+	s := NewServer(0)
+	s.EnableDashboard("secret")
+	mux := http.NewServeMux()
+	s.registerDashboard(mux)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+// askFromAgent connects an agent that asks for an approval, and returns the
+// ask's ID and the agent's event stream
+func askFromAgent(t *testing.T, s *Server) (string, chan *proto.BridgeEvent) {
+	events := connectHost(t, s, "laptop", "session-1")
+	agentID := s.streams[events]
+	ctx := context.WithValue(t.Context(), agentKey{}, agentID)
+	s.SendMessage(ctx, &proto.OutgoingMessage{
+		ChatId:   42,
+		Platform: "whatsapp-test",
+		Body:     "Run rm -rf build?",
+		Buttons: []*proto.ButtonRow{{Buttons: []*proto.Button{
+			{Text: "✅ Approve", Data: "yes"},
+			{Text: "❌ Reject", Data: "no"},
+		}}},
+	})
+
+	st := s.activity.snapshot()
+	if len(st.Asks) != 1 {
+		t.Fatalf("asks = %d, want 1", len(st.Asks))
+	}
+	return st.Asks[0].ID, events
+}
+
+func TestDashboard_RequiresToken(t *testing.T) {
+	_, ts := newTestDashboard(t)
+
+	resp, err := http.Get(ts.URL + "/dashboard/api/state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("without token: status %d, want 401", resp.StatusCode)
+	}
+
+	resp, err = http.Get(ts.URL + "/dashboard?token=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("with token: status %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestDashboard_WithoutTokenOnlyLocal(t *testing.T) {
+	s := NewServer(0)
+	token := s.EnableDashboard("")
+	if token == "" {
+		t.Fatal("no token was generated")
+	}
+	mux := http.NewServeMux()
+	s.registerDashboard(mux)
+
+	for _, tc := range []struct {
+		remote, host, forwarded string
+		want                    int
+	}{
+		{"127.0.0.1:5000", "localhost:8080", "", http.StatusOK},
+		{"[::1]:5000", "[::1]:8080", "", http.StatusOK},
+		{"127.0.0.1:5000", "127.0.0.1:8080", "", http.StatusOK},
+		{"203.0.113.7:5000", "localhost:8080", "", http.StatusForbidden},
+		{"127.0.0.1:5000", "localhost:8080", "203.0.113.7", http.StatusForbidden},
+		// A rebound DNS name resolving to 127.0.0.1
+		{"127.0.0.1:5000", "attacker.example:8080", "", http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/dashboard/api/state", nil)
+		req.RemoteAddr = tc.remote
+		req.Host = tc.host
+		req.Header.Set("Authorization", "Bearer "+token)
+		if tc.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", tc.forwarded)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s to %s (forwarded %q): status %d, want %d", tc.remote, tc.host, tc.forwarded, rec.Code, tc.want)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/dashboard/api/state", nil)
+	req.RemoteAddr = "127.0.0.1:5000"
+	req.Host = "localhost:8080"
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("local request without the generated token: status %d, want 401", rec.Code)
+	}
+}
+
+func TestDashboard_RejectsCrossSiteAnswers(t *testing.T) {
+	s, ts := newTestDashboard(t)
+	askID, _ := askFromAgent(t, s)
+	url := ts.URL + "/dashboard/api/asks/" + askID + "/answer?token=secret"
+
+	// A form post from another page needs no preflight
+	req, _ := http.NewRequest("POST", url, strings.NewReader(`{"data":"yes"}`))
+	req.Header.Set("Content-Type", "text/plain")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("text/plain answer: status %d, want 415", resp.StatusCode)
+	}
+
+	req, _ = http.NewRequest("POST", url, strings.NewReader(`{"data":"yes"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "http://attacker.example")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("cross-origin answer: status %d, want 403", resp.StatusCode)
+	}
+
+	if !strings.HasPrefix(askID, "ask-") || askID == "ask-1" {
+		t.Errorf("ask ID %q is guessable", askID)
+	}
+	if st := s.activity.snapshot(); len(st.Asks) != 1 {
+		t.Errorf("a rejected answer closed the ask: %+v", st.Asks)
+	}
+}
+
+func TestDashboard_State(t *testing.T) {
+	s, ts := newTestDashboard(t)
+	askFromAgent(t, s)
+
+	req, _ := http.NewRequest("GET", ts.URL+"/dashboard/api/state", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var st DashboardState
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Agents) != 1 || st.Agents[0].SessionID != "session-1" {
+		t.Errorf("agents = %+v", st.Agents)
+	}
+	if len(st.Sessions) != 1 || !st.Sessions[0].Connected || st.Sessions[0].Messages != 1 {
+		t.Errorf("sessions = %+v", st.Sessions)
+	}
+	if len(st.Messages) != 1 || st.Messages[0].Direction != "out" {
+		t.Errorf("messages = %+v", st.Messages)
+	}
+}
+
+func TestDashboard_AnswerAsk(t *testing.T) {
+	s, ts := newTestDashboard(t)
+	askID, events := askFromAgent(t, s)
+
+	answer := func(body string) int {
+		req, _ := http.NewRequest("POST", ts.URL+"/dashboard/api/asks/"+askID+"/answer?token=secret", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", ts.URL)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := answer(`{"data":"maybe"}`); code != http.StatusBadRequest {
+		t.Errorf("unknown button: status %d, want 400", code)
+	}
+	if code := answer(`{"data":"yes"}`); code != http.StatusOK {
+		t.Fatalf("answer: status %d, want 200", code)
+	}
+
+	event := <-events
+	cb := event.GetCallback()
+	if event.SessionId != "session-1" || cb.ChatId != 42 || cb.Platform != "whatsapp-test" || cb.Data != "yes" || cb.Label != "✅ Approve" {
+		t.Errorf("event = %+v", event)
+	}
+
+	// Answered asks close
+	if code := answer(`{"data":"yes"}`); code != http.StatusNotFound {
+		t.Errorf("second answer: status %d, want 404", code)
+	}
+}
+
+func TestActivity_IncomingClosesAsksOfItsChat(t *testing.T) {
+	a := newActivity()
+	a.outgoing("", &proto.OutgoingMessage{ChatId: 1, Platform: "telegram", Buttons: []*proto.ButtonRow{{}}})
+	a.outgoing("", &proto.OutgoingMessage{ChatId: 2, Platform: "telegram", Buttons: []*proto.ButtonRow{{}}})
+
+	a.incoming(&proto.BridgeEvent{Payload: &proto.BridgeEvent_Callback{Callback: &proto.Callback{ChatId: 1, Platform: "telegram", Data: "yes"}}})
+
+	st := a.snapshot()
+	if len(st.Asks) != 1 || st.Asks[0].ChatID != 2 {
+		t.Errorf("asks = %+v, want only chat 2's", st.Asks)
+	}
+}
@@ -0,0 +1,127 @@
+package bridge
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/bridge/proto"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// maxOutbox bounds the outbox; the oldest messages are dropped past it
+const maxOutbox = 500
+
+// outboxEntry is a message waiting to be delivered
+type outboxEntry struct {
+	ID       string    `json:"id"`      // Dedupe ID, sent along on every attempt
+	Message  []byte    `json:"message"` // proto-encoded OutgoingMessage
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// Outbox keeps outgoing messages that could not be delivered, on disk, so
+// they survive a restart and are replayed in order after reconnecting
+type Outbox struct {
+	mu      sync.Mutex
+	path    string
+	entries []outboxEntry
+}
+
+// OpenOutbox loads the outbox stored at path, or starts an empty one
+func OpenOutbox(path string) (*Outbox, error) {
+	o := &Outbox{path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &o.entries); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Add queues a message under its dedupe ID
+func (o *Outbox) Add(id string, msg *proto.OutgoingMessage) error {
+	data, err := protobuf.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.entries = append(o.entries, outboxEntry{ID: id, Message: data, QueuedAt: time.Now()})
+	if over := len(o.entries) - maxOutbox; over > 0 {
+		log.Printf("Bridge outbox full, dropping %d oldest message(s)", over)
+		o.entries = append(o.entries[:0:0], o.entries[over:]...)
+	}
+	return o.save()
+}
+
+// Pending returns the queued messages by ID, oldest first
+func (o *Outbox) Pending() ([]string, []*proto.OutgoingMessage) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	ids := make([]string, 0, len(o.entries))
+	msgs := make([]*proto.OutgoingMessage, 0, len(o.entries))
+	for _, e := range o.entries {
+		msg := &proto.OutgoingMessage{}
+		if err := protobuf.Unmarshal(e.Message, msg); err != nil {
+			log.Printf("Bridge outbox: skipping unreadable message %s: %v", e.ID, err)
+			continue
+		}
+		ids = append(ids, e.ID)
+		msgs = append(msgs, msg)
+	}
+	return ids, msgs
+}
+
+// Remove drops a message once it is delivered (or undeliverable)
+func (o *Outbox) Remove(id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i, e := range o.entries {
+		if e.ID == id {
+			o.entries = append(o.entries[:i], o.entries[i+1:]...)
+			return o.save()
+		}
+	}
+	return nil
+}
+
+// Len returns the number of queued messages
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.entries)
+}
+
+// save writes the outbox through a temp file, so a crash can't truncate it.
+// The caller holds the lock.
+func (o *Outbox) save() error {
+	if len(o.entries) == 0 {
+		if err := os.Remove(o.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(o.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(o.path), 0755); err != nil {
+		return err
+	}
+	tmp := o.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, o.path)
+}
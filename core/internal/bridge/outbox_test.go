@@ -0,0 +1,85 @@
+package bridge
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/bridge/proto"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestOutbox_PersistsInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	o, err := OpenOutbox(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Add("a", &proto.OutgoingMessage{ChatId: 1, Body: "first"})
+	o.Add("b", &proto.OutgoingMessage{ChatId: 1, Body: "second"})
+	o.Add("c", &proto.OutgoingMessage{ChatId: 1, Body: "third"})
+	o.Remove("b")
+
+	reopened, err := OpenOutbox(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, msgs := reopened.Pending()
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "c" {
+		t.Fatalf("ids = %v, want [a c]", ids)
+	}
+	if msgs[0].Body != "first" || msgs[1].Body != "third" {
+		t.Errorf("bodies = %q, %q", msgs[0].Body, msgs[1].Body)
+	}
+
+	reopened.Remove("a")
+	reopened.Remove("c")
+	if empty, _ := OpenOutbox(path); empty.Len() != 0 {
+		t.Errorf("emptied outbox reloaded with %d messages", empty.Len())
+	}
+}
+
+func TestOutbox_DropsOldestWhenFull(t *testing.T) {
+	o, _ := OpenOutbox(filepath.Join(t.TempDir(), "outbox.json"))
+	for i := range maxOutbox + 3 {
+		o.Add(fmt.Sprint(i), &proto.OutgoingMessage{Body: fmt.Sprint(i)})
+	}
+	ids, _ := o.Pending()
+	if len(ids) != maxOutbox || ids[0] != "3" {
+		t.Errorf("len = %d, first = %s; want %d, 3", len(ids), ids[0], maxOutbox)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	for attempt, base := range map[int]time.Duration{
+		1:  minBackoff,
+		2:  2 * minBackoff,
+		4:  8 * minBackoff,
+		20: maxBackoff,
+	} {
+		d := backoffDelay(attempt)
+		if d < base || d > base+base/5 {
+			t.Errorf("attempt %d: delay %v outside [%v, %v]", attempt, d, base, base+base/5)
+		}
+	}
+}
+
+func TestServer_SendMessageDedupe(t *testing.T) {
+	s := NewServer(0)
+	ctx := metadata.NewIncomingContext(t.Context(), metadata.Pairs(MessageIDKey, "m1"))
+	first, err := s.SendMessage(ctx, &proto.OutgoingMessage{ChatId: 1, Body: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay, err := s.SendMessage(ctx, &proto.OutgoingMessage{ChatId: 1, Body: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay != first {
+		t.Error("replayed message was not answered with the first response")
+	}
+	if len(s.delivered) != 1 {
+		t.Errorf("delivered = %d, want 1", len(s.delivered))
+	}
+}
@@ -62,6 +62,7 @@ type HandshakeRequest struct {
 	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
 	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
 	Secret        string                 `protobuf:"bytes,3,opt,name=secret,proto3" json:"secret,omitempty"`
+	Host          string                 `protobuf:"bytes,4,opt,name=host,proto3" json:"host,omitempty"` // Machine the agent runs on, e.g. "laptop"
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -117,6 +118,13 @@ func (x *HandshakeRequest) GetSecret() string {
 	return ""
 }
 
+func (x *HandshakeRequest) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
 type HandshakeResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
@@ -335,6 +343,7 @@ type BridgeEvent struct {
 	//	*BridgeEvent_ToolCall
 	//	*BridgeEvent_ToolResult
 	//	*BridgeEvent_Heartbeat
+	//	*BridgeEvent_Callback
 	Payload       isBridgeEvent_Payload `protobuf_oneof:"payload"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -429,6 +438,15 @@ func (x *BridgeEvent) GetHeartbeat() *Heartbeat {
 	return nil
 }
 
+func (x *BridgeEvent) GetCallback() *Callback {
+	if x != nil {
+		if x, ok := x.Payload.(*BridgeEvent_Callback); ok {
+			return x.Callback
+		}
+	}
+	return nil
+}
+
 type isBridgeEvent_Payload interface {
 	isBridgeEvent_Payload()
 }
@@ -453,6 +471,10 @@ type BridgeEvent_Heartbeat struct {
 	Heartbeat *Heartbeat `protobuf:"bytes,6,opt,name=heartbeat,proto3,oneof"`
 }
 
+type BridgeEvent_Callback struct {
+	Callback *Callback `protobuf:"bytes,7,opt,name=callback,proto3,oneof"` // A button press
+}
+
 func (*BridgeEvent_IncomingMessage) isBridgeEvent_Payload() {}
 
 func (*BridgeEvent_OutgoingMessage) isBridgeEvent_Payload() {}
@@ -463,11 +485,15 @@ func (*BridgeEvent_ToolResult) isBridgeEvent_Payload() {}
 
 func (*BridgeEvent_Heartbeat) isBridgeEvent_Payload() {}
 
+func (*BridgeEvent_Callback) isBridgeEvent_Payload() {}
+
 type IncomingMessage struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ChatId        int64                  `protobuf:"varint,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
 	Body          string                 `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
-	Platform      string                 `protobuf:"bytes,3,opt,name=platform,proto3" json:"platform,omitempty"` // "telegram" or "discord"
+	Platform      string                 `protobuf:"bytes,3,opt,name=platform,proto3" json:"platform,omitempty"`                             // "telegram", "discord" or "whatsapp"
+	CallbackData  string                 `protobuf:"bytes,4,opt,name=callback_data,json=callbackData,proto3" json:"callback_data,omitempty"` // Set by older bridges; button presses now come as Callback events
+	Attachments   []*Attachment          `protobuf:"bytes,5,rep,name=attachments,proto3" json:"attachments,omitempty"`                       // Files and images the user sent, body being their caption
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -523,12 +549,28 @@ func (x *IncomingMessage) GetPlatform() string {
 	return ""
 }
 
+func (x *IncomingMessage) GetCallbackData() string {
+	if x != nil {
+		return x.CallbackData
+	}
+	return ""
+}
+
+func (x *IncomingMessage) GetAttachments() []*Attachment {
+	if x != nil {
+		return x.Attachments
+	}
+	return nil
+}
+
 type OutgoingMessage struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ChatId        int64                  `protobuf:"varint,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
 	Body          string                 `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
 	Platform      string                 `protobuf:"bytes,3,opt,name=platform,proto3" json:"platform,omitempty"`
 	ParseMode     string                 `protobuf:"bytes,4,opt,name=parse_mode,json=parseMode,proto3" json:"parse_mode,omitempty"`
+	Buttons       []*ButtonRow           `protobuf:"bytes,5,rep,name=buttons,proto3" json:"buttons,omitempty"`         // Reply buttons; platforms without them get a numbered list
+	Attachments   []*Attachment          `protobuf:"bytes,6,rep,name=attachments,proto3" json:"attachments,omitempty"` // Files sent after the body
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -591,6 +633,20 @@ func (x *OutgoingMessage) GetParseMode() string {
 	return ""
 }
 
+func (x *OutgoingMessage) GetButtons() []*ButtonRow {
+	if x != nil {
+		return x.Buttons
+	}
+	return nil
+}
+
+func (x *OutgoingMessage) GetAttachments() []*Attachment {
+	if x != nil {
+		return x.Attachments
+	}
+	return nil
+}
+
 type ToolCall struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	CallId        string                 `protobuf:"bytes,1,opt,name=call_id,json=callId,proto3" json:"call_id,omitempty"`
@@ -755,17 +811,258 @@ func (x *Heartbeat) GetTimestamp() int64 {
 	return 0
 }
 
+type Button struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	Data          string                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"` // Reported back as Callback.data
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Button) Reset() {
+	*x = Button{}
+	mi := &file_internal_bridge_proto_bridge_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Button) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Button) ProtoMessage() {}
+
+func (x *Button) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_bridge_proto_bridge_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Button.ProtoReflect.Descriptor instead.
+func (*Button) Descriptor() ([]byte, []int) {
+	return file_internal_bridge_proto_bridge_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Button) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Button) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+type ButtonRow struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Buttons       []*Button              `protobuf:"bytes,1,rep,name=buttons,proto3" json:"buttons,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ButtonRow) Reset() {
+	*x = ButtonRow{}
+	mi := &file_internal_bridge_proto_bridge_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ButtonRow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ButtonRow) ProtoMessage() {}
+
+func (x *ButtonRow) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_bridge_proto_bridge_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ButtonRow.ProtoReflect.Descriptor instead.
+func (*ButtonRow) Descriptor() ([]byte, []int) {
+	return file_internal_bridge_proto_bridge_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ButtonRow) GetButtons() []*Button {
+	if x != nil {
+		return x.Buttons
+	}
+	return nil
+}
+
+type Attachment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // File name, e.g. "screenshot.png"
+	MimeType      string                 `protobuf:"bytes,2,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"` // File contents, unless url is set
+	Url           string                 `protobuf:"bytes,4,opt,name=url,proto3" json:"url,omitempty"`   // Where to fetch the file instead
+	Caption       string                 `protobuf:"bytes,5,opt,name=caption,proto3" json:"caption,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Attachment) Reset() {
+	*x = Attachment{}
+	mi := &file_internal_bridge_proto_bridge_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Attachment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attachment) ProtoMessage() {}
+
+func (x *Attachment) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_bridge_proto_bridge_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attachment.ProtoReflect.Descriptor instead.
+func (*Attachment) Descriptor() ([]byte, []int) {
+	return file_internal_bridge_proto_bridge_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Attachment) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Attachment) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *Attachment) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Attachment) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Attachment) GetCaption() string {
+	if x != nil {
+		return x.Caption
+	}
+	return ""
+}
+
+type Callback struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChatId        int64                  `protobuf:"varint,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	Platform      string                 `protobuf:"bytes,2,opt,name=platform,proto3" json:"platform,omitempty"`
+	Data          string                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`   // Data of the pressed button
+	Label         string                 `protobuf:"bytes,4,opt,name=label,proto3" json:"label,omitempty"` // Text of the pressed button
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Callback) Reset() {
+	*x = Callback{}
+	mi := &file_internal_bridge_proto_bridge_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Callback) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Callback) ProtoMessage() {}
+
+func (x *Callback) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_bridge_proto_bridge_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Callback.ProtoReflect.Descriptor instead.
+func (*Callback) Descriptor() ([]byte, []int) {
+	return file_internal_bridge_proto_bridge_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Callback) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+func (x *Callback) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *Callback) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *Callback) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
 var File_internal_bridge_proto_bridge_proto protoreflect.FileDescriptor
 
 const file_internal_bridge_proto_bridge_proto_rawDesc = "" +
 	"\n" +
 	"\"internal/bridge/proto/bridge.proto\x12\x06bridge\"\a\n" +
-	"\x05Empty\"c\n" +
+	"\x05Empty\"w\n" +
 	"\x10HandshakeRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x18\n" +
 	"\aversion\x18\x02 \x01(\tR\aversion\x12\x16\n" +
-	"\x06secret\x18\x03 \x01(\tR\x06secret\"G\n" +
+	"\x06secret\x18\x03 \x01(\tR\x06secret\x12\x12\n" +
+	"\x04host\x18\x04 \x01(\tR\x04host\"G\n" +
 	"\x11HandshakeResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\"J\n" +
@@ -779,7 +1076,7 @@ const file_internal_bridge_proto_bridge_proto_rawDesc = "" +
 	"\x06format\x18\x02 \x01(\tR\x06format\"E\n" +
 	"\x15TranscriptionResponse\x12\x12\n" +
 	"\x04text\x18\x01 \x01(\tR\x04text\x12\x18\n" +
-	"\asuccess\x18\x02 \x01(\bR\asuccess\"\xde\x02\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\"\x8e\x03\n" +
 	"\vBridgeEvent\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12D\n" +
@@ -788,18 +1085,23 @@ const file_internal_bridge_proto_bridge_proto_rawDesc = "" +
 	"\ttool_call\x18\x04 \x01(\v2\x10.bridge.ToolCallH\x00R\btoolCall\x125\n" +
 	"\vtool_result\x18\x05 \x01(\v2\x12.bridge.ToolResultH\x00R\n" +
 	"toolResult\x121\n" +
-	"\theartbeat\x18\x06 \x01(\v2\x11.bridge.HeartbeatH\x00R\theartbeatB\t\n" +
-	"\apayload\"Z\n" +
+	"\theartbeat\x18\x06 \x01(\v2\x11.bridge.HeartbeatH\x00R\theartbeat\x12.\n" +
+	"\bcallback\x18\a \x01(\v2\x10.bridge.CallbackH\x00R\bcallbackB\t\n" +
+	"\apayload\"\xb5\x01\n" +
 	"\x0fIncomingMessage\x12\x17\n" +
 	"\achat_id\x18\x01 \x01(\x03R\x06chatId\x12\x12\n" +
 	"\x04body\x18\x02 \x01(\tR\x04body\x12\x1a\n" +
-	"\bplatform\x18\x03 \x01(\tR\bplatform\"y\n" +
+	"\bplatform\x18\x03 \x01(\tR\bplatform\x12#\n" +
+	"\rcallback_data\x18\x04 \x01(\tR\fcallbackData\x124\n" +
+	"\vattachments\x18\x05 \x03(\v2\x12.bridge.AttachmentR\vattachments\"\xdc\x01\n" +
 	"\x0fOutgoingMessage\x12\x17\n" +
 	"\achat_id\x18\x01 \x01(\x03R\x06chatId\x12\x12\n" +
 	"\x04body\x18\x02 \x01(\tR\x04body\x12\x1a\n" +
 	"\bplatform\x18\x03 \x01(\tR\bplatform\x12\x1d\n" +
 	"\n" +
-	"parse_mode\x18\x04 \x01(\tR\tparseMode\"g\n" +
+	"parse_mode\x18\x04 \x01(\tR\tparseMode\x12+\n" +
+	"\abuttons\x18\x05 \x03(\v2\x11.bridge.ButtonRowR\abuttons\x124\n" +
+	"\vattachments\x18\x06 \x03(\v2\x12.bridge.AttachmentR\vattachments\"g\n" +
 	"\bToolCall\x12\x17\n" +
 	"\acall_id\x18\x01 \x01(\tR\x06callId\x12\x1b\n" +
 	"\ttool_name\x18\x02 \x01(\tR\btoolName\x12%\n" +
@@ -811,7 +1113,24 @@ const file_internal_bridge_proto_bridge_proto_rawDesc = "" +
 	"resultJson\x12\x19\n" +
 	"\bis_error\x18\x03 \x01(\bR\aisError\")\n" +
 	"\tHeartbeat\x12\x1c\n" +
-	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp2Q\n" +
+	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\"0\n" +
+	"\x06Button\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\tR\x04data\"5\n" +
+	"\tButtonRow\x12(\n" +
+	"\abuttons\x18\x01 \x03(\v2\x0e.bridge.ButtonR\abuttons\"}\n" +
+	"\n" +
+	"Attachment\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1b\n" +
+	"\tmime_type\x18\x02 \x01(\tR\bmimeType\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12\x10\n" +
+	"\x03url\x18\x04 \x01(\tR\x03url\x12\x18\n" +
+	"\acaption\x18\x05 \x01(\tR\acaption\"i\n" +
+	"\bCallback\x12\x17\n" +
+	"\achat_id\x18\x01 \x01(\x03R\x06chatId\x12\x1a\n" +
+	"\bplatform\x18\x02 \x01(\tR\bplatform\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\tR\x04data\x12\x14\n" +
+	"\x05label\x18\x04 \x01(\tR\x05label2Q\n" +
 	"\rBridgeService\x12@\n" +
 	"\tHandshake\x12\x18.bridge.HandshakeRequest\x1a\x19.bridge.HandshakeResponse2\x84\x01\n" +
 	"\vChatService\x12?\n" +
@@ -834,7 +1153,7 @@ func file_internal_bridge_proto_bridge_proto_rawDescGZIP() []byte {
 	return file_internal_bridge_proto_bridge_proto_rawDescData
 }
 
-var file_internal_bridge_proto_bridge_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_internal_bridge_proto_bridge_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
 var file_internal_bridge_proto_bridge_proto_goTypes = []any{
 	(*Empty)(nil),                 // 0: bridge.Empty
 	(*HandshakeRequest)(nil),      // 1: bridge.HandshakeRequest
@@ -848,6 +1167,10 @@ var file_internal_bridge_proto_bridge_proto_goTypes = []any{
 	(*ToolCall)(nil),              // 9: bridge.ToolCall
 	(*ToolResult)(nil),            // 10: bridge.ToolResult
 	(*Heartbeat)(nil),             // 11: bridge.Heartbeat
+	(*Button)(nil),                // 12: bridge.Button
+	(*ButtonRow)(nil),             // 13: bridge.ButtonRow
+	(*Attachment)(nil),            // 14: bridge.Attachment
+	(*Callback)(nil),              // 15: bridge.Callback
 }
 var file_internal_bridge_proto_bridge_proto_depIdxs = []int32{
 	7,  // 0: bridge.BridgeEvent.incoming_message:type_name -> bridge.IncomingMessage
@@ -855,19 +1178,24 @@ var file_internal_bridge_proto_bridge_proto_depIdxs = []int32{
 	9,  // 2: bridge.BridgeEvent.tool_call:type_name -> bridge.ToolCall
 	10, // 3: bridge.BridgeEvent.tool_result:type_name -> bridge.ToolResult
 	11, // 4: bridge.BridgeEvent.heartbeat:type_name -> bridge.Heartbeat
-	1,  // 5: bridge.BridgeService.Handshake:input_type -> bridge.HandshakeRequest
-	8,  // 6: bridge.ChatService.SendMessage:input_type -> bridge.OutgoingMessage
-	0,  // 7: bridge.ChatService.StreamEvents:input_type -> bridge.Empty
-	4,  // 8: bridge.STTService.Transcribe:input_type -> bridge.AudioChunk
-	2,  // 9: bridge.BridgeService.Handshake:output_type -> bridge.HandshakeResponse
-	3,  // 10: bridge.ChatService.SendMessage:output_type -> bridge.MessageResponse
-	6,  // 11: bridge.ChatService.StreamEvents:output_type -> bridge.BridgeEvent
-	5,  // 12: bridge.STTService.Transcribe:output_type -> bridge.TranscriptionResponse
-	9,  // [9:13] is the sub-list for method output_type
-	5,  // [5:9] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	15, // 5: bridge.BridgeEvent.callback:type_name -> bridge.Callback
+	14, // 6: bridge.IncomingMessage.attachments:type_name -> bridge.Attachment
+	13, // 7: bridge.OutgoingMessage.buttons:type_name -> bridge.ButtonRow
+	14, // 8: bridge.OutgoingMessage.attachments:type_name -> bridge.Attachment
+	12, // 9: bridge.ButtonRow.buttons:type_name -> bridge.Button
+	1,  // 10: bridge.BridgeService.Handshake:input_type -> bridge.HandshakeRequest
+	8,  // 11: bridge.ChatService.SendMessage:input_type -> bridge.OutgoingMessage
+	0,  // 12: bridge.ChatService.StreamEvents:input_type -> bridge.Empty
+	4,  // 13: bridge.STTService.Transcribe:input_type -> bridge.AudioChunk
+	2,  // 14: bridge.BridgeService.Handshake:output_type -> bridge.HandshakeResponse
+	3,  // 15: bridge.ChatService.SendMessage:output_type -> bridge.MessageResponse
+	6,  // 16: bridge.ChatService.StreamEvents:output_type -> bridge.BridgeEvent
+	5,  // 17: bridge.STTService.Transcribe:output_type -> bridge.TranscriptionResponse
+	14, // [14:18] is the sub-list for method output_type
+	10, // [10:14] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_internal_bridge_proto_bridge_proto_init() }
@@ -881,6 +1209,7 @@ func file_internal_bridge_proto_bridge_proto_init() {
 		(*BridgeEvent_ToolCall)(nil),
 		(*BridgeEvent_ToolResult)(nil),
 		(*BridgeEvent_Heartbeat)(nil),
+		(*BridgeEvent_Callback)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -888,7 +1217,7 @@ func file_internal_bridge_proto_bridge_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_bridge_proto_bridge_proto_rawDesc), len(file_internal_bridge_proto_bridge_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   12,
+			NumMessages:   16,
 			NumExtensions: 0,
 			NumServices:   3,
 		},
@@ -0,0 +1,272 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/bridge/proto"
+)
+
+const (
+	// hostCallback is the data of the host picker buttons, followed by the
+	// host name
+	hostCallback = "host:"
+	// heartbeatInterval is how often connected agents get a heartbeat
+	heartbeatInterval = 15 * time.Second
+)
+
+// HostInfo is a machine agents connect from, as listed to users
+type HostInfo struct {
+	Name     string    `json:"name"`
+	Online   bool      `json:"online"`
+	Sessions []string  `json:"sessions"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// relay routes user messages between several hosts of the same user. Each
+// chat talks to one host, picked with /hosts; events for a session go to
+// the host running it.
+type relay struct {
+	mu       sync.Mutex
+	hosts    map[string]*relayHost // By name, kept when they go offline
+	agents   map[string]string     // Agent ID to host name
+	chatHost map[int64]string      // Host picked per chat
+	held     map[int64]*proto.BridgeEvent
+}
+
+// relayHost is a host and its connected agents
+type relayHost struct {
+	sessions map[string]string // Agent ID to session ID
+	lastSeen time.Time
+}
+
+func newRelay() *relay {
+	return &relay{
+		hosts:    make(map[string]*relayHost),
+		agents:   make(map[string]string),
+		chatHost: make(map[int64]string),
+		held:     make(map[int64]*proto.BridgeEvent),
+	}
+}
+
+// register adds an agent connection of a host
+func (r *relay) register(agentID, host, sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.hosts[host]
+	if !ok {
+		h = &relayHost{sessions: make(map[string]string)}
+		r.hosts[host] = h
+	}
+	h.sessions[agentID] = sessionID
+	h.lastSeen = time.Now()
+	r.agents[agentID] = host
+}
+
+// unregister removes an agent connection; the host stays listed
+func (r *relay) unregister(agentID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	host, ok := r.agents[agentID]
+	if !ok {
+		return
+	}
+	delete(r.agents, agentID)
+	h := r.hosts[host]
+	delete(h.sessions, agentID)
+	h.lastSeen = time.Now()
+}
+
+// seen notes that an agent is alive
+func (r *relay) seen(agentID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if host, ok := r.agents[agentID]; ok {
+		r.hosts[host].lastSeen = time.Now()
+	}
+}
+
+// pick routes a chat to a host, returning the event held while the chat
+// had none
+func (r *relay) pick(chatID int64, host string) (*proto.BridgeEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.hosts[host]; !ok {
+		return nil, fmt.Errorf("unknown host %q", host)
+	}
+	r.chatHost[chatID] = host
+	held := r.held[chatID]
+	delete(r.held, chatID)
+	return held, nil
+}
+
+// route returns the agents an event goes to. All agents get it while there
+// is at most one host online; otherwise a chat that hasn't picked one yet
+// gets ok false, and its event is held until it does.
+func (r *relay) route(event *proto.BridgeEvent) (agents []string, all bool, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.SessionId != "" {
+		for _, h := range r.hosts {
+			for agentID, sessionID := range h.sessions {
+				if sessionID == event.SessionId {
+					agents = append(agents, agentID)
+				}
+			}
+		}
+		if len(agents) > 0 {
+			return agents, false, true
+		}
+	}
+
+	chatID, _, hasChat := eventChat(event)
+	if hasChat {
+		if h, picked := r.hosts[r.chatHost[chatID]]; picked && len(h.sessions) > 0 {
+			for agentID := range h.sessions {
+				agents = append(agents, agentID)
+			}
+			return agents, false, true
+		}
+	}
+
+	online := 0
+	for _, h := range r.hosts {
+		if len(h.sessions) > 0 {
+			online++
+		}
+	}
+	if online <= 1 || !hasChat {
+		return nil, true, true
+	}
+	r.held[chatID] = event
+	return nil, false, false
+}
+
+// picked returns the host a chat talks to
+func (r *relay) picked(chatID int64) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.chatHost[chatID]
+}
+
+// list returns the hosts by name
+func (r *relay) list() []HostInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	hosts := make([]HostInfo, 0, len(r.hosts))
+	for name, h := range r.hosts {
+		info := HostInfo{Name: name, Online: len(h.sessions) > 0, Sessions: []string{}, LastSeen: h.lastSeen}
+		for _, sessionID := range h.sessions {
+			if sessionID != "" {
+				info.Sessions = append(info.Sessions, sessionID)
+			}
+		}
+		sort.Strings(info.Sessions)
+		hosts = append(hosts, info)
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Name < hosts[j].Name })
+	return hosts
+}
+
+// eventChat returns the chat and platform a user event comes from
+func eventChat(event *proto.BridgeEvent) (int64, string, bool) {
+	if msg := event.GetIncomingMessage(); msg != nil {
+		return msg.ChatId, msg.Platform, true
+	}
+	if cb := event.GetCallback(); cb != nil {
+		return cb.ChatId, cb.Platform, true
+	}
+	return 0, "", false
+}
+
+// handleRelayCommand answers /hosts and /host <name>, and host picker
+// buttons. It reports whether the event was for the relay.
+func (s *Server) handleRelayCommand(event *proto.BridgeEvent) bool {
+	var chatID int64
+	var platform, host string
+	if cb := event.GetCallback(); cb != nil && strings.HasPrefix(cb.Data, hostCallback) {
+		chatID, platform, host = cb.ChatId, cb.Platform, strings.TrimPrefix(cb.Data, hostCallback)
+	} else if msg := event.GetIncomingMessage(); msg != nil {
+		fields := strings.Fields(msg.Body)
+		if len(fields) == 0 || (fields[0] != "/hosts" && fields[0] != "/host") {
+			return false
+		}
+		chatID, platform = msg.ChatId, msg.Platform
+		if len(fields) > 1 {
+			host = fields[1]
+		}
+	} else {
+		return false
+	}
+
+	if host == "" {
+		s.sendHosts(chatID, platform, "")
+		return true
+	}
+	held, err := s.relay.pick(chatID, host)
+	if err != nil {
+		s.sendHosts(chatID, platform, fmt.Sprintf("⚠️ No host named %s.", host))
+		return true
+	}
+	s.reply(&proto.OutgoingMessage{ChatId: chatID, Platform: platform, Body: fmt.Sprintf("🖥 This chat now talks to **%s**.", host)})
+	if held != nil {
+		s.dispatch(held)
+	}
+	return true
+}
+
+// sendHosts lists the hosts with buttons to pick the one the chat talks to
+func (s *Server) sendHosts(chatID int64, platform, note string) {
+	hosts := s.relay.list()
+	picked := s.relay.picked(chatID)
+
+	var sb strings.Builder
+	if note != "" {
+		sb.WriteString(note + "\n\n")
+	}
+	if len(hosts) == 0 {
+		sb.WriteString("🖥 No host has connected yet.")
+		s.reply(&proto.OutgoingMessage{ChatId: chatID, Platform: platform, Body: sb.String()})
+		return
+	}
+
+	sb.WriteString("🖥 **Hosts**")
+	var buttons []*proto.ButtonRow
+	for _, h := range hosts {
+		line := fmt.Sprintf("\n• %s — ", h.Name)
+		if h.Online {
+			line += fmt.Sprintf("🟢 online, %d session(s)", len(h.Sessions))
+			buttons = append(buttons, &proto.ButtonRow{Buttons: []*proto.Button{{Text: h.Name, Data: hostCallback + h.Name}}})
+		} else {
+			line += "⚪ offline since " + h.LastSeen.Format("Jan 2 15:04")
+		}
+		if h.Name == picked {
+			line += " ← this chat"
+		}
+		sb.WriteString(line)
+	}
+	if len(buttons) > 0 {
+		sb.WriteString("\n\nPick the host this chat talks to:")
+	}
+	s.reply(&proto.OutgoingMessage{ChatId: chatID, Platform: platform, Body: sb.String(), Buttons: buttons})
+}
+
+// reply sends a message from the bridge itself to a user
+func (s *Server) reply(msg *proto.OutgoingMessage) {
+	s.activity.outgoing("", msg)
+	if msg.Platform == "whatsapp" && s.whatsapp != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if _, err := s.whatsapp.Send(ctx, msg); err != nil {
+			log.Printf("Relay reply failed: %v", err)
+		}
+		return
+	}
+	log.Printf("Relay reply to %s chat %d: %s", msg.Platform, msg.ChatId, msg.Body)
+}
@@ -0,0 +1,114 @@
+package bridge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/bridge/proto"
+)
+
+// connectHost registers an agent of host running session, with its event
+// stream
+func connectHost(t *testing.T, s *Server, host, sessionID string) chan *proto.BridgeEvent {
+	agentID := s.activity.connect("127.0.0.1:1")
+	ctx := context.WithValue(t.Context(), agentKey{}, agentID)
+	s.Handshake(ctx, &proto.HandshakeRequest{SessionId: sessionID, Host: host})
+	ch := make(chan *proto.BridgeEvent, 10)
+	s.streams[ch] = agentID
+	return ch
+}
+
+func userMessage(chatID int64, body string) *proto.BridgeEvent {
+	return &proto.BridgeEvent{Payload: &proto.BridgeEvent_IncomingMessage{IncomingMessage: &proto.IncomingMessage{
+		ChatId: chatID, Platform: "telegram", Body: body,
+	}}}
+}
+
+func received(ch chan *proto.BridgeEvent) int {
+	n := 0
+	for {
+		select {
+		case <-ch:
+			n++
+		default:
+			return n
+		}
+	}
+}
+
+func TestRelay_SingleHostGetsEverything(t *testing.T) {
+	s := NewServer(0)
+	laptop1 := connectHost(t, s, "laptop", "s1")
+	laptop2 := connectHost(t, s, "laptop", "s2")
+
+	s.broadcast(userMessage(1, "hi"))
+	if received(laptop1) != 1 || received(laptop2) != 1 {
+		t.Error("with one host, every agent should get the message")
+	}
+}
+
+func TestRelay_PickHost(t *testing.T) {
+	s := NewServer(0)
+	laptop := connectHost(t, s, "laptop", "s1")
+	desktop := connectHost(t, s, "desktop", "s2")
+
+	// Several hosts and none picked: the message waits for a pick
+	s.broadcast(userMessage(1, "run the tests"))
+	if received(laptop)+received(desktop) != 0 {
+		t.Fatal("message delivered before a host was picked")
+	}
+	asks := s.activity.snapshot().Asks
+	if len(asks) != 1 || len(asks[0].Buttons) != 2 || asks[0].Buttons[0][0].Data != "host:desktop" {
+		t.Fatalf("host picker = %+v", asks)
+	}
+
+	s.broadcast(&proto.BridgeEvent{Payload: &proto.BridgeEvent_Callback{Callback: &proto.Callback{
+		ChatId: 1, Platform: "telegram", Data: "host:desktop",
+	}}})
+	event := <-desktop
+	if event.GetIncomingMessage().GetBody() != "run the tests" {
+		t.Errorf("desktop got %+v, want the held message", event)
+	}
+	if received(laptop) != 0 {
+		t.Error("laptop got a message meant for desktop")
+	}
+
+	// Later messages follow the pick; /host switches it
+	s.broadcast(userMessage(1, "again"))
+	if received(desktop) != 1 {
+		t.Error("desktop should get the chat's next message")
+	}
+	s.broadcast(userMessage(1, "/host laptop"))
+	s.broadcast(userMessage(1, "now here"))
+	if received(laptop) != 1 || received(desktop) != 0 {
+		t.Error("/host laptop should route the chat to laptop")
+	}
+}
+
+func TestRelay_SessionEventsGoToTheirHost(t *testing.T) {
+	s := NewServer(0)
+	laptop := connectHost(t, s, "laptop", "s1")
+	desktop := connectHost(t, s, "desktop", "s2")
+
+	event := userMessage(1, "yes")
+	event.SessionId = "s2"
+	s.broadcast(event)
+	if received(desktop) != 1 || received(laptop) != 0 {
+		t.Error("an event for session s2 should only reach desktop")
+	}
+}
+
+func TestRelay_HostsStayListedOffline(t *testing.T) {
+	r := newRelay()
+	r.register("a1", "laptop", "s1")
+	r.register("a2", "desktop", "s2")
+	r.unregister("a1")
+
+	hosts := r.list()
+	if len(hosts) != 2 || hosts[0].Name != "desktop" || !hosts[0].Online || hosts[1].Online {
+		t.Errorf("hosts = %+v", hosts)
+	}
+	if _, err := r.pick(1, "server"); err == nil {
+		t.Error("picking an unknown host should fail")
+	}
+}
@@ -2,15 +2,21 @@ package bridge
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/hashicorp/yamux"
 	"github.com/igoryan-dao/ricochet/internal/bridge/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 // Server is a mockup of the Ricochet Cloud part for testing
@@ -20,21 +26,127 @@ type Server struct {
 	proto.UnimplementedSTTServiceServer
 	upgrader websocket.Upgrader
 	port     int
+
+	// Token agents must present to connect to /ws; empty lets anyone in
+	agentToken string
+
+	whatsapp *WhatsApp
+
+	// Event streams of connected clients, to their agent IDs
+	streamsMu sync.Mutex
+	streams   map[chan *proto.BridgeEvent]string
+
+	// Hosts the agents run on, and which one each chat talks to
+	relay *relay
+
+	// Responses to recently delivered messages by dedupe ID, so a replayed
+	// message is acknowledged without being sent twice
+	deliveredMu    sync.Mutex
+	delivered      map[string]*proto.MessageResponse
+	deliveredOrder []string
+
+	// Dashboard
+	activity       *activity
+	dashboardOn    bool
+	dashboardToken string
+	dashboardLocal bool // Token was generated: serve the local machine only
 }
 
+// maxDelivered bounds how many dedupe IDs the server remembers
+const maxDelivered = 1000
+
 func NewServer(port int) *Server {
 	return &Server{
 		port: port,
 		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool { return true },
+			// Agents aren't browsers: a request with an Origin comes from a web page
+			CheckOrigin: func(r *http.Request) bool { return r.Header.Get("Origin") == "" },
 		},
+		streams:   make(map[chan *proto.BridgeEvent]string),
+		delivered: make(map[string]*proto.MessageResponse),
+		activity:  newActivity(),
+		relay:     newRelay(),
+	}
+}
+
+// RequireAgentToken makes agents present token as a bearer token to connect
+// to /ws. Agents send $RICOCHET_BRIDGE_SECRET.
+func (s *Server) RequireAgentToken(token string) {
+	s.agentToken = token
+}
+
+// EnableWhatsApp routes WhatsApp Cloud API webhooks to connected clients and
+// delivers their messages for the "whatsapp" platform through it. Point the
+// app's webhook at /whatsapp/webhook. Start refuses to serve it without an
+// agent token, as any agent that connects reads the users' messages.
+func (s *Server) EnableWhatsApp(w *WhatsApp) {
+	s.whatsapp = w
+	go func() {
+		for event := range w.Events() {
+			s.broadcast(event)
+		}
+	}()
+}
+
+// broadcast hands a user event to the connected clients it is routed to:
+// all of them, or those of the host the chat talks to when several hosts
+// are online
+func (s *Server) broadcast(event *proto.BridgeEvent) {
+	s.activity.incoming(event)
+	if s.handleRelayCommand(event) {
+		return
+	}
+	s.dispatch(event)
+}
+
+// dispatch sends an event to the clients of its route, asking the user to
+// pick a host first if the chat has none among several
+func (s *Server) dispatch(event *proto.BridgeEvent) {
+	agents, all, ok := s.relay.route(event)
+	if !ok {
+		chatID, platform, _ := eventChat(event)
+		s.sendHosts(chatID, platform, "Several hosts are online: pick one, your message will follow.")
+		return
+	}
+	targets := make(map[string]bool, len(agents))
+	for _, agentID := range agents {
+		targets[agentID] = true
+	}
+
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+	sent := false
+	for ch, agentID := range s.streams {
+		if !all && !targets[agentID] {
+			continue
+		}
+		sent = true
+		select {
+		case ch <- event:
+		default:
+			log.Printf("Client stream full, dropping event")
+		}
+	}
+	if !sent {
+		log.Printf("No client connected, dropping event")
 	}
 }
 
 func (s *Server) Start(ctx context.Context) error {
-	http.HandleFunc("/ws", s.handleWebSocket)
+	if s.whatsapp != nil && s.agentToken == "" {
+		return errors.New("WhatsApp needs an agent token: without one anyone can connect to /ws and read its messages")
+	}
 
-	server := &http.Server{Addr: fmt.Sprintf(":%d", s.port)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", s.handleWebSocket)
+	if s.whatsapp != nil {
+		mux.Handle("/whatsapp/webhook", s.whatsapp)
+	}
+	if s.dashboardOn {
+		s.registerDashboard(mux)
+	}
+
+	server := &http.Server{Addr: fmt.Sprintf(":%d", s.port), Handler: mux}
 
 	log.Printf("Bridge Test Server starting on :%d...", s.port)
 
@@ -47,6 +159,12 @@ func (s *Server) Start(ctx context.Context) error {
 }
 
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if !s.agentAuthorized(r) {
+		log.Printf("Refused agent from %s: bad or missing token", r.RemoteAddr)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("Upgrade error: %v", err)
@@ -62,41 +180,142 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	agentID := s.activity.connect(r.RemoteAddr)
+	defer s.activity.disconnect(agentID)
+	defer s.relay.unregister(agentID)
+	agent := &agentConn{Server: s, agentID: agentID}
+
 	// Start gRPC server over yamux session
-	grpcServer := grpc.NewServer()
-	proto.RegisterBridgeServiceServer(grpcServer, s)
-	proto.RegisterChatServiceServer(grpcServer, s)
-	proto.RegisterSTTServiceServer(grpcServer, s)
+	grpcServer := grpc.NewServer(grpc.MaxRecvMsgSize(maxMessageSize), grpc.MaxSendMsgSize(maxMessageSize))
+	proto.RegisterBridgeServiceServer(grpcServer, agent)
+	proto.RegisterChatServiceServer(grpcServer, agent)
+	proto.RegisterSTTServiceServer(grpcServer, agent)
 
 	if err := grpcServer.Serve(session); err != nil {
 		log.Printf("gRPC server error: %v", err)
 	}
 }
 
+// agentAuthorized reports whether r carries the agent token, if one is required
+func (s *Server) agentAuthorized(r *http.Request) bool {
+	if s.agentToken == "" {
+		return true
+	}
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.agentToken)) == 1
+}
+
 // Handshake implementation
 func (s *Server) Handshake(ctx context.Context, req *proto.HandshakeRequest) (*proto.HandshakeResponse, error) {
 	log.Printf("Handshake request: session=%s, version=%s", req.SessionId, req.Version)
+	if agentID := agentFromContext(ctx); agentID != "" {
+		host := req.Host
+		if host == "" {
+			host = "default"
+		}
+		s.activity.handshake(agentID, host, req)
+		s.relay.register(agentID, host, req.SessionId)
+	}
 	return &proto.HandshakeResponse{
 		Success: true,
 		Message: "Welcome to Ricochet Cloud Bridge",
 	}, nil
 }
 
-// SendMessage implementation
+// SendMessage implementation. Messages carrying a dedupe ID already seen
+// get the first delivery's response again.
 func (s *Server) SendMessage(ctx context.Context, msg *proto.OutgoingMessage) (*proto.MessageResponse, error) {
+	var dedupeID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(MessageIDKey); len(ids) > 0 {
+			dedupeID = ids[0]
+		}
+	}
+	if resp := s.deliveredResponse(dedupeID); resp != nil {
+		log.Printf("Server skipped duplicate message %s", dedupeID)
+		return resp, nil
+	}
+
 	log.Printf("Server received message for chat %d: %s", msg.ChatId, msg.Body)
-	return &proto.MessageResponse{
+	resp := &proto.MessageResponse{
 		MessageId: "cloud-msg-123",
 		Success:   true,
-	}, nil
+	}
+	if msg.Platform == "whatsapp" && s.whatsapp != nil {
+		id, err := s.whatsapp.Send(ctx, msg)
+		if err != nil {
+			return nil, err
+		}
+		resp = &proto.MessageResponse{MessageId: id, Success: true}
+	}
+	s.recordDelivered(dedupeID, resp)
+	s.activity.outgoing(agentFromContext(ctx), msg)
+	return resp, nil
+}
+
+// deliveredResponse returns the response to an already delivered message
+func (s *Server) deliveredResponse(dedupeID string) *proto.MessageResponse {
+	if dedupeID == "" {
+		return nil
+	}
+	s.deliveredMu.Lock()
+	defer s.deliveredMu.Unlock()
+	return s.delivered[dedupeID]
+}
+
+// recordDelivered remembers a delivered message, forgetting the oldest past
+// maxDelivered
+func (s *Server) recordDelivered(dedupeID string, resp *proto.MessageResponse) {
+	if dedupeID == "" {
+		return
+	}
+	s.deliveredMu.Lock()
+	defer s.deliveredMu.Unlock()
+	s.delivered[dedupeID] = resp
+	s.deliveredOrder = append(s.deliveredOrder, dedupeID)
+	if len(s.deliveredOrder) > maxDelivered {
+		delete(s.delivered, s.deliveredOrder[0])
+		s.deliveredOrder = s.deliveredOrder[1:]
+	}
 }
 
 // StreamEvents implementation
 func (s *Server) StreamEvents(empty *proto.Empty, stream proto.ChatService_StreamEventsServer) error {
+	return s.streamEvents("", stream)
+}
+
+// streamEvents sends an agent its events, and a heartbeat every
+// heartbeatInterval so both ends notice a dead connection
+func (s *Server) streamEvents(agentID string, stream proto.ChatService_StreamEventsServer) error {
 	log.Println("New events stream established")
-	// For now just keep it open
-	<-stream.Context().Done()
-	return nil
+	ch := make(chan *proto.BridgeEvent, 100)
+	s.streamsMu.Lock()
+	s.streams[ch] = agentID
+	s.streamsMu.Unlock()
+	defer func() {
+		s.streamsMu.Lock()
+		delete(s.streams, ch)
+		s.streamsMu.Unlock()
+	}()
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event := <-ch:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		case now := <-ticker.C:
+			heartbeat := &proto.BridgeEvent{Payload: &proto.BridgeEvent_Heartbeat{Heartbeat: &proto.Heartbeat{Timestamp: now.Unix()}}}
+			if err := stream.Send(heartbeat); err != nil {
+				return err
+			}
+			s.relay.seen(agentID)
+		}
+	}
 }
 
 // Transcribe implementation
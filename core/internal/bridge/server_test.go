@@ -0,0 +1,54 @@
+package bridge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestServer_RejectsUnauthenticatedAgents(t *testing.T) {
+	s := NewServer(0)
+	s.RequireAgentToken("secret")
+	ts := httptest.NewServer(http.HandlerFunc(s.handleWebSocket))
+	t.Cleanup(ts.Close)
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http")
+
+	for _, tc := range []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{"no token", nil, http.StatusUnauthorized},
+		{"wrong token", http.Header{"Authorization": {"Bearer guess"}}, http.StatusUnauthorized},
+		{"from a web page", http.Header{"Authorization": {"Bearer secret"}, "Origin": {"http://attacker.example"}}, http.StatusForbidden},
+	} {
+		conn, resp, err := websocket.DefaultDialer.Dial(wsURL, tc.header)
+		if err == nil {
+			conn.Close()
+			t.Errorf("%s: connected", tc.name)
+			continue
+		}
+		if resp == nil || resp.StatusCode != tc.want {
+			t.Errorf("%s: response %v, want status %d", tc.name, resp, tc.want)
+		}
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Authorization": {"Bearer secret"}})
+	if err != nil {
+		t.Fatalf("with the token: %v", err)
+	}
+	conn.Close()
+}
+
+func TestServer_WhatsAppNeedsAgentToken(t *testing.T) {
+	w, _ := newTestWhatsApp(t)
+	s := NewServer(0)
+	s.EnableWhatsApp(w)
+
+	if err := s.Start(t.Context()); err == nil || !strings.Contains(err.Error(), "agent token") {
+		t.Errorf("Start without an agent token = %v, want a refusal", err)
+	}
+}
@@ -0,0 +1,609 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/bridge/proto"
+	"github.com/igoryan-dao/ricochet/internal/discord"
+	"github.com/igoryan-dao/ricochet/internal/format"
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
+)
+
+// WhatsApp Cloud API limits
+const (
+	whatsAppMaxText        = 4096
+	whatsAppMaxBody        = 1024 // Body of an interactive message
+	whatsAppMaxButtons     = 3    // Reply buttons per message
+	whatsAppMaxButtonTitle = 20
+	whatsAppMaxListRows    = 10
+	whatsAppMaxRowTitle    = 24
+)
+
+const defaultGraphURL = "https://graph.facebook.com/v21.0/"
+
+// WhatsAppConfig holds the credentials of a WhatsApp Business phone number
+type WhatsAppConfig struct {
+	PhoneNumberID string // Sending number, from the app's WhatsApp settings
+	AccessToken   string // System user or temporary access token
+	AppSecret     string // Verifies webhook signatures
+	VerifyToken   string // Echoed back when Meta verifies the webhook
+	// AllowedNumbers are the senders whose messages are accepted, in
+	// international format; anyone else who writes to the business number
+	// is ignored
+	AllowedNumbers []string
+}
+
+// WhatsAppConfigFromEnv reads the WHATSAPP_* environment variables
+func WhatsAppConfigFromEnv() WhatsAppConfig {
+	return WhatsAppConfig{
+		PhoneNumberID: os.Getenv("WHATSAPP_PHONE_NUMBER_ID"),
+		AccessToken:   os.Getenv("WHATSAPP_ACCESS_TOKEN"),
+		AppSecret:     os.Getenv("WHATSAPP_APP_SECRET"),
+		VerifyToken:   os.Getenv("WHATSAPP_VERIFY_TOKEN"),
+		// Comma-separated, e.g. "+1 555 123 4567,447700900123"
+		AllowedNumbers: strings.FieldsFunc(os.Getenv("WHATSAPP_ALLOWED_NUMBERS"), func(r rune) bool { return r == ',' }),
+	}
+}
+
+// normalizeNumber reduces a phone number to its digits, the form webhooks
+// report senders in
+func normalizeNumber(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// WhatsApp adapts Meta's WhatsApp Cloud API to bridge events: webhook
+// deliveries become IncomingMessages and OutgoingMessages are sent through
+// the Graph API, with buttons mapped to interactive replies.
+type WhatsApp struct {
+	cfg     WhatsAppConfig
+	apiURL  string
+	http    *http.Client
+	events  chan *proto.BridgeEvent
+	allowed map[string]bool // Normalized AllowedNumbers
+
+	// Buttons last offered per chat as a numbered list, so a reply of "2"
+	// can be reported as the second button
+	mu      sync.Mutex
+	offered map[int64][]*proto.Button
+}
+
+// NewWhatsApp creates a WhatsApp adapter
+func NewWhatsApp(cfg WhatsAppConfig) (*WhatsApp, error) {
+	if cfg.PhoneNumberID == "" || cfg.AccessToken == "" {
+		return nil, fmt.Errorf("whatsapp: phone number ID and access token are required")
+	}
+	if cfg.AppSecret == "" {
+		return nil, fmt.Errorf("whatsapp: app secret is required to verify webhooks")
+	}
+	// Anyone can message a business number, so it only listens to its owners
+	allowed := make(map[string]bool)
+	for _, n := range cfg.AllowedNumbers {
+		if n = normalizeNumber(n); n != "" {
+			allowed[n] = true
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, fmt.Errorf("whatsapp: allowed numbers are required, anyone could send commands otherwise")
+	}
+	return &WhatsApp{
+		cfg:     cfg,
+		apiURL:  defaultGraphURL,
+		http:    httpclient.Client(30 * time.Second),
+		events:  make(chan *proto.BridgeEvent, 100),
+		allowed: allowed,
+		offered: make(map[int64][]*proto.Button),
+	}, nil
+}
+
+// Events returns the messages received through the webhook
+func (w *WhatsApp) Events() <-chan *proto.BridgeEvent {
+	return w.events
+}
+
+// webhookPayload is the part of a Cloud API webhook delivery we use
+type webhookPayload struct {
+	Entry []struct {
+		Changes []struct {
+			Value struct {
+				Messages []whatsAppMessage `json:"messages"`
+			} `json:"value"`
+		} `json:"changes"`
+	} `json:"entry"`
+}
+
+type whatsAppMessage struct {
+	From string `json:"from"`
+	Type string `json:"type"`
+	Text struct {
+		Body string `json:"body"`
+	} `json:"text"`
+	Interactive struct {
+		Type        string `json:"type"`
+		ButtonReply struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		} `json:"button_reply"`
+		ListReply struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		} `json:"list_reply"`
+	} `json:"interactive"`
+	Button struct {
+		Payload string `json:"payload"`
+		Text    string `json:"text"`
+	} `json:"button"`
+	Image    whatsAppMedia `json:"image"`
+	Document whatsAppMedia `json:"document"`
+	Audio    whatsAppMedia `json:"audio"`
+	Video    whatsAppMedia `json:"video"`
+}
+
+// whatsAppMedia is a received file, fetched by ID from the Graph API
+type whatsAppMedia struct {
+	ID       string `json:"id"`
+	MimeType string `json:"mime_type"`
+	Caption  string `json:"caption"`
+	Filename string `json:"filename"`
+}
+
+// media returns the file of a media message, or nil
+func (m *whatsAppMessage) media() *whatsAppMedia {
+	switch m.Type {
+	case "image":
+		return &m.Image
+	case "document":
+		return &m.Document
+	case "audio":
+		return &m.Audio
+	case "video":
+		return &m.Video
+	}
+	return nil
+}
+
+// ServeHTTP answers Meta's verification request and receives message webhooks
+func (w *WhatsApp) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		if q.Get("hub.mode") != "subscribe" || w.cfg.VerifyToken == "" || q.Get("hub.verify_token") != w.cfg.VerifyToken {
+			http.Error(rw, "verification failed", http.StatusForbidden)
+			return
+		}
+		io.WriteString(rw, q.Get("hub.challenge"))
+
+	case http.MethodPost:
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(rw, "read error", http.StatusBadRequest)
+			return
+		}
+		if !w.validSignature(body, r.Header.Get("X-Hub-Signature-256")) {
+			http.Error(rw, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		var payload webhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			http.Error(rw, "invalid payload", http.StatusBadRequest)
+			return
+		}
+		for _, entry := range payload.Entry {
+			for _, change := range entry.Changes {
+				for _, msg := range change.Value.Messages {
+					if !w.allowed[normalizeNumber(msg.From)] {
+						log.Printf("WhatsApp: ignoring message from %s, not an allowed number", msg.From)
+						continue
+					}
+					// Media is downloaded in the background: Meta redelivers
+					// webhooks that aren't answered quickly
+					if msg.media() != nil {
+						go w.receiveMedia(msg)
+						continue
+					}
+					if event := w.toEvent(msg); event != nil {
+						w.events <- event
+					}
+				}
+			}
+		}
+		rw.WriteHeader(http.StatusOK) // Anything else makes Meta redeliver
+
+	default:
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// validSignature checks the HMAC-SHA256 of the body made with the app secret
+func (w *WhatsApp) validSignature(body []byte, header string) bool {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(w.cfg.AppSecret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// toEvent converts a received text message or button press; unsupported
+// types (location, contacts) return nil
+func (w *WhatsApp) toEvent(msg whatsAppMessage) *proto.BridgeEvent {
+	chatID, err := strconv.ParseInt(msg.From, 10, 64)
+	if err != nil {
+		log.Printf("WhatsApp: unexpected sender %q", msg.From)
+		return nil
+	}
+	cb := &proto.Callback{ChatId: chatID, Platform: "whatsapp"}
+
+	switch msg.Type {
+	case "text":
+		body := strings.TrimSpace(msg.Text.Body)
+		btn := w.numberedChoice(chatID, body)
+		if btn == nil {
+			return &proto.BridgeEvent{Payload: &proto.BridgeEvent_IncomingMessage{IncomingMessage: &proto.IncomingMessage{
+				ChatId:   chatID,
+				Platform: "whatsapp",
+				Body:     body,
+			}}}
+		}
+		cb.Label, cb.Data = btn.Text, btn.Data
+	case "interactive":
+		reply := msg.Interactive.ButtonReply
+		if msg.Interactive.Type == "list_reply" {
+			reply = msg.Interactive.ListReply
+		}
+		cb.Label, cb.Data = reply.Title, reply.ID
+	case "button":
+		cb.Label, cb.Data = msg.Button.Text, msg.Button.Payload
+	default:
+		log.Printf("WhatsApp: ignoring %s message from %s", msg.Type, msg.From)
+		return nil
+	}
+
+	w.mu.Lock()
+	delete(w.offered, chatID)
+	w.mu.Unlock()
+	return &proto.BridgeEvent{Payload: &proto.BridgeEvent_Callback{Callback: cb}}
+}
+
+// numberedChoice maps a reply like "2" to the button offered under that number
+func (w *WhatsApp) numberedChoice(chatID int64, text string) *proto.Button {
+	n, err := strconv.Atoi(text)
+	if err != nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	offered := w.offered[chatID]
+	if n < 1 || n > len(offered) {
+		return nil
+	}
+	return offered[n-1]
+}
+
+// receiveMedia downloads a received file and emits it as an attachment,
+// its caption as the body
+func (w *WhatsApp) receiveMedia(msg whatsAppMessage) {
+	chatID, err := strconv.ParseInt(msg.From, 10, 64)
+	if err != nil {
+		log.Printf("WhatsApp: unexpected sender %q", msg.From)
+		return
+	}
+	media := msg.media()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	data, err := w.downloadMedia(ctx, media.ID)
+	if err != nil {
+		log.Printf("WhatsApp: failed to download %s from %s: %v", msg.Type, msg.From, err)
+		return
+	}
+
+	name := media.Filename
+	if name == "" {
+		name = msg.Type + "_" + media.ID
+		if exts, _ := mime.ExtensionsByType(media.MimeType); len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+	w.events <- &proto.BridgeEvent{Payload: &proto.BridgeEvent_IncomingMessage{IncomingMessage: &proto.IncomingMessage{
+		ChatId:   chatID,
+		Platform: "whatsapp",
+		Body:     strings.TrimSpace(media.Caption),
+		Attachments: []*proto.Attachment{{
+			Name:     name,
+			MimeType: media.MimeType,
+			Data:     data,
+		}},
+	}}}
+}
+
+// downloadMedia fetches a received file: the Graph API gives its URL, which
+// needs the access token too
+func (w *WhatsApp) downloadMedia(ctx context.Context, mediaID string) ([]byte, error) {
+	var info struct {
+		URL string `json:"url"`
+	}
+	resp, err := w.get(ctx, w.apiURL+mediaID)
+	if err != nil {
+		return nil, err
+	}
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	resp.Body.Close()
+	if err != nil || info.URL == "" {
+		return nil, fmt.Errorf("no URL for media %s", mediaID)
+	}
+
+	resp, err = w.get(ctx, info.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAttachmentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxAttachmentSize {
+		return nil, fmt.Errorf("media is larger than %d MB", maxAttachmentSize>>20)
+	}
+	return data, nil
+}
+
+// get makes an authenticated Graph API request
+func (w *WhatsApp) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+w.cfg.AccessToken)
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// Send delivers an outgoing message and returns its WhatsApp message ID.
+// Up to three buttons become reply buttons and up to ten a list; more are
+// offered as a numbered list to answer by number. Attachments follow the
+// text as media messages.
+func (w *WhatsApp) Send(ctx context.Context, msg *proto.OutgoingMessage) (string, error) {
+	var id string
+	if msg.Body != "" || len(msg.Buttons) > 0 || len(msg.Attachments) == 0 {
+		var err error
+		if id, err = w.sendText(ctx, msg); err != nil {
+			return "", err
+		}
+	}
+	for _, att := range msg.Attachments {
+		var err error
+		if id, err = w.sendMedia(ctx, msg.ChatId, att); err != nil {
+			return "", err
+		}
+	}
+	return id, nil
+}
+
+// sendMedia sends an attachment by link, or uploads it first
+func (w *WhatsApp) sendMedia(ctx context.Context, chatID int64, att *proto.Attachment) (string, error) {
+	kind := attachmentKind(att)
+	media := map[string]any{}
+	if att.Url != "" {
+		media["link"] = att.Url
+	} else {
+		id, err := w.uploadMedia(ctx, att)
+		if err != nil {
+			return "", err
+		}
+		media["id"] = id
+	}
+	if att.Caption != "" && kind != "audio" {
+		media["caption"] = truncate(format.ToWhatsApp(att.Caption), whatsAppMaxBody)
+	}
+	if kind == "document" {
+		media["filename"] = att.Name
+	}
+
+	return w.post(ctx, map[string]any{
+		"messaging_product": "whatsapp",
+		"to":                strconv.FormatInt(chatID, 10),
+		"type":              kind,
+		kind:                media,
+	})
+}
+
+// uploadMedia uploads an attachment to the Graph API and returns its media ID
+func (w *WhatsApp) uploadMedia(ctx context.Context, att *proto.Attachment) (string, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("messaging_product", "whatsapp")
+	mw.WriteField("type", att.MimeType)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, att.Name))
+	header.Set("Content-Type", att.MimeType)
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return "", err
+	}
+	part.Write(att.Data)
+	mw.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.apiURL+w.cfg.PhoneNumberID+"/media", &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+w.cfg.AccessToken)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("whatsapp upload: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		ID    string `json:"id"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode != http.StatusOK || result.ID == "" {
+		return "", fmt.Errorf("whatsapp upload: HTTP %d: %s", resp.StatusCode, result.Error.Message)
+	}
+	return result.ID, nil
+}
+
+// sendText sends the body of a message with its buttons
+func (w *WhatsApp) sendText(ctx context.Context, msg *proto.OutgoingMessage) (string, error) {
+	to := strconv.FormatInt(msg.ChatId, 10)
+	text := format.ToWhatsApp(msg.Body)
+
+	var buttons []*proto.Button
+	for _, row := range msg.Buttons {
+		buttons = append(buttons, row.Buttons...)
+	}
+
+	if len(buttons) > whatsAppMaxListRows {
+		var sb strings.Builder
+		sb.WriteString(text + "\n")
+		for i, btn := range buttons {
+			sb.WriteString(fmt.Sprintf("\n%d. %s", i+1, btn.Text))
+		}
+		sb.WriteString("\n\nReply with a number.")
+		w.mu.Lock()
+		w.offered[msg.ChatId] = buttons
+		w.mu.Unlock()
+		text, buttons = sb.String(), nil
+	}
+
+	if len(buttons) == 0 {
+		var id string
+		for _, chunk := range discord.SplitMessage(text, whatsAppMaxText) {
+			var err error
+			if id, err = w.post(ctx, map[string]any{
+				"messaging_product": "whatsapp",
+				"to":                to,
+				"type":              "text",
+				"text":              map[string]any{"body": chunk},
+			}); err != nil {
+				return "", err
+			}
+		}
+		return id, nil
+	}
+
+	// Interactive bodies are short: send long text on its own first
+	body := text
+	if len(body) > whatsAppMaxBody {
+		if _, err := w.sendText(ctx, &proto.OutgoingMessage{ChatId: msg.ChatId, Body: msg.Body}); err != nil {
+			return "", err
+		}
+		body = "👇"
+	}
+
+	var action map[string]any
+	if len(buttons) <= whatsAppMaxButtons {
+		var replies []map[string]any
+		for _, btn := range buttons {
+			replies = append(replies, map[string]any{
+				"type":  "reply",
+				"reply": map[string]string{"id": btn.Data, "title": truncate(btn.Text, whatsAppMaxButtonTitle)},
+			})
+		}
+		action = map[string]any{"type": "button", "action": map[string]any{"buttons": replies}}
+	} else {
+		var rows []map[string]string
+		for _, btn := range buttons {
+			rows = append(rows, map[string]string{"id": btn.Data, "title": truncate(btn.Text, whatsAppMaxRowTitle)})
+		}
+		action = map[string]any{"type": "list", "action": map[string]any{
+			"button":   "Choose",
+			"sections": []map[string]any{{"rows": rows}},
+		}}
+	}
+	action["body"] = map[string]string{"text": body}
+
+	return w.post(ctx, map[string]any{
+		"messaging_product": "whatsapp",
+		"to":                to,
+		"type":              "interactive",
+		"interactive":       action,
+	})
+}
+
+// post sends one message through the Graph API
+func (w *WhatsApp) post(ctx context.Context, payload map[string]any) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.apiURL+w.cfg.PhoneNumberID+"/messages", bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+w.cfg.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("whatsapp send: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Messages []struct {
+			ID string `json:"id"`
+		} `json:"messages"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("whatsapp send: HTTP %d: %s", resp.StatusCode, result.Error.Message)
+	}
+	if len(result.Messages) == 0 {
+		return "", nil
+	}
+	return result.Messages[0].ID, nil
+}
+
+// truncate shortens s to max runes, marking the cut
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
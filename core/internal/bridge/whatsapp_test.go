@@ -0,0 +1,171 @@
+package bridge
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/bridge/proto"
+)
+
+func newTestWhatsApp(t *testing.T) (*WhatsApp, *[]map[string]any) {
+	var sent []map[string]any
+	var graph *httptest.Server
+	graph = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, `{"error":{"message":"bad token"}}`, http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/PHONE/messages":
+		case "/PHONE/media":
+			if file, header, err := r.FormFile("file"); err != nil || header.Filename != "shot.png" || r.FormValue("type") != "image/png" {
+				http.Error(w, `{"error":{"message":"bad upload"}}`, http.StatusBadRequest)
+			} else {
+				file.Close()
+				io.WriteString(w, `{"id":"media.1"}`)
+			}
+			return
+		case "/MEDIA1":
+			fmt.Fprintf(w, `{"url":"%s/files/MEDIA1","mime_type":"image/jpeg"}`, graph.URL)
+			return
+		case "/files/MEDIA1":
+			io.WriteString(w, "jpeg bytes")
+			return
+		default:
+			http.Error(w, `{"error":{"message":"bad request"}}`, http.StatusBadRequest)
+			return
+		}
+		var payload map[string]any
+		json.NewDecoder(r.Body).Decode(&payload)
+		sent = append(sent, payload)
+		fmt.Fprintf(w, `{"messages":[{"id":"wamid.%d"}]}`, len(sent))
+	}))
+	t.Cleanup(graph.Close)
+
+	w, err := NewWhatsApp(WhatsAppConfig{PhoneNumberID: "PHONE", AccessToken: "token", AppSecret: "secret", VerifyToken: "verify", AllowedNumbers: []string{"+1 555 123 4567"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.apiURL = graph.URL + "/"
+	return w, &sent
+}
+
+func deliver(t *testing.T, w *WhatsApp, message string, sign bool) int {
+	body := `{"entry":[{"changes":[{"value":{"messages":[` + message + `]}}]}]}`
+	req := httptest.NewRequest(http.MethodPost, "/whatsapp/webhook", strings.NewReader(body))
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(body))
+	if sign {
+		req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestWhatsAppWebhook(t *testing.T) {
+	w, _ := newTestWhatsApp(t)
+
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?hub.mode=subscribe&hub.verify_token=verify&hub.challenge=42", nil))
+	if rec.Body.String() != "42" {
+		t.Errorf("verification answered %d %q", rec.Code, rec.Body)
+	}
+
+	if code := deliver(t, w, `{"from":"15551234567","type":"text","text":{"body":"hi"}}`, false); code != http.StatusUnauthorized {
+		t.Errorf("unsigned webhook got %d", code)
+	}
+
+	deliver(t, w, `{"from":"15551234567","type":"interactive","interactive":{"type":"button_reply","button_reply":{"id":"confirm_yes:s1","title":"Confirm"}}}`, true)
+	cb := (<-w.Events()).GetCallback()
+	if cb.ChatId != 15551234567 || cb.Data != "confirm_yes:s1" || cb.Label != "Confirm" || cb.Platform != "whatsapp" {
+		t.Errorf("button reply became %+v", cb)
+	}
+
+	deliver(t, w, `{"from":"15550000000","type":"text","text":{"body":"stranger"}}`, true)
+	deliver(t, w, `{"from":"15551234567","type":"text","text":{"body":"hi"}}`, true)
+	if msg := (<-w.Events()).GetIncomingMessage(); msg.Body != "hi" {
+		t.Errorf("text became %+v, messages from other numbers must be dropped", msg)
+	}
+
+	if _, err := NewWhatsApp(WhatsAppConfig{PhoneNumberID: "PHONE", AccessToken: "token", AppSecret: "secret"}); err == nil {
+		t.Error("adapter created without allowed numbers")
+	}
+}
+
+func TestWhatsAppReceiveMedia(t *testing.T) {
+	w, _ := newTestWhatsApp(t)
+
+	deliver(t, w, `{"from":"15551234567","type":"image","image":{"id":"MEDIA1","mime_type":"image/jpeg","caption":"the bug"}}`, true)
+	msg := (<-w.Events()).GetIncomingMessage()
+	if msg.Body != "the bug" || len(msg.Attachments) != 1 {
+		t.Fatalf("image became %+v", msg)
+	}
+	att := msg.Attachments[0]
+	if string(att.Data) != "jpeg bytes" || att.MimeType != "image/jpeg" || !strings.HasPrefix(att.Name, "image_MEDIA1") {
+		t.Errorf("attachment = %s %s %q", att.Name, att.MimeType, att.Data)
+	}
+}
+
+func TestWhatsAppSendAttachment(t *testing.T) {
+	w, sent := newTestWhatsApp(t)
+
+	_, err := w.Send(context.Background(), &proto.OutgoingMessage{ChatId: 15551234567, Attachments: []*proto.Attachment{
+		{Name: "shot.png", MimeType: "image/png", Data: []byte("png"), Caption: "Done"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*sent) != 1 {
+		t.Fatalf("sent %d messages, want only the image", len(*sent))
+	}
+	image := (*sent)[0]["image"].(map[string]any)
+	if (*sent)[0]["type"] != "image" || image["id"] != "media.1" || image["caption"] != "Done" {
+		t.Errorf("sent %v", (*sent)[0])
+	}
+}
+
+func TestWhatsAppSendButtons(t *testing.T) {
+	w, sent := newTestWhatsApp(t)
+	ctx := context.Background()
+
+	_, err := w.Send(ctx, &proto.OutgoingMessage{ChatId: 15551234567, Body: "**Run** it?", Buttons: []*proto.ButtonRow{
+		{Buttons: []*proto.Button{{Text: "✅ Confirm", Data: "confirm_yes:s1"}, {Text: "❌ Cancel", Data: "confirm_no:s1"}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interactive := (*sent)[0]["interactive"].(map[string]any)
+	if interactive["type"] != "button" || interactive["body"].(map[string]any)["text"] != "*Run* it?" {
+		t.Errorf("sent %v", interactive)
+	}
+	replies := interactive["action"].(map[string]any)["buttons"].([]any)
+	if len(replies) != 2 || replies[1].(map[string]any)["reply"].(map[string]any)["id"] != "confirm_no:s1" {
+		t.Errorf("buttons %v", replies)
+	}
+
+	// More buttons than a list holds are numbered; the number maps back
+	var many []*proto.Button
+	for i := 1; i <= 12; i++ {
+		many = append(many, &proto.Button{Text: fmt.Sprintf("Option %d", i), Data: fmt.Sprintf("opt:%d", i)})
+	}
+	if _, err := w.Send(ctx, &proto.OutgoingMessage{ChatId: 15551234567, Body: "Pick", Buttons: []*proto.ButtonRow{{Buttons: many}}}); err != nil {
+		t.Fatal(err)
+	}
+	if text := (*sent)[1]["text"].(map[string]any)["body"].(string); !strings.Contains(text, "12. Option 12") {
+		t.Errorf("numbered list missing: %q", text)
+	}
+	deliver(t, w, `{"from":"15551234567","type":"text","text":{"body":"2"}}`, true)
+	if cb := (<-w.Events()).GetCallback(); cb.Data != "opt:2" || cb.Label != "Option 2" {
+		t.Errorf("numbered reply became %+v", cb)
+	}
+}
@@ -0,0 +1,58 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// EgressFilter decides whether the browser may load a URL
+type EgressFilter func(rawURL string) error
+
+// SetEgressFilter makes the browser refuse requests, page loads and
+// sub-resources alike, that the filter rejects. Tabs already open keep the
+// previous filter.
+func (m *BrowserManager) SetEgressFilter(f EgressFilter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.egress = f
+}
+
+func (m *BrowserManager) egressFilter() EgressFilter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.egress
+}
+
+// interceptEgress pauses every request of the tab in ctx and fails those the
+// filter rejects, reporting them to blocked
+func interceptEgress(ctx context.Context, filter EgressFilter, blocked func(url string, err error)) chromedp.Action {
+	chromedp.ListenTarget(ctx, func(ev any) {
+		paused, ok := ev.(*fetch.EventRequestPaused)
+		if !ok {
+			return
+		}
+		// Answering from the event loop would deadlock
+		go func() {
+			var action chromedp.Action = fetch.ContinueRequest(paused.RequestID)
+			if err := filter(paused.Request.URL); err != nil {
+				action = fetch.FailRequest(paused.RequestID, network.ErrorReasonBlockedByClient)
+				if blocked != nil {
+					blocked(paused.Request.URL, err)
+				}
+			}
+			chromedp.Run(ctx, action)
+		}()
+	})
+	return fetch.Enable()
+}
+
+// blockedLogger records refused requests in the page's console log
+func (p *Page) blockedLogger(url string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.addConsole("egress", fmt.Sprintf("Blocked %s: %v", url, err))
+}
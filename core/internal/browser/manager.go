@@ -2,6 +2,7 @@ package browser
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/chromedp/chromedp"
@@ -9,10 +10,14 @@ import (
 
 type BrowserManager struct {
 	remoteURL string // e.g. "ws://localhost:9222"
+
+	mu     sync.Mutex
+	pages  map[string]*Page // Persistent tabs per chat session
+	egress EgressFilter     // Requests it rejects are failed, see SetEgressFilter
 }
 
 func NewBrowserManager(remoteURL string) *BrowserManager {
-	return &BrowserManager{remoteURL: remoteURL}
+	return &BrowserManager{remoteURL: remoteURL, pages: make(map[string]*Page)}
 }
 
 // Action encapsulates a browser operation
@@ -35,6 +40,10 @@ func (m *BrowserManager) Run(ctx context.Context, actions ...chromedp.Action) er
 	browserCtx, cancel := chromedp.NewContext(allocatorCtx)
 	defer cancel()
 
+	if filter := m.egressFilter(); filter != nil {
+		actions = append([]chromedp.Action{interceptEgress(browserCtx, filter, nil)}, actions...)
+	}
+
 	// Run actions with timeout
 	timeoutCtx, cancel := context.WithTimeout(browserCtx, 60*time.Second)
 	defer cancel()
@@ -74,3 +83,31 @@ func (m *BrowserManager) Type(ctx context.Context, url, selector, text string) e
 		chromedp.SendKeys(selector, text),
 	)
 }
+
+// CaptureOptions controls CapturePNG
+type CaptureOptions struct {
+	Width    int // Viewport; screenshots are only comparable at the same size
+	Height   int
+	FullPage bool
+	Selector string // Capture only this element
+}
+
+// CapturePNG renders url at a fixed viewport and returns a lossless screenshot
+func (m *BrowserManager) CapturePNG(ctx context.Context, url string, opts CaptureOptions) ([]byte, error) {
+	var buf []byte
+	actions := []chromedp.Action{
+		chromedp.EmulateViewport(int64(opts.Width), int64(opts.Height)),
+		chromedp.Navigate(url),
+		chromedp.WaitReady("body"),
+	}
+	switch {
+	case opts.Selector != "":
+		actions = append(actions, chromedp.WaitVisible(opts.Selector), chromedp.Screenshot(opts.Selector, &buf))
+	case opts.FullPage:
+		actions = append(actions, chromedp.FullScreenshot(&buf, 100)) // 100 = PNG
+	default:
+		actions = append(actions, chromedp.CaptureScreenshot(&buf))
+	}
+	err := m.Run(ctx, actions...)
+	return buf, err
+}
@@ -0,0 +1,548 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// pageIdle is how long an unused session page is kept open
+	pageIdle = 30 * time.Minute
+	// maxLogEntries caps each of the console and network logs
+	maxLogEntries = 500
+	// networkQuiet is how long no request may be in flight for "network idle"
+	networkQuiet = 500 * time.Millisecond
+)
+
+// Page is a browser tab that lives across tool calls, so navigation state,
+// cookies and logs carry over like in a real browsing session
+type Page struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	downloadDir string
+
+	mu        sync.Mutex
+	lastUsed  time.Time
+	console   []ConsoleEntry
+	requests  []*NetworkEntry
+	byID      map[network.RequestID]*NetworkEntry
+	inflight  int
+	lastNet   time.Time
+	refs      map[string]cdp.BackendNodeID // From the last snapshot
+	downloads map[string]*download
+	waiters   []chan *download
+}
+
+// ConsoleEntry is a console message or uncaught exception
+type ConsoleEntry struct {
+	Time  time.Time
+	Level string
+	Text  string
+}
+
+// NetworkEntry is one request made by the page
+type NetworkEntry struct {
+	Method string
+	URL    string
+	Type   string
+	Status int64
+	Failed string
+	Done   bool
+}
+
+type download struct {
+	guid     string
+	url      string
+	filename string
+	state    browser.DownloadProgressState
+	bytes    float64
+}
+
+// Target picks an element by CSS selector or by a ref from the last snapshot
+type Target struct {
+	Selector string
+	Ref      string
+}
+
+func (t Target) String() string {
+	if t.Ref != "" {
+		return "ref " + t.Ref
+	}
+	return t.Selector
+}
+
+// Page returns the session's tab, starting a browser for it if needed.
+// Downloads are saved to downloadDir. Tabs idle for longer than pageIdle are
+// closed along the way.
+func (m *BrowserManager) Page(sessionID, downloadDir string) (*Page, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pages == nil {
+		m.pages = make(map[string]*Page)
+	}
+
+	for id, p := range m.pages {
+		if id == sessionID {
+			continue
+		}
+		p.mu.Lock()
+		idle := time.Since(p.lastUsed) > pageIdle
+		p.mu.Unlock()
+		if idle {
+			p.cancel()
+			delete(m.pages, id)
+		}
+	}
+
+	if p, ok := m.pages[sessionID]; ok && p.ctx.Err() == nil {
+		return p, nil
+	}
+
+	var allocCtx context.Context
+	var cancelAlloc context.CancelFunc
+	if m.remoteURL != "" {
+		allocCtx, cancelAlloc = chromedp.NewRemoteAllocator(context.Background(), m.remoteURL)
+	} else {
+		allocCtx, cancelAlloc = chromedp.NewExecAllocator(context.Background(), chromedp.DefaultExecAllocatorOptions[:]...)
+	}
+	tabCtx, cancelTab := chromedp.NewContext(allocCtx)
+	p := &Page{
+		ctx: tabCtx,
+		cancel: func() {
+			cancelTab()
+			cancelAlloc()
+		},
+		downloadDir: downloadDir,
+		lastUsed:    time.Now(),
+		byID:        make(map[network.RequestID]*NetworkEntry),
+		refs:        make(map[string]cdp.BackendNodeID),
+		downloads:   make(map[string]*download),
+	}
+	chromedp.ListenTarget(tabCtx, p.onEvent)
+
+	if err := os.MkdirAll(downloadDir, 0755); err != nil {
+		p.cancel()
+		return nil, fmt.Errorf("failed to create download directory: %w", err)
+	}
+	startCtx, cancel := context.WithTimeout(tabCtx, 60*time.Second)
+	defer cancel()
+	start := []chromedp.Action{
+		network.Enable(),
+		browser.SetDownloadBehavior(browser.SetDownloadBehaviorBehaviorAllowAndName).
+			WithDownloadPath(downloadDir).
+			WithEventsEnabled(true),
+	}
+	if m.egress != nil {
+		start = append(start, interceptEgress(tabCtx, m.egress, p.blockedLogger))
+	}
+	err := chromedp.Run(startCtx, start...)
+	if err != nil {
+		p.cancel()
+		return nil, fmt.Errorf("failed to start browser: %w", err)
+	}
+	m.pages[sessionID] = p
+	return p, nil
+}
+
+// ClosePage closes the session's tab and its browser
+func (m *BrowserManager) ClosePage(sessionID string) bool {
+	m.mu.Lock()
+	p, ok := m.pages[sessionID]
+	delete(m.pages, sessionID)
+	m.mu.Unlock()
+	if ok {
+		p.cancel()
+	}
+	return ok
+}
+
+// run executes actions in the tab, bounded by timeout and by ctx
+func (p *Page) run(ctx context.Context, timeout time.Duration, actions ...chromedp.Action) error {
+	p.mu.Lock()
+	p.lastUsed = time.Now()
+	p.mu.Unlock()
+
+	runCtx, cancel := context.WithTimeout(p.ctx, timeout)
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+	return chromedp.Run(runCtx, actions...)
+}
+
+// Navigate opens url and waits according to waitUntil: "load" (default),
+// "domcontentloaded" or "networkidle". It returns the final URL and title.
+func (p *Page) Navigate(ctx context.Context, url, waitUntil string, timeout time.Duration) (string, string, error) {
+	var actions []chromedp.Action
+	switch waitUntil {
+	case "domcontentloaded":
+		actions = append(actions, navigateNoWait(url), chromedp.WaitReady("body"))
+	case "networkidle":
+		actions = append(actions, chromedp.Navigate(url), p.waitNetworkIdle())
+	case "", "load":
+		actions = append(actions, chromedp.Navigate(url))
+	default:
+		return "", "", fmt.Errorf("unknown wait_until %q (use load, domcontentloaded or networkidle)", waitUntil)
+	}
+	var location, title string
+	actions = append(actions, chromedp.Location(&location), chromedp.Title(&title))
+	err := p.run(ctx, timeout, actions...)
+	return location, title, err
+}
+
+// navigateNoWait starts a navigation without waiting for the load event
+func navigateNoWait(url string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		_, _, errText, _, err := page.Navigate(url).Do(ctx)
+		if err != nil {
+			return err
+		}
+		if errText != "" {
+			return fmt.Errorf("page load error %s", errText)
+		}
+		return nil
+	})
+}
+
+// Location returns the current URL and title
+func (p *Page) Location(ctx context.Context) (string, string, error) {
+	var location, title string
+	err := p.run(ctx, 10*time.Second, chromedp.Location(&location), chromedp.Title(&title))
+	return location, title, err
+}
+
+// WaitOptions describes what Wait waits for. Every set condition must hold.
+type WaitOptions struct {
+	Selector    string
+	State       string // visible (default), hidden, attached, detached
+	Text        string // Text that must appear in the page
+	NetworkIdle bool
+}
+
+// Wait blocks until the conditions in opts hold or timeout expires
+func (p *Page) Wait(ctx context.Context, opts WaitOptions, timeout time.Duration) error {
+	var actions []chromedp.Action
+	if opts.Selector != "" {
+		switch opts.State {
+		case "", "visible":
+			actions = append(actions, chromedp.WaitVisible(opts.Selector))
+		case "hidden":
+			actions = append(actions, chromedp.WaitNotVisible(opts.Selector))
+		case "attached":
+			actions = append(actions, chromedp.WaitReady(opts.Selector))
+		case "detached":
+			actions = append(actions, chromedp.WaitNotPresent(opts.Selector))
+		default:
+			return fmt.Errorf("unknown state %q (use visible, hidden, attached or detached)", opts.State)
+		}
+	}
+	if opts.Text != "" {
+		text, _ := json.Marshal(opts.Text)
+		actions = append(actions, chromedp.Poll(fmt.Sprintf("document.body && document.body.innerText.includes(%s)", text), nil))
+	}
+	if opts.NetworkIdle {
+		actions = append(actions, p.waitNetworkIdle())
+	}
+	if len(actions) == 0 {
+		return fmt.Errorf("nothing to wait for: set selector, text or network_idle")
+	}
+	return p.run(ctx, timeout, actions...)
+}
+
+// waitNetworkIdle waits until no request has been in flight for networkQuiet
+func (p *Page) waitNetworkIdle() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			p.mu.Lock()
+			idle := p.inflight <= 0 && time.Since(p.lastNet) >= networkQuiet
+			p.mu.Unlock()
+			if idle {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// Click clicks the target element
+func (p *Page) Click(ctx context.Context, t Target, timeout time.Duration) error {
+	if t.Ref == "" {
+		return p.run(ctx, timeout, chromedp.WaitVisible(t.Selector), chromedp.Click(t.Selector))
+	}
+	id, err := p.ref(t.Ref)
+	if err != nil {
+		return err
+	}
+	return p.run(ctx, timeout, clickNode(id))
+}
+
+// Type types text into the target element, optionally clearing it first and
+// pressing Enter afterwards
+func (p *Page) Type(ctx context.Context, t Target, text string, clear, submit bool, timeout time.Duration) error {
+	var actions []chromedp.Action
+	if t.Ref == "" {
+		actions = append(actions, chromedp.WaitVisible(t.Selector))
+		if clear {
+			actions = append(actions, chromedp.Clear(t.Selector))
+		}
+		actions = append(actions, chromedp.SendKeys(t.Selector, text))
+	} else {
+		id, err := p.ref(t.Ref)
+		if err != nil {
+			return err
+		}
+		actions = append(actions, focusNode(id, clear), chromedp.KeyEvent(text))
+	}
+	if submit {
+		actions = append(actions, chromedp.KeyEvent("\r"))
+	}
+	return p.run(ctx, timeout, actions...)
+}
+
+// Screenshot captures the visible viewport of the tab as PNG
+func (p *Page) Screenshot(ctx context.Context, fullPage bool) ([]byte, error) {
+	var buf []byte
+	action := chromedp.CaptureScreenshot(&buf)
+	if fullPage {
+		action = chromedp.FullScreenshot(&buf, 100)
+	}
+	err := p.run(ctx, 60*time.Second, action)
+	return buf, err
+}
+
+// Download triggers a download by opening url, or by clicking t when url is
+// empty, and returns the saved file path once it completes
+func (p *Page) Download(ctx context.Context, url string, t Target, timeout time.Duration) (string, error) {
+	done := make(chan *download, 1)
+	p.mu.Lock()
+	p.waiters = append(p.waiters, done)
+	p.mu.Unlock()
+	defer p.removeWaiter(done)
+
+	var err error
+	if url != "" {
+		// Navigating to a file reports ERR_ABORTED once the download takes over
+		err = p.run(ctx, timeout, chromedp.ActionFunc(func(ctx context.Context) error {
+			_, _, _, _, err := page.Navigate(url).Do(ctx)
+			return err
+		}))
+	} else {
+		err = p.Click(ctx, t, timeout)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case d := <-done:
+		if d.state != browser.DownloadProgressStateCompleted {
+			return "", fmt.Errorf("download of %s was %s", d.url, d.state)
+		}
+		return p.saveDownload(d)
+	case <-timer.C:
+		return "", fmt.Errorf("no download finished within %s", timeout)
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (p *Page) removeWaiter(ch chan *download) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, w := range p.waiters {
+		if w == ch {
+			p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// saveDownload renames the GUID-named file to its suggested name
+func (p *Page) saveDownload(d *download) (string, error) {
+	src := filepath.Join(p.downloadDir, d.guid)
+	name := filepath.Base(d.filename)
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		name = d.guid
+	}
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	dst := filepath.Join(p.downloadDir, name)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(dst); os.IsNotExist(err) {
+			break
+		}
+		dst = filepath.Join(p.downloadDir, fmt.Sprintf("%s (%d)%s", base, i, ext))
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return "", fmt.Errorf("failed to save download: %w", err)
+	}
+	return dst, nil
+}
+
+// onEvent records console output, network activity and downloads. It runs
+// on chromedp's event loop and must not block.
+func (p *Page) onEvent(ev any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch ev := ev.(type) {
+	case *runtime.EventConsoleAPICalled:
+		var parts []string
+		for _, arg := range ev.Args {
+			parts = append(parts, remoteObjectText(arg))
+		}
+		p.addConsole(string(ev.Type), strings.Join(parts, " "))
+	case *runtime.EventExceptionThrown:
+		text := ev.ExceptionDetails.Text
+		if ex := ev.ExceptionDetails.Exception; ex != nil && ex.Description != "" {
+			text = ex.Description
+		}
+		p.addConsole("exception", text)
+
+	case *network.EventRequestWillBeSent:
+		e := &NetworkEntry{Method: ev.Request.Method, URL: ev.Request.URL, Type: string(ev.Type)}
+		if _, ok := p.byID[ev.RequestID]; !ok {
+			p.inflight++
+		}
+		p.byID[ev.RequestID] = e
+		p.requests = append(p.requests, e)
+		if over := len(p.requests) - maxLogEntries; over > 0 {
+			p.requests = p.requests[over:]
+		}
+		p.lastNet = time.Now()
+	case *network.EventResponseReceived:
+		if e, ok := p.byID[ev.RequestID]; ok {
+			e.Status = ev.Response.Status
+		}
+	case *network.EventLoadingFinished:
+		p.finishRequest(ev.RequestID, "")
+	case *network.EventLoadingFailed:
+		p.finishRequest(ev.RequestID, ev.ErrorText)
+
+	case *browser.EventDownloadWillBegin:
+		p.downloads[ev.GUID] = &download{guid: ev.GUID, url: ev.URL, filename: ev.SuggestedFilename}
+	case *browser.EventDownloadProgress:
+		d, ok := p.downloads[ev.GUID]
+		if !ok {
+			return
+		}
+		d.state, d.bytes = ev.State, ev.ReceivedBytes
+		if ev.State == browser.DownloadProgressStateInProgress {
+			return
+		}
+		delete(p.downloads, ev.GUID)
+		for _, w := range p.waiters {
+			select {
+			case w <- d:
+			default:
+			}
+		}
+	}
+}
+
+func (p *Page) finishRequest(id network.RequestID, failure string) {
+	e, ok := p.byID[id]
+	if !ok {
+		return
+	}
+	delete(p.byID, id)
+	e.Done, e.Failed = true, failure
+	p.inflight--
+	p.lastNet = time.Now()
+}
+
+func (p *Page) addConsole(level, text string) {
+	p.console = append(p.console, ConsoleEntry{Time: time.Now(), Level: level, Text: text})
+	if over := len(p.console) - maxLogEntries; over > 0 {
+		p.console = p.console[over:]
+	}
+}
+
+// remoteObjectText renders a console argument
+func remoteObjectText(o *runtime.RemoteObject) string {
+	if len(o.Value) > 0 {
+		var s string
+		if json.Unmarshal(o.Value, &s) == nil {
+			return s
+		}
+		return string(o.Value)
+	}
+	if o.Description != "" {
+		return o.Description
+	}
+	return string(o.Type)
+}
+
+// Logs formats the captured console and/or network logs. errorsOnly keeps
+// console errors/exceptions and failed or 4xx/5xx requests. clear empties
+// the logs afterwards.
+func (p *Page) Logs(console, net, errorsOnly, clear bool) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var sb strings.Builder
+	if console {
+		sb.WriteString("## Console\n")
+		n := 0
+		for _, e := range p.console {
+			if errorsOnly && e.Level != "error" && e.Level != "exception" && e.Level != "assert" {
+				continue
+			}
+			fmt.Fprintf(&sb, "[%s] %s: %s\n", e.Time.Format("15:04:05"), e.Level, e.Text)
+			n++
+		}
+		if n == 0 {
+			sb.WriteString("(none)\n")
+		}
+	}
+	if net {
+		if console {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("## Network\n")
+		n := 0
+		for _, e := range p.requests {
+			failed := e.Failed != "" || e.Status >= 400
+			if errorsOnly && !failed {
+				continue
+			}
+			status := "pending"
+			switch {
+			case e.Failed != "":
+				status = "FAILED " + e.Failed
+			case e.Status > 0:
+				status = fmt.Sprint(e.Status)
+			}
+			fmt.Fprintf(&sb, "%s %s %s (%s)\n", e.Method, e.URL, status, e.Type)
+			n++
+		}
+		if n == 0 {
+			sb.WriteString("(none)\n")
+		}
+	}
+	if clear {
+		p.console = nil
+		p.requests = nil
+	}
+	return sb.String()
+}
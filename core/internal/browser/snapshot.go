@@ -0,0 +1,197 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/accessibility"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// maxSnapshotBytes caps the rendered accessibility tree
+	maxSnapshotBytes = 48 * 1024
+	// maxAXName caps each accessible name in the snapshot
+	maxAXName = 120
+)
+
+// skippedRoles add nothing to the outline; their children are kept
+var skippedRoles = map[string]bool{
+	"none": true, "generic": true, "InlineTextBox": true, "LineBreak": true,
+	"presentation": true,
+}
+
+// shownProperties are the AX states worth printing
+var shownProperties = map[accessibility.PropertyName]bool{
+	accessibility.PropertyNameChecked:  true,
+	accessibility.PropertyNameDisabled: true,
+	accessibility.PropertyNameExpanded: true,
+	accessibility.PropertyNameSelected: true,
+	accessibility.PropertyNameLevel:    true,
+	accessibility.PropertyNameFocused:  true,
+	accessibility.PropertyNameRequired: true,
+	accessibility.PropertyNameInvalid:  true,
+}
+
+// Snapshot renders the page's accessibility tree as an indented outline.
+// Elements get refs (e1, e2, ...) that Click, Type and Download accept until
+// the next snapshot.
+func (p *Page) Snapshot(ctx context.Context) (string, error) {
+	var nodes []*accessibility.Node
+	err := p.run(ctx, 30*time.Second, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		nodes, err = accessibility.GetFullAXTree().Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return "", err
+	}
+	if len(nodes) == 0 {
+		return "(empty page)", nil
+	}
+
+	out, refs := renderAXTree(nodes)
+	p.mu.Lock()
+	p.refs = refs
+	p.mu.Unlock()
+	return out, nil
+}
+
+// renderAXTree formats the tree rooted at nodes[0] and assigns element refs
+func renderAXTree(nodes []*accessibility.Node) (string, map[string]cdp.BackendNodeID) {
+	byID := make(map[accessibility.NodeID]*accessibility.Node, len(nodes))
+	for _, n := range nodes {
+		byID[n.NodeID] = n
+	}
+	refs := make(map[string]cdp.BackendNodeID)
+	var sb strings.Builder
+	truncated := false
+
+	var walk func(n *accessibility.Node, depth int)
+	walk = func(n *accessibility.Node, depth int) {
+		if truncated {
+			return
+		}
+		role, name := axString(n.Role), axString(n.Name)
+		show := !n.Ignored && !(skippedRoles[role] && name == "")
+		if show {
+			line := strings.Repeat("  ", depth) + "- " + role
+			if role == "StaticText" {
+				line = strings.Repeat("  ", depth) + "- text"
+			}
+			if name != "" {
+				line += fmt.Sprintf(" %q", name)
+			}
+			for _, prop := range n.Properties {
+				if shownProperties[prop.Name] {
+					if v := axString(prop.Value); v != "" && v != "false" {
+						line += fmt.Sprintf(" [%s=%s]", prop.Name, v)
+					}
+				}
+			}
+			if v := axString(n.Value); v != "" {
+				line += ": " + v
+			}
+			if n.BackendDOMNodeID != 0 && role != "StaticText" {
+				ref := fmt.Sprintf("e%d", len(refs)+1)
+				refs[ref] = n.BackendDOMNodeID
+				line += " [ref=" + ref + "]"
+			}
+			if sb.Len()+len(line) > maxSnapshotBytes {
+				truncated = true
+				return
+			}
+			sb.WriteString(line + "\n")
+			depth++
+		}
+		for _, id := range n.ChildIDs {
+			if child, ok := byID[id]; ok {
+				walk(child, depth)
+			}
+		}
+	}
+	walk(nodes[0], 0)
+
+	if truncated {
+		sb.WriteString("... (snapshot truncated)\n")
+	}
+	return sb.String(), refs
+}
+
+// axString renders an AX value as plain text
+func axString(v *accessibility.Value) string {
+	if v == nil || len(v.Value) == 0 {
+		return ""
+	}
+	var raw interface{}
+	if err := json.Unmarshal(v.Value, &raw); err != nil {
+		return ""
+	}
+	s := strings.Join(strings.Fields(fmt.Sprint(raw)), " ")
+	if r := []rune(s); len(r) > maxAXName {
+		s = string(r[:maxAXName]) + "…"
+	}
+	return s
+}
+
+// ref resolves a snapshot ref to its DOM node
+func (p *Page) ref(ref string) (cdp.BackendNodeID, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id, ok := p.refs[ref]
+	if !ok {
+		return 0, fmt.Errorf("unknown ref %q: take a new browser_snapshot, refs change when the page does", ref)
+	}
+	return id, nil
+}
+
+// clickNode scrolls the node into view and clicks its center like a user would
+func clickNode(id cdp.BackendNodeID) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if err := dom.ScrollIntoViewIfNeeded().WithBackendNodeID(id).Do(ctx); err != nil {
+			return fmt.Errorf("element is gone: %w", err)
+		}
+		quads, err := dom.GetContentQuads().WithBackendNodeID(id).Do(ctx)
+		if err != nil || len(quads) == 0 || len(quads[0]) < 8 {
+			return fmt.Errorf("element is not visible")
+		}
+		q := quads[0]
+		x := (q[0] + q[2] + q[4] + q[6]) / 4
+		y := (q[1] + q[3] + q[5] + q[7]) / 4
+		return chromedp.MouseClickXY(x, y).Do(ctx)
+	})
+}
+
+// focusNode focuses the node, emptying its value first when clear is set
+func focusNode(id cdp.BackendNodeID, clear bool) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if err := dom.Focus().WithBackendNodeID(id).Do(ctx); err != nil {
+			return fmt.Errorf("cannot focus element: %w", err)
+		}
+		if !clear {
+			return nil
+		}
+		obj, err := dom.ResolveNode().WithBackendNodeID(id).Do(ctx)
+		if err != nil {
+			return err
+		}
+		_, exc, err := runtime.CallFunctionOn(`function() {
+			if ("value" in this) this.value = "";
+			else if (this.isContentEditable) this.textContent = "";
+			this.dispatchEvent(new Event("input", {bubbles: true}));
+		}`).WithObjectID(obj.ObjectID).Do(ctx)
+		if err != nil {
+			return err
+		}
+		if exc != nil {
+			return fmt.Errorf("cannot clear element: %s", exc.Text)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,42 @@
+package browser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/accessibility"
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+func axValue(v string) *accessibility.Value {
+	return &accessibility.Value{Value: jsontext.Value(v)}
+}
+
+func TestRenderAXTree(t *testing.T) {
+	nodes := []*accessibility.Node{
+		{NodeID: "1", Role: axValue(`"RootWebArea"`), Name: axValue(`"Login"`), ChildIDs: []accessibility.NodeID{"2"}, BackendDOMNodeID: 1},
+		{NodeID: "2", Role: axValue(`"generic"`), ChildIDs: []accessibility.NodeID{"3", "4", "5"}, BackendDOMNodeID: 2},
+		{NodeID: "3", Role: axValue(`"StaticText"`), Name: axValue(`"Welcome  back"`), BackendDOMNodeID: 3},
+		{NodeID: "4", Role: axValue(`"textbox"`), Name: axValue(`"Email"`), Value: axValue(`"a@b.c"`), BackendDOMNodeID: 4,
+			Properties: []*accessibility.Property{
+				{Name: accessibility.PropertyNameRequired, Value: axValue(`true`)},
+				{Name: accessibility.PropertyNameDisabled, Value: axValue(`false`)},
+			}},
+		{NodeID: "5", Role: axValue(`"button"`), Name: axValue(`"Sign in"`), Ignored: true, BackendDOMNodeID: 5},
+	}
+
+	out, refs := renderAXTree(nodes)
+	want := `- RootWebArea "Login" [ref=e1]
+  - text "Welcome back"
+  - textbox "Email" [required=true]: a@b.c [ref=e2]
+`
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+	if len(refs) != 2 || refs["e2"] != 4 {
+		t.Errorf("unexpected refs %v", refs)
+	}
+	if strings.Contains(out, "Sign in") {
+		t.Error("ignored node was rendered")
+	}
+}
@@ -0,0 +1,91 @@
+package browser
+
+import (
+	"image"
+	"image/color"
+)
+
+// maxYIQDelta is the largest possible colorDelta (black vs white)
+const maxYIQDelta = 35215.0
+
+// VisualDiff is the outcome of comparing a screenshot to its baseline
+type VisualDiff struct {
+	DiffPixels  int
+	TotalPixels int
+	SizeChanged bool
+	Diff        *image.RGBA // Baseline faded to gray with differing pixels in red
+}
+
+// Ratio is the share of differing pixels, 0..1
+func (d VisualDiff) Ratio() float64 {
+	if d.TotalPixels == 0 {
+		return 0
+	}
+	return float64(d.DiffPixels) / float64(d.TotalPixels)
+}
+
+// CompareImages counts pixels whose perceptual color distance exceeds
+// threshold (0..1, where 0.1 ignores slight anti-aliasing and compression
+// noise). The distance is measured in YIQ space, which weighs brightness
+// changes the way the eye does. When the sizes differ, pixels outside the
+// overlap count as different.
+func CompareImages(baseline, actual image.Image, threshold float64) VisualDiff {
+	bb, ab := baseline.Bounds(), actual.Bounds()
+	w, h := max(bb.Dx(), ab.Dx()), max(bb.Dy(), ab.Dy())
+	d := VisualDiff{
+		TotalPixels: w * h,
+		SizeChanged: bb.Dx() != ab.Dx() || bb.Dy() != ab.Dy(),
+		Diff:        image.NewRGBA(image.Rect(0, 0, w, h)),
+	}
+	limit := maxYIQDelta * threshold * threshold
+	red := color.RGBA{R: 255, A: 255}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			inBase := x < bb.Dx() && y < bb.Dy()
+			inActual := x < ab.Dx() && y < ab.Dy()
+			if !inBase || !inActual {
+				d.DiffPixels++
+				d.Diff.SetRGBA(x, y, red)
+				continue
+			}
+			p1 := toRGBA(baseline.At(bb.Min.X+x, bb.Min.Y+y))
+			p2 := toRGBA(actual.At(ab.Min.X+x, ab.Min.Y+y))
+			if colorDelta(p1, p2) > limit {
+				d.DiffPixels++
+				d.Diff.SetRGBA(x, y, red)
+				continue
+			}
+			// Unchanged pixels are drawn faded so the red stands out
+			g := uint8(255 - (255-luma(p1))/4)
+			d.Diff.SetRGBA(x, y, color.RGBA{R: g, G: g, B: g, A: 255})
+		}
+	}
+	return d
+}
+
+// toRGBA returns 8-bit channels blended over white
+func toRGBA(c color.Color) [3]float64 {
+	r, g, b, a := c.RGBA()
+	switch a {
+	case 0xffff:
+		return [3]float64{float64(r >> 8), float64(g >> 8), float64(b >> 8)}
+	case 0:
+		return [3]float64{255, 255, 255}
+	}
+	// RGBA() is alpha-premultiplied, so blending over white is just adding
+	// the uncovered share of white
+	white := 255 * (1 - float64(a)/0xffff)
+	return [3]float64{float64(r>>8) + white, float64(g>>8) + white, float64(b>>8) + white}
+}
+
+func luma(p [3]float64) float64 {
+	return p[0]*0.29889531 + p[1]*0.58662247 + p[2]*0.11448223
+}
+
+// colorDelta is the squared YIQ distance between two pixels
+func colorDelta(a, b [3]float64) float64 {
+	y := luma(a) - luma(b)
+	i := (a[0]*0.59597799 - a[1]*0.27417610 - a[2]*0.32180189) - (b[0]*0.59597799 - b[1]*0.27417610 - b[2]*0.32180189)
+	q := (a[0]*0.21147017 - a[1]*0.52261711 + a[2]*0.31114694) - (b[0]*0.21147017 - b[1]*0.52261711 + b[2]*0.31114694)
+	return 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+}
@@ -0,0 +1,44 @@
+package browser
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func solid(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestCompareImages(t *testing.T) {
+	base := solid(10, 10, color.White)
+
+	// Near-identical colors (compression noise) stay under the threshold
+	noisy := solid(10, 10, color.RGBA{R: 250, G: 252, B: 255, A: 255})
+	if d := CompareImages(base, noisy, 0.1); d.DiffPixels != 0 {
+		t.Errorf("noise counted as %d changed pixels", d.DiffPixels)
+	}
+
+	changed := solid(10, 10, color.White)
+	for x := 0; x < 10; x++ {
+		changed.Set(x, 0, color.Black)
+	}
+	d := CompareImages(base, changed, 0.1)
+	if d.DiffPixels != 10 || d.Ratio() != 0.1 || d.SizeChanged {
+		t.Errorf("diff = %d pixels, ratio %v, size changed %v", d.DiffPixels, d.Ratio(), d.SizeChanged)
+	}
+	if got := d.Diff.RGBAAt(3, 0); got != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("changed pixel drawn as %v", got)
+	}
+
+	// Extra rows count as changed
+	if d := CompareImages(base, solid(10, 12, color.White), 0.1); !d.SizeChanged || d.DiffPixels != 20 {
+		t.Errorf("size change diff = %+v", d.DiffPixels)
+	}
+}
@@ -0,0 +1,73 @@
+package codegraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// cacheVersion changes when parsing or linking does, invalidating old caches
+const cacheVersion = 2
+
+// cacheFile is the graph as persisted between sessions
+type cacheFile struct {
+	Version int     `json:"version"`
+	Nodes   []*Node `json:"nodes"`
+}
+
+// LoadCache restores a graph saved by SaveCache, with its links and scores,
+// so the next Update only re-parses files changed since. A cache from
+// another version is ignored.
+func (s *Service) LoadCache(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var cache cacheFile
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return fmt.Errorf("corrupt code graph cache: %w", err)
+	}
+	if cache.Version != cacheVersion {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes = make(map[string]*Node, len(cache.Nodes))
+	for _, node := range cache.Nodes {
+		s.nodes[node.Path] = node
+	}
+	s.changed = make(map[string]bool)
+	s.added = make(map[string]bool)
+	s.rankStale = false
+	s.rankedOnce = true
+	return nil
+}
+
+// SaveCache writes the graph to path, atomically
+func (s *Service) SaveCache(path string) error {
+	s.mu.RLock()
+	if s.rankStale {
+		s.mu.RUnlock()
+		return fmt.Errorf("code graph changed since it was ranked; run CalculatePageRank first")
+	}
+	cache := cacheFile{Version: cacheVersion, Nodes: make([]*Node, 0, len(s.nodes))}
+	for _, node := range s.nodes {
+		cache.Nodes = append(cache.Nodes, node)
+	}
+	data, err := json.Marshal(cache)
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,104 @@
+package codegraph
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// assertSameRanks compares an incrementally maintained graph with a full rebuild
+func assertSameRanks(t *testing.T, s *Service, root string) {
+	t.Helper()
+	full := NewService()
+	if err := full.Rebuild(root); err != nil {
+		t.Fatal(err)
+	}
+	full.CalculatePageRank()
+	if len(full.GetAllFiles()) != len(s.GetAllFiles()) {
+		t.Fatalf("incremental graph has %d files, full rebuild %d", len(s.GetAllFiles()), len(full.GetAllFiles()))
+	}
+	for _, path := range full.GetAllFiles() {
+		want, got := full.GetNode(path).PageRank, s.GetNode(path).PageRank
+		if math.Abs(want-got) > 1e-4 {
+			t.Errorf("%s: PageRank %.5f, full rebuild %.5f", filepath.Base(path), got, want)
+		}
+	}
+}
+
+func TestUpdate_Cache(t *testing.T) {
+	root := t.TempDir()
+	cachePath := filepath.Join(t.TempDir(), "codegraph.json")
+	writeFile(t, root, "main.py", "from .utils import helper\nfrom .models import User\n\ndef main():\n    helper()\n")
+	writeFile(t, root, "utils.py", "from .models import User\n\ndef helper():\n    pass\n")
+	writeFile(t, root, "models.py", "class User:\n    pass\n")
+
+	s := NewService()
+	stats, err := s.Update(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Files != 3 || stats.Parsed != 3 {
+		t.Errorf("first update = %+v, want 3 files parsed", stats)
+	}
+	s.CalculatePageRank()
+	if err := s.SaveCache(cachePath); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new session starts from the cache and parses nothing
+	s = NewService()
+	if err := s.LoadCache(cachePath); err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := s.Update(root); stats.Parsed != 0 || stats.Removed != 0 {
+		t.Errorf("update from cache = %+v, want nothing parsed", stats)
+	}
+	models := filepath.Join(root, "models.py")
+	if n := s.GetNode(models); n == nil || n.PageRank <= s.GetNode(filepath.Join(root, "main.py")).PageRank {
+		t.Errorf("cached ranks lost: %+v", n)
+	}
+
+	// Touched but unchanged files are not parsed again
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(models, later, later)
+	if stats, _ := s.Update(root); stats.Parsed != 0 {
+		t.Errorf("touched file parsed again: %+v", stats)
+	}
+
+	// Only changed, added and deleted files are redone
+	writeFile(t, root, "utils.py", "def helper():\n    pass\n")
+	writeFile(t, root, "api.py", "from .models import User\nfrom .utils import helper\n")
+	os.Remove(filepath.Join(root, "main.py"))
+	stats, err = s.Update(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Files != 3 || stats.Parsed != 2 || stats.Removed != 1 {
+		t.Errorf("incremental update = %+v, want 2 parsed and 1 removed", stats)
+	}
+	s.CalculatePageRank()
+	assertSameRanks(t, s, root)
+}
+
+func TestLoadCache_OtherVersion(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "codegraph.json")
+	if err := os.WriteFile(cachePath, []byte(`{"version":0,"nodes":[{"Path":"/x/a.py"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewService()
+	if err := s.LoadCache(cachePath); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.GetAllFiles()) != 0 {
+		t.Error("a cache from another version was loaded")
+	}
+}
@@ -0,0 +1,280 @@
+package codegraph
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Symbol is a definition of a file with the functions and methods it calls
+type Symbol struct {
+	Name    string
+	Line    int
+	EndLine int
+	Calls   []string // Names called in its body, without receivers or packages
+}
+
+// symbolSpan is a definition while parsing, with its byte range
+type symbolSpan struct {
+	Symbol
+	start, end uint32
+}
+
+type callSite struct {
+	name string
+	at   uint32
+}
+
+// attributeCalls gives each call to the innermost definition around it.
+// Calls outside any definition, like top-level statements, are dropped.
+func attributeCalls(spans []symbolSpan, calls []callSite) []Symbol {
+	seen := make([]map[string]bool, len(spans))
+	for _, call := range calls {
+		inner := -1
+		for i, span := range spans {
+			if span.start <= call.at && call.at < span.end && (inner < 0 || span.end-span.start < spans[inner].end-spans[inner].start) {
+				inner = i
+			}
+		}
+		if inner < 0 {
+			continue
+		}
+		if seen[inner] == nil {
+			seen[inner] = make(map[string]bool)
+		}
+		if !seen[inner][call.name] {
+			seen[inner][call.name] = true
+			spans[inner].Calls = append(spans[inner].Calls, call.name)
+		}
+	}
+	symbols := make([]Symbol, len(spans))
+	for i, span := range spans {
+		symbols[i] = span.Symbol
+	}
+	return symbols
+}
+
+// Caller is a definition that calls a symbol
+type Caller struct {
+	Path     string
+	Symbol   string
+	Line     int
+	PageRank float64 // Of the caller's file
+}
+
+// Callee is something a symbol calls, with where it is defined in the
+// workspace; Locations is empty for library and built-in calls
+type Callee struct {
+	Name      string
+	Locations []Location
+}
+
+// Location is where a symbol is defined
+type Location struct {
+	Path     string
+	Line     int
+	PageRank float64
+}
+
+// Dependent is a file affected by a change to another one
+type Dependent struct {
+	Path     string
+	Distance int      // 1 for direct dependents, more through other files
+	Reasons  []string // How it depends, e.g. "imports store.go" or "calls Save"
+	PageRank float64
+}
+
+// symbolName strips receivers, packages and modules: "pkg.Func",
+// "Type.Method" and "mod::func" all name the last part
+func symbolName(symbol string) string {
+	if i := strings.LastIndexAny(symbol, ".:"); i >= 0 {
+		return symbol[i+1:]
+	}
+	return symbol
+}
+
+// WhoCalls returns the definitions that call symbol, callers in the most
+// central files first. Calls are matched by name, so same-named functions
+// of different types or packages are not told apart.
+func (s *Service) WhoCalls(symbol string) []Caller {
+	name := symbolName(symbol)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var callers []Caller
+	for path, node := range s.nodes {
+		for _, sym := range node.Symbols {
+			for _, call := range sym.Calls {
+				if call == name {
+					callers = append(callers, Caller{Path: path, Symbol: sym.Name, Line: sym.Line, PageRank: node.PageRank})
+					break
+				}
+			}
+		}
+	}
+	sort.Slice(callers, func(i, j int) bool {
+		if callers[i].PageRank != callers[j].PageRank {
+			return callers[i].PageRank > callers[j].PageRank
+		}
+		if callers[i].Path != callers[j].Path {
+			return callers[i].Path < callers[j].Path
+		}
+		return callers[i].Line < callers[j].Line
+	})
+	return callers
+}
+
+// Callees returns what the definitions named symbol call, those defined in
+// the workspace first, each with its definitions ranked by file importance.
+// It reports false when no definition of symbol is known.
+func (s *Service) Callees(symbol string) ([]Callee, bool) {
+	name := symbolName(symbol)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := false
+	seen := make(map[string]bool)
+	var names []string
+	for _, node := range s.nodes {
+		for _, sym := range node.Symbols {
+			if sym.Name != name {
+				continue
+			}
+			found = true
+			for _, call := range sym.Calls {
+				if !seen[call] {
+					seen[call] = true
+					names = append(names, call)
+				}
+			}
+		}
+	}
+
+	defs := s.definitionsLocked()
+	callees := make([]Callee, 0, len(names))
+	for _, call := range names {
+		callees = append(callees, Callee{Name: call, Locations: defs[call]})
+	}
+	sort.Slice(callees, func(i, j int) bool {
+		ri, rj := topRank(callees[i].Locations), topRank(callees[j].Locations)
+		if ri != rj {
+			return ri > rj
+		}
+		return callees[i].Name < callees[j].Name
+	})
+	return callees, found
+}
+
+// ambiguousDefinitions is how many files may define a name before calls to
+// it are too ambiguous to count as a dependency
+const ambiguousDefinitions = 3
+
+// maxImpactDepth bounds how far ImpactOf follows dependents of dependents
+const maxImpactDepth = 3
+
+// ImpactOf returns the files a change to path may break: files importing it
+// or calling what it defines, then their own dependents, up to a few steps
+// away. Direct dependents come first, then the most central files. Calls to
+// names defined in many files (String, New, ...) are ignored as ambiguous.
+func (s *Service) ImpactOf(path string) ([]Dependent, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.nodes[path]; !ok {
+		return nil, false
+	}
+	defs := s.definitionsLocked()
+
+	found := map[string]*Dependent{path: {Path: path}}
+	frontier := []string{path}
+	for depth := 1; depth <= maxImpactDepth && len(frontier) > 0; depth++ {
+		var next []string
+		add := func(dependent string, reason string) {
+			d, ok := found[dependent]
+			if !ok {
+				d = &Dependent{Path: dependent, Distance: depth, PageRank: s.nodes[dependent].PageRank}
+				found[dependent] = d
+				next = append(next, dependent)
+			}
+			if d.Distance == depth && len(d.Reasons) < 5 && !contains(d.Reasons, reason) {
+				d.Reasons = append(d.Reasons, reason)
+			}
+		}
+		for _, changed := range frontier {
+			// Names the file defines that are specific enough to follow calls to
+			defined := make(map[string]bool)
+			for _, sym := range s.nodes[changed].Symbols {
+				if len(defs[sym.Name]) <= ambiguousDefinitions {
+					defined[sym.Name] = true
+				}
+			}
+			for other, node := range s.nodes {
+				if other == changed {
+					continue
+				}
+				if contains(node.Links, changed) {
+					add(other, "imports "+filepath.Base(changed))
+				}
+				for _, sym := range node.Symbols {
+					for _, call := range sym.Calls {
+						if defined[call] {
+							add(other, "calls "+call)
+						}
+					}
+				}
+			}
+		}
+		frontier = next
+	}
+
+	delete(found, path)
+	dependents := make([]Dependent, 0, len(found))
+	for _, d := range found {
+		dependents = append(dependents, *d)
+	}
+	sort.Slice(dependents, func(i, j int) bool {
+		if dependents[i].Distance != dependents[j].Distance {
+			return dependents[i].Distance < dependents[j].Distance
+		}
+		if dependents[i].PageRank != dependents[j].PageRank {
+			return dependents[i].PageRank > dependents[j].PageRank
+		}
+		return dependents[i].Path < dependents[j].Path
+	})
+	return dependents, true
+}
+
+// definitionsLocked indexes every definition by name, most central first
+func (s *Service) definitionsLocked() map[string][]Location {
+	defs := make(map[string][]Location)
+	for path, node := range s.nodes {
+		for _, sym := range node.Symbols {
+			defs[sym.Name] = append(defs[sym.Name], Location{Path: path, Line: sym.Line, PageRank: node.PageRank})
+		}
+	}
+	for _, locs := range defs {
+		sort.Slice(locs, func(i, j int) bool {
+			if locs[i].PageRank != locs[j].PageRank {
+				return locs[i].PageRank > locs[j].PageRank
+			}
+			return locs[i].Path < locs[j].Path
+		})
+	}
+	return defs
+}
+
+func topRank(locs []Location) float64 {
+	if len(locs) == 0 {
+		return -1
+	}
+	return locs[0].PageRank
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package codegraph
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func newCallGraph(t *testing.T) (*Service, string) {
+	t.Helper()
+	root := t.TempDir()
+	writeFile(t, root, "store.go", `package app
+
+type Store struct{}
+
+func (s *Store) Save(key string) error {
+	return s.write(key)
+}
+
+func (s *Store) write(key string) error {
+	fmt.Println(key)
+	return nil
+}
+`)
+	writeFile(t, root, "handler.go", `package app
+
+func HandleSave(s *Store) error {
+	if err := s.Save("k"); err != nil {
+		return wrap(err)
+	}
+	return nil
+}
+
+func wrap(err error) error { return err }
+`)
+	writeFile(t, root, "cli.go", `package app
+
+func Run(s *Store) {
+	HandleSave(s)
+}
+`)
+	writeFile(t, root, "main.py", "from .store import save\n\ndef main():\n    save()\n")
+	writeFile(t, root, "store.py", "def save():\n    pass\n")
+
+	s := NewService()
+	if _, err := s.Update(root); err != nil {
+		t.Fatal(err)
+	}
+	s.CalculatePageRank()
+	return s, root
+}
+
+func TestWhoCalls(t *testing.T) {
+	s, root := newCallGraph(t)
+
+	callers := s.WhoCalls("Store.Save")
+	if len(callers) != 1 || callers[0].Symbol != "HandleSave" || callers[0].Path != filepath.Join(root, "handler.go") || callers[0].Line != 3 {
+		t.Errorf("callers of Save = %+v", callers)
+	}
+	if callers := s.WhoCalls("write"); len(callers) != 1 || callers[0].Symbol != "Save" {
+		t.Errorf("callers of write = %+v", callers)
+	}
+	if callers := s.WhoCalls("Unused"); len(callers) != 0 {
+		t.Errorf("callers of an unused name = %+v", callers)
+	}
+}
+
+func TestCallees(t *testing.T) {
+	s, root := newCallGraph(t)
+
+	callees, found := s.Callees("HandleSave")
+	if !found {
+		t.Fatal("HandleSave not found")
+	}
+	var names []string
+	for _, c := range callees {
+		names = append(names, c.Name)
+		if c.Name == "Save" && (len(c.Locations) != 1 || c.Locations[0].Path != filepath.Join(root, "store.go")) {
+			t.Errorf("Save located at %+v", c.Locations)
+		}
+	}
+	if !slices.Contains(names, "Save") || !slices.Contains(names, "wrap") {
+		t.Errorf("HandleSave calls %v", names)
+	}
+
+	callees, _ = s.Callees("write")
+	if len(callees) != 1 || callees[0].Name != "Println" || len(callees[0].Locations) != 0 {
+		t.Errorf("write calls %+v, want only the unresolved Println", callees)
+	}
+	if _, found := s.Callees("Missing"); found {
+		t.Error("a missing symbol was found")
+	}
+}
+
+func TestImpactOf(t *testing.T) {
+	s, root := newCallGraph(t)
+
+	dependents, found := s.ImpactOf(filepath.Join(root, "store.go"))
+	if !found {
+		t.Fatal("store.go not in the graph")
+	}
+	byPath := map[string]Dependent{}
+	for _, d := range dependents {
+		byPath[filepath.Base(d.Path)] = d
+	}
+	if d := byPath["handler.go"]; d.Distance != 1 || !slices.Contains(d.Reasons, "calls Save") {
+		t.Errorf("handler.go = %+v, want a direct dependent through Save", d)
+	}
+	if d := byPath["cli.go"]; d.Distance != 2 || !slices.Contains(d.Reasons, "calls HandleSave") {
+		t.Errorf("cli.go = %+v, want a dependent of handler.go", d)
+	}
+	if _, ok := byPath["store.go"]; ok {
+		t.Error("the changed file is its own dependent")
+	}
+
+	// Imports are followed too
+	dependents, _ = s.ImpactOf(filepath.Join(root, "store.py"))
+	if len(dependents) != 1 || filepath.Base(dependents[0].Path) != "main.py" {
+		t.Errorf("dependents of store.py = %+v", dependents)
+	}
+	if _, found := s.ImpactOf(filepath.Join(root, "missing.go")); found {
+		t.Error("a missing file was found")
+	}
+}
@@ -0,0 +1,148 @@
+package codegraph
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Focus is what a conversation is working on: the files it touched and the
+// searches it ran
+type Focus struct {
+	Files   []string // Absolute paths, most recent first
+	Queries []string // Most recent first
+}
+
+// focusShare is how much of the restart vector follows the focus; the rest
+// is uniform so files far from it still rank by global importance
+const focusShare = 0.7
+
+// GenerateFocusedRepoMap is GenerateRepoMap ranked by personalized PageRank:
+// the random surfer restarts at the focus files and at files matching the
+// search queries, so the map emphasizes the subsystem being worked on and
+// what it depends on. Without a focus it is the plain repo map.
+func (s *Service) GenerateFocusedRepoMap(maxFiles int, focus Focus) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	restart, focused := s.restartVectorLocked(focus)
+	if len(restart) == 0 {
+		scores := make(map[string]float64, len(s.nodes))
+		for path, node := range s.nodes {
+			scores[path] = node.PageRank
+		}
+		return s.renderRepoMapLocked(maxFiles, scores, nil)
+	}
+	return s.renderRepoMapLocked(maxFiles, s.personalizedRanksLocked(restart), focused)
+}
+
+// restartVectorLocked weights the focus: recent files count more, and each
+// query term spreads its weight over the files whose path or definitions
+// contain it, so broad terms weigh little per file
+func (s *Service) restartVectorLocked(focus Focus) (map[string]float64, map[string]bool) {
+	restart := make(map[string]float64)
+	focused := make(map[string]bool)
+	for i, path := range focus.Files {
+		if _, ok := s.nodes[path]; ok {
+			restart[path] += 1 / float64(i+1)
+			focused[path] = true
+		}
+	}
+
+	for i, query := range focus.Queries {
+		for _, term := range queryTerms(query) {
+			var matches []string
+			for path, node := range s.nodes {
+				if nodeMentions(path, node, term) {
+					matches = append(matches, path)
+				}
+			}
+			for _, path := range matches {
+				restart[path] += 1 / float64(i+1) / float64(len(matches))
+			}
+		}
+	}
+
+	total := 0.0
+	for _, w := range restart {
+		total += w
+	}
+	for path := range restart {
+		restart[path] /= total
+	}
+	return restart, focused
+}
+
+// personalizedRanksLocked runs PageRank whose restart follows the focus,
+// scaled like the global PageRank (averaging 1 per file)
+func (s *Service) personalizedRanksLocked(restart map[string]float64) map[string]float64 {
+	damping := 0.85
+	n := float64(len(s.nodes))
+
+	graph := make(map[string][]string)
+	scores := make(map[string]float64, len(s.nodes))
+	for importerPath, node := range s.nodes {
+		for _, target := range node.Links {
+			graph[target] = append(graph[target], importerPath)
+		}
+		scores[importerPath] = node.PageRank // Warm start
+	}
+
+	for i := 0; i < maxRankIterations; i++ {
+		newScores := make(map[string]float64, len(scores))
+		delta := 0.0
+		for path := range s.nodes {
+			inboundSum := 0.0
+			for _, importerPath := range graph[path] {
+				outDegree := float64(len(s.nodes[importerPath].Imports))
+				if outDegree == 0 {
+					outDegree = 1
+				}
+				inboundSum += scores[importerPath] / outDegree
+			}
+			v := focusShare*restart[path] + (1-focusShare)/n
+			newScores[path] = (1-damping)*n*v + damping*inboundSum
+			delta = math.Max(delta, math.Abs(newScores[path]-scores[path]))
+		}
+		scores = newScores
+		if delta < rankTolerance {
+			break
+		}
+	}
+	return scores
+}
+
+// queryTerms splits a search query into lowercase words worth matching
+func queryTerms(query string) []string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		if len(word) >= 3 && !stopWords[word] {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "how": true, "does": true, "where": true,
+	"what": true, "with": true, "from": true, "that": true, "this": true, "are": true,
+	"code": true, "file": true, "function": true, "which": true, "when": true,
+}
+
+// nodeMentions reports whether a file's name, its directory's or its
+// definitions contain term. Higher directories are left out: they are
+// shared by every file of the workspace.
+func nodeMentions(path string, node *Node, term string) bool {
+	if strings.Contains(strings.ToLower(filepath.Join(filepath.Base(filepath.Dir(path)), filepath.Base(path))), term) {
+		return true
+	}
+	for _, def := range node.Definitions {
+		if strings.Contains(strings.ToLower(def), term) {
+			return true
+		}
+	}
+	return false
+}
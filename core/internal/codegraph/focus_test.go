@@ -0,0 +1,85 @@
+package codegraph
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTwoSubsystems builds a graph where billing is slightly more central
+// than auth, but separate from it
+func newTwoSubsystems(t *testing.T) (*Service, string) {
+	t.Helper()
+	root := t.TempDir()
+	writeFile(t, root, "invoice.py", "from .ledger import post\n\ndef create_invoice():\n    post()\n")
+	writeFile(t, root, "refund.py", "from .ledger import post\n\ndef refund():\n    post()\n")
+	writeFile(t, root, "payout.py", "from .ledger import post\n\ndef payout():\n    post()\n")
+	writeFile(t, root, "ledger.py", "def post():\n    pass\n")
+	writeFile(t, root, "login.py", "from .session import start_session\n\ndef login():\n    start_session()\n")
+	writeFile(t, root, "session.py", "def start_session():\n    pass\n")
+
+	s := NewService()
+	if _, err := s.Update(root); err != nil {
+		t.Fatal(err)
+	}
+	s.CalculatePageRank()
+	return s, root
+}
+
+// mapOrder returns the file names of a repo map, in order
+func mapOrder(repoMap string) []string {
+	var files []string
+	for _, line := range strings.Split(repoMap, "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), `<file path="`); ok {
+			files = append(files, rest[:strings.Index(rest, `"`)])
+		}
+	}
+	return files
+}
+
+func TestGenerateFocusedRepoMap(t *testing.T) {
+	s, root := newTwoSubsystems(t)
+
+	if top := mapOrder(s.GenerateRepoMap(10))[0]; top != "ledger.py" {
+		t.Fatalf("global map starts with %s, want ledger.py", top)
+	}
+
+	// Working on login puts auth first, the file's dependency included
+	focused := s.GenerateFocusedRepoMap(10, Focus{Files: []string{filepath.Join(root, "login.py")}})
+	order := mapOrder(focused)
+	if len(order) != 6 || order[0] != "session.py" && order[0] != "login.py" {
+		t.Errorf("focused map order = %v, want auth files first", order)
+	}
+	if !strings.Contains(focused, `path="login.py" score=`) || !strings.Contains(focused, `focus="true"`) {
+		t.Errorf("focus file not marked:\n%s", focused)
+	}
+
+	// So does searching for it
+	order = mapOrder(s.GenerateFocusedRepoMap(2, Focus{Queries: []string{"where does the session start"}}))
+	if len(order) != 2 || order[0] != "session.py" {
+		t.Errorf("map for a session search = %v", order)
+	}
+
+	// Without a focus the map is the global one
+	if got, want := s.GenerateFocusedRepoMap(10, Focus{Files: []string{"/elsewhere/x.py"}}), s.GenerateRepoMap(10); got != want {
+		t.Errorf("map for an unknown focus differs from the global map:\n%s", got)
+	}
+}
+
+func TestRepoMapZones(t *testing.T) {
+	s, root := newTwoSubsystems(t)
+	s.SetZoneFunc(func(path string) string {
+		if path == filepath.Join(root, "ledger.py") {
+			return "readonly"
+		}
+		return ""
+	})
+
+	repoMap := s.GenerateRepoMap(10)
+	if !strings.Contains(repoMap, `<file path="ledger.py" score=`) || !strings.Contains(repoMap, `zone="readonly">`) {
+		t.Fatalf("ledger.py not annotated:\n%s", repoMap)
+	}
+	if strings.Count(repoMap, "zone=") != 1 {
+		t.Errorf("unzoned files annotated:\n%s", repoMap)
+	}
+}
@@ -7,6 +7,8 @@ const GoQueries = `
 (function_declaration name: (identifier) @def_name)
 (method_declaration name: (field_identifier) @def_name)
 (type_declaration (type_spec name: (type_identifier) @def_name))
+(call_expression function: (identifier) @call_name)
+(call_expression function: (selector_expression field: (field_identifier) @call_name))
 `
 
 const TypescriptQueries = `
@@ -15,4 +17,53 @@ const TypescriptQueries = `
 (class_declaration name: (type_identifier) @def_name)
 (interface_declaration name: (type_identifier) @def_name)
 (variable_declarator name: (identifier) @def_name)
+(method_definition name: (property_identifier) @def_name)
+(call_expression function: (identifier) @call_name)
+(call_expression function: (member_expression property: (property_identifier) @call_name))
+(new_expression constructor: (identifier) @call_name)
+`
+
+const JavascriptQueries = `
+(import_statement source: (string) @import_path)
+(function_declaration name: (identifier) @def_name)
+(class_declaration name: (identifier) @def_name)
+(method_definition name: (property_identifier) @def_name)
+(variable_declarator name: (identifier) @def_name value: [(arrow_function) (function)])
+(call_expression function: (identifier) @call_name)
+(call_expression function: (member_expression property: (property_identifier) @call_name))
+(new_expression constructor: (identifier) @call_name)
+`
+
+const PythonQueries = `
+(import_statement name: (dotted_name) @import_path)
+(import_statement name: (aliased_import name: (dotted_name) @import_path))
+(import_from_statement module_name: (_) @import_path)
+(function_definition name: (identifier) @def_name)
+(class_definition name: (identifier) @def_name)
+(call function: (identifier) @call_name)
+(call function: (attribute attribute: (identifier) @call_name))
+`
+
+const RustQueries = `
+(use_declaration argument: (_) @import_path)
+(mod_item name: (identifier) @import_path !body)
+(function_item name: (identifier) @def_name)
+(struct_item name: (type_identifier) @def_name)
+(enum_item name: (type_identifier) @def_name)
+(trait_item name: (type_identifier) @def_name)
+(type_item name: (type_identifier) @def_name)
+(call_expression function: (identifier) @call_name)
+(call_expression function: (scoped_identifier name: (identifier) @call_name))
+(call_expression function: (field_expression field: (field_identifier) @call_name))
+`
+
+const JavaQueries = `
+(import_declaration [(scoped_identifier) (identifier)] @import_path)
+(class_declaration name: (identifier) @def_name)
+(interface_declaration name: (identifier) @def_name)
+(enum_declaration name: (identifier) @def_name)
+(record_declaration name: (identifier) @def_name)
+(method_declaration name: (identifier) @def_name)
+(method_invocation name: (identifier) @call_name)
+(object_creation_expression type: (type_identifier) @call_name)
 `
@@ -2,14 +2,26 @@ package codegraph
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	ricochetContext "github.com/igoryan-dao/ricochet/internal/context"
 	sitter "github.com/smacker/go-tree-sitter"
 	"github.com/smacker/go-tree-sitter/golang"
+	"github.com/smacker/go-tree-sitter/java"
+	"github.com/smacker/go-tree-sitter/javascript"
+	"github.com/smacker/go-tree-sitter/python"
+	"github.com/smacker/go-tree-sitter/rust"
+	"github.com/smacker/go-tree-sitter/typescript/tsx"
 	"github.com/smacker/go-tree-sitter/typescript/typescript"
 )
 
@@ -18,24 +30,75 @@ type Node struct {
 	Language    string
 	Imports     []string
 	Definitions []string
+	Symbols     []Symbol // Definitions with their lines and the calls they make
 	PageRank    float64
+
+	// Kept in the on-disk cache to skip unchanged files and relinking
+	ModTime time.Time
+	Size    int64
+	Hash    string
+	Links   []string // Files this one imports, once per matching import
 }
 
 type Service struct {
 	nodes map[string]*Node
 	mu    sync.RWMutex
+
+	// Changes since the last CalculatePageRank
+	changed    map[string]bool // Nodes added or re-parsed, whose links are stale
+	added      map[string]bool // New nodes other files may now link to
+	rankStale  bool
+	rankedOnce bool
+
+	zoneOf func(path string) string // Trust zone to annotate a file with, "" for none
 }
 
 func NewService() *Service {
 	return &Service{
-		nodes: make(map[string]*Node),
+		nodes:   make(map[string]*Node),
+		changed: make(map[string]bool),
+		added:   make(map[string]bool),
 	}
 }
 
 func (s *Service) AddFile(path string, content []byte) error {
+	node, err := parseFile(path, content)
+	if err != nil || node == nil {
+		return err
+	}
+	s.mu.Lock()
+	s.putLocked(node)
+	s.mu.Unlock()
+	return nil
+}
+
+// putLocked stores a parsed node and marks the graph for relinking
+func (s *Service) putLocked(node *Node) {
+	if _, ok := s.nodes[node.Path]; !ok {
+		s.added[node.Path] = true
+	}
+	s.nodes[node.Path] = node
+	s.changed[node.Path] = true
+	s.rankStale = true
+}
+
+// removeLocked drops a node and the links to it
+func (s *Service) removeLocked(path string) {
+	delete(s.nodes, path)
+	delete(s.changed, path)
+	delete(s.added, path)
+	for _, node := range s.nodes {
+		node.Links = slices.DeleteFunc(node.Links, func(link string) bool { return link == path })
+	}
+	s.rankStale = true
+}
+
+// parseFile extracts a file's imports and definitions; nil for unsupported
+// languages
+func parseFile(path string, content []byte) (*Node, error) {
 	lang, queryStr := detectLanguage(path)
 	if lang == nil {
-		return nil // Unsupported language, ignore
+		return nil, nil // Unsupported language, ignore
 	}
 
 	// Parser instance (not thread safe, so new per file)
@@ -44,7 +107,7 @@ func (s *Service) AddFile(path string, content []byte) error {
 
 	tree, err := parser.ParseCtx(context.Background(), nil, content)
 	if err != nil {
-		return fmt.Errorf("parsing failed: %w", err)
+		return nil, fmt.Errorf("parsing failed: %w", err)
 	}
 	defer tree.Close()
 
@@ -52,7 +115,7 @@ func (s *Service) AddFile(path string, content []byte) error {
 
 	q, err := sitter.NewQuery([]byte(queryStr), lang)
 	if err != nil {
-		return fmt.Errorf("query creation failed: %w", err)
+		return nil, fmt.Errorf("query creation failed: %w", err)
 	}
 	defer q.Close()
 
@@ -68,6 +131,8 @@ func (s *Service) AddFile(path string, content []byte) error {
 
 	uniqueImports := make(map[string]bool)
 	uniqueDefs := make(map[string]bool)
+	var spans []symbolSpan
+	var calls []callSite
 
 	for {
 		m, ok := qc.NextMatch()
@@ -79,11 +144,11 @@ func (s *Service) AddFile(path string, content []byte) error {
 			name := q.CaptureNameForId(c.Index)
 			text := c.Node.Content(content)
 
-			// Clean up quotes for imports
+			// Clean up quotes and module syntax for imports
 			switch name {
 			case "import_path":
-				text = strings.Trim(text, "\"`")
-				if !uniqueImports[text] {
+				text = ricochetContext.NormalizeImport(filepath.Ext(path), text)
+				if text != "" && !uniqueImports[text] {
 					node.Imports = append(node.Imports, text)
 					uniqueImports[text] = true
 				}
@@ -92,15 +157,24 @@ func (s *Service) AddFile(path string, content []byte) error {
 					node.Definitions = append(node.Definitions, text)
 					uniqueDefs[text] = true
 				}
+				// The declaration around the name spans the body
+				decl := c.Node.Parent()
+				if decl == nil {
+					decl = c.Node
+				}
+				spans = append(spans, symbolSpan{
+					Symbol: Symbol{Name: text, Line: int(decl.StartPoint().Row) + 1, EndLine: int(decl.EndPoint().Row) + 1},
+					start:  decl.StartByte(),
+					end:    decl.EndByte(),
+				})
+			case "call_name":
+				calls = append(calls, callSite{name: text, at: c.Node.StartByte()})
 			}
 		}
 	}
+	node.Symbols = attributeCalls(spans, calls)
 
-	s.mu.Lock()
-	s.nodes[path] = node
-	s.mu.Unlock()
-
-	return nil
+	return node, nil
 }
 
 func (s *Service) GetNode(path string) *Node {
@@ -119,13 +193,34 @@ func (s *Service) GetAllFiles() []string {
 	return files
 }
 
+// Rebuild parses every file of root from scratch
 func (s *Service) Rebuild(root string) error {
 	s.mu.Lock()
-	// Clear existing? Or update? For now, simple clear.
 	s.nodes = make(map[string]*Node)
+	s.changed = make(map[string]bool)
+	s.added = make(map[string]bool)
+	s.rankStale = true
 	s.mu.Unlock()
 
-	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+	_, err := s.Update(root)
+	return err
+}
+
+// UpdateStats reports what an Update had to redo
+type UpdateStats struct {
+	Files   int // Supported files in the workspace
+	Parsed  int // Files new or changed since the graph was last built
+	Removed int // Files deleted since
+}
+
+// Update brings the graph in line with root, re-parsing only the files
+// whose size and modification time changed, and whose content hash then
+// differs. With a graph from LoadCache, a restart parses only what changed.
+func (s *Service) Update(root string) (UpdateStats, error) {
+	var stats UpdateStats
+	seen := make(map[string]bool)
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -136,8 +231,16 @@ func (s *Service) Rebuild(root string) error {
 			return nil
 		}
 
-		ext := filepath.Ext(path)
-		if ext != ".go" && ext != ".ts" && ext != ".tsx" {
+		if lang, _ := detectLanguage(path); lang == nil {
+			return nil
+		}
+		seen[path] = true
+		stats.Files++
+
+		s.mu.RLock()
+		cached := s.nodes[path]
+		s.mu.RUnlock()
+		if cached != nil && cached.ModTime.Equal(info.ModTime()) && cached.Size == info.Size() {
 			return nil
 		}
 
@@ -146,9 +249,43 @@ func (s *Service) Rebuild(root string) error {
 		if err != nil {
 			return nil // Skip unreadable
 		}
+		sum := sha256.Sum256(content)
+		hash := hex.EncodeToString(sum[:])
+		if cached != nil && cached.Hash == hash {
+			// Touched but not changed
+			s.mu.Lock()
+			cached.ModTime, cached.Size = info.ModTime(), info.Size()
+			s.mu.Unlock()
+			return nil
+		}
 
-		return s.AddFile(path, content)
+		node, err := parseFile(path, content)
+		if err != nil || node == nil {
+			return err
+		}
+		node.ModTime, node.Size, node.Hash = info.ModTime(), info.Size(), hash
+		if cached != nil {
+			node.PageRank = cached.PageRank // Warm start for the next ranking
+		}
+		s.mu.Lock()
+		s.putLocked(node)
+		s.mu.Unlock()
+		stats.Parsed++
+		return nil
 	})
+	if err != nil {
+		return stats, err
+	}
+
+	s.mu.Lock()
+	for path := range s.nodes {
+		if !seen[path] {
+			s.removeLocked(path)
+			stats.Removed++
+		}
+	}
+	s.mu.Unlock()
+	return stats, nil
 }
 
 // FindReverseDependencies returns files that import the given path
@@ -218,51 +355,36 @@ func (s *Service) GetContext(path string) (*Node, []*Node) {
 
 // CalculatePageRank computes the importance of each file.
 // Algorithm: PR(A) = (1-d) + d * Sum(PR(T)/C(T)) where T links to A
-// Here, "Link" means T imports A. So we need reverse dependencies.
+// Here, "Link" means T imports A. Only the links of files changed since the
+// last run are resolved again, and iteration starts from the previous
+// scores, so after a small change it converges in a few rounds. Without
+// changes the scores are kept as they are.
 func (s *Service) CalculatePageRank() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if !s.rankStale && s.rankedOnce {
+		return
+	}
+	s.relinkLocked()
+
 	damping := 0.85
-	iterations := 20
 	// Base score implicit in formula (1-d)
 
 	// 1. Build Adjacency Graph (Nodes importing X)
 	// Map: ImportedFile -> []ImporterFiles
 	graph := make(map[string][]string)
-
 	for importerPath, node := range s.nodes {
-		node.PageRank = 1.0 // Reset
-
-		for _, imp := range node.Imports {
-			// Resolve import to file path
-			// Simplified resolution logic similar to GetContext
-			baseImp := filepath.Base(imp)
-
-			for candidatePath := range s.nodes {
-				// Exact match logic + fuzzy logic
-				// If import is "github.com/foo/bar/baz", candidate "/.../baz.go" matches base.
-				// This is heuristic.
-
-				// 1. Check if candidate *is* the import (local relative)
-				// 2. Check if candidate *package* matches import
-
-				if strings.HasSuffix(candidatePath, imp) || strings.HasSuffix(candidatePath, imp+".go") || strings.HasSuffix(candidatePath, imp+".ts") {
-					graph[candidatePath] = append(graph[candidatePath], importerPath)
-				} else {
-					// Fallback: match by filename base if import looks like local file
-					candBase := filepath.Base(candidatePath)
-					candName := strings.TrimSuffix(candBase, filepath.Ext(candBase))
-					if candName == baseImp {
-						graph[candidatePath] = append(graph[candidatePath], importerPath)
-					}
-				}
-			}
+		if !s.rankedOnce || node.PageRank == 0 {
+			node.PageRank = 1.0 // Cold start
+		}
+		for _, target := range node.Links {
+			graph[target] = append(graph[target], importerPath)
 		}
 	}
 
-	// 2. Iterate
-	for i := 0; i < iterations; i++ {
+	// 2. Iterate until the scores settle
+	for i := 0; i < maxRankIterations; i++ {
 		newScores := make(map[string]float64)
 
 		for path := range s.nodes {
@@ -283,10 +405,75 @@ func (s *Service) CalculatePageRank() {
 		}
 
 		// Update scores
+		delta := 0.0
 		for path, score := range newScores {
+			delta = math.Max(delta, math.Abs(score-s.nodes[path].PageRank))
 			s.nodes[path].PageRank = score
 		}
+		if delta < rankTolerance {
+			break
+		}
+	}
+	s.rankStale = false
+	s.rankedOnce = true
+}
+
+const (
+	maxRankIterations = 50
+	rankTolerance     = 1e-6
+)
+
+// relinkLocked resolves the imports of changed files, and links existing
+// files to new ones they import
+func (s *Service) relinkLocked() {
+	for path := range s.changed {
+		node := s.nodes[path]
+		node.Links = nil
+		for _, imp := range node.Imports {
+			for candidatePath := range s.nodes {
+				if importMatches(candidatePath, imp) {
+					node.Links = append(node.Links, candidatePath)
+				}
+			}
+		}
+	}
+	for candidatePath := range s.added {
+		for importerPath, node := range s.nodes {
+			if s.changed[importerPath] {
+				continue // Already resolved against every file
+			}
+			for _, imp := range node.Imports {
+				if importMatches(candidatePath, imp) {
+					node.Links = append(node.Links, candidatePath)
+				}
+			}
+		}
+	}
+	s.changed = make(map[string]bool)
+	s.added = make(map[string]bool)
+}
+
+// importMatches reports whether an import resolves to a file. This is
+// heuristic: if import is "github.com/foo/bar/baz", candidate "/.../baz.go"
+// matches by base name.
+func importMatches(candidatePath, imp string) bool {
+	// 1. Check if candidate *is* the import (local relative)
+	candNoExt := strings.TrimSuffix(candidatePath, filepath.Ext(candidatePath))
+	if strings.HasSuffix(candidatePath, imp) || strings.HasSuffix(candNoExt, imp) {
+		return true
 	}
+	// 2. Fallback: match by filename base if import looks like local file
+	candBase := filepath.Base(candidatePath)
+	candName := strings.TrimSuffix(candBase, filepath.Ext(candBase))
+	return candName == filepath.Base(imp)
+}
+
+// SetZoneFunc makes the repo map mark each file with the trust zone zoneOf
+// names for it, so the model knows where it may write
+func (s *Service) SetZoneFunc(zoneOf func(path string) string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.zoneOf = zoneOf
 }
 
 // GenerateRepoMap returns a formatted string of the most important files.
@@ -295,6 +482,16 @@ func (s *Service) GenerateRepoMap(maxFiles int) string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	scores := make(map[string]float64, len(s.nodes))
+	for path, node := range s.nodes {
+		scores[path] = node.PageRank
+	}
+	return s.renderRepoMapLocked(maxFiles, scores, nil)
+}
+
+// renderRepoMapLocked lists the maxFiles best scored files with their
+// definitions; focused files are marked as such
+func (s *Service) renderRepoMapLocked(maxFiles int, scores map[string]float64, focused map[string]bool) string {
 	type RankedNode struct {
 		Path  string
 		Score float64
@@ -305,20 +502,18 @@ func (s *Service) GenerateRepoMap(maxFiles int) string {
 	for path, node := range s.nodes {
 		ranked = append(ranked, RankedNode{
 			Path:  path,
-			Score: node.PageRank,
+			Score: scores[path],
 			Defs:  node.Definitions,
 		})
 	}
 
 	// Sort by Score descending
-	// Simple bubble sort for now, or could use sort package
-	for i := 0; i < len(ranked)-1; i++ {
-		for j := 0; j < len(ranked)-i-1; j++ {
-			if ranked[j].Score < ranked[j+1].Score {
-				ranked[j], ranked[j+1] = ranked[j+1], ranked[j]
-			}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
 		}
-	}
+		return ranked[i].Path < ranked[j].Path
+	})
 
 	if maxFiles > len(ranked) {
 		maxFiles = len(ranked)
@@ -329,13 +524,17 @@ func (s *Service) GenerateRepoMap(maxFiles int) string {
 
 	for i := 0; i < maxFiles; i++ {
 		node := ranked[i]
-		// Shorten path relative to CWD if possible?
-		// We stored absolute paths usually.
-		rel := node.Path
-		// Assuming we don't have CWD here effectively, just output the stored path.
-		// Or assume stored path is already useful.
-
-		sb.WriteString(fmt.Sprintf("  <file path=\"%s\" score=\"%.2f\">\n", filepath.Base(rel), node.Score))
+		// Stored paths are absolute; the base name keeps the map compact
+		focus := ""
+		if focused[node.Path] {
+			focus = ` focus="true"`
+		}
+		if s.zoneOf != nil {
+			if zone := s.zoneOf(node.Path); zone != "" {
+				focus += fmt.Sprintf(` zone="%s"`, zone)
+			}
+		}
+		sb.WriteString(fmt.Sprintf("  <file path=\"%s\" score=\"%.2f\"%s>\n", filepath.Base(node.Path), node.Score, focus))
 		for _, def := range node.Defs {
 			sb.WriteString(fmt.Sprintf("    <def>%s</def>\n", def))
 		}
@@ -351,8 +550,18 @@ func detectLanguage(path string) (*sitter.Language, string) {
 	switch ext {
 	case ".go":
 		return golang.GetLanguage(), GoQueries
-	case ".ts", ".tsx":
+	case ".ts", ".mts", ".cts":
 		return typescript.GetLanguage(), TypescriptQueries
+	case ".tsx":
+		return tsx.GetLanguage(), TypescriptQueries
+	case ".js", ".jsx", ".mjs", ".cjs":
+		return javascript.GetLanguage(), JavascriptQueries
+	case ".py":
+		return python.GetLanguage(), PythonQueries
+	case ".rs":
+		return rust.GetLanguage(), RustQueries
+	case ".java":
+		return java.GetLanguage(), JavaQueries
 	default:
 		return nil, ""
 	}
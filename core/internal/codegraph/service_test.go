@@ -1,6 +1,7 @@
 package codegraph
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -108,3 +109,53 @@ func TestAddFile_TS(t *testing.T) {
 		t.Errorf("Missing definitions. Found: %v", node.Definitions)
 	}
 }
+
+func TestAddFile_Polyglot(t *testing.T) {
+	files := map[string]struct {
+		code    string
+		imports []string
+		defs    []string
+	}{
+		"app/main.py": {
+			code:    "import os\nfrom .models import User\n\nclass Service:\n    def run(self):\n        pass\n",
+			imports: []string{"os", "./models"},
+			defs:    []string{"Service", "run"},
+		},
+		"src/lib.rs": {
+			code:    "mod config;\nuse crate::store::Cache;\n\npub struct Engine {}\n\nimpl Engine {\n    fn start(&self) {}\n}\n",
+			imports: []string{"config", "store"},
+			defs:    []string{"Engine", "start"},
+		},
+		"src/App.java": {
+			code:    "package com.acme;\n\nimport com.acme.util.Strings;\n\npublic class App {\n    void run() {}\n}\n",
+			imports: []string{"com/acme/util/Strings"},
+			defs:    []string{"App", "run"},
+		},
+		"web/view.tsx": {
+			code:    "import { api } from './api';\n\nexport const View = () => <div />;\n",
+			imports: []string{"./api"},
+			defs:    []string{"View"},
+		},
+	}
+
+	s := NewService()
+	for path, f := range files {
+		if err := s.AddFile(path, []byte(f.code)); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		node := s.GetNode(path)
+		if node == nil {
+			t.Fatalf("%s: node not found", path)
+		}
+		for _, want := range f.imports {
+			if !slices.Contains(node.Imports, want) {
+				t.Errorf("%s: missing import %q in %v", path, want, node.Imports)
+			}
+		}
+		for _, want := range f.defs {
+			if !slices.Contains(node.Definitions, want) {
+				t.Errorf("%s: missing definition %q in %v", path, want, node.Definitions)
+			}
+		}
+	}
+}
@@ -1,7 +1,13 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -16,3 +22,222 @@ func TestToolsSettings_JSON(t *testing.T) {
 		t.Error("Expected DisableLLMCorrection to be true")
 	}
 }
+
+func TestMigrateSettings_V1ToV2(t *testing.T) {
+	raw := map[string]interface{}{}
+	jsonStr := `{"provider": {"provider": "openai", "api_key": "sk-test"}, "context": {"condense_threshold": 0}}`
+	if err := json.Unmarshal([]byte(jsonStr), &raw); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	report, err := MigrateSettings(raw)
+	if err != nil {
+		t.Fatalf("MigrateSettings failed: %v", err)
+	}
+	if report.FromVersion != 1 || report.ToVersion != CurrentSchemaVersion {
+		t.Errorf("Expected v1→v%d, got v%d→v%d", CurrentSchemaVersion, report.FromVersion, report.ToVersion)
+	}
+	if len(report.Changes) == 0 {
+		t.Error("Expected changes to be reported")
+	}
+
+	keys := raw["provider"].(map[string]interface{})["api_keys"].(map[string]interface{})
+	if keys["openai"] != "sk-test" {
+		t.Errorf("Expected legacy key copied into api_keys, got %v", keys)
+	}
+	ctxSettings := raw["context"].(map[string]interface{})
+	if ctxSettings["condense_threshold"] != float64(0) {
+		t.Errorf("Expected the explicit condense_threshold 0 kept, got %v", ctxSettings["condense_threshold"])
+	}
+	if ctxSettings["sliding_window_size"] != float64(20) {
+		t.Errorf("Expected the missing sliding_window_size filled, got %v", ctxSettings["sliding_window_size"])
+	}
+	if SchemaVersionOf(raw) != CurrentSchemaVersion {
+		t.Errorf("Expected schema_version %d, got %d", CurrentSchemaVersion, SchemaVersionOf(raw))
+	}
+
+	// Second run is a no-op
+	again, err := MigrateSettings(raw)
+	if err != nil || again.NeedsMigration() || len(again.Changes) != 0 {
+		t.Errorf("Expected idempotent migration, got %+v, %v", again, err)
+	}
+}
+
+func TestMigrateSettings_NewerSchema(t *testing.T) {
+	raw := map[string]interface{}{"schema_version": float64(CurrentSchemaVersion + 1)}
+	if _, err := MigrateSettings(raw); err == nil {
+		t.Error("Expected error for settings from a newer build")
+	}
+}
+
+func TestStore_EnvSecretsBackend(t *testing.T) {
+	t.Setenv("RICOCHET_OPENAI_KEY", "sk-from-env")
+
+	path := filepath.Join(t.TempDir(), "settings.json")
+	jsonStr := `{"schema_version": 2, "secrets": {"backend": "env"}, "provider": {"provider": "openai", "api_key": "sk-from-env"}}`
+	if err := os.WriteFile(path, []byte(jsonStr), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("OpenStore failed: %v", err)
+	}
+	if got := store.Get().Provider.APIKey; got != "sk-from-env" {
+		t.Errorf("Expected key resolved from env, got %q", got)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "sk-from-env") {
+		t.Error("Expected env-provided key to be removed from settings.json")
+	}
+}
+
+func TestMergeCatalog(t *testing.T) {
+	price := 1.5
+	base := &ProvidersConfig{
+		Providers: map[string]ProviderConfig{
+			"openai": {Enabled: true, Models: []ModelConfig{{ID: "gpt-4o", InputPrice: 2.5}}},
+		},
+		Catalog: CatalogConfig{AddNewModels: true},
+	}
+	catalog := &ModelCatalog{Models: []CatalogModel{
+		{Provider: "openai", ID: "gpt-4o", InputPrice: &price, Deprecated: true},
+		{Provider: "openai", ID: "gpt-5", ContextWindow: 400000},
+	}}
+
+	merged := mergeCatalog(base, catalog)
+	models := merged.Providers["openai"].Models
+	if len(models) != 2 {
+		t.Fatalf("Expected 2 models after merge, got %d", len(models))
+	}
+	if models[0].InputPrice != 1.5 || !models[0].Deprecated {
+		t.Errorf("Expected catalog price and deprecation applied, got %+v", models[0])
+	}
+	if models[1].ID != "gpt-5" || models[1].ContextWindow != 400000 {
+		t.Errorf("Expected new catalog model appended, got %+v", models[1])
+	}
+	if base.Providers["openai"].Models[0].InputPrice != 2.5 {
+		t.Error("mergeCatalog must not mutate the base config")
+	}
+}
+
+func TestProjectOverlay_Apply(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, ".ricochet"), 0755)
+	yamlData := "provider:\n  model: local-model\n  api_key: leaked\nindex:\n  ignore: [\"vendor/\"]\ntheme: light\n"
+	if err := os.WriteFile(filepath.Join(root, ProjectConfigFile), []byte(yamlData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	overlay, err := LoadProjectOverlay(root)
+	if err != nil {
+		t.Fatalf("LoadProjectOverlay failed: %v", err)
+	}
+
+	base := Settings{Theme: "dark", Provider: ProviderSettings{Provider: "anthropic", Model: "global-model", APIKey: "sk-global"}}
+	merged := overlay.Apply(base)
+
+	if merged.Provider.Model != "local-model" || merged.Provider.APIKey != "sk-global" {
+		t.Errorf("unexpected provider: %+v", merged.Provider)
+	}
+	if merged.Theme != "dark" {
+		t.Errorf("theme must not be overridable per project, got %q", merged.Theme)
+	}
+	if len(merged.Index.Ignore) != 1 || base.Index.Ignore != nil {
+		t.Errorf("index.ignore not applied or leaked into base: %v / %v", merged.Index.Ignore, base.Index.Ignore)
+	}
+
+	origins := overlay.Origins()
+	if origins["provider.model"] != OriginProject || origins["index.ignore"] != OriginProject || len(origins) != 2 {
+		t.Errorf("unexpected origins: %v", origins)
+	}
+}
+
+func TestRefreshLocalModels(t *testing.T) {
+	// LM Studio has no /api/tags, only the OpenAI-compatible model list
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"qwen2.5-coder-7b-instruct"}]}`))
+	}))
+	defer srv.Close()
+
+	pm := &ProvidersManager{userKeys: map[string]string{}}
+	pm.base = &ProvidersConfig{Providers: map[string]ProviderConfig{
+		LocalProviderID: {Enabled: true, BaseURL: srv.URL + "/v1"},
+	}}
+	pm.config = pm.effectiveConfig()
+
+	pm.RefreshLocalModels(context.Background())
+
+	providers := pm.GetAvailableProviders()
+	if len(providers) != 1 || !providers[0].Available {
+		t.Fatalf("local provider not available: %+v", providers)
+	}
+	models := providers[0].Models
+	if len(models) != 1 || models[0].ID != "qwen2.5-coder-7b-instruct" || !models[0].IsFree {
+		t.Errorf("unexpected models: %+v", models)
+	}
+	if len(pm.base.Providers[LocalProviderID].Models) != 0 {
+		t.Error("discovered models leaked into the base config")
+	}
+}
+
+func TestRefreshOpenRouterModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"data":[
+			{"id":"anthropic/claude-sonnet-4","name":"Claude Sonnet 4","context_length":200000,
+			 "pricing":{"prompt":"0.000003","completion":"0.000015"},"supported_parameters":["tools","temperature"]},
+			{"id":"meta-llama/llama-3.3-70b-instruct:free","context_length":131072,
+			 "pricing":{"prompt":"0","completion":"0"}},
+			{"id":"openrouter/auto","pricing":{"prompt":"-1","completion":"-1"}}]}`))
+	}))
+	defer srv.Close()
+
+	pm := &ProvidersManager{userKeys: map[string]string{}}
+	pm.base = &ProvidersConfig{Providers: map[string]ProviderConfig{
+		OpenRouterProviderID: {Enabled: true, Key: "sk-or", BaseURL: srv.URL + "/api/v1/"},
+	}}
+	pm.config = pm.effectiveConfig()
+
+	pm.RefreshOpenRouterModels(context.Background())
+
+	models := pm.GetAvailableProviders()[0].Models
+	if len(models) != 3 {
+		t.Fatalf("expected 3 models, got %+v", models)
+	}
+	byID := make(map[string]AvailableModel)
+	for _, m := range models {
+		byID[m.ID] = m
+	}
+	sonnet := byID["anthropic/claude-sonnet-4"]
+	if sonnet.InputPrice != 3 || sonnet.OutputPrice != 15 || !sonnet.SupportsTools || sonnet.IsFree {
+		t.Errorf("unexpected sonnet entry: %+v", sonnet)
+	}
+	if llama := byID["meta-llama/llama-3.3-70b-instruct:free"]; !llama.IsFree || llama.SupportsTools || llama.Name != llama.ID {
+		t.Errorf("unexpected free model entry: %+v", llama)
+	}
+	if auto := byID["openrouter/auto"]; auto.IsFree || auto.InputPrice != 0 {
+		t.Errorf("router model should have unknown, non-free pricing: %+v", auto)
+	}
+}
+
+func TestSecurityCommandLine(t *testing.T) {
+	line, err := securityCommandLine("add-generic-password", "-w", `p"a\ss word`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != `"add-generic-password" "-w" "p\"a\\ss word"`+"\n" {
+		t.Errorf("line = %q", line)
+	}
+	if _, err := securityCommandLine("-w", "two\nlines"); err == nil {
+		t.Error("a line break was accepted")
+	}
+}
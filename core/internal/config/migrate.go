@@ -0,0 +1,157 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CurrentSchemaVersion is the settings.json schema this build reads and writes.
+// Files without a schema_version are treated as version 1.
+const CurrentSchemaVersion = 2
+
+// Migration upgrades raw settings from version From to From+1.
+// Apply mutates raw in place and returns a human-readable line per change.
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(raw map[string]interface{}) []string
+}
+
+// MigrationReport describes what a migration run changed (or would change)
+type MigrationReport struct {
+	FromVersion int      `json:"from_version"`
+	ToVersion   int      `json:"to_version"`
+	Changes     []string `json:"changes"`
+}
+
+// NeedsMigration reports whether the file was on an older schema
+func (r *MigrationReport) NeedsMigration() bool {
+	return r.FromVersion < r.ToVersion
+}
+
+// migrations is the ordered v1→v2→… pipeline. Append only; never edit a released step.
+var migrations = []Migration{
+	{
+		From:        1,
+		Description: "per-provider API keys and context defaults",
+		Apply:       migrateV1ToV2,
+	},
+}
+
+// SchemaVersionOf returns the schema_version of raw settings, defaulting to 1
+func SchemaVersionOf(raw map[string]interface{}) int {
+	switch v := raw["schema_version"].(type) {
+	case float64: // decoded from JSON
+		if v >= 1 {
+			return int(v)
+		}
+	case int: // stamped by MigrateSettings
+		if v >= 1 {
+			return v
+		}
+	}
+	return 1
+}
+
+// MigrateSettings runs every pending migration on raw and stamps the new schema_version.
+// Files from a newer build are left untouched and reported with an error.
+func MigrateSettings(raw map[string]interface{}) (*MigrationReport, error) {
+	from := SchemaVersionOf(raw)
+	report := &MigrationReport{FromVersion: from, ToVersion: CurrentSchemaVersion}
+
+	if from > CurrentSchemaVersion {
+		report.ToVersion = from
+		return report, fmt.Errorf("settings schema v%d is newer than supported v%d", from, CurrentSchemaVersion)
+	}
+
+	version := from
+	for _, m := range migrations {
+		if m.From != version {
+			continue
+		}
+		for _, change := range m.Apply(raw) {
+			report.Changes = append(report.Changes, fmt.Sprintf("v%d→v%d: %s", m.From, m.From+1, change))
+		}
+		version = m.From + 1
+	}
+
+	if version != CurrentSchemaVersion {
+		return report, fmt.Errorf("no migration path from settings v%d to v%d", version, CurrentSchemaVersion)
+	}
+
+	if from != CurrentSchemaVersion {
+		raw["schema_version"] = CurrentSchemaVersion
+		report.Changes = append(report.Changes, fmt.Sprintf("set schema_version to %d", CurrentSchemaVersion))
+	}
+	return report, nil
+}
+
+// PlanMigration reports what migrating the settings file at path would change without writing it
+func PlanMigration(path string) (*MigrationReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return MigrateSettings(raw)
+}
+
+// DefaultSettingsPath returns ~/.ricochet/settings.json
+func DefaultSettingsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home dir: %w", err)
+	}
+	return filepath.Join(homeDir, ".ricochet", "settings.json"), nil
+}
+
+// migrateV1ToV2 copies the legacy single api_key into api_keys and fills
+// context fields that older builds did not write. A value the user set, even
+// 0, is kept.
+func migrateV1ToV2(raw map[string]interface{}) []string {
+	var changes []string
+
+	if provider, ok := raw["provider"].(map[string]interface{}); ok {
+		name, _ := provider["provider"].(string)
+		key, _ := provider["api_key"].(string)
+		if name != "" && key != "" {
+			keys, _ := provider["api_keys"].(map[string]interface{})
+			if keys == nil {
+				keys = make(map[string]interface{})
+			}
+			if existing, _ := keys[name].(string); existing == "" {
+				keys[name] = key
+				provider["api_keys"] = keys
+				changes = append(changes, fmt.Sprintf("copy provider.api_key into provider.api_keys[%q]", name))
+			}
+		}
+	}
+
+	ctxSettings, ok := raw["context"].(map[string]interface{})
+	if !ok {
+		ctxSettings = make(map[string]interface{})
+		raw["context"] = ctxSettings
+	}
+	defaults := []struct {
+		key   string
+		value float64
+	}{
+		{"condense_threshold", 70},
+		{"sliding_window_size", 20},
+	}
+	for _, d := range defaults {
+		if _, set := ctxSettings[d.key]; !set {
+			ctxSettings[d.key] = d.value
+			changes = append(changes, fmt.Sprintf("set context.%s to %v", d.key, d.value))
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectConfigFile is the per-workspace overlay merged over ~/.ricochet/settings.json
+const ProjectConfigFile = ".ricochet/config.yaml"
+
+// Setting origins reported by Origins
+const (
+	OriginGlobal  = "global"
+	OriginProject = "project"
+)
+
+// projectOverlayKeys are the settings a project may override.
+// API keys and tokens are deliberately excluded: config.yaml is meant to be committed.
+var projectOverlayKeys = map[string][]string{
+	"provider":      {"provider", "model", "embedding_provider", "embedding_model", "routing"},
+	"auto_approval": nil, // nil = every field
+	"context":       nil,
+	"checkpoints":   nil,
+	"index":         nil,
+	"tools":         {"databases", "verification"},
+}
+
+// ProjectOverlay is a parsed .ricochet/config.yaml
+type ProjectOverlay struct {
+	Path   string
+	values map[string]interface{} // JSON-shaped subset of Settings
+}
+
+// LoadProjectOverlay reads <workspaceRoot>/.ricochet/config.yaml.
+// Returns nil without error when the file does not exist.
+func LoadProjectOverlay(workspaceRoot string) (*ProjectOverlay, error) {
+	path := filepath.Join(workspaceRoot, ProjectConfigFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	values := make(map[string]interface{})
+	for section, v := range raw {
+		allowed, ok := projectOverlayKeys[section]
+		if !ok {
+			log.Printf("Warning: %s: %q cannot be set per project, ignoring", ProjectConfigFile, section)
+			continue
+		}
+		fields, isMap := v.(map[string]interface{})
+		if !isMap {
+			log.Printf("Warning: %s: %q must be a mapping, ignoring", ProjectConfigFile, section)
+			continue
+		}
+		if allowed == nil {
+			values[section] = fields
+			continue
+		}
+		kept := make(map[string]interface{})
+		for k, fv := range fields {
+			if containsString(allowed, k) {
+				kept[k] = fv
+			} else {
+				log.Printf("Warning: %s: %s.%s cannot be set per project, ignoring", ProjectConfigFile, section, k)
+			}
+		}
+		values[section] = kept
+	}
+
+	// The vector store API key may only reference the environment, e.g. "$QDRANT_API_KEY"
+	if ctx, ok := values["context"].(map[string]interface{}); ok {
+		if vs, ok := ctx["vector_store"].(map[string]interface{}); ok {
+			if key, ok := vs["api_key"].(string); ok && !strings.HasPrefix(key, "$") {
+				log.Printf("Warning: %s: context.vector_store.api_key cannot be set per project, use an environment variable like \"$QDRANT_API_KEY\"", ProjectConfigFile)
+				delete(vs, "api_key")
+			}
+		}
+	}
+
+	return &ProjectOverlay{Path: path, values: values}, nil
+}
+
+// Apply returns base with the overlay merged on top
+func (p *ProjectOverlay) Apply(base Settings) Settings {
+	if p == nil || len(p.values) == 0 {
+		return base
+	}
+
+	// Deep-copy base first so slices and maps are never shared with the global settings
+	baseData, err := json.Marshal(base)
+	if err != nil {
+		return base
+	}
+	var merged Settings
+	if err := json.Unmarshal(baseData, &merged); err != nil {
+		return base
+	}
+
+	// Decoding JSON into a populated struct only overwrites the fields present
+	data, err := json.Marshal(p.values)
+	if err != nil {
+		return base
+	}
+	if err := json.Unmarshal(data, &merged); err != nil {
+		log.Printf("Warning: %s: %v", ProjectConfigFile, err)
+		return base
+	}
+
+	// The legacy single key belongs to the global provider; switch to the project provider's key
+	if merged.Provider.Provider != base.Provider.Provider {
+		merged.Provider.APIKey = merged.Provider.APIKeys[merged.Provider.Provider]
+	}
+	return merged
+}
+
+// Keys lists the dotted setting keys the overlay sets (e.g. "provider.model"), sorted
+func (p *ProjectOverlay) Keys() []string {
+	if p == nil {
+		return nil
+	}
+	var keys []string
+	for section, v := range p.values {
+		for k := range v.(map[string]interface{}) {
+			keys = append(keys, section+"."+k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Origins maps each overridden dotted key to OriginProject; absent keys are global
+func (p *ProjectOverlay) Origins() map[string]string {
+	origins := make(map[string]string)
+	for _, k := range p.Keys() {
+		origins[k] = OriginProject
+	}
+	return origins
+}
+
+// restoreOverriddenKeys undoes edits to global settings that merely echo the
+// project overlay back, so saving effective settings from the UI doesn't leak
+// project values into ~/.ricochet/settings.json.
+func (p *ProjectOverlay) restoreOverriddenKeys(before, after *Settings) {
+	if p == nil {
+		return
+	}
+	beforeMap := toJSONMap(before)
+	afterMap := toJSONMap(after)
+
+	changed := false
+	for _, key := range p.Keys() {
+		section, field, _ := strings.Cut(key, ".")
+		overlayVal := p.values[section].(map[string]interface{})[field]
+		prev := lookupJSONMap(beforeMap, section, field)
+		cur := lookupJSONMap(afterMap, section, field)
+		if !reflect.DeepEqual(prev, cur) && jsonEqual(cur, overlayVal) {
+			if m, ok := afterMap[section].(map[string]interface{}); ok {
+				m[field] = prev
+				changed = true
+			}
+		}
+	}
+
+	if changed {
+		data, _ := json.Marshal(afterMap)
+		var restored Settings
+		if json.Unmarshal(data, &restored) == nil {
+			restored.Provider.APIKeys = after.Provider.APIKeys
+			*after = restored
+		}
+	}
+}
+
+func toJSONMap(s *Settings) map[string]interface{} {
+	data, _ := json.Marshal(s)
+	var m map[string]interface{}
+	json.Unmarshal(data, &m)
+	return m
+}
+
+func lookupJSONMap(m map[string]interface{}, section, field string) interface{} {
+	if sm, ok := m[section].(map[string]interface{}); ok {
+		return sm[field]
+	}
+	return nil
+}
+
+// jsonEqual compares values after a JSON round-trip, so YAML ints match JSON float64s
+func jsonEqual(a, b interface{}) bool {
+	da, errA := json.Marshal(a)
+	db, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	var va, vb interface{}
+	json.Unmarshal(da, &va)
+	json.Unmarshal(db, &vb)
+	return reflect.DeepEqual(va, vb)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
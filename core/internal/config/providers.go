@@ -2,9 +2,13 @@ package config
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -15,25 +19,33 @@ type ProvidersConfig struct {
 	DefaultProvider string                    `yaml:"default_provider"`
 	DefaultModel    string                    `yaml:"default_model"`
 	BYOK            BYOKConfig                `yaml:"byok"`
+	Catalog         CatalogConfig             `yaml:"catalog"`
 }
 
 // ProviderConfig defines a single provider's server configuration
 type ProviderConfig struct {
-	Enabled bool          `yaml:"enabled"`
-	Key     string        `yaml:"key"`      // Can be ${ENV_VAR} reference
-	BaseURL string        `yaml:"base_url"` // Optional custom endpoint
-	Models  []ModelConfig `yaml:"models"`
+	Enabled    bool          `yaml:"enabled"`
+	Key        string        `yaml:"key"`         // Can be ${ENV_VAR} reference
+	BaseURL    string        `yaml:"base_url"`    // Optional custom endpoint (Azure: resource endpoint)
+	APIVersion string        `yaml:"api_version"` // Azure OpenAI api-version
+	Region     string        `yaml:"region"`      // AWS Bedrock region
+	RPM        int           `yaml:"rpm"`         // Requests per minute (0 = unlimited)
+	TPM        int           `yaml:"tpm"`         // Tokens per minute (0 = unlimited)
+	Models     []ModelConfig `yaml:"models"`
 }
 
 // ModelConfig defines a model available from the provider
 type ModelConfig struct {
-	ID            string  `yaml:"id"`
-	Name          string  `yaml:"name"`
-	ContextWindow int     `yaml:"context_window"`
-	InputPrice    float64 `yaml:"input_price"`
-	OutputPrice   float64 `yaml:"output_price"`
-	IsFree        bool    `yaml:"free"`
-	SupportsTools bool    `yaml:"supports_tools"`
+	ID             string  `yaml:"id"`
+	Name           string  `yaml:"name"`
+	ContextWindow  int     `yaml:"context_window"`
+	InputPrice     float64 `yaml:"input_price"`
+	OutputPrice    float64 `yaml:"output_price"`
+	IsFree         bool    `yaml:"free"`
+	SupportsTools  bool    `yaml:"supports_tools"`
+	SupportsVision bool    `yaml:"supports_vision"` // Accepts image input
+	Deprecated     bool    `yaml:"deprecated"`
+	Deployment     string  `yaml:"deployment"` // Azure OpenAI deployment name (defaults to ID)
 }
 
 // BYOKConfig defines bring-your-own-key settings
@@ -53,55 +65,82 @@ type AvailableProvider struct {
 
 // AvailableModel is returned to frontend
 type AvailableModel struct {
-	ID            string  `json:"id"`
-	Name          string  `json:"name"`
-	ContextWindow int     `json:"contextWindow"`
-	InputPrice    float64 `json:"inputPrice"`
-	OutputPrice   float64 `json:"outputPrice"`
-	IsFree        bool    `json:"isFree"`
-	SupportsTools bool    `json:"supportsTools"`
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	ContextWindow  int     `json:"contextWindow"`
+	InputPrice     float64 `json:"inputPrice"`
+	OutputPrice    float64 `json:"outputPrice"`
+	IsFree         bool    `json:"isFree"`
+	SupportsTools  bool    `json:"supportsTools"`
+	SupportsVision bool    `json:"supportsVision,omitempty"`
+	Deprecated     bool    `json:"deprecated,omitempty"`
 }
 
 // ProvidersManager handles loading and querying providers config
 type ProvidersManager struct {
-	config   *ProvidersConfig
-	userKeys map[string]string // User-provided keys from Settings
+	mu          sync.RWMutex
+	config      *ProvidersConfig  // Effective config: base merged with the remote catalog
+	base        *ProvidersConfig  // As loaded from providers.yaml (or defaults)
+	catalog     *ModelCatalog     // Last synced remote catalog, if any
+	userKeys    map[string]string // User-provided keys from Settings
+	configPath  string
+	lastModTime time.Time
+	httpClient  *http.Client // Catalog sync client; nil = http.DefaultClient
+
+	localModels    []ModelConfig // Discovered from the local Ollama/LM Studio server
+	localFetchedAt time.Time
+
+	openRouterModels    []ModelConfig // Fetched from OpenRouter's /models
+	openRouterFetchedAt time.Time
 }
 
 // NewProvidersManager creates a new providers manager
 func NewProvidersManager(configPath string) (*ProvidersManager, error) {
 	pm := &ProvidersManager{
-		userKeys: make(map[string]string),
+		userKeys:   make(map[string]string),
+		configPath: configPath,
 	}
 
 	// Load local dev env file first (if exists)
 	pm.loadEnvLocal()
 
-	// Try to load config file
-	if configPath != "" {
-		if err := pm.loadConfig(configPath); err != nil {
-			// Config file optional - use defaults
-			pm.config = pm.defaultConfig()
+	pm.base = pm.readConfig()
+	pm.catalog = loadCachedCatalog()
+	pm.config = pm.effectiveConfig()
+
+	return pm, nil
+}
+
+// readConfig loads providers.yaml (falling back to defaults) and resolves env vars
+func (pm *ProvidersManager) readConfig() *ProvidersConfig {
+	cfg := pm.defaultConfig()
+	if pm.configPath != "" {
+		// Config file optional - use defaults
+		if loaded, err := loadConfig(pm.configPath); err == nil {
+			cfg = loaded
+		}
+		if info, err := os.Stat(pm.configPath); err == nil {
+			pm.lastModTime = info.ModTime()
 		}
-	} else {
-		pm.config = pm.defaultConfig()
 	}
 
 	// Resolve environment variables in keys
-	pm.resolveEnvVars()
-
-	return pm, nil
+	resolveEnvVars(cfg)
+	return cfg
 }
 
 // loadConfig loads providers config from yaml file
-func (pm *ProvidersManager) loadConfig(path string) error {
+func loadConfig(path string) (*ProvidersConfig, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	pm.config = &ProvidersConfig{}
-	return yaml.Unmarshal(data, pm.config)
+	cfg := &ProvidersConfig{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
 }
 
 // loadEnvLocal loads .env.local file for local development
@@ -157,35 +196,55 @@ func (pm *ProvidersManager) loadEnvLocal() {
 }
 
 // resolveEnvVars replaces ${ENV_VAR} with actual values from environment
-func (pm *ProvidersManager) resolveEnvVars() {
-	for id, p := range pm.config.Providers {
+func resolveEnvVars(cfg *ProvidersConfig) {
+	for id, p := range cfg.Providers {
 		if strings.HasPrefix(p.Key, "${") && strings.HasSuffix(p.Key, "}") {
 			envVar := p.Key[2 : len(p.Key)-1]
 			val := os.Getenv(envVar)
 			fmt.Fprintf(os.Stderr, "[Providers] Resolving %s -> (len=%d)\n", p.Key, len(val))
 			p.Key = val
-			pm.config.Providers[id] = p
 		}
+		p.BaseURL = expandEnvRef(p.BaseURL)
+		p.APIVersion = expandEnvRef(p.APIVersion)
+		p.Region = expandEnvRef(p.Region)
+		cfg.Providers[id] = p
 	}
 }
 
+// expandEnvRef resolves a whole-value ${ENV_VAR} reference in a non-secret field
+func expandEnvRef(v string) string {
+	if strings.HasPrefix(v, "${") && strings.HasSuffix(v, "}") {
+		return os.Getenv(v[2 : len(v)-1])
+	}
+	return v
+}
+
 // SetUserKey sets a user-provided API key for a provider
 func (pm *ProvidersManager) SetUserKey(providerID, key string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
 	pm.userKeys[providerID] = key
 }
 
 // GetAvailableProviders returns providers available to the user
 func (pm *ProvidersManager) GetAvailableProviders() []AvailableProvider {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
 	result := make([]AvailableProvider, 0)
 
 	providerNames := map[string]string{
-		"gemini":    "Google Gemini",
-		"deepseek":  "DeepSeek",
-		"anthropic": "Anthropic (Claude)",
-		"openai":    "OpenAI",
-		"xai":       "xAI (Grok)",
-		"minimax":   "MiniMax",
-		"mistral":   "Mistral AI",
+		"gemini":     "Google Gemini",
+		"deepseek":   "DeepSeek",
+		"anthropic":  "Anthropic (Claude)",
+		"openai":     "OpenAI",
+		"xai":        "xAI (Grok)",
+		"minimax":    "MiniMax",
+		"mistral":    "Mistral AI",
+		"azure":      "Azure OpenAI",
+		"bedrock":    "AWS Bedrock",
+		"local":      "Local (Ollama / LM Studio)",
+		"openrouter": "OpenRouter",
 	}
 
 	for id, p := range pm.config.Providers {
@@ -196,17 +255,23 @@ func (pm *ProvidersManager) GetAvailableProviders() []AvailableProvider {
 		hasServerKey := p.Key != ""
 		hasUserKey := pm.userKeys[id] != ""
 		available := hasServerKey || (pm.config.BYOK.Enabled && hasUserKey)
+		if id == LocalProviderID {
+			// No key needed; usable once the server reports models
+			available = len(p.Models) > 0
+		}
 
 		models := make([]AvailableModel, 0, len(p.Models))
 		for _, m := range p.Models {
 			models = append(models, AvailableModel{
-				ID:            m.ID,
-				Name:          m.Name,
-				ContextWindow: m.ContextWindow,
-				InputPrice:    m.InputPrice,
-				OutputPrice:   m.OutputPrice,
-				IsFree:        m.IsFree,
-				SupportsTools: m.SupportsTools,
+				ID:             m.ID,
+				Name:           m.Name,
+				ContextWindow:  m.ContextWindow,
+				InputPrice:     m.InputPrice,
+				OutputPrice:    m.OutputPrice,
+				IsFree:         m.IsFree,
+				SupportsTools:  m.SupportsTools,
+				SupportsVision: m.SupportsVision,
+				Deprecated:     m.Deprecated,
 			})
 		}
 
@@ -229,6 +294,9 @@ func (pm *ProvidersManager) GetAvailableProviders() []AvailableProvider {
 
 // GetAPIKey returns the API key to use for a provider (server key or user key)
 func (pm *ProvidersManager) GetAPIKey(providerID string) string {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
 	// User key takes priority
 	if key := pm.userKeys[providerID]; key != "" {
 		return key
@@ -242,14 +310,108 @@ func (pm *ProvidersManager) GetAPIKey(providerID string) string {
 
 // GetBaseURL returns custom base URL for a provider if configured
 func (pm *ProvidersManager) GetBaseURL(providerID string) string {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
 	if p, ok := pm.config.Providers[providerID]; ok {
 		return p.BaseURL
 	}
 	return ""
 }
 
+// GetAPIVersion returns the API version for a provider (Azure OpenAI)
+func (pm *ProvidersManager) GetAPIVersion(providerID string) string {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	if p, ok := pm.config.Providers[providerID]; ok {
+		return p.APIVersion
+	}
+	return ""
+}
+
+// GetRegion returns the cloud region for a provider (AWS Bedrock)
+func (pm *ProvidersManager) GetRegion(providerID string) string {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	if p, ok := pm.config.Providers[providerID]; ok {
+		return p.Region
+	}
+	return ""
+}
+
+// GetRateLimits returns the requests- and tokens-per-minute limits for a provider (0 = unlimited)
+func (pm *ProvidersManager) GetRateLimits(providerID string) (rpm, tpm int) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	if p, ok := pm.config.Providers[providerID]; ok {
+		return p.RPM, p.TPM
+	}
+	return 0, 0
+}
+
+// GetDeployment returns the deployment a model is served from (Azure OpenAI),
+// falling back to the model ID when no deployment is configured
+func (pm *ProvidersManager) GetDeployment(providerID, modelID string) string {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	if p, ok := pm.config.Providers[providerID]; ok {
+		for _, m := range p.Models {
+			if m.ID == modelID && m.Deployment != "" {
+				return m.Deployment
+			}
+		}
+	}
+	return modelID
+}
+
+// SupportsVision reports whether providers.yaml marks a model as accepting images
+func (pm *ProvidersManager) SupportsVision(providerID, modelID string) bool {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	if p, ok := pm.config.Providers[providerID]; ok {
+		for _, m := range p.Models {
+			if m.ID == modelID {
+				return m.SupportsVision
+			}
+		}
+	}
+	return false
+}
+
+// VisionModel picks a usable vision model, preferring the default provider,
+// for when the active model cannot see images
+func (pm *ProvidersManager) VisionModel() (providerID, modelID string, ok bool) {
+	available := pm.GetAvailableProviders()
+	defaultID := pm.GetDefaultProvider()
+	sort.Slice(available, func(i, j int) bool {
+		if (available[i].ID == defaultID) != (available[j].ID == defaultID) {
+			return available[i].ID == defaultID
+		}
+		return available[i].ID < available[j].ID
+	})
+	for _, p := range available {
+		if !p.Available || p.ID == LocalProviderID {
+			continue
+		}
+		for _, m := range p.Models {
+			if m.SupportsVision && !m.Deprecated {
+				return p.ID, m.ID, true
+			}
+		}
+	}
+	return "", "", false
+}
+
 // GetDefaultProvider returns the default provider ID
 func (pm *ProvidersManager) GetDefaultProvider() string {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
 	if pm.config.DefaultProvider != "" {
 		return pm.config.DefaultProvider
 	}
@@ -258,6 +420,9 @@ func (pm *ProvidersManager) GetDefaultProvider() string {
 
 // GetDefaultModel returns the default model ID
 func (pm *ProvidersManager) GetDefaultModel() string {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
 	if pm.config.DefaultModel != "" {
 		return pm.config.DefaultModel
 	}
@@ -281,21 +446,21 @@ func (pm *ProvidersManager) defaultConfig() *ProvidersConfig {
 				Enabled: true,
 				Key:     os.Getenv("GEMINI_API_KEY"),
 				Models: []ModelConfig{
-					{ID: "gemini-2.0-flash-exp", Name: "Gemini 2.0 Flash", ContextWindow: 1000000, IsFree: true, SupportsTools: true},
+					{ID: "gemini-2.0-flash-exp", Name: "Gemini 2.0 Flash", ContextWindow: 1000000, IsFree: true, SupportsTools: true, SupportsVision: true},
 				},
 			},
 			"anthropic": {
 				Enabled: true,
 				Key:     os.Getenv("ANTHROPIC_API_KEY"),
 				Models: []ModelConfig{
-					{ID: "claude-3-5-sonnet-20241022", Name: "Claude 3.5 Sonnet", ContextWindow: 200000, InputPrice: 3.0, OutputPrice: 15.0, SupportsTools: true},
+					{ID: "claude-3-5-sonnet-20241022", Name: "Claude 3.5 Sonnet", ContextWindow: 200000, InputPrice: 3.0, OutputPrice: 15.0, SupportsTools: true, SupportsVision: true},
 				},
 			},
 			"openai": {
 				Enabled: true,
 				Key:     os.Getenv("OPENAI_API_KEY"),
 				Models: []ModelConfig{
-					{ID: "gpt-4o", Name: "GPT-4o", ContextWindow: 128000, InputPrice: 2.5, OutputPrice: 10.0, SupportsTools: true},
+					{ID: "gpt-4o", Name: "GPT-4o", ContextWindow: 128000, InputPrice: 2.5, OutputPrice: 10.0, SupportsTools: true, SupportsVision: true},
 				},
 			},
 			"mistral": {
@@ -307,6 +472,33 @@ func (pm *ProvidersManager) defaultConfig() *ProvidersConfig {
 					{ID: "ministral-8b-latest", Name: "Ministral 8B (Free)", ContextWindow: 128000, IsFree: true, SupportsTools: true},
 				},
 			},
+			"azure": {
+				Enabled:    true,
+				Key:        os.Getenv("AZURE_OPENAI_API_KEY"),
+				BaseURL:    os.Getenv("AZURE_OPENAI_ENDPOINT"),
+				APIVersion: os.Getenv("AZURE_OPENAI_API_VERSION"),
+				Models: []ModelConfig{
+					{ID: "gpt-4o", Name: "GPT-4o (Azure)", ContextWindow: 128000, InputPrice: 2.5, OutputPrice: 10.0, SupportsTools: true, SupportsVision: true},
+				},
+			},
+			"bedrock": {
+				Enabled: true,
+				Key:     bedrockKeyFromEnv(),
+				Region:  os.Getenv("AWS_REGION"),
+				Models: []ModelConfig{
+					{ID: "anthropic.claude-3-5-sonnet-20241022-v2:0", Name: "Claude 3.5 Sonnet (Bedrock)", ContextWindow: 200000, InputPrice: 3.0, OutputPrice: 15.0, SupportsTools: true},
+					{ID: "meta.llama3-1-70b-instruct-v1:0", Name: "Llama 3.1 70B (Bedrock)", ContextWindow: 128000, InputPrice: 0.72, OutputPrice: 0.72, SupportsTools: true},
+				},
+			},
+			OpenRouterProviderID: {
+				Enabled: true,
+				Key:     os.Getenv("OPENROUTER_API_KEY"),
+				BaseURL: DefaultOpenRouterURL, // Models are fetched from OpenRouter
+			},
+			LocalProviderID: {
+				Enabled: true,
+				BaseURL: os.Getenv("LOCAL_LLM_URL"), // Empty = http://localhost:11434
+			},
 		},
 		DefaultProvider: "deepseek",
 		DefaultModel:    "deepseek-chat",
@@ -317,6 +509,23 @@ func (pm *ProvidersManager) defaultConfig() *ProvidersConfig {
 	}
 }
 
+// bedrockKeyFromEnv returns a Bedrock API key, or "access:secret[:session]"
+// built from the standard AWS credential variables
+func bedrockKeyFromEnv() string {
+	if token := os.Getenv("AWS_BEARER_TOKEN_BEDROCK"); token != "" {
+		return token
+	}
+	access, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if access == "" || secret == "" {
+		return ""
+	}
+	key := access + ":" + secret
+	if session := os.Getenv("AWS_SESSION_TOKEN"); session != "" {
+		key += ":" + session
+	}
+	return key
+}
+
 // FindConfigFile looks for providers.yaml in standard locations
 func FindConfigFile() string {
 	// Look in local project during dev
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// LocalProviderID is the provider that talks to a local Ollama or LM Studio server.
+// It needs no API key and its models are discovered from the server.
+const LocalProviderID = "local"
+
+const (
+	// DefaultLocalURL is Ollama's default address; LM Studio listens on :1234
+	DefaultLocalURL = "http://localhost:11434"
+
+	localDiscoveryTTL     = 30 * time.Second
+	localDiscoveryTimeout = 2 * time.Second
+)
+
+// LocalBaseURL returns the configured local server root (without /v1)
+func LocalBaseURL(configured string) string {
+	url := configured
+	if url == "" {
+		url = os.Getenv("LOCAL_LLM_URL")
+	}
+	if url == "" {
+		url = DefaultLocalURL
+	}
+	url = strings.TrimSuffix(url, "/")
+	return strings.TrimSuffix(url, "/v1")
+}
+
+// RefreshLocalModels asks the local server which models are installed. Results
+// are cached briefly; an unreachable server simply lists no models.
+func (pm *ProvidersManager) RefreshLocalModels(ctx context.Context) {
+	pm.mu.RLock()
+	p, ok := pm.base.Providers[LocalProviderID]
+	fresh := time.Since(pm.localFetchedAt) < localDiscoveryTTL
+	client := pm.httpClient
+	pm.mu.RUnlock()
+
+	if !ok || !p.Enabled || fresh {
+		return
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, localDiscoveryTimeout)
+	defer cancel()
+
+	models, err := discoverLocalModels(ctx, client, LocalBaseURL(p.BaseURL))
+	if err != nil {
+		log.Printf("[Providers] Local model discovery: %v", err)
+	}
+
+	pm.mu.Lock()
+	pm.localModels = models
+	pm.localFetchedAt = time.Now()
+	pm.config = pm.effectiveConfig()
+	pm.mu.Unlock()
+}
+
+// discoverLocalModels lists models via Ollama's /api/tags, falling back to the
+// OpenAI-compatible /v1/models that LM Studio serves
+func discoverLocalModels(ctx context.Context, client *http.Client, baseURL string) ([]ModelConfig, error) {
+	var tags struct {
+		Models []struct {
+			Name    string `json:"name"`
+			Details struct {
+				ParameterSize     string `json:"parameter_size"`
+				QuantizationLevel string `json:"quantization_level"`
+			} `json:"details"`
+		} `json:"models"`
+	}
+	tagsErr := getJSON(ctx, client, baseURL+"/api/tags", &tags)
+	if tagsErr == nil {
+		models := make([]ModelConfig, 0, len(tags.Models))
+		for _, m := range tags.Models {
+			name := m.Name
+			if m.Details.ParameterSize != "" {
+				name = fmt.Sprintf("%s (%s)", m.Name, m.Details.ParameterSize)
+			}
+			models = append(models, localModel(m.Name, name))
+		}
+		return models, nil
+	}
+
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := getJSON(ctx, client, baseURL+"/v1/models", &list); err != nil {
+		return nil, fmt.Errorf("no local server at %s: %w", baseURL, tagsErr)
+	}
+	models := make([]ModelConfig, 0, len(list.Data))
+	for _, m := range list.Data {
+		models = append(models, localModel(m.ID, m.ID))
+	}
+	return models, nil
+}
+
+func localModel(id, name string) ModelConfig {
+	return ModelConfig{
+		ID:            id,
+		Name:          name,
+		ContextWindow: 8192, // Conservative: the real limit depends on the server's num_ctx
+		IsFree:        true,
+		SupportsTools: true,
+	}
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// effectiveConfig merges the catalog and discovered models onto base. Caller must hold pm.mu.
+func (pm *ProvidersManager) effectiveConfig() *ProvidersConfig {
+	merged := mergeCatalog(pm.base, pm.catalog)
+
+	discovered := map[string][]ModelConfig{
+		LocalProviderID:      pm.localModels,
+		OpenRouterProviderID: pm.openRouterModels,
+	}
+	var out *ProvidersConfig
+	for id, found := range discovered {
+		p, ok := merged.Providers[id]
+		if !ok || len(found) == 0 {
+			continue
+		}
+
+		// Models listed in providers.yaml keep their settings; discovered ones are appended
+		known := make(map[string]bool, len(p.Models))
+		models := append([]ModelConfig(nil), p.Models...)
+		for _, m := range models {
+			known[m.ID] = true
+		}
+		for _, m := range found {
+			if !known[m.ID] {
+				models = append(models, m)
+			}
+		}
+		p.Models = models
+
+		if out == nil {
+			copied := *merged
+			copied.Providers = make(map[string]ProviderConfig, len(merged.Providers))
+			for pid, pc := range merged.Providers {
+				copied.Providers[pid] = pc
+			}
+			out = &copied
+		}
+		out.Providers[id] = p
+	}
+	if out == nil {
+		return merged
+	}
+	return out
+}
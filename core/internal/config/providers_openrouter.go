@@ -0,0 +1,130 @@
+package config
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenRouterProviderID is the OpenRouter provider. Its full model list, with
+// prices, is fetched from the public /models endpoint.
+const OpenRouterProviderID = "openrouter"
+
+const (
+	// DefaultOpenRouterURL is the OpenRouter API root
+	DefaultOpenRouterURL = "https://openrouter.ai/api/v1"
+
+	openRouterModelsTTL     = 6 * time.Hour
+	openRouterModelsTimeout = 15 * time.Second
+)
+
+// RefreshModels updates every discovered model list (local server, OpenRouter)
+func (pm *ProvidersManager) RefreshModels(ctx context.Context) {
+	pm.RefreshLocalModels(ctx)
+	pm.RefreshOpenRouterModels(ctx)
+}
+
+// RefreshOpenRouterModels fetches OpenRouter's model list. Results are cached
+// for a few hours; on failure the previous list is kept.
+func (pm *ProvidersManager) RefreshOpenRouterModels(ctx context.Context) {
+	pm.mu.RLock()
+	p, ok := pm.base.Providers[OpenRouterProviderID]
+	fresh := time.Since(pm.openRouterFetchedAt) < openRouterModelsTTL
+	client := pm.httpClient
+	pm.mu.RUnlock()
+
+	if !ok || !p.Enabled || fresh {
+		return
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	baseURL := strings.TrimSuffix(p.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultOpenRouterURL
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, openRouterModelsTimeout)
+	defer cancel()
+
+	models, err := fetchOpenRouterModels(ctx, client, baseURL)
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.openRouterFetchedAt = time.Now() // Also on failure, so an outage isn't retried on every listing
+	if err != nil {
+		log.Printf("[Providers] OpenRouter model discovery: %v", err)
+		return
+	}
+	pm.openRouterModels = models
+	pm.config = pm.effectiveConfig()
+}
+
+func fetchOpenRouterModels(ctx context.Context, client *http.Client, baseURL string) ([]ModelConfig, error) {
+	var list struct {
+		Data []struct {
+			ID            string `json:"id"`
+			Name          string `json:"name"`
+			ContextLength int    `json:"context_length"`
+			Pricing       struct {
+				Prompt     string `json:"prompt"`     // USD per token, as a decimal string
+				Completion string `json:"completion"` // USD per token
+			} `json:"pricing"`
+			SupportedParameters []string `json:"supported_parameters"`
+			Architecture        struct {
+				InputModalities []string `json:"input_modalities"`
+			} `json:"architecture"`
+		} `json:"data"`
+	}
+	if err := getJSON(ctx, client, baseURL+"/models", &list); err != nil {
+		return nil, err
+	}
+
+	models := make([]ModelConfig, 0, len(list.Data))
+	for _, m := range list.Data {
+		in, out := perMillion(m.Pricing.Prompt), perMillion(m.Pricing.Completion)
+		tools := false
+		for _, param := range m.SupportedParameters {
+			if param == "tools" {
+				tools = true
+				break
+			}
+		}
+		vision := false
+		for _, modality := range m.Architecture.InputModalities {
+			if modality == "image" {
+				vision = true
+				break
+			}
+		}
+		name := m.Name
+		if name == "" {
+			name = m.ID
+		}
+		models = append(models, ModelConfig{
+			ID:             m.ID,
+			Name:           name,
+			ContextWindow:  m.ContextLength,
+			InputPrice:     in,
+			OutputPrice:    out,
+			IsFree:         m.Pricing.Prompt == "0" && m.Pricing.Completion == "0",
+			SupportsTools:  tools,
+			SupportsVision: vision,
+		})
+	}
+	return models, nil
+}
+
+// perMillion converts OpenRouter's per-token price to the per-1M-token prices used in providers.yaml.
+// Negative prices mark router models (e.g. openrouter/auto) whose cost is not known upfront.
+func perMillion(perToken string) float64 {
+	v, err := strconv.ParseFloat(perToken, 64)
+	if err != nil || v < 0 {
+		return 0
+	}
+	return v * 1_000_000
+}
@@ -0,0 +1,321 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CatalogConfig points at an optional hosted model catalog
+type CatalogConfig struct {
+	URL            string `yaml:"url"`             // JSON catalog endpoint; empty disables sync
+	SyncInterval   string `yaml:"sync_interval"`   // Go duration, default 24h
+	AddNewModels   bool   `yaml:"add_new_models"`  // Also list catalog models missing from providers.yaml
+	HideDeprecated bool   `yaml:"hide_deprecated"` // Drop deprecated models from listings
+}
+
+// ModelCatalog is the hosted catalog format
+type ModelCatalog struct {
+	UpdatedAt time.Time      `json:"updated_at"`
+	FetchedAt time.Time      `json:"fetched_at,omitempty"` // Set locally when cached
+	Models    []CatalogModel `json:"models"`
+}
+
+// CatalogModel carries the fields the catalog may override
+type CatalogModel struct {
+	Provider       string   `json:"provider"`
+	ID             string   `json:"id"`
+	Name           string   `json:"name,omitempty"`
+	ContextWindow  int      `json:"context_window,omitempty"`
+	InputPrice     *float64 `json:"input_price,omitempty"`
+	OutputPrice    *float64 `json:"output_price,omitempty"`
+	IsFree         *bool    `json:"free,omitempty"`
+	SupportsTools  *bool    `json:"supports_tools,omitempty"`
+	SupportsVision *bool    `json:"supports_vision,omitempty"`
+	Deprecated     bool     `json:"deprecated,omitempty"`
+}
+
+const (
+	providersWatchInterval     = 3 * time.Second
+	defaultCatalogSyncInterval = 24 * time.Hour
+)
+
+// StartSync watches providers.yaml for changes and, if a catalog URL is
+// configured, refreshes the model catalog on its sync interval.
+func (pm *ProvidersManager) StartSync(ctx context.Context) {
+	go pm.watchConfig(ctx)
+	go pm.syncCatalogLoop(ctx)
+	go pm.RefreshModels(ctx)
+}
+
+// watchConfig polls providers.yaml and reloads it when modified
+func (pm *ProvidersManager) watchConfig(ctx context.Context) {
+	if pm.configPath == "" {
+		return
+	}
+
+	ticker := time.NewTicker(providersWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(pm.configPath)
+			if err != nil {
+				continue
+			}
+
+			pm.mu.RLock()
+			changed := info.ModTime().After(pm.lastModTime)
+			pm.mu.RUnlock()
+
+			if changed {
+				if err := pm.Reload(); err != nil {
+					log.Printf("[Providers] Reload failed, keeping previous catalog: %v", err)
+				} else {
+					log.Printf("[Providers] Reloaded %s", pm.configPath)
+				}
+			}
+		}
+	}
+}
+
+// Reload re-reads providers.yaml. A file that fails to parse leaves the current config in place.
+func (pm *ProvidersManager) Reload() error {
+	if pm.configPath == "" {
+		return nil
+	}
+
+	loaded, err := loadConfig(pm.configPath)
+	info, statErr := os.Stat(pm.configPath)
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	// Remember the mtime even on failure so a broken file isn't re-parsed every tick
+	if statErr == nil {
+		pm.lastModTime = info.ModTime()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", pm.configPath, err)
+	}
+
+	resolveEnvVars(loaded)
+	pm.base = loaded
+	pm.config = pm.effectiveConfig()
+	return nil
+}
+
+func (pm *ProvidersManager) syncCatalogLoop(ctx context.Context) {
+	for {
+		pm.mu.RLock()
+		catalogCfg := pm.base.Catalog
+		lastFetch := time.Time{}
+		if pm.catalog != nil {
+			lastFetch = pm.catalog.FetchedAt
+		}
+		pm.mu.RUnlock()
+
+		interval := defaultCatalogSyncInterval
+		if d, err := time.ParseDuration(catalogCfg.SyncInterval); err == nil && d > 0 {
+			interval = d
+		}
+
+		if catalogCfg.URL != "" && time.Since(lastFetch) >= interval {
+			if err := pm.SyncCatalog(ctx); err != nil {
+				log.Printf("[Providers] Catalog sync failed: %v", err)
+			}
+		}
+
+		// Re-check hourly so a URL added via hot reload is picked up
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Hour):
+		}
+	}
+}
+
+// SyncCatalog fetches the hosted catalog, caches it, and applies it
+func (pm *ProvidersManager) SyncCatalog(ctx context.Context) error {
+	pm.mu.RLock()
+	url := pm.base.Catalog.URL
+	pm.mu.RUnlock()
+
+	if url == "" {
+		return fmt.Errorf("no catalog url configured")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	pm.mu.RLock()
+	client := pm.httpClient
+	pm.mu.RUnlock()
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch catalog: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("catalog returned HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read catalog: %w", err)
+	}
+
+	var catalog ModelCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return fmt.Errorf("failed to parse catalog: %w", err)
+	}
+	catalog.FetchedAt = time.Now()
+
+	saveCachedCatalog(&catalog)
+
+	pm.mu.Lock()
+	pm.catalog = &catalog
+	pm.config = pm.effectiveConfig()
+	pm.mu.Unlock()
+
+	log.Printf("[Providers] Synced model catalog: %d models", len(catalog.Models))
+	return nil
+}
+
+// SetHTTPClient sets the client used for catalog sync (e.g. one honoring proxy settings)
+func (pm *ProvidersManager) SetHTTPClient(client *http.Client) {
+	pm.mu.Lock()
+	pm.httpClient = client
+	pm.mu.Unlock()
+}
+
+// mergeCatalog overlays catalog prices, context sizes and deprecations onto base
+func mergeCatalog(base *ProvidersConfig, catalog *ModelCatalog) *ProvidersConfig {
+	if catalog == nil || len(catalog.Models) == 0 {
+		return base
+	}
+
+	merged := *base
+	merged.Providers = make(map[string]ProviderConfig, len(base.Providers))
+
+	byProvider := make(map[string][]CatalogModel)
+	for _, m := range catalog.Models {
+		byProvider[m.Provider] = append(byProvider[m.Provider], m)
+	}
+
+	for id, p := range base.Providers {
+		entries := byProvider[id]
+		models := make([]ModelConfig, 0, len(p.Models))
+		known := make(map[string]bool)
+
+		for _, m := range p.Models {
+			known[m.ID] = true
+			for _, e := range entries {
+				if e.ID == m.ID {
+					m = applyCatalogModel(m, e)
+					break
+				}
+			}
+			if m.Deprecated && base.Catalog.HideDeprecated {
+				continue
+			}
+			models = append(models, m)
+		}
+
+		if base.Catalog.AddNewModels {
+			for _, e := range entries {
+				if known[e.ID] || (e.Deprecated && base.Catalog.HideDeprecated) {
+					continue
+				}
+				models = append(models, applyCatalogModel(ModelConfig{ID: e.ID, Name: e.ID}, e))
+			}
+		}
+
+		p.Models = models
+		merged.Providers[id] = p
+	}
+
+	return &merged
+}
+
+func applyCatalogModel(m ModelConfig, e CatalogModel) ModelConfig {
+	if e.Name != "" {
+		m.Name = e.Name
+	}
+	if e.ContextWindow > 0 {
+		m.ContextWindow = e.ContextWindow
+	}
+	if e.InputPrice != nil {
+		m.InputPrice = *e.InputPrice
+	}
+	if e.OutputPrice != nil {
+		m.OutputPrice = *e.OutputPrice
+	}
+	if e.IsFree != nil {
+		m.IsFree = *e.IsFree
+	}
+	if e.SupportsTools != nil {
+		m.SupportsTools = *e.SupportsTools
+	}
+	if e.SupportsVision != nil {
+		m.SupportsVision = *e.SupportsVision
+	}
+	m.Deprecated = m.Deprecated || e.Deprecated
+	return m
+}
+
+func catalogCachePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ricochet", "model_catalog.json")
+}
+
+// loadCachedCatalog returns the last synced catalog so offline starts keep current prices
+func loadCachedCatalog() *ModelCatalog {
+	path := catalogCachePath()
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var catalog ModelCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil
+	}
+	return &catalog
+}
+
+func saveCachedCatalog(catalog *ModelCatalog) {
+	path := catalogCachePath()
+	if path == "" {
+		return
+	}
+	data, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Printf("[Providers] Failed to cache catalog: %v", err)
+	}
+}
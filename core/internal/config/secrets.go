@@ -0,0 +1,184 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Secret backends selectable in settings
+const (
+	SecretBackendPlaintext = "plaintext" // Keys stored in settings.json (legacy default)
+	SecretBackendKeychain  = "keychain"  // macOS Keychain, Windows Credential Manager, libsecret
+	SecretBackendEnv       = "env"       // Read-only: RICOCHET_<PROVIDER>_KEY variables
+)
+
+// secretRefPrefix marks a settings value that lives in the SecretStore
+const secretRefPrefix = "secret://"
+
+const keychainService = "ricochet"
+
+var (
+	// ErrSecretNotFound is returned when a secret has no stored value
+	ErrSecretNotFound = errors.New("secret not found")
+	// ErrSecretReadOnly is returned by backends that cannot persist values
+	ErrSecretReadOnly = errors.New("secret backend is read-only")
+)
+
+// SecretStore persists sensitive values such as API keys outside settings.json
+type SecretStore interface {
+	Name() string
+	Get(key string) (string, error)
+	Set(key, value string) error
+	Delete(key string) error
+}
+
+// NewSecretStore returns the backend for name, or nil for plaintext
+func NewSecretStore(name string) (SecretStore, error) {
+	switch name {
+	case "", SecretBackendPlaintext:
+		return nil, nil
+	case SecretBackendKeychain:
+		return newKeychainStore()
+	case SecretBackendEnv:
+		return envSecretStore{}, nil
+	default:
+		return nil, fmt.Errorf("unknown secrets backend: %s", name)
+	}
+}
+
+// providerSecretKey is the SecretStore key for a provider's API key
+func providerSecretKey(provider string) string {
+	return "api_key." + provider
+}
+
+// --- Environment backend ---
+
+type envSecretStore struct{}
+
+func (envSecretStore) Name() string { return SecretBackendEnv }
+
+// Get maps "api_key.openai" to RICOCHET_OPENAI_KEY, matching the dev variables read by NewStore
+func (envSecretStore) Get(key string) (string, error) {
+	if v := os.Getenv(envVarForSecret(key)); v != "" {
+		return v, nil
+	}
+	return "", ErrSecretNotFound
+}
+
+func (envSecretStore) Set(key, value string) error { return ErrSecretReadOnly }
+func (envSecretStore) Delete(key string) error     { return ErrSecretReadOnly }
+
+func envVarForSecret(key string) string {
+	name := strings.TrimPrefix(key, "api_key.")
+	name = strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+	return "RICOCHET_" + name + "_KEY"
+}
+
+// --- OS keychain backend ---
+
+type keychainStore struct {
+	goos string
+}
+
+func newKeychainStore() (SecretStore, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err != nil {
+			return nil, fmt.Errorf("macOS keychain unavailable: %w", err)
+		}
+	case "linux", "freebsd", "openbsd":
+		if _, err := exec.LookPath("secret-tool"); err != nil {
+			return nil, fmt.Errorf("libsecret unavailable (install secret-tool): %w", err)
+		}
+	case "windows":
+	default:
+		return nil, fmt.Errorf("no keychain backend for %s", runtime.GOOS)
+	}
+	return &keychainStore{goos: runtime.GOOS}, nil
+}
+
+func (k *keychainStore) Name() string { return SecretBackendKeychain }
+
+func (k *keychainStore) Get(key string) (string, error) {
+	switch k.goos {
+	case "darwin":
+		out, err := exec.Command("security", "find-generic-password", "-s", keychainService, "-a", key, "-w").Output()
+		if err != nil {
+			return "", ErrSecretNotFound
+		}
+		return strings.TrimRight(string(out), "\n"), nil
+	case "windows":
+		return wincredGet(keychainService + ":" + key)
+	default:
+		out, err := exec.Command("secret-tool", "lookup", "service", keychainService, "account", key).Output()
+		if err != nil || len(out) == 0 {
+			return "", ErrSecretNotFound
+		}
+		return strings.TrimRight(string(out), "\n"), nil
+	}
+}
+
+func (k *keychainStore) Set(key, value string) error {
+	switch k.goos {
+	case "darwin":
+		// security reads the command from stdin in interactive mode, so the
+		// secret never shows up in ps; -U updates the item if it exists
+		line, err := securityCommandLine("add-generic-password", "-U", "-s", keychainService, "-a", key, "-w", value)
+		if err != nil {
+			return err
+		}
+		cmd := exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(line)
+		out, err := cmd.CombinedOutput()
+		msg := strings.TrimSpace(string(out))
+		if err != nil {
+			return fmt.Errorf("keychain write failed: %s: %w", msg, err)
+		}
+		// Interactive mode reports a failed command but still exits 0
+		if strings.Contains(strings.ToLower(msg), "error") {
+			return fmt.Errorf("keychain write failed: %s", msg)
+		}
+		return nil
+	case "windows":
+		return wincredSet(keychainService+":"+key, value)
+	default:
+		// secret-tool reads the secret from stdin so it never shows up in ps
+		cmd := exec.Command("secret-tool", "store", "--label", "Ricochet "+key, "service", keychainService, "account", key)
+		cmd.Stdin = strings.NewReader(value)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("libsecret write failed: %s: %w", strings.TrimSpace(string(out)), err)
+		}
+		return nil
+	}
+}
+
+// securityCommandLine builds one line for `security -i`, which splits
+// words on spaces and honors double quotes with backslash escapes
+func securityCommandLine(args ...string) (string, error) {
+	words := make([]string, len(args))
+	for i, a := range args {
+		if strings.ContainsAny(a, "\r\n") {
+			return "", fmt.Errorf("keychain values cannot contain line breaks")
+		}
+		a = strings.ReplaceAll(a, `\`, `\\`)
+		words[i] = `"` + strings.ReplaceAll(a, `"`, `\"`) + `"`
+	}
+	return strings.Join(words, " ") + "\n", nil
+}
+
+func (k *keychainStore) Delete(key string) error {
+	switch k.goos {
+	case "darwin":
+		exec.Command("security", "delete-generic-password", "-s", keychainService, "-a", key).Run()
+		return nil
+	case "windows":
+		return wincredDelete(keychainService + ":" + key)
+	default:
+		exec.Command("secret-tool", "clear", "service", keychainService, "account", key).Run()
+		return nil
+	}
+}
@@ -2,9 +2,12 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 )
 
@@ -17,6 +20,25 @@ type ContextSettings struct {
 	EnableCheckpoints    bool `json:"enable_checkpoints"`     // Enable workspace checkpointing
 	CheckpointOnWrites   bool `json:"checkpoint_on_writes"`   // Auto-checkpoint after write operations
 	EnableCodeIndex      bool `json:"enable_code_index"`      // Enable codebase indexing for semantic search
+
+	VectorStore VectorStoreSettings `json:"vector_store"` // Where the codebase index is kept
+}
+
+// VectorStoreSettings selects the backend of the codebase index. URL and
+// APIKey may be "$VAR" or "${VAR}" references to the environment.
+type VectorStoreSettings struct {
+	Backend    string `json:"backend,omitempty"`    // sqlite (default), local, qdrant or pgvector
+	URL        string `json:"url,omitempty"`        // Qdrant base URL, or the pgvector Postgres DSN
+	Collection string `json:"collection,omitempty"` // Qdrant collection or Postgres table (default: ricochet_index)
+	APIKey     string `json:"api_key,omitempty"`    // Qdrant API key
+}
+
+// CheckpointSettings bounds the shadow-git history of auto-checkpoints.
+// Zero values use the defaults (200 checkpoints, 30 days, 500 MB).
+type CheckpointSettings struct {
+	MaxCount   int `json:"max_count,omitempty"`    // Checkpoints kept per workspace
+	MaxAgeDays int `json:"max_age_days,omitempty"` // Older checkpoints are pruned
+	MaxSizeMB  int `json:"max_size_mb,omitempty"`  // Disk budget of a workspace's checkpoints
 }
 
 // AutoApprovalSettings controls which actions can run without user confirmation
@@ -36,16 +58,101 @@ type AutoApprovalSettings struct {
 }
 
 type ToolsSettings struct {
-	DisableLLMCorrection bool `json:"disable_llm_correction"`
+	DisableLLMCorrection bool                        `json:"disable_llm_correction"`
+	Python               PythonSettings              `json:"python"`
+	Databases            map[string]DatabaseSettings `json:"databases,omitempty"` // Named connections for the sql_* tools
+	Verification         VerificationSettings        `json:"verification"`
+}
+
+// VerificationSettings configures the checks run on files the agent writes.
+// A check fails the write when its command exits non-zero.
+type VerificationSettings struct {
+	TimeoutSeconds int                 `json:"timeout_seconds,omitempty"` // Budget for checking one write (default 60)
+	Skip           []string            `json:"skip,omitempty"`            // Built-in checks to turn off: go, typescript, javascript, python, rust
+	Checks         []VerificationCheck `json:"checks,omitempty"`          // Replace the built-in check for their extensions
+}
+
+// VerificationCheck is a project-defined check, e.g. eslint for .ts files
+type VerificationCheck struct {
+	Name       string   `json:"name"`
+	Extensions []string `json:"extensions"`    // e.g. [".ts", ".tsx"]
+	Command    string   `json:"command"`       // Shell command; {{.file}} is the file's path, shell-quoted
+	Dir        string   `json:"dir,omitempty"` // Working directory, relative to the workspace (default the workspace)
+}
+
+// DatabaseSettings is a connection the sql_* tools may use. $VAR and ${VAR}
+// in the DSN are expanded from the environment, so project files can keep
+// passwords out of the repository.
+type DatabaseSettings struct {
+	Driver string `json:"driver"` // "postgres" or "sqlite"
+	DSN    string `json:"dsn"`    // postgres://... URL or key=value string; SQLite file path (relative to the workspace)
+}
+
+// PythonSettings sets the resource limits of the execute_python kernel.
+// Zero values use the defaults (60s CPU per call, 4096 MB, 120s wall clock).
+type PythonSettings struct {
+	CPUSeconds     int  `json:"cpu_seconds,omitempty"`
+	MemoryMB       int  `json:"memory_mb,omitempty"`
+	TimeoutSeconds int  `json:"timeout_seconds,omitempty"`
+	AllowNetwork   bool `json:"allow_network,omitempty"` // Skip the approval for network access
 }
 
 type Settings struct {
-	Tools        ToolsSettings        `json:"tools"`
-	Provider     ProviderSettings     `json:"provider"`
-	LiveMode     LiveModeSettings     `json:"live_mode"`
-	Context      ContextSettings      `json:"context"`
-	AutoApproval AutoApprovalSettings `json:"auto_approval"`
-	Theme        string               `json:"theme"`
+	SchemaVersion int                  `json:"schema_version"`
+	Tools         ToolsSettings        `json:"tools"`
+	Provider      ProviderSettings     `json:"provider"`
+	LiveMode      LiveModeSettings     `json:"live_mode"`
+	Context       ContextSettings      `json:"context"`
+	Checkpoints   CheckpointSettings   `json:"checkpoints"`
+	AutoApproval  AutoApprovalSettings `json:"auto_approval"`
+	Secrets       SecretsSettings      `json:"secrets"`
+	Index         IndexSettings        `json:"index"`
+	Memory        MemorySettings       `json:"memory"`
+	Sessions      SessionSettings      `json:"sessions"`
+	Network       NetworkSettings      `json:"network"`
+	Packs         PackSettings         `json:"packs"`
+	Skills        SkillSettings        `json:"skills"`
+	Theme         string               `json:"theme"`
+}
+
+// IndexSettings controls codebase indexing
+type IndexSettings struct {
+	Ignore []string `json:"ignore,omitempty"` // Glob patterns; a trailing "/" matches a directory
+}
+
+// MemorySettings controls the project memory in .ricochet/memory.json
+type MemorySettings struct {
+	DisableLearning bool `json:"disable_learning,omitempty"` // Don't extract facts from finished sessions
+	AutoApprove     bool `json:"auto_approve,omitempty"`     // Keep learned facts without review (/memory review)
+}
+
+// SessionSettings controls how chat sessions are saved in ~/.ricochet/sessions
+type SessionSettings struct {
+	Fsync string `json:"fsync,omitempty"` // When journal appends reach the disk: "always" (default), "periodic" or "never"
+}
+
+// NetworkSettings configures outbound HTTP (see internal/httpclient)
+type NetworkSettings struct {
+	ProxyURL string            `json:"proxy_url,omitempty"` // Overrides HTTPS_PROXY/HTTP_PROXY
+	NoProxy  string            `json:"no_proxy,omitempty"`  // Overrides NO_PROXY
+	CABundle string            `json:"ca_bundle,omitempty"` // PEM file trusted in addition to the system roots
+	Timeouts map[string]string `json:"timeouts,omitempty"`  // Per-provider request timeout, e.g. {"ollama": "30m"}
+}
+
+// PackSettings configures "ricochet pack install" (see internal/packs)
+type PackSettings struct {
+	Registry    string   `json:"registry,omitempty"`     // Git URL or local directory of the pack registry
+	TrustedKeys []string `json:"trusted_keys,omitempty"` // Base64 ed25519 keys whose signed packs install without prompting
+}
+
+// SkillSettings configures the skills injected into prompts (see internal/skills)
+type SkillSettings struct {
+	Disabled []string `json:"disabled,omitempty"` // Skills never activated, toggled with /skills enable|disable
+}
+
+// SecretsSettings selects where API keys are stored
+type SecretsSettings struct {
+	Backend string `json:"backend,omitempty"` // "plaintext" (default), "keychain", "env"
 }
 
 type ProviderSettings struct {
@@ -53,8 +160,9 @@ type ProviderSettings struct {
 	Model             string            `json:"model"`
 	APIKey            string            `json:"api_key"`                      // Legacy single key (backwards compat)
 	APIKeys           map[string]string `json:"api_keys,omitempty"`           // Per-provider keys
-	EmbeddingProvider string            `json:"embedding_provider,omitempty"` // Separate provider for embeddings (e.g. openai)
+	EmbeddingProvider string            `json:"embedding_provider,omitempty"` // Embeddings for codebase search: openai, voyage, jina, minilm, builtin, ...
 	EmbeddingModel    string            `json:"embedding_model,omitempty"`    // Model for embeddings
+	Routing           string            `json:"routing,omitempty"`            // OpenRouter routing: "price", "latency", "throughput"
 }
 
 type LiveModeSettings struct {
@@ -70,6 +178,10 @@ type Store struct {
 	mu       sync.RWMutex
 	path     string
 	settings *Settings
+	secrets  SecretStore       // nil for plaintext
+	backend  string            // Backend the secrets store was created for
+	stored   map[string]string // Values already written to secrets, to skip redundant writes
+	project  *ProjectOverlay   // Per-workspace .ricochet/config.yaml, if any
 }
 
 func NewStore() (*Store, error) {
@@ -109,6 +221,7 @@ func NewStore() (*Store, error) {
 	store := &Store{
 		path: filepath.Join(configDir, "settings.json"),
 		settings: &Settings{
+			SchemaVersion: CurrentSchemaVersion,
 			Provider: ProviderSettings{
 				Provider: defaultProvider,
 				Model:    defaultModel,
@@ -134,6 +247,7 @@ func NewStore() (*Store, error) {
 				ExecuteAllCommands:  false, // Unsafe: any command needs approval
 				UseBrowser:          false, // Disabled by default
 				UseMCP:              true,  // MCP tools are generally safe
+				EnableNotifications: true,  // Desktop alerts when no messenger is active
 			},
 			Tools: ToolsSettings{
 				DisableLLMCorrection: false, // Default enabled
@@ -155,6 +269,15 @@ func NewStore() (*Store, error) {
 	return store, nil
 }
 
+// OpenStore loads an existing settings file at path, migrating it if needed
+func OpenStore(path string) (*Store, error) {
+	store := &Store{path: path, settings: &Settings{}}
+	if err := store.Load(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
 func (s *Store) Load() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -164,20 +287,58 @@ func (s *Store) Load() error {
 		return err
 	}
 
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to parse settings.json: %w", err)
+	}
+
+	report, err := MigrateSettings(raw)
+	if err != nil {
+		// Newer file: read what we understand, but never write it back with an older schema
+		log.Printf("Warning: %v", err)
+	} else if report.NeedsMigration() {
+		migrated, err := json.MarshalIndent(raw, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal migrated settings: %w", err)
+		}
+		backup := fmt.Sprintf("%s.v%d.bak", s.path, report.FromVersion)
+		if err := os.WriteFile(backup, data, 0644); err != nil {
+			return fmt.Errorf("failed to back up settings before migration: %w", err)
+		}
+		if err := os.WriteFile(s.path, migrated, 0644); err != nil {
+			return fmt.Errorf("failed to write migrated settings: %w", err)
+		}
+		log.Printf("Migrated settings.json from v%d to v%d (backup: %s)", report.FromVersion, report.ToVersion, backup)
+		data = migrated
+	}
+
 	var settings Settings
 	if err := json.Unmarshal(data, &settings); err != nil {
 		return fmt.Errorf("failed to parse settings.json: %w", err)
 	}
 
 	s.settings = &settings
+
+	// Resolve secret references; plaintext keys left in the file are moved out on first load
+	if plaintext := s.hydrateSecrets(); plaintext > 0 && s.secrets != nil {
+		log.Printf("Moving %d plaintext API key(s) from settings.json to %s secret store", plaintext, s.secrets.Name())
+		if err := s.save(); err != nil {
+			log.Printf("Warning: failed to migrate API keys: %v", err)
+		}
+	}
 	return nil
 }
 
 func (s *Store) Save() error {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	// Full lock: saving may switch the secret backend
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save()
+}
 
-	data, err := json.MarshalIndent(s.settings, "", "  ")
+// save writes settings to disk; callers must hold s.mu
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.persistable(), "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal settings: %w", err)
 	}
@@ -185,15 +346,171 @@ func (s *Store) Save() error {
 	return os.WriteFile(s.path, data, 0644)
 }
 
+// hydrateSecrets replaces secret references in the provider settings with their
+// values and returns how many keys were still stored in plaintext.
+func (s *Store) hydrateSecrets() int {
+	s.useSecretBackend(s.settings.Secrets.Backend)
+	if s.secrets == nil {
+		return 0
+	}
+
+	plaintext := 0
+	resolve := func(provider, value string) string {
+		key := providerSecretKey(provider)
+		if strings.HasPrefix(value, secretRefPrefix) {
+			key = strings.TrimPrefix(value, secretRefPrefix)
+		} else if value != "" {
+			plaintext++
+			return value
+		}
+		if provider == "" && value == "" {
+			return ""
+		}
+
+		resolved, err := s.secrets.Get(key)
+		if err != nil {
+			if !errors.Is(err, ErrSecretNotFound) {
+				log.Printf("Warning: failed to read %s from %s: %v", key, s.secrets.Name(), err)
+			}
+			return ""
+		}
+		s.stored[key] = resolved
+		return resolved
+	}
+
+	p := &s.settings.Provider
+	for name, value := range p.APIKeys {
+		p.APIKeys[name] = resolve(name, value)
+	}
+	p.APIKey = resolve(p.Provider, p.APIKey)
+
+	return plaintext
+}
+
+// persistable returns a copy of the settings with API keys swapped for secret references
+func (s *Store) persistable() Settings {
+	out := *s.settings
+	s.useSecretBackend(out.Secrets.Backend)
+	if s.secrets == nil {
+		return out
+	}
+
+	p := out.Provider
+	if p.APIKeys != nil {
+		keys := make(map[string]string, len(p.APIKeys))
+		for name, value := range p.APIKeys {
+			keys[name] = s.storeSecret(name, value)
+		}
+		p.APIKeys = keys
+	}
+	p.APIKey = s.storeSecret(p.Provider, p.APIKey)
+	out.Provider = p
+	return out
+}
+
+// storeSecret writes value to the secret store and returns what settings.json should contain
+func (s *Store) storeSecret(provider, value string) string {
+	if value == "" || provider == "" || strings.HasPrefix(value, secretRefPrefix) {
+		return value
+	}
+
+	key := providerSecretKey(provider)
+	if s.stored[key] == value {
+		return secretRefPrefix + key
+	}
+
+	if err := s.secrets.Set(key, value); err != nil {
+		if errors.Is(err, ErrSecretReadOnly) {
+			// Env backend: don't persist keys that already come from the environment
+			if envValue, _ := s.secrets.Get(key); envValue == value {
+				return ""
+			}
+		} else {
+			log.Printf("Warning: failed to store %s in %s: %v", key, s.secrets.Name(), err)
+		}
+		return value
+	}
+
+	s.stored[key] = value
+	return secretRefPrefix + key
+}
+
+// useSecretBackend switches the secret store when the configured backend changes.
+// Unavailable backends fall back to plaintext so keys are never lost.
+func (s *Store) useSecretBackend(name string) {
+	if name == "" {
+		name = SecretBackendPlaintext
+	}
+	if name == s.backend && s.stored != nil {
+		return
+	}
+
+	store, err := NewSecretStore(name)
+	if err != nil {
+		log.Printf("Warning: %v; keeping API keys in settings.json", err)
+		store = nil
+	}
+	s.secrets = store
+	s.backend = name
+	s.stored = make(map[string]string)
+}
+
+// Secrets returns the configured secret store, or nil when secrets are kept in
+// plaintext
+func (s *Store) Secrets() SecretStore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.secrets
+}
+
+// Get returns the effective settings: global settings with the project overlay applied
 func (s *Store) Get() Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.project.Apply(*s.settings)
+}
+
+// GetGlobal returns ~/.ricochet/settings.json without the project overlay
+func (s *Store) GetGlobal() Settings {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return *s.settings
 }
 
+// Update modifies and saves the global settings. Values that only echo the
+// project overlay back are not persisted globally.
 func (s *Store) Update(fn func(*Settings)) error {
 	s.mu.Lock()
+	before := *s.settings
 	fn(s.settings)
+	s.project.restoreOverriddenKeys(&before, s.settings)
 	s.mu.Unlock()
 	return s.Save()
 }
+
+// LoadProject applies <workspaceRoot>/.ricochet/config.yaml on top of the global settings
+func (s *Store) LoadProject(workspaceRoot string) error {
+	overlay, err := LoadProjectOverlay(workspaceRoot)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.project = overlay
+	s.mu.Unlock()
+
+	if overlay != nil {
+		log.Printf("Loaded project settings from %s (%d overrides)", overlay.Path, len(overlay.Keys()))
+	}
+	return nil
+}
+
+// Origins reports which dotted setting keys come from the project overlay
+func (s *Store) Origins() (map[string]string, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.project == nil {
+		return map[string]string{}, ""
+	}
+	return s.project.Origins(), s.project.Path
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package config
+
+import "errors"
+
+var errNoWincred = errors.New("windows credential manager is only available on windows")
+
+func wincredGet(target string) (string, error) { return "", errNoWincred }
+func wincredSet(target, value string) error    { return errNoWincred }
+func wincredDelete(target string) error        { return errNoWincred }
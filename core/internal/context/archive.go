@@ -0,0 +1,275 @@
+package context
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+// Kinds of condensation recorded in the archive
+const (
+	KindCondensed = "condensed" // Older messages summarized for the request
+	KindTruncated = "truncated" // Older messages dropped for the request
+	KindCompacted = "compacted" // Session history replaced by a summary
+)
+
+// ArchiveRecord is one condensation: when it happened, what it hid and the
+// summary that replaced it
+type ArchiveRecord struct {
+	ID      int       `json:"id"`
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Summary string    `json:"summary,omitempty"`
+	Hidden  []string  `json:"hidden"` // Hashes of the hidden messages, in order
+	Tokens  int       `json:"tokens"` // Estimated tokens of the hidden messages
+
+	// Hidden messages no earlier record archived; the others are stored once
+	Messages []protocol.Message `json:"messages,omitempty"`
+}
+
+// ArchiveEntry describes a record for the condensation log
+type ArchiveEntry struct {
+	ID       int       `json:"id"`
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	Summary  string    `json:"summary,omitempty"`
+	Messages int       `json:"messages"`
+	Tokens   int       `json:"tokens"`
+	Preview  string    `json:"preview,omitempty"` // Start of the first hidden prompt
+}
+
+// Archive keeps the messages condensation hides from the model, one JSON
+// lines file per session, so they can be reviewed and brought back
+type Archive struct {
+	dir      string
+	mu       sync.Mutex
+	sessions map[string]*archivedSession
+}
+
+type archivedSession struct {
+	records  []ArchiveRecord
+	messages map[string]protocol.Message // By hash
+	order    []string                    // Hashes in the order they were archived
+}
+
+// NewArchive creates an archive kept in dir
+func NewArchive(dir string) *Archive {
+	return &Archive{dir: dir, sessions: make(map[string]*archivedSession)}
+}
+
+// Record archives the messages of before that are missing from after, the
+// history actually sent. It returns nil when nothing new was hidden since
+// the session's last record of the same kind.
+func (a *Archive) Record(sessionID, kind string, before, after []protocol.Message, summary string) (*ArchiveRecord, error) {
+	kept := make(map[string]bool, len(after))
+	for _, m := range after {
+		kept[messageHash(m)] = true
+	}
+	var hidden []string
+	var hiddenMsgs []protocol.Message
+	seen := make(map[string]bool)
+	tokens := 0
+	for _, m := range before {
+		h := messageHash(m)
+		if kept[h] || seen[h] {
+			continue
+		}
+		seen[h] = true
+		hidden = append(hidden, h)
+		hiddenMsgs = append(hiddenMsgs, m)
+		tokens += EstimateMessageTokens(m)
+	}
+	if len(hidden) == 0 {
+		return nil, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := a.loadLocked(sessionID)
+	if n := len(s.records); n > 0 && s.records[n-1].Kind == kind && equalHashes(s.records[n-1].Hidden, hidden) {
+		return nil, nil
+	}
+
+	rec := ArchiveRecord{ID: len(s.records) + 1, Time: time.Now(), Kind: kind, Summary: summary, Hidden: hidden, Tokens: tokens}
+	for i, h := range hidden {
+		if _, ok := s.messages[h]; !ok {
+			rec.Messages = append(rec.Messages, hiddenMsgs[i])
+		}
+	}
+
+	if err := os.MkdirAll(a.dir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(a.path(sessionID), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return nil, err
+	}
+	s.add(rec)
+	return &rec, nil
+}
+
+// Log describes a session's condensations, oldest first
+func (a *Archive) Log(sessionID string) []ArchiveEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := a.loadLocked(sessionID)
+	entries := make([]ArchiveEntry, 0, len(s.records))
+	for _, rec := range s.records {
+		entry := ArchiveEntry{ID: rec.ID, Time: rec.Time, Kind: rec.Kind, Summary: rec.Summary, Messages: len(rec.Hidden), Tokens: rec.Tokens}
+		for _, h := range rec.Hidden {
+			if m := s.messages[h]; m.Role == "user" && strings.TrimSpace(m.Content) != "" {
+				entry.Preview = firstLine(m.Content, 120)
+				break
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Segment returns the messages a record hid
+func (a *Archive) Segment(sessionID string, id int) ([]protocol.Message, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := a.loadLocked(sessionID)
+	if id < 1 || id > len(s.records) {
+		return nil, fmt.Errorf("no condensation #%d in this session", id)
+	}
+	var msgs []protocol.Message
+	for _, h := range s.records[id-1].Hidden {
+		if m, ok := s.messages[h]; ok {
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs, nil
+}
+
+// Search returns the archived messages mentioning text, each preceded by
+// the message before it for context, in the order they were archived
+func (a *Archive) Search(sessionID, text string) []protocol.Message {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := a.loadLocked(sessionID)
+	text = strings.ToLower(text)
+	var msgs []protocol.Message
+	last := -1
+	for i, h := range s.order {
+		if !strings.Contains(strings.ToLower(messageText(s.messages[h])), text) {
+			continue
+		}
+		for j := max(i-1, last+1); j <= i; j++ {
+			msgs = append(msgs, s.messages[s.order[j]])
+		}
+		last = i
+	}
+	return msgs
+}
+
+// Remove deletes a session's archive
+func (a *Archive) Remove(sessionID string) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.sessions, sessionID)
+	os.Remove(a.path(sessionID))
+}
+
+func (a *Archive) path(sessionID string) string {
+	return filepath.Join(a.dir, filepath.Base(sessionID)+".jsonl")
+}
+
+// loadLocked reads a session's archive on first use
+func (a *Archive) loadLocked(sessionID string) *archivedSession {
+	if s, ok := a.sessions[sessionID]; ok {
+		return s
+	}
+	s := &archivedSession{messages: make(map[string]protocol.Message)}
+	a.sessions[sessionID] = s
+	f, err := os.Open(a.path(sessionID))
+	if err != nil {
+		return s
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 256*1024*1024)
+	for scanner.Scan() {
+		var rec ArchiveRecord
+		if json.Unmarshal(scanner.Bytes(), &rec) == nil {
+			s.add(rec)
+		}
+	}
+	return s
+}
+
+func (s *archivedSession) add(rec ArchiveRecord) {
+	for _, m := range rec.Messages {
+		h := messageHash(m)
+		if _, ok := s.messages[h]; !ok {
+			s.messages[h] = m
+			s.order = append(s.order, h)
+		}
+	}
+	rec.Messages = nil // Held in s.messages
+	s.records = append(s.records, rec)
+}
+
+func messageHash(m protocol.Message) string {
+	data, _ := json.Marshal(m)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// messageText is a message's text with its tool calls and results
+func messageText(m protocol.Message) string {
+	var sb strings.Builder
+	sb.WriteString(m.Content)
+	for _, tu := range m.ToolUse {
+		sb.WriteString("\n" + tu.Name + " " + string(tu.Input))
+	}
+	for _, tr := range m.ToolResults {
+		sb.WriteString("\n" + tr.Content)
+	}
+	return sb.String()
+}
+
+func firstLine(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if r := []rune(s); len(r) > max {
+		s = string(r[:max]) + "…"
+	}
+	return s
+}
+
+func equalHashes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
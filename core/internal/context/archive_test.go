@@ -0,0 +1,82 @@
+package context
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+func archiveHistory(n int) []protocol.Message {
+	var msgs []protocol.Message
+	for i := 0; i < n; i++ {
+		msgs = append(msgs,
+			protocol.Message{Role: "user", Content: fmt.Sprintf("question %d", i)},
+			protocol.Message{Role: "assistant", Content: fmt.Sprintf("answer %d", i)})
+	}
+	return msgs
+}
+
+func TestArchive_RecordsHiddenMessages(t *testing.T) {
+	dir := t.TempDir()
+	a := NewArchive(dir)
+	history := archiveHistory(5)
+	summary := protocol.Message{Role: "system", Content: "summary"}
+	after := append([]protocol.Message{summary}, history[6:]...)
+
+	rec, err := a.Record("s1", KindCondensed, history, after, "summary")
+	if err != nil || rec == nil {
+		t.Fatalf("Record() = %v, %v", rec, err)
+	}
+	if len(rec.Hidden) != 6 || rec.ID != 1 {
+		t.Fatalf("record %d hides %d messages, want #1 hiding 6", rec.ID, len(rec.Hidden))
+	}
+
+	// The same condensation on the next turn adds nothing
+	if rec, _ := a.Record("s1", KindCondensed, history, after, "summary"); rec != nil {
+		t.Errorf("repeated condensation recorded as #%d", rec.ID)
+	}
+
+	// A later condensation hiding more stores only the new messages
+	history = archiveHistory(6)
+	rec, _ = a.Record("s1", KindCondensed, history, append([]protocol.Message{summary}, history[8:]...), "summary 2")
+	if rec == nil || rec.ID != 2 || len(rec.Messages) != 2 {
+		t.Fatalf("second record = %+v, want #2 storing 2 new messages", rec)
+	}
+
+	// A fresh archive reads it back from disk
+	reopened := NewArchive(dir)
+	log := reopened.Log("s1")
+	if len(log) != 2 || log[1].Messages != 8 || log[0].Preview != "question 0" || log[1].Summary != "summary 2" {
+		t.Fatalf("Log() = %+v", log)
+	}
+	segment, err := reopened.Segment("s1", 2)
+	if err != nil || len(segment) != 8 || segment[7].Content != "answer 3" {
+		t.Fatalf("Segment() = %v, %v", segment, err)
+	}
+	if _, err := reopened.Segment("s1", 3); err == nil {
+		t.Error("Segment() of a missing record should fail")
+	}
+}
+
+func TestArchive_Search(t *testing.T) {
+	a := NewArchive(t.TempDir())
+	history := archiveHistory(4)
+	history[3].Content = "the port is 8443"
+	if _, err := a.Record("s1", KindTruncated, history, history[6:], ""); err != nil {
+		t.Fatal(err)
+	}
+
+	found := a.Search("s1", "PORT")
+	if len(found) != 2 || found[0].Content != "question 1" || found[1].Content != "the port is 8443" {
+		t.Fatalf("Search() = %+v, want the match and its question", found)
+	}
+	if found := a.Search("s2", "port"); len(found) != 0 {
+		t.Errorf("Search() of another session = %+v", found)
+	}
+
+	a.Remove("s1")
+	if log := NewArchive(a.dir).Log("s1"); len(log) != 0 {
+		t.Errorf("Log() after Remove = %+v", log)
+	}
+}
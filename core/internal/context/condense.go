@@ -75,8 +75,16 @@ func (cm *CondenseManager) Condense(ctx context.Context, messages []protocol.Mes
 		result.TokensBefore += EstimateMessageTokens(msg)
 	}
 
-	// Split messages: old ones to condense, recent ones to keep
-	oldMessages := messages[:len(messages)-cm.KeepRecent]
+	// Split messages: old ones to condense, recent ones to keep. Pinned old
+	// messages are kept as they are, after the summary.
+	var oldMessages, pinnedMessages []protocol.Message
+	for i, msg := range messages[:len(messages)-cm.KeepRecent] {
+		if msg.Pinned && i > 0 {
+			pinnedMessages = append(pinnedMessages, msg)
+		} else {
+			oldMessages = append(oldMessages, msg)
+		}
+	}
 	recentMessages := messages[len(messages)-cm.KeepRecent:]
 
 	// Skip if no provider available
@@ -108,6 +116,7 @@ func (cm *CondenseManager) Condense(ctx context.Context, messages []protocol.Mes
 		newMessages = append(newMessages, messages[0])
 	}
 	newMessages = append(newMessages, summaryMessage)
+	newMessages = append(newMessages, pinnedMessages...)
 	result.Messages = append(newMessages, recentMessages...)
 
 	result.Summary = summary
@@ -0,0 +1,206 @@
+package context
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	envGitRefresh  = 5 * time.Second // Reuse git status within a burst of tool calls
+	envMaxChanges  = 15              // Changed files listed before "and N more"
+	envTestMaxAge  = 2 * time.Hour   // Failing test runs older than this are dropped
+	envMaxFailures = 10              // Failing tests listed per run
+)
+
+// testCommandPattern recognizes commands that run a test suite
+var testCommandPattern = regexp.MustCompile(`(?i)\b(go test|cargo test|pytest|py\.test|jest|vitest|mocha|rspec|phpunit|dotnet test|mvn (\S+ )*test|gradlew? (\S+ )*test|(npm|yarn|pnpm|bun) (run )?test|make (\S+ )*test)\b`)
+
+// failedTestPatterns pull failing test names out of test runner output
+var failedTestPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?m)^\s*--- FAIL: (\S+)`),                  // go test
+	regexp.MustCompile(`(?m)^FAILED (\S+)`),                        // pytest
+	regexp.MustCompile(`(?m)^test (\S+) \.\.\. FAILED`),            // cargo test
+	regexp.MustCompile(`(?m)^\s*(?:✕|×) (.+?)(?: \(\d+ ?m?s\))?$`), // jest, vitest
+}
+
+// testRun is the outcome of the last run of a test command
+type testRun struct {
+	failed []string // Failing test names, when the runner reports them
+	at     time.Time
+}
+
+// SetJobs sets how the running background jobs are listed
+func (e *EnvironmentTracker) SetJobs(jobs func() []string) {
+	e.jobs = jobs
+}
+
+// ObserveCommand records the outcome of a command the agent ran, so test
+// suites that failed are part of the context until they pass again
+func (e *EnvironmentTracker) ObserveCommand(command, output string, failed bool) {
+	command = strings.TrimSpace(command)
+	if !testCommandPattern.MatchString(command) {
+		return
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for _, re := range failedTestPatterns {
+		for _, m := range re.FindAllStringSubmatch(output, -1) {
+			if name := strings.TrimSpace(m[1]); !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	failed = failed || len(names) > 0
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !failed {
+		delete(e.tests, command)
+		return
+	}
+	e.tests[command] = testRun{failed: names, at: time.Now()}
+}
+
+// testContext lists the test commands whose last run failed
+func (e *EnvironmentTracker) testContext() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	commands := make([]string, 0, len(e.tests))
+	for command, run := range e.tests {
+		if time.Since(run.at) > envTestMaxAge {
+			delete(e.tests, command)
+			continue
+		}
+		commands = append(commands, command)
+	}
+	sort.Slice(commands, func(i, j int) bool { return e.tests[commands[i]].at.After(e.tests[commands[j]].at) })
+
+	var sb strings.Builder
+	for _, command := range commands {
+		run := e.tests[command]
+		ago := time.Since(run.at).Round(time.Minute)
+		fmt.Fprintf(&sb, "- Failing tests (`%s`, %s ago)", command, ago)
+		if len(run.failed) > 0 {
+			names := run.failed
+			more := ""
+			if len(names) > envMaxFailures {
+				more = fmt.Sprintf(" and %d more", len(names)-envMaxFailures)
+				names = names[:envMaxFailures]
+			}
+			fmt.Fprintf(&sb, ": %s%s", strings.Join(names, ", "), more)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// gitContext describes the branch and working tree, re-read at most every
+// envGitRefresh
+func (e *EnvironmentTracker) gitContext() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if time.Since(e.gitAt) < envGitRefresh {
+		return e.gitStatus
+	}
+	e.gitAt = time.Now()
+	e.gitStatus = ""
+
+	st, err := e.git.BranchStatus()
+	if err != nil {
+		return "" // Not a repository, or no git
+	}
+	var sb strings.Builder
+	branch := st.Branch
+	if branch == "" {
+		branch = "detached HEAD"
+	}
+	fmt.Fprintf(&sb, "- Git branch: %s", branch)
+	if st.Upstream != "" {
+		state := []string{"tracking " + st.Upstream}
+		if st.Ahead > 0 {
+			state = append(state, fmt.Sprintf("%d ahead", st.Ahead))
+		}
+		if st.Behind > 0 {
+			state = append(state, fmt.Sprintf("%d behind", st.Behind))
+		}
+		if len(state) == 1 {
+			state = append(state, "up to date")
+		}
+		fmt.Fprintf(&sb, " (%s)", strings.Join(state, ", "))
+	}
+	sb.WriteString("\n")
+	if len(st.Changes) == 0 {
+		sb.WriteString("- Git status: clean\n")
+	} else {
+		changes := st.Changes
+		more := ""
+		if len(changes) > envMaxChanges {
+			more = fmt.Sprintf(", and %d more", len(changes)-envMaxChanges)
+			changes = changes[:envMaxChanges]
+		}
+		for i, c := range changes {
+			changes[i] = strings.TrimSpace(c)
+		}
+		fmt.Fprintf(&sb, "- Git status: %d changed: %s%s\n", len(st.Changes), strings.Join(changes, ", "), more)
+	}
+	e.gitStatus = sb.String()
+	return e.gitStatus
+}
+
+// toolchainVersions reports the versions of the toolchains the project
+// uses, detected once
+func (e *EnvironmentTracker) toolchainVersions() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.toolchain != nil {
+		return *e.toolchain
+	}
+
+	markers := []struct {
+		file string
+		cmd  []string
+	}{
+		{"go.mod", []string{"go", "version"}},
+		{"package.json", []string{"node", "--version"}},
+		{"Cargo.toml", []string{"rustc", "--version"}},
+		{"requirements.txt", []string{"python3", "--version"}},
+		{"pyproject.toml", []string{"python3", "--version"}},
+	}
+	var versions []string
+	seen := make(map[string]bool)
+	for _, m := range markers {
+		if _, err := os.Stat(filepath.Join(e.cwd, m.file)); err != nil || seen[m.cmd[0]] {
+			continue
+		}
+		seen[m.cmd[0]] = true
+		if v := commandVersion(m.cmd); v != "" {
+			versions = append(versions, v)
+		}
+	}
+	tc := strings.Join(versions, "; ")
+	e.toolchain = &tc
+	return tc
+}
+
+// commandVersion runs a version command, giving up after two seconds
+func commandVersion(args []string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+	if err != nil {
+		return ""
+	}
+	v := strings.TrimSpace(string(out))
+	if strings.HasPrefix(v, "v") { // node prints only "v20.11.0"
+		v = args[0] + " " + v
+	}
+	return v
+}
@@ -0,0 +1,53 @@
+package context
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnvironmentTracker_FailingTests(t *testing.T) {
+	e := NewEnvironmentTracker(t.TempDir())
+
+	e.ObserveCommand("ls -la", "--- FAIL: TestNothing", true)
+	e.ObserveCommand("go test ./...", "--- FAIL: TestParse (0.00s)\n--- FAIL: TestParse/empty (0.00s)\nFAIL", true)
+	e.ObserveCommand("pytest tests", "FAILED tests/test_api.py::test_login - AssertionError", false)
+
+	ctx := e.GetContext()
+	if strings.Contains(ctx, "ls -la") {
+		t.Errorf("a command that is no test run was tracked:\n%s", ctx)
+	}
+	for _, want := range []string{"`go test ./...`", "TestParse, TestParse/empty", "tests/test_api.py::test_login"} {
+		if !strings.Contains(ctx, want) {
+			t.Errorf("context lacks %q:\n%s", want, ctx)
+		}
+	}
+
+	// A passing run clears the failures
+	e.ObserveCommand("go test ./...", "ok  \tpkg\t0.01s", false)
+	if ctx := e.GetContext(); strings.Contains(ctx, "TestParse") {
+		t.Errorf("passing run did not clear failures:\n%s", ctx)
+	}
+}
+
+func TestEnvironmentTracker_GitAndJobs(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "-C", dir, "init", "-q", "-b", "trunk").CombinedOutput(); err != nil {
+		t.Skipf("git init: %v %s", err, out)
+	}
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644)
+
+	e := NewEnvironmentTracker(dir)
+	e.SetJobs(func() []string { return []string{"dev server (job-1)"} })
+	ctx := e.GetContext()
+	for _, want := range []string{"- Git branch: trunk\n", "- Git status: 1 changed: ?? main.go", "Background jobs running: dev server (job-1)"} {
+		if !strings.Contains(ctx, want) {
+			t.Errorf("context lacks %q:\n%s", want, ctx)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package handoff
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -21,8 +22,31 @@ func NewService(generator GenerateFunc) *Service {
 	return &Service{generator: generator}
 }
 
-// GenerateSpec creates a specification summary from the message history
-func (s *Service) GenerateSpec(ctx context.Context, messages []protocol.Message) (string, error) {
+// Artifact names, also their @ references: @SPEC resolves to SPEC.md
+const (
+	ArtifactSpec          = "SPEC"
+	ArtifactPlan          = "PLAN"
+	ArtifactOpenQuestions = "OPEN_QUESTIONS"
+)
+
+// Task is one step of the handoff plan
+type Task struct {
+	ID           string   `json:"id"`
+	Title        string   `json:"title"`
+	Description  string   `json:"description,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"` // IDs of earlier tasks
+}
+
+// Artifacts is what a handoff leaves for the next mode
+type Artifacts struct {
+	Spec          string   `json:"spec"`
+	Tasks         []Task   `json:"tasks"`
+	OpenQuestions []string `json:"open_questions"`
+}
+
+// GenerateArtifacts condenses the message history into a spec, a task plan
+// and the questions still open
+func (s *Service) GenerateArtifacts(ctx context.Context, messages []protocol.Message) (*Artifacts, error) {
 	// 1. Format history for the prompt
 	var historyBuilder strings.Builder
 	for _, msg := range messages {
@@ -37,34 +61,121 @@ func (s *Service) GenerateSpec(ctx context.Context, messages []protocol.Message)
 	// 2. Construct Prompt
 	prompt := fmt.Sprintf(`
 You are the "Reflex Engine" of an advanced AI agent.
-Your goal is to perform an "Intelligent Handoff" by condensing the following conversation history into a technical specification (SPEC.md).
+Your goal is to perform an "Intelligent Handoff" by condensing the following conversation history into handoff artifacts.
 The agent is switching modes (e.g., from Architect to Code). The new mode needs a clean state but MUST know what was decided.
 
 CONVERSATION HISTORY:
 %s
 
 INSTRUCTIONS:
-Create a Markdown specification that includes:
-1. **Goal**: What is the user trying to achieve?
-2. **Decisions**: Key architectural or design decisions agreed upon.
-3. **Plan**: The step-by-step plan that was devised.
-4. **Context**: Any specific file paths, constraints, or libraries mentioned that are crucial.
-
-Output ONLY the Markdown content for SPEC.md. Do not include introductory text.
+Reply with a single JSON object and nothing else:
+{
+  "spec": "Markdown specification (SPEC.md) with: **Goal** (what the user is trying to achieve), **Decisions** (key architectural or design decisions agreed upon) and **Context** (file paths, constraints or libraries that are crucial)",
+  "tasks": [{"id": "1", "title": "Short imperative step", "description": "What to do and how to verify it", "dependencies": ["IDs of earlier tasks it needs"]}],
+  "open_questions": ["Questions that were raised but not resolved, or decisions still needed from the user"]
+}
+Tasks are the step-by-step plan that was devised, in order. Leave open_questions empty when nothing is unresolved.
 `, historyBuilder.String())
 
 	// 3. Call Generator
-	return s.generator(ctx, prompt)
+	out, err := s.generator(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return parseArtifacts(out), nil
 }
 
-// SaveSpec writes the spec to SPEC.md in the workspace root or .ricochet folder
-func (s *Service) SaveSpec(cwd string, content string) error {
+// parseArtifacts reads the generator's JSON reply. A reply that is not JSON
+// is kept whole as the spec.
+func parseArtifacts(out string) *Artifacts {
+	var a Artifacts
+	start, end := strings.Index(out, "{"), strings.LastIndex(out, "}")
+	if start < 0 || end < start || json.Unmarshal([]byte(out[start:end+1]), &a) != nil || strings.TrimSpace(a.Spec) == "" {
+		return &Artifacts{Spec: strings.TrimSpace(out)}
+	}
+	for i := range a.Tasks {
+		if a.Tasks[i].ID == "" {
+			a.Tasks[i].ID = fmt.Sprintf("%d", i+1)
+		}
+	}
+	return &a
+}
+
+// ArtifactPath is where an artifact is saved in the workspace
+func ArtifactPath(cwd, name string) string {
+	return filepath.Join(cwd, ".ricochet", name+".md")
+}
+
+// SaveArtifacts writes SPEC.md, PLAN.md and OPEN_QUESTIONS.md to the
+// .ricochet folder and returns the paths written, by artifact name. Files
+// left from an earlier handoff without a counterpart now are removed.
+func (s *Service) SaveArtifacts(cwd string, a *Artifacts) (map[string]string, error) {
 	// Create .ricochet dir if not exists
 	ricoDir := filepath.Join(cwd, ".ricochet")
 	if err := os.MkdirAll(ricoDir, 0755); err != nil {
-		return err
+		return nil, err
+	}
+
+	contents := map[string]string{
+		ArtifactSpec:          a.Spec,
+		ArtifactPlan:          renderPlan(a.Tasks),
+		ArtifactOpenQuestions: renderQuestions(a.OpenQuestions),
 	}
+	paths := make(map[string]string)
+	for name, content := range contents {
+		path := ArtifactPath(cwd, name)
+		if content == "" {
+			os.Remove(path)
+			continue
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return paths, err
+		}
+		paths[name] = path
+	}
+	return paths, nil
+}
+
+// ExistingArtifacts returns the paths of the artifacts saved by an earlier
+// handoff, by artifact name
+func ExistingArtifacts(cwd string) map[string]string {
+	paths := make(map[string]string)
+	for _, name := range []string{ArtifactSpec, ArtifactPlan, ArtifactOpenQuestions} {
+		path := ArtifactPath(cwd, name)
+		if _, err := os.Stat(path); err == nil {
+			paths[name] = path
+		}
+	}
+	return paths
+}
 
-	specPath := filepath.Join(ricoDir, "SPEC.md")
-	return os.WriteFile(specPath, []byte(content), 0644)
+func renderPlan(tasks []Task) string {
+	if len(tasks) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("# Plan\n\n")
+	for _, t := range tasks {
+		fmt.Fprintf(&sb, "%s. **%s**", t.ID, t.Title)
+		if len(t.Dependencies) > 0 {
+			fmt.Fprintf(&sb, " (after %s)", strings.Join(t.Dependencies, ", "))
+		}
+		sb.WriteString("\n")
+		if t.Description != "" {
+			fmt.Fprintf(&sb, "   %s\n", t.Description)
+		}
+	}
+	return sb.String()
+}
+
+func renderQuestions(questions []string) string {
+	if len(questions) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("# Open Questions\n\n")
+	for _, q := range questions {
+		fmt.Fprintf(&sb, "- %s\n", q)
+	}
+	return sb.String()
 }
@@ -0,0 +1,56 @@
+package handoff
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+func TestGenerateArtifacts(t *testing.T) {
+	reply := "```json\n" + `{"spec": "# Goal\nShip it", "tasks": [{"title": "Write the parser"}, {"id": "2", "title": "Wire it up", "dependencies": ["1"]}], "open_questions": ["Which format?"]}` + "\n```"
+	s := NewService(func(ctx context.Context, prompt string) (string, error) { return reply, nil })
+
+	a, err := s.GenerateArtifacts(context.Background(), []protocol.Message{{Role: "user", Content: "plan it"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Spec != "# Goal\nShip it" || len(a.Tasks) != 2 || a.Tasks[0].ID != "1" || len(a.OpenQuestions) != 1 {
+		t.Fatalf("GenerateArtifacts() = %+v", a)
+	}
+
+	// A reply that is not JSON is the spec
+	reply = "# Just a spec"
+	if a, _ := s.GenerateArtifacts(context.Background(), nil); a.Spec != "# Just a spec" || len(a.Tasks) != 0 {
+		t.Errorf("plain reply = %+v", a)
+	}
+}
+
+func TestSaveArtifacts(t *testing.T) {
+	cwd := t.TempDir()
+	s := NewService(nil)
+	a := &Artifacts{
+		Spec:          "# Spec",
+		Tasks:         []Task{{ID: "1", Title: "First"}, {ID: "2", Title: "Second", Dependencies: []string{"1"}}},
+		OpenQuestions: []string{"Which format?"},
+	}
+	paths, err := s.SaveArtifacts(cwd, a)
+	if err != nil || len(paths) != 3 {
+		t.Fatalf("SaveArtifacts() = %v, %v", paths, err)
+	}
+	plan, _ := os.ReadFile(paths[ArtifactPlan])
+	if !strings.Contains(string(plan), "2. **Second** (after 1)") {
+		t.Errorf("PLAN.md = %s", plan)
+	}
+
+	// A later handoff without open questions drops the stale file
+	a.OpenQuestions = nil
+	if paths, _ = s.SaveArtifacts(cwd, a); len(paths) != 2 {
+		t.Errorf("second save wrote %v", paths)
+	}
+	if existing := ExistingArtifacts(cwd); len(existing) != 2 || existing[ArtifactOpenQuestions] != "" {
+		t.Errorf("ExistingArtifacts() = %v", existing)
+	}
+}
@@ -0,0 +1,75 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+// Kinds of pins kept outside the history; pinned messages are flagged in
+// place with protocol.Message.Pinned
+const (
+	PinFile = "file"
+	PinNote = "note"
+)
+
+// maxPinnedFileChars caps how much of a pinned file goes into each request
+const maxPinnedFileChars = 20000
+
+// Pin is a file or note sent with every request of a session. It lives in
+// the system prompt, so condensation and pruning never touch it.
+type Pin struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Path      string    `json:"path,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RenderPins renders pins as a system prompt section. Files are read on
+// every call, so the model sees their current content; paths are relative
+// to root.
+func RenderPins(pins []Pin, root string) string {
+	if len(pins) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\n## Pinned Context\nThe user pinned these; they stay available for the whole session.\n")
+	for _, p := range pins {
+		switch p.Kind {
+		case PinNote:
+			fmt.Fprintf(&sb, "\n### Note %s\n%s\n", p.ID, p.Note)
+		case PinFile:
+			path := p.Path
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(root, path)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				fmt.Fprintf(&sb, "\n### File %s (%s)\n[Unreadable: %v]\n", p.Path, p.ID, err)
+				continue
+			}
+			content := string(data)
+			if len(content) > maxPinnedFileChars {
+				content = content[:maxPinnedFileChars] + "\n[... truncated ...]"
+			}
+			fmt.Fprintf(&sb, "\n### File %s (%s)\n```\n%s\n```\n", p.Path, p.ID, content)
+		}
+	}
+	return sb.String()
+}
+
+// PinnedMessageTokens is the budgeted size of the pinned messages
+func PinnedMessageTokens(messages []protocol.Message) int {
+	tokens := 0
+	for _, m := range messages {
+		if m.Pinned {
+			tokens += EstimateMessageBudgetedTokens(m)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,107 @@
+package context
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+type stubSummarizer struct{}
+
+func (stubSummarizer) Summarize(ctx context.Context, prompt string) (string, error) {
+	return "summary", nil
+}
+
+func pinnedHistory() []protocol.Message {
+	messages := []protocol.Message{{Role: "user", Content: "Initial task"}}
+	for i := 0; i < 30; i++ {
+		messages = append(messages,
+			protocol.Message{Role: "user", Content: fmt.Sprintf("question %d %s", i, strings.Repeat("filler ", 40))},
+			protocol.Message{Role: "assistant", Content: fmt.Sprintf("answer %d %s", i, strings.Repeat("filler ", 40))})
+	}
+	messages[3].Content = "never deploy on Fridays"
+	messages[3].Pinned = true
+	return messages
+}
+
+func hasMessage(messages []protocol.Message, content string) bool {
+	for _, m := range messages {
+		if m.Content == content {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPruneMessages_KeepsPinned(t *testing.T) {
+	wm := NewWindowManager(1500)
+	messages := pinnedHistory()
+
+	pruned := wm.PruneMessages(messages, "System prompt")
+	if len(pruned) >= len(messages) {
+		t.Fatalf("nothing was pruned: %d messages", len(pruned))
+	}
+	if !hasMessage(pruned, "never deploy on Fridays") {
+		t.Error("pinned message was pruned")
+	}
+}
+
+func TestCondense_KeepsPinned(t *testing.T) {
+	cm := NewCondenseManager(1000, 50, stubSummarizer{})
+	result, err := cm.Condense(context.Background(), pinnedHistory(), "")
+	if err != nil || !result.WasCondensed {
+		t.Fatalf("Condense() = %+v, %v", result, err)
+	}
+	if !hasMessage(result.Messages, "never deploy on Fridays") {
+		t.Error("pinned message was condensed")
+	}
+	if got := len(result.Messages); got != 1+1+1+cm.KeepRecent {
+		t.Errorf("condensed to %d messages, want first, summary, pinned and the recent ones", got)
+	}
+}
+
+func TestEvictFileContent_SkipsPinned(t *testing.T) {
+	wm := NewWindowManager(1000)
+	big := strings.Repeat("x", 3000)
+	messages := []protocol.Message{{Role: "user", Content: "Initial task"}}
+	for i := 0; i < 2; i++ {
+		messages = append(messages, protocol.Message{Role: "user", ToolResults: []protocol.ToolResultBlock{{ToolUseID: fmt.Sprint(i), Content: big}}})
+	}
+	messages[1].Pinned = true
+	for i := 0; i < 8; i++ {
+		messages = append(messages, protocol.Message{Role: "assistant", Content: "ok"})
+	}
+
+	evicted := wm.EvictFileContent(messages)
+	if evicted[1].ToolResults[0].Content != big {
+		t.Error("pinned tool result was evicted")
+	}
+	if evicted[2].ToolResults[0].Content == big {
+		t.Error("unpinned tool result was kept")
+	}
+}
+
+func TestRenderPins(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "spec.md"), []byte("the spec"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := RenderPins([]Pin{
+		{ID: "p1", Kind: PinFile, Path: "spec.md"},
+		{ID: "p2", Kind: PinNote, Note: "use tabs"},
+		{ID: "p3", Kind: PinFile, Path: "gone.md"},
+	}, dir)
+	for _, want := range []string{"## Pinned Context", "the spec", "use tabs", "gone.md (p3)\n[Unreadable"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("RenderPins() lacks %q:\n%s", want, out)
+		}
+	}
+	if RenderPins(nil, dir) != "" {
+		t.Error("RenderPins(nil) should be empty")
+	}
+}
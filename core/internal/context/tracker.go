@@ -1,13 +1,18 @@
 package context
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/git"
 )
 
 // Tracker defines interface for context providers
@@ -17,12 +22,20 @@ type Tracker interface {
 
 // EnvironmentTracker tracks system environment
 type EnvironmentTracker struct {
-	cwd string
+	cwd  string
+	git  *git.Manager
+	jobs func() []string // Running background jobs, see SetJobs
+
+	mu        sync.Mutex
+	gitAt     time.Time // When gitStatus was read
+	gitStatus string
+	toolchain *string            // Detected once, see toolchainVersions
+	tests     map[string]testRun // Last run of each test command
 }
 
 // NewEnvironmentTracker creates a new environment tracker
 func NewEnvironmentTracker(cwd string) *EnvironmentTracker {
-	return &EnvironmentTracker{cwd: cwd}
+	return &EnvironmentTracker{cwd: cwd, git: git.NewManager(cwd), tests: make(map[string]testRun)}
 }
 
 // GetCwd returns the tracked current working directory
@@ -47,6 +60,19 @@ func (e *EnvironmentTracker) GetContext() string {
 		sb.WriteString("- Project Type: Python\n")
 	}
 
+	if tc := e.toolchainVersions(); tc != "" {
+		sb.WriteString(fmt.Sprintf("- Toolchain: %s\n", tc))
+	}
+	if st := e.gitContext(); st != "" {
+		sb.WriteString(st)
+	}
+	if e.jobs != nil {
+		if jobs := e.jobs(); len(jobs) > 0 {
+			sb.WriteString(fmt.Sprintf("- Background jobs running: %s\n", strings.Join(jobs, "; ")))
+		}
+	}
+	sb.WriteString(e.testContext())
+
 	sb.WriteString(fmt.Sprintf("- Time: %s\n", time.Now().Format(time.RFC1123)))
 
 	return sb.String()
@@ -55,43 +81,148 @@ func (e *EnvironmentTracker) GetContext() string {
 // FileTracker tracks files relevant to the session
 type FileTracker struct {
 	mu            sync.RWMutex
-	accessedFiles map[string]time.Time
+	accessedFiles map[string]*trackedFile
+	queries       []string // Recent code search queries, oldest first
+}
+
+// trackedFile is a file the agent read or wrote, stamped at that moment so
+// later changes made underneath it can be flagged
+type trackedFile struct {
+	at      time.Time // Last access
+	hash    string    // Content hash at last access; empty if unreadable
+	modTime time.Time // Stat at the last freshness check
+	size    int64
+	changed bool // Content differs from the last access
+	deleted bool
 }
 
+const (
+	maxTrackedQueries = 20            // Search queries kept for focusing the repo map
+	maxTrackedFiles   = 100           // Least recently used files beyond this are forgotten
+	fileDecayAfter    = 2 * time.Hour // Files untouched this long drop out of the context
+	fileContextBudget = 600           // Tokens the accessed files list may take in the prompt
+	maxHashedFileSize = 8 << 20       // Larger files are compared by size and mtime only
+)
+
 // NewFileTracker creates a new file tracker
 func NewFileTracker() *FileTracker {
 	return &FileTracker{
-		accessedFiles: make(map[string]time.Time),
+		accessedFiles: make(map[string]*trackedFile),
 	}
 }
 
-// AddFile marks a file as accessed
+// AddFile marks a file as accessed and stamps its current content
 func (f *FileTracker) AddFile(path string) {
 	if path == "" {
 		return
 	}
+	entry := &trackedFile{at: time.Now()}
+	entry.stamp(path)
+	entry.hash = entry.contentHash(path)
+
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	f.accessedFiles[path] = time.Now()
+	f.accessedFiles[path] = entry
+	if len(f.accessedFiles) > maxTrackedFiles {
+		oldest := ""
+		for p, e := range f.accessedFiles {
+			if oldest == "" || e.at.Before(f.accessedFiles[oldest].at) {
+				oldest = p
+			}
+		}
+		delete(f.accessedFiles, oldest)
+	}
+}
+
+// stamp records the file's stat, reporting whether it changed since the last stamp
+func (e *trackedFile) stamp(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		was := e.deleted
+		e.deleted = true
+		return !was
+	}
+	moved := e.deleted || !info.ModTime().Equal(e.modTime) || info.Size() != e.size
+	e.deleted = false
+	e.modTime, e.size = info.ModTime(), info.Size()
+	return moved
+}
+
+func (e *trackedFile) contentHash(path string) string {
+	if e.deleted || e.size > maxHashedFileSize {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
 }
 
-// GetContext returns the list of recently accessed files
+// refresh re-checks the file against its content at the last access
+func (e *trackedFile) refresh(path string) {
+	if !e.stamp(path) || e.deleted {
+		return
+	}
+	if e.hash == "" {
+		e.changed = true // Too large to hash: the stat moved
+		return
+	}
+	e.changed = e.contentHash(path) != e.hash
+}
+
+// GetContext lists the recently accessed files, most recent first, within
+// fileContextBudget. Files changed or deleted since the agent last read
+// them are marked, so it re-reads them instead of trusting stale content.
 func (f *FileTracker) GetContext() string {
-	f.mu.RLock()
-	defer f.mu.RUnlock()
+	f.mu.Lock()
+	defer f.mu.Unlock()
 
-	if len(f.accessedFiles) == 0 {
+	paths := f.liveLocked()
+	if len(paths) == 0 {
 		return ""
 	}
 
 	var sb strings.Builder
 	sb.WriteString("## Accessed Files\n")
-	for path := range f.accessedFiles {
-		sb.WriteString(fmt.Sprintf("- %s\n", path))
+	used := 0
+	for i, path := range paths {
+		entry := f.accessedFiles[path]
+		entry.refresh(path)
+		line := fmt.Sprintf("- %s", path)
+		switch {
+		case entry.deleted:
+			line += " (deleted since you last accessed it)"
+		case entry.changed:
+			line += " (changed since you last read it; re-read before editing)"
+		}
+		line += "\n"
+		if used += EstimateTokens(line); used > fileContextBudget && i > 0 {
+			sb.WriteString(fmt.Sprintf("- ... and %d less recent files\n", len(paths)-i))
+			break
+		}
+		sb.WriteString(line)
 	}
 	return sb.String()
 }
 
+// liveLocked drops decayed files and returns the rest, most recent first
+func (f *FileTracker) liveLocked() []string {
+	paths := make([]string, 0, len(f.accessedFiles))
+	for path, entry := range f.accessedFiles {
+		if time.Since(entry.at) > fileDecayAfter {
+			delete(f.accessedFiles, path)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return f.accessedFiles[paths[i]].at.After(f.accessedFiles[paths[j]].at)
+	})
+	return paths
+}
+
 // GetFiles returns the list of accessed files as a slice
 func (f *FileTracker) GetFiles() []string {
 	f.mu.RLock()
@@ -103,3 +234,41 @@ func (f *FileTracker) GetFiles() []string {
 	}
 	return files
 }
+
+// RecentFiles returns up to n accessed files, most recently accessed first
+func (f *FileTracker) RecentFiles(n int) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	files := f.liveLocked()
+	if len(files) > n {
+		files = files[:n]
+	}
+	return files
+}
+
+// AddQuery records a code search query
+func (f *FileTracker) AddQuery(query string) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries = append(f.queries, query)
+	if len(f.queries) > maxTrackedQueries {
+		f.queries = f.queries[len(f.queries)-maxTrackedQueries:]
+	}
+}
+
+// RecentQueries returns up to n search queries, most recent first
+func (f *FileTracker) RecentQueries(n int) []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var queries []string
+	for i := len(f.queries) - 1; i >= 0 && len(queries) < n; i-- {
+		queries = append(queries, f.queries[i])
+	}
+	return queries
+}
@@ -0,0 +1,75 @@
+package context
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileTracker_FlagsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	same, edited, gone := filepath.Join(dir, "same.go"), filepath.Join(dir, "edited.go"), filepath.Join(dir, "gone.go")
+	for _, p := range []string{same, edited, gone} {
+		os.WriteFile(p, []byte("package main\n"), 0644)
+	}
+	f := NewFileTracker()
+	for _, p := range []string{same, edited, gone} {
+		f.AddFile(p)
+	}
+
+	os.WriteFile(edited, []byte("package main\n\nfunc main() {}\n"), 0644)
+	os.Remove(gone)
+	ctx := f.GetContext()
+	for _, want := range []string{same + "\n", edited + " (changed since you last read it", gone + " (deleted"} {
+		if !strings.Contains(ctx, want) {
+			t.Errorf("context lacks %q:\n%s", want, ctx)
+		}
+	}
+
+	// Reading it again makes it fresh
+	f.AddFile(edited)
+	if ctx := f.GetContext(); strings.Contains(ctx, edited+" (changed") {
+		t.Errorf("re-read file still flagged:\n%s", ctx)
+	}
+
+	// Touching a file without changing its content is no change
+	os.WriteFile(same, []byte("package main\n"), 0644)
+	os.Chtimes(same, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	if ctx := f.GetContext(); strings.Contains(ctx, same+" (") {
+		t.Errorf("untouched content flagged:\n%s", ctx)
+	}
+}
+
+func TestFileTracker_DecayAndBudget(t *testing.T) {
+	f := NewFileTracker()
+	for i := 0; i < maxTrackedFiles+10; i++ {
+		f.AddFile(fmt.Sprintf("pkg/some/long/directory/name/file_%03d.go", i))
+	}
+	if n := len(f.GetFiles()); n != maxTrackedFiles {
+		t.Errorf("tracking %d files, want the %d most recent", n, maxTrackedFiles)
+	}
+	if files := f.RecentFiles(1); files[0] != fmt.Sprintf("pkg/some/long/directory/name/file_%03d.go", maxTrackedFiles+9) {
+		t.Errorf("RecentFiles() = %v", files)
+	}
+
+	ctx := f.GetContext()
+	if tokens := EstimateTokens(ctx); tokens > fileContextBudget+50 {
+		t.Errorf("file context takes %d tokens, budget %d", tokens, fileContextBudget)
+	}
+	if !strings.Contains(ctx, "less recent files") {
+		t.Errorf("context does not mention the files left out:\n%s", ctx)
+	}
+
+	// Files untouched for long drop out
+	f.mu.Lock()
+	for _, entry := range f.accessedFiles {
+		entry.at = entry.at.Add(-fileDecayAfter - time.Minute)
+	}
+	f.mu.Unlock()
+	if ctx := f.GetContext(); ctx != "" {
+		t.Errorf("decayed files still listed:\n%s", ctx)
+	}
+}
@@ -8,13 +8,28 @@ import (
 
 	sitter "github.com/smacker/go-tree-sitter"
 	"github.com/smacker/go-tree-sitter/golang"
+	"github.com/smacker/go-tree-sitter/java"
 	"github.com/smacker/go-tree-sitter/javascript"
-	// Uncomment when grammars are available/needed:
-	// "github.com/smacker/go-tree-sitter/python"
-	// "github.com/smacker/go-tree-sitter/typescript/typescript"
-	// "github.com/smacker/go-tree-sitter/rust"
+	"github.com/smacker/go-tree-sitter/python"
+	"github.com/smacker/go-tree-sitter/rust"
+	"github.com/smacker/go-tree-sitter/typescript/tsx"
+	"github.com/smacker/go-tree-sitter/typescript/typescript"
 )
 
+// SupportedExtensions lists the file extensions ParseDefinitions understands
+var SupportedExtensions = []string{".go", ".js", ".jsx", ".mjs", ".cjs", ".ts", ".mts", ".cts", ".tsx", ".py", ".rs", ".java"}
+
+// IsSupported reports whether ParseDefinitions can parse path
+func IsSupported(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range SupportedExtensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
 // Definition represents a code symbol (function, class, etc.)
 type Definition struct {
 	Type      string // "function", "class", "method", "struct", "interface"
@@ -53,17 +68,20 @@ func (lp *LanguageParser) Close() {
 func (lp *LanguageParser) GetLanguageForFile(path string) (*sitter.Language, error) {
 	ext := strings.ToLower(filepath.Ext(path))
 	switch ext {
-	case ".js", ".jsx", ".mjs":
+	case ".js", ".jsx", ".mjs", ".cjs":
 		return javascript.GetLanguage(), nil
 	case ".go":
 		return golang.GetLanguage(), nil
-	// Uncomment when grammars are installed:
-	// case ".ts", ".tsx":
-	// 	return typescript.GetLanguage(), nil
-	// case ".py":
-	// 	return python.GetLanguage(), nil
-	// case ".rs":
-	// 	return rust.GetLanguage(), nil
+	case ".ts", ".mts", ".cts":
+		return typescript.GetLanguage(), nil
+	case ".tsx":
+		return tsx.GetLanguage(), nil
+	case ".py":
+		return python.GetLanguage(), nil
+	case ".rs":
+		return rust.GetLanguage(), nil
+	case ".java":
+		return java.GetLanguage(), nil
 	default:
 		return nil, fmt.Errorf("unsupported language for extension: %s", ext)
 	}
@@ -90,16 +108,17 @@ func (lp *LanguageParser) ParseDefinitions(ctx context.Context, path string, sou
 
 	ext := strings.ToLower(filepath.Ext(path))
 	switch ext {
-	case ".js", ".jsx", ".mjs":
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".mts", ".cts", ".tsx":
+		// The TypeScript grammars extend JavaScript's node types
 		return lp.extractJavaScript(root, source), nil
 	case ".go":
 		return lp.extractGo(root, source), nil
-	// case ".ts", ".tsx":
-	// 	return lp.extractTypeScript(root, source), nil
-	// case ".py":
-	// 	return lp.extractPython(root, source), nil
-	// case ".rs":
-	// 	return lp.extractRust(root, source), nil
+	case ".py":
+		return lp.extractPython(root, source), nil
+	case ".rs":
+		return lp.extractRust(root, source), nil
+	case ".java":
+		return lp.extractJava(root, source), nil
 	default:
 		return nil, fmt.Errorf("no extractor for %s", ext)
 	}
@@ -154,7 +173,7 @@ func (lp *LanguageParser) extractJavaScript(root *sitter.Node, source []byte) *F
 				LineEnd:   int(node.EndPoint().Row) + 1,
 			})
 
-		case "class_declaration":
+		case "class_declaration", "abstract_class_declaration":
 			name := ""
 			if nameNode := node.ChildByFieldName("name"); nameNode != nil {
 				name = nameNode.Content(source)
@@ -166,6 +185,26 @@ func (lp *LanguageParser) extractJavaScript(root *sitter.Node, source []byte) *F
 				LineEnd:   int(node.EndPoint().Row) + 1,
 			})
 
+		case "variable_declarator":
+			// const handler = () => {} / function () {}
+			value := node.ChildByFieldName("value")
+			nameNode := node.ChildByFieldName("name")
+			if value != nil && nameNode != nil && nameNode.Type() == "identifier" {
+				switch value.Type() {
+				case "arrow_function", "function", "function_expression":
+					analysis.Definitions = append(analysis.Definitions, newDefinition(node, "function", nameNode.Content(source)))
+				}
+			}
+
+		case "interface_declaration":
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, "interface", fieldContent(node, "name", source)))
+
+		case "type_alias_declaration":
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, "type", fieldContent(node, "name", source)))
+
+		case "enum_declaration":
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, "enum", fieldContent(node, "name", source)))
+
 		case "method_definition":
 			name := ""
 			if nameNode := node.ChildByFieldName("name"); nameNode != nil {
@@ -252,3 +291,228 @@ func (lp *LanguageParser) extractGo(root *sitter.Node, source []byte) *FileAnaly
 	walk(root)
 	return &analysis
 }
+
+func (lp *LanguageParser) extractPython(root *sitter.Node, source []byte) *FileAnalysis {
+	var analysis FileAnalysis
+
+	var walk func(node *sitter.Node, inClass bool)
+	walk = func(node *sitter.Node, inClass bool) {
+		if node == nil {
+			return
+		}
+
+		switch node.Type() {
+		case "import_statement":
+			// import a.b, c as d
+			for i := 0; i < int(node.NamedChildCount()); i++ {
+				child := node.NamedChild(i)
+				if child.Type() == "aliased_import" {
+					child = child.ChildByFieldName("name")
+				}
+				if child != nil && child.Type() == "dotted_name" {
+					analysis.Imports = append(analysis.Imports, NormalizeImport(".py", child.Content(source)))
+				}
+			}
+
+		case "import_from_statement":
+			// from .pkg import x
+			if module := node.ChildByFieldName("module_name"); module != nil {
+				analysis.Imports = append(analysis.Imports, NormalizeImport(".py", module.Content(source)))
+			}
+
+		case "class_definition":
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, "class", fieldContent(node, "name", source)))
+			if body := node.ChildByFieldName("body"); body != nil {
+				walk(body, true)
+			}
+			return
+
+		case "function_definition":
+			typ := "function"
+			if inClass {
+				typ = "method"
+			}
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, typ, fieldContent(node, "name", source)))
+			// Nested functions are not part of the outline
+			return
+		}
+
+		for i := 0; i < int(node.ChildCount()); i++ {
+			child := node.Child(i)
+			// A decorator wrapper keeps the class scope of its definition
+			walk(child, inClass && (node.Type() == "block" || node.Type() == "decorated_definition"))
+		}
+	}
+
+	walk(root, false)
+	return &analysis
+}
+
+func (lp *LanguageParser) extractRust(root *sitter.Node, source []byte) *FileAnalysis {
+	var analysis FileAnalysis
+
+	var walk func(node *sitter.Node, inImpl bool)
+	walk = func(node *sitter.Node, inImpl bool) {
+		if node == nil {
+			return
+		}
+
+		switch node.Type() {
+		case "use_declaration":
+			if arg := node.ChildByFieldName("argument"); arg != nil {
+				if imp := NormalizeImport(".rs", arg.Content(source)); imp != "" {
+					analysis.Imports = append(analysis.Imports, imp)
+				}
+			}
+
+		case "mod_item":
+			name := fieldContent(node, "name", source)
+			if node.ChildByFieldName("body") == nil {
+				// "mod foo;" pulls in foo.rs
+				analysis.Imports = append(analysis.Imports, name)
+			}
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, "module", name))
+
+		case "function_item":
+			typ := "function"
+			if inImpl {
+				typ = "method"
+			}
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, typ, fieldContent(node, "name", source)))
+			return
+
+		case "struct_item":
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, "struct", fieldContent(node, "name", source)))
+		case "enum_item":
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, "enum", fieldContent(node, "name", source)))
+		case "trait_item":
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, "trait", fieldContent(node, "name", source)))
+			inImpl = true
+		case "type_item":
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, "type", fieldContent(node, "name", source)))
+		case "macro_definition":
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, "macro", fieldContent(node, "name", source)))
+
+		case "impl_item":
+			name := fieldContent(node, "type", source)
+			if trait := node.ChildByFieldName("trait"); trait != nil {
+				name = trait.Content(source) + " for " + name
+			}
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, "impl", name))
+			inImpl = true
+		}
+
+		for i := 0; i < int(node.ChildCount()); i++ {
+			walk(node.Child(i), inImpl)
+		}
+	}
+
+	walk(root, false)
+	return &analysis
+}
+
+func (lp *LanguageParser) extractJava(root *sitter.Node, source []byte) *FileAnalysis {
+	var analysis FileAnalysis
+
+	var walk func(node *sitter.Node)
+	walk = func(node *sitter.Node) {
+		if node == nil {
+			return
+		}
+
+		switch node.Type() {
+		case "import_declaration":
+			// import com.acme.util.Strings; / import static ...; / import com.acme.*;
+			for i := 0; i < int(node.NamedChildCount()); i++ {
+				child := node.NamedChild(i)
+				if child.Type() == "scoped_identifier" || child.Type() == "identifier" {
+					analysis.Imports = append(analysis.Imports, NormalizeImport(".java", child.Content(source)))
+					break
+				}
+			}
+
+		case "class_declaration", "record_declaration":
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, "class", fieldContent(node, "name", source)))
+		case "interface_declaration":
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, "interface", fieldContent(node, "name", source)))
+		case "enum_declaration":
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, "enum", fieldContent(node, "name", source)))
+		case "method_declaration", "constructor_declaration":
+			analysis.Definitions = append(analysis.Definitions, newDefinition(node, "method", fieldContent(node, "name", source)))
+			return
+		}
+
+		for i := 0; i < int(node.ChildCount()); i++ {
+			walk(node.Child(i))
+		}
+	}
+
+	walk(root)
+	return &analysis
+}
+
+// NormalizeImport turns a language's module reference into a slash path so
+// it can be matched against file paths: "pkg.util" (Python, Java) becomes
+// "pkg/util" and "crate::config::Settings" (Rust) becomes "config".
+// JavaScript and Go imports are already paths and only lose their quotes.
+func NormalizeImport(ext string, imp string) string {
+	imp = strings.Trim(strings.TrimSpace(imp), "\"'`")
+	switch strings.ToLower(ext) {
+	case ".py":
+		// Leading dots are relative: ".util" -> "./util", "..util" -> "../util"
+		dots := len(imp) - len(strings.TrimLeft(imp, "."))
+		rest := strings.ReplaceAll(imp[dots:], ".", "/")
+		switch {
+		case dots == 0:
+			return rest
+		case dots == 1:
+			return "./" + rest
+		default:
+			return strings.Repeat("../", dots-1) + rest
+		}
+
+	case ".java":
+		return strings.TrimSuffix(strings.ReplaceAll(strings.TrimSuffix(imp, ".*"), ".", "/"), "/")
+
+	case ".rs":
+		// Drop the use-group and item names, keeping the module path
+		if i := strings.Index(imp, "{"); i >= 0 {
+			imp = imp[:i]
+		}
+		if i := strings.Index(imp, " as "); i >= 0 {
+			imp = imp[:i]
+		}
+		var parts []string
+		for _, seg := range strings.Split(strings.Trim(imp, ":"), "::") {
+			seg = strings.TrimSpace(seg)
+			switch {
+			case seg == "" || seg == "*" || seg == "crate" || seg == "self" || seg == "super":
+				continue
+			case seg[0] >= 'A' && seg[0] <= 'Z':
+				// Types, traits and constants live in the module before them
+				return strings.Join(parts, "/")
+			}
+			parts = append(parts, seg)
+		}
+		return strings.Join(parts, "/")
+	}
+	return imp
+}
+
+// newDefinition builds a Definition spanning node
+func newDefinition(node *sitter.Node, typ, name string) Definition {
+	return Definition{
+		Type:      typ,
+		Name:      name,
+		LineStart: int(node.StartPoint().Row) + 1,
+		LineEnd:   int(node.EndPoint().Row) + 1,
+	}
+}
+
+// fieldContent returns the text of node's named field, or ""
+func fieldContent(node *sitter.Node, field string, source []byte) string {
+	if child := node.ChildByFieldName(field); child != nil {
+		return child.Content(source)
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package context
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseDefinitions_Languages(t *testing.T) {
+	cases := map[string]struct {
+		code string
+		want map[string]string // name -> type
+	}{
+		"a.ts": {
+			code: "interface Props { id: string }\ntype ID = string;\nenum Color { Red }\nexport const load = async () => {};\nclass Store {\n  get() {}\n}\n",
+			want: map[string]string{"Props": "interface", "ID": "type", "Color": "enum", "load": "function", "Store": "class", "get": "method"},
+		},
+		"a.py": {
+			code: "def helper():\n    pass\n\nclass Repo:\n    @property\n    def name(self):\n        return 1\n",
+			want: map[string]string{"helper": "function", "Repo": "class", "name": "method"},
+		},
+		"a.rs": {
+			code: "pub trait Shape { fn area(&self) -> f64; }\nstruct Circle;\nimpl Shape for Circle {\n    fn area(&self) -> f64 { 1.0 }\n}\nfn main() {}\n",
+			want: map[string]string{"Shape": "trait", "Circle": "struct", "Shape for Circle": "impl", "area": "method", "main": "function"},
+		},
+		"A.java": {
+			code: "public class A {\n  public A() {}\n  int size() { return 0; }\n}\ninterface B {}\n",
+			want: map[string]string{"A": "class", "size": "method", "B": "interface"},
+		},
+	}
+
+	lp := NewLanguageParser()
+	defer lp.Close()
+	for path, tc := range cases {
+		analysis, err := lp.ParseDefinitions(context.Background(), path, []byte(tc.code))
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		got := make(map[string]string)
+		for _, d := range analysis.Definitions {
+			if _, seen := got[d.Name]; !seen {
+				got[d.Name] = d.Type
+			}
+		}
+		for name, typ := range tc.want {
+			if got[name] != typ {
+				t.Errorf("%s: %s = %q, want %q (all: %v)", path, name, got[name], typ, got)
+			}
+		}
+	}
+}
+
+func TestNormalizeImport(t *testing.T) {
+	cases := []struct{ ext, in, want string }{
+		{".py", "pkg.util", "pkg/util"},
+		{".py", "..models", "../models"},
+		{".java", "com.acme.*", "com/acme"},
+		{".rs", "crate::config::{Settings, load}", "config"},
+		{".rs", "std::collections::HashMap", "std/collections"},
+		{".ts", "'./api'", "./api"},
+	}
+	for _, c := range cases {
+		if got := NormalizeImport(c.ext, c.in); got != c.want {
+			t.Errorf("NormalizeImport(%q, %q) = %q, want %q", c.ext, c.in, got, c.want)
+		}
+	}
+}
@@ -23,6 +23,7 @@ type ContextResult struct {
 	WasTruncated bool
 	Summary      string
 	SystemPrompt string // Modified system prompt (e.g. with Condensed Context)
+	PinnedTokens int    // Part of TokensUsed held by pinned messages
 	TokensUsed   int
 	TokensMax    int
 	Percentage   float64
@@ -110,6 +111,7 @@ func (wm *WindowManager) ManageContext(ctx context.Context, messages []protocol.
 				result.Summary = condenseResult.Summary
 				result.TokensUsed = condenseResult.TokensAfter
 				result.Percentage = float64(condenseResult.TokensAfter) / float64(wm.MaxTokens) * 100
+				result.PinnedTokens = PinnedMessageTokens(result.Messages)
 				log.Printf("[Context] Condensation successful: %d -> %d tokens", condenseResult.TokensBefore, condenseResult.TokensAfter)
 				return result, nil
 			}
@@ -137,12 +139,14 @@ func (wm *WindowManager) ManageContext(ctx context.Context, messages []protocol.
 		}
 	}
 
+	result.PinnedTokens = PinnedMessageTokens(result.Messages)
 	return result, nil
 }
 
 // PruneMessages reduces the message list to fit within MaxTokens.
 // It preserves the System Prompt (usually handled separately) and the most recent messages.
 // This is a simple sliding window approach enhanced with file content eviction.
+// Pinned messages are always kept, and their tokens are reserved first.
 // IMPORTANT: This function ensures that tool result messages are always kept together
 // with their corresponding assistant message containing tool_calls to avoid API errors.
 func (wm *WindowManager) PruneMessages(messages []protocol.Message, systemPrompt string) []protocol.Message {
@@ -173,6 +177,7 @@ func (wm *WindowManager) PruneMessages(messages []protocol.Message, systemPrompt
 	firstMsg := messages[0]
 	firstMsgTokens := EstimateMessageBudgetedTokens(firstMsg)
 	availableTokens -= firstMsgTokens
+	availableTokens -= PinnedMessageTokens(messages[1:])
 
 	if len(messages) == 0 {
 		return messages
@@ -188,6 +193,9 @@ func (wm *WindowManager) PruneMessages(messages []protocol.Message, systemPrompt
 	for i := len(messages) - 1; i >= 1; i-- {
 		msg := messages[i]
 		tokens := EstimateMessageBudgetedTokens(msg)
+		if msg.Pinned {
+			tokens = 0 // Reserved above
+		}
 
 		// Hard limit: always keep at least 3 most recent messages if we have them
 		// and they are not huge (more than 20% of window each)
@@ -261,8 +269,14 @@ func (wm *WindowManager) PruneMessages(messages []protocol.Message, systemPrompt
 		}
 	}
 
-	// Build the keep list
-	keep := messages[cutoffIndex:]
+	// Build the keep list: pinned messages from before the cutoff, then the window
+	var keep []protocol.Message
+	for _, msg := range messages[1:cutoffIndex] {
+		if msg.Pinned {
+			keep = append(keep, msg)
+		}
+	}
+	keep = append(keep, messages[cutoffIndex:]...)
 
 	// PASS 3: Final validation - ensure no orphaned tool results
 	// Check that for every tool result, its corresponding assistant message is present
@@ -294,6 +308,7 @@ func (wm *WindowManager) PruneMessages(messages []protocol.Message, systemPrompt
 				cleanMsg := protocol.Message{
 					Role:    msg.Role,
 					Content: msg.Content,
+					Pinned:  msg.Pinned,
 				}
 				if cleanMsg.Content != "" {
 					validKeep = append(validKeep, cleanMsg)
@@ -334,6 +349,9 @@ func (wm *WindowManager) EvictFileContent(messages []protocol.Message) []protoco
 	// Process messages before the intact window, skipping the first (pinned) message
 	for i := 1; i < len(result)-keepIntact; i++ {
 		msg := &result[i]
+		if msg.Pinned {
+			continue
+		}
 		// If it's a user message containing tool results (which are usually the large ones)
 		if msg.Role == "user" && len(msg.ToolResults) > 0 {
 			for idx := range msg.ToolResults {
@@ -392,7 +410,7 @@ func (wm *WindowManager) OptimizeToolResults(messages []protocol.Message) []prot
 				}
 
 				// If we already saw this tool call, mark the previous one for condensation
-				if prev, exists := lastResults[info]; exists {
+				if prev, exists := lastResults[info]; exists && !optimizedMessages[prev.msgIdx].Pinned {
 					// Replace previous result with a placeholder
 					prevMsg := &optimizedMessages[prev.msgIdx]
 					// Make sure we have a slice copy if we're modifying it (though copy() above handled top level)
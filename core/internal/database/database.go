@@ -0,0 +1,141 @@
+// Package database backs the sql_* tools: it opens the connections named in
+// settings, runs queries read-only unless asked otherwise, renders result
+// sets as truncated text tables and describes schemas.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	_ "github.com/lib/pq"           // postgres driver
+	_ "github.com/mattn/go-sqlite3" // sqlite3 driver
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+)
+
+// Pool keeps one *sql.DB per configured connection
+type Pool struct {
+	workspace string
+
+	mu  sync.Mutex
+	dbs map[string]*conn
+}
+
+type conn struct {
+	driver string
+	dsn    string
+	db     *sql.DB
+}
+
+// NewPool resolves relative SQLite paths against workspace
+func NewPool(workspace string) *Pool {
+	return &Pool{workspace: workspace, dbs: make(map[string]*conn)}
+}
+
+// Select picks a connection by name. An empty name is accepted when exactly
+// one connection is configured.
+func Select(databases map[string]config.DatabaseSettings, name string) (string, config.DatabaseSettings, error) {
+	if len(databases) == 0 {
+		return "", config.DatabaseSettings{}, fmt.Errorf("no databases configured: add tools.databases to .ricochet/config.yaml, e.g. {dev: {driver: postgres, dsn: \"$DATABASE_URL\"}}")
+	}
+	if name == "" {
+		if len(databases) > 1 {
+			return "", config.DatabaseSettings{}, fmt.Errorf("several databases are configured, name one of: %s", strings.Join(Names(databases), ", "))
+		}
+		for n, s := range databases {
+			return n, s, nil
+		}
+	}
+	s, ok := databases[name]
+	if !ok {
+		return "", config.DatabaseSettings{}, fmt.Errorf("unknown database %q (configured: %s)", name, strings.Join(Names(databases), ", "))
+	}
+	return name, s, nil
+}
+
+// Names lists the configured connection names, sorted
+func Names(databases map[string]config.DatabaseSettings) []string {
+	names := make([]string, 0, len(databases))
+	for n := range databases {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns an open handle for the named connection, reopening it when the
+// settings changed since the last call
+func (p *Pool) Get(ctx context.Context, name string, s config.DatabaseSettings) (*DB, error) {
+	driver, dsn, err := p.resolve(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if c, ok := p.dbs[name]; ok {
+		if c.driver == driver && c.dsn == dsn {
+			return &DB{driver: driver, db: c.db}, nil
+		}
+		c.db.Close()
+		delete(p.dbs, name)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+	db.SetMaxOpenConns(2)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect to %s: %w", name, err)
+	}
+	p.dbs[name] = &conn{driver: driver, dsn: dsn, db: db}
+	return &DB{driver: driver, db: db}, nil
+}
+
+// Close closes every open connection
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for name, c := range p.dbs {
+		c.db.Close()
+		delete(p.dbs, name)
+	}
+}
+
+// resolve maps settings to a database/sql driver name and DSN
+func (p *Pool) resolve(s config.DatabaseSettings) (string, string, error) {
+	dsn := os.ExpandEnv(strings.TrimSpace(s.DSN))
+	if dsn == "" {
+		return "", "", fmt.Errorf("database DSN is empty (check the environment variables it references)")
+	}
+	switch strings.ToLower(s.Driver) {
+	case "postgres", "postgresql", "pg":
+		return "postgres", dsn, nil
+	case "sqlite", "sqlite3":
+		if dsn != ":memory:" && !strings.HasPrefix(dsn, "file:") && !filepath.IsAbs(dsn) {
+			dsn = filepath.Join(p.workspace, dsn)
+		}
+		return "sqlite3", dsn, nil
+	default:
+		return "", "", fmt.Errorf("unsupported database driver %q (use postgres or sqlite)", s.Driver)
+	}
+}
+
+// DB is an open connection with driver-specific behaviour
+type DB struct {
+	driver string
+	db     *sql.DB
+}
+
+// Driver returns "postgres" or "sqlite3"
+func (d *DB) Driver() string {
+	return d.driver
+}
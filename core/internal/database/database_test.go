@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+)
+
+func TestSQLite(t *testing.T) {
+	ctx := context.Background()
+	pool := NewPool(t.TempDir())
+	defer pool.Close()
+
+	dbs := map[string]config.DatabaseSettings{"dev": {Driver: "sqlite", DSN: "dev.db"}}
+	name, s, err := Select(dbs, "")
+	if err != nil || name != "dev" {
+		t.Fatalf("select = %q, %v", name, err)
+	}
+	db, err := pool.Get(ctx, name, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := db.Execute(ctx, `CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		CREATE INDEX users_name ON users(name);
+		INSERT INTO users (name) VALUES ('ann'), ('bob|by'), (NULL);`)
+	if err != nil || n != 3 {
+		t.Fatalf("execute = %d, %v", n, err)
+	}
+	if _, err := db.Execute(ctx, "INSERT INTO users (name) VALUES ('x'); INSERT INTO nope VALUES (1)"); err == nil {
+		t.Fatal("expected failing migration")
+	}
+
+	res, err := db.Query(ctx, "SELECT id, name FROM users ORDER BY id", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := res.Format()
+	if !strings.Contains(out, "| 2 | bob\\|by |") || !strings.Contains(out, "first 2 rows; more are available") {
+		t.Errorf("formatted result:\n%s", out)
+	}
+	res, _ = db.Query(ctx, "SELECT count(*) FROM users WHERE name IS NULL OR name = 'x'", 10)
+	if res.Rows[0][0] != "1" {
+		t.Errorf("failed migration was not rolled back: %v", res.Rows)
+	}
+
+	// Reads are enforced by the connection, not just the keyword check
+	if _, err := db.Query(ctx, "WITH x AS (SELECT 1) DELETE FROM users", 10); err == nil {
+		t.Error("write ran through Query")
+	}
+	if _, err := db.Query(ctx, "SELECT 1; DELETE FROM users", 10); err == nil {
+		t.Error("second statement ran through Query")
+	}
+	if _, err := db.Execute(ctx, "DELETE FROM users WHERE name IS NULL"); err != nil {
+		t.Errorf("connection stayed read-only: %v", err)
+	}
+
+	schema, err := db.Schema(ctx, "users")
+	if err != nil || !strings.Contains(schema, "CREATE TABLE users") || !strings.Contains(schema, "CREATE INDEX users_name") {
+		t.Errorf("schema = %q, %v", schema, err)
+	}
+}
+
+func TestIsReadQuery(t *testing.T) {
+	for q, want := range map[string]bool{
+		"select 1":                            true,
+		"-- count\n/* all */ SELECT count(*)": true,
+		"EXPLAIN ANALYZE SELECT 1":            true,
+		"pragma table_info(users)":            true,
+		"PRAGMA journal_mode = WAL":           false,
+		"UPDATE users SET name = 'x'":         false,
+		"  drop table users":                  false,
+		"":                                    false,
+		"SELECT 1; COMMIT; DELETE FROM t":     false,
+		"SELECT 1;\n-- done\n":                true,
+	} {
+		if got := IsReadQuery(q); got != want {
+			t.Errorf("IsReadQuery(%q) = %v", q, got)
+		}
+	}
+}
+
+func TestCountStatements(t *testing.T) {
+	for q, want := range map[string]int{
+		"SELECT 1":                        1,
+		"SELECT 1;;  ":                    1,
+		"SELECT 1; COMMIT; DELETE FROM t": 3,
+		"SELECT 'a;b', \"c;d\" -- e;f\n/* g;h */": 1,
+		"SELECT 'it''s'; SELECT 2":                2,
+		`SELECT 'a\'; DELETE FROM t; --'`:         2,
+		"SELECT $$a;b$$, $x$c;$$;d$x$":            1,
+		"SELECT $1; DELETE FROM t":                2,
+		"SELECT a$b$; DELETE FROM t; $b$":         3,
+		"/* only a comment */":                    0,
+	} {
+		if got := CountStatements(q); got != want {
+			t.Errorf("CountStatements(%q) = %d, want %d", q, got, want)
+		}
+	}
+}
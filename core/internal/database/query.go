@@ -0,0 +1,279 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxCellRunes caps each value in a rendered result set
+	maxCellRunes = 200
+	// maxTableBytes caps a rendered result set
+	maxTableBytes = 32 * 1024
+)
+
+// readKeywords start statements that cannot change data
+var readKeywords = map[string]bool{
+	"select": true, "with": true, "explain": true, "show": true,
+	"values": true, "table": true, "describe": true, "pragma": true,
+}
+
+var sqlComment = regexp.MustCompile(`(?s)^\s*(--[^\n]*\n|/\*.*?\*/|\s)*`)
+
+// IsReadQuery reports whether query is a single statement that looks like
+// a read. It only gives a friendlier error: reads always run in a read-only
+// transaction as well.
+func IsReadQuery(query string) bool {
+	if CountStatements(query) != 1 {
+		return false
+	}
+	q := sqlComment.ReplaceAllString(query, "")
+	words := strings.FieldsFunc(q, func(r rune) bool { return !unicode.IsLetter(r) })
+	if len(words) == 0 {
+		return false
+	}
+	word := strings.ToLower(words[0])
+	if word == "pragma" {
+		return !strings.Contains(q, "=") // PRAGMA x = y changes settings
+	}
+	return readKeywords[word]
+}
+
+// CountStatements returns the number of statements in query, separated by
+// semicolons outside quotes, comments and dollar-quoted strings. Backslashes
+// are not taken as escapes, so an ambiguous string counts as more
+// statements, never fewer.
+func CountStatements(query string) int {
+	count := 0
+	pending := false // The current statement has content
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == ';':
+			if pending {
+				count++
+			}
+			pending = false
+			continue
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end
+			}
+			continue
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 3
+			}
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			// Doubled quotes inside are read as two adjacent strings
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 1
+			}
+		case c == '$' && (i == 0 || !isIdentByte(query[i-1])):
+			if tag, ok := dollarTag(query[i:]); ok {
+				end := strings.Index(query[i+len(tag):], tag)
+				if end < 0 {
+					i = len(query)
+				} else {
+					i += len(tag) + end + len(tag) - 1
+				}
+			}
+		case unicode.IsSpace(rune(c)):
+			continue
+		}
+		pending = true
+	}
+	if pending {
+		count++
+	}
+	return count
+}
+
+// dollarTag returns the opening tag of a PostgreSQL dollar-quoted string
+// ($$ or $name$) at the start of s
+func dollarTag(s string) (string, bool) {
+	for j := 1; j < len(s); j++ {
+		switch {
+		case s[j] == '$':
+			return s[:j+1], true
+		case !isIdentByte(s[j]) || (j == 1 && s[j] >= '0' && s[j] <= '9'):
+			return "", false // $1 is a parameter
+		}
+	}
+	return "", false
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 0x80 || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// Result is a (possibly truncated) result set
+type Result struct {
+	Columns   []string
+	Rows      [][]string
+	Truncated bool // More rows were available than requested
+}
+
+// Query runs a read-only query and returns at most maxRows rows. Only one
+// statement is accepted: on PostgreSQL a COMMIT in a second statement would
+// end the read-only transaction.
+func (d *DB) Query(ctx context.Context, query string, maxRows int) (*Result, error) {
+	if n := CountStatements(query); n != 1 {
+		return nil, fmt.Errorf("expected a single statement, got %d", n)
+	}
+	if d.driver == "sqlite3" {
+		// SQLite ignores read-only transactions; query_only is per connection
+		c, err := d.db.Conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer c.Close()
+		if _, err := c.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+			return nil, err
+		}
+		defer c.ExecContext(context.Background(), "PRAGMA query_only = OFF")
+		rows, err := c.QueryContext(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		return collect(rows, maxRows)
+	}
+
+	tx, err := d.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return collect(rows, maxRows)
+}
+
+// Execute runs statements (DDL/DML, possibly several separated by
+// semicolons) in one transaction and returns the rows affected, or -1 when
+// the driver cannot tell
+func (d *DB) Execute(ctx context.Context, statements string) (int64, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, statements)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return -1, nil
+	}
+	return n, nil
+}
+
+func collect(rows *sql.Rows, maxRows int) (*Result, error) {
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Columns: cols}
+	values := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if len(res.Rows) >= maxRows {
+			res.Truncated = true
+			break
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make([]string, len(cols))
+		for i, v := range values {
+			row[i] = formatValue(v)
+		}
+		res.Rows = append(res.Rows, row)
+	}
+	return res, rows.Err()
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		if !utf8.Valid(v) {
+			return fmt.Sprintf("<%d bytes>", len(v))
+		}
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Format renders the result as a Markdown table
+func (r *Result) Format() string {
+	if len(r.Columns) == 0 {
+		return "(Statement returned no columns)"
+	}
+	var sb strings.Builder
+	sb.WriteString("| " + strings.Join(escapeCells(r.Columns), " | ") + " |\n")
+	sb.WriteString("|" + strings.Repeat(" --- |", len(r.Columns)) + "\n")
+	shown := 0
+	for _, row := range r.Rows {
+		line := "| " + strings.Join(escapeCells(row), " | ") + " |\n"
+		if sb.Len()+len(line) > maxTableBytes {
+			break
+		}
+		sb.WriteString(line)
+		shown++
+	}
+
+	switch {
+	case len(r.Rows) == 0:
+		sb.WriteString("\n(0 rows)")
+	case shown < len(r.Rows):
+		fmt.Fprintf(&sb, "\n(Showing %d of %d fetched rows; output limit reached. Select fewer columns or add LIMIT.)", shown, len(r.Rows))
+	case r.Truncated:
+		fmt.Fprintf(&sb, "\n(Showing the first %d rows; more are available. Raise max_rows or add LIMIT/WHERE.)", shown)
+	default:
+		fmt.Fprintf(&sb, "\n(%d rows)", shown)
+	}
+	return sb.String()
+}
+
+func escapeCells(cells []string) []string {
+	out := make([]string, len(cells))
+	for i, c := range cells {
+		if utf8.RuneCountInString(c) > maxCellRunes {
+			c = string([]rune(c)[:maxCellRunes]) + "…"
+		}
+		c = strings.ReplaceAll(c, "\n", "\\n")
+		out[i] = strings.ReplaceAll(c, "|", "\\|")
+	}
+	return out
+}
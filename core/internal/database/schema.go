@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Schema lists tables and views, or describes one table's columns and
+// indexes when table is set. Postgres tables may be schema-qualified.
+func (d *DB) Schema(ctx context.Context, table string) (string, error) {
+	if d.driver == "sqlite3" {
+		return d.sqliteSchema(ctx, table)
+	}
+	return d.postgresSchema(ctx, table)
+}
+
+func (d *DB) sqliteSchema(ctx context.Context, table string) (string, error) {
+	if table == "" {
+		res, err := d.Query(ctx, `SELECT name, type FROM sqlite_master
+			WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY name`, 1000)
+		if err != nil {
+			return "", err
+		}
+		return res.Format(), nil
+	}
+
+	// The stored CREATE statements are the most compact full description
+	rows, err := d.db.QueryContext(ctx, `SELECT sql FROM sqlite_master
+		WHERE tbl_name = ? AND sql IS NOT NULL ORDER BY type = 'table' DESC, name`, table)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	var parts []string
+	for rows.Next() {
+		var stmt string
+		if err := rows.Scan(&stmt); err != nil {
+			return "", err
+		}
+		parts = append(parts, stmt+";")
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("table %q not found", table)
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+func (d *DB) postgresSchema(ctx context.Context, table string) (string, error) {
+	if table == "" {
+		res, err := d.Query(ctx, `SELECT table_schema AS schema, table_name AS name, table_type AS type
+			FROM information_schema.tables
+			WHERE table_schema NOT IN ('pg_catalog', 'information_schema')
+			ORDER BY table_schema, table_name`, 1000)
+		if err != nil {
+			return "", err
+		}
+		return res.Format(), nil
+	}
+
+	schema, name, ok := strings.Cut(table, ".")
+	if !ok {
+		schema, name = "public", table
+	}
+	cols, err := d.queryArgs(ctx, `SELECT column_name AS column, data_type AS type, is_nullable AS nullable, column_default AS "default"
+		FROM information_schema.columns
+		WHERE table_schema = $1 AND table_name = $2
+		ORDER BY ordinal_position`, schema, name)
+	if err != nil {
+		return "", err
+	}
+	if len(cols.Rows) == 0 {
+		return "", fmt.Errorf("table %q not found", table)
+	}
+	idx, err := d.queryArgs(ctx, `SELECT indexdef AS "index" FROM pg_indexes
+		WHERE schemaname = $1 AND tablename = $2 ORDER BY indexname`, schema, name)
+	if err != nil {
+		return "", err
+	}
+	fks, err := d.queryArgs(ctx, `SELECT conname AS "constraint", pg_get_constraintdef(oid) AS definition
+		FROM pg_constraint
+		WHERE contype = 'f' AND conrelid = format('%I.%I', $1::text, $2::text)::regclass
+		ORDER BY conname`, schema, name)
+	if err != nil {
+		return "", err
+	}
+
+	out := fmt.Sprintf("%s.%s\n\n%s", schema, name, cols.Format())
+	if len(idx.Rows) > 0 {
+		out += "\n\nIndexes:\n" + idx.Format()
+	}
+	if len(fks.Rows) > 0 {
+		out += "\n\nForeign keys:\n" + fks.Format()
+	}
+	return out, nil
+}
+
+// queryArgs runs a parameterized read-only catalog query
+func (d *DB) queryArgs(ctx context.Context, query string, args ...interface{}) (*Result, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return collect(rows, 1000)
+}
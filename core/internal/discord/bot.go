@@ -34,6 +34,21 @@ type Bot struct {
 	// Buffer for messages when no one is listening
 	unreadMu       sync.Mutex
 	unreadMessages map[string][]string
+
+	// Pending AskUser promises (channelID -> response channel)
+	pendingMu sync.Mutex
+	pending   map[string]chan string
+
+	// Session threads: starting is serialized, named by threadNamer
+	threadMu    sync.Mutex
+	threadNamer func(sessionID string) string
+
+	// Voice replies are played one at a time
+	voiceMu sync.Mutex
+
+	// Progress updates being streamed ("channelID/sessionID" -> log)
+	progressMu sync.Mutex
+	progress   map[string]*progressLog
 }
 
 // UserResponse represents a message from user
@@ -59,14 +74,18 @@ func New(token string, guildID string, stateMgr *state.Manager) (*Bot, error) {
 		activeSessions:   make(map[string]string),
 		sessionResponses: make(map[string]chan string),
 		unreadMessages:   make(map[string][]string),
+		pending:          make(map[string]chan string),
+		progress:         make(map[string]*progressLog),
 	}
 
 	// Register handlers
 	session.AddHandler(b.handleMessage)
 	session.AddHandler(b.handleReady)
+	session.AddHandler(b.handleInteraction)
 
 	// Set intents
-	session.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsDirectMessages | discordgo.IntentsMessageContent
+	// Voice states are needed to join voice channels
+	session.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsDirectMessages | discordgo.IntentsMessageContent | discordgo.IntentsGuildVoiceStates
 
 	// Load active sessions from state
 	if stateMgr != nil {
@@ -91,9 +110,10 @@ func (b *Bot) Stop() error {
 	return b.session.Close()
 }
 
-// handleReady logs when bot is connected
-func (b *Bot) handleReady(_ *discordgo.Session, r *discordgo.Ready) {
+// handleReady logs when bot is connected and registers the slash commands
+func (b *Bot) handleReady(s *discordgo.Session, r *discordgo.Ready) {
 	log.Printf("Discord bot connected as %s#%s", r.User.Username, r.User.Discriminator)
+	b.registerCommands(s, r.User.ID)
 }
 
 // handleMessage processes incoming messages
@@ -117,6 +137,17 @@ func (b *Bot) handleMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
 		return
 	}
 
+	b.route(channelID, m.Author.ID, text)
+}
+
+// route delivers a user's message in a channel: as the answer to a pending
+// question, to the channel's session, or to the general channel
+func (b *Bot) route(channelID, userID, text string) {
+	// Check if there's a pending promise for this channel (e.g. from AskUser)
+	if b.answerPending(channelID, text) {
+		return
+	}
+
 	// Route to active session
 	b.activeMu.Lock()
 	sessionID := b.activeSessions[channelID]
@@ -130,7 +161,7 @@ func (b *Bot) handleMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
 	// Buffer or send to general channel
 	b.responseCh <- &UserResponse{
 		ChannelID: channelID,
-		UserID:    m.Author.ID,
+		UserID:    userID,
 		Text:      text,
 	}
 }
@@ -139,7 +170,7 @@ func (b *Bot) handleMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
 func (b *Bot) handleCommand(_ *discordgo.Session, m *discordgo.MessageCreate) {
 	parts := strings.Fields(m.Content)
 	if len(parts) < 2 {
-		b.SendMessage(context.Background(), m.ChannelID, "📡 **Ricochet Discord** — AI Agent Bridge\n\nCommands:\n• `/ricochet status` — Show active session\n• `/ricochet activate <session>` — Activate a session")
+		b.SendMessage(context.Background(), m.ChannelID, "📡 **Ricochet Discord** — AI Agent Bridge\n\nCommands:\n• `/ricochet status` — Show active session\n• `/ricochet activate <session>` — Activate a session\n• `/ricochet threads [off]` — A thread per session in this channel\n• `/ricochet voice <channel|off>` — Play voice replies in a voice channel\n\nSlash commands: `/ask`, `/sessions`, `/approve`")
 		return
 	}
 
@@ -152,7 +183,7 @@ func (b *Bot) handleCommand(_ *discordgo.Session, m *discordgo.MessageCreate) {
 		if sessionID == "" {
 			b.SendMessage(context.Background(), m.ChannelID, "📭 No active session in this channel")
 		} else {
-			b.SendMessage(context.Background(), m.ChannelID, fmt.Sprintf("✅ Active session: `%s`", sessionID[:8]))
+			b.SendMessage(context.Background(), m.ChannelID, fmt.Sprintf("✅ Active session: `%s`", shortID(sessionID)))
 		}
 
 	case "activate":
@@ -162,17 +193,46 @@ func (b *Bot) handleCommand(_ *discordgo.Session, m *discordgo.MessageCreate) {
 		}
 		sessionID := parts[2]
 		b.SetActiveSession(m.ChannelID, sessionID)
-		b.SendMessage(context.Background(), m.ChannelID, fmt.Sprintf("📍 Session `%s` activated for this channel", sessionID[:8]))
+		b.SendMessage(context.Background(), m.ChannelID, fmt.Sprintf("📍 Session `%s` activated for this channel", shortID(sessionID)))
+
+	case "threads":
+		b.handleThreadsCommand(context.Background(), m.ChannelID, parts[2:])
+
+	case "voice":
+		b.handleVoiceCommand(context.Background(), m.ChannelID, m.GuildID, parts[2:])
 
 	default:
 		b.SendMessage(context.Background(), m.ChannelID, "Unknown command. Try `/ricochet` for help.")
 	}
 }
 
-// SendMessage sends a message to a channel
+// SendMessage sends a message to a channel, splitting it over several
+// messages if it exceeds Discord's 2000-char limit
 func (b *Bot) SendMessage(ctx context.Context, channelID string, text string) error {
 	formatted := format.ToDiscordMarkdown(text)
-	_, err := b.session.ChannelMessageSend(channelID, formatted)
+	for _, chunk := range SplitMessage(formatted, MaxMessageLength) {
+		if _, err := b.session.ChannelMessageSend(channelID, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendMessageAndTrack sends a message and returns its ID for later editing.
+// Text over the length limit is truncated; use NewStream for long output.
+func (b *Bot) SendMessageAndTrack(ctx context.Context, channelID string, text string) (string, error) {
+	chunks := SplitMessage(format.ToDiscordMarkdown(text), MaxMessageLength)
+	msg, err := b.session.ChannelMessageSend(channelID, chunks[0])
+	if err != nil {
+		return "", err
+	}
+	return msg.ID, nil
+}
+
+// EditMessage edits an existing message by ID
+func (b *Bot) EditMessage(ctx context.Context, channelID, messageID, newText string) error {
+	chunks := SplitMessage(format.ToDiscordMarkdown(newText), MaxMessageLength)
+	_, err := b.session.ChannelMessageEdit(channelID, messageID, chunks[0])
 	return err
 }
 
@@ -220,8 +280,46 @@ func (b *Bot) SendVoice(ctx context.Context, channelID string, audioPath string)
 // SendCodeBlock sends a formatted code block to Discord
 func (b *Bot) SendCodeBlock(ctx context.Context, channelID string, language, code string) error {
 	formatted := fmt.Sprintf("```%s\n%s\n```", language, code)
-	_, err := b.session.ChannelMessageSend(channelID, formatted)
-	return err
+	for _, chunk := range SplitMessage(formatted, MaxMessageLength) {
+		if _, err := b.session.ChannelMessageSend(channelID, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AskUser sends a question and waits for the next message in the channel
+func (b *Bot) AskUser(ctx context.Context, channelID string, question string) (string, error) {
+	return b.awaitAnswer(ctx, channelID, func() error {
+		return b.SendMessage(ctx, channelID, question)
+	})
+}
+
+// awaitAnswer sends a question with send and waits for the answer in the
+// channel: a message, a button click or a slash command
+func (b *Bot) awaitAnswer(ctx context.Context, channelID string, send func() error) (string, error) {
+	respCh := make(chan string, 1)
+
+	b.pendingMu.Lock()
+	b.pending[channelID] = respCh
+	b.pendingMu.Unlock()
+
+	if err := send(); err != nil {
+		b.pendingMu.Lock()
+		delete(b.pending, channelID)
+		b.pendingMu.Unlock()
+		return "", fmt.Errorf("failed to send question: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		b.pendingMu.Lock()
+		delete(b.pending, channelID)
+		b.pendingMu.Unlock()
+		return "", ctx.Err()
+	case resp := <-respCh:
+		return resp, nil
+	}
 }
 
 // SendToSession routes message to a specific session
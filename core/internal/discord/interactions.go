@@ -0,0 +1,389 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/igoryan-dao/ricochet/internal/format"
+	"github.com/igoryan-dao/ricochet/internal/messenger"
+)
+
+// Custom IDs of the components the bot sends
+const (
+	replyButtonID = "ask:reply" // Opens a modal to answer in free text
+	replyModalID  = "ask:modal"
+	replyInputID  = "answer"
+	sessionPrefix = "session:" // Followed by the session ID to activate
+)
+
+// Discord's limits on message components
+const (
+	maxButtonRows   = 5
+	maxRowButtons   = 5
+	maxButtonLabel  = 80
+	maxListSessions = 10
+)
+
+// slashCommands are registered when the bot connects
+var slashCommands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "ask",
+		Description: "Send a message to the session of this channel",
+		Options: []*discordgo.ApplicationCommandOption{{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "message",
+			Description: "What to tell the agent",
+			Required:    true,
+		}},
+	},
+	{
+		Name:        "sessions",
+		Description: "List agent sessions and pick one for this channel",
+	},
+	{
+		Name:        "approve",
+		Description: "Answer the approval waiting in this channel",
+		Options: []*discordgo.ApplicationCommandOption{{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "decision",
+			Description: "Approve (default), reject or always allow",
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: "Approve", Value: "yes"},
+				{Name: "Reject", Value: "no"},
+				{Name: "Always Allow", Value: "always allow"},
+			},
+		}},
+	},
+}
+
+// registerCommands installs the slash commands, in the guild if the bot is
+// restricted to one (instant) or globally
+func (b *Bot) registerCommands(s *discordgo.Session, appID string) {
+	if _, err := s.ApplicationCommandBulkOverwrite(appID, b.guildID, slashCommands); err != nil {
+		log.Printf("Failed to register Discord slash commands: %v", err)
+	}
+}
+
+// handleInteraction dispatches slash commands, button clicks and modals
+func (b *Bot) handleInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.guildID != "" && i.GuildID != b.guildID {
+		return
+	}
+
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		b.handleSlashCommand(s, i)
+	case discordgo.InteractionMessageComponent:
+		b.handleComponent(s, i)
+	case discordgo.InteractionModalSubmit:
+		b.handleModal(s, i)
+	}
+}
+
+// handleSlashCommand runs /ask, /sessions and /approve
+func (b *Bot) handleSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	options := make(map[string]string)
+	for _, opt := range data.Options {
+		options[opt.Name] = opt.StringValue()
+	}
+
+	switch data.Name {
+	case "ask":
+		text := options["message"]
+		// Slash command input is not shown in the channel: echo it
+		b.respond(s, i, "💬 "+text, false)
+		b.route(i.ChannelID, interactionUser(i), text)
+
+	case "sessions":
+		b.respondSessions(s, i)
+
+	case "approve":
+		decision := options["decision"]
+		if decision == "" {
+			decision = "yes"
+		}
+		if !b.answerPending(i.ChannelID, decision) {
+			b.respond(s, i, "Nothing is waiting for an answer in this channel.", true)
+			return
+		}
+		b.respond(s, i, confirmation(decision, decision), false)
+	}
+}
+
+// handleComponent handles button clicks: answers to questions, the free text
+// reply button, and session pickers
+func (b *Bot) handleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+
+	switch {
+	case data.CustomID == replyButtonID:
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseModal,
+			Data: &discordgo.InteractionResponseData{
+				CustomID: replyModalID,
+				Title:    "Reply",
+				Components: []discordgo.MessageComponent{
+					discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID: replyInputID,
+							Label:    "Your answer",
+							Style:    discordgo.TextInputParagraph,
+							Required: true,
+						},
+					}},
+				},
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to open reply modal: %v", err)
+		}
+
+	case strings.HasPrefix(data.CustomID, sessionPrefix):
+		sessionID := strings.TrimPrefix(data.CustomID, sessionPrefix)
+		b.SetActiveSession(i.ChannelID, sessionID)
+		b.respond(s, i, fmt.Sprintf("📍 Session `%s` activated for this channel", shortID(sessionID)), false)
+
+	default:
+		if !b.answerPending(i.ChannelID, data.CustomID) {
+			b.respond(s, i, "This question is no longer open.", true)
+			return
+		}
+		b.respond(s, i, confirmation(data.CustomID, buttonLabel(i.Message, data.CustomID)), false)
+	}
+}
+
+// handleModal takes the free text answer typed into the reply modal
+func (b *Bot) handleModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+	if data.CustomID != replyModalID {
+		return
+	}
+
+	var answer string
+	for _, c := range data.Components {
+		row, ok := c.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, rc := range row.Components {
+			if input, ok := rc.(*discordgo.TextInput); ok && input.CustomID == replyInputID {
+				answer = input.Value
+			}
+		}
+	}
+
+	if !b.answerPending(i.ChannelID, answer) {
+		b.respond(s, i, "This question is no longer open.", true)
+		return
+	}
+	b.respond(s, i, "✓ Received: "+answer, false)
+}
+
+// respondSessions lists recently seen sessions with buttons to activate one
+func (b *Bot) respondSessions(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.state == nil {
+		b.respond(s, i, "⚠️ State storage is unavailable.", true)
+		return
+	}
+
+	lastSeen := b.state.GetLastSeen()
+	ids := make([]string, 0, len(lastSeen))
+	for id := range lastSeen {
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		b.respond(s, i, "📭 No sessions yet.", true)
+		return
+	}
+	sort.Slice(ids, func(a, c int) bool { return lastSeen[ids[a]].After(lastSeen[ids[c]]) })
+	if len(ids) > maxListSessions {
+		ids = ids[:maxListSessions]
+	}
+
+	active := b.GetActiveSession(i.ChannelID)
+	var sb strings.Builder
+	sb.WriteString("📚 **Sessions** — pick one for this channel")
+	var buttons [][]messenger.Button
+	for _, id := range ids {
+		label := b.sessionName(id)
+		line := fmt.Sprintf("\n• %s (`%s`)", label, shortID(id))
+		if id == active {
+			line += " — active here"
+		}
+		if threadID, ok := b.state.GetSessionThread(id); ok {
+			line += fmt.Sprintf(" — <#%s>", threadID)
+		}
+		sb.WriteString(line)
+		buttons = append(buttons, []messenger.Button{{Text: label, Data: sessionPrefix + id}})
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    sb.String(),
+			Components: buttonRows(buttons),
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to respond to /sessions: %v", err)
+	}
+}
+
+// sessionName is the session's title from the thread namer, or its short ID
+func (b *Bot) sessionName(sessionID string) string {
+	b.threadMu.Lock()
+	namer := b.threadNamer
+	b.threadMu.Unlock()
+	if namer != nil {
+		if name := strings.TrimSpace(namer(sessionID)); name != "" {
+			return name
+		}
+	}
+	return shortID(sessionID)
+}
+
+// respond answers an interaction with a message, only to its user if ephemeral
+func (b *Bot) respond(s *discordgo.Session, i *discordgo.InteractionCreate, text string, ephemeral bool) {
+	data := &discordgo.InteractionResponseData{Content: format.ToDiscordMarkdown(text)}
+	if ephemeral {
+		data.Flags = discordgo.MessageFlagsEphemeral
+	}
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+	if err != nil {
+		log.Printf("Failed to respond to interaction: %v", err)
+	}
+}
+
+// answerPending answers the question waiting in a channel, if any
+func (b *Bot) answerPending(channelID, answer string) bool {
+	b.pendingMu.Lock()
+	respCh, ok := b.pending[channelID]
+	if ok {
+		delete(b.pending, channelID)
+	}
+	b.pendingMu.Unlock()
+
+	if ok {
+		respCh <- answer
+	}
+	return ok
+}
+
+// AskWithButtons sends a question with buttons and waits for a click, a
+// free text answer through the Reply button, or the next message
+func (b *Bot) AskWithButtons(ctx context.Context, channelID, question string, buttons [][]messenger.Button) (string, error) {
+	return b.awaitAnswer(ctx, channelID, func() error {
+		chunks := SplitMessage(format.ToDiscordMarkdown(question), MaxMessageLength)
+		for _, chunk := range chunks[:len(chunks)-1] {
+			if _, err := b.session.ChannelMessageSend(channelID, chunk); err != nil {
+				return err
+			}
+		}
+
+		rows := buttonRows(buttons)
+		if len(rows) < maxButtonRows {
+			rows = append(rows, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "✏️ Reply", Style: discordgo.SecondaryButton, CustomID: replyButtonID},
+			}})
+		}
+		_, err := b.session.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+			Content:    chunks[len(chunks)-1],
+			Components: rows,
+		})
+		return err
+	})
+}
+
+// buttonRows converts buttons to action rows, within Discord's limits
+func buttonRows(buttons [][]messenger.Button) []discordgo.MessageComponent {
+	var rows []discordgo.MessageComponent
+	for _, row := range buttons {
+		if len(rows) == maxButtonRows {
+			break
+		}
+		var components []discordgo.MessageComponent
+		for _, btn := range row {
+			if len(components) == maxRowButtons {
+				break
+			}
+			label := btn.Text
+			if runes := []rune(label); len(runes) > maxButtonLabel {
+				label = string(runes[:maxButtonLabel-1]) + "…"
+			}
+			components = append(components, discordgo.Button{
+				Label:    label,
+				Style:    buttonStyle(btn.Data),
+				CustomID: btn.Data,
+			})
+		}
+		if len(components) > 0 {
+			rows = append(rows, discordgo.ActionsRow{Components: components})
+		}
+	}
+	return rows
+}
+
+// buttonStyle colors approvals green and rejections red
+func buttonStyle(data string) discordgo.ButtonStyle {
+	switch data {
+	case "yes", "always allow":
+		return discordgo.SuccessButton
+	case "no":
+		return discordgo.DangerButton
+	default:
+		return discordgo.PrimaryButton
+	}
+}
+
+// confirmation is the reply to an answer, as on Telegram
+func confirmation(data, label string) string {
+	switch data {
+	case "yes":
+		return "✅ Approved. Executing..."
+	case "no":
+		return "❌ Rejected."
+	case "always allow":
+		return "🛡️ Always Allow enabled. Executing..."
+	default:
+		return "✓ Received: " + label
+	}
+}
+
+// buttonLabel is the text of the clicked button, or its custom ID
+func buttonLabel(msg *discordgo.Message, customID string) string {
+	if msg == nil {
+		return customID
+	}
+	for _, c := range msg.Components {
+		row, ok := c.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, rc := range row.Components {
+			if btn, ok := rc.(*discordgo.Button); ok && btn.CustomID == customID {
+				return btn.Label
+			}
+		}
+	}
+	return customID
+}
+
+// interactionUser returns the ID of the user behind an interaction, in a
+// guild or a DM
+func interactionUser(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/igoryan-dao/ricochet/internal/messenger"
+)
+
+func TestButtonRows(t *testing.T) {
+	var buttons [][]messenger.Button
+	for r := 0; r < 7; r++ {
+		var row []messenger.Button
+		for c := 0; c < 7; c++ {
+			row = append(row, messenger.Button{Text: strings.Repeat("x", 100), Data: "d"})
+		}
+		buttons = append(buttons, row)
+	}
+
+	rows := buttonRows(buttons)
+	if len(rows) != maxButtonRows {
+		t.Fatalf("got %d rows, want %d", len(rows), maxButtonRows)
+	}
+	row := rows[0].(discordgo.ActionsRow)
+	if len(row.Components) != maxRowButtons {
+		t.Fatalf("got %d buttons in a row, want %d", len(row.Components), maxRowButtons)
+	}
+	if label := row.Components[0].(discordgo.Button).Label; len([]rune(label)) != maxButtonLabel {
+		t.Errorf("label is %d runes, want %d", len([]rune(label)), maxButtonLabel)
+	}
+
+	approvals := buttonRows([][]messenger.Button{{{Text: "Yes", Data: "yes"}, {Text: "No", Data: "no"}}})
+	got := approvals[0].(discordgo.ActionsRow).Components
+	if got[0].(discordgo.Button).Style != discordgo.SuccessButton || got[1].(discordgo.Button).Style != discordgo.DangerButton {
+		t.Error("approve and reject buttons are not green and red")
+	}
+}
@@ -0,0 +1,66 @@
+package discord
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// oggReader reads the packets of an Ogg stream, e.g. Opus frames from ffmpeg
+type oggReader struct {
+	r       *bufio.Reader
+	packets [][]byte
+	partial []byte // Packet continued on the next page
+}
+
+func newOggReader(r io.Reader) *oggReader {
+	return &oggReader{r: bufio.NewReader(r)}
+}
+
+// NextPacket returns the next packet, or io.EOF at the end of the stream
+func (o *oggReader) NextPacket() ([]byte, error) {
+	for len(o.packets) == 0 {
+		if err := o.readPage(); err != nil {
+			return nil, err
+		}
+	}
+	packet := o.packets[0]
+	o.packets = o.packets[1:]
+	return packet, nil
+}
+
+// readPage reads one page, splitting its segments into packets. A packet
+// ends with the first segment shorter than 255 bytes.
+func (o *oggReader) readPage() error {
+	var header [27]byte
+	if _, err := io.ReadFull(o.r, header[:]); err != nil {
+		return err
+	}
+	if !bytes.Equal(header[:4], []byte("OggS")) {
+		return errors.New("invalid Ogg page")
+	}
+
+	segments := make([]byte, header[26])
+	if _, err := io.ReadFull(o.r, segments); err != nil {
+		return err
+	}
+	for _, size := range segments {
+		data := make([]byte, size)
+		if _, err := io.ReadFull(o.r, data); err != nil {
+			return err
+		}
+		o.partial = append(o.partial, data...)
+		if size < 255 {
+			o.packets = append(o.packets, o.partial)
+			o.partial = nil
+		}
+	}
+	return nil
+}
+
+// isOpusHeader reports whether a packet is one of the Opus stream headers
+// rather than audio
+func isOpusHeader(packet []byte) bool {
+	return bytes.HasPrefix(packet, []byte("OpusHead")) || bytes.HasPrefix(packet, []byte("OpusTags"))
+}
@@ -0,0 +1,59 @@
+package discord
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// oggPage builds a page holding the given segment sizes and data
+func oggPage(segments []byte, data []byte) []byte {
+	page := append([]byte("OggS"), make([]byte, 22)...)
+	page = append(page, byte(len(segments)))
+	page = append(page, segments...)
+	return append(page, data...)
+}
+
+func TestOggReader(t *testing.T) {
+	long := bytes.Repeat([]byte{'x'}, 300)
+
+	var stream []byte
+	stream = append(stream, oggPage([]byte{8}, []byte("OpusHead"))...)
+	stream = append(stream, oggPage([]byte{3, 2}, []byte("abcde"))...)
+	// A 300-byte packet continued from one page to the next
+	stream = append(stream, oggPage([]byte{255}, long[:255])...)
+	stream = append(stream, oggPage([]byte{45}, long[255:])...)
+
+	r := newOggReader(bytes.NewReader(stream))
+	var got [][]byte
+	for {
+		packet, err := r.NextPacket()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, packet)
+	}
+
+	want := [][]byte{[]byte("OpusHead"), []byte("abc"), []byte("de"), long}
+	if len(got) != len(want) {
+		t.Fatalf("got %d packets, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !bytes.Equal(got[i], want[i]) {
+			t.Errorf("packet %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+	if !isOpusHeader(got[0]) || isOpusHeader(got[1]) {
+		t.Error("isOpusHeader misclassified packets")
+	}
+}
+
+func TestOggReaderInvalid(t *testing.T) {
+	r := newOggReader(bytes.NewReader(append([]byte("NotOgg"), make([]byte, 30)...)))
+	if _, err := r.NextPacket(); err == nil {
+		t.Error("expected an error for a non-Ogg stream")
+	}
+}
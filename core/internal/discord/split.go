@@ -0,0 +1,98 @@
+package discord
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxMessageLength is Discord's hard limit for message content
+const MaxMessageLength = 2000
+
+const fence = "```"
+
+// SplitMessage breaks text into chunks that fit within limit, preferring line
+// boundaries. A code block cut in half is closed at the end of one chunk and
+// reopened (with its language tag) at the start of the next.
+func SplitMessage(text string, limit int) []string {
+	if limit <= 0 {
+		limit = MaxMessageLength
+	}
+	if len(text) <= limit {
+		return []string{text}
+	}
+
+	var chunks []string
+	openFence := "" // Opening fence line (e.g. "```go") carried into the next chunk
+
+	for text != "" {
+		prefix := ""
+		if openFence != "" {
+			prefix = openFence + "\n"
+		}
+
+		if len(prefix)+len(text) <= limit {
+			chunks = append(chunks, prefix+text)
+			break
+		}
+
+		// Reserve room to close a fence we may end up inside of
+		budget := limit - len(prefix) - len("\n"+fence)
+		if budget < limit/4 {
+			budget = limit / 4
+		}
+
+		cut := splitPoint(text, budget)
+		body := text[:cut]
+		text = strings.TrimPrefix(text[cut:], "\n")
+
+		openFence = fenceState(body, openFence)
+		chunk := prefix + body
+		if openFence != "" {
+			chunk += "\n" + fence
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks
+}
+
+// splitPoint picks where to cut text so the head is at most budget bytes:
+// the last newline, else the last space, else a hard cut on a rune boundary.
+func splitPoint(text string, budget int) int {
+	if budget >= len(text) {
+		return len(text)
+	}
+
+	head := text[:budget]
+	if i := strings.LastIndex(head, "\n"); i > budget/2 {
+		return i
+	}
+	if i := strings.LastIndex(head, " "); i > budget/2 {
+		return i
+	}
+
+	cut := budget
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	if cut == 0 {
+		return budget
+	}
+	return cut
+}
+
+// fenceState returns the fence still open after s, given the one open before it
+func fenceState(s, open string) string {
+	for _, line := range strings.Split(s, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, fence) {
+			continue
+		}
+		if open == "" {
+			open = trimmed
+		} else {
+			open = ""
+		}
+	}
+	return open
+}
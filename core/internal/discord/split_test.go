@@ -0,0 +1,43 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitMessage_Short(t *testing.T) {
+	chunks := SplitMessage("hello", MaxMessageLength)
+	if len(chunks) != 1 || chunks[0] != "hello" {
+		t.Errorf("Expected single chunk, got %v", chunks)
+	}
+}
+
+func TestSplitMessage_ReopensCodeBlock(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("Here is the code:\n```go\n")
+	for i := 0; i < 200; i++ {
+		sb.WriteString("fmt.Println(\"line\")\n")
+	}
+	sb.WriteString("```\nDone.")
+
+	chunks := SplitMessage(sb.String(), MaxMessageLength)
+	if len(chunks) < 2 {
+		t.Fatalf("Expected multiple chunks, got %d", len(chunks))
+	}
+
+	for i, chunk := range chunks {
+		if len(chunk) > MaxMessageLength {
+			t.Errorf("Chunk %d exceeds limit: %d chars", i, len(chunk))
+		}
+		if strings.Count(chunk, "```")%2 != 0 {
+			t.Errorf("Chunk %d has an unbalanced code fence", i)
+		}
+		if i > 0 && !strings.HasPrefix(chunk, "```go\n") {
+			t.Errorf("Chunk %d should reopen the go code block", i)
+		}
+	}
+
+	if !strings.HasSuffix(chunks[len(chunks)-1], "Done.") {
+		t.Error("Expected trailing text in last chunk")
+	}
+}
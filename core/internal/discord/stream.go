@@ -0,0 +1,148 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/igoryan-dao/ricochet/internal/format"
+)
+
+const (
+	// Discord allows ~5 message edits per 5s per channel; stay safely below it
+	streamEditInterval = 1200 * time.Millisecond
+	// The typing indicator lasts ~10s and typing calls are rate limited too
+	typingInterval = 8 * time.Second
+)
+
+// Stream delivers a growing response by editing messages in place, like the
+// Telegram live mode. Content past 2000 chars spills into follow-up messages.
+type Stream struct {
+	bot       *Bot
+	channelID string
+
+	mu         sync.Mutex
+	messageIDs []string // One Discord message per chunk
+	sent       []string // Last content delivered per message
+	pending    string
+	lastEdit   time.Time
+	lastTyping time.Time
+	trailing   *time.Timer // Delivers a throttled update once edits are allowed
+}
+
+// NewStream starts a streamed response in a channel
+func (b *Bot) NewStream(channelID string) *Stream {
+	return &Stream{bot: b, channelID: channelID}
+}
+
+// Update replaces the streamed content. Edits are throttled to Discord's rate
+// limits, so intermediate states may be skipped; the latest one is delivered
+// once the throttle allows. Call Finish for the final text.
+func (s *Stream) Update(ctx context.Context, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending = text
+	if time.Since(s.lastTyping) >= typingInterval {
+		s.bot.session.ChannelTyping(s.channelID, discordgo.WithContext(ctx))
+		s.lastTyping = time.Now()
+	}
+
+	if wait := streamEditInterval - time.Since(s.lastEdit); wait > 0 {
+		if s.trailing == nil {
+			s.trailing = time.AfterFunc(wait, s.flushTrailing)
+		}
+		return nil
+	}
+	return s.flush(ctx)
+}
+
+// flushTrailing delivers an update the throttle held back. It runs after
+// the caller's context may be gone, so it uses its own.
+func (s *Stream) flushTrailing() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.trailing = nil
+	if err := s.flush(context.Background()); err != nil {
+		log.Printf("Failed to stream to Discord: %v", err)
+	}
+}
+
+// Finish delivers the final content, bypassing the throttle
+func (s *Stream) Finish(ctx context.Context, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending = text
+	if s.trailing != nil {
+		s.trailing.Stop()
+		s.trailing = nil
+	}
+	return s.flush(ctx)
+}
+
+// progressLog is the progress of a session in a channel, streamed into one
+// message that grows with each stage
+type progressLog struct {
+	stream *Stream
+	lines  []string
+}
+
+// StreamProgress adds a progress line for a session in a channel. The lines
+// are edited into one message rather than sent one message each; done ends
+// the message, and the next line starts a new one.
+func (b *Bot) StreamProgress(ctx context.Context, channelID, sessionID, line string, done bool) error {
+	key := channelID + "/" + sessionID
+	b.progressMu.Lock()
+	p := b.progress[key]
+	if p == nil {
+		p = &progressLog{stream: b.NewStream(channelID)}
+		b.progress[key] = p
+	}
+	p.lines = append(p.lines, line)
+	text := strings.Join(p.lines, "\n")
+	if done {
+		delete(b.progress, key)
+	}
+	b.progressMu.Unlock()
+
+	if done {
+		return p.stream.Finish(ctx, text)
+	}
+	return p.stream.Update(ctx, text)
+}
+
+func (s *Stream) flush(ctx context.Context) error {
+	if s.pending == "" {
+		return nil
+	}
+
+	chunks := SplitMessage(format.ToDiscordMarkdown(s.pending), MaxMessageLength)
+	for i, chunk := range chunks {
+		if i < len(s.messageIDs) {
+			if s.sent[i] == chunk {
+				continue
+			}
+			if _, err := s.bot.session.ChannelMessageEdit(s.channelID, s.messageIDs[i], chunk, discordgo.WithContext(ctx)); err != nil {
+				return fmt.Errorf("failed to edit Discord message: %w", err)
+			}
+			s.sent[i] = chunk
+			continue
+		}
+
+		msg, err := s.bot.session.ChannelMessageSend(s.channelID, chunk, discordgo.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to send Discord message: %w", err)
+		}
+		s.messageIDs = append(s.messageIDs, msg.ID)
+		s.sent = append(s.sent, chunk)
+	}
+
+	s.lastEdit = time.Now()
+	return nil
+}
@@ -0,0 +1,80 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeAPI answers Discord's API and records the message contents sent and
+// edited
+type fakeAPI struct {
+	mu    sync.Mutex
+	calls []string // "POST text" or "PATCH text"
+}
+
+func (f *fakeAPI) RoundTrip(r *http.Request) (*http.Response, error) {
+	var body struct {
+		Content string `json:"content"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	if !strings.HasSuffix(r.URL.Path, "/typing") {
+		f.mu.Lock()
+		f.calls = append(f.calls, r.Method+" "+body.Content)
+		f.mu.Unlock()
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"id": "m1", "channel_id": "c1"}`)),
+		Request:    r,
+	}, nil
+}
+
+func (f *fakeAPI) log() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func TestStreamProgress(t *testing.T) {
+	api := &fakeAPI{}
+	session, _ := discordgo.New("Bot token")
+	session.Client = &http.Client{Transport: api}
+	b := &Bot{session: session, progress: make(map[string]*progressLog)}
+	ctx := context.Background()
+
+	b.StreamProgress(ctx, "c1", "s1", "planning", false)
+	b.StreamProgress(ctx, "c1", "s1", "execution", false) // Throttled
+	if got := strings.Join(api.log(), "|"); got != "POST planning" {
+		t.Fatalf("calls = %q, want the first line sent and the second held back", got)
+	}
+
+	// The held back line is edited in once the throttle allows
+	time.Sleep(streamEditInterval + 300*time.Millisecond)
+	if got := strings.Join(api.log(), "|"); got != "POST planning|PATCH planning\nexecution" {
+		t.Fatalf("calls = %q, want the held back line edited in", got)
+	}
+
+	if err := b.StreamProgress(ctx, "c1", "s1", "completed", true); err != nil {
+		t.Fatal(err)
+	}
+	calls := api.log()
+	if last := calls[len(calls)-1]; last != "PATCH planning\nexecution\ncompleted" {
+		t.Errorf("final call = %q", last)
+	}
+
+	// Progress after completion starts a new message
+	b.StreamProgress(ctx, "c1", "s1", "planning", false)
+	calls = api.log()
+	if last := calls[len(calls)-1]; last != "POST planning" {
+		t.Errorf("call after completion = %q, want a new message", last)
+	}
+}
@@ -0,0 +1,105 @@
+package discord
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxThreadName is Discord's limit on channel and thread names
+	maxThreadName = 100
+	// threadArchiveMinutes archives a thread after a day without messages
+	threadArchiveMinutes = 1440
+)
+
+// SetThreadNamer sets how threads started for sessions are named. By default
+// they are named after the session ID.
+func (b *Bot) SetThreadNamer(name func(sessionID string) string) {
+	b.threadMu.Lock()
+	b.threadNamer = name
+	b.threadMu.Unlock()
+}
+
+// ensureThread returns the session's thread in the threads channel, starting
+// it on first use. The thread is activated for the session, so messages in it
+// reach the session like in any channel. It reports false when threads are
+// off or the thread cannot be started.
+func (b *Bot) ensureThread(sessionID string) (string, bool) {
+	if b.state == nil || sessionID == "" {
+		return "", false
+	}
+	if threadID, ok := b.state.GetSessionThread(sessionID); ok {
+		return threadID, true
+	}
+	channelID := b.state.GetDiscordThreadsChannel()
+	if channelID == "" {
+		return "", false
+	}
+
+	// Serialized so concurrent messages don't start a thread each
+	b.threadMu.Lock()
+	defer b.threadMu.Unlock()
+	if threadID, ok := b.state.GetSessionThread(sessionID); ok {
+		return threadID, true
+	}
+
+	name := "🤖 " + shortID(sessionID)
+	if b.threadNamer != nil {
+		if n := strings.TrimSpace(b.threadNamer(sessionID)); n != "" {
+			name = n
+		}
+	}
+	if runes := []rune(name); len(runes) > maxThreadName {
+		name = string(runes[:maxThreadName-1]) + "…"
+	}
+
+	thread, err := b.session.ThreadStart(channelID, name, discordgo.ChannelTypeGuildPublicThread, threadArchiveMinutes)
+	if err != nil {
+		log.Printf("Failed to start thread for session %s: %v", sessionID, err)
+		return "", false
+	}
+
+	if err := b.state.SetSessionThread(sessionID, thread.ID); err != nil {
+		log.Printf("Failed to save thread for session %s: %v", sessionID, err)
+	}
+	b.SetActiveSession(thread.ID, sessionID)
+	log.Printf("Started thread %s for session %s", thread.ID, sessionID)
+	return thread.ID, true
+}
+
+// handleThreadsCommand turns thread-per-session mode on in a channel with
+// "threads", or off with "threads off"
+func (b *Bot) handleThreadsCommand(ctx context.Context, channelID string, args []string) {
+	if b.state == nil {
+		b.SendMessage(ctx, channelID, "⚠️ State storage is unavailable.")
+		return
+	}
+
+	if len(args) > 0 && args[0] == "off" {
+		if b.state.GetDiscordThreadsChannel() != channelID {
+			b.SendMessage(ctx, channelID, "Threads are not on in this channel.")
+			return
+		}
+		if err := b.state.SetDiscordThreadsChannel(""); err != nil {
+			log.Printf("Failed to save threads channel: %v", err)
+		}
+		b.SendMessage(ctx, channelID, "🧵 Threads off. Sessions share the channel again.")
+		return
+	}
+
+	if err := b.state.SetDiscordThreadsChannel(channelID); err != nil {
+		log.Printf("Failed to save threads channel: %v", err)
+	}
+	b.SendMessage(ctx, channelID, "🧵 **Threads on.** Each agent session gets its own thread here: reply inside a thread to talk to its session. `/sessions` lists them.")
+}
+
+// shortID shortens a session ID for display
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -0,0 +1,122 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/state"
+)
+
+// voiceDrain is how long the last queued Opus frames take to send
+const voiceDrain = 200 * time.Millisecond
+
+// VoiceEnabled reports whether voice replies are played in a voice channel
+func (b *Bot) VoiceEnabled() bool {
+	if b.state == nil {
+		return false
+	}
+	_, ok := b.state.GetDiscordVoice()
+	return ok
+}
+
+// PlayVoice joins the configured voice channel and plays an audio file
+// there. ffmpeg transcodes it to the Opus frames Discord expects. Replies
+// are played one at a time, leaving the channel after each.
+func (b *Bot) PlayVoice(ctx context.Context, audioPath string) error {
+	if b.state == nil {
+		return fmt.Errorf("voice channel not set")
+	}
+	vc, ok := b.state.GetDiscordVoice()
+	if !ok {
+		return fmt.Errorf("voice channel not set")
+	}
+
+	b.voiceMu.Lock()
+	defer b.voiceMu.Unlock()
+
+	conn, err := b.session.ChannelVoiceJoin(vc.GuildID, vc.ChannelID, false, true)
+	if err != nil {
+		return fmt.Errorf("failed to join voice channel: %w", err)
+	}
+	defer conn.Disconnect()
+
+	// 48kHz stereo in 20ms frames, what the voice connection sends
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-loglevel", "error", "-i", audioPath,
+		"-c:a", "libopus", "-ar", "48000", "-ac", "2", "-b:a", "64k",
+		"-frame_duration", "20", "-application", "voip", "-f", "ogg", "pipe:1")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	// Killed in case playback stops before ffmpeg is done writing
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	conn.Speaking(true)
+	defer conn.Speaking(false)
+
+	ogg := newOggReader(stdout)
+	for {
+		packet, err := ogg.NextPacket()
+		if err == io.EOF {
+			// Let the frames still queued play before leaving
+			time.Sleep(voiceDrain)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read audio: %w", err)
+		}
+		if isOpusHeader(packet) {
+			continue
+		}
+		select {
+		case conn.OpusSend <- packet:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// handleVoiceCommand sets the voice channel voice replies are played in with
+// "voice <channel_id>", or turns playback off with "voice off"
+func (b *Bot) handleVoiceCommand(ctx context.Context, channelID, guildID string, args []string) {
+	if b.state == nil {
+		b.SendMessage(ctx, channelID, "⚠️ State storage is unavailable.")
+		return
+	}
+
+	if len(args) == 0 {
+		if vc, ok := b.state.GetDiscordVoice(); ok {
+			b.SendMessage(ctx, channelID, fmt.Sprintf("🔊 Voice replies play in <#%s>. `/ricochet voice off` stops them.", vc.ChannelID))
+		} else {
+			b.SendMessage(ctx, channelID, "Usage: `/ricochet voice <voice_channel_id>` to play voice replies there, `/ricochet voice off` to stop.")
+		}
+		return
+	}
+
+	if args[0] == "off" {
+		if err := b.state.SetDiscordVoice(nil); err != nil {
+			log.Printf("Failed to save voice channel: %v", err)
+		}
+		b.SendMessage(ctx, channelID, "🔇 Voice replies are no longer played aloud.")
+		return
+	}
+
+	if guildID == "" {
+		b.SendMessage(ctx, channelID, "⚠️ Set the voice channel from a channel of the same server.")
+		return
+	}
+	if err := b.state.SetDiscordVoice(&state.VoiceChannel{GuildID: guildID, ChannelID: args[0]}); err != nil {
+		log.Printf("Failed to save voice channel: %v", err)
+	}
+	b.SendMessage(ctx, channelID, fmt.Sprintf("🔊 Voice replies will play in <#%s>.", args[0]))
+}
@@ -0,0 +1,158 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const inlineComposeFile = "docker-compose.eval.yml"
+
+// Fixture is a running environment for a single test case
+type Fixture struct {
+	spec        *Environment
+	workspace   string
+	composeFile string
+	project     string
+	env         []string // KEY=value pairs of spec.Env
+}
+
+// StartEnvironment brings up the services declared by env inside the sandbox,
+// waits for them to become healthy, and runs seed scripts.
+// The returned Fixture must be stopped with Stop, even if StartEnvironment fails part-way.
+func StartEnvironment(ctx context.Context, caseID, workspace string, env *Environment) (*Fixture, error) {
+	f := &Fixture{
+		spec:      env,
+		workspace: workspace,
+		project:   composeProjectName(caseID),
+	}
+
+	// Variables go to each command, not the process, which other cases share
+	for k, v := range env.Env {
+		f.env = append(f.env, k+"="+v)
+	}
+	sort.Strings(f.env)
+
+	switch {
+	case env.Compose != "":
+		f.composeFile = filepath.Join(workspace, inlineComposeFile)
+		if err := os.WriteFile(f.composeFile, []byte(env.Compose), 0644); err != nil {
+			return f, fmt.Errorf("failed to write compose file: %w", err)
+		}
+	case env.ComposeFile != "":
+		if !filepath.IsLocal(env.ComposeFile) {
+			return f, fmt.Errorf("compose file %s must be a relative path inside the sandbox", env.ComposeFile)
+		}
+		f.composeFile = filepath.Join(workspace, env.ComposeFile)
+		if _, err := os.Stat(f.composeFile); err != nil {
+			return f, fmt.Errorf("compose file %s not found: %w", env.ComposeFile, err)
+		}
+	}
+
+	if f.composeFile != "" {
+		if out, err := f.compose(ctx, "up", "-d"); err != nil {
+			return f, fmt.Errorf("docker compose up failed: %w\n%s", err, out)
+		}
+	}
+
+	if err := f.waitReady(ctx); err != nil {
+		return f, err
+	}
+
+	for i, script := range env.Seed {
+		if out, err := f.shell(ctx, script); err != nil {
+			return f, fmt.Errorf("seed script %d failed: %w\n%s", i+1, err, out)
+		}
+	}
+
+	return f, nil
+}
+
+// Env returns the case's variables as KEY=value pairs, for the commands the
+// agent runs
+func (f *Fixture) Env() []string {
+	return f.env
+}
+
+// Stop tears down services
+func (f *Fixture) Stop(ctx context.Context) error {
+	if f.composeFile == "" {
+		return nil
+	}
+
+	// Use a fresh context: teardown must run even if the case was cancelled
+	downCtx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	if out, err := f.compose(downCtx, "down", "-v", "--remove-orphans"); err != nil {
+		return fmt.Errorf("docker compose down failed: %w\n%s", err, out)
+	}
+	return nil
+}
+
+// waitReady retries the health check until it passes or the timeout expires
+func (f *Fixture) waitReady(ctx context.Context) error {
+	if f.spec.HealthCheck == "" {
+		return nil
+	}
+
+	timeout := time.Duration(f.spec.ReadyTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+
+	var lastOut string
+	for {
+		out, err := f.shell(ctx, f.spec.HealthCheck)
+		if err == nil {
+			return nil
+		}
+		lastOut = out
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("environment not ready after %v: %s", timeout, strings.TrimSpace(lastOut))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (f *Fixture) compose(ctx context.Context, args ...string) (string, error) {
+	fullArgs := append([]string{"compose", "-p", f.project, "-f", f.composeFile}, args...)
+	cmd := exec.CommandContext(ctx, "docker", fullArgs...)
+	cmd.Dir = f.workspace
+	cmd.Env = append(os.Environ(), f.env...)
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func (f *Fixture) shell(ctx context.Context, script string) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", script)
+	cmd.Dir = f.workspace
+	cmd.Env = append(os.Environ(), f.env...)
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// composeProjectName derives a docker-safe, per-case project name so parallel suites don't collide
+func composeProjectName(caseID string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(caseID) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('-')
+		}
+	}
+	return fmt.Sprintf("ricochet-eval-%s-%d", sb.String(), time.Now().UnixNano()%100000)
+}
@@ -0,0 +1,83 @@
+//go:build !windows
+
+package eval
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStartEnvironmentSeedsWithItsEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("EVAL_EXISTING", "before")
+	os.Unsetenv("EVAL_NEW")
+	ctx := context.Background()
+
+	f, err := StartEnvironment(ctx, "case 1", dir, &Environment{
+		Env:         map[string]string{"EVAL_EXISTING": "during", "EVAL_NEW": "set"},
+		HealthCheck: "test -f ready || { touch ready; exit 1; }", // Passes on the second try
+		Seed:        []string{`echo "$EVAL_NEW $EVAL_EXISTING" > seeded`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "seeded")); strings.TrimSpace(string(data)) != "set during" {
+		t.Errorf("seed script saw %q", data)
+	}
+	if got := f.Env(); len(got) != 2 || got[0] != "EVAL_EXISTING=during" || got[1] != "EVAL_NEW=set" {
+		t.Errorf("Env() = %q", got)
+	}
+
+	// Other cases share the process: its environment is left alone
+	if os.Getenv("EVAL_EXISTING") != "before" {
+		t.Errorf("EVAL_EXISTING = %q in the process, want it untouched", os.Getenv("EVAL_EXISTING"))
+	}
+	if _, ok := os.LookupEnv("EVAL_NEW"); ok {
+		t.Error("EVAL_NEW set in the process")
+	}
+	if err := f.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStartEnvironmentFailures(t *testing.T) {
+	ctx := context.Background()
+
+	f, err := StartEnvironment(ctx, "c", t.TempDir(), &Environment{HealthCheck: "echo not yet; exit 1", ReadyTimeoutSeconds: 1})
+	if err == nil || !strings.Contains(err.Error(), "not ready") || !strings.Contains(err.Error(), "not yet") {
+		t.Errorf("health check error = %v", err)
+	}
+	f.Stop(ctx)
+
+	f, err = StartEnvironment(ctx, "c", t.TempDir(), &Environment{Seed: []string{"true", "echo broken; exit 2"}})
+	if err == nil || !strings.Contains(err.Error(), "seed script 2") || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("seed error = %v", err)
+	}
+	f.Stop(ctx)
+
+	f, err = StartEnvironment(ctx, "c", t.TempDir(), &Environment{ComposeFile: "missing.yml"})
+	if err == nil || !strings.Contains(err.Error(), "missing.yml") {
+		t.Errorf("missing compose file error = %v", err)
+	}
+	f.Stop(ctx)
+
+	outside := filepath.Join(t.TempDir(), "compose.yml")
+	os.WriteFile(outside, []byte("services: {}\n"), 0644)
+	for _, path := range []string{outside, "../compose.yml", "sub/../../compose.yml"} {
+		f, err = StartEnvironment(ctx, "c", t.TempDir(), &Environment{ComposeFile: path})
+		if err == nil || !strings.Contains(err.Error(), "inside the sandbox") {
+			t.Errorf("compose file %s: err = %v", path, err)
+		}
+		f.Stop(ctx)
+	}
+}
+
+func TestComposeProjectName(t *testing.T) {
+	name := composeProjectName("Fix Bug/#12")
+	if !strings.HasPrefix(name, "ricochet-eval-fix-bug--12-") {
+		t.Errorf("project name = %s", name)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/igoryan-dao/ricochet/internal/agent"
+	"github.com/igoryan-dao/ricochet/internal/host"
 )
 
 type Runner struct {
@@ -53,6 +54,26 @@ func (r *Runner) Run(ctx context.Context, tc *TestCase) (*Result, error) {
 		}
 	}
 
+	// 2.5 Bring up declared environment (docker-compose services, seed scripts)
+	if tc.Environment != nil {
+		fixture, err := StartEnvironment(ctx, tc.ID, tempDir, tc.Environment)
+		defer func() {
+			if fixture == nil {
+				return
+			}
+			if stopErr := fixture.Stop(ctx); stopErr != nil {
+				result.Logs = append(result.Logs, fmt.Sprintf("Environment teardown failed: %v", stopErr))
+			}
+		}()
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("Environment setup failed: %v", err))
+			result.Duration = time.Since(startTime)
+			return result, nil
+		}
+		result.Logs = append(result.Logs, "Environment ready")
+		ctx = host.WithCommandEnv(ctx, fixture.Env())
+	}
+
 	// 3. Initialize Agent Controller
 	// We need to override CWD for the executor to point to tempDir
 	// Note: NewNativeExecutor uses current CWD by default in NewController.
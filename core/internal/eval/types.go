@@ -6,12 +6,13 @@ import (
 
 // TestCase defines a single evaluation scenario
 type TestCase struct {
-	ID           string     `json:"id"`
-	Description  string     `json:"description"`
-	InitialState State      `json:"initial_state"`
-	Prompt       string     `json:"prompt"`
-	Expected     Assertions `json:"expected"`
-	Config       *Config    `json:"config,omitempty"`
+	ID           string       `json:"id"`
+	Description  string       `json:"description"`
+	InitialState State        `json:"initial_state"`
+	Prompt       string       `json:"prompt"`
+	Expected     Assertions   `json:"expected"`
+	Config       *Config      `json:"config,omitempty"`
+	Environment  *Environment `json:"environment,omitempty"` // Optional external services (databases, queues, ...)
 }
 
 // State represents the initial environment (files, env vars)
@@ -19,6 +20,16 @@ type State struct {
 	Files map[string]string `json:"files,omitempty"`
 }
 
+// Environment declares services the runner brings up before a case and tears down after it
+type Environment struct {
+	Compose             string            `json:"compose,omitempty"`               // Inline docker-compose YAML
+	ComposeFile         string            `json:"compose_file,omitempty"`          // Compose file path relative to the sandbox
+	Seed                []string          `json:"seed,omitempty"`                  // Shell commands run in the sandbox once services are ready
+	HealthCheck         string            `json:"health_check,omitempty"`          // Command retried until it succeeds
+	ReadyTimeoutSeconds int               `json:"ready_timeout_seconds,omitempty"` // Default: 60
+	Env                 map[string]string `json:"env,omitempty"`                   // Set for the case's compose, seed and health check commands and the agent's commands
+}
+
 // Assertions are checks to run after the agent finishes
 type Assertions struct {
 	Files      map[string]FileAssertion `json:"files,omitempty"`
@@ -0,0 +1,50 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	var before []string
+	for i := 1; i <= 20; i++ {
+		before = append(before, "line "+string(rune('a'+i-1)))
+	}
+	after := append([]string(nil), before...)
+	after[1] = "changed b"
+	after = append(after[:15], append([]string{"inserted"}, after[15:]...)...)
+
+	got := UnifiedDiff("x.txt", strings.Join(before, "\n")+"\n", strings.Join(after, "\n")+"\n")
+	want := `--- a/x.txt
++++ b/x.txt
+@@ -1,5 +1,5 @@
+ line a
+-line b
++changed b
+ line c
+ line d
+ line e
+@@ -13,6 +13,7 @@
+ line m
+ line n
+ line o
++inserted
+ line p
+ line q
+ line r
+`
+	if got != want {
+		t.Errorf("UnifiedDiff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffNewFile(t *testing.T) {
+	got := UnifiedDiff("new.go", "", "package x\n")
+	want := "--- a/new.go\n+++ b/new.go\n@@ -0,0 +1,1 @@\n+package x\n"
+	if got != want {
+		t.Errorf("UnifiedDiff = %q, want %q", got, want)
+	}
+	if got := UnifiedDiff("same", "a\n", "a\n"); got != "" {
+		t.Errorf("UnifiedDiff of equal texts = %q", got)
+	}
+}
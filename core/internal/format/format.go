@@ -2,6 +2,7 @@ package format
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 )
 
@@ -176,3 +177,29 @@ func ToDiscordMarkdown(text string) string {
 	// We could escape specific things if needed, but for AI output it's usually desired.
 	return text
 }
+
+var (
+	mdBold    = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	mdLink    = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^)\s]+)\)`)
+	mdHeading = regexp.MustCompile(`(?m)^#{1,6}\s+(.+)$`)
+)
+
+// ToSlackMrkdwn converts Markdown to Slack's mrkdwn: **bold** becomes *bold*,
+// links become <url|text> and headings bold lines
+func ToSlackMrkdwn(text string) string {
+	text = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+	text = mdBold.ReplaceAllString(text, "*$1*")
+	text = mdLink.ReplaceAllString(text, "<$2|$1>")
+	text = mdHeading.ReplaceAllString(text, "*$1*")
+	return text
+}
+
+// ToWhatsApp converts Markdown to WhatsApp formatting: **bold** becomes
+// *bold*, headings bold lines, and links "text (url)" since WhatsApp only
+// links bare URLs
+func ToWhatsApp(text string) string {
+	text = mdBold.ReplaceAllString(text, "*$1*")
+	text = mdLink.ReplaceAllString(text, "$1 ($2)")
+	text = mdHeading.ReplaceAllString(text, "*$1*")
+	return text
+}
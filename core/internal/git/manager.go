@@ -70,3 +70,55 @@ func (m *Manager) Commit(msg string) error {
 	_, err := m.execute("commit", "-m", msg)
 	return err
 }
+
+// BranchStatus summarizes the checkout: its branch, how it relates to the
+// upstream and which files changed
+type BranchStatus struct {
+	Branch   string   // Empty on a detached HEAD
+	Upstream string   // Empty without a tracking branch
+	Ahead    int      // Commits not yet pushed
+	Behind   int      // Upstream commits not yet pulled
+	Changes  []string // Short status lines, e.g. " M main.go"
+}
+
+// BranchStatus reads the branch and working tree status
+func (m *Manager) BranchStatus() (*BranchStatus, error) {
+	cmd := exec.Command("git", "status", "--porcelain=v1", "--branch")
+	cmd.Dir = m.cwd
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git status failed: %v", err)
+	}
+	return parseBranchStatus(string(out)), nil
+}
+
+// parseBranchStatus parses `git status --porcelain=v1 --branch`
+func parseBranchStatus(out string) *BranchStatus {
+	st := &BranchStatus{}
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "## ") {
+			st.Changes = append(st.Changes, line)
+			continue
+		}
+		head := strings.TrimPrefix(line, "## ")
+		if i := strings.Index(head, " ["); i >= 0 {
+			for _, part := range strings.Split(strings.Trim(head[i+2:], "]"), ", ") {
+				fmt.Sscanf(part, "ahead %d", &st.Ahead)
+				fmt.Sscanf(part, "behind %d", &st.Behind)
+			}
+			head = head[:i]
+		}
+		switch {
+		case strings.HasPrefix(head, "No commits yet on "):
+			st.Branch = strings.TrimPrefix(head, "No commits yet on ")
+		case strings.HasPrefix(head, "HEAD (no branch)"):
+			// Detached
+		default:
+			st.Branch, st.Upstream, _ = strings.Cut(head, "...")
+		}
+	}
+	return st
+}
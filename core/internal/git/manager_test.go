@@ -0,0 +1,19 @@
+package git
+
+import "testing"
+
+func TestParseBranchStatus(t *testing.T) {
+	st := parseBranchStatus("## main...origin/main [ahead 2, behind 1]\n M core/main.go\n?? notes.txt\n")
+	if st.Branch != "main" || st.Upstream != "origin/main" || st.Ahead != 2 || st.Behind != 1 || len(st.Changes) != 2 || st.Changes[0] != " M core/main.go" {
+		t.Errorf("tracking branch = %+v", st)
+	}
+	if st := parseBranchStatus("## feature\n"); st.Branch != "feature" || st.Upstream != "" || len(st.Changes) != 0 {
+		t.Errorf("local branch = %+v", st)
+	}
+	if st := parseBranchStatus("## No commits yet on main\n"); st.Branch != "main" {
+		t.Errorf("new repository = %+v", st)
+	}
+	if st := parseBranchStatus("## HEAD (no branch)\n"); st.Branch != "" {
+		t.Errorf("detached HEAD = %+v", st)
+	}
+}
@@ -0,0 +1,35 @@
+package host
+
+import (
+	"fmt"
+
+	"github.com/atotto/clipboard"
+
+	"github.com/igoryan-dao/ricochet/internal/notify"
+)
+
+// Desktop is implemented by hosts running on the user's own machine, where
+// the system clipboard and notification center are reachable
+type Desktop interface {
+	ReadClipboard() (string, error)
+	WriteClipboard(text string) error
+	Notify(title, message string) error
+}
+
+func (h *NativeHost) ReadClipboard() (string, error) {
+	if clipboard.Unsupported {
+		return "", fmt.Errorf("no clipboard utility found (install xclip, xsel or wl-clipboard)")
+	}
+	return clipboard.ReadAll()
+}
+
+func (h *NativeHost) WriteClipboard(text string) error {
+	if clipboard.Unsupported {
+		return fmt.Errorf("no clipboard utility found (install xclip, xsel or wl-clipboard)")
+	}
+	return clipboard.WriteAll(text)
+}
+
+func (h *NativeHost) Notify(title, message string) error {
+	return notify.Send(title, message)
+}
@@ -28,6 +28,20 @@ type Host interface {
 	SendRequest(method string, payload interface{}) (interface{}, error)
 }
 
+// Channeled is implemented by hosts that name the UI they ask the user
+// through ("tui", "ide", "cli"), for the approval audit log
+type Channeled interface {
+	Channel() string
+}
+
+// Channel names the UI a host asks through, or "unknown"
+func Channel(h Host) string {
+	if c, ok := h.(Channeled); ok {
+		return c.Channel()
+	}
+	return "unknown"
+}
+
 // CommandStatus represents the current state of a command
 type CommandStatus struct {
 	ID      string `json:"id"`
@@ -0,0 +1,203 @@
+package host
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// JobManager is implemented by hosts that track background commands as jobs
+type JobManager interface {
+	StartJob(ctx context.Context, command, name string) (CommandResult, error)
+	ListJobs() []CommandState
+	JobLogs(ref string, offset int64, lines int) (JobLog, error)
+	KillJob(ref string) (CommandState, error)
+}
+
+// JobLog is a slice of a job's log file
+type JobLog struct {
+	ID      string       `json:"id"`
+	Status  CommandLabel `json:"status"`
+	Content string       `json:"content"`
+	Offset  int64        `json:"offset"`      // Byte offset Content starts at
+	Next    int64        `json:"next_offset"` // Pass as offset to continue reading
+	Size    int64        `json:"size"`
+}
+
+const (
+	// maxJobLogRead caps one job_logs read
+	maxJobLogRead = 32 * 1024
+	// DefaultJobLogLines is the tail length when no offset is given
+	DefaultJobLogLines = 50
+)
+
+// StartJob runs a command in the background under an optional name. Names
+// must be unique among running jobs.
+func (o *CommandOrchestrator) StartJob(ctx context.Context, shellCmd, name string) (*CommandState, error) {
+	if name != "" {
+		o.mu.RLock()
+		for _, s := range o.commands {
+			if s.Name == name && s.Status == StatusRunning {
+				o.mu.RUnlock()
+				return nil, fmt.Errorf("job %q is already running (id %s); kill it or choose another name", name, shortID(s.ID))
+			}
+		}
+		o.mu.RUnlock()
+	}
+	return o.start(ctx, shellCmd, name, true)
+}
+
+// ListJobs returns background commands, running first, then newest first
+func (o *CommandOrchestrator) ListJobs() []CommandState {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	var jobs []CommandState
+	for _, s := range o.commands {
+		if s.Background {
+			job := *s
+			job.Output = "" // Listing only; use JobLogs for output
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		ri, rj := jobs[i].Status == StatusRunning, jobs[j].Status == StatusRunning
+		if ri != rj {
+			return ri
+		}
+		return jobs[i].StartTime.After(jobs[j].StartTime)
+	})
+	return jobs
+}
+
+// KillJob stops a running job and its child processes
+func (o *CommandOrchestrator) KillJob(ref string) (CommandState, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	state, err := o.resolveJob(ref)
+	if err != nil {
+		return CommandState{}, err
+	}
+	cancel, ok := o.cancels[state.ID]
+	if !ok || state.Status != StatusRunning {
+		return *state, fmt.Errorf("job %s is not running (%s)", shortID(state.ID), state.Status)
+	}
+	state.Status = StatusKilled
+	cancel()
+	return *state, nil
+}
+
+// JobLogs reads a job's log. With offset >= 0 it returns up to 32KB from that
+// byte offset; otherwise it returns the last lines lines.
+func (o *CommandOrchestrator) JobLogs(ref string, offset int64, lines int) (JobLog, error) {
+	o.mu.RLock()
+	state, err := o.resolveJob(ref)
+	var id, logFile string
+	var status CommandLabel
+	if err == nil {
+		id, logFile, status = state.ID, state.LogFile, state.Status
+	}
+	o.mu.RUnlock()
+	if err != nil {
+		return JobLog{}, err
+	}
+
+	f, err := os.Open(logFile)
+	if err != nil {
+		return JobLog{}, fmt.Errorf("open log: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return JobLog{}, err
+	}
+	size := info.Size()
+
+	log := JobLog{ID: id, Status: status, Size: size}
+	if offset >= 0 {
+		offset = min(offset, size)
+		buf := make([]byte, min(size-offset, maxJobLogRead))
+		n, err := f.ReadAt(buf, offset)
+		if err != nil && err != io.EOF {
+			return JobLog{}, err
+		}
+		log.Offset, log.Next, log.Content = offset, offset+int64(n), string(buf[:n])
+		return log, nil
+	}
+
+	if lines <= 0 {
+		lines = DefaultJobLogLines
+	}
+	start := max(size-maxJobLogRead, 0)
+	buf := make([]byte, size-start)
+	n, err := f.ReadAt(buf, start)
+	if err != nil && err != io.EOF {
+		return JobLog{}, err
+	}
+	text := string(buf[:n])
+	if start > 0 {
+		// Drop the partial first line
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			start += int64(i + 1)
+			text = text[i+1:]
+		}
+	}
+	all := strings.SplitAfter(text, "\n")
+	if all[len(all)-1] == "" {
+		all = all[:len(all)-1]
+	}
+	if len(all) > lines {
+		for _, l := range all[:len(all)-lines] {
+			start += int64(len(l))
+		}
+		all = all[len(all)-lines:]
+	}
+	log.Offset, log.Next, log.Content = start, size, strings.Join(all, "")
+	return log, nil
+}
+
+// resolveJob finds a background job by id, unique id prefix, or name (newest
+// job with that name). Caller holds o.mu.
+func (o *CommandOrchestrator) resolveJob(ref string) (*CommandState, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, fmt.Errorf("job id or name is required")
+	}
+	if s, ok := o.commands[ref]; ok && s.Background {
+		return s, nil
+	}
+
+	var byName, byPrefix []*CommandState
+	for _, s := range o.commands {
+		if !s.Background {
+			continue
+		}
+		if s.Name == ref {
+			byName = append(byName, s)
+		}
+		if strings.HasPrefix(s.ID, ref) {
+			byPrefix = append(byPrefix, s)
+		}
+	}
+	if len(byName) > 0 {
+		sort.Slice(byName, func(i, j int) bool { return byName[i].StartTime.After(byName[j].StartTime) })
+		return byName[0], nil
+	}
+	switch len(byPrefix) {
+	case 1:
+		return byPrefix[0], nil
+	case 0:
+		return nil, fmt.Errorf("no job %q; use list_jobs to see jobs", ref)
+	default:
+		return nil, fmt.Errorf("job id %q is ambiguous; use more characters", ref)
+	}
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
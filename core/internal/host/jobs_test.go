@@ -0,0 +1,75 @@
+//go:build !windows
+
+package host
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestJobLifecycle(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	o := NewCommandOrchestrator(t.TempDir())
+
+	job, err := o.StartJob(context.Background(), "for i in 1 2 3; do echo line$i; done; sleep 30", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.StartJob(context.Background(), "true", "server"); err == nil {
+		t.Error("duplicate running job name accepted")
+	}
+
+	waitFor(t, func() bool {
+		log, err := o.JobLogs("server", -1, 0)
+		return err == nil && strings.Contains(log.Content, "line3")
+	})
+
+	tail, _ := o.JobLogs("server", -1, 2)
+	if tail.Content != "line2\nline3\n" {
+		t.Errorf("tail = %q", tail.Content)
+	}
+	more, _ := o.JobLogs(job.ID[:8], tail.Next, 0)
+	if more.Content != "" || more.Next != tail.Next {
+		t.Errorf("read past end = %+v", more)
+	}
+
+	if _, err := o.KillJob("server"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		jobs := o.ListJobs()
+		return len(jobs) == 1 && jobs[0].Status == StatusKilled && !jobs[0].EndTime.IsZero()
+	})
+	if _, err := o.KillJob("server"); err == nil {
+		t.Error("killing a finished job should fail")
+	}
+}
+
+func TestCommandEnv(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	o := NewCommandOrchestrator(t.TempDir())
+
+	// Layers add up, as an eval case's variables and the egress proxy do
+	ctx := WithCommandEnv(context.Background(), []string{"RICOCHET_TEST_CASE=one"})
+	ctx = WithCommandEnv(ctx, []string{"RICOCHET_TEST_PROXY=http://127.0.0.1:1"})
+	state, err := o.Execute(ctx, "echo proxy=$RICOCHET_TEST_PROXY case=$RICOCHET_TEST_CASE", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(state.Output, "proxy=http://127.0.0.1:1 case=one") {
+		t.Errorf("env not passed to the command: %q", state.Output)
+	}
+}
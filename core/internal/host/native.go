@@ -99,6 +99,9 @@ func (h *NativeHost) AskUserChoice(question string, choices []string) (int, erro
 	return 0, fmt.Errorf("AskUserChoice not implemented for NativeHost")
 }
 
+// Channel implements Channeled
+func (h *NativeHost) Channel() string { return "cli" }
+
 func (h *NativeHost) SendMessage(msg protocol.RPCMessage) {
 	fmt.Printf("[NOTIFICATION] Type: %s, Payload: %s\n", msg.Type, string(msg.Payload))
 }
@@ -113,3 +116,23 @@ func (h *NativeHost) resolve(path string) string {
 	}
 	return filepath.Join(h.cwd, path)
 }
+
+func (h *NativeHost) StartJob(ctx context.Context, command, name string) (CommandResult, error) {
+	state, err := h.orchestrator.StartJob(ctx, command, name)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	return CommandResult{ID: state.ID}, nil
+}
+
+func (h *NativeHost) ListJobs() []CommandState {
+	return h.orchestrator.ListJobs()
+}
+
+func (h *NativeHost) JobLogs(ref string, offset int64, lines int) (JobLog, error) {
+	return h.orchestrator.JobLogs(ref, offset, lines)
+}
+
+func (h *NativeHost) KillJob(ref string) (CommandState, error) {
+	return h.orchestrator.KillJob(ref)
+}
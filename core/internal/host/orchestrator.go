@@ -22,6 +22,7 @@ const (
 	StatusRunning   CommandLabel = "running"
 	StatusCompleted CommandLabel = "completed"
 	StatusFailed    CommandLabel = "failed"
+	StatusKilled    CommandLabel = "killed"
 )
 
 const (
@@ -31,19 +32,23 @@ const (
 )
 
 type CommandState struct {
-	ID        string       `json:"id"`
-	Command   string       `json:"command"`
-	Status    CommandLabel `json:"status"`
-	Output    string       `json:"output,omitempty"`
-	Error     string       `json:"error,omitempty"`
-	LogFile   string       `json:"log_file,omitempty"`
-	StartTime time.Time    `json:"start_time"`
-	EndTime   time.Time    `json:"end_time,omitempty"`
+	ID         string       `json:"id"`
+	Name       string       `json:"name,omitempty"` // Optional job name for background commands
+	Command    string       `json:"command"`
+	Background bool         `json:"background,omitempty"`
+	PID        int          `json:"pid,omitempty"`
+	Status     CommandLabel `json:"status"`
+	Output     string       `json:"output,omitempty"`
+	Error      string       `json:"error,omitempty"`
+	LogFile    string       `json:"log_file,omitempty"`
+	StartTime  time.Time    `json:"start_time"`
+	EndTime    time.Time    `json:"end_time,omitempty"`
 }
 
 type CommandOrchestrator struct {
 	cwd      string
 	commands map[string]*CommandState
+	cancels  map[string]context.CancelFunc // Running background commands
 	mu       sync.RWMutex
 }
 
@@ -51,16 +56,32 @@ func NewCommandOrchestrator(cwd string) *CommandOrchestrator {
 	return &CommandOrchestrator{
 		cwd:      cwd,
 		commands: make(map[string]*CommandState),
+		cancels:  make(map[string]context.CancelFunc),
 	}
 }
 
+type commandEnvKey struct{}
+
+// WithCommandEnv adds environment variables to the commands started with
+// ctx, after those ctx already adds
+func WithCommandEnv(ctx context.Context, env []string) context.Context {
+	prev, _ := ctx.Value(commandEnvKey{}).([]string)
+	return context.WithValue(ctx, commandEnvKey{}, append(prev[:len(prev):len(prev)], env...))
+}
+
 func (o *CommandOrchestrator) Execute(ctx context.Context, shellCmd string, background bool) (*CommandState, error) {
+	return o.start(ctx, shellCmd, "", background)
+}
+
+func (o *CommandOrchestrator) start(ctx context.Context, shellCmd, name string, background bool) (*CommandState, error) {
 	id := uuid.New().String()
 	state := &CommandState{
-		ID:        id,
-		Command:   shellCmd,
-		Status:    StatusRunning,
-		StartTime: time.Now(),
+		ID:         id,
+		Name:       name,
+		Command:    shellCmd,
+		Background: background,
+		Status:     StatusRunning,
+		StartTime:  time.Now(),
 	}
 
 	o.mu.Lock()
@@ -83,16 +104,24 @@ func (o *CommandOrchestrator) Execute(ctx context.Context, shellCmd string, back
 	var cancel context.CancelFunc
 	if background {
 		// For background commands, we use a background context to avoid being killed when the tool call returns.
+		// The cancel func is kept so the job can be killed later.
 		cmdCtx, cancel = context.WithCancel(context.Background())
-		_ = cancel // In a real system, we'd store cancel to allow killing the process
+		o.mu.Lock()
+		o.cancels[id] = cancel
+		o.mu.Unlock()
 	} else {
 		cmdCtx = ctx
 	}
 
 	cmd := exec.CommandContext(cmdCtx, "sh", "-c", shellCmd)
 	cmd.Dir = o.cwd
+	if env, _ := ctx.Value(commandEnvKey{}).([]string); len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	if background {
+		// Kill the whole process tree (e.g. servers spawned by npm) with the job
+		setProcessGroup(cmd)
 		go o.runCommand(cmd, state)
 		return state, nil
 	}
@@ -119,16 +148,28 @@ func (o *CommandOrchestrator) runCommand(cmd *exec.Cmd, state *CommandState) {
 	cmd.Stdout = mw
 	cmd.Stderr = mw
 
-	err = cmd.Run()
+	if err = cmd.Start(); err == nil {
+		o.mu.Lock()
+		state.PID = cmd.Process.Pid
+		o.mu.Unlock()
+		err = cmd.Wait()
+	}
 
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
+	if cancel, ok := o.cancels[state.ID]; ok {
+		cancel()
+		delete(o.cancels, state.ID)
+	}
 	state.EndTime = time.Now()
-	if err != nil {
+	switch {
+	case state.Status == StatusKilled:
+		// Set by Kill
+	case err != nil:
 		state.Status = StatusFailed
 		state.Error = err.Error()
-	} else {
+	default:
 		state.Status = StatusCompleted
 	}
 
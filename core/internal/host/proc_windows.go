@@ -0,0 +1,13 @@
+//go:build windows
+
+package host
+
+import (
+	"os/exec"
+	"time"
+)
+
+// setProcessGroup relies on the default cancellation (kill the shell process)
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.WaitDelay = 5 * time.Second
+}
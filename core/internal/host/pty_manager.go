@@ -163,3 +163,24 @@ func (m *PTYManager) Close(id string) error {
 
 	return nil
 }
+
+// CloseAll terminates every PTY session, returning how many were running
+func (m *PTYManager) CloseAll() int {
+	m.mu.RLock()
+	var ids []string
+	running := 0
+	for id, session := range m.sessions {
+		ids = append(ids, id)
+		session.mu.Lock()
+		if session.Running {
+			running++
+		}
+		session.mu.Unlock()
+	}
+	m.mu.RUnlock()
+
+	for _, id := range ids {
+		m.Close(id)
+	}
+	return running
+}
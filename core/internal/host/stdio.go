@@ -79,6 +79,26 @@ func (h *StdioHost) GetCommandStatus(id string) (CommandStatus, bool) {
 	}, true
 }
 
+func (h *StdioHost) StartJob(ctx context.Context, command, name string) (CommandResult, error) {
+	state, err := h.orchestrator.StartJob(ctx, command, name)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	return CommandResult{ID: state.ID}, nil
+}
+
+func (h *StdioHost) ListJobs() []CommandState {
+	return h.orchestrator.ListJobs()
+}
+
+func (h *StdioHost) JobLogs(ref string, offset int64, lines int) (JobLog, error) {
+	return h.orchestrator.JobLogs(ref, offset, lines)
+}
+
+func (h *StdioHost) KillJob(ref string) (CommandState, error) {
+	return h.orchestrator.KillJob(ref)
+}
+
 func (h *StdioHost) ShowMessage(level string, text string) {
 	h.sendNotification("show_message", map[string]string{
 		"level": level,
@@ -91,6 +111,9 @@ func (h *StdioHost) AskUser(question string) (string, error) {
 	return h.AskUserWithID(question, id)
 }
 
+// Channel implements Channeled
+func (h *StdioHost) Channel() string { return "ide" }
+
 func (h *StdioHost) AskUserChoice(question string, choices []string) (int, error) {
 	id := fmt.Sprintf("choice-%d", time.Now().UnixNano())
 	return h.AskUserChoiceWithID(question, choices, id)
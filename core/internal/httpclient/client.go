@@ -0,0 +1,184 @@
+// Package httpclient provides the shared outbound HTTP transport. It honors
+// HTTPS_PROXY/HTTP_PROXY/NO_PROXY (or the network section of settings.json),
+// trusts extra CA bundles, and applies per-provider request timeouts.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/igoryan-dao/ricochet/internal/config"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// CABundleEnv names an extra PEM bundle to trust, in addition to settings
+const CABundleEnv = "RICOCHET_CA_BUNDLE"
+
+var (
+	mu        sync.RWMutex
+	settings  config.NetworkSettings
+	proxyFunc = proxyFromConfig(config.NetworkSettings{})
+	tlsConfig *tls.Config // nil = Go defaults
+	transport = newTransport()
+)
+
+// Configure applies network settings to every client created by this package,
+// including ones created before the call.
+func Configure(s config.NetworkSettings) error {
+	tlsCfg, err := loadTLSConfig(s)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	old := transport
+	settings = s
+	proxyFunc = proxyFromConfig(s)
+	tlsConfig = tlsCfg
+	transport = newTransport()
+	mu.Unlock()
+
+	old.CloseIdleConnections()
+	if s.ProxyURL != "" {
+		log.Printf("🌐 Using proxy %s", redactURL(s.ProxyURL))
+	}
+	return nil
+}
+
+// Transport returns a RoundTripper that always uses the current configuration
+func Transport() http.RoundTripper {
+	return sharedTransport{}
+}
+
+// Client returns a client on the shared transport. A zero timeout means none.
+func Client(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: Transport(), Timeout: timeout}
+}
+
+// ForProvider returns a client with the provider's configured timeout, or fallback
+func ForProvider(provider string, fallback time.Duration) *http.Client {
+	return Client(ProviderTimeout(provider, fallback))
+}
+
+// ProviderTimeout returns network.timeouts[provider] if set and valid, else fallback
+func ProviderTimeout(provider string, fallback time.Duration) time.Duration {
+	mu.RLock()
+	raw := settings.Timeouts[strings.ToLower(provider)]
+	mu.RUnlock()
+
+	if raw == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		log.Printf("Warning: invalid timeout %q for provider %s, using %v", raw, provider, fallback)
+		return fallback
+	}
+	return d
+}
+
+// WebsocketDialer returns a dialer that goes through the same proxy and TLS roots
+func WebsocketDialer() *websocket.Dialer {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return &websocket.Dialer{
+		Proxy:            Proxy,
+		TLSClientConfig:  tlsConfig.Clone(),
+		HandshakeTimeout: 45 * time.Second,
+	}
+}
+
+// Proxy resolves the proxy for a request under the current configuration
+func Proxy(req *http.Request) (*url.URL, error) {
+	mu.RLock()
+	fn := proxyFunc
+	mu.RUnlock()
+	return fn(req.URL)
+}
+
+type sharedTransport struct{}
+
+func (sharedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	mu.RLock()
+	t := transport
+	mu.RUnlock()
+	return t.RoundTrip(req)
+}
+
+// newTransport builds a transport from the current package state. Caller must hold mu or be in init.
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy:               Proxy,
+		TLSClientConfig:     tlsConfig.Clone(),
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+	}
+}
+
+// proxyFromConfig reads proxy variables from the environment, letting settings override them
+func proxyFromConfig(s config.NetworkSettings) func(*url.URL) (*url.URL, error) {
+	cfg := httpproxy.FromEnvironment()
+	if s.ProxyURL != "" {
+		cfg.HTTPProxy = s.ProxyURL
+		cfg.HTTPSProxy = s.ProxyURL
+	}
+	if s.NoProxy != "" {
+		cfg.NoProxy = s.NoProxy
+	}
+	return cfg.ProxyFunc()
+}
+
+// loadTLSConfig adds the configured CA bundles to the system roots
+func loadTLSConfig(s config.NetworkSettings) (*tls.Config, error) {
+	var bundles []string
+	if s.CABundle != "" {
+		bundles = append(bundles, s.CABundle)
+	}
+	if env := os.Getenv(CABundleEnv); env != "" {
+		bundles = append(bundles, env)
+	}
+	if len(bundles) == 0 {
+		return nil, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	for _, path := range bundles {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+		}
+	}
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
+// redactURL hides proxy credentials for logging
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "(invalid url)"
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,389 @@
+package index
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Phases of a full index, in order
+const (
+	PhaseScanning  = "scanning"
+	PhaseChunking  = "chunking"
+	PhaseEmbedding = "embedding"
+	PhaseSaving    = "saving"
+	PhaseDone      = "done"
+	PhaseFailed    = "failed"
+)
+
+// Progress of a full index
+type Progress struct {
+	Phase       string    `json:"phase"`
+	FilesDone   int       `json:"files_done"`
+	FilesTotal  int       `json:"files_total"`
+	ChunksDone  int       `json:"chunks_done"`
+	ChunksTotal int       `json:"chunks_total"`
+	Resumed     int       `json:"resumed,omitempty"`     // Chunks reused from an interrupted run
+	ETASeconds  int       `json:"eta_seconds,omitempty"` // Estimated time left while embedding
+	Throttled   string    `json:"throttled,omitempty"`   // Why embedding is paused, e.g. a rate limit
+	Error       string    `json:"error,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+// Summary describes the progress in a line, for task progress displays
+func (p Progress) Summary() string {
+	switch p.Phase {
+	case PhaseScanning:
+		return "Scanning workspace"
+	case PhaseChunking:
+		return fmt.Sprintf("Chunking files %d/%d", p.FilesDone, p.FilesTotal)
+	case PhaseEmbedding:
+		s := fmt.Sprintf("Embedding %d/%d files (%d/%d chunks", p.FilesDone, p.FilesTotal, p.ChunksDone, p.ChunksTotal)
+		if p.Resumed > 0 {
+			s += fmt.Sprintf(", %d resumed", p.Resumed)
+		}
+		s += ")"
+		if p.Throttled != "" {
+			s += ", " + p.Throttled
+		} else if p.ETASeconds > 0 {
+			s += fmt.Sprintf(", about %s left", time.Duration(p.ETASeconds)*time.Second)
+		}
+		return s
+	case PhaseSaving:
+		return fmt.Sprintf("Saving %d chunks", p.ChunksTotal)
+	case PhaseFailed:
+		return "Indexing failed: " + p.Error
+	default:
+		return fmt.Sprintf("Indexed %d files (%d chunks) in %s", p.FilesTotal, p.ChunksTotal, time.Since(p.StartedAt).Round(time.Second))
+	}
+}
+
+// SetProgress sets a callback for full index progress. Updates come at
+// most every progressInterval, and on every phase change.
+func (idx *Indexer) SetProgress(fn func(Progress)) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.onProgress = fn
+}
+
+// SetResumeFile sets where IndexAll keeps the embeddings made so far, so a
+// run interrupted by a crash, a restart or an error resumes instead of
+// paying for every chunk again. The file is removed once the index is saved.
+func (idx *Indexer) SetResumeFile(path string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.resumePath = path
+}
+
+const progressInterval = 500 * time.Millisecond
+
+// progressTracker publishes a full index's progress to Status and the callback
+type progressTracker struct {
+	idx      *Indexer
+	lastSent time.Time
+	phase    string
+}
+
+func (idx *Indexer) startProgress() *progressTracker {
+	t := &progressTracker{idx: idx}
+	idx.mu.Lock()
+	idx.progress = &Progress{Phase: PhaseScanning, StartedAt: time.Now()}
+	idx.mu.Unlock()
+	t.publish(true)
+	return t
+}
+
+func (t *progressTracker) update(fn func(p *Progress)) {
+	t.idx.mu.Lock()
+	fn(t.idx.progress)
+	p := t.idx.progress
+	if p.Phase == PhaseEmbedding && p.ChunksDone > p.Resumed {
+		// Resumed chunks cost nothing, so they don't count toward the rate
+		elapsed := time.Since(p.StartedAt)
+		rate := float64(p.ChunksDone-p.Resumed) / elapsed.Seconds()
+		p.ETASeconds = int(float64(p.ChunksTotal-p.ChunksDone) / rate)
+	}
+	phaseChanged := p.Phase != t.phase
+	t.phase = p.Phase
+	t.idx.mu.Unlock()
+	t.publish(phaseChanged)
+}
+
+func (t *progressTracker) finish(err error) {
+	t.update(func(p *Progress) {
+		p.Phase, p.Throttled, p.ETASeconds = PhaseDone, "", 0
+		if err != nil {
+			p.Phase, p.Error = PhaseFailed, err.Error()
+		}
+	})
+	t.idx.mu.Lock()
+	t.idx.progress = nil
+	t.idx.mu.Unlock()
+}
+
+func (t *progressTracker) publish(force bool) {
+	if !force && time.Since(t.lastSent) < progressInterval {
+		return
+	}
+	t.idx.mu.RLock()
+	fn := t.idx.onProgress
+	p := *t.idx.progress
+	t.idx.mu.RUnlock()
+	if fn != nil {
+		t.lastSent = time.Now()
+		fn(p)
+	}
+}
+
+// embedBatchSize is how many chunks go to the embedder per request
+const embedBatchSize = 20
+
+// maxEmbedRetries is how often a rate-limited batch is retried, backing off
+// from embedRetryBase up to embedRetryMax between attempts
+const maxEmbedRetries = 6
+
+var (
+	embedRetryBase = 2 * time.Second
+	embedRetryMax  = time.Minute
+)
+
+// embed fills in the embeddings of docs, in batches. Secrets in the chunks
+// are redacted first, so they reach neither the embedder nor the store.
+// Chunks already in checkpoint are reused and new ones are added to it;
+// onProgress, if set, gets the chunks done after every batch, how many of
+// those were resumed, and the length of the prefix of docs that is done.
+func (idx *Indexer) embed(ctx context.Context, docs []Document, checkpoint *embedCheckpoint, onProgress func(done, resumed, prefix int)) error {
+	redactions := 0
+	for i := range docs {
+		var n int
+		docs[i].Content, n = redactSecrets(docs[i].Content)
+		redactions += n
+	}
+	if redactions > 0 {
+		log.Printf("Index: redacted %d secret(s) before embedding", redactions)
+	}
+
+	resumed := 0
+	var pending []int
+	for i := range docs {
+		if vec := checkpoint.lookup(docs[i].Content); vec != nil {
+			docs[i].Embedding = vec
+			resumed++
+		} else {
+			pending = append(pending, i)
+		}
+	}
+	if resumed > 0 {
+		log.Printf("Index: resuming, %d of %d chunks already embedded", resumed, len(docs))
+	}
+
+	want := 0
+	for i := range docs {
+		if docs[i].Embedding != nil {
+			want = len(docs[i].Embedding)
+			break
+		}
+	}
+	for start := 0; start < len(pending); start += embedBatchSize {
+		end := min(start+embedBatchSize, len(pending))
+		batch := pending[start:end]
+
+		var batchTexts []string
+		for _, i := range batch {
+			batchTexts = append(batchTexts, docs[i].Content)
+		}
+
+		embeddings, err := idx.embedWithRetry(ctx, batchTexts)
+		if err != nil {
+			return fmt.Errorf("failed to generate embeddings: %w", err)
+		}
+		if len(embeddings) != len(batch) {
+			return fmt.Errorf("embedder returned %d vectors for %d chunks", len(embeddings), len(batch))
+		}
+		if want == 0 {
+			want = len(embeddings[0])
+		}
+		for j, emb := range embeddings {
+			if len(emb) != want {
+				return fmt.Errorf("embedder returned vectors of mixed sizes (%d and %d)", want, len(emb))
+			}
+			docs[batch[j]].Embedding = emb
+			checkpoint.add(docs[batch[j]].Content, emb)
+		}
+
+		// Docs before the next pending one are all embedded, resumed or not
+		prefix := len(docs)
+		if end < len(pending) {
+			prefix = pending[end]
+		}
+		if onProgress != nil {
+			onProgress(resumed+end, resumed, prefix)
+		}
+	}
+	if onProgress != nil && len(pending) == 0 {
+		onProgress(len(docs), resumed, len(docs))
+	}
+	return nil
+}
+
+// embedWithRetry embeds a batch, waiting out rate limits with exponential
+// backoff; progress shows the pause
+func (idx *Indexer) embedWithRetry(ctx context.Context, texts []string) ([][]float32, error) {
+	wait := embedRetryBase
+	for attempt := 0; ; attempt++ {
+		embeddings, err := idx.provider.Embed(ctx, texts)
+		if err == nil || !isRateLimited(err) || attempt == maxEmbedRetries {
+			idx.setThrottled("")
+			return embeddings, err
+		}
+		log.Printf("Index: embedder rate limited, retrying in %v: %v", wait, err)
+		idx.setThrottled(fmt.Sprintf("rate limited, retrying in %v", wait))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, embedRetryMax)
+	}
+}
+
+func (idx *Indexer) setThrottled(reason string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.progress != nil {
+		idx.progress.Throttled = reason
+	}
+}
+
+// isRateLimited reports whether an embedder error is a rate limit or quota
+// response worth waiting out
+func isRateLimited(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, marker := range []string{"429", "rate limit", "rate_limit", "too many requests", "resource_exhausted", "throttl"} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// embedCheckpoint keeps the embeddings of an unfinished IndexAll on disk,
+// one JSON line per chunk after a header naming the model. A nil
+// checkpoint does nothing.
+type embedCheckpoint struct {
+	path    string
+	file    *os.File
+	vectors map[string][]float32 // By content hash
+}
+
+type checkpointHeader struct {
+	Model string `json:"model"`
+}
+
+type checkpointEntry struct {
+	Hash   string    `json:"h"`
+	Vector []float32 `json:"v"`
+}
+
+// openCheckpoint loads the resume file, if one is set. A file written by
+// another embedding model is started over.
+func (idx *Indexer) openCheckpoint() *embedCheckpoint {
+	idx.mu.RLock()
+	path := idx.resumePath
+	idx.mu.RUnlock()
+	if path == "" {
+		return nil
+	}
+	model := EmbeddingModelOf(idx.provider)
+	cp := &embedCheckpoint{path: path, vectors: make(map[string][]float32)}
+
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+		var header checkpointHeader
+		if scanner.Scan() && json.Unmarshal(scanner.Bytes(), &header) == nil && header.Model == model {
+			for scanner.Scan() {
+				var e checkpointEntry
+				if json.Unmarshal(scanner.Bytes(), &e) == nil && e.Hash != "" {
+					cp.vectors[e.Hash] = e.Vector
+				}
+			}
+		}
+		f.Close()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("Warning: can't keep embeddings to resume from: %v", err)
+		return cp
+	}
+	// Rewrite what is kept, dropping a stale header or a torn last line
+	f, err := os.Create(path)
+	if err != nil {
+		log.Printf("Warning: can't keep embeddings to resume from: %v", err)
+		return cp
+	}
+	cp.file = f
+	cp.write(checkpointHeader{Model: model})
+	for hash, vec := range cp.vectors {
+		cp.write(checkpointEntry{Hash: hash, Vector: vec})
+	}
+	return cp
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (cp *embedCheckpoint) lookup(content string) []float32 {
+	if cp == nil {
+		return nil
+	}
+	return cp.vectors[contentHash(content)]
+}
+
+func (cp *embedCheckpoint) add(content string, vec []float32) {
+	if cp == nil {
+		return
+	}
+	hash := contentHash(content)
+	cp.vectors[hash] = vec
+	cp.write(checkpointEntry{Hash: hash, Vector: vec})
+}
+
+func (cp *embedCheckpoint) write(v interface{}) {
+	if cp.file == nil {
+		return
+	}
+	data, _ := json.Marshal(v)
+	if _, err := cp.file.Write(append(data, '\n')); err != nil {
+		log.Printf("Warning: failed to save embeddings to resume from: %v", err)
+		cp.file.Close()
+		cp.file = nil
+	}
+}
+
+// Close flushes the checkpoint, keeping it for the next run
+func (cp *embedCheckpoint) Close() {
+	if cp != nil && cp.file != nil {
+		cp.file.Close()
+		cp.file = nil
+	}
+}
+
+// Remove deletes the checkpoint once the index it resumes is saved
+func (cp *embedCheckpoint) Remove() {
+	if cp == nil {
+		return
+	}
+	cp.Close()
+	os.Remove(cp.path)
+}
@@ -0,0 +1,157 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedEmbedder embeds like the hash embedder, but can fail: it answers
+// rateLimits calls with a 429 and fails every call after failAfter texts
+type scriptedEmbedder struct {
+	*HashEmbedder
+	mu         sync.Mutex
+	embedded   int
+	failAfter  int
+	rateLimits int
+}
+
+func (s *scriptedEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rateLimits > 0 {
+		s.rateLimits--
+		return nil, errors.New("API error 429: Too Many Requests")
+	}
+	if s.failAfter > 0 && s.embedded+len(texts) > s.failAfter {
+		return nil, errors.New("connection reset")
+	}
+	s.embedded += len(texts)
+	return s.HashEmbedder.Embed(ctx, texts)
+}
+
+func writeSources(t *testing.T, root string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		writeSource(t, root, fmt.Sprintf("file%02d.go", i), fmt.Sprintf("package main\n\nfunc F%d() int { return %d }\n", i, i))
+	}
+}
+
+func TestIndexAll_Progress(t *testing.T) {
+	idx, _, root := newWatchedIndexer(t)
+	writeSources(t, root, 45)
+
+	var events []Progress
+	idx.SetProgress(func(p Progress) { events = append(events, p) })
+	if err := idx.IndexAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	phases := map[string]bool{}
+	for _, p := range events {
+		phases[p.Phase] = true
+	}
+	for _, phase := range []string{PhaseScanning, PhaseChunking, PhaseEmbedding, PhaseSaving, PhaseDone} {
+		if !phases[phase] {
+			t.Errorf("no %s event in %+v", phase, events)
+		}
+	}
+	last := events[len(events)-1]
+	if last.Phase != PhaseDone || last.FilesTotal != 45 || last.ChunksTotal == 0 {
+		t.Errorf("last event = %+v", last)
+	}
+	if idx.Status().Progress != nil {
+		t.Error("progress still reported after indexing")
+	}
+}
+
+func TestIndexAll_ResumesAfterFailure(t *testing.T) {
+	root := t.TempDir()
+	writeSources(t, root, 45)
+	store, err := NewLocalStore(filepath.Join(t.TempDir(), "index.vdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resume := filepath.Join(t.TempDir(), "cache", "embeddings.partial")
+
+	embedder := &scriptedEmbedder{HashEmbedder: NewHashEmbedder(), failAfter: 30}
+	idx := NewIndexer(store, embedder, root)
+	idx.SetResumeFile(resume)
+	if err := idx.IndexAll(context.Background()); err == nil {
+		t.Fatal("expected the embedder failure")
+	}
+	firstRun := embedder.embedded
+	if firstRun == 0 {
+		t.Fatal("nothing embedded before the failure")
+	}
+
+	// A fresh indexer, as after a restart, only embeds what is left
+	embedder = &scriptedEmbedder{HashEmbedder: NewHashEmbedder()}
+	idx = NewIndexer(store, embedder, root)
+	idx.SetResumeFile(resume)
+	var last Progress
+	idx.SetProgress(func(p Progress) { last = p })
+	if err := idx.IndexAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if last.Resumed != firstRun || embedder.embedded != last.ChunksTotal-firstRun {
+		t.Errorf("resumed %d and embedded %d of %d chunks, want %d resumed", last.Resumed, embedder.embedded, last.ChunksTotal, firstRun)
+	}
+	if _, err := os.Stat(resume); !os.IsNotExist(err) {
+		t.Errorf("resume file kept after a successful index: %v", err)
+	}
+}
+
+func TestIndexAll_ResumeIgnoresOtherModel(t *testing.T) {
+	resume := filepath.Join(t.TempDir(), "embeddings.partial")
+	if err := os.WriteFile(resume, []byte(`{"model":"other"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx := NewIndexer(nil, NewHashEmbedder(), t.TempDir())
+	idx.SetResumeFile(resume)
+	cp := idx.openCheckpoint()
+	defer cp.Remove()
+	if len(cp.vectors) != 0 {
+		t.Errorf("kept %d vectors of another model", len(cp.vectors))
+	}
+}
+
+func TestEmbed_RetriesRateLimits(t *testing.T) {
+	base := embedRetryBase
+	embedRetryBase = time.Millisecond
+	defer func() { embedRetryBase = base }()
+
+	embedder := &scriptedEmbedder{HashEmbedder: NewHashEmbedder(), rateLimits: 2}
+	idx := NewIndexer(nil, embedder, t.TempDir())
+	docs := []Document{{Content: "a"}, {Content: "b"}}
+	if err := idx.embed(context.Background(), docs, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if docs[0].Embedding == nil || docs[1].Embedding == nil {
+		t.Error("documents not embedded after the rate limit cleared")
+	}
+
+	embedder.rateLimits = maxEmbedRetries + 1
+	if err := idx.embed(context.Background(), []Document{{Content: "c"}}, nil, nil); err == nil {
+		t.Error("expected an error once retries run out")
+	}
+}
+
+func TestIsRateLimited(t *testing.T) {
+	for msg, want := range map[string]bool{
+		"API error 429: slow down":          true,
+		"Rate limit exceeded":               true,
+		"status RESOURCE_EXHAUSTED":         true,
+		"dial tcp: connection refused":      false,
+		"embedder returned 3 vectors for 4": false,
+	} {
+		if got := isRateLimited(errors.New(msg)); got != want {
+			t.Errorf("isRateLimited(%q) = %v, want %v", msg, got, want)
+		}
+	}
+}
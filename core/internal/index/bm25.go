@@ -0,0 +1,127 @@
+package index
+
+import (
+	"math"
+	"sort"
+)
+
+// BM25 parameters: term frequency saturation and document length normalization
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// rrfK dampens the weight of top ranks in reciprocal rank fusion; 60 is the
+// value from the original paper and works without tuning
+const rrfK = 60
+
+// keywordSearcher is implemented by stores that also rank documents by the
+// words of a query, for identifiers that embeddings miss
+type keywordSearcher interface {
+	KeywordSearch(query string, limit int) ([]SearchResult, error)
+}
+
+// keywordIndex is an inverted index of documents, ranked with BM25. Terms
+// are the tokens of the built-in embedder, so "RateLimiter" matches both
+// "ratelimiter" and "rate limiter".
+type keywordIndex struct {
+	postings map[string][]posting
+	lengths  []int // Tokens per document
+	avgLen   float64
+}
+
+type posting struct {
+	doc  int // Index into the store's documents
+	freq int
+}
+
+func buildKeywordIndex(docs []Document) *keywordIndex {
+	k := &keywordIndex{postings: make(map[string][]posting), lengths: make([]int, len(docs))}
+	total := 0
+	for i, d := range docs {
+		tokens := hashTokens(d.Content)
+		k.lengths[i] = len(tokens)
+		total += len(tokens)
+
+		freqs := make(map[string]int)
+		for _, tok := range tokens {
+			freqs[tok]++
+		}
+		for tok, n := range freqs {
+			k.postings[tok] = append(k.postings[tok], posting{doc: i, freq: n})
+		}
+	}
+	if len(docs) > 0 {
+		k.avgLen = float64(total) / float64(len(docs))
+	}
+	return k
+}
+
+// search returns the indexes of the documents matching query, best first,
+// with their BM25 scores
+func (k *keywordIndex) search(query string, limit int) ([]int, []float64) {
+	n := float64(len(k.lengths))
+	scores := make(map[int]float64)
+	seen := make(map[string]bool)
+	for _, term := range hashTokens(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		list := k.postings[term]
+		if len(list) == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-float64(len(list))+0.5)/(float64(len(list))+0.5))
+		for _, p := range list {
+			tf := float64(p.freq)
+			norm := 1 - bm25B + bm25B*float64(k.lengths[p.doc])/k.avgLen
+			scores[p.doc] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+	}
+
+	docs := make([]int, 0, len(scores))
+	for doc := range scores {
+		docs = append(docs, doc)
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		if scores[docs[i]] != scores[docs[j]] {
+			return scores[docs[i]] > scores[docs[j]]
+		}
+		return docs[i] < docs[j]
+	})
+	if len(docs) > limit {
+		docs = docs[:limit]
+	}
+	ranked := make([]float64, len(docs))
+	for i, doc := range docs {
+		ranked[i] = scores[doc]
+	}
+	return docs, ranked
+}
+
+// fuseRanks merges ranked result lists with reciprocal rank fusion: a
+// document scores 1/(rrfK+rank) in every list it appears in. Scores are
+// scaled so a document ranked first in every list scores 1.
+func fuseRanks(lists ...[]SearchResult) []SearchResult {
+	var fused []SearchResult
+	index := make(map[string]int)
+	for _, list := range lists {
+		for rank, res := range list {
+			score := 1 / float64(rrfK+rank+1)
+			if i, ok := index[res.Document.ID]; ok {
+				fused[i].Score += score
+				continue
+			}
+			index[res.Document.ID] = len(fused)
+			fused = append(fused, SearchResult{Document: res.Document, Score: score})
+		}
+	}
+
+	best := float64(len(lists)) / float64(rrfK+1)
+	for i := range fused {
+		fused[i].Score /= best
+	}
+	sort.SliceStable(fused, func(i, j int) bool { return fused[i].Score > fused[j].Score })
+	return fused
+}
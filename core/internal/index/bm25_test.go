@@ -0,0 +1,88 @@
+package index
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeywordSearch(t *testing.T) {
+	store, err := NewLocalStore(filepath.Join(t.TempDir(), "index.vdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Add([]Document{
+		{ID: "limiter", FilePath: "limit.go", Content: "type RateLimiter struct {\n\ttokens int\n}"},
+		{ID: "server", FilePath: "server.go", Content: "func (s *Server) Serve() error {\n\treturn s.listen()\n}"},
+		{ID: "rates", FilePath: "rates.go", Content: "// exchange rate table\nvar rates = map[string]float64{}"},
+	})
+
+	results, err := store.KeywordSearch("RateLimiter struct", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 || results[0].Document.ID != "limiter" {
+		t.Fatalf("results = %+v, want the RateLimiter struct first", results)
+	}
+	for _, r := range results {
+		if r.Document.ID == "server" {
+			t.Error("a document without any query term matched")
+		}
+	}
+
+	// The index follows changes to the store
+	store.RemoveFiles([]string{"limit.go"})
+	results, _ = store.KeywordSearch("RateLimiter", 10)
+	for _, r := range results {
+		if r.Document.ID == "limiter" {
+			t.Error("removed document still found")
+		}
+	}
+}
+
+func TestSearch_FusesKeywordRanking(t *testing.T) {
+	store, err := NewLocalStore(filepath.Join(t.TempDir(), "index.vdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every vector is the same, so only keywords can tell the documents apart
+	emb := &fixedEmbedder{"test/fixed", 4}
+	vec, _ := emb.Embed(context.Background(), []string{""})
+	var docs []Document
+	for _, id := range []string{"a", "b", "c", "d"} {
+		docs = append(docs, Document{ID: id, Content: "func helper" + id + "() {}", Embedding: vec[0]})
+	}
+	docs = append(docs, Document{ID: "target", Content: "type RateLimiter struct{}", Embedding: vec[0]})
+	store.Add(docs)
+
+	idx := NewIndexer(store, emb, t.TempDir())
+	results, err := idx.Search(context.Background(), "RateLimiter", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Document.ID != "target" {
+		t.Fatalf("results = %+v, want the keyword match first", results)
+	}
+	if results[0].Score <= results[1].Score || results[0].Score > 1 {
+		t.Errorf("scores = %.3f, %.3f", results[0].Score, results[1].Score)
+	}
+}
+
+func TestFuseRanks(t *testing.T) {
+	docs := map[string]*Document{"a": {ID: "a"}, "b": {ID: "b"}, "c": {ID: "c"}}
+	list := func(ids ...string) []SearchResult {
+		var out []SearchResult
+		for _, id := range ids {
+			out = append(out, SearchResult{Document: docs[id]})
+		}
+		return out
+	}
+
+	fused := fuseRanks(list("a", "b"), list("b", "c"))
+	if len(fused) != 3 || fused[0].Document.ID != "b" {
+		t.Fatalf("fused = %+v, want b (in both lists) first", fused)
+	}
+	if top := fuseRanks(list("a"), list("a")); top[0].Score != 1 {
+		t.Errorf("first in every list scored %v, want 1", top[0].Score)
+	}
+}
@@ -0,0 +1,259 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	ricochetContext "github.com/igoryan-dao/ricochet/internal/context"
+)
+
+const (
+	maxChunkLines = 150  // Longer definitions are split into their members, or into parts
+	windowLines   = 50   // Chunk size for code outside definitions
+	maxChunkChars = 8000 // Chunks are cut here, to stay within embedder input limits
+)
+
+// Symbol is a definition within a chunk, located for get_definitions
+type Symbol struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Line      int    `json:"line"`      // 1-indexed
+	Character int    `json:"character"` // 0-indexed, UTF-16, of the name
+}
+
+// Symbols returns the definitions within the document's chunk
+func (d *Document) Symbols() []Symbol {
+	switch v := d.Metadata["symbols"].(type) {
+	case []Symbol:
+		return v
+	case []interface{}: // From a store, decoded as JSON
+		data, _ := json.Marshal(v)
+		var symbols []Symbol
+		json.Unmarshal(data, &symbols)
+		return symbols
+	}
+	return nil
+}
+
+// defNode is a definition and the definitions nested in it, like a class
+// and its methods. start and end are 0-indexed lines, end exclusive, with
+// the doc comment above the definition included.
+type defNode struct {
+	def        ricochetContext.Definition
+	start, end int
+	symbol     Symbol
+	children   []*defNode
+}
+
+// chunker splits a file along its definitions, so each chunk is a whole
+// function, type or class where possible. Code between definitions
+// (imports, package-level variables) is chunked too.
+type chunker struct {
+	relPath string
+	lines   []string
+	imports []string
+	docs    []Document
+}
+
+// chunkDefinitions chunks a file by the definitions found in it
+func chunkDefinitions(relPath string, lines []string, defs []ricochetContext.Definition, imports []string) []Document {
+	c := &chunker{relPath: relPath, lines: lines, imports: imports}
+	c.span(0, len(lines), c.nest(defs), nil)
+	return c.docs
+}
+
+// nest arranges definitions into a tree by their line spans
+func (c *chunker) nest(defs []ricochetContext.Definition) []*defNode {
+	sorted := make([]ricochetContext.Definition, 0, len(defs))
+	for _, d := range defs {
+		if d.Name != "" && d.LineStart >= 1 && d.LineEnd >= d.LineStart && d.LineStart <= len(c.lines) {
+			sorted = append(sorted, d)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].LineStart != sorted[j].LineStart {
+			return sorted[i].LineStart < sorted[j].LineStart
+		}
+		return sorted[i].LineEnd > sorted[j].LineEnd
+	})
+
+	var roots, stack []*defNode
+	for _, d := range sorted {
+		n := &defNode{def: d, start: d.LineStart - 1, end: min(d.LineEnd, len(c.lines))}
+		for len(stack) > 0 && n.start >= stack[len(stack)-1].end {
+			stack = stack[:len(stack)-1]
+		}
+		siblings, floor := &roots, 0
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1]
+			n.end = min(n.end, parent.end)
+			siblings, floor = &parent.children, parent.start+1
+		}
+		if len(*siblings) > 0 {
+			floor = max(floor, (*siblings)[len(*siblings)-1].end)
+		}
+		n.symbol = c.locate(d)
+		n.start = c.withComments(n.start, floor)
+		*siblings = append(*siblings, n)
+		stack = append(stack, n)
+	}
+	return roots
+}
+
+// withComments moves a definition's first line up over the comments,
+// decorators and attributes directly above it, but not above floor
+func (c *chunker) withComments(start, floor int) int {
+	for start > floor {
+		line := strings.TrimSpace(c.lines[start-1])
+		isComment := false
+		for _, prefix := range []string{"//", "#", "/*", "*", "--", "@"} {
+			if strings.HasPrefix(line, prefix) {
+				isComment = true
+				break
+			}
+		}
+		if !isComment {
+			break
+		}
+		start--
+	}
+	return start
+}
+
+// locate finds the line and column of a definition's name, which is on its
+// first line unless decorators or a long signature come first
+func (c *chunker) locate(d ricochetContext.Definition) Symbol {
+	s := Symbol{Name: d.Name, Kind: d.Type, Line: d.LineStart}
+	for l := d.LineStart - 1; l < min(d.LineEnd, d.LineStart+5, len(c.lines)); l++ {
+		if col := identifierIndex(c.lines[l], d.Name); col >= 0 {
+			s.Line = l + 1
+			s.Character = len(utf16.Encode([]rune(c.lines[l][:col])))
+			break
+		}
+	}
+	return s
+}
+
+// identifierIndex returns where name occurs in line as a whole identifier, or -1
+func identifierIndex(line, name string) int {
+	isIdent := func(r rune) bool { return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+	for from := 0; from < len(line); {
+		i := strings.Index(line[from:], name)
+		if i < 0 {
+			return -1
+		}
+		i += from
+		end := i + len(name)
+		before, _ := utf8.DecodeLastRuneInString(line[:i])
+		after, _ := utf8.DecodeRuneInString(line[end:])
+		if !isIdent(before) && !isIdent(after) {
+			return i
+		}
+		from = end
+	}
+	return -1
+}
+
+// span chunks lines[from:to]: each definition in nodes, and the code between
+func (c *chunker) span(from, to int, nodes []*defNode, parent *defNode) {
+	at := from
+	for _, n := range nodes {
+		c.between(at, n.start, parent)
+		c.definition(n, parent)
+		at = n.end
+	}
+	c.between(at, to, parent)
+}
+
+// definition chunks a definition whole, or by its members when it's too long
+// and has some, or else in parts
+func (c *chunker) definition(n, parent *defNode) {
+	if n.end-n.start > maxChunkLines && len(n.children) > 0 {
+		c.span(n.start, n.end, n.children, n)
+		return
+	}
+	var symbols []Symbol
+	var collect func(n *defNode)
+	collect = func(n *defNode) {
+		symbols = append(symbols, n.symbol)
+		for _, child := range n.children {
+			collect(child)
+		}
+	}
+	collect(n)
+
+	parts := (n.end - n.start + maxChunkLines - 1) / maxChunkLines
+	for part := 0; part < parts; part++ {
+		start := n.start + part*maxChunkLines
+		end := min(start+maxChunkLines, n.end)
+		label := fmt.Sprintf("// %s: %s", n.def.Type, n.def.Name)
+		if parts > 1 {
+			label += fmt.Sprintf(" (part %d of %d)", part+1, parts)
+		}
+		c.add(start, end, label, parent, n, inSpan(symbols, start, end))
+	}
+}
+
+// between chunks code outside definitions, skipping blank lines and lone
+// closing braces
+func (c *chunker) between(from, to int, parent *defNode) {
+	for start := from; start < to; start += windowLines {
+		end := min(start+windowLines, to)
+		if !strings.ContainsFunc(strings.Join(c.lines[start:end], ""), func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+			continue
+		}
+		label := fmt.Sprintf("// Lines %d-%d", start+1, end)
+		var symbols []Symbol
+		if parent != nil && parent.symbol.Line > start && parent.symbol.Line <= end {
+			symbols = []Symbol{parent.symbol} // The header of a split definition
+		}
+		c.add(start, end, label, parent, nil, symbols)
+	}
+}
+
+func (c *chunker) add(start, end int, label string, parent, def *defNode, symbols []Symbol) {
+	chunk := strings.Join(c.lines[start:end], "\n")
+	if len(chunk) > maxChunkChars {
+		chunk = chunk[:maxChunkChars]
+	}
+	header := fmt.Sprintf("// File: %s\n", c.relPath)
+	if parent != nil {
+		header += fmt.Sprintf("// In %s: %s\n", parent.def.Type, parent.def.Name)
+	}
+
+	doc := Document{
+		ID:        fmt.Sprintf("%s:%d-%d", c.relPath, start+1, end),
+		FilePath:  c.relPath,
+		Content:   header + label + "\n" + chunk,
+		LineStart: start + 1,
+		LineEnd:   end,
+		Metadata:  make(map[string]interface{}),
+	}
+	if c.imports != nil {
+		doc.Metadata["imports"] = c.imports
+	}
+	if def != nil {
+		doc.Metadata["type"] = def.def.Type
+		doc.Metadata["name"] = def.def.Name
+	}
+	if len(symbols) > 0 {
+		doc.Metadata["symbols"] = symbols
+	}
+	c.docs = append(c.docs, doc)
+}
+
+// inSpan keeps the symbols named within lines[start:end]
+func inSpan(symbols []Symbol, start, end int) []Symbol {
+	var kept []Symbol
+	for _, s := range symbols {
+		if s.Line > start && s.Line <= end {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
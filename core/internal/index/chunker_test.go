@@ -0,0 +1,113 @@
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func indexSource(t *testing.T, name, content string) []Document {
+	t.Helper()
+	idx, _, root := newWatchedIndexer(t)
+	writeSource(t, root, name, content)
+	docs, err := idx.indexFile(context.Background(), filepath.Join(root, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return docs
+}
+
+func TestChunkDefinitions_Go(t *testing.T) {
+	docs := indexSource(t, "server.go", `package server
+
+import "net/http"
+
+var defaultAddr = ":8080"
+
+// Server serves the API
+type Server struct {
+	addr string
+}
+
+// Start listens on the server's address
+func (s *Server) Start() error {
+	return http.ListenAndServe(s.addr, nil)
+}
+`)
+	if len(docs) != 3 {
+		t.Fatalf("got %d chunks, want the header, Server and Start: %+v", len(docs), docs)
+	}
+	if !strings.Contains(docs[0].Content, "defaultAddr") {
+		t.Errorf("package-level code not chunked: %q", docs[0].Content)
+	}
+	if !strings.Contains(docs[1].Content, "// Server serves the API") || docs[1].LineStart != 7 {
+		t.Errorf("doc comment not kept with its type: lines %d-%d %q", docs[1].LineStart, docs[1].LineEnd, docs[1].Content)
+	}
+
+	symbols := docs[2].Symbols()
+	want := Symbol{Name: "Start", Kind: "method", Line: 13, Character: 17}
+	if len(symbols) != 1 || symbols[0] != want {
+		t.Errorf("symbols = %+v, want %+v", symbols, want)
+	}
+}
+
+func TestChunkDefinitions_SplitsLongClasses(t *testing.T) {
+	var src strings.Builder
+	src.WriteString("class Big:\n    \"\"\"A class too long for one chunk\"\"\"\n\n")
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&src, "    def method_%d(self):\n        x = %d\n        return x\n\n", i, i)
+	}
+	docs := indexSource(t, "big.py", src.String())
+
+	if len(docs) != 41 {
+		t.Fatalf("got %d chunks, want the class header and 40 methods", len(docs))
+	}
+	if header := docs[0].Symbols(); len(header) != 1 || header[0].Name != "Big" {
+		t.Errorf("header symbols = %+v, want Big", header)
+	}
+	method := docs[5]
+	if !strings.Contains(method.Content, "// In class: Big") || method.Metadata["name"] != "method_4" {
+		t.Errorf("method chunk = %q, %v", method.Content, method.Metadata)
+	}
+}
+
+func TestChunkDefinitions_SplitsLongFunctions(t *testing.T) {
+	var src strings.Builder
+	src.WriteString("package main\n\nfunc long() {\n")
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&src, "\tprintln(%d)\n", i)
+	}
+	src.WriteString("}\n")
+	docs := indexSource(t, "long.go", src.String())
+
+	var parts []Document
+	for _, d := range docs {
+		if d.Metadata["name"] == "long" {
+			parts = append(parts, d)
+		}
+	}
+	if len(parts) != 2 || !strings.Contains(parts[0].Content, "(part 1 of 2)") {
+		t.Fatalf("got %d parts of long", len(parts))
+	}
+	if len(parts[0].Symbols()) != 1 || len(parts[1].Symbols()) != 0 {
+		t.Error("the symbol belongs to the part with its name")
+	}
+}
+
+func TestDocumentSymbols_FromJSON(t *testing.T) {
+	doc := Document{Metadata: map[string]interface{}{"symbols": []Symbol{{Name: "F", Kind: "function", Line: 3, Character: 5}}}}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Document
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.Symbols(); len(got) != 1 || got[0] != doc.Symbols()[0] {
+		t.Errorf("Symbols() after JSON = %+v", got)
+	}
+}
@@ -1,6 +1,7 @@
 package index
 
 import (
+	"path/filepath"
 	"strings"
 )
 
@@ -101,9 +102,7 @@ func (g *DependencyGraph) resolveImport(imp string) *GraphNode {
 
 		// Check if the import path is a suffix of the file path
 		// We strip extensions from file path for comparison
-		pathNoExt := strings.TrimSuffix(normPath, ".go")
-		pathNoExt = strings.TrimSuffix(pathNoExt, ".js")
-		pathNoExt = strings.TrimSuffix(pathNoExt, ".ts")
+		pathNoExt := strings.TrimSuffix(normPath, filepath.Ext(normPath))
 
 		// Heuristic: Import "github.com/org/repo/internal/agent" matches ".../internal/agent/..."
 		if strings.HasSuffix(pathNoExt, imp) {
@@ -0,0 +1,117 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const hashEmbeddingDims = 384
+
+// HashEmbedder is the built-in offline embedder. Words and identifier parts are
+// hashed into a fixed number of signed buckets (feature hashing), so search
+// keeps working on keywords when no embedding API is available.
+type HashEmbedder struct{}
+
+// NewHashEmbedder creates the built-in embedder
+func NewHashEmbedder() *HashEmbedder {
+	return &HashEmbedder{}
+}
+
+// EmbeddingModel identifies the hashing scheme for index compatibility checks
+func (h *HashEmbedder) EmbeddingModel() string {
+	return fmt.Sprintf("builtin/hash-%d", hashEmbeddingDims)
+}
+
+func (h *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		out[i] = hashVector(text)
+	}
+	return out, nil
+}
+
+func hashVector(text string) []float32 {
+	counts := make(map[string]int)
+	for _, tok := range hashTokens(text) {
+		counts[tok]++
+	}
+
+	vec := make([]float32, hashEmbeddingDims)
+	for tok, n := range counts {
+		hasher := fnv.New32a()
+		hasher.Write([]byte(tok))
+		sum := hasher.Sum32()
+		weight := float32(1 + math.Log(float64(n))) // Dampen repeated tokens
+		if sum&0x80000000 != 0 {
+			weight = -weight
+		}
+		vec[sum%hashEmbeddingDims] += weight
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v * v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vec {
+			vec[i] *= scale
+		}
+	}
+	return vec
+}
+
+// hashTokens lowercases words and also splits camelCase and snake_case
+// identifiers, so "parseHTTPHeader" matches a query for "http header"
+func hashTokens(text string) []string {
+	var tokens []string
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, word := range words {
+		if len(word) < 2 {
+			continue
+		}
+		parts := splitIdentifier(word)
+		if len(parts) > 1 {
+			tokens = append(tokens, strings.ToLower(word))
+		}
+		for _, p := range parts {
+			if len(p) > 1 {
+				tokens = append(tokens, strings.ToLower(p))
+			}
+		}
+	}
+	return tokens
+}
+
+func splitIdentifier(word string) []string {
+	var parts []string
+	runes := []rune(word)
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		boundary := i == len(runes) || runes[i] == '_'
+		if !boundary && unicode.IsUpper(runes[i]) {
+			// fooBar -> foo|Bar, HTTPServer -> HTTP|Server
+			boundary = unicode.IsLower(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1]))
+		}
+		if boundary {
+			if i > start {
+				parts = append(parts, string(runes[start:i]))
+			}
+			start = i
+			if i < len(runes) && runes[i] == '_' {
+				start = i + 1
+			}
+		}
+	}
+	return parts
+}
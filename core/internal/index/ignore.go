@@ -0,0 +1,167 @@
+package index
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RicochetIgnoreFile lists paths the indexer skips on top of .gitignore,
+// in the same syntax
+const RicochetIgnoreFile = ".ricochetignore"
+
+// secretFilePatterns are never indexed, whatever the ignore files say
+var secretFilePatterns = []string{
+	".env", ".env.*", "*.env",
+	"*.pem", "*.key", "*.p12", "*.pfx", "*.jks", "*.keystore", "*.kdbx",
+	"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519",
+	".netrc", ".npmrc", ".pypirc", ".pgpass", ".htpasswd",
+	"credentials", "credentials.json", "*.tfvars", "*.tfstate",
+}
+
+// isSecretFile reports whether a file name looks like it holds credentials
+func isSecretFile(name string) bool {
+	for _, pattern := range secretFilePatterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreRule is one line of a .gitignore or .ricochetignore file
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreRules are the rules of every ignore file seen, in order; the last
+// matching rule wins, as in git
+type ignoreRules []ignoreRule
+
+// readIgnoreFile parses an ignore file of the directory base (relative to
+// the workspace root, "" for the root). A missing file has no rules.
+func readIgnoreFile(file, base string) ignoreRules {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	return parseIgnore(string(data), base)
+}
+
+func parseIgnore(data, base string) ignoreRules {
+	var rules ignoreRules
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:] // Escaped "#" or "!"
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		// A slash other than a trailing one anchors the pattern to base
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+
+		expr := "^"
+		if base != "" {
+			expr += regexp.QuoteMeta(base) + "/"
+		}
+		if !anchored {
+			expr += "(?:.*/)?"
+		}
+		expr += globToRegexp(line) + "$"
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// globToRegexp translates gitignore wildcards: * and ? stay within a path
+// segment, ** crosses them
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			sb.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			if end := strings.IndexByte(glob[i:], ']'); end > 0 {
+				class := glob[i+1 : i+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				sb.WriteString("[" + class + "]")
+				i += end
+			} else {
+				sb.WriteString(`\[`)
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// match reports whether rel (slash-separated, relative to the workspace
+// root) is ignored by the rules themselves, not counting its directories
+func (r ignoreRules) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range r {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// ignored reports whether rel or any directory above it is ignored
+func (r ignoreRules) ignored(rel string) bool {
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if r.match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return r.match(rel, false)
+}
+
+// loadIgnoreRules reads the root .gitignore and .ricochetignore; nested
+// .gitignore files are added as the walk reaches their directories
+func loadIgnoreRules(root string) ignoreRules {
+	rules := readIgnoreFile(filepath.Join(root, ".gitignore"), "")
+	return append(rules, readIgnoreFile(filepath.Join(root, RicochetIgnoreFile), "")...)
+}
@@ -2,6 +2,7 @@ package index
 
 import (
 	"encoding/json"
+	"fmt"
 	"math"
 	"os"
 	"path/filepath"
@@ -23,22 +24,36 @@ type Document struct {
 type SearchResult struct {
 	Document *Document
 	Score    float64
+	Repo     string // Root the document belongs to, set by Roots.Search
 }
 
-// VectorStore interface for semantic search
+// VectorStore interface for semantic search. Backends: SQLiteStore (the
+// default), LocalStore (the legacy index.vdb file), QdrantStore and
+// PgvectorStore; see OpenStore.
 type VectorStore interface {
 	Add(docs []Document) error
 	Search(queryEmbedding []float32, limit int) ([]SearchResult, error)
 	Clear() error
+	RemoveFiles(paths []string) (int, error)
+	Count() int
 	Save() error
 	Load() error
 }
 
+// StoreInfo records which embedding model built an index
+type StoreInfo struct {
+	Model      string `json:"model,omitempty"` // e.g. "voyage/voyage-code-3"; empty if unknown
+	Dimensions int    `json:"dimensions"`
+}
+
 // LocalStore implements VectorStore using in-memory slice and local persistence
 type LocalStore struct {
 	mu   sync.RWMutex
 	path string
 	docs []Document
+	info StoreInfo // Persisted next to the index as <path>.meta
+
+	keywords *keywordIndex // Built on the first keyword search after a change
 }
 
 func NewLocalStore(path string) (*LocalStore, error) {
@@ -59,6 +74,7 @@ func (s *LocalStore) Add(docs []Document) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.docs = append(s.docs, docs...)
+	s.keywords = nil
 	return nil
 }
 
@@ -100,13 +116,74 @@ func (s *LocalStore) Search(query []float32, limit int) ([]SearchResult, error)
 	return results, nil
 }
 
+// KeywordSearch ranks the stored documents by the words of query with BM25
+func (s *LocalStore) KeywordSearch(query string, limit int) ([]SearchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keywords == nil {
+		s.keywords = buildKeywordIndex(s.docs)
+	}
+	docs, scores := s.keywords.search(query, limit)
+	results := make([]SearchResult, len(docs))
+	for i, doc := range docs {
+		results[i] = SearchResult{Document: &s.docs[doc], Score: scores[i]}
+	}
+	return results, nil
+}
+
+// Count returns the number of stored documents
+func (s *LocalStore) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.docs)
+}
+
+// RemoveFiles drops the documents of the given workspace-relative files and
+// returns how many were removed
+func (s *LocalStore) RemoveFiles(paths []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	drop := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		drop[p] = true
+	}
+	kept := s.docs[:0]
+	for _, d := range s.docs {
+		if !drop[d.FilePath] {
+			kept = append(kept, d)
+		}
+	}
+	removed := len(s.docs) - len(kept)
+	s.docs = kept
+	s.keywords = nil
+	return removed, nil
+}
+
 func (s *LocalStore) Clear() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.docs = make([]Document, 0)
+	s.info = StoreInfo{}
+	s.keywords = nil
 	return nil
 }
 
+// Info returns the embedding model and dimensions the stored vectors came from
+func (s *LocalStore) Info() StoreInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.info
+}
+
+// SetInfo records the embedding model for the next Save
+func (s *LocalStore) SetInfo(info StoreInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info = info
+}
+
 func (s *LocalStore) Save() error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -119,8 +196,15 @@ func (s *LocalStore) Save() error {
 	if err != nil {
 		return err
 	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return err
+	}
 
-	return os.WriteFile(s.path, data, 0644)
+	meta, err := json.Marshal(s.info)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path+".meta", meta, 0644)
 }
 
 func (s *LocalStore) Load() error {
@@ -131,8 +215,22 @@ func (s *LocalStore) Load() error {
 	if err != nil {
 		return err
 	}
+	if err := json.Unmarshal(data, &s.docs); err != nil {
+		return err
+	}
+	s.keywords = nil
 
-	return json.Unmarshal(data, &s.docs)
+	s.info = StoreInfo{}
+	if meta, err := os.ReadFile(s.path + ".meta"); err == nil {
+		if err := json.Unmarshal(meta, &s.info); err != nil {
+			return fmt.Errorf("failed to parse %s.meta: %w", s.path, err)
+		}
+	}
+	// Indexes written before the .meta file existed: take the size from the vectors
+	if s.info.Dimensions == 0 && len(s.docs) > 0 {
+		s.info.Dimensions = len(s.docs[0].Embedding)
+	}
+	return nil
 }
 
 func cosineSimilarity(a, b []float32) float64 {
@@ -0,0 +1,78 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+type fixedEmbedder struct {
+	model string
+	dims  int
+}
+
+func (f *fixedEmbedder) EmbeddingModel() string { return f.model }
+
+func (f *fixedEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i := range texts {
+		out[i] = make([]float32, f.dims)
+		out[i][0] = 1
+	}
+	return out, nil
+}
+
+func TestCheckCompatible(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.vdb")
+	store, err := NewLocalStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SetInfo(StoreInfo{Model: "openai/text-embedding-3-small", Dimensions: 1536})
+	store.Add([]Document{{ID: "a", Embedding: make([]float32, 1536)}})
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewLocalStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := reloaded.Info(); info.Model != "openai/text-embedding-3-small" || info.Dimensions != 1536 {
+		t.Fatalf("info not persisted: %+v", info)
+	}
+
+	same := NewIndexer(reloaded, &fixedEmbedder{"openai/text-embedding-3-small", 1536}, t.TempDir())
+	if err := same.CheckCompatible(0); err != nil {
+		t.Errorf("same model reported incompatible: %v", err)
+	}
+
+	other := NewIndexer(reloaded, &fixedEmbedder{"voyage/voyage-code-3", 1024}, t.TempDir())
+	if err := other.CheckCompatible(0); !errors.Is(err, ErrIndexIncompatible) {
+		t.Errorf("expected model mismatch, got %v", err)
+	}
+	if _, err := other.Search(context.Background(), "query", 5); !errors.Is(err, ErrIndexIncompatible) {
+		t.Errorf("expected search to refuse a %d-dim index, got %v", 1536, err)
+	}
+}
+
+func TestHashEmbedder(t *testing.T) {
+	e := NewHashEmbedder()
+	vecs, err := e.Embed(context.Background(), []string{
+		"func parseHTTPHeader(line string) (Header, error)",
+		"parse the http header",
+		"render the sidebar component",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vecs[0]) != hashEmbeddingDims {
+		t.Fatalf("dims = %d", len(vecs[0]))
+	}
+	related := cosineSimilarity(vecs[0], vecs[1])
+	unrelated := cosineSimilarity(vecs[0], vecs[2])
+	if related <= unrelated {
+		t.Errorf("expected identifier parts to match: related %.3f <= unrelated %.3f", related, unrelated)
+	}
+}
@@ -0,0 +1,118 @@
+package index
+
+import (
+	"math"
+	"sort"
+)
+
+// The SQLite store ranks searches with an inverted file (IVF) index: the
+// vectors are grouped around centroids found by k-means, and a search only
+// scores the groups whose centroids are closest to the query.
+const (
+	ivfMinVectors    = 2048 // Below this a search scores every vector
+	ivfMaxLists      = 1024
+	ivfSamplePerList = 40 // Training vectors per centroid
+	ivfIterations    = 10
+	ivfMinProbes     = 8
+)
+
+// ivfLists returns how many centroids to train for n vectors
+func ivfLists(n int) int {
+	return min(max(int(math.Sqrt(float64(n))), 1), ivfMaxLists)
+}
+
+// ivfProbes returns how many of k lists a search scores
+func ivfProbes(k int) int {
+	return min(max(k/10, ivfMinProbes), k)
+}
+
+// normalized returns v scaled to unit length, so dot products are cosines
+func normalized(v []float32) []float32 {
+	var norm float64
+	for _, f := range v {
+		norm += float64(f * f)
+	}
+	out := make([]float32, len(v))
+	if norm == 0 {
+		return out
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i, f := range v {
+		out[i] = f * scale
+	}
+	return out
+}
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// nearestCentroid returns the centroid closest to the unit vector v
+func nearestCentroid(centroids [][]float32, v []float32) int {
+	best, bestScore := 0, float32(math.Inf(-1))
+	for i, c := range centroids {
+		if s := dot(c, v); s > bestScore {
+			best, bestScore = i, s
+		}
+	}
+	return best
+}
+
+// nearestCentroids returns the n centroids closest to the unit vector v
+func nearestCentroids(centroids [][]float32, v []float32, n int) []int {
+	order := make([]int, len(centroids))
+	scores := make([]float32, len(centroids))
+	for i, c := range centroids {
+		order[i], scores[i] = i, dot(c, v)
+	}
+	sort.Slice(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+	return order[:min(n, len(order))]
+}
+
+// trainCentroids runs spherical k-means over unit vectors. The initial
+// centroids are spread evenly over the sample, so training is repeatable.
+func trainCentroids(sample [][]float32, k int) [][]float32 {
+	k = min(k, len(sample))
+	if k == 0 {
+		return nil
+	}
+	dims := len(sample[0])
+	centroids := make([][]float32, k)
+	for i := range centroids {
+		centroids[i] = append([]float32(nil), sample[i*len(sample)/k]...)
+	}
+
+	assign := make([]int, len(sample))
+	for iter := 0; iter < ivfIterations; iter++ {
+		changed := false
+		for i, v := range sample {
+			if c := nearestCentroid(centroids, v); c != assign[i] || iter == 0 {
+				assign[i], changed = c, true
+			}
+		}
+		if !changed {
+			break
+		}
+		sums := make([][]float32, k)
+		for i := range sums {
+			sums[i] = make([]float32, dims)
+		}
+		for i, v := range sample {
+			sum := sums[assign[i]]
+			for j, f := range v {
+				sum[j] += f
+			}
+		}
+		for i, sum := range sums {
+			// A centroid that lost all its vectors stays where it was
+			if n := normalized(sum); dot(n, n) > 0 {
+				centroids[i] = n
+			}
+		}
+	}
+	return centroids
+}
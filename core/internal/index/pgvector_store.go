@@ -0,0 +1,234 @@
+package index
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	_ "github.com/lib/pq" // postgres driver
+)
+
+// PgvectorStore keeps the index in a Postgres table with the pgvector
+// extension, ranked by cosine distance in the database. Keyword search uses
+// Postgres full-text search over the same table.
+type PgvectorStore struct {
+	mu    sync.Mutex
+	db    *sql.DB
+	table string
+	info  StoreInfo
+}
+
+func NewPgvectorStore(dsn, table string) (*PgvectorStore, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	schema := fmt.Sprintf(`
+CREATE EXTENSION IF NOT EXISTS vector;
+CREATE TABLE IF NOT EXISTS %[1]s (
+	id         TEXT PRIMARY KEY,
+	file_path  TEXT NOT NULL,
+	content    TEXT NOT NULL,
+	line_start INTEGER NOT NULL,
+	line_end   INTEGER NOT NULL,
+	metadata   JSONB,
+	embedding  vector
+);
+CREATE INDEX IF NOT EXISTS %[1]s_file_path ON %[1]s (file_path);
+CREATE TABLE IF NOT EXISTS %[1]s_info (key TEXT PRIMARY KEY, value TEXT NOT NULL);`, table)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create pgvector schema: %w", err)
+	}
+
+	s := &PgvectorStore{db: db, table: table}
+	if err := s.Load(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// vectorLiteral formats an embedding as pgvector input, e.g. [0.1,0.2]
+func vectorLiteral(v []float32) string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(f), 'g', -1, 32))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+func (s *PgvectorStore) Add(docs []Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(fmt.Sprintf(`INSERT INTO %s (id, file_path, content, line_start, line_end, metadata, embedding)
+VALUES ($1, $2, $3, $4, $5, $6, $7::vector)
+ON CONFLICT (id) DO UPDATE SET file_path = EXCLUDED.file_path, content = EXCLUDED.content, line_start = EXCLUDED.line_start,
+	line_end = EXCLUDED.line_end, metadata = EXCLUDED.metadata, embedding = EXCLUDED.embedding`, s.table))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, d := range docs {
+		meta, err := json.Marshal(d.Metadata)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.Exec(d.ID, d.FilePath, d.Content, d.LineStart, d.LineEnd, string(meta), vectorLiteral(d.Embedding)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *PgvectorStore) Search(query []float32, limit int) ([]SearchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.query(fmt.Sprintf(`SELECT id, file_path, content, line_start, line_end, metadata, 1 - (embedding <=> $1::vector)
+FROM %s ORDER BY embedding <=> $1::vector LIMIT $2`, s.table), vectorLiteral(query), limit)
+}
+
+// KeywordSearch ranks documents with Postgres full-text search, matching any
+// of the query's words
+func (s *PgvectorStore) KeywordSearch(query string, limit int) ([]SearchResult, error) {
+	terms := hashTokens(query) // Only letters, digits and underscores
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.query(fmt.Sprintf(`SELECT id, file_path, content, line_start, line_end, metadata,
+	ts_rank(to_tsvector('simple', content), to_tsquery('simple', $1)) AS score
+FROM %s WHERE to_tsvector('simple', content) @@ to_tsquery('simple', $1) ORDER BY score DESC LIMIT $2`, s.table),
+		strings.Join(terms, " | "), limit)
+}
+
+func (s *PgvectorStore) query(q string, args ...interface{}) ([]SearchResult, error) {
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		d := &Document{}
+		var meta sql.NullString
+		var score float64
+		if err := rows.Scan(&d.ID, &d.FilePath, &d.Content, &d.LineStart, &d.LineEnd, &meta, &score); err != nil {
+			return nil, err
+		}
+		if meta.Valid {
+			json.Unmarshal([]byte(meta.String), &d.Metadata)
+		}
+		results = append(results, SearchResult{Document: d, Score: score})
+	}
+	return results, rows.Err()
+}
+
+// Count returns the number of stored documents, 0 if Postgres is unreachable
+func (s *PgvectorStore) Count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int
+	s.db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s`, s.table)).Scan(&n)
+	return n
+}
+
+// RemoveFiles drops the documents of the given workspace-relative files and
+// returns how many were removed
+func (s *PgvectorStore) RemoveFiles(paths []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	removed := 0
+	for _, p := range paths {
+		res, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE file_path = $1`, s.table), p)
+		if err != nil {
+			return 0, err
+		}
+		n, _ := res.RowsAffected()
+		removed += int(n)
+	}
+	return removed, tx.Commit()
+}
+
+func (s *PgvectorStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.db.Exec(fmt.Sprintf(`TRUNCATE %[1]s; TRUNCATE %[1]s_info`, s.table)); err != nil {
+		return err
+	}
+	s.info = StoreInfo{}
+	return nil
+}
+
+// Info returns the embedding model and dimensions the stored vectors came from
+func (s *PgvectorStore) Info() StoreInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.info
+}
+
+// SetInfo records the embedding model for the next Save
+func (s *PgvectorStore) SetInfo(info StoreInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info = info
+}
+
+// Save persists the store info; documents are written as they are added
+func (s *PgvectorStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.Marshal(s.info)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(fmt.Sprintf(`INSERT INTO %s_info (key, value) VALUES ('store', $1)
+ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value`, s.table), string(data))
+	return err
+}
+
+func (s *PgvectorStore) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.info = StoreInfo{}
+	var data string
+	err := s.db.QueryRow(fmt.Sprintf(`SELECT value FROM %s_info WHERE key = 'store'`, s.table)).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(data), &s.info); err != nil {
+		return fmt.Errorf("failed to parse vector store info: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database connection
+func (s *PgvectorStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,256 @@
+package index
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
+)
+
+// qdrantBatch bounds the points sent in one upsert
+const qdrantBatch = 256
+
+// qdrantNamespace derives point UUIDs from document IDs; Qdrant only takes
+// integers and UUIDs as point IDs
+var qdrantNamespace = uuid.MustParse("6f1c9a52-3b8e-4d0a-9c55-2f8a1e7d4b10")
+
+// QdrantStore keeps the index in a Qdrant collection over its REST API. The
+// collection is created on the first Add, sized to the embeddings. Qdrant
+// records the vector size but not the model, so only sizes are checked.
+type QdrantStore struct {
+	mu         sync.Mutex
+	baseURL    string
+	collection string
+	apiKey     string
+	client     *http.Client
+	info       StoreInfo
+}
+
+func NewQdrantStore(baseURL, collection, apiKey string) *QdrantStore {
+	return &QdrantStore{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		collection: collection,
+		apiKey:     apiKey,
+		client:     httpclient.Client(30 * time.Second),
+	}
+}
+
+// qdrantPayload is the document stored with each point
+type qdrantPayload struct {
+	ID        string                 `json:"id"`
+	FilePath  string                 `json:"file_path"`
+	Content   string                 `json:"content"`
+	LineStart int                    `json:"line_start"`
+	LineEnd   int                    `json:"line_end"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+func (p qdrantPayload) document() *Document {
+	return &Document{ID: p.ID, FilePath: p.FilePath, Content: p.Content, LineStart: p.LineStart, LineEnd: p.LineEnd, Metadata: p.Metadata}
+}
+
+// call sends a request to the collection and decodes the "result" field
+func (s *QdrantStore) call(method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, s.baseURL+"/collections/"+s.collection+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("api-key", s.apiKey)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("qdrant request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return &qdrantError{status: resp.StatusCode, body: strings.TrimSpace(string(data))}
+	}
+	if result == nil {
+		return nil
+	}
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("invalid qdrant response: %w", err)
+	}
+	return json.Unmarshal(envelope.Result, result)
+}
+
+type qdrantError struct {
+	status int
+	body   string
+}
+
+func (e *qdrantError) Error() string {
+	return fmt.Sprintf("qdrant returned %d: %s", e.status, e.body)
+}
+
+func isNotFound(err error) bool {
+	qe, ok := err.(*qdrantError)
+	return ok && qe.status == http.StatusNotFound
+}
+
+// ensureCollection creates the collection for vectors of dims if missing
+func (s *QdrantStore) ensureCollection(dims int) error {
+	err := s.call(http.MethodGet, "", nil, &struct{}{})
+	if err == nil || !isNotFound(err) {
+		return err
+	}
+	return s.call(http.MethodPut, "", map[string]interface{}{
+		"vectors": map[string]interface{}{"size": dims, "distance": "Cosine"},
+	}, nil)
+}
+
+func (s *QdrantStore) Add(docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureCollection(len(docs[0].Embedding)); err != nil {
+		return err
+	}
+	for i := 0; i < len(docs); i += qdrantBatch {
+		end := min(i+qdrantBatch, len(docs))
+		points := make([]map[string]interface{}, 0, end-i)
+		for _, d := range docs[i:end] {
+			points = append(points, map[string]interface{}{
+				"id":      uuid.NewSHA1(qdrantNamespace, []byte(d.ID)).String(),
+				"vector":  d.Embedding,
+				"payload": qdrantPayload{d.ID, d.FilePath, d.Content, d.LineStart, d.LineEnd, d.Metadata},
+			})
+		}
+		if err := s.call(http.MethodPut, "/points?wait=true", map[string]interface{}{"points": points}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *QdrantStore) Search(query []float32, limit int) ([]SearchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var hits []struct {
+		Score   float64       `json:"score"`
+		Payload qdrantPayload `json:"payload"`
+	}
+	err := s.call(http.MethodPost, "/points/search", map[string]interface{}{
+		"vector": query, "limit": limit, "with_payload": true,
+	}, &hits)
+	if isNotFound(err) {
+		return nil, nil // Nothing indexed yet
+	}
+	if err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, len(hits))
+	for i, h := range hits {
+		results[i] = SearchResult{Document: h.Payload.document(), Score: h.Score}
+	}
+	return results, nil
+}
+
+// Count returns the number of stored documents, 0 if Qdrant is unreachable
+func (s *QdrantStore) Count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, _ := s.count()
+	return n
+}
+
+func (s *QdrantStore) count() (int, error) {
+	var res struct {
+		Count int `json:"count"`
+	}
+	err := s.call(http.MethodPost, "/points/count", map[string]interface{}{"exact": true}, &res)
+	if isNotFound(err) {
+		return 0, nil
+	}
+	return res.Count, err
+}
+
+// RemoveFiles drops the documents of the given workspace-relative files and
+// returns how many were removed
+func (s *QdrantStore) RemoveFiles(paths []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before, err := s.count()
+	if err != nil || before == 0 || len(paths) == 0 {
+		return 0, err
+	}
+	filter := map[string]interface{}{
+		"must": []interface{}{map[string]interface{}{"key": "file_path", "match": map[string]interface{}{"any": paths}}},
+	}
+	if err := s.call(http.MethodPost, "/points/delete?wait=true", map[string]interface{}{"filter": filter}, nil); err != nil {
+		return 0, err
+	}
+	after, err := s.count()
+	return before - after, err
+}
+
+// Clear drops the collection; the next Add recreates it
+func (s *QdrantStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info = StoreInfo{}
+	if err := s.call(http.MethodDelete, "", nil, nil); err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// Info returns the vector size of the collection, with the model recorded
+// by this process if any
+func (s *QdrantStore) Info() StoreInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var res struct {
+		Config struct {
+			Params struct {
+				Vectors struct {
+					Size int `json:"size"`
+				} `json:"vectors"`
+			} `json:"params"`
+		} `json:"config"`
+	}
+	if err := s.call(http.MethodGet, "", nil, &res); err != nil {
+		return s.info
+	}
+	info := s.info
+	info.Dimensions = res.Config.Params.Vectors.Size
+	return info
+}
+
+func (s *QdrantStore) SetInfo(info StoreInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info = info
+}
+
+// Save is a no-op: Qdrant persists points as they are upserted
+func (s *QdrantStore) Save() error { return nil }
+
+// Load is a no-op: the collection is read on every request
+func (s *QdrantStore) Load() error { return nil }
@@ -0,0 +1,218 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Root is one repository of a multi-root workspace
+type Root struct {
+	Name    string      `json:"name"`
+	Path    string      `json:"path"`
+	Primary bool        `json:"primary"` // The workspace the session runs in
+	Status  IndexStatus `json:"status"`
+}
+
+// StoreOpener opens the vector store of an extra root
+type StoreOpener func(name, path string) (VectorStore, error)
+
+// Roots indexes several repositories side by side: the primary workspace
+// plus roots registered during the session, such as the other folders of a
+// multi-root VS Code workspace or sibling service repos. Each root has its
+// own indexer and store; searches span them and label results by repo.
+type Roots struct {
+	mu       sync.RWMutex
+	primary  string
+	roots    map[string]*rootIndex
+	open     StoreOpener
+	embedder Embedder
+	ignore   []string
+}
+
+type rootIndex struct {
+	path    string
+	indexer *Indexer
+	stop    context.CancelFunc // Stops background indexing; nil for the primary
+}
+
+// NewRoots wraps the primary workspace's indexer. Extra roots share its
+// embedder and ignore patterns and get their stores from open.
+func NewRoots(primary *Indexer, embedder Embedder, ignore []string, open StoreOpener) *Roots {
+	name := filepath.Base(primary.workspaceRoot)
+	return &Roots{
+		primary:  name,
+		roots:    map[string]*rootIndex{name: {path: primary.workspaceRoot, indexer: primary}},
+		open:     open,
+		embedder: embedder,
+		ignore:   ignore,
+	}
+}
+
+// Add registers a repository and indexes it in the background, then keeps
+// it fresh as files change. name defaults to the directory name.
+func (r *Roots) Add(name, path string) (Root, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Root{}, err
+	}
+	if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+		return Root{}, fmt.Errorf("%s is not a directory", path)
+	}
+	if name == "" {
+		name = filepath.Base(abs)
+	}
+	if strings.ContainsAny(name, `/\,`) {
+		return Root{}, fmt.Errorf("invalid repo name %q", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for existing, root := range r.roots {
+		if root.path == abs {
+			return Root{}, fmt.Errorf("%s is already indexed as %q", path, existing)
+		}
+	}
+	if _, ok := r.roots[name]; ok {
+		return Root{}, fmt.Errorf("a repo named %q is already registered; pick another name", name)
+	}
+
+	store, err := r.open(name, abs)
+	if err != nil {
+		return Root{}, fmt.Errorf("failed to open the index of %s: %w", name, err)
+	}
+	indexer := NewIndexer(store, r.embedder, abs)
+	indexer.SetIgnorePatterns(r.ignore)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.roots[name] = &rootIndex{path: abs, indexer: indexer, stop: cancel}
+	go func() {
+		if err := indexer.IndexAll(ctx); err != nil {
+			log.Printf("Indexing repo %s failed: %v", name, err)
+		}
+		indexer.Watch(ctx)
+	}()
+	log.Printf("Indexing repo %s at %s", name, abs)
+	return Root{Name: name, Path: abs, Status: indexer.Status()}, nil
+}
+
+// Remove unregisters an extra root and stops its indexing. Its store is
+// kept, so registering it again starts from the previous index.
+func (r *Roots) Remove(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if name == r.primary {
+		return fmt.Errorf("%s is the primary workspace and can't be removed", name)
+	}
+	root, ok := r.roots[name]
+	if !ok {
+		return fmt.Errorf("no repo named %q", name)
+	}
+	root.stop()
+	delete(r.roots, name)
+	return nil
+}
+
+// List returns the roots, the primary first and the rest by name
+func (r *Roots) List() []Root {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]Root, 0, len(r.roots))
+	for name, root := range r.roots {
+		list = append(list, Root{Name: name, Path: root.path, Primary: name == r.primary, Status: root.indexer.Status()})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Primary != list[j].Primary {
+			return list[i].Primary
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Multi reports whether any root besides the primary is registered
+func (r *Roots) Multi() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.roots) > 1
+}
+
+// Resolve turns a path relative to a repo into one the agent's file tools
+// accept: unchanged for the primary workspace, absolute for the others
+func (r *Roots) Resolve(repo, rel string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	root, ok := r.roots[repo]
+	if !ok || repo == r.primary {
+		return rel
+	}
+	return filepath.Join(root.path, rel)
+}
+
+// Search runs a query against the given repos, or all of them when repos is
+// empty, and merges the results by score, each labeled with its repo
+func (r *Roots) Search(ctx context.Context, query string, limit int, repos []string) ([]SearchResult, error) {
+	r.mu.RLock()
+	selected := make(map[string]*Indexer)
+	for _, name := range repos {
+		root, ok := r.roots[name]
+		if !ok {
+			r.mu.RUnlock()
+			return nil, fmt.Errorf("no repo named %q; indexed repos: %s", name, strings.Join(r.namesLocked(), ", "))
+		}
+		selected[name] = root.indexer
+	}
+	if len(repos) == 0 {
+		for name, root := range r.roots {
+			selected[name] = root.indexer
+		}
+	}
+	r.mu.RUnlock()
+
+	var results []SearchResult
+	for name, indexer := range selected {
+		found, err := indexer.Search(ctx, query, limit)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for i := range found {
+			found[i].Repo = name
+		}
+		results = append(results, found...)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Repo < results[j].Repo
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// Close stops background indexing of the extra roots
+func (r *Roots) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, root := range r.roots {
+		if root.stop != nil {
+			root.stop()
+		}
+	}
+}
+
+func (r *Roots) namesLocked() []string {
+	names := make([]string, 0, len(r.roots))
+	for name := range r.roots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
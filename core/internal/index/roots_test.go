@@ -0,0 +1,114 @@
+package index
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestRoots(t *testing.T) (*Roots, string) {
+	t.Helper()
+	idx, _, root := newWatchedIndexer(t)
+	stores := t.TempDir()
+	roots := NewRoots(idx, NewHashEmbedder(), nil, func(name, path string) (VectorStore, error) {
+		return NewLocalStore(filepath.Join(stores, name+".vdb"))
+	})
+	t.Cleanup(roots.Close)
+	return roots, root
+}
+
+// waitIndexed waits for a root's first full index
+func waitIndexed(t *testing.T, roots *Roots, name string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, root := range roots.List() {
+			if root.Name == name && !root.Status.LastIndexed.IsZero() {
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("repo %s was not indexed in time", name)
+}
+
+func TestRoots_Search(t *testing.T) {
+	roots, primary := newTestRoots(t)
+	writeSource(t, primary, "web.py", "def render_invoice_page():\n    pass\n")
+	if err := roots.roots[roots.primary].indexer.IndexAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	api := t.TempDir()
+	writeSource(t, api, "billing.py", "def create_invoice():\n    pass\n")
+	root, err := roots.Add("api", api)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Name != "api" || !roots.Multi() {
+		t.Errorf("added root = %+v", root)
+	}
+	waitIndexed(t, roots, "api")
+
+	results, err := roots.Search(context.Background(), "invoice", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos := map[string]string{}
+	for _, res := range results {
+		repos[res.Repo] = res.Document.FilePath
+	}
+	if repos["api"] != "billing.py" || repos[roots.primary] != "web.py" {
+		t.Errorf("results by repo = %v, want both repos", repos)
+	}
+	if got := roots.Resolve("api", "billing.py"); got != filepath.Join(api, "billing.py") {
+		t.Errorf("Resolve = %q, want an absolute path", got)
+	}
+
+	// Repo-scoped search
+	results, err = roots.Search(context.Background(), "invoice", 10, []string{"api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range results {
+		if res.Repo != "api" {
+			t.Errorf("scoped search returned %s from %s", res.Document.FilePath, res.Repo)
+		}
+	}
+	if _, err := roots.Search(context.Background(), "invoice", 10, []string{"missing"}); err == nil {
+		t.Error("search of an unknown repo succeeded")
+	}
+
+	if err := roots.Remove("api"); err != nil {
+		t.Fatal(err)
+	}
+	if roots.Multi() {
+		t.Error("repo still registered after Remove")
+	}
+}
+
+func TestRoots_AddInvalid(t *testing.T) {
+	roots, primary := newTestRoots(t)
+	if _, err := roots.Add("", primary); err == nil {
+		t.Error("the primary workspace was added twice")
+	}
+	if _, err := roots.Add("x", filepath.Join(primary, "missing")); err == nil {
+		t.Error("a missing directory was added")
+	}
+	if err := roots.Remove(roots.primary); err == nil {
+		t.Error("the primary workspace was removed")
+	}
+
+	other := t.TempDir()
+	if err := os.WriteFile(filepath.Join(other, "a.py"), []byte("x = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := roots.Add("lib", other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := roots.Add("lib", t.TempDir()); err == nil {
+		t.Error("a duplicate repo name was accepted")
+	}
+}
@@ -0,0 +1,9 @@
+package index
+
+import "github.com/igoryan-dao/ricochet/internal/safeguard"
+
+// redactSecrets replaces the secrets in text before it is embedded and
+// reports how many it found
+func redactSecrets(text string) (string, int) {
+	return safeguard.RedactSecrets(text)
+}
@@ -2,11 +2,14 @@ package index
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	ricochetContext "github.com/igoryan-dao/ricochet/internal/context"
 )
@@ -16,6 +19,29 @@ type Embedder interface {
 	Embed(ctx context.Context, texts []string) ([][]float32, error)
 }
 
+// ModelIdentifier is implemented by embedders that can name their model, so an
+// index built by a different model is detected even when the sizes match
+type ModelIdentifier interface {
+	EmbeddingModel() string
+}
+
+// describedStore is implemented by stores that record the model that built them
+type describedStore interface {
+	Info() StoreInfo
+	SetInfo(StoreInfo)
+}
+
+// ErrIndexIncompatible means the index was built by another embedding model and must be rebuilt
+var ErrIndexIncompatible = errors.New("codebase index was built with a different embedding model")
+
+// EmbeddingModelOf returns the embedder's model name, or "" if it doesn't say
+func EmbeddingModelOf(e Embedder) string {
+	if m, ok := e.(ModelIdentifier); ok {
+		return m.EmbeddingModel()
+	}
+	return ""
+}
+
 // Indexer handles the codebase indexing process
 type Indexer struct {
 	mu            sync.RWMutex
@@ -24,6 +50,29 @@ type Indexer struct {
 	parser        *ricochetContext.LanguageParser
 	workspaceRoot string
 	isIndexing    bool
+	lastIndexed   time.Time
+	lastDocCount  int
+	lastError     string
+	ignore        []string    // Extra glob patterns from settings (index.ignore)
+	rules         ignoreRules // .gitignore and .ricochetignore rules, as of the last walk
+	watching      bool
+	pending       map[string]time.Time // Changed files (relative) waiting to be re-indexed, by last change
+	progress      *Progress            // Of the running IndexAll, nil when idle
+	onProgress    func(Progress)
+	resumePath    string // Embeddings of an unfinished IndexAll, to resume from
+}
+
+// IndexStatus is a snapshot of indexer health for status/health reporting
+type IndexStatus struct {
+	Indexing    bool      `json:"indexing"`
+	LastIndexed time.Time `json:"last_indexed,omitempty"`
+	Documents   int       `json:"documents"`
+	LastError   string    `json:"last_error,omitempty"`
+	Watching    bool      `json:"watching"`              // Files are re-indexed as they change
+	Pending     int       `json:"pending"`               // Changed files waiting to be re-indexed
+	StaleSince  time.Time `json:"stale_since,omitempty"` // Oldest change not yet in the index
+	Fresh       bool      `json:"fresh"`                 // Indexed, with no pending changes
+	Progress    *Progress `json:"progress,omitempty"`    // Of a full index in progress
 }
 
 func NewIndexer(store VectorStore, provider Embedder, workspaceRoot string) *Indexer {
@@ -35,56 +84,110 @@ func NewIndexer(store VectorStore, provider Embedder, workspaceRoot string) *Ind
 	}
 }
 
+// SetIgnorePatterns sets extra glob patterns to skip, matched against the
+// workspace-relative path and the base name. A trailing "/" matches directories only.
+func (idx *Indexer) SetIgnorePatterns(patterns []string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.ignore = patterns
+}
+
+// isIgnored reports whether path is a secret file or is excluded by a
+// configured pattern or the ignore files
+func (idx *Indexer) isIgnored(path string, isDir bool) bool {
+	if idx.matchesPatterns(path, isDir) {
+		return true
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	rel, err := filepath.Rel(idx.workspaceRoot, path)
+	return err == nil && idx.rules.ignored(filepath.ToSlash(rel))
+}
+
+// matchesPatterns reports whether path is a secret file or matches a
+// configured ignore pattern
+func (idx *Indexer) matchesPatterns(path string, isDir bool) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	rel, err := filepath.Rel(idx.workspaceRoot, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	base := filepath.Base(path)
+	if !isDir && isSecretFile(base) {
+		return true
+	}
+
+	for _, pattern := range idx.ignore {
+		if strings.HasSuffix(pattern, "/") {
+			if !isDir {
+				continue
+			}
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // IndexAll performs a full scan of the workspace
 func (idx *Indexer) IndexAll(ctx context.Context) error {
 	idx.mu.Lock()
 	if idx.isIndexing {
 		idx.mu.Unlock()
-		return fmt.Errorf("indexing already in progress")
+		return errIndexBusy
 	}
 	idx.isIndexing = true
 	idx.mu.Unlock()
 
+	var allDocs []Document
+	var indexErr error
+	progress := idx.startProgress()
+
 	defer func() {
 		idx.mu.Lock()
 		idx.isIndexing = false
+		if indexErr != nil {
+			idx.lastError = indexErr.Error()
+		} else {
+			idx.lastError = ""
+			idx.lastIndexed = time.Now()
+			idx.lastDocCount = len(allDocs)
+		}
 		idx.mu.Unlock()
+		progress.finish(indexErr)
 	}()
 
-	var allDocs []Document
-
-	err := filepath.Walk(idx.workspaceRoot, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			name := info.Name()
-			if strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" || name == "dist" || name == "out" {
-				return filepath.SkipDir
-			}
-			return nil
-		}
+	var files []string
+	err := idx.walk(func(path string, info os.FileInfo) {
+		files = append(files, path)
+	})
+	if err != nil {
+		indexErr = err
+		return err
+	}
 
-		ext := strings.ToLower(filepath.Ext(path))
-		switch ext {
-		case ".js", ".jsx", ".ts", ".tsx", ".py", ".rs", ".go":
-			// Go ahead
-		default:
-			return nil
+	// Chunk every file first, so embedding progress has a known total
+	progress.update(func(p *Progress) { p.Phase, p.FilesTotal = PhaseChunking, len(files) })
+	fileEnds := make([]int, len(files)) // Chunks up to and including each file
+	for i, path := range files {
+		if indexErr = ctx.Err(); indexErr != nil {
+			return indexErr
 		}
-
 		docs, err := idx.indexFile(ctx, path)
 		if err != nil {
 			fmt.Printf("Warning: failed to index file %s: %v\n", path, err)
-			return nil
 		}
-
 		allDocs = append(allDocs, docs...)
-		return nil
-	})
-
-	if err != nil {
-		return err
+		fileEnds[i] = len(allDocs)
+		progress.update(func(p *Progress) { p.FilesDone = i + 1 })
 	}
 
 	if len(allDocs) > 0 {
@@ -103,39 +206,107 @@ func (idx *Indexer) IndexAll(ctx context.Context) error {
 			}
 		}
 
-		// 3. Generate embeddings
-		batchSize := 20
-		for i := 0; i < len(allDocs); i += batchSize {
-			end := i + batchSize
-			if end > len(allDocs) {
-				end = len(allDocs)
-			}
+		// 3. Generate embeddings, resuming an interrupted run
+		checkpoint := idx.openCheckpoint()
+		progress.update(func(p *Progress) { p.Phase, p.FilesDone, p.ChunksTotal = PhaseEmbedding, 0, len(allDocs) })
+		indexErr = idx.embed(ctx, allDocs, checkpoint, func(done, resumed, prefix int) {
+			progress.update(func(p *Progress) {
+				p.ChunksDone, p.Resumed = done, resumed
+				p.FilesDone = sort.Search(len(fileEnds), func(i int) bool { return fileEnds[i] > prefix })
+			})
+		})
+		checkpoint.Close()
+		if indexErr != nil {
+			return indexErr
+		}
+		progress.update(func(p *Progress) { p.Phase = PhaseSaving })
 
-			var batchTexts []string
-			for _, d := range allDocs[i:end] {
-				batchTexts = append(batchTexts, d.Content)
-			}
+		if indexErr = idx.store.Clear(); indexErr != nil {
+			return indexErr
+		}
+		if ds, ok := idx.store.(describedStore); ok {
+			ds.SetInfo(StoreInfo{Model: EmbeddingModelOf(idx.provider), Dimensions: len(allDocs[0].Embedding)})
+		}
+		if indexErr = idx.store.Add(allDocs); indexErr != nil {
+			return indexErr
+		}
+		if indexErr = idx.store.Save(); indexErr != nil {
+			return indexErr
+		}
+		checkpoint.Remove()
+	}
 
-			embeddings, err := idx.provider.Embed(ctx, batchTexts)
-			if err != nil {
-				return fmt.Errorf("failed to generate embeddings: %w", err)
-			}
+	return nil
+}
+
+// walk calls fn for every indexable file of the workspace, skipping hidden
+// and dependency directories, secret files, the configured ignore patterns
+// and whatever .gitignore and .ricochetignore exclude. Ignore files are
+// re-read on every walk.
+func (idx *Indexer) walk(fn func(path string, info os.FileInfo)) error {
+	rules := loadIgnoreRules(idx.workspaceRoot)
+	defer func() {
+		idx.mu.Lock()
+		idx.rules = rules
+		idx.mu.Unlock()
+	}()
 
-			for j, emb := range embeddings {
-				allDocs[i+j].Embedding = emb
+	return filepath.Walk(idx.workspaceRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(idx.workspaceRoot, path)
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() {
+			if path == idx.workspaceRoot {
+				return nil
+			}
+			name := info.Name()
+			if strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" || name == "dist" || name == "out" {
+				return filepath.SkipDir
+			}
+			if idx.matchesPatterns(path, true) || rules.match(rel, true) {
+				return filepath.SkipDir
 			}
+			rules = append(rules, readIgnoreFile(filepath.Join(path, ".gitignore"), rel)...)
+			return nil
+		}
+		if idx.matchesPatterns(path, false) || rules.match(rel, false) {
+			return nil
 		}
 
-		if err := idx.store.Clear(); err != nil {
-			return err
+		if !ricochetContext.IsSupported(path) {
+			return nil
 		}
-		if err := idx.store.Add(allDocs); err != nil {
-			return err
+
+		fn(path, info)
+		return nil
+	})
+}
+
+// Status returns the current indexing state
+func (idx *Indexer) Status() IndexStatus {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	status := IndexStatus{
+		Indexing:    idx.isIndexing,
+		LastIndexed: idx.lastIndexed,
+		Documents:   idx.lastDocCount,
+		LastError:   idx.lastError,
+		Watching:    idx.watching,
+		Pending:     len(idx.pending),
+	}
+	if idx.progress != nil {
+		p := *idx.progress
+		status.Progress = &p
+	}
+	for _, changed := range idx.pending {
+		if status.StaleSince.IsZero() || changed.Before(status.StaleSince) {
+			status.StaleSince = changed
 		}
-		return idx.store.Save()
 	}
-
-	return nil
+	status.Fresh = !status.Indexing && status.Pending == 0 && !status.LastIndexed.IsZero()
+	return status
 }
 
 func (idx *Indexer) indexFile(ctx context.Context, path string) ([]Document, error) {
@@ -152,49 +323,13 @@ func (idx *Indexer) indexFile(ctx context.Context, path string) ([]Document, err
 		return idx.chunkSimple(relPath, string(content)), nil
 	}
 
+	// Chunk along definitions, so each embedding is a whole function or type
 	var docs []Document
-	lines := strings.Split(string(content), "\n")
-
-	// Store file-level metadata (imports) in every chunk derived from this file
-	// or create a dedicated "File" chunk?
-	// For now, we propagate imports to all chunks so the graph builder can pick any.
-
-	// If definitions exist, chunk by definitions
 	if len(analysis.Definitions) > 0 {
-		for _, def := range analysis.Definitions {
-			start := def.LineStart - 1
-			end := def.LineEnd
-			if start < 0 {
-				start = 0
-			}
-			if end > len(lines) {
-				end = len(lines)
-			}
-			if start >= end {
-				continue
-			}
-
-			chunk := strings.Join(lines[start:end], "\n")
-			if len(chunk) > 8000 {
-				chunk = chunk[:8000]
-			}
-
-			docs = append(docs, Document{
-				ID:        fmt.Sprintf("%s:%d-%d", relPath, def.LineStart, def.LineEnd),
-				FilePath:  relPath,
-				Content:   fmt.Sprintf("// File: %s\n// %s: %s\n%s", relPath, def.Type, def.Name, chunk),
-				LineStart: def.LineStart,
-				LineEnd:   def.LineEnd,
-				Metadata: map[string]interface{}{
-					"type":    def.Type,
-					"name":    def.Name,
-					"imports": analysis.Imports, // Add imports to metadata
-				},
-			})
-		}
+		docs = chunkDefinitions(relPath, strings.Split(string(content), "\n"), analysis.Definitions, analysis.Imports)
 	}
 
-	// Always add a "whole file" summary or if chunks were empty
+	// Files without definitions, like scripts, are chunked in windows
 	if len(docs) == 0 && len(content) > 0 {
 		return idx.chunkSimpleWithImports(relPath, string(content), analysis.Imports), nil
 	}
@@ -208,7 +343,7 @@ func (idx *Indexer) chunkSimple(relPath, content string) []Document {
 
 func (idx *Indexer) chunkSimpleWithImports(relPath, content string, imports []string) []Document {
 	lines := strings.Split(content, "\n")
-	chunkSize := 50
+	chunkSize := windowLines
 	var docs []Document
 
 	for i := 0; i < len(lines); i += chunkSize {
@@ -236,6 +371,38 @@ func (idx *Indexer) chunkSimpleWithImports(relPath, content string, imports []st
 	return docs
 }
 
+// CheckCompatible reports ErrIndexIncompatible when the stored index was built by
+// a different model than the current embedder. dims is the query vector size, or
+// 0 to compare model names only.
+func (idx *Indexer) CheckCompatible(dims int) error {
+	ds, ok := idx.store.(describedStore)
+	if !ok {
+		return nil
+	}
+	info := ds.Info()
+	if info.Dimensions == 0 {
+		return nil // Empty index
+	}
+
+	model := EmbeddingModelOf(idx.provider)
+	if dims > 0 && dims != info.Dimensions {
+		return fmt.Errorf("%w: index has %d-dimensional vectors from %s, but %s produces %d; reindex the codebase",
+			ErrIndexIncompatible, info.Dimensions, orUnknown(info.Model), orUnknown(model), dims)
+	}
+	if info.Model != "" && model != "" && info.Model != model {
+		return fmt.Errorf("%w: index was built with %s, but the current embedder is %s; reindex the codebase",
+			ErrIndexIncompatible, info.Model, model)
+	}
+	return nil
+}
+
+func orUnknown(model string) string {
+	if model == "" {
+		return "an unknown model"
+	}
+	return model
+}
+
 func (idx *Indexer) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
 	emb, err := idx.provider.Embed(ctx, []string{query})
 	if err != nil {
@@ -244,12 +411,12 @@ func (idx *Indexer) Search(ctx context.Context, query string, limit int) ([]Sear
 	if len(emb) == 0 {
 		return nil, nil
 	}
+	if err := idx.CheckCompatible(len(emb[0])); err != nil {
+		return nil, err
+	}
 
-	// This is where we would ideally combine vector score with PageRank
-	// But LocalStore.Search is naive.
-	// We should probably modify LocalStore.Search or re-rank here.
-
-	results, err := idx.store.Search(emb[0], limit*2) // Get more results to re-rank
+	candidates := limit * 4 // Get more results to re-rank and fuse
+	results, err := idx.store.Search(emb[0], candidates)
 	if err != nil {
 		return nil, err
 	}
@@ -276,6 +443,16 @@ func (idx *Indexer) Search(ctx context.Context, query string, limit int) ([]Sear
 		}
 	}
 
+	// Embeddings find "where is rate limiting", keywords find "RateLimiter
+	// struct"; fusing the two rankings finds both
+	if ks, ok := idx.store.(keywordSearcher); ok {
+		keyword, err := ks.KeywordSearch(query, candidates)
+		if err != nil {
+			return nil, err
+		}
+		results = fuseRanks(results, keyword)
+	}
+
 	if len(results) > limit {
 		results = results[:limit]
 	}
@@ -0,0 +1,449 @@
+package index
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	_ "github.com/mattn/go-sqlite3" // sqlite3 driver
+)
+
+// SQLiteStore keeps the index in a SQLite database. Writes go to disk as
+// they happen and only vectors are read to rank a search, so it scales well
+// past what LocalStore holds in memory. Once it holds ivfMinVectors
+// documents, each is filed under the nearest of a set of centroids and a
+// search only reads the vectors filed under those closest to the query.
+type SQLiteStore struct {
+	mu   sync.Mutex
+	db   *sql.DB
+	info StoreInfo
+
+	centroids [][]float32 // IVF centroids, unit length; nil until trained
+	trainedAt int         // Document count the centroids were trained at
+
+	keywords *keywordIndex // Built on the first keyword search after a change
+	kwDocs   []Document    // Documents of keywords, without embeddings
+}
+
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	schema := `
+CREATE TABLE IF NOT EXISTS documents (
+	id         TEXT PRIMARY KEY,
+	file_path  TEXT NOT NULL,
+	content    TEXT NOT NULL,
+	line_start INTEGER NOT NULL,
+	line_end   INTEGER NOT NULL,
+	metadata   TEXT,
+	embedding  BLOB,
+	cluster    INTEGER
+);
+CREATE INDEX IF NOT EXISTS documents_file_path ON documents(file_path);
+CREATE TABLE IF NOT EXISTS info (key TEXT PRIMARY KEY, value TEXT NOT NULL);
+CREATE TABLE IF NOT EXISTS centroids (id INTEGER PRIMARY KEY, vector BLOB NOT NULL);`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create index schema in %s: %w", path, err)
+	}
+	// Indexes made before the IVF index lack the cluster column
+	if _, err := db.Exec(`ALTER TABLE documents ADD COLUMN cluster INTEGER`); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+		db.Close()
+		return nil, fmt.Errorf("failed to upgrade index schema in %s: %w", path, err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS documents_cluster ON documents(cluster)`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create index schema in %s: %w", path, err)
+	}
+
+	s := &SQLiteStore{db: db}
+	if err := s.Load(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SQLiteStore) Add(docs []Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO documents (id, file_path, content, line_start, line_end, metadata, embedding, cluster) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, d := range docs {
+		meta, err := json.Marshal(d.Metadata)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.Exec(d.ID, d.FilePath, d.Content, d.LineStart, d.LineEnd, string(meta), encodeVector(d.Embedding), s.cluster(d.Embedding)); err != nil {
+			return err
+		}
+	}
+	s.keywords = nil
+	return tx.Commit()
+}
+
+// cluster returns the centroid to file a vector under, nil before the
+// centroids are trained. Caller must hold the lock.
+func (s *SQLiteStore) cluster(v []float32) interface{} {
+	if len(s.centroids) == 0 || len(v) != len(s.centroids[0]) {
+		return nil
+	}
+	return nearestCentroid(s.centroids, normalized(v))
+}
+
+func (s *SQLiteStore) Search(query []float32, limit int) ([]SearchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var hits []scoredID
+	var err error
+	if k := len(s.centroids); k > 0 && len(query) == len(s.centroids[0]) {
+		probes := nearestCentroids(s.centroids, normalized(query), ivfProbes(k))
+		args := make([]interface{}, len(probes))
+		for i, c := range probes {
+			args[i] = c
+		}
+		// Documents added with another model have no cluster and are always scored
+		hits, err = s.rank(query, `SELECT id, embedding FROM documents WHERE cluster IS NULL OR cluster IN (?`+strings.Repeat(", ?", len(probes)-1)+`)`, args...)
+		if err == nil && len(hits) < limit && len(probes) < k {
+			hits = nil // Too few near the query: fall back to scoring everything
+		}
+	}
+	if hits == nil && err == nil {
+		hits, err = s.rank(query, `SELECT id, embedding FROM documents`)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	results := make([]SearchResult, 0, len(hits))
+	for _, h := range hits {
+		doc, err := s.document(h.id)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, SearchResult{Document: doc, Score: h.score})
+	}
+	return results, nil
+}
+
+type scoredID struct {
+	id    string
+	score float64
+}
+
+// rank scores the vectors selected by q, which yields id and embedding
+// rows, against query. Caller must hold the lock.
+func (s *SQLiteStore) rank(query []float32, q string, args ...interface{}) ([]scoredID, error) {
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	hits := []scoredID{}
+	for rows.Next() {
+		var id string
+		var emb []byte
+		if err := rows.Scan(&id, &emb); err != nil {
+			return nil, err
+		}
+		hits = append(hits, scoredID{id, cosineSimilarity(query, decodeVector(emb))})
+	}
+	return hits, rows.Err()
+}
+
+// train builds the IVF index once the store holds ivfMinVectors documents,
+// and rebuilds it whenever the store has doubled since. Caller must hold
+// the lock.
+func (s *SQLiteStore) train() error {
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM documents`).Scan(&n); err != nil {
+		return err
+	}
+	if n < ivfMinVectors || (len(s.centroids) > 0 && n <= 2*s.trainedAt) {
+		return nil
+	}
+
+	k := ivfLists(n)
+	rows, err := s.db.Query(`SELECT embedding FROM documents ORDER BY random() LIMIT ?`, k*ivfSamplePerList)
+	if err != nil {
+		return err
+	}
+	var sample [][]float32
+	for rows.Next() {
+		var emb []byte
+		if err := rows.Scan(&emb); err != nil {
+			rows.Close()
+			return err
+		}
+		if v := decodeVector(emb); len(sample) == 0 || len(v) == len(sample[0]) {
+			sample = append(sample, normalized(v))
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	centroids := trainCentroids(sample, k)
+
+	// File every document under its centroid
+	type assignment struct {
+		id      string
+		cluster interface{}
+	}
+	var assigned []assignment
+	rows, err = s.db.Query(`SELECT id, embedding FROM documents`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id string
+		var emb []byte
+		if err := rows.Scan(&id, &emb); err != nil {
+			rows.Close()
+			return err
+		}
+		var cluster interface{}
+		if v := decodeVector(emb); len(v) == len(centroids[0]) {
+			cluster = nearestCentroid(centroids, normalized(v))
+		}
+		assigned = append(assigned, assignment{id, cluster})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM centroids`); err != nil {
+		return err
+	}
+	for i, c := range centroids {
+		if _, err := tx.Exec(`INSERT INTO centroids (id, vector) VALUES (?, ?)`, i, encodeVector(c)); err != nil {
+			return err
+		}
+	}
+	stmt, err := tx.Prepare(`UPDATE documents SET cluster = ? WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, a := range assigned {
+		if _, err := stmt.Exec(a.cluster, a.id); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO info (key, value) VALUES ('ivf', ?)`, strconv.Itoa(n)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.centroids, s.trainedAt = centroids, n
+	return nil
+}
+
+// document reads a stored document, without its embedding
+func (s *SQLiteStore) document(id string) (*Document, error) {
+	d := &Document{ID: id}
+	var meta sql.NullString
+	err := s.db.QueryRow(`SELECT file_path, content, line_start, line_end, metadata FROM documents WHERE id = ?`, id).
+		Scan(&d.FilePath, &d.Content, &d.LineStart, &d.LineEnd, &meta)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Valid {
+		json.Unmarshal([]byte(meta.String), &d.Metadata)
+	}
+	return d, nil
+}
+
+// KeywordSearch ranks the stored documents by the words of query with BM25
+func (s *SQLiteStore) KeywordSearch(query string, limit int) ([]SearchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keywords == nil {
+		rows, err := s.db.Query(`SELECT id, file_path, content, line_start, line_end FROM documents`)
+		if err != nil {
+			return nil, err
+		}
+		var docs []Document
+		for rows.Next() {
+			var d Document
+			if err := rows.Scan(&d.ID, &d.FilePath, &d.Content, &d.LineStart, &d.LineEnd); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			docs = append(docs, d)
+		}
+		rows.Close()
+		s.kwDocs = docs
+		s.keywords = buildKeywordIndex(docs)
+	}
+
+	docs, scores := s.keywords.search(query, limit)
+	results := make([]SearchResult, len(docs))
+	for i, doc := range docs {
+		d := s.kwDocs[doc]
+		results[i] = SearchResult{Document: &d, Score: scores[i]}
+	}
+	return results, nil
+}
+
+// Count returns the number of stored documents
+func (s *SQLiteStore) Count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int
+	s.db.QueryRow(`SELECT COUNT(*) FROM documents`).Scan(&n)
+	return n
+}
+
+// RemoveFiles drops the documents of the given workspace-relative files and
+// returns how many were removed
+func (s *SQLiteStore) RemoveFiles(paths []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	removed := 0
+	for _, p := range paths {
+		res, err := tx.Exec(`DELETE FROM documents WHERE file_path = ?`, p)
+		if err != nil {
+			return 0, err
+		}
+		n, _ := res.RowsAffected()
+		removed += int(n)
+	}
+	s.keywords = nil
+	return removed, tx.Commit()
+}
+
+func (s *SQLiteStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.db.Exec(`DELETE FROM documents; DELETE FROM info; DELETE FROM centroids`); err != nil {
+		return err
+	}
+	s.info = StoreInfo{}
+	s.centroids, s.trainedAt = nil, 0
+	s.keywords = nil
+	return nil
+}
+
+// Info returns the embedding model and dimensions the stored vectors came from
+func (s *SQLiteStore) Info() StoreInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.info
+}
+
+// SetInfo records the embedding model for the next Save
+func (s *SQLiteStore) SetInfo(info StoreInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info = info
+}
+
+// Save persists the store info and trains the IVF index when due;
+// documents are written as they are added
+func (s *SQLiteStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.Marshal(s.info)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`INSERT OR REPLACE INTO info (key, value) VALUES ('store', ?)`, string(data)); err != nil {
+		return err
+	}
+	return s.train()
+}
+
+func (s *SQLiteStore) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.info = StoreInfo{}
+	s.keywords = nil
+	if err := s.loadCentroids(); err != nil {
+		return err
+	}
+	var data string
+	err := s.db.QueryRow(`SELECT value FROM info WHERE key = 'store'`).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(data), &s.info); err != nil {
+		return fmt.Errorf("failed to parse vector store info: %w", err)
+	}
+	return nil
+}
+
+// loadCentroids reads the IVF index. Caller must hold the lock.
+func (s *SQLiteStore) loadCentroids() error {
+	s.centroids, s.trainedAt = nil, 0
+	rows, err := s.db.Query(`SELECT vector FROM centroids ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v []byte
+		if err := rows.Scan(&v); err != nil {
+			return err
+		}
+		s.centroids = append(s.centroids, decodeVector(v))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	var trained string
+	if err := s.db.QueryRow(`SELECT value FROM info WHERE key = 'ivf'`).Scan(&trained); err == nil {
+		s.trainedAt, _ = strconv.Atoi(trained)
+	}
+	return nil
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,141 @@
+package index
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+)
+
+// defaultCollection names the Qdrant collection or Postgres table of the index
+const defaultCollection = "ricochet_index"
+
+// collectionName is what Qdrant and Postgres both accept without quoting
+var collectionName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// OpenStore opens the vector store selected in settings. dir holds the
+// local backends: sqlite keeps index.db there, local the legacy index.vdb.
+// A legacy index.vdb is migrated into any other backend while it's empty.
+func OpenStore(s config.VectorStoreSettings, dir string) (VectorStore, error) {
+	collection := s.Collection
+	if collection == "" {
+		collection = defaultCollection
+	}
+	if !collectionName.MatchString(collection) {
+		return nil, fmt.Errorf("invalid vector store collection %q: use letters, digits and underscores", collection)
+	}
+	url := os.ExpandEnv(strings.TrimSpace(s.URL))
+
+	backend := strings.ToLower(s.Backend)
+	if backend == "" {
+		backend = "sqlite"
+	}
+
+	var store VectorStore
+	var err error
+	switch backend {
+	case "sqlite":
+		store, err = NewSQLiteStore(filepath.Join(dir, "index.db"))
+	case "local":
+		return NewLocalStore(filepath.Join(dir, "index.vdb"))
+	case "qdrant":
+		if url == "" {
+			return nil, fmt.Errorf("the qdrant vector store needs a url, e.g. http://localhost:6333")
+		}
+		store = NewQdrantStore(url, collection, os.ExpandEnv(s.APIKey))
+	case "pgvector":
+		if url == "" {
+			return nil, fmt.Errorf("the pgvector vector store needs a url: the Postgres DSN")
+		}
+		store, err = NewPgvectorStore(url, collection)
+	default:
+		return nil, fmt.Errorf("unknown vector store backend %q: use sqlite, local, qdrant or pgvector", s.Backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	legacy := filepath.Join(dir, "index.vdb")
+	if n, err := MigrateLocalStore(legacy, store); err != nil {
+		log.Printf("Warning: failed to migrate %s: %v", legacy, err)
+	} else if n > 0 {
+		log.Printf("Migrated %d documents from %s to the %s vector store", n, legacy, backend)
+	}
+	return store, nil
+}
+
+// OpenRootStore opens the store of an extra workspace root, beside the
+// primary one: local backends under dir/roots, remote ones in a collection
+// suffixed with the repo name and a hash of its path
+func OpenRootStore(s config.VectorStoreSettings, dir, name, path string) (VectorStore, error) {
+	sum := sha256.Sum256([]byte(path))
+	suffix := strings.ToLower(nonIdentChars.ReplaceAllString(name, "_")) + "_" + hex.EncodeToString(sum[:4])
+	if s.Collection == "" {
+		s.Collection = defaultCollection
+	}
+	s.Collection += "_" + suffix
+	rootDir := filepath.Join(dir, "roots", suffix)
+	if err := os.MkdirAll(rootDir, 0755); err != nil {
+		return nil, err
+	}
+	return OpenStore(s, rootDir)
+}
+
+// nonIdentChars are replaced to turn a repo name into a collection suffix
+var nonIdentChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// MigrateLocalStore copies a legacy index.vdb into an empty store and
+// renames the file to <path>.migrated. It returns the documents copied; a
+// missing file or a store that already has documents copies nothing.
+func MigrateLocalStore(path string, to VectorStore) (int, error) {
+	if _, err := os.Stat(path); err != nil || to.Count() > 0 {
+		return 0, nil
+	}
+	from, err := NewLocalStore(path)
+	if err != nil {
+		return 0, err
+	}
+	if len(from.docs) == 0 {
+		return 0, nil
+	}
+
+	if ds, ok := to.(describedStore); ok {
+		ds.SetInfo(from.Info())
+	}
+	if err := to.Add(from.docs); err != nil {
+		return 0, err
+	}
+	if err := to.Save(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(path, path+".migrated"); err != nil {
+		return 0, err
+	}
+	os.Rename(path+".meta", path+".migrated.meta")
+	return len(from.docs), nil
+}
+
+// encodeVector packs an embedding as little-endian float32s
+func encodeVector(v []float32) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(f))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float32 {
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return v
+}
@@ -0,0 +1,344 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.SetInfo(StoreInfo{Model: "builtin/hash-384", Dimensions: 2})
+	err = store.Add([]Document{
+		{ID: "a:1-2", FilePath: "a.go", Content: "func Alpha()", Embedding: []float32{1, 0}, Metadata: map[string]interface{}{"name": "Alpha"}},
+		{ID: "b:1-2", FilePath: "b.go", Content: "func Beta()", Embedding: []float32{0, 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// Documents and info survive reopening
+	store, err = NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if info := store.Info(); info.Model != "builtin/hash-384" || info.Dimensions != 2 {
+		t.Errorf("info = %+v", info)
+	}
+	results, err := store.Search([]float32{0.9, 0.1}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Document.ID != "a:1-2" || results[0].Document.Metadata["name"] != "Alpha" {
+		t.Fatalf("results = %+v", results)
+	}
+	if kw, _ := store.KeywordSearch("Beta", 5); len(kw) != 1 || kw[0].Document.FilePath != "b.go" {
+		t.Errorf("keyword results = %+v", kw)
+	}
+
+	if n, err := store.RemoveFiles([]string{"a.go"}); err != nil || n != 1 {
+		t.Fatalf("RemoveFiles = %d, %v", n, err)
+	}
+	if store.Count() != 1 {
+		t.Errorf("count after remove = %d", store.Count())
+	}
+	if err := store.Clear(); err != nil || store.Count() != 0 || store.Info().Dimensions != 0 {
+		t.Errorf("clear left %d documents, info %+v (%v)", store.Count(), store.Info(), err)
+	}
+}
+
+func TestOpenStore_MigratesLegacyIndex(t *testing.T) {
+	dir := t.TempDir()
+	legacy, err := NewLocalStore(filepath.Join(dir, "index.vdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy.SetInfo(StoreInfo{Model: "test/fixed", Dimensions: 2})
+	legacy.Add([]Document{{ID: "a", FilePath: "a.go", Content: "a", Embedding: []float32{1, 0}}})
+	if err := legacy.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := OpenStore(config.VectorStoreSettings{}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.(*SQLiteStore).Close()
+	if store.Count() != 1 || store.(*SQLiteStore).Info().Model != "test/fixed" {
+		t.Errorf("migrated %d documents, info %+v", store.Count(), store.(*SQLiteStore).Info())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "index.vdb.migrated")); err != nil {
+		t.Errorf("legacy index not renamed: %v", err)
+	}
+}
+
+func TestOpenStore_Invalid(t *testing.T) {
+	for _, s := range []config.VectorStoreSettings{
+		{Backend: "chroma"},
+		{Backend: "qdrant"},
+		{Backend: "pgvector"},
+		{Backend: "qdrant", URL: "http://localhost:6333", Collection: "drop table"},
+	} {
+		if _, err := OpenStore(s, t.TempDir()); err == nil {
+			t.Errorf("OpenStore(%+v) succeeded", s)
+		}
+	}
+}
+
+func TestSQLiteStoreIVF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Vectors scattered around a few topics, like chunks of related code
+	rng := rand.New(rand.NewSource(1))
+	const dims, n = 16, 3000
+	topics := make([][]float32, 20)
+	for i := range topics {
+		topics[i] = make([]float32, dims)
+		for j := range topics[i] {
+			topics[i][j] = float32(rng.NormFloat64())
+		}
+	}
+	docs := make([]Document, n)
+	for i := range docs {
+		emb := make([]float32, dims)
+		for j := range emb {
+			emb[j] = topics[i%len(topics)][j] + 0.3*float32(rng.NormFloat64())
+		}
+		docs[i] = Document{ID: fmt.Sprint(i), FilePath: fmt.Sprintf("f%d.go", i), Content: "x", Embedding: emb}
+	}
+	if err := store.Add(docs); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// The centroids survive reopening and searches find the exact neighbours
+	store, err = NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if len(store.centroids) != ivfLists(n) {
+		t.Fatalf("%d centroids, want %d", len(store.centroids), ivfLists(n))
+	}
+	found, total := 0, 0
+	for q := 0; q < 20; q++ {
+		query := docs[rng.Intn(n)].Embedding
+		exact := make([]Document, len(docs))
+		copy(exact, docs)
+		sort.Slice(exact, func(i, j int) bool {
+			return cosineSimilarity(query, exact[i].Embedding) > cosineSimilarity(query, exact[j].Embedding)
+		})
+		want := map[string]bool{}
+		for _, d := range exact[:10] {
+			want[d.ID] = true
+		}
+		results, err := store.Search(query, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range results {
+			if want[r.Document.ID] {
+				found++
+			}
+		}
+		total += len(want)
+	}
+	if recall := float64(found) / float64(total); recall < 0.9 {
+		t.Errorf("recall@10 = %.2f, want at least 0.9", recall)
+	}
+
+	// Documents added later are filed under a centroid and found
+	extra := Document{ID: "new", FilePath: "new.go", Content: "x", Embedding: docs[0].Embedding}
+	if err := store.Add([]Document{extra}); err != nil {
+		t.Fatal(err)
+	}
+	results, _ := store.Search(docs[0].Embedding, 2)
+	if len(results) != 2 || (results[0].Document.ID != "new" && results[1].Document.ID != "new") {
+		t.Errorf("added document not found: %+v", results)
+	}
+	if err := store.Clear(); err != nil || len(store.centroids) != 0 {
+		t.Errorf("clear kept %d centroids (%v)", len(store.centroids), err)
+	}
+}
+
+// fakeQdrant serves the part of the Qdrant REST API QdrantStore uses,
+// ranking points by dot product
+func fakeQdrant(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	var size int
+	exists := false
+	points := map[string]map[string]interface{}{}
+	reply := func(w http.ResponseWriter, result interface{}) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("api-key") != "key" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		path := strings.TrimPrefix(r.URL.Path, "/collections/code")
+		if !exists && !(r.Method == http.MethodPut && path == "") {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		switch {
+		case path == "" && r.Method == http.MethodGet:
+			reply(w, map[string]interface{}{"config": map[string]interface{}{"params": map[string]interface{}{"vectors": map[string]interface{}{"size": size}}}})
+		case path == "" && r.Method == http.MethodPut:
+			exists, size = true, int(body["vectors"].(map[string]interface{})["size"].(float64))
+			reply(w, true)
+		case path == "" && r.Method == http.MethodDelete:
+			exists, points = false, map[string]map[string]interface{}{}
+			reply(w, true)
+		case path == "/points":
+			for _, p := range body["points"].([]interface{}) {
+				point := p.(map[string]interface{})
+				points[point["id"].(string)] = point
+			}
+			reply(w, true)
+		case path == "/points/count":
+			reply(w, map[string]int{"count": len(points)})
+		case path == "/points/delete":
+			files := body["filter"].(map[string]interface{})["must"].([]interface{})[0].(map[string]interface{})["match"].(map[string]interface{})["any"].([]interface{})
+			for id, p := range points {
+				for _, f := range files {
+					if p["payload"].(map[string]interface{})["file_path"] == f {
+						delete(points, id)
+					}
+				}
+			}
+			reply(w, true)
+		case path == "/points/search":
+			query := body["vector"].([]interface{})
+			var hits []map[string]interface{}
+			for _, p := range points {
+				score := 0.0
+				for i, f := range p["vector"].([]interface{}) {
+					score += f.(float64) * query[i].(float64)
+				}
+				hits = append(hits, map[string]interface{}{"score": score, "payload": p["payload"]})
+			}
+			sort.Slice(hits, func(i, j int) bool { return hits[i]["score"].(float64) > hits[j]["score"].(float64) })
+			reply(w, hits[:min(len(hits), int(body["limit"].(float64)))])
+		default:
+			http.Error(w, "unexpected "+r.Method+" "+r.URL.Path, http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestQdrantStore(t *testing.T) {
+	store := NewQdrantStore(fakeQdrant(t).URL+"/", "code", "key")
+	if results, err := store.Search([]float32{1, 0}, 1); err != nil || len(results) != 0 {
+		t.Fatalf("search before indexing = %+v, %v", results, err)
+	}
+	err := store.Add([]Document{
+		{ID: "a:1-2", FilePath: "a.go", Content: "func Alpha()", Embedding: []float32{1, 0}, Metadata: map[string]interface{}{"name": "Alpha"}},
+		{ID: "b:1-2", FilePath: "b.go", Content: "func Beta()", Embedding: []float32{0, 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Count() != 2 || store.Info().Dimensions != 2 {
+		t.Errorf("count %d, info %+v", store.Count(), store.Info())
+	}
+	results, err := store.Search([]float32{0.9, 0.1}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Document.ID != "a:1-2" || results[0].Document.Metadata["name"] != "Alpha" {
+		t.Fatalf("results = %+v", results)
+	}
+	if n, err := store.RemoveFiles([]string{"a.go"}); err != nil || n != 1 {
+		t.Fatalf("RemoveFiles = %d, %v", n, err)
+	}
+	if err := store.Clear(); err != nil || store.Count() != 0 {
+		t.Errorf("clear left %d documents (%v)", store.Count(), err)
+	}
+
+	if err := NewQdrantStore(fakeQdrant(t).URL, "code", "wrong").Add([]Document{{ID: "a", Embedding: []float32{1}}}); err == nil {
+		t.Error("request with a bad API key succeeded")
+	}
+}
+
+func TestVectorLiteral(t *testing.T) {
+	if got := vectorLiteral([]float32{0.5, -1, 3e-8}); got != "[0.5,-1,3e-08]" {
+		t.Errorf("vectorLiteral = %s", got)
+	}
+}
+
+// TestPgvectorStore runs against the database in RICOCHET_TEST_PGVECTOR_DSN,
+// which needs the pgvector extension
+func TestPgvectorStore(t *testing.T) {
+	dsn := os.Getenv("RICOCHET_TEST_PGVECTOR_DSN")
+	if dsn == "" {
+		t.Skip("RICOCHET_TEST_PGVECTOR_DSN not set")
+	}
+	store, err := NewPgvectorStore(dsn, "ricochet_test_chunks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	defer store.db.Exec(`DROP TABLE ricochet_test_chunks; DROP TABLE ricochet_test_chunks_info`)
+	if err := store.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	store.SetInfo(StoreInfo{Model: "test/fixed", Dimensions: 2})
+	err = store.Add([]Document{
+		{ID: "a:1-2", FilePath: "a.go", Content: "func Alpha()", Embedding: []float32{1, 0}, Metadata: map[string]interface{}{"name": "Alpha"}},
+		{ID: "b:1-2", FilePath: "b.go", Content: "func Beta()", Embedding: []float32{0, 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Load(); err != nil || store.Info().Model != "test/fixed" {
+		t.Errorf("info after load = %+v (%v)", store.Info(), err)
+	}
+	results, err := store.Search([]float32{0.9, 0.1}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Document.ID != "a:1-2" || results[0].Document.Metadata["name"] != "Alpha" {
+		t.Fatalf("results = %+v", results)
+	}
+	if kw, err := store.KeywordSearch("Beta", 5); err != nil || len(kw) != 1 || kw[0].Document.FilePath != "b.go" {
+		t.Errorf("keyword results = %+v (%v)", kw, err)
+	}
+	if n, err := store.RemoveFiles([]string{"a.go"}); err != nil || n != 1 || store.Count() != 1 {
+		t.Errorf("RemoveFiles = %d, %v; count %d", n, err, store.Count())
+	}
+}
@@ -0,0 +1,217 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	ricochetContext "github.com/igoryan-dao/ricochet/internal/context"
+)
+
+const (
+	watchInterval = 2 * time.Second         // How often the workspace is polled for changes
+	watchDebounce = 1500 * time.Millisecond // Quiet time after the last change before re-indexing
+	watchRetry    = 30 * time.Second        // Wait after a failed re-index, e.g. an embedding API outage
+)
+
+// errIndexBusy means another indexing run holds the index; try again later
+var errIndexBusy = errors.New("indexing already in progress")
+
+// fileStamp identifies a version of a file without reading it
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// watcher is the state of one Watch loop
+type watcher struct {
+	idx     *Indexer
+	files   map[string]fileStamp // Last scan, by workspace-relative path
+	retryAt time.Time
+}
+
+// Watch keeps the index fresh until ctx is done: it polls the workspace for
+// changed, added and deleted files and re-indexes only those once edits
+// settle. Polling needs no platform file watcher and also sees changes made
+// outside the editor, like git checkouts.
+func (idx *Indexer) Watch(ctx context.Context) {
+	idx.mu.Lock()
+	if idx.watching {
+		idx.mu.Unlock()
+		return
+	}
+	idx.watching = true
+	idx.mu.Unlock()
+	defer func() {
+		idx.mu.Lock()
+		idx.watching = false
+		idx.mu.Unlock()
+	}()
+
+	files, err := idx.scan()
+	if err != nil {
+		log.Printf("Index watcher: failed to scan workspace: %v", err)
+	}
+	w := &watcher{idx: idx, files: files}
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			w.poll(ctx, now)
+		}
+	}
+}
+
+// poll queues the files changed since the last scan, then re-indexes the
+// queue once no change came in for watchDebounce
+func (w *watcher) poll(ctx context.Context, now time.Time) {
+	idx := w.idx
+	files, err := idx.scan()
+	if err != nil {
+		return // Keep the last scan; the workspace may be mid-checkout
+	}
+	changed := diffScans(w.files, files)
+	w.files = files
+
+	idx.mu.Lock()
+	if idx.pending == nil {
+		idx.pending = make(map[string]time.Time)
+	}
+	for _, path := range changed {
+		idx.pending[path] = now
+	}
+	batch := make(map[string]time.Time, len(idx.pending))
+	for path, at := range idx.pending {
+		if now.Sub(at) < watchDebounce {
+			batch = nil // Still being edited
+			break
+		}
+		batch[path] = at
+	}
+	idx.mu.Unlock()
+	if len(batch) == 0 || now.Before(w.retryAt) {
+		return
+	}
+
+	paths := make([]string, 0, len(batch))
+	for path := range batch {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	if err := idx.IndexFiles(ctx, paths); err != nil {
+		if !errors.Is(err, errIndexBusy) {
+			log.Printf("Index watcher: failed to re-index %d files: %v", len(paths), err)
+			w.retryAt = now.Add(watchRetry)
+		}
+		return
+	}
+
+	// Files changed again while indexing stay queued
+	idx.mu.Lock()
+	for path, at := range batch {
+		if idx.pending[path].Equal(at) {
+			delete(idx.pending, path)
+		}
+	}
+	idx.mu.Unlock()
+}
+
+// scan stamps every indexable file of the workspace
+func (idx *Indexer) scan() (map[string]fileStamp, error) {
+	files := make(map[string]fileStamp)
+	err := idx.walk(func(path string, info os.FileInfo) {
+		if rel, err := filepath.Rel(idx.workspaceRoot, path); err == nil {
+			files[rel] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	})
+	return files, err
+}
+
+// diffScans returns the files added, modified or deleted between two scans
+func diffScans(prev, next map[string]fileStamp) []string {
+	var changed []string
+	for path, stamp := range next {
+		if old, ok := prev[path]; !ok || !old.modTime.Equal(stamp.modTime) || old.size != stamp.size {
+			changed = append(changed, path)
+		}
+	}
+	for path := range prev {
+		if _, ok := next[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
+
+// IndexFiles re-chunks and re-embeds the given workspace-relative files,
+// replacing their documents in the index. Files that no longer exist are
+// dropped. PageRank is left to the next full IndexAll.
+func (idx *Indexer) IndexFiles(ctx context.Context, paths []string) error {
+	idx.mu.Lock()
+	if idx.isIndexing {
+		idx.mu.Unlock()
+		return errIndexBusy
+	}
+	idx.isIndexing = true
+	idx.mu.Unlock()
+
+	var docs []Document
+	var indexErr error
+
+	defer func() {
+		idx.mu.Lock()
+		idx.isIndexing = false
+		if indexErr != nil {
+			idx.lastError = indexErr.Error()
+		} else {
+			idx.lastError = ""
+			idx.lastIndexed = time.Now()
+			idx.lastDocCount = idx.store.Count()
+		}
+		idx.mu.Unlock()
+	}()
+
+	for _, rel := range paths {
+		path := filepath.Join(idx.workspaceRoot, rel)
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() || !ricochetContext.IsSupported(path) || idx.isIgnored(path, false) {
+			continue // Only dropped from the index
+		}
+		fileDocs, err := idx.indexFile(ctx, path)
+		if err != nil {
+			fmt.Printf("Warning: failed to index file %s: %v\n", path, err)
+			continue
+		}
+		docs = append(docs, fileDocs...)
+	}
+
+	if len(docs) > 0 {
+		if indexErr = idx.embed(ctx, docs, nil, nil); indexErr != nil {
+			return indexErr
+		}
+		if indexErr = idx.CheckCompatible(len(docs[0].Embedding)); indexErr != nil {
+			return indexErr
+		}
+	}
+
+	if _, indexErr = idx.store.RemoveFiles(paths); indexErr != nil {
+		return indexErr
+	}
+	if ds, ok := idx.store.(describedStore); ok && len(docs) > 0 && ds.Info().Dimensions == 0 {
+		ds.SetInfo(StoreInfo{Model: EmbeddingModelOf(idx.provider), Dimensions: len(docs[0].Embedding)})
+	}
+	if indexErr = idx.store.Add(docs); indexErr != nil {
+		return indexErr
+	}
+	indexErr = idx.store.Save()
+	return indexErr
+}
@@ -0,0 +1,112 @@
+package index
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newWatchedIndexer(t *testing.T) (*Indexer, *LocalStore, string) {
+	t.Helper()
+	root := t.TempDir()
+	store, err := NewLocalStore(filepath.Join(t.TempDir(), "index.vdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewIndexer(store, NewHashEmbedder(), root), store, root
+}
+
+func writeSource(t *testing.T, root, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// indexedContent joins the indexed chunks of a file
+func indexedContent(store *LocalStore, rel string) string {
+	var sb strings.Builder
+	for _, d := range store.docs {
+		if d.FilePath == rel {
+			sb.WriteString(d.Content)
+		}
+	}
+	return sb.String()
+}
+
+func TestIndexFiles(t *testing.T) {
+	idx, store, root := newWatchedIndexer(t)
+	writeSource(t, root, "a.py", "def alpha():\n    return 1\n")
+	writeSource(t, root, "b.py", "def beta():\n    return 2\n")
+	if err := idx.IndexAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	writeSource(t, root, "a.py", "def gamma():\n    return 3\n")
+	os.Remove(filepath.Join(root, "b.py"))
+	if err := idx.IndexFiles(context.Background(), []string{"a.py", "b.py"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := indexedContent(store, "a.py"); !strings.Contains(got, "gamma") || strings.Contains(got, "alpha") {
+		t.Errorf("a.py indexed as %q, want only the new version", got)
+	}
+	if got := indexedContent(store, "b.py"); got != "" {
+		t.Errorf("deleted b.py still indexed: %q", got)
+	}
+	if st := idx.Status(); st.Documents != store.Count() || !st.Fresh {
+		t.Errorf("status = %+v", st)
+	}
+}
+
+func TestWatcherDebounce(t *testing.T) {
+	idx, store, root := newWatchedIndexer(t)
+	writeSource(t, root, "a.py", "def alpha():\n    return 1\n")
+	if err := idx.IndexAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	files, err := idx.scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &watcher{idx: idx, files: files}
+	ctx := context.Background()
+	now := time.Now()
+
+	writeSource(t, root, "a.py", "def delta():\n    return 4\n")
+	writeSource(t, root, "c.py", "def omega():\n    return 5\n")
+	w.poll(ctx, now)
+	st := idx.Status()
+	if st.Pending != 2 || st.Fresh || st.StaleSince.IsZero() {
+		t.Fatalf("status after edits = %+v, want 2 pending", st)
+	}
+	if strings.Contains(indexedContent(store, "a.py"), "delta") {
+		t.Fatal("re-indexed before the edits settled")
+	}
+
+	// Nothing changed for longer than the debounce: the queue is indexed
+	w.poll(ctx, now.Add(watchDebounce))
+	if st := idx.Status(); st.Pending != 0 || !st.Fresh {
+		t.Errorf("status after debounce = %+v, want fresh", st)
+	}
+	if !strings.Contains(indexedContent(store, "a.py"), "delta") || !strings.Contains(indexedContent(store, "c.py"), "omega") {
+		t.Error("changed files were not re-indexed")
+	}
+}
+
+func TestDiffScans(t *testing.T) {
+	t0 := time.Now()
+	prev := map[string]fileStamp{"same": {t0, 1}, "edited": {t0, 1}, "deleted": {t0, 1}}
+	next := map[string]fileStamp{"same": {t0, 1}, "edited": {t0.Add(time.Second), 1}, "added": {t0, 1}}
+	got := diffScans(prev, next)
+	if len(got) != 3 {
+		t.Fatalf("changed = %v, want edited, added and deleted", got)
+	}
+	for _, p := range got {
+		if p == "same" {
+			t.Errorf("unchanged file reported: %v", got)
+		}
+	}
+}
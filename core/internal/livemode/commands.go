@@ -0,0 +1,192 @@
+package livemode
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/agent"
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+	"github.com/igoryan-dao/ricochet/internal/state"
+	"github.com/igoryan-dao/ricochet/internal/telegram"
+)
+
+// Lines /logs shows by default and at most
+const (
+	defaultLogLines = 30
+	maxLogLines     = 100
+)
+
+// handleCommand runs the palette commands (/status, /diff, /abort, /logs),
+// reporting whether text was one of them
+func (c *Controller) handleCommand(ctx context.Context, chatID int64, text string) bool {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return false
+	}
+	// Commands in groups may be addressed as /status@ricochet_bot
+	command, _, _ := strings.Cut(fields[0], "@")
+
+	switch command {
+	case "/status":
+		c.sendStatus(ctx, chatID)
+	case "/diff":
+		c.sendDiff(ctx, chatID)
+	case "/abort":
+		c.agent.AbortCurrentSession()
+		c.tgBot.SendMessage(ctx, chatID, "🛑 **Aborted** the running turn.")
+	case "/logs":
+		n := defaultLogLines
+		if len(fields) > 1 {
+			if v, err := strconv.Atoi(fields[1]); err == nil && v > 0 {
+				n = min(v, maxLogLines)
+			}
+		}
+		c.sendLogs(ctx, chatID, n)
+	default:
+		return false
+	}
+	return true
+}
+
+// handleEmergencyCommand runs /panic and /resume, reporting whether text was
+// one of them. They work with or without an agent attached, and do not wake
+// Live Mode.
+func (c *Controller) handleEmergencyCommand(ctx context.Context, chatID int64, text string) bool {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return false
+	}
+	command, _, _ := strings.Cut(fields[0], "@")
+
+	switch command {
+	case "/panic":
+		report := agent.EmergencyReport{}
+		if c.agent != nil {
+			report = c.agent.EmergencyStop(safeguard.ChannelTelegram)
+		} else {
+			report.EmergencyState = safeguard.EngageEmergencyStop(safeguard.ChannelTelegram)
+		}
+		c.tgBot.SendMessage(ctx, chatID, agent.FormatEmergencyReport(report))
+	case "/resume":
+		var released safeguard.EmergencyState
+		if c.agent != nil {
+			released = c.agent.ResumeFromEmergencyStop()
+		} else {
+			released = safeguard.ReleaseEmergencyStop()
+		}
+		c.tgBot.SendMessage(ctx, chatID, agent.FormatEmergencyResume(released))
+	default:
+		return false
+	}
+	return true
+}
+
+// sendStatus reports the chat's session: its plan, todos and context usage
+func (c *Controller) sendStatus(ctx context.Context, chatID int64) {
+	sessionID := c.sessionForChat(chatID)
+	session := c.agent.GetSession(sessionID)
+	if session == nil {
+		c.tgBot.SendMessage(ctx, chatID, "⚠️ No active session.")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📊 **Status** — session `%s`\n", sessionID))
+
+	if status := c.agent.GetContextStatus(sessionID); status != nil {
+		sb.WriteString(fmt.Sprintf("\n🧠 Context: %.0f%% (%d / %d tokens)\n💰 Cost: $%.4f\n",
+			status.Percentage, status.TokensUsed, status.TokensMax, status.CumulativeCost))
+	}
+
+	if pm := c.agent.GetPlanManager(); pm != nil {
+		if tasks := pm.GetTasks(); len(tasks) > 0 {
+			sb.WriteString("\n🧭 **Plan**\n")
+			for _, task := range tasks {
+				sb.WriteString(fmt.Sprintf("%s %s. %s\n", taskIcon(task.Status), task.ID, task.Title))
+			}
+		}
+	}
+
+	if len(session.Todos) > 0 {
+		sb.WriteString("\n📝 **Todos**\n")
+		for _, todo := range session.Todos {
+			sb.WriteString(fmt.Sprintf("%s %s\n", todoIcon(todo.Status), todo.Text))
+		}
+	}
+
+	c.tgBot.SendMessage(ctx, chatID, sb.String())
+}
+
+// sendDiff previews the working tree diff, with the rest one tap away
+func (c *Controller) sendDiff(ctx context.Context, chatID int64) {
+	gitMgr := c.agent.GetGitManager()
+	if gitMgr == nil || !gitMgr.IsRepo() {
+		c.tgBot.SendMessage(ctx, chatID, "⚠️ The workspace is not a git repository.")
+		return
+	}
+
+	diff, err := gitMgr.Diff()
+	if err != nil {
+		c.tgBot.SendMessage(ctx, chatID, fmt.Sprintf("❌ Failed to get diff: %v", err))
+		return
+	}
+	if strings.TrimSpace(diff) == "" {
+		c.tgBot.SendMessage(ctx, chatID, "✨ Working tree is clean.")
+		return
+	}
+
+	buttons := [][]telegram.ButtonConfig{{{Text: "📄 View full", Data: telegram.CallbackViewDiff}}}
+	if err := c.tgBot.SendDiff(ctx, state.Recipient{ChatID: chatID}, "🧾 **Working tree diff**", "working-tree", diff, buttons); err != nil {
+		c.tgBot.SendMessage(ctx, chatID, fmt.Sprintf("❌ Failed to send diff: %v", err))
+	}
+}
+
+// sendLogs sends the last n lines the core logged
+func (c *Controller) sendLogs(ctx context.Context, chatID int64, n int) {
+	lines := c.logs.Tail(n)
+	if len(lines) == 0 {
+		c.tgBot.SendMessage(ctx, chatID, "📜 No logs yet.")
+		return
+	}
+
+	// Keep inside the message limit, dropping the oldest lines first
+	text := strings.Join(lines, "\n")
+	if len(text) > 3500 {
+		text = text[len(text)-3500:]
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = text[i+1:]
+		}
+	}
+	if err := c.tgBot.SendCodeBlock(ctx, chatID, "log", text); err != nil {
+		c.tgBot.SendMessage(ctx, chatID, fmt.Sprintf("❌ Failed to send logs: %v", err))
+	}
+}
+
+// taskIcon marks a plan task by status, like the plan's prompt context
+func taskIcon(status string) string {
+	switch status {
+	case "done", "completed":
+		return "✅"
+	case "active", "in_progress":
+		return "▶️"
+	case "failed":
+		return "❌"
+	default:
+		return "⬜"
+	}
+}
+
+// todoIcon marks a todo by status
+func todoIcon(status protocol.TodoStatus) string {
+	switch status {
+	case protocol.TodoCompleted:
+		return "✅"
+	case protocol.TodoCurrent:
+		return "▶️"
+	default:
+		return "⬜"
+	}
+}
@@ -3,6 +3,7 @@ package livemode
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"strings"
 	"sync"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/igoryan-dao/ricochet/internal/agent"
 	"github.com/igoryan-dao/ricochet/internal/protocol"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
 	"github.com/igoryan-dao/ricochet/internal/state"
 	"github.com/igoryan-dao/ricochet/internal/telegram"
 	"github.com/igoryan-dao/ricochet/internal/whisper"
@@ -52,6 +54,9 @@ type Controller struct {
 
 	// Throttling for streaming updates to prevent webview crash
 	lastChatUpdateTime time.Time
+
+	// Recent core logs, for /logs
+	logs logTail
 }
 
 // SetMainSessionID sets the primary session ID for binding
@@ -151,6 +156,10 @@ func New(cfg *Config, agentCtrl *agent.Controller) (*Controller, error) {
 			return nil, fmt.Errorf("failed to create telegram bot: %w", err)
 		}
 		ctrl.tgBot = tgBot
+		tgBot.SetFileHandler(ctrl.ingestFile)
+		if agentCtrl != nil {
+			tgBot.SetRedactor(agentCtrl.RedactSecrets)
+		}
 	}
 
 	// Initialize Whisper if configured
@@ -178,6 +187,9 @@ func (c *Controller) Start(ctx context.Context) {
 	}
 	c.mu.Unlock()
 
+	// Keep recent logs around for /logs, wherever they are written
+	log.SetOutput(safeguard.RedactingWriter(io.MultiWriter(log.Writer(), &c.logs)))
+
 	// Start Telegram bot in background
 	go c.tgBot.Start(ctx)
 
@@ -290,6 +302,11 @@ func (c *Controller) listenForMessages(ctx context.Context) {
 func (c *Controller) handleTelegramMessage(ctx context.Context, resp *telegram.UserResponse) {
 	log.Printf("Live Mode received message from chat %d: %s", resp.ChatID, resp.Text)
 
+	// The panic button works whatever state Live Mode is in
+	if c.handleEmergencyCommand(ctx, resp.ChatID, resp.Text) {
+		return
+	}
+
 	// Auto-Enable if disabled
 	// Auto-Enable if disabled
 	if !c.IsEnabled() {
@@ -348,31 +365,16 @@ func (c *Controller) handleTelegramMessage(ctx context.Context, resp *telegram.U
 		return
 	}
 
+	// Handle palette commands (/status, /diff, /abort, /logs)
+	if c.handleCommand(ctx, resp.ChatID, resp.Text) {
+		return
+	}
+
 	// Emit receiving activity
 	c.emitActivity("receiving", "telegram", resp.Username, resp.Text)
 
 	// Resolve Session ID EARLY so we can tag the user message
-	sessionID := c.tgBot.GetActiveSession(resp.ChatID)
-	// Check if session ID is valid AND exists in current agent instance
-	if sessionID == "" || c.agent.GetSession(sessionID) == nil {
-		// FALLBACK: User wants to resume the Active Shell Session (if any)
-		// Check if there are any active sessions in the agent.
-		sessions := c.agent.ListSessions()
-		if len(sessions) > 0 {
-			// ListSessions returns sorted by CreatedAt descending (0 is latest)
-			// Adopt the latest session (likely the TUI session)
-			sessionID = sessions[0].ID
-			log.Printf("Live Mode: Resuming existing active session %s for chat %d", sessionID, resp.ChatID)
-		} else {
-			// No active session found? Create a NEW one.
-			s := c.agent.CreateSession()
-			sessionID = s.ID
-			log.Printf("Live Mode: Created new session %s for chat %d", sessionID, resp.ChatID)
-		}
-
-		// Bind to chat
-		c.tgBot.SetActiveSession(resp.ChatID, sessionID)
-	}
+	sessionID := c.sessionForChat(resp.ChatID)
 
 	// Forward user message to IDE
 	c.emitChatUpdate(agent.ChatUpdate{
@@ -415,8 +417,9 @@ func (c *Controller) handleTelegramMessage(ctx context.Context, resp *telegram.U
 	// Inject ChatID into context so tools (AskUserRemote) know where to reply
 	chatCtx := context.WithValue(ctx, chatIDKey, resp.ChatID)
 
-	// Stream response to Shell, send final to Telegram
+	// Stream response to Shell, and to Telegram by editing a single message
 	var currentContent string
+	stream := c.tgBot.NewStream(resp.ChatID)
 
 	err := c.agent.Chat(chatCtx, agent.ChatRequestInput{
 		SessionID: sessionID,
@@ -435,18 +438,22 @@ func (c *Controller) handleTelegramMessage(ctx context.Context, resp *telegram.U
 			return
 		}
 
-		// Store final content for Telegram delivery after loop finishes
-		currentContent = chatUpdate.Message.Content
+		// Status-only updates carry no content
+		if chatUpdate.Message.Content != "" {
+			currentContent = chatUpdate.Message.Content
+			if err := stream.Update(ctx, currentContent); err != nil {
+				log.Printf("Failed to stream to Telegram: %v", err)
+			}
+		}
 
 		// Forward updates to Shell with via field
 		chatUpdate.Message.Via = "telegram"
 		c.emitChatUpdate(chatUpdate)
 	})
 
-	// After the Agent is done, send a SINGLE message to Telegram
+	// After the Agent is done, the final edit shows the formatted response
 	if currentContent != "" {
-		_, sendErr := c.tgBot.SendMessageAndTrack(ctx, resp.ChatID, currentContent)
-		if sendErr != nil {
+		if sendErr := stream.Finish(ctx, currentContent); sendErr != nil {
 			log.Printf("Failed to send final message to Telegram: %v", sendErr)
 		}
 	} else if err != nil {
@@ -461,6 +468,35 @@ func (c *Controller) handleTelegramMessage(ctx context.Context, resp *telegram.U
 	}
 }
 
+// sessionForChat returns the session bound to a chat, binding the latest
+// (or a new) session when there is none
+func (c *Controller) sessionForChat(chatID int64) string {
+	sessionID := c.tgBot.GetActiveSession(chatID)
+	// Check if session ID is valid AND exists in current agent instance
+	if sessionID != "" && c.agent.GetSession(sessionID) != nil {
+		return sessionID
+	}
+
+	// FALLBACK: User wants to resume the Active Shell Session (if any)
+	// Check if there are any active sessions in the agent.
+	sessions := c.agent.ListSessions()
+	if len(sessions) > 0 {
+		// ListSessions returns sorted by CreatedAt descending (0 is latest)
+		// Adopt the latest session (likely the TUI session)
+		sessionID = sessions[0].ID
+		log.Printf("Live Mode: Resuming existing active session %s for chat %d", sessionID, chatID)
+	} else {
+		// No active session found? Create a NEW one.
+		s := c.agent.CreateSession()
+		sessionID = s.ID
+		log.Printf("Live Mode: Created new session %s for chat %d", sessionID, chatID)
+	}
+
+	// Bind to chat
+	c.tgBot.SetActiveSession(chatID, sessionID)
+	return sessionID
+}
+
 // handleTelegramCallback processes button clicks
 func (c *Controller) handleTelegramCallback(ctx context.Context, callback *telegram.CallbackEvent) {
 	log.Printf("Live Mode received callback: %s from chat %d", callback.Data, callback.ChatID)
@@ -546,6 +582,9 @@ func (c *Controller) SetAgent(agent *agent.Controller) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.agent = agent
+	if c.tgBot != nil && agent != nil {
+		c.tgBot.SetRedactor(agent.RedactSecrets)
+	}
 }
 
 // SetChatID sets the primary Telegram chat ID
@@ -637,6 +676,22 @@ func (c *Controller) IsEnabled() bool {
 // AskUserRemote sends an approval request to Telegram and waits for response
 // This is used for tool consent when the user is controlling via Ether Mode
 func (c *Controller) AskUserRemote(ctx context.Context, question string) (string, error) {
+	return c.askRemote(ctx, func(tgBot *telegram.Bot, chatID int64) (string, error) {
+		// Use the bot's AskUser method which handles inline buttons
+		return tgBot.AskUser(ctx, chatID, question)
+	})
+}
+
+// AskApproveDiffRemote is AskUserRemote for a file edit: the question comes
+// with a preview of the diff, which can also be viewed in full
+func (c *Controller) AskApproveDiffRemote(ctx context.Context, question, path, diff string) (string, error) {
+	return c.askRemote(ctx, func(tgBot *telegram.Bot, chatID int64) (string, error) {
+		return tgBot.AskApproveDiff(ctx, chatID, question, path, diff)
+	})
+}
+
+// askRemote asks in the chat the request came from, or the configured one
+func (c *Controller) askRemote(ctx context.Context, ask func(tgBot *telegram.Bot, chatID int64) (string, error)) (string, error) {
 	c.mu.RLock()
 	enabled := c.enabled
 	tgBot := c.tgBot
@@ -655,16 +710,11 @@ func (c *Controller) AskUserRemote(ctx context.Context, question string) (string
 		return "", fmt.Errorf("telegram chat ID not set")
 	}
 
-	// Use the bot's AskUser method which handles inline buttons
 	// Prefer context chatID if available (dynamic routing)
-	var response string
-	var err error
 	if ctxChatID, ok := ctx.Value(chatIDKey).(int64); ok {
-		response, err = tgBot.AskUser(ctx, ctxChatID, question)
-	} else {
-		// Fallback to default configured ChatID
-		response, err = tgBot.AskUser(ctx, chatID, question)
+		chatID = ctxChatID
 	}
+	response, err := ask(tgBot, chatID)
 
 	// Emit activity to notify UI about the approval
 	if err == nil && response != "" {
@@ -0,0 +1,90 @@
+package livemode
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/telegram"
+)
+
+// inboxDir is where files sent from the phone land, relative to the workspace
+const inboxDir = "inbox"
+
+// ingestFile moves a file sent over Telegram into the workspace inbox,
+// registers it with the chat's session and tells the agent where it is
+func (c *Controller) ingestFile(_ context.Context, upload *telegram.FileUpload) (string, error) {
+	if c.agent == nil {
+		return "", fmt.Errorf("agent not initialized")
+	}
+	workspace := c.agent.GetHost().GetCWD()
+
+	dir := filepath.Join(workspace, inboxDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	dest := freePath(filepath.Join(dir, upload.Name))
+	if err := moveFile(upload.LocalPath, dest); err != nil {
+		return "", err
+	}
+
+	// Workspace-relative, like the paths tools are called with
+	rel, err := filepath.Rel(workspace, dest)
+	if err != nil {
+		rel = dest
+	}
+	rel = filepath.ToSlash(rel)
+
+	if session := c.agent.GetSession(c.sessionForChat(upload.ChatID)); session != nil {
+		session.FileTracker.AddFile(rel)
+	}
+
+	if upload.IsImage() {
+		return fmt.Sprintf("[User uploaded image %s, use analyze_image to view it]", rel), nil
+	}
+	return fmt.Sprintf("[User uploaded file %s]", rel), nil
+}
+
+// freePath returns path, or path with a numeric suffix if it already exists
+func freePath(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	candidate := path
+	for i := 1; ; i++ {
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s_%d%s", base, i, ext)
+	}
+}
+
+// moveFile renames src to dst, copying when they are on different devices
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
@@ -0,0 +1,40 @@
+package livemode
+
+import (
+	"strings"
+	"sync"
+)
+
+// logTailSize is how many log lines are kept for /logs
+const logTailSize = 500
+
+// logTail is a log writer keeping the last lines written to it
+type logTail struct {
+	mu      sync.Mutex
+	lines   []string
+	partial string // Line not yet terminated by a newline
+}
+
+// Write splits p into lines, dropping the oldest past logTailSize
+func (t *logTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	text := t.partial + string(p)
+	lines := strings.Split(text, "\n")
+	t.partial = lines[len(lines)-1]
+	t.lines = append(t.lines, lines[:len(lines)-1]...)
+	if over := len(t.lines) - logTailSize; over > 0 {
+		t.lines = append(t.lines[:0:0], t.lines[over:]...)
+	}
+	return len(p), nil
+}
+
+// Tail returns up to the last n complete lines
+func (t *logTail) Tail(n int) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	start := max(len(t.lines)-n, 0)
+	return append([]string(nil), t.lines[start:]...)
+}
@@ -0,0 +1,28 @@
+package livemode
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestLogTail(t *testing.T) {
+	var tail logTail
+	fmt.Fprint(&tail, "one\ntwo\nthr")
+	fmt.Fprint(&tail, "ee\n")
+
+	if got, want := tail.Tail(2), []string{"two", "three"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tail(2) = %q, want %q", got, want)
+	}
+	if got := tail.Tail(10); len(got) != 3 {
+		t.Errorf("Tail(10) = %q, want all 3 lines", got)
+	}
+
+	for i := 0; i < logTailSize+5; i++ {
+		fmt.Fprintf(&tail, "line %d\n", i)
+	}
+	got := tail.Tail(logTailSize + 10)
+	if len(got) != logTailSize || got[len(got)-1] != fmt.Sprintf("line %d", logTailSize+4) {
+		t.Errorf("Tail kept %d lines ending in %q", len(got), got[len(got)-1])
+	}
+}
@@ -0,0 +1,431 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClosed is returned for requests to a server that has exited
+var ErrClosed = errors.New("language server is not running")
+
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *ResponseError   `json:"error,omitempty"`
+}
+
+// ResponseError is an error reported by the server
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+type document struct {
+	version int
+	content string
+}
+
+// Client speaks JSON-RPC to one language server over a stream
+type Client struct {
+	conn io.ReadWriteCloser
+
+	writeMu sync.Mutex
+	nextID  atomic.Int64
+
+	mu      sync.Mutex
+	pending map[int64]chan *message
+	docs    map[string]*document    // Open documents by path
+	diags   map[string][]Diagnostic // Latest published diagnostics by path
+	diagSeq map[string]int          // Bumped on each publish, to wait for fresh results
+
+	diagCond *sync.Cond
+	done     chan struct{}
+	err      error
+}
+
+// NewClient starts reading from conn. Call Initialize before any request.
+func NewClient(conn io.ReadWriteCloser) *Client {
+	c := &Client{
+		conn:    conn,
+		pending: make(map[int64]chan *message),
+		docs:    make(map[string]*document),
+		diags:   make(map[string][]Diagnostic),
+		diagSeq: make(map[string]int),
+		done:    make(chan struct{}),
+	}
+	c.diagCond = sync.NewCond(&c.mu)
+	go c.readLoop()
+	return c
+}
+
+// Done is closed when the connection ends
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Initialize performs the initialize handshake for a workspace root
+func (c *Client) Initialize(ctx context.Context, root string) error {
+	params := map[string]interface{}{
+		"processId": os.Getpid(),
+		"rootUri":   PathToURI(root),
+		"workspaceFolders": []map[string]string{
+			{"uri": PathToURI(root), "name": "workspace"},
+		},
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"synchronization":    map[string]interface{}{"didSave": true},
+				"hover":              map[string]interface{}{"contentFormat": []string{"markdown", "plaintext"}},
+				"definition":         map[string]interface{}{"linkSupport": true},
+				"references":         map[string]interface{}{},
+				"rename":             map[string]interface{}{"prepareSupport": false},
+				"publishDiagnostics": map[string]interface{}{},
+			},
+			"workspace": map[string]interface{}{
+				"workspaceEdit":    map[string]interface{}{"documentChanges": true},
+				"configuration":    true,
+				"workspaceFolders": true,
+			},
+		},
+	}
+	if err := c.Call(ctx, "initialize", params, nil); err != nil {
+		return fmt.Errorf("initialize: %w", err)
+	}
+	return c.Notify("initialized", map[string]interface{}{})
+}
+
+// Call sends a request and decodes its result into result (if non-nil)
+func (c *Client) Call(ctx context.Context, method string, params, result interface{}) error {
+	id := c.nextID.Add(1)
+	ch := make(chan *message, 1)
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	rawID := json.RawMessage(strconv.FormatInt(id, 10))
+	if err := c.write(method, &rawID, params); err != nil {
+		return err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result != nil && len(resp.Result) > 0 {
+			return json.Unmarshal(resp.Result, result)
+		}
+		return nil
+	case <-c.done:
+		return ErrClosed
+	case <-ctx.Done():
+		_ = c.Notify("$/cancelRequest", map[string]int64{"id": id})
+		return ctx.Err()
+	}
+}
+
+// Notify sends a notification
+func (c *Client) Notify(method string, params interface{}) error {
+	return c.write(method, nil, params)
+}
+
+func (c *Client) write(method string, id *json.RawMessage, params interface{}) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.send(message{JSONRPC: "2.0", ID: id, Method: method, Params: raw})
+}
+
+func (c *Client) send(msg message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := fmt.Fprintf(c.conn, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = c.conn.Write(body)
+	return err
+}
+
+func (c *Client) readLoop() {
+	r := bufio.NewReader(c.conn)
+	var err error
+	for {
+		var msg *message
+		if msg, err = readMessage(r); err != nil {
+			break
+		}
+		switch {
+		case msg.Method != "" && msg.ID != nil:
+			c.handleServerRequest(msg)
+		case msg.Method != "":
+			c.handleNotification(msg)
+		case msg.ID != nil:
+			id, _ := strconv.ParseInt(string(*msg.ID), 10, 64)
+			c.mu.Lock()
+			ch := c.pending[id]
+			c.mu.Unlock()
+			if ch != nil {
+				ch <- msg
+			}
+		}
+	}
+
+	c.mu.Lock()
+	c.err = err
+	c.diagCond.Broadcast()
+	c.mu.Unlock()
+	close(c.done)
+}
+
+func readMessage(r *bufio.Reader) (*message, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if v, ok := strings.CutPrefix(line, "Content-Length:"); ok {
+			length, err = strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("bad Content-Length %q", v)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("decode message: %w", err)
+	}
+	return &msg, nil
+}
+
+// handleServerRequest answers requests servers make of the client. Settings
+// requests get empty configuration; everything else is acknowledged.
+func (c *Client) handleServerRequest(msg *message) {
+	result := json.RawMessage("null")
+	if msg.Method == "workspace/configuration" {
+		var params struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		items := make([]interface{}, len(params.Items))
+		result, _ = json.Marshal(items)
+	}
+	_ = c.send(message{JSONRPC: "2.0", ID: msg.ID, Result: result})
+}
+
+func (c *Client) handleNotification(msg *message) {
+	if msg.Method != "textDocument/publishDiagnostics" {
+		return // Progress, log and telemetry messages are ignored
+	}
+	var params struct {
+		URI         string       `json:"uri"`
+		Diagnostics []Diagnostic `json:"diagnostics"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return
+	}
+	path := URIToPath(params.URI)
+	c.mu.Lock()
+	c.diags[path] = params.Diagnostics
+	c.diagSeq[path]++
+	c.diagCond.Broadcast()
+	c.mu.Unlock()
+}
+
+// Sync opens path on the server, or sends its new content if it changed on
+// disk since the last sync. It reports whether the server saw new content.
+func (c *Client) Sync(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	content := string(data)
+	uri := PathToURI(path)
+
+	c.mu.Lock()
+	doc, open := c.docs[path]
+	if open && doc.content == content {
+		c.mu.Unlock()
+		return false, nil
+	}
+	if !open {
+		doc = &document{}
+		c.docs[path] = doc
+	}
+	doc.version++
+	doc.content = content
+	version := doc.version
+	c.mu.Unlock()
+
+	if !open {
+		return true, c.Notify("textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"uri":        uri,
+				"languageId": languageID(path),
+				"version":    version,
+				"text":       content,
+			},
+		})
+	}
+	return true, c.Notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": version},
+		"contentChanges": []map[string]string{{"text": content}},
+	})
+}
+
+func positionParams(path string, pos Position) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]string{"uri": PathToURI(path)},
+		"position":     pos,
+	}
+}
+
+// Definition returns where the symbol at pos is defined
+func (c *Client) Definition(ctx context.Context, path string, pos Position) ([]Location, error) {
+	var raw json.RawMessage
+	if err := c.Call(ctx, "textDocument/definition", positionParams(path, pos), &raw); err != nil {
+		return nil, err
+	}
+	return decodeLocations(raw)
+}
+
+// References returns every use of the symbol at pos, including its declaration
+func (c *Client) References(ctx context.Context, path string, pos Position) ([]Location, error) {
+	params := positionParams(path, pos)
+	params["context"] = map[string]bool{"includeDeclaration": true}
+	var locs []Location
+	if err := c.Call(ctx, "textDocument/references", params, &locs); err != nil {
+		return nil, err
+	}
+	return locs, nil
+}
+
+// Hover returns the documentation shown for the symbol at pos
+func (c *Client) Hover(ctx context.Context, path string, pos Position) (string, error) {
+	var h *hoverResult
+	if err := c.Call(ctx, "textDocument/hover", positionParams(path, pos), &h); err != nil {
+		return "", err
+	}
+	if h == nil {
+		return "", nil
+	}
+	return h.text(), nil
+}
+
+// Rename computes (but does not apply) the edits renaming the symbol at pos
+func (c *Client) Rename(ctx context.Context, path string, pos Position, newName string) (*WorkspaceEdit, error) {
+	params := positionParams(path, pos)
+	params["newName"] = newName
+	var edit *WorkspaceEdit
+	if err := c.Call(ctx, "textDocument/rename", params, &edit); err != nil {
+		return nil, err
+	}
+	if edit == nil {
+		return nil, fmt.Errorf("no symbol to rename at this position")
+	}
+	return edit, nil
+}
+
+// Diagnostics syncs path and returns its published diagnostics. For new or
+// changed content it waits up to wait for the server to publish.
+func (c *Client) Diagnostics(path string, wait time.Duration) ([]Diagnostic, error) {
+	c.mu.Lock()
+	seq, published := c.diagSeq[path]
+	c.mu.Unlock()
+
+	changed, err := c.Sync(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !changed && published {
+		return c.diags[path], nil
+	}
+
+	timer := time.AfterFunc(wait, func() {
+		c.mu.Lock()
+		c.diagCond.Broadcast()
+		c.mu.Unlock()
+	})
+	defer timer.Stop()
+
+	deadline := time.Now().Add(wait)
+	for c.diagSeq[path] == seq && c.err == nil && time.Now().Before(deadline) {
+		c.diagCond.Wait()
+	}
+	return c.diags[path], nil
+}
+
+// Close shuts the server down politely, then closes the connection
+func (c *Client) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := c.Call(ctx, "shutdown", nil, nil); err == nil {
+		_ = c.Notify("exit", nil)
+	}
+	return c.conn.Close()
+}
+
+func decodeLocations(raw json.RawMessage) ([]Location, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var list []locationOrLink
+	if err := json.Unmarshal(raw, &list); err != nil {
+		var single locationOrLink
+		if err := json.Unmarshal(raw, &single); err != nil {
+			return nil, fmt.Errorf("decode locations: %w", err)
+		}
+		list = []locationOrLink{single}
+	}
+	locs := make([]Location, 0, len(list))
+	for _, l := range list {
+		locs = append(locs, l.location())
+	}
+	return locs, nil
+}
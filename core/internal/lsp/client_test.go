@@ -0,0 +1,116 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeServer answers requests from a table and publishes diagnostics when a
+// document is opened
+func fakeServer(t *testing.T, conn net.Conn, results map[string]interface{}) {
+	t.Helper()
+	r := bufio.NewReader(conn)
+	write := func(v interface{}) {
+		body, _ := json.Marshal(v)
+		fmt.Fprintf(conn, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	go func() {
+		for {
+			msg, err := readMessage(r)
+			if err != nil {
+				return
+			}
+			switch {
+			case msg.Method == "textDocument/didOpen":
+				var p struct {
+					TextDocument struct {
+						URI string `json:"uri"`
+					} `json:"textDocument"`
+				}
+				json.Unmarshal(msg.Params, &p)
+				write(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"method":  "textDocument/publishDiagnostics",
+					"params": map[string]interface{}{
+						"uri":         p.TextDocument.URI,
+						"diagnostics": []Diagnostic{{Severity: 1, Message: "undefined: foo"}},
+					},
+				})
+			case msg.ID != nil:
+				write(map[string]interface{}{"jsonrpc": "2.0", "id": msg.ID, "result": results[msg.Method]})
+			}
+		}
+	}()
+}
+
+func TestClient(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	os.WriteFile(path, []byte("package main\n\nfunc oldName() {}\n"), 0644)
+	uri := PathToURI(path)
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	fakeServer(t, serverConn, map[string]interface{}{
+		"initialize":              map[string]interface{}{"capabilities": map[string]interface{}{}},
+		"textDocument/hover":      map[string]interface{}{"contents": map[string]string{"kind": "markdown", "value": "func oldName()"}},
+		"textDocument/definition": []map[string]interface{}{{"targetUri": uri, "targetSelectionRange": Range{Start: Position{Line: 2, Character: 5}}}},
+		"textDocument/rename": map[string]interface{}{
+			"changes": map[string][]TextEdit{
+				uri: {{Range: Range{Start: Position{Line: 2, Character: 5}, End: Position{Line: 2, Character: 12}}, NewText: "newName"}},
+			},
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c := NewClient(clientConn)
+	if err := c.Initialize(ctx, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	diags, err := c.Diagnostics(path, 2*time.Second)
+	if err != nil || len(diags) != 1 || diags[0].Message != "undefined: foo" {
+		t.Fatalf("diagnostics = %v, %v", diags, err)
+	}
+
+	pos := Position{Line: 2, Character: 6}
+	if text, err := c.Hover(ctx, path, pos); err != nil || text != "func oldName()" {
+		t.Errorf("hover = %q, %v", text, err)
+	}
+	locs, err := c.Definition(ctx, path, pos)
+	if err != nil || len(locs) != 1 || URIToPath(locs[0].URI) != path || locs[0].Range.Start.Line != 2 {
+		t.Errorf("definition = %+v, %v", locs, err)
+	}
+
+	edit, err := c.Rename(ctx, path, pos, "newName")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := edit.FileEdits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	got, err := ApplyEdits(string(data), files[path])
+	if err != nil || got != "package main\n\nfunc newName() {}\n" {
+		t.Errorf("rename applied = %q, %v", got, err)
+	}
+}
+
+func TestApplyEditsUTF16(t *testing.T) {
+	// "é" is one UTF-16 unit but two bytes; "😀" is two units and four bytes
+	content := "s := \"é😀\" + x\n"
+	edits := []TextEdit{{Range: Range{Start: Position{Character: 13}, End: Position{Character: 14}}, NewText: "y"}}
+	got, err := ApplyEdits(content, edits)
+	if err != nil || got != "s := \"é😀\" + y\n" {
+		t.Errorf("got %q, %v", got, err)
+	}
+}
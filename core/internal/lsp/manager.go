@@ -0,0 +1,214 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// initializeTimeout bounds server startup; first runs of gopls or
+// rust-analyzer on a large workspace can take a while
+const initializeTimeout = 60 * time.Second
+
+// Server describes how to launch a language server
+type Server struct {
+	Name       string
+	Command    string
+	Args       []string
+	Extensions []string
+}
+
+// DefaultServers are the language servers tried for each file type
+var DefaultServers = []Server{
+	{Name: "gopls", Command: "gopls", Extensions: []string{".go"}},
+	{Name: "pyright", Command: "pyright-langserver", Args: []string{"--stdio"}, Extensions: []string{".py"}},
+	{Name: "typescript", Command: "typescript-language-server", Args: []string{"--stdio"},
+		Extensions: []string{".ts", ".tsx", ".mts", ".cts", ".js", ".jsx", ".mjs", ".cjs"}},
+	{Name: "rust-analyzer", Command: "rust-analyzer", Extensions: []string{".rs"}},
+}
+
+// Manager starts one language server per language for a workspace, on first
+// use, and restarts servers that exit
+type Manager struct {
+	root    string
+	servers []Server
+
+	mu      sync.Mutex
+	clients map[string]*managedClient // By server name
+}
+
+type managedClient struct {
+	*Client
+	ready chan struct{} // Closed once initialize finished
+	err   error
+}
+
+// NewManager creates a manager for the workspace root using DefaultServers
+func NewManager(root string) *Manager {
+	return &Manager{
+		root:    root,
+		servers: DefaultServers,
+		clients: make(map[string]*managedClient),
+	}
+}
+
+// serverFor returns the server handling path's extension
+func (m *Manager) serverFor(path string) (Server, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, s := range m.servers {
+		if slices.Contains(s.Extensions, ext) {
+			return s, true
+		}
+	}
+	return Server{}, false
+}
+
+// ClientFor returns a running client for path's language, starting the
+// server if needed. Callers Sync the files they query.
+func (m *Manager) ClientFor(ctx context.Context, path string) (*Client, error) {
+	server, ok := m.serverFor(path)
+	if !ok {
+		return nil, fmt.Errorf("no language server configured for %s files", filepath.Ext(path))
+	}
+
+	m.mu.Lock()
+	mc := m.clients[server.Name]
+	if mc != nil {
+		select {
+		case <-mc.ready:
+			if mc.err != nil || isDone(mc.Client) {
+				mc = nil // Failed or exited: start a new one
+			}
+		default:
+		}
+	}
+	if mc == nil {
+		mc = &managedClient{ready: make(chan struct{})}
+		m.clients[server.Name] = mc
+		go m.start(server, mc)
+	}
+	m.mu.Unlock()
+
+	select {
+	case <-mc.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if mc.err != nil {
+		return nil, mc.err
+	}
+	return mc.Client, nil
+}
+
+func (m *Manager) start(server Server, mc *managedClient) {
+	defer close(mc.ready)
+
+	bin, err := exec.LookPath(server.Command)
+	if err != nil {
+		mc.err = fmt.Errorf("%s is not installed (looked for %q in PATH)", server.Name, server.Command)
+		return
+	}
+	cmd := exec.Command(bin, server.Args...)
+	cmd.Dir = m.root
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		mc.err = err
+		return
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		mc.err = err
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		mc.err = fmt.Errorf("start %s: %w", server.Name, err)
+		return
+	}
+
+	mc.Client = NewClient(&pipeConn{stdout, stdin})
+	go func() {
+		// Reap the process once the stream ends; Wait must follow the last read
+		<-mc.Done()
+		_ = cmd.Wait()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), initializeTimeout)
+	defer cancel()
+	if err := mc.Initialize(ctx, m.root); err != nil {
+		mc.err = fmt.Errorf("%s: %w", server.Name, err)
+		_ = cmd.Process.Kill()
+	}
+}
+
+// Shutdown stops every running server
+func (m *Manager) Shutdown() {
+	m.mu.Lock()
+	clients := m.clients
+	m.clients = make(map[string]*managedClient)
+	m.mu.Unlock()
+
+	for _, mc := range clients {
+		select {
+		case <-mc.ready:
+		default:
+			continue // Still starting; its process exits with ours
+		}
+		if mc.err == nil {
+			_ = mc.Close()
+		}
+	}
+}
+
+func isDone(c *Client) bool {
+	select {
+	case <-c.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// languageID returns the LSP language identifier for path
+func languageID(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return "go"
+	case ".py":
+		return "python"
+	case ".ts", ".mts", ".cts":
+		return "typescript"
+	case ".tsx":
+		return "typescriptreact"
+	case ".jsx":
+		return "javascriptreact"
+	case ".js", ".mjs", ".cjs":
+		return "javascript"
+	case ".rs":
+		return "rust"
+	default:
+		return strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+}
+
+// pipeConn joins a process's stdout and stdin into one stream
+type pipeConn struct {
+	io.ReadCloser
+	w io.WriteCloser
+}
+
+func (p *pipeConn) Write(b []byte) (int, error) {
+	return p.w.Write(b)
+}
+
+func (p *pipeConn) Close() error {
+	werr := p.w.Close()
+	if err := p.ReadCloser.Close(); err != nil {
+		return err
+	}
+	return werr
+}
@@ -0,0 +1,217 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// The subset of the Language Server Protocol used by the core.
+// Positions are zero-based; Character counts UTF-16 code units.
+
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// locationOrLink decodes both Location and LocationLink results
+type locationOrLink struct {
+	URI                  string `json:"uri"`
+	Range                Range  `json:"range"`
+	TargetURI            string `json:"targetUri"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+func (l locationOrLink) location() Location {
+	if l.TargetURI != "" {
+		return Location{URI: l.TargetURI, Range: l.TargetSelectionRange}
+	}
+	return Location{URI: l.URI, Range: l.Range}
+}
+
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+type TextDocumentEdit struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Edits []TextEdit `json:"edits"`
+	Kind  string     `json:"kind,omitempty"` // Set on create/rename/delete operations
+}
+
+type WorkspaceEdit struct {
+	Changes         map[string][]TextEdit `json:"changes,omitempty"`
+	DocumentChanges []TextDocumentEdit    `json:"documentChanges,omitempty"`
+}
+
+// FileEdits flattens the edit into text edits per file path
+func (w *WorkspaceEdit) FileEdits() (map[string][]TextEdit, error) {
+	out := make(map[string][]TextEdit)
+	for uri, edits := range w.Changes {
+		path := URIToPath(uri)
+		out[path] = append(out[path], edits...)
+	}
+	for _, dc := range w.DocumentChanges {
+		if dc.Kind != "" {
+			return nil, fmt.Errorf("unsupported resource operation %q in workspace edit", dc.Kind)
+		}
+		path := URIToPath(dc.TextDocument.URI)
+		out[path] = append(out[path], dc.Edits...)
+	}
+	return out, nil
+}
+
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"` // 1 Error, 2 Warning, 3 Information, 4 Hint
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+}
+
+// SeverityName returns the display name for a diagnostic severity
+func SeverityName(s int) string {
+	switch s {
+	case 1:
+		return "Error"
+	case 2:
+		return "Warning"
+	case 4:
+		return "Hint"
+	default:
+		return "Information"
+	}
+}
+
+// hoverResult decodes MarkupContent, MarkedString and []MarkedString contents
+type hoverResult struct {
+	Contents json.RawMessage `json:"contents"`
+}
+
+func (h *hoverResult) text() string {
+	var markup struct {
+		Kind  string `json:"kind"`
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(h.Contents, &markup); err == nil && markup.Value != "" {
+		return markup.Value
+	}
+	var parts []json.RawMessage
+	if err := json.Unmarshal(h.Contents, &parts); err != nil {
+		parts = []json.RawMessage{h.Contents}
+	}
+	var out []string
+	for _, p := range parts {
+		var s string
+		if json.Unmarshal(p, &s) == nil {
+			out = append(out, s)
+			continue
+		}
+		var ms struct {
+			Language string `json:"language"`
+			Value    string `json:"value"`
+		}
+		if json.Unmarshal(p, &ms) == nil && ms.Value != "" {
+			out = append(out, fmt.Sprintf("```%s\n%s\n```", ms.Language, ms.Value))
+		}
+	}
+	return strings.TrimSpace(strings.Join(out, "\n\n"))
+}
+
+// PathToURI converts an absolute file path to a file:// URI
+func PathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	if runtime.GOOS == "windows" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// URIToPath converts a file:// URI to a local path
+func URIToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path)
+}
+
+// Offset converts a position to a byte offset in content, clamping to the
+// end of the line or file
+func Offset(content string, pos Position) int {
+	off := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(content[off:], '\n')
+		if i < 0 {
+			return len(content)
+		}
+		off += i + 1
+	}
+	units := 0
+	for off < len(content) && units < pos.Character {
+		r, size := utf8.DecodeRuneInString(content[off:])
+		if r == '\n' {
+			break
+		}
+		units += utf16.RuneLen(r)
+		off += size
+	}
+	return off
+}
+
+// Character returns the UTF-16 column of byteCol within line
+func Character(line string, byteCol int) int {
+	units := 0
+	for _, r := range line[:min(byteCol, len(line))] {
+		units += utf16.RuneLen(r)
+	}
+	return units
+}
+
+// ApplyEdits applies non-overlapping text edits to content
+func ApplyEdits(content string, edits []TextEdit) (string, error) {
+	type span struct {
+		start, end int
+		text       string
+	}
+	spans := make([]span, 0, len(edits))
+	for _, e := range edits {
+		spans = append(spans, span{Offset(content, e.Range.Start), Offset(content, e.Range.End), e.NewText})
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var sb strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s.start < last || s.end < s.start {
+			return "", fmt.Errorf("overlapping edits at offset %d", s.start)
+		}
+		sb.WriteString(content[last:s.start])
+		sb.WriteString(s.text)
+		last = s.end
+	}
+	sb.WriteString(content[last:])
+	return sb.String(), nil
+}
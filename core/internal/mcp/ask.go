@@ -0,0 +1,206 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/telegram"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// optionPrefix marks an answer given by pressing one of the option buttons
+const optionPrefix = "option:"
+
+// askSpec describes the answer an ask call expects
+type askSpec struct {
+	Options  []string
+	Pattern  *regexp.Regexp
+	Min, Max *float64
+	Default  string
+	Timeout  time.Duration
+}
+
+// askAnswer is the structured result of an ask call
+type askAnswer struct {
+	Answer   string   `json:"answer"`
+	Option   int      `json:"option,omitempty"` // 1-based index into options
+	Number   *float64 `json:"number,omitempty"`
+	Source   string   `json:"source"` // "user", "button" or "default"
+	TimedOut bool     `json:"timed_out,omitempty"`
+}
+
+// parseAskSpec reads the typed-answer parameters of the ask tool
+func parseAskSpec(args map[string]any) (askSpec, error) {
+	var spec askSpec
+	if raw, _ := args["options"].(string); raw != "" {
+		for _, opt := range strings.Split(raw, ",") {
+			if opt = strings.TrimSpace(opt); opt != "" {
+				spec.Options = append(spec.Options, opt)
+			}
+		}
+	}
+	if raw, _ := args["pattern"].(string); raw != "" {
+		re, err := regexp.Compile(raw)
+		if err != nil {
+			return spec, fmt.Errorf("invalid pattern: %w", err)
+		}
+		spec.Pattern = re
+	}
+	if v, ok := args["min"].(float64); ok {
+		spec.Min = &v
+	}
+	if v, ok := args["max"].(float64); ok {
+		spec.Max = &v
+	}
+	if spec.Min != nil && spec.Max != nil && *spec.Min > *spec.Max {
+		return spec, fmt.Errorf("min %g is greater than max %g", *spec.Min, *spec.Max)
+	}
+	if v, ok := args["timeout_seconds"].(float64); ok && v > 0 {
+		spec.Timeout = time.Duration(v * float64(time.Second))
+	}
+	if def, ok := args["default"].(string); ok && def != "" {
+		if _, msg := spec.check(def); msg != "" {
+			return spec, fmt.Errorf("default %q is not a valid answer: %s", def, msg)
+		}
+		spec.Default = def
+	}
+	return spec, nil
+}
+
+// check validates a reply. On success it returns the answer to report,
+// otherwise a hint for the user.
+func (spec askSpec) check(reply string) (askAnswer, string) {
+	reply = strings.TrimSpace(reply)
+	answer := askAnswer{Answer: reply, Source: "user"}
+
+	if len(spec.Options) > 0 {
+		// Button presses and option text first, then the option's number
+		index := 0
+		if n, ok := strings.CutPrefix(reply, optionPrefix); ok {
+			index, _ = strconv.Atoi(n)
+			answer.Source = "button"
+		} else {
+			for i, opt := range spec.Options {
+				if strings.EqualFold(opt, reply) {
+					index = i + 1
+					break
+				}
+			}
+			if index == 0 {
+				index, _ = strconv.Atoi(reply)
+			}
+		}
+		if index < 1 || index > len(spec.Options) {
+			return answer, "please choose one of: " + strings.Join(spec.Options, ", ")
+		}
+		answer.Answer = spec.Options[index-1]
+		answer.Option = index
+		return answer, ""
+	}
+
+	if spec.Min != nil || spec.Max != nil {
+		n, err := strconv.ParseFloat(strings.ReplaceAll(reply, ",", "."), 64)
+		if err != nil {
+			return answer, "please reply with a number"
+		}
+		if (spec.Min != nil && n < *spec.Min) || (spec.Max != nil && n > *spec.Max) {
+			return answer, "please reply with a number " + spec.rangeText()
+		}
+		answer.Number = &n
+	}
+
+	if spec.Pattern != nil && !spec.Pattern.MatchString(reply) {
+		return answer, fmt.Sprintf("the answer must match `%s`", spec.Pattern)
+	}
+	return answer, ""
+}
+
+func (spec askSpec) rangeText() string {
+	switch {
+	case spec.Min != nil && spec.Max != nil:
+		return fmt.Sprintf("from %g to %g", *spec.Min, *spec.Max)
+	case spec.Min != nil:
+		return fmt.Sprintf("of at least %g", *spec.Min)
+	default:
+		return fmt.Sprintf("of at most %g", *spec.Max)
+	}
+}
+
+// question renders the question with what the answer has to look like.
+// Options are listed too, since Discord and the bridge cannot show buttons.
+func (spec askSpec) question(text string) string {
+	var sb strings.Builder
+	sb.WriteString("❓ " + text)
+	if len(spec.Options) > 0 {
+		sb.WriteString("\n")
+		for i, opt := range spec.Options {
+			sb.WriteString(fmt.Sprintf("\n%d. %s", i+1, opt))
+		}
+	}
+	if spec.Min != nil || spec.Max != nil {
+		sb.WriteString("\n\nReply with a number " + spec.rangeText() + ".")
+	}
+	if spec.Default != "" && spec.Timeout > 0 {
+		sb.WriteString(fmt.Sprintf("\n\nNo answer within %s means \"%s\".", spec.Timeout.Round(time.Second), spec.Default))
+	}
+	return sb.String()
+}
+
+// buttons renders the options as inline buttons whose data reports back as
+// "option:N", prefixed by route for callbacks the server has to dispatch
+func (spec askSpec) buttons(route string) [][]telegram.ButtonConfig {
+	var rows [][]telegram.ButtonConfig
+	for i, opt := range spec.Options {
+		rows = append(rows, []telegram.ButtonConfig{
+			{Text: opt, Data: route + optionPrefix + strconv.Itoa(i+1)},
+		})
+	}
+	return rows
+}
+
+// await reads replies until one is valid, sending retry hints for the rest.
+// When the timeout passes the default, if any, is the answer.
+func (spec askSpec) await(ctx context.Context, replies <-chan string, retry func(hint string)) (*mcp.CallToolResult, error) {
+	var timeout <-chan time.Time
+	if spec.Timeout > 0 {
+		timer := time.NewTimer(spec.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout:
+			return spec.timedOut(), nil
+		case reply := <-replies:
+			answer, hint := spec.check(reply)
+			if hint == "" {
+				return answerResult(answer), nil
+			}
+			retry("⚠️ Invalid answer: " + hint)
+		}
+	}
+}
+
+// timedOut is the result when nobody answered in time
+func (spec askSpec) timedOut() *mcp.CallToolResult {
+	answer := askAnswer{Source: "default", TimedOut: true}
+	if spec.Default != "" {
+		answer, _ = spec.check(spec.Default)
+		answer.Source = "default"
+		answer.TimedOut = true
+	}
+	return answerResult(answer)
+}
+
+func answerResult(answer askAnswer) *mcp.CallToolResult {
+	data, _ := json.Marshal(answer)
+	return mcp.NewToolResultText(string(data))
+}
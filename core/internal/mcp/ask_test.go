@@ -0,0 +1,77 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func decodeAnswer(t *testing.T, res *mcp.CallToolResult) askAnswer {
+	t.Helper()
+	var answer askAnswer
+	if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &answer); err != nil {
+		t.Fatal(err)
+	}
+	return answer
+}
+
+func TestAskSpecCheck(t *testing.T) {
+	spec, err := parseAskSpec(map[string]any{"options": "Deploy, Roll back ,2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for reply, want := range map[string]int{"deploy": 1, "option:2": 2, "2": 3, "1": 1, "option:4": 0, "maybe": 0} {
+		answer, hint := spec.check(reply)
+		if (want == 0 && hint == "") || (want != 0 && (hint != "" || answer.Option != want)) {
+			t.Errorf("check(%q) = %+v, %q; want option %d", reply, answer, hint, want)
+		}
+	}
+
+	spec, err = parseAskSpec(map[string]any{"min": 1.0, "max": 10.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer, hint := spec.check("2,5"); hint != "" || *answer.Number != 2.5 {
+		t.Errorf("check(2,5) = %+v, %q", answer, hint)
+	}
+	for _, bad := range []string{"11", "ten"} {
+		if _, hint := spec.check(bad); hint == "" {
+			t.Errorf("check(%q) accepted", bad)
+		}
+	}
+
+	if _, err := parseAskSpec(map[string]any{"pattern": `^v\d+$`, "default": "latest"}); err == nil {
+		t.Error("default that fails the pattern was accepted")
+	}
+	if _, err := parseAskSpec(map[string]any{"min": 5.0, "max": 1.0}); err == nil {
+		t.Error("min > max was accepted")
+	}
+}
+
+func TestAskSpecAwait(t *testing.T) {
+	spec, _ := parseAskSpec(map[string]any{"pattern": `^v\d+$`, "default": "v1", "timeout_seconds": 0.05})
+
+	replies := make(chan string, 2)
+	replies <- "latest"
+	replies <- "v2"
+	var hints []string
+	res, err := spec.await(context.Background(), replies, func(hint string) { hints = append(hints, hint) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer := decodeAnswer(t, res); answer.Answer != "v2" || answer.Source != "user" || len(hints) != 1 {
+		t.Errorf("got %+v with hints %v", answer, hints)
+	}
+
+	start := time.Now()
+	res, _ = spec.await(context.Background(), make(chan string), func(string) {})
+	if answer := decodeAnswer(t, res); answer.Answer != "v1" || !answer.TimedOut || answer.Source != "default" {
+		t.Errorf("timeout gave %+v", answer)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("timeout not honoured")
+	}
+}
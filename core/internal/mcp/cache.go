@@ -0,0 +1,76 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// toolCache persists each server's tool list, so the hub can advertise tools
+// without starting the server and connect only when one is called
+type toolCache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]cachedTools
+}
+
+type cachedTools struct {
+	ConfigHash string `json:"config_hash"` // Entry is stale once the server's config changes
+	catalog
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newToolCache(path string) *toolCache {
+	c := &toolCache{path: path, entries: make(map[string]cachedTools)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		fmt.Printf("Warning: Ignoring unreadable MCP tool cache %s: %v\n", path, err)
+		c.entries = make(map[string]cachedTools)
+	}
+	return c
+}
+
+// get returns the cached catalog of a server if it matches its current config
+func (c *toolCache) get(name string, config McpServerConfig) (catalog, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[name]
+	if !ok || entry.ConfigHash != configHash(config) {
+		return catalog{}, false
+	}
+	return entry.catalog, true
+}
+
+// put records a server's catalog and writes the cache to disk
+func (c *toolCache) put(name string, config McpServerConfig, cat catalog) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[name] = cachedTools{ConfigHash: configHash(config), catalog: cat, UpdatedAt: time.Now()}
+
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		fmt.Printf("Warning: Failed to encode MCP tool cache: %v\n", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		fmt.Printf("Warning: Failed to write MCP tool cache: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		fmt.Printf("Warning: Failed to write MCP tool cache: %v\n", err)
+	}
+}
+
+func configHash(config McpServerConfig) string {
+	data, _ := json.Marshal(config)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
@@ -1,15 +1,89 @@
 package mcp
 
+import (
+	"os"
+	"time"
+)
+
+// defaultIdleTimeout is how long an unused server stays connected
+const defaultIdleTimeout = 10 * time.Minute
+
 // McpSettings represents the root of mcp_settings.json
 type McpSettings struct {
 	McpServers map[string]McpServerConfig `json:"mcpServers"`
 }
 
+// Transport types for McpServerConfig.Type
+const (
+	TransportStdio = "stdio"
+	TransportHTTP  = "http" // Streamable HTTP
+	TransportSSE   = "sse"
+)
+
 // McpServerConfig represents the configuration for a single MCP server
 type McpServerConfig struct {
-	Command     string            `json:"command"`
-	Args        []string          `json:"args"`
+	Type        string            `json:"type,omitempty"` // "stdio", "http" or "sse"; defaults to "http" when url is set
+	Command     string            `json:"command,omitempty"`
+	Args        []string          `json:"args,omitempty"`
 	Env         map[string]string `json:"env,omitempty"`
+	URL         string            `json:"url,omitempty"`         // Endpoint of a remote server
+	Headers     map[string]string `json:"headers,omitempty"`     // Sent with every request; $VAR and ${VAR} are expanded
+	OAuth       *OAuthConfig      `json:"oauth,omitempty"`       // Authorization-code flow for remote servers; see /mcp login
+	IdleTimeout int               `json:"idleTimeout,omitempty"` // Seconds without calls before disconnecting; 0 means 10 minutes, -1 never
 	Disabled    bool              `json:"disabled,omitempty"`
 	AutoApprove []string          `json:"autoApprove,omitempty"`
+	ToolAliases map[string]string `json:"toolAliases,omitempty"` // Tool name -> name exposed to the model instead of server__tool
+}
+
+// Transport returns the effective transport type
+func (c McpServerConfig) Transport() string {
+	switch c.Type {
+	case TransportHTTP, TransportSSE, TransportStdio:
+		return c.Type
+	case "streamable-http", "streamableHttp":
+		return TransportHTTP
+	}
+	if c.URL != "" && c.Command == "" {
+		return TransportHTTP
+	}
+	return TransportStdio
+}
+
+// idleTimeout returns how long the server may sit unused before the hub
+// disconnects it; zero or less keeps it connected
+func (c McpServerConfig) idleTimeout() time.Duration {
+	switch {
+	case c.IdleTimeout < 0:
+		return 0
+	case c.IdleTimeout == 0:
+		return defaultIdleTimeout
+	}
+	return time.Duration(c.IdleTimeout) * time.Second
+}
+
+// Remote reports whether the server is reached over the network
+func (c McpServerConfig) Remote() bool {
+	return c.Transport() != TransportStdio
+}
+
+// expandedHeaders returns Headers with environment references resolved, so
+// tokens can stay out of the settings file
+func (c McpServerConfig) expandedHeaders() map[string]string {
+	if len(c.Headers) == 0 {
+		return nil
+	}
+	headers := make(map[string]string, len(c.Headers))
+	for k, v := range c.Headers {
+		headers[k] = os.ExpandEnv(v)
+	}
+	return headers
+}
+
+// envList returns Env as KEY=value pairs for the server process
+func (c McpServerConfig) envList() []string {
+	var env []string
+	for k, v := range c.Env {
+		env = append(env, k+"="+os.ExpandEnv(v))
+	}
+	return env
 }
@@ -3,23 +3,65 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
+	"github.com/igoryan-dao/ricochet/internal/paths"
 	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
+// Reconnect backoff for remote servers: 1s, 2s, 4s, ... capped at 2 minutes
+const (
+	reconnectBaseDelay = time.Second
+	reconnectMaxDelay  = 2 * time.Minute
+	connectTimeout     = 30 * time.Second
+)
+
 // Hub manages connections to multiple MCP servers
 type Hub struct {
 	connections map[string]*McpConnection
 	mu          sync.RWMutex
 	configDir   string
-	lastModTime time.Time
+	settings    []string          // Global, then workspace mcp_settings.json; later files win
+	lastLoaded  string            // Modification times of settings at the last load
+	failures    map[string]string // Last connection error per server
+	configs     map[string]McpServerConfig
+	attempts    map[string]int       // Consecutive failed reconnects per remote server
+	retryAt     map[string]time.Time // Scheduled reconnect per remote server
+	known       map[string]catalog   // What each server offers, connected or not
+	lastUsed    map[string]time.Time
+	inFlight    map[string]int         // Running tool calls per server
+	dialMu      map[string]*sync.Mutex // Serializes lazy connects per server
+	index       map[string]toolRef     // Exposed tool name -> server and tool
+	conflicts   map[string][]string    // Tools dropped per server because their name was taken
+	reserved    map[string]bool        // Names MCP tools may not take, e.g. built-in tools
+	cache       *toolCache
+	oauth       *McpOAuthManager // nil when mcp_tokens.json is unreadable
+}
+
+// ServerStatus describes the connection state of a single MCP server
+type ServerStatus struct {
+	Name      string     `json:"name"`
+	Transport string     `json:"transport"`
+	State     string     `json:"state"` // "connected", "idle" (tools cached, connects on first call), "connecting" or "error"
+	Connected bool       `json:"connected"`
+	Tools     int        `json:"tools"`
+	Error     string     `json:"error,omitempty"`
+	Attempts  int        `json:"attempts,omitempty"`   // Failed reconnects since the last success
+	NextRetry *time.Time `json:"next_retry,omitempty"` // When the next reconnect is due
+	LastUsed  *time.Time `json:"last_used,omitempty"`
+	Conflicts []string   `json:"conflicts,omitempty"` // Tools not registered because another server took the name
 }
 
 // McpConnection represents an active connection to an MCP server
@@ -35,6 +77,25 @@ func NewHub(configDir string) *Hub {
 	h := &Hub{
 		connections: make(map[string]*McpConnection),
 		configDir:   configDir,
+		settings: []string{
+			filepath.Join(paths.GetGlobalDir(), "mcp_settings.json"),
+			filepath.Join(configDir, "mcp_settings.json"),
+		},
+		failures: make(map[string]string),
+		configs:  make(map[string]McpServerConfig),
+		attempts: make(map[string]int),
+		retryAt:  make(map[string]time.Time),
+		known:    make(map[string]catalog),
+		lastUsed: make(map[string]time.Time),
+		inFlight: make(map[string]int),
+		dialMu:   make(map[string]*sync.Mutex),
+		reserved: make(map[string]bool),
+		cache:    newToolCache(filepath.Join(paths.GetGlobalDir(), "mcp_tools_cache.json")),
+	}
+	if oauth, err := NewMcpOAuthManager(); err != nil {
+		fmt.Printf("Warning: MCP OAuth unavailable: %v\n", err)
+	} else {
+		h.oauth = oauth
 	}
 	h.StartWatcher()
 	return h
@@ -45,117 +106,251 @@ func (h *Hub) StartWatcher() {
 		ticker := time.NewTicker(3 * time.Second)
 		defer ticker.Stop()
 
-		settingsPath := filepath.Join(h.configDir, "mcp_settings.json")
-
-		// Initial wait for file to exist or just load if exists
-		// We'll trust the loop to pick it up or load initially if exists
-		if _, err := os.Stat(settingsPath); err == nil {
-			h.LoadFromSettings(settingsPath)
-		}
-
+		h.reloadIfChanged()
 		for range ticker.C {
-			info, err := os.Stat(settingsPath)
-			if err != nil {
-				continue
-			}
-
-			if info.ModTime().After(h.lastModTime) {
-				// File changed, reload
-				h.LoadFromSettings(settingsPath)
-			}
+			h.reloadIfChanged()
+			h.disconnectIdle()
 		}
 	}()
 }
 
-func (h *Hub) LoadFromSettings(path string) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		fmt.Printf("Warning: Failed to read %s: %v\n", path, err)
-		return
+// reloadIfChanged reloads when a settings file appeared, changed or vanished
+func (h *Hub) reloadIfChanged() {
+	var stamp strings.Builder
+	for _, path := range h.settings {
+		if info, err := os.Stat(path); err == nil {
+			fmt.Fprintf(&stamp, "%s@%d;", path, info.ModTime().UnixNano())
+		}
 	}
-
-	var settings McpSettings
-	if err := json.Unmarshal(data, &settings); err != nil {
-		fmt.Printf("Error parsing mcp_settings.json: %v\n", err)
-		return
+	h.mu.Lock()
+	changed := stamp.String() != h.lastLoaded
+	h.lastLoaded = stamp.String()
+	h.mu.Unlock()
+	if changed {
+		h.Reload()
 	}
+}
 
-	// Update lastModTime immediately to avoid double loading
-	info, _ := os.Stat(path)
-	if info != nil {
-		h.lastModTime = info.ModTime()
+// Reload re-reads the global and workspace settings and connects, updates or
+// drops servers to match. Workspace entries override global ones by name.
+func (h *Hub) Reload() {
+	merged := McpSettings{McpServers: make(map[string]McpServerConfig)}
+	for _, path := range h.settings {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				fmt.Printf("Warning: Failed to read %s: %v\n", path, err)
+			}
+			continue
+		}
+		var settings McpSettings
+		if err := json.Unmarshal(data, &settings); err != nil {
+			fmt.Printf("Error parsing %s: %v\n", path, err)
+			return // Keep the current servers rather than dropping them over a typo
+		}
+		for name, config := range settings.McpServers {
+			merged.McpServers[name] = config
+		}
 	}
+	h.LoadSettings(merged)
+}
 
+// LoadSettings applies a set of servers to the hub
+func (h *Hub) LoadSettings(settings McpSettings) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
 	// 1. Identify removed servers
-	for name, conn := range h.connections {
+	for name := range h.configs {
 		if _, exists := settings.McpServers[name]; !exists {
 			fmt.Printf("Removing MCP server: %s\n", name)
-			conn.Client.Close()
-			delete(h.connections, name)
+			h.forget(name)
 		}
 	}
 
 	// 2. Add/Update servers
 	for name, config := range settings.McpServers {
 		if config.Disabled {
-			if conn, exists := h.connections[name]; exists {
+			if _, exists := h.configs[name]; exists {
 				fmt.Printf("Disabling MCP server: %s\n", name)
-				conn.Client.Close()
-				delete(h.connections, name)
+				h.forget(name)
 			}
 			continue
 		}
 
-		if _, exists := h.connections[name]; !exists {
-			// New connection
-			// We launch a goroutine to connect to avoid blocking the hub lock for too long,
-			// BUT we are holding the lock right now.
-			// Ideally we should gather new configs and connect outside lock.
-			// For simplicity in MVP, we connect synchronously or strictly assume fast startup.
-			// Startups are NOT fast (process spawn).
-			// So we should do it outside lock.
+		if old, exists := h.configs[name]; exists && !reflect.DeepEqual(old, config) {
+			fmt.Printf("Reconnecting changed MCP server: %s\n", name)
+			h.forget(name)
+		}
+		h.configs[name] = config
 
-			// Strategy: Unlock, Connect, Lock, Assign.
-			// But that's complicated with loop.
-			// Simplest: Just use a separate goroutine for each connection attempt.
+		_, connected := h.connections[name]
+		_, retryPending := h.retryAt[name]
+		_, known := h.known[name]
+		if connected || retryPending || known {
+			continue
+		}
 
-			go h.connectAsync(name, config)
-		} else {
-			// TODO: Check if config changed and reconnect?
-			// Ignoring update for existing connections for now.
+		// Servers with cached tools connect on their first call
+		if cat, ok := h.cache.get(name, config); ok {
+			h.known[name] = cat
+			h.reindex()
+			continue
 		}
+
+		// Otherwise connect now to learn the tools; the idle timeout
+		// disconnects it again if nothing is called.
+		// Connecting happens in a goroutine: process spawns are slow and we
+		// hold the hub lock here.
+		go h.connectAsync(name, config)
 	}
 }
 
+// forget closes and drops everything known about a server. Caller holds h.mu.
+func (h *Hub) forget(name string) {
+	if conn, ok := h.connections[name]; ok {
+		conn.Client.Close()
+		delete(h.connections, name)
+	}
+	delete(h.configs, name)
+	delete(h.failures, name)
+	delete(h.attempts, name)
+	delete(h.retryAt, name)
+	delete(h.known, name)
+	delete(h.lastUsed, name)
+	h.reindex()
+}
+
 func (h *Hub) connectAsync(name string, config McpServerConfig) {
-	fmt.Printf("Connecting to MCP server: %s\n", name)
+	fmt.Printf("Connecting to MCP server: %s (%s)\n", name, config.Transport())
 	if err := h.connectInternal(context.Background(), name, config); err != nil {
 		fmt.Printf("Failed to connect %s: %v\n", name, err)
+		h.mu.Lock()
+		h.failures[name] = err.Error()
+		h.mu.Unlock()
+		// Retrying cannot help until the user logs in
+		if config.Remote() && !errors.Is(err, ErrAuthRequired) {
+			h.scheduleReconnect(name, config)
+		}
 	} else {
 		fmt.Printf("Connected to MCP server: %s\n", name)
 	}
 }
 
-// Connect establishes a connection to an MCP server via Stdio (Public API)
+// scheduleReconnect retries a remote server with exponential backoff
+func (h *Hub) scheduleReconnect(name string, config McpServerConfig) {
+	h.mu.Lock()
+	if current, ok := h.configs[name]; !ok || !reflect.DeepEqual(current, config) {
+		h.mu.Unlock()
+		return // Removed or replaced meanwhile
+	}
+	if _, pending := h.retryAt[name]; pending {
+		h.mu.Unlock()
+		return
+	}
+	delay := reconnectBaseDelay << h.attempts[name]
+	if delay > reconnectMaxDelay || delay <= 0 {
+		delay = reconnectMaxDelay
+	}
+	h.attempts[name]++
+	h.retryAt[name] = time.Now().Add(delay)
+	h.mu.Unlock()
+
+	time.AfterFunc(delay, func() {
+		h.mu.Lock()
+		current, ok := h.configs[name]
+		_, connected := h.connections[name]
+		delete(h.retryAt, name)
+		h.mu.Unlock()
+		if !ok || connected || !reflect.DeepEqual(current, config) {
+			return
+		}
+		h.connectAsync(name, config)
+	})
+}
+
+// onConnectionLost drops a remote connection that went away and starts reconnecting
+func (h *Hub) onConnectionLost(name string, c *client.Client, err error) {
+	h.mu.Lock()
+	conn, ok := h.connections[name]
+	if !ok || conn.Client != c {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.connections, name)
+	h.failures[name] = fmt.Sprintf("connection lost: %v", err)
+	config := h.configs[name]
+	h.mu.Unlock()
+
+	fmt.Printf("Lost connection to MCP server %s: %v\n", name, err)
+	c.Close()
+	h.scheduleReconnect(name, config)
+}
+
+// Connect establishes a connection to an MCP server (Public API)
 func (h *Hub) Connect(ctx context.Context, name string, config McpServerConfig) error {
 	return h.connectInternal(ctx, name, config)
 }
 
-func (h *Hub) connectInternal(ctx context.Context, name string, config McpServerConfig) error {
-	// 1. Create Client (Stdio)
-	// NewStdioMCPClient(command string, args []string) based on my fix
-	mcpClient, err := client.NewStdioMCPClient(config.Command, config.Args)
+// newClient creates and starts a client for the configured transport. auth,
+// if set, adds per-request headers to remote transports.
+func newClient(ctx context.Context, config McpServerConfig, auth transport.HTTPHeaderFunc) (*client.Client, error) {
+	var mcpClient *client.Client
+	var err error
+	switch config.Transport() {
+	case TransportHTTP:
+		opts := []transport.StreamableHTTPCOption{
+			transport.WithHTTPHeaders(config.expandedHeaders()),
+			transport.WithHTTPBasicClient(httpclient.Client(0)),
+			transport.WithContinuousListening(),
+		}
+		if auth != nil {
+			opts = append(opts, transport.WithHTTPHeaderFunc(auth))
+		}
+		mcpClient, err = client.NewStreamableHttpClient(os.ExpandEnv(config.URL), opts...)
+	case TransportSSE:
+		opts := []transport.ClientOption{
+			client.WithHeaders(config.expandedHeaders()),
+			client.WithHTTPClient(httpclient.Client(0)),
+		}
+		if auth != nil {
+			opts = append(opts, client.WithHeaderFunc(auth))
+		}
+		mcpClient, err = client.NewSSEMCPClient(os.ExpandEnv(config.URL), opts...)
+	default:
+		mcpClient, err = client.NewStdioMCPClient(config.Command, config.envList(), config.Args...)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create MCP client for %s: %w", name, err)
+		return nil, err
 	}
 
-	// 2. Start (Launch process)
+	// Launch the process or open the event stream
 	if err := mcpClient.Start(ctx); err != nil {
-		return fmt.Errorf("failed to start MCP client for %s: %w", name, err)
+		mcpClient.Close()
+		return nil, err
 	}
+	return mcpClient, nil
+}
+
+// catalog is what a server offers
+type catalog struct {
+	Tools     []mcp.Tool     `json:"tools"`
+	Resources []mcp.Resource `json:"resources,omitempty"`
+	Prompts   []mcp.Prompt   `json:"prompts,omitempty"`
+}
+
+// dial starts a client, performs the handshake and fetches the server's tools,
+// resources and prompts
+func dial(ctx context.Context, config McpServerConfig, auth transport.HTTPHeaderFunc) (*client.Client, catalog, error) {
+	// 1. Create and start the client
+	mcpClient, err := newClient(ctx, config, auth)
+	if err != nil {
+		return nil, catalog{}, fmt.Errorf("failed to start: %w", err)
+	}
+
+	// 2. Handshake; the client itself lives on ctx, so only bound the handshake
+	ctxInit, cancelInit := context.WithTimeout(ctx, connectTimeout)
+	defer cancelInit()
 
 	// 3. Initialize
 	initReq := mcp.InitializeRequest{}
@@ -166,83 +361,438 @@ func (h *Hub) connectInternal(ctx context.Context, name string, config McpServer
 		Version: "1.0.0",
 	}
 
-	_, err = mcpClient.Initialize(ctx, initReq)
+	initResult, err := mcpClient.Initialize(ctxInit, initReq)
 	if err != nil {
-		return fmt.Errorf("failed to initialize MCP client for %s: %w", name, err)
+		mcpClient.Close()
+		return nil, catalog{}, fmt.Errorf("failed to initialize: %w", err)
 	}
 
-	// 4. Fetch Tools (with timeout)
-	ctxTools, cancel := context.WithTimeout(ctx, 5*time.Second)
+	// 4. Fetch Tools, Resources and Prompts (with timeout)
+	ctxList, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	listToolsResult, err := mcpClient.ListTools(ctxTools, mcp.ListToolsRequest{})
+	cat := catalog{Tools: []mcp.Tool{}}
+	if listToolsResult, _ := mcpClient.ListTools(ctxList, mcp.ListToolsRequest{}); listToolsResult != nil {
+		cat.Tools = listToolsResult.Tools
+	}
+	if initResult.Capabilities.Resources != nil {
+		if res, err := mcpClient.ListResources(ctxList, mcp.ListResourcesRequest{}); err == nil {
+			cat.Resources = res.Resources
+		}
+	}
+	if initResult.Capabilities.Prompts != nil {
+		if res, err := mcpClient.ListPrompts(ctxList, mcp.ListPromptsRequest{}); err == nil {
+			cat.Prompts = res.Prompts
+		}
+	}
+	return mcpClient, cat, nil
+}
 
-	tools := []mcp.Tool{}
-	if listToolsResult != nil {
-		tools = listToolsResult.Tools
+func (h *Hub) connectInternal(ctx context.Context, name string, config McpServerConfig) error {
+	auth, err := h.authHeaders(name, config)
+	if err != nil {
+		return fmt.Errorf("MCP server %s: %w", name, err)
+	}
+	mcpClient, cat, err := dial(ctx, config, auth)
+	if err != nil {
+		return fmt.Errorf("MCP server %s: %w", name, err)
 	}
 
 	conn := &McpConnection{
 		Name:   name,
 		Client: mcpClient,
-		Tools:  tools,
+		Tools:  cat.Tools,
+	}
+
+	if config.Remote() {
+		mcpClient.OnConnectionLost(func(err error) {
+			go h.onConnectionLost(name, mcpClient, err)
+		})
 	}
 
 	h.mu.Lock()
-	defer h.mu.Unlock()
+	if old, ok := h.connections[name]; ok {
+		old.Client.Close()
+	}
 	h.connections[name] = conn
+	h.configs[name] = config
+	h.known[name] = cat
+	h.reindex()
+	h.lastUsed[name] = time.Now()
+	delete(h.failures, name)
+	delete(h.attempts, name)
+	h.mu.Unlock()
+
+	h.cache.put(name, config, cat)
 	return nil
 }
 
-// GetTools returns a flat list of all tools from all servers
-func (h *Hub) GetTools() []mcp.Tool {
+// ensureConnected returns the connection to a server, connecting it first if
+// it is idle
+func (h *Hub) ensureConnected(name string) (*McpConnection, error) {
+	h.mu.Lock()
+	lock, ok := h.dialMu[name]
+	if !ok {
+		lock = &sync.Mutex{}
+		h.dialMu[name] = lock
+	}
+	h.mu.Unlock()
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	h.mu.RLock()
+	conn := h.connections[name]
+	config, configured := h.configs[name]
+	h.mu.RUnlock()
+	if conn != nil {
+		return conn, nil
+	}
+	if !configured {
+		return nil, fmt.Errorf("MCP server %s is not configured", name)
+	}
+
+	fmt.Printf("Connecting to idle MCP server: %s\n", name)
+	// The connection outlives this call, so it is not bound to the caller's context
+	if err := h.connectInternal(context.Background(), name, config); err != nil {
+		h.mu.Lock()
+		h.failures[name] = err.Error()
+		h.mu.Unlock()
+		return nil, err
+	}
+
 	h.mu.RLock()
 	defer h.mu.RUnlock()
+	return h.connections[name], nil
+}
 
-	var allTools []mcp.Tool
-	for _, conn := range h.connections {
-		for _, tool := range conn.Tools {
-			allTools = append(allTools, tool)
+// disconnectIdle closes connections unused for longer than their idle timeout.
+// Their tools stay known and the next call reconnects.
+func (h *Hub) disconnectIdle() {
+	now := time.Now()
+	var idle []*McpConnection
+
+	h.mu.Lock()
+	for name, conn := range h.connections {
+		timeout := h.configs[name].idleTimeout()
+		if timeout <= 0 || h.inFlight[name] > 0 || now.Sub(h.lastUsed[name]) < timeout {
+			continue
 		}
+		delete(h.connections, name)
+		idle = append(idle, conn)
+	}
+	h.mu.Unlock()
+
+	for _, conn := range idle {
+		fmt.Printf("Disconnecting idle MCP server: %s\n", conn.Name)
+		conn.Client.Close()
 	}
-	return allTools
 }
 
-// CallTool executes a tool on the appropriate server
-func (h *Hub) CallTool(ctx context.Context, name string, args map[string]interface{}) (*mcp.CallToolResult, error) {
+// Status reports every configured server with its connection state
+func (h *Hub) Status() []ServerStatus {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	// Find server with this tool
-	var targetConn *McpConnection
-	for _, conn := range h.connections {
-		for _, tool := range conn.Tools {
-			if tool.Name == name {
-				targetConn = conn
+	names := make(map[string]bool)
+	for name := range h.configs {
+		names[name] = true
+	}
+	for name := range h.failures {
+		names[name] = true
+	}
+
+	var statuses []ServerStatus
+	for name := range names {
+		status := ServerStatus{
+			Name:      name,
+			Transport: h.configs[name].Transport(),
+			Tools:     len(h.known[name].Tools),
+			Attempts:  h.attempts[name],
+		}
+		if conn, ok := h.connections[name]; ok {
+			status.State = "connected"
+			status.Connected = true
+			status.Tools = len(conn.Tools)
+			status.Attempts = 0
+		} else if errMsg, ok := h.failures[name]; ok {
+			status.State = "error"
+			status.Error = errMsg
+		} else if _, ok := h.known[name]; ok {
+			status.State = "idle"
+		} else {
+			status.State = "connecting"
+		}
+		if at, ok := h.retryAt[name]; ok {
+			status.NextRetry = &at
+		}
+		if at, ok := h.lastUsed[name]; ok {
+			status.LastUsed = &at
+		}
+		status.Conflicts = h.conflicts[name]
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// GetTools returns the tools of all servers, including idle ones whose tools
+// come from the schema cache, under their exposed server__tool or alias names
+func (h *Hub) GetTools() []mcp.Tool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	names := make([]string, 0, len(h.index))
+	for name := range h.index {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	allTools := make([]mcp.Tool, 0, len(names))
+	for _, name := range names {
+		ref := h.index[name]
+		for _, tool := range h.known[ref.Server].Tools {
+			if tool.Name == ref.Tool {
+				tool.Name = name
+				allTools = append(allTools, tool)
 				break
 			}
 		}
-		if targetConn != nil {
-			break
-		}
 	}
+	return allTools
+}
 
-	if targetConn == nil {
+// ResolveTool maps an exposed tool name to its server and the server's own
+// name for the tool
+func (h *Hub) ResolveTool(name string) (server, tool string, ok bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	ref, ok := h.index[name]
+	return ref.Server, ref.Tool, ok
+}
+
+// CallTool executes a tool on the appropriate server, connecting it first if
+// it is idle
+func (h *Hub) CallTool(ctx context.Context, name string, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	server, tool, ok := h.ResolveTool(name)
+	if !ok {
 		return nil, fmt.Errorf("tool not found: %s", name)
 	}
 
+	targetConn, release, err := h.acquire(server)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// Calculate timeout (default 60s)
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
 	return targetConn.Client.CallTool(ctxWithTimeout, mcp.CallToolRequest{
 		Params: mcp.CallToolParams{
-			Name:      name,
+			Name:      tool,
 			Arguments: args,
 		},
 	})
 }
 
+// acquire connects a server if needed and marks it busy until release is
+// called, so the idle check cannot close it mid-request
+func (h *Hub) acquire(server string) (*McpConnection, func(), error) {
+	h.mu.Lock()
+	h.inFlight[server]++
+	h.mu.Unlock()
+	release := func() {
+		h.mu.Lock()
+		h.inFlight[server]--
+		h.lastUsed[server] = time.Now()
+		h.mu.Unlock()
+	}
+
+	conn, err := h.ensureConnected(server)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	return conn, release, nil
+}
+
+// ServerResource is a resource together with the server that provides it
+type ServerResource struct {
+	Server string `json:"server"`
+	mcp.Resource
+}
+
+// ServerPrompt is a prompt together with the server that provides it
+type ServerPrompt struct {
+	Server string `json:"server"`
+	mcp.Prompt
+}
+
+// Resources lists the resources of all servers, sorted by server and URI
+func (h *Hub) Resources() []ServerResource {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var all []ServerResource
+	for server, cat := range h.known {
+		for _, r := range cat.Resources {
+			all = append(all, ServerResource{Server: server, Resource: r})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Server != all[j].Server {
+			return all[i].Server < all[j].Server
+		}
+		return all[i].URI < all[j].URI
+	})
+	return all
+}
+
+// Prompts lists the prompts of all servers, sorted by server and name
+func (h *Hub) Prompts() []ServerPrompt {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var all []ServerPrompt
+	for server, cat := range h.known {
+		for _, p := range cat.Prompts {
+			all = append(all, ServerPrompt{Server: server, Prompt: p})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Server != all[j].Server {
+			return all[i].Server < all[j].Server
+		}
+		return all[i].Name < all[j].Name
+	})
+	return all
+}
+
+// ReadResource reads a resource. server may be empty, in which case the
+// server listing the URI is used.
+func (h *Hub) ReadResource(ctx context.Context, server, uri string) (*mcp.ReadResourceResult, error) {
+	if server == "" {
+		for _, r := range h.Resources() {
+			if r.URI == uri {
+				server = r.Server
+				break
+			}
+		}
+		if server == "" {
+			return nil, fmt.Errorf("no MCP server lists resource %s; pass the server name", uri)
+		}
+	}
+
+	conn, release, err := h.acquire(server)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+	req := mcp.ReadResourceRequest{}
+	req.Params.URI = uri
+	return conn.Client.ReadResource(ctx, req)
+}
+
+// GetPrompt renders a server's prompt with the given arguments
+func (h *Hub) GetPrompt(ctx context.Context, server, name string, args map[string]string) (*mcp.GetPromptResult, error) {
+	conn, release, err := h.acquire(server)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+	req := mcp.GetPromptRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	return conn.Client.GetPrompt(ctx, req)
+}
+
+// SetSecretStore keeps OAuth refresh tokens in store instead of mcp_tokens.json
+func (h *Hub) SetSecretStore(store SecretStore) {
+	if h.oauth != nil {
+		h.oauth.SetSecretStore(store)
+	}
+}
+
+// authHeaders returns the bearer token header func for OAuth servers, or nil
+// for servers without OAuth. It fails fast when the user has not logged in.
+func (h *Hub) authHeaders(name string, config McpServerConfig) (transport.HTTPHeaderFunc, error) {
+	if config.OAuth == nil || !config.Remote() {
+		return nil, nil
+	}
+	if h.oauth == nil {
+		return nil, fmt.Errorf("%w: OAuth token storage is unavailable", ErrAuthRequired)
+	}
+	if h.oauth.NeedsAuth(name) {
+		return nil, fmt.Errorf("%w: run /mcp login %s", ErrAuthRequired, name)
+	}
+
+	serverURL := os.ExpandEnv(config.URL)
+	oauth := *config.OAuth
+	return func(ctx context.Context) map[string]string {
+		token, err := h.oauth.AccessToken(ctx, name, serverURL, oauth)
+		if err != nil {
+			fmt.Printf("MCP server %s: %v\n", name, err)
+			return nil // The server answers 401 and the call fails with it
+		}
+		return map[string]string{"Authorization": "Bearer " + token}
+	}, nil
+}
+
+// Login runs the OAuth authorization-code flow for a server and connects it.
+// open receives the URL the user has to visit.
+func (h *Hub) Login(ctx context.Context, name string, open func(authURL string)) error {
+	h.mu.RLock()
+	config, ok := h.configs[name]
+	h.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("MCP server %s is not configured", name)
+	}
+	if config.OAuth == nil || !config.Remote() {
+		return fmt.Errorf("MCP server %s has no oauth settings", name)
+	}
+	if h.oauth == nil {
+		return fmt.Errorf("OAuth token storage is unavailable")
+	}
+
+	if _, err := h.oauth.Authorize(ctx, name, os.ExpandEnv(config.URL), *config.OAuth, open); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	delete(h.failures, name)
+	delete(h.attempts, name)
+	h.mu.Unlock()
+	// The connection outlives this call, so it is not bound to the caller's context
+	return h.connectInternal(context.Background(), name, config)
+}
+
+// Logout forgets a server's tokens and disconnects it
+func (h *Hub) Logout(name string) error {
+	if h.oauth == nil {
+		return fmt.Errorf("OAuth token storage is unavailable")
+	}
+	if err := h.oauth.RemoveToken(name); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	conn := h.connections[name]
+	delete(h.connections, name)
+	h.mu.Unlock()
+	if conn != nil {
+		conn.Client.Close()
+	}
+	return nil
+}
+
 // Close closes all connections
 func (h *Hub) Close() error {
 	h.mu.Lock()
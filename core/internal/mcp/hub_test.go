@@ -0,0 +1,201 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestTransportDefaults(t *testing.T) {
+	cases := []struct {
+		config McpServerConfig
+		want   string
+	}{
+		{McpServerConfig{Command: "npx"}, TransportStdio},
+		{McpServerConfig{URL: "https://example.com/mcp"}, TransportHTTP},
+		{McpServerConfig{Type: "sse", URL: "https://example.com/sse"}, TransportSSE},
+		{McpServerConfig{Type: "streamable-http", URL: "https://example.com/mcp"}, TransportHTTP},
+	}
+	for _, c := range cases {
+		if got := c.config.Transport(); got != c.want {
+			t.Errorf("Transport(%+v) = %q, want %q", c.config, got, c.want)
+		}
+	}
+}
+
+func TestConnectStreamableHTTPWithHeaders(t *testing.T) {
+	s := server.NewMCPServer("remote", "1.0.0", server.WithToolCapabilities(false))
+	s.AddTool(mcp.NewTool("echo", mcp.WithString("text")), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(req.GetString("text", "")), nil
+	})
+	mcpHandler := server.NewStreamableHTTPServer(s)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mcpHandler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("REMOTE_MCP_TOKEN", "secret")
+	h := NewHub(t.TempDir())
+	defer h.Close()
+
+	err := h.Connect(context.Background(), "remote", McpServerConfig{
+		URL:     ts.URL + "/mcp",
+		Headers: map[string]string{"Authorization": "Bearer ${REMOTE_MCP_TOKEN}"},
+	})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	res, err := h.CallTool(context.Background(), "remote__echo", map[string]interface{}{"text": "hi"})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if text, ok := res.Content[0].(mcp.TextContent); !ok || text.Text != "hi" {
+		t.Errorf("unexpected result: %+v", res.Content)
+	}
+
+	status := h.Status()
+	if len(status) != 1 || !status[0].Connected || status[0].Tools != 1 || status[0].Transport != TransportHTTP {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
+
+func TestConnectRejectsMissingAuth(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	h := NewHub(t.TempDir())
+	defer h.Close()
+	if err := h.Connect(context.Background(), "remote", McpServerConfig{URL: ts.URL}); err == nil {
+		t.Fatal("expected connect to fail without credentials")
+	}
+}
+
+func TestLazyConnectFromCacheAndIdleDisconnect(t *testing.T) {
+	s := server.NewMCPServer("remote", "1.0.0", server.WithToolCapabilities(false))
+	echo := mcp.NewTool("echo", mcp.WithString("text"))
+	s.AddTool(echo, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(req.GetString("text", "")), nil
+	})
+	ts := server.NewTestStreamableHTTPServer(s)
+	defer ts.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	h := NewHub(t.TempDir())
+	defer h.Close()
+
+	config := McpServerConfig{URL: ts.URL + "/mcp", IdleTimeout: 60}
+	h.cache.put("remote", config, catalog{Tools: []mcp.Tool{echo}})
+	h.LoadSettings(McpSettings{McpServers: map[string]McpServerConfig{"remote": config}})
+
+	if status := h.Status(); len(status) != 1 || status[0].State != "idle" || status[0].Tools != 1 {
+		t.Fatalf("expected idle server with cached tools, got %+v", status)
+	}
+	if tools := h.GetTools(); len(tools) != 1 || tools[0].Name != "remote__echo" {
+		t.Fatalf("expected cached echo tool, got %+v", tools)
+	}
+
+	if _, err := h.CallTool(context.Background(), "remote__echo", map[string]interface{}{"text": "hi"}); err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if status := h.Status(); status[0].State != "connected" {
+		t.Fatalf("expected connection on first call, got %+v", status)
+	}
+
+	h.mu.Lock()
+	h.lastUsed["remote"] = time.Now().Add(-2 * time.Minute)
+	h.mu.Unlock()
+	h.disconnectIdle()
+	if status := h.Status(); status[0].State != "idle" {
+		t.Fatalf("expected idle disconnect, got %+v", status)
+	}
+}
+
+func TestResourcesAndPrompts(t *testing.T) {
+	s := server.NewMCPServer("docs", "1.0.0", server.WithResourceCapabilities(false, false), server.WithPromptCapabilities(false))
+	s.AddResource(mcp.NewResource("docs://readme", "README", mcp.WithMIMEType("text/plain")),
+		func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			return []mcp.ResourceContents{mcp.TextResourceContents{URI: req.Params.URI, MIMEType: "text/plain", Text: "hello docs"}}, nil
+		})
+	s.AddPrompt(mcp.NewPrompt("review", mcp.WithArgument("file", mcp.RequiredArgument())),
+		func(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			return mcp.NewGetPromptResult("", []mcp.PromptMessage{
+				mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent("Review "+req.Params.Arguments["file"])),
+			}), nil
+		})
+	ts := server.NewTestStreamableHTTPServer(s)
+	defer ts.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	h := NewHub(t.TempDir())
+	defer h.Close()
+	if err := h.Connect(context.Background(), "docs", McpServerConfig{URL: ts.URL + "/mcp"}); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	if res := h.Resources(); len(res) != 1 || res[0].Server != "docs" || res[0].URI != "docs://readme" {
+		t.Fatalf("unexpected resources: %+v", res)
+	}
+	read, err := h.ReadResource(context.Background(), "", "docs://readme")
+	if err != nil {
+		t.Fatalf("ReadResource: %v", err)
+	}
+	if text, ok := read.Contents[0].(mcp.TextResourceContents); !ok || text.Text != "hello docs" {
+		t.Errorf("unexpected contents: %+v", read.Contents)
+	}
+
+	if prompts := h.Prompts(); len(prompts) != 1 || prompts[0].Name != "review" {
+		t.Fatalf("unexpected prompts: %+v", prompts)
+	}
+	prompt, err := h.GetPrompt(context.Background(), "docs", "review", map[string]string{"file": "main.go"})
+	if err != nil {
+		t.Fatalf("GetPrompt: %v", err)
+	}
+	if text, ok := prompt.Messages[0].Content.(mcp.TextContent); !ok || text.Text != "Review main.go" {
+		t.Errorf("unexpected prompt: %+v", prompt.Messages)
+	}
+}
+
+func TestToolNamespacingAndCollisions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	h := NewHub(t.TempDir())
+	defer h.Close()
+	h.ReserveToolNames([]string{"read_file"})
+
+	search := mcp.NewTool("search")
+	read := mcp.NewTool("read")
+	docs := McpServerConfig{URL: "http://127.0.0.1:1/mcp", ToolAliases: map[string]string{"read": "read_file"}}
+	wiki := McpServerConfig{URL: "http://127.0.0.1:2/mcp", ToolAliases: map[string]string{"search": "docs__search"}}
+	h.cache.put("docs", docs, catalog{Tools: []mcp.Tool{search, read}})
+	h.cache.put("wiki", wiki, catalog{Tools: []mcp.Tool{search}})
+	h.LoadSettings(McpSettings{McpServers: map[string]McpServerConfig{"docs": docs, "wiki": wiki}})
+
+	tools := h.GetTools()
+	if len(tools) != 1 || tools[0].Name != "docs__search" {
+		t.Fatalf("expected only docs__search, got %+v", tools)
+	}
+	if server, tool, ok := h.ResolveTool("docs__search"); !ok || server != "docs" || tool != "search" {
+		t.Errorf("ResolveTool = %s, %s, %v", server, tool, ok)
+	}
+
+	conflicts := make(map[string]int)
+	for _, s := range h.Status() {
+		conflicts[s.Name] = len(s.Conflicts)
+	}
+	if conflicts["docs"] != 1 || conflicts["wiki"] != 1 {
+		t.Errorf("expected one rejected tool per server, got %v", conflicts)
+	}
+}
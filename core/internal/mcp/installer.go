@@ -0,0 +1,99 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// probeTimeout bounds the startup check; npx may download the package first
+const probeTimeout = 2 * time.Minute
+
+// InstallResult describes a server added by Install
+type InstallResult struct {
+	Name   string          `json:"name"`
+	Config McpServerConfig `json:"config"`
+	Tools  []string        `json:"tools"`
+}
+
+// Probe starts a server from config, lists its tools and shuts it down again
+func Probe(ctx context.Context, config McpServerConfig) ([]string, error) {
+	if !config.Remote() {
+		if _, err := exec.LookPath(config.Command); err != nil {
+			return nil, fmt.Errorf("command %q not found on PATH", config.Command)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	c, cat, err := dial(ctx, config, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.Close()
+
+	names := make([]string, 0, len(cat.Tools))
+	for _, t := range cat.Tools {
+		names = append(names, t.Name)
+	}
+	return names, nil
+}
+
+// Install configures a registry server in mgr's settings. env supplies the
+// item's required variables (falling back to the process environment) and
+// extraArgs is appended to its default arguments. The server must start and
+// answer tools/list before anything is written.
+func Install(ctx context.Context, mgr *Manager, item RegistryItem, env map[string]string, extraArgs []string) (*InstallResult, error) {
+	if item.ArgsHint != "" && len(extraArgs) == 0 {
+		return nil, fmt.Errorf("%s needs arguments: %s", item.Name, item.ArgsHint)
+	}
+
+	config := McpServerConfig{
+		Command: item.InstallCommand,
+		Args:    append(append([]string{}, item.DefaultArgs...), extraArgs...),
+	}
+	var missing []string
+	for _, key := range item.RequiredEnv {
+		value, ok := env[key]
+		if !ok {
+			if _, inEnv := os.LookupEnv(key); !inEnv {
+				missing = append(missing, key)
+				continue
+			}
+			value = "${" + key + "}" // Resolved when the server starts, keeps the secret out of the file
+		}
+		if config.Env == nil {
+			config.Env = make(map[string]string)
+		}
+		config.Env[key] = value
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s needs %s (pass KEY=value or set it in the environment)", item.Name, strings.Join(missing, ", "))
+	}
+
+	servers, err := mgr.ListServers()
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := servers[item.Name]; exists {
+		return nil, fmt.Errorf("server %q is already configured; remove it first to reinstall", item.Name)
+	}
+
+	tools, err := Probe(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("%s did not start: %w", item.Name, err)
+	}
+	if err := mgr.AddServer(item.Name, config); err != nil {
+		return nil, err
+	}
+	return &InstallResult{Name: item.Name, Config: config, Tools: tools}, nil
+}
+
+// ConfigDir returns the directory holding the manager's settings file
+func (m *Manager) ConfigDir() string {
+	return filepath.Dir(m.configPath)
+}
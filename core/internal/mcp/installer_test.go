@@ -0,0 +1,58 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestInstallChecksRequirementsBeforeWriting(t *testing.T) {
+	mgr := NewManager(t.TempDir())
+
+	cases := []struct {
+		name string
+		item RegistryItem
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{
+			name: "missing env",
+			item: RegistryItem{Name: "gh", InstallCommand: "true", RequiredEnv: []string{"TEST_MCP_MISSING_TOKEN"}},
+			want: "TEST_MCP_MISSING_TOKEN",
+		},
+		{
+			name: "missing args",
+			item: RegistryItem{Name: "pg", InstallCommand: "true", ArgsHint: "<postgres-url>"},
+			want: "<postgres-url>",
+		},
+		{
+			name: "missing binary",
+			item: RegistryItem{Name: "nope", InstallCommand: "ricochet-no-such-binary"},
+			want: "not found on PATH",
+		},
+	}
+	for _, c := range cases {
+		_, err := Install(context.Background(), mgr, c.item, c.env, c.args)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: got %v, want error containing %q", c.name, err, c.want)
+		}
+	}
+
+	servers, err := mgr.ListServers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 0 {
+		t.Errorf("failed installs must not write settings, got %v", servers)
+	}
+}
+
+func TestFindRegistryItem(t *testing.T) {
+	if item, ok := FindRegistryItem(GetBuiltInRegistry(), "GitHub"); !ok || item.Name != "github" {
+		t.Errorf("expected case-insensitive lookup of github, got %+v %v", item, ok)
+	}
+	if _, ok := FindRegistryItem(GetBuiltInRegistry(), "unknown"); ok {
+		t.Error("unexpected match for unknown server")
+	}
+}
@@ -0,0 +1,103 @@
+package mcp
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ToolSeparator joins server and tool names in the names the model sees
+const ToolSeparator = "__"
+
+// maxToolName is the longest tool name providers accept
+const maxToolName = 64
+
+// toolRef locates a tool exposed under a namespaced or aliased name
+type toolRef struct {
+	Server string
+	Tool   string
+}
+
+// QualifiedToolName is the name a server's tool is exposed under unless aliased
+func QualifiedToolName(server, tool string) string {
+	return sanitizeToolName(server + ToolSeparator + tool)
+}
+
+// sanitizeToolName keeps to the characters providers allow in tool names
+func sanitizeToolName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, name)
+	if len(name) > maxToolName {
+		name = name[:maxToolName]
+	}
+	return name
+}
+
+// exposedName is the name a tool is offered under: its alias, or server__tool
+func (c McpServerConfig) exposedName(server, tool string) string {
+	if alias := c.ToolAliases[tool]; alias != "" {
+		return sanitizeToolName(alias)
+	}
+	return QualifiedToolName(server, tool)
+}
+
+// ReserveToolNames keeps MCP tools from being exposed under names, such as
+// those of built-in tools, that something else already answers to
+func (h *Hub) ReserveToolNames(names []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	added := false
+	for _, name := range names {
+		if !h.reserved[name] {
+			h.reserved[name] = true
+			added = true
+		}
+	}
+	if added {
+		h.reindex()
+	}
+}
+
+// reindex rebuilds the exposed-name index from the known catalogs. Servers
+// are visited in name order; a tool whose name is already taken is dropped
+// and reported instead of shadowing the first. Caller holds h.mu.
+func (h *Hub) reindex() {
+	servers := make([]string, 0, len(h.known))
+	for name := range h.known {
+		servers = append(servers, name)
+	}
+	sort.Strings(servers)
+
+	index := make(map[string]toolRef)
+	conflicts := make(map[string][]string)
+	for _, server := range servers {
+		config := h.configs[server]
+		for _, tool := range h.known[server].Tools {
+			name := config.exposedName(server, tool.Name)
+			owner := ""
+			if h.reserved[name] {
+				owner = "a built-in tool"
+			} else if ref, taken := index[name]; taken {
+				owner = "server " + ref.Server
+			}
+			if owner != "" {
+				msg := fmt.Sprintf("%s: %s is taken by %s", tool.Name, name, owner)
+				conflicts[server] = append(conflicts[server], msg)
+				if !slices.Contains(h.conflicts[server], msg) {
+					fmt.Printf("Error: MCP server %s: tool %s is not registered: %q is already taken by %s. Set toolAliases to rename it.\n", server, tool.Name, name, owner)
+				}
+				continue
+			}
+			index[name] = toolRef{Server: server, Tool: tool.Name}
+		}
+	}
+	h.index = index
+	h.conflicts = conflicts
+}
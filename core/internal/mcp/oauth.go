@@ -1,55 +1,88 @@
 package mcp
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
+	"github.com/igoryan-dao/ricochet/internal/paths"
 )
 
+// tokenExpirySkew refreshes access tokens slightly before they expire
+const tokenExpirySkew = 30 * time.Second
+
+// ErrAuthRequired is returned for OAuth servers without a usable token
+var ErrAuthRequired = errors.New("authorization required")
+
+// SecretStore keeps refresh tokens out of mcp_tokens.json. config.SecretStore
+// satisfies it.
+type SecretStore interface {
+	Get(key string) (string, error)
+	Set(key, value string) error
+	Delete(key string) error
+}
+
 // OAuthToken represents a stored OAuth token
 type OAuthToken struct {
 	AccessToken  string    `json:"access_token"`
-	RefreshToken string    `json:"refresh_token,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"` // Empty on disk when it lives in the secret store
 	TokenType    string    `json:"token_type"`
 	ExpiresAt    time.Time `json:"expires_at,omitempty"`
 	Scopes       []string  `json:"scopes,omitempty"`
 }
 
-// OAuthConfig describes an OAuth2 configuration for an MCP server
+// OAuthConfig describes an OAuth2 configuration for an MCP server. AuthURL and
+// TokenURL may be omitted for servers that publish authorization server metadata.
 type OAuthConfig struct {
 	ClientID     string   `json:"client_id"`
-	ClientSecret string   `json:"client_secret,omitempty"`
-	AuthURL      string   `json:"auth_url"`
-	TokenURL     string   `json:"token_url"`
-	RedirectURL  string   `json:"redirect_url"`
-	Scopes       []string `json:"scopes"`
+	ClientSecret string   `json:"client_secret,omitempty"` // $VAR and ${VAR} are expanded
+	AuthURL      string   `json:"auth_url,omitempty"`
+	TokenURL     string   `json:"token_url,omitempty"`
+	RedirectURL  string   `json:"redirect_url,omitempty"` // Defaults to a random local port
+	Scopes       []string `json:"scopes,omitempty"`
 }
 
 // McpOAuthManager handles OAuth2 flows for MCP servers
 type McpOAuthManager struct {
-	mu     sync.RWMutex
-	tokens map[string]*OAuthToken // serverName -> token
-	path   string
+	mu      sync.RWMutex
+	tokens  map[string]*OAuthToken // serverName -> token
+	path    string
+	secrets SecretStore       // nil keeps refresh tokens in mcp_tokens.json
+	stored  map[string]string // Refresh tokens already in secrets, to skip redundant writes
+	refresh sync.Mutex        // Serializes refreshes so a rotated refresh token is used once
 }
 
 // NewMcpOAuthManager creates a new OAuth manager
 func NewMcpOAuthManager() (*McpOAuthManager, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get home dir: %w", err)
-	}
-
-	configDir := filepath.Join(homeDir, ".ricochet")
+	configDir := paths.GetGlobalDir()
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create config dir: %w", err)
 	}
+	return newOAuthManager(filepath.Join(configDir, "mcp_tokens.json"))
+}
 
+func newOAuthManager(path string) (*McpOAuthManager, error) {
 	mgr := &McpOAuthManager{
 		tokens: make(map[string]*OAuthToken),
-		path:   filepath.Join(configDir, "mcp_tokens.json"),
+		stored: make(map[string]string),
+		path:   path,
 	}
 
 	if err := mgr.Load(); err != nil {
@@ -61,6 +94,14 @@ func NewMcpOAuthManager() (*McpOAuthManager, error) {
 	return mgr, nil
 }
 
+// SetSecretStore moves refresh tokens into store; nil keeps them on disk
+func (m *McpOAuthManager) SetSecretStore(store SecretStore) {
+	m.mu.Lock()
+	m.secrets = store
+	m.stored = make(map[string]string)
+	m.mu.Unlock()
+}
+
 // Load reads tokens from disk
 func (m *McpOAuthManager) Load() error {
 	m.mu.Lock()
@@ -80,12 +121,27 @@ func (m *McpOAuthManager) Load() error {
 	return nil
 }
 
-// Save writes tokens to disk
+// Save writes tokens to disk. Refresh tokens go to the secret store when one
+// is set and accepts them.
 func (m *McpOAuthManager) Save() error {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	onDisk := make(map[string]*OAuthToken, len(m.tokens))
+	for name, token := range m.tokens {
+		t := *token
+		if t.RefreshToken != "" && m.secrets != nil {
+			if m.stored[name] == t.RefreshToken {
+				t.RefreshToken = ""
+			} else if err := m.secrets.Set(oauthSecretKey(name), t.RefreshToken); err == nil {
+				m.stored[name] = t.RefreshToken
+				t.RefreshToken = ""
+			}
+		}
+		onDisk[name] = &t
+	}
 
-	data, err := json.MarshalIndent(m.tokens, "", "  ")
+	data, err := json.MarshalIndent(onDisk, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal tokens: %w", err)
 	}
@@ -93,6 +149,11 @@ func (m *McpOAuthManager) Save() error {
 	return os.WriteFile(m.path, data, 0600) // Restrictive permissions for security
 }
 
+// oauthSecretKey is the SecretStore key for a server's refresh token
+func oauthSecretKey(serverName string) string {
+	return "mcp_oauth." + serverName
+}
+
 // GetToken retrieves a token for a server
 func (m *McpOAuthManager) GetToken(serverName string) (*OAuthToken, bool) {
 	m.mu.RLock()
@@ -104,7 +165,7 @@ func (m *McpOAuthManager) GetToken(serverName string) (*OAuthToken, bool) {
 	}
 
 	// Check if token is expired
-	if !token.ExpiresAt.IsZero() && token.ExpiresAt.Before(time.Now()) {
+	if !token.ExpiresAt.IsZero() && token.ExpiresAt.Before(time.Now().Add(tokenExpirySkew)) {
 		return nil, false // Token expired
 	}
 
@@ -124,66 +185,372 @@ func (m *McpOAuthManager) SetToken(serverName string, token *OAuthToken) error {
 func (m *McpOAuthManager) RemoveToken(serverName string) error {
 	m.mu.Lock()
 	delete(m.tokens, serverName)
+	delete(m.stored, serverName)
+	secrets := m.secrets
 	m.mu.Unlock()
 
+	if secrets != nil {
+		secrets.Delete(oauthSecretKey(serverName))
+	}
 	return m.Save()
 }
 
-// NeedsAuth checks if a server requires authentication
+// NeedsAuth reports whether the server has neither a valid access token nor a
+// refresh token to obtain one
 func (m *McpOAuthManager) NeedsAuth(serverName string) bool {
-	_, ok := m.GetToken(serverName)
-	return !ok
+	if _, ok := m.GetToken(serverName); ok {
+		return false
+	}
+	return m.refreshToken(serverName) == ""
 }
 
-// StartOAuthFlow initiates an OAuth2 authorization flow
-// Returns the authorization URL for the user to visit
-func (m *McpOAuthManager) StartOAuthFlow(serverName string, config *OAuthConfig) (string, string, error) {
-	// Generate state for CSRF protection
-	state := fmt.Sprintf("%s-%d", serverName, time.Now().UnixNano())
+// refreshToken returns the stored refresh token, from memory or the secret store
+func (m *McpOAuthManager) refreshToken(serverName string) string {
+	m.mu.RLock()
+	token, ok := m.tokens[serverName]
+	secrets := m.secrets
+	m.mu.RUnlock()
+	if !ok {
+		return ""
+	}
+	if token.RefreshToken != "" {
+		return token.RefreshToken
+	}
+	if secrets == nil {
+		return ""
+	}
+	value, err := secrets.Get(oauthSecretKey(serverName))
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// AccessToken returns a valid access token for the server, refreshing it when
+// it has expired
+func (m *McpOAuthManager) AccessToken(ctx context.Context, serverName, serverURL string, config OAuthConfig) (string, error) {
+	if token, ok := m.GetToken(serverName); ok {
+		return token.AccessToken, nil
+	}
+
+	m.refresh.Lock()
+	defer m.refresh.Unlock()
+
+	// Another caller may have refreshed while we waited
+	if token, ok := m.GetToken(serverName); ok {
+		return token.AccessToken, nil
+	}
+	token, err := m.RefreshToken(ctx, serverName, serverURL, config)
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
 
-	// Build authorization URL
-	authURL := fmt.Sprintf("%s?client_id=%s&redirect_uri=%s&response_type=code&state=%s",
-		config.AuthURL,
-		config.ClientID,
-		config.RedirectURL,
-		state,
-	)
+// Authorize runs the authorization-code flow with PKCE. It serves the redirect
+// on a local port, hands the authorization URL to open and waits until the
+// browser comes back or ctx ends.
+func (m *McpOAuthManager) Authorize(ctx context.Context, serverName, serverURL string, config OAuthConfig, open func(authURL string)) (*OAuthToken, error) {
+	endpoints, err := resolveEndpoints(ctx, serverURL, config)
+	if err != nil {
+		return nil, err
+	}
 
+	listener, redirectURL, err := listenForRedirect(config.RedirectURL)
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+
+	verifier := randomString(32)
+	state := randomString(16)
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {config.ClientID},
+		"redirect_uri":          {redirectURL.String()},
+		"state":                 {state},
+		"code_challenge":        {pkceChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
 	if len(config.Scopes) > 0 {
-		scopes := ""
-		for i, s := range config.Scopes {
-			if i > 0 {
-				scopes += " "
-			}
-			scopes += s
+		params.Set("scope", strings.Join(config.Scopes, " "))
+	}
+	if serverURL != "" {
+		params.Set("resource", serverURL)
+	}
+	authURL := endpoints.AuthURL
+	if strings.Contains(authURL, "?") {
+		authURL += "&" + params.Encode()
+	} else {
+		authURL += "?" + params.Encode()
+	}
+
+	type callback struct {
+		code string
+		err  error
+	}
+	done := make(chan callback, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(redirectURL.Path, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		// A request that is not the answer to this flow, e.g. a stale tab or
+		// another page hitting the port, is refused without ending the flow
+		if q.Get("state") != state {
+			http.Error(w, "Ricochet: unknown authorization request", http.StatusBadRequest)
+			return
+		}
+
+		var result callback
+		switch {
+		case q.Get("error") != "":
+			result.err = fmt.Errorf("authorization denied: %s %s", q.Get("error"), q.Get("error_description"))
+		case q.Get("code") == "":
+			result.err = fmt.Errorf("OAuth callback without a code")
+		default:
+			result.code = q.Get("code")
 		}
-		authURL += "&scope=" + scopes
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if result.err != nil {
+			fmt.Fprintf(w, "<html><body><h3>Ricochet: authorization failed</h3><p>%s</p></body></html>", html.EscapeString(result.err.Error()))
+		} else {
+			fmt.Fprint(w, "<html><body><h3>Ricochet: authorization complete</h3><p>You can close this window.</p></body></html>")
+		}
+		select {
+		case done <- result:
+		default:
+		}
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(listener)
+	defer srv.Close()
+
+	open(authURL)
+
+	var result callback
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for authorization: %w", ctx.Err())
+	}
+	if result.err != nil {
+		return nil, result.err
 	}
 
-	return authURL, state, nil
+	return m.ExchangeCode(ctx, serverName, result.code, verifier, redirectURL.String(), endpoints)
 }
 
-// ExchangeCode exchanges an authorization code for tokens
-// This is a stub - actual implementation would make HTTP request to token endpoint
-func (m *McpOAuthManager) ExchangeCode(serverName string, code string, config *OAuthConfig) (*OAuthToken, error) {
-	// TODO: Implement actual OAuth2 token exchange
-	// This would involve:
-	// 1. POST to config.TokenURL with code, client_id, client_secret
-	// 2. Parse response for access_token, refresh_token, expires_in
-	// 3. Store token via SetToken
+// ExchangeCode exchanges an authorization code for tokens and stores them
+func (m *McpOAuthManager) ExchangeCode(ctx context.Context, serverName, code, verifier, redirectURL string, config OAuthConfig) (*OAuthToken, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"code_verifier": {verifier},
+	}
+	token, err := requestToken(ctx, config, form)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.SetToken(serverName, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// RefreshToken obtains a new access token with the stored refresh token
+func (m *McpOAuthManager) RefreshToken(ctx context.Context, serverName, serverURL string, config OAuthConfig) (*OAuthToken, error) {
+	refresh := m.refreshToken(serverName)
+	if refresh == "" {
+		return nil, fmt.Errorf("%w for %s", ErrAuthRequired, serverName)
+	}
+
+	endpoints, err := resolveEndpoints(ctx, serverURL, config)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := requestToken(ctx, endpoints, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refresh},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w for %s: refresh failed: %v", ErrAuthRequired, serverName, err)
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refresh // Servers that don't rotate keep the old one valid
+	}
+	if err := m.SetToken(serverName, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// requestToken posts a grant to the token endpoint
+func requestToken(ctx context.Context, config OAuthConfig, form url.Values) (*OAuthToken, error) {
+	form.Set("client_id", config.ClientID)
+	if secret := os.ExpandEnv(config.ClientSecret); secret != "" {
+		form.Set("client_secret", secret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpclient.Client(30 * time.Second).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	var payload struct {
+		AccessToken      string `json:"access_token"`
+		RefreshToken     string `json:"refresh_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Scope            string `json:"scope"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("token endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if payload.Error != "" {
+		return nil, fmt.Errorf("token endpoint: %s %s", payload.Error, payload.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK || payload.AccessToken == "" {
+		return nil, fmt.Errorf("token endpoint returned %s without an access token", resp.Status)
+	}
+
+	token := &OAuthToken{
+		AccessToken:  payload.AccessToken,
+		RefreshToken: payload.RefreshToken,
+		TokenType:    payload.TokenType,
+		Scopes:       strings.Fields(payload.Scope),
+	}
+	if payload.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(payload.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// resolveEndpoints fills in AuthURL and TokenURL from the authorization server
+// metadata (RFC 8414) when the settings leave them out. The protected resource
+// metadata (RFC 9728) names the authorization server; without it the server's
+// own origin is tried.
+func resolveEndpoints(ctx context.Context, serverURL string, config OAuthConfig) (OAuthConfig, error) {
+	if config.ClientID == "" {
+		return config, fmt.Errorf("oauth.client_id is required")
+	}
+	if config.AuthURL != "" && config.TokenURL != "" {
+		return config, nil
+	}
 
-	return nil, fmt.Errorf("OAuth2 token exchange not yet implemented")
+	base, err := url.Parse(serverURL)
+	if err != nil || base.Host == "" {
+		return config, fmt.Errorf("oauth.auth_url and oauth.token_url are required")
+	}
+	issuer := base.Scheme + "://" + base.Host
+
+	var resource struct {
+		AuthorizationServers []string `json:"authorization_servers"`
+	}
+	if fetchJSON(ctx, issuer+"/.well-known/oauth-protected-resource", &resource) == nil && len(resource.AuthorizationServers) > 0 {
+		issuer = strings.TrimSuffix(resource.AuthorizationServers[0], "/")
+	}
+
+	var metadata struct {
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+	}
+	if err := fetchJSON(ctx, issuer+"/.well-known/oauth-authorization-server", &metadata); err != nil {
+		return config, fmt.Errorf("discovering OAuth endpoints for %s: %w", serverURL, err)
+	}
+	if config.AuthURL == "" {
+		config.AuthURL = metadata.AuthorizationEndpoint
+	}
+	if config.TokenURL == "" {
+		config.TokenURL = metadata.TokenEndpoint
+	}
+	if config.AuthURL == "" || config.TokenURL == "" {
+		return config, fmt.Errorf("authorization server metadata at %s lacks endpoints", issuer)
+	}
+	return config, nil
 }
 
-// RefreshToken attempts to refresh an expired token
-func (m *McpOAuthManager) RefreshToken(serverName string, config *OAuthConfig) (*OAuthToken, error) {
-	token, ok := m.GetToken(serverName)
-	if !ok || token.RefreshToken == "" {
-		return nil, fmt.Errorf("no refresh token available for %s", serverName)
+func fetchJSON(ctx context.Context, target string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpclient.Client(15 * time.Second).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", target, resp.Status)
 	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
 
-	// TODO: Implement actual token refresh
-	// POST to config.TokenURL with grant_type=refresh_token
+// listenForRedirect opens the loopback listener for the OAuth redirect. A
+// configured redirect URL pins the port and path, which some providers require.
+func listenForRedirect(redirect string) (net.Listener, *url.URL, error) {
+	target := &url.URL{Scheme: "http", Host: "127.0.0.1:0", Path: "/callback"}
+	if redirect != "" {
+		u, err := url.Parse(redirect)
+		if err != nil || u.Host == "" {
+			return nil, nil, fmt.Errorf("invalid oauth.redirect_url %q", redirect)
+		}
+		target = u
+		if target.Path == "" {
+			target.Path = "/"
+		}
+	}
 
-	return nil, fmt.Errorf("OAuth2 token refresh not yet implemented")
+	listener, err := net.Listen("tcp", target.Host)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to listen for the OAuth redirect: %w", err)
+	}
+	if redirect == "" {
+		target.Host = listener.Addr().String()
+	}
+	return listener, target, nil
+}
+
+// pkceChallenge derives the S256 code challenge for verifier
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// OpenBrowser asks the desktop to open url; failures are ignored because the
+// caller always shows the link as well
+func OpenBrowser(url string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err == nil {
+		go cmd.Wait()
+	}
 }
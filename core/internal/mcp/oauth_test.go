@@ -0,0 +1,207 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type memorySecrets map[string]string
+
+func (m memorySecrets) Get(key string) (string, error) {
+	if v, ok := m[key]; ok {
+		return v, nil
+	}
+	return "", errors.New("not found")
+}
+func (m memorySecrets) Set(key, value string) error { m[key] = value; return nil }
+func (m memorySecrets) Delete(key string) error     { delete(m, key); return nil }
+
+// newOAuthServer serves authorization server metadata, a token endpoint and an
+// MCP endpoint that only accepts the access tokens it issued
+func newOAuthServer(t *testing.T) (*httptest.Server, *int) {
+	s := server.NewMCPServer("remote", "1.0.0", server.WithToolCapabilities(false))
+	s.AddTool(mcp.NewTool("whoami"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("octocat"), nil
+	})
+	mcpHandler := server.NewStreamableHTTPServer(s)
+
+	var challenge string
+	issued := 0
+	var ts *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"authorization_endpoint": ts.URL + "/authorize",
+			"token_endpoint":         ts.URL + "/token",
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		challenge = r.URL.Query().Get("code_challenge")
+		redirect, _ := url.Parse(r.URL.Query().Get("redirect_uri"))
+		q := redirect.Query()
+		q.Set("code", "the-code")
+		q.Set("state", r.URL.Query().Get("state"))
+		redirect.RawQuery = q.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			if r.Form.Get("code") != "the-code" || pkceChallenge(r.Form.Get("code_verifier")) != challenge {
+				http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+				return
+			}
+		case "refresh_token":
+			if r.Form.Get("refresh_token") != "refresh-1" {
+				http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+				return
+			}
+		}
+		issued++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "access-" + r.Form.Get("grant_type"),
+			"refresh_token": "refresh-1",
+			"token_type":    "Bearer",
+			"expires_in":    3600,
+		})
+	})
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer access-") {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mcpHandler.ServeHTTP(w, r)
+	})
+	ts = httptest.NewServer(mux)
+	return ts, &issued
+}
+
+func TestOAuthLoginAndRefresh(t *testing.T) {
+	ts, issued := newOAuthServer(t)
+	defer ts.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	h := NewHub(t.TempDir())
+	defer h.Close()
+	secrets := memorySecrets{}
+	h.SetSecretStore(secrets)
+
+	config := McpServerConfig{URL: ts.URL + "/mcp", OAuth: &OAuthConfig{ClientID: "ricochet"}}
+	if err := h.Connect(context.Background(), "remote", config); !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("Connect before login = %v, want ErrAuthRequired", err)
+	}
+
+	h.LoadSettings(McpSettings{McpServers: map[string]McpServerConfig{"remote": config}})
+	err := h.Login(context.Background(), "remote", func(authURL string) {
+		// Stand in for the browser: follow the provider's redirect to our callback
+		go func() {
+			resp, err := http.Get(authURL)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+	})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	res, err := h.CallTool(context.Background(), "remote__whoami", nil)
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if text, ok := res.Content[0].(mcp.TextContent); !ok || text.Text != "octocat" {
+		t.Errorf("unexpected result: %+v", res.Content)
+	}
+
+	if secrets[oauthSecretKey("remote")] != "refresh-1" {
+		t.Errorf("refresh token not in secret store: %v", secrets)
+	}
+	h.oauth.mu.Lock()
+	h.oauth.tokens["remote"].ExpiresAt = time.Now().Add(-time.Minute)
+	h.oauth.tokens["remote"].RefreshToken = ""
+	h.oauth.mu.Unlock()
+
+	token, err := h.oauth.AccessToken(context.Background(), "remote", config.URL, *config.OAuth)
+	if err != nil {
+		t.Fatalf("AccessToken after expiry: %v", err)
+	}
+	if token != "access-refresh_token" || *issued != 2 {
+		t.Errorf("expected a refreshed token, got %q after %d grants", token, *issued)
+	}
+
+	// Reloading from disk must not expose the refresh token
+	reloaded, err := newOAuthManager(h.oauth.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.tokens["remote"].RefreshToken != "" {
+		t.Error("refresh token written to mcp_tokens.json despite a secret store")
+	}
+
+	if err := h.Logout("remote"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := secrets[oauthSecretKey("remote")]; ok || !h.oauth.NeedsAuth("remote") {
+		t.Error("logout left tokens behind")
+	}
+}
+
+func TestAuthorizeCallback(t *testing.T) {
+	m, err := newOAuthManager(filepath.Join(t.TempDir(), "mcp_tokens.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := OAuthConfig{ClientID: "ricochet", AuthURL: "http://auth.invalid/authorize", TokenURL: "http://auth.invalid/token"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	bodies := make(chan string, 1)
+	_, err = m.Authorize(ctx, "remote", "", config, func(authURL string) {
+		u, _ := url.Parse(authURL)
+		callback, state := u.Query().Get("redirect_uri"), u.Query().Get("state")
+		go func() {
+			// A callback for another flow is refused and the flow goes on
+			resp, err := http.Get(callback + "?state=other&code=stolen")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("mismatched state: status %d", resp.StatusCode)
+			}
+
+			resp, err = http.Get(callback + "?" + url.Values{
+				"state":             {state},
+				"error":             {"access_denied"},
+				"error_description": {"<script>alert(1)</script>"},
+			}.Encode())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			bodies <- string(body)
+		}()
+	})
+	if err == nil || !strings.Contains(err.Error(), "access_denied") {
+		t.Fatalf("Authorize = %v, want the provider's denial", err)
+	}
+	if body := <-bodies; strings.Contains(body, "<script>") || !strings.Contains(body, "&lt;script&gt;") {
+		t.Errorf("error description not escaped: %s", body)
+	}
+}
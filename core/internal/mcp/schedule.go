@@ -0,0 +1,171 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// schedulerInterval is how often due notifications are looked for
+const schedulerInterval = 30 * time.Second
+
+// registerScheduleTools adds the tools that manage scheduled notifications
+func (s *Server) registerScheduleTools(mcpServer *server.MCPServer) {
+	scheduleTool := mcp.NewTool("schedule_notification",
+		mcp.WithDescription("Schedule a notification to the user, e.g. a reminder when a deploy window opens. It is delivered by the bot even if the IDE has been restarted. Give exactly one of cron, delay or at."),
+		mcp.WithString("message",
+			mcp.Required(),
+			mcp.Description("The message to send"),
+		),
+		mcp.WithString("cron",
+			mcp.Description("Repeat on a cron schedule in local time: 'minute hour day month weekday', e.g. '0 9 * * 1-5', or @hourly/@daily/@weekly"),
+		),
+		mcp.WithString("delay",
+			mcp.Description("Send once after a delay, e.g. '45m' or '2h30m'"),
+		),
+		mcp.WithString("at",
+			mcp.Description("Send once at a time in RFC 3339 format, e.g. '2025-06-01T18:00:00+02:00'"),
+		),
+		mcp.WithString("session_id",
+			mcp.Description("Optional session UUID to route the notification like the session's own messages"),
+		),
+	)
+	mcpServer.AddTool(scheduleTool, s.handleScheduleNotification)
+
+	listTool := mcp.NewTool("list_notifications",
+		mcp.WithDescription("List scheduled notifications"),
+	)
+	mcpServer.AddTool(listTool, s.handleListNotifications)
+
+	cancelTool := mcp.NewTool("cancel_notification",
+		mcp.WithDescription("Cancel a scheduled notification"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("ID returned by schedule_notification"),
+		),
+	)
+	mcpServer.AddTool(cancelTool, s.handleCancelNotification)
+}
+
+// handleScheduleNotification stores a notification for the scheduler
+func (s *Server) handleScheduleNotification(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := getArgs(request)
+	s.updateHeartbeat(args)
+
+	message, _ := args["message"].(string)
+	if strings.TrimSpace(message) == "" {
+		return mcp.NewToolResultError("message parameter is required"), nil
+	}
+	cron, _ := args["cron"].(string)
+	delay, _ := args["delay"].(string)
+	atText, _ := args["at"].(string)
+	sessionID, _ := args["session_id"].(string)
+
+	given := 0
+	for _, v := range []string{cron, delay, atText} {
+		if v != "" {
+			given++
+		}
+	}
+	if given != 1 {
+		return mcp.NewToolResultError("give exactly one of cron, delay or at"), nil
+	}
+	if s.state == nil {
+		return mcp.NewToolResultError("state storage is unavailable"), nil
+	}
+
+	var at time.Time
+	switch {
+	case delay != "":
+		d, err := time.ParseDuration(delay)
+		if err != nil || d <= 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid delay %q: use a duration like '45m' or '2h'", delay)), nil
+		}
+		at = time.Now().Add(d)
+	case atText != "":
+		t, err := time.Parse(time.RFC3339, atText)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid time %q: use RFC 3339, e.g. 2025-06-01T18:00:00+02:00", atText)), nil
+		}
+		if !t.After(time.Now()) {
+			return mcp.NewToolResultError(fmt.Sprintf("%s is in the past", atText)), nil
+		}
+		at = t
+	}
+
+	n, err := s.state.ScheduleNotification(sessionID, message, cron, at)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to schedule: %v", err)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Scheduled notification %s, next at %s", n.ID, n.NextAt.Format(time.RFC3339))), nil
+}
+
+// handleListNotifications lists the scheduled notifications
+func (s *Server) handleListNotifications(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if s.state == nil {
+		return mcp.NewToolResultError("state storage is unavailable"), nil
+	}
+	list := s.state.GetNotifications()
+	if len(list) == 0 {
+		return mcp.NewToolResultText("No scheduled notifications"), nil
+	}
+
+	var sb strings.Builder
+	for _, n := range list {
+		repeat := "once"
+		if n.Cron != "" {
+			repeat = "cron " + n.Cron
+		}
+		sb.WriteString(fmt.Sprintf("- %s: next at %s (%s): %s\n", n.ID, n.NextAt.Format(time.RFC3339), repeat, n.Message))
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// handleCancelNotification removes a scheduled notification
+func (s *Server) handleCancelNotification(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := getArgs(request)
+	id, _ := args["id"].(string)
+	if id == "" {
+		return mcp.NewToolResultError("id parameter is required"), nil
+	}
+	if s.state == nil {
+		return mcp.NewToolResultError("state storage is unavailable"), nil
+	}
+
+	found, err := s.state.CancelNotification(id)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to cancel: %v", err)), nil
+	}
+	if !found {
+		return mcp.NewToolResultError(fmt.Sprintf("no scheduled notification %s", id)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Cancelled notification %s", id)), nil
+}
+
+// runScheduler delivers scheduled notifications as they fall due
+func (s *Server) runScheduler(ctx context.Context) {
+	if s.state == nil {
+		return
+	}
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, n := range s.state.TakeDueNotifications(time.Now()) {
+			if err := s.sendMessage(ctx, n.SessionID, "⏰ "+n.Message, nil); err != nil {
+				log.Printf("Failed to deliver scheduled notification %s: %v", n.ID, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -2,6 +2,7 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -17,7 +18,10 @@ import (
 	"github.com/igoryan-dao/ricochet/internal/bridge"
 	"github.com/igoryan-dao/ricochet/internal/bridge/proto"
 	"github.com/igoryan-dao/ricochet/internal/discord"
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
+	"github.com/igoryan-dao/ricochet/internal/messenger"
 	"github.com/igoryan-dao/ricochet/internal/sessions"
+	"github.com/igoryan-dao/ricochet/internal/slack"
 	"github.com/igoryan-dao/ricochet/internal/state"
 	"github.com/igoryan-dao/ricochet/internal/telegram"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -37,7 +41,9 @@ type Server struct {
 	mcpServer    *server.MCPServer
 	tgBot        *telegram.Bot
 	discordBot   *discord.Bot
+	slackBot     *slack.Bot
 	bridgeClient *bridge.Client
+	router       *messenger.Router // Picks the messenger a session is answered in
 	state        *state.Manager
 	sessionsMgr  *sessions.Manager
 	chatID       int64 // Primary chat for notifications
@@ -52,6 +58,13 @@ func NewServer(tgBot *telegram.Bot, discordBot *discord.Bot, stateMgr *state.Man
 		sessionsMgr: sessions.NewManager(),
 		chatID:      stateMgr.GetPrimaryChatID(),
 	}
+	s.buildRouter()
+	if tgBot != nil {
+		tgBot.SetTopicNamer(s.topicName)
+	}
+	if discordBot != nil {
+		discordBot.SetThreadNamer(s.topicName)
+	}
 
 	// Create MCP server
 	mcpServer := server.NewMCPServer(
@@ -80,19 +93,109 @@ func (s *Server) SetBridge(c *bridge.Client) {
 	go s.listenBridge()
 }
 
+// SetSlack registers a Slack bot. Sessions active in a Slack channel are
+// answered there, like Discord ones.
+func (s *Server) SetSlack(b *slack.Bot) {
+	s.slackBot = b
+	s.buildRouter()
+}
+
+// buildRouter routes sessions to the channel-based messengers they are
+// active in, and to Telegram otherwise
+func (s *Server) buildRouter() {
+	var channels []messenger.Channel
+	if s.discordBot != nil {
+		channels = append(channels, s.discordBot)
+	}
+	if s.slackBot != nil {
+		channels = append(channels, s.slackBot)
+	}
+	if s.tgBot != nil {
+		channels = append(channels, s.tgBot)
+	}
+	s.router = messenger.NewRouter(channels...)
+}
+
+// topicName names a session's Telegram topic or Discord thread after the
+// session's title
+func (s *Server) topicName(sessionID string) string {
+	sess, _ := s.sessionsMgr.GetSession(sessionID)
+	if sess == nil || sess.Title == "" {
+		return ""
+	}
+	return "🤖 " + sess.Title
+}
+
 func (s *Server) listenBridge() {
 	log.Println("Listening for events from Cloud Bridge...")
 	for event := range s.bridgeClient.Incoming() {
+		// Button presses (e.g. WhatsApp interactive replies) act like Telegram callbacks
+		if cb := event.GetCallback(); cb != nil {
+			log.Printf("Bridge: Button %q pressed on %s", cb.Data, cb.Platform)
+			if !s.tgBot.AuthorizeBridge(cb.ChatId, cb.Data) {
+				continue
+			}
+			s.handleCallback(context.Background(), &telegram.CallbackEvent{ChatID: cb.ChatId, Data: cb.Data})
+			continue
+		}
 		if msg := event.GetIncomingMessage(); msg != nil {
 			log.Printf("Bridge: Received message from %s: %s", msg.Platform, msg.Body)
-			// Route to session
-			if event.SessionId != "" {
-				s.tgBot.SendToSession(event.SessionId, msg.Body)
+			// Team roles apply as on Telegram
+			if !s.tgBot.AuthorizeBridge(msg.ChatId, msg.CallbackData) {
+				continue
+			}
+			// Older bridges report button presses as messages with callback data
+			if msg.CallbackData != "" {
+				s.handleCallback(context.Background(), &telegram.CallbackEvent{ChatID: msg.ChatId, Data: msg.CallbackData})
+				continue
+			}
+			text := msg.Body
+			if len(msg.Attachments) > 0 {
+				text = s.bridgeUploads(context.Background(), msg)
+			}
+			// Route to session, or to the one activated in this chat
+			sessionID := event.SessionId
+			if sessionID == "" {
+				sessionID = s.tgBot.GetActiveSession(msg.ChatId)
+			}
+			if sessionID != "" && text != "" {
+				s.tgBot.SendToSession(sessionID, text)
 			}
 		}
 	}
 }
 
+// bridgeUploads saves the files of a bridge message like Telegram uploads
+// and returns the notes standing in for them, followed by the body
+func (s *Server) bridgeUploads(ctx context.Context, msg *proto.IncomingMessage) string {
+	homeDir, _ := os.UserHomeDir()
+	dir := filepath.Join(homeDir, ".ricochet", "tmp", "bridge")
+
+	var notes []string
+	for _, att := range msg.Attachments {
+		path, err := bridge.SaveAttachment(ctx, att, dir)
+		if err != nil {
+			log.Printf("Bridge: failed to save %s: %v", att.Name, err)
+			continue
+		}
+		note, err := s.tgBot.UploadNote(ctx, &telegram.FileUpload{
+			ChatID:    msg.ChatId,
+			Name:      att.Name,
+			LocalPath: path,
+			MimeType:  att.MimeType,
+		})
+		if err != nil {
+			log.Printf("Bridge: failed to store %s: %v", att.Name, err)
+			continue
+		}
+		notes = append(notes, note)
+	}
+	if body := strings.TrimSpace(msg.Body); body != "" {
+		notes = append(notes, body)
+	}
+	return strings.Join(notes, "\n")
+}
+
 // registerTools adds all MCP tools
 func (s *Server) registerTools(mcpServer *server.MCPServer) {
 	// Tool: notify - Send a notification to the user
@@ -110,11 +213,29 @@ func (s *Server) registerTools(mcpServer *server.MCPServer) {
 
 	// Tool: ask - Ask a question and wait for response
 	askTool := mcp.NewTool("ask",
-		mcp.WithDescription("Ask the user a question via Telegram and wait for their response"),
+		mcp.WithDescription("Ask the user a question via Telegram and wait for their response. Returns JSON: {\"answer\", \"option\", \"number\", \"source\", \"timed_out\"}."),
 		mcp.WithString("question",
 			mcp.Required(),
 			mcp.Description("The question to ask"),
 		),
+		mcp.WithString("options",
+			mcp.Description("Optional comma-separated choices, shown as buttons. The answer must be one of them."),
+		),
+		mcp.WithString("pattern",
+			mcp.Description("Optional regular expression a free-text answer must match"),
+		),
+		mcp.WithNumber("min",
+			mcp.Description("Optional lower bound; the answer must be a number"),
+		),
+		mcp.WithNumber("max",
+			mcp.Description("Optional upper bound; the answer must be a number"),
+		),
+		mcp.WithString("default",
+			mcp.Description("Answer to use when nobody replies within timeout_seconds"),
+		),
+		mcp.WithNumber("timeout_seconds",
+			mcp.Description("Optional time to wait for an answer. Without a default the result reports timed_out with an empty answer."),
+		),
 		mcp.WithString("session_id",
 			mcp.Description("Optional session UUID to route the response back to this specific agent"),
 		),
@@ -131,6 +252,12 @@ func (s *Server) registerTools(mcpServer *server.MCPServer) {
 		mcp.WithString("reason",
 			mcp.Description("Why this command might be dangerous"),
 		),
+		mcp.WithString("diff",
+			mcp.Description("Optional unified diff when the action edits a file, previewed with the question"),
+		),
+		mcp.WithString("file_path",
+			mcp.Description("File the diff applies to"),
+		),
 		mcp.WithString("session_id",
 			mcp.Description("Optional session UUID"),
 		),
@@ -147,6 +274,19 @@ func (s *Server) registerTools(mcpServer *server.MCPServer) {
 	)
 	mcpServer.AddTool(setChatTool, s.handleSetChat)
 
+	// Tool: set_recipients - Route a session's notify/ask to several chats or forum topics
+	setRecipientsTool := mcp.NewTool("set_recipients",
+		mcp.WithDescription("Send a session's notifications and questions to several Telegram chats or forum topics, e.g. a team channel and the owner's DM. The first answer from any of them is used."),
+		mcp.WithString("recipients",
+			mcp.Required(),
+			mcp.Description("Comma-separated chat IDs, each optionally followed by :topic_id (message_thread_id), e.g. \"123456, -1001234567890:42\". Empty to revert to the default."),
+		),
+		mcp.WithString("session_id",
+			mcp.Description("Session UUID; omit to set the default for sessions without their own recipients"),
+		),
+	)
+	mcpServer.AddTool(setRecipientsTool, s.handleSetRecipients)
+
 	// Tool: get_unread_messages - Get unread messages for a session
 	getUnreadTool := mcp.NewTool("get_unread_messages",
 		mcp.WithDescription("Get unread messages buffered for a specific session"),
@@ -215,7 +355,7 @@ func (s *Server) registerTools(mcpServer *server.MCPServer) {
 
 	// Tool: update_progress - Send progress stage updates (Rich UX)
 	progressTool := mcp.NewTool("update_progress",
-		mcp.WithDescription("Send a progress update to Telegram. Use to show current work stage."),
+		mcp.WithDescription("Send a progress update to Telegram. Use to show current work stage. On Discord a session's updates are edited into one message until the 'completed' stage."),
 		mcp.WithString("stage",
 			mcp.Required(),
 			mcp.Description("Stage: 'planning', 'execution', 'verification', 'completed'"),
@@ -294,7 +434,7 @@ func (s *Server) registerTools(mcpServer *server.MCPServer) {
 
 	// Tool: voice_reply - Send a voice message (TTS)
 	voiceReplyTool := mcp.NewTool("voice_reply",
-		mcp.WithDescription("Send a voice message (TTS) to the user. Useful for answering in a friendly way or providing updates."),
+		mcp.WithDescription("Send a voice message (TTS) to the user, and play it in the Discord voice channel if one is set. Useful for answering in a friendly way or providing updates."),
 		mcp.WithString("text",
 			mcp.Required(),
 			mcp.Description("Text to convert to speech"),
@@ -304,6 +444,8 @@ func (s *Server) registerTools(mcpServer *server.MCPServer) {
 		),
 	)
 	mcpServer.AddTool(voiceReplyTool, s.handleVoiceReply)
+
+	s.registerScheduleTools(mcpServer)
 }
 
 // registerResources adds MCP resources
@@ -362,60 +504,43 @@ func (s *Server) handleNotify(ctx context.Context, request mcp.CallToolRequest)
 	return mcp.NewToolResultText("Notification sent successfully"), nil
 }
 
+// recipients returns the Telegram chats and topics a session's messages go to
+func (s *Server) recipients(sessionID string) []state.Recipient {
+	var list []state.Recipient
+	if s.state != nil {
+		list = s.state.GetRecipients(sessionID)
+	}
+	if len(list) == 0 && s.chatID != 0 {
+		list = []state.Recipient{{ChatID: s.chatID}}
+	}
+	return list
+}
+
 // sendMessage sends a message to the user, routing through bridge if available
 func (s *Server) sendMessage(ctx context.Context, sessionID, text string, buttons [][]telegram.ButtonConfig) error {
 	if s.bridgeClient != nil {
-		return s.bridgeClient.Send(&proto.BridgeEvent{
-			SessionId: sessionID,
-			Payload: &proto.BridgeEvent_OutgoingMessage{
-				OutgoingMessage: &proto.OutgoingMessage{
-					ChatId:   s.chatID,
-					Body:     text,
-					Platform: "telegram",
-				},
-			},
-		})
-	}
-
-	if sessionID != "" {
-		if s.discordBot != nil {
-			activeDiscord := s.state.GetDiscordActiveSessions()
-			for channelID, sessID := range activeDiscord {
-				if sessID == sessionID {
-					s.discordBot.SendTyping(ctx, channelID)
-					return s.discordBot.SendMessage(ctx, channelID, text)
-				}
-			}
+		recipients := s.recipients(sessionID)
+		if len(recipients) == 0 {
+			recipients = []state.Recipient{{}} // No chat known: send as before and let the bridge route it
 		}
-		s.tgBot.SendTyping(ctx, s.chatID)
-		if len(buttons) > 0 {
-			return s.tgBot.SendMessageWithButtons(ctx, s.chatID, text, buttons)
+		var errs []error
+		for _, r := range recipients {
+			errs = append(errs, s.bridgeClient.Send(&proto.BridgeEvent{
+				SessionId: sessionID,
+				Payload: &proto.BridgeEvent_OutgoingMessage{
+					OutgoingMessage: &proto.OutgoingMessage{
+						ChatId:  r.ChatID,
+						Body:    text,
+						Buttons: telegram.BridgeButtons(buttons),
+					},
+				},
+			}))
 		}
-		return s.tgBot.SendMessage(ctx, s.chatID, text)
-	}
-
-	if s.chatID == 0 {
-		return fmt.Errorf("chat_id not set")
+		return errors.Join(errs...)
 	}
-	s.tgBot.SendTyping(ctx, s.chatID)
-	return s.tgBot.SendMessage(ctx, s.chatID, text)
-}
 
-// resolveChannel finds where the session is active and returns bot and channelID
-func (s *Server) resolveChannel(sessionID string) (tg *telegram.Bot, dg *discord.Bot, tgChatID int64, dgChannelID string) {
-	if sessionID != "" {
-		// Try Discord
-		if s.discordBot != nil {
-			activeDiscord := s.state.GetDiscordActiveSessions()
-			for channelID, sessID := range activeDiscord {
-				if sessID == sessionID {
-					return nil, s.discordBot, 0, channelID
-				}
-			}
-		}
-	}
-	// Default to Telegram
-	return s.tgBot, nil, s.chatID, ""
+	_, err := s.router.Send(ctx, sessionID, messenger.Message{Text: text, Buttons: buttons})
+	return err
 }
 
 // handleAsk asks a question and waits for response
@@ -428,96 +553,100 @@ func (s *Server) handleAsk(ctx context.Context, request mcp.CallToolRequest) (*m
 		return mcp.NewToolResultError("question parameter is required"), nil
 	}
 
+	spec, err := parseAskSpec(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
 	sessionID, _ := args["session_id"].(string)
 
-	if s.chatID == 0 {
+	ch, targets := s.router.Resolve(sessionID)
+	if len(targets) == 0 {
 		return mcp.NewToolResultError("chat_id not set. Use set_chat tool first or send a message to the bot."), nil
 	}
 
+	text := spec.question(question)
+
 	// If we have a sessionID, register a specific handler
 	if sessionID != "" {
-		// Try Discord first
-		if s.discordBot != nil {
-			activeDiscord := s.state.GetDiscordActiveSessions()
-			for channelID, sessID := range activeDiscord {
-				if sessID == sessionID {
-					// Check Discord buffer
-					unread := s.discordBot.GetUnreadMessages(sessionID)
-					if len(unread) > 0 {
-						return mcp.NewToolResultText(unread[len(unread)-1]), nil
-					}
-
-					respCh := make(chan string, 1)
-					s.discordBot.RegisterSessionHandler(sessionID, respCh)
-					defer s.discordBot.UnregisterSessionHandler(sessionID)
-
-					text := fmt.Sprintf("❓ %s", question)
-					s.discordBot.SendTyping(ctx, channelID)
-					if err := s.discordBot.SendMessage(ctx, channelID, text); err != nil {
-						return mcp.NewToolResultError(fmt.Sprintf("failed to send to Discord: %v", err)), nil
-					}
-
-					select {
-					case resp := <-respCh:
-						return mcp.NewToolResultText(resp), nil
-					case <-time.After(10 * time.Minute):
-						return mcp.NewToolResultError("timeout waiting for Discord response"), nil
-					case <-ctx.Done():
-						return nil, ctx.Err()
-					}
-				}
-			}
-		}
-
-		// Fallback to Telegram
-		unread := s.tgBot.GetUnreadMessages(sessionID)
-		if len(unread) > 0 {
-			return mcp.NewToolResultText(unread[len(unread)-1]), nil
+		// Check the buffer first
+		if answer, ok := bufferedAnswer(spec, ch.Unread(sessionID)); ok {
+			return answer, nil
 		}
 
 		respCh := make(chan string, 1)
-		s.tgBot.RegisterSessionHandler(sessionID, respCh)
-		defer s.tgBot.UnregisterSessionHandler(sessionID)
-
-		// Set this session as active for the chat automatically if nothing else is active
-		if s.tgBot.GetActiveSession(s.chatID) == "" {
-			s.tgBot.SetActiveSession(s.chatID, sessionID)
-		}
-
-		text := fmt.Sprintf("❓ %s", question)
-		s.tgBot.SendTyping(ctx, s.chatID)
+		unregister := ch.RegisterSession(sessionID, respCh)
+		defer unregister()
+
+		// Ask every target; the first answer from any of them wins
+		for _, target := range targets {
+			// Set this session as active for the chat automatically if nothing else is active
+			if ch.ActiveSession(target) == "" {
+				ch.Activate(target, sessionID)
+			}
 
-		// Send question with "Activate" button if it's not the active session
-		var buttons [][]telegram.ButtonConfig
-		if s.tgBot.GetActiveSession(s.chatID) != sessionID {
-			buttons = append(buttons, []telegram.ButtonConfig{
-				{Text: "🔗 Начать отвечать здесь", Data: "activate:" + sessionID},
-			})
-		}
+			// Send question with "Activate" button if it's not the active session
+			buttons := spec.buttons("ask:" + sessionID + ":")
+			if ch.ActiveSession(target) != sessionID {
+				buttons = append(buttons, []messenger.Button{
+					{Text: "🔗 Начать отвечать здесь", Data: "activate:" + sessionID},
+				})
+			}
 
-		if len(buttons) > 0 {
-			s.tgBot.SendMessageWithButtons(ctx, s.chatID, text, buttons)
-		} else {
-			s.tgBot.SendMessage(ctx, s.chatID, text)
+			ch.Presence(ctx, target)
+			if err := ch.SendRich(ctx, target, messenger.Message{Text: text, Buttons: buttons}); err != nil {
+				log.Printf("Failed to ask %s %s: %v", ch.Name(), target, err)
+			}
 		}
 
 		// Wait for response from session channel
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case response := <-respCh:
-			return mcp.NewToolResultText(response), nil
-		}
+		return spec.await(ctx, respCh, func(hint string) {
+			for _, target := range targets {
+				ch.Send(ctx, target, hint)
+			}
+		})
 	}
 
 	// Legacy fallback: generic AskUser
-	response, err := s.tgBot.AskUser(ctx, s.chatID, fmt.Sprintf("❓ %s", question))
-	if err != nil {
-		log.Printf("Failed to ask user: %v", err)
-		return mcp.NewToolResultError(fmt.Sprintf("failed to ask: %v", err)), nil
+	waitCtx := ctx
+	if spec.Timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, spec.Timeout)
+		defer cancel()
+	}
+	buttons := spec.buttons("")
+	if len(buttons) == 0 {
+		buttons = telegram.DefaultAskButtons
 	}
+	for {
+		response, err := ch.Ask(waitCtx, targets[0], text, buttons)
+		if err != nil {
+			if ctx.Err() == nil && waitCtx.Err() != nil {
+				return spec.timedOut(), nil
+			}
+			log.Printf("Failed to ask user: %v", err)
+			return mcp.NewToolResultError(fmt.Sprintf("failed to ask: %v", err)), nil
+		}
 
-	return mcp.NewToolResultText(response), nil
+		answer, hint := spec.check(response)
+		if hint == "" {
+			return answerResult(answer), nil
+		}
+		text = "⚠️ Invalid answer: " + hint
+	}
+}
+
+// bufferedAnswer answers from the latest message that arrived before the
+// question was asked, if it is a valid answer
+func bufferedAnswer(spec askSpec, unread []string) (*mcp.CallToolResult, bool) {
+	if len(unread) == 0 {
+		return nil, false
+	}
+	answer, hint := spec.check(unread[len(unread)-1])
+	if hint != "" {
+		return nil, false
+	}
+	return answerResult(answer), true
 }
 
 // handleConfirmDangerous asks for confirmation of a dangerous command
@@ -531,52 +660,51 @@ func (s *Server) handleConfirmDangerous(ctx context.Context, request mcp.CallToo
 	}
 
 	reason, _ := args["reason"].(string)
+	diff, _ := args["diff"].(string)
+	filePath, _ := args["file_path"].(string)
 	sessionID, _ := args["session_id"].(string)
 	if reason == "" {
 		reason = "This command may have destructive side effects"
 	}
 
-	tg, dg, chatID, channelID := s.resolveChannel(sessionID)
+	ch, targets := s.router.Resolve(sessionID)
+	if len(targets) == 0 {
+		return mcp.NewToolResultError("chat_id not set"), nil
+	}
 
 	question := fmt.Sprintf("⚠️ *Dangerous Command Confirmation*\n\n```\n%s\n```\n\n%s\n\nReply 'yes' to confirm, anything else to cancel.", command, reason)
 
-	var response string
-	if dg != nil {
-		dg.SendTyping(ctx, channelID)
-		dg.SendMessage(ctx, channelID, question)
-		respCh := make(chan string, 1)
-		dg.RegisterSessionHandler(sessionID, respCh)
-		defer dg.UnregisterSessionHandler(sessionID)
-
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case response = <-respCh:
-			// obtained
-		case <-time.After(5 * time.Minute):
-			return mcp.NewToolResultError("confirmation timed out"), nil
-		}
-	} else {
-		tg.SendTyping(ctx, chatID)
-		respCh := make(chan string, 1)
-		tg.RegisterSessionHandler(sessionID, respCh)
-		defer tg.UnregisterSessionHandler(sessionID)
+	respCh := make(chan string, 1)
+	unregister := ch.RegisterSession(sessionID, respCh)
+	defer unregister()
 
-		tg.SendMessageWithButtons(ctx, chatID, question, [][]telegram.ButtonConfig{
-			{
-				{Text: "✅ Confirm", Data: "confirm_yes:" + sessionID},
-				{Text: "❌ Cancel", Data: "confirm_no:" + sessionID},
-			},
+	buttons := [][]messenger.Button{
+		{
+			{Text: "✅ Confirm", Data: "confirm_yes:" + sessionID},
+			{Text: "❌ Cancel", Data: "confirm_no:" + sessionID},
+		},
+	}
+	if diff != "" {
+		buttons = append(buttons, []messenger.Button{{Text: "📄 View full", Data: telegram.CallbackViewDiff}})
+	}
+	for _, target := range targets {
+		ch.Presence(ctx, target)
+		ch.SendRich(ctx, target, messenger.Message{
+			Text:     question,
+			Buttons:  buttons,
+			Diff:     diff,
+			DiffPath: filePath,
 		})
+	}
 
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case response = <-respCh:
-			// obtained
-		case <-time.After(5 * time.Minute):
-			return mcp.NewToolResultError("confirmation timed out"), nil
-		}
+	var response string
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case response = <-respCh:
+		// obtained
+	case <-time.After(5 * time.Minute):
+		return mcp.NewToolResultError("confirmation timed out"), nil
 	}
 
 	if response == "yes" || response == "Yes" || response == "YES" || response == "да" || response == "Да" || response == "confirm_yes" {
@@ -607,6 +735,50 @@ func (s *Server) handleSetChat(ctx context.Context, request mcp.CallToolRequest)
 	return mcp.NewToolResultText(fmt.Sprintf("Chat ID set to %d", s.chatID)), nil
 }
 
+// handleSetRecipients stores the chats and topics a session's messages go to
+func (s *Server) handleSetRecipients(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := getArgs(request)
+	raw, _ := args["recipients"].(string)
+	sessionID, _ := args["session_id"].(string)
+
+	recipients, err := parseRecipients(raw)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if s.state == nil {
+		return mcp.NewToolResultError("state storage is unavailable"), nil
+	}
+	if err := s.state.SetRecipients(sessionID, recipients); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to save recipients: %v", err)), nil
+	}
+
+	scope := "default"
+	if sessionID != "" {
+		scope = "session " + sessionID
+	}
+	if len(recipients) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("Recipients for %s cleared", scope)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Recipients for %s set to %s", scope, strings.TrimSpace(raw))), nil
+}
+
+// parseRecipients reads "chat[:topic], ..." lists
+func parseRecipients(raw string) ([]state.Recipient, error) {
+	var recipients []state.Recipient
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		r, err := state.ParseRecipient(part)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, nil
+}
+
 // handleGetUnreadMessages returns buffered messages for a session
 func (s *Server) handleGetUnreadMessages(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := getArgs(request)
@@ -617,11 +789,7 @@ func (s *Server) handleGetUnreadMessages(ctx context.Context, request mcp.CallTo
 		return mcp.NewToolResultError("session_id parameter is required"), nil
 	}
 
-	var messages []string
-	if s.discordBot != nil {
-		messages = append(messages, s.discordBot.GetUnreadMessages(sessionID)...)
-	}
-	messages = append(messages, s.tgBot.GetUnreadMessages(sessionID)...)
+	messages := s.router.Unread(sessionID)
 
 	if len(messages) == 0 {
 		return mcp.NewToolResultText("No unread messages"), nil
@@ -662,42 +830,24 @@ func (s *Server) handleWaitForCommand(ctx context.Context, request mcp.CallToolR
 		timeoutMinutes = t
 	}
 
-	tg, dg, chatID, channelID := s.resolveChannel(sessionID)
+	ch, targets := s.router.Resolve(sessionID)
 
 	// 1. Check for unread messages first
-	var unread []string
-	if dg != nil {
-		unread = dg.GetUnreadMessages(sessionID)
-	} else {
-		unread = tg.GetUnreadMessages(sessionID)
-	}
-
-	if len(unread) > 0 {
+	if unread := ch.Unread(sessionID); len(unread) > 0 {
 		return mcp.NewToolResultText(strings.Join(unread, "\n")), nil
 	}
 
 	// 2. Register handler and wait
 	respCh := make(chan string, 1)
-	if dg != nil {
-		dg.RegisterSessionHandler(sessionID, respCh)
-		defer dg.UnregisterSessionHandler(sessionID)
+	unregister := ch.RegisterSession(sessionID, respCh)
+	defer unregister()
 
-		// Auto-activate for Discord if not active
-		if dg.GetActiveSession(channelID) == "" {
-			dg.SetActiveSession(channelID, sessionID)
+	for _, target := range targets {
+		// Auto-activate if nothing is active
+		if ch.ActiveSession(target) == "" {
+			ch.Activate(target, sessionID)
 		}
-
-		dg.SendMessage(ctx, channelID, "💤 **Agent in standby.** Send next command when ready.")
-	} else {
-		tg.RegisterSessionHandler(sessionID, respCh)
-		defer tg.UnregisterSessionHandler(sessionID)
-
-		// Auto-activate for Telegram if not active
-		if tg.GetActiveSession(chatID) == "" {
-			tg.SetActiveSession(chatID, sessionID)
-		}
-
-		tg.SendMessage(ctx, chatID, "💤 **Agent in standby.** Send next command when ready.")
+		ch.Send(ctx, target, "💤 **Agent in standby.** Send next command when ready.")
 	}
 
 	log.Printf("Session %s entering wait mode...", sessionID)
@@ -833,8 +983,6 @@ func (s *Server) handleUpdateProgress(ctx context.Context, request mcp.CallToolR
 	progressPercent, _ := args["progress_percent"].(float64)
 	sessionID, _ := args["session_id"].(string)
 
-	tg, dg, chatID, channelID := s.resolveChannel(sessionID)
-
 	// Format stage icon
 	stageIcon := "🔄"
 	switch stage {
@@ -853,17 +1001,38 @@ func (s *Server) handleUpdateProgress(ctx context.Context, request mcp.CallToolR
 		text += fmt.Sprintf(" (%d%%)", int(progressPercent))
 	}
 
-	if dg != nil {
-		dg.SendTyping(ctx, channelID)
-		dg.SendMessage(ctx, channelID, text)
-	} else {
-		tg.SendTyping(ctx, chatID)
-		tg.SendMessage(ctx, chatID, text)
+	if ch, targets := s.router.Resolve(sessionID); len(targets) > 0 {
+		if bot, ok := ch.(*discord.Bot); ok {
+			if err := streamProgress(ctx, bot, targets, sessionID, text, stage == "completed"); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to send: %v", err)), nil
+			}
+			return mcp.NewToolResultText("Progress update sent"), nil
+		}
+	}
+
+	if _, err := s.router.Send(ctx, sessionID, messenger.Message{Text: text}); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to send: %v", err)), nil
 	}
 
 	return mcp.NewToolResultText("Progress update sent"), nil
 }
 
+// streamProgress edits a progress line into the session's progress message
+// in each Discord channel. Like Router.Send it fails only if no channel got it.
+func streamProgress(ctx context.Context, bot *discord.Bot, targets []string, sessionID, text string, done bool) error {
+	var errs []error
+	for _, target := range targets {
+		if err := bot.StreamProgress(ctx, target, sessionID, text, done); err != nil {
+			log.Printf("Failed to stream progress to Discord %s: %v", target, err)
+			errs = append(errs, fmt.Errorf("Discord %s: %w", target, err))
+		}
+	}
+	if len(errs) == len(targets) {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
 // handleSendSummary sends a brief summary
 func (s *Server) handleSendSummary(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := getArgs(request)
@@ -881,8 +1050,6 @@ func (s *Server) handleSendSummary(ctx context.Context, request mcp.CallToolRequ
 
 	sessionID, _ := args["session_id"].(string)
 
-	tg, dg, chatID, channelID := s.resolveChannel(sessionID)
-
 	// Format bullet points
 	points := strings.Split(bulletPoints, ",")
 	var formattedPoints []string
@@ -892,12 +1059,8 @@ func (s *Server) handleSendSummary(ctx context.Context, request mcp.CallToolRequ
 
 	text := fmt.Sprintf("📊 **%s**\n\n%s", title, strings.Join(formattedPoints, "\n"))
 
-	if dg != nil {
-		dg.SendTyping(ctx, channelID)
-		dg.SendMessage(ctx, channelID, text)
-	} else {
-		tg.SendTyping(ctx, chatID)
-		tg.SendMessage(ctx, chatID, text)
+	if _, err := s.router.Send(ctx, sessionID, messenger.Message{Text: text}); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to send: %v", err)), nil
 	}
 
 	return mcp.NewToolResultText("Summary sent"), nil
@@ -926,11 +1089,7 @@ func (s *Server) handleReadMessages(ctx context.Context, request mcp.ReadResourc
 		}, nil
 	}
 
-	var messages []string
-	if s.discordBot != nil {
-		messages = append(messages, s.discordBot.GetUnreadMessages(sessionID)...)
-	}
-	messages = append(messages, s.tgBot.GetUnreadMessages(sessionID)...)
+	messages := s.router.Unread(sessionID)
 
 	text := "No unread messages."
 	if len(messages) > 0 {
@@ -963,6 +1122,7 @@ You are connected to Ricochet - a bridge between your IDE and Telegram/Discord m
 | "screenshot" / "скриншот" | → Call 'send_image' |
 | "voice", "say" / "озвучь", "скажи" | → Call 'voice_reply' |
 | "progress" / "прогресс" | → Call 'update_progress' |
+| "remind me", "ping me at" / "напомни" | → Call 'schedule_notification' |
 
 ### STRICT RULES:
 
@@ -1001,6 +1161,7 @@ func (s *Server) Run(ctx context.Context) error {
 
 	// Start background listener for Telegram events
 	go s.listenForEvents(ctx)
+	go s.runScheduler(ctx)
 
 	log.Println("Starting MCP server in stdio mode...")
 	return server.ServeStdio(s.mcpServer)
@@ -1009,6 +1170,7 @@ func (s *Server) Run(ctx context.Context) error {
 // RunStandalone runs only Telegram bot (for testing without MCP)
 func (s *Server) RunStandalone(ctx context.Context) error {
 	log.Println("Running in standalone mode (Telegram only)...")
+	go s.runScheduler(ctx)
 	s.listenForEvents(ctx)
 	return nil
 }
@@ -1022,6 +1184,10 @@ func (s *Server) listenForEvents(ctx context.Context) {
 	if s.discordBot != nil {
 		discordRespCh = s.discordBot.GetResponseChannel()
 	}
+	var slackRespCh <-chan *slack.UserResponse
+	if s.slackBot != nil {
+		slackRespCh = s.slackBot.GetResponseChannel()
+	}
 
 	for {
 		select {
@@ -1043,6 +1209,10 @@ func (s *Server) listenForEvents(ctx context.Context) {
 			// Discord doesn't use s.chatID (primary chat), it routes primarily by sessionID
 			// which is already handled inside discordBot.handleMessage via GetActiveSession
 
+		case resp := <-slackRespCh:
+			log.Printf("[Slack] Received message from channel %s: %s", resp.ChannelID, resp.Text)
+			// Routed by session inside slackBot.handleMessage, like Discord
+
 		case cb := <-callbackCh:
 			s.handleCallback(ctx, cb)
 		}
@@ -1092,6 +1262,13 @@ func (s *Server) handleCallback(ctx context.Context, cb *telegram.CallbackEvent)
 		}
 		s.tgBot.SendMessage(ctx, cb.ChatID, msg)
 
+	case strings.HasPrefix(cb.Data, "ask:"):
+		// ask:<session>:option:<n>
+		sessionID, option, ok := strings.Cut(strings.TrimPrefix(cb.Data, "ask:"), ":")
+		if ok {
+			s.tgBot.SendToSession(sessionID, option)
+		}
+
 	case strings.HasPrefix(cb.Data, "confirm_yes:"):
 		sessionID := strings.TrimPrefix(cb.Data, "confirm_yes:")
 		s.tgBot.SendToSession(sessionID, "confirm_yes")
@@ -1263,28 +1440,17 @@ func (s *Server) handleSendImage(ctx context.Context, request mcp.CallToolReques
 	caption, _ := args["caption"].(string)
 	sessionID, _ := args["session_id"].(string)
 
-	tg, dg, chatID, channelID := s.resolveChannel(sessionID)
-
-	if dg != nil {
-		if err := dg.SendPhoto(ctx, channelID, imagePath, caption); err != nil {
-			log.Printf("Failed to send image to Discord: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to send image to Discord: %v", err)), nil
+	ch, err := s.router.Send(ctx, sessionID, messenger.Message{ImagePath: imagePath, Caption: caption})
+	if err != nil {
+		if errors.Is(err, messenger.ErrNoTarget) {
+			return mcp.NewToolResultError("chat_id not set"), nil
 		}
-		log.Printf("Image sent to Discord: %s (session: %s)", imagePath, sessionID)
-		return mcp.NewToolResultText("Image sent successfully to Discord"), nil
+		log.Printf("Failed to send image to %s: %v", ch.Name(), err)
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to send image to %s: %v", ch.Name(), err)), nil
 	}
 
-	if chatID == 0 {
-		return mcp.NewToolResultError("chat_id not set"), nil
-	}
-
-	if err := tg.SendPhoto(ctx, chatID, imagePath, caption); err != nil {
-		log.Printf("Failed to send image to Telegram: %v", err)
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to send image to Telegram: %v", err)), nil
-	}
-
-	log.Printf("Image sent to Telegram: %s (session: %s)", imagePath, sessionID)
-	return mcp.NewToolResultText("Image sent successfully to Telegram"), nil
+	log.Printf("Image sent to %s: %s (session: %s)", ch.Name(), imagePath, sessionID)
+	return mcp.NewToolResultText("Image sent successfully to " + ch.Name()), nil
 }
 
 // handleSendCodeBlock sends a formatted code block to user
@@ -1304,28 +1470,17 @@ func (s *Server) handleSendCodeBlock(ctx context.Context, request mcp.CallToolRe
 
 	sessionID, _ := args["session_id"].(string)
 
-	tg, dg, chatID, channelID := s.resolveChannel(sessionID)
-
-	if dg != nil {
-		if err := dg.SendCodeBlock(ctx, channelID, language, code); err != nil {
-			log.Printf("Failed to send code block to Discord: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to send code block to Discord: %v", err)), nil
+	ch, err := s.router.Send(ctx, sessionID, messenger.Message{Code: code, Language: language})
+	if err != nil {
+		if errors.Is(err, messenger.ErrNoTarget) {
+			return mcp.NewToolResultError("chat_id not set"), nil
 		}
-		log.Printf("Code block sent to Discord (session: %s, language: %s)", sessionID, language)
-		return mcp.NewToolResultText("Code block sent successfully to Discord"), nil
+		log.Printf("Failed to send code block to %s: %v", ch.Name(), err)
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to send code block to %s: %v", ch.Name(), err)), nil
 	}
 
-	if chatID == 0 {
-		return mcp.NewToolResultError("chat_id not set"), nil
-	}
-
-	if err := tg.SendCodeBlock(ctx, chatID, language, code); err != nil {
-		log.Printf("Failed to send code block to Telegram: %v", err)
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to send code block to Telegram: %v", err)), nil
-	}
-
-	log.Printf("Code block sent to Telegram (session: %s, language: %s)", sessionID, language)
-	return mcp.NewToolResultText("Code block sent successfully to Telegram"), nil
+	log.Printf("Code block sent to %s (session: %s, language: %s)", ch.Name(), sessionID, language)
+	return mcp.NewToolResultText("Code block sent successfully to " + ch.Name()), nil
 }
 
 // handleBrowserSearch performs a web search using DuckDuckGo
@@ -1339,7 +1494,7 @@ func (s *Server) handleBrowserSearch(ctx context.Context, request mcp.CallToolRe
 	}
 
 	searchURL := fmt.Sprintf("https://api.duckduckgo.com/?q=%s&format=json&no_html=1&skip_disambig=1", url.QueryEscape(query))
-	resp, err := http.Get(searchURL)
+	resp, err := httpclient.Client(30 * time.Second).Get(searchURL)
 	if err != nil {
 		log.Printf("Search failed: %v", err)
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to perform search: %v", err)), nil
@@ -1371,8 +1526,12 @@ func (s *Server) handleVoiceReply(ctx context.Context, request mcp.CallToolReque
 		return mcp.NewToolResultError("OPENAI_API_KEY environment variable is not set. Voice reply requires an OpenAI API key."), nil
 	}
 
-	// 1. Resolve delivery channel
-	tg, dg, chatID, channelID := s.resolveChannel(sessionID)
+	// 1. Check there is somewhere to deliver to
+	playAloud := s.discordBot != nil && s.discordBot.VoiceEnabled()
+	_, targets := s.router.Resolve(sessionID)
+	if len(targets) == 0 && !playAloud {
+		return mcp.NewToolResultError("chat_id not set"), nil
+	}
 
 	// 2. Prepare TTS request (OpenAI)
 	tempDir := filepath.Join(os.TempDir(), "ricochet_tts")
@@ -1388,7 +1547,7 @@ func (s *Server) handleVoiceReply(ctx context.Context, request mcp.CallToolReque
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.Client(2 * time.Minute).Do(req)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("TTS API request failed: %v", err)), nil
 	}
@@ -1412,19 +1571,23 @@ func (s *Server) handleVoiceReply(ctx context.Context, request mcp.CallToolReque
 	}
 
 	// 4. Send to user
-	if dg != nil {
-		if err := dg.SendVoice(ctx, channelID, outputPath); err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to send voice to Discord: %v", err)), nil
-		}
-	} else {
-		if chatID == 0 {
-			return mcp.NewToolResultError("Telegram chat_id not set"), nil
-		}
-		if err := tg.SendVoice(ctx, chatID, outputPath); err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to send voice to Telegram: %v", err)), nil
+	if len(targets) > 0 {
+		if ch, err := s.router.Send(ctx, sessionID, messenger.Message{VoicePath: outputPath}); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to send voice to %s: %v", ch.Name(), err)), nil
 		}
 	}
 
+	// 5. Play it in the voice channel, in the background: it takes as long as the speech
+	if playAloud {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			defer cancel()
+			if err := s.discordBot.PlayVoice(ctx, outputPath); err != nil {
+				log.Printf("Failed to play voice reply in Discord: %v", err)
+			}
+		}()
+	}
+
 	log.Printf("Voice reply sent (session: %s, text size: %d)", sessionID, len(text))
 	return mcp.NewToolResultText("Voice reply sent successfully"), nil
 }
@@ -0,0 +1,28 @@
+package mcp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/state"
+)
+
+func TestParseRecipients(t *testing.T) {
+	got, err := parseRecipients(" 123, -1001234567890:42 ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []state.Recipient{{ChatID: 123}, {ChatID: -1001234567890, ThreadID: 42}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRecipients = %+v, want %+v", got, want)
+	}
+
+	if got, err := parseRecipients(""); err != nil || len(got) != 0 {
+		t.Errorf("empty list = %+v, %v", got, err)
+	}
+	for _, bad := range []string{"abc", "123:x", "123:0", "0"} {
+		if _, err := parseRecipients(bad); err == nil {
+			t.Errorf("parseRecipients(%q) accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// Package mcpserver exposes the Ricochet agent as an MCP server, so other
+// coding agents (Claude Code, Cursor, ...) can hand whole tasks to it.
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/igoryan-dao/ricochet/internal/agent"
+	"github.com/igoryan-dao/ricochet/internal/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Server serves the agent's chat, task and code search over MCP
+type Server struct {
+	mcpServer *server.MCPServer
+
+	newAgent func() (*agent.Controller, error)
+	mu       sync.Mutex
+	agent    *agent.Controller
+}
+
+// New creates the server. newAgent is called on the first tool call, so
+// clients can connect and list tools before a provider is configured.
+func New(version string, newAgent func() (*agent.Controller, error)) *Server {
+	s := &Server{newAgent: newAgent}
+	s.mcpServer = server.NewMCPServer(
+		"ricochet",
+		version,
+		server.WithToolCapabilities(false),
+		server.WithInstructions("Ricochet is a coding agent working in the same workspace. Delegate self-contained tasks with ricochet_run_task, hold a multi-turn conversation with ricochet_chat, and find code by meaning with ricochet_search_code."),
+	)
+	s.registerTools()
+	return s
+}
+
+func (s *Server) registerTools() {
+	s.mcpServer.AddTool(mcp.NewTool("ricochet_chat",
+		mcp.WithDescription("Send a message to the Ricochet agent and get its reply. The agent can read and edit files and run tools in the workspace. Pass the returned session_id to continue the conversation."),
+		mcp.WithString("message", mcp.Required(), mcp.Description("The message for the agent")),
+		mcp.WithString("session_id", mcp.Description("Session to continue. Omit to start a new one")),
+	), s.handleChat)
+
+	s.mcpServer.AddTool(mcp.NewTool("ricochet_run_task",
+		mcp.WithDescription("Hand a whole task to the Ricochet agent, which works on it autonomously in a fresh session until it reports completion or failure. Returns a summary of what was done."),
+		mcp.WithString("task", mcp.Required(), mcp.Description("What to accomplish")),
+		mcp.WithString("context", mcp.Description("Relevant background: files, constraints, what was already tried")),
+		mcp.WithString("role", mcp.Description("Agent specialization"), mcp.Enum("general", "architect", "qa", "researcher")),
+	), s.handleRunTask)
+
+	s.mcpServer.AddTool(mcp.NewTool("ricochet_search_code",
+		mcp.WithDescription("Semantic search over the workspace's code index: finds code by what it does rather than by exact text"),
+		mcp.WithString("query", mcp.Required(), mcp.Description("Natural-language description of the code to find")),
+		mcp.WithNumber("limit", mcp.Description("Maximum results (default 5)")),
+	), s.handleSearchCode)
+}
+
+// Serve handles MCP requests on in/out until ctx is done or in is closed
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	return server.NewStdioServer(s.mcpServer).Listen(ctx, in, out)
+}
+
+// controller returns the agent, creating it on first use
+func (s *Server) controller() (*agent.Controller, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.agent != nil {
+		return s.agent, nil
+	}
+	c, err := s.newAgent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize agent: %w", err)
+	}
+	s.agent = c
+	return c, nil
+}
+
+func (s *Server) handleChat(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	message, err := request.RequireString("message")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	c, err := s.controller()
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	sessionID := request.GetString("session_id", "")
+	if sessionID == "" {
+		sessionID = c.CreateSession().ID
+	}
+
+	progress := newProgress(ctx, request)
+	var reply string
+	err = c.Chat(ctx, agent.ChatRequestInput{
+		SessionID: sessionID,
+		Content:   message,
+		Via:       "mcp",
+	}, func(update interface{}) {
+		u, ok := update.(agent.ChatUpdate)
+		if !ok || u.Message.Role != "assistant" {
+			return
+		}
+		progress.report(u.Message.ToolCalls)
+		if !u.Message.IsStreaming && u.Message.Content != "" {
+			reply = u.Message.Content
+		}
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("chat failed: %v\n\nsession_id: %s", err, sessionID)), nil
+	}
+	if reply == "" {
+		reply = "(no reply)"
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s\n\nsession_id: %s", reply, sessionID)), nil
+}
+
+func (s *Server) handleRunTask(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	task, err := request.RequireString("task")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	c, err := s.controller()
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// The subtask forwards its updates to the callback it finds in ctx
+	progress := newProgress(ctx, request)
+	ctx = context.WithValue(ctx, "chat_callback", func(update interface{}) {
+		if u, ok := update.(agent.ChatUpdate); ok {
+			progress.report(u.Message.ToolCalls)
+		}
+	})
+	out, err := c.RunSubtask(ctx, "", task, request.GetString("context", ""), request.GetString("role", "general"))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("task failed: %v", err)), nil
+	}
+	var result tools.SubtaskResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		return mcp.NewToolResultText(out), nil
+	}
+	if result.Status != "success" {
+		msg := "Task failed: " + result.Error
+		if result.RecoveryHint != "" {
+			msg += "\n" + result.RecoveryHint
+		}
+		return mcp.NewToolResultError(msg), nil
+	}
+	// Forwarded updates carry the subtask marker
+	return mcp.NewToolResultText(strings.TrimPrefix(result.Summary, "nested > ")), nil
+}
+
+func (s *Server) handleSearchCode(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, err := request.RequireString("query")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	c, err := s.controller()
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	args, _ := json.Marshal(map[string]interface{}{
+		"query": query,
+		"limit": request.GetInt("limit", 5),
+	})
+	out, err := c.ExecuteTool(ctx, "codebase_search", args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(out), nil
+}
+
+// progress forwards the agent's tool calls as MCP progress notifications
+// when the client asked for them with a progress token
+type progress struct {
+	ctx   context.Context
+	token mcp.ProgressToken
+	mu    sync.Mutex
+	seen  map[string]bool // Tool call IDs already reported
+}
+
+func newProgress(ctx context.Context, request mcp.CallToolRequest) *progress {
+	p := &progress{ctx: ctx, seen: make(map[string]bool)}
+	if request.Params.Meta != nil {
+		p.token = request.Params.Meta.ProgressToken
+	}
+	return p
+}
+
+// report announces each tool call the first time it is seen
+func (p *progress) report(calls []agent.ToolCallInfo) {
+	if p.token == nil {
+		return
+	}
+	srv := server.ServerFromContext(p.ctx)
+	if srv == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, tc := range calls {
+		if tc.ID == "" || p.seen[tc.ID] {
+			continue
+		}
+		p.seen[tc.ID] = true
+		err := srv.SendNotificationToClient(p.ctx, "notifications/progress", map[string]any{
+			"progressToken": p.token,
+			"progress":      len(p.seen),
+			"message":       "Running " + tc.Name,
+		})
+		if err != nil {
+			log.Printf("[MCP] progress notification failed: %v", err)
+		}
+	}
+}
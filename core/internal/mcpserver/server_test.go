@@ -0,0 +1,222 @@
+package mcpserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/agent"
+	"github.com/igoryan-dao/ricochet/internal/host"
+	"github.com/igoryan-dao/ricochet/internal/modes"
+)
+
+// fakeModel is an OpenAI-compatible endpoint that answers the first turn of
+// a chat with a write_file call and the turn after the tool result with text.
+// It records the tool results it is sent.
+type fakeModel struct {
+	mu          sync.Mutex
+	toolResults []string
+}
+
+func (m *fakeModel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+		Tools []json.RawMessage `json:"tools"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	answered := false
+	for _, msg := range req.Messages {
+		if msg.Role == "tool" {
+			m.mu.Lock()
+			m.toolResults = append(m.toolResults, msg.Content)
+			m.mu.Unlock()
+			answered = true
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	if len(req.Tools) > 0 && !answered {
+		args, _ := json.Marshal(`{"path": "pwned.txt", "content": "x"}`)
+		fmt.Fprintf(w, `data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"write_file","arguments":%s}}]}}]}`+"\n\n", args)
+		io.WriteString(w, `data: {"choices":[{"delta":{},"finish_reason":"tool_calls"}]}`+"\n\n")
+	} else {
+		io.WriteString(w, `data: {"choices":[{"delta":{"content":"Done."}}]}`+"\n\n")
+		io.WriteString(w, `data: {"choices":[{"delta":{},"finish_reason":"stop"}]}`+"\n\n")
+	}
+	io.WriteString(w, "data: [DONE]\n\n")
+}
+
+// mcpClient talks JSON-RPC to a Server over pipes, as a stdio client would
+type mcpClient struct {
+	t      *testing.T
+	in     io.Writer
+	out    *bufio.Scanner
+	nextID int
+}
+
+func startServer(t *testing.T, s *Server) *mcpClient {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Serve(ctx, inR, outW)
+	t.Cleanup(func() {
+		cancel()
+		inW.Close()
+		outW.Close()
+	})
+
+	out := bufio.NewScanner(outR)
+	out.Buffer(make([]byte, 1<<20), 1<<20)
+	c := &mcpClient{t: t, in: inW, out: out}
+	c.call("initialize", map[string]any{
+		"protocolVersion": "2024-11-05",
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": "test", "version": "1"},
+	})
+	return c
+}
+
+// call sends a request and returns the result of its response, skipping
+// notifications
+func (c *mcpClient) call(method string, params any) json.RawMessage {
+	c.t.Helper()
+	c.nextID++
+	req, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": c.nextID, "method": method, "params": params})
+	if _, err := c.in.Write(append(req, '\n')); err != nil {
+		c.t.Fatal(err)
+	}
+	for c.out.Scan() {
+		var resp struct {
+			ID     *int            `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(c.out.Bytes(), &resp); err != nil {
+			c.t.Fatalf("invalid response %s: %v", c.out.Bytes(), err)
+		}
+		if resp.ID == nil || *resp.ID != c.nextID {
+			continue
+		}
+		if resp.Error != nil {
+			c.t.Fatalf("%s failed: %s", method, resp.Error.Message)
+		}
+		return resp.Result
+	}
+	c.t.Fatalf("%s: no response (%v)", method, c.out.Err())
+	return nil
+}
+
+// toolText calls a tool and returns its text and whether it is an error
+func (c *mcpClient) toolText(name string, args map[string]any) (string, bool) {
+	c.t.Helper()
+	var result struct {
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	}
+	json.Unmarshal(c.call("tools/call", map[string]any{"name": name, "arguments": args}), &result)
+	var texts []string
+	for _, content := range result.Content {
+		texts = append(texts, content.Text)
+	}
+	return strings.Join(texts, "\n"), result.IsError
+}
+
+func TestToolsList(t *testing.T) {
+	started := false
+	c := startServer(t, New("test", func() (*agent.Controller, error) {
+		started = true
+		return nil, fmt.Errorf("no provider")
+	}))
+
+	var list struct {
+		Tools []struct {
+			Name        string `json:"name"`
+			InputSchema struct {
+				Required []string `json:"required"`
+			} `json:"inputSchema"`
+		} `json:"tools"`
+	}
+	json.Unmarshal(c.call("tools/list", map[string]any{}), &list)
+	var names []string
+	for _, tool := range list.Tools {
+		names = append(names, tool.Name+"("+strings.Join(tool.InputSchema.Required, ",")+")")
+	}
+	if got := strings.Join(names, " "); got != "ricochet_chat(message) ricochet_run_task(task) ricochet_search_code(query)" {
+		t.Errorf("tools = %s", got)
+	}
+	if started {
+		t.Error("listing tools created the agent")
+	}
+
+	// Without an agent, calls fail as tool errors rather than protocol errors
+	if text, isError := c.toolText("ricochet_chat", map[string]any{"message": "hi"}); !isError || !strings.Contains(text, "no provider") {
+		t.Errorf("call without an agent = %q (error %v)", text, isError)
+	}
+	if text, isError := c.toolText("ricochet_chat", map[string]any{}); !isError || !strings.Contains(text, "message") {
+		t.Errorf("call without a message = %q (error %v)", text, isError)
+	}
+}
+
+func TestChatConsentFailsClosed(t *testing.T) {
+	home, dir := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_COMMITTER_NAME", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(name, "test@example.com")
+	}
+	t.Chdir(dir)
+	model := &fakeModel{}
+	srv := httptest.NewServer(model)
+	defer srv.Close()
+
+	c := startServer(t, New("test", func() (*agent.Controller, error) {
+		cfg := &agent.Config{Provider: agent.ProviderConfig{Provider: "openai", APIKey: "key", Model: "test", BaseURL: srv.URL}}
+		return agent.NewController(cfg, agent.ControllerOptions{
+			Host:  host.NewNativeHost(dir),
+			Modes: modes.NewManager(dir),
+		})
+	}))
+
+	done := make(chan struct{})
+	var text string
+	var isError bool
+	go func() {
+		defer close(done)
+		text, isError = c.toolText("ricochet_chat", map[string]any{"message": "write a file"})
+	}()
+	select {
+	case <-done:
+	case <-time.After(60 * time.Second):
+		t.Fatal("chat did not finish")
+	}
+
+	if isError || !strings.Contains(text, "Done.") || !strings.Contains(text, "session_id: ") {
+		t.Errorf("chat result = %q (error %v)", text, isError)
+	}
+	// Nobody can answer the approval over MCP, so the write is refused
+	if _, err := os.Stat(filepath.Join(dir, "pwned.txt")); err == nil {
+		t.Error("write_file ran without approval")
+	}
+	model.mu.Lock()
+	defer model.mu.Unlock()
+	if len(model.toolResults) == 0 || !strings.Contains(strings.ToLower(strings.Join(model.toolResults, "\n")), "consent") {
+		t.Errorf("tool results sent to the model = %q", model.toolResults)
+	}
+}
@@ -0,0 +1,230 @@
+package memory
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SourceLearned marks memories extracted from finished sessions, as opposed
+// to ones set by the user or the remember tool. Only learned memories decay.
+const SourceLearned = "learned"
+
+const (
+	learnedHalfLife    = 30 * 24 * time.Hour // A learned fact's weight halves in this time unless confirmed again
+	forgetBelow        = 0.25                // Learned facts weighing less are forgotten: one seen once lasts 60 days
+	maxLearned         = 60                  // Learned facts kept, the lightest dropped first
+	maxPending         = 30                  // Unreviewed facts kept, likewise
+	maxLearnedInPrompt = 15
+	sameFactSimilarity = 0.5 // Word overlap (Jaccard) at which two facts are taken as the same, the newer wording winning
+)
+
+// Learn stores facts extracted from a session. A fact close to one already
+// learned confirms it instead of adding a duplicate: its weight goes up and
+// its decay restarts. With review, new facts wait in Pending until approved;
+// confirmations of approved facts apply right away. Forgotten facts are
+// pruned on the way.
+func (m *Manager) Learn(facts []string, review bool) (added, confirmed int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, fact := range facts {
+		fact = strings.TrimSpace(fact)
+		if fact == "" {
+			continue
+		}
+		if key, ok := m.findFactLocked(m.store.Memories, fact); ok {
+			if key == "" {
+				continue // Already a manual memory
+			}
+			m.store.Memories[key] = confirm(m.store.Memories[key], fact, now)
+			confirmed++
+			continue
+		}
+		if key, ok := m.findFactLocked(m.store.Pending, fact); ok {
+			m.store.Pending[key] = confirm(m.store.Pending[key], fact, now)
+			confirmed++
+			continue
+		}
+		key := learnedKey(fact)
+		item := MemoryItem{Key: key, Value: fact, Timestamp: now, Source: SourceLearned, LastSeen: now}
+		if review {
+			m.store.Pending[key] = item
+		} else {
+			m.store.Memories[key] = item
+		}
+		added++
+	}
+	m.pruneLocked(m.store.Memories, maxLearned, now)
+	m.pruneLocked(m.store.Pending, maxPending, now)
+	return added, confirmed, m.save()
+}
+
+// confirm records another session stating a learned fact
+func confirm(item MemoryItem, fact string, now time.Time) MemoryItem {
+	item.Value = fact // The latest wording
+	item.Hits++
+	item.LastSeen = now
+	return item
+}
+
+// Pending returns the learned facts waiting for review, oldest first
+func (m *Manager) Pending() []MemoryItem {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	items := make([]MemoryItem, 0, len(m.store.Pending))
+	for _, item := range m.store.Pending {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].Timestamp.Equal(items[j].Timestamp) {
+			return items[i].Timestamp.Before(items[j].Timestamp)
+		}
+		return items[i].Key < items[j].Key
+	})
+	return items
+}
+
+// Approve makes a pending fact permanent, reworded to value unless it's empty
+func (m *Manager) Approve(key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.store.Pending[key]
+	if !ok {
+		return fmt.Errorf("no pending memory %q", key)
+	}
+	delete(m.store.Pending, key)
+	if value = strings.TrimSpace(value); value != "" {
+		item.Value = value
+	}
+	item.LastSeen = time.Now() // Decay starts at approval
+	m.store.Memories[key] = item
+	return m.save()
+}
+
+// Reject drops a pending fact
+func (m *Manager) Reject(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.store.Pending[key]; !ok {
+		return fmt.Errorf("no pending memory %q", key)
+	}
+	delete(m.store.Pending, key)
+	return m.save()
+}
+
+// Learned returns the learned facts still remembered, heaviest first
+func (m *Manager) Learned() []MemoryItem {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	now := time.Now()
+	var items []MemoryItem
+	for _, item := range m.store.Memories {
+		if item.Source == SourceLearned && weight(item, now) >= forgetBelow {
+			items = append(items, item)
+		}
+	}
+	sortByWeight(items, now)
+	return items
+}
+
+// findFactLocked finds a learned fact in items saying the same as fact. A
+// manual memory saying it counts too, with an empty key, so it isn't
+// learned again.
+func (m *Manager) findFactLocked(items map[string]MemoryItem, fact string) (string, bool) {
+	words := factWords(fact)
+	best, bestKey := 0.0, ""
+	for key, item := range items {
+		if sim := similarity(words, factWords(item.Value)); sim > best {
+			best, bestKey = sim, key
+		}
+	}
+	if best < sameFactSimilarity {
+		return "", false
+	}
+	if items[bestKey].Source != SourceLearned {
+		return "", true
+	}
+	return bestKey, true
+}
+
+// pruneLocked forgets learned facts in items that decayed, and the lightest
+// beyond limit
+func (m *Manager) pruneLocked(items map[string]MemoryItem, limit int, now time.Time) {
+	var learned []MemoryItem
+	for key, item := range items {
+		if item.Source != SourceLearned {
+			continue
+		}
+		if weight(item, now) < forgetBelow {
+			delete(items, key)
+			continue
+		}
+		learned = append(learned, item)
+	}
+	if len(learned) > limit {
+		sortByWeight(learned, now)
+		for _, item := range learned[limit:] {
+			delete(items, item.Key)
+		}
+	}
+}
+
+// weight is how much a learned fact counts: one per session that stated it,
+// halving every learnedHalfLife since the last
+func weight(item MemoryItem, now time.Time) float64 {
+	last := item.LastSeen
+	if last.IsZero() {
+		last = item.Timestamp
+	}
+	return float64(1+item.Hits) * math.Pow(0.5, now.Sub(last).Hours()/learnedHalfLife.Hours())
+}
+
+func sortByWeight(items []MemoryItem, now time.Time) {
+	sort.SliceStable(items, func(i, j int) bool { return weight(items[i], now) > weight(items[j], now) })
+}
+
+func learnedKey(fact string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(fact)))
+	return "learned_" + hex.EncodeToString(sum[:4])
+}
+
+// factWords returns the distinct lowercase words of a fact, without filler
+func factWords(fact string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(fact), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != '.'
+	}) {
+		w = strings.Trim(w, ".")
+		if w != "" && !fillerWords[w] {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+var fillerWords = map[string]bool{
+	"the": true, "a": true, "an": true, "is": true, "are": true, "uses": true, "use": true, "with": true,
+	"for": true, "of": true, "to": true, "in": true, "and": true, "project": true, "this": true, "be": true,
+	"should": true, "via": true, "by": true, "run": true, "runs": true,
+}
+
+// similarity is the Jaccard index of two word sets
+func similarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLearn_Dedup(t *testing.T) {
+	m, err := NewManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, confirmed, err := m.Learn([]string{"Project uses pnpm, not npm", "Tests run with make test"}, false)
+	if err != nil || added != 2 || confirmed != 0 {
+		t.Fatalf("Learn = %d, %d, %v", added, confirmed, err)
+	}
+	added, confirmed, _ = m.Learn([]string{"Use pnpm instead of npm", "The CI deploys from main"}, false)
+	if added != 1 || confirmed != 1 {
+		t.Errorf("second Learn = %d added, %d confirmed; want 1, 1", added, confirmed)
+	}
+
+	learned := m.Learned()
+	if len(learned) != 3 || learned[0].Value != "Use pnpm instead of npm" || learned[0].Hits != 1 {
+		t.Errorf("learned = %+v, want the confirmed fact first, in its latest wording", learned)
+	}
+
+	// Survives a restart
+	m2, err := NewManager(m.cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m2.Learned()) != 3 {
+		t.Errorf("reloaded %d learned facts", len(m2.Learned()))
+	}
+}
+
+func TestLearn_SkipsManualFacts(t *testing.T) {
+	m, _ := NewManager(t.TempDir())
+	m.Set("test_cmd", "Tests run with make test")
+	added, confirmed, _ := m.Learn([]string{"tests run with make test"}, false)
+	if added != 0 || confirmed != 0 || len(m.Learned()) != 0 {
+		t.Errorf("a manual memory was learned again: %d added, %d confirmed", added, confirmed)
+	}
+}
+
+func TestLearn_Decay(t *testing.T) {
+	m, _ := NewManager(t.TempDir())
+	old := time.Now().Add(-61 * 24 * time.Hour)
+	m.store.Memories["learned_old"] = MemoryItem{Key: "learned_old", Value: "Deploys use Heroku", Timestamp: old, LastSeen: old, Source: SourceLearned}
+	m.store.Memories["learned_kept"] = MemoryItem{Key: "learned_kept", Value: "Docs live in docs/", Timestamp: old, LastSeen: old, Source: SourceLearned, Hits: 2}
+	m.store.Memories["manual"] = MemoryItem{Key: "manual", Value: "Never touch vendor/", Timestamp: old}
+
+	if _, _, err := m.Learn([]string{"Linting runs golangci-lint"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Get("learned_old"); ok {
+		t.Error("a fact seen once 61 days ago was not forgotten")
+	}
+	if _, ok := m.Get("learned_kept"); !ok {
+		t.Error("a fact confirmed three times was forgotten")
+	}
+	if _, ok := m.Get("manual"); !ok {
+		t.Error("manual memories must not decay")
+	}
+}
+
+func TestGetSystemPromptPart_Learned(t *testing.T) {
+	m, _ := NewManager(t.TempDir())
+	m.Set("deploy_cmd", "make deploy")
+	m.Learn([]string{"Project uses pnpm"}, false)
+	part := m.GetSystemPromptPart()
+	if !strings.Contains(part, "**deploy_cmd**: make deploy") || !strings.Contains(part, "Learned in earlier sessions:\n- Project uses pnpm") {
+		t.Errorf("prompt part = %q", part)
+	}
+}
+
+func TestLearn_Review(t *testing.T) {
+	m, _ := NewManager(t.TempDir())
+	m.Learn([]string{"Project uses pnpm"}, false)
+	added, confirmed, _ := m.Learn([]string{"Tests run with make test", "Linting runs golangci-lint", "The project uses pnpm"}, true)
+	if added != 2 || confirmed != 1 {
+		t.Errorf("Learn = %d added, %d confirmed; want 2, 1", added, confirmed)
+	}
+	pending := m.Pending()
+	if len(pending) != 2 || len(m.Learned()) != 1 {
+		t.Fatalf("pending = %+v, learned = %+v", pending, m.Learned())
+	}
+	if strings.Contains(m.GetSystemPromptPart(), "make test") {
+		t.Error("a pending fact reached the system prompt")
+	}
+
+	// Restating a pending fact confirms it there
+	if _, confirmed, _ := m.Learn([]string{"tests run with make test"}, true); confirmed != 1 || len(m.Pending()) != 2 {
+		t.Errorf("pending fact not confirmed: %+v", m.Pending())
+	}
+
+	tests, lint := pending[0], pending[1]
+	if lint.Value != "Linting runs golangci-lint" {
+		tests, lint = lint, tests
+	}
+	if err := m.Approve(tests.Key, "Tests run with `make test`"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Reject(lint.Key); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Reject(lint.Key); err == nil {
+		t.Error("rejected a fact twice")
+	}
+	if len(m.Pending()) != 0 || !strings.Contains(m.GetSystemPromptPart(), "- Tests run with `make test`") {
+		t.Errorf("after review: pending %+v, prompt %q", m.Pending(), m.GetSystemPromptPart())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -15,11 +16,15 @@ type MemoryItem struct {
 	Key       string    `json:"key"`
 	Value     string    `json:"value"`
 	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source,omitempty"`    // SourceLearned for facts extracted from sessions
+	Hits      int       `json:"hits,omitempty"`      // Later sessions that confirmed a learned fact
+	LastSeen  time.Time `json:"last_seen,omitempty"` // Last confirmation of a learned fact
 }
 
 // MemoryStore is the on-disk format
 type MemoryStore struct {
 	Memories map[string]MemoryItem `json:"memories"`
+	Pending  map[string]MemoryItem `json:"pending,omitempty"` // Learned facts waiting for review
 }
 
 // Manager handles persistence of memories
@@ -44,6 +49,7 @@ func NewManager(cwd string) (*Manager, error) {
 		filePath: filePath,
 		store: &MemoryStore{
 			Memories: make(map[string]MemoryItem),
+			Pending:  make(map[string]MemoryItem),
 		},
 	}
 
@@ -72,6 +78,9 @@ func (m *Manager) load() error {
 	if m.store.Memories == nil {
 		m.store.Memories = make(map[string]MemoryItem)
 	}
+	if m.store.Pending == nil {
+		m.store.Pending = make(map[string]MemoryItem)
+	}
 
 	return nil
 }
@@ -140,6 +149,7 @@ func (m *Manager) Clear() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.store.Memories = make(map[string]MemoryItem)
+	m.store.Pending = make(map[string]MemoryItem)
 	return m.save()
 }
 
@@ -173,14 +183,25 @@ func (m *Manager) GetSystemPromptPart() string {
 		return ""
 	}
 
+	var manual, learned []MemoryItem
+	now := time.Now()
+	for _, item := range items {
+		if item.Source != SourceLearned {
+			manual = append(manual, item)
+		} else if weight(item, now) >= forgetBelow {
+			learned = append(learned, item)
+		}
+	}
+	sort.Slice(manual, func(i, j int) bool { return manual[i].Timestamp.After(manual[j].Timestamp) })
+	sortByWeight(learned, now)
+
 	var sb strings.Builder
 	sb.WriteString("\n\n### 🧠 Permanent Memory (Project Facts)\n")
 	sb.WriteString("Retrieved from .ricochet/memory.json:\n")
 
-	// Limit to top 20 recent? Or just all for now.
-	// 50 items max to avoid polluting context too much.
+	// 30 items max to avoid polluting context too much
 	count := 0
-	for _, item := range items {
+	for _, item := range manual {
 		if count >= 30 {
 			sb.WriteString("... (more memories hidden)\n")
 			break
@@ -188,6 +209,15 @@ func (m *Manager) GetSystemPromptPart() string {
 		sb.WriteString(fmt.Sprintf("- **%s**: %s\n", item.Key, item.Value))
 		count++
 	}
+	if len(learned) > maxLearnedInPrompt {
+		learned = learned[:maxLearnedInPrompt]
+	}
+	if len(learned) > 0 {
+		sb.WriteString("\nLearned in earlier sessions:\n")
+		for _, item := range learned {
+			sb.WriteString(fmt.Sprintf("- %s\n", item.Value))
+		}
+	}
 
 	return sb.String()
 }
@@ -0,0 +1,117 @@
+// Package messenger is the common face of the chat platforms the MCP bridge
+// talks through. Each platform implements Channel in its own package; a
+// Router picks the channel a session is active in.
+package messenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrNoTarget is returned when a session has nowhere to send to
+var ErrNoTarget = errors.New("chat_id not set")
+
+// Button is an inline button. Its Data comes back as the reply when pressed.
+type Button struct {
+	Text string
+	Data string
+}
+
+// Message is rich content. Set fields are sent in order: text with its
+// buttons and diff, code, image, voice. Channels without buttons drop them,
+// so anything the user must see belongs in Text.
+type Message struct {
+	Text      string
+	Buttons   [][]Button
+	Diff      string // Unified diff shown with Text, e.g. of an edit to approve
+	DiffPath  string // File the diff is for
+	Code      string
+	Language  string
+	ImagePath string
+	Caption   string
+	VoicePath string
+}
+
+// Channel is a chat platform. Targets are platform addresses as strings:
+// a channel ID, or a chat with an optional topic.
+type Channel interface {
+	// Name is the platform's display name, e.g. "Telegram"
+	Name() string
+	// Targets lists where a session's messages go, none if the session is
+	// not active on this platform
+	Targets(sessionID string) []string
+
+	Send(ctx context.Context, target, text string) error
+	SendRich(ctx context.Context, target string, msg Message) error
+	// Ask sends a question and waits for the next reply from the target
+	Ask(ctx context.Context, target, question string, buttons [][]Button) (string, error)
+	// Presence shows that the agent is at work, e.g. as a typing indicator
+	Presence(ctx context.Context, target string)
+
+	// RegisterSession delivers a session's replies to ch until unregistered
+	RegisterSession(sessionID string, ch chan string) (unregister func())
+	// Unread drains the replies buffered while nobody listened
+	Unread(sessionID string) []string
+	// Activate routes the target's replies to a session
+	Activate(target, sessionID string)
+	ActiveSession(target string) string
+}
+
+// Router picks the channel for a session
+type Router struct {
+	channels []Channel
+}
+
+// NewRouter creates a router over channels in order of preference. The last
+// one serves sessions that are not active anywhere.
+func NewRouter(channels ...Channel) *Router {
+	return &Router{channels: channels}
+}
+
+// Resolve returns the first channel the session is active in, else the
+// fallback channel, with the session's targets there
+func (r *Router) Resolve(sessionID string) (Channel, []string) {
+	if len(r.channels) == 0 {
+		return nil, nil
+	}
+	for _, ch := range r.channels[:len(r.channels)-1] {
+		if targets := ch.Targets(sessionID); len(targets) > 0 {
+			return ch, targets
+		}
+	}
+	fallback := r.channels[len(r.channels)-1]
+	return fallback, fallback.Targets(sessionID)
+}
+
+// Send delivers a message to every target of the session and returns the
+// channel used. It fails only if no target got the message.
+func (r *Router) Send(ctx context.Context, sessionID string, msg Message) (Channel, error) {
+	ch, targets := r.Resolve(sessionID)
+	if len(targets) == 0 {
+		return ch, ErrNoTarget
+	}
+
+	var errs []error
+	for _, target := range targets {
+		ch.Presence(ctx, target)
+		if err := ch.SendRich(ctx, target, msg); err != nil {
+			log.Printf("Failed to send to %s %s: %v", ch.Name(), target, err)
+			errs = append(errs, fmt.Errorf("%s %s: %w", ch.Name(), target, err))
+		}
+	}
+	if len(errs) == len(targets) {
+		return ch, errors.Join(errs...)
+	}
+	return ch, nil
+}
+
+// Unread drains the replies buffered for a session on every channel
+func (r *Router) Unread(sessionID string) []string {
+	var messages []string
+	for _, ch := range r.channels {
+		messages = append(messages, ch.Unread(sessionID)...)
+	}
+	return messages
+}
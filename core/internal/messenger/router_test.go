@@ -0,0 +1,84 @@
+package messenger
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type fakeChannel struct {
+	name    string
+	targets map[string][]string // sessionID -> targets
+	fail    map[string]bool     // targets whose sends fail
+	sent    []string            // "target: text"
+	unread  []string
+}
+
+func (f *fakeChannel) Name() string                      { return f.name }
+func (f *fakeChannel) Targets(sessionID string) []string { return f.targets[sessionID] }
+func (f *fakeChannel) Send(ctx context.Context, target, text string) error {
+	return f.SendRich(ctx, target, Message{Text: text})
+}
+func (f *fakeChannel) SendRich(_ context.Context, target string, msg Message) error {
+	if f.fail[target] {
+		return errors.New("send failed")
+	}
+	f.sent = append(f.sent, target+": "+msg.Text)
+	return nil
+}
+func (f *fakeChannel) Ask(context.Context, string, string, [][]Button) (string, error) {
+	return "", nil
+}
+func (f *fakeChannel) Presence(context.Context, string)           {}
+func (f *fakeChannel) RegisterSession(string, chan string) func() { return func() {} }
+func (f *fakeChannel) Unread(string) []string                     { return f.unread }
+func (f *fakeChannel) Activate(string, string)                    {}
+func (f *fakeChannel) ActiveSession(string) string                { return "" }
+
+func TestRouterResolve(t *testing.T) {
+	discord := &fakeChannel{name: "Discord", targets: map[string][]string{"s1": {"c1"}}}
+	telegram := &fakeChannel{name: "Telegram", targets: map[string][]string{"": {"42"}}}
+	r := NewRouter(discord, telegram)
+
+	if ch, targets := r.Resolve("s1"); ch != discord || !reflect.DeepEqual(targets, []string{"c1"}) {
+		t.Errorf("Resolve(s1) = %s %v, want Discord [c1]", ch.Name(), targets)
+	}
+	// Not active anywhere: the fallback is used even without targets
+	if ch, targets := r.Resolve("s2"); ch != telegram || len(targets) != 0 {
+		t.Errorf("Resolve(s2) = %s %v, want Telegram []", ch.Name(), targets)
+	}
+	if ch, targets := r.Resolve(""); ch != telegram || !reflect.DeepEqual(targets, []string{"42"}) {
+		t.Errorf("Resolve() = %s %v, want Telegram [42]", ch.Name(), targets)
+	}
+}
+
+func TestRouterSend(t *testing.T) {
+	tg := &fakeChannel{
+		name:    "Telegram",
+		targets: map[string][]string{"s1": {"1", "2"}, "s2": {"3"}},
+		fail:    map[string]bool{"2": true, "3": true},
+	}
+	r := NewRouter(tg)
+
+	// One failed target out of two still counts as delivered
+	if _, err := r.Send(context.Background(), "s1", Message{Text: "hi"}); err != nil {
+		t.Errorf("Send(s1) = %v", err)
+	}
+	if want := []string{"1: hi"}; !reflect.DeepEqual(tg.sent, want) {
+		t.Errorf("sent %v, want %v", tg.sent, want)
+	}
+	if _, err := r.Send(context.Background(), "s2", Message{Text: "hi"}); err == nil {
+		t.Error("Send(s2) succeeded with every target failing")
+	}
+	if _, err := r.Send(context.Background(), "s3", Message{Text: "hi"}); !errors.Is(err, ErrNoTarget) {
+		t.Errorf("Send(s3) = %v, want ErrNoTarget", err)
+	}
+}
+
+func TestRouterUnread(t *testing.T) {
+	r := NewRouter(&fakeChannel{unread: []string{"a"}}, &fakeChannel{unread: []string{"b", "c"}})
+	if got, want := r.Unread("s1"), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unread = %v, want %v", got, want)
+	}
+}
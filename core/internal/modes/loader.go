@@ -3,6 +3,10 @@ package modes
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -24,3 +28,71 @@ func (l *Loader) Load(path string) (*Config, error) {
 
 	return &cfg, nil
 }
+
+// LoadMode parses a single mode file from .ricochet/modes. The slug defaults
+// to the file name without its extension, and the name to the slug.
+func (l *Loader) LoadMode(path string) (Mode, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Mode{}, err
+	}
+
+	var mode Mode
+	if err := yaml.Unmarshal(data, &mode); err != nil {
+		return Mode{}, fmt.Errorf("failed to parse mode: %w", err)
+	}
+	if mode.Slug == "" {
+		mode.Slug = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if mode.Name == "" {
+		mode.Name = mode.Slug
+	}
+	return mode, mode.Validate()
+}
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// zoneNames are the zone names safeguard.ParseTrustZone accepts
+var zoneNames = []string{"danger", "safe", "readonly", "read-only", "read_only"}
+
+// Validate reports the first problem that keeps the mode from loading
+func (mode Mode) Validate() error {
+	if !slugPattern.MatchString(mode.Slug) {
+		return fmt.Errorf("invalid slug '%s': use lowercase letters, digits, '-' and '_'", mode.Slug)
+	}
+	if strings.TrimSpace(mode.RoleDefinition) == "" {
+		return fmt.Errorf("mode '%s' has no role_definition", mode.Slug)
+	}
+	for _, group := range mode.ToolGroups {
+		if _, ok := ToolGroupDefinitions[group]; !ok {
+			return fmt.Errorf("mode '%s': unknown tool group '%s' (known: %s)", mode.Slug, group, strings.Join(toolGroupNames(), ", "))
+		}
+	}
+	if mode.Zone != "" && !containsFold(zoneNames, mode.Zone) {
+		return fmt.Errorf("mode '%s': unknown zone '%s' (use danger, safe or readonly)", mode.Slug, mode.Zone)
+	}
+	for _, r := range mode.FileRestrictions {
+		if _, err := regexp.Compile(r.Regex); err != nil {
+			return fmt.Errorf("mode '%s': invalid file_restrictions regex '%s': %w", mode.Slug, r.Regex, err)
+		}
+	}
+	return nil
+}
+
+func toolGroupNames() []string {
+	names := make([]string, 0, len(ToolGroupDefinitions))
+	for name := range ToolGroupDefinitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, strings.TrimSpace(s)) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,9 +2,12 @@ package modes
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -13,10 +16,11 @@ type Manager struct {
 	cwd          string
 	activeMode   string
 	customModes  map[string]Mode
+	loadErrors   []string
 	onModeChange func(slug string)
 	mu           sync.RWMutex
 	loader       *Loader
-	lastModTime  time.Time
+	signature    string
 }
 
 func (m *Manager) SetOnModeChange(fn func(slug string)) {
@@ -35,64 +39,157 @@ func NewManager(cwd string) *Manager {
 	return m
 }
 
+// StartWatcher reloads project modes when .ricochet/modes.yaml or a file in
+// .ricochet/modes is added, changed or removed
 func (m *Manager) StartWatcher() {
 	go func() {
 		ticker := time.NewTicker(2 * time.Second)
 		defer ticker.Stop()
 
-		configPath := filepath.Join(m.cwd, ".ricochet", "modes.yaml")
-
 		for range ticker.C {
-			info, err := os.Stat(configPath)
-			if err != nil {
-				if os.IsNotExist(err) {
-					// File deleted? clear modes?
-					// For now, keep last known good state or do nothing
-					continue
-				}
-				continue
-			}
+			m.mu.RLock()
+			last := m.signature
+			m.mu.RUnlock()
 
-			if info.ModTime().After(m.lastModTime) {
-				// File changed, reload
+			if m.projectSignature() != last {
 				m.LoadFromProject()
 			}
 		}
 	}()
 }
 
-func (m *Manager) LoadFromProject() {
+// projectFiles lists the project mode files: modes.yaml, then .ricochet/modes/*.yaml
+func (m *Manager) projectFiles() []string {
+	var files []string
 	configPath := filepath.Join(m.cwd, ".ricochet", "modes.yaml")
+	if _, err := os.Stat(configPath); err == nil {
+		files = append(files, configPath)
+	}
+	for _, ext := range []string{"*.yaml", "*.yml"} {
+		matches, _ := filepath.Glob(filepath.Join(m.cwd, ".ricochet", "modes", ext))
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files
+}
 
-	info, err := os.Stat(configPath)
-	if err != nil {
-		return
+// projectSignature changes whenever a project mode file is added, removed or modified
+func (m *Manager) projectSignature() string {
+	var sig strings.Builder
+	for _, path := range m.projectFiles() {
+		if info, err := os.Stat(path); err == nil {
+			fmt.Fprintf(&sig, "%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+		}
 	}
+	return sig.String()
+}
 
-	cfg, err := m.loader.Load(configPath)
-	if err != nil {
-		fmt.Printf("Warning: Failed to parse .ricochet/modes.yaml: %v\n", err)
-		return
+// LoadFromProject loads the project modes. Modes in modes.yaml come first;
+// each file in .ricochet/modes holds one mode. A mode that fails validation
+// is skipped and reported by LoadErrors, the others still load.
+func (m *Manager) LoadFromProject() {
+	signature := m.projectSignature()
+	modes := make(map[string]Mode)
+	var errs []string
+	add := func(file string, mode Mode) {
+		if _, dup := modes[mode.Slug]; dup {
+			errs = append(errs, fmt.Sprintf("%s: mode '%s' is already defined", file, mode.Slug))
+			return
+		}
+		mode.Source = "project"
+		modes[mode.Slug] = mode
+	}
+
+	configPath := filepath.Join(m.cwd, ".ricochet", "modes.yaml")
+	for _, path := range m.projectFiles() {
+		rel, _ := filepath.Rel(m.cwd, path)
+		if path == configPath {
+			cfg, err := m.loader.Load(path)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", rel, err))
+				continue
+			}
+			for _, mode := range cfg.CustomModes {
+				if err := mode.Validate(); err != nil {
+					errs = append(errs, fmt.Sprintf("%s: %v", rel, err))
+					continue
+				}
+				add(rel, mode)
+			}
+			continue
+		}
+		mode, err := m.loader.LoadMode(path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", rel, err))
+			continue
+		}
+		add(rel, mode)
+	}
+	for _, e := range errs {
+		log.Printf("Warning: skipped project mode: %s", e)
 	}
 
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.customModes = modes
+	m.loadErrors = errs
+	m.signature = signature
+	if !m.existsLocked(m.activeMode) {
+		// The active project mode was removed
+		m.activeMode = "code"
+	}
+	active := m.activeMode
+	m.mu.Unlock()
 
-	// Clear old project modes
-	m.customModes = make(map[string]Mode)
+	if m.onModeChange != nil {
+		// Notify the current mode again to refresh context
+		m.onModeChange(active)
+	}
+}
 
-	for _, mode := range cfg.CustomModes {
-		mode.Source = "project"
-		m.customModes[mode.Slug] = mode
+// LoadErrors describes the project modes skipped by the last load
+func (m *Manager) LoadErrors() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.loadErrors...)
+}
+
+// ListModes returns the modes that can be switched to: builtins, with project
+// modes of the same slug in their place, then the other project modes by slug
+func (m *Manager) ListModes() []Mode {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]Mode, 0, len(BuiltinModes)+len(m.customModes))
+	for _, mode := range BuiltinModes {
+		if custom, ok := m.customModes[mode.Slug]; ok {
+			mode = custom
+		}
+		list = append(list, mode)
+	}
+	var extra []Mode
+	for slug, mode := range m.customModes {
+		if !isBuiltin(slug) {
+			extra = append(extra, mode)
+		}
 	}
+	sort.Slice(extra, func(i, j int) bool { return extra[i].Slug < extra[j].Slug })
+	return append(list, extra...)
+}
 
-	m.lastModTime = info.ModTime()
+func (m *Manager) existsLocked(slug string) bool {
+	if _, ok := m.customModes[slug]; ok {
+		return true
+	}
+	return isBuiltin(slug)
+}
 
-	// If active mode was updated, trigger callback
-	if m.onModeChange != nil {
-		// Just notify current mode again to refresh context
-		m.onModeChange(m.activeMode)
+func isBuiltin(slug string) bool {
+	for _, mode := range BuiltinModes {
+		if mode.Slug == slug {
+			return true
+		}
 	}
+	return false
 }
 
 func (m *Manager) GetActiveMode() Mode {
@@ -117,21 +214,7 @@ func (m *Manager) SetMode(slug string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Validate slug exists
-	found := false
-	for _, mode := range BuiltinModes {
-		if mode.Slug == slug {
-			found = true
-			break
-		}
-	}
-	if !found {
-		if _, ok := m.customModes[slug]; ok {
-			found = true
-		}
-	}
-
-	if !found {
+	if !m.existsLocked(slug) {
 		return fmt.Errorf("mode %s not found", slug)
 	}
 
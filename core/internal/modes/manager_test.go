@@ -0,0 +1,104 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeMode(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, ".ricochet", "modes"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".ricochet", "modes", name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadProjectModeFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeMode(t, dir, "reviewer.yaml", "name: Reviewer\nrole_definition: You review code.\ntool_groups: [read]\ntools: [http_request]\nzone: readonly\nmodel: small-model\n")
+	writeMode(t, dir, "broken.yaml", "role_definition: x\ntool_groups: [teleport]\n")
+	writeMode(t, dir, "nozone.yaml", "role_definition: x\nzone: lava\n")
+	writeMode(t, dir, "Bad Slug.yaml", "role_definition: x\n")
+	writeMode(t, dir, "empty.yaml", "tool_groups: [read]\n")
+
+	m := &Manager{cwd: dir, activeMode: "code", loader: &Loader{}}
+	m.LoadFromProject()
+
+	if err := m.SetMode("reviewer"); err != nil {
+		t.Fatal(err)
+	}
+	mode := m.GetActiveMode()
+	if mode.Slug != "reviewer" || mode.Source != "project" || mode.Zone != "readonly" || mode.Model != "small-model" {
+		t.Errorf("reviewer: %+v", mode)
+	}
+	if !IsToolAllowed(mode, "read_file") || !IsToolAllowed(mode, "http_request") || IsToolAllowed(mode, "write_file") {
+		t.Errorf("reviewer tools: %+v", mode)
+	}
+
+	errs := strings.Join(m.LoadErrors(), "\n")
+	for _, want := range []string{"unknown tool group 'teleport'", "unknown zone 'lava'", "invalid slug 'Bad Slug'", "has no role_definition"} {
+		if !strings.Contains(errs, want) {
+			t.Errorf("load errors missing %q:\n%s", want, errs)
+		}
+	}
+	for _, slug := range []string{"broken", "nozone", "empty"} {
+		if err := m.SetMode(slug); err == nil {
+			t.Errorf("invalid mode %s was loaded", slug)
+		}
+	}
+}
+
+func TestReloadProjectModes(t *testing.T) {
+	dir := t.TempDir()
+	writeMode(t, dir, "docs.yaml", "role_definition: You write docs.\ntool_groups: [read, edit]\n")
+
+	var notified []string
+	m := &Manager{cwd: dir, activeMode: "code", loader: &Loader{}}
+	m.LoadFromProject()
+	m.SetOnModeChange(func(slug string) { notified = append(notified, slug) })
+	if err := m.SetMode("docs"); err != nil {
+		t.Fatal(err)
+	}
+
+	before := m.projectSignature()
+	if err := os.Remove(filepath.Join(dir, ".ricochet", "modes", "docs.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if m.projectSignature() == before {
+		t.Fatal("signature did not change when a mode file was removed")
+	}
+	m.LoadFromProject()
+
+	if got := m.GetActiveMode().Slug; got != "code" {
+		t.Errorf("active mode after removal = %s, want code", got)
+	}
+	if len(notified) != 2 || notified[1] != "code" {
+		t.Errorf("notified %v", notified)
+	}
+}
+
+func TestListModesProjectOverridesBuiltin(t *testing.T) {
+	dir := t.TempDir()
+	writeMode(t, dir, "test.yaml", "name: Strict Tester\nrole_definition: You only run tests.\ntool_groups: [read, command]\n")
+	writeMode(t, dir, "zeta.yaml", "role_definition: z\n")
+
+	m := &Manager{cwd: dir, activeMode: "code", loader: &Loader{}}
+	m.LoadFromProject()
+	list := m.ListModes()
+
+	if len(list) != len(BuiltinModes)+1 {
+		t.Fatalf("got %d modes", len(list))
+	}
+	for _, mode := range list {
+		if mode.Slug == "test" && mode.Name != "Strict Tester" {
+			t.Errorf("builtin test mode not replaced: %+v", mode)
+		}
+	}
+	if last := list[len(list)-1]; last.Slug != "zeta" || last.Name != "zeta" {
+		t.Errorf("last mode: %+v", last)
+	}
+}
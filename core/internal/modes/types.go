@@ -7,8 +7,11 @@ type Mode struct {
 	RoleDefinition     string            `json:"role_definition" yaml:"role_definition"`
 	CustomInstructions string            `json:"custom_instructions" yaml:"custom_instructions"`
 	ToolGroups         []string          `json:"tool_groups" yaml:"tool_groups"`
+	Tools              []string          `json:"tools,omitempty" yaml:"tools,omitempty"` // Single tools allowed besides ToolGroups
 	FileRestrictions   []FileRestriction `json:"file_restrictions,omitempty" yaml:"file_restrictions,omitempty"`
-	Source             string            `json:"source" yaml:"source"` // project, global, builtin
+	Zone               string            `json:"zone,omitempty" yaml:"zone,omitempty"`   // Default trust zone: danger, safe or readonly
+	Model              string            `json:"model,omitempty" yaml:"model,omitempty"` // Model on the active provider used while the mode is active
+	Source             string            `json:"source" yaml:"source"`                   // project, global, builtin
 }
 
 // FileRestriction limits which files the mode can interact with
@@ -80,12 +83,23 @@ var BuiltinModes = []Mode{
 
 // ToolGroupDefinitions maps group names to specific tools
 var ToolGroupDefinitions = map[string][]string{
-	"read":    {"list_dir", "read_file", "read_definitions"},
-	"edit":    {"write_file"},
+	"read":    {"list_dir", "read_file", "read_definitions", "read_clipboard", "analyze_image", "who_calls", "what_does_this_call", "impact_of_change"},
+	"edit":    {"write_file", "generate_image"},
 	"command": {"execute_command", "command_status"},
-	"browser": {"browser_open", "browser_screenshot", "browser_click", "browser_type"},
-	"mcp":     {"use_mcp_tool", "access_mcp_resource"}, // Placeholder for MCP
-	"always":  {"switch_mode", "update_todos", "restore_checkpoint", "task_boundary", "start_swarm", "update_plan", "start_task", "notify_user"},
+	"browser": {"web_fetch", "browser_open", "browser_screenshot", "browser_click", "browser_type"},
+	"mcp":     {"use_mcp_tool", "access_mcp_resource", "read_mcp_resource"}, // Server tools are registered as they appear
+	"always":  {"switch_mode", "update_todos", "pin_context", "restore_checkpoint", "experiment", "task_boundary", "start_swarm", "update_plan", "start_task", "notify_user", "copy_to_clipboard", "send_desktop_notification"},
+}
+
+// RegisterToolInGroup adds a runtime-defined tool to a tool group so modes
+// using that group can see it
+func RegisterToolInGroup(group, toolName string) {
+	for _, t := range ToolGroupDefinitions[group] {
+		if t == toolName {
+			return
+		}
+	}
+	ToolGroupDefinitions[group] = append(ToolGroupDefinitions[group], toolName)
 }
 
 func IsToolAllowed(mode Mode, toolName string) bool {
@@ -96,6 +110,12 @@ func IsToolAllowed(mode Mode, toolName string) bool {
 		}
 	}
 
+	for _, t := range mode.Tools {
+		if t == toolName {
+			return true
+		}
+	}
+
 	for _, group := range mode.ToolGroups {
 		if tools, ok := ToolGroupDefinitions[group]; ok {
 			for _, t := range tools {
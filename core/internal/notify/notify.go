@@ -0,0 +1,158 @@
+// Package notify sends native desktop notifications (macOS Notification
+// Center, Windows toasts, freedesktop notify-send). It is the fallback alert
+// channel when no messenger (live mode) is active and the TUI is not focused.
+package notify
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minInterval suppresses bursts, e.g. several approvals in a row
+const minInterval = 3 * time.Second
+
+// RemoteChannel is a messenger that already reaches the user (live mode)
+type RemoteChannel interface {
+	IsEnabled() bool
+}
+
+// Desktop delivers notifications through the OS
+type Desktop struct {
+	mu       sync.Mutex
+	enabled  bool
+	focused  bool // Assume the user is looking until the terminal reports otherwise
+	remote   RemoteChannel
+	lastSent time.Time
+	send     func(title, message string) error
+}
+
+// NewDesktop returns a notifier, or one that never fires when the platform has no backend
+func NewDesktop(enabled bool) *Desktop {
+	d := &Desktop{enabled: enabled, focused: true}
+	send, err := platformSender()
+	if err != nil {
+		log.Printf("Desktop notifications unavailable: %v", err)
+		d.enabled = false
+		return d
+	}
+	d.send = send
+	return d
+}
+
+// SetEnabled toggles notifications (settings.auto_approval.enable_notifications)
+func (d *Desktop) SetEnabled(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.enabled = enabled && d.send != nil
+}
+
+// SetFocused records whether the TUI currently has terminal focus
+func (d *Desktop) SetFocused(focused bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.focused = focused
+}
+
+// SetRemote sets the messenger that takes precedence while enabled
+func (d *Desktop) SetRemote(r RemoteChannel) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.remote = r
+}
+
+// Notify shows a notification unless the user is already reachable another way.
+// It never blocks on the OS command.
+func (d *Desktop) Notify(title, message string) {
+	d.mu.Lock()
+	if !d.enabled || d.focused || (d.remote != nil && d.remote.IsEnabled()) || time.Since(d.lastSent) < minInterval {
+		d.mu.Unlock()
+		return
+	}
+	d.lastSent = time.Now()
+	send := d.send
+	d.mu.Unlock()
+
+	go func() {
+		if err := send(title, truncate(message, 200)); err != nil {
+			log.Printf("Desktop notification failed: %v", err)
+		}
+	}()
+}
+
+// Send shows a notification right away, regardless of focus or rate limits.
+// It is meant for alerts the user asked for explicitly.
+func Send(title, message string) error {
+	send, err := platformSender()
+	if err != nil {
+		return err
+	}
+	return send(title, truncate(message, 200))
+}
+
+func platformSender() (func(title, message string) error, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("osascript"); err != nil {
+			return nil, fmt.Errorf("osascript not found: %w", err)
+		}
+		return func(title, message string) error {
+			script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+			return exec.Command("osascript", "-e", script).Run()
+		}, nil
+	case "windows":
+		if _, err := exec.LookPath("powershell"); err != nil {
+			return nil, fmt.Errorf("powershell not found: %w", err)
+		}
+		return func(title, message string) error {
+			return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript(title, message)).Run()
+		}, nil
+	case "linux", "freebsd", "openbsd":
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return nil, fmt.Errorf("notify-send not found (install libnotify): %w", err)
+		}
+		return func(title, message string) error {
+			return exec.Command("notify-send", "--app-name=Ricochet", title, message).Run()
+		}, nil
+	default:
+		return nil, fmt.Errorf("no notification backend for %s", runtime.GOOS)
+	}
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// windowsPowerShellAppID is PowerShell's registered AppUserModelID; toasts from
+// an unregistered ID are silently dropped
+const windowsPowerShellAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+// windowsToastScript builds a PowerShell snippet that raises a WinRT toast
+func windowsToastScript(title, message string) string {
+	esc := func(s string) string {
+		s = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+		return strings.ReplaceAll(s, "'", "''") // PowerShell single-quoted string
+	}
+	return fmt.Sprintf(`[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null
+$xml = New-Object Windows.Data.Xml.Dom.XmlDocument
+$xml.LoadXml('<toast><visual><binding template="ToastGeneric"><text>%s</text><text>%s</text></binding></visual></toast>')
+$toast = [Windows.UI.Notifications.ToastNotification]::new($xml)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('%s').Show($toast)`,
+		esc(title), esc(message), windowsPowerShellAppID)
+}
+
+func truncate(s string, max int) string {
+	r := []rune(strings.TrimSpace(s))
+	if len(r) <= max {
+		return string(r)
+	}
+	return string(r[:max-1]) + "…"
+}
@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeRemote bool
+
+func (f fakeRemote) IsEnabled() bool { return bool(f) }
+
+func TestDesktopNotifyGating(t *testing.T) {
+	sent := make(chan string, 4)
+	d := &Desktop{enabled: true, focused: true, send: func(title, message string) error {
+		sent <- title
+		return nil
+	}}
+
+	expectSent := func(want bool) {
+		t.Helper()
+		select {
+		case <-sent:
+			if !want {
+				t.Fatal("notification sent unexpectedly")
+			}
+		case <-time.After(100 * time.Millisecond):
+			if want {
+				t.Fatal("notification not sent")
+			}
+		}
+	}
+
+	d.Notify("a", "focused")
+	expectSent(false)
+
+	d.SetFocused(false)
+	d.SetRemote(fakeRemote(true))
+	d.Notify("a", "live mode active")
+	expectSent(false)
+
+	d.SetRemote(fakeRemote(false))
+	d.Notify("a", "unfocused")
+	expectSent(true)
+
+	d.Notify("a", "burst")
+	expectSent(false)
+}
+
+func TestAppleScriptString(t *testing.T) {
+	if got := appleScriptString(`say "hi" \ bye`); got != `"say \"hi\" \\ bye"` {
+		t.Errorf("got %s", got)
+	}
+}
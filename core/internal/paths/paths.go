@@ -46,6 +46,29 @@ func GetShadowGitDir(workspaceRoot string) string {
 	return filepath.Join(GetGlobalDir(), "shadow-git", hash)
 }
 
+// GetStatsDir returns the global usage statistics directory for a workspace
+func GetStatsDir(workspaceRoot string) string {
+	hash := GetWorkspaceHash(workspaceRoot)
+	return filepath.Join(GetGlobalDir(), "stats", hash)
+}
+
+// GetCacheDir returns the global directory of rebuildable caches for a workspace
+func GetCacheDir(workspaceRoot string) string {
+	hash := GetWorkspaceHash(workspaceRoot)
+	return filepath.Join(GetGlobalDir(), "cache", hash)
+}
+
+// GetAuditLogPath returns the global approval audit log, shared by all workspaces
+func GetAuditLogPath() string {
+	return filepath.Join(GetGlobalDir(), "audit", "approvals.jsonl")
+}
+
+// GetWebCacheDir returns the global cache of pages fetched by web_fetch,
+// shared by all workspaces
+func GetWebCacheDir() string {
+	return filepath.Join(GetGlobalDir(), "cache", "web")
+}
+
 // EnsureDir creates the directory and all parents if they don't exist
 func EnsureDir(path string) error {
 	return os.MkdirAll(path, 0755)
@@ -5,7 +5,7 @@ func GetToolGuidelines() string {
 TOOL USE GUIDELINES
 
 1.  **Assess needed information:** Before writing code, use tools like 'list_dir' and 'read_file' to understand the existing codebase.
-2.  **Choose the right tool:** Use 'list_dir' to explore directories, 'grep_search' to find code patterns, and 'read_file' to examine file contents.
+2.  **Choose the right tool:** Use 'list_dir' to explore a directory, 'find_by_name' to locate files by glob (never walk the tree with repeated list_dir calls), 'grep_search' to find code patterns, and 'read_file' to examine file contents.
 3.  **Use tools iteratively:** Use the output of one tool to inform the input of the next.
 
 4.  **File Editing - CRITICAL:**
@@ -14,11 +14,15 @@ TOOL USE GUIDELINES
       * TargetContent: must be UNIQUE and EXACT match.
       * ReplacementContent: the new text.
       * This preserves history and allows diff verification.
+    - **Alternative: 'apply_diff'** for several changes in one file, or edits across files.
+      * Pass a unified diff with 2-3 unchanged context lines around each change.
     - **Step 3: Use 'write_file' ONLY for NEW files.**
       * CAUTION: 'write_file' completely overwrites existing files.
       * DO NOT use it to edit files. It destroys the ability to see what changed.
       * Exception: If a file is < 50 lines and you are rewriting 90% of it.
     - NEVER use sed, echo, cat, awk, or other shell commands to modify files.
+    - Use 'delete_file', 'move_file' and 'create_directory' instead of rm, mv and mkdir. Deleted files go to .ricochet/trash.
+    - To rename a function, type or variable, use 'rename_symbol' rather than editing each use by hand. Use 'get_references' to see every use first, and 'hover_docs' for a symbol's signature.
 
 5.  **Shell Commands - Use ONLY for DevOps/Infrastructure:**
     Execute shell commands for tasks that REQUIRE terminal interaction:
@@ -29,6 +33,11 @@ TOOL USE GUIDELINES
     - System: ls, cat (read-only), tail, head, grep, find
     - Build/Run: npm, yarn, go, python, cargo, make
 
-6.  **Use Scripting for Complexity:** When needing to analyze many files, perform calculations, or process data, PREFER writing a Python script using 'execute_python' over making many individual tool calls. This is more efficient and reliable.
+6.  **Use Scripting for Complexity:** When needing to analyze many files, perform calculations, or process data, PREFER writing a Python script using 'execute_python' over making many individual tool calls. This is more efficient and reliable. The kernel is persistent: load data once and reuse the variables in later calls. For JavaScript tooling or shell pipelines, use 'execute_node' or 'execute_bash_script'; pick the 'strict' profile for scripts that do not need the workspace.
+7.  **Test APIs with http_request, read docs with web_fetch:** To call an API or a local dev server, use 'http_request' rather than curl through 'execute_command'. To read documentation or any other web page, use 'web_fetch', which returns the page as markdown; keep the browser for pages that need JavaScript.
+8.  **Inspect databases with the sql_* tools:** Use 'sql_schema' and 'sql_query' (read-only) for configured databases instead of psql/sqlite3 commands. Changes go through 'sql_execute', which needs approval.
+9.  **Drive web pages with the browser_* tools:** The browser tab persists across calls. After 'browser_open', read the page with 'browser_snapshot' and act on elements by their ref ('browser_click', 'browser_type'). Use 'browser_wait' instead of sleeping, and 'browser_logs' to find console errors and failed requests.
+10. **Desktop tools (local sessions):** Use 'copy_to_clipboard' when the user wants to paste your output elsewhere, and 'send_desktop_notification' when they asked to be pinged after a long task. Only call 'read_clipboard' when the user refers to something they copied.
+11. **Look at images with analyze_image:** When the user shares a screenshot or photo (e.g. "[Image saved to ...]"), call 'analyze_image' on its path, with a prompt that says what to look for. Use 'generate_image' only when the user asks for an image.
 `
 }
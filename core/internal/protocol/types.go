@@ -9,7 +9,9 @@ type Message struct {
 	ReasoningContent string            `json:"reasoning_content,omitempty"` // DeepSeek R1 reasoning
 	ToolUse          []ToolUseBlock    `json:"tool_use,omitempty"`
 	ToolResults      []ToolResultBlock `json:"tool_results,omitempty"`
-	Via              string            `json:"via,omitempty"` // Message source
+	Via              string            `json:"via,omitempty"`        // Message source
+	Checkpoint       string            `json:"checkpoint,omitempty"` // Workspace checkpoint before the first write this prompt led to
+	Pinned           bool              `json:"pinned,omitempty"`     // Never condensed, truncated or evicted
 }
 
 // ToolUseBlock represents a tool call by the assistant
@@ -57,6 +59,7 @@ type ContextStatus struct {
 	WasTruncated   bool    `json:"was_truncated,omitempty"`
 	Summary        string  `json:"summary,omitempty"`
 	CumulativeCost float64 `json:"cumulative_cost,omitempty"`
+	PinnedTokens   int     `json:"pinned_tokens,omitempty"` // Part of TokensUsed held by pinned messages, files and notes
 }
 
 // Checkpoint represents a workspace snapshot for undo/restore functionality
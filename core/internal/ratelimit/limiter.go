@@ -0,0 +1,323 @@
+// Package ratelimit keeps requests to each AI provider under its configured
+// requests-per-minute and tokens-per-minute limits. Waiting requests are queued
+// per session and served round-robin, so one busy session cannot starve others.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limits are per-minute budgets; zero means unlimited
+type Limits struct {
+	RPM int // Requests per minute
+	TPM int // Tokens per minute (prompt + completion)
+}
+
+func (l Limits) unlimited() bool {
+	return l.RPM <= 0 && l.TPM <= 0
+}
+
+// Limiter is a pair of token buckets (requests and tokens) with a fair queue in front
+type Limiter struct {
+	mu          sync.Mutex
+	limits      Limits
+	requests    float64 // Available request budget
+	tokens      float64 // Available token budget
+	last        time.Time
+	pausedUntil time.Time // Set when the provider answers 429
+
+	queues map[string][]*waiter // Session -> FIFO of waiting requests
+	order  []string             // Sessions with waiters; the head is served next
+	timer  *time.Timer
+
+	now func() time.Time
+}
+
+type waiter struct {
+	session string
+	tokens  int
+	ready   chan struct{} // Closed when granted
+	moved   chan struct{} // Signaled when the queue changes
+}
+
+// NewLimiter creates a limiter with full buckets
+func NewLimiter(limits Limits) *Limiter {
+	l := &Limiter{
+		queues: make(map[string][]*waiter),
+		now:    time.Now,
+	}
+	l.SetLimits(limits)
+	return l
+}
+
+// SetLimits changes the budgets, e.g. after providers.yaml is reloaded
+func (l *Limiter) SetLimits(limits Limits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limits == l.limits {
+		return
+	}
+	l.limits = limits
+	l.requests = float64(limits.RPM)
+	l.tokens = float64(limits.TPM)
+	l.last = l.now()
+	l.dispatch()
+}
+
+// Pause holds all requests for d, typically the provider's Retry-After
+func (l *Limiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := l.now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// Release returns a reservation. actualTokens is the real usage if known; 0 keeps
+// the reserved estimate.
+type Release func(actualTokens int)
+
+// Acquire waits until a request of the given token estimate fits the budgets.
+// While it waits, onQueued (if set) is called from the calling goroutine with
+// the number of requests ahead of it whenever that number changes.
+func (l *Limiter) Acquire(ctx context.Context, session string, tokens int, onQueued func(ahead int)) (Release, error) {
+	l.mu.Lock()
+	if l.limits.unlimited() && !l.now().Before(l.pausedUntil) && len(l.order) == 0 {
+		l.mu.Unlock()
+		return func(int) {}, nil
+	}
+
+	if l.limits.TPM > 0 && tokens > l.limits.TPM {
+		tokens = l.limits.TPM // Would never fit otherwise
+	}
+	w := &waiter{
+		session: session,
+		tokens:  tokens,
+		ready:   make(chan struct{}),
+		moved:   make(chan struct{}, 1),
+	}
+	if len(l.queues[session]) == 0 {
+		l.order = append(l.order, session)
+	}
+	l.queues[session] = append(l.queues[session], w)
+	l.dispatch()
+	select {
+	case w.moved <- struct{}{}: // Report the initial position
+	default:
+	}
+	l.mu.Unlock()
+
+	lastAhead := -1
+	for {
+		select {
+		case <-w.ready:
+			return l.releaseFunc(tokens), nil
+		case <-ctx.Done():
+			l.mu.Lock()
+			select {
+			case <-w.ready:
+				// Granted while cancelling: hand the budget back
+				l.mu.Unlock()
+				l.releaseFunc(tokens)(-1)
+			default:
+				l.remove(w)
+				l.dispatch()
+				l.mu.Unlock()
+			}
+			return nil, ctx.Err()
+		case <-w.moved:
+		}
+
+		if onQueued == nil {
+			continue
+		}
+		l.mu.Lock()
+		ahead := l.ahead(w)
+		l.mu.Unlock()
+		if ahead >= 0 && ahead != lastAhead {
+			lastAhead = ahead
+			onQueued(ahead)
+		}
+	}
+}
+
+// releaseFunc settles a grant: refunds or charges the difference between the
+// reserved and actual tokens (-1 refunds everything)
+func (l *Limiter) releaseFunc(reserved int) Release {
+	var once sync.Once
+	return func(actual int) {
+		once.Do(func() {
+			if actual == 0 {
+				return
+			}
+			if actual < 0 {
+				actual = 0
+			}
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.refill()
+			if l.limits.TPM > 0 {
+				l.tokens = math.Min(l.tokens+float64(reserved-actual), float64(l.limits.TPM))
+			}
+			l.dispatch()
+		})
+	}
+}
+
+// QueueLength is the number of requests waiting
+func (l *Limiter) QueueLength() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for _, q := range l.queues {
+		n += len(q)
+	}
+	return n
+}
+
+// refill tops up both buckets for the time elapsed. Caller holds l.mu.
+func (l *Limiter) refill() {
+	now := l.now()
+	elapsed := now.Sub(l.last).Minutes()
+	l.last = now
+	if elapsed <= 0 {
+		return
+	}
+	if l.limits.RPM > 0 {
+		l.requests = math.Min(l.requests+elapsed*float64(l.limits.RPM), float64(l.limits.RPM))
+	}
+	if l.limits.TPM > 0 {
+		l.tokens = math.Min(l.tokens+elapsed*float64(l.limits.TPM), float64(l.limits.TPM))
+	}
+}
+
+// dispatch grants queued requests round-robin across sessions while the budgets
+// allow, then arms a timer for when the next one will fit. Caller holds l.mu.
+func (l *Limiter) dispatch() {
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	l.refill()
+
+	granted := false
+	for len(l.order) > 0 {
+		now := l.now()
+		if now.Before(l.pausedUntil) {
+			l.schedule(l.pausedUntil.Sub(now))
+			break
+		}
+
+		session := l.order[0]
+		w := l.queues[session][0]
+		if wait := l.waitFor(w.tokens); wait > 0 {
+			l.schedule(wait)
+			break
+		}
+
+		if l.limits.RPM > 0 {
+			l.requests--
+		}
+		if l.limits.TPM > 0 {
+			l.tokens -= float64(w.tokens)
+		}
+		l.queues[session] = l.queues[session][1:]
+		l.order = l.order[1:]
+		if len(l.queues[session]) > 0 {
+			l.order = append(l.order, session) // Back of the line for this session's next request
+		} else {
+			delete(l.queues, session)
+		}
+		close(w.ready)
+		granted = true
+	}
+
+	if granted {
+		for _, q := range l.queues {
+			for _, w := range q {
+				select {
+				case w.moved <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// waitFor returns how long until a request of n tokens fits. Caller holds l.mu.
+func (l *Limiter) waitFor(n int) time.Duration {
+	var wait float64 // Minutes
+	if l.limits.RPM > 0 && l.requests < 1 {
+		wait = math.Max(wait, (1-l.requests)/float64(l.limits.RPM))
+	}
+	if l.limits.TPM > 0 && l.tokens < float64(n) {
+		wait = math.Max(wait, (float64(n)-l.tokens)/float64(l.limits.TPM))
+	}
+	if wait == 0 {
+		return 0
+	}
+	return time.Duration(wait*float64(time.Minute)) + time.Millisecond
+}
+
+func (l *Limiter) schedule(d time.Duration) {
+	l.timer = time.AfterFunc(d, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.dispatch()
+	})
+}
+
+// ahead counts the requests served before w under round-robin. Caller holds l.mu.
+func (l *Limiter) ahead(w *waiter) int {
+	queue := l.queues[w.session]
+	k := -1
+	for i, q := range queue {
+		if q == w {
+			k = i
+			break
+		}
+	}
+	if k < 0 {
+		return -1 // Already granted
+	}
+
+	ahead := k
+	before := true // Sessions earlier in the rotation get one extra turn before w
+	for _, s := range l.order {
+		if s == w.session {
+			before = false
+			continue
+		}
+		turns := k
+		if before {
+			turns++
+		}
+		ahead += min(len(l.queues[s]), turns)
+	}
+	return ahead
+}
+
+// remove drops a cancelled waiter. Caller holds l.mu.
+func (l *Limiter) remove(w *waiter) {
+	queue := l.queues[w.session]
+	for i, q := range queue {
+		if q == w {
+			queue = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(queue) > 0 {
+		l.queues[w.session] = queue
+		return
+	}
+	delete(l.queues, w.session)
+	for i, s := range l.order {
+		if s == w.session {
+			l.order = append(l.order[:i:i], l.order[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFairnessAcrossSessions(t *testing.T) {
+	l := NewLimiter(Limits{RPM: 600}) // One request every 100ms once the burst is spent
+	l.requests = 0
+
+	var order []string
+	done := make(chan string, 6)
+	start := func(session string) {
+		go func() {
+			release, err := l.Acquire(context.Background(), session, 0, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			release(0)
+			done <- session
+		}()
+		time.Sleep(5 * time.Millisecond) // Enqueue in a known order
+	}
+
+	start("a")
+	start("a")
+	start("a")
+	start("b")
+
+	if n := l.QueueLength(); n != 4 {
+		t.Fatalf("queued = %d, want 4", n)
+	}
+	for i := 0; i < 4; i++ {
+		select {
+		case s := <-done:
+			order = append(order, s)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out, served %v", order)
+		}
+	}
+	// b arrived last but must not wait behind all of a's requests
+	if order[1] != "b" {
+		t.Errorf("served %v, want b second", order)
+	}
+}
+
+func TestQueuePositionAndCancel(t *testing.T) {
+	l := NewLimiter(Limits{TPM: 1000})
+	l.tokens = 0
+
+	go l.Acquire(context.Background(), "a", 500, nil)
+	time.Sleep(5 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	positions := make(chan int, 4)
+	errc := make(chan error, 1)
+	go func() {
+		_, err := l.Acquire(ctx, "b", 10, func(ahead int) { positions <- ahead })
+		errc <- err
+	}()
+
+	if got := <-positions; got != 1 {
+		t.Errorf("ahead = %d, want 1", got)
+	}
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if n := l.QueueLength(); n != 1 {
+		t.Errorf("queued = %d after cancel, want 1", n)
+	}
+}
+
+func TestOversizedRequestIsClamped(t *testing.T) {
+	l := NewLimiter(Limits{TPM: 100})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := l.Acquire(ctx, "", 5000, nil); err != nil {
+		t.Fatalf("request larger than TPM never admitted: %v", err)
+	}
+}
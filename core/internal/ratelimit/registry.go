@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiters are shared process-wide so the main agent, swarm workers and
+// subagents all draw from the same per-provider budget.
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]*Limiter)
+)
+
+// For returns the limiter for a provider, updating its limits
+func For(provider string, limits Limits) *Limiter {
+	registryMu.Lock()
+	l, ok := registry[provider]
+	if !ok {
+		l = NewLimiter(limits)
+		registry[provider] = l
+	}
+	registryMu.Unlock()
+
+	if ok {
+		l.SetLimits(limits)
+	}
+	return l
+}
+
+type sessionKey struct{}
+type observerKey struct{}
+type limiterKey struct{}
+
+// WithSession tags requests made with ctx as belonging to a chat session, the
+// unit of fairness in the queue
+func WithSession(ctx context.Context, session string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// SessionFrom returns the session set by WithSession, or ""
+func SessionFrom(ctx context.Context) string {
+	s, _ := ctx.Value(sessionKey{}).(string)
+	return s
+}
+
+// WithObserver registers a callback told how many requests are ahead while a
+// request made with ctx is queued
+func WithObserver(ctx context.Context, fn func(ahead int)) context.Context {
+	return context.WithValue(ctx, observerKey{}, fn)
+}
+
+// ObserverFrom returns the callback set by WithObserver, or nil
+func ObserverFrom(ctx context.Context) func(ahead int) {
+	fn, _ := ctx.Value(observerKey{}).(func(ahead int))
+	return fn
+}
+
+// WithLimiter attaches the limiter a request was admitted by, so the transport
+// can report throttling back to it
+func WithLimiter(ctx context.Context, l *Limiter) context.Context {
+	return context.WithValue(ctx, limiterKey{}, l)
+}
+
+// Throttled pauses the limiter attached to ctx, if any, after a 429
+func Throttled(ctx context.Context, retryAfter time.Duration) {
+	if l, ok := ctx.Value(limiterKey{}).(*Limiter); ok {
+		l.Pause(retryAfter)
+	}
+}
@@ -0,0 +1,63 @@
+package rules
+
+import (
+	"path"
+	"strings"
+)
+
+// matchGlob reports whether rel matches pattern. A pattern without "/"
+// matches the base name at any depth, "**" spans directories and {a,b}
+// lists alternatives.
+func matchGlob(pattern, rel string) bool {
+	for _, p := range expandBraces(pattern) {
+		if !strings.Contains(p, "/") {
+			if ok, _ := path.Match(p, path.Base(rel)); ok {
+				return true
+			}
+			continue
+		}
+		if matchSegments(strings.Split(strings.TrimPrefix(p, "./"), "/"), strings.Split(rel, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pat, parts []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pat[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], parts[0]); !ok {
+			return false
+		}
+		pat, parts = pat[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// expandBraces expands one level of {a,b} alternatives: "*.{ts,tsx}" -> "*.ts", "*.tsx"
+func expandBraces(p string) []string {
+	open := strings.IndexByte(p, '{')
+	if open < 0 {
+		return []string{p}
+	}
+	end := strings.IndexByte(p[open:], '}')
+	if end < 0 {
+		return []string{p}
+	}
+	end += open
+	var out []string
+	for _, alt := range strings.Split(p[open+1:end], ",") {
+		out = append(out, expandBraces(p[:open]+alt+p[end+1:])...)
+	}
+	return out
+}
@@ -2,9 +2,14 @@ package rules
 
 import (
 	"fmt"
+	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Manager handles project-specific rules discovery and loading
@@ -16,28 +21,199 @@ func NewManager(cwd string) *Manager {
 	return &Manager{cwd: cwd}
 }
 
-// GetRules loads all .md files from .ricochet/rules and returns them as a single string
-func (m *Manager) GetRules() string {
+// Rule is a markdown file in .ricochet/rules. Its frontmatter scopes it, as
+// Cursor rules do: globs limit it to turns working on matching files and
+// modes to the listed modes. A rule with neither always applies.
+type Rule struct {
+	Name        string   `json:"name"`
+	Path        string   `json:"path"`
+	Description string   `json:"description,omitempty"`
+	Globs       []string `json:"globs,omitempty"`
+	Modes       []string `json:"modes,omitempty"`
+	AlwaysApply bool     `json:"always_apply,omitempty"` // Applies whatever the files; modes still limit it
+	Content     string   `json:"-"`
+}
+
+// Evaluation is whether a rule applies to a turn, and why
+type Evaluation struct {
+	Rule
+	Applied bool   `json:"applied"`
+	Reason  string `json:"reason"`
+}
+
+type ruleFrontmatter struct {
+	Description string     `yaml:"description"`
+	Globs       stringList `yaml:"globs"`
+	Modes       stringList `yaml:"modes"`
+	AlwaysApply bool       `yaml:"alwaysApply"`
+	AlwaysSnake bool       `yaml:"always_apply"`
+}
+
+// stringList reads a YAML list or a comma-separated string ("*.ts, *.tsx")
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = nil
+		for _, item := range strings.Split(node.Value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*l = append(*l, item)
+			}
+		}
+		return nil
+	}
+	var items []string
+	if err := node.Decode(&items); err != nil {
+		return err
+	}
+	*l = items
+	return nil
+}
+
+// Load reads the rules in .ricochet/rules (*.md and Cursor's *.mdc), sorted
+// by name. Rules with invalid frontmatter are skipped and described in the
+// returned errors.
+func (m *Manager) Load() ([]Rule, []string) {
 	rulesDir := filepath.Join(m.cwd, ".ricochet", "rules")
 	files, err := os.ReadDir(rulesDir)
 	if err != nil {
 		// No rules directory or error reading it
-		return ""
+		return nil, nil
 	}
 
-	var sb strings.Builder
-	hasRules := false
+	var rules []Rule
+	var errs []string
 	for _, f := range files {
-		if !f.IsDir() && filepath.Ext(f.Name()) == ".md" {
-			if !hasRules {
-				sb.WriteString("\n\n### Project-Specific Rules\n")
-				hasRules = true
+		ext := filepath.Ext(f.Name())
+		if f.IsDir() || (ext != ".md" && ext != ".mdc") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(rulesDir, f.Name()))
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", f.Name(), err))
+			continue
+		}
+		rule, err := parseRule(f.Name(), string(content))
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", f.Name(), err))
+			continue
+		}
+		rule.Path = filepath.ToSlash(filepath.Join(".ricochet", "rules", f.Name()))
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules, errs
+}
+
+// parseRule splits the optional YAML frontmatter from the rule body
+func parseRule(name, content string) (Rule, error) {
+	rule := Rule{Name: name, Content: content}
+	body, ok := strings.CutPrefix(strings.ReplaceAll(content, "\r\n", "\n"), "---\n")
+	if !ok {
+		return rule, nil
+	}
+	end := strings.Index(body, "\n---")
+	if end < 0 {
+		return rule, fmt.Errorf("frontmatter is not closed with ---")
+	}
+
+	var fm ruleFrontmatter
+	if err := yaml.Unmarshal([]byte(body[:end]), &fm); err != nil {
+		return rule, fmt.Errorf("invalid frontmatter: %w", err)
+	}
+	for _, g := range fm.Globs {
+		for _, alt := range expandBraces(g) {
+			if _, err := path.Match(alt, ""); err != nil {
+				return rule, fmt.Errorf("invalid glob '%s': %w", g, err)
 			}
-			content, err := os.ReadFile(filepath.Join(rulesDir, f.Name()))
-			if err == nil {
-				sb.WriteString(fmt.Sprintf("\n#### Rule: %s\n%s\n", f.Name(), string(content)))
+		}
+	}
+
+	rule.Description = fm.Description
+	rule.Globs = fm.Globs
+	rule.Modes = fm.Modes
+	rule.AlwaysApply = fm.AlwaysApply || fm.AlwaysSnake
+	rule.Content = strings.TrimSpace(strings.TrimPrefix(body[end+len("\n---"):], "-"))
+	return rule, nil
+}
+
+// Evaluate decides which rules apply in mode to a turn working on files
+// (absolute or relative to the workspace)
+func (m *Manager) Evaluate(mode string, files []string) ([]Evaluation, []string) {
+	rules, errs := m.Load()
+	rel := make([]string, 0, len(files))
+	for _, f := range files {
+		rel = append(rel, m.relPath(f))
+	}
+
+	evals := make([]Evaluation, 0, len(rules))
+	for _, rule := range rules {
+		applied, reason := rule.appliesTo(mode, rel)
+		evals = append(evals, Evaluation{Rule: rule, Applied: applied, Reason: reason})
+	}
+	return evals, errs
+}
+
+func (r Rule) appliesTo(mode string, files []string) (bool, string) {
+	if len(r.Modes) > 0 && !containsFold(r.Modes, mode) {
+		return false, fmt.Sprintf("only in modes %s, active mode is %s", strings.Join(r.Modes, ", "), mode)
+	}
+	if len(r.Globs) == 0 || r.AlwaysApply {
+		if len(r.Modes) > 0 {
+			return true, "active mode is " + mode
+		}
+		return true, "always applies"
+	}
+	for _, f := range files {
+		for _, g := range r.Globs {
+			if matchGlob(g, f) {
+				return true, fmt.Sprintf("%s matches %s", f, g)
 			}
 		}
 	}
+	return false, fmt.Sprintf("no file in context matches %s", strings.Join(r.Globs, ", "))
+}
+
+// Prompt renders the rules that apply in mode to a turn working on files
+func (m *Manager) Prompt(mode string, files []string) string {
+	evals, errs := m.Evaluate(mode, files)
+	for _, e := range errs {
+		log.Printf("Warning: skipped rule %s", e)
+	}
+
+	var sb strings.Builder
+	for _, e := range evals {
+		if !e.Applied {
+			continue
+		}
+		if sb.Len() == 0 {
+			sb.WriteString("\n\n### Project-Specific Rules\n")
+		}
+		scope := ""
+		if len(e.Globs) > 0 && !e.AlwaysApply {
+			scope = fmt.Sprintf(" (for %s)", strings.Join(e.Globs, ", "))
+		}
+		sb.WriteString(fmt.Sprintf("\n#### Rule: %s%s\n%s\n", e.Name, scope, e.Content))
+	}
 	return sb.String()
 }
+
+// relPath returns file relative to the workspace with forward slashes, or
+// file itself when it lies outside
+func (m *Manager) relPath(file string) string {
+	if filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(m.cwd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+	}
+	return strings.TrimPrefix(filepath.ToSlash(file), "./")
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeRule(t *testing.T, dir, name, content string) {
+	t.Helper()
+	rulesDir := filepath.Join(dir, ".ricochet", "rules")
+	if err := os.MkdirAll(rulesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(rulesDir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScopedRules(t *testing.T) {
+	dir := t.TempDir()
+	writeRule(t, dir, "style.md", "Use gofmt.")
+	writeRule(t, dir, "tests.md", "---\ndescription: Test style\nglobs: \"*_test.go\"\n---\nAlways use table-driven tests.\n")
+	writeRule(t, dir, "web.mdc", "---\nglobs: [\"web/**/*.{ts,tsx}\"]\n---\nUse hooks.\n")
+	writeRule(t, dir, "arch.md", "---\nmodes: architect\nalwaysApply: true\n---\nWrite ADRs.\n")
+	writeRule(t, dir, "broken.md", "---\nglobs: \"[\"\n---\nNever loaded.\n")
+	writeRule(t, dir, "notes.txt", "Not a rule.")
+	m := NewManager(dir)
+
+	tests := []struct {
+		mode  string
+		files []string
+		want  []string
+	}{
+		{"code", nil, []string{"style.md"}},
+		{"code", []string{filepath.Join(dir, "internal", "x", "handler_test.go")}, []string{"style.md", "tests.md"}},
+		{"code", []string{"web/src/App.tsx"}, []string{"style.md", "web.mdc"}},
+		{"code", []string{"src/App.tsx"}, []string{"style.md"}},
+		{"architect", nil, []string{"arch.md", "style.md"}},
+	}
+	for _, tt := range tests {
+		evals, errs := m.Evaluate(tt.mode, tt.files)
+		var got []string
+		for _, e := range evals {
+			if e.Applied {
+				got = append(got, e.Name)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Evaluate(%s, %v) = %v, want %v", tt.mode, tt.files, got, tt.want)
+		}
+		if len(errs) != 1 || !strings.Contains(errs[0], "broken.md") {
+			t.Errorf("errors: %v", errs)
+		}
+	}
+
+	evals, _ := m.Evaluate("code", []string{"pkg/a_test.go"})
+	for _, e := range evals {
+		if e.Name == "tests.md" && (e.Reason != "pkg/a_test.go matches *_test.go" || e.Content != "Always use table-driven tests." || e.Description != "Test style") {
+			t.Errorf("tests.md: %+v", e)
+		}
+		if e.Name == "arch.md" && e.Reason != "only in modes architect, active mode is code" {
+			t.Errorf("arch.md reason: %s", e.Reason)
+		}
+	}
+
+	prompt := m.Prompt("code", []string{"pkg/a_test.go"})
+	if !strings.Contains(prompt, "#### Rule: tests.md (for *_test.go)\nAlways use table-driven tests.") || strings.Contains(prompt, "globs:") {
+		t.Errorf("prompt:\n%s", prompt)
+	}
+	if m.Prompt("architect", nil) == "" || NewManager(t.TempDir()).Prompt("code", nil) != "" {
+		t.Error("prompt without rules should be empty")
+	}
+}
@@ -32,22 +32,12 @@ const (
 	CategoryMCP     ToolCategory = "mcp"
 )
 
-// SafeCommands that don't modify anything
-var SafeCommands = map[string]bool{
-	"ls": true, "cat": true, "head": true, "tail": true, "wc": true,
-	"find": true, "grep": true, "awk": true, "sed": true, "sort": true,
-	"pwd": true, "whoami": true, "date": true, "echo": true,
-	"which": true, "type": true, "file": true, "stat": true,
-	"go": true, "npm": true, "node": true, "python": true, "python3": true,
-	"git": true, "diff": true, "tree": true,
-}
-
 // GetToolCategory returns the category for a given tool name
 func GetToolCategory(toolName string) ToolCategory {
 	switch toolName {
 	case "read_file", "view_file", "list_directory", "search_files", "grep_search":
 		return CategoryRead
-	case "write_to_file", "write_file", "apply_diff", "replace_in_file", "delete_file", "create_directory":
+	case "write_to_file", "write_file", "apply_diff", "edit_files", "replace_in_file", "delete_file", "move_file", "create_directory", "rename_symbol":
 		return CategoryEdit
 	case "execute_command", "run_command":
 		return CategoryCommand
@@ -136,25 +126,24 @@ func (am *ApprovalManager) CanAutoApprove(toolName string, args map[string]inter
 	return false, "Unknown tool category"
 }
 
-// isExternalPath checks if path is outside workspace
+// isExternalPath checks if path is outside workspace, following symlinks
 func (am *ApprovalManager) isExternalPath(path string) bool {
 	if am.workspaceDir == "" {
 		return false
 	}
-	absPath, err := filepath.Abs(path)
+	absPath, err := canonicalPath(path)
 	if err != nil {
 		return true // Treat errors as external (safer)
 	}
-	return !strings.HasPrefix(absPath, am.workspaceDir)
+	root, err := canonicalPath(am.workspaceDir)
+	if err != nil {
+		return true
+	}
+	rel, err := filepath.Rel(root, absPath)
+	return err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
 }
 
-// isSafeCommand checks if a command is in the safe list
+// isSafeCommand checks if the command policy rates a command safe
 func (am *ApprovalManager) isSafeCommand(cmd string) bool {
-	// Extract first word (command name)
-	parts := strings.Fields(cmd)
-	if len(parts) == 0 {
-		return false
-	}
-	cmdName := filepath.Base(parts[0])
-	return SafeCommands[cmdName]
+	return IsSafeCommand(cmd)
 }
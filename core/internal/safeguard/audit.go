@@ -0,0 +1,179 @@
+package safeguard
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Approval decisions
+const (
+	DecisionApproved = "approved"
+	DecisionDenied   = "denied"
+)
+
+// Approval channels: where a decision was made
+const (
+	ChannelPolicy   = "policy"   // Auto-approval settings, command policy or a stored rule
+	ChannelTelegram = "telegram" // Ether Mode remote approval
+)
+
+// AuditEntry is one approval decision in the audit log
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	Workspace  string    `json:"workspace,omitempty"`
+	Tool       string    `json:"tool"`
+	Target     string    `json:"target,omitempty"`      // Path, command or host, with secrets redacted
+	ArgsDigest string    `json:"args_digest,omitempty"` // SHA-256 prefix of the tool arguments
+	Decision   string    `json:"decision"`
+	Auto       bool      `json:"auto"`
+	Approver   string    `json:"approver"` // User, or the setting or rule that decided
+	Channel    string    `json:"channel"`  // tui, ide, cli, telegram or policy
+}
+
+// AuditQuery filters audit entries; zero fields match everything
+type AuditQuery struct {
+	Workspace string    `json:"workspace,omitempty"`
+	Tool      string    `json:"tool,omitempty"`
+	Decision  string    `json:"decision,omitempty"`
+	Channel   string    `json:"channel,omitempty"`
+	Auto      *bool     `json:"auto,omitempty"`
+	Since     time.Time `json:"since,omitempty"`
+	Until     time.Time `json:"until,omitempty"`
+	Limit     int       `json:"limit,omitempty"` // Keep only the newest N
+}
+
+func (q AuditQuery) matches(e AuditEntry) bool {
+	return (q.Workspace == "" || e.Workspace == q.Workspace) &&
+		(q.Tool == "" || e.Tool == q.Tool) &&
+		(q.Decision == "" || e.Decision == q.Decision) &&
+		(q.Channel == "" || e.Channel == q.Channel) &&
+		(q.Auto == nil || e.Auto == *q.Auto) &&
+		(q.Since.IsZero() || !e.Time.Before(q.Since)) &&
+		(q.Until.IsZero() || e.Time.Before(q.Until))
+}
+
+// AuditLog is an append-only JSONL file of approval decisions. Entries are
+// never rewritten or removed.
+type AuditLog struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewAuditLog opens the audit log at path, creating its directory
+func NewAuditLog(path string) (*AuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit dir: %w", err)
+	}
+	return &AuditLog{path: path}, nil
+}
+
+// Append writes an entry to the end of the log
+func (a *AuditLog) Append(e AuditEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(line)
+	return err
+}
+
+// Query returns the entries matching q, oldest first
+func (a *AuditLog) Query(q AuditQuery) ([]AuditEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.Open(a.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue // Torn write
+		}
+		if q.matches(e) {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[len(entries)-q.Limit:]
+	}
+	return entries, nil
+}
+
+// DigestArgs returns a short digest of tool arguments, so the audit log can
+// tell calls apart without storing their content
+func DigestArgs(args []byte) string {
+	if len(args) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(args)
+	return hex.EncodeToString(sum[:8])
+}
+
+// LocalUser names the account Ricochet runs under, the approver of local prompts
+func LocalUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// RecordApproval appends a decision to the audit log, stamped with the time
+// and workspace. Failures are logged, never returned: auditing must not
+// block the tool.
+func (m *Manager) RecordApproval(e AuditEntry) {
+	if m == nil || m.Audit == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Workspace == "" {
+		e.Workspace = m.root
+	}
+	e.Target, _ = m.RedactSecrets(e.Target)
+	if err := m.Audit.Append(e); err != nil {
+		log.Printf("[Safeguard] Failed to write approval audit entry: %v", err)
+	}
+}
+
+// QueryApprovals searches the audit log
+func (m *Manager) QueryApprovals(q AuditQuery) ([]AuditEntry, error) {
+	if m == nil || m.Audit == nil {
+		return nil, fmt.Errorf("approval audit log not available")
+	}
+	return m.Audit.Query(q)
+}
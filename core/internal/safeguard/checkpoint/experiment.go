@@ -0,0 +1,360 @@
+package checkpoint
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// experimentPrefix namespaces experiment branches in the shadow repository
+const experimentPrefix = "experiment/"
+
+// mainLineKey is the shadow repository setting holding the branch
+// experiments fork from
+const mainLineKey = "ricochet.mainBranch"
+
+// MainLine names the main line in the experiment API
+const MainLine = "main"
+
+// Experiment is a named fork of the workspace, a shadow branch
+type Experiment struct {
+	Name      string    `json:"name"`
+	Head      string    `json:"head"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+	Current   bool      `json:"current"`
+	Ahead     int       `json:"ahead"` // Checkpoints since it forked from the main line
+}
+
+// ExperimentDiff is the three-way comparison of an experiment with the main
+// line, from the checkpoint where they diverged
+type ExperimentDiff struct {
+	Name       string       `json:"name"`
+	Base       string       `json:"base"`
+	Main       []FileChange `json:"main"`       // Changed on the main line since the fork
+	Experiment []FileChange `json:"experiment"` // Changed in the experiment
+	Conflicts  []string     `json:"conflicts"`  // Changed on both sides
+}
+
+// MergeResult reports an experiment merged into the main line
+type MergeResult struct {
+	Hash      string   `json:"hash"`
+	Conflicts []string `json:"conflicts,omitempty"` // Files left with conflict markers
+}
+
+// Fork saves the workspace and starts a named experiment from it. Later
+// checkpoints go to the experiment until switching back to the main line.
+func (g *GitManager) Fork(name string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	branch, err := g.experimentBranch(name)
+	if err != nil {
+		return "", err
+	}
+	if g.branchExists(branch) {
+		return "", fmt.Errorf("experiment %q already exists", name)
+	}
+
+	current, err := g.currentBranch()
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(current, experimentPrefix) {
+		if _, err := g.git("config", mainLineKey, current); err != nil {
+			return "", err
+		}
+	}
+	if err := g.snapshot(fmt.Sprintf("Before experiment %s", name)); err != nil {
+		return "", err
+	}
+	if _, err := g.git("branch", branch); err != nil {
+		return "", err
+	}
+	// The workspace already matches the fork point, so only HEAD moves
+	if _, err := g.git("symbolic-ref", "HEAD", "refs/heads/"+branch); err != nil {
+		return "", err
+	}
+	return g.git("rev-parse", "HEAD")
+}
+
+// Switch saves the workspace to the current branch and checks out an
+// experiment, or the main line for MainLine
+func (g *GitManager) Switch(name string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	branch, err := g.resolveBranch(name)
+	if err != nil {
+		return err
+	}
+	if err := g.snapshot(fmt.Sprintf("Switch to %s", name)); err != nil {
+		return err
+	}
+	return g.checkout(branch)
+}
+
+// Experiments lists the experiments, most recently changed first
+func (g *GitManager) Experiments() ([]Experiment, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	out, err := g.git("for-each-ref", "--sort=-committerdate", "--format=%(refname:short)%1f%(objectname)%1f%(committerdate:unix)%1f%(subject)", "refs/heads/"+experimentPrefix)
+	if err != nil {
+		return nil, err
+	}
+	current, _ := g.currentBranch()
+	main := g.mainBranch()
+
+	experiments := []Experiment{}
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.SplitN(line, "\x1f", 4)
+		if len(parts) != 4 {
+			continue
+		}
+		var unix int64
+		fmt.Sscanf(parts[2], "%d", &unix)
+		exp := Experiment{
+			Name:      strings.TrimPrefix(parts[0], experimentPrefix),
+			Head:      parts[1],
+			Message:   parts[3],
+			Timestamp: time.Unix(unix, 0),
+			Current:   parts[0] == current,
+		}
+		if count, err := g.git("rev-list", "--count", main+".."+parts[0]); err == nil {
+			fmt.Sscanf(count, "%d", &exp.Ahead)
+		}
+		experiments = append(experiments, exp)
+	}
+	return experiments, nil
+}
+
+// CurrentExperiment returns the experiment checkpoints go to, or "" on the
+// main line
+func (g *GitManager) CurrentExperiment() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	current, _ := g.currentBranch()
+	if name, ok := strings.CutPrefix(current, experimentPrefix); ok {
+		return name
+	}
+	return ""
+}
+
+// Compare diffs an experiment and the main line against the checkpoint
+// where they diverged. The workspace is saved first so the current branch
+// includes unsaved work.
+func (g *GitManager) Compare(name string) (ExperimentDiff, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	diff := ExperimentDiff{Name: name}
+	branch, err := g.experimentBranch(name)
+	if err != nil {
+		return diff, err
+	}
+	if !g.branchExists(branch) {
+		return diff, fmt.Errorf("no experiment named %q", name)
+	}
+	if err := g.snapshot(fmt.Sprintf("Compare %s", name)); err != nil {
+		return diff, err
+	}
+	main := g.mainBranch()
+	if diff.Base, err = g.git("merge-base", main, branch); err != nil {
+		return diff, err
+	}
+	if diff.Main, err = g.changes(diff.Base, main); err != nil {
+		return diff, err
+	}
+	if diff.Experiment, err = g.changes(diff.Base, branch); err != nil {
+		return diff, err
+	}
+
+	onMain := make(map[string]bool, len(diff.Main))
+	for _, ch := range diff.Main {
+		onMain[ch.Path] = true
+	}
+	diff.Conflicts = []string{}
+	for _, ch := range diff.Experiment {
+		if onMain[ch.Path] {
+			diff.Conflicts = append(diff.Conflicts, ch.Path)
+		}
+	}
+	sort.Strings(diff.Conflicts)
+	return diff, nil
+}
+
+// Merge brings an experiment into the main line with a three-way merge and
+// deletes it. Conflicting files keep git's conflict markers for the user or
+// agent to resolve; the merge is committed either way.
+func (g *GitManager) Merge(name string) (MergeResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var res MergeResult
+	branch, err := g.experimentBranch(name)
+	if err != nil {
+		return res, err
+	}
+	if !g.branchExists(branch) {
+		return res, fmt.Errorf("no experiment named %q", name)
+	}
+	if err := g.snapshot(fmt.Sprintf("Before merging %s", name)); err != nil {
+		return res, err
+	}
+	if err := g.checkout(g.mainBranch()); err != nil {
+		return res, err
+	}
+
+	message := fmt.Sprintf("Merge experiment %s", name)
+	if _, mergeErr := g.git("merge", "--no-ff", "--no-edit", "-m", message, branch); mergeErr != nil {
+		out, err := g.git("diff", "--name-only", "--diff-filter=U")
+		if err != nil || out == "" {
+			g.git("merge", "--abort")
+			return res, mergeErr
+		}
+		res.Conflicts = strings.Split(out, "\n")
+		if _, err := g.git("add", "."); err != nil {
+			return res, err
+		}
+		if _, err := g.git("commit", "--no-edit", "-m", message+" (with conflicts)"); err != nil {
+			return res, err
+		}
+	}
+	if _, err := g.git("branch", "-D", branch); err != nil {
+		return res, err
+	}
+	res.Hash, err = g.git("rev-parse", "HEAD")
+	return res, err
+}
+
+// Discard deletes an experiment, first returning the workspace to the main
+// line if the experiment is checked out
+func (g *GitManager) Discard(name string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	branch, err := g.experimentBranch(name)
+	if err != nil {
+		return err
+	}
+	if !g.branchExists(branch) {
+		return fmt.Errorf("no experiment named %q", name)
+	}
+	if current, _ := g.currentBranch(); current == branch {
+		if err := g.checkout(g.mainBranch()); err != nil {
+			return err
+		}
+	}
+	_, err = g.git("branch", "-D", branch)
+	return err
+}
+
+// experimentBranch validates an experiment name and returns its branch
+func (g *GitManager) experimentBranch(name string) (string, error) {
+	branch := experimentPrefix + name
+	if name == "" || name == MainLine || exec.Command("git", "check-ref-format", "--branch", branch).Run() != nil {
+		return "", fmt.Errorf("invalid experiment name %q", name)
+	}
+	return branch, nil
+}
+
+// resolveBranch returns the branch of an experiment, or of the main line
+func (g *GitManager) resolveBranch(name string) (string, error) {
+	if name == MainLine {
+		return g.mainBranch(), nil
+	}
+	branch, err := g.experimentBranch(name)
+	if err != nil {
+		return "", err
+	}
+	if !g.branchExists(branch) {
+		return "", fmt.Errorf("no experiment named %q", name)
+	}
+	return branch, nil
+}
+
+func (g *GitManager) branchExists(branch string) bool {
+	_, err := g.git("rev-parse", "--verify", "-q", "refs/heads/"+branch)
+	return err == nil
+}
+
+func (g *GitManager) currentBranch() (string, error) {
+	return g.git("symbolic-ref", "--short", "HEAD")
+}
+
+// mainBranch returns the branch experiments fork from
+func (g *GitManager) mainBranch() string {
+	if main, err := g.git("config", "--get", mainLineKey); err == nil && main != "" {
+		return main
+	}
+	current, _ := g.currentBranch()
+	return current
+}
+
+// snapshot commits the workspace to the current branch if it changed
+func (g *GitManager) snapshot(message string) error {
+	if _, err := g.git("add", "."); err != nil {
+		return err
+	}
+	if status, err := g.git("status", "--porcelain"); err != nil || status == "" {
+		return err
+	}
+	_, err := g.git("commit", "-m", message)
+	return err
+}
+
+// checkout points HEAD at a branch and resets the workspace to it
+func (g *GitManager) checkout(branch string) error {
+	if _, err := g.git("symbolic-ref", "HEAD", "refs/heads/"+branch); err != nil {
+		return err
+	}
+	if _, err := g.git("reset", "--hard"); err != nil {
+		return err
+	}
+	_, err := g.git("clean", "-fd")
+	return err
+}
+
+// git runs a git command on the shadow repository and the workspace,
+// returning its trimmed output
+func (g *GitManager) git(args ...string) (string, error) {
+	args = append([]string{"--git-dir=" + filepath.Join(g.shadowPath, ".git"), "--work-tree=" + g.cwd}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.cwd
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s failed: %s: %w", args[2], strings.TrimSpace(string(exitErr.Stderr)), err)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[2], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Summary renders the three-way comparison as text, one section per side
+func (d ExperimentDiff) Summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Experiment %s vs main line (diverged at %.8s)\n", d.Name, d.Base)
+	section := func(title string, changes []FileChange) {
+		fmt.Fprintf(&sb, "\n%s:\n", title)
+		if len(changes) == 0 {
+			sb.WriteString("  (no changes)\n")
+		}
+		for _, ch := range changes {
+			fmt.Fprintf(&sb, "  %s %s (+%d -%d)\n", ch.Status, ch.Path, ch.Additions, ch.Deletions)
+		}
+	}
+	section("Main line", d.Main)
+	section("Experiment "+d.Name, d.Experiment)
+	if len(d.Conflicts) > 0 {
+		fmt.Fprintf(&sb, "\nChanged on both sides (may conflict on merge):\n")
+		for _, path := range d.Conflicts {
+			fmt.Fprintf(&sb, "  %s\n", path)
+		}
+	}
+	return sb.String()
+}
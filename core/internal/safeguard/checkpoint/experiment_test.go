@@ -0,0 +1,167 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, g *GitManager, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(g.cwd, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, g *GitManager, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(g.cwd, name))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func TestExperiments_TryBoth(t *testing.T) {
+	g := newTestManager(t)
+	writeFile(t, g, "app.go", "base")
+	if _, err := g.Commit("initial"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Design A adds a file, unsaved when switching away
+	if _, err := g.Fork("design-a"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, g, "app.go", "a")
+	writeFile(t, g, "a.go", "only in a")
+	if err := g.Switch(MainLine); err != nil {
+		t.Fatal(err)
+	}
+	if readFile(t, g, "app.go") != "base" || readFile(t, g, "a.go") != "" {
+		t.Fatal("switching to the main line kept design A in the workspace")
+	}
+
+	if _, err := g.Fork("design-b"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, g, "app.go", "b")
+	if g.CurrentExperiment() != "design-b" {
+		t.Errorf("current experiment = %q", g.CurrentExperiment())
+	}
+
+	exps, err := g.Experiments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exps) != 2 {
+		t.Fatalf("experiments = %+v, want 2", exps)
+	}
+	for _, e := range exps {
+		if e.Current != (e.Name == "design-b") || (e.Name == "design-a" && e.Ahead != 1) {
+			t.Errorf("experiment %+v", e)
+		}
+	}
+
+	// Design A is kept, design B dropped
+	if err := g.Switch("design-a"); err != nil {
+		t.Fatal(err)
+	}
+	if readFile(t, g, "a.go") != "only in a" {
+		t.Error("switching back lost design A's work")
+	}
+	if err := g.Discard("design-b"); err != nil {
+		t.Fatal(err)
+	}
+	res, err := g.Merge("design-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Conflicts) != 0 || res.Hash == "" {
+		t.Errorf("merge = %+v", res)
+	}
+	if readFile(t, g, "app.go") != "a" || g.CurrentExperiment() != "" {
+		t.Error("the main line doesn't have design A after merging")
+	}
+	if exps, _ := g.Experiments(); len(exps) != 0 {
+		t.Errorf("experiments after merge = %+v", exps)
+	}
+}
+
+func TestExperiments_Compare(t *testing.T) {
+	g := newTestManager(t)
+	writeFile(t, g, "shared.go", "base")
+	writeFile(t, g, "main.go", "base")
+	if _, err := g.Commit("initial"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := g.Fork("exp"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, g, "shared.go", "experiment")
+	writeFile(t, g, "new.go", "experiment")
+	if err := g.Switch(MainLine); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, g, "shared.go", "main")
+	writeFile(t, g, "main.go", "main")
+
+	diff, err := g.Compare("exp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Main) != 2 || len(diff.Experiment) != 2 {
+		t.Errorf("main changed %+v, experiment changed %+v", diff.Main, diff.Experiment)
+	}
+	if len(diff.Conflicts) != 1 || diff.Conflicts[0] != "shared.go" {
+		t.Errorf("conflicts = %v, want shared.go", diff.Conflicts)
+	}
+
+	res, err := g.Merge("exp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Conflicts) != 1 || res.Conflicts[0] != "shared.go" {
+		t.Errorf("merge conflicts = %v", res.Conflicts)
+	}
+	if !strings.Contains(readFile(t, g, "shared.go"), "<<<<<<<") {
+		t.Error("conflicting file has no conflict markers")
+	}
+	if readFile(t, g, "new.go") != "experiment" || readFile(t, g, "main.go") != "main" {
+		t.Error("merge dropped non-conflicting changes")
+	}
+}
+
+func TestExperiments_Invalid(t *testing.T) {
+	g := newTestManager(t)
+	writeFile(t, g, "app.go", "base")
+	if _, err := g.Commit("initial"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"", MainLine, "bad name", "a..b"} {
+		if _, err := g.Fork(name); err == nil {
+			t.Errorf("Fork(%q) succeeded", name)
+		}
+	}
+	if _, err := g.Fork("exp"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Fork("exp"); err == nil {
+		t.Error("forking an existing experiment succeeded")
+	}
+	if err := g.Switch("missing"); err == nil {
+		t.Error("switching to a missing experiment succeeded")
+	}
+
+	// Discarding the checked-out experiment returns to the main line
+	writeFile(t, g, "app.go", "changed")
+	if err := g.Discard("exp"); err != nil {
+		t.Fatal(err)
+	}
+	if readFile(t, g, "app.go") != "base" || g.CurrentExperiment() != "" {
+		t.Error("discard left the experiment checked out")
+	}
+}
@@ -16,6 +16,30 @@ type Timer struct {
 	start time.Time
 }
 
+// Store is the checkpoint history of a workspace, the one every restore
+// (IDE, TUI, tools, auto-checkpoints) goes through. GitManager keeps it in a
+// shadow git repository under ~/.ricochet. Experiments are named forks of
+// it, kept as shadow branches.
+type Store interface {
+	Commit(message string) (string, error)
+	Restore(commitHash string) error
+	RestorePaths(commitHash string, paths []string) error
+	Diff(fromHash, toHash string) (DiffStat, error)
+	Changes(fromHash, toHash string) ([]FileChange, error)
+	Log(n int) ([]CommitInfo, error)
+	Prune(r Retention) (PruneResult, error)
+
+	Fork(name string) (string, error)
+	Switch(name string) error
+	Experiments() ([]Experiment, error)
+	CurrentExperiment() string
+	Compare(name string) (ExperimentDiff, error)
+	Merge(name string) (MergeResult, error)
+	Discard(name string) error
+}
+
+var _ Store = (*GitManager)(nil)
+
 // GitManager handles shadow git operations
 type GitManager struct {
 	cwd        string
@@ -115,3 +139,179 @@ func (g *GitManager) Restore(commitHash string) error {
 
 	return nil
 }
+
+// DiffStat summarizes the changes between two checkpoints
+type DiffStat struct {
+	Files     int      `json:"files"`
+	Additions int      `json:"additions"`
+	Deletions int      `json:"deletions"`
+	Paths     []string `json:"paths,omitempty"`
+}
+
+// Diff returns aggregate change counts between two commits.
+// An empty toHash compares fromHash against the current working tree.
+func (g *GitManager) Diff(fromHash, toHash string) (DiffStat, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	args := []string{"--git-dir=" + filepath.Join(g.shadowPath, ".git"), "--work-tree=" + g.cwd, "diff", "--numstat", fromHash}
+	if toHash != "" {
+		args = append(args, toHash)
+	}
+
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return DiffStat{}, fmt.Errorf("git diff failed: %w", err)
+	}
+
+	return parseNumstat(string(out)), nil
+}
+
+// parseNumstat converts `git diff --numstat` output into a DiffStat.
+// Binary files report "-" for both columns and only count towards Files.
+func parseNumstat(out string) DiffStat {
+	var stat DiffStat
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		stat.Files++
+		stat.Paths = append(stat.Paths, strings.Join(fields[2:], " "))
+		var add, del int
+		fmt.Sscanf(fields[0], "%d", &add)
+		fmt.Sscanf(fields[1], "%d", &del)
+		stat.Additions += add
+		stat.Deletions += del
+	}
+	return stat
+}
+
+// CommitInfo describes a shadow checkpoint
+type CommitInfo struct {
+	Hash      string    `json:"hash"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Log returns up to n most recent checkpoints, newest first
+func (g *GitManager) Log(n int) ([]CommitInfo, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	out, err := exec.Command("git", "--git-dir="+filepath.Join(g.shadowPath, ".git"), "log", fmt.Sprintf("-n%d", n), "--format=%H%x1f%ct%x1f%s").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log failed: %w", err)
+	}
+
+	var commits []CommitInfo
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		parts := strings.SplitN(line, "\x1f", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		var unix int64
+		fmt.Sscanf(parts[1], "%d", &unix)
+		commits = append(commits, CommitInfo{Hash: parts[0], Message: parts[2], Timestamp: time.Unix(unix, 0)})
+	}
+	return commits, nil
+}
+
+// FileChange is a per-file entry of the difference between two checkpoints
+type FileChange struct {
+	Path      string `json:"path"`
+	Status    string `json:"status"` // A (added), M (modified), D (deleted)
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Hunks     int    `json:"hunks"`
+}
+
+// Changes lists per-file changes from fromHash to toHash, with hunk counts
+func (g *GitManager) Changes(fromHash, toHash string) ([]FileChange, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.changes(fromHash, toHash)
+}
+
+func (g *GitManager) changes(fromHash, toHash string) ([]FileChange, error) {
+	gitDir := "--git-dir=" + filepath.Join(g.shadowPath, ".git")
+
+	statusOut, err := exec.Command("git", gitDir, "diff", "--name-status", "--no-renames", fromHash, toHash).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff failed: %w", err)
+	}
+
+	var changes []FileChange
+	index := make(map[string]int)
+	for _, line := range strings.Split(strings.TrimSpace(string(statusOut)), "\n") {
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		index[parts[1]] = len(changes)
+		changes = append(changes, FileChange{Path: parts[1], Status: parts[0][:1]})
+	}
+
+	numstatOut, err := exec.Command("git", gitDir, "diff", "--numstat", "--no-renames", fromHash, toHash).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff failed: %w", err)
+	}
+	for _, line := range strings.Split(string(numstatOut), "\n") {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if i, ok := index[parts[2]]; ok {
+			fmt.Sscanf(parts[0], "%d", &changes[i].Additions)
+			fmt.Sscanf(parts[1], "%d", &changes[i].Deletions)
+		}
+	}
+
+	// Count hunks from a zero-context patch
+	patchOut, err := exec.Command("git", gitDir, "diff", "--unified=0", "--no-renames", "--no-color", fromHash, toHash).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff failed: %w", err)
+	}
+	current := -1
+	for _, line := range strings.Split(string(patchOut), "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			current = -1
+			if i := strings.LastIndex(line, " b/"); i >= 0 {
+				if idx, ok := index[line[i+3:]]; ok {
+					current = idx
+				}
+			}
+		} else if strings.HasPrefix(line, "@@") && current >= 0 {
+			changes[current].Hunks++
+		}
+	}
+
+	return changes, nil
+}
+
+// RestorePaths reverts only the given files to their state at commitHash.
+// Files that did not exist at commitHash are deleted from the working tree.
+func (g *GitManager) RestorePaths(commitHash string, paths []string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	gitDir := "--git-dir=" + filepath.Join(g.shadowPath, ".git")
+
+	for _, p := range paths {
+		// Does the file exist in the target checkpoint?
+		exists := exec.Command("git", gitDir, "cat-file", "-e", commitHash+":"+p).Run() == nil
+		if !exists {
+			if err := os.Remove(filepath.Join(g.cwd, p)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", p, err)
+			}
+			continue
+		}
+
+		cmd := exec.Command("git", gitDir, "--work-tree="+g.cwd, "checkout", commitHash, "--", p)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git checkout %s failed: %s: %w", p, out, err)
+		}
+	}
+
+	return nil
+}
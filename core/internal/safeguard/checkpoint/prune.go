@@ -0,0 +1,145 @@
+package checkpoint
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Retention bounds the checkpoint history of a workspace. Zero fields are
+// unlimited; the newest checkpoint is always kept.
+type Retention struct {
+	MaxCount int
+	MaxAge   time.Duration
+	MaxSize  int64 // Bytes on disk of the shadow repository
+}
+
+// PruneResult reports what a prune removed
+type PruneResult struct {
+	Removed        int   `json:"removed"`
+	Kept           int   `json:"kept"`
+	SizeBefore     int64 `json:"sizeBefore"`
+	SizeAfter      int64 `json:"sizeAfter"`
+	ReclaimedBytes int64 `json:"reclaimedBytes"`
+}
+
+// Prune drops the checkpoints outside the retention policy and garbage
+// collects the shadow repository. The history is cut at the oldest kept
+// checkpoint without rewriting it, so the hashes of kept checkpoints stay
+// valid. For MaxSize the history is halved, oldest first, until the
+// repository fits.
+func (g *GitManager) Prune(r Retention) (PruneResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	gitDir := filepath.Join(g.shadowPath, ".git")
+	res := PruneResult{SizeBefore: dirSize(gitDir)}
+
+	commits, err := g.history()
+	if err != nil {
+		return res, err
+	}
+	keep := len(commits)
+	if r.MaxCount > 0 && keep > r.MaxCount {
+		keep = r.MaxCount
+	}
+	if r.MaxAge > 0 {
+		cutoff := time.Now().Add(-r.MaxAge)
+		for keep > 1 && commits[keep-1].Timestamp.Before(cutoff) {
+			keep--
+		}
+	}
+
+	size := res.SizeBefore
+	for {
+		if err := g.truncate(commits, keep); err != nil {
+			return res, err
+		}
+		if err := g.collect(); err != nil {
+			return res, err
+		}
+		size = dirSize(gitDir)
+		if r.MaxSize <= 0 || size <= r.MaxSize || keep <= 1 {
+			break
+		}
+		keep /= 2
+	}
+
+	res.Removed = len(commits) - keep
+	res.Kept = keep
+	res.SizeAfter = size
+	if size < res.SizeBefore {
+		res.ReclaimedBytes = res.SizeBefore - size
+	}
+	return res, nil
+}
+
+// history returns every checkpoint, newest first
+func (g *GitManager) history() ([]CommitInfo, error) {
+	gitDir := "--git-dir=" + filepath.Join(g.shadowPath, ".git")
+	if exec.Command("git", gitDir, "rev-parse", "--verify", "-q", "HEAD").Run() != nil {
+		return nil, nil // No checkpoint yet
+	}
+
+	out, err := exec.Command("git", gitDir, "log", "--first-parent", "--format=%H%x1f%ct").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log failed: %w", err)
+	}
+
+	var commits []CommitInfo
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		hash, ts, ok := strings.Cut(line, "\x1f")
+		if !ok {
+			continue
+		}
+		var unix int64
+		fmt.Sscanf(ts, "%d", &unix)
+		commits = append(commits, CommitInfo{Hash: hash, Timestamp: time.Unix(unix, 0)})
+	}
+	return commits, nil
+}
+
+// truncate makes the keep newest commits the whole history. The oldest
+// kept commit is marked as a shallow boundary, like the root of a shallow
+// clone, replacing the boundary left by an earlier prune.
+func (g *GitManager) truncate(commits []CommitInfo, keep int) error {
+	if keep <= 0 || keep >= len(commits) {
+		return nil
+	}
+	root := commits[keep-1].Hash
+	if err := os.WriteFile(filepath.Join(g.shadowPath, ".git", "shallow"), []byte(root+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to cut history: %w", err)
+	}
+	return nil
+}
+
+// collect deletes the objects no kept checkpoint references
+func (g *GitManager) collect() error {
+	gitDir := "--git-dir=" + filepath.Join(g.shadowPath, ".git")
+	if out, err := exec.Command("git", gitDir, "reflog", "expire", "--expire=now", "--all").CombinedOutput(); err != nil {
+		return fmt.Errorf("git reflog expire failed: %s: %w", out, err)
+	}
+	if out, err := exec.Command("git", gitDir, "gc", "--prune=now", "--quiet").CombinedOutput(); err != nil {
+		return fmt.Errorf("git gc failed: %s: %w", out, err)
+	}
+	return nil
+}
+
+// dirSize returns the bytes taken by the files under dir
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,131 @@
+package checkpoint
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestManager(t *testing.T) *GitManager {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	g, err := NewGitManager(t.TempDir(), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Init(); err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// commitFile writes a file of random bytes, which git can't compress, and
+// checkpoints it
+func commitFile(t *testing.T, g *GitManager, i, size int) string {
+	t.Helper()
+	data := make([]byte, size)
+	rand.Read(data)
+	if err := os.WriteFile(filepath.Join(g.cwd, "file.bin"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := g.Commit(fmt.Sprintf("checkpoint %d", i))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestPrune_MaxCount(t *testing.T) {
+	g := newTestManager(t)
+	var hashes []string
+	for i := 0; i < 6; i++ {
+		hashes = append(hashes, commitFile(t, g, i, 64<<10))
+	}
+
+	res, err := g.Prune(Retention{MaxCount: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Removed != 3 || res.Kept != 3 {
+		t.Errorf("removed %d, kept %d; want 3 and 3", res.Removed, res.Kept)
+	}
+	if res.ReclaimedBytes < 3*64<<10 || res.SizeAfter != res.SizeBefore-res.ReclaimedBytes {
+		t.Errorf("reclaimed %d bytes (%d -> %d), want at least the 3 dropped files", res.ReclaimedBytes, res.SizeBefore, res.SizeAfter)
+	}
+
+	log, err := g.Log(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 3 || log[0].Hash != hashes[5] || log[2].Hash != hashes[3] {
+		t.Fatalf("log after prune = %+v, want the 3 newest checkpoints with their hashes", log)
+	}
+	// Kept checkpoints still restore and diff
+	if _, err := g.Diff(hashes[3], hashes[5]); err != nil {
+		t.Errorf("diff between kept checkpoints: %v", err)
+	}
+
+	// A second prune moves the graft forward
+	commitFile(t, g, 6, 64<<10)
+	if res, err = g.Prune(Retention{MaxCount: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if res.Removed != 2 || res.Kept != 2 {
+		t.Errorf("second prune removed %d, kept %d; want 2 and 2", res.Removed, res.Kept)
+	}
+	if log, _ := g.Log(10); len(log) != 2 {
+		t.Errorf("log after second prune has %d checkpoints, want 2", len(log))
+	}
+	if err := g.Restore(hashes[5]); err != nil {
+		t.Errorf("restore of the oldest kept checkpoint: %v", err)
+	}
+}
+
+func TestPrune_MaxSize(t *testing.T) {
+	g := newTestManager(t)
+	for i := 0; i < 8; i++ {
+		commitFile(t, g, i, 256<<10)
+	}
+
+	res, err := g.Prune(Retention{MaxSize: 1 << 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.SizeAfter > 1<<20 {
+		t.Errorf("size after prune = %d, want at most 1 MB", res.SizeAfter)
+	}
+	if res.Kept < 1 || res.Kept >= 8 || res.Removed != 8-res.Kept {
+		t.Errorf("kept %d, removed %d of 8", res.Kept, res.Removed)
+	}
+}
+
+func TestPrune_WithinPolicy(t *testing.T) {
+	g := newTestManager(t)
+
+	// An empty repository has nothing to prune
+	if res, err := g.Prune(Retention{MaxCount: 1}); err != nil || res.Removed != 0 {
+		t.Fatalf("prune of empty repo = %+v, %v", res, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		commitFile(t, g, i, 1024)
+	}
+	res, err := g.Prune(Retention{MaxCount: 10, MaxAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Removed != 0 || res.Kept != 3 {
+		t.Errorf("removed %d, kept %d; want nothing removed", res.Removed, res.Kept)
+	}
+}
@@ -0,0 +1,430 @@
+package safeguard
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// CommandRisk rates what a shell command may do to the machine
+type CommandRisk string
+
+// Command risks, from least to most restricted
+const (
+	CommandSafe      CommandRisk = "safe"      // Reads, builds or tests; runs with execute_safe_commands
+	CommandAsk       CommandRisk = "ask"       // Runs with execute_all_commands, otherwise asks
+	CommandDangerous CommandRisk = "dangerous" // Always asks, even with execute_all_commands
+	CommandDenied    CommandRisk = "denied"    // Never runs
+)
+
+// CommandVerdict is the risk of a command and why
+type CommandVerdict struct {
+	Risk   CommandRisk
+	Reason string // Completes "this command ...", e.g. "pipes a download into a shell"
+}
+
+type commandPattern struct {
+	re     *regexp.Regexp
+	reason string
+}
+
+func patterns(pairs ...string) []commandPattern {
+	var out []commandPattern
+	for i := 0; i < len(pairs); i += 2 {
+		out = append(out, commandPattern{re: regexp.MustCompile(pairs[i]), reason: pairs[i+1]})
+	}
+	return out
+}
+
+// deniedCommands are never worth running
+var deniedCommands = patterns(
+	`\brm\s+(?:-\S+\s+)*(?:/|/\*|~|~/|~/\*|\$HOME|\$HOME/)(?:\s|[;&|]|$)`, "removes the filesystem root or home directory",
+	`:\(\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`, "is a fork bomb",
+	`\bmkfs(?:\.\w+)?\b`, "formats a filesystem",
+	`\bdd\b.*\bof=/dev/(?:sd|hd|nvme|disk|mmcblk)`, "overwrites a disk",
+	`>\s*/dev/(?:sd|hd|nvme|disk|mmcblk)`, "overwrites a disk",
+	`\bch(?:mod|own)\s+(?:-\S+\s+)*-R\s+(?:-\S+\s+)*\S+\s+/(?:\s|$)`, "changes the permissions of the whole filesystem",
+)
+
+// dangerousCommands always ask, whatever the auto-approval settings
+var dangerousCommands = patterns(
+	`\b(?:curl|wget)\b[^|;&]*\|\s*(?:sudo\s+)?(?:ba|z|da|k)?sh\b`, "pipes a download into a shell",
+	`\b(?:ba|z|da|k)?sh\s+(?:-c\s+)?["']?(?:\$\(|<\(|`+"`"+`)\s*(?:curl|wget)\b`, "runs a downloaded script",
+	`(?:^|[;&|]\s*)sudo\b`, "runs as root",
+	`\bgit\s+push\b.*\s(?:--force\b|--force-with-lease\b|-f\b)`, "force-pushes",
+	`\bgit\s+(?:reset\s+--hard|clean\s+-\S*f)`, "discards uncommitted work",
+	`\brm\s+(?:-\S+\s+)*-(?:[a-zA-Z]*[rR][a-zA-Z]*|-recursive)\b`, "removes files recursively",
+	`(?:^|[;&|]\s*)(?:shutdown|reboot|halt|poweroff)\b`, "shuts down the machine",
+)
+
+// readOnlyPrograms only read, whatever their arguments (see unsafeFlags)
+var readOnlyPrograms = map[string]bool{
+	"ls": true, "cat": true, "head": true, "tail": true, "wc": true, "grep": true,
+	"rg": true, "fd": true, "find": true, "sort": true, "uniq": true,
+	"cut": true, "tr": true, "jq": true, "diff": true, "cmp": true, "tree": true,
+	"pwd": true, "cd": true, "echo": true, "printf": true, "date": true, "whoami": true,
+	"which": true, "type": true, "file": true, "stat": true, "du": true, "df": true,
+	"uname": true, "ps": true, "basename": true, "dirname": true, "realpath": true,
+	"true": true, "false": true, "test": true, "nl": true, "column": true,
+	"md5sum": true, "sha256sum": true, "gofmt": true,
+}
+
+// unsafeFlags make an otherwise read-only program or safe subcommand write
+// files or run other programs. sed is not read-only at all: its scripts can
+// write files and run commands.
+var unsafeFlags = map[string][]string{
+	"find":  {"-delete", "-exec", "-execdir", "-ok", "-okdir", "-fprint", "-fprint0", "-fprintf", "-fls"},
+	"fd":    {"-x", "-X", "--exec", "--exec-batch"},
+	"rg":    {"--pre"},
+	"sort":  {"-o", "--output"},
+	"tree":  {"-o"},
+	"gofmt": {"-w"},
+	"git":   {"--output", "-O", "--open-files-in-pager", "--ext-diff"},
+	"go":    {"-exec", "--exec", "-toolexec", "--toolexec", "-vettool", "--vettool"},
+}
+
+// safeSubcommands are the read-only, build and test subcommands of tools
+var safeSubcommands = map[string][]string{
+	"git":     {"status", "diff", "log", "show", "blame", "rev-parse", "ls-files", "describe", "grep", "shortlog"},
+	"go":      {"test", "build", "vet", "list", "version", "doc"},
+	"cargo":   {"build", "test", "check", "clippy", "tree", "doc", "metadata"},
+	"npm":     {"test", "t", "ls", "list", "outdated", "view", "why"},
+	"pnpm":    {"test", "ls", "list", "outdated", "why"},
+	"yarn":    {"test", "list", "outdated", "why"},
+	"node":    {"--version", "-v"},
+	"python":  {"--version", "-V", "-m pytest", "-m unittest", "-m mypy"},
+	"python3": {"--version", "-V", "-m pytest", "-m unittest", "-m mypy"},
+	"pytest":  {""},
+	"mypy":    {""},
+	"tsc":     {""},
+}
+
+// safeScripts are the package.json scripts that build, test or lint
+var safeScripts = map[string]bool{"test": true, "lint": true, "build": true, "typecheck": true, "check": true}
+
+// installCommands install dependencies from the project manifest; they are
+// safe when CommandRules.AllowPackageManagers is set
+var installCommands = map[string][]string{
+	"npm":      {"ci", "install", "i"},
+	"pnpm":     {"install", "i"},
+	"yarn":     {"install"},
+	"go":       {"mod download", "mod tidy", "mod verify"},
+	"cargo":    {"fetch"},
+	"pip":      {"install -r"},
+	"pip3":     {"install -r"},
+	"poetry":   {"install"},
+	"uv":       {"sync"},
+	"bundle":   {"install"},
+	"composer": {"install"},
+}
+
+// ClassifyCommand rates a shell command. Denied and dangerous patterns are
+// checked on the whole line; otherwise every command of a pipeline or list
+// must be safe for the line to be safe.
+func ClassifyCommand(command string, rules CommandRules) CommandVerdict {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return CommandVerdict{Risk: CommandAsk, Reason: "is empty"}
+	}
+
+	for _, expr := range rules.DenyRegex {
+		if re, err := regexp.Compile(expr); err == nil && re.MatchString(command) {
+			return CommandVerdict{Risk: CommandDenied, Reason: fmt.Sprintf("matches deny rule '%s'", expr)}
+		}
+	}
+	for _, p := range deniedCommands {
+		if p.re.MatchString(command) {
+			return CommandVerdict{Risk: CommandDenied, Reason: p.reason}
+		}
+	}
+	for _, p := range dangerousCommands {
+		if p.re.MatchString(command) {
+			return CommandVerdict{Risk: CommandDangerous, Reason: p.reason}
+		}
+	}
+
+	segments, writes, ok := splitCommand(command)
+	if !ok {
+		return CommandVerdict{Risk: CommandAsk, Reason: "uses command substitution or unbalanced quotes"}
+	}
+	if writes {
+		return CommandVerdict{Risk: CommandAsk, Reason: "redirects output into a file"}
+	}
+	for _, words := range segments {
+		if reason := unsafeSegment(words, rules); reason != "" {
+			return CommandVerdict{Risk: CommandAsk, Reason: reason}
+		}
+	}
+	return CommandVerdict{Risk: CommandSafe}
+}
+
+// unsafeSegment says why one simple command is not safe, or "" if it is
+func unsafeSegment(words []string, rules CommandRules) string {
+	// Skip variable assignments and wrappers that only time or limit the command
+strip:
+	for len(words) > 0 {
+		switch {
+		case isAssignment(words[0]), words[0] == "time", words[0] == "nice":
+			words = words[1:]
+		case words[0] == "timeout" && len(words) > 1:
+			words = words[2:]
+		default:
+			break strip
+		}
+	}
+	if len(words) == 0 {
+		return ""
+	}
+	line := strings.Join(words, " ")
+	for _, prefix := range rules.Safe {
+		if line == prefix || strings.HasPrefix(line, prefix+" ") {
+			return ""
+		}
+	}
+
+	program := words[0]
+	if i := strings.LastIndex(program, "/"); i != -1 {
+		program = program[i+1:]
+	}
+	args := words[1:]
+
+	if arg := unsafeFlag(program, args); arg != "" {
+		return fmt.Sprintf("runs %s %s, which writes files or runs other programs", program, arg)
+	}
+	if readOnlyPrograms[program] {
+		return ""
+	}
+
+	if program == "git" {
+		// Global options before the subcommand
+		for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+			// Configuration can name programs for git to run, e.g. core.fsmonitor
+			if strings.HasPrefix(args[0], "-c") || strings.HasPrefix(args[0], "--config-env") {
+				return fmt.Sprintf("runs git %s, which can make git run other programs", args[0])
+			}
+			if args[0] == "-C" {
+				args = args[min(2, len(args)):]
+				continue
+			}
+			args = args[1:]
+		}
+		if len(args) > 0 && gitReadOnly(args[0], args[1:]) {
+			return ""
+		}
+	}
+	for _, sub := range safeSubcommands[program] {
+		if hasSubcommand(args, sub) {
+			return ""
+		}
+	}
+	if program == "npm" || program == "pnpm" || program == "yarn" {
+		if len(args) >= 2 && args[0] == "run" && safeScripts[args[1]] {
+			return ""
+		}
+	}
+	if rules.AllowPackageManagers {
+		for _, install := range installCommands[program] {
+			if isManifestInstall(args, install) {
+				return ""
+			}
+		}
+	}
+	return fmt.Sprintf("runs %s, which is not on the safe list", strings.Join(words[:min(2, len(words))], " "))
+}
+
+// unsafeFlag returns the first of args that is one of the program's unsafe
+// flags, "" if there is none. Flags match alone, with "=value", and short
+// flags also inside a cluster or with the value attached ("-Hx", "-oout").
+func unsafeFlag(program string, args []string) string {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		for _, flag := range unsafeFlags[program] {
+			short := len(flag) == 2 && flag[0] == '-' && flag[1] != '-'
+			if arg == flag || strings.HasPrefix(arg, flag+"=") ||
+				(short && len(arg) > 1 && arg[0] == '-' && arg[1] != '-' && strings.Contains(arg[1:], flag[1:])) {
+				return arg
+			}
+		}
+	}
+	return ""
+}
+
+// gitReadOnly reports whether a git subcommand only reads, e.g. "branch"
+// lists but "branch -D" deletes
+func gitReadOnly(sub string, args []string) bool {
+	readOnly := func(writing ...string) bool {
+		for _, arg := range args {
+			for _, w := range writing {
+				if arg == w {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	switch sub {
+	case "branch":
+		return readOnly("-d", "-D", "--delete", "-m", "-M", "--move", "-c", "-C", "--copy", "-f", "--force", "-u", "--set-upstream-to", "--unset-upstream")
+	case "tag":
+		return len(args) == 0 || args[0] == "-l" || args[0] == "--list"
+	case "remote":
+		return len(args) == 0 || (len(args) == 1 && args[0] == "-v")
+	case "config":
+		return len(args) > 0 && (args[0] == "--get" || args[0] == "--list" || args[0] == "-l" || args[0] == "--get-all")
+	case "stash":
+		return len(args) > 0 && (args[0] == "list" || args[0] == "show")
+	}
+	return false
+}
+
+// hasSubcommand reports whether args start with the words of sub; "" matches anything
+func hasSubcommand(args []string, sub string) bool {
+	words := strings.Fields(sub)
+	if len(args) < len(words) {
+		return false
+	}
+	for i, w := range words {
+		if args[i] != w {
+			return false
+		}
+	}
+	return true
+}
+
+// isManifestInstall reports whether args run install without naming
+// packages, so only what the project manifest lists is installed.
+// An install ending in a flag ("pip install -r") takes one file argument.
+func isManifestInstall(args []string, install string) bool {
+	if !hasSubcommand(args, install) {
+		return false
+	}
+	words := strings.Fields(install)
+	rest := args[len(words):]
+	if strings.HasPrefix(words[len(words)-1], "-") {
+		if len(rest) == 0 {
+			return false
+		}
+		rest = rest[1:]
+	}
+	for _, arg := range rest {
+		if !strings.HasPrefix(arg, "-") {
+			return false
+		}
+	}
+	return true
+}
+
+func isAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitCommand splits a shell line into the words of its simple commands,
+// separated by ;, &, |, && and || or newlines. writes reports an output
+// redirection to a file other than /dev/null; ok is false for command
+// substitution, process substitution and unbalanced quotes, which cannot
+// be judged without running a shell.
+func splitCommand(command string) (segments [][]string, writes, ok bool) {
+	var (
+		segment []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+	)
+	flushWord := func() {
+		if inWord {
+			segment = append(segment, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	flushSegment := func() {
+		flushWord()
+		if len(segment) > 0 {
+			segments = append(segments, segment)
+			segment = nil
+		}
+	}
+	runes := []rune(command)
+	next := func(i int) rune {
+		if i+1 < len(runes) {
+			return runes[i+1]
+		}
+		return 0
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '`' || (r == '$' && next(i) == '('):
+			return nil, false, false
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\' && i+1 < len(runes):
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case r == '<' || r == '>' || (r == '&' && next(i) == '>'):
+			if r == '<' && next(i) == '(' {
+				return nil, false, false
+			}
+			// A file descriptor number belongs to the redirection, not the command
+			if inWord && strings.Trim(word.String(), "0123456789") == "" {
+				word.Reset()
+				inWord = false
+			}
+			flushWord()
+			output := r != '<'
+			for i+1 < len(runes) && (runes[i+1] == '<' || runes[i+1] == '>' || runes[i+1] == '&') {
+				i++
+			}
+			for i+1 < len(runes) && runes[i+1] == ' ' {
+				i++
+			}
+			start := i + 1
+			for i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && !strings.ContainsRune(";&|<>", runes[i+1]) {
+				i++
+			}
+			target := string(runes[start : i+1])
+			if output && target != "/dev/null" && target != "-" && strings.Trim(target, "0123456789") != "" {
+				writes = true
+			}
+		case r == ';' || r == '&' || r == '|' || r == '\n':
+			flushSegment()
+		case unicode.IsSpace(r):
+			flushWord()
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, false, false
+	}
+	flushSegment()
+	return segments, writes, true
+}
@@ -4,21 +4,29 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 
 	"gopkg.in/yaml.v3"
 )
 
 // PermissionConfig holds the rules definition
 type PermissionConfig struct {
-	Files    FileRules    `yaml:"files"`
-	Tools    ToolRules    `yaml:"tools"`
-	Commands CommandRules `yaml:"commands"`
+	Files     FileRules                 `yaml:"files"`
+	Tools     ToolRules                 `yaml:"tools"`
+	Commands  CommandRules              `yaml:"commands"`
+	Domains   DomainRules               `yaml:"domains"`
+	MCP       McpRules                  `yaml:"mcp"`
+	Sandbox   map[string]SandboxProfile `yaml:"sandbox"` // Named profiles for execute_node / execute_bash_script
+	Redaction RedactionRules            `yaml:"redaction"`
+	Zones     map[string]string         `yaml:"zones"` // Path patterns to trust zones, see PathZone
+	Injection InjectionRules            `yaml:"injection"`
 }
 
 // FileRules defines file access patterns
 type FileRules struct {
-	Allow []string `yaml:"allow"` // Glob patterns to allow
-	Deny  []string `yaml:"deny"`  // Glob patterns to deny (precedence over allow)
+	Allow     []string `yaml:"allow"`      // Glob patterns to allow
+	Deny      []string `yaml:"deny"`       // Glob patterns to deny (precedence over allow)
+	DenyWrite []string `yaml:"deny_write"` // Directories file tools may not write into; unset means .git and node_modules
 }
 
 // ToolRules defines tool usage permissions
@@ -27,10 +35,75 @@ type ToolRules struct {
 	Deny  []string `yaml:"deny"`  // Tool names to deny
 }
 
-// CommandRules defines shell command permissions
+// CommandRules defines shell command permissions. Besides the allow and
+// deny lists, every command is rated by ClassifyCommand: DenyRegex blocks
+// commands outright, and Safe and AllowPackageManagers widen what
+// execute_safe_commands auto-approves.
 type CommandRules struct {
-	Allow []string `yaml:"allow"` // Command prefixes or exact matches to allow
-	Deny  []string `yaml:"deny"`  // Command prefixes or exact matches to deny
+	Allow                []string `yaml:"allow"`                  // Command prefixes or exact matches to allow
+	Deny                 []string `yaml:"deny"`                   // Command prefixes or exact matches to deny
+	DenyRegex            []string `yaml:"deny_regex"`             // Commands matching any expression never run
+	Safe                 []string `yaml:"safe"`                   // Extra safe command prefixes, e.g. "make test"
+	AllowPackageManagers bool     `yaml:"allow_package_managers"` // Installs from the project manifest (npm ci, go mod download, pip install -r) count as safe
+}
+
+// DomainRules defines which hosts web_fetch and http_request may contact without asking.
+// Patterns are exact hosts, "*.example.com" (the domain and its subdomains)
+// or "*". Hosts matching neither list need the user's approval.
+// Deny also applies to the browser and shell commands, see CheckEgress.
+type DomainRules struct {
+	Allow   []string `yaml:"allow"`
+	Deny    []string `yaml:"deny"`    // Never contacted (precedence over allow)
+	Enforce bool     `yaml:"enforce"` // Allow is an egress allowlist: other hosts are refused everywhere, never asked about
+}
+
+// RedactionRules configures the masking of secrets in tool results and
+// messenger forwards. API keys, tokens, JWTs and private keys are always
+// matched unless Disabled.
+type RedactionRules struct {
+	Disabled bool     `yaml:"disabled"`
+	Patterns []string `yaml:"patterns"` // Extra regular expressions; one with a group masks only the group
+}
+
+// InjectionRules configures the screening of web, MCP and file content for
+// prompt injections, see ScreenContent
+type InjectionRules struct {
+	Disabled bool     `yaml:"disabled"`
+	Patterns []string `yaml:"patterns"` // Extra regular expressions flagged as injections
+}
+
+// MCP tool policies
+const (
+	McpAllow = "allow" // Run without asking
+	McpAsk   = "ask"   // Ask first, even with auto-approval on
+	McpDeny  = "deny"  // Never run
+)
+
+// McpRules sets policies for MCP tools. The most specific policy wins: tool,
+// then server, then Default. Without any, MCP tools follow auto-approval.
+type McpRules struct {
+	Default string                    `yaml:"default"`
+	Servers map[string]McpServerRules `yaml:"servers"`
+}
+
+// McpServerRules is the policy for one MCP server and overrides for its tools
+type McpServerRules struct {
+	Policy string                 `yaml:"policy"`
+	Tools  map[string]McpToolRule `yaml:"tools"`
+}
+
+// McpToolRule is the policy for one MCP tool. Args constrain argument values
+// by wildcard pattern ("*" matches anything, including "/"): a value matching
+// deny blocks the call, and a value outside a non-empty allow list needs approval.
+type McpToolRule struct {
+	Policy string             `yaml:"policy"`
+	Args   map[string]ArgRule `yaml:"args"`
+}
+
+// ArgRule constrains the string form of one tool argument
+type ArgRule struct {
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"` // Precedence over allow
 }
 
 // LoadConfig loads permissions from the project root
@@ -51,8 +124,12 @@ func LoadConfig(cwd string) (*PermissionConfig, error) {
 				Allow: []string{"*"},
 			},
 			Commands: CommandRules{
-				Allow: []string{"*"},
-				Deny:  []string{"rm -rf /", ":(){ :|:& };:"}, // Basic sanity
+				Allow:                []string{"*"},
+				Deny:                 []string{"rm -rf /", ":(){ :|:& };:"}, // Basic sanity
+				AllowPackageManagers: true,
+			},
+			Domains: DomainRules{
+				Allow: []string{"localhost", "127.0.0.1", "::1"}, // Local dev servers
 			},
 		}, nil
 	}
@@ -66,6 +143,26 @@ func LoadConfig(cwd string) (*PermissionConfig, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse permissions config: %w", err)
 	}
+	for _, expr := range cfg.Commands.DenyRegex {
+		if _, err := regexp.Compile(expr); err != nil {
+			return nil, fmt.Errorf("invalid commands.deny_regex '%s': %w", expr, err)
+		}
+	}
+	for pattern, name := range cfg.Zones {
+		if _, err := ParseTrustZone(name); err != nil {
+			return nil, fmt.Errorf("invalid zones '%s': %w", pattern, err)
+		}
+	}
+	for _, expr := range cfg.Injection.Patterns {
+		if _, err := regexp.Compile(expr); err != nil {
+			return nil, fmt.Errorf("invalid injection.patterns '%s': %w", expr, err)
+		}
+	}
+	for _, expr := range cfg.Redaction.Patterns {
+		if _, err := regexp.Compile(expr); err != nil {
+			return nil, fmt.Errorf("invalid redaction.patterns '%s': %w", expr, err)
+		}
+	}
 
 	return &cfg, nil
 }
@@ -0,0 +1,223 @@
+package safeguard
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
+)
+
+// The egress policy is the domains section of permissions.yaml applied to
+// every request the agent starts: http_request, the browser, and shell
+// commands, which are pointed at a local filtering proxy through the usual
+// proxy environment variables. Programs that ignore those variables are not
+// covered.
+
+// CheckEgress reports whether the agent may contact host from a channel that
+// cannot ask the user (browser, shell commands): denied hosts never, and with
+// enforce only allowed ones
+func (m *Manager) CheckEgress(host string) error {
+	if m == nil || m.Permissions == nil {
+		return nil
+	}
+	if _, err := m.CheckDomain(host); err != nil {
+		return fmt.Errorf("egress to %s blocked: %w", host, err)
+	}
+	return nil
+}
+
+// CheckEgressURL is CheckEgress for the host of a URL; one without a scheme
+// is taken as https. Schemes that do not reach the network (data:, blob:,
+// about:) pass.
+func (m *Manager) CheckEgressURL(raw string) error {
+	if !strings.Contains(raw, "://") && !strings.HasPrefix(raw, "data:") && !strings.HasPrefix(raw, "about:") && !strings.HasPrefix(raw, "blob:") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil
+	}
+	switch u.Scheme {
+	case "http", "https", "ws", "wss", "ftp":
+		return m.CheckEgress(u.Hostname())
+	}
+	return nil
+}
+
+// EgressActive reports whether an egress policy restricts anything
+func (m *Manager) EgressActive() bool {
+	return m != nil && m.Permissions != nil &&
+		(len(m.Permissions.Domains.Deny) > 0 || m.Permissions.Domains.Enforce)
+}
+
+// EgressEnv returns the environment that routes a shell command's HTTP(S)
+// traffic through the filtering proxy, or nil when no policy is active. The
+// proxy starts on first use and chains to the configured upstream proxy. If
+// it can't start, an enforced allowlist fails the command; a deny list alone
+// lets it run unfiltered.
+func (m *Manager) EgressEnv() ([]string, error) {
+	if !m.EgressActive() {
+		return nil, nil
+	}
+	m.egressMu.Lock()
+	defer m.egressMu.Unlock()
+	if m.egress == nil {
+		p, err := startEgressProxy(m.CheckEgress)
+		if err != nil {
+			if m.Permissions.Domains.Enforce {
+				return nil, fmt.Errorf("egress proxy failed to start, refusing to run commands unfiltered: %w", err)
+			}
+			log.Printf("[Safeguard] Failed to start egress proxy, commands run unfiltered: %v", err)
+			return nil, nil
+		}
+		m.egress = p
+	}
+	proxy := "http://" + m.egress.addr
+	var env []string
+	for _, name := range []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY"} {
+		env = append(env, name+"="+proxy, strings.ToLower(name)+"="+proxy)
+	}
+	// Nothing may bypass the proxy
+	return append(env, "NO_PROXY=", "no_proxy="), nil
+}
+
+// egressProxy is a local HTTP proxy that refuses hosts the policy blocks
+type egressProxy struct {
+	addr  string
+	check func(host string) error
+}
+
+// listenEgress opens the proxy's listener
+var listenEgress = func() (net.Listener, error) { return net.Listen("tcp", "127.0.0.1:0") }
+
+func startEgressProxy(check func(host string) error) (*egressProxy, error) {
+	ln, err := listenEgress()
+	if err != nil {
+		return nil, err
+	}
+	p := &egressProxy{addr: ln.Addr().String(), check: check}
+	srv := &http.Server{Handler: p, ReadHeaderTimeout: 30 * time.Second}
+	go srv.Serve(ln)
+	return p, nil
+}
+
+func (p *egressProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host := r.URL.Hostname()
+	if r.Method == http.MethodConnect {
+		host, _, _ = net.SplitHostPort(r.Host)
+	}
+	if err := p.check(host); err != nil {
+		log.Printf("[Safeguard] Egress proxy refused %s %s: %v", r.Method, r.Host, err)
+		http.Error(w, "Blocked by Ricochet egress policy: "+err.Error(), http.StatusForbidden)
+		return
+	}
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(w, "not a proxy request", http.StatusBadRequest)
+		return
+	}
+	p.forward(w, r)
+}
+
+// forward relays a plain HTTP request
+func (p *egressProxy) forward(w http.ResponseWriter, r *http.Request) {
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	for _, h := range []string{"Proxy-Connection", "Proxy-Authorization", "Connection", "Keep-Alive", "Te", "Trailer", "Upgrade"} {
+		out.Header.Del(h)
+	}
+	resp, err := httpclient.Transport().RoundTrip(out)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for k, vs := range resp.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// tunnel relays a CONNECT (HTTPS) stream, directly or through the upstream proxy
+func (p *egressProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, err := dialTunnel(r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "tunneling not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buf, err := hj.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	client.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+
+	go func() {
+		if n := buf.Reader.Buffered(); n > 0 {
+			pending, _ := buf.Reader.Peek(n)
+			upstream.Write(pending)
+		}
+		io.Copy(upstream, client)
+		upstream.Close()
+	}()
+	io.Copy(client, upstream)
+	client.Close()
+}
+
+// dialTunnel connects to target (host:port), through the configured upstream
+// proxy if there is one for it
+func dialTunnel(target string) (net.Conn, error) {
+	proxyURL, _ := httpclient.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: target}})
+	if proxyURL == nil {
+		return net.DialTimeout("tcp", target, 30*time.Second)
+	}
+
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+	conn, err := net.DialTimeout("tcp", proxyAddr, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	req := fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", target, target)
+	if u := proxyURL.User; u != nil {
+		pass, _ := u.Password()
+		req += "Proxy-Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(u.Username()+":"+pass)) + "\r\n"
+	}
+	if _, err := conn.Write([]byte(req + "\r\n")); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("upstream proxy: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("upstream proxy refused CONNECT %s: %s", target, resp.Status)
+	}
+	return conn, nil
+}
@@ -0,0 +1,118 @@
+package safeguard
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCheckEgress(t *testing.T) {
+	m := &Manager{Permissions: &PermissionConfig{Domains: DomainRules{Deny: []string{"*.pastebin.com"}}}}
+	if err := m.CheckEgress("api.pastebin.com"); err == nil {
+		t.Error("denied host allowed")
+	}
+	if err := m.CheckEgress("github.com"); err != nil {
+		t.Errorf("without enforce only deny applies: %v", err)
+	}
+	if !m.EgressActive() {
+		t.Error("deny list should activate the policy")
+	}
+
+	m.Permissions.Domains = DomainRules{Allow: []string{"*.github.com", "proxy.golang.org"}, Enforce: true}
+	for host, want := range map[string]bool{"api.github.com": true, "proxy.golang.org": true, "evil.example": false} {
+		if got := m.CheckEgress(host) == nil; got != want {
+			t.Errorf("CheckEgress(%s) allowed = %v, want %v", host, got, want)
+		}
+	}
+	if _, err := m.CheckDomain("evil.example"); err == nil {
+		t.Error("enforced allowlist should refuse http_request instead of asking")
+	}
+
+	for raw, want := range map[string]bool{
+		"https://api.github.com/repos": true,
+		"evil.example/path":            false,
+		"wss://evil.example/socket":    false,
+		"data:text/html,hi":            true,
+		"about:blank":                  true,
+	} {
+		if got := m.CheckEgressURL(raw) == nil; got != want {
+			t.Errorf("CheckEgressURL(%s) allowed = %v, want %v", raw, got, want)
+		}
+	}
+
+	var none *Manager
+	if env, err := none.EgressEnv(); none.EgressActive() || env != nil || err != nil || none.CheckEgress("x") != nil {
+		t.Error("nil manager should not restrict egress")
+	}
+}
+
+func TestEgressProxy(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "plain ok") }))
+	defer plain.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "tls ok") }))
+	defer secure.Close()
+
+	m := &Manager{Permissions: &PermissionConfig{Domains: DomainRules{Allow: []string{"127.0.0.1"}, Enforce: true}}}
+	env, err := m.EgressEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var proxy string
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, "HTTPS_PROXY="); ok {
+			proxy = v
+		}
+	}
+	if proxy == "" || !strings.Contains(strings.Join(env, " "), "NO_PROXY=") {
+		t.Fatalf("unexpected env %v", env)
+	}
+	proxyURL, _ := url.Parse(proxy)
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+
+	get := func(target string) (int, string, error) {
+		resp, err := client.Get(target)
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), nil
+	}
+
+	if code, body, err := get(plain.URL); err != nil || code != 200 || body != "plain ok" {
+		t.Errorf("allowed HTTP: %d %q %v", code, body, err)
+	}
+	if code, body, err := get(secure.URL); err != nil || code != 200 || body != "tls ok" {
+		t.Errorf("allowed HTTPS tunnel: %d %q %v", code, body, err)
+	}
+	if code, body, err := get("http://blocked.example/"); err != nil || code != http.StatusForbidden || !strings.Contains(body, "egress policy") {
+		t.Errorf("blocked HTTP: %d %q %v", code, body, err)
+	}
+	if _, _, err := get("https://blocked.example/"); err == nil {
+		t.Error("blocked HTTPS tunnel succeeded")
+	}
+}
+
+func TestEgressEnvFailsClosed(t *testing.T) {
+	listen := listenEgress
+	listenEgress = func() (net.Listener, error) { return nil, errors.New("no sockets") }
+	defer func() { listenEgress = listen }()
+
+	enforced := &Manager{Permissions: &PermissionConfig{Domains: DomainRules{Allow: []string{"github.com"}, Enforce: true}}}
+	if env, err := enforced.EgressEnv(); err == nil || env != nil {
+		t.Errorf("enforced policy without a proxy: env %v, err %v", env, err)
+	}
+	denyOnly := &Manager{Permissions: &PermissionConfig{Domains: DomainRules{Deny: []string{"*.pastebin.com"}}}}
+	if env, err := denyOnly.EgressEnv(); err != nil || env != nil {
+		t.Errorf("deny list without a proxy: env %v, err %v", env, err)
+	}
+}
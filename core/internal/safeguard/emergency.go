@@ -0,0 +1,63 @@
+package safeguard
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EmergencyState is the process-wide kill switch. While it is engaged every
+// workspace runs as if read-only: writes, commands, scripts, HTTP requests
+// and MCP tools are refused until it is released. It lives outside any
+// Manager so that a controller rebuilt after a config change stays stopped.
+type EmergencyState struct {
+	Active bool      `json:"active"`
+	Since  time.Time `json:"since,omitempty"`
+	By     string    `json:"by,omitempty"` // Channel that pulled it: tui, ide, telegram...
+}
+
+var emergency struct {
+	mu    sync.RWMutex
+	state EmergencyState
+}
+
+// EngageEmergencyStop pulls the kill switch. Engaging it again keeps the
+// original time and channel.
+func EngageEmergencyStop(by string) EmergencyState {
+	emergency.mu.Lock()
+	defer emergency.mu.Unlock()
+	if !emergency.state.Active {
+		emergency.state = EmergencyState{Active: true, Since: time.Now(), By: by}
+	}
+	return emergency.state
+}
+
+// ReleaseEmergencyStop re-enables writes and execution, returning the state
+// that was released
+func ReleaseEmergencyStop() EmergencyState {
+	emergency.mu.Lock()
+	defer emergency.mu.Unlock()
+	released := emergency.state
+	emergency.state = EmergencyState{}
+	return released
+}
+
+// Emergency returns the kill switch state
+func Emergency() EmergencyState {
+	emergency.mu.RLock()
+	defer emergency.mu.RUnlock()
+	return emergency.state
+}
+
+// CheckEmergency refuses the tools a read-only zone would while the kill
+// switch is engaged
+func CheckEmergency(tool string) error {
+	state := Emergency()
+	if !state.Active {
+		return nil
+	}
+	if CheckZonePermission(ZoneReadOnly, tool) == nil {
+		return nil
+	}
+	return fmt.Errorf("emergency stop engaged (by %s at %s): tool '%s' is disabled until it is released with /resume", state.By, state.Since.Format(time.Kitchen), tool)
+}
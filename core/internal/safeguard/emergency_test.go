@@ -0,0 +1,37 @@
+package safeguard
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmergencyStop(t *testing.T) {
+	t.Cleanup(func() { ReleaseEmergencyStop() })
+	m := &Manager{CurrentZone: ZoneDanger}
+
+	first := EngageEmergencyStop("telegram")
+	if again := EngageEmergencyStop("tui"); again != first {
+		t.Errorf("engaging twice replaced the state: %+v, want %+v", again, first)
+	}
+
+	for _, tool := range []string{"write_file", "execute_command", "http_request", "db__drop_table"} {
+		err := m.CheckPermission(tool)
+		if err == nil || !strings.Contains(err.Error(), "emergency stop") {
+			t.Errorf("%s allowed during an emergency stop: %v", tool, err)
+		}
+	}
+	if err := m.CheckPathPermission("write_file", "/tmp/a"); err == nil {
+		t.Error("path check ignored the emergency stop")
+	}
+	if err := m.CheckPermission("read_file"); err != nil {
+		t.Errorf("reads should keep working: %v", err)
+	}
+
+	released := ReleaseEmergencyStop()
+	if !released.Active || released.By != "telegram" {
+		t.Errorf("released %+v", released)
+	}
+	if Emergency().Active || m.CheckPermission("write_file") != nil {
+		t.Error("writes still blocked after release")
+	}
+}
@@ -2,7 +2,12 @@ package safeguard
 
 import (
 	"fmt"
+	"log"
+	"net"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/igoryan-dao/ricochet/internal/config"
 	"github.com/igoryan-dao/ricochet/internal/paths"
@@ -11,14 +16,34 @@ import (
 
 // Manager handles all safeguard operations including checkpoints
 type Manager struct {
-	gitManager      *checkpoint.GitManager
+	checkpoints     checkpoint.Store
 	PermissionStore *PermissionStore
+	Audit           *AuditLog         // Approval decisions, see RecordApproval
 	Permissions     *PermissionConfig // Loaded from .ricochet/permissions.yaml
 	CurrentZone     TrustZone
+	Trust           TrustLevel // Empty until the workspace is classified
 	AutoApproval    *config.AutoApprovalSettings
 	ToolsSettings   *config.ToolsSettings
+
+	root      string // Canonical workspace path file tools are confined to, see CheckPath
+	egressMu  sync.Mutex
+	egress    *egressProxy // Started on first use, see EgressEnv
+	retention checkpoint.Retention
+	gcMu      sync.Mutex
+	gcRunning bool
+	lastGC    time.Time
 }
 
+// Checkpoint retention defaults, used for unset CheckpointSettings fields
+const (
+	defaultCheckpointMaxCount   = 200
+	defaultCheckpointMaxAgeDays = 30
+	defaultCheckpointMaxSizeMB  = 500
+)
+
+// gcInterval is the least time between two background prunes
+const gcInterval = 10 * time.Minute
+
 // NewManager creates a new safeguard manager
 func NewManager(cwd string) (*Manager, error) {
 	// Use global storage or similar for shadow git
@@ -43,6 +68,11 @@ func NewManager(cwd string) (*Manager, error) {
 		return nil, fmt.Errorf("failed to create permission store: %w", err)
 	}
 
+	audit, err := NewAuditLog(paths.GetAuditLogPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create approval audit log: %w", err)
+	}
+
 	// Load file/tool/command permissions
 	permConfig, err := LoadConfig(cwd)
 	if err != nil {
@@ -56,12 +86,20 @@ func NewManager(cwd string) (*Manager, error) {
 		}
 	}
 
-	return &Manager{
-		gitManager:      gitMgr,
+	m := &Manager{
+		checkpoints:     gitMgr,
 		PermissionStore: permStore,
+		Audit:           audit,
 		Permissions:     permConfig,
-		CurrentZone:     ZoneSafe, // Default to Safe Zone
-	}, nil
+		CurrentZone:     ZoneSafe,   // Default to Safe Zone
+		lastGC:          time.Now(), // First background prune one interval in, not during startup
+	}
+	if root, err := canonicalPath(cwd); err == nil {
+		m.root = root
+	}
+	permStore.SetProject(m.root)
+	m.SetCheckpointSettings(config.CheckpointSettings{})
+	return m, nil
 }
 
 // SetAutoApproval updates the auto-approval settings
@@ -74,18 +112,83 @@ func (m *Manager) SetToolsSettings(settings *config.ToolsSettings) {
 	m.ToolsSettings = settings
 }
 
+// SetCheckpointSettings updates the retention policy of checkpoints
+func (m *Manager) SetCheckpointSettings(settings config.CheckpointSettings) {
+	if settings.MaxCount <= 0 {
+		settings.MaxCount = defaultCheckpointMaxCount
+	}
+	if settings.MaxAgeDays <= 0 {
+		settings.MaxAgeDays = defaultCheckpointMaxAgeDays
+	}
+	if settings.MaxSizeMB <= 0 {
+		settings.MaxSizeMB = defaultCheckpointMaxSizeMB
+	}
+	m.gcMu.Lock()
+	defer m.gcMu.Unlock()
+	m.retention = checkpoint.Retention{
+		MaxCount: settings.MaxCount,
+		MaxAge:   time.Duration(settings.MaxAgeDays) * 24 * time.Hour,
+		MaxSize:  int64(settings.MaxSizeMB) << 20,
+	}
+}
+
 // CreateCheckpoint creates a checkpoint of the current state
 func (m *Manager) CreateCheckpoint(message string) (string, error) {
-	return m.gitManager.Commit(message)
+	hash, err := m.checkpoints.Commit(message)
+	if err == nil {
+		m.maybeGC()
+	}
+	return hash, err
+}
+
+// PruneCheckpoints applies the retention policy now
+func (m *Manager) PruneCheckpoints() (checkpoint.PruneResult, error) {
+	m.gcMu.Lock()
+	retention := m.retention
+	m.lastGC = time.Now()
+	m.gcMu.Unlock()
+	return m.checkpoints.Prune(retention)
+}
+
+// maybeGC prunes checkpoints in the background, at most once per gcInterval
+func (m *Manager) maybeGC() {
+	m.gcMu.Lock()
+	defer m.gcMu.Unlock()
+	if m.gcRunning || time.Since(m.lastGC) < gcInterval {
+		return
+	}
+	m.gcRunning = true
+	m.lastGC = time.Now()
+	retention := m.retention
+
+	go func() {
+		defer func() {
+			m.gcMu.Lock()
+			m.gcRunning = false
+			m.gcMu.Unlock()
+		}()
+		res, err := m.checkpoints.Prune(retention)
+		if err != nil {
+			log.Printf("[Safeguard] Checkpoint GC failed: %v", err)
+			return
+		}
+		if res.Removed > 0 {
+			log.Printf("[Safeguard] Pruned %d checkpoints, reclaimed %d KB", res.Removed, res.ReclaimedBytes>>10)
+		}
+	}()
 }
 
 // RestoreCheckpoint restores the state to a specific checkpoint
 func (m *Manager) RestoreCheckpoint(commitHash string) error {
-	return m.gitManager.Restore(commitHash)
+	return m.checkpoints.Restore(commitHash)
 }
 
 // CheckPermission verifies if the tool execution is allowed in the current zone
 func (m *Manager) CheckPermission(tool string) error {
+	if err := CheckEmergency(tool); err != nil {
+		return err
+	}
+
 	// Check Auto-Approval checks first
 	if m.AutoApproval != nil && m.AutoApproval.Enabled {
 		switch tool {
@@ -93,14 +196,11 @@ func (m *Manager) CheckPermission(tool string) error {
 			if m.AutoApproval.ExecuteAllCommands {
 				return nil
 			}
-			if m.AutoApproval.ExecuteSafeCommands && IsSafeCommand(tool) { // Need to verify IsSafeCommand or equivalent logic
-				return nil
-			}
 		case "read_file", "list_dir", "codebase_search":
 			if m.AutoApproval.ReadFiles {
 				return nil
 			}
-		case "write_file", "replace_file_content", "apply_diff":
+		case "write_file", "replace_file_content", "apply_diff", "edit_files", "delete_file", "move_file", "create_directory", "rename_symbol":
 			if m.AutoApproval.EditFiles {
 				return nil
 			}
@@ -188,12 +288,139 @@ func (m *Manager) CheckCommand(command string) error {
 	return nil
 }
 
-// Helper to check if a command is generally safe (simple heuristic)
-// Since we don't have the command args here, we just return false for now unless we change signature.
-// For now, we rely on 'ExecuteAllCommands' for the "Always Proceed" button which is the user's issue.
-func IsSafeCommand(tool string) bool {
-	// Refactor: We might needs args to determine if command is safe (e.g. ls vs rm)
-	// But CheckPermission only takes tool name.
-	// We will trust the Zone system for finer grained control if AutoApproval is off.
+// CheckDomain reports whether web_fetch and http_request may contact host without asking.
+// Denied hosts return an error. Without an allow list only loopback hosts
+// are pre-approved.
+func (m *Manager) CheckDomain(host string) (bool, error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	var rules DomainRules
+	if m.Permissions != nil {
+		rules = m.Permissions.Domains
+	}
+
+	for _, pattern := range rules.Deny {
+		if matchDomain(pattern, host) {
+			return false, fmt.Errorf("domain denied by pattern '%s'", pattern)
+		}
+	}
+	if allowedDomain(rules, host) {
+		return true, nil
+	}
+	if rules.Enforce {
+		return false, fmt.Errorf("domain %s is not on the egress allowlist", host)
+	}
+	return false, nil
+}
+
+// allowedDomain reports whether host is on the allow list, or is loopback
+// when the list is empty
+func allowedDomain(rules DomainRules, host string) bool {
+	if len(rules.Allow) == 0 {
+		ip := net.ParseIP(host)
+		return host == "localhost" || (ip != nil && ip.IsLoopback())
+	}
+	for _, pattern := range rules.Allow {
+		if matchDomain(pattern, host) {
+			return true
+		}
+	}
 	return false
 }
+
+// matchDomain matches a host against "*", "*.example.com" or an exact name
+func matchDomain(pattern, host string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "*" {
+		return true
+	}
+	if base, ok := strings.CutPrefix(pattern, "*."); ok {
+		return host == base || strings.HasSuffix(host, "."+base)
+	}
+	return host == pattern
+}
+
+// IsSafeCommand reports whether the built-in policy rates a shell command safe
+func IsSafeCommand(command string) bool {
+	return ClassifyCommand(command, CommandRules{}).Risk == CommandSafe
+}
+
+// ClassifyCommand rates a shell command against the built-in policy and the
+// commands section of permissions.yaml
+func (m *Manager) ClassifyCommand(command string) CommandVerdict {
+	var rules CommandRules
+	if m != nil && m.Permissions != nil {
+		rules = m.Permissions.Commands
+	}
+	return ClassifyCommand(command, rules)
+}
+
+// AutoApprovesCommand reports whether a rated command runs without asking:
+// safe ones with execute_safe_commands, unrated ones only with
+// execute_all_commands, dangerous ones never
+func (m *Manager) AutoApprovesCommand(v CommandVerdict) bool {
+	if m == nil || m.AutoApproval == nil || !m.AutoApproval.Enabled {
+		return false
+	}
+	switch v.Risk {
+	case CommandSafe:
+		return m.AutoApproval.ExecuteSafeCommands || m.AutoApproval.ExecuteAllCommands
+	case CommandAsk:
+		return m.AutoApproval.ExecuteAllCommands
+	}
+	return false
+}
+
+// CheckpointDiff returns the diffstat between two checkpoints (empty toHash = working tree)
+func (m *Manager) CheckpointDiff(fromHash, toHash string) (checkpoint.DiffStat, error) {
+	return m.checkpoints.Diff(fromHash, toHash)
+}
+
+// ListCheckpoints returns up to n recent shadow checkpoints, newest first
+func (m *Manager) ListCheckpoints(n int) ([]checkpoint.CommitInfo, error) {
+	return m.checkpoints.Log(n)
+}
+
+// CheckpointChanges lists per-file changes between two checkpoints
+func (m *Manager) CheckpointChanges(fromHash, toHash string) ([]checkpoint.FileChange, error) {
+	return m.checkpoints.Changes(fromHash, toHash)
+}
+
+// RestoreCheckpointPaths reverts only the given files to a checkpoint
+func (m *Manager) RestoreCheckpointPaths(commitHash string, paths []string) error {
+	return m.checkpoints.RestorePaths(commitHash, paths)
+}
+
+// ForkExperiment starts a named experiment from the current workspace
+func (m *Manager) ForkExperiment(name string) (string, error) {
+	return m.checkpoints.Fork(name)
+}
+
+// SwitchExperiment checks out an experiment, or the main line for checkpoint.MainLine
+func (m *Manager) SwitchExperiment(name string) error {
+	return m.checkpoints.Switch(name)
+}
+
+// ListExperiments returns the experiments, most recently changed first
+func (m *Manager) ListExperiments() ([]checkpoint.Experiment, error) {
+	return m.checkpoints.Experiments()
+}
+
+// CurrentExperiment returns the checked-out experiment, or "" on the main line
+func (m *Manager) CurrentExperiment() string {
+	return m.checkpoints.CurrentExperiment()
+}
+
+// CompareExperiment diffs an experiment and the main line from where they diverged
+func (m *Manager) CompareExperiment(name string) (checkpoint.ExperimentDiff, error) {
+	return m.checkpoints.Compare(name)
+}
+
+// MergeExperiment merges an experiment into the main line and deletes it
+func (m *Manager) MergeExperiment(name string) (checkpoint.MergeResult, error) {
+	return m.checkpoints.Merge(name)
+}
+
+// DiscardExperiment deletes an experiment
+func (m *Manager) DiscardExperiment(name string) error {
+	return m.checkpoints.Discard(name)
+}
@@ -0,0 +1,124 @@
+package safeguard
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CheckMcpTool reports whether an MCP tool call may run without asking.
+// Denied calls return an error. Without a matching policy the auto-approval
+// use_mcp flag decides, see autoApprovesMcp.
+func (m *Manager) CheckMcpTool(server, tool string, args map[string]interface{}) (bool, error) {
+	var rules McpRules
+	if m.Permissions != nil {
+		rules = m.Permissions.MCP
+	}
+	serverRules := rules.Servers[server]
+	toolRule, hasToolRule := serverRules.Tools[tool]
+
+	policy := rules.Default
+	if serverRules.Policy != "" {
+		policy = serverRules.Policy
+	}
+	if toolRule.Policy != "" {
+		policy = toolRule.Policy
+	}
+
+	if policy == McpDeny {
+		return false, fmt.Errorf("MCP tool %s on %s is denied by policy", tool, server)
+	}
+
+	// Argument constraints apply whatever the policy
+	needsApproval := false
+	if hasToolRule {
+		names := make([]string, 0, len(toolRule.Args))
+		for name := range toolRule.Args {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			value, ok := args[name]
+			if !ok {
+				continue
+			}
+			rule := toolRule.Args[name]
+			text := argString(value)
+			for _, pattern := range rule.Deny {
+				if matchWildcard(pattern, text) {
+					return false, fmt.Errorf("MCP tool %s on %s: argument %s=%q matches deny pattern '%s'", tool, server, name, text, pattern)
+				}
+			}
+			if len(rule.Allow) > 0 && !matchAny(rule.Allow, text) {
+				needsApproval = true
+			}
+		}
+	}
+
+	switch policy {
+	case McpAllow:
+		return !needsApproval, nil
+	case "":
+		if needsApproval {
+			return false, nil
+		}
+		return m.autoApprovesMcp(), nil
+	default:
+		return false, nil // "ask", and unknown policies fail safe
+	}
+}
+
+// autoApprovesMcp reports whether MCP tools without a policy run without
+// asking. A manager with no auto-approval settings allows them, as MCP tools
+// did before there were policies; in a chat the agent's approval prompt
+// still covers them.
+func (m *Manager) autoApprovesMcp() bool {
+	if m.AutoApproval == nil {
+		return true
+	}
+	return m.AutoApproval.Enabled && m.AutoApproval.UseMCP
+}
+
+// argString is the form argument patterns match against: strings as they are,
+// anything else as JSON
+func argString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if matchWildcard(p, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWildcard matches s against a pattern where "*" is any run of
+// characters and "?" a single one
+func matchWildcard(pattern, s string) bool {
+	star, mark := -1, 0
+	p, i := 0, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[i]):
+			p++
+			i++
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, i
+			p++
+		case star >= 0:
+			p = star + 1
+			mark++
+			i = mark
+		default:
+			return false
+		}
+	}
+	return strings.Trim(pattern[p:], "*") == ""
+}
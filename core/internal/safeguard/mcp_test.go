@@ -0,0 +1,68 @@
+package safeguard
+
+import (
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+)
+
+func TestCheckMcpTool(t *testing.T) {
+	m := &Manager{
+		AutoApproval: &config.AutoApprovalSettings{Enabled: true, UseMCP: true},
+		Permissions: &PermissionConfig{MCP: McpRules{
+			Servers: map[string]McpServerRules{
+				"github": {
+					Policy: McpAsk,
+					Tools: map[string]McpToolRule{
+						"get_issue":   {Policy: McpAllow},
+						"delete_repo": {Policy: McpDeny},
+						"push_files": {
+							Policy: McpAllow,
+							Args: map[string]ArgRule{
+								"branch": {Allow: []string{"feature/*"}, Deny: []string{"main", "release/*"}},
+							},
+						},
+					},
+				},
+				"shell": {Policy: McpDeny},
+			},
+		}},
+	}
+
+	cases := []struct {
+		server, tool string
+		args         map[string]interface{}
+		allowed      bool
+		denied       bool
+	}{
+		{"docs", "search", nil, true, false}, // No policy: use_mcp
+		{"github", "list_repos", nil, false, false},
+		{"github", "get_issue", nil, true, false},
+		{"github", "delete_repo", nil, false, true},
+		{"shell", "run", nil, false, true},
+		{"github", "push_files", map[string]interface{}{"branch": "feature/x/y"}, true, false},
+		{"github", "push_files", map[string]interface{}{"branch": "hotfix"}, false, false},
+		{"github", "push_files", map[string]interface{}{"branch": "release/1.0"}, false, true},
+		{"github", "push_files", map[string]interface{}{"branch": "main"}, false, true},
+	}
+	for _, c := range cases {
+		allowed, err := m.CheckMcpTool(c.server, c.tool, c.args)
+		if allowed != c.allowed || (err != nil) != c.denied {
+			t.Errorf("CheckMcpTool(%s, %s, %v) = %v, %v; want allowed=%v denied=%v", c.server, c.tool, c.args, allowed, err, c.allowed, c.denied)
+		}
+	}
+
+	m.AutoApproval.UseMCP = false
+	if allowed, _ := m.CheckMcpTool("docs", "search", nil); allowed {
+		t.Error("use_mcp off should require approval")
+	}
+
+	// No auto-approval settings is the documented allow default
+	m.AutoApproval = nil
+	if allowed, _ := m.CheckMcpTool("docs", "search", nil); !allowed {
+		t.Error("no auto-approval settings should allow MCP tools without a policy")
+	}
+	if allowed, _ := m.CheckMcpTool("github", "list_repos", nil); allowed {
+		t.Error("an ask policy must still ask without auto-approval settings")
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 )
 
 type PermissionScope string
@@ -23,20 +25,57 @@ type TrustZone int
 
 // ZoneConfig maps tools to their minimum required zone (Lower zone = Higher trust required)
 var toolZoneMap = map[string]TrustZone{
-	"execute_command": ZoneSafe,     // Safe (Protected by IsSafeCommand + ensureConsent)
-	"write_file":      ZoneSafe,     // Safe (Project only)
-	"execute_python":  ZoneSafe,     // Safe (Sandboxed - theoretically)
-	"read_file":       ZoneReadOnly, // Read only
-	"list_dir":        ZoneReadOnly,
-	"codebase_search": ZoneReadOnly,
-	"browser_open":    ZoneReadOnly,
+	"execute_command":           ZoneSafe,     // Safe (Protected by IsSafeCommand + ensureConsent)
+	"write_file":                ZoneSafe,     // Safe (Project only)
+	"execute_python":            ZoneSafe,     // Safe (Sandboxed - theoretically)
+	"execute_node":              ZoneSafe,     // Safe (Sandbox profile)
+	"execute_bash_script":       ZoneSafe,     // Safe (Sandbox profile)
+	"http_request":              ZoneSafe,     // Safe (Domain allow list + ensureConsent)
+	"sql_execute":               ZoneSafe,     // Safe (ensureConsent)
+	"read_file":                 ZoneReadOnly, // Read only
+	"list_dir":                  ZoneReadOnly,
+	"codebase_search":           ZoneReadOnly,
+	"sql_query":                 ZoneReadOnly, // Read-only transaction
+	"sql_schema":                ZoneReadOnly,
+	"browser_open":              ZoneReadOnly,
+	"browser_assert_visual":     ZoneReadOnly,
+	"browser_snapshot":          ZoneReadOnly,
+	"browser_wait":              ZoneReadOnly,
+	"browser_logs":              ZoneReadOnly,
+	"read_clipboard":            ZoneReadOnly,
+	"copy_to_clipboard":         ZoneReadOnly,
+	"send_desktop_notification": ZoneReadOnly,
+	"analyze_image":             ZoneReadOnly,
+	"generate_image":            ZoneSafe,
+	"web_fetch":                 ZoneReadOnly, // GET only, domain allow list + ensureConsent
 }
 
 type PermissionRule struct {
-	Tool   string          `json:"tool"`
-	Path   string          `json:"path,omitempty"` // Regex or exact path
-	Action string          `json:"action"`         // "allow", "deny"
-	Scope  PermissionScope `json:"scope"`
+	ID        string          `json:"id"`
+	Tool      string          `json:"tool"`
+	Path      string          `json:"path,omitempty"`  // Path, command or other target; empty matches any, a trailing * any rest
+	Exact     bool            `json:"exact,omitempty"` // Path is matched literally; set on rules saved by "don't ask again"
+	Action    string          `json:"action"`          // "allow", "deny"
+	Scope     PermissionScope `json:"scope"`
+	Project   string          `json:"project,omitempty"` // Workspace a project rule applies to; empty applies everywhere
+	CreatedAt time.Time       `json:"created_at,omitempty"`
+}
+
+// Matches reports whether the rule covers a use of tool on target
+func (r PermissionRule) Matches(tool, target string) bool {
+	if r.Tool != tool {
+		return false
+	}
+	// A remembered approval covers the same target only: an approved
+	// command ending in * must not allow whatever follows it
+	if r.Exact {
+		return r.Path != "" && r.Path == target
+	}
+	if r.Path == "" || r.Path == target {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(r.Path, "*")
+	return ok && strings.HasPrefix(target, prefix)
 }
 
 type Permissions struct {
@@ -47,6 +86,7 @@ type PermissionStore struct {
 	mu          sync.RWMutex
 	path        string
 	permissions *Permissions
+	project     string // Workspace whose project rules apply
 }
 
 // NewPermissionStore creates a store for persistent permissions
@@ -95,10 +135,36 @@ func (s *PermissionStore) Load() error {
 		return fmt.Errorf("failed to parse permissions.json: %w", err)
 	}
 
+	// Rules saved before they had IDs get one, so they can be removed
 	s.permissions = &perms
+	for i := range perms.Rules {
+		if perms.Rules[i].ID == "" {
+			perms.Rules[i].ID = s.nextID()
+		}
+	}
 	return nil
 }
 
+// nextID returns an unused rule ID. Caller must hold the lock.
+func (s *PermissionStore) nextID() string {
+	next := 1
+	for _, r := range s.permissions.Rules {
+		var n int
+		if _, err := fmt.Sscanf(r.ID, "r%d", &n); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+	return fmt.Sprintf("r%d", next)
+}
+
+// SetProject sets the workspace whose project rules apply, and which new
+// project rules are saved for
+func (s *PermissionStore) SetProject(root string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.project = root
+}
+
 // Save writes permissions to disk. NOTE: Caller must hold lock if needed.
 func (s *PermissionStore) Save() error {
 	// Removed internal locking to prevent deadlock since AddRule calls this while holding Lock
@@ -110,28 +176,100 @@ func (s *PermissionStore) Save() error {
 	return os.WriteFile(s.path, data, 0644)
 }
 
+// AddRule saves a rule, unless an identical one exists. Project rules
+// without a project are tied to the current workspace.
 func (s *PermissionStore) AddRule(rule PermissionRule) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Check for duplicates? For now just append
+	if rule.Scope == ScopeProject && rule.Project == "" {
+		rule.Project = s.project
+	}
+	for _, r := range s.permissions.Rules {
+		if r.Tool == rule.Tool && r.Path == rule.Path && r.Exact == rule.Exact && r.Action == rule.Action && r.Scope == rule.Scope && r.Project == rule.Project {
+			return nil
+		}
+	}
+	rule.ID = s.nextID()
+	if rule.CreatedAt.IsZero() {
+		rule.CreatedAt = time.Now()
+	}
 	s.permissions.Rules = append(s.permissions.Rules, rule)
 	return s.Save() // Auto-save
 }
 
+// RemoveRule deletes a rule by ID
+func (s *PermissionStore) RemoveRule(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, r := range s.permissions.Rules {
+		if r.ID == id {
+			s.permissions.Rules = append(s.permissions.Rules[:i:i], s.permissions.Rules[i+1:]...)
+			return s.Save()
+		}
+	}
+	return fmt.Errorf("permission rule %s not found", id)
+}
+
+// Rules returns the rules that apply in the current workspace: global ones
+// and those of this project
+func (s *PermissionStore) Rules() []PermissionRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var rules []PermissionRule
+	for _, r := range s.permissions.Rules {
+		if s.applies(r) {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// Export returns the rules that apply in the current workspace as a
+// permissions.json document
+func (s *PermissionStore) Export() ([]byte, error) {
+	rules := s.Rules()
+	if rules == nil {
+		rules = []PermissionRule{}
+	}
+	return json.MarshalIndent(Permissions{Rules: rules}, "", "  ")
+}
+
+// applies reports whether a rule is in force in the current workspace.
+// Caller must hold the lock.
+func (s *PermissionStore) applies(r PermissionRule) bool {
+	return r.Scope != ScopeProject || r.Project == "" || s.project == "" || r.Project == s.project
+}
+
+// IsAllowed reports whether an allow rule in force covers a use of tool on
+// path, and no deny rule does
 func (s *PermissionStore) IsAllowed(tool string, path string) bool {
+	_, ok := s.AllowingRule(tool, path)
+	return ok
+}
+
+// AllowingRule is IsAllowed that also returns the ID of the allow rule
+func (s *PermissionStore) AllowingRule(tool string, path string) (string, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	id := ""
 	for _, rule := range s.permissions.Rules {
-		if rule.Tool == tool && rule.Action == "allow" {
-			if rule.Path == "" || rule.Path == path { // Simple match for MVP
-				return true
+		if !s.applies(rule) || !rule.Matches(tool, path) {
+			continue
+		}
+		switch rule.Action {
+		case "deny":
+			return "", false
+		case "allow":
+			if id == "" {
+				id = rule.ID
 			}
-			// Regex match could be added here
 		}
 	}
-	return false
+	return id, id != ""
 }
 
 // CheckZonePermission checks if a tool is allowed in the given zone
@@ -0,0 +1,126 @@
+package safeguard
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestPermissionStore(t *testing.T, project string) *PermissionStore {
+	t.Helper()
+	s := &PermissionStore{
+		path:        filepath.Join(t.TempDir(), "permissions.json"),
+		permissions: &Permissions{Rules: []PermissionRule{}},
+	}
+	s.SetProject(project)
+	return s
+}
+
+func TestPermissionStoreProjectScope(t *testing.T) {
+	s := newTestPermissionStore(t, "/work/a")
+	if err := s.AddRule(PermissionRule{Tool: "execute_command", Path: "make test", Action: "allow", Scope: ScopeProject}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddRule(PermissionRule{Tool: "mcp__db__query", Action: "allow", Scope: ScopeGlobal}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.IsAllowed("execute_command", "make test") {
+		t.Error("project rule should apply in its project")
+	}
+	if s.IsAllowed("execute_command", "make deploy") {
+		t.Error("command rule should only match the exact command")
+	}
+
+	s.SetProject("/work/b")
+	if s.IsAllowed("execute_command", "make test") {
+		t.Error("project rule should not apply in another project")
+	}
+	if !s.IsAllowed("mcp__db__query", "anything") {
+		t.Error("global rule without a path should apply everywhere")
+	}
+	if rules := s.Rules(); len(rules) != 1 || rules[0].Tool != "mcp__db__query" {
+		t.Errorf("Rules() = %+v, want only the global rule", rules)
+	}
+}
+
+func TestPermissionStoreMatching(t *testing.T) {
+	s := newTestPermissionStore(t, "/work")
+	s.AddRule(PermissionRule{Tool: "write_file", Path: "docs/*", Action: "allow", Scope: ScopeProject})
+	s.AddRule(PermissionRule{Tool: "write_file", Path: "docs/secret.md", Action: "deny", Scope: ScopeProject})
+
+	if !s.IsAllowed("write_file", "docs/guide.md") {
+		t.Error("trailing * should match by prefix")
+	}
+	if s.IsAllowed("write_file", "docs/secret.md") {
+		t.Error("deny rule should override allow")
+	}
+	if s.IsAllowed("write_file", "src/main.go") {
+		t.Error("rule should not match outside its prefix")
+	}
+	if s.IsAllowed("edit_file", "docs/guide.md") {
+		t.Error("rule should not match another tool")
+	}
+}
+
+func TestPermissionStoreAddRemoveExport(t *testing.T) {
+	s := newTestPermissionStore(t, "/work")
+	rule := PermissionRule{Tool: "execute_command", Path: "go test ./...", Action: "allow", Scope: ScopeProject}
+	s.AddRule(rule)
+	s.AddRule(rule)
+	s.AddRule(PermissionRule{Tool: "read_clipboard", Action: "allow", Scope: ScopeProject})
+
+	rules := s.Rules()
+	if len(rules) != 2 {
+		t.Fatalf("duplicate rule was added: %+v", rules)
+	}
+	if rules[0].ID != "r1" || rules[1].ID != "r2" || rules[0].Project != "/work" || rules[0].CreatedAt.IsZero() {
+		t.Errorf("unexpected rule metadata: %+v", rules)
+	}
+
+	if err := s.RemoveRule("r1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemoveRule("r1"); err == nil {
+		t.Error("removing a missing rule should fail")
+	}
+	if s.IsAllowed("execute_command", "go test ./...") {
+		t.Error("removed rule still applies")
+	}
+
+	data, err := s.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported Permissions
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("export is not valid JSON: %v", err)
+	}
+	if len(exported.Rules) != 1 || exported.Rules[0].ID != "r2" {
+		t.Errorf("exported %+v", exported.Rules)
+	}
+}
+
+func TestPermissionStoreLoadAssignsIDs(t *testing.T) {
+	s := newTestPermissionStore(t, "/work")
+	legacy := `{"rules":[{"tool":"write_file","path":"a.go","action":"allow","scope":"project"},{"id":"r7","tool":"list_dir","action":"allow","scope":"global"}]}`
+	if err := os.WriteFile(s.path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := s.Rules()
+	if len(rules) != 2 || rules[0].ID != "r8" || rules[1].ID != "r7" {
+		t.Fatalf("rules after load: %+v", rules)
+	}
+	if !s.IsAllowed("write_file", "a.go") {
+		t.Error("legacy project rule without a project should still apply")
+	}
+	s.AddRule(PermissionRule{Tool: "delete_file", Action: "allow", Scope: ScopeProject})
+	if rules := s.Rules(); rules[2].ID != "r9" {
+		t.Errorf("new rule ID = %s, want r9", rules[2].ID)
+	}
+}
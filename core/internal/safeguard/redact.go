@@ -0,0 +1,97 @@
+package safeguard
+
+import (
+	"io"
+	"regexp"
+)
+
+// Redacted replaces secrets found in tool results, logs and messenger
+// forwards, and in chunks before they are embedded
+const Redacted = "[REDACTED]"
+
+// secretPatterns match credentials that must not reach a provider, a
+// messenger or a remote vector store. A pattern with a group redacts only
+// the group.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),                                      // AWS access key ID
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),                                     // GitHub token
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`),                                   // GitHub fine-grained token
+	regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`),                                       // GitLab token
+	regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`),                                  // Slack token
+	regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}\b`),                           // OpenAI/Anthropic key
+	regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{16,}\b`),                                    // Stripe key
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),                                          // Google API key
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`), // JWT
+	regexp.MustCompile(`(?i)\b[a-z]+://[^\s:/@]+:([^\s@/]{3,})@`),                            // Password in a URL
+	// A literal assigned to something named like a secret
+	regexp.MustCompile(`(?i)(?:api[_-]?key|secret|passw(?:or)?d|token|credential)[a-z_]*["']?\s*[:=]\s*["']([^"'\s]{8,})["']`),
+	// An unquoted .env style assignment, e.g. DB_PASSWORD=hunter2hunter2
+	regexp.MustCompile(`(?m)^\s*(?:export\s+)?[A-Z0-9_]*(?:KEY|SECRET|TOKEN|PASSWORD|PASSWD|CREDENTIALS?)[A-Z0-9_]*\s*=\s*([^"'\s#]{8,})`),
+}
+
+// RedactSecrets replaces the secrets in text found by the built-in patterns
+// and reports how many it found
+func RedactSecrets(text string) (string, int) {
+	return redact(text, secretPatterns)
+}
+
+func redact(text string, patterns []*regexp.Regexp) (string, int) {
+	count := 0
+	for _, re := range patterns {
+		text = re.ReplaceAllStringFunc(text, func(match string) string {
+			if re.NumSubexp() == 0 {
+				count++
+				return Redacted
+			}
+			loc := re.FindStringSubmatchIndex(match)
+			if loc == nil || loc[2] < 0 || match[loc[2]:loc[3]] == Redacted {
+				return match
+			}
+			count++
+			return match[:loc[2]] + Redacted + match[loc[3]:]
+		})
+	}
+	return text, count
+}
+
+// RedactSecrets masks secrets with the built-in patterns plus those of the
+// redaction section of permissions.yaml, unless it turns redaction off
+func (m *Manager) RedactSecrets(text string) (string, int) {
+	if m == nil || m.Permissions == nil {
+		return RedactSecrets(text)
+	}
+	rules := m.Permissions.Redaction
+	if rules.Disabled {
+		return text, 0
+	}
+	patterns := secretPatterns
+	for _, expr := range rules.Patterns {
+		if re, err := regexp.Compile(expr); err == nil {
+			patterns = append(patterns[:len(patterns):len(patterns)], re)
+		}
+	}
+	return redact(text, patterns)
+}
+
+// RedactingWriter masks secrets in everything written through it, e.g. the
+// log output. Each write is redacted on its own; the log package writes
+// whole entries.
+func RedactingWriter(w io.Writer) io.Writer {
+	return redactingWriter{w}
+}
+
+type redactingWriter struct {
+	w io.Writer
+}
+
+func (r redactingWriter) Write(p []byte) (int, error) {
+	text, n := RedactSecrets(string(p))
+	if n == 0 {
+		return r.w.Write(p)
+	}
+	if _, err := io.WriteString(r.w, text); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,65 @@
+package safeguard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultSandboxProfile is used when a script tool does not name a profile
+const DefaultSandboxProfile = "default"
+
+// SandboxProfile describes the limits execute_node and execute_bash_script
+// run under. Zero limits mean "no limit".
+type SandboxProfile struct {
+	CPUSeconds     int  `yaml:"cpu_seconds"`     // RLIMIT_CPU
+	MemoryMB       int  `yaml:"memory_mb"`       // RLIMIT_AS
+	FileSizeMB     int  `yaml:"file_size_mb"`    // RLIMIT_FSIZE, largest file the script may write
+	TimeoutSeconds int  `yaml:"timeout_seconds"` // Wall clock
+	Network        bool `yaml:"network"`         // Allow network access without asking
+	Tmpfs          bool `yaml:"tmpfs"`           // Run in a throwaway temp directory instead of the workspace
+}
+
+// builtinSandboxProfiles are available even without a permissions.yaml.
+// Entries in the config with the same name replace them.
+var builtinSandboxProfiles = map[string]SandboxProfile{
+	"default": {CPUSeconds: 60, MemoryMB: 4096, FileSizeMB: 512, TimeoutSeconds: 120},
+	"strict":  {CPUSeconds: 30, MemoryMB: 1024, FileSizeMB: 64, TimeoutSeconds: 60, Tmpfs: true},
+	"network": {CPUSeconds: 60, MemoryMB: 4096, FileSizeMB: 512, TimeoutSeconds: 120, Network: true},
+}
+
+// SandboxProfile returns the named profile, preferring the project config
+// over the built-in ones
+func (c *PermissionConfig) SandboxProfile(name string) (SandboxProfile, error) {
+	if name == "" {
+		name = DefaultSandboxProfile
+	}
+	if c != nil {
+		if p, ok := c.Sandbox[name]; ok {
+			return p, nil
+		}
+	}
+	if p, ok := builtinSandboxProfiles[name]; ok {
+		return p, nil
+	}
+	return SandboxProfile{}, fmt.Errorf("unknown sandbox profile %q (available: %s)", name, strings.Join(c.SandboxProfileNames(), ", "))
+}
+
+// SandboxProfileNames lists the built-in and configured profile names
+func (c *PermissionConfig) SandboxProfileNames() []string {
+	seen := make(map[string]bool)
+	for name := range builtinSandboxProfiles {
+		seen[name] = true
+	}
+	if c != nil {
+		for name := range c.Sandbox {
+			seen[name] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
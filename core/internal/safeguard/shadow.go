@@ -2,21 +2,36 @@ package safeguard
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
 )
 
 // Linter defines an interface for language-specific validation
 type Linter interface {
+	Name() string
 	CanLint(path string) bool
 	Lint(ctx context.Context, path string) error
 }
 
+// defaultVerifyTimeout bounds the checks of one write when
+// tools.verification.timeout_seconds is unset
+const defaultVerifyTimeout = 60 * time.Second
+
 // ShadowVerifier manages the linter loop
 type ShadowVerifier struct {
-	linters []Linter
+	linters []Linter // Built-in, first match wins
+	checks  []Linter // Project checks, replacing built-ins for their extensions
+	timeout time.Duration
 }
 
 func NewShadowVerifier() *ShadowVerifier {
@@ -24,17 +39,112 @@ func NewShadowVerifier() *ShadowVerifier {
 		linters: []Linter{
 			&GoLinter{},
 			&TSLinter{},
+			&JSLinter{},
+			&PythonLinter{},
+			&RustLinter{},
 		},
+		timeout: defaultVerifyTimeout,
+	}
+}
+
+// WithSettings returns a copy of the verifier configured by
+// tools.verification, whose check commands run relative to root
+func (v *ShadowVerifier) WithSettings(root string, settings config.VerificationSettings) *ShadowVerifier {
+	c := &ShadowVerifier{timeout: v.timeout}
+	if settings.TimeoutSeconds > 0 {
+		c.timeout = time.Duration(settings.TimeoutSeconds) * time.Second
 	}
+	for _, l := range v.linters {
+		if !slices.Contains(settings.Skip, l.Name()) {
+			c.linters = append(c.linters, l)
+		}
+	}
+	for _, check := range settings.Checks {
+		c.checks = append(c.checks, &CommandLinter{Check: check, Root: root})
+	}
+	return c
 }
 
-// Verify checks a file against registered linters
+// Verify checks a file with the project checks for its extension, or else the
+// first built-in linter that handles it. Checks still running when the
+// timeout budget runs out are abandoned without failing the write.
 func (v *ShadowVerifier) Verify(ctx context.Context, path string) error {
-	for _, linter := range v.linters {
-		if linter.CanLint(path) {
-			return linter.Lint(ctx, path)
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	var errs []string
+	matched := false
+	for _, check := range v.checks {
+		if check.CanLint(path) {
+			matched = true
+			if err := check.Lint(ctx, path); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if !matched {
+		for _, linter := range v.linters {
+			if linter.CanLint(path) {
+				if err := linter.Lint(ctx, path); err != nil {
+					errs = append(errs, err.Error())
+				}
+				break
+			}
+		}
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		log.Printf("[Safeguard] Verification of %s exceeded its %s budget, skipped", path, v.timeout)
+		return nil
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// OverlayLinter is a Linter that can check staged content without it being on
+// disk. It checks the whole directory (package) containing path.
+type OverlayLinter interface {
+	LintOverlay(ctx context.Context, path string, overlay map[string]string) error
+}
+
+// VerifyOverlay checks staged files before they are written. overlay maps each
+// absolute target path to a temp file holding its new content. Files whose
+// linter cannot read an overlay are not checked.
+func (v *ShadowVerifier) VerifyOverlay(ctx context.Context, overlay map[string]string) error {
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	paths := make([]string, 0, len(overlay))
+	for path := range overlay {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var errs []string
+	checked := make(map[string]bool) // Linter + directory
+	for _, path := range paths {
+		for i, linter := range v.linters {
+			if !linter.CanLint(path) {
+				continue
+			}
+			key := fmt.Sprintf("%d:%s", i, filepath.Dir(path))
+			if ol, ok := linter.(OverlayLinter); ok && !checked[key] {
+				checked[key] = true
+				if err := ol.LintOverlay(ctx, path, overlay); err != nil {
+					errs = append(errs, err.Error())
+				}
+			}
+			break
 		}
 	}
+	if ctx.Err() == context.DeadlineExceeded {
+		log.Printf("[Safeguard] Verification of staged edits exceeded its %s budget, skipped", v.timeout)
+		return nil
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
 	return nil
 }
 
@@ -42,6 +152,8 @@ func (v *ShadowVerifier) Verify(ctx context.Context, path string) error {
 
 type GoLinter struct{}
 
+func (l *GoLinter) Name() string { return "go" }
+
 func (l *GoLinter) CanLint(path string) bool {
 	return strings.HasSuffix(path, ".go")
 }
@@ -56,8 +168,8 @@ func (l *GoLinter) Lint(ctx context.Context, path string) error {
 		return nil // Skip if no go installed
 	}
 
-	// Run go vet
-	cmd := exec.CommandContext(ctx, "go", "vet", path)
+	// Run go vet on the package: the file alone would not see its siblings
+	cmd := exec.CommandContext(ctx, "go", "vet", ".")
 	cmd.Dir = dir
 	out, err := cmd.CombinedOutput()
 	if err != nil {
@@ -67,27 +179,55 @@ func (l *GoLinter) Lint(ctx context.Context, path string) error {
 	return nil
 }
 
-// --- TypeScript/JavaScript Linter ---
-
-type TSLinter struct{}
+// LintOverlay vets the file's package with staged content swapped in via
+// go's -overlay. A package whose directory does not exist yet cannot be
+// vetted, so it is type-checked with go build from the closest existing
+// parent directory instead.
+func (l *GoLinter) LintOverlay(ctx context.Context, path string, overlay map[string]string) error {
+	if _, err := exec.LookPath("go"); err != nil {
+		return nil
+	}
 
-func (l *TSLinter) CanLint(path string) bool {
-	return strings.HasSuffix(path, ".ts") || strings.HasSuffix(path, ".tsx") ||
-		strings.HasSuffix(path, ".js") || strings.HasSuffix(path, ".jsx")
-}
+	f, err := os.CreateTemp("", "ricochet-overlay-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	err = json.NewEncoder(f).Encode(map[string]interface{}{"Replace": overlay})
+	f.Close()
+	if err != nil {
+		return err
+	}
 
-func (l *TSLinter) Lint(ctx context.Context, path string) error {
-	// This is trickier as it depends on project config (eslint, tsc).
-	// For now, we'll try a generic check if 'tsc' is available for .ts files
+	dir := filepath.Dir(path)
+	if _, err := os.Stat(dir); err == nil {
+		cmd := exec.CommandContext(ctx, "go", "vet", "-overlay", f.Name(), ".")
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("go vet failed:\n%s", string(out))
+		}
+		return nil
+	}
 
-	if strings.HasSuffix(path, ".ts") || strings.HasSuffix(path, ".tsx") {
-		if _, err := exec.LookPath("tsc"); err == nil {
-			// tsc --noEmit --skipLibCheck [path]
-			// Note: Usually tsc runs on project, but we can try single file or just skip if no config
-			// For now, return nil to avoid false positives without proper project context
+	parent := filepath.Dir(dir)
+	for {
+		if _, err := os.Stat(parent); err == nil {
+			break
+		}
+		next := filepath.Dir(parent)
+		if next == parent {
 			return nil
 		}
+		parent = next
+	}
+	rel, err := filepath.Rel(parent, dir)
+	if err != nil {
+		return nil
+	}
+	cmd := exec.CommandContext(ctx, "go", "build", "-overlay", f.Name(), "-o", os.DevNull, "./"+filepath.ToSlash(rel))
+	cmd.Dir = parent
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("go build failed:\n%s", string(out))
 	}
-
 	return nil
 }
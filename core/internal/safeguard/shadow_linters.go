@@ -0,0 +1,220 @@
+package safeguard
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+)
+
+// Linters for languages other than Go. Each checks only what the compiler
+// would reject, skips silently when its tool is not installed, and reports
+// only the diagnostics about the written file, so errors elsewhere in the
+// project never block a write.
+
+// --- TypeScript Linter ---
+
+// TSLinter type-checks with tsc --noEmit: the whole project when a
+// tsconfig.json is found above the file, otherwise the file alone
+type TSLinter struct{}
+
+func (l *TSLinter) Name() string { return "typescript" }
+
+func (l *TSLinter) CanLint(path string) bool {
+	return hasExt(path, ".ts", ".tsx", ".mts", ".cts")
+}
+
+func (l *TSLinter) Lint(ctx context.Context, path string) error {
+	dir := filepath.Dir(path)
+	tsc := findNodeBin(dir, "tsc")
+	if tsc == "" {
+		return nil
+	}
+
+	args := []string{"--noEmit", "--pretty", "false"}
+	if project := findUp(dir, "tsconfig.json"); project != "" {
+		dir = project
+		args = append(args, "-p", ".")
+	} else {
+		args = append(args, "--skipLibCheck", path)
+	}
+	return runCheck(ctx, "tsc", dir, path, tsc, args...)
+}
+
+// --- JavaScript Linter ---
+
+// JSLinter checks syntax with node --check. JSX is not plain JavaScript and
+// is left to project checks.
+type JSLinter struct{}
+
+func (l *JSLinter) Name() string { return "javascript" }
+
+func (l *JSLinter) CanLint(path string) bool {
+	return hasExt(path, ".js", ".mjs", ".cjs")
+}
+
+func (l *JSLinter) Lint(ctx context.Context, path string) error {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		return nil
+	}
+	return runCheck(ctx, "node --check", filepath.Dir(path), "", node, "--check", path)
+}
+
+// --- Python Linter ---
+
+// PythonLinter runs ruff for syntax errors and undefined names when it is
+// installed, otherwise compiles the file the way py_compile does, without
+// writing a .pyc
+type PythonLinter struct{}
+
+func (l *PythonLinter) Name() string { return "python" }
+
+func (l *PythonLinter) CanLint(path string) bool {
+	return hasExt(path, ".py")
+}
+
+func (l *PythonLinter) Lint(ctx context.Context, path string) error {
+	dir := filepath.Dir(path)
+	if ruff, err := exec.LookPath("ruff"); err == nil {
+		return runCheck(ctx, "ruff", dir, "", ruff, "check", "--no-cache", "--output-format", "concise", "--select", "E9,F63,F7,F82", path)
+	}
+	for _, name := range []string{"python3", "python"} {
+		if python, err := exec.LookPath(name); err == nil {
+			return runCheck(ctx, "py_compile", dir, "", python, "-c", "import sys; compile(open(sys.argv[1], 'rb').read(), sys.argv[1], 'exec')", path)
+		}
+	}
+	return nil
+}
+
+// --- Rust Linter ---
+
+// RustLinter runs cargo check on the crate containing the file
+type RustLinter struct{}
+
+func (l *RustLinter) Name() string { return "rust" }
+
+func (l *RustLinter) CanLint(path string) bool {
+	return hasExt(path, ".rs")
+}
+
+func (l *RustLinter) Lint(ctx context.Context, path string) error {
+	cargo, err := exec.LookPath("cargo")
+	if err != nil {
+		return nil
+	}
+	crate := findUp(filepath.Dir(path), "Cargo.toml")
+	if crate == "" {
+		return nil
+	}
+	return runCheck(ctx, "cargo check", crate, path, cargo, "check", "--quiet", "--message-format", "short")
+}
+
+// --- Project checks ---
+
+// CommandLinter runs a check from tools.verification through the shell
+type CommandLinter struct {
+	Check config.VerificationCheck
+	Root  string // Workspace the check's Dir is relative to
+}
+
+func (l *CommandLinter) Name() string { return l.Check.Name }
+
+func (l *CommandLinter) CanLint(path string) bool {
+	return l.Check.Command != "" && hasExt(path, l.Check.Extensions...)
+}
+
+func (l *CommandLinter) Lint(ctx context.Context, path string) error {
+	command := strings.ReplaceAll(l.Check.Command, "{{.file}}", shellQuote(path))
+	name, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		name, flag = "cmd", "/C"
+	}
+	label := l.Check.Name
+	if label == "" {
+		label = l.Check.Command
+	}
+	return runCheck(ctx, label, filepath.Join(l.Root, l.Check.Dir), "", name, flag, command)
+}
+
+// runCheck runs a checker in dir and turns a failure into an error carrying
+// its output. With about set, only output lines mentioning that file count;
+// a failure caused only by other files passes. A check cut short by the
+// context is not a failure.
+func runCheck(ctx context.Context, label, dir, about, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.WaitDelay = time.Second // Children of a killed shell may hold its output open
+	out, err := cmd.CombinedOutput()
+	if err == nil || ctx.Err() != nil {
+		return nil
+	}
+	output := string(out)
+	if about != "" {
+		output = linesAbout(output, about, dir)
+		if output == "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s failed:\n%s", label, strings.TrimSpace(output))
+}
+
+// linesAbout keeps the lines of checker output that mention file, by its
+// absolute path or its path relative to dir
+func linesAbout(output, file, dir string) string {
+	names := []string{file}
+	if rel, err := filepath.Rel(dir, file); err == nil {
+		names = append(names, rel, filepath.ToSlash(rel))
+	}
+	var kept []string
+	for _, line := range strings.Split(output, "\n") {
+		if slices.ContainsFunc(names, func(n string) bool { return strings.Contains(line, n) }) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+func hasExt(path string, exts ...string) bool {
+	return slices.Contains(exts, strings.ToLower(filepath.Ext(path)))
+}
+
+// findUp returns the nearest directory from dir upwards containing name
+func findUp(dir, name string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// findNodeBin prefers a project's own node_modules/.bin tool over one on PATH
+func findNodeBin(dir, name string) string {
+	if modules := findUp(dir, filepath.Join("node_modules", ".bin", name)); modules != "" {
+		return filepath.Join(modules, "node_modules", ".bin", name)
+	}
+	if path, err := exec.LookPath(name); err == nil {
+		return path
+	}
+	return ""
+}
+
+// shellQuote wraps s in single quotes for POSIX shells
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + s + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,127 @@
+package safeguard
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+)
+
+func writeFile(t *testing.T, path, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestVerifyProjectChecks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("checks use POSIX shell commands")
+	}
+	root := t.TempDir()
+	v := NewShadowVerifier().WithSettings(root, config.VerificationSettings{
+		Checks: []config.VerificationCheck{
+			{Name: "no-broken", Extensions: []string{".txt", ".go"}, Command: "! grep -n BROKEN {{.file}}"},
+		},
+	})
+
+	ok := writeFile(t, filepath.Join(root, "it's fine.txt"), "all good\n")
+	if err := v.Verify(context.Background(), ok); err != nil {
+		t.Errorf("passing check failed: %v", err)
+	}
+
+	bad := writeFile(t, filepath.Join(root, "bad.txt"), "line\nBROKEN\n")
+	err := v.Verify(context.Background(), bad)
+	if err == nil || !strings.Contains(err.Error(), "no-broken failed") || !strings.Contains(err.Error(), "2:BROKEN") {
+		t.Errorf("failing check: %v", err)
+	}
+
+	// The project check replaces go vet for .go files
+	goFile := writeFile(t, filepath.Join(root, "x.go"), "package x\nfunc f() { undefined() }\n")
+	if err := v.Verify(context.Background(), goFile); err != nil {
+		t.Errorf("project check should replace the Go linter: %v", err)
+	}
+
+	if err := v.Verify(context.Background(), writeFile(t, filepath.Join(root, "notes.md"), "BROKEN")); err != nil {
+		t.Errorf("file without a check failed: %v", err)
+	}
+}
+
+func TestVerifyTimeoutBudget(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("checks use POSIX shell commands")
+	}
+	root := t.TempDir()
+	v := NewShadowVerifier().WithSettings(root, config.VerificationSettings{
+		TimeoutSeconds: 1,
+		Checks:         []config.VerificationCheck{{Name: "slow", Extensions: []string{".txt"}, Command: "sleep 10; exit 1"}},
+	})
+	if err := v.Verify(context.Background(), writeFile(t, filepath.Join(root, "a.txt"), "")); err != nil {
+		t.Errorf("check over budget should be skipped, got %v", err)
+	}
+}
+
+func TestVerifySkipBuiltin(t *testing.T) {
+	v := NewShadowVerifier().WithSettings(t.TempDir(), config.VerificationSettings{Skip: []string{"python", "go"}})
+	for _, l := range v.linters {
+		if l.Name() == "python" || l.Name() == "go" {
+			t.Errorf("skipped linter %s still registered", l.Name())
+		}
+	}
+	if len(v.linters) != 3 {
+		t.Errorf("got %d linters, want 3", len(v.linters))
+	}
+}
+
+func TestPythonLinter(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		if _, err := exec.LookPath("ruff"); err != nil {
+			t.Skip("neither ruff nor python3 installed")
+		}
+	}
+	dir := t.TempDir()
+	v := NewShadowVerifier()
+	if err := v.Verify(context.Background(), writeFile(t, filepath.Join(dir, "ok.py"), "def f():\n    return 1\n")); err != nil {
+		t.Errorf("valid Python rejected: %v", err)
+	}
+	if err := v.Verify(context.Background(), writeFile(t, filepath.Join(dir, "bad.py"), "def f(:\n    return 1\n")); err == nil {
+		t.Error("syntax error not caught")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "__pycache__")); err == nil {
+		t.Error("verification wrote a __pycache__")
+	}
+}
+
+func TestJSLinter(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node not installed")
+	}
+	dir := t.TempDir()
+	v := NewShadowVerifier()
+	if err := v.Verify(context.Background(), writeFile(t, filepath.Join(dir, "ok.js"), "const a = 1;\n")); err != nil {
+		t.Errorf("valid JavaScript rejected: %v", err)
+	}
+	if err := v.Verify(context.Background(), writeFile(t, filepath.Join(dir, "bad.js"), "const a = ;\n")); err == nil {
+		t.Error("syntax error not caught")
+	}
+}
+
+func TestLinesAbout(t *testing.T) {
+	out := "src/a.ts(3,5): error TS2322: bad\nsrc/b.ts(1,1): error TS1005: other\n"
+	got := linesAbout(out, "/p/src/a.ts", "/p")
+	if got != "src/a.ts(3,5): error TS2322: bad" {
+		t.Errorf("linesAbout = %q", got)
+	}
+	if linesAbout(out, "/p/src/c.ts", "/p") != "" {
+		t.Error("errors in other files should be dropped")
+	}
+}
@@ -0,0 +1,153 @@
+package safeguard
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+	"github.com/igoryan-dao/ricochet/internal/paths"
+)
+
+// TrustLevel is the user's classification of a workspace root
+type TrustLevel string
+
+const (
+	TrustTrusted    TrustLevel = "trusted"    // Configured auto-approval, indexing allowed
+	TrustRestricted TrustLevel = "restricted" // Every action asks for consent, no indexing
+	TrustReadOnly   TrustLevel = "read-only"  // Analysis only: writes and commands are blocked
+)
+
+// TrustLevels lists the levels in the order they are offered to the user
+var TrustLevels = []TrustLevel{TrustTrusted, TrustRestricted, TrustReadOnly}
+
+// ParseTrustLevel maps a user answer (level name, or a yes/no button) to a level
+func ParseTrustLevel(answer string) (TrustLevel, bool) {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "trusted", "trust", "yes", "y", "always allow":
+		return TrustTrusted, true
+	case "restricted", "restrict":
+		return TrustRestricted, true
+	case "read-only", "readonly", "read only", "no", "n":
+		return TrustReadOnly, true
+	}
+	return "", false
+}
+
+// Zone returns the safeguard zone seeded by the level
+func (l TrustLevel) Zone() TrustZone {
+	if l == TrustReadOnly {
+		return ZoneReadOnly
+	}
+	return ZoneSafe
+}
+
+// AllowsIndexing reports whether workspace files may be sent for embedding
+func (l TrustLevel) AllowsIndexing() bool {
+	return l != TrustRestricted
+}
+
+// AutoApproval returns the auto-approval settings allowed at this level, derived from the configured ones.
+// The result is a copy; configured settings are never mutated.
+func (l TrustLevel) AutoApproval(configured *config.AutoApprovalSettings) *config.AutoApprovalSettings {
+	var s config.AutoApprovalSettings
+	if configured != nil {
+		s = *configured
+	}
+
+	switch l {
+	case TrustRestricted:
+		s.Enabled = false
+	case TrustReadOnly:
+		// Auto-approval bypasses zone checks, so keep reads only
+		s = config.AutoApprovalSettings{
+			Enabled:             s.Enabled,
+			ReadFiles:           s.ReadFiles,
+			EnableNotifications: s.EnableNotifications,
+		}
+	}
+	return &s
+}
+
+// WorkspaceTrust is a persisted trust decision
+type WorkspaceTrust struct {
+	Level     TrustLevel `json:"level"`
+	DecidedAt time.Time  `json:"decided_at"`
+}
+
+// TrustStore persists trust decisions per workspace root in ~/.ricochet/trusted_workspaces.json
+type TrustStore struct {
+	mu         sync.RWMutex
+	path       string
+	Workspaces map[string]WorkspaceTrust `json:"workspaces"`
+}
+
+// NewTrustStore loads the trust store, starting empty if it doesn't exist yet
+func NewTrustStore() (*TrustStore, error) {
+	configDir := paths.GetGlobalDir()
+	if err := paths.EnsureDir(configDir); err != nil {
+		return nil, fmt.Errorf("failed to create config dir: %w", err)
+	}
+
+	store := &TrustStore{
+		path:       filepath.Join(configDir, "trusted_workspaces.json"),
+		Workspaces: make(map[string]WorkspaceTrust),
+	}
+
+	data, err := os.ReadFile(store.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("failed to parse trusted_workspaces.json: %w", err)
+	}
+	if store.Workspaces == nil {
+		store.Workspaces = make(map[string]WorkspaceTrust)
+	}
+	return store, nil
+}
+
+// Get returns the trust level recorded for root, if the user has classified it
+func (s *TrustStore) Get(root string) (TrustLevel, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.Workspaces[trustKey(root)]
+	return t.Level, ok
+}
+
+// Set records the trust level for root and saves the store
+func (s *TrustStore) Set(root string, level TrustLevel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Workspaces[trustKey(root)] = WorkspaceTrust{Level: level, DecidedAt: time.Now()}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal trust store: %w", err)
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+func trustKey(root string) string {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return filepath.Clean(root)
+	}
+	return abs
+}
+
+// ApplyTrust seeds the zone and auto-approval for the workspace from a trust level
+func (m *Manager) ApplyTrust(level TrustLevel, configured *config.AutoApprovalSettings) {
+	m.Trust = level
+	m.CurrentZone = level.Zone()
+	m.AutoApproval = level.AutoApproval(configured)
+}
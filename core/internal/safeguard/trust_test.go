@@ -0,0 +1,121 @@
+package safeguard
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+)
+
+func TestParseTrustLevel(t *testing.T) {
+	for answer, want := range map[string]TrustLevel{
+		"trusted":      TrustTrusted,
+		" Yes ":        TrustTrusted,
+		"Always allow": TrustTrusted,
+		"restricted":   TrustRestricted,
+		"read only":    TrustReadOnly,
+		"no":           TrustReadOnly,
+	} {
+		if got, ok := ParseTrustLevel(answer); !ok || got != want {
+			t.Errorf("ParseTrustLevel(%q) = %q, %v; want %q", answer, got, ok, want)
+		}
+	}
+	if got, ok := ParseTrustLevel("maybe"); ok {
+		t.Errorf("ParseTrustLevel(maybe) = %q, want no level", got)
+	}
+}
+
+func TestTrustLevelDecisions(t *testing.T) {
+	configured := &config.AutoApprovalSettings{
+		Enabled:             true,
+		ReadFiles:           true,
+		EditFiles:           true,
+		ExecuteAllCommands:  true,
+		UseMCP:              true,
+		EnableNotifications: true,
+	}
+
+	trusted := TrustTrusted.AutoApproval(configured)
+	if *trusted != *configured || trusted == configured {
+		t.Errorf("trusted: auto-approval %+v, want a copy of the configured settings", trusted)
+	}
+	if TrustTrusted.Zone() != ZoneSafe || !TrustTrusted.AllowsIndexing() {
+		t.Error("trusted: want the safe zone with indexing")
+	}
+
+	if TrustRestricted.AutoApproval(configured).Enabled {
+		t.Error("restricted: auto-approval left on")
+	}
+	if TrustRestricted.Zone() != ZoneSafe || TrustRestricted.AllowsIndexing() {
+		t.Error("restricted: want the safe zone without indexing")
+	}
+
+	readOnly := TrustReadOnly.AutoApproval(configured)
+	want := config.AutoApprovalSettings{Enabled: true, ReadFiles: true, EnableNotifications: true}
+	if *readOnly != want {
+		t.Errorf("read-only: auto-approval %+v, want reads only", readOnly)
+	}
+	if TrustReadOnly.Zone() != ZoneReadOnly || !TrustReadOnly.AllowsIndexing() {
+		t.Error("read-only: want the read-only zone with indexing")
+	}
+
+	if !configured.EditFiles || !configured.Enabled {
+		t.Error("configured settings were changed")
+	}
+	if got := TrustRestricted.AutoApproval(nil); got == nil || got.Enabled {
+		t.Errorf("restricted without settings = %+v", got)
+	}
+}
+
+func TestApplyTrust(t *testing.T) {
+	configured := &config.AutoApprovalSettings{Enabled: true, ReadFiles: true, EditFiles: true, ExecuteAllCommands: true}
+	m := &Manager{}
+
+	m.ApplyTrust(TrustReadOnly, configured)
+	for _, tool := range []string{"write_file", "execute_command"} {
+		if err := m.CheckPermission(tool); err == nil {
+			t.Errorf("read-only workspace allowed %s", tool)
+		}
+	}
+	if err := m.CheckPermission("read_file"); err != nil {
+		t.Errorf("read-only workspace blocked read_file: %v", err)
+	}
+
+	m.ApplyTrust(TrustTrusted, configured)
+	if m.Trust != TrustTrusted {
+		t.Errorf("Trust = %q", m.Trust)
+	}
+	if err := m.CheckPermission("write_file"); err != nil {
+		t.Errorf("trusted workspace blocked write_file: %v", err)
+	}
+}
+
+func TestTrustStore(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	root := filepath.Join(t.TempDir(), "project")
+
+	store, err := NewTrustStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.Get(root); ok {
+		t.Fatal("new store has a decision")
+	}
+	if err := store.Set(root, TrustRestricted); err != nil {
+		t.Fatal(err)
+	}
+
+	// Decisions persist and are keyed by the cleaned absolute path
+	reloaded, err := NewTrustStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level, ok := reloaded.Get(root + string(filepath.Separator) + "."); !ok || level != TrustRestricted {
+		t.Errorf("reloaded Get = %q, %v; want restricted", level, ok)
+	}
+	if _, ok := reloaded.Get(filepath.Join(root, "sub")); ok {
+		t.Error("a subdirectory inherited the decision")
+	}
+}
@@ -0,0 +1,131 @@
+package safeguard
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultDenyWrite are the directories file tools never write into when
+// files.deny_write is not set in permissions.yaml
+var defaultDenyWrite = []string{".git", "node_modules"}
+
+// maxSymlinkHops bounds symlink resolution, like the kernel's ELOOP limit
+const maxSymlinkHops = 40
+
+// CheckPath verifies a file tool may read or write an absolute path. The
+// path is resolved through symlinks first, so a link inside the workspace
+// can't reach files outside it. Paths outside the workspace need
+// auto_approval.read_files_external (reads) or edit_files_external, and
+// writes must stay out of the files.deny_write directories. It returns the
+// resolved path.
+func (m *Manager) CheckPath(path string, write bool) (string, error) {
+	resolved, err := canonicalPath(path)
+	if err != nil {
+		return "", fmt.Errorf("access denied: %w", err)
+	}
+	if m == nil || m.root == "" {
+		return resolved, nil
+	}
+
+	rel, err := filepath.Rel(m.root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		if !m.externalAllowed(write) {
+			flag := "read_files_external"
+			if write {
+				flag = "edit_files_external"
+			}
+			return "", fmt.Errorf("access denied: '%s' is outside the workspace (enable auto_approval.%s to allow)", path, flag)
+		}
+		return resolved, nil
+	}
+
+	if write {
+		if dir := m.deniedWriteDir(filepath.ToSlash(rel)); dir != "" {
+			return "", fmt.Errorf("access denied: writes into '%s' are not allowed", dir)
+		}
+	}
+	return resolved, nil
+}
+
+func (m *Manager) externalAllowed(write bool) bool {
+	if m.AutoApproval == nil {
+		return false
+	}
+	if write {
+		return m.AutoApproval.EditFilesExternal
+	}
+	return m.AutoApproval.ReadFilesExternal || m.AutoApproval.EditFilesExternal
+}
+
+// deniedWriteDir returns the deny_write entry covering a workspace-relative
+// path. Entries with a "/" are anchored at the workspace root; others match
+// a directory of that name at any depth.
+func (m *Manager) deniedWriteDir(rel string) string {
+	denied := defaultDenyWrite
+	if m.Permissions != nil && m.Permissions.Files.DenyWrite != nil {
+		denied = m.Permissions.Files.DenyWrite
+	}
+	parts := strings.Split(rel, "/")
+	for _, dir := range denied {
+		dir = strings.Trim(filepath.ToSlash(dir), "/")
+		if dir == "" {
+			continue
+		}
+		if strings.Contains(dir, "/") {
+			if rel == dir || strings.HasPrefix(rel, dir+"/") {
+				return dir
+			}
+			continue
+		}
+		for _, part := range parts {
+			if part == dir {
+				return dir
+			}
+		}
+	}
+	return ""
+}
+
+// canonicalPath makes a path absolute and resolves its symlinks. For a path
+// that does not exist yet, its deepest existing ancestor is resolved and the
+// rest appended, so new files are judged by the directory they land in.
+func canonicalPath(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	for hops := 0; hops < maxSymlinkHops; hops++ {
+		rest := ""
+		p := path
+		for {
+			resolved, err := filepath.EvalSymlinks(p)
+			if err == nil {
+				return filepath.Join(resolved, rest), nil
+			}
+			if !os.IsNotExist(err) {
+				return "", err
+			}
+			if info, lerr := os.Lstat(p); lerr == nil && info.Mode()&os.ModeSymlink != 0 {
+				// A dangling link: writing through it would create its target
+				target, err := os.Readlink(p)
+				if err != nil {
+					return "", err
+				}
+				if !filepath.IsAbs(target) {
+					target = filepath.Join(filepath.Dir(p), target)
+				}
+				path = filepath.Join(target, rest)
+				break
+			}
+			parent := filepath.Dir(p)
+			if parent == p {
+				return filepath.Join(p, rest), nil
+			}
+			rest = filepath.Join(filepath.Base(p), rest)
+			p = parent
+		}
+	}
+	return "", fmt.Errorf("too many levels of symbolic links: %s", path)
+}
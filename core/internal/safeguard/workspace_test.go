@@ -0,0 +1,75 @@
+package safeguard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+)
+
+func TestCheckPath(t *testing.T) {
+	root, outside := t.TempDir(), t.TempDir()
+	os.MkdirAll(filepath.Join(root, "src"), 0755)
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("x"), 0644)
+	// Links inside the workspace that lead out of it
+	os.Symlink(outside, filepath.Join(root, "escape"))
+	os.Symlink(filepath.Join(outside, "new.txt"), filepath.Join(root, "dangling"))
+
+	m := &Manager{AutoApproval: &config.AutoApprovalSettings{}}
+	m.root, _ = canonicalPath(root)
+
+	allowed := []struct {
+		path  string
+		write bool
+	}{
+		{filepath.Join(root, "src", "main.go"), true},
+		{filepath.Join(root, "src", "new", "deep", "file.go"), true},
+		{filepath.Join(root, ".git", "config"), false},
+		{filepath.Join(root, "node_modules", "pkg", "index.js"), false},
+	}
+	for _, tt := range allowed {
+		if _, err := m.CheckPath(tt.path, tt.write); err != nil {
+			t.Errorf("CheckPath(%s, %v) = %v, want allowed", tt.path, tt.write, err)
+		}
+	}
+
+	denied := []struct {
+		path  string
+		write bool
+		want  string
+	}{
+		{filepath.Join(outside, "secret.txt"), false, "read_files_external"},
+		{filepath.Join(root, "..", filepath.Base(outside), "secret.txt"), true, "edit_files_external"},
+		{filepath.Join(root, "escape", "secret.txt"), false, "outside the workspace"},
+		{filepath.Join(root, "escape", "fresh.txt"), true, "outside the workspace"},
+		{filepath.Join(root, "dangling"), true, "outside the workspace"},
+		{filepath.Join(root, ".git", "hooks", "pre-commit"), true, "'.git'"},
+		{filepath.Join(root, "web", "node_modules", "x.js"), true, "'node_modules'"},
+	}
+	for _, tt := range denied {
+		if _, err := m.CheckPath(tt.path, tt.write); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("CheckPath(%s, %v) = %v, want an error mentioning %q", tt.path, tt.write, err, tt.want)
+		}
+	}
+
+	m.AutoApproval.ReadFilesExternal = true
+	if _, err := m.CheckPath(filepath.Join(root, "escape", "secret.txt"), false); err != nil {
+		t.Errorf("read_files_external should allow reads outside: %v", err)
+	}
+	if _, err := m.CheckPath(filepath.Join(root, "escape", "secret.txt"), true); err == nil {
+		t.Error("read_files_external must not allow writes outside")
+	}
+
+	m.Permissions = &PermissionConfig{Files: FileRules{DenyWrite: []string{"build/generated"}}}
+	if _, err := m.CheckPath(filepath.Join(root, ".git", "config"), true); err != nil {
+		t.Errorf("deny_write replaces the defaults: %v", err)
+	}
+	if _, err := m.CheckPath(filepath.Join(root, "build", "generated", "api.go"), true); err == nil {
+		t.Error("anchored deny_write entry should block writes")
+	}
+	if _, err := m.CheckPath(filepath.Join(root, "src", "build", "generated", "x.go"), true); err != nil {
+		t.Errorf("anchored entries only match from the root: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package safeguard
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Path zones refine the workspace zone per directory: the zones section of
+// permissions.yaml maps path patterns to a zone, and every file a tool call
+// touches is checked against the zone of its path. A read-only directory
+// blocks writes even under auto-approval; a danger directory takes edits
+// without asking once the workspace is trusted.
+
+// String is the zone's name in permissions.yaml
+func (z TrustZone) String() string {
+	switch z {
+	case ZoneDanger:
+		return "danger"
+	case ZoneReadOnly:
+		return "readonly"
+	}
+	return "safe"
+}
+
+// ParseTrustZone maps a zone name from permissions.yaml to its zone
+func ParseTrustZone(name string) (TrustZone, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "danger":
+		return ZoneDanger, nil
+	case "safe":
+		return ZoneSafe, nil
+	case "readonly", "read-only", "read_only":
+		return ZoneReadOnly, nil
+	}
+	return ZoneSafe, fmt.Errorf("unknown trust zone '%s' (want danger, safe or readonly)", name)
+}
+
+// PathZone is a zone pattern in force in the workspace
+type PathZone struct {
+	Pattern string `json:"pattern"`
+	Zone    string `json:"zone"`
+}
+
+// PathZones lists the configured zone patterns, most specific first
+func (m *Manager) PathZones() []PathZone {
+	if m == nil || m.Permissions == nil {
+		return nil
+	}
+	var zones []PathZone
+	for pattern, name := range m.Permissions.Zones {
+		if zone, err := ParseTrustZone(name); err == nil {
+			zones = append(zones, PathZone{Pattern: pattern, Zone: zone.String()})
+		}
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		if len(zones[i].Pattern) != len(zones[j].Pattern) {
+			return len(zones[i].Pattern) > len(zones[j].Pattern)
+		}
+		return zones[i].Pattern < zones[j].Pattern
+	})
+	return zones
+}
+
+// zoneFor returns the zone the most specific pattern gives path, if any
+func (m *Manager) zoneFor(path string) (TrustZone, string, bool) {
+	rel := m.relPath(path)
+	if rel == "" {
+		return 0, "", false
+	}
+	for _, z := range m.PathZones() {
+		if matchZonePattern(z.Pattern, rel) {
+			zone, _ := ParseTrustZone(z.Zone)
+			return zone, z.Pattern, true
+		}
+	}
+	return 0, "", false
+}
+
+// SetModeZone sets the workspace zone to the one the active mode asks for; ""
+// restores the zone seeded by the workspace trust. A read-only workspace
+// stays read-only whatever the mode says.
+func (m *Manager) SetModeZone(name string) error {
+	zone := m.Trust.Zone()
+	if name != "" && m.Trust != TrustReadOnly {
+		z, err := ParseTrustZone(name)
+		if err != nil {
+			return err
+		}
+		zone = z
+	}
+	m.CurrentZone = zone
+	return nil
+}
+
+// PathZone returns the zone a tool call on path runs in: the zone of the most
+// specific matching pattern, otherwise the workspace zone. A read-only
+// workspace stays read-only whatever its permissions.yaml says.
+func (m *Manager) PathZone(path string) TrustZone {
+	if m.CurrentZone == ZoneReadOnly {
+		return ZoneReadOnly
+	}
+	if zone, _, ok := m.zoneFor(path); ok {
+		return zone
+	}
+	return m.CurrentZone
+}
+
+// ZoneLabel names the zone a pattern assigns path, or "" when none does
+func (m *Manager) ZoneLabel(path string) string {
+	if m == nil {
+		return ""
+	}
+	if _, _, ok := m.zoneFor(path); !ok {
+		return ""
+	}
+	return m.PathZone(path).String()
+}
+
+// CheckPathPermission is CheckPermission for a tool call touching path. A
+// path in a configured zone is held to that zone, ahead of auto-approval.
+func (m *Manager) CheckPathPermission(tool, path string) error {
+	if err := CheckEmergency(tool); err != nil {
+		return err
+	}
+	zone, pattern, ok := m.zoneFor(path)
+	if !ok || m.CurrentZone == ZoneReadOnly {
+		return m.CheckPermission(tool)
+	}
+	if err := CheckZonePermission(zone, tool); err != nil {
+		return fmt.Errorf("%s is in the %s zone ('%s' in permissions.yaml): tool '%s' is not allowed there", m.relPath(path), zone, pattern, tool)
+	}
+	return nil
+}
+
+// EditsWithoutConsent reports whether edits to path are approved by its zone:
+// a danger path in a trusted workspace
+func (m *Manager) EditsWithoutConsent(path string) bool {
+	return m != nil && m.Trust == TrustTrusted && m.PathZone(path) == ZoneDanger
+}
+
+// relPath returns path relative to the workspace root with forward slashes,
+// or "" when it lies outside
+func (m *Manager) relPath(path string) string {
+	if path == "" || m.root == "" {
+		return ""
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.root, path)
+	}
+	if resolved, err := canonicalPath(path); err == nil {
+		path = resolved
+	}
+	rel, err := filepath.Rel(m.root, filepath.Clean(path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// matchZonePattern matches a workspace-relative path against a zone pattern:
+// "infra/" or "infra/**" covers the directory and everything below it, a glob
+// without a slash matches a file or directory name at any depth, and other
+// globs match the path or one of its parents
+func matchZonePattern(pattern, rel string) bool {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+		pattern = dir + "/"
+	}
+	if dir, ok := strings.CutSuffix(pattern, "/"); ok {
+		return rel == dir || strings.HasPrefix(rel, dir+"/")
+	}
+	nameOnly := !strings.Contains(pattern, "/")
+	for p := rel; p != "." && p != ""; p = filepath.ToSlash(filepath.Dir(p)) {
+		candidate := p
+		if nameOnly {
+			candidate = filepath.Base(p)
+		}
+		if matched, _ := filepath.Match(pattern, candidate); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package safeguard
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPathZones(t *testing.T) {
+	root, err := canonicalPath(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{
+		root:        root,
+		CurrentZone: ZoneSafe,
+		Trust:       TrustTrusted,
+		Permissions: &PermissionConfig{Zones: map[string]string{
+			"infra/":           "readonly",
+			"infra/scratch/**": "danger",
+			"docs/":            "danger",
+			"*.lock":           "readonly",
+		}},
+	}
+	at := func(rel string) string { return filepath.Join(root, filepath.FromSlash(rel)) }
+
+	for rel, want := range map[string]TrustZone{
+		"infra/main.tf":          ZoneReadOnly,
+		"infra/scratch/notes.md": ZoneDanger, // The longer pattern wins
+		"docs/guide.md":          ZoneDanger,
+		"web/package.lock":       ZoneReadOnly,
+		"src/main.go":            ZoneSafe,
+		"infrastructure.md":      ZoneSafe,
+	} {
+		if got := m.PathZone(at(rel)); got != want {
+			t.Errorf("PathZone(%s) = %s, want %s", rel, got, want)
+		}
+	}
+
+	err = m.CheckPathPermission("write_file", at("infra/main.tf"))
+	if err == nil || !strings.Contains(err.Error(), "readonly") {
+		t.Errorf("write into a read-only zone: %v", err)
+	}
+	m.AutoApproval = nil
+	if err := m.CheckPathPermission("read_file", at("infra/main.tf")); err != nil {
+		t.Errorf("read in a read-only zone: %v", err)
+	}
+	if err := m.CheckPathPermission("write_file", at("src/main.go")); err != nil {
+		t.Errorf("write outside any zone: %v", err)
+	}
+
+	if !m.EditsWithoutConsent(at("docs/guide.md")) || m.EditsWithoutConsent(at("src/main.go")) {
+		t.Error("only danger paths take edits without consent")
+	}
+	m.Trust = TrustRestricted
+	if m.EditsWithoutConsent(at("docs/guide.md")) {
+		t.Error("a restricted workspace must not skip consent")
+	}
+
+	if got := m.ZoneLabel(at("docs/guide.md")); got != "danger" {
+		t.Errorf("ZoneLabel = %q", got)
+	}
+	if got := m.ZoneLabel(at("src/main.go")); got != "" {
+		t.Errorf("unzoned path labelled %q", got)
+	}
+	if zones := m.PathZones(); len(zones) != 4 || zones[0].Pattern != "infra/scratch/**" {
+		t.Errorf("PathZones not most specific first: %+v", zones)
+	}
+
+	// The repo's permissions.yaml cannot loosen a read-only workspace
+	m.CurrentZone = ZoneReadOnly
+	if m.PathZone(at("docs/guide.md")) != ZoneReadOnly || m.CheckPathPermission("write_file", at("docs/guide.md")) == nil {
+		t.Error("danger zone escaped a read-only workspace")
+	}
+}
+
+func TestParseTrustZone(t *testing.T) {
+	for name, want := range map[string]TrustZone{"danger": ZoneDanger, "Safe": ZoneSafe, "read-only": ZoneReadOnly} {
+		if got, err := ParseTrustZone(name); err != nil || got != want {
+			t.Errorf("ParseTrustZone(%s) = %v, %v", name, got, err)
+		}
+	}
+	if _, err := ParseTrustZone("yolo"); err == nil {
+		t.Error("unknown zone accepted")
+	}
+}
+
+func TestSetModeZone(t *testing.T) {
+	m := &Manager{Trust: TrustTrusted, CurrentZone: ZoneSafe}
+	if err := m.SetModeZone("readonly"); err != nil || m.CurrentZone != ZoneReadOnly {
+		t.Fatalf("readonly mode: zone %s, err %v", m.CurrentZone, err)
+	}
+	if err := m.SetModeZone(""); err != nil || m.CurrentZone != ZoneSafe {
+		t.Fatalf("no mode zone: zone %s, err %v", m.CurrentZone, err)
+	}
+	if err := m.SetModeZone("lava"); err == nil {
+		t.Error("unknown zone accepted")
+	}
+
+	// A mode cannot loosen a read-only workspace
+	m = &Manager{Trust: TrustReadOnly, CurrentZone: ZoneReadOnly}
+	if err := m.SetModeZone("danger"); err != nil || m.CurrentZone != ZoneReadOnly {
+		t.Errorf("read-only workspace: zone %s, err %v", m.CurrentZone, err)
+	}
+}
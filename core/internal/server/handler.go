@@ -5,17 +5,24 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/igoryan-dao/ricochet/internal/agent"
-	"github.com/igoryan-dao/ricochet/internal/checkpoints"
 	"github.com/igoryan-dao/ricochet/internal/codegraph"
 	"github.com/igoryan-dao/ricochet/internal/config"
 	"github.com/igoryan-dao/ricochet/internal/host"
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
+	"github.com/igoryan-dao/ricochet/internal/index"
 	"github.com/igoryan-dao/ricochet/internal/livemode"
 	"github.com/igoryan-dao/ricochet/internal/mcp"
 	"github.com/igoryan-dao/ricochet/internal/modes"
+	"github.com/igoryan-dao/ricochet/internal/paths"
 	"github.com/igoryan-dao/ricochet/internal/protocol"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+	"github.com/igoryan-dao/ricochet/internal/safeguard/checkpoint"
+	"github.com/igoryan-dao/ricochet/internal/tools"
 	"github.com/igoryan-dao/ricochet/internal/whisper"
 	"github.com/igoryan-dao/ricochet/internal/workflow"
 )
@@ -29,7 +36,6 @@ type ResponseWriter interface {
 type Handler struct {
 	Agent          *agent.Controller
 	LiveMode       *livemode.Controller
-	Checkpoint     *checkpoints.CheckpointService
 	Providers      *config.ProvidersManager
 	Config         *agent.Config
 	LiveModeConfig *livemode.Config
@@ -44,6 +50,8 @@ type Handler struct {
 	AudioMu        sync.Mutex
 	InitMu         sync.Mutex // Protects lazy init of Agent
 	GlobalCtx      context.Context
+	StartedAt      time.Time
+	extraRoots     []index.Root // Repos indexed beside the workspace, re-added when the agent is recreated
 }
 
 // NewHandler creates a new handler with initial state
@@ -62,6 +70,7 @@ func NewHandler(
 ) *Handler {
 	return &Handler{
 		GlobalCtx:      ctx,
+		StartedAt:      time.Now(),
 		Config:         cfg,
 		LiveModeConfig: liveCfg,
 		LiveMode:       liveCtrl,
@@ -78,6 +87,13 @@ func NewHandler(
 // HandleMessage processes a single RPC message
 func (h *Handler) HandleMessage(msg protocol.RPCMessage, writer ResponseWriter) {
 	switch msg.Type {
+	case "health":
+		writer.Send(protocol.RPCMessage{
+			ID:      msg.ID,
+			Type:    "health",
+			Payload: protocol.EncodeRPC(h.Health()),
+		})
+
 	case "get_state":
 		var payload struct {
 			SessionID string `json:"session_id"`
@@ -90,6 +106,9 @@ func (h *Handler) HandleMessage(msg protocol.RPCMessage, writer ResponseWriter)
 
 		if h.Agent != nil {
 			state := h.Agent.GetState(sessionID)
+			if h.McpHub != nil {
+				state["mcpServers"] = h.McpHub.Status()
+			}
 			writer.Send(protocol.RPCMessage{
 				ID:      msg.ID,
 				Type:    "state",
@@ -173,6 +192,26 @@ func (h *Handler) HandleMessage(msg protocol.RPCMessage, writer ResponseWriter)
 		}
 		writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "session_deleted"})
 
+	case "rewind_to_message":
+		var payload struct {
+			SessionID string `json:"session_id"`
+			MessageID string `json:"message_id"`
+		}
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+			return
+		}
+		if err := h.lazyInitAgent(); err != nil {
+			writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+			return
+		}
+		res, err := h.Agent.RewindToMessage(payload.SessionID, payload.MessageID)
+		if err != nil {
+			writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+			return
+		}
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "session_rewound", Payload: protocol.EncodeRPC(res)})
+
 	case "abort_chat":
 		log.Printf("Received abort_chat request")
 		if h.Agent != nil {
@@ -266,6 +305,9 @@ func (h *Handler) HandleMessage(msg protocol.RPCMessage, writer ResponseWriter)
 			pm, err := config.NewProvidersManager(configPath)
 			if err != nil {
 				log.Printf("get_models: Error creating ProvidersManager: %v", err)
+			} else {
+				pm.SetHTTPClient(httpclient.Client(0)) // SyncCatalog bounds the request itself
+				pm.StartSync(h.GlobalCtx)
 			}
 			h.Providers = pm
 		}
@@ -288,6 +330,7 @@ func (h *Handler) HandleMessage(msg protocol.RPCMessage, writer ResponseWriter)
 			}
 		}
 
+		h.Providers.RefreshModels(h.GlobalCtx)
 		providers := h.Providers.GetAvailableProviders()
 		writer.Send(protocol.RPCMessage{
 			ID:   msg.ID,
@@ -324,18 +367,92 @@ func (h *Handler) HandleMessage(msg protocol.RPCMessage, writer ResponseWriter)
 		settings := map[string]interface{}{
 			"provider":       s.Provider.Provider,
 			"model":          s.Provider.Model,
+			"routing":        s.Provider.Routing,
 			"apiKeys":        s.Provider.APIKeys,
 			"telegramToken":  s.LiveMode.TelegramToken,
 			"telegramChatId": s.LiveMode.TelegramChatID,
 			"context":        s.Context,
+			"checkpoints":    s.Checkpoints,
 			"auto_approval":  s.AutoApproval,
+			"index":          s.Index,
 			"theme":          s.Theme,
 		}
+		// Dotted keys overridden by .ricochet/config.yaml; anything absent is global
+		origins, projectFile := h.Settings.Origins()
+		settings["origins"] = origins
+		if projectFile != "" {
+			settings["projectConfig"] = projectFile
+		}
 		writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "settings_loaded", Payload: protocol.EncodeRPC(settings)})
 
 	case "save_settings":
 		h.handleSaveSettings(msg, writer)
 
+	case "list_jobs", "job_logs", "kill_job":
+		h.handleJobs(msg, writer)
+
+	case "get_tool_stats":
+		h.handleToolStats(msg, writer)
+
+	case "index_status":
+		// Freshness of the codebase index and the changed files waiting to be re-indexed
+		if err := h.lazyInitAgent(); err != nil {
+			writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+			return
+		}
+		idx := h.Agent.GetIndexer()
+		if idx == nil {
+			writer.Send(protocol.RPCMessage{ID: msg.ID, Error: "codebase index is not available"})
+			return
+		}
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "index_status", Payload: protocol.EncodeRPC(idx.Status())})
+
+	case "index_roots", "index_add_root", "index_remove_root":
+		h.handleIndexRoots(msg, writer)
+
+	case "memory_pending", "memory_review":
+		h.handleMemoryReview(msg, writer)
+
+	case "get_condensation_log":
+		h.handleCondensationLog(msg, writer)
+
+	case "get_context_breakdown":
+		h.handleContextBreakdown(msg, writer)
+
+	case "get_effective_rules":
+		h.handleEffectiveRules(msg, writer)
+
+	case "list_permissions", "remove_permission", "export_permissions":
+		h.handlePermissions(msg, writer)
+
+	case "emergency_stop", "emergency_resume", "emergency_status":
+		h.handleEmergency(msg, writer)
+
+	case "query_approval_audit":
+		h.handleApprovalAudit(msg, writer)
+
+	case "list_pins", "pin_context", "unpin_context":
+		h.handlePins(msg, writer)
+
+	case "checkpoint_list", "checkpoint_save", "checkpoint_restore", "checkpoint_prune":
+		h.handleCheckpoints(msg, writer)
+	case "experiment_list", "experiment_fork", "experiment_switch", "experiment_diff", "experiment_merge", "experiment_discard":
+		h.handleExperiments(msg, writer)
+
+	case "mcp_list", "mcp_registry", "mcp_install", "mcp_remove":
+		h.handleMcpServers(msg, writer)
+
+	case "mcp_status":
+		status := []mcp.ServerStatus{}
+		if h.McpHub != nil {
+			status = h.McpHub.Status()
+		}
+		writer.Send(protocol.RPCMessage{
+			ID:      msg.ID,
+			Type:    "mcp_status",
+			Payload: protocol.EncodeRPC(map[string]interface{}{"servers": status}),
+		})
+
 	case "set_live_mode":
 		h.handleSetLiveMode(msg, writer)
 
@@ -368,6 +485,11 @@ func (h *Handler) lazyInitAgent() error {
 	if h.Settings != nil {
 		s := h.Settings.Get()
 		h.Config.AutoApproval = &s.AutoApproval
+		h.Config.Tools = s.Tools
+		h.Config.Checkpoints = s.Checkpoints
+		h.Config.Memory = s.Memory
+		h.Config.Sessions = s.Sessions
+		h.Config.VectorStore = s.Context.VectorStore
 	}
 
 	log.Printf("Initializing agent controller with provider %s (%s)", h.Config.Provider.Provider, h.Config.Provider.Model)
@@ -379,6 +501,7 @@ func (h *Handler) lazyInitAgent() error {
 		ProvidersManager: h.Providers,
 		Codegraph:        h.Codegraph,
 		WorkflowManager:  h.Workflows,
+		Settings:         h.Settings,
 	})
 	if err != nil {
 		return err
@@ -387,6 +510,11 @@ func (h *Handler) lazyInitAgent() error {
 		h.Agent.SetLiveMode(h.LiveMode)
 		h.LiveMode.SetAgent(h.Agent)
 	}
+	for _, root := range h.extraRoots {
+		if _, err := h.Agent.GetIndexRoots().Add(root.Name, root.Path); err != nil {
+			log.Printf("Warning: failed to re-register repo %s: %v", root.Name, err)
+		}
+	}
 	return nil
 }
 
@@ -397,10 +525,12 @@ func (h *Handler) handleSaveSettings(msg protocol.RPCMessage, writer ResponseWri
 		Model             string                       `json:"model"`
 		EmbeddingProvider string                       `json:"embeddingProvider"`
 		EmbeddingModel    string                       `json:"embeddingModel"`
+		Routing           *string                      `json:"routing,omitempty"` // "" resets to OpenRouter's default
 		TelegramChatID    int64                        `json:"telegramChatId"`
 		TelegramToken     string                       `json:"telegramToken"`
 		Context           *config.ContextSettings      `json:"context,omitempty"`
 		AutoApproval      *config.AutoApprovalSettings `json:"auto_approval,omitempty"`
+		Checkpoints       *config.CheckpointSettings   `json:"checkpoints,omitempty"`
 	}
 	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
 		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
@@ -415,6 +545,15 @@ func (h *Handler) handleSaveSettings(msg protocol.RPCMessage, writer ResponseWri
 		h.LiveMode.SetChatID(payload.TelegramChatID)
 	}
 
+	if h.Agent != nil {
+		h.extraRoots = nil
+		for _, root := range h.Agent.GetIndexRoots().List() {
+			if !root.Primary {
+				h.extraRoots = append(h.extraRoots, root)
+			}
+		}
+		h.Agent.Close()
+	}
 	h.Agent = nil // Reset agent to re-init with new config
 
 	if h.Settings != nil {
@@ -449,6 +588,14 @@ func (h *Handler) handleSaveSettings(msg protocol.RPCMessage, writer ResponseWri
 			if payload.EmbeddingModel != "" {
 				s.Provider.EmbeddingModel = payload.EmbeddingModel
 			}
+			if payload.Routing != nil {
+				if routing, err := agent.NormalizeRouting(*payload.Routing); err != nil {
+					log.Printf("[Settings] %v", err)
+				} else {
+					s.Provider.Routing = routing
+					h.Config.Provider.Routing = routing
+				}
+			}
 			if payload.TelegramToken != "" {
 				s.LiveMode.TelegramToken = payload.TelegramToken
 				h.LiveModeConfig.TelegramToken = payload.TelegramToken
@@ -460,6 +607,9 @@ func (h *Handler) handleSaveSettings(msg protocol.RPCMessage, writer ResponseWri
 			if payload.AutoApproval != nil {
 				s.AutoApproval = *payload.AutoApproval
 			}
+			if payload.Checkpoints != nil {
+				s.Checkpoints = *payload.Checkpoints
+			}
 			s.LiveMode.Enabled = s.LiveMode.TelegramToken != ""
 		})
 	}
@@ -501,6 +651,570 @@ func (h *Handler) handleSaveSettings(msg protocol.RPCMessage, writer ResponseWri
 	})
 }
 
+// checkpointListLimit bounds the checkpoints returned by checkpoint_list
+const checkpointListLimit = 100
+
+// handleCheckpoints serves the workspace checkpoint history shared by the
+// IDE, the TUI and the agent's auto-checkpoints
+func (h *Handler) handleCheckpoints(msg protocol.RPCMessage, writer ResponseWriter) {
+	var payload struct {
+		Hash    string `json:"hash"`
+		Message string `json:"message"`
+		Limit   int    `json:"limit"`
+	}
+	json.Unmarshal(msg.Payload, &payload)
+
+	fail := func(err error) {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+	}
+	if err := h.lazyInitAgent(); err != nil {
+		fail(err)
+		return
+	}
+
+	switch msg.Type {
+	case "checkpoint_list":
+		limit := payload.Limit
+		if limit <= 0 || limit > checkpointListLimit {
+			limit = checkpointListLimit
+		}
+		commits, err := h.Agent.ListCheckpoints(limit)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if commits == nil {
+			commits = []checkpoint.CommitInfo{}
+		}
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "checkpoint_list", Payload: protocol.EncodeRPC(map[string]interface{}{"checkpoints": commits})})
+
+	case "checkpoint_save":
+		hash, err := h.Agent.SaveCheckpoint(payload.Message)
+		if err != nil {
+			fail(err)
+			return
+		}
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "checkpoint_saved", Payload: protocol.EncodeRPC(map[string]interface{}{"hash": hash, "message": payload.Message})})
+
+	case "checkpoint_restore":
+		if payload.Hash == "" {
+			fail(fmt.Errorf("hash is required"))
+			return
+		}
+		if err := h.Agent.RestoreCheckpoint(payload.Hash); err != nil {
+			fail(err)
+			return
+		}
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "checkpoint_restored", Payload: protocol.EncodeRPC(map[string]interface{}{"hash": payload.Hash})})
+
+	case "checkpoint_prune":
+		// Applies the retention policy right away and reports the space reclaimed
+		res, err := h.Agent.PruneCheckpoints()
+		if err != nil {
+			fail(err)
+			return
+		}
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "checkpoint_pruned", Payload: protocol.EncodeRPC(res)})
+	}
+}
+
+// handleExperiments serves named experiments: forks of the workspace kept as
+// shadow branches next to the checkpoint history
+func (h *Handler) handleExperiments(msg protocol.RPCMessage, writer ResponseWriter) {
+	var payload struct {
+		Name string `json:"name"`
+	}
+	json.Unmarshal(msg.Payload, &payload)
+
+	fail := func(err error) {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+	}
+	if err := h.lazyInitAgent(); err != nil {
+		fail(err)
+		return
+	}
+	if msg.Type != "experiment_list" && payload.Name == "" {
+		fail(fmt.Errorf("name is required"))
+		return
+	}
+
+	var err error
+	switch msg.Type {
+	case "experiment_list":
+	case "experiment_fork":
+		_, err = h.Agent.ForkExperiment(payload.Name)
+	case "experiment_switch":
+		err = h.Agent.SwitchExperiment(payload.Name)
+	case "experiment_discard":
+		err = h.Agent.DiscardExperiment(payload.Name)
+
+	case "experiment_diff":
+		diff, err := h.Agent.CompareExperiment(payload.Name)
+		if err != nil {
+			fail(err)
+			return
+		}
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "experiment_diff", Payload: protocol.EncodeRPC(diff)})
+		return
+
+	case "experiment_merge":
+		res, err := h.Agent.MergeExperiment(payload.Name)
+		if err != nil {
+			fail(err)
+			return
+		}
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "experiment_merged", Payload: protocol.EncodeRPC(res)})
+		return
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	// Every other change answers with the new list, so panels stay in sync
+	state, err := h.Agent.ListExperiments()
+	if err != nil {
+		fail(err)
+		return
+	}
+	writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "experiment_list", Payload: protocol.EncodeRPC(state)})
+}
+
+// handleIndexRoots registers and lists the repos indexed for search beside
+// the workspace, e.g. the other folders of a multi-root VS Code workspace
+func (h *Handler) handleIndexRoots(msg protocol.RPCMessage, writer ResponseWriter) {
+	var payload struct {
+		Name string `json:"name"`
+		Path string `json:"path"`
+	}
+	json.Unmarshal(msg.Payload, &payload)
+
+	if err := h.lazyInitAgent(); err != nil {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+		return
+	}
+	roots := h.Agent.GetIndexRoots()
+
+	var err error
+	switch msg.Type {
+	case "index_add_root":
+		if payload.Path == "" {
+			err = fmt.Errorf("path is required")
+			break
+		}
+		_, err = roots.Add(payload.Name, payload.Path)
+	case "index_remove_root":
+		err = roots.Remove(payload.Name)
+	}
+	if err != nil {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+		return
+	}
+	writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "index_roots", Payload: protocol.EncodeRPC(map[string]interface{}{"roots": roots.List()})})
+}
+
+// handleMemoryReview lists the learned facts waiting for review and
+// approves or rejects them by key
+func (h *Handler) handleMemoryReview(msg protocol.RPCMessage, writer ResponseWriter) {
+	var payload struct {
+		Approve []string          `json:"approve"`
+		Reject  []string          `json:"reject"`
+		Edits   map[string]string `json:"edits"` // Rewordings of approved facts, by key
+	}
+	json.Unmarshal(msg.Payload, &payload)
+
+	if err := h.lazyInitAgent(); err != nil {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+		return
+	}
+	if msg.Type == "memory_review" {
+		if err := h.Agent.ReviewMemories(payload.Approve, payload.Reject, payload.Edits); err != nil {
+			writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+			return
+		}
+	}
+	writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "memory_pending", Payload: protocol.EncodeRPC(map[string]interface{}{"pending": h.Agent.PendingMemories()})})
+}
+
+// handleCondensationLog lists what condensation hid from the model in a
+// session; with an id it also returns that segment's messages
+func (h *Handler) handleCondensationLog(msg protocol.RPCMessage, writer ResponseWriter) {
+	var payload struct {
+		SessionID string `json:"session_id"`
+		ID        int    `json:"id"`
+	}
+	json.Unmarshal(msg.Payload, &payload)
+	if payload.SessionID == "" {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: "session_id is required"})
+		return
+	}
+
+	if err := h.lazyInitAgent(); err != nil {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+		return
+	}
+	result := map[string]interface{}{"entries": h.Agent.CondensationLog(payload.SessionID)}
+	if payload.ID > 0 {
+		msgs, err := h.Agent.CondensedSegment(payload.SessionID, payload.ID)
+		if err != nil {
+			writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+			return
+		}
+		result["messages"] = msgs
+	}
+	writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "condensation_log", Payload: protocol.EncodeRPC(result)})
+}
+
+// handleContextBreakdown reports where a session's context tokens go
+func (h *Handler) handleContextBreakdown(msg protocol.RPCMessage, writer ResponseWriter) {
+	var payload struct {
+		SessionID string `json:"session_id"`
+	}
+	json.Unmarshal(msg.Payload, &payload)
+	if payload.SessionID == "" {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: "session_id is required"})
+		return
+	}
+
+	if err := h.lazyInitAgent(); err != nil {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+		return
+	}
+	breakdown, err := h.Agent.ContextBreakdown(payload.SessionID)
+	if err != nil {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+		return
+	}
+	writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "context_breakdown", Payload: protocol.EncodeRPC(breakdown)})
+}
+
+// handleEffectiveRules reports which project rules apply to a session's
+// files, or to the given mode and files, and why
+func (h *Handler) handleEffectiveRules(msg protocol.RPCMessage, writer ResponseWriter) {
+	var payload struct {
+		SessionID string   `json:"session_id"`
+		Mode      string   `json:"mode"`
+		Files     []string `json:"files"`
+	}
+	json.Unmarshal(msg.Payload, &payload)
+
+	if err := h.lazyInitAgent(); err != nil {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+		return
+	}
+	report, err := h.Agent.EffectiveRules(payload.SessionID, payload.Mode, payload.Files)
+	if err != nil {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+		return
+	}
+	writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "effective_rules", Payload: protocol.EncodeRPC(report)})
+}
+
+// handlePermissions lists, removes and exports the stored "don't ask
+// again" permission rules of the workspace
+func (h *Handler) handlePermissions(msg protocol.RPCMessage, writer ResponseWriter) {
+	var payload struct {
+		ID string `json:"id"` // Rule to remove
+	}
+	json.Unmarshal(msg.Payload, &payload)
+
+	if err := h.lazyInitAgent(); err != nil {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+		return
+	}
+	switch msg.Type {
+	case "remove_permission":
+		if payload.ID == "" {
+			writer.Send(protocol.RPCMessage{ID: msg.ID, Error: "id is required"})
+			return
+		}
+		if err := h.Agent.RemovePermissionRule(payload.ID); err != nil {
+			writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+			return
+		}
+	case "export_permissions":
+		data, err := h.Agent.ExportPermissionRules()
+		if err != nil {
+			writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+			return
+		}
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "permissions_export", Payload: protocol.EncodeRPC(map[string]string{"json": data})})
+		return
+	}
+	rules, err := h.Agent.PermissionRules()
+	if err != nil {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+		return
+	}
+	writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "permissions", Payload: protocol.EncodeRPC(map[string]interface{}{"rules": rules})})
+}
+
+// handleEmergency pulls, releases or reports the kill switch. It never starts
+// the agent: with none running there is nothing to abort, and the switch
+// still holds for the agent started later.
+func (h *Handler) handleEmergency(msg protocol.RPCMessage, writer ResponseWriter) {
+	result := map[string]interface{}{}
+	switch msg.Type {
+	case "emergency_stop":
+		report := agent.EmergencyReport{}
+		if h.Agent != nil {
+			report = h.Agent.EmergencyStop("ide")
+		} else {
+			report.EmergencyState = safeguard.EngageEmergencyStop("ide")
+		}
+		result["report"] = report
+	case "emergency_resume":
+		if h.Agent != nil {
+			result["released"] = h.Agent.ResumeFromEmergencyStop()
+		} else {
+			result["released"] = safeguard.ReleaseEmergencyStop()
+		}
+	}
+	result["state"] = safeguard.Emergency()
+	writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "emergency", Payload: protocol.EncodeRPC(result)})
+}
+
+// handleApprovalAudit searches the log of approval decisions
+func (h *Handler) handleApprovalAudit(msg protocol.RPCMessage, writer ResponseWriter) {
+	var query safeguard.AuditQuery
+	if len(msg.Payload) > 0 {
+		if err := json.Unmarshal(msg.Payload, &query); err != nil {
+			writer.Send(protocol.RPCMessage{ID: msg.ID, Error: "Invalid payload: " + err.Error()})
+			return
+		}
+	}
+
+	if err := h.lazyInitAgent(); err != nil {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+		return
+	}
+	entries, err := h.Agent.QueryApprovals(query)
+	if err != nil {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+		return
+	}
+	if entries == nil {
+		entries = []safeguard.AuditEntry{}
+	}
+	writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "approval_audit", Payload: protocol.EncodeRPC(map[string]interface{}{"entries": entries})})
+}
+
+// handlePins pins messages, files and notes to a session, so condensation
+// never drops them, and lists them with the tokens each takes
+func (h *Handler) handlePins(msg protocol.RPCMessage, writer ResponseWriter) {
+	var payload struct {
+		SessionID string `json:"session_id"`
+		Kind      string `json:"kind"`  // message, file or note
+		Index     *int   `json:"index"` // Message to pin
+		Path      string `json:"path"`
+		Note      string `json:"note"`
+		ID        string `json:"id"` // Pin to remove
+	}
+	json.Unmarshal(msg.Payload, &payload)
+	if payload.SessionID == "" {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: "session_id is required"})
+		return
+	}
+
+	if err := h.lazyInitAgent(); err != nil {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+		return
+	}
+	var err error
+	switch msg.Type {
+	case "pin_context":
+		switch payload.Kind {
+		case "message":
+			if payload.Index == nil {
+				err = fmt.Errorf("index is required to pin a message")
+			} else {
+				err = h.Agent.PinMessage(payload.SessionID, *payload.Index, true)
+			}
+		case "file":
+			_, err = h.Agent.AddPin(payload.SessionID, payload.Kind, payload.Path)
+		default:
+			_, err = h.Agent.AddPin(payload.SessionID, payload.Kind, payload.Note)
+		}
+	case "unpin_context":
+		err = h.Agent.Unpin(payload.SessionID, payload.ID)
+	}
+	if err != nil {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+		return
+	}
+	writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "pins", Payload: protocol.EncodeRPC(map[string]interface{}{
+		"pins":           h.Agent.PinnedContext(payload.SessionID),
+		"context_status": h.Agent.GetContextStatus(payload.SessionID),
+	})})
+}
+
+// handleJobs serves the jobs panel: background commands the agent started
+func (h *Handler) handleJobs(msg protocol.RPCMessage, writer ResponseWriter) {
+	jm, ok := h.Host.(host.JobManager)
+	if !ok {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: "background jobs are not supported by this host"})
+		return
+	}
+
+	var payload struct {
+		ID     string `json:"id"`
+		Offset *int64 `json:"offset"`
+		Lines  int    `json:"lines"`
+	}
+	json.Unmarshal(msg.Payload, &payload)
+
+	switch msg.Type {
+	case "list_jobs":
+		writer.Send(protocol.RPCMessage{
+			ID:      msg.ID,
+			Type:    "jobs_list",
+			Payload: protocol.EncodeRPC(map[string]interface{}{"jobs": jm.ListJobs()}),
+		})
+
+	case "job_logs":
+		offset := int64(-1)
+		if payload.Offset != nil {
+			offset = *payload.Offset
+		}
+		log, err := jm.JobLogs(payload.ID, offset, payload.Lines)
+		if err != nil {
+			writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+			return
+		}
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "job_logs", Payload: protocol.EncodeRPC(log)})
+
+	case "kill_job":
+		job, err := jm.KillJob(payload.ID)
+		if err != nil {
+			writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+			return
+		}
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "job_killed", Payload: protocol.EncodeRPC(job)})
+	}
+}
+
+// handleMcpServers manages the global MCP server settings: listing, the
+// curated registry, installing (with a startup check) and removal
+func (h *Handler) handleMcpServers(msg protocol.RPCMessage, writer ResponseWriter) {
+	var payload struct {
+		Name string            `json:"name"`
+		Env  map[string]string `json:"env"`
+		Args []string          `json:"args"`
+	}
+	json.Unmarshal(msg.Payload, &payload)
+
+	mgr := mcp.NewManager(paths.GetGlobalDir())
+	fail := func(err error) {
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+	}
+
+	switch msg.Type {
+	case "mcp_list":
+		servers, err := mgr.ListServers()
+		if err != nil {
+			fail(err)
+			return
+		}
+		var status []mcp.ServerStatus
+		if h.McpHub != nil {
+			status = h.McpHub.Status()
+		}
+		writer.Send(protocol.RPCMessage{
+			ID:      msg.ID,
+			Type:    "mcp_list",
+			Payload: protocol.EncodeRPC(map[string]interface{}{"servers": servers, "status": status}),
+		})
+
+	case "mcp_registry":
+		items, err := mcp.LoadRegistry(mgr.ConfigDir())
+		if err != nil {
+			fail(err)
+			return
+		}
+		writer.Send(protocol.RPCMessage{
+			ID:      msg.ID,
+			Type:    "mcp_registry",
+			Payload: protocol.EncodeRPC(map[string]interface{}{"items": items}),
+		})
+
+	case "mcp_install":
+		items, err := mcp.LoadRegistry(mgr.ConfigDir())
+		if err != nil {
+			fail(err)
+			return
+		}
+		item, ok := mcp.FindRegistryItem(items, payload.Name)
+		if !ok {
+			fail(fmt.Errorf("%q is not in the MCP registry", payload.Name))
+			return
+		}
+		result, err := mcp.Install(h.GlobalCtx, mgr, item, payload.Env, payload.Args)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if h.McpHub != nil {
+			h.McpHub.Reload()
+		}
+		writer.Send(protocol.RPCMessage{ID: msg.ID, Type: "mcp_installed", Payload: protocol.EncodeRPC(result)})
+
+	case "mcp_remove":
+		if err := mgr.RemoveServer(payload.Name); err != nil {
+			fail(err)
+			return
+		}
+		if h.McpHub != nil {
+			h.McpHub.Reload()
+		}
+		writer.Send(protocol.RPCMessage{
+			ID:      msg.ID,
+			Type:    "mcp_removed",
+			Payload: protocol.EncodeRPC(map[string]string{"name": payload.Name}),
+		})
+	}
+}
+
+// handleToolStats reports per-tool usage; {"reset": true} clears it first
+func (h *Handler) handleToolStats(msg protocol.RPCMessage, writer ResponseWriter) {
+	var payload struct {
+		Reset bool `json:"reset"`
+	}
+	json.Unmarshal(msg.Payload, &payload)
+
+	// Stats are persisted, so they can be shown before the agent starts
+	var stats *tools.ToolStats
+	if h.Agent != nil {
+		stats = h.Agent.ToolStats()
+	}
+	if stats == nil {
+		stats = tools.NewToolStats(filepath.Join(paths.GetStatsDir(h.Host.GetCWD()), "tools.json"))
+	}
+	if payload.Reset {
+		if err := stats.Reset(); err != nil {
+			writer.Send(protocol.RPCMessage{ID: msg.ID, Error: err.Error()})
+			return
+		}
+	}
+
+	list, since := stats.Snapshot()
+	type toolStat struct {
+		tools.ToolStat
+		AvgMs int64 `json:"avg_ms"`
+		P50Ms int64 `json:"p50_ms"`
+		P95Ms int64 `json:"p95_ms"`
+	}
+	out := make([]toolStat, 0, len(list))
+	for _, st := range list {
+		out = append(out, toolStat{ToolStat: st, AvgMs: st.AvgMs(), P50Ms: st.Percentile(50), P95Ms: st.Percentile(95)})
+		out[len(out)-1].Recent = nil
+	}
+	writer.Send(protocol.RPCMessage{
+		ID:      msg.ID,
+		Type:    "tool_stats",
+		Payload: protocol.EncodeRPC(map[string]interface{}{"since": since, "tools": out}),
+	})
+}
+
 func (h *Handler) handleSetLiveMode(msg protocol.RPCMessage, writer ResponseWriter) {
 	var payload struct {
 		Enabled bool `json:"enabled"`
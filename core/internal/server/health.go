@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/index"
+	"github.com/igoryan-dao/ricochet/internal/mcp"
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+// DefaultHeartbeatInterval is how often the sidecar pings the extension
+const DefaultHeartbeatInterval = 15 * time.Second
+
+// HealthStatus is the payload of the "health" RPC
+type HealthStatus struct {
+	Status   string             `json:"status"` // ok, degraded
+	UptimeMs int64              `json:"uptime_ms"`
+	Provider ProviderHealth     `json:"provider"`
+	Indexer  *index.IndexStatus `json:"indexer,omitempty"`
+	Mcp      []mcp.ServerStatus `json:"mcp"`
+	Memory   MemoryHealth       `json:"memory"`
+}
+
+// ProviderHealth describes the configured AI provider
+type ProviderHealth struct {
+	Name        string `json:"name"`
+	Model       string `json:"model"`
+	Configured  bool   `json:"configured"`  // API key present
+	Initialized bool   `json:"initialized"` // Agent controller created
+}
+
+// MemoryHealth reports Go runtime memory usage
+type MemoryHealth struct {
+	AllocBytes uint64 `json:"alloc_bytes"`
+	SysBytes   uint64 `json:"sys_bytes"`
+	NumGC      uint32 `json:"num_gc"`
+	Goroutines int    `json:"goroutines"`
+}
+
+// Health collects a liveness snapshot of the core subsystems
+func (h *Handler) Health() HealthStatus {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	status := HealthStatus{
+		Status:   "ok",
+		UptimeMs: time.Since(h.StartedAt).Milliseconds(),
+		Provider: ProviderHealth{
+			Name:       h.Config.Provider.Provider,
+			Model:      h.Config.Provider.Model,
+			Configured: h.Config.Provider.APIKey != "",
+		},
+		Mcp: []mcp.ServerStatus{},
+		Memory: MemoryHealth{
+			AllocBytes: mem.Alloc,
+			SysBytes:   mem.Sys,
+			NumGC:      mem.NumGC,
+			Goroutines: runtime.NumGoroutine(),
+		},
+	}
+
+	// The agent is created lazily under InitMu
+	h.InitMu.Lock()
+	ag := h.Agent
+	h.InitMu.Unlock()
+	if ag != nil {
+		status.Provider.Initialized = true
+		if idx := ag.GetIndexer(); idx != nil {
+			idxStatus := idx.Status()
+			status.Indexer = &idxStatus
+			if idxStatus.LastError != "" {
+				status.Status = "degraded"
+			}
+		}
+	}
+
+	if h.McpHub != nil {
+		status.Mcp = h.McpHub.Status()
+		for _, s := range status.Mcp {
+			if s.State == "error" {
+				status.Status = "degraded"
+			}
+		}
+	}
+
+	if !status.Provider.Configured {
+		status.Status = "degraded"
+	}
+
+	return status
+}
+
+// StartHeartbeat sends a periodic "ping" notification until ctx is cancelled,
+// letting the host detect a wedged core and restart it.
+func (h *Handler) StartHeartbeat(ctx context.Context, writer ResponseWriter, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var seq int64
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				seq++
+				writer.Send(protocol.RPCMessage{
+					Type: "ping",
+					Payload: protocol.EncodeRPC(map[string]interface{}{
+						"seq":       seq,
+						"timestamp": now.UnixMilli(),
+						"uptime_ms": time.Since(h.StartedAt).Milliseconds(),
+					}),
+				})
+			}
+		}
+	}()
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/agent"
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+func TestHealth(t *testing.T) {
+	h := &Handler{
+		Config:    &agent.Config{Provider: agent.ProviderConfig{Provider: "openai", Model: "gpt-4o"}},
+		StartedAt: time.Now().Add(-time.Second),
+	}
+	status := h.Health()
+	if status.Status != "degraded" || status.Provider.Configured || status.Provider.Initialized {
+		t.Errorf("without an API key: %+v", status)
+	}
+	if status.UptimeMs < 1000 || status.Provider.Name != "openai" || status.Mcp == nil || status.Memory.Goroutines == 0 {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	h.Config.Provider.APIKey = "key"
+	if status := h.Health(); status.Status != "ok" || !status.Provider.Configured {
+		t.Errorf("configured: %+v", status)
+	}
+}
+
+// recordingWriter collects the messages sent to the extension
+type recordingWriter struct {
+	mu   sync.Mutex
+	msgs []protocol.RPCMessage
+}
+
+func (w *recordingWriter) Send(msg interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.msgs = append(w.msgs, msg.(protocol.RPCMessage))
+	return nil
+}
+
+func (w *recordingWriter) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.msgs)
+}
+
+func TestHeartbeat(t *testing.T) {
+	h := &Handler{StartedAt: time.Now()}
+	w := &recordingWriter{}
+	ctx, cancel := context.WithCancel(context.Background())
+	h.StartHeartbeat(ctx, w, 10*time.Millisecond)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for w.count() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if w.count() < 2 {
+		t.Fatalf("%d pings sent", w.count())
+	}
+	w.mu.Lock()
+	var ping struct {
+		Seq int64 `json:"seq"`
+	}
+	json.Unmarshal(w.msgs[1].Payload, &ping)
+	if w.msgs[1].Type != "ping" || ping.Seq != 2 {
+		t.Errorf("second ping = %s %s", w.msgs[1].Type, w.msgs[1].Payload)
+	}
+	w.mu.Unlock()
+
+	// No pings after cancellation
+	time.Sleep(20 * time.Millisecond)
+	n := w.count()
+	time.Sleep(30 * time.Millisecond)
+	if w.count() != n {
+		t.Error("heartbeat kept running after cancel")
+	}
+}
@@ -0,0 +1,141 @@
+package skills
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var skillNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Import installs skills from a git repository (or a local directory) into
+// .ricochet/skills and reloads. A repository with SKILL.md at its root is one
+// skill, named name or after the repository. Otherwise every directory with a
+// SKILL.md, at the root or under skills/, is a skill and name picks one.
+// Installed skills are never overwritten.
+func (m *Manager) Import(source, name string) ([]string, error) {
+	dir := source
+	if info, err := os.Stat(source); err != nil || !info.IsDir() {
+		tmp, err := os.MkdirTemp("", "ricochet-skills-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmp)
+		dir = filepath.Join(tmp, "repo")
+		if out, err := exec.Command("git", "clone", "--depth", "1", "--quiet", "--", source, dir).CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to clone %s: %v: %s", source, err, strings.TrimSpace(string(out)))
+		}
+	}
+
+	found, err := findSkills(dir, source, name)
+	if err != nil {
+		return nil, err
+	}
+
+	destRoot := filepath.Join(m.cwd, ".ricochet", "skills")
+	names := make([]string, 0, len(found))
+	for skill := range found {
+		if !skillNamePattern.MatchString(skill) {
+			return nil, fmt.Errorf("invalid skill name %q", skill)
+		}
+		if _, err := os.Stat(filepath.Join(destRoot, skill)); err == nil {
+			return nil, fmt.Errorf("skill %s is already installed in .ricochet/skills/%s; remove it first", skill, skill)
+		}
+		names = append(names, skill)
+	}
+	sort.Strings(names)
+
+	for _, skill := range names {
+		if err := copySkillDir(found[skill], filepath.Join(destRoot, skill)); err != nil {
+			return nil, fmt.Errorf("failed to install %s: %w", skill, err)
+		}
+	}
+	return names, m.LoadSkills()
+}
+
+// findSkills maps skill names to their directories in a checkout
+func findSkills(dir, source, name string) (map[string]string, error) {
+	found := make(map[string]string)
+	if _, err := os.Stat(filepath.Join(dir, "SKILL.md")); err == nil {
+		if name == "" {
+			name = repoName(source)
+		}
+		found[name] = dir
+		return found, nil
+	}
+
+	for _, base := range []string{dir, filepath.Join(dir, "skills")} {
+		entries, _ := os.ReadDir(base)
+		for _, e := range entries {
+			if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(base, e.Name(), "SKILL.md")); err == nil {
+				found[e.Name()] = filepath.Join(base, e.Name())
+			}
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no SKILL.md found in %s", source)
+	}
+	if name != "" {
+		path, ok := found[name]
+		if !ok {
+			return nil, fmt.Errorf("no skill %s in %s", name, source)
+		}
+		return map[string]string{name: path}, nil
+	}
+	return found, nil
+}
+
+// repoName derives a skill name from a git URL or path: the last path
+// element without ".git"
+func repoName(source string) string {
+	source = strings.TrimSuffix(strings.TrimRight(source, "/"), ".git")
+	if i := strings.LastIndexAny(source, "/:"); i >= 0 {
+		source = source[i+1:]
+	}
+	return source
+}
+
+// copySkillDir copies a skill directory, leaving out .git and symlinks
+func copySkillDir(src, dest string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(dest, rel), 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.Create(filepath.Join(dest, rel))
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
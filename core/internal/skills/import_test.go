@@ -0,0 +1,91 @@
+package skills
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	repo := filepath.Join(t.TempDir(), "team-skills")
+	for name, content := range files {
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "--quiet", "-m", "skills"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Skipf("git %v: %v: %s", args, err, out)
+		}
+	}
+	return repo
+}
+
+func TestImportSkills(t *testing.T) {
+	repo := gitRepo(t, map[string]string{
+		"skills/go-style/SKILL.md": "---\ntriggers:\n  pathPatterns: [\"**/*.go\"]\n---\nUse gofmt.\n",
+		"skills/go-style/extra.md": "More.",
+		"react/SKILL.md":           "Prefer hooks.",
+		"README.md":                "Not a skill.",
+		"skills/not-a-skill/notes": "x",
+	})
+	dir := t.TempDir()
+	m := NewManager(dir)
+
+	installed, err := m.Import("file://"+repo, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(installed, ",") != "go-style,react" {
+		t.Fatalf("installed %v", installed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".ricochet", "skills", "go-style", "extra.md")); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".ricochet", "skills", "go-style", ".git")); err == nil {
+		t.Error(".git was copied")
+	}
+	if got := m.FindApplicableSkills("", []string{filepath.Join(dir, "main.go")}); len(got) != 1 || got[0].Name != "go-style" {
+		t.Errorf("imported skill not loaded: %v", got)
+	}
+
+	if _, err := m.Import("file://"+repo, "react"); err == nil || !strings.Contains(err.Error(), "already installed") {
+		t.Errorf("reinstall: %v", err)
+	}
+	if _, err := m.Import("file://"+repo, "vue"); err == nil || !strings.Contains(err.Error(), "no skill vue") {
+		t.Errorf("missing skill: %v", err)
+	}
+}
+
+func TestImportRootSkill(t *testing.T) {
+	repo := gitRepo(t, map[string]string{"SKILL.md": "Review carefully."})
+	dir := t.TempDir()
+
+	installed, err := NewManager(dir).Import(repo, "")
+	if err != nil || len(installed) != 1 || installed[0] != "team-skills" {
+		t.Fatalf("installed %v, %v", installed, err)
+	}
+}
+
+func TestRepoName(t *testing.T) {
+	for source, want := range map[string]string{
+		"https://github.com/acme/team-skills.git": "team-skills",
+		"git@github.com:acme/review":              "review",
+		"/tmp/skills/":                            "skills",
+	} {
+		if got := repoName(source); got != want {
+			t.Errorf("repoName(%s) = %s, want %s", source, got, want)
+		}
+	}
+}
@@ -32,15 +32,18 @@ type TriggerConfig struct {
 }
 
 type Manager struct {
-	mu     sync.RWMutex
-	cwd    string
-	skills map[string]*SkillRule
+	mu        sync.RWMutex
+	cwd       string
+	skills    map[string]*SkillRule
+	disabled  map[string]bool // Skills turned off in settings, see SetDisabled
+	signature string          // Skill files at the last load, see StartWatcher
 }
 
 func NewManager(cwd string) *Manager {
 	return &Manager{
-		cwd:    cwd,
-		skills: make(map[string]*SkillRule),
+		cwd:      cwd,
+		skills:   make(map[string]*SkillRule),
+		disabled: make(map[string]bool),
 	}
 }
 
@@ -58,14 +61,31 @@ type skillFrontmatter struct {
 	} `yaml:"triggers"`
 }
 
-// LoadSkills loads the skill-rules.json and associated markdown files
+// LoadSkills loads the skill-rules.json and associated markdown files, the
+// embedded skills and the project skills in .ricochet/skills. A broken
+// skill-rules.json is reported, but the other skills still load.
 func (m *Manager) LoadSkills() error {
+	signature := m.filesSignature()
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.signature = signature
+	m.skills = make(map[string]*SkillRule)
+	err := m.loadRuleSkills()
+
+	m.loadEmbeddedSkills()
+
+	// ─── Phase 19: Dynamic Project Skills ───
+	m.loadDynamicSkills()
+
+	return err
+}
+
+func (m *Manager) loadRuleSkills() error {
 	rulesPath := filepath.Join(m.cwd, ".agent", "skills", "skill-rules.json")
 	if _, err := os.Stat(rulesPath); os.IsNotExist(err) {
-		return nil // No skills defined, totally fine
+		return nil // No skill rules defined, totally fine
 	}
 
 	data, err := os.ReadFile(rulesPath)
@@ -78,7 +98,6 @@ func (m *Manager) LoadSkills() error {
 		return fmt.Errorf("parse skill rules: %w", err)
 	}
 
-	m.skills = make(map[string]*SkillRule)
 	for name, rule := range rulesMap {
 		rule.Name = name
 
@@ -94,8 +113,10 @@ func (m *Manager) LoadSkills() error {
 
 		m.skills[name] = rule
 	}
+	return nil
+}
 
-	// Load embedded skills
+func (m *Manager) loadEmbeddedSkills() {
 	for _, skill := range PluginDevSkills() {
 		m.skills[skill.Name] = &SkillRule{
 			Name:           skill.Name,
@@ -106,11 +127,6 @@ func (m *Manager) LoadSkills() error {
 			PromptTriggers: skill.Triggers,
 		}
 	}
-
-	// ─── Phase 19: Dynamic Project Skills ───
-	m.loadDynamicSkills()
-
-	return nil
 }
 
 func (m *Manager) loadDynamicSkills() {
@@ -176,7 +192,7 @@ func (m *Manager) FindApplicableSkills(prompt string, activeFiles []string) []*S
 	seen := make(map[string]bool)
 
 	for _, rule := range m.skills {
-		if seen[rule.Name] {
+		if seen[rule.Name] || m.disabled[rule.Name] {
 			continue
 		}
 
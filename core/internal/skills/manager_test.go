@@ -124,3 +124,57 @@ func TestFindApplicableSkills(t *testing.T) {
 		})
 	}
 }
+
+func writeDynamicSkill(t *testing.T, dir, name, content string) {
+	t.Helper()
+	skillDir := filepath.Join(dir, ".ricochet", "skills", name)
+	if err := os.MkdirAll(skillDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestToggleAndReloadSkills(t *testing.T) {
+	dir := t.TempDir()
+	// No skill-rules.json: project skills still load
+	writeDynamicSkill(t, dir, "sql", "---\nenforcement: suggest\ntriggers:\n  keywords: [migration]\n---\nWrite reversible migrations.\n")
+
+	m := NewManager(dir)
+	if err := m.LoadSkills(); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.FindApplicableSkills("add a migration", nil); len(got) != 1 || got[0].Name != "sql" {
+		t.Fatalf("before disabling: %v", got)
+	}
+
+	m.SetDisabled([]string{"other"})
+	if err := m.SetEnabled("sql", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.FindApplicableSkills("add a migration", nil); len(got) != 0 {
+		t.Errorf("disabled skill activated: %v", got)
+	}
+	if got := m.Disabled(); len(got) != 2 || got[0] != "other" || got[1] != "sql" {
+		t.Errorf("Disabled() = %v", got)
+	}
+	if err := m.SetEnabled("missing", true); err == nil {
+		t.Error("enabling an unknown skill should fail")
+	}
+
+	before := m.filesSignature()
+	writeDynamicSkill(t, dir, "docs", "---\ntriggers:\n  keywords: [readme]\n---\nKeep the README short.\n")
+	if m.filesSignature() == before {
+		t.Fatal("signature did not change when a skill was added")
+	}
+	if err := m.LoadSkills(); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.FindApplicableSkills("update the readme", nil); len(got) != 1 || got[0].Name != "docs" {
+		t.Errorf("after reload: %v", got)
+	}
+	if m.IsEnabled("sql") {
+		t.Error("reload re-enabled a disabled skill")
+	}
+}
@@ -0,0 +1,67 @@
+package skills
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SetDisabled turns off the named skills, as stored in settings
+// (skills.disabled); all others are enabled
+func (m *Manager) SetDisabled(names []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.disabled = make(map[string]bool, len(names))
+	for _, name := range names {
+		m.disabled[name] = true
+	}
+}
+
+// SetEnabled turns a loaded skill on or off
+func (m *Manager) SetEnabled(name string, enabled bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.skills[name]; !ok {
+		return fmt.Errorf("skill %s not found", name)
+	}
+	if enabled {
+		delete(m.disabled, name)
+	} else {
+		m.disabled[name] = true
+	}
+	return nil
+}
+
+// Disabled lists the skills turned off, sorted, including ones not loaded
+func (m *Manager) Disabled() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.disabled))
+	for name := range m.disabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsEnabled reports whether a skill may be activated
+func (m *Manager) IsEnabled(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return !m.disabled[name]
+}
+
+// Skills returns the loaded skills sorted by name
+func (m *Manager) Skills() []SkillRule {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]SkillRule, 0, len(m.skills))
+	for _, rule := range m.skills {
+		list = append(list, *rule)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
@@ -0,0 +1,51 @@
+package skills
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StartWatcher reloads the skills when a file under .agent/skills or
+// .ricochet/skills is added, changed or removed
+func (m *Manager) StartWatcher() {
+	go func() {
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			m.mu.RLock()
+			last := m.signature
+			m.mu.RUnlock()
+
+			if m.filesSignature() == last {
+				continue
+			}
+			if err := m.LoadSkills(); err != nil {
+				log.Printf("Warning: Failed to reload skills: %v", err)
+			} else {
+				log.Printf("🧠 Skills reloaded")
+			}
+		}
+	}()
+}
+
+// filesSignature changes whenever a skill file is added, removed or modified
+func (m *Manager) filesSignature() string {
+	var sig strings.Builder
+	for _, dir := range []string{filepath.Join(m.cwd, ".agent", "skills"), filepath.Join(m.cwd, ".ricochet", "skills")} {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				fmt.Fprintf(&sig, "%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+			}
+			return nil
+		})
+	}
+	return sig.String()
+}
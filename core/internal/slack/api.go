@@ -0,0 +1,134 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// apiResponse is the envelope every Web API method answers with
+type apiResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// call invokes a Web API method with a JSON body and decodes the reply into out
+func (b *Bot) call(ctx context.Context, token, method string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.apiURL+method, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	return b.do(req, method, out)
+}
+
+// callForm invokes a Web API method that only takes form arguments
+func (b *Bot) callForm(ctx context.Context, token, method string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.apiURL+method, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return b.do(req, method, out)
+}
+
+func (b *Bot) do(req *http.Request, method string, out any) error {
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack %s: HTTP %d", method, resp.StatusCode)
+	}
+
+	var status apiResponse
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+	if !status.OK {
+		return fmt.Errorf("slack %s: %s", method, status.Error)
+	}
+	if out != nil {
+		return json.Unmarshal(raw, out)
+	}
+	return nil
+}
+
+// uploadFile shares a local file in a channel using the external upload flow
+func (b *Bot) uploadFile(ctx context.Context, channelID, path, comment string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	name := filepath.Base(path)
+
+	var upload struct {
+		UploadURL string `json:"upload_url"`
+		FileID    string `json:"file_id"`
+	}
+	form := url.Values{"filename": {name}, "length": {strconv.FormatInt(info.Size(), 10)}}
+	if err := b.callForm(ctx, b.botToken, "files.getUploadURLExternal", form, &upload); err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return err
+	}
+	mw.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, upload.UploadURL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack upload: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack upload: HTTP %d", resp.StatusCode)
+	}
+
+	complete := map[string]any{
+		"files":      []map[string]string{{"id": upload.FileID, "title": name}},
+		"channel_id": channelID,
+	}
+	if comment != "" {
+		complete["initial_comment"] = comment
+	}
+	return b.call(ctx, b.botToken, "files.completeUploadExternal", complete, nil)
+}
@@ -0,0 +1,481 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/igoryan-dao/ricochet/internal/discord"
+	"github.com/igoryan-dao/ricochet/internal/format"
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
+	"github.com/igoryan-dao/ricochet/internal/state"
+)
+
+// MaxMessageLength is the length Slack recommends keeping message text under
+const MaxMessageLength = 4000
+
+const defaultAPIURL = "https://slack.com/api/"
+
+// Bot wraps a Slack app connected over Socket Mode with message handling
+type Bot struct {
+	botToken string // xoxb- token for the Web API
+	appToken string // xapp- token for Socket Mode
+	apiURL   string
+	http     *http.Client
+	state    *state.Manager
+	userID   string // The bot's own user, whose messages are ignored
+
+	cancel context.CancelFunc
+	connMu sync.Mutex
+	conn   *websocket.Conn
+
+	// Channel for receiving user responses
+	responseCh chan *UserResponse
+
+	// Active session per channel (channelID -> SessionUUID)
+	activeMu       sync.Mutex
+	activeSessions map[string]string
+
+	// Session specific channels (SessionUUID -> response channel)
+	sessionMu        sync.Mutex
+	sessionResponses map[string]chan string
+
+	// Buffer for messages when no one is listening
+	unreadMu       sync.Mutex
+	unreadMessages map[string][]string
+
+	// Pending AskUser promises (channelID -> response channel)
+	pendingMu sync.Mutex
+	pending   map[string]chan string
+}
+
+// UserResponse represents a message from user
+type UserResponse struct {
+	ChannelID string
+	UserID    string
+	Text      string
+	SessionID string
+}
+
+// New creates a new Slack bot. botToken is the app's bot token (xoxb-),
+// appToken an app-level token (xapp-) with the connections:write scope.
+func New(botToken, appToken string, stateMgr *state.Manager) (*Bot, error) {
+	if !strings.HasPrefix(botToken, "xoxb-") {
+		return nil, fmt.Errorf("slack bot token must start with xoxb-")
+	}
+	if !strings.HasPrefix(appToken, "xapp-") {
+		return nil, fmt.Errorf("slack app token must start with xapp- (Socket Mode needs an app-level token)")
+	}
+
+	b := &Bot{
+		botToken:         botToken,
+		appToken:         appToken,
+		apiURL:           defaultAPIURL,
+		http:             httpclient.Client(30 * time.Second),
+		state:            stateMgr,
+		responseCh:       make(chan *UserResponse, 100),
+		activeSessions:   make(map[string]string),
+		sessionResponses: make(map[string]chan string),
+		unreadMessages:   make(map[string][]string),
+		pending:          make(map[string]chan string),
+	}
+
+	// Load active sessions from state
+	if stateMgr != nil {
+		for channelID, sessionID := range stateMgr.GetSlackActiveSessions() {
+			b.activeSessions[channelID] = sessionID
+		}
+	}
+
+	return b, nil
+}
+
+// Start checks the bot token and connects to Socket Mode in the background
+func (b *Bot) Start() error {
+	log.Println("Starting Slack bot...")
+
+	var auth struct {
+		UserID string `json:"user_id"`
+		User   string `json:"user"`
+		Team   string `json:"team"`
+	}
+	if err := b.call(context.Background(), b.botToken, "auth.test", map[string]any{}, &auth); err != nil {
+		return fmt.Errorf("failed to authenticate Slack bot: %w", err)
+	}
+	b.userID = auth.UserID
+	log.Printf("Slack bot connected as %s in %s", auth.User, auth.Team)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	go b.run(ctx)
+	return nil
+}
+
+// Stop closes connection
+func (b *Bot) Stop() error {
+	log.Println("Stopping Slack bot...")
+	if b.cancel != nil {
+		b.cancel()
+	}
+	b.connMu.Lock()
+	defer b.connMu.Unlock()
+	if b.conn != nil {
+		return b.conn.Close()
+	}
+	return nil
+}
+
+// run keeps a Socket Mode connection open, reconnecting when Slack asks to
+// or the connection drops
+func (b *Bot) run(ctx context.Context) {
+	backoff := time.Second
+	for ctx.Err() == nil {
+		start := time.Now()
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("Slack connection lost: %v", err)
+		}
+
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
+}
+
+// envelope is a Socket Mode message; each with an ID must be acknowledged
+type envelope struct {
+	EnvelopeID string          `json:"envelope_id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// listen opens one Socket Mode connection and handles it until it closes
+func (b *Bot) listen(ctx context.Context) error {
+	var open struct {
+		URL string `json:"url"`
+	}
+	if err := b.call(ctx, b.appToken, "apps.connections.open", map[string]any{}, &open); err != nil {
+		return err
+	}
+
+	conn, _, err := httpclient.WebsocketDialer().DialContext(ctx, open.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Socket Mode: %w", err)
+	}
+	b.connMu.Lock()
+	b.conn = conn
+	b.connMu.Unlock()
+	defer conn.Close()
+
+	for {
+		var env envelope
+		if err := conn.ReadJSON(&env); err != nil {
+			return err
+		}
+		if env.EnvelopeID != "" {
+			if err := conn.WriteJSON(map[string]string{"envelope_id": env.EnvelopeID}); err != nil {
+				return err
+			}
+		}
+
+		switch env.Type {
+		case "hello":
+			log.Println("Slack Socket Mode connected")
+		case "disconnect":
+			return nil // Slack hands out a fresh URL on reconnect
+		case "events_api":
+			b.handleEvent(env.Payload)
+		case "slash_commands":
+			b.handleSlashCommand(env.Payload)
+		}
+	}
+}
+
+// handleEvent processes Events API callbacks
+func (b *Bot) handleEvent(payload json.RawMessage) {
+	var callback struct {
+		Event struct {
+			Type    string `json:"type"`
+			Subtype string `json:"subtype"`
+			BotID   string `json:"bot_id"`
+			User    string `json:"user"`
+			Channel string `json:"channel"`
+			Text    string `json:"text"`
+		} `json:"event"`
+	}
+	if err := json.Unmarshal(payload, &callback); err != nil {
+		log.Printf("Failed to decode Slack event: %v", err)
+		return
+	}
+	ev := callback.Event
+
+	// Ignore edits, joins, and the bot's own messages
+	if (ev.Type != "message" && ev.Type != "app_mention") || ev.Subtype != "" || ev.BotID != "" || ev.User == b.userID {
+		return
+	}
+
+	text := strings.TrimSpace(ev.Text)
+	if b.userID != "" {
+		text = strings.TrimSpace(strings.ReplaceAll(text, "<@"+b.userID+">", ""))
+	}
+	b.handleMessage(ev.Channel, ev.User, text)
+}
+
+// handleMessage routes a user message like the Discord bot does
+func (b *Bot) handleMessage(channelID, userID, text string) {
+	if strings.HasPrefix(text, "!ricochet") {
+		b.handleCommand(channelID, strings.Fields(strings.TrimPrefix(text, "!ricochet")))
+		return
+	}
+
+	// Check if there's a pending promise for this channel (e.g. from AskUser)
+	b.pendingMu.Lock()
+	respCh, ok := b.pending[channelID]
+	if ok {
+		delete(b.pending, channelID)
+		b.pendingMu.Unlock()
+		respCh <- text
+		return
+	}
+	b.pendingMu.Unlock()
+
+	// Route to active session
+	b.activeMu.Lock()
+	sessionID := b.activeSessions[channelID]
+	b.activeMu.Unlock()
+
+	if sessionID != "" {
+		b.SendToSession(sessionID, text)
+		return
+	}
+
+	// Buffer or send to general channel
+	b.responseCh <- &UserResponse{
+		ChannelID: channelID,
+		UserID:    userID,
+		Text:      text,
+	}
+}
+
+// handleSlashCommand processes the /ricochet slash command
+func (b *Bot) handleSlashCommand(payload json.RawMessage) {
+	var cmd struct {
+		Command   string `json:"command"`
+		Text      string `json:"text"`
+		ChannelID string `json:"channel_id"`
+	}
+	if err := json.Unmarshal(payload, &cmd); err != nil {
+		log.Printf("Failed to decode Slack command: %v", err)
+		return
+	}
+	b.handleCommand(cmd.ChannelID, strings.Fields(cmd.Text))
+}
+
+// handleCommand processes bot commands
+func (b *Bot) handleCommand(channelID string, args []string) {
+	ctx := context.Background()
+	if len(args) == 0 {
+		b.SendMessage(ctx, channelID, "📡 **Ricochet Slack** — AI Agent Bridge\n\nCommands:\n• `/ricochet status` — Show active session\n• `/ricochet activate <session>` — Activate a session")
+		return
+	}
+
+	switch args[0] {
+	case "status":
+		sessionID := b.GetActiveSession(channelID)
+		if sessionID == "" {
+			b.SendMessage(ctx, channelID, "📭 No active session in this channel")
+		} else {
+			b.SendMessage(ctx, channelID, fmt.Sprintf("✅ Active session: `%s`", shortID(sessionID)))
+		}
+
+	case "activate":
+		if len(args) < 2 {
+			b.SendMessage(ctx, channelID, "Usage: `/ricochet activate <session_id>`")
+			return
+		}
+		b.SetActiveSession(channelID, args[1])
+		b.SendMessage(ctx, channelID, fmt.Sprintf("📍 Session `%s` activated for this channel", shortID(args[1])))
+
+	default:
+		b.SendMessage(ctx, channelID, "Unknown command. Try `/ricochet` for help.")
+	}
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// SendMessage sends a message to a channel, splitting it over several
+// messages if it exceeds the recommended length
+func (b *Bot) SendMessage(ctx context.Context, channelID string, text string) error {
+	_, err := b.SendMessageAndTrack(ctx, channelID, text)
+	return err
+}
+
+// SendMessageAndTrack sends a message and returns the timestamp Slack
+// identifies it by, for later editing. Long text goes out in several
+// messages; the last one is returned.
+func (b *Bot) SendMessageAndTrack(ctx context.Context, channelID string, text string) (string, error) {
+	var ts string
+	for _, chunk := range discord.SplitMessage(format.ToSlackMrkdwn(text), MaxMessageLength) {
+		var resp struct {
+			TS string `json:"ts"`
+		}
+		if err := b.call(ctx, b.botToken, "chat.postMessage", map[string]any{"channel": channelID, "text": chunk}, &resp); err != nil {
+			return "", err
+		}
+		ts = resp.TS
+	}
+	return ts, nil
+}
+
+// EditMessage edits an existing message by timestamp
+func (b *Bot) EditMessage(ctx context.Context, channelID, messageID, newText string) error {
+	chunks := discord.SplitMessage(format.ToSlackMrkdwn(newText), MaxMessageLength)
+	return b.call(ctx, b.botToken, "chat.update", map[string]any{"channel": channelID, "ts": messageID, "text": chunks[0]}, nil)
+}
+
+// SendPhoto sends an image to a Slack channel
+func (b *Bot) SendPhoto(ctx context.Context, channelID string, photoPath string, caption string) error {
+	return b.uploadFile(ctx, channelID, photoPath, caption)
+}
+
+// SendVoice sends an audio file to a Slack channel
+func (b *Bot) SendVoice(ctx context.Context, channelID string, audioPath string) error {
+	return b.uploadFile(ctx, channelID, audioPath, "")
+}
+
+// SendCodeBlock sends a formatted code block to Slack. Slack does not
+// highlight, so the language is dropped.
+func (b *Bot) SendCodeBlock(ctx context.Context, channelID string, language, code string) error {
+	formatted := fmt.Sprintf("```\n%s\n```", code)
+	for _, chunk := range discord.SplitMessage(formatted, MaxMessageLength) {
+		if err := b.call(ctx, b.botToken, "chat.postMessage", map[string]any{"channel": channelID, "text": chunk}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AskUser sends a question and waits for the next message in the channel
+func (b *Bot) AskUser(ctx context.Context, channelID string, question string) (string, error) {
+	respCh := make(chan string, 1)
+
+	b.pendingMu.Lock()
+	b.pending[channelID] = respCh
+	b.pendingMu.Unlock()
+
+	if err := b.SendMessage(ctx, channelID, question); err != nil {
+		b.pendingMu.Lock()
+		delete(b.pending, channelID)
+		b.pendingMu.Unlock()
+		return "", fmt.Errorf("failed to send question: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		b.pendingMu.Lock()
+		delete(b.pending, channelID)
+		b.pendingMu.Unlock()
+		return "", ctx.Err()
+	case resp := <-respCh:
+		return resp, nil
+	}
+}
+
+// SendToSession routes message to a specific session
+func (b *Bot) SendToSession(sessionID, text string) {
+	b.sessionMu.Lock()
+	ch, ok := b.sessionResponses[sessionID]
+	b.sessionMu.Unlock()
+
+	if ok {
+		select {
+		case ch <- text:
+			log.Printf("Message sent to session %s", sessionID)
+		default:
+			log.Printf("Session %s channel full, buffering", sessionID)
+			b.bufferMessage(sessionID, text)
+		}
+		return
+	}
+
+	b.bufferMessage(sessionID, text)
+}
+
+func (b *Bot) bufferMessage(sessionID, text string) {
+	b.unreadMu.Lock()
+	b.unreadMessages[sessionID] = append(b.unreadMessages[sessionID], text)
+	b.unreadMu.Unlock()
+	log.Printf("Message buffered for session %s", sessionID)
+}
+
+// SetActiveSession sets the active session for a channel
+func (b *Bot) SetActiveSession(channelID, sessionID string) {
+	b.activeMu.Lock()
+	b.activeSessions[channelID] = sessionID
+	b.activeMu.Unlock()
+	log.Printf("Active session for Slack channel %s set to %s", channelID, sessionID)
+
+	if b.state != nil {
+		if err := b.state.SetSlackActiveSession(channelID, sessionID); err != nil {
+			log.Printf("Failed to save Slack session state: %v", err)
+		}
+	}
+}
+
+// GetActiveSession returns the active session for a channel
+func (b *Bot) GetActiveSession(channelID string) string {
+	b.activeMu.Lock()
+	defer b.activeMu.Unlock()
+	return b.activeSessions[channelID]
+}
+
+// RegisterSessionHandler registers a channel for session responses
+func (b *Bot) RegisterSessionHandler(sessionID string, ch chan string) {
+	b.sessionMu.Lock()
+	b.sessionResponses[sessionID] = ch
+	b.sessionMu.Unlock()
+}
+
+// UnregisterSessionHandler removes session handler
+func (b *Bot) UnregisterSessionHandler(sessionID string) {
+	b.sessionMu.Lock()
+	delete(b.sessionResponses, sessionID)
+	b.sessionMu.Unlock()
+}
+
+// GetUnreadMessages returns and clears buffered messages
+func (b *Bot) GetUnreadMessages(sessionID string) []string {
+	b.unreadMu.Lock()
+	defer b.unreadMu.Unlock()
+	msgs := b.unreadMessages[sessionID]
+	delete(b.unreadMessages, sessionID)
+	return msgs
+}
+
+// GetResponseChannel returns the general response channel
+func (b *Bot) GetResponseChannel() <-chan *UserResponse {
+	return b.responseCh
+}
+
+// SendTyping is a no-op: Slack has no typing indicator for bots
+func (b *Bot) SendTyping(ctx context.Context, channelID string) {}
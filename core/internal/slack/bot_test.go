@@ -0,0 +1,111 @@
+package slack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeSlack serves the Web API methods the bot uses and a Socket Mode
+// endpoint that delivers one message event
+func fakeSlack(t *testing.T) (*httptest.Server, *sync.Map, chan string) {
+	var posted sync.Map
+	acks := make(chan string, 1)
+	var ts *httptest.Server
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/auth.test", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "user_id": "UBOT", "user": "ricochet", "team": "acme"})
+	})
+	mux.HandleFunc("/api/apps.connections.open", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xapp-test" {
+			json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "invalid_auth"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "url": "ws" + strings.TrimPrefix(ts.URL, "http") + "/socket"})
+	})
+	mux.HandleFunc("/api/chat.postMessage", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		posted.Store(body["channel"], body["text"])
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "ts": "1.000"})
+	})
+	mux.HandleFunc("/socket", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.WriteJSON(map[string]any{"type": "hello"})
+		conn.WriteJSON(map[string]any{
+			"envelope_id": "env-1",
+			"type":        "events_api",
+			"payload": map[string]any{"event": map[string]any{
+				"type": "message", "channel": "C1", "user": "U1", "text": "<@UBOT> run the tests",
+			}},
+		})
+		var ack map[string]string
+		if conn.ReadJSON(&ack) == nil {
+			acks <- ack["envelope_id"]
+		}
+		conn.ReadMessage() // Hold the connection until the bot stops
+	})
+	ts = httptest.NewServer(mux)
+	return ts, &posted, acks
+}
+
+func TestSocketModeRouting(t *testing.T) {
+	ts, posted, acks := fakeSlack(t)
+	defer ts.Close()
+
+	b, err := New("xoxb-test", "xapp-test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.apiURL = ts.URL + "/api/"
+	b.SetActiveSession("C1", "session-1")
+
+	respCh := make(chan string, 1)
+	b.RegisterSessionHandler("session-1", respCh)
+
+	if err := b.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Stop()
+
+	select {
+	case id := <-acks:
+		if id != "env-1" {
+			t.Errorf("acked %q", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("envelope not acknowledged")
+	}
+	select {
+	case text := <-respCh:
+		if text != "run the tests" {
+			t.Errorf("session got %q", text)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not routed to session")
+	}
+
+	if err := b.SendMessage(t.Context(), "C1", "**Done** <3, see [logs](https://ci.example.com/1)"); err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := posted.Load("C1"); text != "*Done* &lt;3, see <https://ci.example.com/1|logs>" {
+		t.Errorf("posted %q", text)
+	}
+}
+
+func TestNewRejectsWrongTokens(t *testing.T) {
+	if _, err := New("xapp-1", "xoxb-1", nil); err == nil {
+		t.Error("swapped tokens accepted")
+	}
+}
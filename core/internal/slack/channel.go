@@ -0,0 +1,97 @@
+package slack
+
+import (
+	"context"
+	"sort"
+
+	"github.com/igoryan-dao/ricochet/internal/messenger"
+)
+
+var _ messenger.Channel = (*Bot)(nil)
+
+// Name implements messenger.Channel
+func (b *Bot) Name() string { return "Slack" }
+
+// Targets returns the channels the session is active in
+func (b *Bot) Targets(sessionID string) []string {
+	if sessionID == "" {
+		return nil
+	}
+	b.activeMu.Lock()
+	defer b.activeMu.Unlock()
+	var targets []string
+	for channelID, active := range b.activeSessions {
+		if active == sessionID {
+			targets = append(targets, channelID)
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// Send implements messenger.Channel
+func (b *Bot) Send(ctx context.Context, target, text string) error {
+	return b.SendMessage(ctx, target, text)
+}
+
+// SendRich implements messenger.Channel. There are no buttons here: the
+// text has to say how to answer.
+func (b *Bot) SendRich(ctx context.Context, target string, msg messenger.Message) error {
+	if msg.Text != "" {
+		if err := b.SendMessage(ctx, target, msg.Text); err != nil {
+			return err
+		}
+	}
+	if msg.Diff != "" {
+		if err := b.SendCodeBlock(ctx, target, "diff", msg.Diff); err != nil {
+			return err
+		}
+	}
+	if msg.Code != "" {
+		if err := b.SendCodeBlock(ctx, target, msg.Language, msg.Code); err != nil {
+			return err
+		}
+	}
+	if msg.ImagePath != "" {
+		if err := b.SendPhoto(ctx, target, msg.ImagePath, msg.Caption); err != nil {
+			return err
+		}
+	}
+	if msg.VoicePath != "" {
+		if err := b.SendVoice(ctx, target, msg.VoicePath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ask implements messenger.Channel; buttons are dropped
+func (b *Bot) Ask(ctx context.Context, target, question string, _ [][]messenger.Button) (string, error) {
+	return b.AskUser(ctx, target, question)
+}
+
+// Presence implements messenger.Channel
+func (b *Bot) Presence(ctx context.Context, target string) {
+	b.SendTyping(ctx, target)
+}
+
+// RegisterSession implements messenger.Channel
+func (b *Bot) RegisterSession(sessionID string, ch chan string) func() {
+	b.RegisterSessionHandler(sessionID, ch)
+	return func() { b.UnregisterSessionHandler(sessionID) }
+}
+
+// Unread implements messenger.Channel
+func (b *Bot) Unread(sessionID string) []string {
+	return b.GetUnreadMessages(sessionID)
+}
+
+// Activate implements messenger.Channel
+func (b *Bot) Activate(target, sessionID string) {
+	b.SetActiveSession(target, sessionID)
+}
+
+// ActiveSession implements messenger.Channel
+func (b *Bot) ActiveSession(target string) string {
+	return b.GetActiveSession(target)
+}
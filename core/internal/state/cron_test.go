@@ -0,0 +1,78 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2025, 1, 15, 10, 7, 30, 0, time.UTC)
+	cases := map[string]time.Time{
+		"*/15 * * * *":     time.Date(2025, 1, 15, 10, 15, 0, 0, time.UTC),
+		"0 9 * * 1-5":      time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC),
+		"30 18 * * 0":      time.Date(2025, 1, 19, 18, 30, 0, 0, time.UTC),
+		"30 18 * * 7":      time.Date(2025, 1, 19, 18, 30, 0, 0, time.UTC),
+		"0 0 1 * *":        time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		"@daily":           time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC),
+		"0 12 29 2 *":      time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC),
+		"0 8 1 * 5":        time.Date(2025, 1, 17, 8, 0, 0, 0, time.UTC), // Either day field matches
+		"5,10/20 10 * * *": time.Date(2025, 1, 15, 10, 10, 0, 0, time.UTC),
+	}
+	for expr, want := range cases {
+		c, err := ParseCron(expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", expr, err)
+			continue
+		}
+		if got := c.Next(from); !got.Equal(want) {
+			t.Errorf("Next(%q) = %v, want %v", expr, got, want)
+		}
+	}
+
+	if c, _ := ParseCron("0 0 30 2 *"); !c.Next(from).IsZero() {
+		t.Error("February 30th should never fire")
+	}
+	for _, bad := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseCron(bad); err == nil {
+			t.Errorf("ParseCron(%q) accepted", bad)
+		}
+	}
+}
+
+func TestTakeDueNotifications(t *testing.T) {
+	m := &Manager{path: filepath.Join(t.TempDir(), "state.json")}
+	if _, err := m.ScheduleNotification("", "once", "", time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	hourly, err := m.ScheduleNotification("s1", "hourly", "@hourly", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if due := m.TakeDueNotifications(time.Now()); len(due) != 0 {
+		t.Fatalf("nothing should be due yet: %+v", due)
+	}
+	later := hourly.NextAt.Add(3 * time.Hour) // Missed runs fire once
+	due := m.TakeDueNotifications(later)
+	if len(due) != 2 {
+		t.Fatalf("want both due, got %+v", due)
+	}
+
+	left := m.GetNotifications()
+	if len(left) != 1 || left[0].ID != hourly.ID || !left[0].NextAt.After(later) {
+		t.Errorf("want only the hourly one rescheduled, got %+v", left)
+	}
+
+	reloaded := &Manager{path: m.path}
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.GetNotifications()) != 1 {
+		t.Error("schedule not persisted")
+	}
+	if ok, _ := reloaded.CancelNotification(hourly.ID); !ok || len(reloaded.GetNotifications()) != 0 {
+		t.Error("cancel failed")
+	}
+}
@@ -2,8 +2,11 @@ package state
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,10 +15,50 @@ import (
 
 // State represents the persisted application state
 type State struct {
-	ActiveSessions        map[int64]string     `json:"active_sessions"`
-	DiscordActiveSessions map[string]string    `json:"discord_active_sessions"`
-	PrimaryChatID         int64                `json:"primary_chat_id"`
-	LastSeen              map[string]time.Time `json:"last_seen"`
+	ActiveSessions        map[int64]string       `json:"active_sessions"`
+	DiscordActiveSessions map[string]string      `json:"discord_active_sessions"`
+	SlackActiveSessions   map[string]string      `json:"slack_active_sessions,omitempty"`
+	PrimaryChatID         int64                  `json:"primary_chat_id"`
+	LastSeen              map[string]time.Time   `json:"last_seen"`
+	Recipients            map[string][]Recipient `json:"recipients,omitempty"`              // Per-session Telegram delivery targets
+	DefaultRecipients     []Recipient            `json:"default_recipients,omitempty"`      // For sessions without their own
+	Notifications         []Notification         `json:"notifications,omitempty"`           // Scheduled by schedule_notification
+	ForumChatID           int64                  `json:"forum_chat_id,omitempty"`           // Supergroup where each session gets a topic
+	SessionTopics         map[string]Recipient   `json:"session_topics,omitempty"`          // Topic created for each session
+	Team                  map[int64]Role         `json:"team,omitempty"`                    // Telegram user ID -> role
+	DiscordThreadsChannel string                 `json:"discord_threads_channel,omitempty"` // Discord channel where each session gets a thread
+	DiscordSessionThreads map[string]string      `json:"discord_session_threads,omitempty"` // Thread created for each session
+	DiscordVoice          *VoiceChannel          `json:"discord_voice,omitempty"`           // Where voice replies are played aloud
+}
+
+// Recipient is a Telegram chat, optionally narrowed to a forum topic
+type Recipient struct {
+	ChatID   int64 `json:"chat_id"`
+	ThreadID int   `json:"thread_id,omitempty"` // message_thread_id of the topic; 0 for the main chat
+}
+
+// String formats the recipient as "chat" or "chat:topic"
+func (r Recipient) String() string {
+	if r.ThreadID == 0 {
+		return strconv.FormatInt(r.ChatID, 10)
+	}
+	return fmt.Sprintf("%d:%d", r.ChatID, r.ThreadID)
+}
+
+// ParseRecipient reads a recipient formatted as "chat" or "chat:topic"
+func ParseRecipient(s string) (Recipient, error) {
+	chat, topic, hasTopic := strings.Cut(strings.TrimSpace(s), ":")
+	var r Recipient
+	var err error
+	if r.ChatID, err = strconv.ParseInt(strings.TrimSpace(chat), 10, 64); err != nil || r.ChatID == 0 {
+		return Recipient{}, fmt.Errorf("invalid chat ID %q", chat)
+	}
+	if hasTopic {
+		if r.ThreadID, err = strconv.Atoi(strings.TrimSpace(topic)); err != nil || r.ThreadID <= 0 {
+			return Recipient{}, fmt.Errorf("invalid topic ID %q", topic)
+		}
+	}
+	return r, nil
 }
 
 // Manager handles state persistence
@@ -37,7 +80,9 @@ func NewManager() (*Manager, error) {
 		data: State{
 			ActiveSessions:        make(map[int64]string),
 			DiscordActiveSessions: make(map[string]string),
+			SlackActiveSessions:   make(map[string]string),
 			LastSeen:              make(map[string]time.Time),
+			Recipients:            make(map[string][]Recipient),
 		},
 	}
 
@@ -73,9 +118,15 @@ func (m *Manager) Load() error {
 	if m.data.DiscordActiveSessions == nil {
 		m.data.DiscordActiveSessions = make(map[string]string)
 	}
+	if m.data.SlackActiveSessions == nil {
+		m.data.SlackActiveSessions = make(map[string]string)
+	}
 	if m.data.LastSeen == nil {
 		m.data.LastSeen = make(map[string]time.Time)
 	}
+	if m.data.Recipients == nil {
+		m.data.Recipients = make(map[string][]Recipient)
+	}
 	return nil
 }
 
@@ -132,6 +183,29 @@ func (m *Manager) SetDiscordActiveSession(channelID string, sessionID string) er
 	return m.Save()
 }
 
+// GetSlackActiveSessions returns the active Slack sessions map
+func (m *Manager) GetSlackActiveSessions() map[string]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	copy := make(map[string]string)
+	for k, v := range m.data.SlackActiveSessions {
+		copy[k] = v
+	}
+	return copy
+}
+
+// SetSlackActiveSession updates an active Slack session
+func (m *Manager) SetSlackActiveSession(channelID string, sessionID string) error {
+	m.mu.Lock()
+	if m.data.SlackActiveSessions == nil {
+		m.data.SlackActiveSessions = make(map[string]string)
+	}
+	m.data.SlackActiveSessions[channelID] = sessionID
+	m.mu.Unlock()
+	return m.Save()
+}
+
 // GetPrimaryChatID returns the stored primary chat ID
 func (m *Manager) GetPrimaryChatID() int64 {
 	m.mu.Lock()
@@ -147,6 +221,44 @@ func (m *Manager) SetPrimaryChatID(chatID int64) error {
 	return m.Save()
 }
 
+// GetRecipients returns where messages for a session go: its own recipients,
+// else its forum topic, else the defaults, else the primary chat
+func (m *Manager) GetRecipients(sessionID string) []Recipient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := m.data.Recipients[sessionID]
+	if topic, ok := m.data.SessionTopics[sessionID]; ok && len(list) == 0 {
+		return []Recipient{topic}
+	}
+	if len(list) == 0 {
+		list = m.data.DefaultRecipients
+	}
+	if len(list) == 0 {
+		if m.data.PrimaryChatID == 0 {
+			return nil
+		}
+		return []Recipient{{ChatID: m.data.PrimaryChatID}}
+	}
+	return append([]Recipient(nil), list...)
+}
+
+// SetRecipients sets the recipients of a session, or the defaults when
+// sessionID is empty. An empty list reverts to the fallback.
+func (m *Manager) SetRecipients(sessionID string, recipients []Recipient) error {
+	m.mu.Lock()
+	switch {
+	case sessionID == "":
+		m.data.DefaultRecipients = recipients
+	case len(recipients) == 0:
+		delete(m.data.Recipients, sessionID)
+	default:
+		m.data.Recipients[sessionID] = recipients
+	}
+	m.mu.Unlock()
+	return m.Save()
+}
+
 // UpdateHeartbeat marks a session as alive
 func (m *Manager) UpdateHeartbeat(sessionID string) error {
 	m.mu.Lock()
@@ -0,0 +1,106 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification is a message delivered at a set time, or repeatedly on a
+// cron schedule
+type Notification struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"session_id,omitempty"` // Routes delivery like the session's own notifications
+	Message   string    `json:"message"`
+	Cron      string    `json:"cron,omitempty"` // Empty for one-off notifications
+	NextAt    time.Time `json:"next_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ScheduleNotification stores a notification. A cron expression makes it
+// repeat; otherwise it fires once at at.
+func (m *Manager) ScheduleNotification(sessionID, message, cron string, at time.Time) (Notification, error) {
+	now := time.Now()
+	n := Notification{
+		ID:        uuid.New().String()[:8],
+		SessionID: sessionID,
+		Message:   message,
+		Cron:      cron,
+		NextAt:    at,
+		CreatedAt: now,
+	}
+	if cron != "" {
+		schedule, err := ParseCron(cron)
+		if err != nil {
+			return Notification{}, err
+		}
+		if n.NextAt = schedule.Next(now); n.NextAt.IsZero() {
+			return Notification{}, fmt.Errorf("cron expression %q never fires", cron)
+		}
+	}
+
+	m.mu.Lock()
+	m.data.Notifications = append(m.data.Notifications, n)
+	m.mu.Unlock()
+	return n, m.Save()
+}
+
+// CancelNotification removes a scheduled notification
+func (m *Manager) CancelNotification(id string) (bool, error) {
+	m.mu.Lock()
+	found := false
+	for i, n := range m.data.Notifications {
+		if n.ID == id {
+			m.data.Notifications = append(m.data.Notifications[:i], m.data.Notifications[i+1:]...)
+			found = true
+			break
+		}
+	}
+	m.mu.Unlock()
+	if !found {
+		return false, nil
+	}
+	return true, m.Save()
+}
+
+// GetNotifications returns the scheduled notifications, soonest first
+func (m *Manager) GetNotifications() []Notification {
+	m.mu.Lock()
+	list := append([]Notification(nil), m.data.Notifications...)
+	m.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].NextAt.Before(list[j].NextAt) })
+	return list
+}
+
+// TakeDueNotifications returns the notifications due at now. One-off ones
+// are removed and repeating ones move to their next run; runs missed while
+// nothing was running are delivered once, not once per missed run.
+func (m *Manager) TakeDueNotifications(now time.Time) []Notification {
+	m.mu.Lock()
+	var due, kept []Notification
+	for _, n := range m.data.Notifications {
+		if n.NextAt.After(now) {
+			kept = append(kept, n)
+			continue
+		}
+		due = append(due, n)
+		if n.Cron == "" {
+			continue
+		}
+		if schedule, err := ParseCron(n.Cron); err == nil {
+			if n.NextAt = schedule.Next(now); !n.NextAt.IsZero() {
+				kept = append(kept, n)
+			}
+		}
+	}
+	m.data.Notifications = kept
+	m.mu.Unlock()
+
+	if len(due) > 0 {
+		m.Save()
+	}
+	return due
+}
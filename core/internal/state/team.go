@@ -0,0 +1,83 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Role is what a team member may do with the bot
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"   // Receives notifications only
+	RoleOperator Role = "operator" // Also chats with sessions and answers questions
+	RoleOwner    Role = "owner"    // Also approves dangerous commands and manages the team
+)
+
+var roleRank = map[Role]int{RoleViewer: 1, RoleOperator: 2, RoleOwner: 3}
+
+// ParseRole reads a role name
+func ParseRole(s string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := roleRank[role]; !ok {
+		return "", fmt.Errorf("unknown role %q (owner, operator or viewer)", s)
+	}
+	return role, nil
+}
+
+// Can reports whether the role grants at least the rights of min
+func (r Role) Can(min Role) bool {
+	return roleRank[r] >= roleRank[min]
+}
+
+// Member is a team member with their role
+type Member struct {
+	UserID int64
+	Role   Role
+}
+
+// GetRole returns the role of a team member
+func (m *Manager) GetRole(userID int64) (Role, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	role, ok := m.data.Team[userID]
+	return role, ok
+}
+
+// SetRole adds a user to the team or changes their role
+func (m *Manager) SetRole(userID int64, role Role) error {
+	m.mu.Lock()
+	if m.data.Team == nil {
+		m.data.Team = make(map[int64]Role)
+	}
+	m.data.Team[userID] = role
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// RemoveMember removes a user from the team
+func (m *Manager) RemoveMember(userID int64) error {
+	m.mu.Lock()
+	delete(m.data.Team, userID)
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// GetTeam returns the team members, owners first, then by user ID
+func (m *Manager) GetTeam() []Member {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	members := make([]Member, 0, len(m.data.Team))
+	for id, role := range m.data.Team {
+		members = append(members, Member{UserID: id, Role: role})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Role != members[j].Role {
+			return roleRank[members[i].Role] > roleRank[members[j].Role]
+		}
+		return members[i].UserID < members[j].UserID
+	})
+	return members
+}
@@ -0,0 +1,54 @@
+package state
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTeam(t *testing.T) {
+	m := &Manager{path: filepath.Join(t.TempDir(), "state.json")}
+
+	if _, ok := m.GetRole(1); ok {
+		t.Error("GetRole on an empty team found a member")
+	}
+	for id, role := range map[int64]Role{3: RoleViewer, 2: RoleOwner, 1: RoleOperator, 4: RoleOwner} {
+		if err := m.SetRole(id, role); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if role, ok := m.GetRole(1); !ok || role != RoleOperator {
+		t.Errorf("GetRole(1) = %q, %v, want operator", role, ok)
+	}
+
+	want := []Member{{2, RoleOwner}, {4, RoleOwner}, {1, RoleOperator}, {3, RoleViewer}}
+	if got := m.GetTeam(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTeam() = %v, want %v", got, want)
+	}
+
+	if err := m.RemoveMember(4); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.GetRole(4); ok {
+		t.Error("removed member still has a role")
+	}
+}
+
+func TestRole(t *testing.T) {
+	if role, err := ParseRole(" Operator "); err != nil || role != RoleOperator {
+		t.Errorf("ParseRole = %q, %v", role, err)
+	}
+	if _, err := ParseRole("admin"); err == nil {
+		t.Error("ParseRole(admin) succeeded")
+	}
+
+	if !RoleOwner.Can(RoleOperator) || !RoleOperator.Can(RoleOperator) {
+		t.Error("owner and operator should have operator rights")
+	}
+	if RoleViewer.Can(RoleOperator) || RoleOperator.Can(RoleOwner) {
+		t.Error("rights granted above the role")
+	}
+	if Role("").Can(RoleViewer) {
+		t.Error("no role should have no rights")
+	}
+}
@@ -0,0 +1,40 @@
+package state
+
+// GetDiscordThreadsChannel returns the Discord channel where sessions get
+// their own threads, or "" if threads are off
+func (m *Manager) GetDiscordThreadsChannel() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.data.DiscordThreadsChannel
+}
+
+// SetDiscordThreadsChannel turns threads on in a channel, or off with "".
+// Threads started in another channel are forgotten.
+func (m *Manager) SetDiscordThreadsChannel(channelID string) error {
+	m.mu.Lock()
+	if m.data.DiscordThreadsChannel != channelID {
+		m.data.DiscordSessionThreads = nil
+	}
+	m.data.DiscordThreadsChannel = channelID
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// GetSessionThread returns the Discord thread of a session
+func (m *Manager) GetSessionThread(sessionID string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	threadID, ok := m.data.DiscordSessionThreads[sessionID]
+	return threadID, ok
+}
+
+// SetSessionThread records the Discord thread started for a session
+func (m *Manager) SetSessionThread(sessionID, threadID string) error {
+	m.mu.Lock()
+	if m.data.DiscordSessionThreads == nil {
+		m.data.DiscordSessionThreads = make(map[string]string)
+	}
+	m.data.DiscordSessionThreads[sessionID] = threadID
+	m.mu.Unlock()
+	return m.Save()
+}
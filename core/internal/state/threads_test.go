@@ -0,0 +1,34 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSessionThreads(t *testing.T) {
+	m := &Manager{path: filepath.Join(t.TempDir(), "state.json")}
+
+	if err := m.SetDiscordThreadsChannel("c1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetSessionThread("s1", "t1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := m.GetSessionThread("s1"); !ok || got != "t1" {
+		t.Errorf("GetSessionThread = %q, %v, want t1", got, ok)
+	}
+
+	// Turning threads on again in the same channel keeps them
+	m.SetDiscordThreadsChannel("c1")
+	if _, ok := m.GetSessionThread("s1"); !ok {
+		t.Error("thread forgotten when the channel did not change")
+	}
+
+	m.SetDiscordThreadsChannel("")
+	if _, ok := m.GetSessionThread("s1"); ok {
+		t.Error("thread kept after threads were turned off")
+	}
+	if got := m.GetDiscordThreadsChannel(); got != "" {
+		t.Errorf("GetDiscordThreadsChannel = %q, want off", got)
+	}
+}
@@ -0,0 +1,83 @@
+package state
+
+import "sort"
+
+// GetForumChatID returns the supergroup where sessions get their own topics,
+// or 0 if topics are off
+func (m *Manager) GetForumChatID() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.data.ForumChatID
+}
+
+// SetForumChatID turns topics on in a forum supergroup, or off with 0.
+// Topics created in another chat are forgotten.
+func (m *Manager) SetForumChatID(chatID int64) error {
+	m.mu.Lock()
+	m.data.ForumChatID = chatID
+	for sessionID, topic := range m.data.SessionTopics {
+		if topic.ChatID != chatID {
+			delete(m.data.SessionTopics, sessionID)
+		}
+	}
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// GetSessionTopic returns the topic of a session
+func (m *Manager) GetSessionTopic(sessionID string) (Recipient, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	topic, ok := m.data.SessionTopics[sessionID]
+	return topic, ok
+}
+
+// SetSessionTopic records the topic created for a session
+func (m *Manager) SetSessionTopic(sessionID string, topic Recipient) error {
+	m.mu.Lock()
+	if m.data.SessionTopics == nil {
+		m.data.SessionTopics = make(map[string]Recipient)
+	}
+	m.data.SessionTopics[sessionID] = topic
+	m.mu.Unlock()
+	return m.Save()
+}
+
+// SessionForTopic returns the session a topic belongs to, if any
+func (m *Manager) SessionForTopic(chatID int64, threadID int) string {
+	if threadID == 0 {
+		return ""
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for sessionID, topic := range m.data.SessionTopics {
+		if topic.ChatID == chatID && topic.ThreadID == threadID {
+			return sessionID
+		}
+	}
+	return ""
+}
+
+// SessionTopic is a session with the topic it is answered in
+type SessionTopic struct {
+	SessionID string
+	Topic     Recipient
+}
+
+// GetSessionTopics lists the session topics, most recently active first
+func (m *Manager) GetSessionTopics() []SessionTopic {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]SessionTopic, 0, len(m.data.SessionTopics))
+	for sessionID, topic := range m.data.SessionTopics {
+		list = append(list, SessionTopic{SessionID: sessionID, Topic: topic})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := m.data.LastSeen[list[i].SessionID], m.data.LastSeen[list[j].SessionID]
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return list[i].SessionID < list[j].SessionID
+	})
+	return list
+}
@@ -0,0 +1,56 @@
+package state
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSessionTopics(t *testing.T) {
+	m := &Manager{path: filepath.Join(t.TempDir(), "state.json")}
+	m.data.PrimaryChatID = 7
+
+	if err := m.SetForumChatID(-1001); err != nil {
+		t.Fatal(err)
+	}
+	topic := Recipient{ChatID: -1001, ThreadID: 42}
+	if err := m.SetSessionTopic("s1", topic); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := m.SessionForTopic(-1001, 42); got != "s1" {
+		t.Errorf("SessionForTopic = %q, want s1", got)
+	}
+	if got := m.SessionForTopic(-1001, 0); got != "" {
+		t.Errorf("SessionForTopic(general) = %q, want none", got)
+	}
+
+	// The topic replaces the primary chat, but not the session's own recipients
+	if got := m.GetRecipients("s1"); !reflect.DeepEqual(got, []Recipient{topic}) {
+		t.Errorf("GetRecipients(s1) = %v, want the topic", got)
+	}
+	if got := m.GetRecipients("s2"); !reflect.DeepEqual(got, []Recipient{{ChatID: 7}}) {
+		t.Errorf("GetRecipients(s2) = %v, want the primary chat", got)
+	}
+	m.data.Recipients = map[string][]Recipient{"s1": {{ChatID: 9}}}
+	if got := m.GetRecipients("s1"); !reflect.DeepEqual(got, []Recipient{{ChatID: 9}}) {
+		t.Errorf("GetRecipients(s1) = %v, want its own recipients", got)
+	}
+
+	// Moving the forum forgets topics of the old one
+	if err := m.SetForumChatID(-1002); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.GetSessionTopic("s1"); ok {
+		t.Error("topic in the old forum kept")
+	}
+}
+
+func TestRecipientString(t *testing.T) {
+	for _, r := range []Recipient{{ChatID: 123}, {ChatID: -1001234567890, ThreadID: 42}} {
+		got, err := ParseRecipient(r.String())
+		if err != nil || got != r {
+			t.Errorf("ParseRecipient(%q) = %+v, %v", r.String(), got, err)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package state
+
+// VoiceChannel is a Discord voice channel and the guild it belongs to
+type VoiceChannel struct {
+	GuildID   string `json:"guild_id"`
+	ChannelID string `json:"channel_id"`
+}
+
+// GetDiscordVoice returns the voice channel voice replies are played in
+func (m *Manager) GetDiscordVoice() (VoiceChannel, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.data.DiscordVoice == nil {
+		return VoiceChannel{}, false
+	}
+	return *m.data.DiscordVoice, true
+}
+
+// SetDiscordVoice sets the voice channel voice replies are played in, or
+// turns playback off with nil
+func (m *Manager) SetDiscordVoice(vc *VoiceChannel) error {
+	m.mu.Lock()
+	m.data.DiscordVoice = vc
+	m.mu.Unlock()
+	return m.Save()
+}
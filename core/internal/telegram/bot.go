@@ -20,6 +20,9 @@ import (
 	"github.com/igoryan-dao/ricochet/internal/bridge"
 	"github.com/igoryan-dao/ricochet/internal/bridge/proto"
 	"github.com/igoryan-dao/ricochet/internal/format"
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
+	"github.com/igoryan-dao/ricochet/internal/messenger"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
 	"github.com/igoryan-dao/ricochet/internal/state"
 	"github.com/igoryan-dao/ricochet/internal/whisper"
 )
@@ -30,6 +33,9 @@ const (
 	CallbackNewChat     = "new_chat"
 )
 
+// telegramPollTimeout matches the library's default long-poll timeout
+const telegramPollTimeout = time.Minute
+
 // Global lock to prevent multiple bots in the same process
 var (
 	processLock   sync.Mutex
@@ -54,9 +60,11 @@ type Bot struct {
 	activeMu       sync.Mutex
 	activeSessions map[int64]string
 
-	// Pending questions awaiting answers (chatID -> response channel)
+	// Pending questions awaiting answers (chatID -> response channel);
+	// approvals among them can only be answered by owners
 	pendingMu sync.Mutex
 	pending   map[int64]chan string
+	approvals map[int64]bool
 
 	// Session specific channels (SessionUUID -> response channel)
 	sessionMu        sync.Mutex
@@ -66,9 +74,24 @@ type Bot struct {
 	unreadMu       sync.Mutex
 	unreadMessages map[string][]string
 
+	// Diff last previewed per chat, sent in full on "View full"
+	diffMu sync.Mutex
+	diffs  map[int64]sharedDiff
+
+	// Forum topics: creation is serialized, named by topicNamer
+	topicMu    sync.Mutex
+	topicNamer func(sessionID string) string
+
 	// Whisper transcriber
 	transcriber *whisper.Transcriber
 
+	// Stores uploaded files for the session; nil keeps them in the tmp dir
+	fileHandler FileHandler
+
+	// Masks secrets in outgoing text; nil uses the built-in patterns
+	redactMu sync.Mutex
+	redactor func(string) string
+
 	// Cloud Bridge client (optional)
 	bridgeClient *bridge.Client
 
@@ -110,12 +133,15 @@ func New(token string, allowedIDs []int64, stateMgr *state.Manager) (*Bot, error
 		callbackCh:       make(chan *CallbackEvent, 100),
 		activeSessions:   stateMgr.GetActiveSessions(),
 		pending:          make(map[int64]chan string),
+		approvals:        make(map[int64]bool),
 		sessionResponses: make(map[string]chan string),
 		unreadMessages:   make(map[string][]string),
+		diffs:            make(map[int64]sharedDiff),
 	}
 
 	opts := []bot.Option{
 		bot.WithDefaultHandler(b.handleUpdate),
+		bot.WithHTTPClient(telegramPollTimeout, httpclient.Client(telegramPollTimeout)),
 	}
 
 	if token != "" {
@@ -249,6 +275,14 @@ func (b *Bot) Start(ctx context.Context) {
 			{Command: "start", Description: "🚀 Activate Ricochet"},
 			{Command: "new", Description: "🆕 New Session"},
 			{Command: "sessions", Description: "📚 List Sessions"},
+			{Command: "status", Description: "📊 Plan & Context Usage"},
+			{Command: "diff", Description: "🧾 Working Tree Diff"},
+			{Command: "abort", Description: "🛑 Cancel Running Turn"},
+			{Command: "panic", Description: "🚨 Emergency Stop Everything"},
+			{Command: "resume", Description: "✅ Lift the Emergency Stop"},
+			{Command: "logs", Description: "📜 Recent Core Logs"},
+			{Command: "topics", Description: "🧵 A Topic per Session"},
+			{Command: "team", Description: "👥 Team & Roles"},
 			{Command: "stop", Description: "🛑 Stop Live Mode"},
 		},
 	})
@@ -274,6 +308,8 @@ func (b *Bot) handleUpdate(ctx context.Context, tgBot *bot.Bot, update *models.U
 	if update.Message != nil {
 		if update.Message.Voice != nil {
 			b.handleVoice(ctx, tgBot, update.Message)
+		} else if isFileMessage(update.Message) {
+			b.handleFile(ctx, tgBot, update.Message)
 		} else {
 			b.handleMessage(ctx, tgBot, update.Message)
 		}
@@ -286,18 +322,40 @@ func (b *Bot) handleCallback(ctx context.Context, tgBot *bot.Bot, callback *mode
 	userID := callback.From.ID
 
 	// Check if chat or user is allowed
-	if len(b.allowedUserIDs) > 0 && !b.allowedUserIDs[userID] && !b.allowedUserIDs[chatID] {
+	role, allowed := b.roleOf(userID, chatID)
+	if !allowed {
 		log.Printf("Unauthorized callback from user %d in chat %d", userID, chatID)
 		return
 	}
 
+	log.Printf("Callback received: %s from chat %d", callback.Data, chatID)
+
+	// Viewing a diff is not an answer: the question stays open
+	if callback.Data == CallbackViewDiff {
+		tgBot.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: callback.ID})
+		b.sendFullDiff(ctx, chatID)
+		return
+	}
+
+	if !role.Can(state.RoleOperator) {
+		denyCallback(ctx, tgBot, callback, viewerNotice)
+		return
+	}
+	if !role.Can(callbackNeeds(callback.Data)) {
+		denyCallback(ctx, tgBot, callback, approvalNotice)
+		return
+	}
+	respCh, hasPending, denied := b.claimPending(chatID, role)
+	if denied {
+		denyCallback(ctx, tgBot, callback, approvalNotice)
+		return
+	}
+
 	// Answer callback to remove loading state
 	tgBot.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
 		CallbackQueryID: callback.ID,
 	})
 
-	log.Printf("Callback received: %s from chat %d", callback.Data, chatID)
-
 	// Send confirmation message to user
 	var confirmMsg string
 	switch callback.Data {
@@ -308,23 +366,18 @@ func (b *Bot) handleCallback(ctx context.Context, tgBot *bot.Bot, callback *mode
 	case "always allow":
 		confirmMsg = "🛡️ Always Allow enabled. Executing..."
 	default:
-		confirmMsg = "✓ Received: " + callback.Data
+		confirmMsg = "✓ Received: " + buttonLabel(callback)
 	}
 	tgBot.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID: chatID,
 		Text:   confirmMsg,
 	})
 
-	// Check if there's a pending promise for this chat
-	b.pendingMu.Lock()
-	respCh, ok := b.pending[chatID]
-	if ok {
-		delete(b.pending, chatID)
-		b.pendingMu.Unlock()
+	// Answer the pending question of this chat, if any
+	if hasPending {
 		respCh <- callback.Data
 		return
 	}
-	b.pendingMu.Unlock()
 
 	// Send to callback channel
 	b.callbackCh <- &CallbackEvent{
@@ -335,6 +388,21 @@ func (b *Bot) handleCallback(ctx context.Context, tgBot *bot.Bot, callback *mode
 	}
 }
 
+// buttonLabel is the text of the pressed button, or its data if the
+// keyboard is no longer available
+func buttonLabel(callback *models.CallbackQuery) string {
+	if msg := callback.Message.Message; msg != nil && msg.ReplyMarkup != nil {
+		for _, row := range msg.ReplyMarkup.InlineKeyboard {
+			for _, btn := range row {
+				if btn.CallbackData == callback.Data {
+					return btn.Text
+				}
+			}
+		}
+	}
+	return callback.Data
+}
+
 // SendToSession sends a message to a session listener or buffers it
 func (b *Bot) SendToSession(sessionID string, text string) {
 	b.sessionMu.Lock()
@@ -362,27 +430,80 @@ func (b *Bot) handleMessage(ctx context.Context, _ *bot.Bot, message *models.Mes
 	userID := message.From.ID
 
 	// Check if chat or user is allowed
-	if len(b.allowedUserIDs) > 0 && !b.allowedUserIDs[userID] && !b.allowedUserIDs[chatID] {
+	role, allowed := b.roleOf(userID, chatID)
+	if !allowed {
 		log.Printf("Unauthorized access attempt from user %d in chat %d", userID, chatID)
 		return
 	}
 
 	text := message.Text
+	if isCommand(text, "/team") {
+		b.handleTeamCommand(ctx, message, role)
+		return
+	}
+	if !role.Can(state.RoleOperator) {
+		b.SendMessage(ctx, chatID, viewerNotice)
+		return
+	}
+	// The panic button must not be taken as the answer to a pending question
+	// or go to a topic's session. Re-enabling writes is for owners only.
+	if isCommand(text, "/panic") || isCommand(text, "/resume") {
+		if isCommand(text, "/resume") && !role.Can(state.RoleOwner) {
+			b.SendMessage(ctx, chatID, resumeNotice)
+			return
+		}
+		b.responseCh <- &UserResponse{
+			ChatID:    chatID,
+			Text:      text,
+			Username:  message.From.Username,
+			MessageID: message.ID,
+			Timestamp: int64(message.Date),
+		}
+		return
+	}
 	if strings.HasPrefix(text, "/start") {
 		b.sendWelcomeMenu(ctx, chatID)
 		return
 	}
+	if isCommand(text, "/topics") {
+		b.handleTopicsCommand(ctx, message)
+		return
+	}
+	if isCommand(text, "/sessions") && b.state != nil && b.state.GetForumChatID() == chatID {
+		b.sendTopicList(ctx, chatID)
+		return
+	}
+
+	// A session's topic talks to that session only
+	if sessionID := b.topicSession(message); sessionID != "" {
+		b.SendToSession(sessionID, text)
+		return
+	}
 
 	// Check if there's a pending promise for this chat (e.g. from AskUser)
-	b.pendingMu.Lock()
-	respCh, ok := b.pending[chatID]
+	respCh, ok, denied := b.claimPending(chatID, role)
+	if denied {
+		b.SendMessage(ctx, chatID, approvalNotice)
+		return
+	}
 	if ok {
-		delete(b.pending, chatID)
-		b.pendingMu.Unlock()
 		respCh <- text
 		return
 	}
-	b.pendingMu.Unlock()
+
+	// A session waiting on an answer (ask) takes replies from any chat it asked in
+	b.activeMu.Lock()
+	sessionID := b.activeSessions[chatID]
+	b.activeMu.Unlock()
+	if sessionID != "" {
+		b.sessionMu.Lock()
+		_, waiting := b.sessionResponses[sessionID]
+		b.sessionMu.Unlock()
+		if waiting {
+			b.SendToSession(sessionID, text)
+			return
+		}
+	}
 
 	// Always send to response channel for Live Mode processing
 	b.responseCh <- &UserResponse{
@@ -400,8 +521,7 @@ func (b *Bot) handleVoice(ctx context.Context, tgBot *bot.Bot, message *models.M
 	userID := message.From.ID
 
 	// Check if chat or user is allowed
-	if len(b.allowedUserIDs) > 0 && !b.allowedUserIDs[userID] && !b.allowedUserIDs[chatID] {
-		log.Printf("Unauthorized voice access attempt from user %d in chat %d", userID, chatID)
+	if !b.canOperate(ctx, userID, chatID) {
 		return
 	}
 
@@ -449,9 +569,7 @@ func (b *Bot) handleVoice(ctx context.Context, tgBot *bot.Bot, message *models.M
 	b.SendMessage(ctx, chatID, fmt.Sprintf("📝 _Text_: %s", text))
 
 	// 4. Route to session
-	b.activeMu.Lock()
-	sessionID := b.activeSessions[chatID]
-	b.activeMu.Unlock()
+	sessionID := b.sessionForMessage(message)
 
 	if sessionID != "" {
 		b.SendToSession(sessionID, "[Voice Message]: "+text)
@@ -468,7 +586,7 @@ func (b *Bot) handleVoice(ctx context.Context, tgBot *bot.Bot, message *models.M
 func (b *Bot) downloadFile(_ context.Context, tgFilePath, localPath string) error {
 	fileURL := fmt.Sprintf("https://api.telegram.org/file/bot%s/%s", b.token, tgFilePath)
 
-	resp, err := http.Get(fileURL)
+	resp, err := httpclient.Client(5 * time.Minute).Get(fileURL)
 	if err != nil {
 		return err
 	}
@@ -493,6 +611,25 @@ func (b *Bot) SetTranscriber(t *whisper.Transcriber) {
 	b.transcriber = t
 }
 
+// SetRedactor sets how secrets are masked in everything the bot sends
+func (b *Bot) SetRedactor(fn func(string) string) {
+	b.redactMu.Lock()
+	defer b.redactMu.Unlock()
+	b.redactor = fn
+}
+
+// redact masks the secrets in outgoing text
+func (b *Bot) redact(text string) string {
+	b.redactMu.Lock()
+	fn := b.redactor
+	b.redactMu.Unlock()
+	if fn != nil {
+		return fn(text)
+	}
+	text, _ = safeguard.RedactSecrets(text)
+	return text
+}
+
 // SetBridge registers the Cloud Bridge client
 func (b *Bot) SetBridge(c *bridge.Client) {
 	b.bridgeClient = c
@@ -500,7 +637,7 @@ func (b *Bot) SetBridge(c *bridge.Client) {
 
 // SendMessageAndTrack sends a message and returns its ID for later editing
 func (b *Bot) SendMessageAndTrack(ctx context.Context, chatID int64, text string) (int, error) {
-	formatted := format.ToTelegramHTML(text)
+	formatted := format.ToTelegramHTML(b.redact(text))
 	msg, err := b.bot.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID:    chatID,
 		Text:      formatted,
@@ -517,7 +654,7 @@ func (b *Bot) EditMessage(ctx context.Context, chatID int64, messageID int, newT
 	_, err := b.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
 		ChatID:    chatID,
 		MessageID: messageID,
-		Text:      format.ToTelegramHTML(newText),
+		Text:      format.ToTelegramHTML(b.redact(newText)),
 		ParseMode: models.ParseModeHTML,
 	})
 	return err
@@ -525,6 +662,10 @@ func (b *Bot) EditMessage(ctx context.Context, chatID int64, messageID int, newT
 
 // SendPhoto sends an image to a chat
 func (b *Bot) SendPhoto(ctx context.Context, chatID int64, photoPath string, caption string) error {
+	if b.bot == nil {
+		return b.sendBridgeFile(chatID, photoPath, caption)
+	}
+
 	file, err := os.Open(photoPath)
 	if err != nil {
 		return fmt.Errorf("failed to open photo: %w", err)
@@ -534,7 +675,7 @@ func (b *Bot) SendPhoto(ctx context.Context, chatID int64, photoPath string, cap
 	_, err = b.bot.SendPhoto(ctx, &bot.SendPhotoParams{
 		ChatID:    chatID,
 		Photo:     &models.InputFileUpload{Filename: filepath.Base(photoPath), Data: file},
-		Caption:   format.ToTelegramHTML(caption),
+		Caption:   format.ToTelegramHTML(b.redact(caption)),
 		ParseMode: models.ParseModeHTML,
 	})
 	return err
@@ -542,6 +683,10 @@ func (b *Bot) SendPhoto(ctx context.Context, chatID int64, photoPath string, cap
 
 // SendVoice sends a voice message (audio file) to a chat
 func (b *Bot) SendVoice(ctx context.Context, chatID int64, audioPath string) error {
+	if b.bot == nil {
+		return b.sendBridgeFile(chatID, audioPath, "")
+	}
+
 	file, err := os.Open(audioPath)
 	if err != nil {
 		return fmt.Errorf("failed to open audio: %w", err)
@@ -557,7 +702,7 @@ func (b *Bot) SendVoice(ctx context.Context, chatID int64, audioPath string) err
 
 // SendCodeBlock sends a nicely formatted code block
 func (b *Bot) SendCodeBlock(ctx context.Context, chatID int64, language, code string) error {
-	text := fmt.Sprintf("<pre><code class=\"language-%s\">%s</code></pre>", language, format.EscapeHTML(code))
+	text := fmt.Sprintf("<pre><code class=\"language-%s\">%s</code></pre>", language, format.EscapeHTML(b.redact(code)))
 	_, err := b.bot.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID:    chatID,
 		Text:      text,
@@ -657,6 +802,7 @@ func (b *Bot) SendSessionList(ctx context.Context, chatID int64, sessions []Sess
 }
 
 func (b *Bot) SendMessage(ctx context.Context, chatID int64, text string) error {
+	text = b.redact(text)
 	if b.bot != nil {
 		formatted := format.ToTelegramHTML(text)
 		_, err := b.bot.SendMessage(ctx, &bot.SendMessageParams{
@@ -681,48 +827,50 @@ func (b *Bot) SendMessage(ctx context.Context, chatID int64, text string) error
 	return fmt.Errorf("no communication channel (no local bot and no bridge)")
 }
 
+// sendBridgeFile sends a file as an attachment over the Cloud Bridge
+func (b *Bot) sendBridgeFile(chatID int64, path, caption string) error {
+	if b.bridgeClient == nil {
+		return fmt.Errorf("no communication channel (no local bot and no bridge)")
+	}
+	att, err := bridge.FileAttachment(path, caption)
+	if err != nil {
+		return err
+	}
+	return b.bridgeClient.Send(&proto.BridgeEvent{
+		Payload: &proto.BridgeEvent_OutgoingMessage{
+			OutgoingMessage: &proto.OutgoingMessage{
+				ChatId:      chatID,
+				Attachments: []*proto.Attachment{att},
+			},
+		},
+	})
+}
+
 // SendMessageWithButtons sends a message with inline keyboard
 func (b *Bot) SendMessageWithButtons(ctx context.Context, chatID int64, text string, buttons [][]ButtonConfig) error {
+	text = b.redact(text)
 	if b.bot != nil {
 		formatted := format.ToTelegramHTML(text)
-		keyboard := make([][]models.InlineKeyboardButton, len(buttons))
-		for i, row := range buttons {
-			keyboard[i] = make([]models.InlineKeyboardButton, len(row))
-			for j, btn := range row {
-				keyboard[i][j] = models.InlineKeyboardButton{
-					Text:         btn.Text,
-					CallbackData: btn.Data,
-				}
-			}
-		}
-
 		_, err := b.bot.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID:    chatID,
 			Text:      formatted,
 			ParseMode: models.ParseModeHTML,
 			ReplyMarkup: &models.InlineKeyboardMarkup{
-				InlineKeyboard: keyboard,
+				InlineKeyboard: inlineKeyboard(buttons),
 			},
 		})
 		return err
 	}
 
 	if b.bridgeClient != nil {
-		// Buttons are currently not supported over bridge proto (IncomingMessage only has Body)
-		// We fallback to plain text list
-		var sb strings.Builder
-		sb.WriteString(text + "\n\n")
-		for _, row := range buttons {
-			for _, btn := range row {
-				sb.WriteString(fmt.Sprintf("🔹 %s\n", btn.Text))
-			}
-		}
-
+		// The bridge maps buttons to the platform's own (e.g. WhatsApp
+		// interactive replies); presses come back as callback data
 		return b.bridgeClient.Send(&proto.BridgeEvent{
 			Payload: &proto.BridgeEvent_OutgoingMessage{
 				OutgoingMessage: &proto.OutgoingMessage{
-					ChatId: chatID,
-					Body:   sb.String(),
+					ChatId:  chatID,
+					Body:    text,
+					Buttons: BridgeButtons(buttons),
 				},
 			},
 		})
@@ -731,45 +879,110 @@ func (b *Bot) SendMessageWithButtons(ctx context.Context, chatID int64, text str
 	return fmt.Errorf("no communication channel")
 }
 
+// SendToRecipient sends a message to a chat or one of its forum topics. The
+// Cloud Bridge cannot address topics, so there it goes to the chat.
+func (b *Bot) SendToRecipient(ctx context.Context, r state.Recipient, text string, buttons [][]ButtonConfig) error {
+	text = b.redact(text)
+	if r.ThreadID == 0 || b.bot == nil {
+		if len(buttons) > 0 {
+			return b.SendMessageWithButtons(ctx, r.ChatID, text, buttons)
+		}
+		return b.SendMessage(ctx, r.ChatID, text)
+	}
+
+	params := &bot.SendMessageParams{
+		ChatID:          r.ChatID,
+		MessageThreadID: r.ThreadID,
+		Text:            format.ToTelegramHTML(text),
+		ParseMode:       models.ParseModeHTML,
+	}
+	if len(buttons) > 0 {
+		params.ReplyMarkup = &models.InlineKeyboardMarkup{InlineKeyboard: inlineKeyboard(buttons)}
+	}
+	_, err := b.bot.SendMessage(ctx, params)
+	return err
+}
+
+func inlineKeyboard(buttons [][]ButtonConfig) [][]models.InlineKeyboardButton {
+	keyboard := make([][]models.InlineKeyboardButton, len(buttons))
+	for i, row := range buttons {
+		keyboard[i] = make([]models.InlineKeyboardButton, len(row))
+		for j, btn := range row {
+			keyboard[i][j] = models.InlineKeyboardButton{
+				Text:         btn.Text,
+				CallbackData: btn.Data,
+			}
+		}
+	}
+	return keyboard
+}
+
+// BridgeButtons converts buttons for an OutgoingMessage over the Cloud Bridge
+func BridgeButtons(buttons [][]ButtonConfig) []*proto.ButtonRow {
+	rows := make([]*proto.ButtonRow, 0, len(buttons))
+	for _, row := range buttons {
+		r := &proto.ButtonRow{}
+		for _, btn := range row {
+			r.Buttons = append(r.Buttons, &proto.Button{Text: btn.Text, Data: btn.Data})
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
 // ButtonConfig represents a button configuration
-type ButtonConfig struct {
-	Text string
-	Data string
+type ButtonConfig = messenger.Button
+
+// DefaultAskButtons are offered with questions that have no options of their own
+var DefaultAskButtons = [][]ButtonConfig{
+	{
+		{Text: "✅ Yes", Data: "yes"},
+		{Text: "❌ No", Data: "no"},
+	},
+	{
+		{Text: "🛡️ Always Allow", Data: "always allow"},
+	},
 }
 
 // AskUser sends a question and waits for response (generic legacy)
 func (b *Bot) AskUser(ctx context.Context, chatID int64, question string) (string, error) {
+	return b.AskUserWithButtons(ctx, chatID, question, DefaultAskButtons)
+}
+
+// AskUserWithButtons sends a question with the given buttons and waits for
+// the next message or button press in the chat
+func (b *Bot) AskUserWithButtons(ctx context.Context, chatID int64, question string, buttons [][]ButtonConfig) (string, error) {
+	return b.askRecipient(ctx, state.Recipient{ChatID: chatID}, question, buttons)
+}
+
+// askRecipient asks in a chat or forum topic. Replies are awaited per chat,
+// so an answer from any topic of the chat counts.
+func (b *Bot) askRecipient(ctx context.Context, r state.Recipient, question string, buttons [][]ButtonConfig) (string, error) {
+	return b.awaitAnswer(ctx, r.ChatID, isApproval(buttons), func() error {
+		return b.SendToRecipient(ctx, r, question, buttons)
+	})
+}
+
+// awaitAnswer sends a question with send and waits for the next message or
+// button press in the chat. Only owners can answer an approval.
+func (b *Bot) awaitAnswer(ctx context.Context, chatID int64, approval bool, send func() error) (string, error) {
 	// Create response channel
 	respCh := make(chan string, 1)
 
 	b.pendingMu.Lock()
 	b.pending[chatID] = respCh
+	b.approvals[chatID] = approval
 	b.pendingMu.Unlock()
 
-	// Send question with buttons for easier interaction
-	buttons := [][]ButtonConfig{
-		{
-			{Text: "✅ Yes", Data: "yes"},
-			{Text: "❌ No", Data: "no"},
-		},
-		{
-			{Text: "🛡️ Always Allow", Data: "always allow"},
-		},
-	}
-
-	if err := b.SendMessageWithButtons(ctx, chatID, question, buttons); err != nil {
-		b.pendingMu.Lock()
-		delete(b.pending, chatID)
-		b.pendingMu.Unlock()
+	if err := send(); err != nil {
+		b.dropPending(chatID, respCh)
 		return "", fmt.Errorf("failed to send question: %w", err)
 	}
 
 	// Wait for response
 	select {
 	case <-ctx.Done():
-		b.pendingMu.Lock()
-		delete(b.pending, chatID)
-		b.pendingMu.Unlock()
+		b.dropPending(chatID, respCh)
 		return "", ctx.Err()
 	case resp := <-respCh:
 		return resp, nil
@@ -807,8 +1020,17 @@ func (b *Bot) IsSessionOnline(sessionID string) bool {
 
 // SendTyping sends a typing action to a chat
 func (b *Bot) SendTyping(ctx context.Context, chatID int64) {
+	b.SendTypingTo(ctx, state.Recipient{ChatID: chatID})
+}
+
+// SendTypingTo sends a typing action to a chat or forum topic
+func (b *Bot) SendTypingTo(ctx context.Context, r state.Recipient) {
+	if b.bot == nil {
+		return
+	}
 	b.bot.SendChatAction(ctx, &bot.SendChatActionParams{
-		ChatID: chatID,
-		Action: models.ChatActionTyping,
+		ChatID:          r.ChatID,
+		MessageThreadID: r.ThreadID,
+		Action:          models.ChatActionTyping,
 	})
 }
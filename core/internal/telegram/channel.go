@@ -0,0 +1,129 @@
+package telegram
+
+import (
+	"context"
+	"log"
+
+	"github.com/igoryan-dao/ricochet/internal/messenger"
+	"github.com/igoryan-dao/ricochet/internal/state"
+)
+
+var _ messenger.Channel = (*Bot)(nil)
+
+// Name implements messenger.Channel
+func (b *Bot) Name() string { return "Telegram" }
+
+// Targets returns the session's recipients as "chat" or "chat:topic",
+// falling back to its forum topic, the defaults and the primary chat. The
+// topic is created here the first time the session writes.
+func (b *Bot) Targets(sessionID string) []string {
+	if b.state == nil {
+		return nil
+	}
+	b.ensureTopic(context.Background(), sessionID)
+	var targets []string
+	for _, r := range b.state.GetRecipients(sessionID) {
+		targets = append(targets, r.String())
+	}
+	return targets
+}
+
+// Send implements messenger.Channel
+func (b *Bot) Send(ctx context.Context, target, text string) error {
+	return b.SendRich(ctx, target, messenger.Message{Text: text})
+}
+
+// SendRich implements messenger.Channel. Media and code go to the chat, not
+// the topic.
+func (b *Bot) SendRich(ctx context.Context, target string, msg messenger.Message) error {
+	r, err := state.ParseRecipient(target)
+	if err != nil {
+		return err
+	}
+	if msg.Diff != "" {
+		if err := b.SendDiff(ctx, r, msg.Text, msg.DiffPath, msg.Diff, msg.Buttons); err != nil {
+			return err
+		}
+	} else if msg.Text != "" {
+		if err := b.SendToRecipient(ctx, r, msg.Text, msg.Buttons); err != nil {
+			return err
+		}
+	}
+	if msg.Code != "" {
+		if err := b.SendCodeBlock(ctx, r.ChatID, msg.Language, msg.Code); err != nil {
+			return err
+		}
+	}
+	if msg.ImagePath != "" {
+		if err := b.SendPhoto(ctx, r.ChatID, msg.ImagePath, msg.Caption); err != nil {
+			return err
+		}
+	}
+	if msg.VoicePath != "" {
+		if err := b.SendVoice(ctx, r.ChatID, msg.VoicePath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ask implements messenger.Channel
+func (b *Bot) Ask(ctx context.Context, target, question string, buttons [][]messenger.Button) (string, error) {
+	r, err := state.ParseRecipient(target)
+	if err != nil {
+		return "", err
+	}
+	return b.askRecipient(ctx, r, question, buttons)
+}
+
+// Presence shows the typing indicator
+func (b *Bot) Presence(ctx context.Context, target string) {
+	if r, err := state.ParseRecipient(target); err == nil {
+		b.SendTypingTo(ctx, r)
+	}
+}
+
+// RegisterSession implements messenger.Channel
+func (b *Bot) RegisterSession(sessionID string, ch chan string) func() {
+	b.RegisterSessionHandler(sessionID, ch)
+	return func() { b.UnregisterSessionHandler(sessionID) }
+}
+
+// Unread implements messenger.Channel
+func (b *Bot) Unread(sessionID string) []string {
+	return b.GetUnreadMessages(sessionID)
+}
+
+// Activate makes the session the active one of the target's chat. Session
+// topics always belong to their session.
+func (b *Bot) Activate(target, sessionID string) {
+	r, err := state.ParseRecipient(target)
+	if err != nil {
+		log.Printf("Cannot activate session for %q: %v", target, err)
+		return
+	}
+	if b.topicOwner(r) != "" {
+		return
+	}
+	b.SetActiveSession(r.ChatID, sessionID)
+}
+
+// ActiveSession returns the session owning the target's topic, else the
+// active session of its chat
+func (b *Bot) ActiveSession(target string) string {
+	r, err := state.ParseRecipient(target)
+	if err != nil {
+		return ""
+	}
+	if owner := b.topicOwner(r); owner != "" {
+		return owner
+	}
+	return b.GetActiveSession(r.ChatID)
+}
+
+func (b *Bot) topicOwner(r state.Recipient) string {
+	if b.state == nil {
+		return ""
+	}
+	return b.state.SessionForTopic(r.ChatID, r.ThreadID)
+}
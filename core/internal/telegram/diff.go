@@ -0,0 +1,165 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/igoryan-dao/ricochet/internal/bridge/proto"
+	"github.com/igoryan-dao/ricochet/internal/format"
+	"github.com/igoryan-dao/ricochet/internal/state"
+)
+
+// CallbackViewDiff asks for the full diff of the last preview as a document
+const CallbackViewDiff = "diff:view"
+
+// Diff previews stay well inside the 4096-char message limit
+const (
+	diffPreviewLines = 40
+	diffPreviewChars = 2500
+)
+
+// sharedDiff is a diff that can be sent in full on request
+type sharedDiff struct {
+	path string
+	diff string
+}
+
+// ApprovalButtons answer an approval the way AskUser's buttons do, plus a
+// button to get the full diff
+var ApprovalButtons = [][]ButtonConfig{
+	{
+		{Text: "✅ Approve", Data: "yes"},
+		{Text: "❌ Reject", Data: "no"},
+	},
+	{
+		{Text: "🛡️ Always Allow", Data: "always allow"},
+		{Text: "📄 View full", Data: CallbackViewDiff},
+	},
+}
+
+// AskApproveDiff asks to approve a file edit, previewing its unified diff
+// under the question. Answers are those of AskUser.
+func (b *Bot) AskApproveDiff(ctx context.Context, chatID int64, question, path, diff string) (string, error) {
+	return b.awaitAnswer(ctx, chatID, true, func() error {
+		return b.SendDiff(ctx, state.Recipient{ChatID: chatID}, question, path, diff, ApprovalButtons)
+	})
+}
+
+// SendDiff sends text followed by a truncated, highlighted preview of a
+// unified diff. A "View full" button (CallbackViewDiff) among buttons sends
+// the whole diff as a file.
+func (b *Bot) SendDiff(ctx context.Context, r state.Recipient, text, path, diff string, buttons [][]ButtonConfig) error {
+	text, diff = b.redact(text), b.redact(diff)
+	preview, truncated := diffPreview(diff)
+
+	b.diffMu.Lock()
+	b.diffs[r.ChatID] = sharedDiff{path: path, diff: diff}
+	b.diffMu.Unlock()
+
+	if b.bot == nil {
+		// Over the Cloud Bridge there are no callbacks to fetch the diff
+		// later: a truncated one is attached right away
+		if err := b.SendToRecipient(ctx, r, text+"\n\n```diff\n"+preview+"\n```", withoutViewDiff(buttons)); err != nil {
+			return err
+		}
+		if !truncated || b.bridgeClient == nil {
+			return nil
+		}
+		return b.bridgeClient.Send(&proto.BridgeEvent{
+			Payload: &proto.BridgeEvent_OutgoingMessage{
+				OutgoingMessage: &proto.OutgoingMessage{
+					ChatId: r.ChatID,
+					Attachments: []*proto.Attachment{{
+						Name:     diffFileName(path),
+						MimeType: "text/x-diff",
+						Data:     []byte(diff),
+						Caption:  path,
+					}},
+				},
+			},
+		})
+	}
+
+	html := format.ToTelegramHTML(text) +
+		"\n\n<pre><code class=\"language-diff\">" + format.EscapeHTML(preview) + "</code></pre>"
+	if truncated {
+		html += "\n<i>Preview truncated, use View full for the whole diff.</i>"
+	}
+	params := &bot.SendMessageParams{
+		ChatID:          r.ChatID,
+		MessageThreadID: r.ThreadID,
+		Text:            html,
+		ParseMode:       models.ParseModeHTML,
+	}
+	if len(buttons) > 0 {
+		params.ReplyMarkup = &models.InlineKeyboardMarkup{InlineKeyboard: inlineKeyboard(buttons)}
+	}
+	_, err := b.bot.SendMessage(ctx, params)
+	return err
+}
+
+// sendFullDiff sends the chat's last previewed diff as a .diff document
+func (b *Bot) sendFullDiff(ctx context.Context, chatID int64) {
+	b.diffMu.Lock()
+	shared, ok := b.diffs[chatID]
+	b.diffMu.Unlock()
+	if !ok {
+		b.SendMessage(ctx, chatID, "⚠️ That diff is no longer available.")
+		return
+	}
+
+	_, err := b.bot.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID:   chatID,
+		Document: &models.InputFileUpload{Filename: diffFileName(shared.path), Data: strings.NewReader(shared.diff)},
+		Caption:  shared.path,
+	})
+	if err != nil {
+		log.Printf("Failed to send diff: %v", err)
+		b.SendMessage(ctx, chatID, fmt.Sprintf("❌ Failed to send diff: %v", err))
+	}
+}
+
+// diffFileName names the file a diff of path is sent as
+func diffFileName(path string) string {
+	if path == "" {
+		return "changes.diff"
+	}
+	return filepath.Base(path) + ".diff"
+}
+
+// diffPreview cuts a diff to the preview limits, reporting whether it did
+func diffPreview(diff string) (string, bool) {
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	var sb strings.Builder
+	for i, line := range lines {
+		if i == diffPreviewLines || sb.Len()+len(line) > diffPreviewChars {
+			sb.WriteString(fmt.Sprintf("… %d more lines", len(lines)-i))
+			return sb.String(), true
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), false
+}
+
+// withoutViewDiff drops the "View full" button
+func withoutViewDiff(buttons [][]ButtonConfig) [][]ButtonConfig {
+	var rows [][]ButtonConfig
+	for _, row := range buttons {
+		var kept []ButtonConfig
+		for _, btn := range row {
+			if btn.Data != CallbackViewDiff {
+				kept = append(kept, btn)
+			}
+		}
+		if len(kept) > 0 {
+			rows = append(rows, kept)
+		}
+	}
+	return rows
+}
@@ -0,0 +1,26 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffPreview(t *testing.T) {
+	short := "--- a/x\n+++ b/x\n@@ -1,1 +1,1 @@\n-a\n+b\n"
+	if got, truncated := diffPreview(short); truncated || got != strings.TrimSuffix(short, "\n") {
+		t.Errorf("diffPreview(short) = %q, %v", got, truncated)
+	}
+
+	long := strings.Repeat("+line\n", diffPreviewLines+10)
+	got, truncated := diffPreview(long)
+	if !truncated || !strings.HasSuffix(got, "… 10 more lines") {
+		t.Errorf("diffPreview(long) = %q, %v", got, truncated)
+	}
+}
+
+func TestWithoutViewDiff(t *testing.T) {
+	got := withoutViewDiff([][]ButtonConfig{{{Data: CallbackViewDiff}}, {{Data: "yes"}, {Data: CallbackViewDiff}}})
+	if len(got) != 1 || len(got[0]) != 1 || got[0][0].Data != "yes" {
+		t.Errorf("withoutViewDiff = %v", got)
+	}
+}
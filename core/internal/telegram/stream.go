@@ -0,0 +1,140 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/igoryan-dao/ricochet/internal/discord"
+	"github.com/igoryan-dao/ricochet/internal/format"
+)
+
+const (
+	// Telegram allows about one edit per second in a chat; stay below it
+	streamEditInterval = 1500 * time.Millisecond
+	// The typing action lasts ~5s
+	streamTypingInterval = 4 * time.Second
+	// Telegram counts the 4096-char limit after markup is parsed, so
+	// markdown chunks of this size fit once formatted
+	streamChunkLength = 4000
+)
+
+// Stream delivers a growing response by editing one message in place rather
+// than sending a message per update. While streaming the text is shown as
+// is, since half-written markdown may not parse; Finish formats it. Content
+// past the message limit spills into follow-up messages.
+type Stream struct {
+	bot    *Bot
+	chatID int64
+
+	mu         sync.Mutex
+	messageIDs []int    // One Telegram message per chunk
+	sent       []string // Last content delivered per message
+	pending    string
+	lastEdit   time.Time
+	lastTyping time.Time
+}
+
+// NewStream starts a streamed response in a chat
+func (b *Bot) NewStream(chatID int64) *Stream {
+	return &Stream{bot: b, chatID: chatID}
+}
+
+// Update replaces the streamed content. Edits are throttled to Telegram's
+// rate limits, so intermediate states may be skipped; call Finish for the
+// final text.
+func (s *Stream) Update(ctx context.Context, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.bot.bot == nil {
+		return nil // Over the Cloud Bridge only the final text is sent
+	}
+
+	s.pending = text
+	if time.Since(s.lastTyping) >= streamTypingInterval {
+		s.bot.SendTyping(ctx, s.chatID)
+		s.lastTyping = time.Now()
+	}
+
+	if time.Since(s.lastEdit) < streamEditInterval {
+		return nil
+	}
+	return s.flush(ctx, false)
+}
+
+// Finish delivers the final content formatted, bypassing the throttle
+func (s *Stream) Finish(ctx context.Context, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.bot.bot == nil {
+		return s.bot.SendMessage(ctx, s.chatID, text)
+	}
+
+	s.pending = text
+	return s.flush(ctx, true)
+}
+
+func (s *Stream) flush(ctx context.Context, final bool) error {
+	if strings.TrimSpace(s.pending) == "" {
+		return nil
+	}
+
+	for i, chunk := range discord.SplitMessage(s.pending, streamChunkLength) {
+		text, parseMode := chunk, models.ParseMode("")
+		if final {
+			text, parseMode = format.ToTelegramHTML(chunk), models.ParseModeHTML
+		}
+		if i < len(s.messageIDs) && s.sent[i] == text {
+			continue // Telegram rejects edits that change nothing
+		}
+
+		err := s.send(ctx, i, text, parseMode)
+		if err != nil && final {
+			// Markup Telegram cannot parse: keep the text readable instead
+			text = chunk
+			err = s.send(ctx, i, text, "")
+		}
+		if err != nil {
+			return err
+		}
+		s.sent[i] = text
+	}
+
+	s.lastEdit = time.Now()
+	return nil
+}
+
+// send edits the i-th message of the stream, or sends it if it is new
+func (s *Stream) send(ctx context.Context, i int, text string, parseMode models.ParseMode) error {
+	if i < len(s.messageIDs) {
+		_, err := s.bot.bot.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    s.chatID,
+			MessageID: s.messageIDs[i],
+			Text:      text,
+			ParseMode: parseMode,
+		})
+		// Formatting may render the same as the streamed text, which is no change
+		if err != nil && !strings.Contains(err.Error(), "message is not modified") {
+			return fmt.Errorf("failed to edit Telegram message: %w", err)
+		}
+		return nil
+	}
+
+	msg, err := s.bot.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    s.chatID,
+		Text:      text,
+		ParseMode: parseMode,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send Telegram message: %w", err)
+	}
+	s.messageIDs = append(s.messageIDs, msg.ID)
+	s.sent = append(s.sent, "")
+	return nil
+}
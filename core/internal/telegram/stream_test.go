@@ -0,0 +1,65 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot"
+)
+
+// fakeAPI records the messages sent and edited through the Bot API
+type fakeAPI struct {
+	mu    sync.Mutex
+	calls []string // "method text parse_mode"
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseMultipartForm(1 << 20)
+	method := path.Base(r.URL.Path)
+	if method != "sendChatAction" {
+		f.mu.Lock()
+		f.calls = append(f.calls, fmt.Sprintf("%s %q %s", method, r.FormValue("text"), r.FormValue("parse_mode")))
+		f.mu.Unlock()
+	}
+	result := any(true)
+	if method == "sendMessage" || method == "editMessageText" {
+		result = map[string]any{"message_id": 1, "date": 0, "chat": map[string]any{"id": 7, "type": "private"}}
+	}
+	json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+func TestStreamEditsOneMessage(t *testing.T) {
+	api := &fakeAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	tg, err := bot.New("123:abc", bot.WithServerURL(srv.URL), bot.WithSkipGetMe())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &Bot{bot: tg}
+	ctx := context.Background()
+
+	s := b.NewStream(7)
+	s.Update(ctx, "Hello")
+	s.Update(ctx, "Hello **wor") // Throttled
+	s.lastEdit = time.Time{}
+	s.Update(ctx, "Hello **world")
+	s.Finish(ctx, "Hello **world**")
+
+	want := []string{
+		`sendMessage "Hello" `,
+		`editMessageText "Hello **world" `,
+		`editMessageText "Hello <b>world</b>" HTML`,
+	}
+	if fmt.Sprint(api.calls) != fmt.Sprint(want) {
+		t.Errorf("calls:\n%v\nwant:\n%v", api.calls, want)
+	}
+}
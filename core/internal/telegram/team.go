@@ -0,0 +1,236 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/igoryan-dao/ricochet/internal/state"
+)
+
+const (
+	viewerNotice   = "👀 You're a viewer: you get notifications but can't send commands or answer questions."
+	approvalNotice = "🔒 Only owners can answer approvals."
+	resumeNotice   = "🔒 Only owners can lift the emergency stop."
+)
+
+// roleOf returns the role of a user, false if they may not use the bot.
+// Team roles come first; users and chats on the allowlist are owners, and
+// with neither an allowlist nor a team everyone is (the token protects the bot).
+func (b *Bot) roleOf(userID, chatID int64) (state.Role, bool) {
+	teamSize := 0
+	if b.state != nil {
+		if role, ok := b.state.GetRole(userID); ok {
+			return role, true
+		}
+		teamSize = len(b.state.GetTeam())
+	}
+	if b.allowedUserIDs[userID] || b.allowedUserIDs[chatID] {
+		return state.RoleOwner, true
+	}
+	if len(b.allowedUserIDs) == 0 && teamSize == 0 {
+		return state.RoleOwner, true
+	}
+	return "", false
+}
+
+// canOperate checks that a user may talk to sessions, telling viewers why not
+func (b *Bot) canOperate(ctx context.Context, userID, chatID int64) bool {
+	role, ok := b.roleOf(userID, chatID)
+	if !ok {
+		log.Printf("Unauthorized access attempt from user %d in chat %d", userID, chatID)
+		return false
+	}
+	if !role.Can(state.RoleOperator) {
+		b.SendMessage(ctx, chatID, viewerNotice)
+		return false
+	}
+	return true
+}
+
+// callbackNeeds returns the role a button press requires: confirming or
+// cancelling a dangerous command is an approval
+func callbackNeeds(data string) state.Role {
+	if strings.HasPrefix(data, "confirm_yes:") || strings.HasPrefix(data, "confirm_no:") {
+		return state.RoleOwner
+	}
+	return state.RoleOperator
+}
+
+// AuthorizeBridge reports whether a bridge chat may send a message (empty
+// callbackData) or press a button. Bridge events carry no user, so the chat
+// stands for its user and is looked up in the team and the allowlist.
+func (b *Bot) AuthorizeBridge(chatID int64, callbackData string) bool {
+	role, ok := b.roleOf(chatID, chatID)
+	if !ok {
+		log.Printf("Unauthorized bridge event from chat %d", chatID)
+		return false
+	}
+	need := state.RoleOperator
+	if callbackData != "" {
+		need = callbackNeeds(callbackData)
+	}
+	if !role.Can(need) {
+		log.Printf("Bridge chat %d is a %s and may not do that (%s needed)", chatID, role, need)
+		return false
+	}
+	return true
+}
+
+// denyCallback answers a button press with an alert and leaves it unhandled
+func denyCallback(ctx context.Context, tgBot *bot.Bot, callback *models.CallbackQuery, text string) {
+	tgBot.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: callback.ID,
+		Text:            text,
+		ShowAlert:       true,
+	})
+}
+
+// isApproval reports whether a question grants permissions, i.e. offers
+// "Always Allow"
+func isApproval(buttons [][]ButtonConfig) bool {
+	for _, row := range buttons {
+		for _, btn := range row {
+			if btn.Data == "always allow" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// claimPending takes the pending question of a chat for an answer from a
+// user with role. denied is set, and the question left open, when it is an
+// approval and the user is not an owner.
+func (b *Bot) claimPending(chatID int64, role state.Role) (respCh chan string, ok, denied bool) {
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+
+	respCh, ok = b.pending[chatID]
+	if !ok {
+		return nil, false, false
+	}
+	if b.approvals[chatID] && !role.Can(state.RoleOwner) {
+		return nil, false, true
+	}
+	delete(b.pending, chatID)
+	delete(b.approvals, chatID)
+	return respCh, true, false
+}
+
+// dropPending forgets a question that is no longer awaited, unless a newer
+// one replaced it
+func (b *Bot) dropPending(chatID int64, respCh chan string) {
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+	if b.pending[chatID] == respCh {
+		delete(b.pending, chatID)
+		delete(b.approvals, chatID)
+	}
+}
+
+// handleTeamCommand lists the team with "/team", and lets owners manage it
+// with "/team add <user_id> [role]" and "/team remove <user_id>". Replying
+// to someone's message with "/team add [role]" targets them instead.
+func (b *Bot) handleTeamCommand(ctx context.Context, message *models.Message, role state.Role) {
+	chatID := message.Chat.ID
+	if b.state == nil {
+		b.SendMessage(ctx, chatID, "⚠️ State storage is unavailable.")
+		return
+	}
+
+	args := strings.Fields(message.Text)[1:]
+	if len(args) == 0 {
+		b.sendTeam(ctx, chatID)
+		return
+	}
+	if !role.Can(state.RoleOwner) {
+		b.SendMessage(ctx, chatID, "🔒 Only owners can manage the team.")
+		return
+	}
+
+	action, args := args[0], args[1:]
+	var userID int64
+	if reply := message.ReplyToMessage; reply != nil && reply.From != nil {
+		userID = reply.From.ID
+	} else if len(args) > 0 {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			b.SendMessage(ctx, chatID, fmt.Sprintf("❌ Invalid user ID %q.", args[0]))
+			return
+		}
+		userID, args = id, args[1:]
+	}
+	if userID == 0 || (action != "add" && action != "remove") {
+		b.SendMessage(ctx, chatID, "Usage: `/team add <user_id> [owner|operator|viewer]`, `/team remove <user_id>`, or reply to a message with `/team add [role]`.")
+		return
+	}
+
+	newRole := state.RoleOperator
+	if action == "add" && len(args) > 0 {
+		parsed, err := state.ParseRole(args[0])
+		if err != nil {
+			b.SendMessage(ctx, chatID, fmt.Sprintf("❌ %v", err))
+			return
+		}
+		newRole = parsed
+	}
+
+	// Nobody must be locked out: the first change makes its author an owner,
+	// and the last owner stays one
+	team := b.state.GetTeam()
+	if len(team) == 0 && userID != message.From.ID {
+		if err := b.state.SetRole(message.From.ID, state.RoleOwner); err != nil {
+			log.Printf("Failed to save team: %v", err)
+		}
+	}
+	if current, _ := b.state.GetRole(userID); current == state.RoleOwner && (action == "remove" || newRole != state.RoleOwner) &&
+		len(b.allowedUserIDs) == 0 && countOwners(team) == 1 {
+		b.SendMessage(ctx, chatID, "❌ The team needs at least one owner.")
+		return
+	}
+
+	var err error
+	if action == "remove" {
+		err = b.state.RemoveMember(userID)
+	} else {
+		err = b.state.SetRole(userID, newRole)
+	}
+	if err != nil {
+		b.SendMessage(ctx, chatID, fmt.Sprintf("❌ Failed to save team: %v", err))
+		return
+	}
+	b.sendTeam(ctx, chatID)
+}
+
+// sendTeam lists the team members and their roles
+func (b *Bot) sendTeam(ctx context.Context, chatID int64) {
+	team := b.state.GetTeam()
+	if len(team) == 0 {
+		b.SendMessage(ctx, chatID, "👥 No team yet: everyone allowed to use the bot is an owner.\n\nAdd members with `/team add <user_id> [owner|operator|viewer]`.")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("👥 **Team**\n")
+	for _, m := range team {
+		sb.WriteString(fmt.Sprintf("\n• `%d` — %s", m.UserID, m.Role))
+	}
+	sb.WriteString("\n\nViewers get notifications, operators also chat and answer questions, owners also approve dangerous commands.")
+	b.SendMessage(ctx, chatID, sb.String())
+}
+
+// countOwners counts the owners of a team
+func countOwners(team []state.Member) int {
+	n := 0
+	for _, m := range team {
+		if m.Role == state.RoleOwner {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,100 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/state"
+)
+
+func newTeamBot(t *testing.T, allowed ...int64) *Bot {
+	t.Setenv("HOME", t.TempDir())
+	mgr, err := state.NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &Bot{
+		allowedUserIDs: make(map[int64]bool),
+		state:          mgr,
+		pending:        make(map[int64]chan string),
+		approvals:      make(map[int64]bool),
+	}
+	for _, id := range allowed {
+		b.allowedUserIDs[id] = true
+	}
+	return b
+}
+
+func TestRoleOf(t *testing.T) {
+	b := newTeamBot(t)
+	if role, ok := b.roleOf(1, 1); !ok || role != state.RoleOwner {
+		t.Errorf("open bot: roleOf = %q, %v, want owner", role, ok)
+	}
+
+	// Once there is a team, strangers are out
+	b.state.SetRole(2, state.RoleViewer)
+	if role, ok := b.roleOf(2, 2); !ok || role != state.RoleViewer {
+		t.Errorf("member: roleOf = %q, %v, want viewer", role, ok)
+	}
+	if _, ok := b.roleOf(1, 1); ok {
+		t.Error("stranger allowed once a team exists")
+	}
+
+	b = newTeamBot(t, 10)
+	if role, ok := b.roleOf(3, 10); !ok || role != state.RoleOwner {
+		t.Errorf("allowed chat: roleOf = %q, %v, want owner", role, ok)
+	}
+	if _, ok := b.roleOf(3, 11); ok {
+		t.Error("user outside the allowlist allowed")
+	}
+}
+
+func TestClaimPendingApproval(t *testing.T) {
+	b := newTeamBot(t)
+	respCh := make(chan string, 1)
+	b.pending[5] = respCh
+	b.approvals[5] = isApproval(DefaultAskButtons)
+
+	if _, ok, denied := b.claimPending(5, state.RoleOperator); ok || !denied {
+		t.Fatalf("operator claimed an approval: ok=%v denied=%v", ok, denied)
+	}
+	if got, ok, _ := b.claimPending(5, state.RoleOwner); !ok || got != respCh {
+		t.Fatal("owner could not claim the approval")
+	}
+	if _, ok, _ := b.claimPending(5, state.RoleOwner); ok {
+		t.Error("approval claimed twice")
+	}
+
+	// Plain questions are open to operators
+	b.pending[5] = respCh
+	b.approvals[5] = isApproval([][]ButtonConfig{{{Text: "A", Data: "a"}}})
+	if _, ok, denied := b.claimPending(5, state.RoleOperator); !ok || denied {
+		t.Errorf("operator could not answer a question: ok=%v denied=%v", ok, denied)
+	}
+}
+
+func TestAuthorizeBridge(t *testing.T) {
+	b := newTeamBot(t)
+	b.state.SetRole(1, state.RoleOwner)
+	b.state.SetRole(2, state.RoleOperator)
+	b.state.SetRole(3, state.RoleViewer)
+
+	cases := []struct {
+		chat int64
+		data string
+		want bool
+	}{
+		{1, "confirm_yes:s1", true},
+		{2, "", true},
+		{2, "ask:s1:2", true},
+		{2, "confirm_yes:s1", false},
+		{2, "confirm_no:s1", false},
+		{3, "", false},
+		{3, "activate:s1", false},
+		{4, "", false},
+	}
+	for _, c := range cases {
+		if got := b.AuthorizeBridge(c.chat, c.data); got != c.want {
+			t.Errorf("AuthorizeBridge(%d, %q) = %v, want %v", c.chat, c.data, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/igoryan-dao/ricochet/internal/state"
+)
+
+// maxTopicName is Telegram's limit on forum topic names
+const maxTopicName = 128
+
+// SetTopicNamer sets how topics created for sessions are named. By default
+// they are named after the session ID.
+func (b *Bot) SetTopicNamer(name func(sessionID string) string) {
+	b.topicMu.Lock()
+	b.topicNamer = name
+	b.topicMu.Unlock()
+}
+
+// ensureTopic returns the session's topic in the forum chat, creating it on
+// first use. It reports false when topics are off or the topic cannot be made.
+func (b *Bot) ensureTopic(ctx context.Context, sessionID string) (state.Recipient, bool) {
+	if b.state == nil || sessionID == "" {
+		return state.Recipient{}, false
+	}
+	if topic, ok := b.state.GetSessionTopic(sessionID); ok {
+		return topic, true
+	}
+	forumID := b.state.GetForumChatID()
+	if forumID == 0 || b.bot == nil {
+		return state.Recipient{}, false
+	}
+
+	// Serialized so concurrent messages don't open a topic each
+	b.topicMu.Lock()
+	defer b.topicMu.Unlock()
+	if topic, ok := b.state.GetSessionTopic(sessionID); ok {
+		return topic, true
+	}
+
+	name := "🤖 " + shortSessionID(sessionID)
+	if b.topicNamer != nil {
+		if n := strings.TrimSpace(b.topicNamer(sessionID)); n != "" {
+			name = n
+		}
+	}
+	if runes := []rune(name); len(runes) > maxTopicName {
+		name = string(runes[:maxTopicName-1]) + "…"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	created, err := b.bot.CreateForumTopic(ctx, &bot.CreateForumTopicParams{ChatID: forumID, Name: name})
+	if err != nil {
+		log.Printf("Failed to create topic for session %s: %v", sessionID, err)
+		return state.Recipient{}, false
+	}
+
+	topic := state.Recipient{ChatID: forumID, ThreadID: created.MessageThreadID}
+	if err := b.state.SetSessionTopic(sessionID, topic); err != nil {
+		log.Printf("Failed to save topic for session %s: %v", sessionID, err)
+	}
+	log.Printf("Created topic %d for session %s", topic.ThreadID, sessionID)
+	return topic, true
+}
+
+// topicSession returns the session a message's topic belongs to
+func (b *Bot) topicSession(message *models.Message) string {
+	if b.state == nil || !message.IsTopicMessage {
+		return ""
+	}
+	return b.state.SessionForTopic(message.Chat.ID, message.MessageThreadID)
+}
+
+// sessionForMessage returns the session a message is for: the one owning its
+// topic, else the one active in the chat
+func (b *Bot) sessionForMessage(message *models.Message) string {
+	if sessionID := b.topicSession(message); sessionID != "" {
+		return sessionID
+	}
+	return b.GetActiveSession(message.Chat.ID)
+}
+
+// handleTopicsCommand turns topic-per-session mode on in a forum supergroup
+// with "/topics", or off with "/topics off"
+func (b *Bot) handleTopicsCommand(ctx context.Context, message *models.Message) {
+	chatID := message.Chat.ID
+	if b.state == nil {
+		b.SendMessage(ctx, chatID, "⚠️ State storage is unavailable.")
+		return
+	}
+
+	if fields := strings.Fields(message.Text); len(fields) > 1 && fields[1] == "off" {
+		if b.state.GetForumChatID() != chatID {
+			b.SendMessage(ctx, chatID, "Topics are not on in this chat.")
+			return
+		}
+		if err := b.state.SetForumChatID(0); err != nil {
+			log.Printf("Failed to save forum chat: %v", err)
+		}
+		b.SendMessage(ctx, chatID, "🧵 Topics off. Sessions share the chat again.")
+		return
+	}
+
+	if !message.Chat.IsForum {
+		b.SendMessage(ctx, chatID, "⚠️ Topics need a supergroup with **Topics** enabled in the group settings, and the bot as an admin that can manage topics.")
+		return
+	}
+	if err := b.state.SetForumChatID(chatID); err != nil {
+		log.Printf("Failed to save forum chat: %v", err)
+	}
+	b.SendMessage(ctx, chatID, "🧵 **Topics on.** Each agent session gets its own topic here: reply inside a topic to talk to its session. /sessions lists them.")
+}
+
+// sendTopicList lists the session topics with links to open them
+func (b *Bot) sendTopicList(ctx context.Context, chatID int64) {
+	var buttons [][]models.InlineKeyboardButton
+	for _, st := range b.state.GetSessionTopics() {
+		if st.Topic.ChatID != chatID {
+			continue
+		}
+		label := "🧵 " + shortSessionID(st.SessionID)
+		if b.IsSessionOnline(st.SessionID) {
+			label = "🟢 " + shortSessionID(st.SessionID)
+		}
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{Text: label, URL: topicLink(st.Topic)},
+		})
+	}
+
+	if len(buttons) == 0 {
+		b.SendMessage(ctx, chatID, "📭 No session topics yet. A topic opens when a session first writes.")
+		return
+	}
+
+	_, err := b.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        "📚 Session topics:",
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: buttons},
+	})
+	if err != nil {
+		log.Printf("Failed to send topic list: %v", err)
+	}
+}
+
+// topicLink opens a topic of a supergroup. Supergroup IDs are -100 followed
+// by the ID used in links.
+func topicLink(topic state.Recipient) string {
+	id := strings.TrimPrefix(fmt.Sprint(topic.ChatID), "-100")
+	return fmt.Sprintf("https://t.me/c/%s/%d", id, topic.ThreadID)
+}
+
+// isCommand reports whether text is the command, also when addressed to the
+// bot by name as groups do ("/sessions@ricochet_bot")
+func isCommand(text, command string) bool {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return false
+	}
+	name, _, _ := strings.Cut(fields[0], "@")
+	return name == command
+}
+
+func shortSessionID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
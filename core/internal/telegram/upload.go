@@ -0,0 +1,126 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// FileUpload is a file or photo a user sent to the bot, downloaded to LocalPath
+type FileUpload struct {
+	ChatID    int64
+	Name      string // File name as sent, or a generated one for photos
+	LocalPath string
+	MimeType  string
+}
+
+// IsImage reports whether the upload is a picture analyze_image can read
+func (f *FileUpload) IsImage() bool {
+	return strings.HasPrefix(f.MimeType, "image/")
+}
+
+// FileHandler stores an upload for the session and returns the note the
+// agent gets in place of the file
+type FileHandler func(ctx context.Context, upload *FileUpload) (string, error)
+
+// SetFileHandler sets where uploaded files end up, e.g. the workspace inbox
+func (b *Bot) SetFileHandler(fn FileHandler) {
+	b.fileHandler = fn
+}
+
+// isFileMessage reports whether a message carries a photo or a document
+func isFileMessage(message *models.Message) bool {
+	return len(message.Photo) > 0 || message.Document != nil
+}
+
+// uploadName is a safe file name for the document, or a generated one
+func uploadName(message *models.Message) string {
+	if len(message.Photo) > 0 {
+		return "photo_" + message.Photo[len(message.Photo)-1].FileUniqueID + ".jpg"
+	}
+	name := filepath.Base(strings.ReplaceAll(message.Document.FileName, "\\", "/"))
+	if name == "" || name == "." || name == "/" || name == ".." {
+		name = "file_" + message.Document.FileUniqueID
+	}
+	return name
+}
+
+// handleFile downloads an incoming photo or document and hands it to the
+// session: through the file handler when set, else as a path in the tmp dir
+func (b *Bot) handleFile(ctx context.Context, tgBot *bot.Bot, message *models.Message) {
+	chatID := message.Chat.ID
+	userID := message.From.ID
+
+	// Check if chat or user is allowed
+	if !b.canOperate(ctx, userID, chatID) {
+		return
+	}
+
+	// Photos come in several sizes, largest last; documents are sent as is
+	upload := &FileUpload{ChatID: chatID, Name: uploadName(message)}
+	var fileID, uniqueID string
+	if len(message.Photo) > 0 {
+		photo := message.Photo[len(message.Photo)-1]
+		fileID, uniqueID = photo.FileID, photo.FileUniqueID
+		upload.MimeType = "image/jpeg"
+	} else {
+		fileID, uniqueID = message.Document.FileID, message.Document.FileUniqueID
+		upload.MimeType = message.Document.MimeType
+	}
+
+	file, err := tgBot.GetFile(ctx, &bot.GetFileParams{FileID: fileID})
+	if err != nil {
+		b.SendMessage(ctx, chatID, fmt.Sprintf("❌ Error getting file: %v", err))
+		return
+	}
+
+	// Kept after the turn: the agent may come back to the file later
+	homeDir, _ := os.UserHomeDir()
+	upload.LocalPath = filepath.Join(homeDir, ".ricochet", "tmp", "telegram", uniqueID+filepath.Ext(upload.Name))
+	os.MkdirAll(filepath.Dir(upload.LocalPath), 0755)
+
+	if err := b.downloadFile(ctx, file.FilePath, upload.LocalPath); err != nil {
+		b.SendMessage(ctx, chatID, fmt.Sprintf("❌ Error downloading file: %v", err))
+		return
+	}
+
+	text, err := b.UploadNote(ctx, upload)
+	if err != nil {
+		b.SendMessage(ctx, chatID, fmt.Sprintf("❌ Error saving file: %v", err))
+		return
+	}
+	if caption := strings.TrimSpace(message.Caption); caption != "" {
+		text += "\n" + caption
+	}
+
+	sessionID := b.sessionForMessage(message)
+	if sessionID != "" {
+		b.SendToSession(sessionID, text)
+	} else {
+		b.responseCh <- &UserResponse{
+			ChatID:    chatID,
+			Text:      text,
+			Username:  message.From.Username,
+			MessageID: message.ID,
+			Timestamp: int64(message.Date),
+		}
+	}
+}
+
+// UploadNote hands a downloaded upload to the file handler and returns the
+// note the agent gets in place of the file. Without a handler the file stays
+// where it is.
+func (b *Bot) UploadNote(ctx context.Context, upload *FileUpload) (string, error) {
+	if b.fileHandler != nil {
+		return b.fileHandler(ctx, upload)
+	}
+	if upload.IsImage() {
+		return fmt.Sprintf("[Image saved to %s, use analyze_image to view it]", upload.LocalPath), nil
+	}
+	return fmt.Sprintf("[File %s saved to %s]", upload.Name, upload.LocalPath), nil
+}
@@ -0,0 +1,46 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestUploadName(t *testing.T) {
+	tests := []struct {
+		name    string
+		message *models.Message
+		want    string
+	}{
+		{
+			name: "largest photo",
+			message: &models.Message{Photo: []models.PhotoSize{
+				{FileUniqueID: "small"}, {FileUniqueID: "large"},
+			}},
+			want: "photo_large.jpg",
+		},
+		{
+			name:    "document",
+			message: &models.Message{Document: &models.Document{FileName: "sales.csv"}},
+			want:    "sales.csv",
+		},
+		{
+			name:    "path stripped",
+			message: &models.Message{Document: &models.Document{FileName: "../../etc\\passwd"}},
+			want:    "passwd",
+		},
+		{
+			name:    "no name",
+			message: &models.Message{Document: &models.Document{FileUniqueID: "abc"}},
+			want:    "file_abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uploadName(tt.message); got != tt.want {
+				t.Errorf("uploadName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/igoryan-dao/ricochet/internal/host"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+type auditArgsKey struct{}
+
+// withAuditArgs lets consent checks record a digest of the call's arguments
+func withAuditArgs(ctx context.Context, args json.RawMessage) context.Context {
+	return context.WithValue(ctx, auditArgsKey{}, safeguard.DigestArgs(args))
+}
+
+// recordApproval writes a consent decision on the current tool call to the
+// approval audit log
+func (e *NativeExecutor) recordApproval(ctx context.Context, tool, target string, approved, auto bool, approver, channel string) {
+	if e.safeguard == nil {
+		return
+	}
+	decision := safeguard.DecisionDenied
+	if approved {
+		decision = safeguard.DecisionApproved
+	}
+	digest, _ := ctx.Value(auditArgsKey{}).(string)
+	e.safeguard.RecordApproval(safeguard.AuditEntry{
+		Tool:       tool,
+		Target:     target,
+		ArgsDigest: digest,
+		Decision:   decision,
+		Auto:       auto,
+		Approver:   approver,
+		Channel:    channel,
+	})
+}
+
+// recordUserApproval records a decision the user made when asked
+func (e *NativeExecutor) recordUserApproval(ctx context.Context, tool, target string, approved, remote bool) {
+	if remote {
+		e.recordApproval(ctx, tool, target, approved, false, safeguard.ChannelTelegram, safeguard.ChannelTelegram)
+		return
+	}
+	e.recordApproval(ctx, tool, target, approved, false, safeguard.LocalUser(), host.Channel(e.host))
+}
@@ -1,38 +1,99 @@
 package tools
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image/png"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/browser"
+)
+
+const (
+	defaultBrowserTimeout = 30 * time.Second
+	maxBrowserTimeout     = 5 * time.Minute
 )
 
+// browserPage returns the chat session's persistent tab
+func (e *NativeExecutor) browserPage(ctx context.Context) (*browser.Page, error) {
+	sessionID, _ := ctx.Value("session_id").(string)
+	return e.browser.Page(sessionID, filepath.Join(e.host.GetCWD(), ".ricochet", "downloads"))
+}
+
+// browserTimeout converts a timeout in seconds, applying the default and cap
+func browserTimeout(seconds int) time.Duration {
+	if seconds <= 0 {
+		return defaultBrowserTimeout
+	}
+	return min(time.Duration(seconds)*time.Second, maxBrowserTimeout)
+}
+
+// browserTarget validates that exactly one of selector and ref is set
+func browserTarget(selector, ref string) (browser.Target, error) {
+	t := browser.Target{Selector: strings.TrimSpace(selector), Ref: strings.TrimSpace(ref)}
+	if (t.Selector == "") == (t.Ref == "") {
+		return t, fmt.Errorf("pass either selector or ref (from browser_snapshot)")
+	}
+	return t, nil
+}
+
 func (e *NativeExecutor) BrowserOpen(ctx context.Context, args json.RawMessage) (string, error) {
 	var payload struct {
-		URL string `json:"url"`
+		URL       string `json:"url"`
+		WaitUntil string `json:"wait_until"`
+		Timeout   int    `json:"timeout"`
 	}
 	if err := json.Unmarshal(args, &payload); err != nil {
 		return "", fmt.Errorf("invalid arguments: %w", err)
 	}
+	if payload.URL == "" {
+		return "", fmt.Errorf("url is required")
+	}
+	if err := e.checkEgress(payload.URL); err != nil {
+		return "", err
+	}
 
-	if err := e.browser.Navigate(ctx, payload.URL); err != nil {
+	page, err := e.browserPage(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to start browser: %w", err)
+	}
+	location, title, err := page.Navigate(ctx, payload.URL, payload.WaitUntil, browserTimeout(payload.Timeout))
+	if err != nil {
 		return "", fmt.Errorf("failed to open URL: %w", err)
 	}
 
-	return fmt.Sprintf("Successfully opened %s", payload.URL), nil
+	return fmt.Sprintf("Opened %s\nTitle: %s\nUse browser_snapshot to see the page content.", location, title), nil
 }
 
 func (e *NativeExecutor) BrowserScreenshot(ctx context.Context, args json.RawMessage) (string, error) {
 	var payload struct {
-		URL string `json:"url"`
+		URL      string `json:"url"`
+		FullPage bool   `json:"full_page"`
 	}
 	if err := json.Unmarshal(args, &payload); err != nil {
 		return "", fmt.Errorf("invalid arguments: %w", err)
 	}
 
-	data, err := e.browser.Screenshot(ctx, payload.URL)
+	if err := e.checkEgress(payload.URL); err != nil {
+		return "", err
+	}
+	page, err := e.browserPage(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to start browser: %w", err)
+	}
+	if payload.URL != "" {
+		if _, _, err := page.Navigate(ctx, payload.URL, "", defaultBrowserTimeout); err != nil {
+			return "", fmt.Errorf("failed to open URL: %w", err)
+		}
+	}
+	data, err := page.Screenshot(ctx, payload.FullPage)
 	if err != nil {
 		return "", fmt.Errorf("failed to capture screenshot: %w", err)
 	}
@@ -56,43 +117,361 @@ func (e *NativeExecutor) BrowserClick(ctx context.Context, args json.RawMessage)
 	var payload struct {
 		URL      string `json:"url"`
 		Selector string `json:"selector"`
+		Ref      string `json:"ref"`
+		Timeout  int    `json:"timeout"`
 	}
 	if err := json.Unmarshal(args, &payload); err != nil {
 		return "", fmt.Errorf("invalid arguments: %w", err)
 	}
+	target, err := browserTarget(payload.Selector, payload.Ref)
+	if err != nil {
+		return "", err
+	}
+
+	page, err := e.browserPage(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to start browser: %w", err)
+	}
+	location, err := e.browserLocation(ctx, page, payload.URL)
+	if err != nil {
+		return "", err
+	}
 
 	// INTERACTIVE CONSENT
-	desc := fmt.Sprintf("Click element '%s' on %s", payload.Selector, payload.URL)
-	if err := e.ensureConsent(ctx, "browser_click", payload.URL, desc); err != nil {
+	desc := fmt.Sprintf("Click element '%s' on %s", target, location)
+	if err := e.ensureConsent(ctx, "browser_click", location, desc); err != nil {
 		return "", err
 	}
 
-	if err := e.browser.Click(ctx, payload.URL, payload.Selector); err != nil {
+	if err := page.Click(ctx, target, browserTimeout(payload.Timeout)); err != nil {
 		return "", fmt.Errorf("failed to click element: %w", err)
 	}
 
-	return fmt.Sprintf("Successfully clicked %s on %s", payload.Selector, payload.URL), nil
+	return fmt.Sprintf("Successfully clicked %s on %s", target, location), nil
 }
 
 func (e *NativeExecutor) BrowserType(ctx context.Context, args json.RawMessage) (string, error) {
 	var payload struct {
 		URL      string `json:"url"`
 		Selector string `json:"selector"`
+		Ref      string `json:"ref"`
 		Text     string `json:"text"`
+		Clear    bool   `json:"clear"`
+		Submit   bool   `json:"submit"`
+		Timeout  int    `json:"timeout"`
 	}
 	if err := json.Unmarshal(args, &payload); err != nil {
 		return "", fmt.Errorf("invalid arguments: %w", err)
 	}
+	target, err := browserTarget(payload.Selector, payload.Ref)
+	if err != nil {
+		return "", err
+	}
+
+	page, err := e.browserPage(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to start browser: %w", err)
+	}
+	location, err := e.browserLocation(ctx, page, payload.URL)
+	if err != nil {
+		return "", err
+	}
 
 	// INTERACTIVE CONSENT
-	desc := fmt.Sprintf("Type text into '%s' on %s", payload.Selector, payload.URL)
-	if err := e.ensureConsent(ctx, "browser_type", payload.URL, desc); err != nil {
+	desc := fmt.Sprintf("Type text into '%s' on %s", target, location)
+	if err := e.ensureConsent(ctx, "browser_type", location, desc); err != nil {
 		return "", err
 	}
 
-	if err := e.browser.Type(ctx, payload.URL, payload.Selector, payload.Text); err != nil {
+	if err := page.Type(ctx, target, payload.Text, payload.Clear, payload.Submit, browserTimeout(payload.Timeout)); err != nil {
 		return "", fmt.Errorf("failed to type text: %w", err)
 	}
 
-	return fmt.Sprintf("Successfully typed text into %s on %s", payload.Selector, payload.URL), nil
+	return fmt.Sprintf("Successfully typed text into %s on %s", target, location), nil
+}
+
+// browserLocation opens url when given and returns the page's current URL
+func (e *NativeExecutor) browserLocation(ctx context.Context, page *browser.Page, url string) (string, error) {
+	if url != "" {
+		location, _, err := page.Navigate(ctx, url, "", defaultBrowserTimeout)
+		if err != nil {
+			return "", fmt.Errorf("failed to open URL: %w", err)
+		}
+		return location, nil
+	}
+	location, _, err := page.Location(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read page location: %w", err)
+	}
+	return location, nil
+}
+
+func (e *NativeExecutor) BrowserSnapshot(ctx context.Context, args json.RawMessage) (string, error) {
+	page, err := e.browserPage(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to start browser: %w", err)
+	}
+	location, title, err := page.Location(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read page location: %w", err)
+	}
+	tree, err := page.Snapshot(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read accessibility tree: %w", err)
+	}
+	return fmt.Sprintf("URL: %s\nTitle: %s\n\n%s", location, title, tree), nil
+}
+
+func (e *NativeExecutor) BrowserWait(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		Selector    string `json:"selector"`
+		State       string `json:"state"`
+		Text        string `json:"text"`
+		NetworkIdle bool   `json:"network_idle"`
+		Timeout     int    `json:"timeout"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if payload.Selector == "" && payload.Text == "" && !payload.NetworkIdle {
+		return "", fmt.Errorf("pass selector, text or network_idle")
+	}
+
+	page, err := e.browserPage(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to start browser: %w", err)
+	}
+	timeout := browserTimeout(payload.Timeout)
+	opts := browser.WaitOptions{
+		Selector:    payload.Selector,
+		State:       payload.State,
+		Text:        payload.Text,
+		NetworkIdle: payload.NetworkIdle,
+	}
+	start := time.Now()
+	if err := page.Wait(ctx, opts, timeout); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "", fmt.Errorf("condition not met within %s", timeout)
+		}
+		return "", err
+	}
+	return fmt.Sprintf("Condition met after %s.", time.Since(start).Round(10*time.Millisecond)), nil
+}
+
+func (e *NativeExecutor) BrowserLogs(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		Type       string `json:"type"`
+		ErrorsOnly bool   `json:"errors_only"`
+		Clear      bool   `json:"clear"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	var console, network bool
+	switch payload.Type {
+	case "", "all":
+		console, network = true, true
+	case "console":
+		console = true
+	case "network":
+		network = true
+	default:
+		return "", fmt.Errorf("unknown type %q (use console, network or all)", payload.Type)
+	}
+
+	page, err := e.browserPage(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to start browser: %w", err)
+	}
+	return page.Logs(console, network, payload.ErrorsOnly, payload.Clear), nil
+}
+
+func (e *NativeExecutor) BrowserDownload(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		URL      string `json:"url"`
+		Selector string `json:"selector"`
+		Ref      string `json:"ref"`
+		Timeout  int    `json:"timeout"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	var target browser.Target
+	if payload.URL == "" {
+		var err error
+		if target, err = browserTarget(payload.Selector, payload.Ref); err != nil {
+			return "", fmt.Errorf("pass url, or selector/ref of the element that starts the download")
+		}
+	} else if err := e.checkEgress(payload.URL); err != nil {
+		return "", err
+	}
+
+	page, err := e.browserPage(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to start browser: %w", err)
+	}
+	source := payload.URL
+	if source == "" {
+		location, _, err := page.Location(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to read page location: %w", err)
+		}
+		source = fmt.Sprintf("'%s' on %s", target, location)
+	}
+	if err := e.ensureConsent(ctx, "browser_download", source, "Download a file from "+source); err != nil {
+		return "", err
+	}
+
+	timeout := browserTimeout(payload.Timeout)
+	if payload.Timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	path, err := page.Download(ctx, payload.URL, target, timeout)
+	if err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	return fmt.Sprintf("Downloaded %s (%d bytes)", e.displayPath(path), info.Size()), nil
+}
+
+func (e *NativeExecutor) BrowserClose(ctx context.Context, args json.RawMessage) (string, error) {
+	sessionID, _ := ctx.Value("session_id").(string)
+	if !e.browser.ClosePage(sessionID) {
+		return "No browser page was open.", nil
+	}
+	return "Browser page closed.", nil
+}
+
+// visualNameRe matches characters not allowed in baseline file names
+var visualNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// BrowserAssertVisual screenshots a page and compares it with the stored
+// baseline. The first run (or update_baseline) records the baseline.
+func (e *NativeExecutor) BrowserAssertVisual(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		URL            string   `json:"url"`
+		Name           string   `json:"name"`
+		Selector       string   `json:"selector"`
+		Width          int      `json:"width"`
+		Height         int      `json:"height"`
+		FullPage       bool     `json:"full_page"`
+		Threshold      float64  `json:"threshold"` // Per-pixel color sensitivity, 0..1
+		Tolerance      *float64 `json:"tolerance"` // Allowed differing pixels, percent
+		UpdateBaseline bool     `json:"update_baseline"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if payload.URL == "" {
+		return "", fmt.Errorf("url is required")
+	}
+	if payload.Width <= 0 {
+		payload.Width = 1280
+	}
+	if payload.Height <= 0 {
+		payload.Height = 800
+	}
+	if payload.Threshold <= 0 || payload.Threshold > 1 {
+		payload.Threshold = 0.1
+	}
+	tolerance := 0.1
+	if payload.Tolerance != nil {
+		tolerance = max(*payload.Tolerance, 0)
+	}
+
+	name := payload.Name
+	if name == "" {
+		name = strings.TrimPrefix(strings.TrimPrefix(payload.URL, "http://"), "https://")
+		if payload.Selector != "" {
+			name += "-" + payload.Selector
+		}
+	}
+	name = strings.Trim(visualNameRe.ReplaceAllString(name, "_"), "_.")
+	if name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+
+	data, err := e.browser.CapturePNG(ctx, payload.URL, browser.CaptureOptions{
+		Width:    payload.Width,
+		Height:   payload.Height,
+		FullPage: payload.FullPage,
+		Selector: payload.Selector,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to capture screenshot: %w", err)
+	}
+	actual, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+
+	root := filepath.Join(e.host.GetCWD(), ".ricochet")
+	baselinePath := filepath.Join(root, "visual-baselines", name+".png")
+	if err := os.MkdirAll(filepath.Dir(baselinePath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create baseline directory: %w", err)
+	}
+
+	baselineData, err := os.ReadFile(baselinePath)
+	if os.IsNotExist(err) || payload.UpdateBaseline {
+		if err := os.WriteFile(baselinePath, data, 0644); err != nil {
+			return "", fmt.Errorf("failed to save baseline: %w", err)
+		}
+		return fmt.Sprintf("BASELINE SAVED: %s (%dx%d). Later calls with name %q compare against it.",
+			e.displayPath(baselinePath), actual.Bounds().Dx(), actual.Bounds().Dy(), name), nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read baseline: %w", err)
+	}
+	baseline, err := png.Decode(bytes.NewReader(baselineData))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode baseline %s: %w", baselinePath, err)
+	}
+
+	diff := browser.CompareImages(baseline, actual, payload.Threshold)
+	percent := diff.Ratio() * 100
+	if percent <= tolerance && !diff.SizeChanged {
+		return fmt.Sprintf("PASS: %.3f%% of pixels differ from the baseline (tolerance %.3f%%).", percent, tolerance), nil
+	}
+
+	outDir := filepath.Join(root, "screenshots", "visual")
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create screenshot directory: %w", err)
+	}
+	actualPath := filepath.Join(outDir, name+"-actual.png")
+	diffPath := filepath.Join(outDir, name+"-diff.png")
+	if err := os.WriteFile(actualPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to save screenshot: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, diff.Diff); err != nil {
+		return "", fmt.Errorf("failed to encode diff image: %w", err)
+	}
+	if err := os.WriteFile(diffPath, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to save diff image: %w", err)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "FAIL: %.3f%% of pixels differ from the baseline (%d of %d, tolerance %.3f%%).\n",
+		percent, diff.DiffPixels, diff.TotalPixels, tolerance)
+	if diff.SizeChanged {
+		fmt.Fprintf(&sb, "Size changed: baseline %dx%d, actual %dx%d.\n",
+			baseline.Bounds().Dx(), baseline.Bounds().Dy(), actual.Bounds().Dx(), actual.Bounds().Dy())
+	}
+	fmt.Fprintf(&sb, "Diff image (changes in red): %s\nActual: %s\nBaseline: %s\n",
+		e.displayPath(diffPath), e.displayPath(actualPath), e.displayPath(baselinePath))
+	sb.WriteString("If the change is intended, call again with update_baseline: true.")
+	return sb.String(), nil
+}
+
+// checkEgress refuses a URL the egress policy blocks before the browser tries it
+func (e *NativeExecutor) checkEgress(rawURL string) error {
+	if rawURL == "" {
+		return nil
+	}
+	if err := e.safeguard.CheckEgressURL(rawURL); err != nil {
+		return fmt.Errorf("safeguard: %w", err)
+	}
+	return nil
 }
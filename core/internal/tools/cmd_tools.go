@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/igoryan-dao/ricochet/internal/host"
 	"github.com/igoryan-dao/ricochet/internal/safeguard"
 )
 
@@ -17,6 +18,7 @@ func (e *NativeExecutor) ExecuteCommand(ctx context.Context, args json.RawMessag
 	var payload struct {
 		Command    string `json:"command"`
 		Background bool   `json:"background"`
+		Name       string `json:"name"` // Job name for background commands
 	}
 	if err := json.Unmarshal(args, &payload); err != nil {
 		return "", fmt.Errorf("invalid arguments: %w", err)
@@ -43,10 +45,30 @@ func (e *NativeExecutor) ExecuteCommand(ctx context.Context, args json.RawMessag
 		}
 	}
 
-	if !safeguard.IsSafeCommand(payload.Command) {
-		if err := e.ensureConsent(ctx, "execute_command", payload.Command, actionDesc); err != nil {
-			return "", err
+	// 2. Command policy
+	if err := e.checkCommandPolicy(ctx, "execute_command", payload.Command, actionDesc, e.safeguard.ClassifyCommand(payload.Command), false); err != nil {
+		return "", err
+	}
+
+	// Route the command's HTTP(S) traffic through the egress policy proxy
+	env, err := e.safeguard.EgressEnv()
+	if err != nil {
+		return "", fmt.Errorf("safeguard: %w", err)
+	}
+	if env != nil {
+		ctx = host.WithCommandEnv(ctx, env)
+	}
+
+	if jm, ok := e.host.(host.JobManager); ok && payload.Background {
+		res, err := jm.StartJob(ctx, payload.Command, payload.Name)
+		if err != nil {
+			return "", fmt.Errorf("execution failed: %w", err)
+		}
+		label := res.ID
+		if payload.Name != "" {
+			label = fmt.Sprintf("%s (%s)", payload.Name, res.ID)
 		}
+		return fmt.Sprintf("Command started in background as job %s.\nUse job_logs to read its output, kill_job to stop it, list_jobs to see all jobs.", label), nil
 	}
 
 	res, err := e.host.ExecuteCommand(ctx, payload.Command, payload.Background)
@@ -61,6 +83,25 @@ func (e *NativeExecutor) ExecuteCommand(ctx context.Context, args json.RawMessag
 	return res.Output, nil
 }
 
+// checkCommandPolicy approves a command rated verdict that tool runs on
+// target: safe commands follow execute_safe_commands, others
+// execute_all_commands; dangerous ones always ask and denied ones never run.
+// confirm asks even when the policy would run the command.
+func (e *NativeExecutor) checkCommandPolicy(ctx context.Context, tool, target, actionDesc string, verdict safeguard.CommandVerdict, confirm bool) error {
+	switch {
+	case verdict.Risk == safeguard.CommandDenied:
+		e.recordApproval(ctx, tool, target, false, true, "command_policy", safeguard.ChannelPolicy)
+		return fmt.Errorf("safeguard: command blocked, it %s", verdict.Reason)
+	case verdict.Risk == safeguard.CommandDangerous:
+		// Stored rules never stand in for the user on a dangerous command
+		return e.promptPathsConsent(ctx, tool, []string{target}, fmt.Sprintf("%s\n\n⚠️ This command %s.", actionDesc, verdict.Reason), "", false)
+	case confirm || !e.safeguard.AutoApprovesCommand(verdict):
+		return e.askConsent(ctx, tool, target, actionDesc)
+	}
+	e.recordApproval(ctx, tool, target, true, true, "command_policy", safeguard.ChannelPolicy)
+	return nil
+}
+
 func (e *NativeExecutor) GetCommandStatus(args json.RawMessage) (string, error) {
 	var payload struct {
 		ID string `json:"id"`
@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// defaultGraphResults caps call graph answers unless the model asks for more
+const defaultGraphResults = 20
+
+type graphQuery struct {
+	Symbol string `json:"symbol"`
+	Path   string `json:"path"`
+	Limit  int    `json:"limit"`
+}
+
+func (e *NativeExecutor) parseGraphQuery(args json.RawMessage) (graphQuery, error) {
+	var q graphQuery
+	if e.codegraph == nil {
+		return q, fmt.Errorf("the code graph is not available")
+	}
+	if err := json.Unmarshal(args, &q); err != nil {
+		return q, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultGraphResults
+	}
+	if len(e.codegraph.GetAllFiles()) == 0 {
+		return q, fmt.Errorf("the code graph is still being built; try again shortly or use grep_search")
+	}
+	return q, nil
+}
+
+// relPath shows a graph path relative to the workspace
+func (e *NativeExecutor) relPath(path string) string {
+	if rel, err := filepath.Rel(e.host.GetCWD(), path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// WhoCalls lists the functions and methods that call a symbol
+func (e *NativeExecutor) WhoCalls(args json.RawMessage) (string, error) {
+	q, err := e.parseGraphQuery(args)
+	if err != nil {
+		return "", err
+	}
+	if q.Symbol == "" {
+		return "", fmt.Errorf("symbol is required")
+	}
+
+	callers := e.codegraph.WhoCalls(q.Symbol)
+	if len(callers) == 0 {
+		return fmt.Sprintf("No callers of %s found in the code graph.", q.Symbol), nil
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d callers of %s (most central files first):\n", len(callers), q.Symbol)
+	for i, c := range callers {
+		if i == q.Limit {
+			fmt.Fprintf(&sb, "... and %d more\n", len(callers)-q.Limit)
+			break
+		}
+		fmt.Fprintf(&sb, "- %s:%d %s\n", e.relPath(c.Path), c.Line, c.Symbol)
+	}
+	sb.WriteString("Calls are matched by name, so same-named methods of other types may appear.")
+	return sb.String(), nil
+}
+
+// WhatDoesThisCall lists the calls a symbol makes and where they're defined
+func (e *NativeExecutor) WhatDoesThisCall(args json.RawMessage) (string, error) {
+	q, err := e.parseGraphQuery(args)
+	if err != nil {
+		return "", err
+	}
+	if q.Symbol == "" {
+		return "", fmt.Errorf("symbol is required")
+	}
+
+	callees, found := e.codegraph.Callees(q.Symbol)
+	if !found {
+		return fmt.Sprintf("No definition of %s found in the code graph.", q.Symbol), nil
+	}
+	if len(callees) == 0 {
+		return fmt.Sprintf("%s makes no calls.", q.Symbol), nil
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s calls %d functions:\n", q.Symbol, len(callees))
+	var external []string
+	for i, c := range callees {
+		if i == q.Limit {
+			fmt.Fprintf(&sb, "... and %d more\n", len(callees)-q.Limit)
+			break
+		}
+		if len(c.Locations) == 0 {
+			external = append(external, c.Name)
+			continue
+		}
+		locs := make([]string, 0, 3)
+		for j, loc := range c.Locations {
+			if j == 3 {
+				locs = append(locs, fmt.Sprintf("+%d more", len(c.Locations)-3))
+				break
+			}
+			locs = append(locs, fmt.Sprintf("%s:%d", e.relPath(loc.Path), loc.Line))
+		}
+		fmt.Fprintf(&sb, "- %s (%s)\n", c.Name, strings.Join(locs, ", "))
+	}
+	if len(external) > 0 {
+		fmt.Fprintf(&sb, "Not defined in the workspace (libraries, built-ins): %s\n", strings.Join(external, ", "))
+	}
+	return sb.String(), nil
+}
+
+// ImpactOfChange lists the files that may break when a file changes
+func (e *NativeExecutor) ImpactOfChange(args json.RawMessage) (string, error) {
+	q, err := e.parseGraphQuery(args)
+	if err != nil {
+		return "", err
+	}
+	if q.Path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+	path, err := e.resolvePath(q.Path)
+	if err != nil {
+		return "", err
+	}
+
+	dependents, found := e.codegraph.ImpactOf(path)
+	if !found {
+		return "", fmt.Errorf("%s is not in the code graph (unsupported language, ignored, or not yet indexed)", q.Path)
+	}
+	if len(dependents) == 0 {
+		return fmt.Sprintf("Nothing in the workspace depends on %s.", q.Path), nil
+	}
+	direct := 0
+	for _, d := range dependents {
+		if d.Distance == 1 {
+			direct++
+		}
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Changing %s may affect %d files (%d directly):\n", q.Path, len(dependents), direct)
+	for i, d := range dependents {
+		if i == q.Limit {
+			fmt.Fprintf(&sb, "... and %d more\n", len(dependents)-q.Limit)
+			break
+		}
+		how := strings.Join(d.Reasons, ", ")
+		if d.Distance > 1 {
+			how = fmt.Sprintf("%d steps away: %s", d.Distance, how)
+		}
+		fmt.Fprintf(&sb, "- %s (%s)\n", e.relPath(d.Path), how)
+	}
+	return sb.String(), nil
+}
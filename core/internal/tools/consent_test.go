@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/host"
+	"github.com/igoryan-dao/ricochet/internal/modes"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+// consentHost answers every approval request with answer
+type consentHost struct {
+	*host.NativeHost
+	answer    string
+	questions []string
+}
+
+func (h *consentHost) AskUser(question string) (string, error) {
+	h.questions = append(h.questions, question)
+	return h.answer, nil
+}
+
+// newConsentExecutor returns an executor on a fresh workspace with a
+// checkpointing safeguard, no auto-approval and a host answering "no"
+func newConsentExecutor(t *testing.T) (*NativeExecutor, *consentHost, string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	for _, k := range []string{"GIT_AUTHOR", "GIT_COMMITTER"} {
+		t.Setenv(k+"_NAME", "test")
+		t.Setenv(k+"_EMAIL", "test@example.com")
+	}
+	dir := t.TempDir()
+	sg, err := safeguard.NewManager(dir)
+	if err != nil {
+		t.Skipf("safeguard unavailable: %v", err)
+	}
+	if sg.PermissionStore == nil {
+		if sg.PermissionStore, err = safeguard.NewPermissionStore(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := &consentHost{NativeHost: host.NewNativeHost(dir), answer: "no"}
+	return &NativeExecutor{host: h, modes: modes.NewManager(dir), safeguard: sg}, h, dir
+}
+
+func TestApplyDiffAsksForEveryFile(t *testing.T) {
+	e, h, dir := newConsentExecutor(t)
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b\n"), 0644)
+	e.safeguard.PermissionStore.AddRule(safeguard.PermissionRule{Tool: "apply_diff", Path: "a.txt", Action: "allow", Scope: safeguard.ScopeProject})
+
+	diff, _ := json.Marshal(map[string]string{"diff": "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+A\n--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-b\n+B\n"})
+	if _, err := e.ApplyDiff(context.Background(), diff); err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Fatalf("diff touching an unapproved file: err = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "b.txt")); string(data) != "b\n" || len(h.questions) != 1 {
+		t.Fatalf("b.txt = %q after %d question(s)", data, len(h.questions))
+	}
+
+	h.answer = "always"
+	if _, err := e.ApplyDiff(context.Background(), diff); err != nil {
+		t.Fatal(err)
+	}
+	if !e.safeguard.PermissionStore.IsAllowed("apply_diff", "b.txt") {
+		t.Error("always did not save a rule for b.txt")
+	}
+}
+
+func TestMoveFileAsksForDestination(t *testing.T) {
+	e, h, dir := newConsentExecutor(t)
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+	e.safeguard.PermissionStore.AddRule(safeguard.PermissionRule{Tool: "move_file", Path: "a.txt", Action: "allow", Scope: safeguard.ScopeProject})
+
+	args := json.RawMessage(`{"source":"a.txt","destination":"docs/a.txt"}`)
+	if _, err := e.MoveFile(context.Background(), args); err == nil || len(h.questions) != 1 {
+		t.Fatalf("move to an unapproved destination: err = %v, %d question(s)", err, len(h.questions))
+	}
+	e.safeguard.PermissionStore.AddRule(safeguard.PermissionRule{Tool: "move_file", Path: "docs/*", Action: "allow", Scope: safeguard.ScopeProject})
+	if _, err := e.MoveFile(context.Background(), args); err != nil || len(h.questions) != 1 {
+		t.Fatalf("move allowed at both ends: err = %v, %d question(s)", err, len(h.questions))
+	}
+}
+
+func TestDangerousCommandIgnoresStoredRules(t *testing.T) {
+	e, h, _ := newConsentExecutor(t)
+	command := "rm -rf build"
+	e.safeguard.PermissionStore.AddRule(safeguard.PermissionRule{Tool: "execute_command", Path: command, Exact: true, Action: "allow", Scope: safeguard.ScopeProject})
+
+	verdict := e.safeguard.ClassifyCommand(command)
+	if verdict.Risk != safeguard.CommandDangerous {
+		t.Fatalf("%q rated %s, want dangerous", command, verdict.Risk)
+	}
+	if err := e.checkCommandPolicy(context.Background(), "execute_command", command, "Execute command: "+command, verdict, false); err == nil || len(h.questions) != 1 {
+		t.Fatalf("stored rule let a dangerous command run: err = %v after %d question(s)", err, len(h.questions))
+	}
+
+	// "Always" runs a dangerous command this time without saving a rule
+	h.answer = "always"
+	other := "rm -rf out"
+	if err := e.checkCommandPolicy(context.Background(), "execute_command", other, "Execute command: "+other, e.safeguard.ClassifyCommand(other), false); err != nil {
+		t.Fatal(err)
+	}
+	if e.safeguard.PermissionStore.IsAllowed("execute_command", other) {
+		t.Error("always saved a rule for a dangerous command")
+	}
+}
@@ -0,0 +1,310 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/igoryan-dao/ricochet/internal/modes"
+	"github.com/igoryan-dao/ricochet/internal/protocol"
+)
+
+const defaultCustomToolTimeout = 2 * time.Minute
+
+var customToolNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// CustomToolSpec is a tool declared in .ricochet/tools/*.yaml (or .json).
+// Exactly one of Command and HTTP is set. Arguments are substituted into
+// the templates as {{.name}}, escaped for where they end up: shell-quoted
+// in Command, URL-escaped in HTTP.URL and JSON-encoded in HTTP.Body.
+type CustomToolSpec struct {
+	Name        string                 `yaml:"name"`
+	Description string                 `yaml:"description"`
+	Parameters  map[string]interface{} `yaml:"parameters"` // JSON Schema of the arguments
+	Command     string                 `yaml:"command"`
+	HTTP        *CustomHTTPSpec        `yaml:"http"`
+	Timeout     int                    `yaml:"timeout"`   // Seconds
+	ReadOnly    bool                   `yaml:"read_only"` // No side effects: runs without approval, also in read-only modes. HTTP tools only.
+	Confirm     bool                   `yaml:"confirm"`   // Ask before every run
+}
+
+// CustomHTTPSpec is the request an HTTP custom tool sends. $VARS in the URL
+// and header values are expanded from the environment, e.g. for tokens.
+type CustomHTTPSpec struct {
+	Method  string            `yaml:"method"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+}
+
+// customTool adapts a spec to the dynamic tool interface
+type customTool struct {
+	spec    CustomToolSpec
+	command *template.Template
+	url     *template.Template
+	body    *template.Template
+	e       *NativeExecutor
+}
+
+// LoadCustomTools registers the tool definitions found in dir. Invalid files
+// are logged and skipped, so one bad definition doesn't hide the rest.
+func (e *NativeExecutor) LoadCustomTools(dir string) []string {
+	var files []string
+	for _, pattern := range []string{"*.yaml", "*.yml", "*.json"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	builtin := make(map[string]bool)
+	for _, def := range e.GetDefinitions() {
+		builtin[def.Name] = true
+	}
+
+	var loaded []string
+	for _, path := range files {
+		t, err := e.loadCustomTool(path)
+		if err == nil && builtin[t.spec.Name] {
+			err = fmt.Errorf("name %q is already taken by another tool", t.spec.Name)
+		}
+		if err != nil {
+			log.Printf("Warning: skipping custom tool %s: %v", filepath.Base(path), err)
+			continue
+		}
+		builtin[t.spec.Name] = true
+
+		e.RegisterTool(t)
+		category, group := CategoryExecute, "command"
+		switch {
+		case t.spec.ReadOnly && t.spec.Command != "":
+			// The command could do anything; it goes through the command policy
+			log.Printf("Warning: custom tool %s: read_only is ignored for command tools", t.spec.Name)
+		case t.spec.ReadOnly:
+			category, group = CategoryRead, "read"
+		}
+		RegisterToolCategory(t.spec.Name, category)
+		modes.RegisterToolInGroup(group, t.spec.Name)
+		loaded = append(loaded, t.spec.Name)
+	}
+	return loaded
+}
+
+func (e *NativeExecutor) loadCustomTool(path string) (*customTool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML, so one decoder handles both
+	var spec CustomToolSpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	t, err := newCustomTool(spec)
+	if err != nil {
+		return nil, err
+	}
+	t.e = e
+	return t, nil
+}
+
+func newCustomTool(spec CustomToolSpec) (*customTool, error) {
+	if !customToolNameRe.MatchString(spec.Name) {
+		return nil, fmt.Errorf("name %q must be lower_snake_case", spec.Name)
+	}
+	if spec.Description == "" {
+		return nil, fmt.Errorf("description is required")
+	}
+	if (spec.Command == "") == (spec.HTTP == nil) {
+		return nil, fmt.Errorf("set exactly one of command and http")
+	}
+	if spec.Parameters == nil {
+		spec.Parameters = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+
+	t := &customTool{spec: spec}
+	var err error
+	if spec.Command != "" {
+		if t.command, err = parseToolTemplate("command", spec.Command); err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+	if spec.HTTP.URL == "" {
+		return nil, fmt.Errorf("http.url is required")
+	}
+	if t.url, err = parseToolTemplate("url", spec.HTTP.URL); err != nil {
+		return nil, err
+	}
+	if t.body, err = parseToolTemplate("body", spec.HTTP.Body); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func parseToolTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s template: %w", name, err)
+	}
+	return t, nil
+}
+
+func (t *customTool) Definition() protocol.Tool {
+	return protocol.Tool{
+		Name:        t.spec.Name,
+		Description: t.spec.Description,
+		InputSchema: t.spec.Parameters,
+	}
+}
+
+func (t *customTool) Execute(ctx context.Context, args json.RawMessage) (string, error) {
+	var values map[string]interface{}
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &values); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	// Declared parameters the model left out render as empty values
+	if props, ok := t.spec.Parameters["properties"].(map[string]interface{}); ok {
+		for name := range props {
+			if _, ok := values[name]; !ok {
+				if values == nil {
+					values = make(map[string]interface{})
+				}
+				values[name] = nil
+			}
+		}
+	}
+
+	timeout := defaultCustomToolTimeout
+	if t.spec.Timeout > 0 {
+		timeout = time.Duration(t.spec.Timeout) * time.Second
+	}
+
+	if t.command != nil {
+		command, err := render(t.command, values, shellQuote)
+		if err != nil {
+			return "", err
+		}
+		desc := fmt.Sprintf("Run custom tool %s: %s", t.spec.Name, command)
+		if err := t.e.checkCommandPolicy(ctx, t.spec.Name, command, desc, t.e.safeguard.ClassifyCommand(command), t.spec.Confirm); err != nil {
+			return "", err
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		res, err := t.e.host.ExecuteCommand(ctx, command, false)
+		if err != nil {
+			return "", fmt.Errorf("execution failed: %w", err)
+		}
+		return res.Output, nil
+	}
+
+	target, err := render(t.url, values, func(s string) (string, error) {
+		return strings.ReplaceAll(url.QueryEscape(s), "+", "%20"), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	body, err := render(t.body, values, nil)
+	if err != nil {
+		return "", err
+	}
+	headers := make(map[string]string, len(t.spec.HTTP.Headers))
+	for k, v := range t.spec.HTTP.Headers {
+		headers[k] = os.ExpandEnv(v)
+	}
+	target = os.ExpandEnv(target)
+	if t.spec.Confirm {
+		desc := fmt.Sprintf("Run custom tool %s: %s %s", t.spec.Name, t.spec.HTTP.Method, target)
+		if err := t.e.ensureConsent(ctx, t.spec.Name, target, desc); err != nil {
+			return "", err
+		}
+	}
+
+	// Reuse http_request so domain rules, redirects and output match it
+	req, err := json.Marshal(map[string]interface{}{
+		"method":  t.spec.HTTP.Method,
+		"url":     target,
+		"headers": headers,
+		"body":    body,
+		"timeout": int(timeout.Seconds()),
+	})
+	if err != nil {
+		return "", err
+	}
+	return t.e.HTTPRequest(ctx, req)
+}
+
+// render executes tmpl with every argument passed through escape, or
+// JSON-encoded when escape is nil
+func render(tmpl *template.Template, values map[string]interface{}, escape func(string) (string, error)) (string, error) {
+	data := make(map[string]string, len(values))
+	for k, v := range values {
+		if escape == nil {
+			enc, err := json.Marshal(v)
+			if err != nil {
+				return "", fmt.Errorf("argument %s: %w", k, err)
+			}
+			data[k] = string(enc)
+			continue
+		}
+		var s string
+		switch v := v.(type) {
+		case nil:
+		case string:
+			s = v
+		case float64:
+			s = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			s = strconv.FormatBool(v)
+		default:
+			enc, _ := json.Marshal(v)
+			s = string(enc)
+		}
+		escaped, err := escape(s)
+		if err != nil {
+			return "", fmt.Errorf("argument %s: %w", k, err)
+		}
+		data[k] = escaped
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("%s template: %w", tmpl.Name(), err)
+	}
+	return sb.String(), nil
+}
+
+// shellQuote quotes s as one argument of a shell command
+func shellQuote(s string) (string, error) {
+	return quoteShellArg(runtime.GOOS, s)
+}
+
+// quoteShellArg quotes s for the shells commands run in on goos. POSIX
+// shells get single quotes. On Windows the shell may be cmd.exe, PowerShell
+// or sh, which share no escaping rules, so s is double-quoted and characters
+// any of them would interpret are refused.
+func quoteShellArg(goos, s string) (string, error) {
+	if goos != "windows" {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'", nil
+	}
+	if i := strings.IndexAny(s, "\"%^&|<>`$!\r\n"); i >= 0 {
+		return "", fmt.Errorf("%q cannot be passed safely to a Windows shell", s[i])
+	}
+	if strings.HasSuffix(s, `\`) {
+		return "", fmt.Errorf("a trailing backslash cannot be passed safely to a Windows shell")
+	}
+	return `"` + s + `"`, nil
+}
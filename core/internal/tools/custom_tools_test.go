@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+	"github.com/igoryan-dao/ricochet/internal/host"
+	"github.com/igoryan-dao/ricochet/internal/modes"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+func TestCustomTools(t *testing.T) {
+	var gotPath, gotBody, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotBody, gotAuth = r.URL.RawPath, string(body), r.Header.Get("Authorization")
+		w.Write([]byte("created"))
+	}))
+	defer srv.Close()
+	t.Setenv("TICKET_TOKEN", "s3cret")
+
+	dir := t.TempDir()
+	toolsDir := filepath.Join(dir, ".ricochet", "tools")
+	os.MkdirAll(toolsDir, 0755)
+	os.WriteFile(filepath.Join(toolsDir, "greet.yaml"), []byte(`
+name: greet
+description: Print a greeting
+parameters:
+  type: object
+  properties:
+    who: {type: string}
+command: echo hello {{.who}}
+`), 0644)
+	os.WriteFile(filepath.Join(toolsDir, "ticket.json"), []byte(`{
+  "name": "create_ticket",
+  "description": "Open a ticket",
+  "http": {
+    "method": "POST",
+    "url": "`+srv.URL+`/boards/{{.board}}/tickets",
+    "headers": {"Authorization": "Bearer $TICKET_TOKEN"},
+    "body": "{\"title\": {{.title}}}"
+  }
+}`), 0644)
+	os.WriteFile(filepath.Join(toolsDir, "clash.yaml"), []byte("name: read_file\ndescription: x\ncommand: cat\n"), 0644)
+
+	sg := &safeguard.Manager{AutoApproval: &config.AutoApprovalSettings{Enabled: true, ExecuteAllCommands: true}}
+	e := NewNativeExecutor(host.NewNativeHost(dir), modes.NewManager(dir), sg, nil, nil, nil, nil)
+	loaded := e.LoadCustomTools(toolsDir)
+	if strings.Join(loaded, ",") != "greet,create_ticket" {
+		t.Fatalf("loaded %v, want greet and create_ticket (read_file is taken)", loaded)
+	}
+	if GetToolCategory("greet") != CategoryExecute {
+		t.Errorf("greet category = %s", GetToolCategory("greet"))
+	}
+
+	out, err := e.Execute(context.Background(), "greet", []byte(`{"who": "it's me; echo pwned"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out) != "hello it's me; echo pwned" {
+		t.Errorf("command output %q: argument was not quoted", out)
+	}
+
+	out, err = e.Execute(context.Background(), "create_ticket", []byte(`{"board": "a/b c", "title": "Say \"hi\""}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "created") {
+		t.Errorf("unexpected response %q", out)
+	}
+	if gotPath != "/boards/a%2Fb%20c/tickets" {
+		t.Errorf("path %q", gotPath)
+	}
+	if gotBody != `{"title": "Say \"hi\""}` {
+		t.Errorf("body %q", gotBody)
+	}
+	if gotAuth != "Bearer s3cret" {
+		t.Errorf("auth header %q", gotAuth)
+	}
+}
+
+func TestCustomCommandToolIgnoresReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	toolsDir := filepath.Join(dir, ".ricochet", "tools")
+	os.MkdirAll(toolsDir, 0755)
+	os.WriteFile(filepath.Join(toolsDir, "wipe.yaml"), []byte("name: wipe_cache\ndescription: x\nread_only: true\ncommand: rm -rf build\n"), 0644)
+
+	sg := &safeguard.Manager{AutoApproval: &config.AutoApprovalSettings{Enabled: true, ExecuteSafeCommands: true}}
+	e := NewNativeExecutor(host.NewNativeHost(dir), modes.NewManager(dir), sg, nil, nil, nil, nil)
+	e.LoadCustomTools(toolsDir)
+	if GetToolCategory("wipe_cache") != CategoryExecute {
+		t.Errorf("wipe_cache category = %s, read_only must not apply to command tools", GetToolCategory("wipe_cache"))
+	}
+	if _, err := e.Execute(context.Background(), "wipe_cache", []byte(`{}`)); err == nil {
+		t.Fatal("command ran without the user's approval")
+	}
+}
+
+func TestQuoteShellArg(t *testing.T) {
+	if got, _ := quoteShellArg("linux", "it's"); got != `'it'\''s'` {
+		t.Errorf("posix quoting: %s", got)
+	}
+	if got, err := quoteShellArg("windows", `C:\some dir\file.txt`); err != nil || got != `"C:\some dir\file.txt"` {
+		t.Errorf("windows quoting: %s, %v", got, err)
+	}
+	for _, arg := range []string{`a" & calc`, "%PATH%", "a|b", "$(whoami)", "line\nbreak", `dir\`} {
+		if got, err := quoteShellArg("windows", arg); err == nil {
+			t.Errorf("%q was quoted as %s on windows, want an error", arg, got)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/igoryan-dao/ricochet/internal/host"
+)
+
+// maxClipboardRead caps how much clipboard text is returned to the model
+const maxClipboardRead = 32 * 1024
+
+func (e *NativeExecutor) desktop() (host.Desktop, error) {
+	d, ok := e.host.(host.Desktop)
+	if !ok {
+		return nil, fmt.Errorf("clipboard and desktop notifications are only available when running locally")
+	}
+	return d, nil
+}
+
+func (e *NativeExecutor) CopyToClipboard(args json.RawMessage) (string, error) {
+	var payload struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if payload.Text == "" {
+		return "", fmt.Errorf("text is required")
+	}
+
+	d, err := e.desktop()
+	if err != nil {
+		return "", err
+	}
+	if err := d.WriteClipboard(payload.Text); err != nil {
+		return "", fmt.Errorf("failed to copy to clipboard: %w", err)
+	}
+	return fmt.Sprintf("Copied %d characters to the clipboard.", utf8.RuneCountInString(payload.Text)), nil
+}
+
+func (e *NativeExecutor) ReadClipboard(ctx context.Context) (string, error) {
+	d, err := e.desktop()
+	if err != nil {
+		return "", err
+	}
+	// The clipboard may hold passwords or tokens, so always ask
+	if err := e.ensureConsent(ctx, "read_clipboard", "", "Read the contents of your clipboard"); err != nil {
+		return "", err
+	}
+
+	text, err := d.ReadClipboard()
+	if err != nil {
+		return "", fmt.Errorf("failed to read clipboard: %w", err)
+	}
+	if text == "" {
+		return "The clipboard is empty.", nil
+	}
+	if len(text) > maxClipboardRead {
+		cut := maxClipboardRead
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		return text[:cut] + fmt.Sprintf("\n... (truncated, %d bytes total)", len(text)), nil
+	}
+	return text, nil
+}
+
+func (e *NativeExecutor) SendDesktopNotification(args json.RawMessage) (string, error) {
+	var payload struct {
+		Title   string `json:"title"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if payload.Message == "" {
+		return "", fmt.Errorf("message is required")
+	}
+	if payload.Title == "" {
+		payload.Title = "Ricochet"
+	}
+
+	d, err := e.desktop()
+	if err != nil {
+		return "", err
+	}
+	if err := d.Notify(payload.Title, payload.Message); err != nil {
+		return "", fmt.Errorf("failed to send notification: %w", err)
+	}
+	return "Notification sent.", nil
+}
@@ -0,0 +1,362 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Unified diff parsing and hunk application for apply_diff.
+// Hunks are located by their context lines rather than trusting line numbers:
+// exact match first, then ignoring whitespace, then the most similar window.
+
+// minHunkSimilarity is the share of old lines a fuzzy match must agree on
+const minHunkSimilarity = 0.8
+
+type diffHunk struct {
+	oldStart int      // 1-based line from the @@ header; 0 if absent
+	old      []string // Context and removed lines
+	new      []string // Context and added lines
+	adds     int
+	dels     int
+}
+
+type fileDiff struct {
+	oldPath string // "" when the diff has no --- header
+	newPath string
+	hunks   []diffHunk
+}
+
+// isNew reports a diff creating the file (--- /dev/null)
+func (fd fileDiff) isNew() bool {
+	return fd.oldPath == "/dev/null"
+}
+
+// path is the file the diff applies to
+func (fd fileDiff) path() string {
+	if fd.newPath != "" && fd.newPath != "/dev/null" {
+		return fd.newPath
+	}
+	if fd.oldPath != "/dev/null" {
+		return fd.oldPath
+	}
+	return ""
+}
+
+// DiffPaths lists the files apply_diff would edit with diff, path naming the
+// file of a single-file diff as in ApplyDiff. It returns nil when the diff is
+// invalid or a file cannot be told.
+func DiffPaths(diff, path string) []string {
+	files, err := parseUnifiedDiff(diff)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, fd := range files {
+		p := fd.path()
+		if p == "" || (path != "" && len(files) == 1) {
+			p = path
+		}
+		if p == "" {
+			return nil
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parseUnifiedDiff splits a unified diff into per-file hunks. File headers are
+// optional for a single-file diff, and bare "@@" hunk headers without line
+// numbers are accepted.
+func parseUnifiedDiff(text string) ([]fileDiff, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+
+	var files []fileDiff
+	var cur *fileDiff
+	var hunk *diffHunk
+
+	flushHunk := func() {
+		if hunk != nil && cur != nil {
+			cur.hunks = append(cur.hunks, *hunk)
+		}
+		hunk = nil
+	}
+	flushFile := func() {
+		flushHunk()
+		if cur != nil {
+			files = append(files, *cur)
+		}
+		cur = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			flushFile()
+			cur = &fileDiff{
+				oldPath: diffHeaderPath(line[4:]),
+				newPath: diffHeaderPath(lines[i+1][4:]),
+			}
+			i++
+		case strings.HasPrefix(line, "@@"):
+			flushHunk()
+			if cur == nil {
+				cur = &fileDiff{}
+			}
+			hunk = &diffHunk{}
+			if m := hunkHeaderRe.FindStringSubmatch(line); m != nil {
+				hunk.oldStart, _ = strconv.Atoi(m[1])
+			}
+		case hunk == nil:
+			// Preamble ("diff --git", "index ...", prose) outside any hunk
+		case strings.HasPrefix(line, "+"):
+			hunk.new = append(hunk.new, line[1:])
+			hunk.adds++
+		case strings.HasPrefix(line, "-"):
+			hunk.old = append(hunk.old, line[1:])
+			hunk.dels++
+		case strings.HasPrefix(line, " "):
+			hunk.old = append(hunk.old, line[1:])
+			hunk.new = append(hunk.new, line[1:])
+		case line == "":
+			// Editors and models often strip the space from blank context lines
+			hunk.old = append(hunk.old, "")
+			hunk.new = append(hunk.new, "")
+		case strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file"
+		default:
+			return nil, fmt.Errorf("line %d: unexpected %q inside a hunk; every hunk line must start with ' ', '+' or '-'", i+1, truncate(line, 40))
+		}
+	}
+	flushFile()
+
+	var out []fileDiff
+	for _, fd := range files {
+		var hunks []diffHunk
+		for _, h := range fd.hunks {
+			if h.adds > 0 || h.dels > 0 {
+				hunks = append(hunks, h)
+			}
+		}
+		if len(hunks) > 0 {
+			fd.hunks = hunks
+			out = append(out, fd)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no hunks found; expected a unified diff with @@ hunk headers")
+	}
+	return out, nil
+}
+
+// diffHeaderPath strips the a/ b/ prefixes and timestamps from a ---/+++ path
+func diffHeaderPath(s string) string {
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return s
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		s = s[2:]
+	}
+	return s
+}
+
+// DiffStats counts added and removed lines in a unified diff
+func DiffStats(diff string) (additions, deletions int) {
+	files, err := parseUnifiedDiff(diff)
+	if err != nil {
+		return 0, 0
+	}
+	for _, fd := range files {
+		for _, h := range fd.hunks {
+			additions += h.adds
+			deletions += h.dels
+		}
+	}
+	return additions, deletions
+}
+
+// applyResult describes how a file's hunks were applied
+type applyResult struct {
+	content string
+	fuzzy   []string // Notes for hunks that needed fuzzy matching
+}
+
+// applyHunks applies hunks in order to content. Each hunk is searched for
+// after the previous one; ambiguous or missing context is an error naming the
+// hunk so the diff can be regenerated.
+func applyHunks(content string, hunks []diffHunk) (applyResult, error) {
+	crlf := strings.Contains(content, "\r\n")
+	if crlf {
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+	}
+	trailingNewline := strings.HasSuffix(content, "\n")
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	var res applyResult
+	from := 0   // Hunks apply in order: search after the previous one
+	offset := 0 // Net lines added by earlier hunks, to adjust header line numbers
+	for n, h := range hunks {
+		num := n + 1
+		if len(h.old) == 0 {
+			if len(hunks) > 1 || len(lines) > 0 && h.oldStart == 0 {
+				return res, fmt.Errorf("hunk %d has no context lines; include a few unchanged lines around the change", num)
+			}
+			at := min(max(h.oldStart+offset, 0), len(lines))
+			lines = splice(lines, at, 0, h.new)
+			offset += len(h.new)
+			from = at + len(h.new)
+			continue
+		}
+
+		hint := -1
+		if h.oldStart > 0 {
+			hint = h.oldStart - 1 + offset
+		}
+		pos, note, err := locateHunk(lines, h.old, from, hint)
+		if err != nil {
+			return res, fmt.Errorf("hunk %d (%s): %w", num, hunkLabel(h), err)
+		}
+		if note != "" {
+			res.fuzzy = append(res.fuzzy, fmt.Sprintf("hunk %d %s at line %d", num, note, pos+1))
+		}
+
+		lines = splice(lines, pos, len(h.old), h.new)
+		offset += len(h.new) - len(h.old)
+		from = pos + len(h.new)
+	}
+
+	out := strings.Join(lines, "\n")
+	if trailingNewline || (content == "" && len(lines) > 0) {
+		out += "\n"
+	}
+	if crlf {
+		out = strings.ReplaceAll(out, "\n", "\r\n")
+	}
+	res.content = out
+	return res, nil
+}
+
+// locateHunk finds where old occurs in lines at or after from. Line-number
+// hints only break ties between otherwise identical matches.
+func locateHunk(lines, old []string, from, hint int) (int, string, error) {
+	passes := []struct {
+		note string
+		norm func(string) string
+	}{
+		{"", func(s string) string { return s }},
+		{"matched ignoring trailing whitespace", func(s string) string { return strings.TrimRight(s, " \t") }},
+		{"matched ignoring whitespace", func(s string) string { return strings.Join(strings.Fields(s), " ") }},
+	}
+	for _, p := range passes {
+		matches := findAll(lines, old, from, p.norm)
+		switch {
+		case len(matches) == 1:
+			return matches[0], p.note, nil
+		case len(matches) > 1:
+			for _, m := range matches {
+				if m == hint {
+					return m, p.note, nil
+				}
+			}
+			return 0, "", fmt.Errorf("context matches %d places (lines %s); add more unchanged lines so it is unique",
+				len(matches), lineList(matches))
+		}
+	}
+
+	// Most similar window, for context that drifted slightly
+	norm := passes[2].norm
+	best, bestScore, ties := -1, 0.0, 0
+	for i := from; i+len(old) <= len(lines); i++ {
+		same := 0
+		for j := range old {
+			if norm(lines[i+j]) == norm(old[j]) {
+				same++
+			}
+		}
+		score := float64(same) / float64(len(old))
+		switch {
+		case score > bestScore:
+			best, bestScore, ties = i, score, 1
+		case score == bestScore && score > 0:
+			ties++
+			if i == hint {
+				best = i
+			}
+		}
+	}
+	if best < 0 || bestScore < minHunkSimilarity {
+		if best >= 0 {
+			return 0, "", fmt.Errorf("context not found; closest match is line %d (%.0f%% similar). Re-read the file and regenerate the diff",
+				best+1, bestScore*100)
+		}
+		return 0, "", fmt.Errorf("context not found. Re-read the file and regenerate the diff")
+	}
+	if ties > 1 && best != hint {
+		return 0, "", fmt.Errorf("context is %.0f%% similar to %d places; add more unchanged lines so it is unique", bestScore*100, ties)
+	}
+	return best, fmt.Sprintf("matched %.0f%% of context", bestScore*100), nil
+}
+
+func findAll(lines, old []string, from int, norm func(string) string) []int {
+	var matches []int
+	for i := max(from, 0); i+len(old) <= len(lines); i++ {
+		ok := true
+		for j := range old {
+			if norm(lines[i+j]) != norm(old[j]) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+func splice(lines []string, at, remove int, insert []string) []string {
+	out := make([]string, 0, len(lines)-remove+len(insert))
+	out = append(out, lines[:at]...)
+	out = append(out, insert...)
+	return append(out, lines[at+remove:]...)
+}
+
+func hunkLabel(h diffHunk) string {
+	for _, l := range h.old {
+		if s := strings.TrimSpace(l); s != "" {
+			return fmt.Sprintf("starting %q", truncate(s, 40))
+		}
+	}
+	return fmt.Sprintf("@@ -%d", h.oldStart)
+}
+
+func lineList(idx []int) string {
+	parts := make([]string, 0, len(idx))
+	for i, n := range idx {
+		if i == 5 {
+			parts = append(parts, "...")
+			break
+		}
+		parts = append(parts, strconv.Itoa(n+1))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+const diffSource = `package main
+
+func a() {
+	return 1
+}
+
+func b() {
+	return 1
+}
+`
+
+func TestApplyDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		diff    string
+		want    string
+		fuzzy   bool
+		errLike string
+	}{
+		{
+			name:    "exact",
+			content: diffSource,
+			diff: `--- a/main.go
++++ b/main.go
+@@ -7,3 +7,3 @@
+ func b() {
+-	return 1
++	return 2
+ }
+`,
+			want: strings.Replace(diffSource, "func b() {\n\treturn 1", "func b() {\n\treturn 2", 1),
+		},
+		{
+			name:    "wrong line numbers",
+			content: diffSource,
+			diff: `@@ -40,2 +40,3 @@
+ func a() {
++	println("a")
+ 	return 1
+`,
+			want: strings.Replace(diffSource, "func a() {\n", "func a() {\n\tprintln(\"a\")\n", 1),
+		},
+		{
+			name:    "whitespace drift",
+			content: diffSource,
+			diff: `@@
+ func a() {
+-    return 1
++    return 3
+ }
+`,
+			want:  strings.Replace(diffSource, "func a() {\n\treturn 1", "func a() {\n    return 3", 1),
+			fuzzy: true,
+		},
+		{
+			name:    "ambiguous",
+			content: diffSource,
+			diff: `@@
+-	return 1
++	return 0
+ }
+`,
+			errLike: "matches 2 places",
+		},
+		{
+			name:    "header breaks tie",
+			content: diffSource,
+			diff: `@@ -8,2 +8,2 @@
+-	return 1
++	return 0
+ }
+`,
+			want: strings.Replace(diffSource, "func b() {\n\treturn 1", "func b() {\n\treturn 0", 1),
+		},
+		{
+			name:    "context not found",
+			content: diffSource,
+			diff: `@@
+ func c() {
+-	return 1
++	return 0
+ }
+`,
+			errLike: "closest match",
+		},
+		{
+			name:    "crlf preserved",
+			content: "one\r\ntwo\r\nthree\r\n",
+			diff:    "@@\n one\n-two\n+2\n three\n",
+			want:    "one\r\n2\r\nthree\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := parseUnifiedDiff(tt.diff)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			res, err := applyHunks(tt.content, files[0].hunks)
+			if tt.errLike != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errLike) {
+					t.Fatalf("err = %v, want %q", err, tt.errLike)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.content != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", res.content, tt.want)
+			}
+			if got := len(res.fuzzy) > 0; got != tt.fuzzy {
+				t.Errorf("fuzzy = %v (%v), want %v", got, res.fuzzy, tt.fuzzy)
+			}
+		})
+	}
+}
+
+func TestDiffStats(t *testing.T) {
+	diff := `--- a/x.go
++++ b/x.go
+@@ -1,2 +1,3 @@
+ a
+-b
++c
++d
+--- /dev/null
++++ b/y.go
+@@ -0,0 +1 @@
++new
+`
+	files, err := parseUnifiedDiff(diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].path() != "x.go" || !files[1].isNew() || files[1].path() != "y.go" {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+	if adds, dels := DiffStats(diff); adds != 3 || dels != 1 {
+		t.Errorf("stats = +%d -%d, want +3 -1", adds, dels)
+	}
+}
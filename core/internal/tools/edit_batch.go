@@ -0,0 +1,212 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+// batchEdit is one change in an edit_files batch. Exactly one of
+// TargetContent/ReplacementContent, Content or Diff is used.
+type batchEdit struct {
+	Path               string  `json:"path"`
+	TargetContent      string  `json:"TargetContent"`
+	ReplacementContent string  `json:"ReplacementContent"`
+	Content            *string `json:"content"` // Whole-file content (new files or rewrites)
+	Diff               string  `json:"diff"`    // Unified diff for this file
+}
+
+// stagedFile is a file's original and pending content within a batch
+type stagedFile struct {
+	path     string
+	original []byte
+	existed  bool
+	content  string
+}
+
+// EditFiles applies a batch of edits across files atomically: every edit is
+// staged in memory, the result is shadow-verified through a temp overlay, and
+// only then written under a single checkpoint. Any failure leaves all files
+// as they were.
+func (e *NativeExecutor) EditFiles(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		Edits []batchEdit `json:"edits"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if len(payload.Edits) == 0 {
+		return "", fmt.Errorf("edits cannot be empty")
+	}
+
+	// 1. Stage: apply edits in order; several edits may target the same file
+	staged := make(map[string]*stagedFile)
+	var order []string
+	for i, edit := range payload.Edits {
+		if edit.Path == "" {
+			return "", fmt.Errorf("edit %d: path is required", i+1)
+		}
+		// "a.go" and "./a.go" are the same file
+		edit.Path = filepath.Clean(edit.Path)
+		f, ok := staged[edit.Path]
+		if !ok {
+			if allowed, msg := e.modes.CanAccessFile(edit.Path); !allowed {
+				return "", fmt.Errorf("permission denied: %s", msg)
+			}
+			if err := e.checkAccess(edit.Path, true); err != nil {
+				return "", err
+			}
+			f = &stagedFile{path: edit.Path}
+			if data, err := e.host.ReadFile(edit.Path); err == nil {
+				f.original, f.existed, f.content = data, true, string(data)
+			}
+			staged[edit.Path] = f
+			order = append(order, edit.Path)
+		}
+
+		content, err := applyBatchEdit(f, edit)
+		if err != nil {
+			return "", fmt.Errorf("edit %d (%s): %w. No files were changed", i+1, edit.Path, err)
+		}
+		f.content = content
+	}
+
+	// 2. Shadow-verify the staged result before anything touches the workspace
+	bypassCorrection := false
+	if e.safeguard != nil && e.safeguard.ToolsSettings != nil {
+		bypassCorrection = e.safeguard.ToolsSettings.DisableLLMCorrection
+	}
+	if e.shadowVerifier != nil && !bypassCorrection {
+		if err := e.verifyStaged(ctx, staged); err != nil {
+			return "", fmt.Errorf("verification failed, no files were changed: %w. Please fix the edits", err)
+		}
+	}
+
+	// 3. Consent and a single checkpoint for the whole batch
+	if err := e.ensurePathsConsent(ctx, "edit_files", order, fmt.Sprintf("Edit %d file(s): %s", len(order), strings.Join(order, ", ")), ""); err != nil {
+		return "", err
+	}
+	if e.safeguard != nil {
+		msg := fmt.Sprintf("Checkpoint before edit_files (%d files)", len(order))
+		if _, err := e.safeguard.CreateCheckpoint(msg); err != nil {
+			return "", fmt.Errorf("failed to create checkpoint: %w", err)
+		}
+	} else {
+		for _, path := range order {
+			if err := safeguard.Backup(e.host.GetCWD() + "/" + path); err != nil {
+				return "", fmt.Errorf("safeguard backup failed: %w", err)
+			}
+		}
+	}
+
+	// 4. Commit, rolling back written files if any write fails
+	var written []*stagedFile
+	for _, path := range order {
+		f := staged[path]
+		if err := e.host.WriteFile(path, []byte(f.content)); err != nil {
+			if rbErr := e.rollback(written); rbErr != nil {
+				return "", fmt.Errorf("write %s failed: %w; rollback incomplete: %v", path, err, rbErr)
+			}
+			return "", fmt.Errorf("write %s failed: %w. All files were rolled back", path, err)
+		}
+		written = append(written, f)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Applied %d edit(s) to %d file(s):\n", len(payload.Edits), len(order)))
+	for _, path := range order {
+		if staged[path].existed {
+			sb.WriteString("- " + path + "\n")
+		} else {
+			sb.WriteString("- " + path + " (created)\n")
+		}
+	}
+	return sb.String(), nil
+}
+
+// applyBatchEdit returns f's content with edit applied
+func applyBatchEdit(f *stagedFile, edit batchEdit) (string, error) {
+	switch {
+	case edit.Diff != "":
+		files, err := parseUnifiedDiff(edit.Diff)
+		if err != nil {
+			return "", fmt.Errorf("invalid diff: %w", err)
+		}
+		if len(files) != 1 {
+			return "", fmt.Errorf("diff must cover exactly one file, got %d", len(files))
+		}
+		if !f.existed && !files[0].isNew() && f.content == "" {
+			return "", fmt.Errorf("file does not exist")
+		}
+		res, err := applyHunks(f.content, files[0].hunks)
+		if err != nil {
+			return "", err
+		}
+		return res.content, nil
+
+	case edit.Content != nil:
+		return *edit.Content, nil
+
+	case edit.TargetContent != "":
+		if !strings.Contains(f.content, edit.TargetContent) {
+			return "", fmt.Errorf("TargetContent not found in file. Please ensure exact match including whitespace")
+		}
+		if strings.Count(f.content, edit.TargetContent) > 1 {
+			return "", fmt.Errorf("TargetContent found multiple times. Please provide more context to make it unique")
+		}
+		return strings.Replace(f.content, edit.TargetContent, edit.ReplacementContent, 1), nil
+
+	default:
+		return "", fmt.Errorf("one of TargetContent, content or diff is required")
+	}
+}
+
+// verifyStaged writes staged content to a temp dir and runs the shadow
+// verifier against it as an overlay of the real paths
+func (e *NativeExecutor) verifyStaged(ctx context.Context, staged map[string]*stagedFile) error {
+	dir, err := os.MkdirTemp("", "ricochet-edit-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	overlay := make(map[string]string, len(staged))
+	i := 0
+	for path, f := range staged {
+		abs, _ := e.resolvePath(path)
+		tmp := filepath.Join(dir, fmt.Sprintf("%d%s", i, filepath.Ext(path)))
+		if err := os.WriteFile(tmp, []byte(f.content), 0644); err != nil {
+			return err
+		}
+		overlay[abs] = tmp
+		i++
+	}
+	return e.verifier().VerifyOverlay(ctx, overlay)
+}
+
+// rollback restores written files to their original content, removing files
+// the batch created
+func (e *NativeExecutor) rollback(written []*stagedFile) error {
+	var errs []string
+	for _, f := range written {
+		var err error
+		if f.existed {
+			err = e.host.WriteFile(f.path, f.original)
+		} else {
+			abs, _ := e.resolvePath(f.path)
+			err = os.Remove(abs)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", f.path, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/host"
+	"github.com/igoryan-dao/ricochet/internal/modes"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+func newBatchTestExecutor(t *testing.T) (*NativeExecutor, string) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/batch\n\ngo 1.21\n",
+		"a.go":   "package batch\n\nfunc Old() int { return 1 }\n",
+		"b.go":   "package batch\n\nfunc Use() int { return Old() }\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &NativeExecutor{
+		host:           host.NewNativeHost(dir),
+		modes:          modes.NewManager(dir),
+		shadowVerifier: safeguard.NewShadowVerifier(),
+	}, dir
+}
+
+func editArgs(t *testing.T, edits ...batchEdit) json.RawMessage {
+	data, err := json.Marshal(map[string]interface{}{"edits": edits})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func assertUnchanged(t *testing.T, dir string) {
+	t.Helper()
+	a, _ := os.ReadFile(filepath.Join(dir, "a.go"))
+	b, _ := os.ReadFile(filepath.Join(dir, "b.go"))
+	if !strings.Contains(string(a), "func Old()") || !strings.Contains(string(b), "return Old()") {
+		t.Errorf("files changed after a failed batch:\n%s\n%s", a, b)
+	}
+}
+
+func TestEditFilesStagingFailureChangesNothing(t *testing.T) {
+	e, dir := newBatchTestExecutor(t)
+	_, err := e.EditFiles(context.Background(), editArgs(t,
+		batchEdit{Path: "a.go", TargetContent: "func Old()", ReplacementContent: "func New()"},
+		batchEdit{Path: "b.go", TargetContent: "missing", ReplacementContent: "New()"},
+	))
+	if err == nil || !strings.Contains(err.Error(), "edit 2") {
+		t.Fatalf("err = %v, want failure on edit 2", err)
+	}
+	assertUnchanged(t, dir)
+}
+
+func TestEditFilesVerificationFailureChangesNothing(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+	e, dir := newBatchTestExecutor(t)
+	// Renaming without updating the caller must fail vet on the staged overlay
+	_, err := e.EditFiles(context.Background(), editArgs(t,
+		batchEdit{Path: "a.go", TargetContent: "func Old()", ReplacementContent: "func New()"},
+	))
+	if err == nil || !strings.Contains(err.Error(), "verification failed") {
+		t.Fatalf("err = %v, want verification failure", err)
+	}
+	assertUnchanged(t, dir)
+}
+
+func TestEditFilesNewPackage(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+	e, dir := newBatchTestExecutor(t)
+	t.Setenv("HOME", t.TempDir())
+	e.host = &consentHost{NativeHost: host.NewNativeHost(dir), answer: "yes"}
+	content := "package sub\n\nfunc Value() int { return 1 }\n"
+	// Both edits name the same file, and its package directory is new
+	_, err := e.EditFiles(context.Background(), editArgs(t,
+		batchEdit{Path: "./sub/c.go", Content: &content},
+		batchEdit{Path: "sub/c.go", TargetContent: "return 1", ReplacementContent: "return 2"},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "sub", "c.go")); !strings.Contains(string(data), "return 2") {
+		t.Errorf("sub/c.go = %q", data)
+	}
+
+	broken := "package sub\n\nfunc Broken() int { return \"x\" }\n"
+	if _, err := e.EditFiles(context.Background(), editArgs(t, batchEdit{Path: "other/d.go", Content: &broken})); err == nil || !strings.Contains(err.Error(), "verification failed") {
+		t.Errorf("broken new package: err = %v", err)
+	}
+}
+
+func TestEditFilesAsksForEveryFile(t *testing.T) {
+	e, h, dir := newConsentExecutor(t)
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+	e.safeguard.PermissionStore.AddRule(safeguard.PermissionRule{Tool: "edit_files", Path: "a.txt", Action: "allow", Scope: safeguard.ScopeProject})
+
+	content := "b\n"
+	_, err := e.EditFiles(context.Background(), editArgs(t,
+		batchEdit{Path: "a.txt", TargetContent: "a", ReplacementContent: "A"},
+		batchEdit{Path: "b.txt", Content: &content},
+	))
+	if err == nil || !strings.Contains(err.Error(), "rejected") || len(h.questions) != 1 {
+		t.Fatalf("batch touching an unapproved file: err = %v, %d question(s)", err, len(h.questions))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.txt")); err == nil {
+		t.Error("rejected batch created b.txt")
+	}
+}
@@ -7,13 +7,16 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/igoryan-dao/ricochet/internal/browser"
 	"github.com/igoryan-dao/ricochet/internal/codegraph"
 	contextPkg "github.com/igoryan-dao/ricochet/internal/context"
 	"github.com/igoryan-dao/ricochet/internal/context/parser"
+	"github.com/igoryan-dao/ricochet/internal/database"
 	"github.com/igoryan-dao/ricochet/internal/host"
 	"github.com/igoryan-dao/ricochet/internal/index"
+	"github.com/igoryan-dao/ricochet/internal/lsp"
 	mcpHubPkg "github.com/igoryan-dao/ricochet/internal/mcp"
 	"github.com/igoryan-dao/ricochet/internal/memory"
 	"github.com/igoryan-dao/ricochet/internal/modes"
@@ -39,6 +42,13 @@ type ToolDefinition struct {
 type LiveModeProvider interface {
 	IsEnabled() bool
 	AskUserRemote(ctx context.Context, question string) (string, error)
+	// AskApproveDiffRemote asks to approve a file edit, showing its unified diff
+	AskApproveDiffRemote(ctx context.Context, question, path, diff string) (string, error)
+}
+
+// Notifier raises a desktop alert when the user may not be watching
+type Notifier interface {
+	Notify(title, message string)
 }
 
 // NativeExecutor implements Executor using a Host for OS operations and ModeManager for permissions
@@ -49,12 +59,19 @@ type NativeExecutor struct {
 	browser         *browser.BrowserManager
 	mcpHub          *mcpHubPkg.Hub
 	indexer         *index.Indexer
+	indexRoots      *index.Roots // Every indexed repo, for multi-root search; nil searches only indexer
 	codegraph       *codegraph.Service
 	workflows       *workflow.Manager
 	livemode        LiveModeProvider
+	notifier        Notifier
+	stats           *ToolStats // Per-tool usage, nil when not recorded
+	images          ImageModel // Vision and image generation, nil when unavailable
 	shadowVerifier  *safeguard.ShadowVerifier
 	ptyManager      *host.PTYManager
 	memory          *memory.Manager
+	lsp             *lsp.Manager              // Native language servers for hosts without an IDE
+	python          *pythonKernels            // Persistent execute_python kernels per session
+	databases       *database.Pool            // Connections for the sql_* tools
 	dynamicTools    map[string]ToolDefinition // Support for dynamic tools (e.g. subtask)
 	dynamicHandlers map[string]interface {
 		Execute(context.Context, json.RawMessage) (string, error)
@@ -62,7 +79,7 @@ type NativeExecutor struct {
 }
 
 func NewNativeExecutor(h host.Host, m *modes.Manager, sg *safeguard.Manager, mcpHub *mcpHubPkg.Hub, idx *index.Indexer, cg *codegraph.Service, wm *workflow.Manager) *NativeExecutor {
-	return &NativeExecutor{
+	e := &NativeExecutor{
 		host:           h,
 		modes:          m,
 		safeguard:      sg,
@@ -74,11 +91,18 @@ func NewNativeExecutor(h host.Host, m *modes.Manager, sg *safeguard.Manager, mcp
 		shadowVerifier: safeguard.NewShadowVerifier(),
 		ptyManager:     host.NewPTYManager(),
 		memory:         mustCreateMemory(h.GetCWD()),
+		lsp:            lsp.NewManager(h.GetCWD()),
+		python:         newPythonKernels(h.GetCWD()),
+		databases:      database.NewPool(h.GetCWD()),
 		dynamicTools:   make(map[string]ToolDefinition),
 		dynamicHandlers: make(map[string]interface {
 			Execute(context.Context, json.RawMessage) (string, error)
 		}),
 	}
+	if sg.EgressActive() {
+		e.browser.SetEgressFilter(sg.CheckEgressURL)
+	}
+	return e
 }
 
 // RegisterTool allows registering implementation-specific tools at runtime
@@ -100,6 +124,31 @@ func (e *NativeExecutor) SetLiveMode(lm LiveModeProvider) {
 	e.livemode = lm
 }
 
+// SetStats enables per-tool usage recording
+func (e *NativeExecutor) SetStats(s *ToolStats) {
+	e.stats = s
+}
+
+// Stats returns the usage recorder, or nil when recording is off
+func (e *NativeExecutor) Stats() *ToolStats {
+	return e.stats
+}
+
+// SetMemory replaces the executor's own memory store with a shared one
+func (e *NativeExecutor) SetMemory(m *memory.Manager) {
+	e.memory = m
+}
+
+// SetIndexRoots lets codebase_search span every repo of a multi-root workspace
+func (e *NativeExecutor) SetIndexRoots(r *index.Roots) {
+	e.indexRoots = r
+}
+
+// SetNotifier sets the desktop fallback used for approval prompts outside live mode
+func (e *NativeExecutor) SetNotifier(n Notifier) {
+	e.notifier = n
+}
+
 // Hook interface for intercepting tool execution
 type ToolHook interface {
 	Name() string
@@ -134,7 +183,20 @@ func (h *BashValidator) PreExecute(ctx context.Context, toolName string, args ma
 	return nil
 }
 
+// Execute runs a tool, recording its latency and result size when stats are enabled
 func (e *NativeExecutor) Execute(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	ctx = withAuditArgs(ctx, args)
+	if e.stats == nil {
+		return e.execute(ctx, name, args)
+	}
+	ctx, waited := withConsentTimer(ctx)
+	start := time.Now()
+	out, err := e.execute(ctx, name, args)
+	e.stats.Record(name, time.Since(start), *waited, len(out), err != nil)
+	return out, err
+}
+
+func (e *NativeExecutor) execute(ctx context.Context, name string, args json.RawMessage) (string, error) {
 	// 0. Parse args into map for hooks (optimization: only if hooks exist)
 	// For now, we only have one hardcoded hook, so let's check it.
 	// In the future, e.hooks would determine this.
@@ -147,9 +209,12 @@ func (e *NativeExecutor) Execute(ctx context.Context, name string, args json.Raw
 		}
 	}
 
-	// 1. Enforce Trust Zones
+	// 1. Enforce Trust Zones; the emergency stop holds even without a safeguard
+	if err := safeguard.CheckEmergency(name); err != nil {
+		return "", fmt.Errorf("safeguard violation: %w", err)
+	}
 	if e.safeguard != nil {
-		if err := e.safeguard.CheckPermission(name); err != nil {
+		if err := e.checkZones(name, args); err != nil {
 			return "", fmt.Errorf("safeguard violation: %w", err)
 		}
 	}
@@ -171,6 +236,8 @@ func (e *NativeExecutor) Execute(ctx context.Context, name string, args json.Raw
 
 	case "list_dir":
 		return e.ListDir(args)
+	case "find_by_name":
+		return e.FindByName(ctx, args)
 	case "read_file":
 		return e.ReadFile(args)
 	case "write_file":
@@ -181,10 +248,24 @@ func (e *NativeExecutor) Execute(ctx context.Context, name string, args json.Raw
 		return e.CodebaseSearch(ctx, args)
 	case "command_status":
 		return e.GetCommandStatus(args)
+	case "list_jobs":
+		return e.ListJobs()
+	case "job_logs":
+		return e.JobLogs(args)
+	case "kill_job":
+		return e.KillJob(args)
 	case "restore_checkpoint":
 		return e.RestoreCheckpoint(args)
+	case "experiment":
+		return e.Experiment(args)
 	case "read_definitions":
 		return e.ReadDefinitions(args)
+	case "who_calls":
+		return e.WhoCalls(args)
+	case "what_does_this_call":
+		return e.WhatDoesThisCall(args)
+	case "impact_of_change":
+		return e.ImpactOfChange(args)
 	case "browser_open":
 		return e.BrowserOpen(ctx, args)
 	case "browser_screenshot":
@@ -193,13 +274,43 @@ func (e *NativeExecutor) Execute(ctx context.Context, name string, args json.Raw
 		return e.BrowserClick(ctx, args)
 	case "browser_type":
 		return e.BrowserType(ctx, args)
+	case "browser_assert_visual":
+		return e.BrowserAssertVisual(ctx, args)
+	case "browser_snapshot":
+		return e.BrowserSnapshot(ctx, args)
+	case "browser_wait":
+		return e.BrowserWait(ctx, args)
+	case "browser_logs":
+		return e.BrowserLogs(ctx, args)
+	case "browser_download":
+		return e.BrowserDownload(ctx, args)
+	case "browser_close":
+		return e.BrowserClose(ctx, args)
+	case "copy_to_clipboard":
+		return e.CopyToClipboard(args)
+	case "read_clipboard":
+		return e.ReadClipboard(ctx)
+	case "send_desktop_notification":
+		return e.SendDesktopNotification(args)
+	case "read_mcp_resource":
+		return e.ReadMcpResource(ctx, args)
+	case "analyze_image":
+		return e.AnalyzeImage(ctx, args)
+	case "generate_image":
+		return e.GenerateImage(ctx, args)
 	case "get_diagnostics":
 		return e.GetDiagnostics(ctx, args)
 	case "get_definitions":
 		return e.GetDefinitionsLSP(ctx, args)
+	case "get_references":
+		return e.GetReferences(ctx, args)
+	case "hover_docs":
+		return e.HoverDocs(ctx, args)
+	case "rename_symbol":
+		return e.RenameSymbol(ctx, args)
 	case "switch_mode":
 		return e.SwitchMode(args)
-	case "update_todos", "task_boundary", "update_plan":
+	case "update_todos", "task_boundary", "update_plan", "pin_context":
 		return "Interpreted by controller", nil
 	case "get_workflows":
 		return e.GetWorkflows(ctx, args)
@@ -209,9 +320,35 @@ func (e *NativeExecutor) Execute(ctx context.Context, name string, args json.Raw
 	case "replace_file_content":
 		// This method is defined in fs_tools.go but called on NativeExecutor
 		return e.ReplaceFileContent(ctx, args)
+	case "apply_diff":
+		return e.ApplyDiff(ctx, args)
+	case "edit_files":
+		return e.EditFiles(ctx, args)
+	case "delete_file":
+		return e.DeleteFile(ctx, args)
+	case "move_file":
+		return e.MoveFile(ctx, args)
+	case "create_directory":
+		return e.CreateDirectory(ctx, args)
 
 	case "execute_python":
 		return e.ExecutePythonTool(ctx, args)
+	case "reset_python":
+		return e.ResetPython(ctx)
+	case "execute_node":
+		return e.ExecuteNode(ctx, args)
+	case "execute_bash_script":
+		return e.ExecuteBashScript(ctx, args)
+	case "http_request":
+		return e.HTTPRequest(ctx, args)
+	case "sql_query":
+		return e.SQLQuery(ctx, args)
+	case "sql_schema":
+		return e.SQLSchema(ctx, args)
+	case "sql_execute":
+		return e.SQLExecute(ctx, args)
+	case "web_fetch":
+		return e.WebFetch(ctx, args)
 
 	case "create_checkpoint":
 		return e.CreateCheckpoint(args)
@@ -242,6 +379,10 @@ func (e *NativeExecutor) Execute(ctx context.Context, name string, args json.Raw
 				}
 			}
 
+			if err := e.checkMcpPolicy(ctx, name, argsMap); err != nil {
+				return "", err
+			}
+
 			result, err := e.mcpHub.CallTool(ctx, name, argsMap)
 			if err != nil {
 				return "", fmt.Errorf("mcp tool error: %w", err)
@@ -283,7 +424,7 @@ func (e *NativeExecutor) GetDefinitions() []ToolDefinition {
 	defs := []ToolDefinition{
 		{
 			Name:        "list_dir",
-			Description: "List files in a directory",
+			Description: "List files in a single directory (not recursive). To locate files across the tree, use find_by_name instead of listing directories one by one.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -295,6 +436,53 @@ func (e *NativeExecutor) GetDefinitions() []ToolDefinition {
 				"required": []string{"path"},
 			},
 		},
+		{
+			Name:        "find_by_name",
+			Description: "Find files or directories by glob pattern, e.g. '**/*_test.go', '*.{ts,tsx}', 'internal/**/handler*'. A pattern without '/' matches file names at any depth. Skips .git, node_modules, vendor and hidden entries. Returns paths with size and modification time.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"pattern": map[string]interface{}{
+						"type":        "string",
+						"description": "Glob relative to path; '**' matches any number of directories",
+					},
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Directory to search (default: workspace root)",
+					},
+					"extensions": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Only files with these extensions, e.g. [\"go\", \"md\"]",
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"file", "dir"},
+						"description": "Only files or only directories",
+					},
+					"min_size": map[string]interface{}{
+						"type":        "string",
+						"description": "Minimum file size, e.g. \"10KB\"",
+					},
+					"max_size": map[string]interface{}{
+						"type":        "string",
+						"description": "Maximum file size, e.g. \"1MB\"",
+					},
+					"modified_within": map[string]interface{}{
+						"type":        "string",
+						"description": "Only entries modified within this long, e.g. \"2h\" or \"7d\"",
+					},
+					"max_results": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum results to return (default 100, max 1000)",
+					},
+					"include_hidden": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Include dotfiles and dot-directories",
+					},
+				},
+			},
+		},
 		{
 			Name:        "read_file",
 			Description: "Read file content",
@@ -353,6 +541,118 @@ func (e *NativeExecutor) GetDefinitions() []ToolDefinition {
 				"required": []string{"path", "TargetContent", "ReplacementContent"},
 			},
 		},
+		{
+			Name:        "apply_diff",
+			Description: "Edit one or more existing files with a unified diff. Hunks are located by their context lines (line numbers are only hints), so include 2-3 unchanged lines around each change. Prefer this over replace_file_content for several changes in one file.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "File to patch. Optional when the diff has ---/+++ file headers",
+					},
+					"diff": map[string]interface{}{
+						"type":        "string",
+						"description": "Unified diff: @@ hunk headers followed by lines starting with ' ' (context), '-' (removed) or '+' (added)",
+					},
+				},
+				"required": []string{"diff"},
+			},
+		},
+		{
+			Name:        "edit_files",
+			Description: "Apply a batch of edits across several files as one transaction: all edits are verified together, then either all written under a single checkpoint or none are. Use for changes that must land together (renames, signature changes).",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"edits": map[string]interface{}{
+						"type":        "array",
+						"description": "Edits applied in order; several may target the same file",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"path": map[string]interface{}{
+									"type":        "string",
+									"description": "File to edit or create",
+								},
+								"TargetContent": map[string]interface{}{
+									"type":        "string",
+									"description": "Exact unique text to replace (with ReplacementContent)",
+								},
+								"ReplacementContent": map[string]interface{}{
+									"type":        "string",
+									"description": "The new text for TargetContent",
+								},
+								"content": map[string]interface{}{
+									"type":        "string",
+									"description": "Whole-file content, for new files",
+								},
+								"diff": map[string]interface{}{
+									"type":        "string",
+									"description": "Unified diff for this file, instead of TargetContent",
+								},
+							},
+							"required": []string{"path"},
+						},
+					},
+				},
+				"required": []string{"edits"},
+			},
+		},
+		{
+			Name:        "delete_file",
+			Description: "Delete a file or directory. It is moved to .ricochet/trash (recoverable with move_file); use this instead of rm.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "File or directory to delete",
+					},
+					"recursive": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Required to delete a non-empty directory",
+					},
+				},
+				"required": []string{"path"},
+			},
+		},
+		{
+			Name:        "move_file",
+			Description: "Move or rename a file or directory; missing parent directories are created. Use this instead of mv.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"source": map[string]interface{}{
+						"type":        "string",
+						"description": "Existing path",
+					},
+					"destination": map[string]interface{}{
+						"type":        "string",
+						"description": "New path (full target path, not a directory to move into)",
+					},
+					"overwrite": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Replace an existing destination file",
+					},
+				},
+				"required": []string{"source", "destination"},
+			},
+		},
+		{
+			Name:        "create_directory",
+			Description: "Create a directory, including missing parents. Use this instead of mkdir.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Directory to create",
+					},
+				},
+				"required": []string{"path"},
+			},
+		},
 		{
 			Name:        "execute_command",
 			Description: "Execute a shell command. Supports background execution.",
@@ -367,6 +667,10 @@ func (e *NativeExecutor) GetDefinitions() []ToolDefinition {
 						"type":        "boolean",
 						"description": "Whether to run the command in the background",
 					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name for a background job (e.g. 'dev-server'), usable with job_logs and kill_job",
+					},
 				},
 				"required": []string{"command"},
 			},
@@ -385,19 +689,63 @@ func (e *NativeExecutor) GetDefinitions() []ToolDefinition {
 				"required": []string{"id"},
 			},
 		},
+		{
+			Name:        "list_jobs",
+			Description: "List background jobs started with execute_command (running first) with status, PID and runtime.",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "job_logs",
+			Description: "Read a background job's output. Without offset, returns the last lines; with offset, returns output from that byte offset so you can poll for new output using the returned next offset.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "Job id (or its first 8 characters) or job name",
+					},
+					"offset": map[string]interface{}{
+						"type":        "integer",
+						"description": "Byte offset to read from (the next offset of a previous call)",
+					},
+					"lines": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of lines to tail when no offset is given (default 50)",
+					},
+				},
+				"required": []string{"id"},
+			},
+		},
+		{
+			Name:        "kill_job",
+			Description: "Stop a running background job and its child processes.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "Job id (or its first 8 characters) or job name",
+					},
+				},
+				"required": []string{"id"},
+			},
+		},
 		{
 			Name:        "switch_mode",
-			Description: "Switch the agent's operating mode (persona).",
+			Description: "Switch the agent's operating mode (persona). Each mode limits the tools you can use; project modes may also change the trust zone and model.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
 					"mode": map[string]interface{}{
 						"type":        "string",
-						"description": "Mode slug to switch to (e.g., 'architect', 'code', 'test')",
+						"description": "Mode slug to switch to. Available: " + e.modeSummary(),
 					},
 					"handoff": map[string]interface{}{
 						"type":        "boolean",
-						"description": "If true, triggers 'Intelligent Handoff' (summarizes context to SPEC.md before switching).",
+						"description": "If true, triggers 'Intelligent Handoff': summarizes context into SPEC.md, PLAN.md (also seeded into the plan) and OPEN_QUESTIONS.md before switching; the new mode reads them as @SPEC, @PLAN and @OPEN_QUESTIONS.",
 					},
 				},
 				"required": []string{"mode"},
@@ -424,38 +772,193 @@ func (e *NativeExecutor) GetDefinitions() []ToolDefinition {
 				"required": []string{"todos"},
 			},
 		},
+		{
+			Name:        "pin_context",
+			Description: "Pin a workspace file or a note so it stays in your context for the rest of the session: pinned items are never condensed or dropped, and pinned files are re-read on every turn. Use it for facts you must not lose, like the user's hard constraints or the spec you are implementing. Pinned items cost tokens on every turn, so unpin what is no longer needed.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{"type": "string", "enum": []string{"pin", "unpin", "list"}, "description": "Defaults to pin"},
+					"file":   map[string]interface{}{"type": "string", "description": "Workspace file to pin"},
+					"note":   map[string]interface{}{"type": "string", "description": "Note to pin, when not pinning a file"},
+					"id":     map[string]interface{}{"type": "string", "description": "Pin to remove, for unpin (see list)"},
+				},
+			},
+		},
 		{
 			Name:        "codebase_search",
-			Description: "Perform semantic search over the codebase using embeddings. Returns relevant code snippets.",
+			Description: "Search the codebase by meaning and by exact words: embedding and keyword (BM25) rankings are fused, so both descriptions (\"where is rate limiting\") and identifiers (\"RateLimiter struct\") work. Returns whole functions and types, each with the symbols it defines and their line and character, ready for get_definitions.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
 					"query": map[string]interface{}{
 						"type":        "string",
-						"description": "Natural language search query",
+						"description": "Natural language query or identifiers to find",
 					},
 					"limit": map[string]interface{}{
 						"type":        "integer",
 						"description": "Number of results to return (default: 5)",
 					},
+					"repos": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "In a multi-repo workspace, only search these repos (by name). Default: all indexed repos",
+					},
 				},
 				"required": []string{"query"},
 			},
 		},
 		{
 			Name:        "execute_python",
-			Description: "Execute a Python script. Use this to analyze files, perform math, or automate tasks instead of making multiple tool calls. Stdout/stderr are captured.",
+			Description: "Execute Python code in a persistent kernel, like a notebook cell: variables and imports survive between calls in this session, and the value of a trailing expression is printed. Use this to analyze files, perform math, or automate tasks instead of making multiple tool calls. Stdout/stderr are captured. CPU time and memory are limited. Without allow_network, sockets opened from Python fail; this is advisory, not an OS-level sandbox, so don't rely on it to contain untrusted code.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
 					"script": map[string]interface{}{
 						"type":        "string",
-						"description": "The valid Python script to execute.",
+						"description": "The Python code to execute.",
+					},
+					"timeout": map[string]interface{}{
+						"type":        "integer",
+						"description": "Wall-clock limit in seconds (default 120, max 600). The cell is interrupted when it expires.",
+					},
+					"allow_network": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Request network access for this cell (asks the user for approval).",
 					},
 				},
 				"required": []string{"script"},
 			},
 		},
+		{
+			Name:        "reset_python",
+			Description: "Restart the Python kernel used by execute_python, clearing all variables and imports.",
+			InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+		},
+		{
+			Name:        "execute_node",
+			Description: "Run a one-off Node.js script under a sandbox profile (rlimits, network toggle, optional throwaway working directory). Each call starts fresh. Stdout/stderr are captured. Use it for JavaScript-specific analysis, e.g. with the project's own node_modules. Approved like an unrated execute_command; runs with network access always ask, strict runs without network don't.",
+			InputSchema: scriptToolSchema("The JavaScript code to run (CommonJS; require() resolves from the working directory)."),
+		},
+		{
+			Name:        "execute_bash_script",
+			Description: "Run a multi-line bash script under a sandbox profile (rlimits, network toggle, optional throwaway working directory). Prefer this over chaining many execute_command calls for scripted analysis. Stdout/stderr and the exit status are returned. Approved like execute_command, with the script rated as a command line; runs with network access always ask, strict runs without network only when dangerous.",
+			InputSchema: scriptToolSchema("The bash script to run."),
+		},
+		{
+			Name:        "http_request",
+			Description: "Send an HTTP request and return the status line, response headers and body (truncated to 16KB). Use this to test APIs and local servers instead of running curl through execute_command. Hosts outside the project's domain allow list (localhost by default) need the user's approval.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"method": map[string]interface{}{
+						"type":        "string",
+						"description": "HTTP method (default GET).",
+					},
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "Absolute http:// or https:// URL.",
+					},
+					"headers": map[string]interface{}{
+						"type":                 "object",
+						"description":          "Request headers.",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+					},
+					"body": map[string]interface{}{
+						"type":        "string",
+						"description": "Request body. Content-Type defaults to application/json when the body is valid JSON.",
+					},
+					"timeout": map[string]interface{}{
+						"type":        "integer",
+						"description": "Timeout in seconds (default 30, max 120).",
+					},
+				},
+				"required": []string{"url"},
+			},
+		},
+		{
+			Name:        "sql_query",
+			Description: "Run one read-only SQL query (SELECT, WITH, EXPLAIN, ...) against a database configured in tools.databases and return the rows as a table. Queries run in a read-only transaction and several statements are refused; use sql_execute for changes.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "Configured connection name (optional when only one is configured).",
+					},
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "The SQL query.",
+					},
+					"max_rows": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum rows to return (default 100, max 1000).",
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+		{
+			Name:        "sql_schema",
+			Description: "Describe a configured database: without a table, list its tables and views; with a table, show its columns, indexes and foreign keys (the CREATE statements for SQLite).",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "Configured connection name (optional when only one is configured).",
+					},
+					"table": map[string]interface{}{
+						"type":        "string",
+						"description": "Table to describe; Postgres tables may be schema-qualified (default schema public).",
+					},
+				},
+			},
+		},
+		{
+			Name:        "sql_execute",
+			Description: "Run DDL/DML statements (CREATE, ALTER, INSERT, UPDATE, DELETE, migrations) against a configured database. Requires the user's approval. Several statements separated by semicolons run in one transaction and are rolled back together on error.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"database": map[string]interface{}{
+						"type":        "string",
+						"description": "Configured connection name (optional when only one is configured).",
+					},
+					"statements": map[string]interface{}{
+						"type":        "string",
+						"description": "The SQL statements to run.",
+					},
+				},
+				"required": []string{"statements"},
+			},
+		},
+		{
+			Name:        "web_fetch",
+			Description: "Fetch a web page (documentation, changelogs, READMEs, API references) and return its readable content as markdown, without navigation, ads and other boilerplate. Text and JSON are returned as is. Pages are cached for an hour; long pages are returned in chunks, continue with offset. Hosts outside the project's domain allow list need the user's approval. Use browser_open for pages that need JavaScript or interaction.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "Absolute http:// or https:// URL.",
+					},
+					"max_chars": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum characters of content to return (default 20000, max 100000).",
+					},
+					"offset": map[string]interface{}{
+						"type":        "integer",
+						"description": "Character offset to continue a truncated page from.",
+					},
+					"refresh": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Fetch again instead of using the cached copy.",
+					},
+				},
+				"required": []string{"url"},
+			},
+		},
 	}
 
 	if e.safeguard != nil {
@@ -472,13 +975,31 @@ func (e *NativeExecutor) GetDefinitions() []ToolDefinition {
 				},
 				"required": []string{"hash"},
 			},
+		}, ToolDefinition{
+			Name:        "experiment",
+			Description: "Fork the workspace into a named experiment to try an approach without losing the current one, then merge or discard it. Use it for 'try both designs' requests: fork one experiment per design, switching back to 'main' in between, compare each with diff, then merge the winner and discard the rest.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"fork", "switch", "list", "diff", "merge", "discard"},
+						"description": "fork: start an experiment from the current workspace; switch: check out an experiment or 'main'; list: show experiments; diff: three-way comparison with the main line; merge: merge into the main line; discard: delete it.",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Experiment name, e.g. 'design-a'. Not needed for list.",
+					},
+				},
+				"required": []string{"action"},
+			},
 		})
 	}
 
 	// Add read_definitions tool
 	defs = append(defs, ToolDefinition{
 		Name:        "read_definitions",
-		Description: "Read code definitions (functions, structs) from a file. Currently supports .go files.",
+		Description: "Read the outline of a source file: functions, methods, classes, types and their line ranges. Supports Go, JavaScript/TypeScript, Python, Rust and Java.",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -491,6 +1012,56 @@ func (e *NativeExecutor) GetDefinitions() []ToolDefinition {
 		},
 	})
 
+	if e.codegraph != nil {
+		limit := map[string]interface{}{
+			"type":        "integer",
+			"description": fmt.Sprintf("Maximum results (default: %d)", defaultGraphResults),
+		}
+		defs = append(defs, ToolDefinition{
+			Name:        "who_calls",
+			Description: "List the functions and methods that call a symbol, in the most central files first. Use it before changing a function's signature or behavior to find every call site to update.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"symbol": map[string]interface{}{
+						"type":        "string",
+						"description": "Function or method name, e.g. 'Save' or 'Store.Save'",
+					},
+					"limit": limit,
+				},
+				"required": []string{"symbol"},
+			},
+		}, ToolDefinition{
+			Name:        "what_does_this_call",
+			Description: "List the functions a symbol calls and where each is defined in the workspace, to follow a code path without reading every file.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"symbol": map[string]interface{}{
+						"type":        "string",
+						"description": "Function or method name",
+					},
+					"limit": limit,
+				},
+				"required": []string{"symbol"},
+			},
+		}, ToolDefinition{
+			Name:        "impact_of_change",
+			Description: "Estimate the blast radius of editing a file: the files that import it or call what it defines, then their dependents, ranked with direct dependents first. Use it before editing shared code.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "File to be changed",
+					},
+					"limit": limit,
+				},
+				"required": []string{"path"},
+			},
+		})
+	}
+
 	// Add ask_user_choice tool
 	defs = append(defs, ToolDefinition{
 		Name:        "ask_user_choice",
@@ -508,7 +1079,7 @@ func (e *NativeExecutor) GetDefinitions() []ToolDefinition {
 	// Add LSP tools
 	defs = append(defs, ToolDefinition{
 		Name:        "get_diagnostics",
-		Description: "Get diagnostics (errors/warnings) for a file from the IDE.",
+		Description: "Get diagnostics (errors/warnings) for a file from the IDE or a local language server.",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -540,6 +1111,26 @@ func (e *NativeExecutor) GetDefinitions() []ToolDefinition {
 			},
 			"required": []string{"path", "line", "character"},
 		},
+	}, ToolDefinition{
+		Name:        "get_references",
+		Description: "Find every reference to a symbol across the workspace via the language server. More precise than grep for identifiers shared by unrelated symbols.",
+		InputSchema: lspPositionSchema(),
+	}, ToolDefinition{
+		Name:        "hover_docs",
+		Description: "Show the type signature and documentation of a symbol via the language server.",
+		InputSchema: lspPositionSchema(),
+	}, ToolDefinition{
+		Name:        "rename_symbol",
+		Description: "Rename a symbol and all its references across the workspace via the language server, in one checkpointed edit.",
+		InputSchema: func() map[string]interface{} {
+			schema := lspPositionSchema()
+			schema["properties"].(map[string]interface{})["new_name"] = map[string]interface{}{
+				"type":        "string",
+				"description": "New name for the symbol",
+			}
+			schema["required"] = []string{"path", "line", "new_name"}
+			return schema
+		}(),
 	}, ToolDefinition{
 		Name:        "get_workflows",
 		Description: "Get list of available workflow commands defined in .agent/workflows. Used for autocomplete.",
@@ -553,51 +1144,193 @@ func (e *NativeExecutor) GetDefinitions() []ToolDefinition {
 	// Add browser tools
 	defs = append(defs, ToolDefinition{
 		Name:        "browser_open",
-		Description: "Open a URL in the browser",
+		Description: "Open a URL in the session's browser tab. The tab persists across calls, keeping cookies, history and console/network logs.",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
-				"url": map[string]interface{}{"type": "string"},
+				"url":        map[string]interface{}{"type": "string"},
+				"wait_until": map[string]interface{}{"type": "string", "enum": []string{"load", "domcontentloaded", "networkidle"}, "description": "When navigation counts as done (default load)"},
+				"timeout":    map[string]interface{}{"type": "integer", "description": "Timeout in seconds (default 30)"},
 			},
 			"required": []string{"url"},
 		},
+	}, ToolDefinition{
+		Name:        "browser_snapshot",
+		Description: "Read the current page as an accessibility tree: roles, names, values and states, with a ref (e1, e2, ...) per element for browser_click, browser_type and browser_download. Prefer it over screenshots to understand a page.",
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
 	}, ToolDefinition{
 		Name:        "browser_screenshot",
-		Description: "Capture a screenshot of a URL",
+		Description: "Capture a screenshot of the current page, or of a URL after opening it",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
-				"url": map[string]interface{}{"type": "string"},
+				"url":       map[string]interface{}{"type": "string", "description": "Open this URL first"},
+				"full_page": map[string]interface{}{"type": "boolean", "description": "Capture the whole scrollable page"},
 			},
-			"required": []string{"url"},
 		},
 	}, ToolDefinition{
 		Name:        "browser_click",
-		Description: "Click an element by selector",
+		Description: "Click an element on the current page by CSS selector or snapshot ref",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
-				"url":      map[string]interface{}{"type": "string"},
+				"url":      map[string]interface{}{"type": "string", "description": "Open this URL first"},
 				"selector": map[string]interface{}{"type": "string"},
+				"ref":      map[string]interface{}{"type": "string", "description": "Element ref from browser_snapshot"},
+				"timeout":  map[string]interface{}{"type": "integer", "description": "Timeout in seconds (default 30)"},
 			},
-			"required": []string{"url", "selector"},
 		},
 	}, ToolDefinition{
 		Name:        "browser_type",
-		Description: "Type text into an element",
+		Description: "Type text into an element on the current page by CSS selector or snapshot ref",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
-				"url":      map[string]interface{}{"type": "string"},
+				"url":      map[string]interface{}{"type": "string", "description": "Open this URL first"},
 				"selector": map[string]interface{}{"type": "string"},
+				"ref":      map[string]interface{}{"type": "string", "description": "Element ref from browser_snapshot"},
 				"text":     map[string]interface{}{"type": "string"},
+				"clear":    map[string]interface{}{"type": "boolean", "description": "Clear the field first"},
+				"submit":   map[string]interface{}{"type": "boolean", "description": "Press Enter afterwards"},
+				"timeout":  map[string]interface{}{"type": "integer", "description": "Timeout in seconds (default 30)"},
+			},
+			"required": []string{"text"},
+		},
+	}, ToolDefinition{
+		Name:        "browser_wait",
+		Description: "Wait until an element reaches a state, text appears on the page, or the network goes idle. All given conditions must hold.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"selector":     map[string]interface{}{"type": "string"},
+				"state":        map[string]interface{}{"type": "string", "enum": []string{"visible", "hidden", "attached", "detached"}, "description": "State of selector to wait for (default visible)"},
+				"text":         map[string]interface{}{"type": "string", "description": "Text that must appear in the page"},
+				"network_idle": map[string]interface{}{"type": "boolean", "description": "Wait until no requests are in flight for 500ms"},
+				"timeout":      map[string]interface{}{"type": "integer", "description": "Timeout in seconds (default 30)"},
+			},
+		},
+	}, ToolDefinition{
+		Name:        "browser_logs",
+		Description: "Show console messages, uncaught exceptions and network requests captured from the browser tab",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"type":        map[string]interface{}{"type": "string", "enum": []string{"console", "network", "all"}, "description": "Which log to show (default all)"},
+				"errors_only": map[string]interface{}{"type": "boolean", "description": "Only console errors and failed or 4xx/5xx requests"},
+				"clear":       map[string]interface{}{"type": "boolean", "description": "Empty the logs after reading"},
+			},
+		},
+	}, ToolDefinition{
+		Name:        "browser_download",
+		Description: "Download a file by URL or by clicking an element, saving it under .ricochet/downloads",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"url":      map[string]interface{}{"type": "string"},
+				"selector": map[string]interface{}{"type": "string", "description": "Element that starts the download"},
+				"ref":      map[string]interface{}{"type": "string", "description": "Element ref from browser_snapshot"},
+				"timeout":  map[string]interface{}{"type": "integer", "description": "Timeout in seconds (default 120)"},
+			},
+		},
+	}, ToolDefinition{
+		Name:        "browser_close",
+		Description: "Close the session's browser tab, discarding its cookies and logs",
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		},
+	}, ToolDefinition{
+		Name:        "browser_assert_visual",
+		Description: "Screenshot a URL at a fixed viewport and compare it with the stored baseline using a perceptual pixel diff. Returns PASS/FAIL with the share of changed pixels and, on failure, the path of a diff image with changes in red. The first call for a name records the baseline. Use it to verify UI changes.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"url":             map[string]interface{}{"type": "string"},
+				"name":            map[string]interface{}{"type": "string", "description": "Baseline name (default derived from the URL and selector)"},
+				"selector":        map[string]interface{}{"type": "string", "description": "Capture only this element"},
+				"width":           map[string]interface{}{"type": "integer", "description": "Viewport width (default 1280)"},
+				"height":          map[string]interface{}{"type": "integer", "description": "Viewport height (default 800)"},
+				"full_page":       map[string]interface{}{"type": "boolean", "description": "Capture the whole scrollable page"},
+				"threshold":       map[string]interface{}{"type": "number", "description": "Per-pixel color sensitivity, 0-1 (default 0.1; lower is stricter)"},
+				"tolerance":       map[string]interface{}{"type": "number", "description": "Percent of pixels allowed to differ (default 0.1)"},
+				"update_baseline": map[string]interface{}{"type": "boolean", "description": "Replace the baseline with this screenshot"},
 			},
-			"required": []string{"url", "selector", "text"},
+			"required": []string{"url"},
 		},
 	})
 
+	// Desktop integration is only offered when running on the user's machine
+	if _, ok := e.host.(host.Desktop); ok {
+		defs = append(defs, ToolDefinition{
+			Name:        "copy_to_clipboard",
+			Description: "Copy text to the user's clipboard so they can paste it elsewhere",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"text": map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"text"},
+			},
+		}, ToolDefinition{
+			Name:        "read_clipboard",
+			Description: "Read text from the user's clipboard. Requires the user's approval.",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		}, ToolDefinition{
+			Name:        "send_desktop_notification",
+			Description: "Show a desktop notification, e.g. to tell the user a long task has finished",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"title":   map[string]interface{}{"type": "string", "description": "Default \"Ricochet\""},
+					"message": map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"message"},
+			},
+		})
+	}
+
+	if e.images != nil {
+		defs = append(defs, ToolDefinition{
+			Name:        "analyze_image",
+			Description: "Look at an image file (PNG, JPEG, GIF or WebP) with a vision model: screenshots, photos of errors, UI mockups, diagrams. Returns a text description or the answer to prompt.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path":   map[string]interface{}{"type": "string", "description": "Image file, relative to the workspace or absolute"},
+					"prompt": map[string]interface{}{"type": "string", "description": "What to look for or ask about the image. Default: a detailed description"},
+				},
+				"required": []string{"path"},
+			},
+		}, ToolDefinition{
+			Name:        "generate_image",
+			Description: "Generate an image from a text prompt and save it as PNG. Requires the user's approval.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"prompt": map[string]interface{}{"type": "string"},
+					"size":   map[string]interface{}{"type": "string", "description": "e.g. 1024x1024 (default), 1792x1024, 1024x1792"},
+					"path":   map[string]interface{}{"type": "string", "description": "Where to save it. Default .ricochet/images/image_<time>.png"},
+				},
+				"required": []string{"prompt"},
+			},
+		})
+	}
+
 	// Add MCP tools
 	if e.mcpHub != nil {
+		// Keep aliased MCP tools from shadowing built-in ones
+		builtin := make([]string, 0, len(defs))
+		for _, d := range defs {
+			builtin = append(builtin, d.Name)
+		}
+		e.mcpHub.ReserveToolNames(builtin)
+
 		mcpTools := e.mcpHub.GetTools()
 		for _, t := range mcpTools {
 			// Convert InputSchema using JSON marshaling for safety
@@ -610,6 +1343,22 @@ func (e *NativeExecutor) GetDefinitions() []ToolDefinition {
 				Description: t.Description,
 				InputSchema: schema,
 			})
+			modes.RegisterToolInGroup("mcp", t.Name)
+		}
+
+		if len(e.mcpHub.Resources()) > 0 {
+			defs = append(defs, ToolDefinition{
+				Name:        "read_mcp_resource",
+				Description: "Read a resource (file, document, record...) exposed by an MCP server. Available resources are listed in the system prompt.",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"uri":    map[string]interface{}{"type": "string", "description": "Resource URI"},
+						"server": map[string]interface{}{"type": "string", "description": "MCP server name. Optional when only one server lists the URI"},
+					},
+					"required": []string{"uri"},
+				},
+			})
 		}
 	}
 
@@ -766,6 +1515,12 @@ func (e *NativeExecutor) StartTerminal(ctx context.Context, args json.RawMessage
 	return fmt.Sprintf("Terminal started. ID: %s. Use send_input/read_terminal to interact.", session.ID), nil
 }
 
+// CloseTerminals kills the agent's interactive terminals, returning how many
+// were running
+func (e *NativeExecutor) CloseTerminals() int {
+	return e.ptyManager.CloseAll()
+}
+
 func (e *NativeExecutor) SendTerminalInput(ctx context.Context, args json.RawMessage) (string, error) {
 	var payload struct {
 		ID   string `json:"id"`
@@ -805,16 +1560,6 @@ func (e *NativeExecutor) ReadTerminalOutput(ctx context.Context, args json.RawMe
 	return output, nil
 }
 
-func (e *NativeExecutor) ExecutePythonTool(ctx context.Context, args json.RawMessage) (string, error) {
-	var payload struct {
-		Script string `json:"script"`
-	}
-	if err := json.Unmarshal(args, &payload); err != nil {
-		return "", fmt.Errorf("invalid arguments: %w", err)
-	}
-	return ExecutePython(ctx, payload.Script)
-}
-
 func (e *NativeExecutor) SwitchMode(args json.RawMessage) (string, error) {
 	var payload struct {
 		Mode string `json:"mode"`
@@ -828,7 +1573,32 @@ func (e *NativeExecutor) SwitchMode(args json.RawMessage) (string, error) {
 	}
 
 	mode := e.modes.GetActiveMode()
-	return fmt.Sprintf("Successfully switched to %s mode. Current role: %s", mode.Name, mode.RoleDefinition), nil
+	if e.safeguard != nil {
+		// Tools called later in this turn already run in the mode's zone
+		if err := e.safeguard.SetModeZone(mode.Zone); err != nil {
+			return "", err
+		}
+	}
+	result := fmt.Sprintf("Successfully switched to %s mode. Current role: %s", mode.Name, mode.RoleDefinition)
+	if mode.Zone != "" {
+		result += fmt.Sprintf("\nTrust zone: %s", mode.Zone)
+	}
+	if mode.Model != "" {
+		result += fmt.Sprintf("\nModel: %s (from the next turn)", mode.Model)
+	}
+	return result, nil
+}
+
+// modeSummary lists the mode slugs switch_mode accepts with their names
+func (e *NativeExecutor) modeSummary() string {
+	if e.modes == nil {
+		return "code, architect, test"
+	}
+	var parts []string
+	for _, mode := range e.modes.ListModes() {
+		parts = append(parts, fmt.Sprintf("'%s' (%s)", mode.Slug, mode.Name))
+	}
+	return strings.Join(parts, ", ")
 }
 
 func (e *NativeExecutor) CreateCheckpoint(args json.RawMessage) (string, error) {
@@ -882,6 +1652,9 @@ func (e *NativeExecutor) ReadDefinitions(args json.RawMessage) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if err := e.checkAccess(targetPath, false); err != nil {
+		return "", err
+	}
 
 	// Read content
 	content, err := os.ReadFile(targetPath)
@@ -909,16 +1682,8 @@ func (e *NativeExecutor) ReadDefinitions(args json.RawMessage) (string, error) {
 	}
 
 	// Use Tree-sitter for other languages
-	supportedExts := map[string]bool{
-		".js": true, ".jsx": true, ".mjs": true,
-		".ts": true, ".tsx": true,
-		".py": true,
-		".rs": true,
-		".go": true,
-	}
-
-	if !supportedExts[ext] {
-		return "", fmt.Errorf("unsupported file type: %s (supported: .go, .js, .ts, .py, .rs)", ext)
+	if !contextPkg.IsSupported(targetPath) {
+		return "", fmt.Errorf("unsupported file type: %s (supported: %s)", ext, strings.Join(contextPkg.SupportedExtensions, ", "))
 	}
 
 	ctx := context.Background()
@@ -943,70 +1708,10 @@ func (e *NativeExecutor) ReadDefinitions(args json.RawMessage) (string, error) {
 
 // IsSafeCommand returns true if the command is a read-only or safe operation
 func IsSafeCommand(command string) bool {
-	command = strings.TrimSpace(command)
-	if command == "" {
-		return true
-	}
-
-	parts := strings.Fields(command)
-	if len(parts) == 0 {
-		return true
-	}
-	base := parts[0]
-
-	// Strip path if present in base (e.g. /bin/ls -> ls)
-	if lastSlash := strings.LastIndex(base, "/"); lastSlash != -1 {
-		base = base[lastSlash+1:]
-	}
-
-	safeCommands := map[string]bool{
-		"pwd":    true,
-		"ls":     true,
-		"dir":    true,
-		"echo":   true,
-		"cat":    true,
-		"head":   true,
-		"tail":   true,
-		"grep":   true,
-		"find":   true,
-		"date":   true,
-		"whoami": true,
-		"df":     true,
-		"du":     true,
-		"ps":     true,
-		"uname":  true,
-		"file":   true,
-		"type":   true,
-		"which":  true,
-		"top":    true,
-	}
-
-	if safeCommands[base] {
+	if strings.TrimSpace(command) == "" {
 		return true
 	}
-
-	// Special cases for git read-only commands
-	if base == "git" && len(parts) > 1 {
-		sub := parts[1]
-		gitSafe := map[string]bool{
-			"status":    true,
-			"diff":      true,
-			"log":       true,
-			"branch":    true,
-			"remote":    true,
-			"show":      true,
-			"rev-parse": true,
-			"ls-files":  true,
-			"config":    true, // Usually safe to read
-			"tag":       true,
-			"describe":  true,
-		}
-		if gitSafe[sub] {
-			return true
-		}
-	}
-
-	return false
+	return safeguard.IsSafeCommand(command)
 }
 
 // Helper methods from fs_tools.go need to be accessible. They are methods on NativeExecutor.
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Experiment forks the workspace into named experiments and merges or
+// discards them, for trying several approaches side by side
+func (e *NativeExecutor) Experiment(args json.RawMessage) (string, error) {
+	if e.safeguard == nil {
+		return "", fmt.Errorf("safeguard not initialized")
+	}
+
+	var payload struct {
+		Action string `json:"action"`
+		Name   string `json:"name"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	switch payload.Action {
+	case "fork":
+		hash, err := e.safeguard.ForkExperiment(payload.Name)
+		if err != nil {
+			return "", fmt.Errorf("fork failed: %w", err)
+		}
+		return fmt.Sprintf("Started experiment %s from checkpoint %.8s. Changes now go to the experiment; switch to 'main' to go back.", payload.Name, hash), nil
+	case "switch":
+		if err := e.safeguard.SwitchExperiment(payload.Name); err != nil {
+			return "", fmt.Errorf("switch failed: %w", err)
+		}
+		return fmt.Sprintf("Switched the workspace to %s. Unsaved work was checkpointed on the previous branch.", payload.Name), nil
+	case "list":
+		exps, err := e.safeguard.ListExperiments()
+		if err != nil {
+			return "", err
+		}
+		if len(exps) == 0 {
+			return "No experiments. Start one with action 'fork'.", nil
+		}
+		var sb strings.Builder
+		for _, exp := range exps {
+			marker := " "
+			if exp.Current {
+				marker = "*"
+			}
+			fmt.Fprintf(&sb, "%s %s (%d checkpoints, last: %s)\n", marker, exp.Name, exp.Ahead, exp.Message)
+		}
+		if e.safeguard.CurrentExperiment() == "" {
+			sb.WriteString("* main\n")
+		}
+		return sb.String(), nil
+	case "diff":
+		diff, err := e.safeguard.CompareExperiment(payload.Name)
+		if err != nil {
+			return "", fmt.Errorf("diff failed: %w", err)
+		}
+		return diff.Summary(), nil
+	case "merge":
+		res, err := e.safeguard.MergeExperiment(payload.Name)
+		if err != nil {
+			return "", fmt.Errorf("merge failed: %w", err)
+		}
+		if len(res.Conflicts) > 0 {
+			return fmt.Sprintf("Merged experiment %s into the main line with conflicts in: %s. Resolve the conflict markers in these files.", payload.Name, strings.Join(res.Conflicts, ", ")), nil
+		}
+		return fmt.Sprintf("Merged experiment %s into the main line (checkpoint %.8s).", payload.Name, res.Hash), nil
+	case "discard":
+		if err := e.safeguard.DiscardExperiment(payload.Name); err != nil {
+			return "", fmt.Errorf("discard failed: %w", err)
+		}
+		return fmt.Sprintf("Discarded experiment %s.", payload.Name), nil
+	default:
+		return "", fmt.Errorf("unknown action %q: use fork, switch, list, diff, merge or discard", payload.Action)
+	}
+}
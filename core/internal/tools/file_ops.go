@@ -0,0 +1,214 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+// TrashDir is where delete_file moves entries, relative to the workspace
+const TrashDir = ".ricochet/trash"
+
+// checkWritable runs the mode and safeguard checks shared by write tools
+func (e *NativeExecutor) checkWritable(path string) error {
+	if allowed, msg := e.modes.CanAccessFile(path); !allowed {
+		return fmt.Errorf("permission denied: %s", msg)
+	}
+	return e.checkAccess(path, true)
+}
+
+// checkpoint records the workspace before a destructive change
+func (e *NativeExecutor) checkpoint(msg string, paths ...string) error {
+	if e.safeguard != nil {
+		if _, err := e.safeguard.CreateCheckpoint(msg); err != nil {
+			return fmt.Errorf("failed to create checkpoint: %w", err)
+		}
+		return nil
+	}
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			if err := safeguard.Backup(p); err != nil {
+				return fmt.Errorf("safeguard backup failed: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteFile soft-deletes a file or directory into .ricochet/trash. Entries
+// already in the trash are removed permanently.
+func (e *NativeExecutor) DeleteFile(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		Path      string `json:"path"`
+		Recursive bool   `json:"recursive"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if payload.Path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+
+	cwd := e.host.GetCWD()
+	absPath, _ := e.resolvePath(payload.Path)
+	absPath = filepath.Clean(absPath)
+	if absPath == filepath.Clean(cwd) {
+		return "", fmt.Errorf("refusing to delete the workspace root")
+	}
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		return "", fmt.Errorf("delete: %w", err)
+	}
+	if info.IsDir() && !payload.Recursive {
+		entries, _ := os.ReadDir(absPath)
+		if len(entries) > 0 {
+			return "", fmt.Errorf("%s is a non-empty directory; set recursive to delete it", payload.Path)
+		}
+	}
+	if err := e.checkWritable(payload.Path); err != nil {
+		return "", err
+	}
+
+	if err := e.ensureConsent(ctx, "delete_file", payload.Path, fmt.Sprintf("Delete: %s (moved to %s)", payload.Path, TrashDir)); err != nil {
+		return "", err
+	}
+	if err := e.checkpoint(fmt.Sprintf("Checkpoint before deleting %s", payload.Path), absPath); err != nil {
+		return "", err
+	}
+
+	trashRoot := filepath.Join(cwd, filepath.FromSlash(TrashDir))
+	if rel, err := filepath.Rel(trashRoot, absPath); err == nil && !strings.HasPrefix(rel, "..") {
+		if err := os.RemoveAll(absPath); err != nil {
+			return "", fmt.Errorf("delete: %w", err)
+		}
+		return fmt.Sprintf("Permanently deleted %s from the trash", payload.Path), nil
+	}
+
+	dest, err := trashPath(cwd, trashRoot, absPath)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(absPath, dest); err != nil {
+		return "", fmt.Errorf("move to trash: %w", err)
+	}
+	relDest, _ := filepath.Rel(cwd, dest)
+	return fmt.Sprintf("Deleted %s (moved to %s; restore it with move_file)", payload.Path, filepath.ToSlash(relDest)), nil
+}
+
+// trashPath returns a fresh trash location for absPath, keeping its
+// workspace-relative path under a timestamped directory
+func trashPath(cwd, trashRoot, absPath string) (string, error) {
+	if err := os.MkdirAll(trashRoot, 0755); err != nil {
+		return "", fmt.Errorf("create trash: %w", err)
+	}
+	// Keep trashed files out of version control
+	ignore := filepath.Join(trashRoot, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		_ = os.WriteFile(ignore, []byte("*\n"), 0644)
+	}
+
+	rel, err := filepath.Rel(cwd, absPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(absPath) // Outside the workspace
+	}
+	stamp := time.Now().Format("20060102-150405")
+	dest := filepath.Join(trashRoot, stamp, rel)
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(dest); os.IsNotExist(err) {
+			break
+		}
+		dest = filepath.Join(trashRoot, fmt.Sprintf("%s-%d", stamp, i), rel)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", fmt.Errorf("create trash: %w", err)
+	}
+	return dest, nil
+}
+
+// MoveFile renames or moves a file or directory
+func (e *NativeExecutor) MoveFile(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		Source      string `json:"source"`
+		Destination string `json:"destination"`
+		Overwrite   bool   `json:"overwrite"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if payload.Source == "" || payload.Destination == "" {
+		return "", fmt.Errorf("source and destination are required")
+	}
+
+	src, _ := e.resolvePath(payload.Source)
+	dst, _ := e.resolvePath(payload.Destination)
+	if _, err := os.Lstat(src); err != nil {
+		return "", fmt.Errorf("move: %w", err)
+	}
+	if info, err := os.Stat(dst); err == nil {
+		if info.IsDir() {
+			return "", fmt.Errorf("destination %s is an existing directory; give the full target path", payload.Destination)
+		}
+		if !payload.Overwrite {
+			return "", fmt.Errorf("destination %s exists; set overwrite to replace it", payload.Destination)
+		}
+	}
+	for _, p := range []string{payload.Source, payload.Destination} {
+		if err := e.checkWritable(p); err != nil {
+			return "", err
+		}
+	}
+
+	// A rule for the source alone must not let a file land anywhere
+	if err := e.ensurePathsConsent(ctx, "move_file", []string{payload.Source, payload.Destination}, fmt.Sprintf("Move: %s -> %s", payload.Source, payload.Destination), ""); err != nil {
+		return "", err
+	}
+	if err := e.checkpoint(fmt.Sprintf("Checkpoint before moving %s to %s", payload.Source, payload.Destination), src, dst); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", fmt.Errorf("move: %w", err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return "", fmt.Errorf("move: %w", err)
+	}
+	return fmt.Sprintf("Moved %s to %s", payload.Source, payload.Destination), nil
+}
+
+// CreateDirectory creates a directory and any missing parents
+func (e *NativeExecutor) CreateDirectory(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if payload.Path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+
+	absPath, _ := e.resolvePath(payload.Path)
+	if info, err := os.Stat(absPath); err == nil {
+		if info.IsDir() {
+			return fmt.Sprintf("Directory %s already exists", payload.Path), nil
+		}
+		return "", fmt.Errorf("%s exists and is not a directory", payload.Path)
+	}
+	if err := e.checkWritable(payload.Path); err != nil {
+		return "", err
+	}
+	if err := e.ensureConsent(ctx, "create_directory", payload.Path, fmt.Sprintf("Create directory: %s", payload.Path)); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(absPath, 0755); err != nil {
+		return "", fmt.Errorf("create directory: %w", err)
+	}
+	return fmt.Sprintf("Created directory %s", payload.Path), nil
+}
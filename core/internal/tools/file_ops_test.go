@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+	"github.com/igoryan-dao/ricochet/internal/host"
+	"github.com/igoryan-dao/ricochet/internal/modes"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+func TestFileOpsWithTrash(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	for _, k := range []string{"GIT_AUTHOR", "GIT_COMMITTER"} {
+		t.Setenv(k+"_NAME", "test")
+		t.Setenv(k+"_EMAIL", "test@example.com")
+	}
+	dir := t.TempDir()
+	sg, err := safeguard.NewManager(dir)
+	if err != nil {
+		t.Skipf("safeguard unavailable: %v", err)
+	}
+	sg.AutoApproval = &config.AutoApprovalSettings{Enabled: true}
+	e := &NativeExecutor{host: host.NewNativeHost(dir), modes: modes.NewManager(dir), safeguard: sg}
+	ctx := context.Background()
+
+	call := func(fn func(context.Context, json.RawMessage) (string, error), args map[string]interface{}) (string, error) {
+		data, _ := json.Marshal(args)
+		return fn(ctx, data)
+	}
+
+	if _, err := call(e.CreateDirectory, map[string]interface{}{"path": "a/b"}); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "a/b/file.txt"), []byte("keep me"), 0644)
+
+	if _, err := call(e.MoveFile, map[string]interface{}{"source": "a/b/file.txt", "destination": "c/renamed.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "c/renamed.txt")); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+
+	if _, err := call(e.DeleteFile, map[string]interface{}{"path": "c"}); err == nil {
+		t.Error("non-empty directory deleted without recursive")
+	}
+	out, err := call(e.DeleteFile, map[string]interface{}{"path": "c", "recursive": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "c")); !os.IsNotExist(err) {
+		t.Error("directory still present after delete")
+	}
+
+	// The trashed copy keeps its content and relative path
+	var trashed string
+	filepath.Walk(filepath.Join(dir, TrashDir), func(p string, info os.FileInfo, err error) error {
+		if err == nil && info.Name() == "renamed.txt" {
+			trashed = p
+		}
+		return nil
+	})
+	if data, _ := os.ReadFile(trashed); string(data) != "keep me" || !strings.Contains(out, TrashDir) {
+		t.Errorf("trash copy = %q at %q (%s)", data, trashed, out)
+	}
+
+	if _, err := call(e.DeleteFile, map[string]interface{}{"path": "."}); err == nil {
+		t.Error("workspace root deleted")
+	}
+}
@@ -0,0 +1,299 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFindResults = 100
+	maxFindResults     = 1000
+)
+
+// findSkipDirs are never descended into unless they are the search root
+var findSkipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"out":          true,
+	"__pycache__":  true,
+}
+
+// byteSize accepts a byte count or a string such as "10KB" or "1.5MB"
+type byteSize int64
+
+func (b *byteSize) UnmarshalJSON(data []byte) error {
+	var n int64
+	if err := json.Unmarshal(data, &n); err == nil {
+		*b = byteSize(n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("size must be a number of bytes or a string like \"10KB\"")
+	}
+	v, err := parseByteSize(s)
+	if err != nil {
+		return err
+	}
+	*b = byteSize(v)
+	return nil
+}
+
+func parseByteSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	mult := int64(1)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, mult = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.mult
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(v * float64(mult)), nil
+}
+
+// parseAge parses a duration, also accepting days ("7d")
+func parseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// FindByName lists files and directories matching a glob and optional
+// extension, type, size and modification-time filters
+func (e *NativeExecutor) FindByName(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		Pattern        string   `json:"pattern"`
+		Path           string   `json:"path"`
+		Extensions     []string `json:"extensions"`
+		Type           string   `json:"type"` // file, dir or "" for both
+		MinSize        byteSize `json:"min_size"`
+		MaxSize        byteSize `json:"max_size"`
+		ModifiedWithin string   `json:"modified_within"`
+		MaxResults     int      `json:"max_results"`
+		IncludeHidden  bool     `json:"include_hidden"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	root, _ := e.resolvePath(payload.Path)
+	if payload.Path == "" {
+		root = e.host.GetCWD()
+	}
+	if err := e.checkAccess(root, false); err != nil {
+		return "", err
+	}
+
+	patterns := expandBraces(filepath.ToSlash(strings.TrimSpace(payload.Pattern)))
+	for _, p := range patterns {
+		if _, err := path.Match(strings.ReplaceAll(p, "**", "*"), ""); err != nil {
+			return "", fmt.Errorf("invalid pattern %q: %w", payload.Pattern, err)
+		}
+	}
+
+	exts := make(map[string]bool)
+	for _, ext := range payload.Extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext != "" && !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		exts[ext] = true
+	}
+
+	var since time.Time
+	if payload.ModifiedWithin != "" {
+		age, err := parseAge(payload.ModifiedWithin)
+		if err != nil {
+			return "", err
+		}
+		since = time.Now().Add(-age)
+	}
+
+	wantType := strings.ToLower(payload.Type)
+	if wantType != "" && wantType != "file" && wantType != "dir" {
+		return "", fmt.Errorf("type must be \"file\" or \"dir\"")
+	}
+	limit := payload.MaxResults
+	if limit <= 0 {
+		limit = defaultFindResults
+	}
+	limit = min(limit, maxFindResults)
+
+	type match struct {
+		rel     string
+		isDir   bool
+		size    int64
+		modTime time.Time
+	}
+	var matches []match
+	total := 0
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err
+			}
+			return nil // Unreadable entry: skip it
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+
+		name := d.Name()
+		if d.IsDir() && (findSkipDirs[name] || (!payload.IncludeHidden && strings.HasPrefix(name, "."))) {
+			return filepath.SkipDir
+		}
+		if !payload.IncludeHidden && strings.HasPrefix(name, ".") {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+
+		if wantType == "file" && d.IsDir() || wantType == "dir" && !d.IsDir() {
+			return nil
+		}
+		if len(patterns) > 0 && patterns[0] != "" && !matchAnyGlob(patterns, rel) {
+			return nil
+		}
+		if len(exts) > 0 && (d.IsDir() || !exts[strings.ToLower(filepath.Ext(name))]) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			if payload.MinSize > 0 && info.Size() < int64(payload.MinSize) {
+				return nil
+			}
+			if payload.MaxSize > 0 && info.Size() > int64(payload.MaxSize) {
+				return nil
+			}
+		}
+		if !since.IsZero() && info.ModTime().Before(since) {
+			return nil
+		}
+
+		total++
+		if len(matches) < limit {
+			matches = append(matches, match{rel: rel, isDir: d.IsDir(), size: info.Size(), modTime: info.ModTime()})
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("find: %w", err)
+	}
+
+	if total == 0 {
+		return "No matching files found.", nil
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].rel < matches[j].rel })
+	var sb strings.Builder
+	for _, m := range matches {
+		if m.isDir {
+			sb.WriteString(fmt.Sprintf("%s/ (dir)\n", m.rel))
+		} else {
+			sb.WriteString(fmt.Sprintf("%s (%s, %s)\n", m.rel, formatBytes(m.size), m.modTime.Format("2006-01-02 15:04")))
+		}
+	}
+	if total > len(matches) {
+		sb.WriteString(fmt.Sprintf("... %d more match(es) not shown; narrow the pattern or raise max_results\n", total-len(matches)))
+	}
+	return sb.String(), nil
+}
+
+// matchAnyGlob reports whether rel matches one of the patterns. A pattern
+// without "/" matches the base name at any depth; "**" spans directories.
+func matchAnyGlob(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if !strings.Contains(p, "/") {
+			if ok, _ := path.Match(p, path.Base(rel)); ok {
+				return true
+			}
+			continue
+		}
+		if matchSegments(strings.Split(strings.TrimPrefix(p, "./"), "/"), strings.Split(rel, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pat, parts []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pat[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], parts[0]); !ok {
+			return false
+		}
+		pat, parts = pat[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// expandBraces expands one level of {a,b} alternatives: "*.{ts,tsx}" -> "*.ts", "*.tsx"
+func expandBraces(p string) []string {
+	open := strings.IndexByte(p, '{')
+	if open < 0 {
+		return []string{p}
+	}
+	end := strings.IndexByte(p[open:], '}')
+	if end < 0 {
+		return []string{p}
+	}
+	end += open
+	var out []string
+	for _, alt := range strings.Split(p[open+1:end], ",") {
+		out = append(out, expandBraces(p[:open]+alt+p[end+1:])...)
+	}
+	return out
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
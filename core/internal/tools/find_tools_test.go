@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/host"
+)
+
+func TestMatchAnyGlob(t *testing.T) {
+	tests := []struct {
+		pattern, rel string
+		want         bool
+	}{
+		{"*.go", "internal/tools/find.go", true},
+		{"**/*_test.go", "a/b/c_test.go", true},
+		{"**/*_test.go", "c_test.go", true},
+		{"internal/**/handler*", "internal/server/handler.go", true},
+		{"internal/*/handler*", "internal/a/b/handler.go", false},
+		{"*.{ts,tsx}", "web/app.tsx", true},
+		{"src/*.go", "lib/x.go", false},
+	}
+	for _, tt := range tests {
+		if got := matchAnyGlob(expandBraces(tt.pattern), tt.rel); got != tt.want {
+			t.Errorf("match(%q, %q) = %v, want %v", tt.pattern, tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestFindByName(t *testing.T) {
+	dir := t.TempDir()
+	for name, size := range map[string]int{
+		"main.go":                  10,
+		"pkg/big.go":               5000,
+		"pkg/small_test.go":        10,
+		"node_modules/dep/x.go":    10,
+		".hidden/secret.go":        10,
+		"docs/readme.md":           10,
+		"docs/nested/deep/page.md": 10,
+	} {
+		p := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e := &NativeExecutor{host: host.NewNativeHost(dir)}
+
+	find := func(args map[string]interface{}) []string {
+		t.Helper()
+		data, _ := json.Marshal(args)
+		out, err := e.FindByName(context.Background(), data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			paths = append(paths, strings.Fields(line)[0])
+		}
+		return paths
+	}
+
+	if got := find(map[string]interface{}{"pattern": "*.go"}); strings.Join(got, ",") != "main.go,pkg/big.go,pkg/small_test.go" {
+		t.Errorf("*.go = %v", got)
+	}
+	if got := find(map[string]interface{}{"extensions": []string{"go"}, "min_size": "1KB"}); strings.Join(got, ",") != "pkg/big.go" {
+		t.Errorf("min_size = %v", got)
+	}
+	if got := find(map[string]interface{}{"pattern": "docs/**", "type": "file"}); strings.Join(got, ",") != "docs/nested/deep/page.md,docs/readme.md" {
+		t.Errorf("docs/** = %v", got)
+	}
+	if got := find(map[string]interface{}{"pattern": "*.md", "max_results": 1}); len(got) != 2 || got[1] != "..." {
+		t.Errorf("max_results = %v", got)
+	}
+}
@@ -7,7 +7,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/igoryan-dao/ricochet/internal/format"
+	"github.com/igoryan-dao/ricochet/internal/index"
 	"github.com/igoryan-dao/ricochet/internal/safeguard"
 )
 
@@ -18,6 +21,24 @@ func (e *NativeExecutor) resolvePath(path string) (string, error) {
 	return filepath.Join(e.host.GetCWD(), path), nil
 }
 
+// checkAccess runs the safeguard checks of a file tool: the workspace
+// boundary and denied directories, then the permissions.yaml globs
+func (e *NativeExecutor) checkAccess(path string, write bool) error {
+	if e.safeguard == nil {
+		return nil
+	}
+	abs, _ := e.resolvePath(path)
+	if _, err := e.safeguard.CheckPath(abs, write); err != nil {
+		return fmt.Errorf("safeguard: %w", err)
+	}
+	if e.safeguard.Permissions != nil {
+		if err := e.safeguard.CheckFileAccess(path, write); err != nil {
+			return fmt.Errorf("safeguard: %w", err)
+		}
+	}
+	return nil
+}
+
 func (e *NativeExecutor) ListDir(args json.RawMessage) (string, error) {
 	var payload struct {
 		Path string `json:"path"`
@@ -26,6 +47,10 @@ func (e *NativeExecutor) ListDir(args json.RawMessage) (string, error) {
 		return "", fmt.Errorf("invalid arguments: %w", err)
 	}
 
+	if err := e.checkAccess(payload.Path, false); err != nil {
+		return "", err
+	}
+
 	infos, err := e.host.ListDir(payload.Path)
 	if err != nil {
 		return "", fmt.Errorf("list dir: %w", err)
@@ -55,10 +80,8 @@ func (e *NativeExecutor) ReadFile(args json.RawMessage) (string, error) {
 	}
 
 	// Granular Check (Phase 13)
-	if e.safeguard != nil && e.safeguard.Permissions != nil {
-		if err := e.safeguard.CheckFileAccess(payload.Path, false); err != nil {
-			return "", fmt.Errorf("safeguard: %w", err)
-		}
+	if err := e.checkAccess(payload.Path, false); err != nil {
+		return "", err
 	}
 
 	content, err := e.host.ReadFile(payload.Path)
@@ -95,14 +118,14 @@ func (e *NativeExecutor) WriteFile(ctx context.Context, args json.RawMessage) (s
 	}
 
 	// Granular Check (Phase 13)
-	if e.safeguard != nil && e.safeguard.Permissions != nil {
-		if err := e.safeguard.CheckFileAccess(payload.Path, true); err != nil {
-			return "", fmt.Errorf("safeguard: %w", err)
-		}
+	if err := e.checkAccess(payload.Path, true); err != nil {
+		return "", err
 	}
 
 	// INTERACTIVE CONSENT (Phase 11)
-	if err := e.ensureConsent(ctx, "write_file", payload.Path, fmt.Sprintf("Write to file: %s", payload.Path)); err != nil {
+	existing, _ := e.host.ReadFile(payload.Path) // Empty for a new file
+	diff := format.UnifiedDiff(payload.Path, string(existing), payload.Content)
+	if err := e.ensureEditConsent(ctx, "write_file", payload.Path, fmt.Sprintf("Write to file: %s", payload.Path), diff); err != nil {
 		return "", err
 	}
 
@@ -131,7 +154,7 @@ func (e *NativeExecutor) WriteFile(ctx context.Context, args json.RawMessage) (s
 	}
 
 	if e.shadowVerifier != nil && !bypassCorrection {
-		if err := e.shadowVerifier.Verify(ctx, payload.Path); err != nil {
+		if err := e.verifier().Verify(ctx, absPath); err != nil {
 			// We return an error to force the agent to fix it.
 			// But we clarify that the file WAS written.
 			return "", fmt.Errorf("file written, but failed verification: %w. Please fix the code", err)
@@ -141,12 +164,35 @@ func (e *NativeExecutor) WriteFile(ctx context.Context, args json.RawMessage) (s
 	return "File written successfully", nil
 }
 
+// verifier returns the shadow verifier with the tools.verification settings
+func (e *NativeExecutor) verifier() *safeguard.ShadowVerifier {
+	if e.safeguard == nil || e.safeguard.ToolsSettings == nil {
+		return e.shadowVerifier
+	}
+	return e.shadowVerifier.WithSettings(e.host.GetCWD(), e.safeguard.ToolsSettings.Verification)
+}
+
 func (e *NativeExecutor) ensureConsent(ctx context.Context, tool, path, description string) error {
+	return e.ensureEditConsent(ctx, tool, path, description, "")
+}
+
+// ensureEditConsent is ensureConsent for a file edit, whose unified diff is
+// shown to a remote approver
+func (e *NativeExecutor) ensureEditConsent(ctx context.Context, tool, path, description, diff string) error {
+	return e.ensurePathsConsent(ctx, tool, []string{path}, description, diff)
+}
+
+// ensurePathsConsent is ensureEditConsent for a call touching several
+// files: each one is checked and recorded on its own
+func (e *NativeExecutor) ensurePathsConsent(ctx context.Context, tool string, paths []string, description, diff string) error {
 	// 0. Check AutoApproval settings (Always Proceed)
 	if e.safeguard != nil && e.safeguard.AutoApproval != nil && e.safeguard.AutoApproval.Enabled {
 		// Phase 11 Fix: If Auto-Approval is globally enabled (Act Mode), we allow ALL actions.
 		// Previous granular logic caused false positives where "Act" mode was active but specific
 		// flags were missing, causing telegram bugs.
+		for _, path := range paths {
+			e.recordApproval(ctx, tool, path, true, true, "auto_approval", safeguard.ChannelPolicy)
+		}
 		return nil
 
 		/* Granular checks preserved for reference or future specific modes
@@ -155,7 +201,7 @@ func (e *NativeExecutor) ensureConsent(ctx context.Context, tool, path, descript
 			if e.safeguard.AutoApproval.ExecuteAllCommands {
 				return nil
 			}
-		case "write_file", "replace_file_content", "apply_diff":
+		case "write_file", "replace_file_content", "apply_diff", "edit_files", "delete_file", "move_file", "create_directory", "rename_symbol":
 			if e.safeguard.AutoApproval.EditFiles {
 				return nil
 			}
@@ -170,14 +216,52 @@ func (e *NativeExecutor) ensureConsent(ctx context.Context, tool, path, descript
 		}
 		*/
 	}
+	if len(paths) == 1 && e.zoneApproves(ctx, tool, paths[0]) {
+		return nil
+	}
+
+	return e.askPathsConsent(ctx, tool, paths, description, diff)
+}
+
+// askConsent asks the user to approve an action unless a persistent "always"
+// rule already covers it. Unlike ensureConsent it ignores auto-approval, for
+// policies that demand a prompt.
+func (e *NativeExecutor) askConsent(ctx context.Context, tool, path, description string) error {
+	return e.askEditConsent(ctx, tool, path, description, "")
+}
 
+// askEditConsent is askConsent with the diff of a file edit, if any
+func (e *NativeExecutor) askEditConsent(ctx context.Context, tool, path, description, diff string) error {
+	return e.askPathsConsent(ctx, tool, []string{path}, description, diff)
+}
+
+// askPathsConsent is askEditConsent for a call touching several files. The
+// question is skipped only when persistent rules allow every one of them,
+// and "always" saves a rule for each.
+func (e *NativeExecutor) askPathsConsent(ctx context.Context, tool string, paths []string, description, diff string) error {
 	// 1. Check persistent permissions (Phase 15)
 	if e.safeguard != nil && e.safeguard.PermissionStore != nil {
-		if e.safeguard.PermissionStore.IsAllowed(tool, path) {
+		ruleIDs := make([]string, 0, len(paths))
+		for _, path := range paths {
+			id, ok := e.safeguard.PermissionStore.AllowingRule(tool, path)
+			if !ok {
+				break
+			}
+			ruleIDs = append(ruleIDs, id)
+		}
+		if len(ruleIDs) == len(paths) {
+			for i, path := range paths {
+				e.recordApproval(ctx, tool, path, true, true, "rule "+ruleIDs[i], safeguard.ChannelPolicy)
+			}
 			return nil // Auto-allowed
 		}
 	}
+	return e.promptPathsConsent(ctx, tool, paths, description, diff, true)
+}
 
+// promptPathsConsent puts an action to the user, whatever the stored rules.
+// "Always" saves a rule for each path only if remember is set.
+func (e *NativeExecutor) promptPathsConsent(ctx context.Context, tool string, paths []string, description, diff string, remember bool) error {
 	// 2. Check mode context
 	mode := e.modes.GetActiveMode()
 	question := fmt.Sprintf("Mode: %s\n\nDo you allow Ricochet to perform the following action?\n\n%s", mode.Name, description)
@@ -185,12 +269,21 @@ func (e *NativeExecutor) ensureConsent(ctx context.Context, tool, path, descript
 	// 3. Ask User (Dual-Channel if Live Mode enabled)
 	var response string
 	var err error
+	defer addConsentWait(ctx, time.Now())
 
-	if e.livemode != nil && e.livemode.IsEnabled() {
+	remote := e.livemode != nil && e.livemode.IsEnabled()
+	if remote {
 		// Ether Mode: Ask via Telegram ONLY
-		response, err = e.livemode.AskUserRemote(ctx, question)
+		if diff != "" {
+			response, err = e.livemode.AskApproveDiffRemote(ctx, question, strings.Join(paths, ", "), diff)
+		} else {
+			response, err = e.livemode.AskUserRemote(ctx, question)
+		}
 	} else {
 		// IDE Mode - ask via host popup only
+		if e.notifier != nil {
+			e.notifier.Notify("Ricochet needs approval", description)
+		}
 		response, err = e.host.AskUser(question)
 	}
 
@@ -203,27 +296,38 @@ func (e *NativeExecutor) ensureConsent(ctx context.Context, tool, path, descript
 
 	// Handle various positive responses
 	if resp == "yes" || resp == "y" || resp == "approve" || resp == "ok" {
+		for _, path := range paths {
+			e.recordUserApproval(ctx, tool, path, true, remote)
+		}
 		return nil
 	}
 
 	// Handle "Always" variations
 	if strings.Contains(resp, "always") {
-		// "always allow", "always proceed", "always"
-		if e.safeguard != nil && e.safeguard.PermissionStore != nil {
-			err := e.safeguard.PermissionStore.AddRule(safeguard.PermissionRule{
-				Tool:   tool,
-				Path:   path,
-				Action: "allow",
-				Scope:  safeguard.ScopeProject,
-			})
-			if err != nil {
-				// Log but allow once
-				fmt.Printf("Warning: failed to save permission: %v\n", err)
+		// "always allow", "always proceed", "always"; a rule without a
+		// target would allow any use of the tool, so none is saved then
+		for _, path := range paths {
+			if remember && e.safeguard != nil && e.safeguard.PermissionStore != nil && path != "" {
+				err := e.safeguard.PermissionStore.AddRule(safeguard.PermissionRule{
+					Tool:   tool,
+					Path:   path,
+					Exact:  true,
+					Action: "allow",
+					Scope:  safeguard.ScopeProject,
+				})
+				if err != nil {
+					// Log but allow once
+					fmt.Printf("Warning: failed to save permission: %v\n", err)
+				}
 			}
+			e.recordUserApproval(ctx, tool, path, true, remote)
 		}
 		return nil
 	}
 
+	for _, path := range paths {
+		e.recordUserApproval(ctx, tool, path, false, remote)
+	}
 	return fmt.Errorf("action was rejected by user")
 }
 
@@ -233,8 +337,9 @@ func (e *NativeExecutor) CodebaseSearch(ctx context.Context, args json.RawMessag
 	}
 
 	var payload struct {
-		Query string `json:"query"`
-		Limit int    `json:"limit"`
+		Query string   `json:"query"`
+		Limit int      `json:"limit"`
+		Repos []string `json:"repos"`
 	}
 	if err := json.Unmarshal(args, &payload); err != nil {
 		return "", fmt.Errorf("invalid arguments: %w", err)
@@ -244,7 +349,15 @@ func (e *NativeExecutor) CodebaseSearch(ctx context.Context, args json.RawMessag
 		payload.Limit = 5
 	}
 
-	results, err := e.indexer.Search(ctx, payload.Query, payload.Limit)
+	var results []index.SearchResult
+	var err error
+	if e.indexRoots != nil {
+		results, err = e.indexRoots.Search(ctx, payload.Query, payload.Limit, payload.Repos)
+	} else if len(payload.Repos) > 0 {
+		return "", fmt.Errorf("only the workspace is indexed; there are no other repos to search")
+	} else {
+		results, err = e.indexer.Search(ctx, payload.Query, payload.Limit)
+	}
 	if err != nil {
 		return "", fmt.Errorf("search failed: %w", err)
 	}
@@ -254,10 +367,22 @@ func (e *NativeExecutor) CodebaseSearch(ctx context.Context, args json.RawMessag
 	}
 
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Semantic search results for '%s':\n\n", payload.Query))
+	sb.WriteString(fmt.Sprintf("Code search results for '%s':\n\n", payload.Query))
+	multi := e.indexRoots != nil && e.indexRoots.Multi()
 	for _, res := range results {
+		path := res.Document.FilePath
+		if multi {
+			path = fmt.Sprintf("[%s] %s", res.Repo, e.indexRoots.Resolve(res.Repo, path))
+		}
 		sb.WriteString(fmt.Sprintf("--- %s (Lines %d-%d, Score: %.2f) ---\n",
-			res.Document.FilePath, res.Document.LineStart, res.Document.LineEnd, res.Score))
+			path, res.Document.LineStart, res.Document.LineEnd, res.Score))
+		if symbols := res.Document.Symbols(); len(symbols) > 0 {
+			var names []string
+			for _, sym := range symbols {
+				names = append(names, fmt.Sprintf("%s %s (line %d, character %d)", sym.Kind, sym.Name, sym.Line, sym.Character))
+			}
+			sb.WriteString("Symbols: " + strings.Join(names, ", ") + "\n")
+		}
 		sb.WriteString(res.Document.Content)
 		sb.WriteString("\n\n")
 	}
@@ -313,10 +438,8 @@ func (e *NativeExecutor) ReplaceFileContent(ctx context.Context, args json.RawMe
 	}
 
 	// Granular Check (Phase 13)
-	if e.safeguard != nil && e.safeguard.Permissions != nil {
-		if err := e.safeguard.CheckFileAccess(payload.Path, true); err != nil {
-			return "", fmt.Errorf("safeguard: %w", err)
-		}
+	if err := e.checkAccess(payload.Path, true); err != nil {
+		return "", err
 	}
 
 	// Verify file exists and read it
@@ -350,7 +473,8 @@ func (e *NativeExecutor) ReplaceFileContent(ctx context.Context, args json.RawMe
 	// Let's implement directly to use correct tool name "replace_file_content".
 
 	// INTERACTIVE CONSENT
-	if err := e.ensureConsent(ctx, "replace_file_content", payload.Path, fmt.Sprintf("Replace content in file: %s", payload.Path)); err != nil {
+	diff := format.UnifiedDiff(payload.Path, content, newContent)
+	if err := e.ensureEditConsent(ctx, "replace_file_content", payload.Path, fmt.Sprintf("Replace content in file: %s", payload.Path), diff); err != nil {
 		return "", err
 	}
 
@@ -374,3 +498,113 @@ func (e *NativeExecutor) ReplaceFileContent(ctx context.Context, args json.RawMe
 
 	return "File updated successfully", nil
 }
+
+func (e *NativeExecutor) ApplyDiff(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		Path string `json:"path"`
+		Diff string `json:"diff"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(payload.Diff) == "" {
+		return "", fmt.Errorf("diff cannot be empty")
+	}
+
+	files, err := parseUnifiedDiff(payload.Diff)
+	if err != nil {
+		return "", fmt.Errorf("invalid diff: %w", err)
+	}
+
+	// Apply every file in memory first so a bad hunk leaves nothing half-written
+	type pendingWrite struct {
+		path    string
+		content string
+		summary string
+	}
+	var writes []pendingWrite
+	for _, fd := range files {
+		path := fd.path()
+		if path == "" || (payload.Path != "" && len(files) == 1) {
+			path = payload.Path
+		}
+		if path == "" {
+			return "", fmt.Errorf("path is required when the diff has no ---/+++ file headers")
+		}
+		if fd.newPath == "/dev/null" {
+			return "", fmt.Errorf("%s: apply_diff cannot delete files", path)
+		}
+
+		if allowed, msg := e.modes.CanAccessFile(path); !allowed {
+			return "", fmt.Errorf("permission denied: %s", msg)
+		}
+		if err := e.checkAccess(path, true); err != nil {
+			return "", err
+		}
+
+		var content string
+		if existing, err := e.host.ReadFile(path); err == nil {
+			if fd.isNew() {
+				return "", fmt.Errorf("%s: diff creates the file but it already exists", path)
+			}
+			content = string(existing)
+		} else if !fd.isNew() {
+			return "", fmt.Errorf("read file failed: %w", err)
+		}
+
+		res, err := applyHunks(content, fd.hunks)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+
+		var adds, dels int
+		for _, h := range fd.hunks {
+			adds += h.adds
+			dels += h.dels
+		}
+		summary := fmt.Sprintf("%s: %d hunk(s), +%d -%d", path, len(fd.hunks), adds, dels)
+		if len(res.fuzzy) > 0 {
+			summary += " (" + strings.Join(res.fuzzy, "; ") + ")"
+		}
+		writes = append(writes, pendingWrite{path: path, content: res.content, summary: summary})
+	}
+
+	paths := make([]string, len(writes))
+	for i, w := range writes {
+		paths[i] = w.path
+	}
+
+	if err := e.checkPathZones("apply_diff", paths...); err != nil {
+		return "", fmt.Errorf("safeguard violation: %w", err)
+	}
+
+	// INTERACTIVE CONSENT
+	if err := e.ensurePathsConsent(ctx, "apply_diff", paths, fmt.Sprintf("Apply diff to: %s", strings.Join(paths, ", ")), payload.Diff); err != nil {
+		return "", err
+	}
+
+	// CHECKPOINT
+	if e.safeguard != nil {
+		msg := fmt.Sprintf("Checkpoint before apply_diff in %s", strings.Join(paths, ", "))
+		if _, err := e.safeguard.CreateCheckpoint(msg); err != nil {
+			return "", fmt.Errorf("failed to create checkpoint: %w", err)
+		}
+	} else {
+		for _, p := range paths {
+			if err := safeguard.Backup(e.host.GetCWD() + "/" + p); err != nil {
+				return "", fmt.Errorf("safeguard backup failed: %w", err)
+			}
+		}
+	}
+
+	// WRITE
+	var sb strings.Builder
+	sb.WriteString("Diff applied successfully\n")
+	for _, w := range writes {
+		if err := e.host.WriteFile(w.path, []byte(w.content)); err != nil {
+			return "", fmt.Errorf("write file failed: %w", err)
+		}
+		sb.WriteString(w.summary + "\n")
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,167 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
+)
+
+const (
+	defaultHTTPTimeout = 30 * time.Second
+	maxHTTPTimeout     = 2 * time.Minute
+	// maxHTTPBody caps the response body returned to the model
+	maxHTTPBody = 16 * 1024
+	// maxHTTPRead is how much of the body is read before giving up on the rest
+	maxHTTPRead = 10 << 20
+)
+
+// HTTPRequest sends a single HTTP request and reports status, headers and
+// the (truncated) body. Hosts outside the safeguard domain allow list need
+// approval, including redirect targets.
+func (e *NativeExecutor) HTTPRequest(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		Method  string            `json:"method"`
+		URL     string            `json:"url"`
+		Headers map[string]string `json:"headers"`
+		Body    string            `json:"body"`
+		Timeout int               `json:"timeout"` // Seconds
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	method := strings.ToUpper(strings.TrimSpace(payload.Method))
+	if method == "" {
+		method = http.MethodGet
+	}
+	target, err := url.Parse(strings.TrimSpace(payload.URL))
+	if err != nil || target.Host == "" {
+		return "", fmt.Errorf("invalid url %q: an absolute http(s) URL is required", payload.URL)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return "", fmt.Errorf("unsupported scheme %q: only http and https are allowed", target.Scheme)
+	}
+	if err := e.checkDomain(ctx, "http_request", method, target); err != nil {
+		return "", err
+	}
+
+	timeout := defaultHTTPTimeout
+	if payload.Timeout > 0 {
+		timeout = min(time.Duration(payload.Timeout)*time.Second, maxHTTPTimeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var body io.Reader
+	if payload.Body != "" {
+		body = strings.NewReader(payload.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return "", fmt.Errorf("invalid request: %w", err)
+	}
+	for k, v := range payload.Headers {
+		req.Header.Set(k, v)
+	}
+	if payload.Body != "" && req.Header.Get("Content-Type") == "" && json.Valid([]byte(payload.Body)) {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := httpclient.Client(0) // Bounded by ctx
+	var redirects []string
+	client.CheckRedirect = func(next *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		if err := e.checkDomain(next.Context(), "http_request", next.Method, next.URL); err != nil {
+			return err
+		}
+		redirects = append(redirects, next.URL.String())
+		return nil
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "", fmt.Errorf("request timed out after %s", timeout)
+		}
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPRead+1))
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var sb strings.Builder
+	for _, r := range redirects {
+		fmt.Fprintf(&sb, "Redirected to %s\n", r)
+	}
+	fmt.Fprintf(&sb, "%s %s (%s)\n", resp.Proto, resp.Status, elapsed)
+	keys := make([]string, 0, len(resp.Header))
+	for k := range resp.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range resp.Header[k] {
+			fmt.Fprintf(&sb, "%s: %s\n", k, v)
+		}
+	}
+	sb.WriteString("\n")
+	sb.WriteString(formatHTTPBody(data))
+	return sb.String(), nil
+}
+
+// checkDomain applies the safeguard domain rules to u, asking the user about
+// hosts that are neither allowed nor denied on behalf of tool
+func (e *NativeExecutor) checkDomain(ctx context.Context, tool, method string, u *url.URL) error {
+	if e.safeguard == nil {
+		return nil
+	}
+	host := u.Hostname()
+	allowed, err := e.safeguard.CheckDomain(host)
+	if err != nil {
+		return fmt.Errorf("safeguard: %w", err)
+	}
+	if allowed {
+		return nil
+	}
+	return e.ensureConsent(ctx, tool, host, fmt.Sprintf("Send %s request to %s", method, u.Redacted()))
+}
+
+// formatHTTPBody truncates the body and hides binary content
+func formatHTTPBody(data []byte) string {
+	if len(data) == 0 {
+		return "(Empty body)"
+	}
+	size := fmt.Sprintf("%d bytes", len(data))
+	if len(data) > maxHTTPRead {
+		size = fmt.Sprintf("more than %d bytes", maxHTTPRead)
+	}
+	if bytes.IndexByte(data[:min(len(data), 512)], 0) >= 0 {
+		return fmt.Sprintf("(Binary body, %s)", size)
+	}
+	if len(data) <= maxHTTPBody {
+		return strings.ToValidUTF8(string(data), "\uFFFD")
+	}
+	cut := maxHTTPBody
+	for cut > 0 && !utf8.RuneStart(data[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s\n... (body truncated: showing %d of %s)", strings.ToValidUTF8(string(data[:cut]), "\uFFFD"), cut, size)
+}
@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+func TestHTTPRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/echo", http.StatusFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.Write([]byte(strings.Repeat(string(body), 2000)))
+	}))
+	defer srv.Close()
+
+	e := &NativeExecutor{safeguard: &safeguard.Manager{}}
+	call := func(args map[string]interface{}) (string, error) {
+		data, _ := json.Marshal(args)
+		return e.HTTPRequest(context.Background(), data)
+	}
+
+	out, err := call(map[string]interface{}{"method": "post", "url": srv.URL + "/old", "body": `{"a":1}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A 302 turns the POST into a bodyless GET
+	for _, want := range []string{"Redirected to " + srv.URL + "/echo", "200 OK", "X-Method: GET", "(Empty body)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	out, err = call(map[string]interface{}{"method": "PUT", "url": srv.URL + "/echo", "body": strings.Repeat("x", 10)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "X-Method: PUT") || !strings.Contains(out, "body truncated: showing 16384 of 20000 bytes") {
+		t.Errorf("put output = %s", out[:min(len(out), 500)])
+	}
+
+	e.safeguard.Permissions = &safeguard.PermissionConfig{Domains: safeguard.DomainRules{Deny: []string{"*"}}}
+	if _, err := call(map[string]interface{}{"url": srv.URL}); err == nil || !strings.Contains(err.Error(), "domain denied") {
+		t.Errorf("denied host error = %v", err)
+	}
+	if _, err := call(map[string]interface{}{"url": "file:///etc/passwd"}); err == nil {
+		t.Error("file URL was accepted")
+	}
+}
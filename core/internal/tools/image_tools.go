@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxImageBytes caps images sent for analysis; providers reject larger ones
+const maxImageBytes = 20 * 1024 * 1024
+
+const defaultImagePrompt = "Describe this image in detail. Transcribe any visible text, and if it shows an error, UI or diagram, explain what it shows."
+
+// imageTypes are the formats every vision API accepts
+var imageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// ImageModel sees and creates images through a multimodal provider
+type ImageModel interface {
+	AnalyzeImage(ctx context.Context, prompt string, image []byte, mimeType string) (string, error)
+	GenerateImage(ctx context.Context, prompt, size string) ([]byte, error)
+}
+
+// SetImageModel enables analyze_image and generate_image
+func (e *NativeExecutor) SetImageModel(m ImageModel) {
+	e.images = m
+}
+
+func (e *NativeExecutor) AnalyzeImage(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		Path   string `json:"path"`
+		Prompt string `json:"prompt"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if payload.Path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+	if e.images == nil {
+		return "", fmt.Errorf("image analysis is not available in this session")
+	}
+
+	path, err := e.resolvePath(payload.Path)
+	if err != nil {
+		return "", err
+	}
+	if err := e.checkAccess(path, false); err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	if info.Size() > maxImageBytes {
+		return "", fmt.Errorf("image is %s, the limit is %s", formatBytes(info.Size()), formatBytes(maxImageBytes))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	mimeType := http.DetectContentType(data)
+	if !imageTypes[mimeType] {
+		return "", fmt.Errorf("%s is %s, not a PNG, JPEG, GIF or WebP image", e.displayPath(path), mimeType)
+	}
+
+	prompt := strings.TrimSpace(payload.Prompt)
+	if prompt == "" {
+		prompt = defaultImagePrompt
+	}
+	out, err := e.images.AnalyzeImage(ctx, prompt, data, mimeType)
+	if err != nil {
+		return "", fmt.Errorf("image analysis failed: %w", err)
+	}
+	return out, nil
+}
+
+func (e *NativeExecutor) GenerateImage(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		Prompt string `json:"prompt"`
+		Size   string `json:"size"`
+		Path   string `json:"path"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(payload.Prompt) == "" {
+		return "", fmt.Errorf("prompt is required")
+	}
+	if e.images == nil {
+		return "", fmt.Errorf("image generation is not available in this session")
+	}
+
+	path := filepath.Join(e.host.GetCWD(), ".ricochet", "images", fmt.Sprintf("image_%d.png", time.Now().Unix()))
+	if payload.Path != "" {
+		var err error
+		if path, err = e.resolvePath(payload.Path); err != nil {
+			return "", err
+		}
+	}
+	if err := e.checkWritable(path); err != nil {
+		return "", err
+	}
+	// Generation is billed per image, so it is confirmed like a write
+	if err := e.ensureConsent(ctx, "generate_image", path, fmt.Sprintf("Generate an image and save it to %s:\n\n%s", e.displayPath(path), payload.Prompt)); err != nil {
+		return "", err
+	}
+
+	data, err := e.images.GenerateImage(ctx, payload.Prompt, payload.Size)
+	if err != nil {
+		return "", fmt.Errorf("image generation failed: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create image directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to save image: %w", err)
+	}
+	return fmt.Sprintf("Image generated and saved to %s (%s)", e.displayPath(path), formatBytes(int64(len(data)))), nil
+}
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeImageModel returns a fixed image and counts generations
+type fakeImageModel struct{ generated int }
+
+func (m *fakeImageModel) AnalyzeImage(ctx context.Context, prompt string, image []byte, mimeType string) (string, error) {
+	return "an image", nil
+}
+
+func (m *fakeImageModel) GenerateImage(ctx context.Context, prompt, size string) ([]byte, error) {
+	m.generated++
+	return []byte("png bytes"), nil
+}
+
+func TestGenerateImageStaysInWorkspace(t *testing.T) {
+	e, h, dir := newConsentExecutor(t)
+	h.answer = "yes"
+	model := &fakeImageModel{}
+	e.SetImageModel(model)
+	outside := filepath.Join(t.TempDir(), "escaped.png")
+
+	for _, path := range []string{outside, "../escaped.png"} {
+		args, _ := json.Marshal(map[string]string{"prompt": "a cat", "path": path})
+		if _, err := e.GenerateImage(context.Background(), args); err == nil {
+			t.Errorf("saved an image to %s", path)
+		}
+	}
+	if _, err := os.Stat(outside); !os.IsNotExist(err) {
+		t.Error("image written outside the workspace")
+	}
+	if model.generated != 0 || len(h.questions) != 0 {
+		t.Errorf("generated %d images and asked %d times for refused paths", model.generated, len(h.questions))
+	}
+
+	args, _ := json.Marshal(map[string]string{"prompt": "a cat", "path": "art/cat.png"})
+	if _, err := e.GenerateImage(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "art", "cat.png")); err != nil || string(data) != "png bytes" {
+		t.Errorf("workspace image = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/host"
+)
+
+func (e *NativeExecutor) jobManager() (host.JobManager, error) {
+	jm, ok := e.host.(host.JobManager)
+	if !ok {
+		return nil, fmt.Errorf("background jobs are not supported by this host")
+	}
+	return jm, nil
+}
+
+func (e *NativeExecutor) ListJobs() (string, error) {
+	jm, err := e.jobManager()
+	if err != nil {
+		return "", err
+	}
+	jobs := jm.ListJobs()
+	if len(jobs) == 0 {
+		return "No background jobs.", nil
+	}
+
+	var sb strings.Builder
+	for _, job := range jobs {
+		name := job.Name
+		if name == "" {
+			name = "-"
+		}
+		sb.WriteString(fmt.Sprintf("%s  %-16s %-9s pid=%-7d %-8s %s\n",
+			job.ID[:8], name, job.Status, job.PID, jobDuration(job), job.Command))
+	}
+	return sb.String(), nil
+}
+
+func (e *NativeExecutor) JobLogs(args json.RawMessage) (string, error) {
+	var payload struct {
+		ID     string `json:"id"`
+		Offset *int64 `json:"offset"`
+		Lines  int    `json:"lines"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	jm, err := e.jobManager()
+	if err != nil {
+		return "", err
+	}
+
+	offset := int64(-1) // Tail
+	if payload.Offset != nil {
+		offset = *payload.Offset
+	}
+	log, err := jm.JobLogs(payload.ID, offset, payload.Lines)
+	if err != nil {
+		return "", err
+	}
+
+	content := log.Content
+	if content == "" {
+		content = "(no new output)\n"
+	} else if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return fmt.Sprintf("--- job %s (%s), bytes %d-%d of %d ---\n%s--- next offset: %d ---",
+		log.ID[:8], log.Status, log.Offset, log.Next, log.Size, content, log.Next), nil
+}
+
+func (e *NativeExecutor) KillJob(args json.RawMessage) (string, error) {
+	var payload struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	jm, err := e.jobManager()
+	if err != nil {
+		return "", err
+	}
+	job, err := jm.KillJob(payload.ID)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Killed job %s: %s", job.ID[:8], job.Command), nil
+}
+
+// jobDuration is how long a job has run (or ran)
+func jobDuration(job host.CommandState) string {
+	end := job.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return end.Sub(job.StartTime).Round(time.Second).String()
+}
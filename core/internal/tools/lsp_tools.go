@@ -4,11 +4,141 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/igoryan-dao/ricochet/internal/lsp"
 	"github.com/igoryan-dao/ricochet/internal/protocol"
 )
 
+const (
+	// lspDiagnosticsWait is how long to wait for a language server to publish
+	// diagnostics for a newly opened or changed file
+	lspDiagnosticsWait = 5 * time.Second
+	maxReferences      = 100
+)
+
+// lspPositionSchema is the input schema shared by position-based LSP tools
+func lspPositionSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File containing the symbol",
+			},
+			"line": map[string]interface{}{
+				"type":        "integer",
+				"description": "Line number (1-indexed)",
+			},
+			"symbol": map[string]interface{}{
+				"type":        "string",
+				"description": "Symbol name on that line; locates the column so character can be omitted",
+			},
+			"character": map[string]interface{}{
+				"type":        "integer",
+				"description": "Character position (0-indexed). Optional when symbol is given",
+			},
+		},
+		"required": []string{"path", "line"},
+	}
+}
+
+// lspTarget is a symbol position given to an LSP tool
+type lspTarget struct {
+	Path      string `json:"path"`
+	Line      int    `json:"line"`
+	Character *int   `json:"character"`
+	Symbol    string `json:"symbol"`
+}
+
+// lspClient starts (or reuses) the native language server for the target
+// file and resolves the target to an LSP position
+func (e *NativeExecutor) lspClient(ctx context.Context, t lspTarget) (*lsp.Client, string, lsp.Position, error) {
+	if e.lsp == nil {
+		return nil, "", lsp.Position{}, fmt.Errorf("language servers are not available")
+	}
+	if t.Path == "" {
+		return nil, "", lsp.Position{}, fmt.Errorf("path is required")
+	}
+	abspath, err := e.resolvePath(t.Path)
+	if err != nil {
+		return nil, "", lsp.Position{}, err
+	}
+	if err := e.checkAccess(abspath, false); err != nil {
+		return nil, "", lsp.Position{}, err
+	}
+
+	pos, err := symbolPosition(abspath, t)
+	if err != nil {
+		return nil, "", lsp.Position{}, err
+	}
+	client, err := e.lsp.ClientFor(ctx, abspath)
+	if err != nil {
+		return nil, "", lsp.Position{}, fmt.Errorf("language server: %w", err)
+	}
+	if _, err := client.Sync(abspath); err != nil {
+		return nil, "", lsp.Position{}, err
+	}
+	return client, abspath, pos, nil
+}
+
+// symbolPosition converts a 1-indexed line plus symbol or character into an
+// LSP position
+func symbolPosition(abspath string, t lspTarget) (lsp.Position, error) {
+	data, err := os.ReadFile(abspath)
+	if err != nil {
+		return lsp.Position{}, fmt.Errorf("read file: %w", err)
+	}
+	lines := strings.Split(string(data), "\n")
+	if t.Line < 1 || t.Line > len(lines) {
+		return lsp.Position{}, fmt.Errorf("line %d is out of range (file has %d lines)", t.Line, len(lines))
+	}
+	text := strings.TrimRight(lines[t.Line-1], "\r")
+
+	pos := lsp.Position{Line: t.Line - 1}
+	switch {
+	case t.Symbol != "":
+		col := indexWord(text, t.Symbol)
+		if col < 0 {
+			return lsp.Position{}, fmt.Errorf("symbol %q not found on line %d: %s", t.Symbol, t.Line, strings.TrimSpace(text))
+		}
+		pos.Character = lsp.Character(text, col)
+	case t.Character != nil:
+		pos.Character = *t.Character
+	default:
+		pos.Character = lsp.Character(text, len(text)-len(strings.TrimLeft(text, " \t")))
+	}
+	return pos, nil
+}
+
+// indexWord finds word in s, preferring an occurrence not embedded in a
+// longer identifier
+func indexWord(s, word string) int {
+	isIdent := func(b byte) bool {
+		return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+	}
+	first := -1
+	for from := 0; ; {
+		i := strings.Index(s[from:], word)
+		if i < 0 {
+			return first
+		}
+		i += from
+		if first < 0 {
+			first = i
+		}
+		end := i + len(word)
+		if (i == 0 || !isIdent(s[i-1])) && (end == len(s) || !isIdent(s[end])) {
+			return i
+		}
+		from = i + 1
+	}
+}
+
 func (e *NativeExecutor) GetDiagnostics(ctx context.Context, args json.RawMessage) (string, error) {
 	var payload struct {
 		Path string `json:"path"`
@@ -23,18 +153,21 @@ func (e *NativeExecutor) GetDiagnostics(ctx context.Context, args json.RawMessag
 	}
 
 	// Send request to Host (VS Code Extension)
-	resp, err := e.host.SendRequest("get_diagnostics", map[string]string{
+	var diagnostics []protocol.Diagnostic
+	resp, hostErr := e.host.SendRequest("get_diagnostics", map[string]string{
 		"path": abspath,
 	})
-	if err != nil {
-		return "", fmt.Errorf("lsp request failed: %w", err)
-	}
-
-	// Unmarshal response
-	var diagnostics []protocol.Diagnostic
-	respBytes, _ := json.Marshal(resp) // Re-marshal interface{} or RawMessage
-	if err := json.Unmarshal(respBytes, &diagnostics); err != nil {
-		return "", fmt.Errorf("failed to parse diagnostics: %w", err)
+	if hostErr == nil {
+		respBytes, _ := json.Marshal(resp) // Re-marshal interface{} or RawMessage
+		if err := json.Unmarshal(respBytes, &diagnostics); err != nil {
+			return "", fmt.Errorf("failed to parse diagnostics: %w", err)
+		}
+	} else {
+		// No IDE: ask a local language server instead
+		diagnostics, err = e.nativeDiagnostics(ctx, abspath)
+		if err != nil {
+			return "", fmt.Errorf("lsp request failed: %v; %w", hostErr, err)
+		}
 	}
 
 	if len(diagnostics) == 0 {
@@ -48,7 +181,7 @@ func (e *NativeExecutor) GetDiagnostics(ctx context.Context, args json.RawMessag
 		switch d.Severity {
 		case "Error":
 			icon = "❌"
-		case "Information":
+		case "Information", "Hint":
 			icon = "ℹ️"
 		}
 		sb.WriteString(fmt.Sprintf("%s Line %d: [%s] %s\n", icon, d.Line, d.Severity, d.Message))
@@ -56,12 +189,33 @@ func (e *NativeExecutor) GetDiagnostics(ctx context.Context, args json.RawMessag
 	return sb.String(), nil
 }
 
-func (e *NativeExecutor) GetDefinitionsLSP(ctx context.Context, args json.RawMessage) (string, error) {
-	var payload struct {
-		Path      string `json:"path"`
-		Line      int    `json:"line"`
-		Character int    `json:"character"`
+func (e *NativeExecutor) nativeDiagnostics(ctx context.Context, abspath string) ([]protocol.Diagnostic, error) {
+	if e.lsp == nil {
+		return nil, fmt.Errorf("language servers are not available")
 	}
+	client, err := e.lsp.ClientFor(ctx, abspath)
+	if err != nil {
+		return nil, err
+	}
+	published, err := client.Diagnostics(abspath, lspDiagnosticsWait)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]protocol.Diagnostic, 0, len(published))
+	for _, d := range published {
+		out = append(out, protocol.Diagnostic{
+			File:     abspath,
+			Line:     d.Range.Start.Line + 1,
+			Message:  d.Message,
+			Severity: lsp.SeverityName(d.Severity),
+		})
+	}
+	return out, nil
+}
+
+func (e *NativeExecutor) GetDefinitionsLSP(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload lspTarget
 	if err := json.Unmarshal(args, &payload); err != nil {
 		return "", fmt.Errorf("invalid arguments: %w", err)
 	}
@@ -72,20 +226,37 @@ func (e *NativeExecutor) GetDefinitionsLSP(ctx context.Context, args json.RawMes
 	}
 
 	// Send request to Host (VS Code Extension)
-	resp, err := e.host.SendRequest("get_definitions", map[string]interface{}{
+	var locations []protocol.DefinitionLocation
+	character := 0
+	if payload.Character != nil {
+		character = *payload.Character
+	}
+	resp, hostErr := e.host.SendRequest("get_definitions", map[string]interface{}{
 		"path":      abspath,
 		"line":      payload.Line,
-		"character": payload.Character,
+		"character": character,
 	})
-	if err != nil {
-		return "", fmt.Errorf("lsp request failed: %w", err)
-	}
-
-	// Unmarshal response
-	var locations []protocol.DefinitionLocation
-	respBytes, _ := json.Marshal(resp)
-	if err := json.Unmarshal(respBytes, &locations); err != nil {
-		return "", fmt.Errorf("failed to parse definitions: %w", err)
+	if hostErr == nil {
+		respBytes, _ := json.Marshal(resp)
+		if err := json.Unmarshal(respBytes, &locations); err != nil {
+			return "", fmt.Errorf("failed to parse definitions: %w", err)
+		}
+	} else {
+		client, _, pos, err := e.lspClient(ctx, payload)
+		if err != nil {
+			return "", fmt.Errorf("lsp request failed: %v; %w", hostErr, err)
+		}
+		locs, err := client.Definition(ctx, abspath, pos)
+		if err != nil {
+			return "", fmt.Errorf("definition: %w", err)
+		}
+		for _, loc := range locs {
+			locations = append(locations, protocol.DefinitionLocation{
+				File:      lsp.URIToPath(loc.URI),
+				StartLine: loc.Range.Start.Line + 1,
+				EndLine:   loc.Range.End.Line + 1,
+			})
+		}
 	}
 
 	if len(locations) == 0 {
@@ -98,3 +269,177 @@ func (e *NativeExecutor) GetDefinitionsLSP(ctx context.Context, args json.RawMes
 	}
 	return sb.String(), nil
 }
+
+// GetReferences lists every reference to a symbol with its source line
+func (e *NativeExecutor) GetReferences(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload lspTarget
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	client, abspath, pos, err := e.lspClient(ctx, payload)
+	if err != nil {
+		return "", err
+	}
+
+	locs, err := client.References(ctx, abspath, pos)
+	if err != nil {
+		return "", fmt.Errorf("references: %w", err)
+	}
+	if len(locs) == 0 {
+		return "No references found.", nil
+	}
+
+	sort.Slice(locs, func(i, j int) bool {
+		if locs[i].URI != locs[j].URI {
+			return locs[i].URI < locs[j].URI
+		}
+		return locs[i].Range.Start.Line < locs[j].Range.Start.Line
+	})
+
+	files := make(map[string][]string) // Source lines, read once per file
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d reference(s):\n", len(locs)))
+	for i, loc := range locs {
+		if i == maxReferences {
+			sb.WriteString(fmt.Sprintf("... %d more not shown\n", len(locs)-maxReferences))
+			break
+		}
+		path := lsp.URIToPath(loc.URI)
+		lines, ok := files[path]
+		if !ok {
+			if data, err := os.ReadFile(path); err == nil {
+				lines = strings.Split(string(data), "\n")
+			}
+			files[path] = lines
+		}
+		text := ""
+		if n := loc.Range.Start.Line; n < len(lines) {
+			text = strings.TrimSpace(lines[n])
+		}
+		sb.WriteString(fmt.Sprintf("%s:%d: %s\n", e.displayPath(path), loc.Range.Start.Line+1, truncate(text, 120)))
+	}
+	return sb.String(), nil
+}
+
+// HoverDocs returns the signature and documentation for a symbol
+func (e *NativeExecutor) HoverDocs(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload lspTarget
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	client, abspath, pos, err := e.lspClient(ctx, payload)
+	if err != nil {
+		return "", err
+	}
+
+	text, err := client.Hover(ctx, abspath, pos)
+	if err != nil {
+		return "", fmt.Errorf("hover: %w", err)
+	}
+	if text == "" {
+		return "No documentation found at this position.", nil
+	}
+	return text, nil
+}
+
+// RenameSymbol renames a symbol across the workspace using the language
+// server's edits, applied under one checkpoint and rolled back on failure
+func (e *NativeExecutor) RenameSymbol(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		lspTarget
+		NewName string `json:"new_name"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if payload.NewName == "" {
+		return "", fmt.Errorf("new_name is required")
+	}
+	client, abspath, pos, err := e.lspClient(ctx, payload.lspTarget)
+	if err != nil {
+		return "", err
+	}
+
+	edit, err := client.Rename(ctx, abspath, pos, payload.NewName)
+	if err != nil {
+		return "", fmt.Errorf("rename: %w", err)
+	}
+	fileEdits, err := edit.FileEdits()
+	if err != nil {
+		return "", err
+	}
+	if len(fileEdits) == 0 {
+		return "Nothing to rename.", nil
+	}
+
+	// Stage every file before writing any of them
+	var order []string
+	for path := range fileEdits {
+		order = append(order, path)
+	}
+	sort.Strings(order)
+	staged := make(map[string]*stagedFile, len(order))
+	for _, path := range order {
+		if err := e.checkWritable(path); err != nil {
+			return "", fmt.Errorf("%s: %w", e.displayPath(path), err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read %s: %w", e.displayPath(path), err)
+		}
+		content, err := lsp.ApplyEdits(string(data), fileEdits[path])
+		if err != nil {
+			return "", fmt.Errorf("%s: %w. No files were changed", e.displayPath(path), err)
+		}
+		staged[path] = &stagedFile{path: path, original: data, existed: true, content: content}
+	}
+
+	symbol := payload.Symbol
+	if symbol == "" {
+		symbol = fmt.Sprintf("symbol at %s:%d", payload.Path, payload.Line)
+	}
+	// Every file the server wants to edit needs consent, not just the one named
+	targets := make([]string, len(order))
+	for i, path := range order {
+		targets[i] = e.displayPath(path)
+	}
+	desc := fmt.Sprintf("Rename %s to %s in %d file(s): %s", symbol, payload.NewName, len(order), strings.Join(targets, ", "))
+	if err := e.checkPathZones("rename_symbol", order...); err != nil {
+		return "", fmt.Errorf("safeguard violation: %w", err)
+	}
+	if err := e.ensurePathsConsent(ctx, "rename_symbol", targets, desc, ""); err != nil {
+		return "", err
+	}
+	if err := e.checkpoint(fmt.Sprintf("Checkpoint before renaming %s to %s", symbol, payload.NewName), order...); err != nil {
+		return "", err
+	}
+
+	var written []*stagedFile
+	for _, path := range order {
+		f := staged[path]
+		if err := e.host.WriteFile(path, []byte(f.content)); err != nil {
+			if rbErr := e.rollback(written); rbErr != nil {
+				return "", fmt.Errorf("write %s failed: %w; rollback incomplete: %v", e.displayPath(path), err, rbErr)
+			}
+			return "", fmt.Errorf("write %s failed: %w. All files were rolled back", e.displayPath(path), err)
+		}
+		written = append(written, f)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Renamed %s to %s:\n", symbol, payload.NewName))
+	for _, path := range order {
+		// Keep the server's view in step with the files just written
+		_, _ = client.Sync(path)
+		sb.WriteString(fmt.Sprintf("- %s (%d edit(s))\n", e.displayPath(path), len(fileEdits[path])))
+	}
+	return sb.String(), nil
+}
+
+// displayPath shows path relative to the workspace when it is inside it
+func (e *NativeExecutor) displayPath(path string) string {
+	if rel, err := filepath.Rel(e.host.GetCWD(), path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type userApprovalKey struct{}
+
+// WithUserApproval marks the tool calls run with ctx as already approved by
+// the user, so checks that would ask for the same call don't ask again
+func WithUserApproval(ctx context.Context) context.Context {
+	return context.WithValue(ctx, userApprovalKey{}, true)
+}
+
+func userApproved(ctx context.Context) bool {
+	approved, _ := ctx.Value(userApprovalKey{}).(bool)
+	return approved
+}
+
+// checkMcpPolicy enforces the safeguard's MCP policies before a tool call.
+// Calls the policy does not pre-approve are put to the user, unless the user
+// already approved the call before it was dispatched.
+func (e *NativeExecutor) checkMcpPolicy(ctx context.Context, name string, args map[string]interface{}) error {
+	if e.safeguard == nil {
+		return nil
+	}
+	server, tool, ok := e.mcpHub.ResolveTool(name)
+	if !ok {
+		return nil // CallTool reports the unknown tool
+	}
+
+	allowed, err := e.safeguard.CheckMcpTool(server, tool, args)
+	if err != nil {
+		return fmt.Errorf("safeguard: %w", err)
+	}
+	if allowed || userApproved(ctx) {
+		return nil
+	}
+
+	preview, _ := json.MarshalIndent(args, "", "  ")
+	if len(preview) > 1000 {
+		preview = append(preview[:1000], "..."...)
+	}
+	return e.askConsent(ctx, name, server, fmt.Sprintf("Call MCP tool `%s` on server `%s` with:\n%s", tool, server, preview))
+}
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxResourceChars caps the text returned by read_mcp_resource
+const maxResourceChars = 100_000
+
+func (e *NativeExecutor) ReadMcpResource(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		Server string `json:"server"`
+		URI    string `json:"uri"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if payload.URI == "" {
+		return "", fmt.Errorf("uri is required")
+	}
+	if e.mcpHub == nil {
+		return "", fmt.Errorf("no MCP servers are configured")
+	}
+
+	result, err := e.mcpHub.ReadResource(ctx, payload.Server, payload.URI)
+	if err != nil {
+		return "", fmt.Errorf("failed to read MCP resource: %w", err)
+	}
+	return FormatResourceContents(result.Contents), nil
+}
+
+// FormatResourceContents renders resource contents as text; binary parts are
+// described rather than inlined
+func FormatResourceContents(contents []mcp.ResourceContents) string {
+	var sb strings.Builder
+	for _, c := range contents {
+		switch v := c.(type) {
+		case mcp.TextResourceContents:
+			if len(contents) > 1 {
+				sb.WriteString(fmt.Sprintf("--- %s ---\n", v.URI))
+			}
+			sb.WriteString(v.Text)
+			sb.WriteString("\n")
+		case mcp.BlobResourceContents:
+			sb.WriteString(fmt.Sprintf("[Binary resource %s (%s), %d bytes base64]\n", v.URI, v.MIMEType, len(v.Blob)))
+		}
+	}
+	text := sb.String()
+	if len(text) > maxResourceChars {
+		text = text[:maxResourceChars] + "\n... [truncated]"
+	}
+	if text == "" {
+		return "(empty resource)"
+	}
+	return text
+}
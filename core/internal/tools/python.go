@@ -1,55 +1,427 @@
 package tools
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"sync"
 	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
 )
 
-// ExecutePython runs a python script in a temporary isolated file and returns its stdout/stderr
-func ExecutePython(ctx context.Context, script string) (string, error) {
-	// 1. Create a temp directory if not exists
-	tempDir := filepath.Join(os.TempDir(), "ricochet_exec")
-	if err := os.MkdirAll(tempDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create temp dir: %w", err)
-	}
+//go:embed python_kernel.py
+var pythonKernelSource string
+
+const (
+	defaultPythonCPUSeconds = 60
+	defaultPythonMemoryMB   = 4096
+	defaultPythonTimeout    = 120 * time.Second
+	maxPythonTimeout        = 10 * time.Minute
 
-	// 2. Create a unique temp file
-	filename := fmt.Sprintf("script_%d.py", time.Now().UnixNano())
-	filePath := filepath.Join(tempDir, filename)
+	// pythonKernelIdle is how long an unused kernel is kept before it is stopped
+	pythonKernelIdle = 30 * time.Minute
+	// maxPythonBuffer caps output held while a cell runs; older output is dropped
+	maxPythonBuffer = 1 << 20
+	// maxPythonOutput caps the output returned to the model
+	maxPythonOutput = 32 * 1024
+)
+
+// pythonLimits are the resource limits applied to a kernel
+type pythonLimits struct {
+	cpuSeconds   int
+	memoryMB     int
+	timeout      time.Duration
+	allowNetwork bool
+}
 
-	if err := ioutil.WriteFile(filePath, []byte(script), 0644); err != nil {
-		return "", fmt.Errorf("failed to write script file: %w", err)
+// pythonLimitsFrom applies settings over the defaults
+func pythonLimitsFrom(s *config.ToolsSettings) pythonLimits {
+	l := pythonLimits{
+		cpuSeconds: defaultPythonCPUSeconds,
+		memoryMB:   defaultPythonMemoryMB,
+		timeout:    defaultPythonTimeout,
 	}
-	// Cleanup on exit
-	defer os.Remove(filePath)
+	if s == nil {
+		return l
+	}
+	if s.Python.CPUSeconds > 0 {
+		l.cpuSeconds = s.Python.CPUSeconds
+	}
+	if s.Python.MemoryMB > 0 {
+		l.memoryMB = s.Python.MemoryMB
+	}
+	if s.Python.TimeoutSeconds > 0 {
+		l.timeout = time.Duration(s.Python.TimeoutSeconds) * time.Second
+	}
+	l.allowNetwork = s.Python.AllowNetwork
+	return l
+}
 
-	// 3. Prepare command
-	// We use "python3" as the default command. Ensure python3 is in PATH.
-	cmd := exec.CommandContext(ctx, "python3", filePath)
+// pythonKernel is a long-lived python3 process that keeps variables and
+// imports between execute_python calls
+type pythonKernel struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	marker   []byte
+	memoryMB int
 
-	// 4. Run and capture output
-	// We combine stdout and stderr to give the full picture
-	output, err := cmd.CombinedOutput()
+	run      sync.Mutex // One cell at a time
+	lastUsed time.Time  // Guarded by pythonKernels.mu
+	users    int        // Calls holding the kernel; guarded by pythonKernels.mu
 
-	result := string(output)
+	outMu   sync.Mutex
+	out     bytes.Buffer
+	dropped int
+	notify  chan struct{}
+	done    chan struct{}
+}
 
+// kernelResult is the JSON the kernel writes after the marker
+type kernelResult struct {
+	Value *string `json:"value"`
+	Error *string `json:"error"`
+}
+
+func startPythonKernel(dir string, memoryMB int) (*pythonKernel, error) {
+	python, err := exec.LookPath("python3")
 	if err != nil {
-		// If the process failed, we still want the output (syntax errors, exceptions)
-		// But we also append the error message
-		if result == "" {
-			return "", fmt.Errorf("python execution failed: %v", err)
+		if python, err = exec.LookPath("python"); err != nil {
+			return nil, fmt.Errorf("python3 not found in PATH")
+		}
+	}
+
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	marker := "\x1eRICOCHET_KERNEL_DONE_" + hex.EncodeToString(id)
+
+	k := &pythonKernel{
+		marker:   []byte("\n" + marker),
+		memoryMB: memoryMB,
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		lastUsed: time.Now(),
+	}
+	k.cmd = exec.Command(python, "-u", "-c", pythonKernelSource)
+	k.cmd.Dir = dir
+	k.cmd.Env = append(os.Environ(),
+		"RICOCHET_KERNEL_MARKER="+marker,
+		fmt.Sprintf("RICOCHET_KERNEL_MEMORY_MB=%d", memoryMB),
+		"PYTHONIOENCODING=utf-8",
+	)
+	if k.stdin, err = k.cmd.StdinPipe(); err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	k.cmd.Stdout = pw
+	k.cmd.Stderr = pw
+	if err := k.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start python kernel: %w", err)
+	}
+
+	go k.readOutput(pr)
+	go func() {
+		_ = k.cmd.Wait()
+		pw.Close()
+	}()
+	return k, nil
+}
+
+func (k *pythonKernel) readOutput(r io.Reader) {
+	defer close(k.done)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			k.outMu.Lock()
+			k.out.Write(buf[:n])
+			if over := k.out.Len() - maxPythonBuffer; over > 0 {
+				k.out.Next(over)
+				k.dropped += over
+			}
+			k.outMu.Unlock()
+			select {
+			case k.notify <- struct{}{}:
+			default:
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// alive reports whether the kernel process is still running
+func (k *pythonKernel) alive() bool {
+	select {
+	case <-k.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// execute runs code in the kernel and returns its output. If the cell
+// outlives timeout it is interrupted; a kernel that ignores the interrupt is
+// killed and reported as lost.
+func (k *pythonKernel) execute(ctx context.Context, code string, limits pythonLimits, network bool) (string, error) {
+	k.run.Lock()
+	defer k.run.Unlock()
+
+	k.outMu.Lock()
+	k.out.Reset()
+	k.dropped = 0
+	k.outMu.Unlock()
+
+	req, _ := json.Marshal(map[string]interface{}{
+		"code":        code,
+		"cpu_seconds": limits.cpuSeconds,
+		"network":     network,
+	})
+	if _, err := k.stdin.Write(append(req, '\n')); err != nil {
+		return "", fmt.Errorf("python kernel is not running: %w", err)
+	}
+
+	timer := time.NewTimer(limits.timeout)
+	defer timer.Stop()
+	var interrupted bool
+	var killAt <-chan time.Time
+	for {
+		if out, res, ok := k.takeResult(); ok {
+			return formatKernelOutput(out, res), nil
+		}
+		select {
+		case <-k.notify:
+		case <-k.done:
+			if out, res, ok := k.takeResult(); ok {
+				return formatKernelOutput(out, res), nil
+			}
+			out := k.output()
+			if interrupted {
+				return "", fmt.Errorf("execution timed out after %s and the kernel was stopped; variables were lost\n%s", limits.timeout, out)
+			}
+			return "", fmt.Errorf("python kernel exited (memory limit %d MB or a crash); variables were lost\n%s", k.memoryMB, out)
+		case <-ctx.Done():
+			k.kill()
+			return "", fmt.Errorf("execution cancelled; the kernel was stopped: %w", ctx.Err())
+		case <-timer.C:
+			interrupted = true
+			if err := k.cmd.Process.Signal(os.Interrupt); err != nil {
+				k.kill()
+			}
+			killAt = time.After(5 * time.Second)
+		case <-killAt:
+			k.kill()
 		}
-		return fmt.Sprintf("%s\n\n(Execution failed with code: %v)", result, err), nil
 	}
+}
+
+// takeResult returns the cell output and result once the marker has arrived
+func (k *pythonKernel) takeResult() (string, kernelResult, bool) {
+	k.outMu.Lock()
+	defer k.outMu.Unlock()
+	data := k.out.Bytes()
+	i := bytes.Index(data, k.marker)
+	if i < 0 {
+		return "", kernelResult{}, false
+	}
+	rest := data[i+len(k.marker):]
+	end := bytes.IndexByte(rest, '\n')
+	if end < 0 {
+		return "", kernelResult{}, false
+	}
+	var res kernelResult
+	if err := json.Unmarshal(rest[:end], &res); err != nil {
+		msg := "unreadable kernel result"
+		res.Error = &msg
+	}
+	out := string(data[:i])
+	if k.dropped > 0 {
+		out = fmt.Sprintf("... (%d bytes of earlier output dropped)\n%s", k.dropped, out)
+	}
+	k.out.Reset()
+	return out, res, true
+}
+
+func (k *pythonKernel) output() string {
+	k.outMu.Lock()
+	defer k.outMu.Unlock()
+	return k.out.String()
+}
+
+func (k *pythonKernel) kill() {
+	if k.cmd.Process != nil {
+		_ = k.cmd.Process.Kill()
+	}
+}
 
+// close stops the kernel and waits for it to exit
+func (k *pythonKernel) close() {
+	_ = k.stdin.Close()
+	select {
+	case <-k.done:
+	case <-time.After(2 * time.Second):
+		k.kill()
+		<-k.done
+	}
+}
+
+func formatKernelOutput(out string, res kernelResult) string {
+	var sb bytes.Buffer
+	sb.WriteString(out)
+	if res.Value != nil {
+		if sb.Len() > 0 && !bytes.HasSuffix(sb.Bytes(), []byte("\n")) {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(*res.Value)
+	}
+	if res.Error != nil {
+		if sb.Len() > 0 && !bytes.HasSuffix(sb.Bytes(), []byte("\n")) {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(*res.Error)
+	}
+	result := sb.String()
+	if len(result) > maxPythonOutput {
+		result = fmt.Sprintf("... (output truncated to the last %d bytes)\n%s", maxPythonOutput, result[len(result)-maxPythonOutput:])
+	}
 	if result == "" {
-		return "(No output)", nil
+		return "(No output)"
 	}
+	return result
+}
+
+// pythonKernels holds one kernel per chat session
+type pythonKernels struct {
+	dir string
 
-	return result, nil
+	mu      sync.Mutex
+	kernels map[string]*pythonKernel
+}
+
+func newPythonKernels(dir string) *pythonKernels {
+	return &pythonKernels{dir: dir, kernels: make(map[string]*pythonKernel)}
+}
+
+// get returns the session's kernel, starting one if needed, and holds it
+// until release so it is not stopped as idle in the meantime. Kernels idle
+// for longer than pythonKernelIdle are stopped along the way.
+func (p *pythonKernels) get(session string, limits pythonLimits) (*pythonKernel, bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id, k := range p.kernels {
+		if id != session && k.users == 0 && time.Since(k.lastUsed) > pythonKernelIdle {
+			go k.close()
+			delete(p.kernels, id)
+		}
+	}
+
+	k, ok := p.kernels[session]
+	started := false
+	if !ok || !k.alive() {
+		var err error
+		if k, err = startPythonKernel(p.dir, limits.memoryMB); err != nil {
+			return nil, false, err
+		}
+		p.kernels[session] = k
+		started = true
+	}
+	k.users++
+	k.lastUsed = time.Now()
+	return k, started, nil
+}
+
+// release gives back a kernel taken with get
+func (p *pythonKernels) release(k *pythonKernel) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	k.users--
+	k.lastUsed = time.Now()
+}
+
+// reset stops the session's kernel; the next call starts a fresh one
+func (p *pythonKernels) reset(session string) bool {
+	p.mu.Lock()
+	k, ok := p.kernels[session]
+	delete(p.kernels, session)
+	p.mu.Unlock()
+	if ok {
+		k.kill()
+		<-k.done
+	}
+	return ok
+}
+
+// ExecutePython runs a script in a throwaway kernel with the default limits
+// and returns its combined stdout/stderr
+func ExecutePython(ctx context.Context, script string) (string, error) {
+	dir, _ := os.Getwd()
+	limits := pythonLimitsFrom(nil)
+	k, err := startPythonKernel(dir, limits.memoryMB)
+	if err != nil {
+		return "", err
+	}
+	defer k.close()
+	return k.execute(ctx, script, limits, false)
+}
+
+func (e *NativeExecutor) ExecutePythonTool(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		Script       string `json:"script"`
+		Timeout      int    `json:"timeout"` // Seconds
+		AllowNetwork bool   `json:"allow_network"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	var settings *config.ToolsSettings
+	if e.safeguard != nil {
+		settings = e.safeguard.ToolsSettings
+	}
+	limits := pythonLimitsFrom(settings)
+	if payload.Timeout > 0 {
+		limits.timeout = min(time.Duration(payload.Timeout)*time.Second, maxPythonTimeout)
+	}
+
+	network := limits.allowNetwork
+	if payload.AllowNetwork && !network {
+		if err := e.ensureConsent(ctx, "execute_python", "network", "Allow this Python cell to access the network"); err != nil {
+			return "", err
+		}
+		network = true
+	}
+
+	session, _ := ctx.Value("session_id").(string)
+	kernel, started, err := e.python.get(session, limits)
+	if err != nil {
+		return "", err
+	}
+	out, err := kernel.execute(ctx, payload.Script, limits, network)
+	e.python.release(kernel)
+	if err != nil {
+		e.python.reset(session)
+		return "", err
+	}
+	if started {
+		out = "(Started a new Python kernel)\n" + out
+	}
+	return out, nil
+}
+
+// ResetPython discards the session's kernel state
+func (e *NativeExecutor) ResetPython(ctx context.Context) (string, error) {
+	session, _ := ctx.Value("session_id").(string)
+	if !e.python.reset(session) {
+		return "No Python kernel was running; the next execute_python call starts fresh.", nil
+	}
+	return "Python kernel reset. All variables and imports were cleared.", nil
 }
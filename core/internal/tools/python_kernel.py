@@ -0,0 +1,151 @@
+# Persistent Python kernel for execute_python.
+#
+# Requests arrive as JSON lines on the original stdin:
+#   {"code": "...", "cpu_seconds": 60, "network": false}
+# Output of the code goes to stdout/stderr as usual. When a request finishes,
+# a line with MARKER followed by a JSON result is written to stdout.
+
+import ast
+import json
+import linecache
+import os
+import signal
+import socket
+import sys
+import traceback
+
+MARKER = os.environ.get("RICOCHET_KERNEL_MARKER", "\x1eRICOCHET_KERNEL_DONE")
+
+try:
+    import resource
+except ImportError:  # Windows
+    resource = None
+
+# Keep the protocol stream away from user code: input() reads /dev/null
+proto_in = os.fdopen(os.dup(0), "r")
+devnull = os.open(os.devnull, os.O_RDONLY)
+os.dup2(devnull, 0)
+sys.stdin = open(os.devnull)
+
+memory_mb = int(os.environ.get("RICOCHET_KERNEL_MEMORY_MB", "0"))
+if resource and memory_mb > 0:
+    limit = memory_mb * 1024 * 1024
+    try:
+        resource.setrlimit(resource.RLIMIT_AS, (limit, limit))
+    except (ValueError, OSError):
+        pass
+
+
+class CPULimitExceeded(Exception):
+    pass
+
+
+def on_cpu_limit(signum, frame):
+    raise CPULimitExceeded("CPU time limit exceeded")
+
+
+if hasattr(signal, "SIGXCPU"):
+    signal.signal(signal.SIGXCPU, on_cpu_limit)
+
+
+def cpu_used():
+    if not resource:
+        return 0
+    usage = resource.getrusage(resource.RUSAGE_SELF)
+    return usage.ru_utime + usage.ru_stime
+
+
+def set_cpu_budget(seconds):
+    # RLIMIT_CPU counts the whole process lifetime, so each call gets the
+    # time used so far plus its own budget
+    if not resource or seconds <= 0:
+        return
+    soft = int(cpu_used()) + seconds
+    _, hard = resource.getrlimit(resource.RLIMIT_CPU)
+    if hard != resource.RLIM_INFINITY:
+        soft = min(soft, hard)
+    try:
+        resource.setrlimit(resource.RLIMIT_CPU, (soft, hard))
+    except (ValueError, OSError):
+        pass
+
+
+# Advisory network guard: blocks sockets opened from Python code. It is not a
+# sandbox: subprocesses, native extensions and code that restores the socket
+# functions get around it, and the tool description says so.
+network = {"allowed": False}
+_connect = socket.socket.connect
+_connect_ex = socket.socket.connect_ex
+_getaddrinfo = socket.getaddrinfo
+
+
+def _blocked(*args, **kwargs):
+    raise PermissionError("network access is disabled in the Python sandbox; "
+                          "call execute_python with allow_network to request it")
+
+
+def _guard(fn):
+    def wrapper(*args, **kwargs):
+        if not network["allowed"]:
+            _blocked()
+        return fn(*args, **kwargs)
+    return wrapper
+
+
+socket.socket.connect = _guard(_connect)
+socket.socket.connect_ex = _guard(_connect_ex)
+socket.getaddrinfo = _guard(_getaddrinfo)
+
+namespace = {"__name__": "__main__", "__builtins__": __builtins__}
+
+
+def run(code):
+    # Let tracebacks show the cell's source lines
+    linecache.cache["<cell>"] = (len(code), None, code.splitlines(True), "<cell>")
+    tree = ast.parse(code, "<cell>", "exec")
+    last = None
+    if tree.body and isinstance(tree.body[-1], ast.Expr):
+        last = ast.Expression(tree.body.pop().value)
+    exec(compile(tree, "<cell>", "exec"), namespace)
+    if last is not None:
+        value = eval(compile(last, "<cell>", "eval"), namespace)
+        if value is not None:
+            namespace["_"] = value
+            return repr(value)
+    return None
+
+
+def format_error():
+    etype, value, tb = sys.exc_info()
+    # Drop the kernel's own frames so the traceback starts in the cell
+    frames = traceback.extract_tb(tb)
+    frames = [f for f in frames if f.filename == "<cell>"] or frames[-1:]
+    lines = traceback.format_list(frames)
+    lines += traceback.format_exception_only(etype, value)
+    return "Traceback (most recent call last):\n" + "".join(lines)
+
+
+for line in proto_in:
+    try:
+        req = json.loads(line)
+    except ValueError:
+        continue
+    network["allowed"] = bool(req.get("network"))
+    set_cpu_budget(int(req.get("cpu_seconds", 0)))
+
+    result = {"value": None, "error": None}
+    try:
+        result["value"] = run(req.get("code", ""))
+    except KeyboardInterrupt:
+        result["error"] = "KeyboardInterrupt: execution interrupted (timeout)"
+    except SystemExit as e:
+        result["error"] = "SystemExit: %s (the kernel keeps running; use reset_python to restart)" % (e.code,)
+    except MemoryError:
+        result["error"] = "MemoryError: memory limit of %d MB exceeded" % memory_mb
+    except BaseException:
+        result["error"] = format_error()
+
+    sys.stdout.flush()
+    sys.stderr.flush()
+    sys.stdout.write("\n" + MARKER + json.dumps(result) + "\n")
+    sys.stdout.flush()
@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPythonKernelPersistsState(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not installed")
+	}
+	kernels := newPythonKernels(t.TempDir())
+	limits := pythonLimitsFrom(nil)
+	limits.timeout = 2 * time.Second
+	ctx := context.Background()
+
+	run := func(code string) string {
+		t.Helper()
+		k, _, err := kernels.get("s1", limits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := k.execute(ctx, code, limits, false)
+		kernels.release(k)
+		if err != nil {
+			t.Fatalf("%q: %v", code, err)
+		}
+		return out
+	}
+
+	run("import math\nx = 21")
+	if out := run("print('twice:', x * 2)\nmath.sqrt(x * x)"); out != "twice: 42\n21.0" {
+		t.Errorf("output = %q", out)
+	}
+	if out := run("1 / 0"); !strings.Contains(out, "ZeroDivisionError") || !strings.Contains(out, "1 / 0") {
+		t.Errorf("error output = %q", out)
+	}
+	if out := run("import socket\nsocket.create_connection(('example.com', 80))"); !strings.Contains(out, "network access is disabled") {
+		t.Errorf("network output = %q", out)
+	}
+
+	// A timed-out cell is interrupted without losing the kernel
+	if out := run("while True: pass"); !strings.Contains(out, "KeyboardInterrupt") {
+		t.Errorf("timeout output = %q", out)
+	}
+	if out := run("x"); out != "21" {
+		t.Errorf("state after interrupt = %q", out)
+	}
+
+	if !kernels.reset("s1") {
+		t.Fatal("reset found no kernel")
+	}
+	if out := run("'x' in globals()"); out != "False" {
+		t.Errorf("state after reset = %q", out)
+	}
+}
+
+func TestPythonKernelHeldIsNotStopped(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not installed")
+	}
+	kernels := newPythonKernels(t.TempDir())
+	limits := pythonLimitsFrom(nil)
+	defer kernels.reset("s1")
+	defer kernels.reset("s2")
+
+	k1, _, err := kernels.get("s1", limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idle := func() {
+		kernels.mu.Lock()
+		k1.lastUsed = time.Now().Add(-2 * pythonKernelIdle)
+		kernels.mu.Unlock()
+	}
+
+	// Another session looking for idle kernels must leave a held one alone
+	idle()
+	k2, _, err := kernels.get("s2", limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kernels.release(k2)
+	if out, err := k1.execute(context.Background(), "1 + 1", limits, false); err != nil || out != "2" {
+		t.Fatalf("held kernel was stopped: %q, %v", out, err)
+	}
+
+	kernels.release(k1)
+	idle()
+	k2, _, _ = kernels.get("s2", limits)
+	kernels.release(k2)
+	kernels.mu.Lock()
+	_, kept := kernels.kernels["s1"]
+	kernels.mu.Unlock()
+	if kept {
+		t.Error("idle kernel was kept after release")
+	}
+}
@@ -0,0 +1,446 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Elements never part of the readable content
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Canvas: true, atom.Iframe: true, atom.Object: true,
+	atom.Form: true, atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true,
+	atom.Nav: true, atom.Aside: true, atom.Dialog: true,
+}
+
+var droppedRoles = map[string]bool{
+	"navigation": true, "banner": true, "contentinfo": true, "complementary": true,
+	"search": true, "dialog": true, "menu": true, "menubar": true,
+}
+
+var (
+	boilerplateClass = regexp.MustCompile(`(?i)\b(nav|navbar|navigation|menu|footer|sidebar|breadcrumbs?|cookie|consent|banner|advert|ads|promo|share|social|comments?|related|popup|modal|newsletter|subscribe|skip-link)\b`)
+	contentClass     = regexp.MustCompile(`(?i)\b(content|article|main|post|entry|markdown|prose|documentation|docs)\b`)
+	languageClass    = regexp.MustCompile(`(?:^|\s)(?:language|lang|highlight-source)-([\w+#-]+)`)
+	blankLines       = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToMarkdown extracts the readable part of an HTML page as markdown, in
+// the spirit of readability: scripts, navigation, headers, footers, sidebars
+// and other boilerplate are dropped, and the element that holds most of the
+// prose is kept. Links are resolved against base.
+func htmlToMarkdown(data []byte, base *url.URL) (title, markdown string, err error) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	if t := findElement(doc, atom.Title); t != nil {
+		title = collapseSpace(textContent(t))
+	}
+	if b := findElement(doc, atom.Base); b != nil {
+		if href, err := url.Parse(attr(b, "href")); err == nil && base != nil {
+			base = base.ResolveReference(href)
+		}
+	}
+
+	pruneBoilerplate(doc, false)
+	root := contentRoot(doc)
+
+	w := &mdWriter{base: base}
+	w.render(root)
+	return title, w.result(), nil
+}
+
+// pruneBoilerplate removes what is never content. Headers and footers are
+// kept inside an article, where they hold its title and byline.
+func pruneBoilerplate(n *html.Node, inArticle bool) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode {
+			n.RemoveChild(c)
+		} else if c.Type == html.ElementNode {
+			if isBoilerplate(c, inArticle) {
+				n.RemoveChild(c)
+			} else {
+				pruneBoilerplate(c, inArticle || c.DataAtom == atom.Article || c.DataAtom == atom.Main)
+			}
+		}
+		c = next
+	}
+}
+
+func isBoilerplate(n *html.Node, inArticle bool) bool {
+	if droppedElements[n.DataAtom] {
+		return true
+	}
+	if (n.DataAtom == atom.Header || n.DataAtom == atom.Footer) && !inArticle {
+		return true
+	}
+	if droppedRoles[strings.ToLower(attr(n, "role"))] || attr(n, "aria-hidden") == "true" || hasAttr(n, "hidden") {
+		return true
+	}
+	if strings.Contains(strings.ReplaceAll(strings.ToLower(attr(n, "style")), " ", ""), "display:none") {
+		return true
+	}
+	if n.DataAtom == atom.Body || n.DataAtom == atom.Main || n.DataAtom == atom.Article {
+		return false
+	}
+	names := attr(n, "class") + " " + attr(n, "id")
+	return boilerplateClass.MatchString(names) && !contentClass.MatchString(names)
+}
+
+// contentRoot picks the element holding the content: the largest <main> or
+// <article>, otherwise the element whose paragraphs score highest
+func contentRoot(doc *html.Node) *html.Node {
+	var best *html.Node
+	bestLen := 0
+	walkElements(doc, func(n *html.Node) {
+		if n.DataAtom == atom.Main || n.DataAtom == atom.Article || attr(n, "role") == "main" {
+			if l := len(collapseSpace(textContent(n))); l > bestLen {
+				best, bestLen = n, l
+			}
+		}
+	})
+	if best != nil && bestLen >= 200 {
+		return best
+	}
+
+	scores := make(map[*html.Node]float64)
+	walkElements(doc, func(n *html.Node) {
+		if n.DataAtom != atom.P && n.DataAtom != atom.Pre {
+			return
+		}
+		text := collapseSpace(textContent(n))
+		if len(text) < 25 || n.Parent == nil {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + float64(min(len(text)/100, 3))
+		scores[n.Parent] += score
+		if gp := n.Parent.Parent; gp != nil {
+			scores[gp] += score / 2
+		}
+	})
+	var top *html.Node
+	topScore := 0.0
+	for n, s := range scores {
+		s *= 1 - linkDensity(n)
+		if s > topScore {
+			top, topScore = n, s
+		}
+	}
+	if top != nil {
+		return top
+	}
+	if body := findElement(doc, atom.Body); body != nil {
+		return body
+	}
+	return doc
+}
+
+// linkDensity is the share of an element's text inside links
+func linkDensity(n *html.Node) float64 {
+	total := len(collapseSpace(textContent(n)))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	walkElements(n, func(c *html.Node) {
+		if c.DataAtom == atom.A {
+			links += len(collapseSpace(textContent(c)))
+		}
+	})
+	return float64(links) / float64(total)
+}
+
+// mdWriter renders HTML nodes as markdown
+type mdWriter struct {
+	sb        strings.Builder
+	base      *url.URL
+	listDepth int
+}
+
+func (w *mdWriter) result() string {
+	lines := strings.Split(w.sb.String(), "\n")
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+		}
+		if !inFence {
+			lines[i] = strings.TrimRight(line, " \t")
+		}
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+func (w *mdWriter) lastByte() byte {
+	s := w.sb.String()
+	if s == "" {
+		return '\n'
+	}
+	return s[len(s)-1]
+}
+
+// text writes collapsed text, without leading space at the start of a line
+func (w *mdWriter) text(s string) {
+	s = whitespace.ReplaceAllString(s, " ")
+	if last := w.lastByte(); last == '\n' || last == ' ' {
+		s = strings.TrimLeft(s, " ")
+	}
+	w.sb.WriteString(s)
+}
+
+// block starts a new paragraph, or a new line of the current list item
+func (w *mdWriter) block() {
+	if w.listDepth > 0 {
+		w.newline()
+		w.sb.WriteString(strings.Repeat("  ", w.listDepth))
+		return
+	}
+	w.sb.WriteString("\n\n")
+}
+
+func (w *mdWriter) newline() {
+	if w.lastByte() != '\n' {
+		w.sb.WriteString("\n")
+	}
+}
+
+// inline renders the children of n on their own and returns the text
+func (w *mdWriter) inline(n *html.Node) string {
+	sub := &mdWriter{base: w.base}
+	sub.renderChildren(n)
+	return strings.TrimSpace(whitespace.ReplaceAllString(sub.sb.String(), " "))
+}
+
+func (w *mdWriter) renderChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.render(c)
+	}
+}
+
+func (w *mdWriter) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.DocumentNode:
+		w.renderChildren(n)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		if text := w.inline(n); text != "" {
+			w.block()
+			w.sb.WriteString(strings.Repeat("#", int(n.Data[1]-'0')) + " " + text)
+			w.block()
+		}
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Footer, atom.Figure, atom.Figcaption, atom.Dl:
+		w.block()
+		w.renderChildren(n)
+		w.block()
+	case atom.Br:
+		w.newline()
+	case atom.Hr:
+		w.block()
+		w.sb.WriteString("---")
+		w.block()
+	case atom.A:
+		text := w.inline(n)
+		href := strings.TrimSpace(attr(n, "href"))
+		if text == "" {
+			return
+		}
+		if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			w.text(text)
+			return
+		}
+		w.text("[" + text + "](" + w.resolve(href) + ")")
+	case atom.Img:
+		if alt := collapseSpace(attr(n, "alt")); alt != "" {
+			w.text("![" + alt + "](" + w.resolve(attr(n, "src")) + ")")
+		}
+	case atom.Strong, atom.B:
+		if text := w.inline(n); text != "" {
+			w.text("**" + text + "**")
+		}
+	case atom.Em, atom.I:
+		if text := w.inline(n); text != "" {
+			w.text("_" + text + "_")
+		}
+	case atom.Code, atom.Kbd, atom.Samp:
+		if text := collapseSpace(textContent(n)); text != "" {
+			w.text("`" + text + "`")
+		}
+	case atom.Pre:
+		w.block()
+		code := strings.Trim(textContent(n), "\n")
+		w.sb.WriteString("```" + codeLanguage(n) + "\n" + code + "\n```")
+		w.block()
+	case atom.Ul, atom.Ol:
+		w.list(n)
+	case atom.Blockquote:
+		sub := &mdWriter{base: w.base}
+		sub.renderChildren(n)
+		w.block()
+		for _, line := range strings.Split(sub.result(), "\n") {
+			w.sb.WriteString("> " + line + "\n")
+		}
+		w.block()
+	case atom.Table:
+		w.table(n)
+	case atom.Dt:
+		w.newline()
+		if text := w.inline(n); text != "" {
+			w.sb.WriteString("**" + text + "**\n")
+		}
+	case atom.Dd:
+		w.newline()
+		w.sb.WriteString(": ")
+		w.renderChildren(n)
+		w.newline()
+	default:
+		w.renderChildren(n)
+	}
+}
+
+func (w *mdWriter) list(n *html.Node) {
+	if w.listDepth == 0 {
+		w.block()
+	}
+	number := 1
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+		w.newline()
+		w.sb.WriteString(strings.Repeat("  ", w.listDepth))
+		if n.DataAtom == atom.Ol {
+			fmt.Fprintf(&w.sb, "%d. ", number)
+			number++
+		} else {
+			w.sb.WriteString("- ")
+		}
+		w.listDepth++
+		w.renderChildren(li)
+		w.listDepth--
+	}
+	w.newline()
+	if w.listDepth == 0 {
+		w.block()
+	}
+}
+
+func (w *mdWriter) table(n *html.Node) {
+	var rows [][]string
+	walkElements(n, func(tr *html.Node) {
+		if tr.DataAtom != atom.Tr {
+			return
+		}
+		var cells []string
+		for c := tr.FirstChild; c != nil; c = c.NextSibling {
+			if c.DataAtom == atom.Td || c.DataAtom == atom.Th {
+				cells = append(cells, strings.ReplaceAll(w.inline(c), "|", `\|`))
+			}
+		}
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+		}
+	})
+	if len(rows) == 0 {
+		return
+	}
+	w.block()
+	for i, row := range rows {
+		w.sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			w.sb.WriteString("|" + strings.Repeat(" --- |", len(row)) + "\n")
+		}
+	}
+	w.block()
+}
+
+func (w *mdWriter) resolve(href string) string {
+	u, err := url.Parse(href)
+	if err != nil || w.base == nil {
+		return href
+	}
+	return w.base.ResolveReference(u).String()
+}
+
+// codeLanguage reads the language of a code block from its classes
+func codeLanguage(pre *html.Node) string {
+	for _, n := range []*html.Node{pre, findElement(pre, atom.Code)} {
+		if n == nil {
+			continue
+		}
+		if m := languageClass.FindStringSubmatch(attr(n, "class")); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+func collapseSpace(s string) string {
+	return strings.TrimSpace(whitespace.ReplaceAllString(s, " "))
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(textContent(c))
+	}
+	return sb.String()
+}
+
+func walkElements(n *html.Node, fn func(*html.Node)) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			fn(c)
+		}
+		walkElements(c, fn)
+	}
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	walkElements(n, func(c *html.Node) {
+		if found == nil && c.DataAtom == a {
+			found = c
+		}
+	})
+	return found
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+//go:build !windows
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+var (
+	netIsolationOnce sync.Once
+	netIsolationCmd  string
+)
+
+// networkIsolator returns the unshare binary when this system lets an
+// unprivileged process create a network namespace, or "" otherwise
+func networkIsolator() string {
+	netIsolationOnce.Do(func() {
+		if runtime.GOOS != "linux" {
+			return
+		}
+		bin, err := exec.LookPath("unshare")
+		if err != nil {
+			return
+		}
+		if exec.Command(bin, "-rn", "true").Run() == nil {
+			netIsolationCmd = bin
+		}
+	})
+	return netIsolationCmd
+}
+
+// sandboxCommand wraps argv so it runs under the profile's rlimits and, when
+// network is false, in an empty network namespace. The returned note
+// describes any part of the profile that could not be enforced.
+func sandboxCommand(ctx context.Context, p safeguard.SandboxProfile, network bool, argv []string) (*exec.Cmd, string, error) {
+	shell, err := exec.LookPath("bash")
+	if err != nil {
+		return nil, "", fmt.Errorf("bash not found in PATH")
+	}
+
+	// A failing ulimit means the hard limit is already lower, which is fine
+	var limits []string
+	if p.CPUSeconds > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -t %d 2>/dev/null", p.CPUSeconds))
+	}
+	if p.MemoryMB > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -v %d 2>/dev/null", p.MemoryMB*1024))
+	}
+	if p.FileSizeMB > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -f %d 2>/dev/null", p.FileSizeMB*1024))
+	}
+	wrapper := strings.Join(append(limits, `exec "$@"`), "; ")
+
+	var note string
+	if !network {
+		if bin := networkIsolator(); bin != "" {
+			argv = append([]string{bin, "-rn"}, argv...)
+		} else {
+			note = "(Network isolation is not available on this system; the script was not cut off from the network)"
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, shell, append([]string{"-c", wrapper, "ricochet-sandbox"}, argv...)...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = 2 * time.Second // Background children may hold the pipes open
+	return cmd, note, nil
+}
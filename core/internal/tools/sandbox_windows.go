@@ -0,0 +1,17 @@
+//go:build windows
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+// sandboxCommand is not available on Windows: rlimits and network
+// namespaces have no direct equivalent there
+func sandboxCommand(ctx context.Context, p safeguard.SandboxProfile, network bool, argv []string) (*exec.Cmd, string, error) {
+	return nil, "", fmt.Errorf("sandboxed script execution is not supported on Windows; use execute_python or execute_command")
+}
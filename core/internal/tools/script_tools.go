@@ -0,0 +1,247 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+const (
+	defaultScriptTimeout = 120 * time.Second
+	maxScriptTimeout     = 10 * time.Minute
+	// maxScriptOutput caps the output returned to the model
+	maxScriptOutput = 32 * 1024
+)
+
+// scriptRuntime describes how a script tool starts its interpreter
+type scriptRuntime struct {
+	tool        string
+	label       string
+	interpreter string
+	ext         string
+	stdin       bool // Pass the script on stdin instead of as a file
+	classify    bool // Rate the script with the command classifier
+}
+
+var (
+	nodeRuntime = scriptRuntime{tool: "execute_node", label: "Node.js", interpreter: "node", ext: ".js", stdin: true}
+	bashRuntime = scriptRuntime{tool: "execute_bash_script", label: "bash", interpreter: "bash", ext: ".sh", classify: true}
+)
+
+func (e *NativeExecutor) ExecuteNode(ctx context.Context, args json.RawMessage) (string, error) {
+	return e.executeScript(ctx, nodeRuntime, args)
+}
+
+func (e *NativeExecutor) ExecuteBashScript(ctx context.Context, args json.RawMessage) (string, error) {
+	return e.executeScript(ctx, bashRuntime, args)
+}
+
+// scriptToolSchema is the input schema shared by the sandboxed script tools
+func scriptToolSchema(scriptDesc string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"script": map[string]interface{}{
+				"type":        "string",
+				"description": scriptDesc,
+			},
+			"profile": map[string]interface{}{
+				"type":        "string",
+				"description": "Sandbox profile from .ricochet/permissions.yaml. Built-in: default (workspace dir, no network), strict (throwaway temp dir, tighter limits, no network), network (workspace dir, network allowed).",
+			},
+			"timeout": map[string]interface{}{
+				"type":        "integer",
+				"description": "Wall-clock limit in seconds (default from the profile, max 600).",
+			},
+			"allow_network": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Request network access for this run when the profile blocks it.",
+			},
+		},
+		"required": []string{"script"},
+	}
+}
+
+// executeScript runs a one-off script under a sandbox profile from
+// permissions.yaml and returns its combined stdout/stderr
+func (e *NativeExecutor) executeScript(ctx context.Context, rt scriptRuntime, args json.RawMessage) (string, error) {
+	var payload struct {
+		Script       string `json:"script"`
+		Profile      string `json:"profile"`
+		Timeout      int    `json:"timeout"` // Seconds
+		AllowNetwork bool   `json:"allow_network"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(payload.Script) == "" {
+		return "", fmt.Errorf("script is required")
+	}
+
+	var permissions *safeguard.PermissionConfig
+	if e.safeguard != nil {
+		permissions = e.safeguard.Permissions
+	}
+	profile, err := permissions.SandboxProfile(payload.Profile)
+	if err != nil {
+		return "", err
+	}
+
+	timeout := defaultScriptTimeout
+	if profile.TimeoutSeconds > 0 {
+		timeout = time.Duration(profile.TimeoutSeconds) * time.Second
+	}
+	if payload.Timeout > 0 {
+		timeout = min(time.Duration(payload.Timeout)*time.Second, maxScriptTimeout)
+	}
+
+	// Scripts follow the execute_command policy, with the bash script rated
+	// like a command line, whatever the profile: a throwaway directory is not
+	// a filesystem jail, and the network namespace may be unavailable. One
+	// with network access always asks.
+	network := profile.Network || payload.AllowNetwork
+	verdict := safeguard.CommandVerdict{Risk: safeguard.CommandAsk, Reason: "is a " + rt.label + " script"}
+	if rt.classify {
+		verdict = e.safeguard.ClassifyCommand(payload.Script)
+	}
+	name := payload.Profile
+	if name == "" {
+		name = "default"
+	}
+	desc := fmt.Sprintf("Run %s script (sandbox: %s", rt.label, name)
+	if network {
+		desc += ", with network access"
+	}
+	desc += "):\n\n" + payload.Script
+	if err := e.checkCommandPolicy(ctx, rt.tool, ScriptTarget(payload.Script), desc, verdict, network); err != nil {
+		return "", err
+	}
+
+	interpreter, err := exec.LookPath(rt.interpreter)
+	if err != nil {
+		return "", fmt.Errorf("%s not found in PATH", rt.interpreter)
+	}
+
+	dir := e.host.GetCWD()
+	scriptDir := ""
+	if profile.Tmpfs {
+		if dir, err = os.MkdirTemp("", "ricochet-sandbox-*"); err != nil {
+			return "", fmt.Errorf("failed to create sandbox directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		scriptDir = dir
+	}
+
+	argv := []string{interpreter, "-"}
+	if !rt.stdin {
+		f, err := os.CreateTemp(scriptDir, "ricochet-script-*"+rt.ext)
+		if err != nil {
+			return "", fmt.Errorf("failed to write script: %w", err)
+		}
+		defer os.Remove(f.Name())
+		_, err = f.WriteString(payload.Script)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to write script: %w", err)
+		}
+		argv = []string{interpreter, f.Name()}
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd, note, err := sandboxCommand(runCtx, profile, network, argv)
+	if err != nil {
+		return "", err
+	}
+	cmd.Dir = dir
+	if rt.stdin {
+		cmd.Stdin = strings.NewReader(payload.Script)
+	}
+	out := &tailBuffer{max: maxScriptOutput}
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	err = cmd.Run()
+
+	var sb strings.Builder
+	if note != "" {
+		sb.WriteString(note + "\n")
+	}
+	sb.WriteString(out.String())
+	status := ""
+	var exitErr *exec.ExitError
+	switch {
+	case runCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil:
+		status = fmt.Sprintf("Execution timed out after %s and was stopped", timeout)
+	case ctx.Err() != nil:
+		return "", fmt.Errorf("execution cancelled: %w", ctx.Err())
+	case errors.As(err, &exitErr):
+		status = fmt.Sprintf("Exit status %d", exitErr.ExitCode())
+		if exitErr.ExitCode() < 0 {
+			// Killed by a signal, usually SIGXCPU, SIGXFSZ or SIGKILL from a limit
+			status = fmt.Sprintf("Stopped (%s); the profile allows %ds of CPU, %d MB of memory and %d MB files",
+				exitErr.ProcessState, profile.CPUSeconds, profile.MemoryMB, profile.FileSizeMB)
+		}
+	case err != nil:
+		return "", fmt.Errorf("execution failed: %w", err)
+	}
+	if status != "" {
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(status)
+	}
+	if sb.Len() == 0 {
+		return "(No output)", nil
+	}
+	return sb.String(), nil
+}
+
+// ScriptTarget identifies a script in permission rules and the approval
+// audit by its digest, so "don't ask again" only covers the same script
+func ScriptTarget(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// tailBuffer keeps the last max bytes written to it
+type tailBuffer struct {
+	max int
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	dropped int
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf.Write(p)
+	if over := t.buf.Len() - t.max; over > 0 {
+		t.buf.Next(over)
+		t.dropped += over
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.dropped > 0 {
+		return fmt.Sprintf("... (output truncated to the last %d bytes)\n%s", t.max, t.buf.String())
+	}
+	return t.buf.String()
+}
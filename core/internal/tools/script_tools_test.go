@@ -0,0 +1,83 @@
+//go:build !windows
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+	"github.com/igoryan-dao/ricochet/internal/host"
+	"github.com/igoryan-dao/ricochet/internal/modes"
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+func TestExecuteScriptProfiles(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "lib.js"), []byte("module.exports = 21"), 0644)
+	h := &consentHost{NativeHost: host.NewNativeHost(dir), answer: "yes"}
+	e := &NativeExecutor{
+		host:  h,
+		modes: modes.NewManager(dir),
+		safeguard: &safeguard.Manager{
+			Permissions: &safeguard.PermissionConfig{
+				Sandbox: map[string]safeguard.SandboxProfile{"tiny": {TimeoutSeconds: 1, FileSizeMB: 1}},
+			},
+			AutoApproval: &config.AutoApprovalSettings{Enabled: true, ExecuteSafeCommands: true},
+		},
+	}
+	ctx := context.Background()
+	call := func(fn func(context.Context, json.RawMessage) (string, error), args map[string]interface{}) string {
+		t.Helper()
+		data, _ := json.Marshal(args)
+		out, err := fn(ctx, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	if _, err := exec.LookPath("node"); err == nil {
+		if out := call(e.ExecuteNode, map[string]interface{}{"script": "console.log(require('./lib') * 2)"}); !strings.HasSuffix(out, "42\n") {
+			t.Errorf("node output = %q", out)
+		}
+	}
+
+	out := call(e.ExecuteBashScript, map[string]interface{}{"script": "pwd\necho oops >&2\nexit 3", "profile": "strict"})
+	if strings.Contains(out, dir) || !strings.Contains(out, "oops") || !strings.HasSuffix(out, "Exit status 3") {
+		t.Errorf("strict output = %q", out)
+	}
+	if out := call(e.ExecuteBashScript, map[string]interface{}{"script": "sleep 5", "profile": "tiny"}); !strings.Contains(out, "timed out after 1s") {
+		t.Errorf("timeout output = %q", out)
+	}
+	if _, err := e.ExecuteBashScript(ctx, json.RawMessage(`{"script":"true","profile":"missing"}`)); err == nil || !strings.Contains(err.Error(), "strict") {
+		t.Errorf("unknown profile error = %v", err)
+	}
+
+	// Scripts follow the command policy whatever the profile; network runs
+	// always ask
+	h.questions = nil
+	call(e.ExecuteBashScript, map[string]interface{}{"script": "true", "profile": "strict"})
+	call(e.ExecuteBashScript, map[string]interface{}{"script": "true"})
+	call(e.ExecuteBashScript, map[string]interface{}{"script": "touch y", "profile": "strict"})
+	call(e.ExecuteBashScript, map[string]interface{}{"script": "true", "profile": "strict", "allow_network": true})
+	call(e.ExecuteBashScript, map[string]interface{}{"script": "touch y"})
+	if len(h.questions) != 3 || !strings.Contains(h.questions[0], "sandbox: strict") || !strings.Contains(h.questions[1], "with network access") || !strings.Contains(h.questions[2], "touch y") {
+		t.Errorf("approvals asked = %q", h.questions)
+	}
+	if _, err := e.ExecuteBashScript(ctx, json.RawMessage(`{"script":"rm -rf /","profile":"strict"}`)); err == nil || !strings.Contains(err.Error(), "blocked") {
+		t.Errorf("denied script error = %v", err)
+	}
+	h.answer = "no"
+	if _, err := e.ExecuteBashScript(ctx, json.RawMessage(`{"script":"touch x"}`)); err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Errorf("rejected script error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "x")); err == nil {
+		t.Error("rejected script ran")
+	}
+}
@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/config"
+	"github.com/igoryan-dao/ricochet/internal/database"
+)
+
+const (
+	defaultSQLRows = 100
+	maxSQLRows     = 1000
+	sqlTimeout     = 60 * time.Second
+)
+
+// openDatabase resolves a configured connection by name
+func (e *NativeExecutor) openDatabase(ctx context.Context, name string) (string, *database.DB, error) {
+	var settings map[string]config.DatabaseSettings
+	if e.safeguard != nil && e.safeguard.ToolsSettings != nil {
+		settings = e.safeguard.ToolsSettings.Databases
+	}
+	name, s, err := database.Select(settings, name)
+	if err != nil {
+		return "", nil, err
+	}
+	db, err := e.databases.Get(ctx, name, s)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, db, nil
+}
+
+func (e *NativeExecutor) SQLQuery(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		Database string `json:"database"`
+		Query    string `json:"query"`
+		MaxRows  int    `json:"max_rows"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if !database.IsReadQuery(payload.Query) {
+		return "", fmt.Errorf("sql_query only runs a single read-only query (SELECT, WITH, EXPLAIN, SHOW, ...); use sql_execute for DDL/DML and several statements")
+	}
+	maxRows := defaultSQLRows
+	if payload.MaxRows > 0 {
+		maxRows = min(payload.MaxRows, maxSQLRows)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sqlTimeout)
+	defer cancel()
+	_, db, err := e.openDatabase(ctx, payload.Database)
+	if err != nil {
+		return "", err
+	}
+	res, err := db.Query(ctx, payload.Query, maxRows)
+	if err != nil {
+		return "", fmt.Errorf("query failed: %w", err)
+	}
+	return res.Format(), nil
+}
+
+func (e *NativeExecutor) SQLSchema(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		Database string `json:"database"`
+		Table    string `json:"table"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sqlTimeout)
+	defer cancel()
+	_, db, err := e.openDatabase(ctx, payload.Database)
+	if err != nil {
+		return "", err
+	}
+	out, err := db.Schema(ctx, strings.TrimSpace(payload.Table))
+	if err != nil {
+		return "", fmt.Errorf("schema lookup failed: %w", err)
+	}
+	return out, nil
+}
+
+func (e *NativeExecutor) SQLExecute(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		Database   string `json:"database"`
+		Statements string `json:"statements"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(payload.Statements) == "" {
+		return "", fmt.Errorf("statements are required")
+	}
+
+	name, db, err := e.openDatabase(ctx, payload.Database)
+	if err != nil {
+		return "", err
+	}
+	if err := e.ensureConsent(ctx, "sql_execute", name, fmt.Sprintf("Run on database %s:\n\n%s", name, payload.Statements)); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sqlTimeout)
+	defer cancel()
+	n, err := db.Execute(ctx, payload.Statements)
+	if err != nil {
+		return "", fmt.Errorf("execution failed (transaction rolled back): %w", err)
+	}
+	if n < 0 {
+		return fmt.Sprintf("Committed on %s.", name), nil
+	}
+	return fmt.Sprintf("Committed on %s. %d row(s) affected.", name, n), nil
+}
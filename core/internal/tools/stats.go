@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recentSamples is how many latencies per tool are kept for percentiles
+const recentSamples = 200
+
+// ToolStat aggregates the calls of one tool. Durations exclude time spent
+// waiting for the user's approval.
+type ToolStat struct {
+	Name        string    `json:"name"`
+	Calls       int       `json:"calls"`
+	Errors      int       `json:"errors"`
+	TotalMs     int64     `json:"total_ms"`
+	MaxMs       int64     `json:"max_ms"`
+	ResultBytes int64     `json:"result_bytes"`
+	Approvals   int       `json:"approvals"`   // Calls that waited for consent
+	ApprovalMs  int64     `json:"approval_ms"` // Total time waiting for consent
+	LastUsed    time.Time `json:"last_used"`
+	Recent      []int64   `json:"recent_ms,omitempty"`
+}
+
+// AvgMs is the mean duration in milliseconds
+func (s ToolStat) AvgMs() int64 {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.TotalMs / int64(s.Calls)
+}
+
+// Percentile returns the p-th percentile (0..100) of the recent durations
+func (s ToolStat) Percentile(p float64) int64 {
+	if len(s.Recent) == 0 {
+		return 0
+	}
+	sorted := append([]int64(nil), s.Recent...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	i := int(p / 100 * float64(len(sorted)-1))
+	return sorted[i]
+}
+
+// ToolStats records per-tool usage and persists it as JSON
+type ToolStats struct {
+	mu    sync.Mutex
+	path  string
+	since time.Time
+	tools map[string]*ToolStat
+}
+
+type toolStatsFile struct {
+	Since time.Time            `json:"since"`
+	Tools map[string]*ToolStat `json:"tools"`
+}
+
+// NewToolStats loads the stats stored at path, starting empty if there are none
+func NewToolStats(path string) *ToolStats {
+	s := &ToolStats{path: path, since: time.Now(), tools: make(map[string]*ToolStat)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s
+	}
+	var f toolStatsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		log.Printf("Warning: ignoring corrupt tool stats %s: %v", path, err)
+		return s
+	}
+	if f.Tools != nil {
+		s.tools = f.Tools
+	}
+	if !f.Since.IsZero() {
+		s.since = f.Since
+	}
+	return s
+}
+
+// Record adds one call. approval is the part of d spent waiting for consent.
+func (s *ToolStats) Record(name string, d, approval time.Duration, resultBytes int, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.tools[name]
+	if !ok {
+		st = &ToolStat{Name: name}
+		s.tools[name] = st
+	}
+	ms := (d - approval).Milliseconds()
+	st.Calls++
+	if failed {
+		st.Errors++
+	}
+	st.TotalMs += ms
+	st.MaxMs = max(st.MaxMs, ms)
+	st.ResultBytes += int64(resultBytes)
+	if approval > 0 {
+		st.Approvals++
+		st.ApprovalMs += approval.Milliseconds()
+	}
+	st.LastUsed = time.Now()
+	st.Recent = append(st.Recent, ms)
+	if over := len(st.Recent) - recentSamples; over > 0 {
+		st.Recent = st.Recent[over:]
+	}
+
+	if err := s.save(); err != nil {
+		log.Printf("Warning: failed to save tool stats: %v", err)
+	}
+}
+
+// Snapshot returns a copy of all stats, most used first, and when recording began
+func (s *ToolStats) Snapshot() ([]ToolStat, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]ToolStat, 0, len(s.tools))
+	for _, st := range s.tools {
+		c := *st
+		c.Recent = append([]int64(nil), st.Recent...)
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Calls != out[j].Calls {
+			return out[i].Calls > out[j].Calls
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, s.since
+}
+
+// Reset clears all stats
+func (s *ToolStats) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tools = make(map[string]*ToolStat)
+	s.since = time.Now()
+	return s.save()
+}
+
+func (s *ToolStats) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(toolStatsFile{Since: s.since, Tools: s.tools})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// FormatToolStats renders stats as a markdown table
+func FormatToolStats(stats []ToolStat, since time.Time) string {
+	if len(stats) == 0 {
+		return "No tool calls recorded yet."
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Tool usage since %s\n\n", since.Format("2006-01-02 15:04"))
+	sb.WriteString("| Tool | Calls | Errors | Avg | p95 | Max | Avg result | Approvals |\n")
+	sb.WriteString("|---|---:|---:|---:|---:|---:|---:|---:|\n")
+	for _, st := range stats {
+		approvals := "-"
+		if st.Approvals > 0 {
+			approvals = fmt.Sprintf("%d (avg wait %s)", st.Approvals, formatMs(st.ApprovalMs/int64(st.Approvals)))
+		}
+		fmt.Fprintf(&sb, "| %s | %d | %d | %s | %s | %s | %s | %s |\n",
+			st.Name, st.Calls, st.Errors, formatMs(st.AvgMs()), formatMs(st.Percentile(95)), formatMs(st.MaxMs),
+			formatBytes(st.ResultBytes/int64(max(st.Calls, 1))), approvals)
+	}
+	return sb.String()
+}
+
+func formatMs(ms int64) string {
+	if ms < 1000 {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return fmt.Sprintf("%.1fs", float64(ms)/1000)
+}
+
+type consentWaitKey struct{}
+
+// withConsentTimer lets ensureConsent report how long it waited for the user
+func withConsentTimer(ctx context.Context) (context.Context, *time.Duration) {
+	var waited time.Duration
+	return context.WithValue(ctx, consentWaitKey{}, &waited), &waited
+}
+
+// addConsentWait adds the time since start to the call's consent timer
+func addConsentWait(ctx context.Context, start time.Time) {
+	if waited, ok := ctx.Value(consentWaitKey{}).(*time.Duration); ok {
+		*waited += time.Since(start)
+	}
+}
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToolStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats", "tools.json")
+	s := NewToolStats(path)
+	for i := 1; i <= 10; i++ {
+		s.Record("read_file", time.Duration(i)*time.Millisecond, 0, 100, false)
+	}
+	s.Record("execute_command", 3*time.Second, 2*time.Second, 10, true)
+
+	// A fresh instance sees the persisted numbers
+	list, _ := NewToolStats(path).Snapshot()
+	if len(list) != 2 || list[0].Name != "read_file" {
+		t.Fatalf("unexpected stats %+v", list)
+	}
+	rf, ec := list[0], list[1]
+	if rf.Calls != 10 || rf.MaxMs != 10 || rf.Percentile(95) != 9 || rf.ResultBytes != 1000 {
+		t.Errorf("read_file stats %+v", rf)
+	}
+	if ec.Errors != 1 || ec.TotalMs != 1000 || ec.Approvals != 1 || ec.ApprovalMs != 2000 {
+		t.Errorf("approval wait not excluded: %+v", ec)
+	}
+	if out := FormatToolStats(list, time.Now()); !strings.Contains(out, "| execute_command | 1 | 1 | 1.0s |") {
+		t.Errorf("unexpected table:\n%s", out)
+	}
+
+	if err := s.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := NewToolStats(path).Snapshot(); len(list) != 0 {
+		t.Errorf("stats not reset: %+v", list)
+	}
+}
+
+func TestConsentTimer(t *testing.T) {
+	ctx, waited := withConsentTimer(context.Background())
+	addConsentWait(ctx, time.Now().Add(-time.Second))
+	if *waited < time.Second {
+		t.Errorf("waited = %s", *waited)
+	}
+	addConsentWait(context.Background(), time.Now()) // No timer: no-op
+}
@@ -85,6 +85,15 @@ var UpdatePlanTool = ToolDefinition{
 				"items":       map[string]interface{}{"type": "string"},
 				"description": "List of task IDs that must complete before this task starts.",
 			},
+			"attempts": map[string]interface{}{
+				"type":        "integer",
+				"description": "Optional best-of-N: run N competing swarm attempts, verify each with QC, and merge the best one.",
+			},
+			"selection_policy": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"auto", "manual"},
+				"description": "How the best-of winner is chosen: 'auto' (top-ranked) or 'manual' (ask the user).",
+			},
 		},
 		"required": []string{"task_id", "status"},
 	},
@@ -42,8 +42,12 @@ var toolCategoryRegistry = map[string]ToolCategory{
 	"read_file":           CategoryRead,
 	"read_definitions":    CategoryRead,
 	"codebase_search":     CategoryRead,
+	"who_calls":           CategoryRead,
+	"what_does_this_call": CategoryRead,
+	"impact_of_change":    CategoryRead,
 	"grep_search":         CategoryRead,
 	"find_by_name":        CategoryRead,
+	"web_fetch":           CategoryRead, // GET only; hosts off the allow list still ask
 	"view_file":           CategoryRead,
 	"view_file_outline":   CategoryRead,
 	"view_code_item":      CategoryRead,
@@ -53,45 +57,75 @@ var toolCategoryRegistry = map[string]ToolCategory{
 	"lsp_hover":           CategoryRead,
 	"lsp_references":      CategoryRead,
 	"lsp_completions":     CategoryRead,
+	"get_references":      CategoryRead,
+	"hover_docs":          CategoryRead,
+	"sql_query":           CategoryRead, // Runs in a read-only transaction
+	"sql_schema":          CategoryRead,
 	"command_status":      CategoryRead, // Check status of bg command (read-only)
+	"list_jobs":           CategoryRead,
+	"job_logs":            CategoryRead,
 	"get_workflows":       CategoryRead,
 	"get_context_stats":   CategoryRead,
+	"read_clipboard":      CategoryRead, // Asks for consent itself
+	"analyze_image":       CategoryRead,
 
 	// ─── WRITE TOOLS (Require Approval in Act Mode, Blocked in Plan) ───
 	"write_file":           CategoryWrite,
+	"generate_image":       CategoryWrite,
 	"write_to_file":        CategoryWrite,
 	"replace_file_content": CategoryWrite,
 	"replace_in_file":      CategoryWrite,
 	"apply_diff":           CategoryWrite,
+	"edit_files":           CategoryWrite,
 	"delete_file":          CategoryWrite,
 	"move_file":            CategoryWrite,
 	"create_directory":     CategoryWrite,
+	"rename_symbol":        CategoryWrite,
 
 	// ─── EXECUTE TOOLS (Require Approval) ───
-	"execute_command": CategoryExecute,
-	"run_command":     CategoryExecute,
-	"execute_python":  CategoryExecute,
+	"execute_command":     CategoryExecute,
+	"run_command":         CategoryExecute,
+	"execute_python":      CategoryExecute,
+	"execute_node":        CategoryExecute,
+	"execute_bash_script": CategoryExecute,
+	"http_request":        CategoryExecute, // Can change remote state
+	"sql_execute":         CategoryExecute,
+	"kill_job":            CategoryExecute,
 
 	// ─── META TOOLS (Always Silent Auto-Approve) ───
-	"task_boundary":   CategoryMeta,
-	"update_todos":    CategoryMeta,
-	"update_plan":     CategoryMeta,
-	"list_tasks":      CategoryMeta,
-	"start_task":      CategoryMeta,
-	"switch_mode":     CategoryMeta,
-	"ask_user_choice": CategoryMeta, // User interaction tool
-	"notify_user":     CategoryMeta,
-	"start_swarm":     CategoryMeta,
+	"task_boundary":             CategoryMeta,
+	"update_todos":              CategoryMeta,
+	"pin_context":               CategoryMeta,
+	"update_plan":               CategoryMeta,
+	"list_tasks":                CategoryMeta,
+	"start_task":                CategoryMeta,
+	"switch_mode":               CategoryMeta,
+	"reset_python":              CategoryMeta, // Only clears the sandboxed kernel
+	"ask_user_choice":           CategoryMeta, // User interaction tool
+	"notify_user":               CategoryMeta,
+	"start_swarm":               CategoryMeta,
+	"copy_to_clipboard":         CategoryMeta,
+	"send_desktop_notification": CategoryMeta,
+
+	// ─── MCP TOOLS (Follow the use_mcp approval flag) ───
+	"read_mcp_resource": CategoryMCP,
 
 	// ─── BROWSER TOOLS ───
-	"browser_open":       CategoryBrowser,
-	"browser_click":      CategoryBrowser,
-	"browser_type":       CategoryBrowser,
-	"browser_screenshot": CategoryBrowser,
-	"browser_navigate":   CategoryBrowser,
+	"browser_open":          CategoryBrowser,
+	"browser_click":         CategoryBrowser,
+	"browser_type":          CategoryBrowser,
+	"browser_screenshot":    CategoryBrowser,
+	"browser_navigate":      CategoryBrowser,
+	"browser_assert_visual": CategoryBrowser,
+	"browser_snapshot":      CategoryBrowser,
+	"browser_wait":          CategoryBrowser,
+	"browser_logs":          CategoryBrowser,
+	"browser_download":      CategoryBrowser,
+	"browser_close":         CategoryBrowser,
 
 	// ─── SAFEGUARD TOOLS ───
 	"restore_checkpoint": CategoryWrite, // Modifies workspace state
+	"experiment":         CategoryWrite, // Switches and merges the workspace
 }
 
 // GetToolCategory returns the category for a tool.
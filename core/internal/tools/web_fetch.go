@@ -0,0 +1,238 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
+	"github.com/igoryan-dao/ricochet/internal/paths"
+)
+
+const (
+	webFetchTimeout = 30 * time.Second
+	// webFetchCacheTTL is how long a fetched page is served from the disk cache
+	webFetchCacheTTL = time.Hour
+	// maxWebFetchRead is how much of a page is downloaded; the rest is dropped
+	maxWebFetchRead = 5 << 20
+	// defaultWebFetchChars and maxWebFetchChars cap the markdown returned per call
+	defaultWebFetchChars = 20000
+	maxWebFetchChars     = 100000
+)
+
+// webPage is a fetched page as cached on disk
+type webPage struct {
+	URL         string    `json:"url"`
+	FinalURL    string    `json:"final_url"`
+	Title       string    `json:"title,omitempty"`
+	ContentType string    `json:"content_type"`
+	Content     string    `json:"content"`
+	Truncated   bool      `json:"truncated,omitempty"` // The download hit maxWebFetchRead
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// WebFetch downloads a page and returns its readable content as markdown.
+// Pages are cached on disk for an hour; long pages are returned in chunks
+// through offset. Hosts are checked against the safeguard domain rules, as
+// for http_request.
+func (e *NativeExecutor) WebFetch(ctx context.Context, args json.RawMessage) (string, error) {
+	var payload struct {
+		URL      string `json:"url"`
+		MaxChars int    `json:"max_chars"`
+		Offset   int    `json:"offset"`
+		Refresh  bool   `json:"refresh"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	target, err := url.Parse(strings.TrimSpace(payload.URL))
+	if err != nil || target.Host == "" {
+		return "", fmt.Errorf("invalid url %q: an absolute http(s) URL is required", payload.URL)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return "", fmt.Errorf("unsupported scheme %q: only http and https are allowed", target.Scheme)
+	}
+	target.Fragment = ""
+	// Checked before the cache too: the rules may have changed since the page was fetched
+	if err := e.checkDomain(ctx, "web_fetch", http.MethodGet, target); err != nil {
+		return "", err
+	}
+
+	cachePath := webCachePath(target.String())
+	page, cached := readWebCache(cachePath)
+	if payload.Refresh || !cached {
+		if page, err = e.fetchPage(ctx, target); err != nil {
+			return "", err
+		}
+		cached = false
+		writeWebCache(cachePath, page)
+	}
+	return formatWebPage(page, cached, payload.Offset, payload.MaxChars), nil
+}
+
+// fetchPage downloads target, following redirects the domain rules allow,
+// and converts HTML to markdown
+func (e *NativeExecutor) fetchPage(ctx context.Context, target *url.URL) (*webPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, webFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Ricochet web_fetch)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/markdown,text/plain;q=0.9,*/*;q=0.5")
+
+	client := httpclient.Client(0) // Bounded by ctx
+	client.CheckRedirect = func(next *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return e.checkDomain(next.Context(), "web_fetch", http.MethodGet, next.URL)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("request timed out after %s", webFetchTimeout)
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("fetch failed: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxWebFetchRead+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	page := &webPage{
+		URL:       target.String(),
+		FinalURL:  resp.Request.URL.String(),
+		Truncated: len(data) > maxWebFetchRead,
+		FetchedAt: time.Now(),
+	}
+	if page.Truncated {
+		data = data[:maxWebFetchRead]
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" {
+		mediaType = http.DetectContentType(data)
+		mediaType, _, _ = mime.ParseMediaType(mediaType)
+	}
+	page.ContentType = mediaType
+
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		title, markdown, err := htmlToMarkdown(data, resp.Request.URL)
+		if err != nil {
+			return nil, err
+		}
+		page.Title, page.Content = title, markdown
+	case isTextMedia(mediaType) && bytes.IndexByte(data[:min(len(data), 512)], 0) < 0:
+		page.Content = strings.ToValidUTF8(string(data), "\uFFFD")
+	default:
+		return nil, fmt.Errorf("unsupported content type %s: web_fetch reads HTML and text, use http_request for other content", mediaType)
+	}
+	return page, nil
+}
+
+func isTextMedia(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") ||
+		mediaType == "application/json" || mediaType == "application/xml" ||
+		mediaType == "application/javascript" || mediaType == "application/x-yaml" || mediaType == "application/yaml"
+}
+
+// formatWebPage returns maxChars of the page content from offset, with a
+// header naming the page and a footer saying how to read the rest
+func formatWebPage(page *webPage, cached bool, offset, maxChars int) string {
+	if maxChars <= 0 {
+		maxChars = defaultWebFetchChars
+	}
+	maxChars = min(maxChars, maxWebFetchChars)
+
+	var sb strings.Builder
+	if page.Title != "" {
+		fmt.Fprintf(&sb, "# %s\n", page.Title)
+	}
+	fmt.Fprintf(&sb, "URL: %s\n", page.FinalURL)
+	if cached {
+		fmt.Fprintf(&sb, "(Cached %s ago; pass refresh=true to fetch again)\n", time.Since(page.FetchedAt).Round(time.Second))
+	}
+	if page.Truncated {
+		fmt.Fprintf(&sb, "(Page larger than %d MB; only the beginning was downloaded)\n", maxWebFetchRead>>20)
+	}
+	sb.WriteString("\n")
+
+	content := page.Content
+	total := utf8.RuneCountInString(content)
+	if total == 0 {
+		sb.WriteString("(No readable content)")
+		return sb.String()
+	}
+	if offset >= total {
+		fmt.Fprintf(&sb, "(Offset %d is past the end of the content, %d characters)", offset, total)
+		return sb.String()
+	}
+	runes := []rune(content)
+	start := max(offset, 0)
+	end := min(start+maxChars, total)
+	sb.WriteString(string(runes[start:end]))
+	if end < total {
+		fmt.Fprintf(&sb, "\n\n... (content truncated: showing characters %d-%d of %d; call again with offset=%d for more)", start, end, total, end)
+	}
+	return sb.String()
+}
+
+func webCachePath(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(paths.GetWebCacheDir(), hex.EncodeToString(sum[:16])+".json")
+}
+
+// readWebCache returns the cached page if it is fresh enough
+func readWebCache(path string) (*webPage, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var page webPage
+	if err := json.Unmarshal(data, &page); err != nil || time.Since(page.FetchedAt) > webFetchCacheTTL {
+		return nil, false
+	}
+	return &page, true
+}
+
+// writeWebCache stores page; the cache is best effort, so failures are ignored
+func writeWebCache(path string, page *webPage) {
+	data, err := json.Marshal(page)
+	if err != nil {
+		return
+	}
+	if err := paths.EnsureDir(filepath.Dir(path)); err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+	}
+}
@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+const docsPage = `<!DOCTYPE html>
+<html><head><title>Widgets guide</title><script>var tracking = 1;</script></head>
+<body>
+<header><a href="/">Home</a> <a href="/pricing">Pricing</a></header>
+<nav class="sidebar"><ul><li><a href="/a">Chapter A</a></li><li><a href="/b">Chapter B</a></li></ul></nav>
+<div id="cookie-banner">We use cookies, accept them all.</div>
+<main>
+  <article>
+    <h1>Configuring widgets</h1>
+    <p>Widgets are configured with a <code>widget.yaml</code> file, which lives in the project root, next to the <a href="guide/install">install guide</a>.</p>
+    <h2>Options</h2>
+    <ul><li>Set <strong>size</strong> first<ul><li>small</li><li>large</li></ul></li><li>Then <em>color</em></li></ul>
+    <pre><code class="language-yaml">size: large
+color: red</code></pre>
+    <table><tr><th>Key</th><th>Default</th></tr><tr><td>size</td><td>small</td></tr></table>
+    <div class="share-buttons">Share on social media</div>
+  </article>
+</main>
+<footer>Copyright 2026 Widgets Inc.</footer>
+</body></html>`
+
+func TestHTMLToMarkdown(t *testing.T) {
+	base, _ := url.Parse("https://docs.example.com/widgets/config")
+	title, md, err := htmlToMarkdown([]byte(docsPage), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "Widgets guide" {
+		t.Errorf("title = %q", title)
+	}
+	for _, want := range []string{
+		"# Configuring widgets",
+		"configured with a `widget.yaml` file, which lives in the project root, next to the [install guide](https://docs.example.com/widgets/guide/install).",
+		"## Options",
+		"- Set **size** first\n  - small\n  - large\n- Then _color_",
+		"```yaml\nsize: large\ncolor: red\n```",
+		"| Key | Default |\n| --- | --- |\n| size | small |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	for _, boilerplate := range []string{"tracking", "Pricing", "Chapter A", "cookies", "Share on", "Copyright"} {
+		if strings.Contains(md, boilerplate) {
+			t.Errorf("markdown kept boilerplate %q:\n%s", boilerplate, md)
+		}
+	}
+}
+
+func TestHTMLToMarkdownWithoutMain(t *testing.T) {
+	page := `<html><body>
+<div class="menu"><a href="/x">One</a> <a href="/y">Two</a> <a href="/z">Three</a></div>
+<div class="wrapper"><div class="body-text">
+<p>The first paragraph of the story is long enough to count, with commas, clauses, and more.</p>
+<p>The second paragraph carries on the story so that this block wins the score.</p>
+</div><div class="links"><a href="/1">Link one</a> <a href="/2">Link two</a></div></div>
+</body></html>`
+	_, md, err := htmlToMarkdown([]byte(page), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "The first paragraph of the story is long enough to count, with commas, clauses, and more.\n\nThe second paragraph carries on the story so that this block wins the score."
+	if md != want {
+		t.Errorf("markdown = %q", md)
+	}
+}
+
+func TestWebFetch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/docs", http.StatusMovedPermanently)
+		case "/docs":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(docsPage))
+		case "/long.txt":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(strings.Repeat("é", 30000)))
+		case "/image.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("\x89PNG\r\n\x1a\n\x00\x00"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	e := &NativeExecutor{safeguard: &safeguard.Manager{}}
+	call := func(args map[string]interface{}) (string, error) {
+		data, _ := json.Marshal(args)
+		return e.WebFetch(context.Background(), data)
+	}
+
+	out, err := call(map[string]interface{}{"url": srv.URL + "/old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# Widgets guide\nURL: " + srv.URL + "/docs\n", "# Configuring widgets"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Cached") {
+		t.Errorf("first fetch reported as cached:\n%s", out)
+	}
+
+	out, err = call(map[string]interface{}{"url": srv.URL + "/old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits != 2 || !strings.Contains(out, "(Cached ") {
+		t.Errorf("second fetch: %d hits, output:\n%s", hits, out)
+	}
+	if _, err := call(map[string]interface{}{"url": srv.URL + "/old", "refresh": true}); err != nil || hits != 4 {
+		t.Errorf("refresh: %d hits, err %v", hits, err)
+	}
+
+	out, err = call(map[string]interface{}{"url": srv.URL + "/long.txt", "max_chars": 1000, "offset": 29500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(out, "\n\n"+strings.Repeat("é", 500)) {
+		t.Errorf("tail of long text:\n%s", out[:min(len(out), 300)])
+	}
+	out, _ = call(map[string]interface{}{"url": srv.URL + "/long.txt", "max_chars": 1000})
+	if !strings.Contains(out, "showing characters 0-1000 of 30000; call again with offset=1000") {
+		t.Errorf("head of long text:\n%s", out[len(out)-200:])
+	}
+
+	if _, err := call(map[string]interface{}{"url": srv.URL + "/image.png"}); err == nil || !strings.Contains(err.Error(), "unsupported content type image/png") {
+		t.Errorf("image error = %v", err)
+	}
+	if _, err := call(map[string]interface{}{"url": srv.URL + "/missing"}); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("missing page error = %v", err)
+	}
+	if _, err := call(map[string]interface{}{"url": "ftp://example.com/file"}); err == nil {
+		t.Error("ftp URL was accepted")
+	}
+
+	// Denied hosts are refused, cached pages included
+	e.safeguard.Permissions = &safeguard.PermissionConfig{Domains: safeguard.DomainRules{Deny: []string{"*"}}}
+	if _, err := call(map[string]interface{}{"url": srv.URL + "/old"}); err == nil || !strings.Contains(err.Error(), "domain denied") {
+		t.Errorf("denied host error = %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/igoryan-dao/ricochet/internal/safeguard"
+)
+
+// checkZones enforces the trust zones for a tool call: the zone of every
+// file it names, or the workspace zone when it names none
+func (e *NativeExecutor) checkZones(name string, args json.RawMessage) error {
+	targets := zoneTargets(args)
+	if len(targets) == 0 {
+		return e.safeguard.CheckPermission(name)
+	}
+	return e.checkPathZones(name, targets...)
+}
+
+// checkPathZones holds a tool to the zone of each path it is about to touch,
+// for tools whose files are only known once their arguments are worked out
+// (diffs, renames)
+func (e *NativeExecutor) checkPathZones(name string, paths ...string) error {
+	if e.safeguard == nil {
+		return nil
+	}
+	for _, path := range paths {
+		abs, _ := e.resolvePath(path)
+		if err := e.safeguard.CheckPathPermission(name, abs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zoneTargets lists the paths a tool call's arguments name
+func zoneTargets(args json.RawMessage) []string {
+	var payload struct {
+		Path        string `json:"path"`
+		TargetFile  string `json:"TargetFile"`
+		Source      string `json:"source"`
+		Destination string `json:"destination"`
+		Edits       []struct {
+			Path string `json:"path"`
+		} `json:"edits"`
+	}
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return nil
+	}
+	var targets []string
+	for _, p := range []string{payload.Path, payload.TargetFile, payload.Source, payload.Destination} {
+		if p != "" {
+			targets = append(targets, p)
+		}
+	}
+	for _, edit := range payload.Edits {
+		if edit.Path != "" {
+			targets = append(targets, edit.Path)
+		}
+	}
+	return targets
+}
+
+// zoneApproves reports whether the trust zone of path takes an edit without
+// asking, recording the decision when it does. Only tools touching a single
+// file qualify; batches and moves span paths that may lie in other zones.
+func (e *NativeExecutor) zoneApproves(ctx context.Context, tool, path string) bool {
+	switch tool {
+	case "write_file", "replace_file_content", "delete_file", "create_directory":
+	default:
+		return false
+	}
+	if e.safeguard == nil {
+		return false
+	}
+	abs, _ := e.resolvePath(path)
+	if !e.safeguard.EditsWithoutConsent(abs) {
+		return false
+	}
+	e.recordApproval(ctx, tool, path, true, true, fmt.Sprintf("zone %s", e.safeguard.PathZone(abs)), safeguard.ChannelPolicy)
+	return true
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/igoryan-dao/ricochet/internal/agent"
 	"github.com/igoryan-dao/ricochet/internal/config"
 	"github.com/igoryan-dao/ricochet/internal/mcp"
 )
@@ -26,14 +27,28 @@ func (m *Model) handleSlashCommand(input string) (string, tea.Cmd) {
 **Available Commands:**
 - **/help** or **?**: Show this help
 - **/model <name> [provider] [key]**: Switch AI model (Configures settings.json)
+- **/mode [slug]**: List the modes, including project modes from .ricochet/modes, or switch to one
+- **/skills [enable|disable <name>|import <git-url> [name]]**: List skills with their matchers, toggle them or install them from git
 - **/auto <N>**: Engage Auto-Pilot for N steps
 - **/status**: Show current session insights
 - **/init**: Initialize a new project (scan codebase)
-- **/permissions**: Manage security permissions
-- **/checkpoint**: Save current state
+- **/permissions**: List, remove and export "don't ask again" rules
+- **/panic**: Emergency stop: abort turns, kill jobs, disable writes and commands (also Ctrl+Shift+C or Ctrl+\\)
+- **/resume**: Lift the emergency stop
+- **/checkpoint [message]**: Save current state
+- **/checkpoints [N]**: List the last N checkpoints (default 10)
 - **/restore <hash>**: Restore to a checkpoint
-- **/memory**: Show long-term memory stats
+- **/rewind**: Undo the last answer: its file changes and the conversation
+- **/experiment [fork|switch|diff|merge|discard] <name>**: Try approaches in named forks of the workspace
+- **/repo [add <path> [name]|remove <name>]**: Index sibling repos for codebase search
+- **/undo-run [N] [paths...]**: Review and revert the last N agent runs
+- **/trust [trusted|restricted|read-only]**: Show or change workspace trust
+- **/stats [reset]**: Show tool usage, latency and error counts
+- **/memory**: Show long-term memory stats; /memory review to approve learned facts
+- **/expand [n|text]**: Restore context hidden by condensation
+- **/context**: Show where context tokens go
 - **/hooks**: List active hooks
+- **/mcp [list|registry|add|remove]**: Install and manage MCP servers from the registry
 - **/extensions**: Manage MCP extensions (install, uninstall, list)
 - **/ether**: Remote control (Telegram)
 - **/demo**: Run feature demo
@@ -95,20 +110,6 @@ func (m *Model) handleSlashCommand(input string) (string, tea.Cmd) {
 		m.AutoStepsRemaining = n
 		return fmt.Sprintf("🟣 Auto-Pilot Engaged: %d steps allowed.", n), nil
 
-	case "/permissions":
-		// NEW FEATURE: Verify permissions
-		sg := m.Controller.GetSafeguard()
-		if sg == nil || sg.PermissionStore == nil {
-			return "Safeguard not initialized.", nil
-		}
-
-		// rules := sg.PermissionStore.ExportRules() // Pending implementation access
-
-		status := fmt.Sprintf("**Security Status**:\n- Auto-Approval: %v\n", sg.AutoApproval != nil && sg.AutoApproval.Enabled)
-
-		// For now, let's just show a placeholder if we can't reach internal state.
-		return status + "\n(Detailed rule listing coming soon)", nil
-
 	case "/commit":
 		gitMgr := m.Controller.GetGitManager()
 		if !gitMgr.IsRepo() {
@@ -285,6 +286,149 @@ func (m *Model) handleSlashCommand(input string) (string, tea.Cmd) {
 			return "Unknown action. Use list, install, or uninstall.", nil
 		}
 
+	case "/checkpoint":
+		hash, err := m.Controller.SaveCheckpoint(strings.Join(parts[1:], " "))
+		if err != nil {
+			return fmt.Sprintf("Checkpoint failed: %v", err), nil
+		}
+		return fmt.Sprintf("📸 Checkpoint `%.8s` saved. Run `/restore %.8s` to return to it.", hash, hash), nil
+
+	case "/checkpoints":
+		n := 10
+		if len(parts) > 1 {
+			fmt.Sscanf(parts[1], "%d", &n)
+		}
+		commits, err := m.Controller.ListCheckpoints(n)
+		if err != nil {
+			return fmt.Sprintf("Failed to list checkpoints: %v", err), nil
+		}
+		if len(commits) == 0 {
+			return "No checkpoints yet.", nil
+		}
+		var sb strings.Builder
+		sb.WriteString("**Checkpoints** (newest first):\n")
+		for _, c := range commits {
+			sb.WriteString(fmt.Sprintf("- `%.8s` %s — %s\n", c.Hash, c.Timestamp.Format("Jan 2 15:04"), c.Message))
+		}
+		return sb.String(), nil
+
+	case "/restore":
+		if len(parts) < 2 {
+			return "Usage: /restore <hash> (see /checkpoints)", nil
+		}
+		if err := m.Controller.RestoreCheckpoint(parts[1]); err != nil {
+			return fmt.Sprintf("Restore failed: %v", err), nil
+		}
+		return fmt.Sprintf("⏪ Workspace restored to `%.8s`. The previous state was checkpointed first.", parts[1]), nil
+
+	case "/rewind":
+		id, ok := m.Controller.LastPromptID(m.SessionID)
+		if !ok {
+			return "Nothing to rewind.", nil
+		}
+		res, err := m.Controller.RewindToMessage(m.SessionID, id)
+		if err != nil {
+			return fmt.Sprintf("Rewind failed: %v", err), nil
+		}
+		m.Textarea.SetValue(res.Prompt)
+		reply := fmt.Sprintf("⏪ Undid the last answer (%d messages).", res.Removed)
+		if res.Checkpoint != "" {
+			reply += fmt.Sprintf(" Workspace restored to `%.8s`; run `/restore %.8s` to take it back.", res.Checkpoint, res.Safety)
+		}
+		return reply + " Your prompt is back in the input.", nil
+
+	case "/experiment", "/experiments":
+		if len(parts) < 2 || parts[1] == "list" {
+			state, err := m.Controller.ListExperiments()
+			if err != nil {
+				return fmt.Sprintf("Failed to list experiments: %v", err), nil
+			}
+			if len(state.Experiments) == 0 {
+				return "No experiments. Start one with `/experiment fork <name>`.", nil
+			}
+			var sb strings.Builder
+			sb.WriteString(fmt.Sprintf("**Experiments** (on `%s`):\n", state.Current))
+			for _, e := range state.Experiments {
+				sb.WriteString(fmt.Sprintf("- `%s` %d checkpoints, %s — %s\n", e.Name, e.Ahead, e.Timestamp.Format("Jan 2 15:04"), e.Message))
+			}
+			return sb.String(), nil
+		}
+		if len(parts) < 3 {
+			return "Usage: /experiment [list|fork|switch|diff|merge|discard] <name> (`/experiment switch main` returns to the main line)", nil
+		}
+		name := parts[2]
+		switch parts[1] {
+		case "fork":
+			if _, err := m.Controller.ForkExperiment(name); err != nil {
+				return fmt.Sprintf("Fork failed: %v", err), nil
+			}
+			return fmt.Sprintf("🧪 Started experiment `%s`. Changes go to it until `/experiment switch main`.", name), nil
+		case "switch":
+			if err := m.Controller.SwitchExperiment(name); err != nil {
+				return fmt.Sprintf("Switch failed: %v", err), nil
+			}
+			return fmt.Sprintf("🧪 Workspace switched to `%s`.", name), nil
+		case "diff":
+			diff, err := m.Controller.CompareExperiment(name)
+			if err != nil {
+				return fmt.Sprintf("Diff failed: %v", err), nil
+			}
+			return "```\n" + diff.Summary() + "```", nil
+		case "merge":
+			res, err := m.Controller.MergeExperiment(name)
+			if err != nil {
+				return fmt.Sprintf("Merge failed: %v", err), nil
+			}
+			if len(res.Conflicts) > 0 {
+				return fmt.Sprintf("⚠️ Merged `%s` with conflicts in %s; resolve the conflict markers.", name, strings.Join(res.Conflicts, ", ")), nil
+			}
+			return fmt.Sprintf("✅ Merged `%s` into the main line.", name), nil
+		case "discard":
+			if err := m.Controller.DiscardExperiment(name); err != nil {
+				return fmt.Sprintf("Discard failed: %v", err), nil
+			}
+			return fmt.Sprintf("🗑️ Discarded experiment `%s`.", name), nil
+		default:
+			return "Unknown action. Use list, fork, switch, diff, merge or discard.", nil
+		}
+
+	case "/repo", "/repos":
+		roots := m.Controller.GetIndexRoots()
+		if len(parts) >= 3 && parts[1] == "add" {
+			name := ""
+			if len(parts) > 3 {
+				name = parts[3]
+			}
+			root, err := roots.Add(name, parts[2])
+			if err != nil {
+				return fmt.Sprintf("Failed to add repo: %v", err), nil
+			}
+			return fmt.Sprintf("📚 Indexing `%s` (%s) in the background. codebase_search now spans it.", root.Name, root.Path), nil
+		}
+		if len(parts) >= 3 && parts[1] == "remove" {
+			if err := roots.Remove(parts[2]); err != nil {
+				return fmt.Sprintf("Failed to remove repo: %v", err), nil
+			}
+			return fmt.Sprintf("Stopped indexing `%s`.", parts[2]), nil
+		}
+		if len(parts) > 1 && parts[1] != "list" {
+			return "Usage: /repo [list|add <path> [name]|remove <name>]", nil
+		}
+		var sb strings.Builder
+		sb.WriteString("**Indexed repos**:\n")
+		for _, root := range roots.List() {
+			state := fmt.Sprintf("%d chunks", root.Status.Documents)
+			if root.Status.Indexing {
+				state = "indexing…"
+			}
+			label := ""
+			if root.Primary {
+				label = " (workspace)"
+			}
+			sb.WriteString(fmt.Sprintf("- `%s`%s %s — %s\n", root.Name, label, root.Path, state))
+		}
+		return sb.String(), nil
+
 	case "/status":
 		// ... (Implementation from existing tui.go)
 		return fmt.Sprintf("**Session ID**: %s\n**Model**: %s\n**Tokens Used**: ???", m.SessionID, m.ModelName), nil
@@ -301,6 +445,21 @@ func (m *Model) handleSlashCommand(input string) (string, tea.Cmd) {
 		m.Viewport.SetContent(welcome)
 		return "Cleared history.", nil
 
+	case "/undo-run", "/trust", "/stats", "/mcp", "/memory", "/expand", "/context", "/permissions", "/panic", "/resume", "/mode", "/skills":
+		return "", m.runAsync(input, func() (string, error) {
+			var result string
+			err := m.Controller.Chat(context.Background(), agent.ChatRequestInput{
+				SessionID: m.SessionID,
+				Content:   input,
+				Via:       "cli",
+			}, func(update interface{}) {
+				if cu, ok := update.(agent.ChatUpdate); ok {
+					result = cu.Message.Content
+				}
+			})
+			return result, err
+		})
+
 	case "/exit":
 		return "Goodbye!", tea.Quit
 
@@ -0,0 +1,52 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/igoryan-dao/ricochet/internal/agent"
+)
+
+// panicCSISequences are Ctrl+Shift+C in terminals that report it apart from
+// Ctrl+C: the kitty keyboard protocol and xterm's modifyOtherKeys. Bubble Tea
+// v1 passes them on as unknown CSI sequences, which only print themselves.
+var panicCSISequences = map[string]bool{
+	csiString("99;6u"):    true,
+	csiString("67;6u"):    true,
+	csiString("27;6;99~"): true,
+	csiString("27;6;67~"): true,
+}
+
+func csiString(params string) string {
+	return fmt.Sprintf("?CSI%+v?", []byte(params))
+}
+
+// isPanicKey reports whether msg is the emergency stop key: Ctrl+Shift+C, or
+// Ctrl+\ in terminals that send Ctrl+Shift+C as Ctrl+C or keep it for copy
+func isPanicKey(msg tea.Msg) bool {
+	if k, ok := msg.(tea.KeyMsg); ok {
+		return k.String() == "ctrl+\\"
+	}
+	if s, ok := msg.(fmt.Stringer); ok {
+		return panicCSISequences[s.String()]
+	}
+	return false
+}
+
+// emergencyStop pulls the kill switch and reports what it halted
+func (m *Model) emergencyStop() tea.Cmd {
+	report := m.Controller.EmergencyStop("tui")
+	wasLoading := m.IsLoading
+	m.IsLoading = false
+	m.CurrentAction = ""
+	m.finishActiveBlocks()
+
+	textBlock := m.getOrCreateTextBlock()
+	textBlock.Content += "\n\n" + agent.FormatEmergencyReport(report)
+	m.UpdateViewport()
+
+	if wasLoading {
+		return m.waitForMsg()
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package tui
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyProbe records whether the program read a panic key
+type keyProbe struct{ got *bool }
+
+func (p keyProbe) Init() tea.Cmd { return nil }
+
+func (p keyProbe) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if isPanicKey(msg) {
+		*p.got = true
+		return p, tea.Quit
+	}
+	return p, nil
+}
+
+func (p keyProbe) View() string { return "" }
+
+func TestIsPanicKey(t *testing.T) {
+	for name, input := range map[string]string{
+		"kitty":           "\x1b[99;6u",
+		"modifyOtherKeys": "\x1b[27;6;67~",
+		"ctrl+backslash":  "\x1c",
+	} {
+		got := false
+		p := tea.NewProgram(keyProbe{&got}, tea.WithInput(strings.NewReader(input)), tea.WithOutput(io.Discard), tea.WithoutRenderer(), tea.WithoutSignalHandler())
+		go func() {
+			time.Sleep(2 * time.Second)
+			p.Quit()
+		}()
+		if _, err := p.Run(); err != nil {
+			t.Fatal(err)
+		}
+		if !got {
+			t.Errorf("%s: Ctrl+Shift+C not recognised", name)
+		}
+	}
+
+	if isPanicKey(tea.KeyMsg{Type: tea.KeyCtrlC}) {
+		t.Error("plain Ctrl+C must keep quitting, not stop everything")
+	}
+}
@@ -32,6 +32,9 @@ func (h *TuiHost) AskUserChoice(question string, choices []string) (int, error)
 	return <-respChan, nil
 }
 
+// Channel implements host.Channeled
+func (h *TuiHost) Channel() string { return "tui" }
+
 func (h *TuiHost) ShowMessage(level string, text string) {
 	h.msgChan <- LogMsg{Level: level, Text: text}
 }
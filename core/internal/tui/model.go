@@ -14,6 +14,7 @@ import (
 	"github.com/igoryan-dao/ricochet/internal/agent"
 	"github.com/igoryan-dao/ricochet/internal/config"
 	"github.com/igoryan-dao/ricochet/internal/livemode"
+	"github.com/igoryan-dao/ricochet/internal/notify"
 	"github.com/igoryan-dao/ricochet/internal/protocol"
 	"github.com/igoryan-dao/ricochet/internal/tui/style"
 )
@@ -176,7 +177,8 @@ type Model struct {
 	IsEtherActive bool
 	IsToggling    bool
 	SettingsStore *config.Store
-	CurrentAction string // Granular status like "Searching...", "Writing..."
+	Notifier      *notify.Desktop // Desktop alerts while the terminal is unfocused
+	CurrentAction string          // Granular status like "Searching...", "Writing..."
 
 	// Whimsical Flavor
 	CurrentStatusStr string // "Meandering..."
@@ -220,8 +222,8 @@ func NewModel(cwd, modelName string, msgChan chan tea.Msg, ctrl *agent.Controlle
 
 	// Initial commands list
 	cmds := []string{
-		"/help", "/model", "/status", "/checkpoint", "/restore", "/init", "/shell",
-		"/memory", "/hooks", "/clear", "/mode", "/exit", "/ether", "/permissions",
+		"/help", "/model", "/status", "/checkpoint", "/checkpoints", "/restore", "/rewind", "/experiment", "/repo", "/undo-run", "/trust", "/stats", "/mcp", "/init", "/shell",
+		"/memory", "/expand", "/context", "/hooks", "/clear", "/mode", "/skills", "/exit", "/ether", "/permissions", "/panic", "/resume",
 	}
 
 	// Generate Welcome Content (Plain Text to prevent ALL artifacts)
@@ -22,6 +22,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		spCmd tea.Cmd
 	)
 
+	// Terminal focus reports gate desktop notifications
+	switch msg.(type) {
+	case tea.FocusMsg:
+		if m.Notifier != nil {
+			m.Notifier.SetFocused(true)
+		}
+		return m, nil
+	case tea.BlurMsg:
+		if m.Notifier != nil {
+			m.Notifier.SetFocused(false)
+		}
+		return m, nil
+	}
+
+	// EMERGENCY STOP: works in every view, mid-turn included
+	if isPanicKey(msg) && m.Controller != nil {
+		return m, m.emergencyStop()
+	}
+
 	// GLOBAL TOGGLES
 	if kmsg, ok := msg.(tea.KeyMsg); ok {
 		if kmsg.String() == "ctrl+p" {
@@ -267,7 +267,7 @@ func RenderDashboard(m Model) string {
 func detectToolType(name string) string {
 	// Block Tools: Produce heavy output or side effects worth showing in a box
 	switch name {
-	case "view_file", "write_to_file", "replace_file_content", "run_command", "view_code_item":
+	case "view_file", "write_to_file", "replace_file_content", "apply_diff", "run_command", "view_code_item":
 		return "block"
 	}
 	// Inline Tools: Quick status checks or lightweight ops
@@ -474,9 +474,20 @@ func RenderStatusBar(m Model) string {
 
 	right := modeStyle.Render(modeIndicator + " ")
 
-	spacer := strings.Repeat(" ", max(0, w-lipgloss.Width(left)-lipgloss.Width(right)-lipgloss.Width(etherProps)-lipgloss.Width(autoBadge)))
+	// Agent mode (persona), with the zone a project mode sets
+	agentMode := ""
+	if m.Controller != nil {
+		active := m.Controller.ActiveMode()
+		label := active.Name
+		if active.Zone != "" {
+			label += " · " + active.Zone
+		}
+		agentMode = style.SystemStyle.Render(label + " ")
+	}
+
+	spacer := strings.Repeat(" ", max(0, w-lipgloss.Width(left)-lipgloss.Width(right)-lipgloss.Width(etherProps)-lipgloss.Width(autoBadge)-lipgloss.Width(agentMode)))
 
-	return lipgloss.JoinHorizontal(lipgloss.Bottom, left, spacer, autoBadge, etherProps, right)
+	return lipgloss.JoinHorizontal(lipgloss.Bottom, left, spacer, autoBadge, etherProps, agentMode, right)
 }
 
 func (m Model) renderSuggestions() string {
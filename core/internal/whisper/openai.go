@@ -9,6 +9,9 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
+
+	"github.com/igoryan-dao/ricochet/internal/httpclient"
 )
 
 // OpenAICloudTranscriber uses OpenAI API for transcription
@@ -52,8 +55,7 @@ func (t *OpenAICloudTranscriber) Transcribe(path string) (string, error) {
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("Authorization", "Bearer "+t.apiKey)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpclient.ForProvider("whisper", 5*time.Minute).Do(req)
 	if err != nil {
 		return "", err
 	}
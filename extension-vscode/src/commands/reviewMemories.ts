@@ -0,0 +1,41 @@
+import * as vscode from 'vscode';
+import { CoreProcess } from '../core-process';
+
+interface PendingMemory {
+    key: string;
+    value: string;
+    hits?: number;
+}
+
+// Lets the user keep or drop the facts the agent learned from past sessions
+// before they reach future system prompts
+export async function reviewMemories(core: CoreProcess) {
+    const result = await core.send('memory_pending', {}) as { pending?: PendingMemory[] };
+    const pending = result?.pending || [];
+    if (pending.length === 0) {
+        vscode.window.showInformationMessage('No learned facts wait for review.');
+        return;
+    }
+
+    const items = pending.map(memory => ({
+        label: memory.value,
+        description: memory.hits ? `seen in ${memory.hits + 1} sessions` : undefined,
+        picked: true,
+        key: memory.key,
+    }));
+    const kept = await vscode.window.showQuickPick(items, {
+        canPickMany: true,
+        title: 'Learned facts',
+        placeHolder: 'Select the facts to keep; the others are dropped',
+    });
+    if (!kept) {
+        return; // Cancelled: review later
+    }
+
+    const keep = new Set(kept.map(item => item.key));
+    await core.send('memory_review', {
+        approve: pending.filter(m => keep.has(m.key)).map(m => m.key),
+        reject: pending.filter(m => !keep.has(m.key)).map(m => m.key),
+    });
+    vscode.window.showInformationMessage(`Kept ${keep.size} of ${pending.length} learned facts.`);
+}
@@ -16,6 +16,21 @@ export async function activate(context: vscode.ExtensionContext) {
     coreProcess = new CoreProcess(workspacePath, context.extensionPath);
     await coreProcess.start();
 
+    // Index the other folders of a multi-root workspace beside the first
+    registerWorkspaceRoots(coreProcess, vscode.workspace.workspaceFolders?.slice(1) || []);
+    context.subscriptions.push(
+        vscode.workspace.onDidChangeWorkspaceFolders(event => {
+            if (!coreProcess) {
+                return;
+            }
+            registerWorkspaceRoots(coreProcess, event.added);
+            for (const folder of event.removed) {
+                coreProcess.send('index_remove_root', { name: folder.name })
+                    .catch(e => console.error(`Failed to unregister ${folder.name}:`, e));
+            }
+        })
+    );
+
     // Initialize Language Service (LSP Bridge)
     new LanguageService(coreProcess);
 
@@ -61,6 +76,14 @@ export async function activate(context: vscode.ExtensionContext) {
         })
     );
 
+    context.subscriptions.push(
+        vscode.commands.registerCommand('ricochet.reviewMemories', async () => {
+            if (coreProcess) {
+                await reviewMemories(coreProcess);
+            }
+        })
+    );
+
     // Register generic install command
     context.subscriptions.push(
         vscode.commands.registerCommand('ricochet.installCli', async () => {
@@ -75,10 +98,18 @@ export async function activate(context: vscode.ExtensionContext) {
 }
 
 import { installCli } from './commands/installCli';
+import { reviewMemories } from './commands/reviewMemories';
 import * as fs from 'fs';
 import * as path from 'path';
 import * as os from 'os';
 
+function registerWorkspaceRoots(core: CoreProcess, folders: readonly vscode.WorkspaceFolder[]) {
+    for (const folder of folders) {
+        core.send('index_add_root', { name: folder.name, path: folder.uri.fsPath })
+            .catch(e => console.error(`Failed to index ${folder.name}:`, e));
+    }
+}
+
 function checkCliInstallation(context: vscode.ExtensionContext) {
     const homeDir = os.homedir();
     const targetPath = path.join(homeDir, '.local', 'bin', 'ricochet');
@@ -76,6 +76,10 @@ export class ChatService {
                 await this.core.send(message.type, message.payload || {});
                 break;
 
+            case 'rewind_to_message':
+                await this.rewindToMessage(message.payload.sessionId || this.activeSessionId, message.payload.messageId);
+                break;
+
             case 'get_state':
                 const state = await this.core.send('get_state', { session_id: message.payload.sessionId });
                 this.postMessage({ type: 'state', payload: state });
@@ -96,6 +100,32 @@ export class ChatService {
         }
     }
 
+    /**
+     * Undoes an answer in core (workspace and history), then stores the
+     * truncated history so reloading the session doesn't bring it back
+     */
+    private async rewindToMessage(sessionId: string | null, messageId: string): Promise<void> {
+        if (!sessionId) return;
+        const answer = await vscode.window.showWarningMessage(
+            "Undo this answer? Its file changes are reverted and the conversation goes back to before your prompt.",
+            "Undo", "Cancel");
+        if (answer !== 'Undo') return;
+
+        try {
+            const result = await this.core.send('rewind_to_message', { session_id: sessionId, message_id: messageId }) as any;
+            const state = await this.core.send('get_state', { session_id: sessionId }) as any;
+            const messages = (state?.messages || []).map((m: any) => ({ role: m.role, content: m.content, timestamp: m.timestamp }));
+
+            const existing = await this.sessionService.loadSession(sessionId);
+            const workspaceDir = vscode.workspace.workspaceFolders?.[0].uri.fsPath || '';
+            await this.sessionService.saveSession(sessionId, { messages, todos: existing?.todos || [] }, workspaceDir);
+
+            this.postMessage({ type: 'session_rewound', payload: { ...result, messages: state?.messages || [] } });
+        } catch (e: any) {
+            vscode.window.showErrorMessage(`Failed to undo answer: ${e.message}`);
+        }
+    }
+
     public async onChatUpdate(payload: any): Promise<void> {
         const isFinalMessage = payload.message?.isStreaming === false || payload.done === true;
 
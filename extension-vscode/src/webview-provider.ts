@@ -2,14 +2,12 @@ import * as vscode from 'vscode';
 import { CoreProcess } from './core-process';
 import { McpServerManager } from './services/mcp/McpServerManager';
 import { McpHub } from './services/mcp/McpHub';
-import { ShadowCheckpointService } from './services/checkpoints/ShadowCheckpointService';
 import { SessionService } from './services/session/SessionService';
 import { ChatService } from './services/chat/ChatService';
 import { McpService } from './services/mcp/McpService';
 import { AgentService } from './services/agent/AgentService';
 import * as path from 'path';
 import * as fs from 'fs';
-import * as nodeCrypto from 'crypto';
 
 
 /**
@@ -22,7 +20,6 @@ export class WebviewProvider implements vscode.WebviewViewProvider {
     private view?: vscode.WebviewView;
     private isLiveModeEnabled = false;
 
-    private checkpointService?: ShadowCheckpointService;
     private sessionService: SessionService;
     private chatService?: ChatService;
     private mcpService?: McpService;
@@ -184,36 +181,44 @@ export class WebviewProvider implements vscode.WebviewViewProvider {
                     }
                     break;
 
+                // Checkpoints live in core, shared with the TUI and the agent's auto-checkpoints
                 case 'checkpoint_init':
                     await this.initCheckpoints();
                     break;
 
+                case 'checkpoint_list':
+                    await this.listCheckpoints();
+                    break;
+
                 case 'save_checkpoint':
-                    if (this.checkpointService) {
-                        try {
-                            const result = await this.checkpointService.saveCheckpoint(message.payload.message || 'Manual Checkpoint');
-                            this.view?.webview.postMessage({ type: 'checkpoint_saved', payload: { commit: result?.commit } });
-                        } catch (e: any) {
-                            vscode.window.showErrorMessage(`Failed to save checkpoint: ${e.message}`);
-                        }
+                case 'checkpoint_save':
+                    try {
+                        const saved = await this.core.send('checkpoint_save', { message: message.payload?.message || 'Manual checkpoint' });
+                        this.postMessage({ type: 'checkpoint_saved', payload: saved });
+                    } catch (e: any) {
+                        this.postMessage({ type: 'checkpoint_error', payload: { error: e.message } });
+                        vscode.window.showErrorMessage(`Failed to save checkpoint: ${e.message}`);
                     }
                     break;
 
                 case 'restore_checkpoint':
-                    if (this.checkpointService) {
-                        // Confirmation dialog
-                        const ans = await vscode.window.showWarningMessage("Are you sure you want to restore? Current changes will be lost.", "Yes", "No");
-                        if (ans === 'Yes') {
-                            try {
-                                await this.checkpointService.restoreCheckpoint(message.payload.hash);
-                                vscode.window.showInformationMessage("Checkpoint restored.");
-                                // Reload window or notify core?
-                            } catch (e: any) {
-                                vscode.window.showErrorMessage(`Failed to restore checkpoint: ${e.message}`);
-                            }
-                        }
+                case 'checkpoint_restore': {
+                    const ans = await vscode.window.showWarningMessage("Restore the workspace to this checkpoint? Current changes are checkpointed first.", "Yes", "No");
+                    if (ans !== 'Yes') {
+                        this.postMessage({ type: 'checkpoint_error', payload: { error: 'cancelled' } });
+                        break;
+                    }
+                    try {
+                        const restored = await this.core.send('checkpoint_restore', { hash: message.payload.hash });
+                        this.postMessage({ type: 'checkpoint_restored', payload: restored });
+                        vscode.window.showInformationMessage("Checkpoint restored.");
+                        await this.listCheckpoints();
+                    } catch (e: any) {
+                        this.postMessage({ type: 'checkpoint_error', payload: { error: e.message } });
+                        vscode.window.showErrorMessage(`Failed to restore checkpoint: ${e.message}`);
                     }
                     break;
+                }
 
                 case 'search_files':
                     // Handle file search request from webview
@@ -441,45 +446,17 @@ export class WebviewProvider implements vscode.WebviewViewProvider {
     }
 
     private async initCheckpoints() {
-        if (this.checkpointService) {
-            this.view?.webview.postMessage({
-                type: 'checkpoint_initialized',
-                payload: { baseHash: this.checkpointService.baseHash || '' }
-            });
-            return;
-        }
+        // Core creates the shadow repository on startup; nothing to set up here
+        this.postMessage({ type: 'checkpoint_initialized', payload: { baseHash: '' } });
+    }
 
-        const workspaceFolders = vscode.workspace.workspaceFolders;
-        if (!workspaceFolders || workspaceFolders.length === 0) {
-            return;
+    private async listCheckpoints() {
+        try {
+            const result = await this.core.send('checkpoint_list', {}) as { checkpoints: unknown[] };
+            this.postMessage({ type: 'checkpoint_list', payload: result });
+        } catch (e: any) {
+            console.error('Failed to list checkpoints:', e);
         }
-        const workspaceDir = workspaceFolders[0].uri.fsPath;
-        const globalStorageDir = this.context.globalStorageUri.fsPath;
-        const taskId = "default-session"; // TODO: mult-session support
-
-        // Hash workspace to avoid collision if multiple Workspaces use same taskId (though taskId should be unique)
-        // Roo uses tasks/{taskId}/checkpoints. We can use simplified path for now.
-        const checkpointsDir = path.join(globalStorageDir, "checkpoints", nodeCrypto.createHash('md5').update(workspaceDir).digest('hex'));
-
-        this.checkpointService = new ShadowCheckpointService(
-            taskId,
-            checkpointsDir,
-            workspaceDir,
-            (msg) => console.log(`[Checkpoint] ${msg}`)
-        );
-
-        await this.checkpointService.initShadowGit();
-
-        // Send confirmation to webview
-        this.view?.webview.postMessage({
-            type: 'checkpoint_initialized',
-            payload: { baseHash: this.checkpointService.baseHash || '' }
-        });
-
-        // Listen for internal events
-        this.checkpointService.on('checkpoint', (data) => {
-            this.view?.webview.postMessage({ type: 'checkpoint_update', payload: data });
-        });
     }
 
     // Public methods for extension.ts
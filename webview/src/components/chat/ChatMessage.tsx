@@ -1,5 +1,5 @@
 import { useState, useMemo, useEffect, useRef } from 'react';
-import { ChevronDown, ChevronRight, ChevronUp, FileText, Edit3, Terminal, RotateCcw } from 'lucide-react';
+import { ChevronDown, ChevronRight, ChevronUp, FileText, Edit3, Terminal, RotateCcw, Undo2 } from 'lucide-react';
 import { ChatMessage as ChatMessageType, ToolCall, ActivityItem, TaskProgress } from '@hooks/useChat';
 import { useVSCodeApi } from '@hooks/useVSCodeApi';
 import { DiffView, parseDiff } from '../diff/DiffView';
@@ -14,6 +14,7 @@ interface ChatMessageProps {
     taskProgress?: TaskProgress | null;
     onExecuteCommand?: (command: string) => void;
     onRestore?: (hash: string) => void;
+    onUndo?: (messageId: string) => void;
 }
 
 
@@ -22,8 +23,10 @@ interface ChatMessageProps {
  * Chat message message component with markdown rendering.
  * Matches competitor styling with code blocks and reasoning sections.
  */
-export function ChatMessage({ message, taskProgress, onExecuteCommand, onRestore }: ChatMessageProps) {
+export function ChatMessage({ message, taskProgress, onExecuteCommand, onRestore, onUndo }: ChatMessageProps) {
     const isUser = message.role === 'user';
+    // Core history IDs are msg-<index>; locally added messages can't be rewound to
+    const canUndo = onUndo && !message.isStreaming && /^msg-\d+$/.test(message.id);
 
     return (
         <div className={`py-4 px-2 transition-colors ${!isUser ? 'bg-vscode-sideBar-background/30' : ''}`}>
@@ -40,6 +43,16 @@ export function ChatMessage({ message, taskProgress, onExecuteCommand, onRestore
                                 Restore
                             </button>
                         )}
+                        {canUndo && (
+                            <button
+                                onClick={() => onUndo!(message.id)}
+                                className="flex items-center gap-1.5 px-2 py-1 rounded-md bg-white/5 hover:bg-white/10 border border-white/5 text-[9px] font-medium text-white/50 hover:text-white/80 uppercase tracking-wider transition-all backdrop-blur-sm"
+                                title="Undo this answer: revert its file changes and go back to before your prompt"
+                            >
+                                <Undo2 className="w-3 h-3" />
+                                Undo
+                            </button>
+                        )}
                     </div>
                     <AssistantContent message={message} taskProgress={taskProgress} onExecuteCommand={onExecuteCommand} />
                 </div>
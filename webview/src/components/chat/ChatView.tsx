@@ -29,7 +29,7 @@ interface ChatViewProps {
  */
 export function ChatView({ onOpenSettings }: ChatViewProps) {
     const { currentSessionId } = useSessions();
-    const { messages, todos, isLoading, inputValue, setInputValue, sendMessage, cancelGeneration, executeCommand, restoreCheckpoint, taskProgress } = useChat(currentSessionId || 'default');
+    const { messages, todos, isLoading, inputValue, setInputValue, sendMessage, cancelGeneration, executeCommand, restoreCheckpoint, rewindToMessage, taskProgress } = useChat(currentSessionId || 'default');
     const { status: liveStatus, toggleLiveMode } = useLiveMode();
     const scrollRef = useRef<HTMLDivElement>(null);
     // Auto-respond to permission requests to prevent Promise deadlock
@@ -124,6 +124,7 @@ export function ChatView({ onOpenSettings }: ChatViewProps) {
                                         taskProgress={shouldShowProgress ? taskProgress : undefined}
                                         onExecuteCommand={executeCommand}
                                         onRestore={restoreCheckpoint}
+                                        onUndo={rewindToMessage}
                                     />
                                 );
                             })}
@@ -67,14 +67,22 @@ export function CheckpointPanel({ taskId = 'default', onRestore }: CheckpointPan
                     onRestore?.((message.payload as { hash: string }).hash);
                     break;
 
-                case 'checkpoint_list':
-                    const list = message.payload as { checkpoints: string[]; baseHash: string };
-                    setCheckpoints(list.checkpoints.map((hash, i) => ({
-                        hash,
-                        message: `Checkpoint ${i + 1}`,
-                        timestamp: Date.now() - (list.checkpoints.length - i) * 60000
-                    })));
-                    setBaseHash(list.baseHash);
+                case 'checkpoint_list': {
+                    // Core lists newest first; the oldest kept checkpoint is the base
+                    const list = message.payload as { checkpoints: { hash: string; message: string; timestamp: string }[] };
+                    const oldestFirst = [...list.checkpoints].reverse().map(cp => ({
+                        hash: cp.hash,
+                        message: cp.message || 'Checkpoint',
+                        timestamp: Date.parse(cp.timestamp)
+                    }));
+                    setBaseHash(oldestFirst[0]?.hash || '');
+                    setCheckpoints(oldestFirst.slice(1));
+                    break;
+                }
+
+                case 'checkpoint_error':
+                    setIsLoading(false);
+                    setIsSaving(false);
                     break;
             }
         });
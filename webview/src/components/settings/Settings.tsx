@@ -36,6 +36,8 @@ interface ContextSettings {
     show_context_indicator: boolean;
     enable_checkpoints: boolean;
     checkpoint_on_writes: boolean;
+    // Codebase index backend; set in settings files, kept as-is on save
+    vector_store?: { backend?: string; url?: string; collection?: string; api_key?: string };
 }
 
 type TabId = typeof TABS[number]['id'];
@@ -55,6 +55,7 @@ export interface ContextStatus {
     was_condensed?: boolean;
     was_truncated?: boolean;
     cumulative_cost?: number;
+    pinned_tokens?: number;
 }
 
 export interface TaskProgress {
@@ -143,6 +144,15 @@ export function useChat(sessionId: string = 'default') {
                 case 'chat_cleared':
                     setMessages([]);
                     break;
+                case 'session_rewound': {
+                    // The answer is undone: show the truncated history and give the prompt back
+                    const rewound = message.payload as { sessionId: string; messages: ChatMessage[]; prompt: string };
+                    if (rewound.sessionId && rewound.sessionId !== sessionId) return;
+                    setMessages(rewound.messages);
+                    setInputValue(rewound.prompt);
+                    setIsLoading(false);
+                    break;
+                }
                 case 'state':
                     // ... existing
                     const state = message.payload as { messages?: ChatMessage[]; mode?: string; todos?: Todo[]; session_id?: string };
@@ -269,6 +279,14 @@ export function useChat(sessionId: string = 'default') {
         });
     }, [postMessage]);
 
+    // Undoes an assistant answer: its file changes and the conversation from its prompt on
+    const rewindToMessage = useCallback((messageId: string) => {
+        postMessage({
+            type: 'rewind_to_message',
+            payload: { sessionId, messageId }
+        });
+    }, [postMessage, sessionId]);
+
     const cancelGeneration = useCallback(() => {
         postMessage({ type: 'cancel_generation' });
         setIsLoading(false);
@@ -290,6 +308,7 @@ export function useChat(sessionId: string = 'default') {
         executeCommand,
         saveCheckpoint,
         restoreCheckpoint,
+        rewindToMessage,
         cancelGeneration
     };
 }